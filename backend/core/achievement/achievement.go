@@ -0,0 +1,182 @@
+package achievement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"athlete-forge/dailylog"
+	"athlete-forge/events"
+	"athlete-forge/profile"
+	"athlete-forge/store"
+	"athlete-forge/summary"
+	"athlete-forge/workout"
+)
+
+const awardSKPrefix = "ACHIEVEMENT#"
+
+// Achievement IDs
+const (
+	FirstWorkout    = "first-workout"
+	HundredSessions = "100-sessions"
+	TonneSession    = "1000kg-session"
+	YearStreak      = "1-year-streak"
+)
+
+// streakWeeks is how many consecutive training weeks make a year
+const streakWeeks = 52
+
+// History is what rules look back over: the user's workouts, including the one
+// an event is about, and the unit their weights are logged in
+type History struct {
+	Workouts []workout.Workout
+	Unit     string
+}
+
+// Rule earns an achievement when an event of type On meets its condition
+type Rule struct {
+	ID          string
+	Name        string
+	Description string
+	On          string
+	met         func(e events.Event, h History) bool
+}
+
+// Rules are every achievement that can be earned
+var Rules = []Rule{
+	{
+		ID: FirstWorkout, Name: "First Workout", Description: "Complete your first workout",
+		On:  events.TypeWorkoutCompleted,
+		met: func(e events.Event, h History) bool { return sessions(h.Workouts) >= 1 },
+	},
+	{
+		ID: HundredSessions, Name: "Century", Description: "Complete 100 workouts",
+		On:  events.TypeWorkoutCompleted,
+		met: func(e events.Event, h History) bool { return sessions(h.Workouts) >= 100 },
+	},
+	{
+		ID: TonneSession, Name: "Tonne Session", Description: "Lift 1000kg in total in a single workout",
+		On: events.TypeWorkoutCompleted,
+		met: func(e events.Event, h History) bool {
+			data, ok := e.Data.(events.WorkoutCompletedData)
+			return ok && profile.Kilograms(data.Volume, h.Unit) >= 1000
+		},
+	},
+	{
+		ID: YearStreak, Name: "Year Streak", Description: "Train every week for a year",
+		On:  events.TypeWorkoutCompleted,
+		met: func(e events.Event, h History) bool { return weekStreak(h.Workouts, e.OccurredAt) >= streakWeeks },
+	},
+}
+
+// Award is an achievement a user has earned. EventID is the domain event that
+// earned it, and AwardedAt is when that event occurred
+type Award struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"userId"`
+	EventID   string    `json:"eventId"`
+	AwardedAt time.Time `json:"awardedAt"`
+}
+
+// Evaluate returns the awards the published events earn, given the user's
+// history and the achievements they already hold. Each achievement is awarded
+// at most once, for the first event that meets its rule
+func Evaluate(published []events.Event, h History, held map[string]bool) []Award {
+	var awards []Award
+	for _, rule := range Rules {
+		if held[rule.ID] {
+			continue
+		}
+		for _, e := range published {
+			if e.Type != rule.On || !rule.met(e, h) {
+				continue
+			}
+			awards = append(awards, Award{ID: rule.ID, UserID: e.UserID, EventID: e.ID, AwardedAt: e.OccurredAt})
+			break
+		}
+	}
+	return awards
+}
+
+// Find returns the rule for id
+func Find(id string) (Rule, bool) {
+	for _, rule := range Rules {
+		if rule.ID == id {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+func sessions(workouts []workout.Workout) int {
+	count := 0
+	for _, w := range workouts {
+		if w.Status == workout.StatusCompleted {
+			count++
+		}
+	}
+	return count
+}
+
+// weekStreak counts consecutive weeks with a completed workout, ending with
+// at's week
+func weekStreak(workouts []workout.Workout, at time.Time) int {
+	trained := map[string]bool{}
+	for _, w := range workouts {
+		if w.Status == workout.StatusCompleted {
+			trained[summary.WeekStart(w.At()).Format(dailylog.DateLayout)] = true
+		}
+	}
+
+	count := 0
+	for week := summary.WeekStart(at); trained[week.Format(dailylog.DateLayout)]; week = week.AddDate(0, 0, -7) {
+		count++
+	}
+	return count
+}
+
+// Repository loads and saves users' awards
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// List returns userID's awards
+func (r *Repository) List(ctx context.Context, userID string) ([]Award, error) {
+	items, err := r.store.Query(ctx, store.UserPK(userID), awardSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list achievements: %w", err)
+	}
+
+	awards := make([]Award, 0, len(items))
+	for _, item := range items {
+		var a Award
+		if err := item.Decode(&a); err != nil {
+			return nil, err
+		}
+		awards = append(awards, a)
+	}
+	return awards, nil
+}
+
+// Grant saves a unless the user already holds the achievement, reporting
+// whether it was saved; an earlier award is never replaced
+func (r *Repository) Grant(ctx context.Context, a Award) (bool, error) {
+	var existing Award
+	err := r.store.Get(ctx, store.UserPK(a.UserID), awardSKPrefix+a.ID, &existing)
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return false, fmt.Errorf("failed to load achievement: %w", err)
+	}
+	if err := r.store.Put(ctx, store.UserPK(a.UserID), awardSKPrefix+a.ID, a); err != nil {
+		return false, fmt.Errorf("failed to save achievement: %w", err)
+	}
+	return true, nil
+}
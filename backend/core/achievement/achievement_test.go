@@ -0,0 +1,124 @@
+package achievement
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"athlete-forge/events"
+	"athlete-forge/profile"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+func completedOn(at time.Time, weight float64) workout.Workout {
+	return workout.Workout{
+		ID: at.Format(time.RFC3339), UserID: "user-1", Status: workout.StatusCompleted, StartedAt: at, CompletedAt: &at,
+		Exercises: []workout.Exercise{{Name: "Squat", Sets: []workout.Set{{Reps: 10, Weight: weight}}}},
+	}
+}
+
+func ids(awards []Award) map[string]bool {
+	got := map[string]bool{}
+	for _, a := range awards {
+		got[a.ID] = true
+	}
+	return got
+}
+
+func TestEvaluate(t *testing.T) {
+	start := time.Date(2024, 1, 1, 18, 0, 0, 0, time.UTC)
+
+	t.Run("awards the first workout once", func(t *testing.T) {
+		// Arrange
+		w := completedOn(start, 60)
+		h := History{Workouts: []workout.Workout{w}, Unit: profile.UnitKilograms}
+		published := []events.Event{events.WorkoutCompleted(w)}
+
+		// Act
+		first := Evaluate(published, h, nil)
+		again := Evaluate(published, h, map[string]bool{FirstWorkout: true})
+
+		// Assert
+		if len(first) != 1 || first[0].ID != FirstWorkout || first[0].EventID != published[0].ID || !first[0].AwardedAt.Equal(start) {
+			t.Errorf("expected the first workout award, got %+v", first)
+		}
+		if len(again) != 0 {
+			t.Errorf("expected nothing for a held achievement, got %+v", again)
+		}
+	})
+
+	t.Run("converts session volume to kilograms", func(t *testing.T) {
+		// Arrange
+		w := completedOn(start, 150)
+		published := []events.Event{events.WorkoutCompleted(w)}
+
+		// Act
+		kilograms := Evaluate(published, History{Workouts: []workout.Workout{w}, Unit: profile.UnitKilograms}, nil)
+		pounds := Evaluate(published, History{Workouts: []workout.Workout{w}, Unit: profile.UnitPounds}, nil)
+
+		// Assert
+		if !ids(kilograms)[TonneSession] {
+			t.Errorf("expected 1500kg to earn %s, got %+v", TonneSession, kilograms)
+		}
+		if ids(pounds)[TonneSession] {
+			t.Errorf("expected 1500lb to fall short, got %+v", pounds)
+		}
+	})
+
+	t.Run("counts a hundred sessions and a year of consecutive weeks", func(t *testing.T) {
+		// Arrange
+		var history []workout.Workout
+		for i := 0; i < 2*streakWeeks; i++ {
+			history = append(history, completedOn(start.AddDate(0, 0, 7*(i/2)), 60))
+		}
+		last := history[len(history)-1]
+
+		// Act
+		got := ids(Evaluate([]events.Event{events.WorkoutCompleted(last)}, History{Workouts: history, Unit: profile.UnitKilograms}, nil))
+
+		// Assert
+		if !got[HundredSessions] || !got[YearStreak] {
+			t.Errorf("expected both milestones, got %v", got)
+		}
+	})
+
+	t.Run("a missed week restarts the streak", func(t *testing.T) {
+		// Arrange
+		var history []workout.Workout
+		for week := 0; week < 54; week++ {
+			if week != 10 {
+				history = append(history, completedOn(start.AddDate(0, 0, 7*week), 60))
+			}
+		}
+		last := history[len(history)-1]
+
+		// Act
+		got := ids(Evaluate([]events.Event{events.WorkoutCompleted(last)}, History{Workouts: history, Unit: profile.UnitKilograms}, nil))
+
+		// Assert
+		if got[YearStreak] {
+			t.Errorf("expected 43 weeks not to be a year, got %v", got)
+		}
+	})
+}
+
+func TestRepository_Grant(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	r := NewRepository(store.NewMemoryStore())
+	first := Award{ID: FirstWorkout, UserID: "user-1", EventID: "e1", AwardedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	// Act
+	granted, err := r.Grant(ctx, first)
+	regranted, _ := r.Grant(ctx, Award{ID: FirstWorkout, UserID: "user-1", EventID: "e2", AwardedAt: first.AwardedAt.AddDate(0, 0, 1)})
+	awards, _ := r.List(ctx, "user-1")
+
+	// Assert
+	if err != nil || !granted || regranted {
+		t.Fatalf("expected one grant, got %v then %v (%v)", granted, regranted, err)
+	}
+	if len(awards) != 1 || awards[0].EventID != "e1" {
+		t.Errorf("expected the original award kept, got %+v", awards)
+	}
+}
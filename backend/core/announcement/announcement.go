@@ -0,0 +1,193 @@
+package announcement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"athlete-forge/store"
+)
+
+const (
+	announcementsPK      = "ANNOUNCEMENTS"
+	announcementSKPrefix = "ANNOUNCEMENT#"
+	readSKPrefix         = "ANNOUNCEMENT_READ#"
+	maxTitle             = 120
+	maxBody              = 5000
+)
+
+// Kinds of announcement
+const (
+	KindRelease     = "release"
+	KindMaintenance = "maintenance"
+	KindNotice      = "notice"
+)
+
+// Announcement is a message shown to every user in the apps, such as release
+// notes or a maintenance notice. It is shown from PublishedAt, which can be in
+// the future to schedule it, until ExpiresAt, if set
+type Announcement struct {
+	ID          string     `json:"id"`
+	Kind        string     `json:"kind"`
+	Title       string     `json:"title"`
+	Body        string     `json:"body"`
+	Version     string     `json:"version,omitempty"`
+	PublishedAt time.Time  `json:"publishedAt"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// Validate checks the announcement can be saved
+func (a *Announcement) Validate() error {
+	switch a.Kind {
+	case KindRelease, KindMaintenance, KindNotice:
+	default:
+		return fmt.Errorf("kind must be %s, %s or %s", KindRelease, KindMaintenance, KindNotice)
+	}
+	a.Title = strings.TrimSpace(a.Title)
+	if a.Title == "" {
+		return errors.New("title is required")
+	}
+	if len([]rune(a.Title)) > maxTitle {
+		return fmt.Errorf("title must be at most %d characters", maxTitle)
+	}
+	if len([]rune(a.Body)) > maxBody {
+		return fmt.Errorf("body must be at most %d characters", maxBody)
+	}
+	if a.PublishedAt.IsZero() {
+		return errors.New("publishedAt is required")
+	}
+	if a.ExpiresAt != nil && !a.ExpiresAt.After(a.PublishedAt) {
+		return errors.New("expiresAt must be after publishedAt")
+	}
+	return nil
+}
+
+// Live reports whether the announcement is shown at now
+func (a *Announcement) Live(now time.Time) bool {
+	return !now.Before(a.PublishedAt) && (a.ExpiresAt == nil || now.Before(*a.ExpiresAt))
+}
+
+// Repository stores announcements, shared by every user in the home table, and
+// which of them each user has read, in the user's partition
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Get returns the announcement with id
+func (r *Repository) Get(ctx context.Context, id string) (*Announcement, error) {
+	var a Announcement
+	if err := r.store.Get(ctx, announcementsPK, announcementSKPrefix+id, &a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// List returns every announcement, scheduled and expired ones included, most
+// recently published first
+func (r *Repository) List(ctx context.Context) ([]Announcement, error) {
+	items, err := r.store.Query(ctx, announcementsPK, announcementSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list announcements: %w", err)
+	}
+	announcements := make([]Announcement, 0, len(items))
+	for _, item := range items {
+		var a Announcement
+		if err := item.Decode(&a); err != nil {
+			return nil, err
+		}
+		announcements = append(announcements, a)
+	}
+	sort.SliceStable(announcements, func(i, j int) bool {
+		return announcements[i].PublishedAt.After(announcements[j].PublishedAt)
+	})
+	return announcements, nil
+}
+
+// Live returns the announcements shown at now, most recently published first
+func (r *Repository) Live(ctx context.Context, now time.Time) ([]Announcement, error) {
+	all, err := r.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	live := []Announcement{}
+	for _, a := range all {
+		if a.Live(now) {
+			live = append(live, a)
+		}
+	}
+	return live, nil
+}
+
+// Save validates and stores a, assigning an ID to new announcements
+func (r *Repository) Save(ctx context.Context, a *Announcement, now time.Time) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	if a.ID == "" {
+		a.ID = store.NewID()
+		a.CreatedAt = now
+	}
+	a.UpdatedAt = now
+	if err := r.store.Put(ctx, announcementsPK, announcementSKPrefix+a.ID, a); err != nil {
+		return fmt.Errorf("failed to save announcement: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the announcement with id. Users' read markers for it are left
+// behind, as they are only read for announcements that exist
+func (r *Repository) Delete(ctx context.Context, id string) error {
+	if err := r.store.Delete(ctx, announcementsPK, announcementSKPrefix+id); err != nil {
+		return fmt.Errorf("failed to delete announcement: %w", err)
+	}
+	return nil
+}
+
+// read marks an announcement a user has read
+type read struct {
+	ReadAt time.Time `json:"readAt"`
+}
+
+// Read returns when userID read each announcement they have read, by ID
+func (r *Repository) Read(ctx context.Context, userID string) (map[string]time.Time, error) {
+	items, err := r.store.Query(ctx, store.UserPK(userID), readSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list read announcements: %w", err)
+	}
+	reads := make(map[string]time.Time, len(items))
+	for _, item := range items {
+		var m read
+		if err := item.Decode(&m); err != nil {
+			return nil, err
+		}
+		reads[strings.TrimPrefix(item.SK, readSKPrefix)] = m.ReadAt
+	}
+	return reads, nil
+}
+
+// MarkRead records that userID read the announcement with id at now, keeping
+// the first time they read it
+func (r *Repository) MarkRead(ctx context.Context, userID, id string, now time.Time) (time.Time, error) {
+	var existing read
+	err := r.store.Get(ctx, store.UserPK(userID), readSKPrefix+id, &existing)
+	if err == nil {
+		return existing.ReadAt, nil
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return time.Time{}, fmt.Errorf("failed to load read announcement: %w", err)
+	}
+	if err := r.store.Put(ctx, store.UserPK(userID), readSKPrefix+id, read{ReadAt: now}); err != nil {
+		return time.Time{}, fmt.Errorf("failed to mark announcement read: %w", err)
+	}
+	return now, nil
+}
@@ -0,0 +1,83 @@
+package announcement
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+)
+
+func TestAnnouncement_Validate(t *testing.T) {
+	published := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	expired := published.Add(-time.Hour)
+	cases := map[string]Announcement{
+		"unknown kind":        {Kind: "promo", Title: "Sale", PublishedAt: published},
+		"missing title":       {Kind: KindRelease, Title: " ", PublishedAt: published},
+		"missing publishedAt": {Kind: KindRelease, Title: "2.4"},
+		"expires first":       {Kind: KindMaintenance, Title: "Downtime", PublishedAt: published, ExpiresAt: &expired},
+	}
+	for name, a := range cases {
+		if err := a.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+
+	t.Run("shows only announcements published and not yet expired", func(t *testing.T) {
+		// Arrange
+		r := NewRepository(store.NewMemoryStore())
+		ended := now.Add(-time.Hour)
+		for _, a := range []*Announcement{
+			{Kind: KindRelease, Title: "2.3", PublishedAt: now.Add(-48 * time.Hour)},
+			{Kind: KindRelease, Title: "2.4", PublishedAt: now.Add(-time.Hour)},
+			{Kind: KindRelease, Title: "2.5", PublishedAt: now.Add(time.Hour)},
+			{Kind: KindMaintenance, Title: "Downtime", PublishedAt: now.Add(-2 * time.Hour), ExpiresAt: &ended},
+		} {
+			if err := r.Save(ctx, a, now); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		// Act
+		live, err := r.Live(ctx, now)
+		all, _ := r.List(ctx)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(live) != 2 || live[0].Title != "2.4" || live[1].Title != "2.3" {
+			t.Errorf("unexpected live announcements: %+v", live)
+		}
+		if len(all) != 4 || all[0].Title != "2.5" {
+			t.Errorf("unexpected announcements: %+v", all)
+		}
+	})
+
+	t.Run("keeps the first time a user read an announcement", func(t *testing.T) {
+		// Arrange
+		r := NewRepository(store.NewMemoryStore())
+
+		// Act
+		first, _ := r.MarkRead(ctx, "user-1", "a1", now)
+		again, err := r.MarkRead(ctx, "user-1", "a1", now.Add(time.Hour))
+		reads, _ := r.Read(ctx, "user-1")
+		others, _ := r.Read(ctx, "user-2")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !first.Equal(now) || !again.Equal(now) || !reads["a1"].Equal(now) {
+			t.Errorf("expected the first read time, got %v, %v and %v", first, again, reads)
+		}
+		if len(others) != 0 {
+			t.Errorf("expected reads to be per user, got %v", others)
+		}
+	})
+}
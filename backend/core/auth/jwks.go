@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// jwksRefreshInterval is how long fetched signing keys are trusted before refetching
+	jwksRefreshInterval = time.Hour
+
+	// jwksMinRefetch limits refetches triggered by tokens naming unknown keys
+	jwksMinRefetch = time.Minute
+)
+
+// keySet caches a provider's published RSA signing keys, refetching when they
+// age out or a token names an unknown key after rotation
+type keySet struct {
+	url        string
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+type jwksResponse struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+// key returns the public key with kid
+func (k *keySet) key(ctx context.Context, kid string, now time.Time) (*rsa.PublicKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	age := now.Sub(k.fetchedAt)
+	key, ok := k.keys[kid]
+	if ok && age < jwksRefreshInterval {
+		return key, nil
+	}
+	if !ok && k.keys != nil && age < jwksMinRefetch {
+		return nil, ErrInvalidToken
+	}
+	if err := k.fetch(ctx, now); err != nil {
+		return nil, err
+	}
+	if key, ok = k.keys[kid]; !ok {
+		return nil, ErrInvalidToken
+	}
+	return key, nil
+}
+
+func (k *keySet) fetch(ctx context.Context, now time.Time) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build signing key request: %w", err)
+	}
+	resp, err := k.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("signing key fetch failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("signing key fetch returned status %d", resp.StatusCode)
+	}
+
+	var body jwksResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("failed to decode signing keys: %w", err)
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, jwk := range body.Keys {
+		if jwk.Kty != "RSA" {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	k.keys = keys
+	k.fetchedAt = now
+	return nil
+}
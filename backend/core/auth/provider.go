@@ -0,0 +1,293 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Provider names
+const (
+	ProviderGoogle = "google"
+	ProviderApple  = "apple"
+)
+
+// clockSkew is the leeway allowed when checking token expiry
+const clockSkew = time.Minute
+
+// appleClientSecretTTL is how long generated Apple client secrets are valid; Apple
+// allows up to six months
+const appleClientSecretTTL = 5 * time.Minute
+
+// ErrInvalidCredentials is returned when a provider rejects the code or ID token
+var ErrInvalidCredentials = errors.New("invalid sign-in credentials")
+
+// Credentials are what a client obtained from the provider's sign-in flow: either
+// an authorization code to exchange, or an ID token from a native SDK
+type Credentials struct {
+	Code        string `json:"code,omitempty"`
+	IDToken     string `json:"idToken,omitempty"`
+	RedirectURI string `json:"redirectUri,omitempty"`
+	Nonce       string `json:"nonce,omitempty"`
+
+	// Name is only sent by Apple, to the client, on the first sign-in
+	Name string `json:"name,omitempty"`
+}
+
+// Identity is a verified account at an external provider
+type Identity struct {
+	Provider      string `json:"provider"`
+	Subject       string `json:"subject"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified bool   `json:"emailVerified"`
+	Name          string `json:"name,omitempty"`
+}
+
+// Provider verifies sign-in credentials from an external identity provider
+type Provider interface {
+	Name() string
+	Authenticate(ctx context.Context, creds Credentials) (*Identity, error)
+}
+
+// OIDC authenticates against an OpenID Connect provider, exchanging authorization
+// codes at TokenURL and verifying RS256 ID tokens with keys published at JWKSURL
+type OIDC struct {
+	ProviderName string
+	Issuers      []string
+	ClientID     string
+	TokenURL     string
+	JWKSURL      string
+	HTTPClient   *http.Client
+
+	// ClientSecret returns the secret sent with code exchanges
+	ClientSecret func(now time.Time) (string, error)
+
+	// Now returns the current time; tests override it
+	Now func() time.Time
+
+	keysOnce sync.Once
+	keys     *keySet
+}
+
+// NewGoogle creates a provider for Sign in with Google
+func NewGoogle(clientID, clientSecret string) *OIDC {
+	return &OIDC{
+		ProviderName: ProviderGoogle,
+		Issuers:      []string{"https://accounts.google.com", "accounts.google.com"},
+		ClientID:     clientID,
+		TokenURL:     "https://oauth2.googleapis.com/token",
+		JWKSURL:      "https://www.googleapis.com/oauth2/v3/certs",
+		HTTPClient:   &http.Client{Timeout: 5 * time.Second},
+		ClientSecret: func(time.Time) (string, error) { return clientSecret, nil },
+		Now:          time.Now,
+	}
+}
+
+// NewApple creates a provider for Sign in with Apple; Apple's client secret is a
+// short-lived JWT signed with the team's PKCS #8 (.p8) private key
+func NewApple(clientID, teamID, keyID string, privateKeyPEM []byte) (*OIDC, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, errors.New("apple private key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse apple private key: %w", err)
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("apple private key is not an ECDSA key")
+	}
+
+	return &OIDC{
+		ProviderName: ProviderApple,
+		Issuers:      []string{"https://appleid.apple.com"},
+		ClientID:     clientID,
+		TokenURL:     "https://appleid.apple.com/auth/token",
+		JWKSURL:      "https://appleid.apple.com/auth/keys",
+		HTTPClient:   &http.Client{Timeout: 5 * time.Second},
+		ClientSecret: func(now time.Time) (string, error) {
+			return signES256(map[string]interface{}{
+				"iss": teamID,
+				"iat": now.Unix(),
+				"exp": now.Add(appleClientSecretTTL).Unix(),
+				"aud": "https://appleid.apple.com",
+				"sub": clientID,
+			}, key, keyID)
+		},
+		Now: time.Now,
+	}, nil
+}
+
+// Name returns the provider name used in routes and identity links
+func (o *OIDC) Name() string {
+	return o.ProviderName
+}
+
+// Authenticate exchanges the authorization code when one is given, then verifies
+// the resulting ID token
+func (o *OIDC) Authenticate(ctx context.Context, creds Credentials) (*Identity, error) {
+	idToken := creds.IDToken
+	if creds.Code != "" {
+		token, err := o.exchange(ctx, creds.Code, creds.RedirectURI)
+		if err != nil {
+			return nil, err
+		}
+		idToken = token
+	}
+	if idToken == "" {
+		return nil, fmt.Errorf("%w: code or idToken is required", ErrInvalidCredentials)
+	}
+
+	identity, err := o.verify(ctx, idToken, creds.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	if identity.Name == "" {
+		identity.Name = strings.TrimSpace(creds.Name)
+	}
+	return identity, nil
+}
+
+type tokenResponse struct {
+	IDToken string `json:"id_token"`
+	Error   string `json:"error"`
+}
+
+// exchange redeems an authorization code for the provider's ID token
+func (o *OIDC) exchange(ctx context.Context, code, redirectURI string) (string, error) {
+	secret, err := o.ClientSecret(o.Now())
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"client_id":     {o.ClientID},
+		"client_secret": {secret},
+	}
+	if redirectURI != "" {
+		form.Set("redirect_uri", redirectURI)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := o.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%s token exchange failed: %w", o.ProviderName, err)
+	}
+	defer resp.Body.Close()
+
+	var body tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode %s token response: %w", o.ProviderName, err)
+	}
+	// invalid_grant covers expired, reused and mismatched codes
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized {
+		return "", fmt.Errorf("%w: %s", ErrInvalidCredentials, body.Error)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s token exchange returned status %d", o.ProviderName, resp.StatusCode)
+	}
+	if body.IDToken == "" {
+		return "", fmt.Errorf("%s token response has no id_token", o.ProviderName)
+	}
+	return body.IDToken, nil
+}
+
+// idTokenClaims are the ID token claims we check; Apple sends email_verified as a
+// string while Google sends a boolean
+type idTokenClaims struct {
+	Issuer        string      `json:"iss"`
+	Subject       string      `json:"sub"`
+	Audience      audience    `json:"aud"`
+	ExpiresAt     int64       `json:"exp"`
+	Nonce         string      `json:"nonce"`
+	Email         string      `json:"email"`
+	EmailVerified interface{} `json:"email_verified"`
+	Name          string      `json:"name"`
+}
+
+// audience accepts the aud claim as either a string or an array
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// verify checks the ID token's signature, issuer, audience, expiry and nonce
+func (o *OIDC) verify(ctx context.Context, idToken, nonce string) (*Identity, error) {
+	now := o.Now()
+	token, err := parseJWT(idToken)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	o.keysOnce.Do(func() {
+		o.keys = &keySet{url: o.JWKSURL, httpClient: o.HTTPClient}
+	})
+	key, err := o.keys.key(ctx, token.header.Kid, now)
+	if errors.Is(err, ErrInvalidToken) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := token.verifyRS256(key); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	var claims idTokenClaims
+	if err := token.claims(&claims); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if !contains(o.Issuers, claims.Issuer) || !contains(claims.Audience, o.ClientID) || claims.Subject == "" {
+		return nil, fmt.Errorf("%w: token was not issued for this app", ErrInvalidCredentials)
+	}
+	if now.After(time.Unix(claims.ExpiresAt, 0).Add(clockSkew)) {
+		return nil, fmt.Errorf("%w: token has expired", ErrInvalidCredentials)
+	}
+	if nonce != "" && claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce does not match", ErrInvalidCredentials)
+	}
+
+	return &Identity{
+		Provider:      o.ProviderName,
+		Subject:       claims.Subject,
+		Email:         strings.ToLower(claims.Email),
+		EmailVerified: claims.EmailVerified == true || claims.EmailVerified == "true",
+		Name:          claims.Name,
+	}, nil
+}
+
+func contains(values []string, want string) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,246 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// testIssuer signs ID tokens and serves them from a fake provider
+type testIssuer struct {
+	key      *rsa.PrivateKey
+	server   *httptest.Server
+	idToken  string
+	lastForm map[string]string
+}
+
+func newTestProvider(t *testing.T) (*OIDC, *testIssuer) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	issuer := &testIssuer{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "key-1",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		issuer.lastForm = map[string]string{}
+		for k := range r.PostForm {
+			issuer.lastForm[k] = r.PostForm.Get(k)
+		}
+		if r.PostForm.Get("code") != "good-code" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": issuer.idToken})
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+
+	provider := NewGoogle("client-1", "secret-1")
+	provider.TokenURL = issuer.server.URL + "/token"
+	provider.JWKSURL = issuer.server.URL + "/keys"
+	provider.Now = func() time.Time { return testNow }
+	return provider, issuer
+}
+
+// sign creates an RS256 ID token with claims
+func (i *testIssuer) sign(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+	input, err := signingInput(jwtHeader{Alg: "RS256", Kid: "key-1"}, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte(input))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, i.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func googleClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":            "https://accounts.google.com",
+		"sub":            "google-sub-1",
+		"aud":            "client-1",
+		"exp":            testNow.Add(time.Hour).Unix(),
+		"email":          "Lifter@Example.com",
+		"email_verified": true,
+		"name":           "Sam Lifter",
+	}
+}
+
+func TestOIDC_Authenticate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("exchanges a code and verifies the ID token", func(t *testing.T) {
+		// Arrange
+		provider, issuer := newTestProvider(t)
+		issuer.idToken = issuer.sign(t, googleClaims())
+
+		// Act
+		identity, err := provider.Authenticate(ctx, Credentials{Code: "good-code", RedirectURI: "https://app.example.com/callback"})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if identity.Provider != ProviderGoogle || identity.Subject != "google-sub-1" || identity.Email != "lifter@example.com" || !identity.EmailVerified {
+			t.Errorf("unexpected identity: %+v", identity)
+		}
+		if issuer.lastForm["client_secret"] != "secret-1" || issuer.lastForm["redirect_uri"] != "https://app.example.com/callback" {
+			t.Errorf("unexpected token request: %v", issuer.lastForm)
+		}
+	})
+
+	t.Run("rejects a code the provider does not accept", func(t *testing.T) {
+		// Arrange
+		provider, _ := newTestProvider(t)
+
+		// Act
+		_, err := provider.Authenticate(ctx, Credentials{Code: "reused-code"})
+
+		// Assert
+		if !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("expected ErrInvalidCredentials, got %v", err)
+		}
+	})
+
+	tests := []struct {
+		name   string
+		modify func(claims map[string]interface{})
+		nonce  string
+	}{
+		{name: "expired", modify: func(c map[string]interface{}) { c["exp"] = testNow.Add(-time.Hour).Unix() }},
+		{name: "another app's audience", modify: func(c map[string]interface{}) { c["aud"] = "client-2" }},
+		{name: "wrong issuer", modify: func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" }},
+		{name: "nonce mismatch", modify: func(c map[string]interface{}) { c["nonce"] = "abc" }, nonce: "xyz"},
+	}
+	for _, tt := range tests {
+		t.Run("rejects an ID token with "+tt.name, func(t *testing.T) {
+			// Arrange
+			provider, issuer := newTestProvider(t)
+			claims := googleClaims()
+			tt.modify(claims)
+
+			// Act
+			_, err := provider.Authenticate(ctx, Credentials{IDToken: issuer.sign(t, claims), Nonce: tt.nonce})
+
+			// Assert
+			if !errors.Is(err, ErrInvalidCredentials) {
+				t.Errorf("expected ErrInvalidCredentials, got %v", err)
+			}
+		})
+	}
+
+	t.Run("rejects a token signed with another key", func(t *testing.T) {
+		// Arrange
+		provider, _ := newTestProvider(t)
+		_, other := newTestProvider(t)
+
+		// Act
+		_, err := provider.Authenticate(ctx, Credentials{IDToken: other.sign(t, googleClaims())})
+
+		// Assert
+		if !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("expected ErrInvalidCredentials, got %v", err)
+		}
+	})
+
+	t.Run("accepts Apple's string email_verified and array audience", func(t *testing.T) {
+		// Arrange
+		provider, issuer := newTestProvider(t)
+		provider.Issuers = []string{"https://appleid.apple.com"}
+		claims := googleClaims()
+		claims["iss"] = "https://appleid.apple.com"
+		claims["aud"] = []string{"client-1"}
+		claims["email_verified"] = "true"
+		delete(claims, "name")
+
+		// Act
+		identity, err := provider.Authenticate(ctx, Credentials{IDToken: issuer.sign(t, claims), Name: "Sam"})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !identity.EmailVerified || identity.Name != "Sam" {
+			t.Errorf("unexpected identity: %+v", identity)
+		}
+	})
+}
+
+func TestNewApple(t *testing.T) {
+	t.Run("signs client secrets with the team key", func(t *testing.T) {
+		// Arrange
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		der, _ := x509.MarshalPKCS8PrivateKey(key)
+		provider, err := NewApple("com.example.app", "TEAM123", "KEY123", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// Act
+		secret, err := provider.ClientSecret(testNow)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		token, err := parseJWT(secret)
+		if err != nil {
+			t.Fatalf("failed to parse client secret: %v", err)
+		}
+		var claims struct {
+			Issuer  string `json:"iss"`
+			Subject string `json:"sub"`
+			Expires int64  `json:"exp"`
+		}
+		token.claims(&claims)
+		if token.header.Alg != "ES256" || token.header.Kid != "KEY123" || claims.Issuer != "TEAM123" || claims.Subject != "com.example.app" {
+			t.Errorf("unexpected client secret: %+v %+v", token.header, claims)
+		}
+		if time.Unix(claims.Expires, 0).After(testNow.Add(appleClientSecretTTL)) {
+			t.Errorf("client secret expires too late")
+		}
+		digest := sha256.Sum256([]byte(token.signingInput))
+		r := new(big.Int).SetBytes(token.signature[:32])
+		s := new(big.Int).SetBytes(token.signature[32:])
+		if !ecdsa.Verify(&key.PublicKey, digest[:], r, s) {
+			t.Error("client secret signature does not verify")
+		}
+	})
+
+	t.Run("rejects keys that are not PEM", func(t *testing.T) {
+		if _, err := NewApple("com.example.app", "TEAM123", "KEY123", []byte("not a key")); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
@@ -0,0 +1,212 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"athlete-forge/store"
+)
+
+const (
+	sessionSKPrefix = "SESSION#"
+
+	// RefreshTokenTTL is how long a refresh token stays usable; each rotation
+	// starts a new period, so active devices stay signed in
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+var (
+	// ErrInvalidRefreshToken is returned for unknown, expired or revoked refresh tokens
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+	// ErrRefreshTokenReused is returned when an already rotated refresh token is
+	// presented, which means it was copied; the session is revoked
+	ErrRefreshTokenReused = errors.New("refresh token was already used; session revoked")
+)
+
+// Session is a signed-in device; its refresh token is stored only as a hash
+type Session struct {
+	ID           string    `json:"id"`
+	UserID       string    `json:"userId"`
+	Provider     string    `json:"provider,omitempty"`
+	UserAgent    string    `json:"userAgent,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+	LastUsedAt   time.Time `json:"lastUsedAt"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	TokenHash    string    `json:"tokenHash"`
+	PreviousHash string    `json:"previousHash,omitempty"`
+}
+
+// SessionRepository stores sessions under their user and rotates refresh tokens
+type SessionRepository struct {
+	store store.Store
+}
+
+// NewSessionRepository creates a SessionRepository backed by s
+func NewSessionRepository(s store.Store) *SessionRepository {
+	return &SessionRepository{store: s}
+}
+
+// Create starts a session for userID and returns it with its first refresh token
+func (r *SessionRepository) Create(ctx context.Context, userID, provider, userAgent string, now time.Time) (*Session, string, error) {
+	s := &Session{
+		ID:         store.NewID(),
+		UserID:     userID,
+		Provider:   provider,
+		UserAgent:  userAgent,
+		CreatedAt:  now,
+		LastUsedAt: now,
+	}
+	token, err := r.rotate(ctx, s, now)
+	if err != nil {
+		return nil, "", err
+	}
+	return s, token, nil
+}
+
+// Refresh exchanges refreshToken for a new one. Presenting a token that has already
+// been rotated revokes the session, cutting off whichever party holds the newer token
+func (r *SessionRepository) Refresh(ctx context.Context, refreshToken string, now time.Time) (*Session, string, error) {
+	userID, sessionID, secret, ok := parseRefreshToken(refreshToken)
+	if !ok {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	s, err := r.Get(ctx, userID, sessionID, now)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, "", ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	hash := hashSecret(secret)
+	if !hashEqual(hash, s.TokenHash) {
+		if s.PreviousHash != "" && hashEqual(hash, s.PreviousHash) {
+			if err := r.Revoke(ctx, userID, sessionID); err != nil {
+				return nil, "", err
+			}
+			return nil, "", ErrRefreshTokenReused
+		}
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	s.PreviousHash = s.TokenHash
+	s.LastUsedAt = now
+	token, err := r.rotate(ctx, s, now)
+	if err != nil {
+		return nil, "", err
+	}
+	return s, token, nil
+}
+
+// Get returns userID's active session with id; expired sessions are not found
+func (r *SessionRepository) Get(ctx context.Context, userID, id string, now time.Time) (*Session, error) {
+	var s Session
+	if err := r.store.Get(ctx, store.UserPK(userID), sessionSKPrefix+id, &s); err != nil {
+		return nil, err
+	}
+	if !now.Before(s.ExpiresAt) {
+		return nil, store.ErrNotFound
+	}
+	return &s, nil
+}
+
+// List returns userID's active sessions, oldest first
+func (r *SessionRepository) List(ctx context.Context, userID string, now time.Time) ([]Session, error) {
+	items, err := r.store.Query(ctx, store.UserPK(userID), sessionSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	sessions := []Session{}
+	for _, item := range items {
+		var s Session
+		if err := item.Decode(&s); err != nil {
+			return nil, err
+		}
+		if now.Before(s.ExpiresAt) {
+			sessions = append(sessions, s)
+		}
+	}
+	return sessions, nil
+}
+
+// Revoke ends one of userID's sessions, invalidating its refresh token and any
+// session tokens issued for it
+func (r *SessionRepository) Revoke(ctx context.Context, userID, id string) error {
+	if err := r.store.Delete(ctx, store.UserPK(userID), sessionSKPrefix+id); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+// RevokeAll ends every one of userID's sessions and returns how many were active
+func (r *SessionRepository) RevokeAll(ctx context.Context, userID string, now time.Time) (int, error) {
+	items, err := r.store.Query(ctx, store.UserPK(userID), sessionSKPrefix)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	revoked := 0
+	for _, item := range items {
+		var s Session
+		if err := item.Decode(&s); err != nil {
+			return revoked, err
+		}
+		if err := r.Revoke(ctx, userID, s.ID); err != nil {
+			return revoked, err
+		}
+		if now.Before(s.ExpiresAt) {
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+// rotate gives s a new refresh token, extends its expiry and saves it
+func (r *SessionRepository) rotate(ctx context.Context, s *Session, now time.Time) (string, error) {
+	var secret [32]byte
+	if _, err := rand.Read(secret[:]); err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(secret[:])
+
+	s.TokenHash = hashSecret(encoded)
+	s.ExpiresAt = now.Add(RefreshTokenTTL)
+	if err := r.store.Put(ctx, store.UserPK(s.UserID), sessionSKPrefix+s.ID, s); err != nil {
+		return "", fmt.Errorf("failed to save session: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(s.UserID)) + "." + s.ID + "." + encoded, nil
+}
+
+// parseRefreshToken splits a refresh token into the user and session it belongs to
+// and its secret
+func parseRefreshToken(token string) (string, string, string, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+		return "", "", "", false
+	}
+	userID, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || len(userID) == 0 {
+		return "", "", "", false
+	}
+	return string(userID), parts[1], parts[2], true
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func hashEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
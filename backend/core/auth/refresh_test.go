@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+)
+
+func TestSessionRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rotates the refresh token on each use", func(t *testing.T) {
+		// Arrange
+		sessions := NewSessionRepository(store.NewMemoryStore())
+		created, first, _ := sessions.Create(ctx, "user-1", ProviderGoogle, "iPhone", testNow)
+
+		// Act
+		refreshed, second, err := sessions.Refresh(ctx, first, testNow.Add(time.Hour))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if refreshed.ID != created.ID || second == first {
+			t.Errorf("expected the same session with a new token, got %+v", refreshed)
+		}
+		if !refreshed.ExpiresAt.Equal(testNow.Add(time.Hour + RefreshTokenTTL)) {
+			t.Errorf("expected expiry to extend, got %s", refreshed.ExpiresAt)
+		}
+	})
+
+	t.Run("revokes the session when a rotated token is reused", func(t *testing.T) {
+		// Arrange
+		sessions := NewSessionRepository(store.NewMemoryStore())
+		_, stolen, _ := sessions.Create(ctx, "user-1", ProviderGoogle, "", testNow)
+		_, current, _ := sessions.Refresh(ctx, stolen, testNow)
+
+		// Act
+		_, _, reuseErr := sessions.Refresh(ctx, stolen, testNow)
+		_, _, currentErr := sessions.Refresh(ctx, current, testNow)
+
+		// Assert
+		if !errors.Is(reuseErr, ErrRefreshTokenReused) {
+			t.Errorf("expected ErrRefreshTokenReused, got %v", reuseErr)
+		}
+		if !errors.Is(currentErr, ErrInvalidRefreshToken) {
+			t.Errorf("expected the newer token to be revoked too, got %v", currentErr)
+		}
+	})
+
+	tests := []struct {
+		name  string
+		token func(valid string) string
+		at    time.Time
+	}{
+		{name: "malformed", token: func(string) string { return "abc" }, at: testNow},
+		{name: "unknown secret", token: func(valid string) string { return valid[:len(valid)-4] + "AAAA" }, at: testNow},
+		{name: "expired", token: func(valid string) string { return valid }, at: testNow.Add(RefreshTokenTTL)},
+	}
+	for _, tt := range tests {
+		t.Run("rejects a "+tt.name+" token", func(t *testing.T) {
+			// Arrange
+			sessions := NewSessionRepository(store.NewMemoryStore())
+			_, token, _ := sessions.Create(ctx, "user-1", ProviderGoogle, "", testNow)
+
+			// Act
+			_, _, err := sessions.Refresh(ctx, tt.token(token), tt.at)
+
+			// Assert
+			if !errors.Is(err, ErrInvalidRefreshToken) {
+				t.Errorf("expected ErrInvalidRefreshToken, got %v", err)
+			}
+		})
+	}
+
+	t.Run("revoke all ends every session for the user only", func(t *testing.T) {
+		// Arrange
+		sessions := NewSessionRepository(store.NewMemoryStore())
+		_, phone, _ := sessions.Create(ctx, "user-1", ProviderGoogle, "phone", testNow)
+		sessions.Create(ctx, "user-1", ProviderApple, "laptop", testNow)
+		sessions.Create(ctx, "user-2", ProviderGoogle, "", testNow)
+
+		// Act
+		revoked, err := sessions.RevokeAll(ctx, "user-1", testNow)
+
+		// Assert
+		if err != nil || revoked != 2 {
+			t.Fatalf("expected 2 sessions revoked, got %d (%v)", revoked, err)
+		}
+		if _, _, err := sessions.Refresh(ctx, phone, testNow); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("expected revoked refresh token to fail, got %v", err)
+		}
+		if others, _ := sessions.List(ctx, "user-2", testNow); len(others) != 1 {
+			t.Errorf("expected user-2's session to remain, got %d", len(others))
+		}
+	})
+}
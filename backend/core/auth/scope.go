@@ -0,0 +1,39 @@
+package auth
+
+import "strings"
+
+// Scopes granted to callers and required by routes
+const (
+	ScopeWorkoutsRead  = "workouts:read"
+	ScopeWorkoutsWrite = "workouts:write"
+	ScopeAdmin         = "admin"
+)
+
+// DefaultScopes are granted to every signed-in user
+var DefaultScopes = []string{ScopeWorkoutsRead, ScopeWorkoutsWrite}
+
+// HasScope reports whether granted satisfies required; admin satisfies every scope
+func HasScope(granted []string, required string) bool {
+	for _, scope := range granted {
+		if scope == required || scope == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseScopes splits a space-delimited OAuth scope string
+func ParseScopes(s string) []string {
+	return strings.Fields(s)
+}
+
+// GrantedScopes returns the default scopes plus any extra scopes on the account
+func GrantedScopes(user *User) []string {
+	scopes := append([]string{}, DefaultScopes...)
+	for _, scope := range user.Scopes {
+		if !contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
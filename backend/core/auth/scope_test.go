@@ -0,0 +1,35 @@
+package auth
+
+import "testing"
+
+func TestHasScope(t *testing.T) {
+	tests := []struct {
+		name     string
+		granted  []string
+		required string
+		want     bool
+	}{
+		{name: "granted", granted: DefaultScopes, required: ScopeWorkoutsWrite, want: true},
+		{name: "not granted", granted: []string{ScopeWorkoutsRead}, required: ScopeWorkoutsWrite},
+		{name: "admin satisfies any scope", granted: []string{ScopeAdmin}, required: ScopeWorkoutsWrite, want: true},
+		{name: "defaults exclude admin", granted: DefaultScopes, required: ScopeAdmin},
+		{name: "nothing granted", required: ScopeWorkoutsRead},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasScope(tt.granted, tt.required); got != tt.want {
+				t.Errorf("HasScope(%v, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrantedScopes(t *testing.T) {
+	scopes := GrantedScopes(&User{Scopes: []string{ScopeAdmin, ScopeWorkoutsRead}})
+	if len(scopes) != 3 || scopes[2] != ScopeAdmin {
+		t.Errorf("unexpected scopes %v", scopes)
+	}
+	if len(DefaultScopes) != 2 {
+		t.Errorf("GrantedScopes modified the defaults: %v", DefaultScopes)
+	}
+}
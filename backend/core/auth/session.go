@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	// sessionIssuer identifies session tokens issued by this service
+	sessionIssuer = "athlete-forge"
+
+	// DefaultSessionTTL is how long issued session tokens are valid; clients renew
+	// them with their refresh token
+	DefaultSessionTTL = 15 * time.Minute
+)
+
+// SessionClaims are the claims carried by session tokens
+type SessionClaims struct {
+	Issuer    string `json:"iss"`
+	Subject   string `json:"sub"`
+	SessionID string `json:"sid"`
+	Scope     string `json:"scope,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Sessions issues and verifies the HS256 session tokens clients send as bearer tokens
+type Sessions struct {
+	secret []byte
+	TTL    time.Duration
+}
+
+// NewSessions creates a session issuer signing with secret
+func NewSessions(secret []byte) *Sessions {
+	return &Sessions{secret: secret, TTL: DefaultSessionTTL}
+}
+
+// Issue returns a session token for userID's session granting scopes, and when it expires
+func (s *Sessions) Issue(userID, sessionID string, scopes []string, now time.Time) (string, time.Time, error) {
+	expires := now.Add(s.TTL).Truncate(time.Second)
+	token, err := signHS256(SessionClaims{
+		Issuer:    sessionIssuer,
+		Subject:   userID,
+		SessionID: sessionID,
+		Scope:     strings.Join(scopes, " "),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expires.Unix(),
+	}, s.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expires, nil
+}
+
+// Scopes returns the scopes the token grants; tokens without a scope claim get the defaults
+func (c *SessionClaims) Scopes() []string {
+	if c.Scope == "" {
+		return DefaultScopes
+	}
+	return ParseScopes(c.Scope)
+}
+
+// Verify checks token's signature, issuer and expiry and returns its claims
+func (s *Sessions) Verify(token string, now time.Time) (*SessionClaims, error) {
+	parsed, err := parseJWT(token)
+	if err != nil {
+		return nil, err
+	}
+	if err := parsed.verifyHS256(s.secret); err != nil {
+		return nil, err
+	}
+
+	var claims SessionClaims
+	if err := parsed.claims(&claims); err != nil {
+		return nil, err
+	}
+	if claims.Issuer != sessionIssuer || claims.Subject == "" || claims.SessionID == "" || !now.Before(time.Unix(claims.ExpiresAt, 0)) {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
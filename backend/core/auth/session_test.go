@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessions(t *testing.T) {
+	sessions := NewSessions([]byte("secret"))
+	token, expires, err := sessions.Issue("user-1", "session-1", []string{ScopeWorkoutsRead, ScopeAdmin}, testNow)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !expires.Equal(testNow.Add(DefaultSessionTTL)) {
+		t.Errorf("unexpected expiry %s", expires)
+	}
+
+	tests := []struct {
+		name     string
+		sessions *Sessions
+		token    string
+		at       time.Time
+		wantErr  bool
+	}{
+		{name: "valid", sessions: sessions, token: token, at: testNow.Add(time.Minute)},
+		{name: "expired", sessions: sessions, token: token, at: expires, wantErr: true},
+		{name: "other secret", sessions: NewSessions([]byte("other")), token: token, at: testNow, wantErr: true},
+		{name: "tampered", sessions: sessions, token: strings.Replace(token, ".", ".e30", 1), at: testNow, wantErr: true},
+		{name: "malformed", sessions: sessions, token: "abc", at: testNow, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := tt.sessions.Verify(tt.token, tt.at)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Errorf("expected ErrInvalidToken, got %v", err)
+				}
+				return
+			}
+			if err != nil || claims.Subject != "user-1" || claims.SessionID != "session-1" || !HasScope(claims.Scopes(), ScopeAdmin) {
+				t.Errorf("unexpected result %+v, %v", claims, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,321 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"athlete-forge/store"
+)
+
+const (
+	accountSK        = "ACCOUNT"
+	identityPKPrefix = "IDENTITY#"
+	emailPKPrefix    = "EMAIL#"
+
+	// identitySK and emailSK keyed lookup items before they were keyed by
+	// their user; such items are still read and replaced when next saved
+	identitySK = "IDENTITY"
+	emailSK    = "EMAIL"
+)
+
+// AccountSKPrefixes are the sort key prefixes of the items a user has from
+// signing up: their account and sign-in sessions
+var AccountSKPrefixes = []string{accountSK, sessionSKPrefix}
+
+// LookupPKPrefixes are the partitions of identity and email lookups, which are
+// keyed by the user they point at so they can be kept in the user's region
+var LookupPKPrefixes = []string{identityPKPrefix, emailPKPrefix}
+
+// ErrNotLinked is returned when unlinking a provider the account has no identity from
+var ErrNotLinked = errors.New("no identity from this provider is linked")
+
+// ErrLastIdentity is returned when unlinking would leave an account no way to sign in
+var ErrLastIdentity = errors.New("cannot unlink the only sign-in method")
+
+// ErrIdentityLinked is returned when linking an identity that belongs to another user
+var ErrIdentityLinked = errors.New("identity is linked to another account")
+
+// User is an account created through a provider sign-in
+type User struct {
+	ID         string     `json:"id"`
+	Email      string     `json:"email,omitempty"`
+	Name       string     `json:"name,omitempty"`
+	Identities []Identity `json:"identities"`
+	Scopes     []string   `json:"scopes,omitempty"`
+	Region     string     `json:"region,omitempty"`
+
+	// Unlinked holds the identities the user unlinked, as provider#subject, so
+	// signing in with one no longer reaches the account through its email
+	Unlinked    []string  `json:"unlinked,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	LastLoginAt time.Time `json:"lastLoginAt"`
+}
+
+// link points an identity or email at the user that owns it
+type link struct {
+	UserID string `json:"userId"`
+}
+
+// Users stores accounts with lookup items for each linked identity and verified
+// email, kept in the application table alongside the user's other data. A
+// lookup item is keyed by the user it points at, so it is read by querying its
+// partition
+type Users struct {
+	store store.Store
+}
+
+// NewUsers creates a Users repository backed by s
+func NewUsers(s store.Store) *Users {
+	return &Users{store: s}
+}
+
+// Get returns the account with id
+func (u *Users) Get(ctx context.Context, id string) (*User, error) {
+	var user User
+	if err := u.store.Get(ctx, store.UserPK(id), accountSK, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SignIn returns the account for identity, creating one when the identity is new.
+// A new identity whose verified email matches an existing account is linked to it,
+// so signing in with Apple and Google reaches the same account
+func (u *Users) SignIn(ctx context.Context, identity Identity, now time.Time) (*User, bool, error) {
+	userID, err := u.lookup(ctx, identityPK(identity))
+	if err != nil {
+		return nil, false, err
+	}
+	byEmail := false
+	if userID == "" && identity.EmailVerified && identity.Email != "" {
+		if userID, err = u.lookup(ctx, emailPKPrefix+identity.Email); err != nil {
+			return nil, false, err
+		}
+		byEmail = userID != ""
+	}
+
+	var user *User
+	if userID != "" {
+		if user, err = u.Get(ctx, userID); err != nil {
+			return nil, false, err
+		}
+		if byEmail && user.unlinked(identity) {
+			user = nil
+		}
+	}
+	created := user == nil
+	if created {
+		user = &User{ID: store.NewID(), Email: identity.Email, Name: identity.Name, CreatedAt: now}
+	}
+
+	addIdentity(user, identity)
+	user.LastLoginAt = now
+	if err := u.save(ctx, user, identity); err != nil {
+		return nil, false, err
+	}
+	return user, created, nil
+}
+
+// Link adds identity to userID's account
+func (u *Users) Link(ctx context.Context, userID string, identity Identity, now time.Time) (*User, error) {
+	owner, err := u.lookup(ctx, identityPK(identity))
+	if err != nil {
+		return nil, err
+	}
+	if owner != "" && owner != userID {
+		return nil, ErrIdentityLinked
+	}
+
+	user, err := u.Get(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		// Users who signed up through Cognito have no account record yet
+		user = &User{ID: userID, CreatedAt: now}
+	} else if err != nil {
+		return nil, err
+	}
+	if user.Email == "" {
+		user.Email = identity.Email
+	}
+	if user.Name == "" {
+		user.Name = identity.Name
+	}
+
+	addIdentity(user, identity)
+	user.Unlinked = removeKey(user.Unlinked, identityKey(identity))
+	if err := u.save(ctx, user, identity); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Unlink removes the identities from provider from userID's account, so they no
+// longer sign in to it; an account must keep at least one identity
+func (u *Users) Unlink(ctx context.Context, userID, provider string) (*User, error) {
+	user, err := u.Get(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrNotLinked
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var kept, removed []Identity
+	for _, identity := range user.Identities {
+		if identity.Provider == provider {
+			removed = append(removed, identity)
+		} else {
+			kept = append(kept, identity)
+		}
+	}
+	if len(removed) == 0 {
+		return nil, ErrNotLinked
+	}
+	if len(kept) == 0 {
+		return nil, ErrLastIdentity
+	}
+
+	user.Identities = kept
+	for _, identity := range removed {
+		user.Unlinked = append(removeKey(user.Unlinked, identityKey(identity)), identityKey(identity))
+	}
+	if err := u.Save(ctx, user); err != nil {
+		return nil, err
+	}
+	for _, identity := range removed {
+		for _, sk := range []string{store.UserPK(user.ID), identitySK} {
+			if err := u.store.Delete(ctx, identityPK(identity), sk); err != nil {
+				return nil, fmt.Errorf("failed to unlink identity: %w", err)
+			}
+		}
+	}
+	return user, nil
+}
+
+// Relink rewrites the lookup items pointing at user under the keys LinkKeys
+// returns, replacing any stored under older keys
+func (u *Users) Relink(ctx context.Context, user *User) error {
+	for _, identity := range user.Identities {
+		if err := u.setLink(ctx, identityPK(identity), identitySK, user.ID); err != nil {
+			return fmt.Errorf("failed to link identity: %w", err)
+		}
+	}
+	if user.Email == "" {
+		return nil
+	}
+	if owner, err := u.lookup(ctx, emailPKPrefix+user.Email); err != nil || owner != user.ID {
+		return err
+	}
+	if err := u.setLink(ctx, emailPKPrefix+user.Email, emailSK, user.ID); err != nil {
+		return fmt.Errorf("failed to index email: %w", err)
+	}
+	return nil
+}
+
+// LinkKeys returns the keys of the lookup items that can point at user: one per
+// linked identity and one for their email
+func LinkKeys(user *User) []store.Key {
+	var keys []store.Key
+	for _, identity := range user.Identities {
+		keys = append(keys, store.Key{PK: identityPK(identity), SK: store.UserPK(user.ID)})
+	}
+	if user.Email != "" {
+		keys = append(keys, store.Key{PK: emailPKPrefix + user.Email, SK: store.UserPK(user.ID)})
+	}
+	return keys
+}
+
+// Save stores changes to an account, such as granted scopes
+func (u *Users) Save(ctx context.Context, user *User) error {
+	if err := u.store.Put(ctx, store.UserPK(user.ID), accountSK, user); err != nil {
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+	return nil
+}
+
+// save writes the account and its lookup items for identity
+func (u *Users) save(ctx context.Context, user *User, identity Identity) error {
+	if err := u.Save(ctx, user); err != nil {
+		return err
+	}
+	if err := u.setLink(ctx, identityPK(identity), identitySK, user.ID); err != nil {
+		return fmt.Errorf("failed to link identity: %w", err)
+	}
+	if identity.EmailVerified && identity.Email != "" {
+		// Keep the first account claiming an email so a later identity cannot take it over
+		if owner, err := u.lookup(ctx, emailPKPrefix+identity.Email); err != nil {
+			return err
+		} else if owner == "" || owner == user.ID {
+			if err := u.setLink(ctx, emailPKPrefix+identity.Email, emailSK, user.ID); err != nil {
+				return fmt.Errorf("failed to index email: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// setLink points the lookup item in pk at userID, replacing one stored under the
+// older sort key legacySK
+func (u *Users) setLink(ctx context.Context, pk, legacySK, userID string) error {
+	if err := u.store.Put(ctx, pk, store.UserPK(userID), link{UserID: userID}); err != nil {
+		return err
+	}
+	return u.store.Delete(ctx, pk, legacySK)
+}
+
+// lookup returns the user ID the lookup item in pk points at, or "" when there
+// is none
+func (u *Users) lookup(ctx context.Context, pk string) (string, error) {
+	items, err := u.store.Query(ctx, pk, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to look up user: %w", err)
+	}
+	if len(items) == 0 {
+		return "", nil
+	}
+	var l link
+	if err := items[0].Decode(&l); err != nil {
+		return "", err
+	}
+	return l.UserID, nil
+}
+
+func identityPK(identity Identity) string {
+	return identityPKPrefix + identityKey(identity)
+}
+
+func identityKey(identity Identity) string {
+	return identity.Provider + "#" + identity.Subject
+}
+
+// unlinked reports whether the user unlinked identity from their account
+func (user *User) unlinked(identity Identity) bool {
+	for _, key := range user.Unlinked {
+		if key == identityKey(identity) {
+			return true
+		}
+	}
+	return false
+}
+
+func removeKey(keys []string, key string) []string {
+	kept := keys[:0:0]
+	for _, k := range keys {
+		if k != key {
+			kept = append(kept, k)
+		}
+	}
+	return kept
+}
+
+// addIdentity records identity on user, refreshing its details if already present
+func addIdentity(user *User, identity Identity) {
+	for i, existing := range user.Identities {
+		if existing.Provider == identity.Provider && existing.Subject == identity.Subject {
+			user.Identities[i] = identity
+			return
+		}
+	}
+	user.Identities = append(user.Identities, identity)
+}
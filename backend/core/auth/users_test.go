@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"athlete-forge/store"
+)
+
+func TestUsers(t *testing.T) {
+	ctx := context.Background()
+	google := Identity{Provider: ProviderGoogle, Subject: "g-1", Email: "sam@example.com", EmailVerified: true, Name: "Sam"}
+	apple := Identity{Provider: ProviderApple, Subject: "a-1", Email: "sam@example.com", EmailVerified: true}
+
+	t.Run("creates an account on first sign-in and reuses it after", func(t *testing.T) {
+		// Arrange
+		users := NewUsers(store.NewMemoryStore())
+
+		// Act
+		first, created, err := users.SignIn(ctx, google, testNow)
+		second, createdAgain, err2 := users.SignIn(ctx, google, testNow)
+
+		// Assert
+		if err != nil || err2 != nil {
+			t.Fatalf("unexpected errors: %v, %v", err, err2)
+		}
+		if !created || createdAgain || first.ID != second.ID || first.Name != "Sam" {
+			t.Errorf("unexpected accounts: %+v %+v", first, second)
+		}
+	})
+
+	t.Run("links a new provider by verified email", func(t *testing.T) {
+		// Arrange
+		users := NewUsers(store.NewMemoryStore())
+		existing, _, _ := users.SignIn(ctx, google, testNow)
+
+		// Act
+		user, created, err := users.SignIn(ctx, apple, testNow)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if created || user.ID != existing.ID || len(user.Identities) != 2 {
+			t.Errorf("expected apple to link to the existing account, got %+v", user)
+		}
+	})
+
+	t.Run("does not link by unverified email", func(t *testing.T) {
+		// Arrange
+		users := NewUsers(store.NewMemoryStore())
+		existing, _, _ := users.SignIn(ctx, google, testNow)
+		unverified := apple
+		unverified.EmailVerified = false
+
+		// Act
+		user, created, err := users.SignIn(ctx, unverified, testNow)
+
+		// Assert
+		if err != nil || !created || user.ID == existing.ID {
+			t.Errorf("expected a separate account, got %+v (created %v, err %v)", user, created, err)
+		}
+	})
+
+	t.Run("refuses to link an identity owned by another account", func(t *testing.T) {
+		// Arrange
+		users := NewUsers(store.NewMemoryStore())
+		users.SignIn(ctx, google, testNow)
+
+		// Act
+		_, err := users.Link(ctx, "cognito-user", google, testNow)
+
+		// Assert
+		if !errors.Is(err, ErrIdentityLinked) {
+			t.Errorf("expected ErrIdentityLinked, got %v", err)
+		}
+	})
+
+	t.Run("links an identity to an existing Cognito user", func(t *testing.T) {
+		// Arrange
+		users := NewUsers(store.NewMemoryStore())
+
+		// Act
+		user, err := users.Link(ctx, "cognito-user", apple, testNow)
+		signedIn, created, _ := users.SignIn(ctx, apple, testNow)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if user.ID != "cognito-user" || created || signedIn.ID != "cognito-user" {
+			t.Errorf("expected sign-in to reach the linked user, got %+v", signedIn)
+		}
+	})
+
+	t.Run("unlinked identities no longer reach the account by email", func(t *testing.T) {
+		// Arrange
+		users := NewUsers(store.NewMemoryStore())
+		existing, _, _ := users.SignIn(ctx, google, testNow)
+		users.SignIn(ctx, apple, testNow)
+
+		// Act
+		unlinked, err := users.Unlink(ctx, existing.ID, ProviderApple)
+		separate, created, _ := users.SignIn(ctx, apple, testNow)
+
+		// Assert
+		if err != nil || len(unlinked.Identities) != 1 || len(unlinked.Unlinked) != 1 {
+			t.Fatalf("unexpected unlink: %+v (%v)", unlinked, err)
+		}
+		if !created || separate.ID == existing.ID {
+			t.Errorf("expected the unlinked identity to sign in to a new account, got %+v", separate)
+		}
+	})
+
+	t.Run("refuses to unlink the only identity", func(t *testing.T) {
+		// Arrange
+		users := NewUsers(store.NewMemoryStore())
+		existing, _, _ := users.SignIn(ctx, google, testNow)
+
+		// Act
+		_, last := users.Unlink(ctx, existing.ID, ProviderGoogle)
+		_, missing := users.Unlink(ctx, existing.ID, ProviderApple)
+
+		// Assert
+		if !errors.Is(last, ErrLastIdentity) || !errors.Is(missing, ErrNotLinked) {
+			t.Errorf("expected ErrLastIdentity and ErrNotLinked, got %v and %v", last, missing)
+		}
+	})
+	t.Run("reads lookups stored under older keys and replaces them", func(t *testing.T) {
+		// Arrange
+		s := store.NewMemoryStore()
+		users := NewUsers(s)
+		s.Put(ctx, store.UserPK("user-1"), accountSK, User{ID: "user-1", Email: google.Email, Identities: []Identity{google}})
+		s.Put(ctx, identityPK(google), identitySK, link{UserID: "user-1"})
+		s.Put(ctx, emailPKPrefix+google.Email, emailSK, link{UserID: "user-1"})
+
+		// Act
+		user, created, err := users.SignIn(ctx, google, testNow)
+
+		// Assert
+		if err != nil || created || user.ID != "user-1" {
+			t.Fatalf("expected the existing account, got %+v (%v)", user, err)
+		}
+		for _, key := range LinkKeys(user) {
+			items, _ := s.Query(ctx, key.PK, "")
+			if len(items) != 1 || items[0].SK != key.SK {
+				t.Errorf("expected %s keyed by its user, got %+v", key.PK, items)
+			}
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package autocomplete
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"athlete-forge/exercise"
+)
+
+// Cache keeps the index of the exercise catalog in the container, so that
+// suggesting names as the user types reads nothing from the store. The index is
+// rebuilt with the stored popularity once it is older than its TTL
+type Cache struct {
+	popularity *Repository
+	ttl        time.Duration
+
+	mu      sync.Mutex
+	index   *Index
+	builtAt time.Time
+}
+
+// NewCache creates a Cache ranking by the popularity in r, rebuilt every ttl
+func NewCache(r *Repository, ttl time.Duration) *Cache {
+	return &Cache{popularity: r, ttl: ttl}
+}
+
+// Index returns the index as of now, building it when it is missing or stale.
+// When rebuilding fails a stale index is kept rather than failing the request
+func (c *Cache) Index(ctx context.Context, now time.Time) (*Index, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.index != nil && now.Sub(c.builtAt) < c.ttl {
+		return c.index, nil
+	}
+
+	p, err := c.popularity.Get(ctx)
+	if err != nil {
+		if c.index != nil {
+			return c.index, nil
+		}
+		return nil, err
+	}
+	catalog := exercise.Search("")
+	names := make([]string, 0, len(catalog))
+	for _, e := range catalog {
+		names = append(names, e.Name)
+	}
+	c.index, c.builtAt = NewIndex(names, p.Counts), now
+	return c.index, nil
+}
+
+// Reset drops the index, so the next request builds it with fresh popularity
+func (c *Cache) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.index = nil
+}
@@ -0,0 +1,221 @@
+package autocomplete
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// minSimilarity is the least trigram similarity a name needs to be suggested
+// for a query none of its words start with, such as a misspelling
+const minSimilarity = 0.3
+
+// Kinds of match, best first
+const (
+	MatchPrefix     = "prefix"
+	MatchWordPrefix = "word-prefix"
+	MatchFuzzy      = "fuzzy"
+)
+
+// Suggestion is a name suggested for a query and how it matched
+type Suggestion struct {
+	Name  string `json:"name"`
+	Match string `json:"match"`
+}
+
+// entry is an indexed name with its popularity
+type entry struct {
+	name       string
+	key        string
+	trigrams   int
+	popularity int
+}
+
+// Index suggests names as the user types. It is built once from the names and
+// their popularity: every prefix of each name and of each of its words maps to
+// the names it starts, and every trigram to the names containing it, so a
+// query is answered with map lookups rather than a scan of the names
+type Index struct {
+	entries  []entry
+	prefixes map[string][]int
+	words    map[string][]int
+	trigrams map[string][]int
+}
+
+// NewIndex indexes names, ranking names that match equally well by popularity,
+// the higher the better; names missing from popularity count as 0
+func NewIndex(names []string, popularity map[string]int) *Index {
+	ix := &Index{prefixes: map[string][]int{}, words: map[string][]int{}, trigrams: map[string][]int{}}
+	for i, name := range names {
+		key := normalize(name)
+		grams := trigramsOf(key)
+		ix.entries = append(ix.entries, entry{name: name, key: key, trigrams: len(grams), popularity: popularity[name]})
+
+		for _, prefix := range prefixesOf(compact(key)) {
+			ix.prefixes[prefix] = append(ix.prefixes[prefix], i)
+		}
+		seen := map[string]bool{}
+		for _, word := range strings.Fields(key) {
+			for _, prefix := range prefixesOf(word) {
+				if !seen[prefix] {
+					seen[prefix] = true
+					ix.words[prefix] = append(ix.words[prefix], i)
+				}
+			}
+		}
+		for gram := range grams {
+			ix.trigrams[gram] = append(ix.trigrams[gram], i)
+		}
+	}
+	return ix
+}
+
+// Suggest returns up to limit names for query: names it starts, then names
+// with a word starting with each of its words, then names spelled like it,
+// each group most popular first. An empty query suggests the most popular names
+func (ix *Index) Suggest(query string, limit int) []Suggestion {
+	key := normalize(query)
+	type candidate struct {
+		index int
+		rank  int
+		score float64
+	}
+	candidates := map[int]candidate{}
+	consider := func(i, rank int, score float64) {
+		if c, ok := candidates[i]; !ok || rank < c.rank {
+			candidates[i] = candidate{index: i, rank: rank, score: score}
+		}
+	}
+
+	if key == "" {
+		for i := range ix.entries {
+			consider(i, 0, 0)
+		}
+	} else {
+		for _, i := range ix.prefixes[compact(key)] {
+			consider(i, 0, 0)
+		}
+		for _, i := range ix.wordMatches(strings.Fields(key)) {
+			consider(i, 1, 0)
+		}
+		for i, similarity := range ix.similar(key) {
+			if similarity >= minSimilarity {
+				consider(i, 2, similarity)
+			}
+		}
+	}
+
+	ranked := make([]candidate, 0, len(candidates))
+	for _, c := range candidates {
+		ranked = append(ranked, c)
+	}
+	sort.Slice(ranked, func(a, b int) bool {
+		x, y := ranked[a], ranked[b]
+		if x.rank != y.rank {
+			return x.rank < y.rank
+		}
+		if x.score != y.score {
+			return x.score > y.score
+		}
+		ex, ey := ix.entries[x.index], ix.entries[y.index]
+		if ex.popularity != ey.popularity {
+			return ex.popularity > ey.popularity
+		}
+		if len(ex.name) != len(ey.name) {
+			return len(ex.name) < len(ey.name)
+		}
+		return ex.name < ey.name
+	})
+
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	suggestions := make([]Suggestion, 0, len(ranked))
+	for _, c := range ranked {
+		match := []string{MatchPrefix, MatchWordPrefix, MatchFuzzy}[c.rank]
+		suggestions = append(suggestions, Suggestion{Name: ix.entries[c.index].name, Match: match})
+	}
+	return suggestions
+}
+
+// wordMatches returns the names with a word starting with each of words
+func (ix *Index) wordMatches(words []string) []int {
+	var matches []int
+	for n, word := range words {
+		found := ix.words[word]
+		if n == 0 {
+			matches = found
+			continue
+		}
+		kept := []int{}
+		for _, i := range matches {
+			if containsIndex(found, i) {
+				kept = append(kept, i)
+			}
+		}
+		matches = kept
+	}
+	return matches
+}
+
+// similar returns the Jaccard similarity of key's trigrams to each name
+// sharing one with it
+func (ix *Index) similar(key string) map[int]float64 {
+	grams := trigramsOf(key)
+	shared := map[int]int{}
+	for gram := range grams {
+		for _, i := range ix.trigrams[gram] {
+			shared[i]++
+		}
+	}
+	similarity := make(map[int]float64, len(shared))
+	for i, n := range shared {
+		similarity[i] = float64(n) / float64(len(grams)+ix.entries[i].trigrams-n)
+	}
+	return similarity
+}
+
+// normalize lower-cases s and reduces it to words of letters and digits, so
+// "Push-Up" and "push up" are the same
+func normalize(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// compact removes the spaces from a normalized key, so "pushup" starts "push up"
+func compact(key string) string {
+	return strings.ReplaceAll(key, " ", "")
+}
+
+// prefixesOf returns every non-empty prefix of s
+func prefixesOf(s string) []string {
+	runes := []rune(s)
+	prefixes := make([]string, 0, len(runes))
+	for n := 1; n <= len(runes); n++ {
+		prefixes = append(prefixes, string(runes[:n]))
+	}
+	return prefixes
+}
+
+// trigramsOf returns the trigrams of each word of key, padded so that short
+// words and word starts count
+func trigramsOf(key string) map[string]bool {
+	grams := map[string]bool{}
+	for _, word := range strings.Fields(key) {
+		runes := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			grams[string(runes[i:i+3])] = true
+		}
+	}
+	return grams
+}
+
+func containsIndex(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
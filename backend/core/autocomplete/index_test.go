@@ -0,0 +1,96 @@
+package autocomplete
+
+import (
+	"reflect"
+	"testing"
+)
+
+func names(suggestions []Suggestion) []string {
+	result := []string{}
+	for _, s := range suggestions {
+		result = append(result, s.Name)
+	}
+	return result
+}
+
+func TestIndex_Suggest(t *testing.T) {
+	catalog := []string{"squat", "back squat", "front squat", "goblet squat", "bench press", "dumbbell bench press", "push-up", "pull-up", "deadlift", "romanian deadlift"}
+	popularity := map[string]int{"back squat": 3, "front squat": 1, "bench press": 5, "deadlift": 2}
+	ix := NewIndex(catalog, popularity)
+
+	t.Run("suggests names the query starts before names with a word it starts", func(t *testing.T) {
+		// Act
+		suggestions := ix.Suggest("squ", 10)
+
+		// Assert
+		expected := []Suggestion{
+			{Name: "squat", Match: MatchPrefix},
+			{Name: "back squat", Match: MatchWordPrefix},
+			{Name: "front squat", Match: MatchWordPrefix},
+			{Name: "goblet squat", Match: MatchWordPrefix},
+		}
+		if !reflect.DeepEqual(suggestions, expected) {
+			t.Errorf("expected %+v, got %+v", expected, suggestions)
+		}
+	})
+
+	t.Run("ranks equal matches by popularity", func(t *testing.T) {
+		// Act
+		suggestions := ix.Suggest("b", 10)
+
+		// Assert
+		if got := names(suggestions); !reflect.DeepEqual(got[:2], []string{"bench press", "back squat"}) {
+			t.Errorf("expected the most popular first, got %v", got)
+		}
+	})
+
+	t.Run("matches every word of the query", func(t *testing.T) {
+		// Act
+		suggestions := ix.Suggest("bench dumb", 10)
+
+		// Assert
+		if len(suggestions) == 0 || suggestions[0] != (Suggestion{Name: "dumbbell bench press", Match: MatchWordPrefix}) {
+			t.Errorf("expected dumbbell bench press first, got %+v", suggestions)
+		}
+	})
+
+	t.Run("ignores case, hyphens and spacing", func(t *testing.T) {
+		// Act
+		suggestions := ix.Suggest("PushUp", 10)
+
+		// Assert
+		if len(suggestions) == 0 || suggestions[0] != (Suggestion{Name: "push-up", Match: MatchPrefix}) {
+			t.Errorf("expected push-up, got %+v", suggestions)
+		}
+	})
+
+	t.Run("suggests names spelled like a misspelled query", func(t *testing.T) {
+		// Act
+		suggestions := ix.Suggest("deadlfit", 10)
+
+		// Assert
+		if len(suggestions) == 0 || suggestions[0] != (Suggestion{Name: "deadlift", Match: MatchFuzzy}) {
+			t.Errorf("expected deadlift, got %+v", suggestions)
+		}
+	})
+
+	t.Run("suggests the most popular names for an empty query", func(t *testing.T) {
+		// Act
+		suggestions := ix.Suggest(" ", 2)
+
+		// Assert
+		if got := names(suggestions); !reflect.DeepEqual(got, []string{"bench press", "back squat"}) {
+			t.Errorf("expected the two most popular, got %v", got)
+		}
+	})
+
+	t.Run("suggests nothing for an unrelated query", func(t *testing.T) {
+		// Act
+		suggestions := ix.Suggest("zzz", 10)
+
+		// Assert
+		if len(suggestions) != 0 {
+			t.Errorf("expected no suggestions, got %+v", suggestions)
+		}
+	})
+}
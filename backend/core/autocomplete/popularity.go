@@ -0,0 +1,100 @@
+package autocomplete
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"athlete-forge/exercise"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+const (
+	popularityPK = "EXERCISE_POPULARITY"
+	popularitySK = "COUNTS"
+)
+
+// Window is how far back workouts count towards an exercise's popularity, so
+// the ranking follows what people train now
+const Window = 90 * 24 * time.Hour
+
+// Popularity is how many users trained each catalog exercise within Window of
+// UpdatedAt
+type Popularity struct {
+	Counts    map[string]int `json:"counts"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+}
+
+// Tally counts the users training each catalog exercise
+type Tally struct {
+	since  time.Time
+	counts map[string]int
+}
+
+// NewTally starts a count of the workouts started within Window of now
+func NewTally(now time.Time) *Tally {
+	return &Tally{since: now.Add(-Window), counts: map[string]int{}}
+}
+
+// Add counts one user's workouts, each catalog exercise at most once however
+// often they trained it, so a handful of keen users cannot set the ranking.
+// It reports whether any of them counted
+func (t *Tally) Add(workouts []workout.Workout) bool {
+	trained := map[string]bool{}
+	for _, w := range workouts {
+		if w.StartedAt.Before(t.since) {
+			continue
+		}
+		for _, e := range w.Exercises {
+			if known, ok := exercise.Lookup(e.Name); ok {
+				trained[known.Name] = true
+			}
+		}
+	}
+	for name := range trained {
+		t.counts[name]++
+	}
+	return len(trained) > 0
+}
+
+// Popularity returns the counts as of now
+func (t *Tally) Popularity(now time.Time) *Popularity {
+	return &Popularity{Counts: t.counts, UpdatedAt: now}
+}
+
+// Repository stores the exercise popularity in one item, read whole when an
+// index is built
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Get returns the stored popularity, with no counts before it is first computed
+func (r *Repository) Get(ctx context.Context) (*Popularity, error) {
+	var p Popularity
+	err := r.store.Get(ctx, popularityPK, popularitySK, &p)
+	if errors.Is(err, store.ErrNotFound) {
+		return &Popularity{Counts: map[string]int{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load exercise popularity: %w", err)
+	}
+	if p.Counts == nil {
+		p.Counts = map[string]int{}
+	}
+	return &p, nil
+}
+
+// Save replaces the stored popularity with p
+func (r *Repository) Save(ctx context.Context, p *Popularity) error {
+	if err := r.store.Put(ctx, popularityPK, popularitySK, p); err != nil {
+		return fmt.Errorf("failed to save exercise popularity: %w", err)
+	}
+	return nil
+}
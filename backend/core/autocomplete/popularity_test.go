@@ -0,0 +1,106 @@
+package autocomplete
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+func TestTally(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	trained := func(daysAgo int, exercises ...string) workout.Workout {
+		w := workout.Workout{StartedAt: now.AddDate(0, 0, -daysAgo)}
+		for _, name := range exercises {
+			w.Exercises = append(w.Exercises, workout.Exercise{Name: name})
+		}
+		return w
+	}
+
+	t.Run("counts each user once per recent catalog exercise", func(t *testing.T) {
+		// Arrange
+		tally := NewTally(now)
+
+		// Act
+		first := tally.Add([]workout.Workout{trained(1, "Squat", "Bench Press"), trained(3, "squat"), trained(120, "deadlift")})
+		second := tally.Add([]workout.Workout{trained(2, "squat", "my own lift")})
+		third := tally.Add([]workout.Workout{trained(200, "squat")})
+
+		// Assert
+		if !first || !second || third {
+			t.Errorf("expected only the users with recent lifts counted, got %v %v %v", first, second, third)
+		}
+		expected := map[string]int{"squat": 2, "bench press": 1}
+		if p := tally.Popularity(now); !reflect.DeepEqual(p.Counts, expected) || !p.UpdatedAt.Equal(now) {
+			t.Errorf("expected %v, got %+v", expected, p)
+		}
+	})
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("has no counts before the first ranking", func(t *testing.T) {
+		// Arrange
+		r := NewRepository(store.NewMemoryStore())
+
+		// Act
+		p, err := r.Get(ctx)
+
+		// Assert
+		if err != nil || p.Counts == nil || len(p.Counts) != 0 {
+			t.Errorf("expected empty counts, got %+v %v", p, err)
+		}
+	})
+
+	t.Run("returns the saved popularity", func(t *testing.T) {
+		// Arrange
+		r := NewRepository(store.NewMemoryStore())
+		saved := &Popularity{Counts: map[string]int{"squat": 4}, UpdatedAt: time.Now().UTC()}
+
+		// Act
+		r.Save(ctx, saved)
+		p, err := r.Get(ctx)
+
+		// Assert
+		if err != nil || p.Counts["squat"] != 4 {
+			t.Errorf("expected the saved counts, got %+v %v", p, err)
+		}
+	})
+}
+
+func TestCache_Index(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	t.Run("keeps the index until it is stale or reset", func(t *testing.T) {
+		// Arrange
+		r := NewRepository(store.NewMemoryStore())
+		c := NewCache(r, time.Minute)
+		first, _ := c.Index(ctx, now)
+		r.Save(ctx, &Popularity{Counts: map[string]int{"lunge": 9}, UpdatedAt: now})
+
+		// Act
+		cached, _ := c.Index(ctx, now.Add(30*time.Second))
+		stale, _ := c.Index(ctx, now.Add(2*time.Minute))
+		c.Reset()
+		reset, err := c.Index(ctx, now.Add(2*time.Minute))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cached != first {
+			t.Error("expected the index kept within its TTL")
+		}
+		if stale == first || reset == stale {
+			t.Error("expected the index rebuilt when stale and after a reset")
+		}
+		if top := stale.Suggest("", 1); len(top) != 1 || top[0].Name != "lunge" {
+			t.Errorf("expected the rebuilt index ranked by popularity, got %+v", top)
+		}
+	})
+}
@@ -0,0 +1,171 @@
+package awsapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"athlete-forge/errreport"
+)
+
+// Service describes an AWS service that speaks the JSON RPC protocol
+type Service struct {
+	Name         string
+	TargetPrefix string
+	JSONVersion  string
+}
+
+// DynamoDB is the DynamoDB JSON protocol descriptor
+var DynamoDB = Service{Name: "dynamodb", TargetPrefix: "DynamoDB_20120810", JSONVersion: "1.0"}
+
+// SSM is the Systems Manager JSON protocol descriptor
+var SSM = Service{Name: "ssm", TargetPrefix: "AmazonSSM", JSONVersion: "1.1"}
+
+// StepFunctions is the Step Functions JSON protocol descriptor
+var StepFunctions = Service{Name: "states", TargetPrefix: "AWSStepFunctions", JSONVersion: "1.0"}
+
+// APIError is returned when an AWS service responds with a non-2xx status
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("aws api error %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// Client performs signed calls against AWS service endpoints without the SDK
+type Client struct {
+	Region      string
+	Credentials Credentials
+	HTTPClient  *http.Client
+
+	// Endpoint resolves the base URL for a service; overridden in tests
+	Endpoint func(service string) string
+
+	now func() time.Time
+}
+
+// NewClientFromEnv creates a Client using the Lambda runtime's region and credentials
+func NewClientFromEnv() *Client {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = "eu-west-2"
+	}
+	return NewClient(region, CredentialsFromEnv())
+}
+
+// NewClient creates a Client for region with static credentials
+func NewClient(region string, creds Credentials) *Client {
+	return &Client{
+		Region:      region,
+		Credentials: creds,
+		HTTPClient:  &http.Client{Timeout: 10 * time.Second},
+		now:         time.Now,
+	}
+}
+
+// URL returns the base URL used for service in the client's region, so each
+// deployment of a multi-region pair talks to its own region's replicas
+func (c *Client) URL(service string) string {
+	if c.Endpoint != nil {
+		return c.Endpoint(service)
+	}
+	return fmt.Sprintf("https://%s.%s.%s", service, c.Region, dnsSuffix(c.Region))
+}
+
+// dnsSuffix returns the domain of the partition region belongs to
+func dnsSuffix(region string) string {
+	if strings.HasPrefix(region, "cn-") {
+		return "amazonaws.com.cn"
+	}
+	return "amazonaws.com"
+}
+
+// Call invokes a JSON protocol operation, e.g. Call(ctx, DynamoDB, "GetItem", in, &out)
+func (c *Client) Call(ctx context.Context, svc Service, operation string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s request: %w", operation, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL(svc.Name)+"/", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", operation, err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-"+svc.JSONVersion)
+	req.Header.Set("X-Amz-Target", svc.TargetPrefix+"."+operation)
+
+	respBody, err := c.Do(req, body, svc.Name)
+	if err != nil {
+		return err
+	}
+
+	if out != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("failed to unmarshal %s response: %w", operation, err)
+		}
+	}
+	return nil
+}
+
+// Do signs and sends req, returning the response body or an *APIError. Errors
+// carry the caller's stack, so a failure reported far up shows which call made it
+func (c *Client) Do(req *http.Request, body []byte, service string) ([]byte, error) {
+	SignRequest(req, body, c.Credentials, c.Region, service, c.now())
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, errreport.WithStack(fmt.Errorf("%s request failed: %w", service, err))
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errreport.WithStack(fmt.Errorf("failed to read %s response: %w", service, err))
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, errreport.WithStack(parseAPIError(resp.StatusCode, respBody))
+	}
+	return respBody, nil
+}
+
+// parseAPIError extracts the error code from a JSON protocol error body
+func parseAPIError(statusCode int, body []byte) *APIError {
+	var payload struct {
+		Type         string `json:"__type"`
+		Message      string `json:"message"`
+		MessageUpper string `json:"Message"`
+	}
+	apiErr := &APIError{StatusCode: statusCode, Message: string(body)}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return apiErr
+	}
+
+	code := payload.Type
+	if i := strings.LastIndex(code, "#"); i >= 0 {
+		code = code[i+1:]
+	}
+	apiErr.Code = code
+	if payload.Message != "" {
+		apiErr.Message = payload.Message
+	} else if payload.MessageUpper != "" {
+		apiErr.Message = payload.MessageUpper
+	}
+	return apiErr
+}
+
+// IsErrorCode reports whether err is an *APIError with the given code
+func IsErrorCode(err error, code string) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
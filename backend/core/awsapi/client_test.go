@@ -0,0 +1,91 @@
+package awsapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := NewClient("eu-west-2", testCredentials)
+	client.Endpoint = func(string) string { return server.URL }
+	return client
+}
+
+func TestClient_Call(t *testing.T) {
+	t.Run("sends target header and decodes response", func(t *testing.T) {
+		// Arrange
+		var gotTarget, gotContentType string
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			gotTarget = r.Header.Get("X-Amz-Target")
+			gotContentType = r.Header.Get("Content-Type")
+			json.NewEncoder(w).Encode(map[string]string{"TableName": "workouts"})
+		})
+
+		// Act
+		var out struct{ TableName string }
+		err := client.Call(context.Background(), DynamoDB, "DescribeTable", map[string]string{"TableName": "workouts"}, &out)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if gotTarget != "DynamoDB_20120810.DescribeTable" {
+			t.Errorf("unexpected X-Amz-Target %q", gotTarget)
+		}
+		if gotContentType != "application/x-amz-json-1.0" {
+			t.Errorf("unexpected Content-Type %q", gotContentType)
+		}
+		if out.TableName != "workouts" {
+			t.Errorf("expected decoded TableName, got %q", out.TableName)
+		}
+	})
+
+	t.Run("returns APIError with parsed code", func(t *testing.T) {
+		// Arrange
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ConditionalCheckFailedException","message":"The conditional request failed"}`))
+		})
+
+		// Act
+		err := client.Call(context.Background(), DynamoDB, "PutItem", map[string]string{}, nil)
+
+		// Assert
+		if !IsErrorCode(err, "ConditionalCheckFailedException") {
+			t.Fatalf("expected ConditionalCheckFailedException, got %v", err)
+		}
+	})
+}
+
+func TestClient_URL(t *testing.T) {
+	tests := []struct {
+		name   string
+		region string
+		want   string
+	}{
+		{name: "uses the client's region", region: "eu-west-1", want: "https://dynamodb.eu-west-1.amazonaws.com"},
+		{name: "uses the China partition's domain", region: "cn-north-1", want: "https://dynamodb.cn-north-1.amazonaws.com.cn"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			client := NewClient(tt.region, testCredentials)
+
+			// Act
+			got := client.URL("dynamodb")
+
+			// Assert
+			if got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package awsapi
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testCredentials = Credentials{
+	AccessKeyID:     "AKIDEXAMPLE",
+	SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+}
+
+func TestSignRequest(t *testing.T) {
+	t.Run("matches the AWS get-vanilla test vector", func(t *testing.T) {
+		// Arrange
+		req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+		signedAt := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+		// Act
+		SignRequest(req, nil, testCredentials, "us-east-1", "service", signedAt)
+
+		// Assert
+		expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+			"SignedHeaders=host;x-amz-date, " +
+			"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+		if got := req.Header.Get("Authorization"); got != expected {
+			t.Errorf("expected Authorization %q, got %q", expected, got)
+		}
+	})
+
+	t.Run("signs the session token when present", func(t *testing.T) {
+		// Arrange
+		req, _ := http.NewRequest(http.MethodPost, "https://dynamodb.eu-west-2.amazonaws.com/", nil)
+		creds := testCredentials
+		creds.SessionToken = "session-token"
+
+		// Act
+		SignRequest(req, []byte("{}"), creds, "eu-west-2", "dynamodb", time.Now())
+
+		// Assert
+		if req.Header.Get("X-Amz-Security-Token") != "session-token" {
+			t.Error("expected X-Amz-Security-Token header to be set")
+		}
+		if !strings.Contains(req.Header.Get("Authorization"), "x-amz-security-token") {
+			t.Error("expected session token to be included in signed headers")
+		}
+	})
+}
+
+func TestPresignURL(t *testing.T) {
+	t.Run("adds signature query parameters", func(t *testing.T) {
+		// Arrange
+		signedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		// Act
+		signed, err := PresignURL("https://bucket.s3.eu-west-2.amazonaws.com/reports/a b.pdf", testCredentials, "eu-west-2", "s3", signedAt, 15*time.Minute)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		u, err := url.Parse(signed)
+		if err != nil {
+			t.Fatalf("presigned URL does not parse: %v", err)
+		}
+		query := u.Query()
+		if query.Get("X-Amz-Expires") != "900" {
+			t.Errorf("expected X-Amz-Expires 900, got %q", query.Get("X-Amz-Expires"))
+		}
+		if query.Get("X-Amz-Date") != "20240102T030405Z" {
+			t.Errorf("unexpected X-Amz-Date %q", query.Get("X-Amz-Date"))
+		}
+		if len(query.Get("X-Amz-Signature")) != 64 {
+			t.Errorf("expected hex signature, got %q", query.Get("X-Amz-Signature"))
+		}
+	})
+}
+
+func TestURIEncode(t *testing.T) {
+	tests := map[string]string{
+		"abc-_.~":  "abc-_.~",
+		"a b":      "a%20b",
+		"a/b":      "a%2Fb",
+		"key=val+": "key%3Dval%2B",
+	}
+	for input, expected := range tests {
+		if got := uriEncode(input); got != expected {
+			t.Errorf("uriEncode(%q) expected %q, got %q", input, expected, got)
+		}
+	}
+}
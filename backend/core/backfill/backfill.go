@@ -0,0 +1,405 @@
+package backfill
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+const (
+	backfillSKPrefix = "BACKFILL#"
+
+	// chunkSKPrefix keys uploaded chunks apart from their backfill, so chunks
+	// uploaded in parallel do not overwrite each other's record of the upload
+	chunkSKPrefix = "BACKFILLCHUNK#"
+
+	// MaxChunks is the most chunks one backfill takes
+	MaxChunks = 1000
+
+	// MaxChunkRecords is the most workouts one chunk carries, keeping uploads
+	// well inside the request size limit and each chunk quick to write
+	MaxChunkRecords = 500
+
+	// maxSourceLength bounds the name of the platform history comes from
+	maxSourceLength = 64
+
+	// maxRejections and maxDiscrepancies bound the problems listed, so a bad
+	// export cannot grow responses and the stored report without limit; counts
+	// still cover every problem
+	maxRejections    = 50
+	maxDiscrepancies = 50
+)
+
+// Backfill statuses. A backfill takes chunks while uploading and is written by
+// a background job once committed
+const (
+	StatusUploading = "uploading"
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// Discrepancy problems
+const (
+	ProblemMissing    = "missing"
+	ProblemMismatched = "mismatched"
+)
+
+// Record is one completed workout from another platform; SourceID is its ID
+// there, which keeps it from being imported twice
+type Record struct {
+	SourceID string `json:"sourceId"`
+	workout.Workout
+}
+
+// Rejection is a record left out of a chunk and why
+type Rejection struct {
+	Index    int    `json:"index"`
+	SourceID string `json:"sourceId,omitempty"`
+	Error    string `json:"error"`
+}
+
+// Chunk is an uploaded part of a backfill: how many records it carried and
+// how many were accepted
+type Chunk struct {
+	Number     int       `json:"number"`
+	Records    int       `json:"records"`
+	Accepted   int       `json:"accepted"`
+	UploadedAt time.Time `json:"uploadedAt"`
+}
+
+// Discrepancy is a written workout that did not read back as written
+type Discrepancy struct {
+	WorkoutID string `json:"workoutId"`
+	Chunk     int    `json:"chunk"`
+	Problem   string `json:"problem"`
+}
+
+// Report accounts for every record of a backfill: those rejected on upload,
+// those written, and those read back unchanged afterwards. The backfill is
+// consistent when every accepted record was written and verified
+type Report struct {
+	Records       int           `json:"records"`
+	Rejected      int           `json:"rejected"`
+	Written       int           `json:"written"`
+	Verified      int           `json:"verified"`
+	Missing       int           `json:"missing"`
+	Mismatched    int           `json:"mismatched"`
+	Consistent    bool          `json:"consistent"`
+	Discrepancies []Discrepancy `json:"discrepancies,omitempty"`
+}
+
+// Discrepant records a workout that did not read back as written
+func (r *Report) Discrepant(d Discrepancy) {
+	if d.Problem == ProblemMissing {
+		r.Missing++
+	} else {
+		r.Mismatched++
+	}
+	if len(r.Discrepancies) < maxDiscrepancies {
+		r.Discrepancies = append(r.Discrepancies, d)
+	}
+}
+
+// Backfill imports a user's workout history from another platform in one
+// operation. The history is uploaded in numbered chunks, each validated as it
+// arrives, then committed and written by a background job that reports how
+// consistent the result is. Chunks lists the chunks committed, Total counts
+// their accepted workouts and Processed the chunks written
+type Backfill struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"userId"`
+	Source      string     `json:"source"`
+	Status      string     `json:"status"`
+	Chunks      []Chunk    `json:"chunks"`
+	JobID       string     `json:"jobId,omitempty"`
+	Total       int        `json:"total"`
+	Processed   int        `json:"processed"`
+	Progress    int        `json:"progress"`
+	Report      *Report    `json:"report,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// Validate checks the backfill names its source
+func (b *Backfill) Validate() error {
+	source := strings.TrimSpace(b.Source)
+	if source == "" || len(source) > maxSourceLength {
+		return fmt.Errorf("source is required and must be at most %d characters", maxSourceLength)
+	}
+	switch b.Status {
+	case StatusUploading, StatusPending, StatusRunning, StatusCompleted, StatusFailed:
+	default:
+		return fmt.Errorf("unknown status %q", b.Status)
+	}
+	return nil
+}
+
+// Commit readies an uploaded backfill to write chunks, starting its report
+// with the records they carried
+func (b *Backfill) Commit(chunks []Chunk) error {
+	report := Report{}
+	for _, c := range chunks {
+		report.Records += c.Records
+		report.Rejected += c.Records - c.Accepted
+	}
+	if report.Records == report.Rejected {
+		return errors.New("upload at least one chunk with valid workouts before committing")
+	}
+	b.Status = StatusPending
+	b.Chunks = chunks
+	b.Total = report.Records - report.Rejected
+	b.Report = &report
+	return nil
+}
+
+// Advance records another written chunk and updates the progress percentage
+func (b *Backfill) Advance() {
+	b.Processed++
+	if len(b.Chunks) > 0 {
+		b.Progress = b.Processed * 100 / len(b.Chunks)
+	}
+}
+
+// Finish marks the backfill completed, judging whether it is consistent
+func (b *Backfill) Finish(now time.Time) {
+	r := b.Report
+	r.Consistent = r.Written == b.Total && r.Verified == r.Written
+	b.Status = StatusCompleted
+	b.Progress = 100
+	b.CompletedAt = &now
+}
+
+// Accept validates a chunk of records for userID's backfill from source,
+// returning the workouts to write and the first maxRejections records left
+// out. Only completed workouts that finished by now are accepted, and not
+// ones referring to this app's programs or gyms, which another platform
+// cannot know
+func Accept(userID, source string, records []Record, now time.Time) ([]workout.Workout, []Rejection) {
+	var accepted []workout.Workout
+	var rejections []Rejection
+	reject := func(i int, sourceID string, err error) {
+		if len(rejections) < maxRejections {
+			rejections = append(rejections, Rejection{Index: i, SourceID: sourceID, Error: err.Error()})
+		}
+	}
+
+	seen := make(map[string]bool, len(records))
+	for i, record := range records {
+		sourceID := strings.TrimSpace(record.SourceID)
+		if sourceID == "" {
+			reject(i, "", errors.New("sourceId is required"))
+			continue
+		}
+		if seen[sourceID] {
+			reject(i, sourceID, errors.New("sourceId appears more than once in the chunk"))
+			continue
+		}
+		seen[sourceID] = true
+
+		w, err := prepare(userID, source, sourceID, record.Workout, now)
+		if err != nil {
+			reject(i, sourceID, err)
+			continue
+		}
+		accepted = append(accepted, w)
+	}
+	return accepted, rejections
+}
+
+// prepare checks w is completed history and assigns it its user and ID
+func prepare(userID, source, sourceID string, w workout.Workout, now time.Time) (workout.Workout, error) {
+	if w.Status == "" {
+		w.Status = workout.StatusCompleted
+	}
+	switch {
+	case w.Status != workout.StatusCompleted:
+		return w, fmt.Errorf("status must be %q", workout.StatusCompleted)
+	case w.StartedAt.IsZero() || w.CompletedAt == nil:
+		return w, errors.New("startedAt and completedAt are required")
+	case w.CompletedAt.Before(w.StartedAt):
+		return w, errors.New("completedAt must not be before startedAt")
+	case w.CompletedAt.After(now):
+		return w, errors.New("completedAt must not be in the future")
+	case w.ProgramID != "" || w.GymID != "":
+		return w, errors.New("programId and gymId cannot be backfilled")
+	}
+	if err := w.Validate(); err != nil {
+		return w, err
+	}
+
+	w.ID = WorkoutID(source, sourceID, w.StartedAt)
+	w.UserID = userID
+	w.StartedAt = w.StartedAt.UTC()
+	completedAt := w.CompletedAt.UTC()
+	w.CompletedAt = &completedAt
+	w.ScheduledAt, w.AutoFinishedAt, w.ClientStartedAt, w.ClientCompletedAt = nil, nil, nil, nil
+	return w, nil
+}
+
+// Unchanged reports whether stored reads back as the written workout
+func Unchanged(written, stored workout.Workout) bool {
+	a, err := json.Marshal(written)
+	if err != nil {
+		return false
+	}
+	b, err := json.Marshal(stored)
+	return err == nil && bytes.Equal(a, b)
+}
+
+// WorkoutID returns the ID of the workout backfilled from sourceID on source,
+// which sorts by when it started like IDs from store.NewIDAt. It is derived
+// rather than random, so backfilling the same history again, or retrying a
+// chunk, rewrites the workouts instead of duplicating them
+func WorkoutID(source, sourceID string, startedAt time.Time) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(source)) + "\x00" + sourceID))
+	return fmt.Sprintf("%012x%s", startedAt.UnixMilli(), hex.EncodeToString(sum[:5]))
+}
+
+// ChunkKey returns the blob key an accepted chunk is kept at until it is
+// written; keys start with the kind and user, so the chunk stays in the user's
+// region
+func ChunkKey(userID, id string, number int) string {
+	return fmt.Sprintf("backfills/%s/%s/%d.json", userID, id, number)
+}
+
+// Pacer spaces writes to a steady rate, so a backfill leaves the table's
+// capacity for users' requests rather than being throttled alongside them
+type Pacer struct {
+	interval time.Duration
+	next     time.Time
+	now      func() time.Time
+	sleep    func(ctx context.Context, d time.Duration) error
+}
+
+// NewPacer creates a Pacer allowing perSecond writes a second, or any number
+// when perSecond is not positive
+func NewPacer(perSecond int) *Pacer {
+	p := &Pacer{now: time.Now, sleep: sleep}
+	if perSecond > 0 {
+		p.interval = time.Second / time.Duration(perSecond)
+	}
+	return p
+}
+
+// Wait blocks until n more writes fit within the rate
+func (p *Pacer) Wait(ctx context.Context, n int) error {
+	if p.interval == 0 {
+		return nil
+	}
+	now := p.now()
+	if p.next.After(now) {
+		if err := p.sleep(ctx, p.next.Sub(now)); err != nil {
+			return err
+		}
+		now = p.next
+	}
+	p.next = now.Add(time.Duration(n) * p.interval)
+	return nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Repository loads and saves backfills
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Get returns the backfill with id owned by userID
+func (r *Repository) Get(ctx context.Context, userID, id string) (*Backfill, error) {
+	var b Backfill
+	if err := r.store.Get(ctx, store.UserPK(userID), backfillSKPrefix+id, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// List returns all of userID's backfills
+func (r *Repository) List(ctx context.Context, userID string) ([]Backfill, error) {
+	items, err := r.store.Query(ctx, store.UserPK(userID), backfillSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backfills: %w", err)
+	}
+
+	backfills := make([]Backfill, 0, len(items))
+	for _, item := range items {
+		var b Backfill
+		if err := item.Decode(&b); err != nil {
+			return nil, err
+		}
+		backfills = append(backfills, b)
+	}
+	return backfills, nil
+}
+
+// Save validates and stores b, assigning an ID to new backfills
+func (r *Repository) Save(ctx context.Context, b *Backfill) error {
+	if err := b.Validate(); err != nil {
+		return err
+	}
+	if b.ID == "" {
+		b.ID = store.NewID()
+	}
+	if err := r.store.Put(ctx, store.UserPK(b.UserID), backfillSKPrefix+b.ID, b); err != nil {
+		return fmt.Errorf("failed to save backfill: %w", err)
+	}
+	return nil
+}
+
+// SaveChunk records a chunk uploaded to userID's backfill id, replacing an
+// earlier upload of the same number
+func (r *Repository) SaveChunk(ctx context.Context, userID, id string, c Chunk) error {
+	if err := r.store.Put(ctx, store.UserPK(userID), chunkSK(id, c.Number), c); err != nil {
+		return fmt.Errorf("failed to save backfill chunk: %w", err)
+	}
+	return nil
+}
+
+// Chunks returns the chunks uploaded to userID's backfill id in order
+func (r *Repository) Chunks(ctx context.Context, userID, id string) ([]Chunk, error) {
+	items, err := r.store.Query(ctx, store.UserPK(userID), chunkSKPrefix+id+"#")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backfill chunks: %w", err)
+	}
+
+	chunks := make([]Chunk, 0, len(items))
+	for _, item := range items {
+		var c Chunk
+		if err := item.Decode(&c); err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, c)
+	}
+	return chunks, nil
+}
+
+// chunkSK pads the chunk number, so chunks sort in number order
+func chunkSK(id string, number int) string {
+	return fmt.Sprintf("%s%s#%04d", chunkSKPrefix, id, number)
+}
@@ -0,0 +1,170 @@
+package backfill
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+func TestAccept(t *testing.T) {
+	now := time.Date(2026, 5, 4, 18, 0, 0, 0, time.UTC)
+	record := func(sourceID string, startedAt time.Time) Record {
+		completedAt := startedAt.Add(time.Hour)
+		return Record{SourceID: sourceID, Workout: workout.Workout{
+			StartedAt:   startedAt,
+			CompletedAt: &completedAt,
+			Exercises:   []workout.Exercise{{Name: "Squat", Sets: []workout.Set{{Reps: 5, Weight: 100}}}},
+		}}
+	}
+
+	t.Run("accepts completed history with derived IDs", func(t *testing.T) {
+		// Arrange
+		started := time.Date(2021, 3, 1, 7, 0, 0, 0, time.FixedZone("EST", -5*3600))
+
+		// Act
+		accepted, rejections := Accept("user-1", "OtherApp", []Record{record("a-1", started)}, now)
+
+		// Assert
+		if len(accepted) != 1 || len(rejections) != 0 {
+			t.Fatalf("expected the record accepted, got %+v %+v", accepted, rejections)
+		}
+		w := accepted[0]
+		if w.UserID != "user-1" || w.Status != workout.StatusCompleted || w.StartedAt.Location() != time.UTC {
+			t.Errorf("unexpected workout: %+v", w)
+		}
+		if at, ok := store.IDTime(w.ID); !ok || !at.Equal(started) || w.ID != WorkoutID("otherapp", "a-1", started) {
+			t.Errorf("expected an ID derived from the source and start, got %s", w.ID)
+		}
+	})
+
+	t.Run("rejects records that are not completed history", func(t *testing.T) {
+		// Arrange
+		started := now.Add(-48 * time.Hour)
+		planned := record("a-2", started)
+		planned.Status = workout.StatusPlanned
+		unfinished := record("a-3", started)
+		unfinished.CompletedAt = nil
+		future := record("a-4", now.Add(-time.Minute))
+		program := record("a-5", started)
+		program.ProgramID = "p-1"
+		inverted := record("a-6", started)
+		early := started.Add(-time.Hour)
+		inverted.CompletedAt = &early
+
+		// Act
+		accepted, rejections := Accept("user-1", "OtherApp", []Record{
+			record("", started), record("a-1", started), record("a-1", started), planned, unfinished, future, program, inverted,
+		}, now)
+
+		// Assert
+		if len(accepted) != 1 || len(rejections) != 7 {
+			t.Fatalf("expected one record accepted, got %+v %+v", accepted, rejections)
+		}
+		if rejections[0].Index != 0 || rejections[1].Index != 2 || rejections[1].SourceID != "a-1" {
+			t.Errorf("unexpected rejections: %+v", rejections)
+		}
+	})
+}
+
+func TestBackfill_Commit(t *testing.T) {
+	t.Run("reports the records uploaded in re-sent chunks once", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		repo := NewRepository(store.NewMemoryStore())
+		b := Backfill{UserID: "user-1", Source: "OtherApp", Status: StatusUploading}
+		repo.Save(ctx, &b)
+		repo.SaveChunk(ctx, "user-1", b.ID, Chunk{Number: 10, Records: 10, Accepted: 10})
+		repo.SaveChunk(ctx, "user-1", b.ID, Chunk{Number: 2, Records: 10, Accepted: 4})
+		repo.SaveChunk(ctx, "user-1", b.ID, Chunk{Number: 2, Records: 10, Accepted: 9})
+		chunks, _ := repo.Chunks(ctx, "user-1", b.ID)
+
+		// Act
+		err := b.Commit(chunks)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(b.Chunks) != 2 || b.Chunks[0].Number != 2 || b.Status != StatusPending {
+			t.Errorf("unexpected chunks: %+v", b.Chunks)
+		}
+		if backfills, _ := repo.List(ctx, "user-1"); len(backfills) != 1 {
+			t.Errorf("expected chunks kept apart from backfills, got %+v", backfills)
+		}
+		if b.Total != 19 || b.Report.Records != 20 || b.Report.Rejected != 1 {
+			t.Errorf("unexpected report: total %d %+v", b.Total, b.Report)
+		}
+	})
+
+	t.Run("requires valid workouts", func(t *testing.T) {
+		// Arrange
+		b := Backfill{Status: StatusUploading}
+
+		// Act
+		err := b.Commit([]Chunk{{Number: 1, Records: 3}})
+
+		// Assert
+		if err == nil || b.Status != StatusUploading {
+			t.Errorf("expected an error, got %v", err)
+		}
+	})
+
+	t.Run("is consistent only when every workout reads back", func(t *testing.T) {
+		// Arrange
+		b := Backfill{Status: StatusRunning, Total: 3, Report: &Report{Written: 3, Verified: 2}}
+		b.Report.Discrepant(Discrepancy{WorkoutID: "w-1", Problem: ProblemMissing})
+
+		// Act
+		b.Finish(time.Now())
+
+		// Assert
+		if b.Report.Consistent || b.Report.Missing != 1 || b.Status != StatusCompleted {
+			t.Errorf("unexpected report: %+v", b.Report)
+		}
+	})
+}
+
+func TestPacer(t *testing.T) {
+	t.Run("spaces batches to the rate", func(t *testing.T) {
+		// Arrange
+		clock := time.Date(2026, 5, 4, 18, 0, 0, 0, time.UTC)
+		var slept []time.Duration
+		p := NewPacer(50)
+		p.now = func() time.Time { return clock }
+		p.sleep = func(ctx context.Context, d time.Duration) error {
+			slept = append(slept, d)
+			clock = clock.Add(d)
+			return nil
+		}
+
+		// Act
+		p.Wait(context.Background(), 25)
+		p.Wait(context.Background(), 25)
+		p.Wait(context.Background(), 10)
+
+		// Assert
+		if len(slept) != 2 || slept[0] != 500*time.Millisecond || slept[1] != 500*time.Millisecond {
+			t.Errorf("unexpected waits: %v", slept)
+		}
+	})
+
+	t.Run("does not wait without a rate", func(t *testing.T) {
+		// Arrange
+		p := NewPacer(0)
+		p.sleep = func(context.Context, time.Duration) error {
+			t.Error("unexpected wait")
+			return nil
+		}
+
+		// Act
+		err := p.Wait(context.Background(), 1000)
+
+		// Assert
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,22 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned when no object exists at a key
+var ErrNotFound = errors.New("object not found")
+
+// Store saves files and hands out time-limited download links
+type Store interface {
+	// Put writes data at key, replacing any existing object
+	Put(ctx context.Context, key, contentType string, data []byte) error
+
+	// Read returns the data at key, or ErrNotFound
+	Read(ctx context.Context, key string) ([]byte, error)
+
+	// URL returns a link that downloads key until expires has elapsed
+	URL(ctx context.Context, key string, expires time.Duration) (string, error)
+}
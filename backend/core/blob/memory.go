@@ -0,0 +1,59 @@
+package blob
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Object is a stored file
+type Object struct {
+	ContentType string
+	Data        []byte
+}
+
+// MemoryStore is an in-process Store used for tests and local development; its
+// URLs use a memory:// scheme and cannot be downloaded
+type MemoryStore struct {
+	mu      sync.RWMutex
+	objects map[string]Object
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{objects: make(map[string]Object)}
+}
+
+// Put stores a copy of data at key
+func (m *MemoryStore) Put(ctx context.Context, key, contentType string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = Object{ContentType: contentType, Data: append([]byte(nil), data...)}
+	return nil
+}
+
+// Read returns a copy of the data at key
+func (m *MemoryStore) Read(ctx context.Context, key string) ([]byte, error) {
+	obj, ok := m.Get(key)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), obj.Data...), nil
+}
+
+// URL returns a memory:// link for key
+func (m *MemoryStore) URL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	if _, ok := m.Get(key); !ok {
+		return "", ErrNotFound
+	}
+	return (&url.URL{Scheme: "memory", Path: "/" + key}).String(), nil
+}
+
+// Get returns the object at key
+func (m *MemoryStore) Get(key string) (Object, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	obj, ok := m.objects[key]
+	return obj, ok
+}
@@ -0,0 +1,92 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"athlete-forge/awsapi"
+)
+
+// S3Store stores objects in an S3 bucket and presigns GET URLs for downloads
+type S3Store struct {
+	client *awsapi.Client
+	bucket string
+	now    func() time.Time
+}
+
+// NewS3Store creates an S3Store for bucket
+func NewS3Store(client *awsapi.Client, bucket string) *S3Store {
+	return &S3Store{client: client, bucket: bucket, now: time.Now}
+}
+
+// objectURL returns the virtual-hosted-style URL for key
+func (s *S3Store) objectURL(key string) string {
+	base := fmt.Sprintf("https://%s.s3.%s.amazonaws.com", s.bucket, s.client.Region)
+	if s.client.Endpoint != nil {
+		base = s.client.URL("s3") + "/" + s.bucket
+	}
+
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = pathEscape(segment)
+	}
+	return base + "/" + strings.Join(segments, "/")
+}
+
+// Put uploads data with PutObject
+func (s *S3Store) Put(ctx context.Context, key, contentType string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create PutObject request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.ContentLength = int64(len(data))
+
+	if _, err := s.client.Do(req, data, "s3"); err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	return nil
+}
+
+// Read downloads key with GetObject
+func (s *S3Store) Read(ctx context.Context, key string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GetObject request: %w", err)
+	}
+
+	data, err := s.client.Do(req, nil, "s3")
+	var apiErr *awsapi.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	return data, nil
+}
+
+// URL presigns a GET for key
+func (s *S3Store) URL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	return awsapi.PresignURL(s.objectURL(key), s.client.Credentials, s.client.Region, "s3", s.now(), expires)
+}
+
+// pathEscape encodes a key segment the way S3 expects, keeping unreserved characters
+func pathEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
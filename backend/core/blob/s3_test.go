@@ -0,0 +1,98 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"athlete-forge/awsapi"
+)
+
+func TestS3Store(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("uploads with a signed PutObject", func(t *testing.T) {
+		// Arrange
+		var method, path, contentType, payloadHash, body string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			method, path = r.Method, r.URL.EscapedPath()
+			contentType = r.Header.Get("Content-Type")
+			payloadHash = r.Header.Get("X-Amz-Content-Sha256")
+			data, _ := io.ReadAll(r.Body)
+			body = string(data)
+		}))
+		defer server.Close()
+		client := awsapi.NewClient("eu-west-2", awsapi.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"})
+		client.Endpoint = func(string) string { return server.URL }
+
+		// Act
+		err := NewS3Store(client, "reports").Put(ctx, "exports/user 1/report.pdf", "application/pdf", []byte("%PDF"))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if method != http.MethodPut || path != "/reports/exports/user%201/report.pdf" || contentType != "application/pdf" || body != "%PDF" {
+			t.Errorf("unexpected request: %s %s %s %q", method, path, contentType, body)
+		}
+		if payloadHash == "" {
+			t.Error("expected payload hash header")
+		}
+	})
+
+	t.Run("downloads with GetObject and reports missing objects", func(t *testing.T) {
+		// Arrange
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet || r.URL.Path != "/reports/backfills/chunk.json" {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`<Error><Code>NoSuchKey</Code><Message>missing</Message></Error>`))
+				return
+			}
+			w.Write([]byte(`{"workouts":[]}`))
+		}))
+		defer server.Close()
+		client := awsapi.NewClient("eu-west-2", awsapi.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"})
+		client.Endpoint = func(string) string { return server.URL }
+		s := NewS3Store(client, "reports")
+
+		// Act
+		data, err := s.Read(ctx, "backfills/chunk.json")
+		_, missingErr := s.Read(ctx, "backfills/other.json")
+
+		// Assert
+		if err != nil || string(data) != `{"workouts":[]}` {
+			t.Errorf("unexpected read %q: %v", data, err)
+		}
+		if !errors.Is(missingErr, ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", missingErr)
+		}
+	})
+
+	t.Run("presigns download links", func(t *testing.T) {
+		// Arrange
+		client := awsapi.NewClient("eu-west-2", awsapi.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"})
+		s := NewS3Store(client, "reports")
+		s.now = func() time.Time { return time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC) }
+
+		// Act
+		link, err := s.URL(ctx, "exports/report.pdf", time.Hour)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		u, _ := url.Parse(link)
+		if u.Host != "reports.s3.eu-west-2.amazonaws.com" || u.Path != "/exports/report.pdf" {
+			t.Errorf("unexpected URL %s", link)
+		}
+		if u.Query().Get("X-Amz-Expires") != "3600" || !strings.HasPrefix(u.Query().Get("X-Amz-Credential"), "AKID/20240304/eu-west-2/s3/") {
+			t.Errorf("unexpected presign query %s", u.RawQuery)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package bodyweight
+
+import (
+	"sort"
+	"time"
+
+	"athlete-forge/dailylog"
+	"athlete-forge/exercise"
+	"athlete-forge/workout"
+)
+
+// weighIn is a bodyweight logged on a day
+type weighIn struct {
+	date   string
+	weight float64
+}
+
+// History is a user's logged bodyweights, used to find what they weighed on
+// the day of a past session
+type History struct {
+	weighIns []weighIn
+	current  float64
+}
+
+// NewHistory builds a History from daily logs. current, such as the profile's
+// bodyweight, is used when no bodyweight has been logged
+func NewHistory(logs []dailylog.Log, current float64) History {
+	h := History{current: current}
+	for _, l := range logs {
+		if l.Bodyweight != nil && *l.Bodyweight > 0 {
+			h.weighIns = append(h.weighIns, weighIn{date: l.Date, weight: *l.Bodyweight})
+		}
+	}
+	sort.Slice(h.weighIns, func(i, j int) bool { return h.weighIns[i].date < h.weighIns[j].date })
+	return h
+}
+
+// On returns the bodyweight on t's UTC day: the latest logged on or before it,
+// else the earliest logged after it, else the current bodyweight. 0 means the
+// bodyweight is not known
+func (h History) On(t time.Time) float64 {
+	if len(h.weighIns) == 0 {
+		return h.current
+	}
+	date := t.UTC().Format(dailylog.DateLayout)
+	i := sort.Search(len(h.weighIns), func(i int) bool { return h.weighIns[i].date > date })
+	if i == 0 {
+		return h.weighIns[0].weight
+	}
+	return h.weighIns[i-1].weight
+}
+
+// Load returns workouts with the sets of bodyweight exercises weighing the
+// bodyweight on the day of the workout plus the weight logged as added, so
+// volume and one-rep max estimates count the lifter's own weight. Workouts
+// whose day's bodyweight is not known are returned as logged, and workouts is
+// not modified
+func Load(workouts []workout.Workout, h History) []workout.Workout {
+	loaded := make([]workout.Workout, len(workouts))
+	copy(loaded, workouts)
+	for i, w := range loaded {
+		at := w.StartedAt
+		if w.CompletedAt != nil {
+			at = *w.CompletedAt
+		}
+		weight := h.On(at)
+		if weight <= 0 {
+			continue
+		}
+
+		var exercises []workout.Exercise
+		for j, e := range w.Exercises {
+			known, ok := exercise.Lookup(e.Name)
+			if !ok || !known.Bodyweight() {
+				continue
+			}
+			if exercises == nil {
+				exercises = append([]workout.Exercise(nil), w.Exercises...)
+			}
+			sets := make([]workout.Set, len(e.Sets))
+			for k, set := range e.Sets {
+				if set.Type == "" || set.Type == workout.SetReps {
+					set.Weight += weight
+				}
+				sets[k] = set
+			}
+			exercises[j].Sets = sets
+		}
+		if exercises != nil {
+			loaded[i].Exercises = exercises
+		}
+	}
+	return loaded
+}
@@ -0,0 +1,100 @@
+package bodyweight
+
+import (
+	"testing"
+	"time"
+
+	"athlete-forge/dailylog"
+	"athlete-forge/workout"
+)
+
+func weight(v float64) *float64 { return &v }
+
+func TestHistory_On(t *testing.T) {
+	// Arrange
+	h := NewHistory([]dailylog.Log{
+		{Date: "2024-03-10", Bodyweight: weight(82)},
+		{Date: "2024-03-01", Bodyweight: weight(80)},
+		{Date: "2024-03-05", WaterML: new(int)},
+	}, 85)
+
+	cases := map[string]float64{
+		"2024-02-20": 80,
+		"2024-03-01": 80,
+		"2024-03-09": 80,
+		"2024-03-10": 82,
+		"2024-06-01": 82,
+	}
+	for date, want := range cases {
+		// Act
+		day, _ := time.Parse(dailylog.DateLayout, date)
+		got := h.On(day.Add(18 * time.Hour))
+
+		// Assert
+		if got != want {
+			t.Errorf("%s: expected %v, got %v", date, want, got)
+		}
+	}
+
+	t.Run("falls back to the current bodyweight when none is logged", func(t *testing.T) {
+		// Act
+		got := NewHistory(nil, 85).On(time.Now())
+
+		// Assert
+		if got != 85 {
+			t.Errorf("expected 85, got %v", got)
+		}
+	})
+}
+
+func TestLoad(t *testing.T) {
+	march := time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)
+	june := time.Date(2024, 6, 2, 10, 0, 0, 0, time.UTC)
+	h := NewHistory([]dailylog.Log{{Date: "2024-03-01", Bodyweight: weight(80)}, {Date: "2024-06-01", Bodyweight: weight(78)}}, 0)
+
+	t.Run("adds the bodyweight on the day to bodyweight exercises", func(t *testing.T) {
+		// Arrange
+		workouts := []workout.Workout{
+			{ID: "w1", Status: workout.StatusCompleted, CompletedAt: &march, Exercises: []workout.Exercise{
+				{Name: "Pull-Up", Sets: []workout.Set{{Reps: 5, Weight: 10}, {Reps: 8, Assistance: 20}}},
+				{Name: "Bench Press", Sets: []workout.Set{{Reps: 5, Weight: 100}}},
+			}},
+			{ID: "w2", Status: workout.StatusCompleted, CompletedAt: &june, Exercises: []workout.Exercise{
+				{Name: "push-up", Sets: []workout.Set{{Reps: 20}}},
+			}},
+		}
+
+		// Act
+		loaded := Load(workouts, h)
+
+		// Assert
+		pullUps := loaded[0].Exercises[0].Sets
+		if pullUps[0].Weight != 90 || pullUps[1].Weight != 80 || pullUps[1].Volume() != 480 {
+			t.Errorf("unexpected pull-ups: %+v", pullUps)
+		}
+		if loaded[0].Exercises[1].Sets[0].Weight != 100 {
+			t.Errorf("expected the bench press as logged, got %+v", loaded[0].Exercises[1])
+		}
+		if loaded[1].Exercises[0].Sets[0].Weight != 78 {
+			t.Errorf("expected June's bodyweight, got %+v", loaded[1].Exercises[0])
+		}
+		if workouts[0].Exercises[0].Sets[0].Weight != 10 {
+			t.Error("expected the workouts not to be modified")
+		}
+	})
+
+	t.Run("leaves workouts as logged when bodyweight is not known", func(t *testing.T) {
+		// Arrange
+		workouts := []workout.Workout{{ID: "w1", StartedAt: march, Exercises: []workout.Exercise{
+			{Name: "Chin-Up", Sets: []workout.Set{{Reps: 5, Weight: 10}}},
+		}}}
+
+		// Act
+		loaded := Load(workouts, NewHistory(nil, 0))
+
+		// Assert
+		if loaded[0].Exercises[0].Sets[0].Weight != 10 {
+			t.Errorf("expected the chin-ups as logged, got %+v", loaded[0].Exercises[0])
+		}
+	})
+}
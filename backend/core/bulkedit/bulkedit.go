@@ -0,0 +1,224 @@
+package bulkedit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"athlete-forge/exercise"
+	"athlete-forge/profile"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+const (
+	editSKPrefix = "BULKEDIT#"
+
+	// DateLayout is the format of the edit's date range
+	DateLayout = "2006-01-02"
+
+	// poundsPerKilogram converts between weight units
+	poundsPerKilogram = 2.20462262
+)
+
+// Operations
+const (
+	OpConvertUnits          = "convert-units"
+	OpSwapExercise          = "swap-exercise"
+	OpRecalculateBodyweight = "recalculate-bodyweight"
+)
+
+// Edit statuses
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// Edit is a retroactive change to a user's workout history, run as a background
+// job. From and To optionally bound the workouts edited by start date, inclusive.
+// Unit conversions rewrite set weights logged in FromUnit as ToUnit; exercise swaps
+// rename Exercise to Replacement. Bodyweight recalculations leave the workouts as
+// logged and rebuild the stored data that counts the bodyweight on their day
+type Edit struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"userId"`
+	Operation   string     `json:"operation"`
+	From        string     `json:"from,omitempty"`
+	To          string     `json:"to,omitempty"`
+	FromUnit    string     `json:"fromUnit,omitempty"`
+	ToUnit      string     `json:"toUnit,omitempty"`
+	Exercise    string     `json:"exercise,omitempty"`
+	Replacement string     `json:"replacement,omitempty"`
+	Status      string     `json:"status"`
+	JobID       string     `json:"jobId,omitempty"`
+	Total       int        `json:"total"`
+	Processed   int        `json:"processed"`
+	Changed     int        `json:"changed"`
+	Progress    int        `json:"progress"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// Validate checks the edit has the parameters its operation needs
+func (e *Edit) Validate() error {
+	for _, date := range []string{e.From, e.To} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(DateLayout, date); err != nil {
+			return errors.New("from and to must be dates in YYYY-MM-DD format")
+		}
+	}
+	if e.From != "" && e.To != "" && e.To < e.From {
+		return errors.New("to must not be before from")
+	}
+
+	switch e.Operation {
+	case OpConvertUnits:
+		if !isUnit(e.FromUnit) || !isUnit(e.ToUnit) || e.FromUnit == e.ToUnit {
+			return fmt.Errorf("fromUnit and toUnit must be different units, %q or %q", profile.UnitKilograms, profile.UnitPounds)
+		}
+	case OpSwapExercise:
+		if strings.TrimSpace(e.Exercise) == "" || strings.TrimSpace(e.Replacement) == "" {
+			return errors.New("exercise and replacement are required")
+		}
+		if strings.EqualFold(e.Exercise, e.Replacement) {
+			return errors.New("replacement must differ from exercise")
+		}
+	case OpRecalculateBodyweight:
+	default:
+		return fmt.Errorf("operation must be %q, %q or %q", OpConvertUnits, OpSwapExercise, OpRecalculateBodyweight)
+	}
+	return nil
+}
+
+// Matches reports whether w started within the edit's date range
+func (e *Edit) Matches(w workout.Workout) bool {
+	date := w.StartedAt.UTC().Format(DateLayout)
+	if w.StartedAt.IsZero() && w.ScheduledAt != nil {
+		date = w.ScheduledAt.UTC().Format(DateLayout)
+	}
+	return (e.From == "" || date >= e.From) && (e.To == "" || date <= e.To)
+}
+
+// Recalculates reports whether a bodyweight recalculation rebuilds the data
+// derived from w: w must be completed and have a catalog bodyweight exercise
+func (e *Edit) Recalculates(w workout.Workout) bool {
+	if e.Operation != OpRecalculateBodyweight || w.Status != workout.StatusCompleted {
+		return false
+	}
+	for _, logged := range w.Exercises {
+		if known, ok := exercise.Lookup(logged.Name); ok && known.Bodyweight() {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply edits w in place and reports whether anything changed
+func (e *Edit) Apply(w *workout.Workout) bool {
+	changed := false
+	for i := range w.Exercises {
+		exercise := &w.Exercises[i]
+		switch e.Operation {
+		case OpConvertUnits:
+			for j := range exercise.Sets {
+				set := &exercise.Sets[j]
+				if set.Weight == 0 && set.Assistance == 0 {
+					continue
+				}
+				set.Weight = e.convert(set.Weight)
+				set.Assistance = e.convert(set.Assistance)
+				changed = true
+			}
+		case OpSwapExercise:
+			if strings.EqualFold(exercise.Name, e.Exercise) {
+				exercise.Name = e.Replacement
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// Advance records another processed workout and updates the progress percentage
+func (e *Edit) Advance(changed bool) {
+	e.Processed++
+	if changed {
+		e.Changed++
+	}
+	if e.Total > 0 {
+		e.Progress = e.Processed * 100 / e.Total
+	}
+}
+
+func (e *Edit) convert(weight float64) float64 {
+	if e.ToUnit == profile.UnitPounds {
+		weight *= poundsPerKilogram
+	} else {
+		weight /= poundsPerKilogram
+	}
+	return math.Round(weight*100) / 100
+}
+
+func isUnit(unit string) bool {
+	return unit == profile.UnitKilograms || unit == profile.UnitPounds
+}
+
+// Repository loads and saves bulk edits
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Get returns the edit with id owned by userID
+func (r *Repository) Get(ctx context.Context, userID, id string) (*Edit, error) {
+	var e Edit
+	if err := r.store.Get(ctx, store.UserPK(userID), editSKPrefix+id, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// List returns all of userID's edits
+func (r *Repository) List(ctx context.Context, userID string) ([]Edit, error) {
+	items, err := r.store.Query(ctx, store.UserPK(userID), editSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bulk edits: %w", err)
+	}
+
+	edits := make([]Edit, 0, len(items))
+	for _, item := range items {
+		var e Edit
+		if err := item.Decode(&e); err != nil {
+			return nil, err
+		}
+		edits = append(edits, e)
+	}
+	return edits, nil
+}
+
+// Save validates and stores e, assigning an ID to new edits
+func (r *Repository) Save(ctx context.Context, e *Edit) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	if e.ID == "" {
+		e.ID = store.NewID()
+	}
+	if err := r.store.Put(ctx, store.UserPK(e.UserID), editSKPrefix+e.ID, e); err != nil {
+		return fmt.Errorf("failed to save bulk edit: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package bulkedit
+
+import (
+	"testing"
+	"time"
+
+	"athlete-forge/workout"
+)
+
+func TestEdit_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    Edit
+		wantErr bool
+	}{
+		{name: "convert units", edit: Edit{Operation: OpConvertUnits, FromUnit: "lb", ToUnit: "kg", From: "2024-01-01", To: "2024-02-01"}},
+		{name: "convert to the same unit", edit: Edit{Operation: OpConvertUnits, FromUnit: "kg", ToUnit: "kg"}, wantErr: true},
+		{name: "convert to unknown unit", edit: Edit{Operation: OpConvertUnits, FromUnit: "kg", ToUnit: "stone"}, wantErr: true},
+		{name: "swap exercise", edit: Edit{Operation: OpSwapExercise, Exercise: "Squat", Replacement: "Back Squat"}},
+		{name: "swap without replacement", edit: Edit{Operation: OpSwapExercise, Exercise: "Squat"}, wantErr: true},
+		{name: "recalculate bodyweight", edit: Edit{Operation: OpRecalculateBodyweight, From: "2024-01-01"}},
+		{name: "swap to itself", edit: Edit{Operation: OpSwapExercise, Exercise: "Squat", Replacement: "squat"}, wantErr: true},
+		{name: "inverted range", edit: Edit{Operation: OpSwapExercise, Exercise: "Squat", Replacement: "Back Squat", From: "2024-02-01", To: "2024-01-01"}, wantErr: true},
+		{name: "malformed date", edit: Edit{Operation: OpSwapExercise, Exercise: "Squat", Replacement: "Back Squat", From: "January"}, wantErr: true},
+		{name: "unknown operation", edit: Edit{Operation: "delete-everything"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.edit.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestEdit_Apply(t *testing.T) {
+	started := time.Date(2024, 1, 15, 18, 0, 0, 0, time.UTC)
+	newWorkout := func() workout.Workout {
+		return workout.Workout{StartedAt: started, Exercises: []workout.Exercise{
+			{Name: "squat", Sets: []workout.Set{{Reps: 5, Weight: 225}}},
+			{Name: "Pull-Up", Sets: []workout.Set{{Reps: 8, Assistance: 50}}},
+			{Name: "Plank", Sets: []workout.Set{{Type: workout.SetDuration, DurationSeconds: 60}}},
+		}}
+	}
+
+	t.Run("converts set weights and assistance", func(t *testing.T) {
+		// Arrange
+		w := newWorkout()
+		e := Edit{Operation: OpConvertUnits, FromUnit: "lb", ToUnit: "kg"}
+
+		// Act
+		changed := e.Apply(&w)
+
+		// Assert
+		if !changed || w.Exercises[0].Sets[0].Weight != 102.06 || w.Exercises[1].Sets[0].Assistance != 22.68 {
+			t.Errorf("unexpected conversion: %+v", w.Exercises)
+		}
+	})
+
+	t.Run("swaps an exercise regardless of case", func(t *testing.T) {
+		// Arrange
+		w := newWorkout()
+		e := Edit{Operation: OpSwapExercise, Exercise: "Squat", Replacement: "Back Squat"}
+
+		// Act
+		changed := e.Apply(&w)
+
+		// Assert
+		if !changed || w.Exercises[0].Name != "Back Squat" || w.Exercises[1].Name != "Pull-Up" {
+			t.Errorf("unexpected swap: %+v", w.Exercises)
+		}
+	})
+
+	t.Run("leaves workouts without the exercise unchanged", func(t *testing.T) {
+		w := newWorkout()
+		e := Edit{Operation: OpSwapExercise, Exercise: "Bench", Replacement: "Floor Press"}
+		if e.Apply(&w) {
+			t.Error("expected no change")
+		}
+	})
+
+	t.Run("recalculates completed workouts with bodyweight exercises without changing them", func(t *testing.T) {
+		// Arrange
+		w := newWorkout()
+		w.Status = workout.StatusCompleted
+		e := Edit{Operation: OpRecalculateBodyweight}
+		weighted := workout.Workout{Status: workout.StatusCompleted, Exercises: []workout.Exercise{{Name: "Squat"}}}
+		planned := newWorkout()
+
+		// Act
+		changed := e.Apply(&w)
+
+		// Assert
+		if changed || !e.Recalculates(w) || e.Recalculates(weighted) || e.Recalculates(planned) {
+			t.Errorf("unexpected recalculation: changed %v, %v, %v, %v", changed, e.Recalculates(w), e.Recalculates(weighted), e.Recalculates(planned))
+		}
+	})
+
+	t.Run("matches workouts by start date", func(t *testing.T) {
+		w := newWorkout()
+		if !(&Edit{From: "2024-01-15", To: "2024-01-15"}).Matches(w) || (&Edit{From: "2024-01-16"}).Matches(w) {
+			t.Error("unexpected date range match")
+		}
+	})
+}
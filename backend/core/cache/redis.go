@@ -0,0 +1,208 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultTimeout bounds each command, so an unreachable cluster slows reads down
+// rather than stalling them
+const defaultTimeout = 200 * time.Millisecond
+
+// Redis is a store.Cache backed by a Redis server such as an ElastiCache cluster,
+// speaking the RESP protocol over a single connection that is reopened after any
+// failure
+type Redis struct {
+	addr     string
+	password string
+	tls      bool
+	timeout  time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewRedis creates a Redis cache for endpoint, a redis:// or TLS rediss:// URL
+// optionally carrying a password, such as rediss://:token@host:6379
+func NewRedis(endpoint string) (*Redis, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cache endpoint: %w", err)
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("invalid cache endpoint: unsupported scheme %q", u.Scheme)
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	password, _ := u.User.Password()
+	return &Redis{addr: addr, password: password, tls: u.Scheme == "rediss", timeout: defaultTimeout}, nil
+}
+
+// Get returns the value at key and whether it was present
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := r.do(ctx, "GET", key)
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("unexpected cache reply to GET: %v", reply)
+	}
+	return value, true, nil
+}
+
+// Set stores value at key, expiring it after ttl
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_, err := r.do(ctx, "SET", key, string(value), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+// Delete removes keys
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := r.do(ctx, "DEL", keys...)
+	return err
+}
+
+// do sends one command and reads its reply, connecting first when needed
+func (r *Redis) do(ctx context.Context, command string, args ...string) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conn == nil {
+		if err := r.connect(ctx); err != nil {
+			return nil, err
+		}
+	}
+	reply, err := r.roundTrip(ctx, append([]string{command}, args...))
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		// The connection may be left mid-reply, so it cannot be reused
+		r.conn.Close()
+		r.conn, r.reader = nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cache %s failed: %w", command, err)
+	}
+	return reply, nil
+}
+
+// connect opens the connection and authenticates it
+func (r *Redis) connect(ctx context.Context) error {
+	dialer := &net.Dialer{Timeout: r.timeout}
+	var conn net.Conn
+	var err error
+	if r.tls {
+		host, _, _ := net.SplitHostPort(r.addr)
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", r.addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", r.addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to cache: %w", err)
+	}
+	r.conn, r.reader = conn, bufio.NewReader(conn)
+
+	if r.password != "" {
+		if _, err := r.roundTrip(ctx, []string{"AUTH", r.password}); err != nil {
+			conn.Close()
+			r.conn, r.reader = nil, nil
+			return fmt.Errorf("failed to authenticate to cache: %w", err)
+		}
+	}
+	return nil
+}
+
+// roundTrip writes args as a RESP array and reads the reply
+func (r *Redis) roundTrip(ctx context.Context, args []string) (interface{}, error) {
+	deadline := time.Now().Add(r.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	r.conn.SetDeadline(deadline)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(r.conn, b.String()); err != nil {
+		return nil, err
+	}
+	return readReply(r.reader)
+}
+
+// redisError is an error reply from the server; the connection remains usable
+type redisError string
+
+func (e redisError) Error() string {
+	return string(e)
+}
+
+// readReply reads one RESP reply: simple strings and integers as strings, bulk
+// strings as bytes, nil bulk strings as nil and arrays as slices
+func readReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty cache reply")
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache reply %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache reply %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		elements := make([]interface{}, n)
+		for i := range elements {
+			if elements[i], err = readReply(reader); err != nil {
+				return nil, err
+			}
+		}
+		return elements, nil
+	}
+	return nil, fmt.Errorf("invalid cache reply %q", line)
+}
@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis serves GET, SET, DEL and AUTH from a map over RESP
+type fakeRedis struct {
+	listener net.Listener
+	password string
+
+	mu       sync.Mutex
+	values   map[string]string
+	commands []string
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on loopback: %v", err)
+	}
+	f := &fakeRedis{listener: listener, password: password, values: map[string]string{}}
+	t.Cleanup(func() { listener.Close() })
+	go f.serve()
+	return f
+}
+
+func (f *fakeRedis) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authed := f.password == ""
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.commands = append(f.commands, args[0])
+		var reply string
+		switch {
+		case args[0] == "AUTH":
+			authed = args[1] == f.password
+			reply = "+OK\r\n"
+			if !authed {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+		case !authed:
+			reply = "-NOAUTH Authentication required.\r\n"
+		case args[0] == "GET":
+			if value, ok := f.values[args[1]]; ok {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+			} else {
+				reply = "$-1\r\n"
+			}
+		case args[0] == "SET":
+			f.values[args[1]] = args[2]
+			reply = "+OK\r\n"
+		case args[0] == "DEL":
+			for _, key := range args[1:] {
+				delete(f.values, key)
+			}
+			reply = fmt.Sprintf(":%d\r\n", len(args)-1)
+		default:
+			reply = "-ERR unknown command\r\n"
+		}
+		f.mu.Unlock()
+		conn.Write([]byte(reply))
+	}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, n)
+	for i := range args {
+		if _, err := reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("set, get and delete round-trip through the server", func(t *testing.T) {
+		// Arrange
+		server := newFakeRedis(t, "")
+		r, err := NewRedis("redis://" + server.listener.Addr().String())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// Act
+		setErr := r.Set(ctx, "item|USER#1|PROFILE", []byte(`{"name":"ada"}`), time.Minute)
+		value, found, getErr := r.Get(ctx, "item|USER#1|PROFILE")
+		deleteErr := r.Delete(ctx, "item|USER#1|PROFILE")
+		_, foundAfter, _ := r.Get(ctx, "item|USER#1|PROFILE")
+
+		// Assert
+		for _, err := range []error{setErr, getErr, deleteErr} {
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if !found || string(value) != `{"name":"ada"}` {
+			t.Errorf("expected cached value, got %q (found %v)", value, found)
+		}
+		if foundAfter {
+			t.Error("expected the key to be deleted")
+		}
+	})
+
+	t.Run("authenticates with the endpoint's password", func(t *testing.T) {
+		// Arrange
+		server := newFakeRedis(t, "token")
+		r, _ := NewRedis("redis://:token@" + server.listener.Addr().String())
+
+		// Act
+		_, _, err := r.Get(ctx, "missing")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if server.commands[0] != "AUTH" {
+			t.Errorf("expected AUTH first, got %v", server.commands)
+		}
+	})
+
+	t.Run("reconnects after the connection fails", func(t *testing.T) {
+		// Arrange
+		server := newFakeRedis(t, "")
+		r, _ := NewRedis("redis://" + server.listener.Addr().String())
+		r.Set(ctx, "key", []byte("value"), time.Minute)
+		r.conn.Close()
+
+		// Act
+		_, _, failed := r.Get(ctx, "key")
+		value, found, err := r.Get(ctx, "key")
+
+		// Assert
+		if failed == nil {
+			t.Error("expected the read on the closed connection to fail")
+		}
+		if err != nil || !found || string(value) != "value" {
+			t.Errorf("expected value after reconnecting, got %q, %v, %v", value, found, err)
+		}
+	})
+
+	t.Run("rejects endpoints that are not redis URLs", func(t *testing.T) {
+		// Act
+		_, err := NewRedis("dax://cluster.abc.dax-clusters.eu-west-2.amazonaws.com")
+
+		// Assert
+		if err == nil {
+			t.Error("expected an error for a dax endpoint")
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"athlete-forge/program"
+	"athlete-forge/workout"
+)
+
+// defaultWorkoutDuration is used for planned workouts, which have no length of their own
+const defaultWorkoutDuration = time.Hour
+
+// dayCodes maps schedule days to RFC 5545 BYDAY values
+var dayCodes = map[string]string{
+	"mon": "MO", "tue": "TU", "wed": "WE", "thu": "TH", "fri": "FR", "sat": "SA", "sun": "SU",
+}
+
+// Events lists the scheduled program days and planned workouts for a feed; the
+// feed is built on each request, so it always reflects the current schedules
+func Events(programs []program.Program, workouts []workout.Workout) []Event {
+	events := []Event{}
+	for _, p := range programs {
+		if p.Schedule == nil {
+			continue
+		}
+		events = append(events, programEvent(p))
+	}
+	for _, w := range workouts {
+		if w.Status != workout.StatusPlanned || w.ScheduledAt == nil {
+			continue
+		}
+		events = append(events, workoutEvent(w))
+	}
+	return events
+}
+
+// programEvent repeats a program's session on its scheduled days, starting on the
+// first scheduled day on or after the program was created
+func programEvent(p program.Program) Event {
+	s := p.Schedule
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+	var hour, minute int
+	fmt.Sscanf(s.StartTime, "%d:%d", &hour, &minute)
+
+	created := p.CreatedAt.In(loc)
+	start := time.Date(created.Year(), created.Month(), created.Day(), hour, minute, 0, 0, loc)
+	for i := 0; i < 7 && !scheduledOn(s, start.Weekday()); i++ {
+		start = start.AddDate(0, 0, 1)
+	}
+
+	codes := make([]string, 0, len(s.Days))
+	for _, day := range program.Weekdays {
+		for _, scheduled := range s.Days {
+			if scheduled == day {
+				codes = append(codes, dayCodes[day])
+			}
+		}
+	}
+
+	lines := make([]string, 0, len(p.Exercises))
+	for _, e := range p.Exercises {
+		lines = append(lines, fmt.Sprintf("%s %dx%d @ %g", e.Exercise, e.Sets, e.Reps, e.Weight))
+	}
+
+	return Event{
+		UID:         "program-" + p.ID + "@athlete-forge",
+		Summary:     p.Name,
+		Description: strings.Join(lines, "\n"),
+		Start:       start,
+		TimeZone:    s.TimeZone,
+		Duration:    time.Duration(s.DurationMinutes) * time.Minute,
+		Weekly:      codes,
+		Updated:     p.UpdatedAt,
+	}
+}
+
+func workoutEvent(w workout.Workout) Event {
+	names := make([]string, 0, len(w.Exercises))
+	for _, e := range w.Exercises {
+		names = append(names, e.Name)
+	}
+	summary := "Planned workout"
+	if len(names) > 0 {
+		summary = strings.Join(names, ", ")
+	}
+
+	return Event{
+		UID:         "workout-" + w.ID + "@athlete-forge",
+		Summary:     summary,
+		Description: w.Notes,
+		Start:       *w.ScheduledAt,
+		Duration:    defaultWorkoutDuration,
+	}
+}
+
+// scheduledOn reports whether s includes weekday
+func scheduledOn(s *program.Schedule, weekday time.Weekday) bool {
+	// time.Weekday starts on Sunday while program.Weekdays starts on Monday
+	index := (int(weekday) + 6) % 7
+	for _, day := range s.Days {
+		if program.WeekdayIndex(day) == index {
+			return true
+		}
+	}
+	return false
+}
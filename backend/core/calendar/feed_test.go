@@ -0,0 +1,61 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"athlete-forge/program"
+	"athlete-forge/workout"
+)
+
+func TestEvents(t *testing.T) {
+	t.Run("repeats scheduled programs from the first scheduled day", func(t *testing.T) {
+		// Arrange
+		created := time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC) // Tuesday
+		programs := []program.Program{
+			{
+				ID:        "p1",
+				Name:      "5x5",
+				CreatedAt: created,
+				Exercises: []program.Prescription{{Exercise: "Squat", Sets: 5, Reps: 5, Weight: 102.5}},
+				Schedule:  &program.Schedule{Days: []string{"fri", "mon"}, StartTime: "18:15", DurationMinutes: 75, TimeZone: "America/New_York"},
+			},
+			{ID: "p2", Name: "Unscheduled", CreatedAt: created},
+		}
+
+		// Act
+		events := Events(programs, nil)
+
+		// Assert
+		if len(events) != 1 {
+			t.Fatalf("expected 1 event, got %d", len(events))
+		}
+		e := events[0]
+		if got := e.Start.Format("2006-01-02 15:04 MST"); got != "2024-03-08 18:15 EST" {
+			t.Errorf("unexpected start %s", got)
+		}
+		if len(e.Weekly) != 2 || e.Weekly[0] != "MO" || e.Weekly[1] != "FR" {
+			t.Errorf("unexpected recurrence %v", e.Weekly)
+		}
+		if e.Duration != 75*time.Minute || e.Description != "Squat 5x5 @ 102.5" {
+			t.Errorf("unexpected event: %+v", e)
+		}
+	})
+
+	t.Run("includes only planned workouts", func(t *testing.T) {
+		// Arrange
+		at := time.Date(2024, 3, 6, 7, 0, 0, 0, time.UTC)
+		workouts := []workout.Workout{
+			{ID: "w1", Status: workout.StatusPlanned, ScheduledAt: &at, Exercises: []workout.Exercise{{Name: "Deadlift"}}},
+			{ID: "w2", Status: workout.StatusCompleted, StartedAt: at, CompletedAt: &at},
+		}
+
+		// Act
+		events := Events(nil, workouts)
+
+		// Assert
+		if len(events) != 1 || events[0].UID != "workout-w1@athlete-forge" || events[0].Summary != "Deadlift" {
+			t.Errorf("unexpected events: %+v", events)
+		}
+	})
+}
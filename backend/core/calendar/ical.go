@@ -0,0 +1,130 @@
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	utcLayout   = "20060102T150405Z"
+	localLayout = "20060102T150405"
+
+	// maxLineOctets is the longest content line allowed before folding
+	maxLineOctets = 75
+)
+
+// Event is a single or weekly recurring calendar entry
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+
+	// Start is interpreted in TimeZone when set, otherwise written as UTC
+	Start    time.Time
+	TimeZone string
+	Duration time.Duration
+
+	// Weekly lists RFC 5545 day codes (MO, TU, ...) the event repeats on
+	Weekly []string
+
+	Updated time.Time
+}
+
+// Feed is a named collection of events served as one calendar
+type Feed struct {
+	Name   string
+	Events []Event
+}
+
+// Render writes the feed as an iCalendar document, stamping events with now
+func Render(f Feed, now time.Time) string {
+	var b strings.Builder
+	w := func(line string) {
+		writeFolded(&b, line)
+	}
+
+	w("BEGIN:VCALENDAR")
+	w("VERSION:2.0")
+	w("PRODID:-//athlete-forge//training calendar//EN")
+	w("CALSCALE:GREGORIAN")
+	w("METHOD:PUBLISH")
+	w("X-WR-CALNAME:" + escape(f.Name))
+	// Ask subscribing clients to poll hourly so schedule changes show up promptly
+	w("REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+	w("X-PUBLISHED-TTL:PT1H")
+
+	for _, e := range f.Events {
+		w("BEGIN:VEVENT")
+		w("UID:" + e.UID)
+		w("DTSTAMP:" + now.UTC().Format(utcLayout))
+		if !e.Updated.IsZero() {
+			w("LAST-MODIFIED:" + e.Updated.UTC().Format(utcLayout))
+		}
+		w(dateTime("DTSTART", e.Start, e.TimeZone))
+		w("DURATION:" + duration(e.Duration))
+		if len(e.Weekly) > 0 {
+			w("RRULE:FREQ=WEEKLY;BYDAY=" + strings.Join(e.Weekly, ","))
+		}
+		w("SUMMARY:" + escape(e.Summary))
+		if e.Description != "" {
+			w("DESCRIPTION:" + escape(e.Description))
+		}
+		w("END:VEVENT")
+	}
+
+	w("END:VCALENDAR")
+	return b.String()
+}
+
+// dateTime formats a date-time property, using a TZID parameter for zoned times so
+// recurring events keep their wall-clock time across daylight saving changes
+func dateTime(name string, t time.Time, zone string) string {
+	if zone == "" || zone == "UTC" {
+		return name + ":" + t.UTC().Format(utcLayout)
+	}
+	if loc, err := time.LoadLocation(zone); err == nil {
+		t = t.In(loc)
+	}
+	return fmt.Sprintf("%s;TZID=%s:%s", name, zone, t.Format(localLayout))
+}
+
+// duration formats d as an RFC 5545 duration in whole minutes
+func duration(d time.Duration) string {
+	minutes := int(d.Minutes())
+	if minutes <= 0 {
+		minutes = 60
+	}
+	if minutes%60 == 0 {
+		return fmt.Sprintf("PT%dH", minutes/60)
+	}
+	return fmt.Sprintf("PT%dM", minutes)
+}
+
+// escape protects TEXT values per RFC 5545
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// writeFolded writes line terminated by CRLF, folding it at 75 octets without
+// splitting multi-byte characters
+func writeFolded(b *strings.Builder, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts towards the limit
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+func isRuneStart(c byte) bool {
+	return c&0xC0 != 0x80
+}
@@ -0,0 +1,82 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestRender(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("writes zoned weekly events with CRLF line endings", func(t *testing.T) {
+		// Arrange
+		loc, _ := time.LoadLocation("Europe/London")
+		feed := Feed{Name: "Training", Events: []Event{{
+			UID:      "program-1@athlete-forge",
+			Summary:  "Upper, lower",
+			Start:    time.Date(2024, 3, 4, 7, 30, 0, 0, loc),
+			TimeZone: "Europe/London",
+			Duration: 90 * time.Minute,
+			Weekly:   []string{"MO", "TH"},
+		}}}
+
+		// Act
+		out := Render(feed, now)
+
+		// Assert
+		for _, want := range []string{
+			"BEGIN:VCALENDAR\r\n",
+			"DTSTAMP:20240301T120000Z\r\n",
+			"DTSTART;TZID=Europe/London:20240304T073000\r\n",
+			"DURATION:PT90M\r\n",
+			"RRULE:FREQ=WEEKLY;BYDAY=MO,TH\r\n",
+			"SUMMARY:Upper\\, lower\r\n",
+			"END:VCALENDAR\r\n",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("expected %q in:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("writes one-off events in UTC", func(t *testing.T) {
+		// Arrange
+		start := time.Date(2024, 3, 5, 18, 0, 0, 0, time.FixedZone("EST", -5*3600))
+		feed := Feed{Events: []Event{{UID: "workout-1@athlete-forge", Summary: "Squat", Start: start, Duration: time.Hour}}}
+
+		// Act
+		out := Render(feed, now)
+
+		// Assert
+		if !strings.Contains(out, "DTSTART:20240305T230000Z\r\n") || !strings.Contains(out, "DURATION:PT1H\r\n") {
+			t.Errorf("unexpected event:\n%s", out)
+		}
+		if strings.Contains(out, "RRULE") {
+			t.Error("one-off event should not repeat")
+		}
+	})
+
+	t.Run("folds long lines without splitting characters", func(t *testing.T) {
+		// Arrange
+		feed := Feed{Events: []Event{{UID: "workout-2@athlete-forge", Summary: "Squat", Description: strings.Repeat("é", 80)}}}
+
+		// Act
+		out := Render(feed, now)
+
+		// Assert
+		for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+			if len(line) > maxLineOctets {
+				t.Errorf("line exceeds %d octets: %q", maxLineOctets, line)
+			}
+			if !utf8.ValidString(line) {
+				t.Errorf("line splits a character: %q", line)
+			}
+		}
+		unfolded := strings.ReplaceAll(out, "\r\n ", "")
+		if !strings.Contains(unfolded, "DESCRIPTION:"+strings.Repeat("é", 80)+"\r\n") {
+			t.Error("unfolded description does not round-trip")
+		}
+	})
+}
@@ -0,0 +1,196 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"athlete-forge/dailylog"
+	"athlete-forge/exercise"
+	"athlete-forge/program"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+const monthSKPrefix = "CALENDAR#MONTH#"
+
+// MonthLayout is the format of a month, such as 2024-03
+const MonthLayout = "2006-01"
+
+// maxMuscleGroups is how many of a day's most trained muscle groups are listed
+const maxMuscleGroups = 3
+
+// Day summarizes a UTC day of a month view. Planned counts the workouts
+// scheduled for the day and the sessions of program schedules falling on it;
+// Completed counts the workouts completed that day, planned or not
+type Day struct {
+	Date         string   `json:"date"`
+	Trained      bool     `json:"trained"`
+	Planned      int      `json:"planned"`
+	Completed    int      `json:"completed"`
+	Sets         int      `json:"sets"`
+	Volume       float64  `json:"volume"`
+	MuscleGroups []string `json:"muscleGroups"`
+}
+
+// Month is a user's month view: a Day for every day of the month and the
+// month's totals
+type Month struct {
+	UserID      string    `json:"userId"`
+	Month       string    `json:"month"`
+	Days        []Day     `json:"days"`
+	TrainedDays int       `json:"trainedDays"`
+	Planned     int       `json:"planned"`
+	Completed   int       `json:"completed"`
+	Sets        int       `json:"sets"`
+	Volume      float64   `json:"volume"`
+	BuiltAt     time.Time `json:"builtAt"`
+}
+
+// ParseMonth parses a month in MonthLayout as the first instant of the month in UTC
+func ParseMonth(value string) (time.Time, error) {
+	month, err := time.Parse(MonthLayout, value)
+	if err != nil {
+		return time.Time{}, errors.New("month must be in YYYY-MM format")
+	}
+	return month, nil
+}
+
+// MonthOf returns the month of t, in MonthLayout
+func MonthOf(t time.Time) string {
+	return t.UTC().Format(MonthLayout)
+}
+
+// BuildMonth summarizes the workouts of the month beginning month: those
+// completed in it and those scheduled in it. Program schedules are not stored
+// with the month, and are added by AddSchedules when it is read
+func BuildMonth(userID string, month time.Time, workouts []workout.Workout, now time.Time) Month {
+	days := time.Date(month.Year(), month.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	m := Month{UserID: userID, Month: month.Format(MonthLayout), Days: make([]Day, days), BuiltAt: now.UTC()}
+	for i := range m.Days {
+		m.Days[i] = Day{Date: month.AddDate(0, 0, i).Format(dailylog.DateLayout), MuscleGroups: []string{}}
+	}
+	muscleSets := make([]map[string]int, days)
+
+	for _, w := range workouts {
+		if w.ScheduledAt != nil {
+			if i, ok := m.dayOf(*w.ScheduledAt); ok {
+				m.Days[i].Planned++
+			}
+		}
+		if w.Status != workout.StatusCompleted {
+			continue
+		}
+		at := w.StartedAt
+		if w.CompletedAt != nil {
+			at = *w.CompletedAt
+		}
+		i, ok := m.dayOf(at)
+		if !ok {
+			continue
+		}
+		day := &m.Days[i]
+		day.Trained = true
+		day.Completed++
+		for _, block := range w.Blocks() {
+			day.Sets += block.Sets
+			day.Volume += block.Volume
+		}
+		if muscleSets[i] == nil {
+			muscleSets[i] = map[string]int{}
+		}
+		for _, e := range w.Exercises {
+			known, ok := exercise.Lookup(e.Name)
+			if !ok {
+				continue
+			}
+			for _, muscle := range known.PrimaryMuscles() {
+				muscleSets[i][muscle] += len(e.Sets)
+			}
+		}
+	}
+
+	for i := range m.Days {
+		day := &m.Days[i]
+		day.Volume = math.Round(day.Volume*10) / 10
+		day.MuscleGroups = mostTrained(muscleSets[i])
+		if day.Trained {
+			m.TrainedDays++
+		}
+		m.Planned += day.Planned
+		m.Completed += day.Completed
+		m.Sets += day.Sets
+		m.Volume += day.Volume
+	}
+	m.Volume = math.Round(m.Volume*10) / 10
+	return m
+}
+
+// AddSchedules counts a planned session on each day of the month that one of
+// the programs' schedules falls on, from the day the program was created
+func (m *Month) AddSchedules(programs []program.Program) {
+	for _, p := range programs {
+		if p.Schedule == nil {
+			continue
+		}
+		created := p.CreatedAt.UTC().Truncate(24 * time.Hour)
+		for i := range m.Days {
+			date, _ := time.Parse(dailylog.DateLayout, m.Days[i].Date)
+			if date.Before(created) || !scheduledOn(p.Schedule, date.Weekday()) {
+				continue
+			}
+			m.Days[i].Planned++
+			m.Planned++
+		}
+	}
+}
+
+// dayOf returns the index in m.Days of t's day, or false when t is outside the month
+func (m *Month) dayOf(t time.Time) (int, bool) {
+	if MonthOf(t) != m.Month {
+		return 0, false
+	}
+	return t.UTC().Day() - 1, true
+}
+
+// mostTrained returns the muscle groups with the most sets, ties in name order
+func mostTrained(sets map[string]int) []string {
+	muscles := make([]string, 0, len(sets))
+	for muscle := range sets {
+		muscles = append(muscles, muscle)
+	}
+	sort.Slice(muscles, func(i, j int) bool {
+		if sets[muscles[i]] != sets[muscles[j]] {
+			return sets[muscles[i]] > sets[muscles[j]]
+		}
+		return muscles[i] < muscles[j]
+	})
+	if len(muscles) > maxMuscleGroups {
+		muscles = muscles[:maxMuscleGroups]
+	}
+	return muscles
+}
+
+// GetMonth returns userID's stored month view of month, or false when none is stored
+func (r *Repository) GetMonth(ctx context.Context, userID, month string) (*Month, bool, error) {
+	var m Month
+	err := r.store.Get(ctx, store.UserPK(userID), monthSKPrefix+month, &m)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load month view: %w", err)
+	}
+	return &m, true, nil
+}
+
+// SaveMonth stores m, replacing any earlier view of the same month
+func (r *Repository) SaveMonth(ctx context.Context, m *Month) error {
+	if err := r.store.Put(ctx, store.UserPK(m.UserID), monthSKPrefix+m.Month, m); err != nil {
+		return fmt.Errorf("failed to save month view: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package calendar
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"athlete-forge/program"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+func TestBuildMonth(t *testing.T) {
+	month := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	at := func(day, hour int) *time.Time {
+		t := time.Date(2024, 2, day, hour, 0, 0, 0, time.UTC)
+		return &t
+	}
+
+	t.Run("summarizes each day and the month", func(t *testing.T) {
+		// Arrange
+		workouts := []workout.Workout{
+			{ID: "w1", Status: workout.StatusCompleted, ScheduledAt: at(5, 7), StartedAt: *at(5, 7), CompletedAt: at(5, 8), Exercises: []workout.Exercise{
+				{Name: "Squat", Sets: []workout.Set{{Reps: 5, Weight: 100}, {Reps: 5, Weight: 100}}},
+				{Name: "Bench Press", Sets: []workout.Set{{Reps: 5, Weight: 80}}},
+				{Name: "Mystery Machine", Sets: []workout.Set{{Reps: 10, Weight: 20}}},
+			}},
+			{ID: "w2", Status: workout.StatusPlanned, ScheduledAt: at(7, 7)},
+			{ID: "w3", Status: workout.StatusCompleted, StartedAt: *at(3, 9), CompletedAt: at(3, 10)},
+			{ID: "w4", Status: workout.StatusCompleted, StartedAt: *at(31, 9)},
+		}
+
+		// Act
+		m := BuildMonth("user-1", month, workouts, month)
+
+		// Assert
+		if m.Month != "2024-02" || len(m.Days) != 29 {
+			t.Fatalf("expected the 29 days of February 2024, got %s with %d", m.Month, len(m.Days))
+		}
+		day := m.Days[4]
+		if day.Date != "2024-02-05" || !day.Trained || day.Planned != 1 || day.Completed != 1 || day.Sets != 4 || day.Volume != 1600 {
+			t.Errorf("unexpected day: %+v", day)
+		}
+		if len(day.MuscleGroups) != 3 || day.MuscleGroups[0] != "glutes" || day.MuscleGroups[1] != "quads" || day.MuscleGroups[2] != "chest" {
+			t.Errorf("unexpected muscle groups: %v", day.MuscleGroups)
+		}
+		if m.Days[6].Trained || m.Days[6].Planned != 1 {
+			t.Errorf("expected a planned rest day, got %+v", m.Days[6])
+		}
+		if m.TrainedDays != 2 || m.Planned != 2 || m.Completed != 2 || m.Volume != 1600 {
+			t.Errorf("unexpected totals: %+v", m)
+		}
+	})
+
+	t.Run("adds program schedules from the day the program was created", func(t *testing.T) {
+		// Arrange
+		m := BuildMonth("user-1", month, nil, month)
+		programs := []program.Program{{ID: "p1", CreatedAt: *at(14, 12), Schedule: &program.Schedule{Days: []string{"mon", "thu"}}}}
+
+		// Act
+		m.AddSchedules(programs)
+
+		// Assert
+		if m.Planned != 5 || m.Days[14].Planned != 1 || m.Days[11].Planned != 0 {
+			t.Errorf("expected the Thursdays and Mondays from the 15th, got %d", m.Planned)
+		}
+	})
+}
+
+func TestRepository_Month(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	r := NewRepository(store.NewMemoryStore())
+	m := BuildMonth("user-1", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), nil, time.Now())
+
+	// Act
+	_, before, err := r.GetMonth(ctx, "user-1", "2024-02")
+	saveErr := r.SaveMonth(ctx, &m)
+	stored, after, _ := r.GetMonth(ctx, "user-1", "2024-02")
+
+	// Assert
+	if err != nil || saveErr != nil {
+		t.Fatalf("unexpected errors: %v, %v", err, saveErr)
+	}
+	if before || !after || len(stored.Days) != 29 {
+		t.Errorf("expected the month stored, got %v then %v", before, after)
+	}
+}
@@ -0,0 +1,51 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"athlete-forge/store"
+)
+
+const settingsSK = "CALENDAR"
+
+// Settings holds a user's feed state; Version is part of the signed token, so
+// incrementing it revokes previously shared feed URLs
+type Settings struct {
+	UserID    string    `json:"userId"`
+	Version   int       `json:"version"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Repository loads and saves calendar settings
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Get returns userID's settings, or the initial settings when none are stored
+func (r *Repository) Get(ctx context.Context, userID string) (*Settings, error) {
+	var s Settings
+	err := r.store.Get(ctx, store.UserPK(userID), settingsSK, &s)
+	if errors.Is(err, store.ErrNotFound) {
+		return &Settings{UserID: userID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Save stores s
+func (r *Repository) Save(ctx context.Context, s *Settings) error {
+	if err := r.store.Put(ctx, store.UserPK(s.UserID), settingsSK, s); err != nil {
+		return fmt.Errorf("failed to save calendar settings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package calendar
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+)
+
+// Signer creates and checks the tokens that authorize unauthenticated feed URLs
+type Signer struct {
+	secret []byte
+}
+
+// NewSigner creates a Signer using secret as the HMAC key
+func NewSigner(secret []byte) *Signer {
+	return &Signer{secret: secret}
+}
+
+// Token returns the feed token for userID; bumping version invalidates earlier tokens
+func (s *Signer) Token(userID string, version int) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(userID + ":" + strconv.Itoa(version)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether token is the current token for userID
+func (s *Signer) Verify(userID string, version int, token string) bool {
+	return hmac.Equal([]byte(token), []byte(s.Token(userID, version)))
+}
@@ -0,0 +1,28 @@
+package calendar
+
+import "testing"
+
+func TestSigner(t *testing.T) {
+	signer := NewSigner([]byte("secret"))
+	token := signer.Token("user-1", 0)
+
+	tests := []struct {
+		name    string
+		userID  string
+		version int
+		token   string
+		want    bool
+	}{
+		{name: "current token", userID: "user-1", version: 0, token: token, want: true},
+		{name: "another user's token", userID: "user-2", version: 0, token: token},
+		{name: "revoked token", userID: "user-1", version: 1, token: token},
+		{name: "tampered token", userID: "user-1", version: 0, token: token[:len(token)-1] + "x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := signer.Verify(tt.userID, tt.version, tt.token); got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package cardio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"athlete-forge/hrzone"
+	"athlete-forge/store"
+	"athlete-forge/track"
+)
+
+const activitySKPrefix = "ACTIVITY#"
+
+// Supported sports
+var sports = map[string]bool{
+	"run":   true,
+	"ride":  true,
+	"swim":  true,
+	"row":   true,
+	"walk":  true,
+	"hike":  true,
+	"other": true,
+}
+
+// maxSamples bounds the samples stored with an activity; longer recordings
+// should be downsampled before import
+const maxSamples = 5000
+
+// Activity is an imported or manually logged cardio activity. Samples are the
+// distance, altitude and power recorded over it, from which runs get a Run
+// summary and rides a Ride summary when they are saved. Pool swims have the
+// Lengths swum in a pool PoolLength metres long, summarized in Swim. Track is
+// the GPS track sent with an import, which is stored separately with only its
+// Route summary kept on the activity
+type Activity struct {
+	ID              string          `json:"id"`
+	UserID          string          `json:"userId"`
+	Sport           string          `json:"sport"`
+	Source          string          `json:"source,omitempty"`
+	StartTime       time.Time       `json:"startTime"`
+	DurationSeconds int             `json:"durationSeconds"`
+	DistanceMeters  float64         `json:"distanceMeters,omitempty"`
+	AverageHR       int             `json:"averageHr,omitempty"`
+	MaxHR           int             `json:"maxHr,omitempty"`
+	HeartRate       []hrzone.Sample `json:"heartRate,omitempty"`
+	Samples         []Sample        `json:"samples,omitempty"`
+	Zones           *hrzone.Summary `json:"zones,omitempty"`
+	Run             *RunSummary     `json:"run,omitempty"`
+	Ride            *RideSummary    `json:"ride,omitempty"`
+	PoolLength      float64         `json:"poolLengthMeters,omitempty"`
+	Lengths         []Length        `json:"lengths,omitempty"`
+	Swim            *SwimSummary    `json:"swim,omitempty"`
+	Track           []track.Point   `json:"track,omitempty"`
+	Route           *track.Summary  `json:"route,omitempty"`
+}
+
+// Validate checks the activity fields and heart rate samples
+func (a *Activity) Validate() error {
+	if !sports[a.Sport] {
+		return fmt.Errorf("unsupported sport %q", a.Sport)
+	}
+	if a.StartTime.IsZero() {
+		return errors.New("startTime is required")
+	}
+	if a.DurationSeconds <= 0 {
+		return errors.New("durationSeconds must be positive")
+	}
+	if a.DistanceMeters < 0 {
+		return errors.New("distanceMeters must not be negative")
+	}
+	last := -1
+	for _, sample := range a.HeartRate {
+		if sample.Offset <= last || sample.Offset > a.DurationSeconds {
+			return errors.New("heart rate samples must have increasing offsets within the activity duration")
+		}
+		if sample.BPM < 0 || sample.BPM > 250 {
+			return errors.New("heart rate samples must be between 0 and 250 bpm")
+		}
+		last = sample.Offset
+	}
+	if len(a.Track) > 0 {
+		if err := track.Validate(a.Track); err != nil {
+			return err
+		}
+	}
+	if err := a.validateLengths(); err != nil {
+		return err
+	}
+	return a.validateSamples()
+}
+
+func (a *Activity) validateSamples() error {
+	if len(a.Samples) > maxSamples {
+		return fmt.Errorf("an activity can have at most %d samples", maxSamples)
+	}
+	last, distance := -1, 0.0
+	for _, sample := range a.Samples {
+		if sample.Offset <= last || sample.Offset > a.DurationSeconds {
+			return errors.New("samples must have increasing offsets within the activity duration")
+		}
+		if sample.Distance < distance {
+			return errors.New("sample distances must not decrease")
+		}
+		if sample.Power < 0 || sample.Power > 3000 {
+			return errors.New("sample power must be between 0 and 3000 watts")
+		}
+		last, distance = sample.Offset, sample.Distance
+	}
+	return nil
+}
+
+// FillHeartRateStats derives average and max heart rate from samples when they were not supplied
+func (a *Activity) FillHeartRateStats() {
+	if len(a.HeartRate) == 0 {
+		return
+	}
+
+	var weighted, total, peak int
+	for i, sample := range a.HeartRate {
+		end := a.DurationSeconds
+		if i+1 < len(a.HeartRate) {
+			end = a.HeartRate[i+1].Offset
+		}
+		span := end - sample.Offset
+		weighted += sample.BPM * span
+		total += span
+		if sample.BPM > peak {
+			peak = sample.BPM
+		}
+	}
+
+	if a.AverageHR == 0 && total > 0 {
+		a.AverageHR = weighted / total
+	}
+	if a.MaxHR == 0 {
+		a.MaxHR = peak
+	}
+}
+
+// Summarize returns the activity's time-in-zone breakdown for config
+func (a *Activity) Summarize(config hrzone.Config) hrzone.Summary {
+	return config.Summarize(a.HeartRate, a.DurationSeconds, a.AverageHR)
+}
+
+// WeeklySummary aggregates cardio activities for one week. Run totals the run
+// summaries of the week's runs
+type WeeklySummary struct {
+	WeekStart       string          `json:"weekStart"`
+	Activities      int             `json:"activities"`
+	DurationSeconds int             `json:"durationSeconds"`
+	DistanceMeters  float64         `json:"distanceMeters"`
+	Zones           *hrzone.Summary `json:"zones,omitempty"`
+	Run             *RunSummary     `json:"run,omitempty"`
+}
+
+// WeekStart returns the Monday 00:00 UTC beginning the week containing t
+func WeekStart(t time.Time) time.Time {
+	t = t.UTC()
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC)
+}
+
+// Weekly sums the activities starting in the week beginning weekStart; zone time and
+// load are included when config is not nil
+func Weekly(activities []Activity, weekStart time.Time, config *hrzone.Config) WeeklySummary {
+	weekEnd := weekStart.AddDate(0, 0, 7)
+	summary := WeeklySummary{WeekStart: weekStart.Format("2006-01-02")}
+	if config != nil {
+		zones := hrzone.EmptySummary()
+		summary.Zones = &zones
+	}
+
+	for _, activity := range activities {
+		if activity.StartTime.Before(weekStart) || !activity.StartTime.Before(weekEnd) {
+			continue
+		}
+		summary.Activities++
+		summary.DurationSeconds += activity.DurationSeconds
+		summary.DistanceMeters += activity.DistanceMeters
+		if config != nil {
+			summary.Zones.Add(activity.Summarize(*config))
+		}
+		if activity.Run != nil {
+			if summary.Run == nil {
+				summary.Run = &RunSummary{}
+			}
+			summary.Run.Add(*activity.Run)
+		}
+	}
+	return summary
+}
+
+// Repository loads and saves cardio activities
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Get returns the activity with id owned by userID
+func (r *Repository) Get(ctx context.Context, userID, id string) (*Activity, error) {
+	var a Activity
+	if err := r.store.Get(ctx, store.UserPK(userID), activitySKPrefix+id, &a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// List returns all of userID's activities in import order
+func (r *Repository) List(ctx context.Context, userID string) ([]Activity, error) {
+	items, err := r.store.Query(ctx, store.UserPK(userID), activitySKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list activities: %w", err)
+	}
+
+	activities := make([]Activity, 0, len(items))
+	for _, item := range items {
+		var a Activity
+		if err := item.Decode(&a); err != nil {
+			return nil, err
+		}
+		activities = append(activities, a)
+	}
+	return activities, nil
+}
+
+// FromItem decodes a stored item, returning false when it is not an activity
+func FromItem(item store.Item) (*Activity, bool) {
+	if !strings.HasPrefix(item.SK, activitySKPrefix) {
+		return nil, false
+	}
+	var a Activity
+	if err := item.Decode(&a); err != nil {
+		return nil, false
+	}
+	return &a, true
+}
+
+// Save validates and stores a, assigning an ID to new activities; computed zone
+// summaries are not persisted because they depend on the current zone
+// configuration, and tracks are not persisted because they are stored whole
+// outside the table
+func (r *Repository) Save(ctx context.Context, a *Activity) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	if a.ID == "" {
+		a.ID = store.NewID()
+	}
+
+	stored := *a
+	stored.Zones = nil
+	stored.Track = nil
+	if err := r.store.Put(ctx, store.UserPK(a.UserID), activitySKPrefix+a.ID, stored); err != nil {
+		return fmt.Errorf("failed to save activity: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package cardio
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"athlete-forge/hrzone"
+	"athlete-forge/store"
+)
+
+func TestActivity_FillHeartRateStats(t *testing.T) {
+	t.Run("derives time-weighted average and peak", func(t *testing.T) {
+		// Arrange
+		a := Activity{DurationSeconds: 400, HeartRate: []hrzone.Sample{{Offset: 0, BPM: 100}, {Offset: 100, BPM: 160}}}
+
+		// Act
+		a.FillHeartRateStats()
+
+		// Assert
+		if a.AverageHR != 145 || a.MaxHR != 160 {
+			t.Errorf("unexpected stats: avg %d max %d", a.AverageHR, a.MaxHR)
+		}
+	})
+}
+
+func TestActivity_Validate(t *testing.T) {
+	start := time.Date(2024, 3, 4, 7, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		activity Activity
+		wantErr  bool
+	}{
+		{name: "valid", activity: Activity{Sport: "run", StartTime: start, DurationSeconds: 600}},
+		{name: "unknown sport", activity: Activity{Sport: "curling", StartTime: start, DurationSeconds: 600}, wantErr: true},
+		{name: "missing duration", activity: Activity{Sport: "run", StartTime: start}, wantErr: true},
+		{
+			name:     "samples out of order",
+			activity: Activity{Sport: "run", StartTime: start, DurationSeconds: 600, HeartRate: []hrzone.Sample{{Offset: 10}, {Offset: 5}}},
+			wantErr:  true,
+		},
+		{
+			name:     "run distance going backwards",
+			activity: Activity{Sport: "run", StartTime: start, DurationSeconds: 600, Samples: []Sample{{Offset: 0, Distance: 100}, {Offset: 60, Distance: 50}}},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.activity.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestWeekly(t *testing.T) {
+	t.Run("sums activities in the week only", func(t *testing.T) {
+		// Arrange
+		config := &hrzone.Config{Method: hrzone.MethodMaxHR, MaxHR: 200}
+		weekStart := WeekStart(time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC))
+		activities := []Activity{
+			{StartTime: time.Date(2024, 3, 4, 7, 0, 0, 0, time.UTC), DurationSeconds: 1800, DistanceMeters: 5000, AverageHR: 145},
+			{StartTime: time.Date(2024, 3, 10, 23, 0, 0, 0, time.UTC), DurationSeconds: 600, AverageHR: 125},
+			{StartTime: time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC), DurationSeconds: 3600, AverageHR: 145},
+		}
+
+		// Act
+		summary := Weekly(activities, weekStart, config)
+
+		// Assert
+		if summary.WeekStart != "2024-03-04" {
+			t.Errorf("expected week start 2024-03-04, got %s", summary.WeekStart)
+		}
+		if summary.Activities != 2 || summary.DurationSeconds != 2400 || summary.DistanceMeters != 5000 {
+			t.Errorf("unexpected totals: %+v", summary)
+		}
+		if summary.Zones.Load != 110 {
+			t.Errorf("expected load 110, got %v", summary.Zones.Load)
+		}
+	})
+}
+
+func TestRepository_Save(t *testing.T) {
+	t.Run("does not persist computed zones", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		repo := NewRepository(store.NewMemoryStore())
+		a := &Activity{UserID: "user-1", Sport: "run", StartTime: time.Now(), DurationSeconds: 60, Zones: &hrzone.Summary{Load: 5}}
+
+		// Act
+		repo.Save(ctx, a)
+		stored, err := repo.Get(ctx, "user-1", a.ID)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if stored.Zones != nil {
+			t.Error("expected zones not to be stored")
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package cardio
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// DuplicateWindow is how far apart two recordings of the same activity may
+// start, since devices and providers round or shift the start differently
+const DuplicateWindow = 5 * time.Minute
+
+// Duplicate strategies for an import matching an activity already stored
+const (
+	// DuplicateSkip keeps the stored activity and drops the import
+	DuplicateSkip = "skip"
+	// DuplicateReplace overwrites the stored activity with the import, keeping its ID
+	DuplicateReplace = "replace"
+	// DuplicateKeepBoth saves the import alongside the stored activity
+	DuplicateKeepBoth = "keep-both"
+)
+
+// ParseDuplicateStrategy validates a duplicate strategy, returning def when
+// none is given
+func ParseDuplicateStrategy(raw, def string) (string, error) {
+	switch raw {
+	case "":
+		return def, nil
+	case DuplicateSkip, DuplicateReplace, DuplicateKeepBoth:
+		return raw, nil
+	}
+	return "", fmt.Errorf("duplicates must be %s, %s or %s", DuplicateSkip, DuplicateReplace, DuplicateKeepBoth)
+}
+
+// Fingerprint is what identifies a recording apart from its start: the sport,
+// elapsed time and distance, which different exports of the same activity
+// agree on within a small tolerance
+type Fingerprint struct {
+	Sport           string
+	DurationSeconds int
+	DistanceMeters  float64
+}
+
+// Fingerprint returns the activity's fingerprint
+func (a *Activity) Fingerprint() Fingerprint {
+	return Fingerprint{Sport: a.Sport, DurationSeconds: a.DurationSeconds, DistanceMeters: a.DistanceMeters}
+}
+
+// Matches reports whether f and other describe the same recording: the same
+// sport, durations within 5% or a minute and, when both have one, distances
+// within 5% or 100 m
+func (f Fingerprint) Matches(other Fingerprint) bool {
+	if f.Sport != other.Sport {
+		return false
+	}
+	if !within(float64(f.DurationSeconds), float64(other.DurationSeconds), 60) {
+		return false
+	}
+	if f.DistanceMeters == 0 || other.DistanceMeters == 0 {
+		return true
+	}
+	return within(f.DistanceMeters, other.DistanceMeters, 100)
+}
+
+// within reports whether a and b differ by at most 5% of the larger or by
+// floor, whichever is more lenient
+func within(a, b, floor float64) bool {
+	return math.Abs(a-b) <= math.Max(floor, 0.05*math.Max(a, b))
+}
+
+// IsDuplicate reports whether a and other are the same activity recorded or
+// exported twice: they start within DuplicateWindow of each other and their
+// fingerprints match
+func (a *Activity) IsDuplicate(other *Activity) bool {
+	gap := a.StartTime.Sub(other.StartTime)
+	if gap < -DuplicateWindow || gap > DuplicateWindow {
+		return false
+	}
+	return a.Fingerprint().Matches(other.Fingerprint())
+}
+
+// FindDuplicate returns the activity in existing that a duplicates, the one
+// starting closest to it when several do, or nil. An activity with a's own ID
+// is the same import being saved again rather than a duplicate, and is ignored
+func FindDuplicate(existing []Activity, a *Activity) *Activity {
+	var found *Activity
+	var closest time.Duration
+	for i := range existing {
+		other := &existing[i]
+		if a.ID != "" && other.ID == a.ID {
+			continue
+		}
+		if !a.IsDuplicate(other) {
+			continue
+		}
+		gap := a.StartTime.Sub(other.StartTime)
+		if gap < 0 {
+			gap = -gap
+		}
+		if found == nil || gap < closest {
+			found, closest = other, gap
+		}
+	}
+	return found
+}
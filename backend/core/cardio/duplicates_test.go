@@ -0,0 +1,58 @@
+package cardio
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFindDuplicate(t *testing.T) {
+	start := time.Date(2024, 3, 5, 7, 0, 0, 0, time.UTC)
+	existing := []Activity{
+		{ID: "fit", Sport: "run", StartTime: start, DurationSeconds: 1800, DistanceMeters: 5000},
+		{ID: "ride", Sport: "ride", StartTime: start, DurationSeconds: 1800, DistanceMeters: 5000},
+	}
+
+	tests := []struct {
+		name     string
+		activity Activity
+		want     string
+	}{
+		{"same recording from another source", Activity{Sport: "run", StartTime: start.Add(90 * time.Second), DurationSeconds: 1790, DistanceMeters: 5030}, "fit"},
+		{"without a distance", Activity{Sport: "run", StartTime: start.Add(-time.Minute), DurationSeconds: 1850}, "fit"},
+		{"outside the window", Activity{Sport: "run", StartTime: start.Add(6 * time.Minute), DurationSeconds: 1800, DistanceMeters: 5000}, ""},
+		{"different duration", Activity{Sport: "run", StartTime: start, DurationSeconds: 2400, DistanceMeters: 5000}, ""},
+		{"different distance", Activity{Sport: "run", StartTime: start, DurationSeconds: 1800, DistanceMeters: 6000}, ""},
+		{"different sport", Activity{Sport: "swim", StartTime: start, DurationSeconds: 1800, DistanceMeters: 5000}, ""},
+		{"the same import saved again", Activity{ID: "fit", Sport: "run", StartTime: start, DurationSeconds: 1800, DistanceMeters: 5000}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got := FindDuplicate(existing, &tt.activity)
+
+			// Assert
+			if tt.want == "" && got != nil {
+				t.Errorf("expected no duplicate, got %s", got.ID)
+			}
+			if tt.want != "" && (got == nil || got.ID != tt.want) {
+				t.Errorf("expected duplicate of %s, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseDuplicateStrategy(t *testing.T) {
+	t.Run("defaults when omitted and rejects unknown strategies", func(t *testing.T) {
+		// Act
+		def, err := ParseDuplicateStrategy("", DuplicateSkip)
+		_, invalid := ParseDuplicateStrategy("merge", DuplicateSkip)
+
+		// Assert
+		if def != DuplicateSkip || err != nil {
+			t.Errorf("expected the default, got %q, %v", def, err)
+		}
+		if invalid == nil {
+			t.Error("expected an error for an unknown strategy")
+		}
+	})
+}
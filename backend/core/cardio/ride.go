@@ -0,0 +1,122 @@
+package cardio
+
+import "math"
+
+// SportRide is the sport whose activities get a ride summary
+const SportRide = "ride"
+
+const (
+	// normalizedWindow is the rolling average, in seconds, that normalized
+	// power is taken over
+	normalizedWindow = 30
+
+	// ftpTestSeconds is the length of the best effort FTP is estimated from
+	ftpTestSeconds = 20 * 60
+)
+
+// RideSummary analyses a ride's power samples. NormalizedPower weights hard
+// efforts as the body feels them, from the fourth-power mean of 30 second
+// rolling averages. IntensityFactor is normalized power over the FTP the ride
+// was scored with and TSS the training stress score, 100 for an hour at FTP;
+// both are 0 without an FTP. Best20MinPower is the highest 20 minute average
+// power, 0 for rides shorter than that
+type RideSummary struct {
+	PoweredSeconds  int     `json:"poweredSeconds"`
+	AveragePower    int     `json:"averagePower"`
+	MaxPower        int     `json:"maxPower"`
+	NormalizedPower int     `json:"normalizedPower"`
+	WorkKilojoules  float64 `json:"workKilojoules"`
+	Best20MinPower  int     `json:"best20MinPower,omitempty"`
+	FTP             int     `json:"ftp,omitempty"`
+	IntensityFactor float64 `json:"intensityFactor,omitempty"`
+	TSS             float64 `json:"tss,omitempty"`
+}
+
+// SummarizeRide analyses a ride's power with the user's FTP, which may be 0,
+// returning nil for other sports or when the samples record no power
+func (a *Activity) SummarizeRide(ftp int) *RideSummary {
+	if a.Sport != SportRide {
+		return nil
+	}
+	watts := secondByWatts(a.Samples)
+	var summary RideSummary
+	total := 0
+	for _, w := range watts {
+		total += w
+		summary.MaxPower = max(summary.MaxPower, w)
+	}
+	if summary.MaxPower == 0 {
+		return nil
+	}
+
+	summary.PoweredSeconds = len(watts)
+	summary.AveragePower = int(math.Round(float64(total) / float64(len(watts))))
+	summary.WorkKilojoules = math.Round(float64(total)/100) / 10
+	summary.NormalizedPower = normalizedPower(watts)
+	summary.Best20MinPower = bestAverage(watts, ftpTestSeconds)
+	if ftp > 0 {
+		intensity := float64(summary.NormalizedPower) / float64(ftp)
+		summary.FTP = ftp
+		summary.IntensityFactor = math.Round(intensity*100) / 100
+		summary.TSS = math.Round(float64(len(watts))*intensity*intensity/3600*1000) / 10
+	}
+	return &summary
+}
+
+// secondByWatts expands samples into the power of each second between the
+// first and last sample, each sample's power covering the seconds since the
+// previous sample
+func secondByWatts(samples []Sample) []int {
+	if len(samples) < 2 {
+		return nil
+	}
+	watts := make([]int, 0, samples[len(samples)-1].Offset-samples[0].Offset)
+	for i := 1; i < len(samples); i++ {
+		for s := samples[i-1].Offset; s < samples[i].Offset; s++ {
+			watts = append(watts, samples[i].Power)
+		}
+	}
+	return watts
+}
+
+// normalizedPower is the fourth root of the mean fourth power of 30 second
+// rolling averages, or the average power for rides shorter than the window
+func normalizedPower(watts []int) int {
+	if len(watts) < normalizedWindow {
+		total := 0
+		for _, w := range watts {
+			total += w
+		}
+		return int(math.Round(float64(total) / float64(len(watts))))
+	}
+	sum, fourth := 0, 0.0
+	for i, w := range watts {
+		sum += w
+		if i >= normalizedWindow {
+			sum -= watts[i-normalizedWindow]
+		}
+		if i >= normalizedWindow-1 {
+			fourth += math.Pow(float64(sum)/normalizedWindow, 4)
+		}
+	}
+	return int(math.Round(math.Pow(fourth/float64(len(watts)-normalizedWindow+1), 0.25)))
+}
+
+// bestAverage returns the highest average power held for seconds, or 0 when
+// the ride is shorter
+func bestAverage(watts []int, seconds int) int {
+	if len(watts) < seconds {
+		return 0
+	}
+	sum, best := 0, 0
+	for i, w := range watts {
+		sum += w
+		if i >= seconds {
+			sum -= watts[i-seconds]
+		}
+		if i >= seconds-1 {
+			best = max(best, sum)
+		}
+	}
+	return int(math.Round(float64(best) / float64(seconds)))
+}
@@ -0,0 +1,66 @@
+package cardio
+
+import "testing"
+
+// poweredRide returns samples every 10 seconds holding watts(second)
+func poweredRide(seconds int, watts func(second int) int) []Sample {
+	samples := []Sample{{Offset: 0}}
+	for s := 10; s <= seconds; s += 10 {
+		samples = append(samples, Sample{Offset: s, Power: watts(s)})
+	}
+	return samples
+}
+
+func TestActivity_SummarizeRide(t *testing.T) {
+	t.Run("an hour at FTP scores 100 TSS", func(t *testing.T) {
+		// Arrange
+		a := Activity{Sport: SportRide, DurationSeconds: 3600, Samples: poweredRide(3600, func(int) int { return 250 })}
+
+		// Act
+		s := a.SummarizeRide(250)
+
+		// Assert
+		expected := RideSummary{
+			PoweredSeconds: 3600, AveragePower: 250, MaxPower: 250, NormalizedPower: 250, WorkKilojoules: 900,
+			Best20MinPower: 250, FTP: 250, IntensityFactor: 1, TSS: 100,
+		}
+		if *s != expected {
+			t.Errorf("expected %+v, got %+v", expected, *s)
+		}
+	})
+
+	t.Run("normalized power weights hard efforts", func(t *testing.T) {
+		// Arrange: half an hour at 300 W then half an hour at 100 W
+		a := Activity{Sport: SportRide, DurationSeconds: 3600, Samples: poweredRide(3600, func(s int) int {
+			if s <= 1800 {
+				return 300
+			}
+			return 100
+		})}
+
+		// Act
+		s := a.SummarizeRide(250)
+
+		// Assert
+		if s.AveragePower != 200 || s.NormalizedPower < 250 || s.Best20MinPower != 300 {
+			t.Errorf("unexpected summary: %+v", s)
+		}
+		if s.IntensityFactor <= 1 || s.TSS <= 100 {
+			t.Errorf("expected the ride scored above threshold, got IF %v TSS %v", s.IntensityFactor, s.TSS)
+		}
+	})
+
+	t.Run("leaves intensity out without an FTP", func(t *testing.T) {
+		a := Activity{Sport: SportRide, DurationSeconds: 600, Samples: poweredRide(600, func(int) int { return 180 })}
+		if s := a.SummarizeRide(0); s.IntensityFactor != 0 || s.TSS != 0 || s.Best20MinPower != 0 {
+			t.Errorf("unexpected summary: %+v", s)
+		}
+	})
+
+	t.Run("returns nil without power", func(t *testing.T) {
+		a := Activity{Sport: SportRide, DurationSeconds: 600, Samples: poweredRide(600, func(int) int { return 0 })}
+		if s := a.SummarizeRide(250); s != nil {
+			t.Errorf("expected no summary, got %+v", s)
+		}
+	})
+}
@@ -0,0 +1,207 @@
+package cardio
+
+import (
+	"math"
+	"sort"
+
+	"athlete-forge/hrzone"
+	"athlete-forge/runzone"
+)
+
+// SportRun is the sport whose activities get a run summary
+const SportRun = "run"
+
+const (
+	// movingSpeed is the slowest speed, in metres per second, counted as
+	// moving; slower stretches are stops and are left out of run analytics
+	movingSpeed = 0.5
+
+	// maxGrade bounds the grade of a stretch, beyond which altitude readings
+	// are more likely noise than terrain
+	maxGrade = 0.45
+
+	// Pace distribution buckets, in seconds per kilometre: 30 second buckets
+	// from 3:00 to 10:00, the first and last also holding anything faster or
+	// slower
+	bucketWidth   = 30
+	fastestBucket = 180
+	slowestBucket = 600
+)
+
+// Sample is a reading at Offset seconds from the activity start: the distance
+// covered so far and the altitude in metres, and the power in watts, each 0
+// when the device did not record it
+type Sample struct {
+	Offset   int     `json:"offset"`
+	Distance float64 `json:"distance"`
+	Altitude float64 `json:"altitude,omitempty"`
+	Power    int     `json:"power,omitempty"`
+}
+
+// PaceBucket is the moving time spent at paces from Pace to Pace plus 30
+// seconds per kilometre
+type PaceBucket struct {
+	Pace    int `json:"pace"`
+	Seconds int `json:"seconds"`
+}
+
+// RunSummary analyses a run's samples. Paces are in seconds per kilometre over
+// moving time. GradeAdjustedPace is the pace the same effort would have run
+// on the flat, from the distance EquivalentFlatMeters that effort would cover.
+// AveragePower is over the PoweredSeconds with a power reading. PaceZones
+// scores grade-adjusted pace against the threshold pace and PowerZones power
+// against the threshold power, each only when the threshold is configured;
+// Load is scored like heart rate TRIMP from the pace zones, or the power zones
+// without them, and is 0 without either
+type RunSummary struct {
+	MovingSeconds        int             `json:"movingSeconds"`
+	DistanceMeters       float64         `json:"distanceMeters"`
+	EquivalentFlatMeters float64         `json:"equivalentFlatMeters"`
+	ElevationGainMeters  float64         `json:"elevationGainMeters"`
+	AveragePace          int             `json:"averagePace"`
+	GradeAdjustedPace    int             `json:"gradeAdjustedPace"`
+	AveragePower         int             `json:"averagePower,omitempty"`
+	PoweredSeconds       int             `json:"poweredSeconds,omitempty"`
+	Distribution         []PaceBucket    `json:"paceDistribution"`
+	PaceZones            *hrzone.Summary `json:"paceZones,omitempty"`
+	PowerZones           *hrzone.Summary `json:"powerZones,omitempty"`
+	Load                 float64         `json:"load"`
+}
+
+// SummarizeRun analyses a run's samples with the user's running zones, which
+// may be nil, returning nil for other sports or when the samples cover no
+// moving distance
+func (a *Activity) SummarizeRun(config *runzone.Config) *RunSummary {
+	if a.Sport != SportRun || len(a.Samples) < 2 {
+		return nil
+	}
+
+	var summary RunSummary
+	buckets := map[int]int{}
+	// Seconds in zones 1-5 by zone number; running zones have nothing below zone 1
+	paceSeconds := make([]int, 6)
+	powerSeconds := make([]int, 6)
+	wattSeconds := 0
+	for i := 1; i < len(a.Samples); i++ {
+		previous, current := a.Samples[i-1], a.Samples[i]
+		seconds, metres := current.Offset-previous.Offset, current.Distance-previous.Distance
+		if seconds <= 0 || metres/float64(seconds) < movingSpeed {
+			continue
+		}
+		climb := current.Altitude - previous.Altitude
+		grade := math.Max(-maxGrade, math.Min(maxGrade, climb/metres))
+		flat := metres * runningCost(grade) / runningCost(0)
+
+		summary.MovingSeconds += seconds
+		summary.DistanceMeters += metres
+		summary.EquivalentFlatMeters += flat
+		summary.ElevationGainMeters += math.Max(climb, 0)
+		buckets[bucket(float64(seconds)/metres*1000)] += seconds
+
+		if config != nil {
+			paceSeconds[config.ZoneForSpeed(flat/float64(seconds))] += seconds
+			if current.Power > 0 {
+				powerSeconds[config.ZoneForPower(current.Power)] += seconds
+			}
+		}
+		if current.Power > 0 {
+			summary.PoweredSeconds += seconds
+			wattSeconds += current.Power * seconds
+		}
+	}
+	if summary.MovingSeconds == 0 {
+		return nil
+	}
+
+	summary.Distribution = distribution(buckets)
+	if summary.PoweredSeconds > 0 {
+		summary.AveragePower = wattSeconds / summary.PoweredSeconds
+	}
+	if config != nil && config.ThresholdPace > 0 {
+		zones := hrzone.SummaryOf(paceSeconds)
+		summary.PaceZones = &zones
+	}
+	if config != nil && config.ThresholdPower > 0 && summary.PoweredSeconds > 0 {
+		zones := hrzone.SummaryOf(powerSeconds)
+		summary.PowerZones = &zones
+	}
+	summary.finish()
+	return &summary
+}
+
+// Add accumulates other into s, as for a week of runs
+func (s *RunSummary) Add(other RunSummary) {
+	if other.PoweredSeconds > 0 {
+		s.AveragePower = (s.AveragePower*s.PoweredSeconds + other.AveragePower*other.PoweredSeconds) / (s.PoweredSeconds + other.PoweredSeconds)
+		s.PoweredSeconds += other.PoweredSeconds
+	}
+	s.MovingSeconds += other.MovingSeconds
+	s.DistanceMeters += other.DistanceMeters
+	s.EquivalentFlatMeters += other.EquivalentFlatMeters
+	s.ElevationGainMeters += other.ElevationGainMeters
+
+	buckets := map[int]int{}
+	for _, b := range append(s.Distribution, other.Distribution...) {
+		buckets[b.Pace] += b.Seconds
+	}
+	s.Distribution = distribution(buckets)
+	s.PaceZones = addZones(s.PaceZones, other.PaceZones)
+	s.PowerZones = addZones(s.PowerZones, other.PowerZones)
+	s.finish()
+}
+
+// finish rounds the totals and derives the paces and load from them
+func (s *RunSummary) finish() {
+	s.DistanceMeters = math.Round(s.DistanceMeters*10) / 10
+	s.EquivalentFlatMeters = math.Round(s.EquivalentFlatMeters*10) / 10
+	s.ElevationGainMeters = math.Round(s.ElevationGainMeters*10) / 10
+	s.AveragePace, s.GradeAdjustedPace = 0, 0
+	if s.DistanceMeters > 0 {
+		s.AveragePace = int(math.Round(float64(s.MovingSeconds) / s.DistanceMeters * 1000))
+	}
+	if s.EquivalentFlatMeters > 0 {
+		s.GradeAdjustedPace = int(math.Round(float64(s.MovingSeconds) / s.EquivalentFlatMeters * 1000))
+	}
+	switch {
+	case s.PaceZones != nil:
+		s.Load = s.PaceZones.Load
+	case s.PowerZones != nil:
+		s.Load = s.PowerZones.Load
+	default:
+		s.Load = 0
+	}
+}
+
+// runningCost is Minetti's energy cost of running at grade, in joules per
+// kilogram per metre
+func runningCost(grade float64) float64 {
+	return 155.4*math.Pow(grade, 5) - 30.4*math.Pow(grade, 4) - 43.3*math.Pow(grade, 3) + 46.3*grade*grade + 19.5*grade + 3.6
+}
+
+// bucket returns the distribution bucket a pace falls in
+func bucket(pace float64) int {
+	b := int(pace) / bucketWidth * bucketWidth
+	return max(fastestBucket, min(b, slowestBucket))
+}
+
+// distribution lists buckets fastest first
+func distribution(buckets map[int]int) []PaceBucket {
+	list := make([]PaceBucket, 0, len(buckets))
+	for pace, seconds := range buckets {
+		list = append(list, PaceBucket{Pace: pace, Seconds: seconds})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Pace < list[j].Pace })
+	return list
+}
+
+func addZones(total, other *hrzone.Summary) *hrzone.Summary {
+	if other == nil {
+		return total
+	}
+	if total == nil {
+		zones := hrzone.EmptySummary()
+		total = &zones
+	}
+	total.Add(*other)
+	return total
+}
@@ -0,0 +1,112 @@
+package cardio
+
+import (
+	"testing"
+
+	"athlete-forge/runzone"
+)
+
+// steadyRun returns samples every minute covering metres per minute, climbing
+// climb metres each minute
+func steadyRun(minutes int, metres, climb float64) []Sample {
+	samples := make([]Sample, minutes+1)
+	for i := range samples {
+		samples[i] = Sample{Offset: i * 60, Distance: float64(i) * metres, Altitude: float64(i) * climb}
+	}
+	return samples
+}
+
+func TestActivity_SummarizeRun(t *testing.T) {
+	t.Run("flat pace equals grade-adjusted pace", func(t *testing.T) {
+		// Arrange
+		a := Activity{Sport: SportRun, DurationSeconds: 600, Samples: steadyRun(10, 250, 0)}
+
+		// Act
+		s := a.SummarizeRun(nil)
+
+		// Assert
+		if s.MovingSeconds != 600 || s.DistanceMeters != 2500 || s.AveragePace != 240 || s.GradeAdjustedPace != 240 {
+			t.Errorf("unexpected summary: %+v", s)
+		}
+		if len(s.Distribution) != 1 || s.Distribution[0] != (PaceBucket{Pace: 240, Seconds: 600}) {
+			t.Errorf("unexpected distribution: %+v", s.Distribution)
+		}
+		if s.PaceZones != nil || s.Load != 0 {
+			t.Errorf("expected no zones or load without a config, got %+v", s)
+		}
+	})
+
+	t.Run("climbing runs a faster grade-adjusted pace", func(t *testing.T) {
+		// Arrange
+		a := Activity{Sport: SportRun, DurationSeconds: 600, Samples: steadyRun(10, 200, 10)}
+
+		// Act
+		s := a.SummarizeRun(nil)
+
+		// Assert
+		if s.AveragePace != 300 || s.GradeAdjustedPace >= 300 || s.ElevationGainMeters != 100 {
+			t.Errorf("unexpected summary: %+v", s)
+		}
+	})
+
+	t.Run("leaves stops out of moving time", func(t *testing.T) {
+		// Arrange
+		samples := append(steadyRun(5, 250, 0), Sample{Offset: 600, Distance: 1250})
+
+		// Act
+		s := (&Activity{Sport: SportRun, DurationSeconds: 600, Samples: samples}).SummarizeRun(nil)
+
+		// Assert
+		if s.MovingSeconds != 300 || s.AveragePace != 240 {
+			t.Errorf("unexpected summary: %+v", s)
+		}
+	})
+
+	t.Run("scores zones and load from the thresholds", func(t *testing.T) {
+		// Arrange
+		samples := steadyRun(10, 250, 0)
+		for i := range samples {
+			samples[i].Power = 230
+		}
+		a := Activity{Sport: SportRun, DurationSeconds: 600, Samples: samples}
+
+		// Act
+		s := a.SummarizeRun(&runzone.Config{ThresholdPace: 300, ThresholdPower: 250})
+
+		// Assert
+		if s.PaceZones == nil || s.PaceZones.Zones[4].Seconds != 600 || s.Load != 50 {
+			t.Errorf("unexpected pace zones: %+v", s)
+		}
+		if s.PowerZones == nil || s.PowerZones.Zones[2].Seconds != 600 || s.AveragePower != 230 {
+			t.Errorf("unexpected power zones: %+v", s)
+		}
+	})
+
+	t.Run("returns nil for other sports", func(t *testing.T) {
+		a := Activity{Sport: "ride", DurationSeconds: 600, Samples: steadyRun(10, 500, 0)}
+		if s := a.SummarizeRun(nil); s != nil {
+			t.Errorf("expected no summary, got %+v", s)
+		}
+	})
+}
+
+func TestRunSummary_Add(t *testing.T) {
+	// Arrange
+	config := &runzone.Config{ThresholdPace: 300}
+	total := *(&Activity{Sport: SportRun, Samples: steadyRun(10, 250, 0)}).SummarizeRun(config)
+	slow := (&Activity{Sport: SportRun, Samples: steadyRun(10, 125, 0)}).SummarizeRun(config)
+
+	// Act
+	total.Add(*slow)
+
+	// Assert
+	if total.MovingSeconds != 1200 || total.DistanceMeters != 3750 || total.AveragePace != 320 {
+		t.Errorf("unexpected totals: %+v", total)
+	}
+	if len(total.Distribution) != 2 || total.Distribution[1].Pace != 480 {
+		t.Errorf("unexpected distribution: %+v", total.Distribution)
+	}
+	if total.Load != 60 {
+		t.Errorf("expected load 60, got %v", total.Load)
+	}
+}
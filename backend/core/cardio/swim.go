@@ -0,0 +1,293 @@
+package cardio
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"athlete-forge/fit"
+)
+
+// SportSwim is the sport whose activities can have pool lengths
+const SportSwim = "swim"
+
+// Strokes a length can be swum with; mixed lengths change stroke partway and
+// im lengths are part of an individual medley
+const (
+	StrokeFreestyle    = "freestyle"
+	StrokeBackstroke   = "backstroke"
+	StrokeBreaststroke = "breaststroke"
+	StrokeButterfly    = "butterfly"
+	StrokeDrill        = "drill"
+	StrokeMixed        = "mixed"
+	StrokeIM           = "im"
+)
+
+// fitStrokes maps FIT swim_stroke values to strokes, in the profile's order
+var fitStrokes = []string{StrokeFreestyle, StrokeBackstroke, StrokeBreaststroke, StrokeButterfly, StrokeDrill, StrokeMixed, StrokeIM}
+
+// fitSports maps FIT sports to the sports activities are imported as
+var fitSports = map[uint64]string{
+	fit.SportRunning:  "run",
+	fit.SportCycling:  SportRide,
+	fit.SportSwimming: SportSwim,
+	fit.SportRowing:   "row",
+	fit.SportWalking:  "walk",
+	fit.SportHiking:   "hike",
+}
+
+// Limits on pool swims
+const (
+	maxLengths          = 2000
+	minPoolLength       = 10
+	maxPoolLength       = 100
+	maxStrokesPerLength = 200
+)
+
+// FIT field numbers read from activity files
+const (
+	sessionStartTime    = 2
+	sessionSport        = 5
+	sessionElapsedTime  = 7
+	sessionDistance     = 9
+	sessionAverageHR    = 16
+	sessionMaxHR        = 17
+	sessionPoolLength   = 44
+	lengthStartTime     = 2
+	lengthTimerTime     = 4
+	lengthTotalStrokes  = 5
+	lengthSwimStroke    = 7
+	lengthType          = 12
+	lengthTypeIdle      = 0
+	fitScaleSeconds     = 1000
+	fitScaleCentimetres = 100
+)
+
+// Length is one length of a pool swim, starting Offset seconds into the swim.
+// Rest lengths are time spent at the wall, with no stroke or distance
+type Length struct {
+	Offset  int     `json:"offset"`
+	Seconds float64 `json:"seconds"`
+	Stroke  string  `json:"stroke,omitempty"`
+	Strokes int     `json:"strokes,omitempty"`
+	Rest    bool    `json:"rest,omitempty"`
+}
+
+// StrokeSummary totals the lengths swum with one stroke
+type StrokeSummary struct {
+	Stroke         string  `json:"stroke"`
+	Lengths        int     `json:"lengths"`
+	DistanceMeters float64 `json:"distanceMeters"`
+	Seconds        float64 `json:"seconds"`
+	PacePer100m    float64 `json:"pacePer100m"`
+	AverageSWOLF   float64 `json:"averageSwolf,omitempty"`
+}
+
+// SwimSummary analyses a pool swim's lengths. Paces are in seconds per 100
+// metres of swimming, leaving out rest. SWOLF scores a length as its seconds
+// plus its stroke count, lower being more efficient, and is only averaged over
+// lengths with a stroke count
+type SwimSummary struct {
+	PoolLengthMeters float64         `json:"poolLengthMeters"`
+	Lengths          int             `json:"lengths"`
+	DistanceMeters   float64         `json:"distanceMeters"`
+	SwimSeconds      float64         `json:"swimSeconds"`
+	RestSeconds      float64         `json:"restSeconds"`
+	PacePer100m      float64         `json:"pacePer100m"`
+	AverageSWOLF     float64         `json:"averageSwolf,omitempty"`
+	BestSWOLF        int             `json:"bestSwolf,omitempty"`
+	Strokes          []StrokeSummary `json:"strokes"`
+}
+
+// validateLengths checks a pool swim's pool length and lengths
+func (a *Activity) validateLengths() error {
+	if len(a.Lengths) == 0 {
+		return nil
+	}
+	if a.Sport != SportSwim {
+		return errors.New("only swims can have lengths")
+	}
+	if len(a.Lengths) > maxLengths {
+		return fmt.Errorf("a swim can have at most %d lengths", maxLengths)
+	}
+	if a.PoolLength < minPoolLength || a.PoolLength > maxPoolLength {
+		return fmt.Errorf("poolLengthMeters must be between %d and %d", minPoolLength, maxPoolLength)
+	}
+	last := 0
+	for i, length := range a.Lengths {
+		if length.Offset < last || length.Seconds <= 0 || math.IsNaN(length.Seconds) {
+			return fmt.Errorf("length %d must start no earlier than the one before and last a positive time", i+1)
+		}
+		if length.Rest {
+			if length.Stroke != "" || length.Strokes != 0 {
+				return fmt.Errorf("length %d is rest and cannot have a stroke", i+1)
+			}
+		} else if !validStroke(length.Stroke) {
+			return fmt.Errorf("length %d has unknown stroke %q", i+1, length.Stroke)
+		}
+		if length.Strokes < 0 || length.Strokes > maxStrokesPerLength {
+			return fmt.Errorf("length %d must have between 0 and %d strokes", i+1, maxStrokesPerLength)
+		}
+		last = length.Offset
+	}
+	return nil
+}
+
+func validStroke(stroke string) bool {
+	for _, s := range fitStrokes {
+		if s == stroke {
+			return true
+		}
+	}
+	return false
+}
+
+// SummarizeSwim analyses a pool swim's lengths, returning nil for other sports
+// or swims without lengths
+func (a *Activity) SummarizeSwim() *SwimSummary {
+	if a.Sport != SportSwim || len(a.Lengths) == 0 {
+		return nil
+	}
+
+	summary := SwimSummary{PoolLengthMeters: a.PoolLength, Strokes: []StrokeSummary{}}
+	// index finds each stroke's summary, kept in the order strokes were first swum
+	index := map[string]int{}
+	swolf := map[string][2]int{}
+	var swolfTotal, swolfLengths int
+	for _, length := range a.Lengths {
+		if length.Rest {
+			summary.RestSeconds += length.Seconds
+			continue
+		}
+		summary.Lengths++
+		summary.SwimSeconds += length.Seconds
+
+		i, ok := index[length.Stroke]
+		if !ok {
+			i = len(summary.Strokes)
+			index[length.Stroke] = i
+			summary.Strokes = append(summary.Strokes, StrokeSummary{Stroke: length.Stroke})
+		}
+		s := &summary.Strokes[i]
+		s.Lengths++
+		s.Seconds += length.Seconds
+		if length.Strokes > 0 {
+			score := int(math.Round(length.Seconds)) + length.Strokes
+			total := swolf[length.Stroke]
+			swolf[length.Stroke] = [2]int{total[0] + score, total[1] + 1}
+			swolfTotal += score
+			swolfLengths++
+			if summary.BestSWOLF == 0 || score < summary.BestSWOLF {
+				summary.BestSWOLF = score
+			}
+		}
+	}
+	if summary.Lengths == 0 {
+		return nil
+	}
+
+	summary.DistanceMeters = float64(summary.Lengths) * a.PoolLength
+	summary.PacePer100m = pacePer100m(summary.SwimSeconds, summary.DistanceMeters)
+	summary.AverageSWOLF = average(swolfTotal, swolfLengths)
+	summary.SwimSeconds = math.Round(summary.SwimSeconds*10) / 10
+	summary.RestSeconds = math.Round(summary.RestSeconds*10) / 10
+	for i := range summary.Strokes {
+		s := &summary.Strokes[i]
+		s.DistanceMeters = float64(s.Lengths) * a.PoolLength
+		s.PacePer100m = pacePer100m(s.Seconds, s.DistanceMeters)
+		s.AverageSWOLF = average(swolf[s.Stroke][0], swolf[s.Stroke][1])
+		s.Seconds = math.Round(s.Seconds*10) / 10
+	}
+	return &summary
+}
+
+func pacePer100m(seconds, metres float64) float64 {
+	return math.Round(seconds/metres*100*10) / 10
+}
+
+func average(total, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+	return math.Round(float64(total)/float64(count)*10) / 10
+}
+
+// FromFIT reads an activity from a FIT activity file's session: its sport,
+// start, duration, distance and heart rate, and for pool swims the pool
+// length and lengths
+func FromFIT(data []byte) (*Activity, error) {
+	messages, err := fit.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var a *Activity
+	var lengths []fit.Message
+	for _, m := range messages {
+		switch m.Global {
+		case fit.MesgSession:
+			if a == nil {
+				a = activityFromSession(m)
+			}
+		case fit.MesgLength:
+			lengths = append(lengths, m)
+		}
+	}
+	if a == nil {
+		return nil, errors.New("FIT file has no activity session")
+	}
+	if a.Sport != SportSwim || a.PoolLength == 0 {
+		return a, nil
+	}
+
+	for _, m := range lengths {
+		seconds, _ := m.Value(lengthTimerTime)
+		length := Length{Seconds: float64(seconds) / fitScaleSeconds}
+		if start, ok := m.Value(lengthStartTime); ok {
+			length.Offset = max(0, int(fit.Time(start).Sub(a.StartTime)/time.Second))
+		}
+		if kind, ok := m.Value(lengthType); ok && kind == lengthTypeIdle {
+			length.Rest = true
+			a.Lengths = append(a.Lengths, length)
+			continue
+		}
+		length.Stroke = StrokeMixed
+		if stroke, ok := m.Value(lengthSwimStroke); ok && stroke < uint64(len(fitStrokes)) {
+			length.Stroke = fitStrokes[stroke]
+		}
+		if strokes, ok := m.Value(lengthTotalStrokes); ok {
+			length.Strokes = int(strokes)
+		}
+		a.Lengths = append(a.Lengths, length)
+	}
+	return a, nil
+}
+
+func activityFromSession(m fit.Message) *Activity {
+	a := &Activity{Sport: "other"}
+	if sport, ok := m.Value(sessionSport); ok {
+		if name, known := fitSports[sport]; known {
+			a.Sport = name
+		}
+	}
+	if start, ok := m.Value(sessionStartTime); ok {
+		a.StartTime = fit.Time(start)
+	}
+	if elapsed, ok := m.Value(sessionElapsedTime); ok {
+		a.DurationSeconds = int(math.Round(float64(elapsed) / fitScaleSeconds))
+	}
+	if distance, ok := m.Value(sessionDistance); ok {
+		a.DistanceMeters = float64(distance) / fitScaleCentimetres
+	}
+	if hr, ok := m.Value(sessionAverageHR); ok {
+		a.AverageHR = int(hr)
+	}
+	if hr, ok := m.Value(sessionMaxHR); ok {
+		a.MaxHR = int(hr)
+	}
+	if pool, ok := m.Value(sessionPoolLength); ok {
+		a.PoolLength = float64(pool) / fitScaleCentimetres
+	}
+	return a
+}
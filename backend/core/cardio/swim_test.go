@@ -0,0 +1,169 @@
+package cardio
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"athlete-forge/fit"
+)
+
+// fitField is an integer field written by fitFile
+type fitField struct {
+	num, size, baseType uint8
+	value               uint64
+}
+
+// fitFile builds a FIT file holding a data message of each global message
+// number with fields, in order
+func fitFile(messages []uint16, fields [][]fitField) []byte {
+	var data []byte
+	for i, global := range messages {
+		data = append(data, 0x40, 0, 0)
+		data = binary.LittleEndian.AppendUint16(data, global)
+		data = append(data, uint8(len(fields[i])))
+		for _, f := range fields[i] {
+			data = append(data, f.num, f.size, f.baseType)
+		}
+		data = append(data, 0)
+		for _, f := range fields[i] {
+			value := make([]byte, 8)
+			binary.LittleEndian.PutUint64(value, f.value)
+			data = append(data, value[:f.size]...)
+		}
+	}
+	header := []byte{12, 0x20, 0, 0, 0, 0, 0, 0, '.', 'F', 'I', 'T'}
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(data)))
+	file := append(header, data...)
+	return binary.LittleEndian.AppendUint16(file, fit.CRC(file))
+}
+
+func TestActivity_SummarizeSwim(t *testing.T) {
+	t.Run("totals lengths by stroke with pace and SWOLF", func(t *testing.T) {
+		// Arrange
+		a := Activity{Sport: SportSwim, PoolLength: 25, Lengths: []Length{
+			{Offset: 0, Seconds: 20, Stroke: StrokeFreestyle, Strokes: 15},
+			{Offset: 20, Seconds: 22, Stroke: StrokeFreestyle, Strokes: 16},
+			{Offset: 42, Seconds: 30, Rest: true},
+			{Offset: 72, Seconds: 28, Stroke: StrokeBreaststroke, Strokes: 10},
+			{Offset: 100, Seconds: 30, Stroke: StrokeDrill},
+		}}
+
+		// Act
+		s := a.SummarizeSwim()
+
+		// Assert
+		if s.Lengths != 4 || s.DistanceMeters != 100 || s.SwimSeconds != 100 || s.RestSeconds != 30 || s.PacePer100m != 100 {
+			t.Errorf("unexpected totals: %+v", s)
+		}
+		if s.AverageSWOLF != 37 || s.BestSWOLF != 35 {
+			t.Errorf("expected SWOLF average 37 and best 35, got %v and %d", s.AverageSWOLF, s.BestSWOLF)
+		}
+		if len(s.Strokes) != 3 || s.Strokes[0] != (StrokeSummary{Stroke: StrokeFreestyle, Lengths: 2, DistanceMeters: 50, Seconds: 42, PacePer100m: 84, AverageSWOLF: 36.5}) {
+			t.Errorf("unexpected strokes: %+v", s.Strokes)
+		}
+		if s.Strokes[2].AverageSWOLF != 0 {
+			t.Errorf("expected no SWOLF without stroke counts, got %+v", s.Strokes[2])
+		}
+	})
+
+	t.Run("returns nil without lengths", func(t *testing.T) {
+		if s := (&Activity{Sport: SportSwim}).SummarizeSwim(); s != nil {
+			t.Errorf("expected no summary, got %+v", s)
+		}
+	})
+}
+
+func TestActivity_ValidateLengths(t *testing.T) {
+	start := time.Date(2024, 3, 4, 7, 0, 0, 0, time.UTC)
+	swim := func(pool float64, lengths ...Length) Activity {
+		return Activity{Sport: SportSwim, StartTime: start, DurationSeconds: 600, PoolLength: pool, Lengths: lengths}
+	}
+	tests := []struct {
+		name     string
+		activity Activity
+		wantErr  bool
+	}{
+		{name: "valid", activity: swim(25, Length{Seconds: 20, Stroke: StrokeFreestyle, Strokes: 14}, Length{Offset: 20, Seconds: 15, Rest: true})},
+		{name: "missing pool length", activity: swim(0, Length{Seconds: 20, Stroke: StrokeFreestyle}), wantErr: true},
+		{name: "unknown stroke", activity: swim(25, Length{Seconds: 20, Stroke: "doggy"}), wantErr: true},
+		{name: "rest with a stroke", activity: swim(25, Length{Seconds: 20, Stroke: StrokeFreestyle, Rest: true}), wantErr: true},
+		{name: "lengths out of order", activity: swim(25, Length{Offset: 30, Seconds: 20, Stroke: StrokeFreestyle}, Length{Offset: 10, Seconds: 20, Stroke: StrokeFreestyle}), wantErr: true},
+		{
+			name:     "lengths on a run",
+			activity: Activity{Sport: "run", StartTime: start, DurationSeconds: 600, PoolLength: 25, Lengths: []Length{{Seconds: 20, Stroke: StrokeFreestyle}}},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.activity.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// poolSwimFIT is a FIT file of a 25 m pool swim starting at start: a
+// freestyle length, a rest and a backstroke length
+func poolSwimFIT(start time.Time) []byte {
+	ts := uint64(start.Sub(time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)) / time.Second)
+	length := func(offset, millis, stroke, strokes, kind uint64) []fitField {
+		return []fitField{
+			{num: 2, size: 4, baseType: 0x86, value: ts + offset}, {num: 4, size: 4, baseType: 0x86, value: millis},
+			{num: 5, size: 2, baseType: 0x84, value: strokes}, {num: 7, size: 1, baseType: 0x00, value: stroke},
+			{num: 12, size: 1, baseType: 0x00, value: kind},
+		}
+	}
+	return fitFile(
+		[]uint16{fit.MesgLength, fit.MesgLength, fit.MesgLength, fit.MesgSession},
+		[][]fitField{
+			length(0, 21500, 0, 16, 1),
+			length(22, 30000, 0xFF, 0xFFFF, 0),
+			length(52, 26000, 1, 18, 1),
+			{
+				{num: 2, size: 4, baseType: 0x86, value: ts}, {num: 5, size: 1, baseType: 0x00, value: fit.SportSwimming},
+				{num: 7, size: 4, baseType: 0x86, value: 80000}, {num: 9, size: 4, baseType: 0x86, value: 5000},
+				{num: 16, size: 1, baseType: 0x02, value: 132}, {num: 44, size: 2, baseType: 0x84, value: 2500},
+			},
+		},
+	)
+}
+
+func TestFromFIT(t *testing.T) {
+	t.Run("reads a pool swim's session and lengths", func(t *testing.T) {
+		// Arrange
+		start := time.Date(2024, 3, 6, 6, 30, 0, 0, time.UTC)
+
+		// Act
+		a, err := FromFIT(poolSwimFIT(start))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if a.Sport != SportSwim || !a.StartTime.Equal(start) || a.DurationSeconds != 80 || a.DistanceMeters != 50 || a.AverageHR != 132 || a.PoolLength != 25 {
+			t.Errorf("unexpected activity: %+v", a)
+		}
+		expected := []Length{
+			{Offset: 0, Seconds: 21.5, Stroke: StrokeFreestyle, Strokes: 16},
+			{Offset: 22, Seconds: 30, Rest: true},
+			{Offset: 52, Seconds: 26, Stroke: StrokeBackstroke, Strokes: 18},
+		}
+		if len(a.Lengths) != len(expected) {
+			t.Fatalf("expected %d lengths, got %+v", len(expected), a.Lengths)
+		}
+		for i := range expected {
+			if a.Lengths[i] != expected[i] {
+				t.Errorf("length %d: expected %+v, got %+v", i+1, expected[i], a.Lengths[i])
+			}
+		}
+	})
+
+	t.Run("rejects files without a session", func(t *testing.T) {
+		file := fitFile([]uint16{fit.MesgLength}, [][]fitField{{{num: 4, size: 4, baseType: 0x86, value: 20000}}})
+		if _, err := FromFIT(file); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package cdn
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"athlete-forge/awsapi"
+)
+
+// Invalidator removes cached copies of API paths from the CDN in front of the API
+type Invalidator interface {
+	// Invalidate drops paths from every edge cache; a trailing * matches any suffix
+	Invalidate(ctx context.Context, paths ...string) error
+}
+
+// CloudFront invalidates paths in a CloudFront distribution. CloudFront is a
+// global service, so its client must sign for us-east-1
+type CloudFront struct {
+	client *awsapi.Client
+
+	// ssm and parameter name where the distribution ID is read from when it is
+	// not known up front
+	ssm       *awsapi.Client
+	parameter string
+
+	mu             sync.Mutex
+	distributionID string
+}
+
+// NewCloudFront creates a CloudFront invalidator for distributionID
+func NewCloudFront(client *awsapi.Client, distributionID string) *CloudFront {
+	return &CloudFront{client: client, distributionID: distributionID}
+}
+
+// NewCloudFrontFromParameter creates a CloudFront invalidator for the distribution
+// whose ID is stored in the SSM parameter named parameter. The distribution
+// routes to this function, so the function cannot be configured with its ID
+// directly; it is read on the first invalidation
+func NewCloudFrontFromParameter(client, ssm *awsapi.Client, parameter string) *CloudFront {
+	return &CloudFront{client: client, ssm: ssm, parameter: parameter}
+}
+
+// invalidationBatch is the CreateInvalidation request body
+type invalidationBatch struct {
+	XMLName xml.Name `xml:"http://cloudfront.amazonaws.com/doc/2020-05-31/ InvalidationBatch"`
+	Paths   struct {
+		Quantity int      `xml:"Quantity"`
+		Items    []string `xml:"Items>Path"`
+	} `xml:"Paths"`
+	CallerReference string `xml:"CallerReference"`
+}
+
+// Invalidate starts an invalidation of paths; CloudFront completes it within a
+// few minutes
+func (c *CloudFront) Invalidate(ctx context.Context, paths ...string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	distributionID, err := c.distribution(ctx)
+	if err != nil {
+		return err
+	}
+
+	var batch invalidationBatch
+	batch.Paths.Quantity = len(paths)
+	batch.Paths.Items = paths
+	batch.CallerReference = strconv.FormatInt(time.Now().UnixNano(), 10)
+	body, err := xml.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal invalidation: %w", err)
+	}
+
+	base := "https://cloudfront.amazonaws.com"
+	if c.client.Endpoint != nil {
+		base = c.client.URL("cloudfront")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/2020-05-31/distribution/"+distributionID+"/invalidation", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create CreateInvalidation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/xml")
+
+	if _, err := c.client.Do(req, body, "cloudfront"); err != nil {
+		return fmt.Errorf("failed to invalidate %d paths: %w", len(paths), err)
+	}
+	return nil
+}
+
+// distribution returns the distribution ID, reading it from SSM the first time
+func (c *CloudFront) distribution(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.distributionID != "" {
+		return c.distributionID, nil
+	}
+
+	var out struct {
+		Parameter struct {
+			Value string `json:"Value"`
+		} `json:"Parameter"`
+	}
+	if err := c.ssm.Call(ctx, awsapi.SSM, "GetParameter", map[string]string{"Name": c.parameter}, &out); err != nil {
+		return "", fmt.Errorf("failed to read distribution ID: %w", err)
+	}
+	c.distributionID = out.Parameter.Value
+	return c.distributionID, nil
+}
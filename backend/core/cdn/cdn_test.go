@@ -0,0 +1,78 @@
+package cdn
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"athlete-forge/awsapi"
+)
+
+func TestCloudFront(t *testing.T) {
+	type request struct {
+		method, path, target, body string
+	}
+	newClient := func(t *testing.T, requests *[]request) *awsapi.Client {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			data, _ := io.ReadAll(r.Body)
+			*requests = append(*requests, request{r.Method, r.URL.Path, r.Header.Get("X-Amz-Target"), string(data)})
+			if r.Header.Get("X-Amz-Target") != "" {
+				w.Write([]byte(`{"Parameter":{"Value":"EDFDVBD6EXAMPLE"}}`))
+				return
+			}
+			w.WriteHeader(201)
+		}))
+		t.Cleanup(server.Close)
+		client := awsapi.NewClient("us-east-1", awsapi.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"})
+		client.Endpoint = func(string) string { return server.URL }
+		return client
+	}
+
+	t.Run("creates an invalidation for the paths", func(t *testing.T) {
+		// Arrange
+		var requests []request
+		c := NewCloudFront(newClient(t, &requests), "EDFDVBD6EXAMPLE")
+
+		// Act
+		err := c.Invalidate(context.Background(), "/api/exercises/catalog", "/api/calendar/feeds/user-1/*")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(requests) != 1 || requests[0].method != "POST" || requests[0].path != "/2020-05-31/distribution/EDFDVBD6EXAMPLE/invalidation" {
+			t.Fatalf("unexpected requests: %+v", requests)
+		}
+		body := requests[0].body
+		for _, want := range []string{"<Quantity>2</Quantity>", "<Path>/api/exercises/catalog</Path>", "<Path>/api/calendar/feeds/user-1/*</Path>", "<CallerReference>"} {
+			if !strings.Contains(body, want) {
+				t.Errorf("expected %s in %s", want, body)
+			}
+		}
+	})
+
+	t.Run("reads the distribution ID from SSM once", func(t *testing.T) {
+		// Arrange
+		var requests []request
+		client := newClient(t, &requests)
+		c := NewCloudFrontFromParameter(client, client, "/workout-tracker/prod/cdn-distribution-id")
+
+		// Act
+		c.Invalidate(context.Background(), "/api/a")
+		err := c.Invalidate(context.Background(), "/api/b")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(requests) != 3 || requests[0].target != "AmazonSSM.GetParameter" {
+			t.Fatalf("expected one parameter read and two invalidations, got %+v", requests)
+		}
+		if requests[2].path != "/2020-05-31/distribution/EDFDVBD6EXAMPLE/invalidation" {
+			t.Errorf("unexpected invalidation path %s", requests[2].path)
+		}
+	})
+}
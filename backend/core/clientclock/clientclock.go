@@ -0,0 +1,77 @@
+package clientclock
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// Tolerance is the largest difference between a device's clock and the
+	// server's put down to network latency rather than a wrong clock, and left
+	// uncorrected
+	Tolerance = 2 * time.Minute
+
+	// MaxSkew is the largest skew corrected. A larger one more likely comes from
+	// a request replayed with the time it was first sent than from a wrong clock
+	MaxSkew = 24 * time.Hour
+)
+
+// ErrMalformed is returned for device times that are not RFC 3339 timestamps
+var ErrMalformed = errors.New("client time must be an RFC 3339 timestamp")
+
+// Clock relates the clock of the device that sent a request to the server's.
+// Skew is how far the server's clock was ahead of the device's when the
+// request arrived at ReceivedAt, and is zero when the device did not say
+type Clock struct {
+	ReceivedAt time.Time
+	Skew       time.Duration
+}
+
+// New returns the clock of a request received at receivedAt from a device
+// whose clock read sentAt when it sent the request, or a clock without skew
+// when sentAt is zero
+func New(sentAt, receivedAt time.Time) Clock {
+	c := Clock{ReceivedAt: receivedAt.UTC()}
+	if !sentAt.IsZero() {
+		c.Skew = receivedAt.Sub(sentAt)
+	}
+	return c
+}
+
+// Parse returns the clock of a request received at receivedAt from a device
+// that sent raw as its time, or a clock without skew when raw is empty
+func Parse(raw string, receivedAt time.Time) (Clock, error) {
+	if raw == "" {
+		return New(time.Time{}, receivedAt), nil
+	}
+	sentAt, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return Clock{}, ErrMalformed
+	}
+	return New(sentAt, receivedAt), nil
+}
+
+// Correction is the offset added to the device's times: its skew when that is
+// beyond Tolerance but within MaxSkew, and zero otherwise
+func (c Clock) Correction() time.Duration {
+	if skew := c.Skew.Abs(); skew <= Tolerance || skew > MaxSkew {
+		return 0
+	}
+	return c.Skew
+}
+
+// Unreliable reports whether the skew is beyond MaxSkew, so the device's times
+// are only bounded by ReceivedAt
+func (c Clock) Unreliable() bool {
+	return c.Skew.Abs() > MaxSkew
+}
+
+// Server converts t from the device's clock to the server's. Nothing is
+// logged before it is sent, so the result is never later than ReceivedAt
+func (c Clock) Server(t time.Time) time.Time {
+	server := t.Add(c.Correction()).UTC()
+	if server.After(c.ReceivedAt) {
+		return c.ReceivedAt
+	}
+	return server
+}
@@ -0,0 +1,75 @@
+package clientclock
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestClock_Server(t *testing.T) {
+	received := time.Date(2026, 5, 4, 18, 0, 0, 0, time.UTC)
+	logged := received.Add(-40 * time.Minute)
+
+	t.Run("corrects times from a device whose clock is behind", func(t *testing.T) {
+		// Arrange
+		clock, _ := Parse(received.Add(-3*time.Hour).Format(time.RFC3339), received)
+
+		// Act
+		server := clock.Server(logged.Add(-3 * time.Hour))
+
+		// Assert
+		if !server.Equal(logged) || clock.Correction() != 3*time.Hour {
+			t.Errorf("expected %v, got %v", logged, server)
+		}
+	})
+
+	t.Run("leaves skew within tolerance uncorrected", func(t *testing.T) {
+		// Arrange
+		clock := New(received.Add(-90*time.Second), received)
+
+		// Act
+		server := clock.Server(logged)
+
+		// Assert
+		if !server.Equal(logged) || clock.Correction() != 0 {
+			t.Errorf("expected %v, got %v", logged, server)
+		}
+	})
+
+	t.Run("only bounds times when the skew is too large to trust", func(t *testing.T) {
+		// Arrange
+		clock := New(received.Add(-72*time.Hour), received)
+
+		// Act
+		past := clock.Server(logged)
+		future := clock.Server(received.Add(time.Hour))
+
+		// Assert
+		if !clock.Unreliable() || !past.Equal(logged) || !future.Equal(received) {
+			t.Errorf("expected %v and %v, got %v and %v", logged, received, past, future)
+		}
+	})
+
+	t.Run("never puts times after the request arrived", func(t *testing.T) {
+		// Arrange
+		clock, _ := Parse("", received)
+
+		// Act
+		server := clock.Server(received.Add(10 * time.Minute))
+
+		// Assert
+		if !server.Equal(received) {
+			t.Errorf("expected %v, got %v", received, server)
+		}
+	})
+
+	t.Run("rejects malformed device times", func(t *testing.T) {
+		// Act
+		_, err := Parse("yesterday", received)
+
+		// Assert
+		if !errors.Is(err, ErrMalformed) {
+			t.Errorf("expected ErrMalformed, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,239 @@
+package clientconfig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"athlete-forge/clientversion"
+)
+
+// DefaultInterval is how often the configuration is reloaded; a change takes
+// effect on each warm instance within this long
+const DefaultInterval = time.Minute
+
+// Document is the client configuration as stored in AppConfig, such as
+//
+//	{"minVersions": {"ios": "2.0"},
+//	 "killSwitches": [{"feature": "rest-timer-sync", "platforms": ["android"], "toVersion": "2.4.1"}],
+//	 "values": {"maxPhotoUploads": 5}}
+type Document struct {
+	MinVersions  map[string]string          `json:"minVersions,omitempty"`
+	KillSwitches []KillSwitch               `json:"killSwitches,omitempty"`
+	Values       map[string]json.RawMessage `json:"values,omitempty"`
+}
+
+// KillSwitch turns a client feature off on the given platforms, all when none
+// are given, for releases from FromVersion through ToVersion, either of which
+// may be left open
+type KillSwitch struct {
+	Feature     string   `json:"feature"`
+	Platforms   []string `json:"platforms,omitempty"`
+	FromVersion string   `json:"fromVersion,omitempty"`
+	ToVersion   string   `json:"toVersion,omitempty"`
+	Message     string   `json:"message,omitempty"`
+
+	from, to *clientversion.Version
+}
+
+// applies reports whether the switch covers v; requests without a version are
+// covered by switches open at both ends
+func (k *KillSwitch) applies(v clientversion.Version, sent bool) bool {
+	if !sent {
+		return len(k.Platforms) == 0 && k.from == nil && k.to == nil
+	}
+	if len(k.Platforms) > 0 {
+		found := false
+		for _, p := range k.Platforms {
+			found = found || p == v.Platform
+		}
+		if !found {
+			return false
+		}
+	}
+	return (k.from == nil || v.Compare(*k.from) >= 0) && (k.to == nil || v.Compare(*k.to) <= 0)
+}
+
+// Config is a validated Document
+type Config struct {
+	doc      Document
+	minimums clientversion.Minimums
+}
+
+// Parse decodes and validates a Document; empty data is an empty Config
+func Parse(data []byte) (*Config, error) {
+	c := &Config{minimums: clientversion.Minimums{}}
+	if len(data) == 0 {
+		return c, nil
+	}
+	if err := json.Unmarshal(data, &c.doc); err != nil {
+		return nil, fmt.Errorf("failed to decode client configuration: %w", err)
+	}
+	for platform, release := range c.doc.MinVersions {
+		v, err := clientversion.Parse(platform + "/" + release)
+		if err != nil {
+			return nil, fmt.Errorf("invalid minimum version: %w", err)
+		}
+		c.minimums[v.Platform] = v
+	}
+	for i := range c.doc.KillSwitches {
+		k := &c.doc.KillSwitches[i]
+		if k.Feature == "" {
+			return nil, errors.New("kill switches need a feature")
+		}
+		for _, p := range k.Platforms {
+			if _, err := clientversion.Parse(p + "/0.0"); err != nil {
+				return nil, fmt.Errorf("kill switch %s: %w", k.Feature, err)
+			}
+		}
+		for _, bound := range []struct {
+			raw string
+			v   **clientversion.Version
+		}{{k.FromVersion, &k.from}, {k.ToVersion, &k.to}} {
+			if bound.raw == "" {
+				continue
+			}
+			v, err := clientversion.ParseRelease(bound.raw)
+			if err != nil {
+				return nil, fmt.Errorf("kill switch %s: %w", k.Feature, err)
+			}
+			*bound.v = &v
+		}
+	}
+	return c, nil
+}
+
+// Minimums returns the oldest supported release of each platform configured
+func (c *Config) Minimums() clientversion.Minimums {
+	return c.minimums
+}
+
+// DisabledFeature is a client feature turned off for the caller's release,
+// with a message the app may show in its place
+type DisabledFeature struct {
+	Feature string `json:"feature"`
+	Message string `json:"message,omitempty"`
+}
+
+// Client is the configuration as one release sees it
+type Client struct {
+	MinVersions      map[string]string          `json:"minVersions"`
+	UpgradeRequired  bool                       `json:"upgradeRequired"`
+	DisabledFeatures []DisabledFeature          `json:"disabledFeatures"`
+	Values           map[string]json.RawMessage `json:"values"`
+}
+
+// For returns the configuration for release v, and whether the request sent
+// one, given the minimums in force, which may add to the configured ones
+func (c *Config) For(v clientversion.Version, sent bool, minimums clientversion.Minimums) Client {
+	client := Client{MinVersions: map[string]string{}, DisabledFeatures: []DisabledFeature{}, Values: c.doc.Values}
+	for platform, minimum := range minimums {
+		client.MinVersions[platform] = minimum.Release()
+	}
+	client.UpgradeRequired = sent && !minimums.Supports(v)
+	for i := range c.doc.KillSwitches {
+		if k := &c.doc.KillSwitches[i]; k.applies(v, sent) {
+			client.DisabledFeatures = append(client.DisabledFeatures, DisabledFeature{Feature: k.Feature, Message: k.Message})
+		}
+	}
+	if client.Values == nil {
+		client.Values = map[string]json.RawMessage{}
+	}
+	return client
+}
+
+// Source loads the stored configuration
+type Source interface {
+	Load(ctx context.Context) ([]byte, error)
+}
+
+// DefaultExtensionPort is the port the AppConfig Lambda extension listens on
+const DefaultExtensionPort = "2772"
+
+// Extension loads the configuration through the AppConfig Lambda extension,
+// which polls AppConfig in the background and serves its copy locally
+type Extension struct {
+	url    string
+	client *http.Client
+}
+
+// NewExtension creates a source reading the configuration profile at path,
+// /applications/<app>/environments/<env>/configurations/<profile>, from the
+// extension on port
+func NewExtension(port, path string) *Extension {
+	return &Extension{url: "http://localhost:" + port + path, client: &http.Client{Timeout: 2 * time.Second}}
+}
+
+// Load reads the configuration from the extension
+func (e *Extension) Load(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client configuration request: %w", err)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client configuration: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client configuration: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to read client configuration: status %d: %s", resp.StatusCode, body)
+	}
+	return body, nil
+}
+
+// Cache holds the configuration in the container, reloading it from its
+// source as requests arrive
+type Cache struct {
+	source   Source
+	interval time.Duration
+
+	mu       sync.Mutex
+	config   *Config
+	loadedAt time.Time
+}
+
+// NewCache creates a Cache loading from source every interval, empty until
+// the first load
+func NewCache(source Source, interval time.Duration) *Cache {
+	empty, _ := Parse(nil)
+	return &Cache{source: source, interval: interval, config: empty}
+}
+
+// Refresh reloads the configuration when it is older than the interval. A
+// failed load, or an invalid configuration, keeps the previous one and is not
+// retried until the interval passes again
+func (c *Cache) Refresh(ctx context.Context, now time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.loadedAt.IsZero() && now.Sub(c.loadedAt) < c.interval {
+		return nil
+	}
+	c.loadedAt = now
+
+	data, err := c.source.Load(ctx)
+	if err != nil {
+		return err
+	}
+	config, err := Parse(data)
+	if err != nil {
+		return err
+	}
+	c.config = config
+	return nil
+}
+
+// Current returns the configuration last loaded
+func (c *Cache) Current() *Config {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.config
+}
@@ -0,0 +1,207 @@
+package clientconfig
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"athlete-forge/clientversion"
+)
+
+// staticSource returns data, or err when set, counting loads
+type staticSource struct {
+	data  string
+	err   error
+	loads int
+}
+
+func (s *staticSource) Load(ctx context.Context) ([]byte, error) {
+	s.loads++
+	return []byte(s.data), s.err
+}
+
+const document = `{
+	"minVersions": {"ios": "2.0"},
+	"killSwitches": [
+		{"feature": "rest-timer-sync", "platforms": ["android"], "fromVersion": "2.3", "toVersion": "2.4.1", "message": "Temporarily unavailable"},
+		{"feature": "live-activities"}
+	],
+	"values": {"maxPhotoUploads": 5}
+}`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{name: "valid document", data: document},
+		{name: "empty document", data: ""},
+		{name: "malformed JSON", data: `{"minVersions":`, wantErr: true},
+		{name: "unknown platform minimum", data: `{"minVersions": {"symbian": "1.0"}}`, wantErr: true},
+		{name: "malformed minimum", data: `{"minVersions": {"ios": "two"}}`, wantErr: true},
+		{name: "kill switch without feature", data: `{"killSwitches": [{}]}`, wantErr: true},
+		{name: "kill switch on unknown platform", data: `{"killSwitches": [{"feature": "x", "platforms": ["symbian"]}]}`, wantErr: true},
+		{name: "kill switch with malformed bound", data: `{"killSwitches": [{"feature": "x", "toVersion": "latest"}]}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, err := Parse([]byte(tt.data))
+
+			// Assert
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestConfig_For(t *testing.T) {
+	config, err := Parse([]byte(document))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	features := func(c Client) []string {
+		var names []string
+		for _, f := range c.DisabledFeatures {
+			names = append(names, f.Feature)
+		}
+		return names
+	}
+
+	tests := []struct {
+		name        string
+		version     string
+		wantUpgrade bool
+		wantOff     []string
+	}{
+		{name: "release inside the kill switch range", version: "android/2.4.1", wantOff: []string{"rest-timer-sync", "live-activities"}},
+		{name: "release after the kill switch range", version: "android/2.4.2", wantOff: []string{"live-activities"}},
+		{name: "release before the kill switch range", version: "android/2.2.9", wantOff: []string{"live-activities"}},
+		{name: "other platform", version: "ios/2.4.0", wantOff: []string{"live-activities"}},
+		{name: "release below the minimum", version: "ios/1.9", wantUpgrade: true, wantOff: []string{"live-activities"}},
+		{name: "no version sent", wantOff: []string{"live-activities"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var v clientversion.Version
+			if tt.version != "" {
+				v, _ = clientversion.Parse(tt.version)
+			}
+
+			// Act
+			client := config.For(v, tt.version != "", config.Minimums())
+
+			// Assert
+			if client.UpgradeRequired != tt.wantUpgrade {
+				t.Errorf("expected upgradeRequired %v, got %v", tt.wantUpgrade, client.UpgradeRequired)
+			}
+			if got := features(client); len(got) != len(tt.wantOff) || (len(got) > 0 && got[0] != tt.wantOff[0]) {
+				t.Errorf("expected disabled features %v, got %v", tt.wantOff, got)
+			}
+			if client.MinVersions["ios"] != "2.0.0" || string(client.Values["maxPhotoUploads"]) != "5" {
+				t.Errorf("unexpected config: %+v", client)
+			}
+		})
+	}
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	t.Run("reloads only once the interval has passed", func(t *testing.T) {
+		// Arrange
+		source := &staticSource{data: document}
+		cache := NewCache(source, DefaultInterval)
+
+		// Act
+		cache.Refresh(ctx, now)
+		cache.Refresh(ctx, now.Add(30*time.Second))
+		cache.Refresh(ctx, now.Add(DefaultInterval))
+
+		// Assert
+		if source.loads != 2 {
+			t.Errorf("expected 2 loads, got %d", source.loads)
+		}
+		if cache.Current().Minimums()["ios"].Major != 2 {
+			t.Errorf("expected the loaded minimums, got %v", cache.Current().Minimums())
+		}
+	})
+
+	t.Run("keeps the previous configuration when loading fails or it is invalid", func(t *testing.T) {
+		// Arrange
+		source := &staticSource{data: document}
+		cache := NewCache(source, DefaultInterval)
+		cache.Refresh(ctx, now)
+
+		// Act
+		source.err = errors.New("extension unavailable")
+		failed := cache.Refresh(ctx, now.Add(DefaultInterval))
+		source.data, source.err = `{"minVersions": {"ios": "two"}}`, nil
+		invalid := cache.Refresh(ctx, now.Add(2*DefaultInterval))
+
+		// Assert
+		if failed == nil || invalid == nil {
+			t.Fatalf("expected errors, got %v and %v", failed, invalid)
+		}
+		if cache.Current().Minimums()["ios"].Major != 2 {
+			t.Errorf("expected the previous minimums, got %v", cache.Current().Minimums())
+		}
+	})
+}
+
+func TestExtension_Load(t *testing.T) {
+	ctx := context.Background()
+	path := "/applications/app/environments/test/configurations/client-config"
+
+	t.Run("reads the profile from the extension", func(t *testing.T) {
+		// Arrange
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != path {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(document))
+		}))
+		defer server.Close()
+		extension := NewExtension("0", path)
+		extension.url = server.URL + path
+
+		// Act
+		data, err := extension.Load(ctx)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(data) != document {
+			t.Errorf("unexpected data: %s", data)
+		}
+	})
+
+	t.Run("fails on an error status", func(t *testing.T) {
+		// Arrange
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "not deployed", http.StatusBadRequest)
+		}))
+		defer server.Close()
+		extension := NewExtension("0", path)
+		extension.url = server.URL + path
+
+		// Act
+		_, err := extension.Load(ctx)
+
+		// Assert
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
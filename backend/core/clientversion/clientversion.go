@@ -0,0 +1,122 @@
+package clientversion
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Platforms the apps are released on
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformWeb     = "web"
+	PlatformWatchOS = "watchos"
+	PlatformWearOS  = "wearos"
+)
+
+var platforms = map[string]bool{
+	PlatformIOS:     true,
+	PlatformAndroid: true,
+	PlatformWeb:     true,
+	PlatformWatchOS: true,
+	PlatformWearOS:  true,
+}
+
+// number matches a release number such as 2.4 or 2.4.1
+var number = regexp.MustCompile(`^(\d{1,4})\.(\d{1,4})(?:\.(\d{1,4}))?$`)
+
+// Version is an app release on a platform, sent by the apps as
+// <platform>/<major>.<minor>[.<patch>], such as ios/2.4.1
+type Version struct {
+	Platform string
+	Major    int
+	Minor    int
+	Patch    int
+}
+
+// Parse parses a version as the apps send it
+func Parse(s string) (Version, error) {
+	platform, release, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Version{}, fmt.Errorf("version %q must be <platform>/<major>.<minor>[.<patch>]", s)
+	}
+	platform = strings.ToLower(platform)
+	if !platforms[platform] {
+		return Version{}, fmt.Errorf("unknown platform %q", platform)
+	}
+	v, err := ParseRelease(release)
+	if err != nil {
+		return Version{}, err
+	}
+	v.Platform = platform
+	return v, nil
+}
+
+// ParseRelease parses a release number without a platform, such as 2.4.1
+func ParseRelease(s string) (Version, error) {
+	m := number.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return Version{}, fmt.Errorf("release %q must be <major>.<minor>[.<patch>]", s)
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	patch, _ := strconv.Atoi(m[3])
+	return Version{Major: major, Minor: minor, Patch: patch}, nil
+}
+
+// Release returns the release number, such as 2.4.1
+func (v Version) Release() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// String returns the version as the apps send it
+func (v Version) String() string {
+	return v.Platform + "/" + v.Release()
+}
+
+// Compare compares the release numbers of v and other, whatever their
+// platforms, returning -1, 0 or 1
+func (v Version) Compare(other Version) int {
+	for _, d := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+	return 0
+}
+
+// Minimums are the oldest releases supported on each platform. Platforms
+// without one support every release
+type Minimums map[string]Version
+
+// ParseMinimums parses comma-separated platform=release pairs, such as
+// ios=2.0,android=2.1.3
+func ParseMinimums(s string) (Minimums, error) {
+	minimums := Minimums{}
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		platform, release, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("minimum %q must be <platform>=<release>", pair)
+		}
+		v, err := Parse(strings.TrimSpace(platform) + "/" + release)
+		if err != nil {
+			return nil, err
+		}
+		minimums[v.Platform] = v
+	}
+	return minimums, nil
+}
+
+// Supports reports whether v is at least its platform's minimum
+func (m Minimums) Supports(v Version) bool {
+	minimum, ok := m[v.Platform]
+	return !ok || v.Compare(minimum) >= 0
+}
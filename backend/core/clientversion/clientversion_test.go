@@ -0,0 +1,65 @@
+package clientversion
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want Version
+		ok   bool
+	}{
+		{raw: "ios/2.4.1", want: Version{Platform: PlatformIOS, Major: 2, Minor: 4, Patch: 1}, ok: true},
+		{raw: "Android/3.0", want: Version{Platform: PlatformAndroid, Major: 3}, ok: true},
+		{raw: "2.4.1"},
+		{raw: "blackberry/1.0"},
+		{raw: "ios/2"},
+		{raw: "ios/2.4.1-beta"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			// Act
+			v, err := Parse(tt.raw)
+
+			// Assert
+			if (err == nil) != tt.ok {
+				t.Fatalf("expected ok %v, got error %v", tt.ok, err)
+			}
+			if v != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, v)
+			}
+		})
+	}
+}
+
+func TestMinimums_Supports(t *testing.T) {
+	// Arrange
+	minimums, err := ParseMinimums("ios=2.4, android=2.1.3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := map[string]bool{
+		"ios/2.3.9":     false,
+		"ios/2.4.0":     true,
+		"ios/10.0":      true,
+		"android/2.1.2": false,
+		"web/0.1":       true,
+	}
+	for raw, want := range tests {
+		// Act
+		v, _ := Parse(raw)
+
+		// Assert
+		if minimums.Supports(v) != want {
+			t.Errorf("%s: expected supported %v", raw, want)
+		}
+	}
+}
+
+func TestParseMinimums(t *testing.T) {
+	for _, raw := range []string{"ios", "ios=two", "palm=1.0"} {
+		if _, err := ParseMinimums(raw); err == nil {
+			t.Errorf("%s: expected an error", raw)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package compliance
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"athlete-forge/dailylog"
+	"athlete-forge/program"
+	"athlete-forge/store"
+	"athlete-forge/summary"
+	"athlete-forge/workout"
+)
+
+const complianceSKPrefix = "COMPLIANCE#"
+
+// Week compares what a program planned for a week with what was performed.
+// Sessions are planned by the program's schedule, so an unscheduled program
+// plans none. Prescribed volume is each completed session's prescriptions as
+// they stood before it progressed them, and performed volume is the volume
+// logged for the exercises the program prescribes, so volume compliance
+// reflects the sessions done rather than the ones missed. Rates are fractions,
+// and are 0 when nothing was planned or prescribed. WorkoutIDs are the sessions
+// counted, so a completion is never counted twice, and weeks never recorded
+// have no UpdatedAt
+type Week struct {
+	UserID            string     `json:"userId"`
+	ProgramID         string     `json:"programId"`
+	WeekStart         string     `json:"weekStart"`
+	SessionsPlanned   int        `json:"sessionsPlanned"`
+	SessionsCompleted int        `json:"sessionsCompleted"`
+	SessionRate       float64    `json:"sessionRate"`
+	PrescribedVolume  float64    `json:"prescribedVolume"`
+	PerformedVolume   float64    `json:"performedVolume"`
+	VolumeRate        float64    `json:"volumeRate"`
+	WorkoutIDs        []string   `json:"workoutIds"`
+	UpdatedAt         *time.Time `json:"updatedAt,omitempty"`
+}
+
+// WeekOf returns the start of the compliance week w counts towards: the week
+// it was completed in
+func WeekOf(w workout.Workout) string {
+	return summary.WeekStart(summary.CompletedAt(w)).Format(dailylog.DateLayout)
+}
+
+// NewWeek returns p's empty week starting weekStart, planning a session for
+// each day of its schedule
+func NewWeek(p program.Program, weekStart string) Week {
+	return Week{UserID: p.UserID, ProgramID: p.ID, WeekStart: weekStart, SessionsPlanned: planned(p), WorkoutIDs: []string{}}
+}
+
+// Record counts completed workout w, performed as part of p, towards week. p
+// is the program as it was before w progressed it. It reports false, leaving
+// week unchanged, when w has already been counted
+func Record(week *Week, p program.Program, w workout.Workout, now time.Time) bool {
+	for _, id := range week.WorkoutIDs {
+		if id == w.ID {
+			return false
+		}
+	}
+	week.WorkoutIDs = append(week.WorkoutIDs, w.ID)
+	week.SessionsPlanned = planned(p)
+	week.SessionsCompleted++
+
+	prescribed := map[string]bool{}
+	for _, prescription := range p.Exercises {
+		prescribed[strings.ToLower(prescription.Exercise)] = true
+		week.PrescribedVolume += float64(prescription.Sets*prescription.Reps) * prescription.Weight
+	}
+	for _, exercise := range w.Exercises {
+		if !prescribed[strings.ToLower(exercise.Name)] {
+			continue
+		}
+		for _, set := range exercise.Sets {
+			week.PerformedVolume += set.Volume()
+		}
+	}
+	week.PrescribedVolume = round(week.PrescribedVolume, 100)
+	week.PerformedVolume = round(week.PerformedVolume, 100)
+	week.rate()
+	updated := now.UTC()
+	week.UpdatedAt = &updated
+	return true
+}
+
+// Weeks returns p's compliance for the weeks from the one starting from to the
+// one starting to, oldest first. Weeks without a stored record had no sessions
+// completed, and weeks before the program was created are left out
+func Weeks(stored []Week, p program.Program, from, to time.Time) []Week {
+	byWeek := map[string]Week{}
+	for _, week := range stored {
+		byWeek[week.WeekStart] = week
+	}
+
+	created := summary.WeekStart(p.CreatedAt)
+	weeks := []Week{}
+	for start := summary.WeekStart(from); !start.After(to); start = start.AddDate(0, 0, 7) {
+		key := start.Format(dailylog.DateLayout)
+		if week, ok := byWeek[key]; ok {
+			weeks = append(weeks, week)
+			continue
+		}
+		if start.Before(created) {
+			continue
+		}
+		week := NewWeek(p, key)
+		week.rate()
+		weeks = append(weeks, week)
+	}
+	return weeks
+}
+
+func (week *Week) rate() {
+	week.SessionRate, week.VolumeRate = 0, 0
+	if week.SessionsPlanned > 0 {
+		week.SessionRate = round(float64(week.SessionsCompleted)/float64(week.SessionsPlanned), 100)
+	}
+	if week.PrescribedVolume > 0 {
+		week.VolumeRate = round(week.PerformedVolume/week.PrescribedVolume, 100)
+	}
+}
+
+// planned is the number of sessions p's schedule plans each week
+func planned(p program.Program) int {
+	if p.Schedule == nil {
+		return 0
+	}
+	return len(p.Schedule.Days)
+}
+
+func round(v, scale float64) float64 {
+	return math.Round(v*scale) / scale
+}
+
+// Repository loads and saves programs' weekly compliance
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Get returns the compliance of userID's program for the week starting weekStart
+func (r *Repository) Get(ctx context.Context, userID, programID, weekStart string) (*Week, error) {
+	var week Week
+	if err := r.store.Get(ctx, store.UserPK(userID), complianceSKPrefix+programID+"#"+weekStart, &week); err != nil {
+		return nil, err
+	}
+	return &week, nil
+}
+
+// List returns userID's weekly compliance, oldest first within each program.
+// An empty programID lists every program's
+func (r *Repository) List(ctx context.Context, userID, programID string) ([]Week, error) {
+	prefix := complianceSKPrefix
+	if programID != "" {
+		prefix += programID + "#"
+	}
+	items, err := r.store.Query(ctx, store.UserPK(userID), prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list compliance: %w", err)
+	}
+
+	weeks := make([]Week, 0, len(items))
+	for _, item := range items {
+		var week Week
+		if err := item.Decode(&week); err != nil {
+			return nil, err
+		}
+		weeks = append(weeks, week)
+	}
+	return weeks, nil
+}
+
+// Save stores week, replacing any earlier record of the same program and week
+func (r *Repository) Save(ctx context.Context, week *Week) error {
+	if err := r.store.Put(ctx, store.UserPK(week.UserID), complianceSKPrefix+week.ProgramID+"#"+week.WeekStart, week); err != nil {
+		return fmt.Errorf("failed to save compliance: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package compliance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"athlete-forge/program"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+func scheduled() program.Program {
+	return program.Program{
+		ID: "p1", UserID: "user-1", Name: "5x5",
+		Exercises: []program.Prescription{{Exercise: "Squat", Sets: 3, Reps: 5, Weight: 100}},
+		Schedule:  &program.Schedule{Days: []string{"mon", "wed", "fri"}},
+		CreatedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func session(id string, at time.Time, sets ...workout.Set) workout.Workout {
+	return workout.Workout{
+		ID: id, UserID: "user-1", ProgramID: "p1", Status: workout.StatusCompleted, StartedAt: at, CompletedAt: &at,
+		Exercises: []workout.Exercise{{Name: "squat", Sets: sets}, {Name: "Curl", Sets: []workout.Set{{Reps: 10, Weight: 20}}}},
+	}
+}
+
+func TestRecord(t *testing.T) {
+	now := time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC)
+
+	t.Run("compares sessions and volume with the plan", func(t *testing.T) {
+		// Arrange
+		p := scheduled()
+		full := session("w1", time.Date(2024, 3, 18, 18, 0, 0, 0, time.UTC), workout.Set{Reps: 5, Weight: 100}, workout.Set{Reps: 5, Weight: 100}, workout.Set{Reps: 5, Weight: 100})
+		short := session("w2", time.Date(2024, 3, 20, 18, 0, 0, 0, time.UTC), workout.Set{Reps: 5, Weight: 100})
+		week := NewWeek(p, WeekOf(full))
+
+		// Act
+		Record(&week, p, full, now)
+		Record(&week, p, short, now)
+
+		// Assert
+		if week.WeekStart != "2024-03-18" || week.SessionsPlanned != 3 || week.SessionsCompleted != 2 || week.SessionRate != 0.67 {
+			t.Errorf("unexpected sessions: %+v", week)
+		}
+		if week.PrescribedVolume != 3000 || week.PerformedVolume != 2000 || week.VolumeRate != 0.67 {
+			t.Errorf("unexpected volume: %+v", week)
+		}
+	})
+
+	t.Run("counts a workout once", func(t *testing.T) {
+		// Arrange
+		p := scheduled()
+		w := session("w1", time.Date(2024, 3, 18, 18, 0, 0, 0, time.UTC), workout.Set{Reps: 5, Weight: 100})
+		week := NewWeek(p, WeekOf(w))
+		Record(&week, p, w, now)
+
+		// Act
+		recorded := Record(&week, p, w, now)
+
+		// Assert
+		if recorded || week.SessionsCompleted != 1 || week.PerformedVolume != 500 {
+			t.Errorf("expected the replay ignored, got %v: %+v", recorded, week)
+		}
+	})
+
+	t.Run("unscheduled programs plan no sessions", func(t *testing.T) {
+		// Arrange
+		p := scheduled()
+		p.Schedule = nil
+		w := session("w1", time.Date(2024, 3, 18, 18, 0, 0, 0, time.UTC), workout.Set{Reps: 5, Weight: 100})
+		week := NewWeek(p, WeekOf(w))
+
+		// Act
+		Record(&week, p, w, now)
+
+		// Assert
+		if week.SessionsPlanned != 0 || week.SessionRate != 0 || week.VolumeRate != 0.33 {
+			t.Errorf("unexpected compliance: %+v", week)
+		}
+	})
+}
+
+func TestWeeks(t *testing.T) {
+	t.Run("fills weeks without sessions from the program's creation", func(t *testing.T) {
+		// Arrange
+		p := scheduled()
+		stored := []Week{{ProgramID: "p1", WeekStart: "2024-03-11", SessionsPlanned: 3, SessionsCompleted: 3, SessionRate: 1}}
+
+		// Act
+		weeks := Weeks(stored, p, time.Date(2024, 2, 19, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC))
+
+		// Assert
+		if len(weeks) != 4 || weeks[0].WeekStart != "2024-02-26" || weeks[2].SessionRate != 1 || weeks[3].SessionsPlanned != 3 || weeks[3].SessionsCompleted != 0 {
+			t.Errorf("unexpected weeks: %+v", weeks)
+		}
+	})
+}
+
+func TestRepository(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	r := NewRepository(store.NewMemoryStore())
+	for _, week := range []Week{
+		{UserID: "user-1", ProgramID: "p1", WeekStart: "2024-03-11"},
+		{UserID: "user-1", ProgramID: "p1", WeekStart: "2024-03-18"},
+		{UserID: "user-1", ProgramID: "p2", WeekStart: "2024-03-18"},
+	} {
+		if err := r.Save(ctx, &week); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// Act
+	p1, err := r.List(ctx, "user-1", "p1")
+	all, _ := r.List(ctx, "user-1", "")
+
+	// Assert
+	if err != nil || len(p1) != 2 || p1[0].WeekStart != "2024-03-11" {
+		t.Errorf("unexpected program weeks: %+v (%v)", p1, err)
+	}
+	if len(all) != 3 {
+		t.Errorf("expected every program's weeks, got %+v", all)
+	}
+}
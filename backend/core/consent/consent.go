@@ -0,0 +1,219 @@
+package consent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"athlete-forge/store"
+)
+
+const (
+	consentSK = "CONSENT"
+
+	// maxHistory bounds the consent changes kept per user
+	maxHistory = 200
+
+	// legacyVersion is the document version that profile opt-ins made before
+	// consent was recorded are taken to agree to
+	legacyVersion = 1
+)
+
+// Purposes a user can consent to
+const (
+	PurposeAnalytics             = "analytics"
+	PurposeMarketingEmails       = "marketing-emails"
+	PurposePopulationComparisons = "population-comparisons"
+)
+
+// Sources of a consent change: the consent endpoints, or the profile's opt-in
+// fields
+const (
+	SourceApp     = "app"
+	SourceProfile = "profile"
+)
+
+// Document is a version of the text a user agrees to for a purpose. Publishing
+// a new version asks everyone who consented to an older one to review it;
+// until they do, their consent no longer counts
+type Document struct {
+	Purpose     string    `json:"purpose"`
+	Version     int       `json:"version"`
+	Title       string    `json:"title"`
+	Text        string    `json:"text"`
+	PublishedAt time.Time `json:"publishedAt"`
+}
+
+// documents holds every version of each purpose's document, oldest first
+var documents = []Document{
+	{
+		Purpose:     PurposeAnalytics,
+		Version:     1,
+		Title:       "Usage analytics",
+		Text:        "Send anonymized events about how you use the app, such as the screens you open, so we can improve it. Events are sent under an anonymous ID and never include your workouts or profile.",
+		PublishedAt: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+	},
+	{
+		Purpose:     PurposeMarketingEmails,
+		Version:     1,
+		Title:       "Product and offer emails",
+		Text:        "Receive occasional emails about new features, programs and offers. Training reports and account emails are sent either way.",
+		PublishedAt: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+	},
+	{
+		Purpose:     PurposePopulationComparisons,
+		Version:     1,
+		Title:       "Strength comparisons",
+		Text:        "Compare your lifts with other lifters of your sex, age and bodyweight, and let your best lifts count anonymously towards those comparisons.",
+		PublishedAt: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+	},
+}
+
+// Purposes returns every purpose in the order they are presented
+func Purposes() []string {
+	return []string{PurposeAnalytics, PurposeMarketingEmails, PurposePopulationComparisons}
+}
+
+// Current returns the latest document for purpose
+func Current(purpose string) (Document, bool) {
+	var current Document
+	found := false
+	for _, d := range documents {
+		if d.Purpose == purpose {
+			current, found = d, true
+		}
+	}
+	return current, found
+}
+
+// Versions returns every version of purpose's document, oldest first
+func Versions(purpose string) []Document {
+	versions := []Document{}
+	for _, d := range documents {
+		if d.Purpose == purpose {
+			versions = append(versions, d)
+		}
+	}
+	return versions
+}
+
+// Record is a consent decision: granting or withdrawing consent to Version of
+// Purpose's document
+type Record struct {
+	Purpose    string    `json:"purpose"`
+	Version    int       `json:"version"`
+	Granted    bool      `json:"granted"`
+	Source     string    `json:"source"`
+	RecordedAt time.Time `json:"recordedAt"`
+}
+
+// Validate checks the record is for a known purpose and that consent is only
+// granted to the current document, which the user must have been shown
+func (r *Record) Validate() error {
+	current, ok := Current(r.Purpose)
+	if !ok {
+		return fmt.Errorf("unknown purpose %q", r.Purpose)
+	}
+	if r.Granted && r.Version != current.Version {
+		return fmt.Errorf("consent can only be given to the current version %d of the %s document", current.Version, r.Purpose)
+	}
+	return nil
+}
+
+// Consents is a user's latest decision for each purpose and every change,
+// oldest first, as evidence of what they agreed to and when
+type Consents struct {
+	UserID  string   `json:"userId"`
+	Records []Record `json:"records"`
+	History []Record `json:"history"`
+}
+
+// Latest returns the user's latest decision for purpose
+func (c *Consents) Latest(purpose string) (Record, bool) {
+	for _, r := range c.Records {
+		if r.Purpose == purpose {
+			return r, true
+		}
+	}
+	return Record{}, false
+}
+
+// Allows reports whether the user has consented to the current document for
+// purpose; consent to an older version does not count
+func (c *Consents) Allows(purpose string) bool {
+	r, ok := c.Latest(purpose)
+	if !ok || !r.Granted {
+		return false
+	}
+	current, _ := Current(purpose)
+	return r.Version == current.Version
+}
+
+// AllowsLegacy is Allows for a purpose the profile had an opt-in field for
+// before consent was recorded: without any decision on record, optedIn counts
+// as consent to the first version of the document
+func (c *Consents) AllowsLegacy(purpose string, optedIn bool) bool {
+	if _, ok := c.Latest(purpose); ok {
+		return c.Allows(purpose)
+	}
+	current, _ := Current(purpose)
+	return optedIn && current.Version == legacyVersion
+}
+
+// Add records r as the latest decision for its purpose
+func (c *Consents) Add(r Record) {
+	replaced := false
+	for i := range c.Records {
+		if c.Records[i].Purpose == r.Purpose {
+			c.Records[i], replaced = r, true
+		}
+	}
+	if !replaced {
+		c.Records = append(c.Records, r)
+	}
+	c.History = append(c.History, r)
+	if len(c.History) > maxHistory {
+		c.History = c.History[len(c.History)-maxHistory:]
+	}
+}
+
+// Repository loads and saves users' consent records
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Get returns userID's consents, which are empty when they have made no
+// decisions
+func (r *Repository) Get(ctx context.Context, userID string) (*Consents, error) {
+	var c Consents
+	err := r.store.Get(ctx, store.UserPK(userID), consentSK, &c)
+	if errors.Is(err, store.ErrNotFound) {
+		return &Consents{UserID: userID, Records: []Record{}, History: []Record{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load consents: %w", err)
+	}
+	return &c, nil
+}
+
+// Record validates rec and adds it to userID's consents, returning them updated
+func (r *Repository) Record(ctx context.Context, userID string, rec Record) (*Consents, error) {
+	if err := rec.Validate(); err != nil {
+		return nil, err
+	}
+	c, err := r.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	c.Add(rec)
+	if err := r.store.Put(ctx, store.UserPK(userID), consentSK, c); err != nil {
+		return nil, fmt.Errorf("failed to save consents: %w", err)
+	}
+	return c, nil
+}
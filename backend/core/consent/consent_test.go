@@ -0,0 +1,128 @@
+package consent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+)
+
+func TestConsents(t *testing.T) {
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	current, _ := Current(PurposeAnalytics)
+
+	t.Run("allows consent to the current document", func(t *testing.T) {
+		// Arrange
+		var c Consents
+
+		// Act
+		c.Add(Record{Purpose: PurposeAnalytics, Version: current.Version, Granted: true, RecordedAt: now})
+
+		// Assert
+		if !c.Allows(PurposeAnalytics) || c.Allows(PurposeMarketingEmails) {
+			t.Errorf("expected only analytics to be allowed, got %+v", c.Records)
+		}
+	})
+
+	t.Run("does not count consent to an older document", func(t *testing.T) {
+		// Arrange
+		var c Consents
+
+		// Act
+		c.Add(Record{Purpose: PurposeAnalytics, Version: current.Version - 1, Granted: true, RecordedAt: now})
+
+		// Assert
+		if c.Allows(PurposeAnalytics) {
+			t.Error("expected consent to an older version not to count")
+		}
+	})
+
+	t.Run("a withdrawal replaces the decision and is kept in the history", func(t *testing.T) {
+		// Arrange
+		var c Consents
+		c.Add(Record{Purpose: PurposeAnalytics, Version: current.Version, Granted: true, RecordedAt: now})
+
+		// Act
+		c.Add(Record{Purpose: PurposeAnalytics, Version: current.Version, Granted: false, RecordedAt: now.Add(time.Hour)})
+
+		// Assert
+		if c.Allows(PurposeAnalytics) || len(c.Records) != 1 || len(c.History) != 2 {
+			t.Errorf("expected one withdrawn decision and two changes, got %+v", c)
+		}
+	})
+
+	t.Run("counts a profile opt-in only without a decision on record", func(t *testing.T) {
+		// Arrange
+		var c Consents
+		legacy := c.AllowsLegacy(PurposePopulationComparisons, true)
+
+		// Act
+		c.Add(Record{Purpose: PurposePopulationComparisons, Version: current.Version, Granted: false, RecordedAt: now})
+
+		// Assert
+		if !legacy || c.AllowsLegacy(PurposePopulationComparisons, true) {
+			t.Error("expected the opt-in to count until a withdrawal was recorded")
+		}
+	})
+}
+
+func TestRecord_Validate(t *testing.T) {
+	current, _ := Current(PurposeMarketingEmails)
+	tests := []struct {
+		name    string
+		record  Record
+		wantErr bool
+	}{
+		{name: "grant to the current version", record: Record{Purpose: PurposeMarketingEmails, Version: current.Version, Granted: true}},
+		{name: "withdrawal of any version", record: Record{Purpose: PurposeMarketingEmails, Version: 0}},
+		{name: "grant to another version", record: Record{Purpose: PurposeMarketingEmails, Version: current.Version + 1, Granted: true}, wantErr: true},
+		{name: "unknown purpose", record: Record{Purpose: "profiling", Version: 1, Granted: true}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.record.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDocuments(t *testing.T) {
+	for _, purpose := range Purposes() {
+		versions := Versions(purpose)
+		if len(versions) == 0 {
+			t.Errorf("expected a document for %s", purpose)
+			continue
+		}
+		for i, d := range versions {
+			if d.Version != i+1 || d.Title == "" || d.Text == "" {
+				t.Errorf("expected %s versions numbered from 1 with text, got %+v", purpose, d)
+			}
+		}
+	}
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+	r := NewRepository(store.NewMemoryStore())
+	current, _ := Current(PurposeAnalytics)
+
+	// Act
+	empty, _ := r.Get(ctx, "user-1")
+	_, err := r.Record(ctx, "user-1", Record{Purpose: PurposeAnalytics, Version: current.Version, Granted: true})
+	saved, _ := r.Get(ctx, "user-1")
+	_, invalid := r.Record(ctx, "user-1", Record{Purpose: "profiling", Granted: true})
+
+	// Assert
+	if len(empty.Records) != 0 || err != nil {
+		t.Fatalf("expected no records then a saved one, got %+v (%v)", empty, err)
+	}
+	if !saved.Allows(PurposeAnalytics) || len(saved.History) != 1 {
+		t.Errorf("expected analytics consent to be saved, got %+v", saved)
+	}
+	if invalid == nil {
+		t.Error("expected an invalid record to be refused")
+	}
+}
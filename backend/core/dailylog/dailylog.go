@@ -0,0 +1,198 @@
+package dailylog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"athlete-forge/store"
+)
+
+const (
+	dailyLogSKPrefix = "DAILY#"
+
+	// DateLayout is the format of daily log dates
+	DateLayout = "2006-01-02"
+)
+
+// Limits that reject obvious entry mistakes
+const (
+	MaxWaterML = 20000
+	MaxSteps   = 200000
+
+	// MaxBodyweight bounds bodyweight in either kilograms or pounds
+	MaxBodyweight = 1000
+)
+
+// Log is a user's hydration, sleep and step totals and bodyweight, in the
+// profile's unit, for one day; nil fields have not been logged
+type Log struct {
+	UserID     string    `json:"userId"`
+	Date       string    `json:"date"`
+	WaterML    *int      `json:"waterMl,omitempty"`
+	SleepHours *float64  `json:"sleepHours,omitempty"`
+	Steps      *int      `json:"steps,omitempty"`
+	Bodyweight *float64  `json:"bodyweight,omitempty"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// Update is a partial change to a day's log; only the fields that are set are applied
+type Update struct {
+	WaterML    *int     `json:"waterMl"`
+	SleepHours *float64 `json:"sleepHours"`
+	Steps      *int     `json:"steps"`
+	Bodyweight *float64 `json:"bodyweight"`
+}
+
+// Validate checks the date and that every logged value is in range
+func (l *Log) Validate() error {
+	if _, err := time.Parse(DateLayout, l.Date); err != nil {
+		return errors.New("date must be in YYYY-MM-DD format")
+	}
+	if l.WaterML != nil && (*l.WaterML < 0 || *l.WaterML > MaxWaterML) {
+		return fmt.Errorf("waterMl must be between 0 and %d", MaxWaterML)
+	}
+	if l.SleepHours != nil && (*l.SleepHours < 0 || *l.SleepHours > 24) {
+		return errors.New("sleepHours must be between 0 and 24")
+	}
+	if l.Steps != nil && (*l.Steps < 0 || *l.Steps > MaxSteps) {
+		return fmt.Errorf("steps must be between 0 and %d", MaxSteps)
+	}
+	if l.Bodyweight != nil && (*l.Bodyweight <= 0 || *l.Bodyweight > MaxBodyweight) {
+		return fmt.Errorf("bodyweight must be positive and at most %d", MaxBodyweight)
+	}
+	return nil
+}
+
+// Apply overwrites the log's fields with those set in u
+func (l *Log) Apply(u Update) {
+	if u.WaterML != nil {
+		l.WaterML = u.WaterML
+	}
+	if u.SleepHours != nil {
+		l.SleepHours = u.SleepHours
+	}
+	if u.Steps != nil {
+		l.Steps = u.Steps
+	}
+	if u.Bodyweight != nil {
+		l.Bodyweight = u.Bodyweight
+	}
+}
+
+// AddWater adds ml to the day's hydration total
+func (l *Log) AddWater(ml int) {
+	total := ml
+	if l.WaterML != nil {
+		total += *l.WaterML
+	}
+	l.WaterML = &total
+}
+
+// WeekStats averages daily logs over the days each metric was logged
+type WeekStats struct {
+	DaysLogged        int     `json:"daysLogged"`
+	AverageWaterML    int     `json:"averageWaterMl"`
+	AverageSleepHours float64 `json:"averageSleepHours"`
+	AverageSteps      int     `json:"averageSteps"`
+	TotalSteps        int     `json:"totalSteps"`
+}
+
+// Summarize averages logs, counting each metric only on days it was logged
+func Summarize(logs []Log) WeekStats {
+	var stats WeekStats
+	var waterDays, sleepDays, stepDays, water int
+	var sleep float64
+	for _, l := range logs {
+		if l.WaterML == nil && l.SleepHours == nil && l.Steps == nil {
+			continue
+		}
+		stats.DaysLogged++
+		if l.WaterML != nil {
+			water += *l.WaterML
+			waterDays++
+		}
+		if l.SleepHours != nil {
+			sleep += *l.SleepHours
+			sleepDays++
+		}
+		if l.Steps != nil {
+			stats.TotalSteps += *l.Steps
+			stepDays++
+		}
+	}
+
+	if waterDays > 0 {
+		stats.AverageWaterML = water / waterDays
+	}
+	if sleepDays > 0 {
+		stats.AverageSleepHours = math.Round(sleep/float64(sleepDays)*10) / 10
+	}
+	if stepDays > 0 {
+		stats.AverageSteps = stats.TotalSteps / stepDays
+	}
+	return stats
+}
+
+// Repository loads and saves daily logs
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Get returns userID's log for date
+func (r *Repository) Get(ctx context.Context, userID, date string) (*Log, error) {
+	var l Log
+	if err := r.store.Get(ctx, store.UserPK(userID), dailyLogSKPrefix+date, &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// GetOrNew returns userID's log for date, or an empty log when nothing has been recorded
+func (r *Repository) GetOrNew(ctx context.Context, userID, date string) (*Log, error) {
+	l, err := r.Get(ctx, userID, date)
+	if errors.Is(err, store.ErrNotFound) {
+		return &Log{UserID: userID, Date: date}, nil
+	}
+	return l, err
+}
+
+// List returns userID's logs between from and to inclusive, oldest first;
+// empty bounds are open-ended
+func (r *Repository) List(ctx context.Context, userID, from, to string) ([]Log, error) {
+	items, err := r.store.Query(ctx, store.UserPK(userID), dailyLogSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daily logs: %w", err)
+	}
+
+	logs := []Log{}
+	for _, item := range items {
+		var l Log
+		if err := item.Decode(&l); err != nil {
+			return nil, err
+		}
+		if (from != "" && l.Date < from) || (to != "" && l.Date > to) {
+			continue
+		}
+		logs = append(logs, l)
+	}
+	return logs, nil
+}
+
+// Save validates l and upserts it for the user and date
+func (r *Repository) Save(ctx context.Context, l *Log) error {
+	if err := l.Validate(); err != nil {
+		return err
+	}
+	if err := r.store.Put(ctx, store.UserPK(l.UserID), dailyLogSKPrefix+l.Date, l); err != nil {
+		return fmt.Errorf("failed to save daily log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package dailylog
+
+import (
+	"context"
+	"testing"
+
+	"athlete-forge/store"
+)
+
+func intPtr(v int) *int { return &v }
+
+func floatPtr(v float64) *float64 { return &v }
+
+func TestLog_Apply(t *testing.T) {
+	t.Run("keeps fields not in the update", func(t *testing.T) {
+		// Arrange
+		l := Log{Date: "2024-03-04", WaterML: intPtr(1500), Steps: intPtr(4000)}
+
+		// Act
+		l.Apply(Update{Steps: intPtr(9000), SleepHours: floatPtr(7.5)})
+
+		// Assert
+		if *l.WaterML != 1500 || *l.Steps != 9000 || *l.SleepHours != 7.5 {
+			t.Errorf("unexpected log: water %d steps %d sleep %v", *l.WaterML, *l.Steps, *l.SleepHours)
+		}
+	})
+}
+
+func TestLog_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		log     Log
+		wantErr bool
+	}{
+		{name: "empty day", log: Log{Date: "2024-03-04"}},
+		{name: "bad date", log: Log{Date: "04/03/2024"}, wantErr: true},
+		{name: "negative water", log: Log{Date: "2024-03-04", WaterML: intPtr(-1)}, wantErr: true},
+		{name: "too much sleep", log: Log{Date: "2024-03-04", SleepHours: floatPtr(25)}, wantErr: true},
+		{name: "too many steps", log: Log{Date: "2024-03-04", Steps: intPtr(MaxSteps + 1)}, wantErr: true},
+		{name: "zero bodyweight", log: Log{Date: "2024-03-04", Bodyweight: floatPtr(0)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.log.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	t.Run("averages each metric over the days it was logged", func(t *testing.T) {
+		// Arrange
+		logs := []Log{
+			{Date: "2024-03-04", WaterML: intPtr(2000), SleepHours: floatPtr(7)},
+			{Date: "2024-03-05", WaterML: intPtr(3000), Steps: intPtr(10000)},
+			{Date: "2024-03-06", SleepHours: floatPtr(8)},
+			{Date: "2024-03-07"},
+		}
+
+		// Act
+		stats := Summarize(logs)
+
+		// Assert
+		want := WeekStats{DaysLogged: 3, AverageWaterML: 2500, AverageSleepHours: 7.5, AverageSteps: 10000, TotalSteps: 10000}
+		if stats != want {
+			t.Errorf("expected %+v, got %+v", want, stats)
+		}
+	})
+}
+
+func TestRepository(t *testing.T) {
+	t.Run("adds water to an existing day", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		repo := NewRepository(store.NewMemoryStore())
+		repo.Save(ctx, &Log{UserID: "user-1", Date: "2024-03-04", WaterML: intPtr(500)})
+
+		// Act
+		l, _ := repo.GetOrNew(ctx, "user-1", "2024-03-04")
+		l.AddWater(250)
+		repo.Save(ctx, l)
+		logs, err := repo.List(ctx, "user-1", "2024-03-01", "2024-03-31")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(logs) != 1 || *logs[0].WaterML != 750 {
+			t.Errorf("unexpected logs: %+v", logs)
+		}
+	})
+}
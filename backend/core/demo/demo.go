@@ -0,0 +1,194 @@
+package demo
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"athlete-forge/cardio"
+	"athlete-forge/program"
+	"athlete-forge/readiness"
+	"athlete-forge/workout"
+)
+
+// Weeks is how much history a demo account is given
+const Weeks = 8
+
+// ProgramName names the demo program, so demo accounts are recognisable
+const ProgramName = "Demo: Beginner Strength"
+
+// lift is one of the demo program's exercises and where it starts
+type lift struct {
+	name      string
+	sets      int
+	reps      int
+	start     float64
+	increment float64
+}
+
+// Sessions alternate between day A and day B, three times a week
+var (
+	dayA = []lift{
+		{name: "back squat", sets: 3, reps: 5, start: 60, increment: 2.5},
+		{name: "bench press", sets: 3, reps: 5, start: 45, increment: 2.5},
+		{name: "barbell row", sets: 3, reps: 8, start: 40, increment: 2.5},
+	}
+	dayB = []lift{
+		{name: "back squat", sets: 3, reps: 5, start: 60, increment: 2.5},
+		{name: "overhead press", sets: 3, reps: 5, start: 30, increment: 1.25},
+		{name: "deadlift", sets: 1, reps: 5, start: 80, increment: 5},
+	}
+)
+
+// trainingDays are the weekdays sessions fall on, as days after Monday
+var trainingDays = []int{0, 2, 4}
+
+// Data is a demo account's history
+type Data struct {
+	Program    program.Program
+	Workouts   []workout.Workout
+	Activities []cardio.Activity
+	CheckIns   []readiness.CheckIn
+}
+
+// Items returns how many items d saves
+func (d Data) Items() int {
+	return 1 + len(d.Workouts) + len(d.Activities) + len(d.CheckIns)
+}
+
+// Generate builds history for userID over the Weeks before the current one: a
+// linear progression program with three completed sessions a week and a weekend
+// run, and a daily check-in up to today. The same seed gives the same history, with small variations
+// in effort, missed reps and sleep so charts and readiness look lived in
+func Generate(userID string, now time.Time, seed int64) Data {
+	random := rand.New(rand.NewSource(seed))
+	now = now.UTC()
+	start := cardio.WeekStart(now).AddDate(0, 0, -7*Weeks)
+
+	weights := map[string]float64{}
+	for _, l := range append(dayA, dayB...) {
+		weights[l.name] = l.start
+	}
+
+	var d Data
+	for week := 0; week < Weeks; week++ {
+		monday := start.AddDate(0, 0, 7*week)
+		for _, offset := range trainingDays {
+			startedAt := monday.AddDate(0, 0, offset).Add(17*time.Hour + 30*time.Minute)
+			lifts := dayA
+			if len(d.Workouts)%2 == 1 {
+				lifts = dayB
+			}
+			d.Workouts = append(d.Workouts, session(userID, startedAt, lifts, weights, random))
+		}
+
+		saturday := monday.AddDate(0, 0, 5).Add(9 * time.Hour)
+		d.Activities = append(d.Activities, run(userID, saturday, week, random))
+	}
+
+	for day := start; day.Before(now); day = day.AddDate(0, 0, 1) {
+		d.CheckIns = append(d.CheckIns, checkIn(userID, day, now, random))
+	}
+
+	d.Program = program.Program{
+		ID: id(start, 0), UserID: userID,
+		Name:              ProgramName,
+		SessionsCompleted: len(d.Workouts),
+		CreatedAt:         start,
+		UpdatedAt:         now,
+	}
+	seen := map[string]bool{}
+	for _, l := range append(dayA, dayB...) {
+		if seen[l.name] {
+			continue
+		}
+		seen[l.name] = true
+		d.Program.Exercises = append(d.Program.Exercises, program.Prescription{
+			Exercise: l.name,
+			Sets:     l.sets,
+			Reps:     l.reps,
+			Weight:   weights[l.name],
+			Rule:     program.Rule{Type: program.RuleLinear, Increment: l.increment, RoundTo: 1.25},
+		})
+	}
+	return d
+}
+
+// session performs lifts at their current weights, progressing each lift whose
+// sets were all completed; roughly one lift in eight misses a rep on its last set
+func session(userID string, startedAt time.Time, lifts []lift, weights map[string]float64, random *rand.Rand) workout.Workout {
+	completedAt := startedAt.Add(time.Duration(50+random.Intn(25)) * time.Minute)
+	w := workout.Workout{
+		ID:          id(startedAt, 1),
+		UserID:      userID,
+		Status:      workout.StatusCompleted,
+		StartedAt:   startedAt,
+		CompletedAt: &completedAt,
+	}
+	for _, l := range lifts {
+		e := workout.Exercise{Name: l.name}
+		missed := random.Intn(8) == 0
+		for set := 0; set < l.sets; set++ {
+			reps := l.reps
+			if missed && set == l.sets-1 {
+				reps--
+			}
+			rpe := 6.5 + float64(set) + float64(random.Intn(3))*0.5
+			if rpe > 10 {
+				rpe = 10
+			}
+			e.Sets = append(e.Sets, workout.Set{Reps: reps, Weight: weights[l.name], RPE: rpe})
+		}
+		w.Exercises = append(w.Exercises, e)
+		if !missed {
+			weights[l.name] += l.increment
+		}
+	}
+	return w
+}
+
+// run is an easy weekend run that lengthens over the weeks
+func run(userID string, startTime time.Time, week int, random *rand.Rand) cardio.Activity {
+	distance := 5000 + float64(week)*500 + float64(random.Intn(500))
+	pace := 330 + random.Intn(30)
+	return cardio.Activity{
+		ID:              id(startTime, 2),
+		UserID:          userID,
+		Sport:           "run",
+		StartTime:       startTime,
+		DurationSeconds: int(distance/1000*float64(pace)) + 1,
+		DistanceMeters:  distance,
+		AverageHR:       140 + random.Intn(15),
+		MaxHR:           165 + random.Intn(15),
+	}
+}
+
+// id identifies a demo item by when it happened, sorting by time like the IDs of
+// items users create; kind keeps items of different kinds apart. Being derived
+// from the history, IDs are the same when it is generated again in the same week,
+// so a retried seeding overwrites rather than duplicates
+func id(at time.Time, kind int) string {
+	return fmt.Sprintf("%012x%010x", at.UnixMilli(), kind)
+}
+
+// checkIn rates day, with lower sleep and more soreness after training days
+func checkIn(userID string, day, now time.Time, random *rand.Rand) readiness.CheckIn {
+	soreness := 1 + random.Intn(2)
+	if weekday := int(day.Weekday()+6) % 7; weekday == 1 || weekday == 3 || weekday == 5 {
+		soreness++
+	}
+	updatedAt := day.Add(7 * time.Hour)
+	if updatedAt.After(now) {
+		updatedAt = now
+	}
+	return readiness.CheckIn{
+		UserID:       userID,
+		Date:         day.Format(readiness.DateLayout),
+		SleepHours:   6 + float64(random.Intn(5))*0.5,
+		SleepQuality: 3 + random.Intn(3),
+		Soreness:     soreness,
+		Mood:         3 + random.Intn(3),
+		Energy:       2 + random.Intn(4),
+		UpdatedAt:    updatedAt,
+	}
+}
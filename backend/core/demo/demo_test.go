@@ -0,0 +1,70 @@
+package demo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"athlete-forge/workout"
+)
+
+func TestGenerate(t *testing.T) {
+	now := time.Date(2024, 3, 14, 12, 0, 0, 0, time.UTC)
+
+	t.Run("builds valid history for the weeks before this one", func(t *testing.T) {
+		// Act
+		d := Generate("user-1", now, 1)
+
+		// Assert
+		if err := d.Program.Validate(); err != nil {
+			t.Errorf("invalid program: %v", err)
+		}
+		if len(d.Workouts) != 3*Weeks || len(d.Activities) != Weeks {
+			t.Errorf("expected %d workouts and %d activities, got %d and %d", 3*Weeks, Weeks, len(d.Workouts), len(d.Activities))
+		}
+		for _, w := range d.Workouts {
+			if err := w.Validate(); err != nil || w.Status != workout.StatusCompleted || !w.CompletedAt.Before(time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)) {
+				t.Errorf("unexpected workout %+v: %v", w, err)
+			}
+		}
+		for _, a := range d.Activities {
+			if err := a.Validate(); err != nil {
+				t.Errorf("invalid activity: %v", err)
+			}
+		}
+		for _, c := range d.CheckIns {
+			if err := c.Validate(); err != nil {
+				t.Errorf("invalid check-in: %v", err)
+			}
+		}
+		if len(d.CheckIns) != 7*Weeks+4 || d.CheckIns[len(d.CheckIns)-1].Date != "2024-03-14" {
+			t.Errorf("expected daily check-ins up to today, got %d", len(d.CheckIns))
+		}
+		if d.Program.SessionsCompleted != len(d.Workouts) {
+			t.Errorf("expected %d sessions completed, got %d", len(d.Workouts), d.Program.SessionsCompleted)
+		}
+	})
+
+	t.Run("loads progress across the weeks", func(t *testing.T) {
+		// Act
+		d := Generate("user-1", now, 1)
+
+		// Assert
+		first := d.Workouts[0].Exercises[0].Sets[0].Weight
+		last := d.Workouts[len(d.Workouts)-1].Exercises[0].Sets[0].Weight
+		if last <= first {
+			t.Errorf("expected squat to progress from %v, got %v", first, last)
+		}
+	})
+
+	t.Run("the same seed generates the same history", func(t *testing.T) {
+		// Act
+		first := Generate("user-1", now, 7)
+		second := Generate("user-1", now, 7)
+
+		// Assert
+		if !reflect.DeepEqual(first, second) {
+			t.Error("expected identical history for the same seed")
+		}
+	})
+}
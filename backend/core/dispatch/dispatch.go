@@ -0,0 +1,50 @@
+package dispatch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"athlete-forge/awsapi"
+)
+
+// Dispatcher hands work to a background invocation so a request can return early
+type Dispatcher interface {
+	// Dispatch queues event for asynchronous processing
+	Dispatch(ctx context.Context, event interface{}) error
+}
+
+// LambdaDispatcher invokes a Lambda function asynchronously with the event as its payload
+type LambdaDispatcher struct {
+	client       *awsapi.Client
+	functionName string
+}
+
+// NewLambdaDispatcher creates a dispatcher targeting functionName, usually the current function
+func NewLambdaDispatcher(client *awsapi.Client, functionName string) *LambdaDispatcher {
+	return &LambdaDispatcher{client: client, functionName: functionName}
+}
+
+// Dispatch calls Invoke with the Event invocation type, which returns once Lambda has queued the event
+func (d *LambdaDispatcher) Dispatch(ctx context.Context, event interface{}) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dispatched event: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/2015-03-31/functions/%s/invocations", d.client.URL("lambda"), url.PathEscape(d.functionName))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Invoke request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Amz-Invocation-Type", "Event")
+
+	if _, err := d.client.Do(req, body, "lambda"); err != nil {
+		return fmt.Errorf("failed to dispatch event: %w", err)
+	}
+	return nil
+}
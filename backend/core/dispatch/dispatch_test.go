@@ -0,0 +1,93 @@
+package dispatch
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"athlete-forge/awsapi"
+)
+
+func TestLambdaDispatcher_Dispatch(t *testing.T) {
+	t.Run("invokes the function asynchronously", func(t *testing.T) {
+		// Arrange
+		var path, invocationType, body string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			invocationType = r.Header.Get("X-Amz-Invocation-Type")
+			data, _ := io.ReadAll(r.Body)
+			body = string(data)
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer server.Close()
+		client := awsapi.NewClient("eu-west-2", awsapi.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"})
+		client.Endpoint = func(string) string { return server.URL }
+
+		// Act
+		err := NewLambdaDispatcher(client, "athlete-forge").Dispatch(context.Background(), map[string]string{"job": "render-export"})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if path != "/2015-03-31/functions/athlete-forge/invocations" || invocationType != "Event" || body != `{"job":"render-export"}` {
+			t.Errorf("unexpected request: %s %s %s", path, invocationType, body)
+		}
+	})
+}
+
+func TestSQSDispatcher_Dispatch(t *testing.T) {
+	t.Run("sends the event as a message", func(t *testing.T) {
+		// Arrange
+		var target, body string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			target = r.Header.Get("X-Amz-Target")
+			data, _ := io.ReadAll(r.Body)
+			body = string(data)
+			w.Write([]byte(`{"MessageId":"m1"}`))
+		}))
+		defer server.Close()
+		client := awsapi.NewClient("eu-west-2", awsapi.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"})
+		client.Endpoint = func(string) string { return server.URL }
+
+		// Act
+		err := NewSQSDispatcher(client, "https://sqs.eu-west-2.amazonaws.com/123/jobs").Dispatch(context.Background(), map[string]string{"job": "bulk-edit"})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if target != "AmazonSQS.SendMessage" || body != `{"MessageBody":"{\"job\":\"bulk-edit\"}","QueueUrl":"https://sqs.eu-west-2.amazonaws.com/123/jobs"}` {
+			t.Errorf("unexpected request: %s %s", target, body)
+		}
+	})
+}
+
+func TestStepFunctionsDispatcher_Dispatch(t *testing.T) {
+	t.Run("starts an execution with the event as input", func(t *testing.T) {
+		// Arrange
+		var target, body string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			target = r.Header.Get("X-Amz-Target")
+			data, _ := io.ReadAll(r.Body)
+			body = string(data)
+			w.Write([]byte(`{"executionArn":"arn:aws:states:eu-west-2:123:execution:jobs:1"}`))
+		}))
+		defer server.Close()
+		client := awsapi.NewClient("eu-west-2", awsapi.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"})
+		client.Endpoint = func(string) string { return server.URL }
+
+		// Act
+		err := NewStepFunctionsDispatcher(client, "arn:aws:states:eu-west-2:123:stateMachine:jobs").Dispatch(context.Background(), map[string]string{"job": "recompute-stats"})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if target != "AWSStepFunctions.StartExecution" || body != `{"input":"{\"job\":\"recompute-stats\"}","stateMachineArn":"arn:aws:states:eu-west-2:123:stateMachine:jobs"}` {
+			t.Errorf("unexpected request: %s %s", target, body)
+		}
+	})
+}
@@ -0,0 +1,38 @@
+package dispatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"athlete-forge/awsapi"
+)
+
+// sqsService is the SQS JSON protocol descriptor
+var sqsService = awsapi.Service{Name: "sqs", TargetPrefix: "AmazonSQS", JSONVersion: "1.0"}
+
+// SQSDispatcher sends events to a queue whose worker is the same function, so
+// failed jobs are retried by the queue and end up in its dead-letter queue
+type SQSDispatcher struct {
+	client   *awsapi.Client
+	queueURL string
+}
+
+// NewSQSDispatcher creates a dispatcher sending to queueURL
+func NewSQSDispatcher(client *awsapi.Client, queueURL string) *SQSDispatcher {
+	return &SQSDispatcher{client: client, queueURL: queueURL}
+}
+
+// Dispatch sends event as the body of one message
+func (d *SQSDispatcher) Dispatch(ctx context.Context, event interface{}) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dispatched event: %w", err)
+	}
+
+	in := map[string]string{"QueueUrl": d.queueURL, "MessageBody": string(body)}
+	if err := d.client.Call(ctx, sqsService, "SendMessage", in, nil); err != nil {
+		return fmt.Errorf("failed to dispatch event: %w", err)
+	}
+	return nil
+}
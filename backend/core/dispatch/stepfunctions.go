@@ -0,0 +1,35 @@
+package dispatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"athlete-forge/awsapi"
+)
+
+// StepFunctionsDispatcher starts a state machine execution per event, for jobs
+// that run as a sequence of task invocations rather than one
+type StepFunctionsDispatcher struct {
+	client          *awsapi.Client
+	stateMachineARN string
+}
+
+// NewStepFunctionsDispatcher creates a dispatcher starting executions of stateMachineARN
+func NewStepFunctionsDispatcher(client *awsapi.Client, stateMachineARN string) *StepFunctionsDispatcher {
+	return &StepFunctionsDispatcher{client: client, stateMachineARN: stateMachineARN}
+}
+
+// Dispatch calls StartExecution with event as the execution input
+func (d *StepFunctionsDispatcher) Dispatch(ctx context.Context, event interface{}) error {
+	input, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dispatched event: %w", err)
+	}
+
+	in := map[string]string{"stateMachineArn": d.stateMachineARN, "input": string(input)}
+	if err := d.client.Call(ctx, awsapi.StepFunctions, "StartExecution", in, nil); err != nil {
+		return fmt.Errorf("failed to dispatch event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DataKeyTTL is how long one data key encrypts new values before another is
+// requested, bounding key provider calls without reusing a key indefinitely
+const DataKeyTTL = 5 * time.Minute
+
+// maxCachedKeys bounds the decrypted data keys kept in memory
+const maxCachedKeys = 256
+
+// ErrDecrypt is returned when a sealed value cannot be decrypted
+var ErrDecrypt = errors.New("failed to decrypt sealed value")
+
+// DataKey is a fresh 256-bit key in plaintext and wrapped under a master key
+type DataKey struct {
+	KeyID     string
+	Plaintext []byte
+	Wrapped   []byte
+}
+
+// KeyProvider issues data keys and unwraps them, usually backed by KMS
+type KeyProvider interface {
+	// GenerateDataKey returns a new data key wrapped under the current master key
+	GenerateDataKey(ctx context.Context) (*DataKey, error)
+
+	// Decrypt unwraps a data key, returning its plaintext and the master key that wrapped it
+	Decrypt(ctx context.Context, wrapped []byte) ([]byte, string, error)
+}
+
+// Sealed is an encrypted value stored alongside the wrapped data key needed to open it
+type Sealed struct {
+	KeyID      string `json:"keyId"`
+	DataKey    []byte `json:"dataKey"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Cipher seals values with AES-256-GCM under data keys from a KeyProvider.
+// Values are bound to an associated string, such as the owner's ID, so a
+// sealed value copied to another record fails to open
+type Cipher struct {
+	keys KeyProvider
+	now  func() time.Time
+
+	mu        sync.Mutex
+	current   *DataKey
+	expiresAt time.Time
+	opened    map[string][]byte
+}
+
+// NewCipher creates a Cipher using keys
+func NewCipher(keys KeyProvider) *Cipher {
+	return &Cipher{keys: keys, now: time.Now, opened: map[string][]byte{}}
+}
+
+// Seal encrypts plaintext bound to the associated string
+func (c *Cipher) Seal(ctx context.Context, plaintext []byte, associated string) (*Sealed, error) {
+	key, err := c.dataKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return &Sealed{
+		KeyID:      key.KeyID,
+		DataKey:    key.Wrapped,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, []byte(associated)),
+	}, nil
+}
+
+// Open decrypts s, which must have been sealed with the same associated string
+func (c *Cipher) Open(ctx context.Context, s *Sealed, associated string) ([]byte, error) {
+	key, err := c.unwrap(ctx, s.DataKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, s.Nonce, s.Ciphertext, []byte(associated))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// NeedsRotation reports whether s was sealed under a master key other than the
+// current one, so that re-sealing it completes a key rotation
+func (c *Cipher) NeedsRotation(ctx context.Context, s *Sealed) (bool, error) {
+	key, err := c.dataKey(ctx)
+	if err != nil {
+		return false, err
+	}
+	return s.KeyID != key.KeyID, nil
+}
+
+// dataKey returns the data key for new values, requesting another once it expires
+func (c *Cipher) dataKey(ctx context.Context) (*DataKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if c.current != nil && now.Before(c.expiresAt) {
+		return c.current, nil
+	}
+	key, err := c.keys.GenerateDataKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	c.current = key
+	c.expiresAt = now.Add(DataKeyTTL)
+	c.remember(key.Wrapped, key.Plaintext)
+	return key, nil
+}
+
+// unwrap returns the plaintext of a wrapped data key, from memory when it was seen before
+func (c *Cipher) unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	c.mu.Lock()
+	key, ok := c.opened[string(wrapped)]
+	c.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	key, _, err := c.keys.Decrypt(ctx, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecrypt, err)
+	}
+	c.mu.Lock()
+	c.remember(wrapped, key)
+	c.mu.Unlock()
+	return key, nil
+}
+
+// remember caches an unwrapped data key, dropping the cache when it is full;
+// callers hold c.mu
+func (c *Cipher) remember(wrapped, plaintext []byte) {
+	if len(c.opened) >= maxCachedKeys {
+		c.opened = map[string][]byte{}
+	}
+	c.opened[string(wrapped)] = plaintext
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
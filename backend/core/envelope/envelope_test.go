@@ -0,0 +1,113 @@
+package envelope
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// countingKeys counts the calls made to a key provider
+type countingKeys struct {
+	KeyProvider
+	generated int
+	decrypted int
+}
+
+func (c *countingKeys) GenerateDataKey(ctx context.Context) (*DataKey, error) {
+	c.generated++
+	return c.KeyProvider.GenerateDataKey(ctx)
+}
+
+func (c *countingKeys) Decrypt(ctx context.Context, wrapped []byte) ([]byte, string, error) {
+	c.decrypted++
+	return c.KeyProvider.Decrypt(ctx, wrapped)
+}
+
+func newLocalKeys(t *testing.T, current string) *LocalKeys {
+	t.Helper()
+	keys, err := NewLocalKeys(current, map[string][]byte{
+		"old": []byte("0123456789abcdef0123456789abcdef"),
+		"new": []byte("fedcba9876543210fedcba9876543210"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return keys
+}
+
+func TestCipher(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("opens what it seals", func(t *testing.T) {
+		// Arrange
+		c := NewCipher(newLocalKeys(t, "new"))
+
+		// Act
+		sealed, err := c.Seal(ctx, []byte("torn meniscus"), "user-1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		plaintext, err := c.Open(ctx, sealed, "user-1")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(plaintext) != "torn meniscus" || sealed.KeyID != "new" {
+			t.Errorf("unexpected result %q sealed under %q", plaintext, sealed.KeyID)
+		}
+	})
+
+	t.Run("rejects a different associated string", func(t *testing.T) {
+		// Arrange
+		c := NewCipher(newLocalKeys(t, "new"))
+		sealed, _ := c.Seal(ctx, []byte("torn meniscus"), "user-1")
+
+		// Act
+		_, err := c.Open(ctx, sealed, "user-2")
+
+		// Assert
+		if !errors.Is(err, ErrDecrypt) {
+			t.Errorf("expected ErrDecrypt, got %v", err)
+		}
+	})
+
+	t.Run("reuses a data key until it expires", func(t *testing.T) {
+		// Arrange
+		keys := &countingKeys{KeyProvider: newLocalKeys(t, "new")}
+		c := NewCipher(keys)
+		now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+		c.now = func() time.Time { return now }
+
+		// Act
+		first, _ := c.Seal(ctx, []byte("a"), "user-1")
+		c.Seal(ctx, []byte("b"), "user-2")
+		now = now.Add(DataKeyTTL)
+		c.Seal(ctx, []byte("c"), "user-3")
+		NewCipher(keys).Open(ctx, first, "user-1")
+
+		// Assert
+		if keys.generated != 2 || keys.decrypted != 1 {
+			t.Errorf("expected 2 data keys and 1 decrypt, got %d and %d", keys.generated, keys.decrypted)
+		}
+	})
+
+	t.Run("opens values sealed under a previous master key", func(t *testing.T) {
+		// Arrange
+		sealed, _ := NewCipher(newLocalKeys(t, "old")).Seal(ctx, []byte("torn meniscus"), "user-1")
+		rotated := NewCipher(newLocalKeys(t, "new"))
+
+		// Act
+		plaintext, err := rotated.Open(ctx, sealed, "user-1")
+		stale, _ := rotated.NeedsRotation(ctx, sealed)
+
+		// Assert
+		if err != nil || string(plaintext) != "torn meniscus" {
+			t.Fatalf("unexpected result %q: %v", plaintext, err)
+		}
+		if !stale {
+			t.Error("expected value sealed under the old key to need rotation")
+		}
+	})
+}
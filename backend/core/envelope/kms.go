@@ -0,0 +1,51 @@
+package envelope
+
+import (
+	"context"
+	"fmt"
+
+	"athlete-forge/awsapi"
+)
+
+// kmsService is the KMS JSON protocol descriptor
+var kmsService = awsapi.Service{Name: "kms", TargetPrefix: "TrentService", JSONVersion: "1.1"}
+
+// KMS wraps data keys under a KMS key. KeyID may be a key ID, ARN or alias;
+// pointing an alias at a new key rotates new values onto it while older values
+// still decrypt, since KMS finds the wrapping key from the ciphertext
+type KMS struct {
+	client *awsapi.Client
+	keyID  string
+}
+
+// NewKMS creates a KMS key provider for keyID
+func NewKMS(client *awsapi.Client, keyID string) *KMS {
+	return &KMS{client: client, keyID: keyID}
+}
+
+// GenerateDataKey calls GenerateDataKey for a 256-bit AES key
+func (k *KMS) GenerateDataKey(ctx context.Context) (*DataKey, error) {
+	in := map[string]string{"KeyId": k.keyID, "KeySpec": "AES_256"}
+	var out struct {
+		KeyID          string `json:"KeyId"`
+		Plaintext      []byte `json:"Plaintext"`
+		CiphertextBlob []byte `json:"CiphertextBlob"`
+	}
+	if err := k.client.Call(ctx, kmsService, "GenerateDataKey", in, &out); err != nil {
+		return nil, fmt.Errorf("kms GenerateDataKey failed: %w", err)
+	}
+	return &DataKey{KeyID: out.KeyID, Plaintext: out.Plaintext, Wrapped: out.CiphertextBlob}, nil
+}
+
+// Decrypt calls Decrypt on a wrapped data key
+func (k *KMS) Decrypt(ctx context.Context, wrapped []byte) ([]byte, string, error) {
+	in := map[string][]byte{"CiphertextBlob": wrapped}
+	var out struct {
+		KeyID     string `json:"KeyId"`
+		Plaintext []byte `json:"Plaintext"`
+	}
+	if err := k.client.Call(ctx, kmsService, "Decrypt", in, &out); err != nil {
+		return nil, "", fmt.Errorf("kms Decrypt failed: %w", err)
+	}
+	return out.Plaintext, out.KeyID, nil
+}
@@ -0,0 +1,54 @@
+package envelope
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"athlete-forge/awsapi"
+)
+
+func TestKMS(t *testing.T) {
+	t.Run("generates and decrypts data keys", func(t *testing.T) {
+		// Arrange
+		var targets []string
+		var generateRequest map[string]string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			target := r.Header.Get("X-Amz-Target")
+			targets = append(targets, target)
+			if target == "TrentService.GenerateDataKey" {
+				json.NewDecoder(r.Body).Decode(&generateRequest)
+				w.Write([]byte(`{"KeyId":"arn:aws:kms:eu-west-2:111122223333:key/1234","Plaintext":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=","CiphertextBlob":"d3JhcHBlZA=="}`))
+				return
+			}
+			w.Write([]byte(`{"KeyId":"arn:aws:kms:eu-west-2:111122223333:key/1234","Plaintext":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="}`))
+		}))
+		defer server.Close()
+		client := awsapi.NewClient("eu-west-2", awsapi.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"})
+		client.Endpoint = func(string) string { return server.URL }
+		kms := NewKMS(client, "alias/athlete-forge-profile")
+
+		// Act
+		key, err := kms.GenerateDataKey(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		plaintext, keyID, err := kms.Decrypt(context.Background(), key.Wrapped)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if generateRequest["KeyId"] != "alias/athlete-forge-profile" || generateRequest["KeySpec"] != "AES_256" {
+			t.Errorf("unexpected GenerateDataKey request: %v", generateRequest)
+		}
+		if len(key.Plaintext) != 32 || string(key.Wrapped) != "wrapped" || len(plaintext) != 32 || keyID != key.KeyID {
+			t.Errorf("unexpected keys: %+v, %d bytes from %s", key, len(plaintext), keyID)
+		}
+		if len(targets) != 2 || targets[1] != "TrentService.Decrypt" {
+			t.Errorf("unexpected calls: %v", targets)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package envelope
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// LocalKeys wraps data keys under master keys held in memory, for local runs and
+// tests. New data keys use the current key; the others only decrypt, so adding
+// a key and making it current rotates without losing older values
+type LocalKeys struct {
+	current string
+	keys    map[string][]byte
+}
+
+// NewLocalKeys creates a provider whose current master key is keys[current];
+// every key must be 32 bytes
+func NewLocalKeys(current string, keys map[string][]byte) (*LocalKeys, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("current key %q is not in the key set", current)
+	}
+	for id, key := range keys {
+		if len(key) != 32 || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("key %q must be 32 bytes with no colon in its ID", id)
+		}
+	}
+	return &LocalKeys{current: current, keys: keys}, nil
+}
+
+// GenerateDataKey returns a random data key wrapped as "<keyID>:<sealed key>"
+func (l *LocalKeys) GenerateDataKey(ctx context.Context) (*DataKey, error) {
+	plaintext := make([]byte, 32)
+	if _, err := rand.Read(plaintext); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	aead, err := newAEAD(l.keys[l.current])
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	wrapped := append([]byte(l.current+":"), nonce...)
+	wrapped = aead.Seal(wrapped, nonce, plaintext, []byte(l.current))
+	return &DataKey{KeyID: l.current, Plaintext: plaintext, Wrapped: wrapped}, nil
+}
+
+// Decrypt unwraps a data key with the master key named in its prefix
+func (l *LocalKeys) Decrypt(ctx context.Context, wrapped []byte) ([]byte, string, error) {
+	id, rest, ok := strings.Cut(string(wrapped), ":")
+	key, known := l.keys[id]
+	if !ok || !known {
+		return nil, "", errors.New("unknown master key")
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(rest) < aead.NonceSize() {
+		return nil, "", errors.New("wrapped key is truncated")
+	}
+	nonce, ciphertext := []byte(rest[:aead.NonceSize()]), []byte(rest[aead.NonceSize():])
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(id))
+	if err != nil {
+		return nil, "", err
+	}
+	return plaintext, id, nil
+}
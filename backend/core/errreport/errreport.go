@@ -0,0 +1,133 @@
+package errreport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Kinds of report
+const (
+	KindError = "error"
+	KindPanic = "panic"
+)
+
+// maxFrames bounds the stack captured for a report
+const maxFrames = 50
+
+// Frame is one call in a stack trace
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// Report describes one failure: a handler error or a recovered panic, with the
+// request or job it happened in. Stack is innermost call first
+type Report struct {
+	Kind            string
+	Message         string
+	Stack           []Frame
+	Time            time.Time
+	Release         string
+	Environment     string
+	Method          string
+	Route           string
+	Path            string
+	Job             string
+	UserID          string
+	ClientRequestID string
+}
+
+// Reporter sends failures somewhere they can be searched and alerted on
+type Reporter interface {
+	Report(ctx context.Context, r Report) error
+}
+
+// Stack returns the calling goroutine's stack, skipping skip callers of Stack
+// and the runtime's own panic frames
+func Stack(skip int) []Frame {
+	pcs := make([]uintptr, maxFrames)
+	n := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var stack []Frame
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.") {
+			stack = append(stack, Frame{Function: frame.Function, File: frame.File, Line: frame.Line})
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+// stackError is an error with the stack where WithStack was called on it
+type stackError struct {
+	err   error
+	stack []Frame
+}
+
+func (e *stackError) Error() string { return e.err.Error() }
+
+func (e *stackError) Unwrap() error { return e.err }
+
+// WithStack returns err with its caller's stack attached, for StackOf to find
+// once the error is reported however it has been wrapped since. Errors that
+// already carry a stack, and nil, are returned as they are
+func WithStack(err error) error {
+	if err == nil || StackOf(err) != nil {
+		return err
+	}
+	return &stackError{err: err, stack: Stack(1)}
+}
+
+// StackOf returns the stack attached to err, or to an error it wraps, by
+// WithStack, or nil when there is none
+func StackOf(err error) []Frame {
+	var withStack *stackError
+	if errors.As(err, &withStack) {
+		return withStack.stack
+	}
+	return nil
+}
+
+// Log reports failures as structured log lines, so they can be found in
+// CloudWatch Logs Insights with filter error_report = 1 without another service
+type Log struct {
+	logger zerolog.Logger
+}
+
+// NewLog creates a reporter writing to logger
+func NewLog(logger zerolog.Logger) *Log {
+	return &Log{logger: logger}
+}
+
+// Report logs r at error level
+func (l *Log) Report(ctx context.Context, r Report) error {
+	stack := make([]string, len(r.Stack))
+	for i, f := range r.Stack {
+		stack[i] = fmt.Sprintf("%s (%s:%d)", f.Function, f.File, f.Line)
+	}
+	l.logger.Error().
+		Bool("error_report", true).
+		Str("kind", r.Kind).
+		Str("error", r.Message).
+		Strs("stack", stack).
+		Str("release", r.Release).
+		Str("environment", r.Environment).
+		Str("method", r.Method).
+		Str("route", r.Route).
+		Str("path", r.Path).
+		Str("job", r.Job).
+		Str("user_id", r.UserID).
+		Str("client_request_id", r.ClientRequestID).
+		Msg("Error reported")
+	return nil
+}
@@ -0,0 +1,155 @@
+package errreport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestStack(t *testing.T) {
+	// Act
+	stack := Stack(0)
+
+	// Assert
+	if len(stack) == 0 || !strings.HasSuffix(stack[0].Function, "errreport.TestStack") || stack[0].Line == 0 {
+		t.Errorf("expected the caller first, got %+v", stack)
+	}
+}
+
+func TestWithStack(t *testing.T) {
+	t.Run("keeps the stack where the error was first wrapped", func(t *testing.T) {
+		// Arrange
+		base := errors.New("throttled")
+		err := fmt.Errorf("failed to save: %w", WithStack(base))
+
+		// Act
+		stack := StackOf(WithStack(err))
+
+		// Assert
+		if len(stack) == 0 || !strings.HasSuffix(stack[0].Function, "errreport.TestWithStack.func1") {
+			t.Errorf("expected the wrapping caller first, got %+v", stack)
+		}
+		if !errors.Is(err, base) || err.Error() != "failed to save: throttled" {
+			t.Errorf("expected the error unchanged, got %v", err)
+		}
+	})
+
+	t.Run("leaves nil and errors without a stack alone", func(t *testing.T) {
+		if WithStack(nil) != nil || StackOf(errors.New("plain")) != nil {
+			t.Error("expected no stack")
+		}
+	})
+}
+
+func TestLog_Report(t *testing.T) {
+	// Arrange
+	var out bytes.Buffer
+	reporter := NewLog(zerolog.New(&out))
+
+	// Act
+	err := reporter.Report(context.Background(), Report{
+		Kind:    KindPanic,
+		Message: "index out of range",
+		Stack:   []Frame{{Function: "handler.handleGetWorkout", File: "handler/workouts.go", Line: 42}},
+		Release: "abc123",
+		Route:   "/api/workouts/{id}",
+		UserID:  "user-1",
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var line map[string]interface{}
+	json.Unmarshal(out.Bytes(), &line)
+	if line["error_report"] != true || line["kind"] != KindPanic || line["release"] != "abc123" || line["user_id"] != "user-1" {
+		t.Errorf("unexpected log line: %s", out.String())
+	}
+	if stack, _ := line["stack"].([]interface{}); len(stack) != 1 || stack[0] != "handler.handleGetWorkout (handler/workouts.go:42)" {
+		t.Errorf("unexpected stack: %v", line["stack"])
+	}
+}
+
+func TestNewSentry(t *testing.T) {
+	tests := []struct {
+		dsn      string
+		endpoint string
+		wantErr  bool
+	}{
+		{dsn: "https://public@o1.ingest.sentry.io/42", endpoint: "https://o1.ingest.sentry.io/api/42/store/"},
+		{dsn: "https://public@sentry.example.com/sentry/7", endpoint: "https://sentry.example.com/sentry/api/7/store/"},
+		{dsn: "https://o1.ingest.sentry.io/42", wantErr: true},
+		{dsn: "https://public@o1.ingest.sentry.io/", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dsn, func(t *testing.T) {
+			// Act
+			s, err := NewSentry(tt.dsn)
+
+			// Assert
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil || s.endpoint != tt.endpoint || s.key != "public" {
+				t.Errorf("unexpected reporter %+v, %v", s, err)
+			}
+		})
+	}
+}
+
+func TestSentry_Report(t *testing.T) {
+	// Arrange
+	var auth string
+	var event map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("X-Sentry-Auth")
+		json.NewDecoder(r.Body).Decode(&event)
+		w.Write([]byte(`{"id":"x"}`))
+	}))
+	defer server.Close()
+	s, _ := NewSentry(strings.Replace(server.URL, "http://", "http://public@", 1) + "/42")
+
+	// Act
+	err := s.Report(context.Background(), Report{
+		Kind:            KindError,
+		Message:         "failed to load workout",
+		Stack:           []Frame{{Function: "inner", Line: 2}, {Function: "outer", Line: 1}},
+		Time:            time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC),
+		Release:         "abc123",
+		Method:          "GET",
+		Route:           "/api/workouts/{id}",
+		Path:            "/api/workouts/w1",
+		UserID:          "user-1",
+		ClientRequestID: "sync-42",
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(auth, "sentry_key=public") {
+		t.Errorf("unexpected auth header %q", auth)
+	}
+	if event["release"] != "abc123" || event["transaction"] != "GET /api/workouts/{id}" || event["user"].(map[string]interface{})["id"] != "user-1" {
+		t.Errorf("unexpected event: %v", event)
+	}
+	if event["tags"].(map[string]interface{})["client_request_id"] != "sync-42" {
+		t.Errorf("unexpected tags: %v", event["tags"])
+	}
+	frames := event["exception"].(map[string]interface{})["values"].([]interface{})[0].(map[string]interface{})["stacktrace"].(map[string]interface{})["frames"].([]interface{})
+	if len(frames) != 2 || frames[0].(map[string]interface{})["function"] != "outer" {
+		t.Errorf("expected frames outermost first, got %v", frames)
+	}
+}
@@ -0,0 +1,157 @@
+package errreport
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Sentry sends failures to a Sentry project through its store endpoint
+type Sentry struct {
+	endpoint   string
+	key        string
+	HTTPClient *http.Client
+}
+
+// NewSentry creates a reporter for the project identified by dsn, such as
+// https://<key>@o0.ingest.sentry.io/<project>
+func NewSentry(dsn string) (*Sentry, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.User == nil || u.User.Username() == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN")
+	}
+	path := strings.Trim(u.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	project := path[slash+1:]
+	if project == "" {
+		return nil, fmt.Errorf("Sentry DSN has no project")
+	}
+	prefix := ""
+	if slash >= 0 {
+		prefix = "/" + path[:slash]
+	}
+	return &Sentry{
+		endpoint:   fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, prefix, project),
+		key:        u.User.Username(),
+		HTTPClient: &http.Client{Timeout: 3 * time.Second},
+	}, nil
+}
+
+// sentryFrame is a stack frame in Sentry's format
+type sentryFrame struct {
+	Function string `json:"function"`
+	Filename string `json:"filename"`
+	Lineno   int    `json:"lineno"`
+}
+
+// sentryEvent is the subset of Sentry's event payload the reports fill
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Release     string            `json:"release,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Transaction string            `json:"transaction,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	User        *struct {
+		ID string `json:"id"`
+	} `json:"user,omitempty"`
+	Request *struct {
+		Method string `json:"method"`
+		URL    string `json:"url"`
+	} `json:"request,omitempty"`
+	Exception struct {
+		Values []sentryException `json:"values"`
+	} `json:"exception"`
+}
+
+type sentryException struct {
+	Type       string `json:"type"`
+	Value      string `json:"value"`
+	Stacktrace struct {
+		Frames []sentryFrame `json:"frames"`
+	} `json:"stacktrace"`
+}
+
+// event converts r to a Sentry event
+func (s *Sentry) event(r Report) (sentryEvent, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return sentryEvent{}, fmt.Errorf("failed to generate event ID: %w", err)
+	}
+	e := sentryEvent{
+		EventID:     hex.EncodeToString(id),
+		Timestamp:   r.Time.UTC().Format(time.RFC3339),
+		Level:       "error",
+		Platform:    "go",
+		Release:     r.Release,
+		Environment: r.Environment,
+		Tags:        map[string]string{"kind": r.Kind},
+	}
+	switch {
+	case r.Route != "":
+		e.Transaction = r.Method + " " + r.Route
+	case r.Job != "":
+		e.Transaction = "job " + r.Job
+		e.Tags["job"] = r.Job
+	}
+	if r.ClientRequestID != "" {
+		e.Tags["client_request_id"] = r.ClientRequestID
+	}
+	if r.UserID != "" {
+		e.User = &struct {
+			ID string `json:"id"`
+		}{ID: r.UserID}
+	}
+	if r.Path != "" {
+		e.Request = &struct {
+			Method string `json:"method"`
+			URL    string `json:"url"`
+		}{Method: r.Method, URL: r.Path}
+	}
+
+	// Sentry lists frames outermost first
+	exception := sentryException{Type: r.Kind, Value: r.Message}
+	for i := len(r.Stack) - 1; i >= 0; i-- {
+		f := r.Stack[i]
+		exception.Stacktrace.Frames = append(exception.Stacktrace.Frames, sentryFrame{Function: f.Function, Filename: f.File, Lineno: f.Line})
+	}
+	e.Exception.Values = []sentryException{exception}
+	return e, nil
+}
+
+// Report sends r to Sentry
+func (s *Sentry) Report(ctx context.Context, r Report) error {
+	e, err := s.event(r)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Sentry event: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Sentry request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", "Sentry sentry_version=7, sentry_client=athlete-forge/1.0, sentry_key="+s.key)
+
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send Sentry event: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Sentry returned status %d", resp.StatusCode)
+	}
+	return nil
+}
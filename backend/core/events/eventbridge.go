@@ -0,0 +1,72 @@
+package events
+
+import (
+	"context"
+	"fmt"
+
+	"athlete-forge/awsapi"
+)
+
+// maxEntries is the most events PutEvents accepts in one call
+const maxEntries = 10
+
+// eventBridge is the EventBridge JSON protocol descriptor
+var eventBridge = awsapi.Service{Name: "events", TargetPrefix: "AWSEvents", JSONVersion: "1.1"}
+
+// Publisher publishes domain events for other services to subscribe to
+type Publisher interface {
+	// Publish sends events, failing if any of them was not accepted
+	Publish(ctx context.Context, events ...Event) error
+}
+
+// EventBridge publishes events to an EventBridge bus
+type EventBridge struct {
+	client  *awsapi.Client
+	busName string
+}
+
+// NewEventBridge creates a publisher for busName
+func NewEventBridge(client *awsapi.Client, busName string) *EventBridge {
+	return &EventBridge{client: client, busName: busName}
+}
+
+// putEventsEntry is one event in a PutEvents request
+type putEventsEntry struct {
+	EventBusName string `json:"EventBusName"`
+	Source       string `json:"Source"`
+	DetailType   string `json:"DetailType"`
+	Detail       string `json:"Detail"`
+	Time         int64  `json:"Time"`
+}
+
+// Publish calls PutEvents in batches of maxEntries
+func (p *EventBridge) Publish(ctx context.Context, events ...Event) error {
+	for start := 0; start < len(events); start += maxEntries {
+		end := min(start+maxEntries, len(events))
+		entries := make([]putEventsEntry, 0, end-start)
+		for _, e := range events[start:end] {
+			detail, err := e.Detail()
+			if err != nil {
+				return err
+			}
+			entries = append(entries, putEventsEntry{
+				EventBusName: p.busName,
+				Source:       Source,
+				DetailType:   e.Type,
+				Detail:       string(detail),
+				Time:         e.OccurredAt.Unix(),
+			})
+		}
+
+		var out struct {
+			FailedEntryCount int `json:"FailedEntryCount"`
+		}
+		if err := p.client.Call(ctx, eventBridge, "PutEvents", map[string]interface{}{"Entries": entries}, &out); err != nil {
+			return fmt.Errorf("failed to publish events: %w", err)
+		}
+		if out.FailedEntryCount > 0 {
+			return fmt.Errorf("failed to publish %d of %d events", out.FailedEntryCount, len(entries))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"athlete-forge/awsapi"
+	"athlete-forge/program"
+)
+
+func newTestEventBridge(t *testing.T, handler http.HandlerFunc) *EventBridge {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := awsapi.NewClient("eu-west-2", awsapi.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	client.Endpoint = func(string) string { return server.URL }
+	return NewEventBridge(client, "athlete-forge-events")
+}
+
+func TestEventBridge_Publish(t *testing.T) {
+	event := ProgramAssigned(program.Program{ID: "p1", UserID: "user-1", Name: "5x5", CreatedAt: time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)})
+
+	t.Run("puts events on the bus in batches of ten", func(t *testing.T) {
+		// Arrange
+		var target string
+		var batches [][]putEventsEntry
+		p := newTestEventBridge(t, func(w http.ResponseWriter, r *http.Request) {
+			target = r.Header.Get("X-Amz-Target")
+			var in struct {
+				Entries []putEventsEntry `json:"Entries"`
+			}
+			data, _ := io.ReadAll(r.Body)
+			json.Unmarshal(data, &in)
+			batches = append(batches, in.Entries)
+			w.Write([]byte(`{"FailedEntryCount":0}`))
+		})
+		published := make([]Event, 12)
+		for i := range published {
+			published[i] = event
+		}
+
+		// Act
+		err := p.Publish(context.Background(), published...)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if target != "AWSEvents.PutEvents" || len(batches) != 2 || len(batches[0]) != 10 || len(batches[1]) != 2 {
+			t.Fatalf("unexpected requests: %s %d", target, len(batches))
+		}
+		entry := batches[0][0]
+		if entry.EventBusName != "athlete-forge-events" || entry.Source != Source || entry.DetailType != TypeProgramAssigned || entry.Time != event.OccurredAt.Unix() {
+			t.Errorf("unexpected entry: %+v", entry)
+		}
+	})
+
+	t.Run("fails when an entry is rejected", func(t *testing.T) {
+		// Arrange
+		p := newTestEventBridge(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"FailedEntryCount":1}`))
+		})
+
+		// Act
+		err := p.Publish(context.Background(), event)
+
+		// Assert
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
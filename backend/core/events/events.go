@@ -0,0 +1,207 @@
+package events
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"athlete-forge/messaging"
+	"athlete-forge/program"
+	"athlete-forge/records"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+// Source is the EventBridge source of every event the service publishes
+const Source = "athlete-forge"
+
+// Event types, published as the EventBridge detail type
+const (
+	TypeWorkoutCompleted    = "WorkoutCompleted"
+	TypePRAchieved          = "PRAchieved"
+	TypeProgramAssigned     = "ProgramAssigned"
+	TypeAchievementUnlocked = "AchievementUnlocked"
+	TypeMessageSent         = "MessageSent"
+	TypeWorkoutAutoFinished = "WorkoutAutoFinished"
+)
+
+// schemas holds the JSON schema of each event type's detail, one file per
+// version named <type>.v<version>.json
+//
+//go:embed schemas/*.json
+var schemas embed.FS
+
+// Event is a domain event. The detail published is the event without its type;
+// Version selects the schema describing it, and changes that would break
+// subscribers get a new version rather than editing the old one.
+// ClientRequestID is the app's ID for the request that caused the event, if any
+type Event struct {
+	ID              string      `json:"id"`
+	Type            string      `json:"-"`
+	Version         int         `json:"version"`
+	OccurredAt      time.Time   `json:"occurredAt"`
+	UserID          string      `json:"userId"`
+	ClientRequestID string      `json:"clientRequestId,omitempty"`
+	Data            interface{} `json:"data"`
+}
+
+// Detail marshals the event as its EventBridge detail
+func (e Event) Detail() ([]byte, error) {
+	detail, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s event: %w", e.Type, err)
+	}
+	return detail, nil
+}
+
+// Schema returns the JSON schema for version of eventType
+func Schema(eventType string, version int) ([]byte, error) {
+	return schemas.ReadFile(fmt.Sprintf("schemas/%s.v%d.json", eventType, version))
+}
+
+// WorkoutCompletedData is the WorkoutCompleted v1 payload
+type WorkoutCompletedData struct {
+	WorkoutID   string    `json:"workoutId"`
+	ProgramID   string    `json:"programId,omitempty"`
+	GymID       string    `json:"gymId,omitempty"`
+	CompletedAt time.Time `json:"completedAt"`
+	Exercises   []string  `json:"exercises"`
+	Sets        int       `json:"sets"`
+	Volume      float64   `json:"volume"`
+}
+
+// PRAchievedData is the PRAchieved v1 payload; Previous1RM is the best estimate
+// before the workout
+type PRAchievedData struct {
+	WorkoutID    string  `json:"workoutId"`
+	Exercise     string  `json:"exercise"`
+	Weight       float64 `json:"weight"`
+	Reps         int     `json:"reps"`
+	Estimated1RM float64 `json:"estimated1rm"`
+	Previous1RM  float64 `json:"previous1rm"`
+}
+
+// ProgramAssignedData is the ProgramAssigned v1 payload
+type ProgramAssignedData struct {
+	ProgramID string   `json:"programId"`
+	Name      string   `json:"name"`
+	GymID     string   `json:"gymId,omitempty"`
+	Exercises []string `json:"exercises"`
+}
+
+// AchievementUnlockedData is the AchievementUnlocked v1 payload; EventID is the
+// event that earned the achievement
+type AchievementUnlockedData struct {
+	AchievementID string `json:"achievementId"`
+	Name          string `json:"name"`
+	EventID       string `json:"eventId"`
+}
+
+// MessageSentData is the MessageSent v1 payload. It leaves out the text, so
+// subscribers such as push notifications never hold message contents
+type MessageSentData struct {
+	LinkID      string `json:"linkId"`
+	MessageID   string `json:"messageId"`
+	SenderID    string `json:"senderId"`
+	RecipientID string `json:"recipientId"`
+}
+
+// WorkoutAutoFinishedData is the WorkoutAutoFinished v1 payload. Status is
+// completed when the session had performed sets, and abandoned otherwise
+type WorkoutAutoFinishedData struct {
+	WorkoutID   string     `json:"workoutId"`
+	Status      string     `json:"status"`
+	StartedAt   time.Time  `json:"startedAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// WorkoutCompleted returns the event for a completed workout, occurring when it
+// was completed
+func WorkoutCompleted(w workout.Workout) Event {
+	data := WorkoutCompletedData{WorkoutID: w.ID, ProgramID: w.ProgramID, GymID: w.GymID, CompletedAt: w.StartedAt, Exercises: []string{}}
+	if w.CompletedAt != nil {
+		data.CompletedAt = *w.CompletedAt
+	}
+	for _, exercise := range w.Exercises {
+		data.Exercises = append(data.Exercises, exercise.Name)
+	}
+	for _, block := range w.Blocks() {
+		data.Sets += block.Sets
+		data.Volume += block.Volume
+	}
+	return newEvent(TypeWorkoutCompleted, w.UserID, data.CompletedAt, data)
+}
+
+// PRAchieved returns the event for record, which beat previous1RM
+func PRAchieved(userID string, record records.Record, previous1RM float64) Event {
+	return newEvent(TypePRAchieved, userID, record.Date, PRAchievedData{
+		WorkoutID:    record.WorkoutID,
+		Exercise:     record.Exercise,
+		Weight:       record.Weight,
+		Reps:         record.Reps,
+		Estimated1RM: record.Estimated1RM,
+		Previous1RM:  previous1RM,
+	})
+}
+
+// PRsAchieved returns a PRAchieved event for each exercise whose best estimated
+// one-rep max the completed workout w beat, given the user's workouts including w
+func PRsAchieved(workouts []workout.Workout, w workout.Workout) []Event {
+	if w.CompletedAt == nil {
+		return nil
+	}
+	at := *w.CompletedAt
+	before := records.Best(workouts, at)
+
+	var prs []Event
+	for _, record := range records.New(workouts, at, at.Add(time.Nanosecond)) {
+		if record.WorkoutID != w.ID {
+			continue
+		}
+		prs = append(prs, PRAchieved(w.UserID, record, before[key(record.Exercise)].Estimated1RM))
+	}
+	return prs
+}
+
+// ProgramAssigned returns the event for a program newly assigned to its user
+func ProgramAssigned(p program.Program) Event {
+	data := ProgramAssignedData{ProgramID: p.ID, Name: p.Name, GymID: p.GymID, Exercises: []string{}}
+	for _, prescription := range p.Exercises {
+		data.Exercises = append(data.Exercises, prescription.Exercise)
+	}
+	return newEvent(TypeProgramAssigned, p.UserID, p.CreatedAt, data)
+}
+
+// AchievementUnlocked returns the event for userID earning an achievement at
+// at, because of the event eventID
+func AchievementUnlocked(userID, achievementID, name, eventID string, at time.Time) Event {
+	return newEvent(TypeAchievementUnlocked, userID, at, AchievementUnlockedData{AchievementID: achievementID, Name: name, EventID: eventID})
+}
+
+// WorkoutAutoFinished returns the event for w, a session left open that was
+// finished for the user at w.AutoFinishedAt, so they can be notified
+func WorkoutAutoFinished(w workout.Workout) Event {
+	at := w.StartedAt
+	if w.AutoFinishedAt != nil {
+		at = *w.AutoFinishedAt
+	}
+	return newEvent(TypeWorkoutAutoFinished, w.UserID, at, WorkoutAutoFinishedData{WorkoutID: w.ID, Status: w.Status, StartedAt: w.StartedAt, CompletedAt: w.CompletedAt})
+}
+
+// MessageSent returns the event for m sent over link l, belonging to its
+// recipient so their notifications can be sent
+func MessageSent(l messaging.Link, m messaging.Message) Event {
+	recipientID := l.Peer(m.SenderID)
+	return newEvent(TypeMessageSent, recipientID, m.SentAt, MessageSentData{LinkID: l.ID, MessageID: m.ID, SenderID: m.SenderID, RecipientID: recipientID})
+}
+
+func newEvent(eventType, userID string, at time.Time, data interface{}) Event {
+	return Event{ID: store.NewID(), Type: eventType, Version: 1, OccurredAt: at.UTC(), UserID: userID, Data: data}
+}
+
+// key identifies an exercise the way records does
+func key(exercise string) string {
+	return strings.ToLower(exercise)
+}
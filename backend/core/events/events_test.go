@@ -0,0 +1,141 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"athlete-forge/messaging"
+	"athlete-forge/program"
+	"athlete-forge/records"
+	"athlete-forge/workout"
+)
+
+func TestEvent_Detail(t *testing.T) {
+	completed := time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		event Event
+	}{
+		{
+			name: "workout completed",
+			event: WorkoutCompleted(workout.Workout{ID: "w1", UserID: "user-1", Status: workout.StatusCompleted, CompletedAt: &completed,
+				Exercises: []workout.Exercise{{Name: "Squat", Sets: []workout.Set{{Reps: 5, Weight: 100}, {Reps: 5, Weight: 100}}}}}),
+		},
+		{
+			name:  "PR achieved",
+			event: PRAchieved("user-1", records.Record{Exercise: "Squat", Weight: 110, Reps: 5, Estimated1RM: 128.3, WorkoutID: "w1", Date: completed}, 116.7),
+		},
+		{
+			name:  "program assigned",
+			event: ProgramAssigned(program.Program{ID: "p1", UserID: "user-1", Name: "5x5", Exercises: []program.Prescription{{Exercise: "Squat"}}, CreatedAt: completed}),
+		},
+		{
+			name:  "achievement unlocked",
+			event: AchievementUnlocked("user-1", "first-workout", "First Workout", "e1", completed),
+		},
+		{
+			name: "workout auto-finished",
+			event: WorkoutAutoFinished(workout.Workout{ID: "w1", UserID: "user-1", Status: workout.StatusAbandoned, StartedAt: completed,
+				AutoFinishedAt: &completed}),
+		},
+		{
+			name: "message sent",
+			event: MessageSent(messaging.Link{ID: "l1", CoachID: "coach-1", AthleteID: "user-1"},
+				messaging.Message{ID: "m1", LinkID: "l1", SenderID: "coach-1", Text: "Nice squats", SentAt: completed}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" matches its schema", func(t *testing.T) {
+			// Arrange
+			raw, err := Schema(tt.event.Type, tt.event.Version)
+			if err != nil {
+				t.Fatalf("missing schema: %v", err)
+			}
+			var schema struct {
+				Required   []string `json:"required"`
+				Properties struct {
+					Data struct {
+						Required []string `json:"required"`
+					} `json:"data"`
+				} `json:"properties"`
+			}
+			if err := json.Unmarshal(raw, &schema); err != nil {
+				t.Fatalf("invalid schema: %v", err)
+			}
+
+			// Act
+			detail, err := tt.event.Detail()
+
+			// Assert
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got map[string]interface{}
+			json.Unmarshal(detail, &got)
+			for _, field := range schema.Required {
+				if _, ok := got[field]; !ok {
+					t.Errorf("detail is missing %q: %s", field, detail)
+				}
+			}
+			data, _ := got["data"].(map[string]interface{})
+			for _, field := range schema.Properties.Data.Required {
+				if _, ok := data[field]; !ok {
+					t.Errorf("data is missing %q: %s", field, detail)
+				}
+			}
+		})
+	}
+
+	t.Run("workout completed totals sets and volume", func(t *testing.T) {
+		// Act
+		data := tests[0].event.Data.(WorkoutCompletedData)
+
+		// Assert
+		if data.Sets != 2 || data.Volume != 1000 || !data.CompletedAt.Equal(completed) || tests[0].event.Type != TypeWorkoutCompleted {
+			t.Errorf("unexpected data: %+v", data)
+		}
+	})
+}
+
+func TestPRsAchieved(t *testing.T) {
+	at := func(day int) *time.Time {
+		t := time.Date(2024, 3, day, 18, 0, 0, 0, time.UTC)
+		return &t
+	}
+	session := func(id string, day int, weight float64) workout.Workout {
+		return workout.Workout{ID: id, UserID: "user-1", Status: workout.StatusCompleted, CompletedAt: at(day),
+			Exercises: []workout.Exercise{{Name: "Squat", Sets: []workout.Set{{Reps: 5, Weight: weight}}}, {Name: "Bench", Sets: []workout.Set{{Reps: 5, Weight: 60}}}}}
+	}
+
+	t.Run("reports only exercises the workout beat", func(t *testing.T) {
+		// Arrange
+		workouts := []workout.Workout{session("w1", 1, 100), session("w2", 4, 110), session("w3", 8, 105)}
+
+		// Act
+		prs := PRsAchieved(workouts, workouts[1])
+
+		// Assert
+		if len(prs) != 1 {
+			t.Fatalf("expected one PR, got %+v", prs)
+		}
+		data := prs[0].Data.(PRAchievedData)
+		if prs[0].Type != TypePRAchieved || data.Exercise != "Squat" || data.Weight != 110 || data.Previous1RM != records.EstimatedOneRepMax(100, 5) {
+			t.Errorf("unexpected PR: %+v", data)
+		}
+	})
+
+	t.Run("a first session is not a PR", func(t *testing.T) {
+		// Arrange
+		workouts := []workout.Workout{session("w1", 1, 100)}
+
+		// Act
+		prs := PRsAchieved(workouts, workouts[0])
+
+		// Assert
+		if len(prs) != 0 {
+			t.Errorf("expected no PRs, got %+v", prs)
+		}
+	})
+}
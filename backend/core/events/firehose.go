@@ -0,0 +1,35 @@
+package events
+
+import (
+	"context"
+
+	"athlete-forge/firehose"
+)
+
+// record is an event as written to a Firehose stream: its detail with the
+// source and type that EventBridge carries alongside it
+type record struct {
+	Source string `json:"source"`
+	Type   string `json:"type"`
+	Event
+}
+
+// Firehose publishes events to a Kinesis Data Firehose stream, which buffers
+// them into S3 for analytics pipelines
+type Firehose struct {
+	stream *firehose.Stream
+}
+
+// NewFirehose creates a publisher writing to stream
+func NewFirehose(stream *firehose.Stream) *Firehose {
+	return &Firehose{stream: stream}
+}
+
+// Publish puts events on the stream, one JSON line each
+func (f *Firehose) Publish(ctx context.Context, events ...Event) error {
+	values := make([]interface{}, len(events))
+	for i, e := range events {
+		values[i] = record{Source: Source, Type: e.Type, Event: e}
+	}
+	return f.stream.Put(ctx, values...)
+}
@@ -0,0 +1,56 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"athlete-forge/awsapi"
+	"athlete-forge/firehose"
+	"athlete-forge/program"
+)
+
+func TestFirehose_Publish(t *testing.T) {
+	// Arrange
+	var lines []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in struct {
+			Records []struct {
+				Data []byte `json:"Data"`
+			} `json:"Records"`
+		}
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &in)
+		for _, record := range in.Records {
+			var line map[string]interface{}
+			json.Unmarshal(record.Data, &line)
+			lines = append(lines, line)
+		}
+		w.Write([]byte(`{"FailedPutCount":0}`))
+	}))
+	t.Cleanup(server.Close)
+	client := awsapi.NewClient("eu-west-2", awsapi.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	client.Endpoint = func(string) string { return server.URL }
+	p := NewFirehose(firehose.NewStream(client, "athlete-forge-events"))
+	event := ProgramAssigned(program.Program{ID: "p1", UserID: "user-1", Name: "5x5", CreatedAt: time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)})
+
+	// Act
+	err := p.Publish(context.Background(), event)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(lines) != 1 {
+		t.Fatalf("expected one record, got %d", len(lines))
+	}
+	line := lines[0]
+	data, _ := line["data"].(map[string]interface{})
+	if line["source"] != Source || line["type"] != TypeProgramAssigned || line["id"] != event.ID || line["userId"] != "user-1" || data["programId"] != "p1" {
+		t.Errorf("unexpected record: %v", line)
+	}
+}
@@ -0,0 +1,168 @@
+package exercise
+
+import (
+	"sort"
+	"strings"
+)
+
+// Body parts an exercise can load
+const (
+	BodyPartNeck      = "neck"
+	BodyPartShoulder  = "shoulder"
+	BodyPartElbow     = "elbow"
+	BodyPartWrist     = "wrist"
+	BodyPartChest     = "chest"
+	BodyPartBack      = "back"
+	BodyPartHip       = "hip"
+	BodyPartHamstring = "hamstring"
+	BodyPartKnee      = "knee"
+	BodyPartAnkle     = "ankle"
+)
+
+// Movement patterns
+const (
+	PatternSquat           = "squat"
+	PatternHinge           = "hinge"
+	PatternLunge           = "lunge"
+	PatternHorizontalPress = "horizontal-press"
+	PatternVerticalPress   = "vertical-press"
+	PatternHorizontalPull  = "horizontal-pull"
+	PatternVerticalPull    = "vertical-pull"
+	PatternIsolation       = "isolation"
+)
+
+// Patterns lists the movement patterns
+var Patterns = []string{
+	PatternSquat, PatternHinge, PatternLunge, PatternHorizontalPress,
+	PatternVerticalPress, PatternHorizontalPull, PatternVerticalPull, PatternIsolation,
+}
+
+// Equipment that exercises can require
+const (
+	EquipmentBarbell      = "barbell"
+	EquipmentRack         = "rack"
+	EquipmentBench        = "bench"
+	EquipmentDumbbells    = "dumbbells"
+	EquipmentKettlebell   = "kettlebell"
+	EquipmentTrapBar      = "trap-bar"
+	EquipmentLandmine     = "landmine"
+	EquipmentPullUpBar    = "pull-up-bar"
+	EquipmentCable        = "cable"
+	EquipmentLegPress     = "leg-press"
+	EquipmentLegCurl      = "leg-curl"
+	EquipmentLegExtension = "leg-extension"
+	EquipmentBeltSquat    = "belt-squat"
+	EquipmentBands        = "bands"
+	EquipmentSled         = "sled"
+)
+
+// Equipment lists the equipment an inventory can hold
+var Equipment = []string{
+	EquipmentBarbell, EquipmentRack, EquipmentBench, EquipmentDumbbells, EquipmentKettlebell,
+	EquipmentTrapBar, EquipmentLandmine, EquipmentPullUpBar, EquipmentCable, EquipmentLegPress,
+	EquipmentLegCurl, EquipmentLegExtension, EquipmentBeltSquat, EquipmentBands, EquipmentSled,
+}
+
+// Exercise describes a known exercise: its movement pattern, the body parts it
+// loads, the equipment it needs and what can replace it, best substitute first
+type Exercise struct {
+	Name        string   `json:"name"`
+	Pattern     string   `json:"pattern"`
+	BodyParts   []string `json:"bodyParts"`
+	Equipment   []string `json:"equipment"`
+	Substitutes []string `json:"substitutes,omitempty"`
+}
+
+// bodyweightExercises are the catalog exercises that move the lifter's own bodyweight
+var bodyweightExercises = map[string]bool{"push-up": true, "pull-up": true, "chin-up": true}
+
+// catalog is keyed by lower-case exercise name
+var catalog = map[string]Exercise{}
+
+func init() {
+	for _, e := range []Exercise{
+		{"squat", PatternSquat, []string{BodyPartKnee, BodyPartHip, BodyPartBack}, []string{EquipmentBarbell, EquipmentRack}, []string{"belt squat", "leg press", "goblet squat", "hip thrust"}},
+		{"back squat", PatternSquat, []string{BodyPartKnee, BodyPartHip, BodyPartBack}, []string{EquipmentBarbell, EquipmentRack}, []string{"belt squat", "leg press", "goblet squat", "hip thrust"}},
+		{"front squat", PatternSquat, []string{BodyPartKnee, BodyPartHip, BodyPartBack, BodyPartWrist}, []string{EquipmentBarbell, EquipmentRack}, []string{"belt squat", "leg press", "goblet squat", "hip thrust"}},
+		{"goblet squat", PatternSquat, []string{BodyPartKnee, BodyPartHip}, []string{EquipmentDumbbells}, []string{"lunge"}},
+		{"belt squat", PatternSquat, []string{BodyPartKnee, BodyPartHip}, []string{EquipmentBeltSquat}, []string{"hip thrust"}},
+		{"leg press", PatternSquat, []string{BodyPartKnee, BodyPartHip}, []string{EquipmentLegPress}, []string{"hip thrust"}},
+		{"lunge", PatternLunge, []string{BodyPartKnee, BodyPartHip, BodyPartAnkle}, nil, []string{"belt squat", "hip thrust"}},
+		{"deadlift", PatternHinge, []string{BodyPartBack, BodyPartHip, BodyPartHamstring, BodyPartKnee}, []string{EquipmentBarbell}, []string{"trap bar deadlift", "hip thrust", "kettlebell swing", "leg curl"}},
+		{"trap bar deadlift", PatternHinge, []string{BodyPartBack, BodyPartHip, BodyPartKnee}, []string{EquipmentTrapBar}, []string{"hip thrust"}},
+		{"romanian deadlift", PatternHinge, []string{BodyPartBack, BodyPartHip, BodyPartHamstring}, []string{EquipmentBarbell}, []string{"hip thrust", "leg curl"}},
+		{"kettlebell swing", PatternHinge, []string{BodyPartBack, BodyPartHip, BodyPartHamstring}, []string{EquipmentKettlebell}, []string{"hip thrust"}},
+		{"hip thrust", PatternHinge, []string{BodyPartHip}, []string{EquipmentBarbell, EquipmentBench}, nil},
+		{"leg curl", PatternIsolation, []string{BodyPartHamstring, BodyPartKnee}, []string{EquipmentLegCurl}, nil},
+		{"leg extension", PatternIsolation, []string{BodyPartKnee}, []string{EquipmentLegExtension}, nil},
+		{"bench press", PatternHorizontalPress, []string{BodyPartChest, BodyPartShoulder, BodyPartElbow, BodyPartWrist}, []string{EquipmentBarbell, EquipmentBench}, []string{"dumbbell bench press", "floor press", "push-up"}},
+		{"dumbbell bench press", PatternHorizontalPress, []string{BodyPartChest, BodyPartShoulder, BodyPartElbow}, []string{EquipmentDumbbells, EquipmentBench}, []string{"push-up"}},
+		{"floor press", PatternHorizontalPress, []string{BodyPartChest, BodyPartElbow, BodyPartWrist}, []string{EquipmentBarbell}, nil},
+		{"push-up", PatternHorizontalPress, []string{BodyPartChest, BodyPartShoulder, BodyPartWrist}, nil, nil},
+		{"overhead press", PatternVerticalPress, []string{BodyPartShoulder, BodyPartElbow, BodyPartWrist, BodyPartBack, BodyPartNeck}, []string{EquipmentBarbell, EquipmentRack}, []string{"landmine press", "dumbbell bench press"}},
+		{"landmine press", PatternVerticalPress, []string{BodyPartShoulder, BodyPartElbow}, []string{EquipmentBarbell, EquipmentLandmine}, []string{"dumbbell bench press"}},
+		{"barbell row", PatternHorizontalPull, []string{BodyPartBack, BodyPartElbow, BodyPartShoulder}, []string{EquipmentBarbell}, []string{"chest-supported row", "lat pulldown"}},
+		{"row", PatternHorizontalPull, []string{BodyPartBack, BodyPartElbow, BodyPartShoulder}, []string{EquipmentBarbell}, []string{"chest-supported row", "lat pulldown"}},
+		{"chest-supported row", PatternHorizontalPull, []string{BodyPartElbow, BodyPartShoulder}, []string{EquipmentDumbbells, EquipmentBench}, []string{"lat pulldown"}},
+		{"pull-up", PatternVerticalPull, []string{BodyPartShoulder, BodyPartElbow}, []string{EquipmentPullUpBar}, []string{"lat pulldown"}},
+		{"chin-up", PatternVerticalPull, []string{BodyPartShoulder, BodyPartElbow}, []string{EquipmentPullUpBar}, []string{"lat pulldown"}},
+		{"lat pulldown", PatternVerticalPull, []string{BodyPartShoulder, BodyPartElbow}, []string{EquipmentCable}, []string{"chest-supported row"}},
+		{"calf raise", PatternIsolation, []string{BodyPartAnkle}, nil, nil},
+		{"biceps curl", PatternIsolation, []string{BodyPartElbow}, []string{EquipmentDumbbells}, nil},
+		{"triceps extension", PatternIsolation, []string{BodyPartElbow}, []string{EquipmentDumbbells}, nil},
+		{"lateral raise", PatternIsolation, []string{BodyPartShoulder}, []string{EquipmentDumbbells}, nil},
+	} {
+		catalog[e.Name] = e
+	}
+}
+
+// Lookup finds a known exercise by name, ignoring case and surrounding space
+func Lookup(name string) (Exercise, bool) {
+	e, ok := catalog[strings.ToLower(strings.TrimSpace(name))]
+	return e, ok
+}
+
+// Search returns the known exercises whose name contains query, ignoring case,
+// in name order; an empty query returns every exercise
+func Search(query string) []Exercise {
+	query = strings.ToLower(strings.TrimSpace(query))
+	results := []Exercise{}
+	for name, e := range catalog {
+		if strings.Contains(name, query) {
+			results = append(results, e)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results
+}
+
+// Bodyweight reports whether e moves the lifter's own bodyweight, so that its
+// load is bodyweight plus any added weight, less any assistance
+func (e Exercise) Bodyweight() bool {
+	return bodyweightExercises[e.Name]
+}
+
+// Missing returns the equipment e needs that is not in available
+func (e Exercise) Missing(available []string) []string {
+	missing := []string{}
+	for _, needed := range e.Equipment {
+		if !contains(available, needed) {
+			missing = append(missing, needed)
+		}
+	}
+	return missing
+}
+
+// IsEquipment reports whether name is a known piece of equipment
+func IsEquipment(name string) bool {
+	return contains(Equipment, name)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
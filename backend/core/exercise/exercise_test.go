@@ -0,0 +1,66 @@
+package exercise
+
+import "testing"
+
+func TestLookup(t *testing.T) {
+	t.Run("ignores case and surrounding space", func(t *testing.T) {
+		// Act
+		e, ok := Lookup("  Bench Press ")
+
+		// Assert
+		if !ok || e.Pattern != PatternHorizontalPress {
+			t.Errorf("unexpected exercise: %+v", e)
+		}
+	})
+}
+
+func TestSearch(t *testing.T) {
+	// Act
+	results := Search("squat")
+
+	// Assert
+	if len(results) < 4 || results[0].Name != "back squat" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	for _, e := range results {
+		if e.Pattern != PatternSquat {
+			t.Errorf("unexpected result %q", e.Name)
+		}
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	for name, e := range catalog {
+		for _, item := range e.Equipment {
+			if !IsEquipment(item) {
+				t.Errorf("%s needs unknown equipment %q", name, item)
+			}
+		}
+		for _, substitute := range e.Substitutes {
+			if _, ok := Lookup(substitute); !ok {
+				t.Errorf("%s has unknown substitute %q", name, substitute)
+			}
+		}
+	}
+}
+
+func TestExercise_Missing(t *testing.T) {
+	// Arrange
+	e, _ := Lookup("bench press")
+
+	// Act
+	missing := e.Missing([]string{EquipmentBarbell})
+
+	// Assert
+	if len(missing) != 1 || missing[0] != EquipmentBench {
+		t.Errorf("expected bench missing, got %v", missing)
+	}
+}
+
+func TestExercise_PrimaryMuscles(t *testing.T) {
+	for name, e := range catalog {
+		if len(e.PrimaryMuscles()) == 0 {
+			t.Errorf("%s has no primary muscles", name)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package exercise
+
+import "sort"
+
+// Movement categories: the upper-body pushes and pulls and the knee-dominant
+// squats and hip-dominant hinges of the lower body
+const (
+	CategoryPush  = "push"
+	CategoryPull  = "pull"
+	CategorySquat = "squat"
+	CategoryHinge = "hinge"
+)
+
+// Categories lists the movement categories
+var Categories = []string{CategoryPush, CategoryPull, CategorySquat, CategoryHinge}
+
+// patternCategories are the categories of the compound movement patterns;
+// isolation exercises take the category of their main muscle group
+var patternCategories = map[string]string{
+	PatternSquat:           CategorySquat,
+	PatternLunge:           CategorySquat,
+	PatternHinge:           CategoryHinge,
+	PatternHorizontalPress: CategoryPush,
+	PatternVerticalPress:   CategoryPush,
+	PatternHorizontalPull:  CategoryPull,
+	PatternVerticalPull:    CategoryPull,
+}
+
+// Body regions muscle groups belong to
+const (
+	RegionUpper = "upper"
+	RegionLower = "lower"
+)
+
+// Muscle groups exercises train
+const (
+	MuscleQuads      = "quads"
+	MuscleGlutes     = "glutes"
+	MuscleHamstrings = "hamstrings"
+	MuscleCalves     = "calves"
+	MuscleChest      = "chest"
+	MuscleShoulders  = "shoulders"
+	MuscleTriceps    = "triceps"
+	MuscleBack       = "back"
+	MuscleBiceps     = "biceps"
+)
+
+// Muscle is a muscle group with the body region it is in and the movement
+// category that mainly trains it
+type Muscle struct {
+	Name     string `json:"name"`
+	Region   string `json:"region"`
+	Category string `json:"category"`
+}
+
+// Muscles lists the muscle groups, lower body first
+var Muscles = []Muscle{
+	{MuscleQuads, RegionLower, CategorySquat},
+	{MuscleGlutes, RegionLower, CategoryHinge},
+	{MuscleHamstrings, RegionLower, CategoryHinge},
+	{MuscleCalves, RegionLower, CategorySquat},
+	{MuscleChest, RegionUpper, CategoryPush},
+	{MuscleShoulders, RegionUpper, CategoryPush},
+	{MuscleTriceps, RegionUpper, CategoryPush},
+	{MuscleBack, RegionUpper, CategoryPull},
+	{MuscleBiceps, RegionUpper, CategoryPull},
+}
+
+// LookupMuscle returns the muscle group named name
+func LookupMuscle(name string) (Muscle, bool) {
+	for _, m := range Muscles {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return Muscle{}, false
+}
+
+// IsCategory reports whether name is a movement category
+func IsCategory(name string) bool {
+	return contains(Categories, name)
+}
+
+// patternMuscles are the muscle groups each compound movement pattern mainly trains
+var patternMuscles = map[string][]string{
+	PatternSquat:           {MuscleQuads, MuscleGlutes},
+	PatternHinge:           {MuscleHamstrings, MuscleGlutes, MuscleBack},
+	PatternLunge:           {MuscleQuads, MuscleGlutes},
+	PatternHorizontalPress: {MuscleChest, MuscleTriceps},
+	PatternVerticalPress:   {MuscleShoulders, MuscleTriceps},
+	PatternHorizontalPull:  {MuscleBack, MuscleBiceps},
+	PatternVerticalPull:    {MuscleBack, MuscleBiceps},
+}
+
+// patternSecondaryMuscles are the muscle groups each compound movement pattern
+// also works, as stabilizers or synergists
+var patternSecondaryMuscles = map[string][]string{
+	PatternSquat:           {MuscleHamstrings, MuscleBack},
+	PatternHinge:           {MuscleQuads},
+	PatternLunge:           {MuscleHamstrings, MuscleCalves},
+	PatternHorizontalPress: {MuscleShoulders},
+	PatternVerticalPress:   {MuscleChest},
+	PatternHorizontalPull:  {MuscleShoulders},
+	PatternVerticalPull:    {MuscleShoulders},
+}
+
+// isolationMuscles are the muscle groups of isolation exercises, whose pattern
+// says nothing about them
+var isolationMuscles = map[string][]string{
+	"leg curl":          {MuscleHamstrings},
+	"leg extension":     {MuscleQuads},
+	"calf raise":        {MuscleCalves},
+	"biceps curl":       {MuscleBiceps},
+	"triceps extension": {MuscleTriceps},
+	"lateral raise":     {MuscleShoulders},
+}
+
+// isolationSecondaryMuscles are the muscle groups isolation exercises also work
+var isolationSecondaryMuscles = map[string][]string{
+	"leg curl": {MuscleCalves},
+}
+
+// PrimaryMuscles returns the muscle groups e mainly trains, most trained first
+func (e Exercise) PrimaryMuscles() []string {
+	if muscles, ok := isolationMuscles[e.Name]; ok {
+		return muscles
+	}
+	return patternMuscles[e.Pattern]
+}
+
+// SecondaryMuscles returns the muscle groups e also works, which are not
+// counted as trained by it
+func (e Exercise) SecondaryMuscles() []string {
+	if _, ok := isolationMuscles[e.Name]; ok {
+		return orEmpty(isolationSecondaryMuscles[e.Name])
+	}
+	return orEmpty(patternSecondaryMuscles[e.Pattern])
+}
+
+// Category returns e's movement category, which for isolation exercises is
+// that of the muscle group they train
+func (e Exercise) Category() string {
+	if category, ok := patternCategories[e.Pattern]; ok {
+		return category
+	}
+	if muscles := e.PrimaryMuscles(); len(muscles) > 0 {
+		m, _ := LookupMuscle(muscles[0])
+		return m.Category
+	}
+	return ""
+}
+
+// Trains reports whether muscle is one of the muscle groups e mainly trains
+func (e Exercise) Trains(muscle string) bool {
+	return contains(e.PrimaryMuscles(), muscle)
+}
+
+// Alternatives returns the catalog exercises that could stand in for e beyond
+// its curated substitutes: those of the same category sharing a primary muscle
+// group with it, most shared muscle groups first and then by name
+func (e Exercise) Alternatives() []string {
+	category := e.Category()
+	shared := map[string]int{}
+	for name, candidate := range catalog {
+		if name == e.Name || contains(e.Substitutes, name) || candidate.Category() != category {
+			continue
+		}
+		for _, muscle := range candidate.PrimaryMuscles() {
+			if e.Trains(muscle) {
+				shared[name]++
+			}
+		}
+	}
+
+	alternatives := []string{}
+	for name := range shared {
+		alternatives = append(alternatives, name)
+	}
+	sort.Slice(alternatives, func(i, j int) bool {
+		a, b := alternatives[i], alternatives[j]
+		if shared[a] != shared[b] {
+			return shared[a] > shared[b]
+		}
+		return a < b
+	})
+	return alternatives
+}
+
+// Movement describes a movement pattern: its category and the muscle groups
+// it trains and works. Isolation has no category or muscle groups of its own
+type Movement struct {
+	Pattern          string   `json:"pattern"`
+	Category         string   `json:"category,omitempty"`
+	PrimaryMuscles   []string `json:"primaryMuscles"`
+	SecondaryMuscles []string `json:"secondaryMuscles"`
+}
+
+// CategoryGroup is a movement category with its patterns and muscle groups
+type CategoryGroup struct {
+	Name     string   `json:"name"`
+	Patterns []string `json:"patterns"`
+	Muscles  []string `json:"muscles"`
+}
+
+// Taxonomy is the classification every use of muscle groups and movement
+// categories draws on: the calendar's muscle groups, substitutions and
+// exercise search
+type Taxonomy struct {
+	Categories []CategoryGroup `json:"categories"`
+	Patterns   []Movement      `json:"patterns"`
+	Muscles    []Muscle        `json:"muscles"`
+}
+
+// DescribeTaxonomy returns the taxonomy in the order of Categories, Patterns
+// and Muscles
+func DescribeTaxonomy() Taxonomy {
+	t := Taxonomy{Categories: []CategoryGroup{}, Patterns: []Movement{}, Muscles: Muscles}
+	for _, category := range Categories {
+		group := CategoryGroup{Name: category, Patterns: []string{}, Muscles: []string{}}
+		for _, pattern := range Patterns {
+			if patternCategories[pattern] == category {
+				group.Patterns = append(group.Patterns, pattern)
+			}
+		}
+		for _, m := range Muscles {
+			if m.Category == category {
+				group.Muscles = append(group.Muscles, m.Name)
+			}
+		}
+		t.Categories = append(t.Categories, group)
+	}
+	for _, pattern := range Patterns {
+		t.Patterns = append(t.Patterns, Movement{
+			Pattern:          pattern,
+			Category:         patternCategories[pattern],
+			PrimaryMuscles:   orEmpty(patternMuscles[pattern]),
+			SecondaryMuscles: orEmpty(patternSecondaryMuscles[pattern]),
+		})
+	}
+	return t
+}
+
+// orEmpty returns values, or an empty slice for nil so it encodes as []
+func orEmpty(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,70 @@
+package exercise
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTaxonomy(t *testing.T) {
+	t.Run("classifies every catalog exercise with known muscle groups", func(t *testing.T) {
+		for name, e := range catalog {
+			if !IsCategory(e.Category()) {
+				t.Errorf("%s has unknown category %q", name, e.Category())
+			}
+			for _, muscle := range append(e.PrimaryMuscles(), e.SecondaryMuscles()...) {
+				if _, ok := LookupMuscle(muscle); !ok {
+					t.Errorf("%s trains unknown muscle group %q", name, muscle)
+				}
+			}
+			for _, muscle := range e.SecondaryMuscles() {
+				if e.Trains(muscle) {
+					t.Errorf("%s lists %s as both primary and secondary", name, muscle)
+				}
+			}
+		}
+	})
+
+	t.Run("isolation exercises take their muscle group's category", func(t *testing.T) {
+		tests := map[string]string{
+			"biceps curl":       CategoryPull,
+			"triceps extension": CategoryPush,
+			"leg curl":          CategoryHinge,
+			"leg extension":     CategorySquat,
+		}
+		for name, want := range tests {
+			e, _ := Lookup(name)
+			if got := e.Category(); got != want {
+				t.Errorf("%s: expected %s, got %s", name, want, got)
+			}
+		}
+	})
+
+	t.Run("describes each category's patterns and muscle groups", func(t *testing.T) {
+		// Act
+		taxonomy := DescribeTaxonomy()
+
+		// Assert
+		if len(taxonomy.Categories) != len(Categories) || len(taxonomy.Patterns) != len(Patterns) || len(taxonomy.Muscles) != len(Muscles) {
+			t.Fatalf("unexpected taxonomy %+v", taxonomy)
+		}
+		push := taxonomy.Categories[0]
+		if push.Name != CategoryPush || !reflect.DeepEqual(push.Patterns, []string{PatternHorizontalPress, PatternVerticalPress}) ||
+			!reflect.DeepEqual(push.Muscles, []string{MuscleChest, MuscleShoulders, MuscleTriceps}) {
+			t.Errorf("unexpected push category %+v", push)
+		}
+	})
+}
+
+func TestExercise_Alternatives(t *testing.T) {
+	// Arrange
+	e, _ := Lookup("leg curl")
+
+	// Act
+	alternatives := e.Alternatives()
+
+	// Assert
+	want := []string{"deadlift", "hip thrust", "kettlebell swing", "romanian deadlift", "trap bar deadlift"}
+	if !reflect.DeepEqual(alternatives, want) {
+		t.Errorf("expected %v, got %v", want, alternatives)
+	}
+}
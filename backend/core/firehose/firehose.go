@@ -0,0 +1,62 @@
+package firehose
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"athlete-forge/awsapi"
+)
+
+// maxRecords is the most records PutRecordBatch accepts in one call
+const maxRecords = 500
+
+// service is the Kinesis Data Firehose JSON protocol descriptor
+var service = awsapi.Service{Name: "firehose", TargetPrefix: "Firehose_20150804", JSONVersion: "1.1"}
+
+// Stream writes JSON records to a Kinesis Data Firehose delivery stream
+type Stream struct {
+	client *awsapi.Client
+	name   string
+}
+
+// NewStream creates a writer for the delivery stream called name
+func NewStream(client *awsapi.Client, name string) *Stream {
+	return &Stream{client: client, name: name}
+}
+
+// record is one record in a PutRecordBatch request; Data is base64 encoded by
+// its JSON marshaling
+type record struct {
+	Data []byte `json:"Data"`
+}
+
+// Put marshals each value as a line of JSON, so the objects the stream writes
+// are newline-delimited JSON, and calls PutRecordBatch in batches of maxRecords.
+// It fails if any record was not accepted; records in earlier batches will have
+// been delivered
+func (s *Stream) Put(ctx context.Context, values ...interface{}) error {
+	for start := 0; start < len(values); start += maxRecords {
+		end := min(start+maxRecords, len(values))
+		records := make([]record, 0, end-start)
+		for _, v := range values[start:end] {
+			data, err := json.Marshal(v)
+			if err != nil {
+				return fmt.Errorf("failed to marshal record: %w", err)
+			}
+			records = append(records, record{Data: append(data, '\n')})
+		}
+
+		var out struct {
+			FailedPutCount int `json:"FailedPutCount"`
+		}
+		in := map[string]interface{}{"DeliveryStreamName": s.name, "Records": records}
+		if err := s.client.Call(ctx, service, "PutRecordBatch", in, &out); err != nil {
+			return fmt.Errorf("failed to put records to %s: %w", s.name, err)
+		}
+		if out.FailedPutCount > 0 {
+			return fmt.Errorf("failed to put %d of %d records to %s", out.FailedPutCount, len(records), s.name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package firehose
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"athlete-forge/awsapi"
+)
+
+func newTestStream(t *testing.T, handler http.HandlerFunc) *Stream {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := awsapi.NewClient("eu-west-2", awsapi.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	client.Endpoint = func(string) string { return server.URL }
+	return NewStream(client, "athlete-forge-telemetry")
+}
+
+func TestStream_Put(t *testing.T) {
+	value := map[string]string{"event": "app_opened"}
+
+	t.Run("puts newline-delimited records in batches of 500", func(t *testing.T) {
+		// Arrange
+		var target, name string
+		var batches [][]record
+		s := newTestStream(t, func(w http.ResponseWriter, r *http.Request) {
+			target = r.Header.Get("X-Amz-Target")
+			var in struct {
+				DeliveryStreamName string   `json:"DeliveryStreamName"`
+				Records            []record `json:"Records"`
+			}
+			data, _ := io.ReadAll(r.Body)
+			json.Unmarshal(data, &in)
+			name = in.DeliveryStreamName
+			batches = append(batches, in.Records)
+			w.Write([]byte(`{"FailedPutCount":0}`))
+		})
+		values := make([]interface{}, 501)
+		for i := range values {
+			values[i] = value
+		}
+
+		// Act
+		err := s.Put(context.Background(), values...)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if target != "Firehose_20150804.PutRecordBatch" || name != "athlete-forge-telemetry" || len(batches) != 2 || len(batches[0]) != 500 || len(batches[1]) != 1 {
+			t.Fatalf("unexpected requests: %s %s %d", target, name, len(batches))
+		}
+		data := batches[0][0].Data
+		if string(data) != "{\"event\":\"app_opened\"}\n" {
+			t.Errorf("unexpected record data %q", data)
+		}
+	})
+
+	t.Run("fails when a record is rejected", func(t *testing.T) {
+		// Arrange
+		s := newTestStream(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"FailedPutCount":1}`))
+		})
+
+		// Act
+		err := s.Put(context.Background(), value)
+
+		// Assert
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
@@ -0,0 +1,193 @@
+package fit
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Global message numbers of activity files
+const (
+	MesgSession = 18
+	MesgLap     = 19
+	MesgLength  = 101
+)
+
+// FieldTimestamp is the field number of every message's timestamp
+const FieldTimestamp = 253
+
+// Message is a decoded data message: its global message number and the
+// values of its set fields by field number. Integer fields are read as
+// unsigned integers, with signed fields sign-extended; strings, floats and
+// arrays are left out
+type Message struct {
+	Global uint16
+	Fields map[uint8]uint64
+}
+
+// Value returns field num, reporting whether it was set
+func (m Message) Value(num uint8) (uint64, bool) {
+	v, ok := m.Fields[num]
+	return v, ok
+}
+
+// Time converts a FIT timestamp to a time
+func Time(v uint64) time.Time {
+	return fitEpoch.Add(time.Duration(v) * time.Second)
+}
+
+// baseTypes gives the size and invalid value of each integer base type, and
+// whether it is signed
+var baseTypes = map[uint8]struct {
+	size    int
+	invalid uint64
+	signed  bool
+}{
+	0x00: {1, 0xFF, false},               // enum
+	0x01: {1, 0x7F, true},                // sint8
+	0x02: {1, 0xFF, false},               // uint8
+	0x83: {2, 0x7FFF, true},              // sint16
+	0x84: {2, 0xFFFF, false},             // uint16
+	0x85: {4, 0x7FFFFFFF, true},          // sint32
+	0x86: {4, 0xFFFFFFFF, false},         // uint32
+	0x0A: {1, 0, false},                  // uint8z
+	0x8B: {2, 0, false},                  // uint16z
+	0x8C: {4, 0, false},                  // uint32z
+	0x0D: {1, 0xFF, false},               // byte
+	0x8E: {8, 0x7FFFFFFFFFFFFFFF, true},  // sint64
+	0x8F: {8, 0xFFFFFFFFFFFFFFFF, false}, // uint64
+	0x90: {8, 0, false},                  // uint64z
+}
+
+type definition struct {
+	global    uint16
+	bigEndian bool
+	fields    []field
+	devSize   int
+}
+
+// Decode reads the data messages of a FIT file after checking its header and
+// CRC. Developer fields are skipped, and compressed timestamp headers are read
+// without expanding their timestamps
+func Decode(data []byte) ([]Message, error) {
+	if len(data) < 12 || string(data[8:12]) != ".FIT" {
+		return nil, errors.New("not a FIT file")
+	}
+	size := int(data[0])
+	end := size + int(binary.LittleEndian.Uint32(data[4:8]))
+	if size < 12 || end+2 > len(data) {
+		return nil, errors.New("FIT file is truncated")
+	}
+	if CRC(data[:end+2]) != 0 {
+		return nil, errors.New("FIT file is corrupt")
+	}
+
+	definitions := map[uint8]*definition{}
+	var messages []Message
+	for i := size; i < end; {
+		header := data[i]
+		i++
+		local := header & 0x0F
+		if header&0x80 != 0 {
+			local = (header >> 5) & 0x03
+		} else if header&0x40 != 0 {
+			d, next, err := readDefinition(data[:end], i, header&0x20 != 0)
+			if err != nil {
+				return nil, err
+			}
+			definitions[local], i = d, next
+			continue
+		}
+
+		d, ok := definitions[local]
+		if !ok {
+			return nil, fmt.Errorf("FIT data message for undefined local type %d", local)
+		}
+		m := Message{Global: d.global, Fields: map[uint8]uint64{}}
+		for _, f := range d.fields {
+			if i+int(f.size) > end {
+				return nil, errors.New("FIT file is truncated")
+			}
+			if v, ok := readValue(data[i:i+int(f.size)], f.baseType, d.bigEndian); ok {
+				m.Fields[f.num] = v
+			}
+			i += int(f.size)
+		}
+		i += d.devSize
+		if i > end {
+			return nil, errors.New("FIT file is truncated")
+		}
+		messages = append(messages, m)
+	}
+	return messages, nil
+}
+
+// readDefinition reads the definition message starting at i, returning it and
+// where the next record starts
+func readDefinition(data []byte, i int, developer bool) (*definition, int, error) {
+	if i+5 > len(data) {
+		return nil, 0, errors.New("FIT file is truncated")
+	}
+	d := &definition{bigEndian: data[i+1] == 1}
+	if d.bigEndian {
+		d.global = binary.BigEndian.Uint16(data[i+2:])
+	} else {
+		d.global = binary.LittleEndian.Uint16(data[i+2:])
+	}
+	count := int(data[i+4])
+	i += 5
+	if i+count*3 > len(data) {
+		return nil, 0, errors.New("FIT file is truncated")
+	}
+	for j := 0; j < count; j++ {
+		d.fields = append(d.fields, field{num: data[i], size: data[i+1], baseType: data[i+2]})
+		i += 3
+	}
+	if developer {
+		if i >= len(data) {
+			return nil, 0, errors.New("FIT file is truncated")
+		}
+		count := int(data[i])
+		i++
+		if i+count*3 > len(data) {
+			return nil, 0, errors.New("FIT file is truncated")
+		}
+		for j := 0; j < count; j++ {
+			d.devSize += int(data[i+1])
+			i += 3
+		}
+	}
+	return d, i, nil
+}
+
+// readValue reads a single integer value, reporting false for other base
+// types, arrays and invalid values
+func readValue(b []byte, baseType uint8, bigEndian bool) (uint64, bool) {
+	t, ok := baseTypes[baseType]
+	if !ok || len(b) != t.size {
+		return 0, false
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if bigEndian {
+		order = binary.BigEndian
+	}
+	var v uint64
+	switch t.size {
+	case 1:
+		v = uint64(b[0])
+	case 2:
+		v = uint64(order.Uint16(b))
+	case 4:
+		v = uint64(order.Uint32(b))
+	case 8:
+		v = order.Uint64(b)
+	}
+	if v == t.invalid {
+		return 0, false
+	}
+	if t.signed && t.size < 8 && v&(1<<(t.size*8-1)) != 0 {
+		v |= ^uint64(0) << (t.size * 8)
+	}
+	return v, true
+}
@@ -0,0 +1,230 @@
+package fit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentType is the media type of FIT files
+const ContentType = "application/vnd.ant.fit"
+
+// Protocol and profile versions written to the file header: protocol 2.0 and
+// profile 21.32
+const (
+	protocolVersion = 0x20
+	profileVersion  = 2132
+	headerSize      = 14
+)
+
+// Global message numbers
+const (
+	mesgFileID      = 0
+	mesgWorkout     = 26
+	mesgWorkoutStep = 27
+)
+
+// Field base types
+const (
+	typeEnum    = 0x00
+	typeUint16  = 0x84
+	typeUint32  = 0x86
+	typeString  = 0x07
+	typeUint32z = 0x8C
+)
+
+// Invalid values mark a field as not set
+const (
+	invalidEnum   = 0xFF
+	invalidUint32 = 0xFFFFFFFF
+)
+
+// fitEpoch is the zero of FIT timestamps
+var fitEpoch = time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// File types and the development manufacturer ID written to the file ID
+const (
+	fileWorkout             = 5
+	manufacturerDevelopment = 255
+)
+
+// Sports
+const (
+	SportGeneric  = 0
+	SportRunning  = 1
+	SportCycling  = 2
+	SportSwimming = 5
+	SportWalking  = 11
+	SportRowing   = 15
+	SportHiking   = 17
+)
+
+// How a workout step ends: after a time in milliseconds, a distance in
+// centimetres, when the lap button is pressed, or, for repeat steps, once the
+// steps from DurationValue on have been repeated TargetValue times
+const (
+	DurationTime     = 0
+	DurationDistance = 1
+	DurationOpen     = 5
+	DurationRepeat   = 6
+)
+
+// What a workout step targets. Custom speed ranges are in millimetres per
+// second, heart rates in beats per minute plus 100 and power in watts plus
+// 1000, as the profile reserves lower values for percentages of max heart rate
+// and FTP
+const (
+	TargetSpeed     = 0
+	TargetHeartRate = 1
+	TargetOpen      = 2
+	TargetPower     = 4
+)
+
+// Offsets of custom heart rate and power targets
+const (
+	HeartRateOffset = 100
+	PowerOffset     = 1000
+)
+
+// Step intensities
+const (
+	IntensityActive   = 0
+	IntensityRest     = 1
+	IntensityWarmup   = 2
+	IntensityCooldown = 3
+	IntensityRecovery = 4
+	IntensityInterval = 5
+)
+
+// Name lengths in bytes, including the terminating zero
+const (
+	workoutNameSize = 32
+	stepNameSize    = 16
+)
+
+// Step is one workout step as written to the file
+type Step struct {
+	Name          string
+	Intensity     uint8
+	DurationType  uint8
+	DurationValue uint32
+	TargetType    uint8
+	TargetValue   uint32
+	TargetLow     uint32
+	TargetHigh    uint32
+}
+
+// Workout is a structured workout a device can follow step by step
+type Workout struct {
+	Name      string
+	Sport     uint8
+	Steps     []Step
+	CreatedAt time.Time
+}
+
+type field struct {
+	num, size, baseType uint8
+}
+
+// Encode writes w as a FIT workout file
+func Encode(w Workout) []byte {
+	var data bytes.Buffer
+
+	define(&data, 0, mesgFileID, []field{{0, 1, typeEnum}, {1, 2, typeUint16}, {2, 2, typeUint16}, {3, 4, typeUint32z}, {4, 4, typeUint32}})
+	data.WriteByte(0)
+	write(&data, uint8(fileWorkout), uint16(manufacturerDevelopment), uint16(0), uint32(1), timestamp(w.CreatedAt))
+
+	define(&data, 1, mesgWorkout, []field{{4, 1, typeEnum}, {6, 2, typeUint16}, {8, workoutNameSize, typeString}})
+	data.WriteByte(1)
+	write(&data, w.Sport, uint16(len(w.Steps)))
+	data.Write(fixed(w.Name, workoutNameSize))
+
+	define(&data, 2, mesgWorkoutStep, []field{
+		{254, 2, typeUint16}, {0, stepNameSize, typeString}, {1, 1, typeEnum}, {2, 4, typeUint32},
+		{3, 1, typeEnum}, {4, 4, typeUint32}, {5, 4, typeUint32}, {6, 4, typeUint32}, {7, 1, typeEnum},
+	})
+	for i, step := range w.Steps {
+		data.WriteByte(2)
+		write(&data, uint16(i))
+		data.Write(fixed(step.Name, stepNameSize))
+		targetType, low, high, intensity := step.TargetType, step.TargetLow, step.TargetHigh, step.Intensity
+		if step.DurationType == DurationRepeat {
+			targetType, low, high, intensity = invalidEnum, invalidUint32, invalidUint32, invalidEnum
+		} else if step.TargetType == TargetOpen {
+			low, high = invalidUint32, invalidUint32
+		}
+		durationValue := step.DurationValue
+		if step.DurationType == DurationOpen {
+			durationValue = invalidUint32
+		}
+		write(&data, step.DurationType, durationValue, targetType, step.TargetValue, low, high, intensity)
+	}
+
+	header := make([]byte, headerSize)
+	header[0] = headerSize
+	header[1] = protocolVersion
+	binary.LittleEndian.PutUint16(header[2:], profileVersion)
+	binary.LittleEndian.PutUint32(header[4:], uint32(data.Len()))
+	copy(header[8:], ".FIT")
+	binary.LittleEndian.PutUint16(header[12:], CRC(header[:12]))
+
+	file := append(header, data.Bytes()...)
+	return binary.LittleEndian.AppendUint16(file, CRC(file))
+}
+
+// define writes a definition message for local message type local
+func define(buf *bytes.Buffer, local uint8, global uint16, fields []field) {
+	buf.WriteByte(0x40 | local)
+	buf.WriteByte(0) // reserved
+	buf.WriteByte(0) // little-endian
+	write(buf, global, uint8(len(fields)))
+	for _, f := range fields {
+		buf.Write([]byte{f.num, f.size, f.baseType})
+	}
+}
+
+func write(buf *bytes.Buffer, values ...interface{}) {
+	for _, v := range values {
+		binary.Write(buf, binary.LittleEndian, v)
+	}
+}
+
+// fixed returns s zero-padded to size bytes, truncated on a rune boundary to
+// leave room for the terminating zero
+func fixed(s string, size int) []byte {
+	for len(s) > size-1 {
+		_, n := utf8.DecodeLastRuneInString(s)
+		s = s[:len(s)-n]
+	}
+	out := make([]byte, size)
+	copy(out, s)
+	return out
+}
+
+func timestamp(t time.Time) uint32 {
+	if t.Before(fitEpoch) {
+		return 0
+	}
+	return uint32(t.Sub(fitEpoch) / time.Second)
+}
+
+var crcTable = [16]uint16{
+	0x0000, 0xCC01, 0xD801, 0x1400, 0xF001, 0x3C00, 0x2800, 0xE401,
+	0xA001, 0x6C00, 0x7800, 0xB401, 0x5000, 0x9C01, 0x8801, 0x4400,
+}
+
+// CRC returns the FIT CRC-16 of data, computed a nibble at a time
+func CRC(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		tmp := crcTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ crcTable[b&0xF]
+
+		tmp = crcTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ crcTable[(b>>4)&0xF]
+	}
+	return crc
+}
@@ -0,0 +1,166 @@
+package fit
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func TestCRC(t *testing.T) {
+	// Act
+	crc := CRC([]byte("123456789"))
+
+	// Assert
+	if crc != 0xBB3D {
+		t.Errorf("expected 0xBB3D, got %#04x", crc)
+	}
+}
+
+// messages walks a FIT file's records, returning each data message's fields by
+// global message number
+func messages(t *testing.T, file []byte) map[uint16][]map[uint8][]byte {
+	t.Helper()
+	size := binary.LittleEndian.Uint32(file[4:8])
+	data := file[headerSize : headerSize+int(size)]
+	type definition struct {
+		global uint16
+		fields []field
+	}
+	definitions := map[uint8]definition{}
+	found := map[uint16][]map[uint8][]byte{}
+	for i := 0; i < len(data); {
+		header := data[i]
+		i++
+		local := header & 0x0F
+		if header&0x40 != 0 {
+			d := definition{global: binary.LittleEndian.Uint16(data[i+2:])}
+			count := int(data[i+4])
+			i += 5
+			for j := 0; j < count; j++ {
+				d.fields = append(d.fields, field{num: data[i], size: data[i+1], baseType: data[i+2]})
+				i += 3
+			}
+			definitions[local] = d
+			continue
+		}
+		d, ok := definitions[local]
+		if !ok {
+			t.Fatalf("data message for undefined local type %d", local)
+		}
+		values := map[uint8][]byte{}
+		for _, f := range d.fields {
+			values[f.num] = data[i : i+int(f.size)]
+			i += int(f.size)
+		}
+		found[d.global] = append(found[d.global], values)
+	}
+	return found
+}
+
+func TestEncode(t *testing.T) {
+	// Arrange
+	w := Workout{
+		Name:      "Track 6x400m",
+		Sport:     SportRunning,
+		CreatedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Steps: []Step{
+			{Name: "Warm up", Intensity: IntensityWarmup, DurationType: DurationTime, DurationValue: 600000, TargetType: TargetOpen},
+			{Name: "400m", Intensity: IntensityInterval, DurationType: DurationDistance, DurationValue: 40000, TargetType: TargetHeartRate, TargetLow: 260, TargetHigh: 275},
+			{Name: "Jog", Intensity: IntensityRecovery, DurationType: DurationOpen, TargetType: TargetOpen},
+			{DurationType: DurationRepeat, DurationValue: 1, TargetValue: 6},
+		},
+	}
+
+	// Act
+	file := Encode(w)
+
+	// Assert
+	if file[0] != headerSize || string(file[8:12]) != ".FIT" || binary.LittleEndian.Uint16(file[12:14]) != CRC(file[:12]) {
+		t.Fatalf("unexpected header % x", file[:14])
+	}
+	if int(binary.LittleEndian.Uint32(file[4:8])) != len(file)-headerSize-2 || CRC(file) != 0 {
+		t.Fatalf("expected the data size and file CRC to cover the file")
+	}
+	found := messages(t, file)
+	if ids := found[mesgFileID]; len(ids) != 1 || ids[0][0][0] != fileWorkout {
+		t.Errorf("expected a workout file ID, got %v", ids)
+	}
+	workouts := found[mesgWorkout]
+	if len(workouts) != 1 || workouts[0][4][0] != SportRunning || binary.LittleEndian.Uint16(workouts[0][6]) != 4 {
+		t.Fatalf("unexpected workout message: %v", workouts)
+	}
+	steps := found[mesgWorkoutStep]
+	if len(steps) != 4 {
+		t.Fatalf("expected 4 steps, got %d", len(steps))
+	}
+	if binary.LittleEndian.Uint32(steps[1][2]) != 40000 || binary.LittleEndian.Uint32(steps[1][5]) != 260 || steps[1][7][0] != IntensityInterval {
+		t.Errorf("unexpected interval step: %v", steps[1])
+	}
+	if steps[3][1][0] != DurationRepeat || binary.LittleEndian.Uint32(steps[3][2]) != 1 || binary.LittleEndian.Uint32(steps[3][4]) != 6 {
+		t.Errorf("unexpected repeat step: %v", steps[3])
+	}
+}
+
+func TestFixed(t *testing.T) {
+	// Act
+	name := fixed("Tempo 10 km, ré", stepNameSize)
+
+	// Assert
+	if len(name) != stepNameSize || string(name[:14]) != "Tempo 10 km, r" || name[14] != 0 {
+		t.Errorf("expected the name truncated on a rune boundary, got %q", name)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	// Arrange
+	file := Encode(Workout{
+		Name:  "Hills",
+		Sport: SportRunning,
+		Steps: []Step{
+			{Intensity: IntensityInterval, DurationType: DurationTime, DurationValue: 90000, TargetType: TargetOpen},
+			{DurationType: DurationRepeat, DurationValue: 0, TargetValue: 8},
+		},
+	})
+
+	t.Run("reads integer fields and leaves out invalid values", func(t *testing.T) {
+		// Act
+		messages, err := Decode(file)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var steps []Message
+		for _, m := range messages {
+			if m.Global == mesgWorkoutStep {
+				steps = append(steps, m)
+			}
+		}
+		if len(steps) != 2 {
+			t.Fatalf("expected 2 steps, got %d", len(steps))
+		}
+		if v, _ := steps[0].Value(2); v != 90000 {
+			t.Errorf("expected a 90 second duration, got %d", v)
+		}
+		if _, ok := steps[1].Value(7); ok {
+			t.Error("expected the repeat step's invalid intensity left out")
+		}
+		if v, _ := steps[1].Value(4); v != 8 {
+			t.Errorf("expected 8 repeats, got %d", v)
+		}
+	})
+
+	t.Run("rejects a corrupt file", func(t *testing.T) {
+		// Arrange
+		corrupt := append([]byte(nil), file...)
+		corrupt[20] ^= 0xFF
+
+		// Act
+		_, err := Decode(corrupt)
+
+		// Assert
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package ftp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"athlete-forge/cardio"
+	"athlete-forge/store"
+)
+
+const historySK = "FTP"
+
+// maxHistory is how many changes are kept, oldest dropped first
+const maxHistory = 100
+
+// Sources of an FTP change
+const (
+	SourceManual   = "manual"
+	SourceDetected = "detected"
+)
+
+// Limits on a plausible FTP in watts
+const (
+	MinWatts = 50
+	MaxWatts = 600
+)
+
+const (
+	// detectionWindow is how far back rides are searched for efforts that
+	// suggest a higher FTP
+	detectionWindow = 42 * 24 * time.Hour
+
+	// twentyMinuteFactor scales a best 20 minute power to FTP, as in the
+	// standard 20 minute test
+	twentyMinuteFactor = 0.95
+)
+
+// Validate checks watts is a plausible FTP
+func Validate(watts int) error {
+	if watts < MinWatts || watts > MaxWatts {
+		return fmt.Errorf("ftp must be between %d and %d watts", MinWatts, MaxWatts)
+	}
+	return nil
+}
+
+// Entry is one change to a user's FTP. ActivityID is the ride a detected FTP
+// came from
+type Entry struct {
+	Watts      int       `json:"watts"`
+	Source     string    `json:"source"`
+	ActivityID string    `json:"activityId,omitempty"`
+	SetAt      time.Time `json:"setAt"`
+}
+
+// History is a user's FTP changes, oldest first
+type History struct {
+	UserID  string  `json:"userId"`
+	Entries []Entry `json:"entries"`
+}
+
+// Add records e as the latest change
+func (h *History) Add(e Entry) {
+	h.Entries = append(h.Entries, e)
+	if len(h.Entries) > maxHistory {
+		h.Entries = h.Entries[len(h.Entries)-maxHistory:]
+	}
+}
+
+// Suggestion is a higher FTP estimated from a ride's best 20 minute power
+type Suggestion struct {
+	Watts          int       `json:"watts"`
+	ActivityID     string    `json:"activityId"`
+	Best20MinPower int       `json:"best20MinPower"`
+	Date           time.Time `json:"date"`
+}
+
+// Detect looks for the best 20 minute power in the rides of the six weeks
+// before now and suggests 95% of it as FTP when that is above current, which
+// is 0 when the user has not set an FTP. It returns nil when no ride beats the
+// current FTP; lower FTPs are left for the user to set, since an easy few
+// weeks do not mean fitness was lost
+func Detect(activities []cardio.Activity, current int, now time.Time) *Suggestion {
+	var best *Suggestion
+	for _, a := range activities {
+		if a.Ride == nil || a.Ride.Best20MinPower == 0 || a.StartTime.After(now) || now.Sub(a.StartTime) > detectionWindow {
+			continue
+		}
+		if best == nil || a.Ride.Best20MinPower > best.Best20MinPower {
+			best = &Suggestion{ActivityID: a.ID, Best20MinPower: a.Ride.Best20MinPower, Date: a.StartTime}
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	best.Watts = int(math.Round(float64(best.Best20MinPower) * twentyMinuteFactor))
+	if best.Watts <= current || Validate(best.Watts) != nil {
+		return nil
+	}
+	return best
+}
+
+// Repository loads and saves users' FTP history
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Get returns userID's FTP history, which is empty when they have never set
+// an FTP
+func (r *Repository) Get(ctx context.Context, userID string) (*History, error) {
+	var h History
+	err := r.store.Get(ctx, store.UserPK(userID), historySK, &h)
+	if errors.Is(err, store.ErrNotFound) {
+		return &History{UserID: userID, Entries: []Entry{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load FTP history: %w", err)
+	}
+	return &h, nil
+}
+
+// Record adds e to userID's FTP history and returns the updated history
+func (r *Repository) Record(ctx context.Context, userID string, e Entry) (*History, error) {
+	h, err := r.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	h.Add(e)
+	if err := r.store.Put(ctx, store.UserPK(userID), historySK, h); err != nil {
+		return nil, fmt.Errorf("failed to save FTP history: %w", err)
+	}
+	return h, nil
+}
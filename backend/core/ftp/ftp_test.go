@@ -0,0 +1,67 @@
+package ftp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"athlete-forge/cardio"
+	"athlete-forge/store"
+)
+
+func TestDetect(t *testing.T) {
+	now := time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)
+	ride := func(id string, daysAgo, best20 int) cardio.Activity {
+		return cardio.Activity{ID: id, Sport: "ride", StartTime: now.AddDate(0, 0, -daysAgo), Ride: &cardio.RideSummary{Best20MinPower: best20}}
+	}
+	activities := []cardio.Activity{ride("old", 60, 320), ride("recent", 10, 280), ride("easy", 3, 200)}
+
+	t.Run("suggests 95% of the best recent 20 minutes", func(t *testing.T) {
+		// Act
+		s := Detect(activities, 250, now)
+
+		// Assert
+		if s == nil || s.Watts != 266 || s.ActivityID != "recent" || s.Best20MinPower != 280 {
+			t.Errorf("unexpected suggestion: %+v", s)
+		}
+	})
+
+	t.Run("suggests nothing at or below the current FTP", func(t *testing.T) {
+		if s := Detect(activities, 266, now); s != nil {
+			t.Errorf("expected no suggestion, got %+v", s)
+		}
+	})
+
+	t.Run("suggests a first FTP", func(t *testing.T) {
+		if s := Detect(activities, 0, now); s == nil || s.Watts != 266 {
+			t.Errorf("unexpected suggestion: %+v", s)
+		}
+	})
+}
+
+func TestRepository_Record(t *testing.T) {
+	t.Run("keeps changes oldest first", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		r := NewRepository(store.NewMemoryStore())
+		r.Record(ctx, "user-1", Entry{Watts: 240, Source: SourceManual})
+
+		// Act
+		h, err := r.Record(ctx, "user-1", Entry{Watts: 255, Source: SourceDetected, ActivityID: "ride-1"})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(h.Entries) != 2 || h.Entries[0].Watts != 240 || h.Entries[1].Source != SourceDetected {
+			t.Errorf("unexpected history: %+v", h.Entries)
+		}
+	})
+
+	t.Run("returns an empty history before any FTP is set", func(t *testing.T) {
+		h, err := NewRepository(store.NewMemoryStore()).Get(context.Background(), "user-1")
+		if err != nil || h.Entries == nil || len(h.Entries) != 0 {
+			t.Errorf("expected an empty history, got %+v, %v", h, err)
+		}
+	})
+}
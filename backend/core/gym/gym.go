@@ -0,0 +1,210 @@
+package gym
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"athlete-forge/exercise"
+	"athlete-forge/store"
+)
+
+const (
+	gymSKPrefix    = "GYM#"
+	maxAddressSize = 500
+)
+
+// Gym is a place a user trains, such as a commercial gym, a garage or a park,
+// and the equipment available there. Workouts are tagged with the gym they were
+// performed at
+type Gym struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"userId"`
+	Name      string    `json:"name"`
+	Location  *Location `json:"location,omitempty"`
+	Equipment []string  `json:"equipment"`
+	Default   bool      `json:"default"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Location is where a gym is, as an address, coordinates or both
+type Location struct {
+	Address   string   `json:"address,omitempty"`
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+}
+
+// Validate checks the location's address and that coordinates come in pairs
+// within range
+func (l *Location) Validate() error {
+	if len(l.Address) > maxAddressSize {
+		return fmt.Errorf("address must be at most %d characters", maxAddressSize)
+	}
+	if (l.Latitude == nil) != (l.Longitude == nil) {
+		return errors.New("latitude and longitude must be given together")
+	}
+	if l.Latitude != nil && (*l.Latitude < -90 || *l.Latitude > 90) {
+		return errors.New("latitude must be between -90 and 90")
+	}
+	if l.Longitude != nil && (*l.Longitude < -180 || *l.Longitude > 180) {
+		return errors.New("longitude must be between -180 and 180")
+	}
+	return nil
+}
+
+// Validate checks the gym's name, location and equipment
+func (g *Gym) Validate() error {
+	if g.Name == "" {
+		return errors.New("gym name is required")
+	}
+	if g.Location != nil {
+		if err := g.Location.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, item := range g.Equipment {
+		if !exercise.IsEquipment(item) {
+			return fmt.Errorf("unknown equipment %q", item)
+		}
+	}
+	return nil
+}
+
+// Warning flags a program exercise the gym cannot support
+type Warning struct {
+	Exercise    string   `json:"exercise"`
+	Missing     []string `json:"missing"`
+	Substitutes []string `json:"substitutes,omitempty"`
+}
+
+// Check returns a warning for each named exercise that needs equipment the gym
+// lacks, suggesting substitutes it can support: the exercise's curated
+// substitutes, or when it supports none of them, alternatives of the same
+// category training the same muscle groups. Exercises missing from the catalog
+// are assumed feasible
+func (g *Gym) Check(names []string) []Warning {
+	warnings := []Warning{}
+	for _, name := range names {
+		e, ok := exercise.Lookup(name)
+		if !ok {
+			continue
+		}
+		missing := e.Missing(g.Equipment)
+		if len(missing) == 0 {
+			continue
+		}
+
+		warning := Warning{Exercise: name, Missing: missing, Substitutes: g.supported(e.Substitutes)}
+		if len(warning.Substitutes) == 0 {
+			warning.Substitutes = g.supported(e.Alternatives())
+		}
+		warnings = append(warnings, warning)
+	}
+	return warnings
+}
+
+// supported returns the catalog exercises in names the gym has the equipment
+// for, or nil when there are none
+func (g *Gym) supported(names []string) []string {
+	var supported []string
+	for _, name := range names {
+		if e, ok := exercise.Lookup(name); ok && len(e.Missing(g.Equipment)) == 0 {
+			supported = append(supported, name)
+		}
+	}
+	return supported
+}
+
+// Repository loads and saves gyms
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Get returns the gym with id owned by userID
+func (r *Repository) Get(ctx context.Context, userID, id string) (*Gym, error) {
+	var g Gym
+	if err := r.store.Get(ctx, store.UserPK(userID), gymSKPrefix+id, &g); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// List returns all of userID's gyms
+func (r *Repository) List(ctx context.Context, userID string) ([]Gym, error) {
+	items, err := r.store.Query(ctx, store.UserPK(userID), gymSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list gyms: %w", err)
+	}
+
+	gyms := make([]Gym, 0, len(items))
+	for _, item := range items {
+		var g Gym
+		if err := item.Decode(&g); err != nil {
+			return nil, err
+		}
+		gyms = append(gyms, g)
+	}
+	return gyms, nil
+}
+
+// Default returns userID's default gym, or their only gym, and ErrNotFound otherwise
+func (r *Repository) Default(ctx context.Context, userID string) (*Gym, error) {
+	gyms, err := r.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range gyms {
+		if gyms[i].Default {
+			return &gyms[i], nil
+		}
+	}
+	if len(gyms) == 1 {
+		return &gyms[0], nil
+	}
+	return nil, store.ErrNotFound
+}
+
+// Save validates and stores g, assigning an ID to new gyms; saving a default gym
+// clears the flag on the user's other gyms
+func (r *Repository) Save(ctx context.Context, g *Gym) error {
+	if err := g.Validate(); err != nil {
+		return err
+	}
+	if g.ID == "" {
+		g.ID = store.NewID()
+	}
+	if g.Default {
+		gyms, err := r.List(ctx, g.UserID)
+		if err != nil {
+			return err
+		}
+		for _, other := range gyms {
+			if other.ID == g.ID || !other.Default {
+				continue
+			}
+			other.Default = false
+			if err := r.store.Put(ctx, store.UserPK(other.UserID), gymSKPrefix+other.ID, other); err != nil {
+				return fmt.Errorf("failed to save gym: %w", err)
+			}
+		}
+	}
+	if err := r.store.Put(ctx, store.UserPK(g.UserID), gymSKPrefix+g.ID, g); err != nil {
+		return fmt.Errorf("failed to save gym: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the gym with id owned by userID
+func (r *Repository) Delete(ctx context.Context, userID, id string) error {
+	if err := r.store.Delete(ctx, store.UserPK(userID), gymSKPrefix+id); err != nil {
+		return fmt.Errorf("failed to delete gym: %w", err)
+	}
+	return nil
+}
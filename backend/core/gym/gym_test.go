@@ -0,0 +1,78 @@
+package gym
+
+import (
+	"context"
+	"testing"
+
+	"athlete-forge/exercise"
+	"athlete-forge/store"
+)
+
+func TestGym_Check(t *testing.T) {
+	// Arrange
+	g := &Gym{Name: "Garage", Equipment: []string{exercise.EquipmentDumbbells, exercise.EquipmentBench, exercise.EquipmentPullUpBar}}
+
+	// Act
+	warnings := g.Check([]string{"Squat", "Pull-up", "Bench Press", "Turkish Get-up"})
+
+	// Assert
+	if len(warnings) != 2 {
+		t.Fatalf("expected warnings for squat and bench press, got %+v", warnings)
+	}
+	if warnings[0].Exercise != "Squat" || len(warnings[0].Missing) != 2 || warnings[0].Substitutes[0] != "goblet squat" {
+		t.Errorf("unexpected squat warning: %+v", warnings[0])
+	}
+	if warnings[1].Substitutes[0] != "dumbbell bench press" {
+		t.Errorf("unexpected bench press warning: %+v", warnings[1])
+	}
+}
+
+func TestGym_Check_Alternatives(t *testing.T) {
+	// Arrange
+	g := &Gym{Name: "Park", Equipment: []string{exercise.EquipmentPullUpBar}}
+
+	// Act
+	warnings := g.Check([]string{"Lat Pulldown"})
+
+	// Assert
+	if len(warnings) != 1 || len(warnings[0].Substitutes) != 2 || warnings[0].Substitutes[0] != "chin-up" || warnings[0].Substitutes[1] != "pull-up" {
+		t.Errorf("expected pulls the gym supports in place of the unsupported substitute, got %+v", warnings)
+	}
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("keeps one default gym", func(t *testing.T) {
+		// Arrange
+		repo := NewRepository(store.NewMemoryStore())
+		home := &Gym{UserID: "user-1", Name: "Home", Default: true}
+		repo.Save(ctx, home)
+		commercial := &Gym{UserID: "user-1", Name: "Commercial", Equipment: exercise.Equipment, Default: true}
+
+		// Act
+		if err := repo.Save(ctx, commercial); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		selected, err := repo.Default(ctx, "user-1")
+		reloaded, _ := repo.Get(ctx, "user-1", home.ID)
+
+		// Assert
+		if err != nil || selected.ID != commercial.ID {
+			t.Errorf("expected commercial gym as default, got %+v (%v)", selected, err)
+		}
+		if reloaded.Default {
+			t.Error("expected home gym to lose its default flag")
+		}
+	})
+
+	t.Run("rejects unknown equipment", func(t *testing.T) {
+		// Act
+		err := NewRepository(store.NewMemoryStore()).Save(ctx, &Gym{UserID: "user-1", Name: "Home", Equipment: []string{"hover board"}})
+
+		// Assert
+		if err == nil {
+			t.Error("expected error for unknown equipment")
+		}
+	})
+}
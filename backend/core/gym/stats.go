@@ -0,0 +1,111 @@
+package gym
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"athlete-forge/workout"
+)
+
+const (
+	// statsWindow is how far back Stats looks for a gym's recent frequency
+	statsWindow = 28 * 24 * time.Hour
+	maxTopLifts = 10
+)
+
+// Stats describes how a gym is used: how often workouts are completed there
+// and which lifts are trained there most
+type Stats struct {
+	GymID           string     `json:"gymId"`
+	Workouts        int        `json:"workouts"`
+	RecentWorkouts  int        `json:"recentWorkouts"`
+	WorkoutsPerWeek float64    `json:"workoutsPerWeek"`
+	FirstVisit      *time.Time `json:"firstVisit,omitempty"`
+	LastVisit       *time.Time `json:"lastVisit,omitempty"`
+	TopLifts        []Lift     `json:"topLifts"`
+}
+
+// Lift is an exercise trained at a gym, with the workouts it appeared in and
+// the sets and volume logged there
+type Lift struct {
+	Exercise string  `json:"exercise"`
+	Workouts int     `json:"workouts"`
+	Sets     int     `json:"sets"`
+	Volume   float64 `json:"volume"`
+}
+
+// BuildStats summarizes the completed workouts tagged with gymID. Recent
+// workouts are those completed in the four weeks before now, and
+// WorkoutsPerWeek is their weekly average. TopLifts lists the ten exercises
+// with the most sets performed, matching names ignoring case and naming each
+// as it was first logged
+func BuildStats(gymID string, workouts []workout.Workout, now time.Time) Stats {
+	stats := Stats{GymID: gymID, TopLifts: []Lift{}}
+	lifts := map[string]*Lift{}
+	named := map[string]time.Time{}
+	for _, w := range workouts {
+		if w.GymID != gymID || w.Status != workout.StatusCompleted {
+			continue
+		}
+		at := w.StartedAt
+		if w.CompletedAt != nil {
+			at = *w.CompletedAt
+		}
+		stats.Workouts++
+		if now.Sub(at) < statsWindow && !at.After(now) {
+			stats.RecentWorkouts++
+		}
+		if stats.FirstVisit == nil || at.Before(*stats.FirstVisit) {
+			stats.FirstVisit = &at
+		}
+		if stats.LastVisit == nil || at.After(*stats.LastVisit) {
+			stats.LastVisit = &at
+		}
+
+		seen := map[string]bool{}
+		for _, e := range w.Exercises {
+			key := strings.ToLower(e.Name)
+			lift, ok := lifts[key]
+			if !ok {
+				lift = &Lift{Exercise: e.Name}
+				lifts[key] = lift
+				named[key] = at
+			} else if at.Before(named[key]) {
+				lift.Exercise = e.Name
+				named[key] = at
+			}
+			if !seen[key] {
+				seen[key] = true
+				lift.Workouts++
+			}
+			for _, set := range e.Sets {
+				if set.Performed() {
+					lift.Sets++
+					lift.Volume += set.Volume()
+				}
+			}
+		}
+	}
+
+	for _, lift := range lifts {
+		lift.Volume = math.Round(lift.Volume*10) / 10
+		stats.TopLifts = append(stats.TopLifts, *lift)
+	}
+	sort.Slice(stats.TopLifts, func(i, j int) bool {
+		a, b := stats.TopLifts[i], stats.TopLifts[j]
+		if a.Sets != b.Sets {
+			return a.Sets > b.Sets
+		}
+		if a.Workouts != b.Workouts {
+			return a.Workouts > b.Workouts
+		}
+		return a.Exercise < b.Exercise
+	})
+	if len(stats.TopLifts) > maxTopLifts {
+		stats.TopLifts = stats.TopLifts[:maxTopLifts]
+	}
+	stats.WorkoutsPerWeek = math.Round(float64(stats.RecentWorkouts)/4*10) / 10
+	return stats
+}
@@ -0,0 +1,48 @@
+package gym
+
+import (
+	"testing"
+	"time"
+
+	"athlete-forge/workout"
+)
+
+func TestBuildStats(t *testing.T) {
+	// Arrange
+	now := time.Date(2026, 3, 29, 12, 0, 0, 0, time.UTC)
+	at := func(daysAgo int) *time.Time {
+		completed := now.AddDate(0, 0, -daysAgo)
+		return &completed
+	}
+	workouts := []workout.Workout{
+		{GymID: "g1", Status: workout.StatusCompleted, CompletedAt: at(60), Exercises: []workout.Exercise{
+			{Name: "Deadlift", Sets: []workout.Set{{Reps: 5, Weight: 140}}},
+		}},
+		{GymID: "g1", Status: workout.StatusCompleted, CompletedAt: at(7), Exercises: []workout.Exercise{
+			{Name: "Bench Press", Sets: []workout.Set{{Reps: 5, Weight: 80}, {Reps: 5, Weight: 80}, {Reps: 0, Weight: 80}}},
+		}},
+		{GymID: "g1", Status: workout.StatusCompleted, CompletedAt: at(1), Exercises: []workout.Exercise{
+			{Name: "bench press", Sets: []workout.Set{{Reps: 5, Weight: 82.5}}},
+		}},
+		{GymID: "g1", Status: workout.StatusActive, StartedAt: now},
+		{GymID: "g2", Status: workout.StatusCompleted, CompletedAt: at(2)},
+	}
+
+	// Act
+	stats := BuildStats("g1", workouts, now)
+
+	// Assert
+	if stats.Workouts != 3 || stats.RecentWorkouts != 2 || stats.WorkoutsPerWeek != 0.5 {
+		t.Errorf("unexpected frequency: %+v", stats)
+	}
+	if !stats.FirstVisit.Equal(*at(60)) || !stats.LastVisit.Equal(*at(1)) {
+		t.Errorf("unexpected visits: %v to %v", stats.FirstVisit, stats.LastVisit)
+	}
+	if len(stats.TopLifts) != 2 {
+		t.Fatalf("expected two lifts, got %+v", stats.TopLifts)
+	}
+	bench := stats.TopLifts[0]
+	if bench.Exercise != "Bench Press" || bench.Workouts != 2 || bench.Sets != 3 || bench.Volume != 1212.5 {
+		t.Errorf("unexpected top lift: %+v", bench)
+	}
+}
@@ -0,0 +1,254 @@
+package handle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"athlete-forge/store"
+)
+
+const (
+	claimPKPrefix = "HANDLE#"
+	claimSK       = "HANDLE"
+	accountSK     = "HANDLE"
+
+	// Cooldown is how long a user keeps a handle before they can change it again
+	Cooldown = 30 * 24 * time.Hour
+
+	// RedirectPeriod is how long a replaced handle keeps pointing at its former
+	// holder, so shared links keep working and nobody can take it over at once
+	RedirectPeriod = 90 * 24 * time.Hour
+)
+
+var (
+	// ErrTaken is returned when claiming a handle another user holds
+	ErrTaken = errors.New("handle is already taken")
+
+	// ErrCooldown is returned when a user changes their handle again too soon
+	ErrCooldown = errors.New("handle was changed too recently")
+)
+
+var pattern = regexp.MustCompile(`^[a-z0-9_]{3,30}$`)
+
+// reserved are handles that could pass for the service or its staff, or clash
+// with paths clients build from handles
+var reserved = map[string]bool{
+	"admin": true, "administrator": true, "api": true, "athleteforge": true, "athlete_forge": true,
+	"help": true, "me": true, "mod": true, "moderator": true, "null": true, "official": true,
+	"public": true, "root": true, "security": true, "settings": true, "staff": true,
+	"support": true, "system": true, "team": true, "undefined": true, "users": true, "www": true,
+}
+
+// profane are words no handle may contain, checked after undoing common digit
+// substitutions so "sh1t" is caught as well as "shit"
+var profane = []string{
+	"asshole", "bastard", "bitch", "bollocks", "cunt", "dick", "fuck", "nazi",
+	"nigger", "penis", "piss", "porn", "shit", "slut", "twat", "wank", "whore",
+}
+
+// deleet maps digits commonly used in place of letters back to the letters
+var deleet = strings.NewReplacer("0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "_", "")
+
+// Normalize returns handle as it is stored and looked up: lower case, without
+// a leading @
+func Normalize(handle string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+}
+
+// Validate checks that a normalized handle may be chosen at all, whoever holds it
+func Validate(handle string) error {
+	if !pattern.MatchString(handle) {
+		return errors.New("handle must be 3 to 30 letters, digits or underscores")
+	}
+	if reserved[handle] || reserved[strings.ReplaceAll(handle, "_", "")] {
+		return errors.New("handle is reserved")
+	}
+	plain := deleet.Replace(handle)
+	for _, word := range profane {
+		if strings.Contains(plain, word) {
+			return errors.New("handle is not allowed")
+		}
+	}
+	return nil
+}
+
+// Account is the handle a user holds and when they last changed it
+type Account struct {
+	UserID       string     `json:"userId"`
+	Handle       string     `json:"handle,omitempty"`
+	ChangedAt    *time.Time `json:"changedAt,omitempty"`
+	ChangeableAt *time.Time `json:"changeableAt,omitempty"`
+}
+
+// claim is the lookup item of a handle, kept outside users' partitions so a
+// handle can be resolved without knowing whose it is. A replaced handle keeps
+// its claim as a redirect until it expires
+type claim struct {
+	Handle    string     `json:"handle"`
+	UserID    string     `json:"userId"`
+	Redirect  bool       `json:"redirect,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// expired reports whether c no longer holds its handle at now
+func (c *claim) expired(now time.Time) bool {
+	return c.Redirect && c.ExpiresAt != nil && !now.Before(*c.ExpiresAt)
+}
+
+// Resolution is who a handle belongs to. Redirect is set when the handle is
+// one the user has since replaced with Handle
+type Resolution struct {
+	UserID   string
+	Handle   string
+	Redirect bool
+}
+
+// Registry claims handles for users and resolves them
+type Registry struct {
+	store store.Store
+}
+
+// New creates a Registry backed by s
+func New(s store.Store) *Registry {
+	return &Registry{store: s}
+}
+
+// Get returns userID's handle, which is empty when they have not chosen one
+func (r *Registry) Get(ctx context.Context, userID string) (*Account, error) {
+	var a Account
+	err := r.store.Get(ctx, store.UserPK(userID), accountSK, &a)
+	if errors.Is(err, store.ErrNotFound) {
+		return &Account{UserID: userID}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load handle: %w", err)
+	}
+	return &a, nil
+}
+
+// Available checks whether userID could claim handle at now, returning the
+// reason they could not
+func (r *Registry) Available(ctx context.Context, userID, handle string, now time.Time) error {
+	handle = Normalize(handle)
+	if err := Validate(handle); err != nil {
+		return err
+	}
+	var c claim
+	err := r.store.Get(ctx, claimPKPrefix+handle, claimSK, &c)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up handle: %w", err)
+	}
+	if c.UserID != userID && !c.expired(now) {
+		return ErrTaken
+	}
+	return nil
+}
+
+// Claim gives userID handle at now. The handle is taken with a conditional
+// write, so two users racing for it cannot both get it. The handle it replaces
+// redirects to the new one for RedirectPeriod, and another change is refused
+// with ErrCooldown until Cooldown has passed
+func (r *Registry) Claim(ctx context.Context, userID, handle string, now time.Time) (*Account, error) {
+	handle = Normalize(handle)
+	if err := Validate(handle); err != nil {
+		return nil, err
+	}
+	current, err := r.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if current.Handle == handle {
+		return current, nil
+	}
+	if current.ChangeableAt != nil && now.Before(*current.ChangeableAt) {
+		return nil, ErrCooldown
+	}
+
+	if err := r.take(ctx, userID, handle, now); err != nil {
+		return nil, err
+	}
+	changeable := now.Add(Cooldown)
+	account := &Account{UserID: userID, Handle: handle, ChangedAt: &now, ChangeableAt: &changeable}
+	if err := r.store.Put(ctx, store.UserPK(userID), accountSK, account); err != nil {
+		return nil, fmt.Errorf("failed to save handle: %w", err)
+	}
+	if current.Handle != "" {
+		expires := now.Add(RedirectPeriod)
+		redirect := claim{Handle: current.Handle, UserID: userID, Redirect: true, ExpiresAt: &expires}
+		if err := r.store.Put(ctx, claimPKPrefix+current.Handle, claimSK, redirect); err != nil {
+			return nil, fmt.Errorf("failed to redirect handle: %w", err)
+		}
+	}
+	return account, nil
+}
+
+// take writes the claim of handle for userID. A claim already there is only
+// replaced when it is userID's own redirect or a redirect that has expired. An
+// expired claim is deleted and the handle created again, so a user racing for
+// it gets ErrTaken rather than overwriting the winner's claim
+func (r *Registry) take(ctx context.Context, userID, handle string, now time.Time) error {
+	c := claim{Handle: handle, UserID: userID}
+	err := store.Create(ctx, r.store, claimPKPrefix+handle, claimSK, c)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, store.ErrExists) {
+		return fmt.Errorf("failed to claim handle: %w", err)
+	}
+
+	var existing claim
+	if err := r.store.Get(ctx, claimPKPrefix+handle, claimSK, &existing); err != nil {
+		return fmt.Errorf("failed to look up handle: %w", err)
+	}
+	if existing.UserID == userID {
+		if err := r.store.Put(ctx, claimPKPrefix+handle, claimSK, c); err != nil {
+			return fmt.Errorf("failed to claim handle: %w", err)
+		}
+		return nil
+	}
+	if !existing.expired(now) {
+		return ErrTaken
+	}
+	if err := r.store.Delete(ctx, claimPKPrefix+handle, claimSK); err != nil {
+		return fmt.Errorf("failed to release expired handle: %w", err)
+	}
+	err = store.Create(ctx, r.store, claimPKPrefix+handle, claimSK, c)
+	if errors.Is(err, store.ErrExists) {
+		return ErrTaken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to claim handle: %w", err)
+	}
+	return nil
+}
+
+// Resolve returns who holds handle at now, or store.ErrNotFound. For a
+// replaced handle it returns the user's current one with Redirect set
+func (r *Registry) Resolve(ctx context.Context, handle string, now time.Time) (*Resolution, error) {
+	handle = Normalize(handle)
+	var c claim
+	if err := r.store.Get(ctx, claimPKPrefix+handle, claimSK, &c); err != nil {
+		return nil, err
+	}
+	if c.expired(now) {
+		return nil, store.ErrNotFound
+	}
+	if !c.Redirect {
+		return &Resolution{UserID: c.UserID, Handle: c.Handle}, nil
+	}
+	account, err := r.Get(ctx, c.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Handle == "" {
+		return nil, store.ErrNotFound
+	}
+	return &Resolution{UserID: c.UserID, Handle: account.Handle, Redirect: true}, nil
+}
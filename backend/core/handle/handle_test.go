@@ -0,0 +1,178 @@
+package handle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		handle string
+		valid  bool
+	}{
+		{handle: "lifter_01", valid: true},
+		{handle: "ab"},
+		{handle: "lift.er"},
+		{handle: "admin"},
+		{handle: "athlete_forge"},
+		{handle: "big_sh1t_lifter"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.handle, func(t *testing.T) {
+			// Act
+			err := Validate(tt.handle)
+
+			// Assert
+			if (err == nil) != tt.valid {
+				t.Errorf("expected valid %v, got error %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+
+	t.Run("claims and resolves a handle", func(t *testing.T) {
+		// Arrange
+		r := New(store.NewMemoryStore())
+
+		// Act
+		account, err := r.Claim(ctx, "user-1", "@Lifter", now)
+		resolved, resolveErr := r.Resolve(ctx, "lifter", now)
+
+		// Assert
+		if err != nil || resolveErr != nil {
+			t.Fatalf("unexpected errors: %v, %v", err, resolveErr)
+		}
+		if account.Handle != "lifter" || !account.ChangeableAt.Equal(now.Add(Cooldown)) {
+			t.Errorf("unexpected account: %+v", account)
+		}
+		if resolved.UserID != "user-1" || resolved.Redirect {
+			t.Errorf("unexpected resolution: %+v", resolved)
+		}
+	})
+
+	t.Run("rejects a handle another user holds", func(t *testing.T) {
+		// Arrange
+		r := New(store.NewMemoryStore())
+		r.Claim(ctx, "user-1", "lifter", now)
+
+		// Act
+		_, err := r.Claim(ctx, "user-2", "lifter", now)
+		available := r.Available(ctx, "user-2", "lifter", now)
+
+		// Assert
+		if !errors.Is(err, ErrTaken) || !errors.Is(available, ErrTaken) {
+			t.Errorf("expected ErrTaken, got %v and %v", err, available)
+		}
+	})
+
+	t.Run("refuses a change during the cooldown", func(t *testing.T) {
+		// Arrange
+		r := New(store.NewMemoryStore())
+		r.Claim(ctx, "user-1", "lifter", now)
+
+		// Act
+		_, err := r.Claim(ctx, "user-1", "squatter", now.Add(Cooldown-time.Hour))
+
+		// Assert
+		if !errors.Is(err, ErrCooldown) {
+			t.Errorf("expected ErrCooldown, got %v", err)
+		}
+	})
+
+	t.Run("redirects a replaced handle until it expires", func(t *testing.T) {
+		// Arrange
+		r := New(store.NewMemoryStore())
+		r.Claim(ctx, "user-1", "lifter", now)
+		changed := now.Add(Cooldown)
+		r.Claim(ctx, "user-1", "squatter", changed)
+
+		// Act
+		redirect, err := r.Resolve(ctx, "lifter", changed)
+		_, takenErr := r.Claim(ctx, "user-2", "lifter", changed)
+		_, expiredErr := r.Resolve(ctx, "lifter", changed.Add(RedirectPeriod))
+		_, claimErr := r.Claim(ctx, "user-2", "lifter", changed.Add(RedirectPeriod))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !redirect.Redirect || redirect.Handle != "squatter" || redirect.UserID != "user-1" {
+			t.Errorf("unexpected resolution: %+v", redirect)
+		}
+		if !errors.Is(takenErr, ErrTaken) {
+			t.Errorf("expected the redirect to hold the handle, got %v", takenErr)
+		}
+		if !errors.Is(expiredErr, store.ErrNotFound) || claimErr != nil {
+			t.Errorf("expected the expired handle to be free, got %v and %v", expiredErr, claimErr)
+		}
+	})
+
+	t.Run("gives an expired handle to only one of two racing users", func(t *testing.T) {
+		// Arrange
+		s := &racingStore{MemoryStore: store.NewMemoryStore()}
+		r := New(s)
+		r.Claim(ctx, "user-1", "lifter", now)
+		changed := now.Add(Cooldown)
+		r.Claim(ctx, "user-1", "squatter", changed)
+		expired := changed.Add(RedirectPeriod)
+		s.onDelete = func() {
+			New(s.MemoryStore).Claim(ctx, "user-3", "lifter", expired)
+		}
+
+		// Act
+		_, err := r.Claim(ctx, "user-2", "lifter", expired)
+		resolved, resolveErr := r.Resolve(ctx, "lifter", expired)
+
+		// Assert
+		if !errors.Is(err, ErrTaken) {
+			t.Errorf("expected the loser to find the handle taken, got %v", err)
+		}
+		if resolveErr != nil || resolved.UserID != "user-3" {
+			t.Errorf("expected the winner to keep the handle, got %+v (%v)", resolved, resolveErr)
+		}
+	})
+
+	t.Run("lets a user take back their replaced handle", func(t *testing.T) {
+		// Arrange
+		r := New(store.NewMemoryStore())
+		r.Claim(ctx, "user-1", "lifter", now)
+		r.Claim(ctx, "user-1", "squatter", now.Add(Cooldown))
+
+		// Act
+		account, err := r.Claim(ctx, "user-1", "lifter", now.Add(2*Cooldown))
+		resolved, _ := r.Resolve(ctx, "lifter", now.Add(2*Cooldown))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if account.Handle != "lifter" || resolved.Redirect {
+			t.Errorf("unexpected account %+v and resolution %+v", account, resolved)
+		}
+	})
+}
+
+// racingStore runs onDelete once, after the first delete, to let another
+// claim land between releasing an expired handle and creating it again
+type racingStore struct {
+	*store.MemoryStore
+	onDelete func()
+}
+
+func (s *racingStore) Delete(ctx context.Context, pk, sk string) error {
+	err := s.MemoryStore.Delete(ctx, pk, sk)
+	if s.onDelete != nil {
+		race := s.onDelete
+		s.onDelete = nil
+		race()
+	}
+	return err
+}
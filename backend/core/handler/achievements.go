@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"athlete-forge/achievement"
+	"athlete-forge/events"
+	"athlete-forge/i18n"
+	"athlete-forge/workout"
+)
+
+// AchievementResponse is an achievement and whether the user has earned it
+type AchievementResponse struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Earned      bool       `json:"earned"`
+	AwardedAt   *time.Time `json:"awardedAt,omitempty"`
+}
+
+// handleListAchievements lists every achievement, earned ones with when they
+// were awarded
+func (h *LambdaHandler) handleListAchievements(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	awards, err := h.achievements.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	awarded := map[string]time.Time{}
+	for _, a := range awards {
+		awarded[a.ID] = a.AwardedAt
+	}
+
+	response := make([]AchievementResponse, 0, len(achievement.Rules))
+	for _, rule := range achievement.Rules {
+		item := AchievementResponse{ID: rule.ID, Name: i18n.T(locale(ctx), rule.Name), Description: i18n.T(locale(ctx), rule.Description)}
+		if at, ok := awarded[rule.ID]; ok {
+			item.Earned = true
+			item.AwardedAt = &at
+		}
+		response = append(response, item)
+	}
+	return h.createJSONResponse(200, response)
+}
+
+// awardAchievements grants the achievements published earns the user and
+// returns an AchievementUnlocked event for each newly granted one. Achievements
+// already held are skipped, so replayed events never award twice; failures are
+// logged rather than failing the request that caused the events
+func (h *LambdaHandler) awardAchievements(ctx context.Context, userID string, published []events.Event, history []workout.Workout) []events.Event {
+	logFailure := func(err error, msg string) {
+		h.logger.Warn().
+			Err(err).
+			Str("user_id", userID).
+			Msg(msg)
+	}
+	awards, err := h.achievements.List(ctx, userID)
+	if err != nil {
+		logFailure(err, "Failed to load achievements")
+		return nil
+	}
+	held := map[string]bool{}
+	for _, a := range awards {
+		held[a.ID] = true
+	}
+	p, err := h.profiles.Get(ctx, userID)
+	if err != nil {
+		logFailure(err, "Failed to load profile for achievements")
+		return nil
+	}
+
+	var unlocked []events.Event
+	for _, a := range achievement.Evaluate(published, achievement.History{Workouts: history, Unit: p.Unit}, held) {
+		granted, err := h.achievements.Grant(ctx, a)
+		if err != nil {
+			logFailure(err, "Failed to grant achievement")
+			continue
+		}
+		if !granted {
+			continue
+		}
+		rule, _ := achievement.Find(a.ID)
+		unlocked = append(unlocked, events.AchievementUnlocked(userID, a.ID, rule.Name, a.EventID, a.AwardedAt))
+	}
+	return unlocked
+}
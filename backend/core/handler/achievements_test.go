@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"athlete-forge/achievement"
+	"athlete-forge/events"
+)
+
+func TestLambdaHandler_Achievements(t *testing.T) {
+	ctx := context.Background()
+	publisher := &recordingPublisher{}
+	h := NewLambdaHandler(zerolog.Nop(), WithEventPublisher(publisher))
+	heavy := `{"status":"completed","exercises":[{"name":"Squat","sets":[{"reps":10,"weight":120}]}]}`
+
+	t.Run("completions award each achievement once", func(t *testing.T) {
+		// Act
+		createWorkout(t, h, "user-1", heavy)
+		createWorkout(t, h, "user-1", heavy)
+
+		// Assert
+		unlocked := map[string]int{}
+		for _, e := range publisher.events {
+			if e.Type == events.TypeAchievementUnlocked {
+				unlocked[e.Data.(events.AchievementUnlockedData).AchievementID]++
+			}
+		}
+		if len(unlocked) != 2 || unlocked[achievement.FirstWorkout] != 1 || unlocked[achievement.TonneSession] != 1 {
+			t.Errorf("expected the first workout and tonne session once each, got %v", unlocked)
+		}
+	})
+
+	t.Run("lists earned and locked achievements", func(t *testing.T) {
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("GET", "/api/achievements", "user-1", nil, ""))
+
+		// Assert
+		if err != nil || response.StatusCode != 200 {
+			t.Fatalf("expected 200, got %d (%v)", response.StatusCode, err)
+		}
+		var got []AchievementResponse
+		json.Unmarshal([]byte(response.Body), &got)
+		if len(got) != len(achievement.Rules) {
+			t.Fatalf("expected every achievement, got %d", len(got))
+		}
+		for _, a := range got {
+			earned := a.ID == achievement.FirstWorkout || a.ID == achievement.TonneSession
+			if a.Earned != earned || (a.AwardedAt != nil) != earned {
+				t.Errorf("unexpected status for %s: %+v", a.ID, a)
+			}
+		}
+	})
+}
@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"athlete-forge/cardio"
+	"athlete-forge/store"
+)
+
+// handleListActivities returns the user's cardio activities
+func (h *LambdaHandler) handleListActivities(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	activities, err := h.activities.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, activities)
+}
+
+// handleGetActivity returns an activity with its time-in-zone summary when the user has zones configured
+func (h *LambdaHandler) handleGetActivity(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	a, err := h.activities.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Activity not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	p, err := h.profiles.Get(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	if p.HeartRate != nil {
+		summary := a.Summarize(*p.HeartRate)
+		a.Zones = &summary
+	}
+	return h.createJSONResponse(200, a)
+}
+
+// handleCreateActivity imports a cardio activity, storing any GPS track sent
+// with it. Duplicates of stored activities are kept unless ?duplicates= says
+// otherwise
+func (h *LambdaHandler) handleCreateActivity(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	strategy, err := cardio.ParseDuplicateStrategy(event.QueryStringParameters["duplicates"], cardio.DuplicateKeepBoth)
+	if err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	var a cardio.Activity
+	if err := decodeBody(event, &a); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	a.ID = ""
+	a.UserID = userID
+	a.Zones = nil
+	a.Run = nil
+	a.Ride = nil
+	a.Swim = nil
+	a.Route = nil
+	if a.Source == "" {
+		a.Source = "manual"
+	}
+
+	if err := a.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	return h.importActivity(ctx, &a, strategy)
+}
+
+// handleImportFITActivity imports a cardio activity from a FIT activity file,
+// sent as the raw or base64-encoded body. Duplicates of stored activities are
+// skipped unless ?duplicates= says otherwise
+func (h *LambdaHandler) handleImportFITActivity(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	strategy, err := cardio.ParseDuplicateStrategy(event.QueryStringParameters["duplicates"], cardio.DuplicateSkip)
+	if err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	data := []byte(event.Body)
+	if event.IsBase64Encoded {
+		if data, err = base64.StdEncoding.DecodeString(event.Body); err != nil {
+			return h.createErrorResponse(400, "Request body is not valid base64"), nil
+		}
+	}
+	a, err := cardio.FromFIT(data)
+	if err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	a.UserID = userID
+	a.Source = "fit"
+
+	if err := a.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	return h.importActivity(ctx, a, strategy)
+}
+
+// importActivity saves a validated import, resolving a duplicate of a stored
+// activity with strategy. It returns 201 with the new activity, or 200 with
+// the stored activity it was skipped for or replaced, which keeps its ID
+func (h *LambdaHandler) importActivity(ctx context.Context, a *cardio.Activity, strategy string) (Response, error) {
+	status := 201
+	if strategy != cardio.DuplicateKeepBoth {
+		existing, err := h.activities.List(ctx, a.UserID)
+		if err != nil {
+			return Response{}, err
+		}
+		if duplicate := cardio.FindDuplicate(existing, a); duplicate != nil {
+			if strategy == cardio.DuplicateSkip {
+				return h.createJSONResponse(200, duplicate)
+			}
+			a.ID = duplicate.ID
+			status = 200
+		}
+	}
+	if err := h.saveImportedActivity(ctx, a); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(status, a)
+}
+
+// saveImportedActivity derives a validated import's heart rate stats and
+// summaries, stores its track and saves it
+func (h *LambdaHandler) saveImportedActivity(ctx context.Context, a *cardio.Activity) error {
+	a.FillHeartRateStats()
+	if err := h.summarizeSamples(ctx, a); err != nil {
+		return err
+	}
+	if err := h.storeTrack(ctx, a); err != nil {
+		return err
+	}
+	if err := h.activities.Save(ctx, a); err != nil {
+		return err
+	}
+	return h.touchUser(ctx, a.UserID, time.Now())
+}
+
+// summarizeSamples analyses a run's samples with the user's running zones, a
+// ride's power with their FTP and a pool swim's lengths, so the stored summary
+// reflects the profile at import
+func (h *LambdaHandler) summarizeSamples(ctx context.Context, a *cardio.Activity) error {
+	a.Swim = a.SummarizeSwim()
+	if a.Swim != nil && a.DistanceMeters == 0 {
+		a.DistanceMeters = a.Swim.DistanceMeters
+	}
+	if a.Sport != cardio.SportRun && a.Sport != cardio.SportRide {
+		return nil
+	}
+	p, err := h.profiles.Get(ctx, a.UserID)
+	if err != nil {
+		return err
+	}
+	a.Run = a.SummarizeRun(p.Running)
+	a.Ride = a.SummarizeRide(p.FTP)
+	return nil
+}
+
+// handleWeeklyCardio returns cardio totals, zone load and run totals for the
+// week containing ?week= (default this week)
+func (h *LambdaHandler) handleWeeklyCardio(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	day := time.Now().UTC()
+	if raw := event.QueryStringParameters["week"]; raw != "" {
+		parsed, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return h.createErrorResponse(400, "week must be a date in YYYY-MM-DD format"), nil
+		}
+		day = parsed
+	}
+
+	activities, err := h.activities.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	p, err := h.profiles.Get(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+
+	return h.createJSONResponse(200, cardio.Weekly(activities, cardio.WeekStart(day), p.HeartRate))
+}
@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"athlete-forge/cardio"
+	"athlete-forge/fit"
+)
+
+const runActivityBody = `{
+	"sport": "run",
+	"startTime": "2024-03-05T07:00:00Z",
+	"durationSeconds": 1800,
+	"distanceMeters": 5000,
+	"heartRate": [{"offset": 0, "bpm": 125}, {"offset": 600, "bpm": 145}, {"offset": 1200, "bpm": 165}]
+}`
+
+// createActivity imports an activity through the API and returns it
+func createActivity(t *testing.T, h *LambdaHandler, userID, body string) cardio.Activity {
+	t.Helper()
+	response, err := h.HandleRequest(context.Background(), apiEvent("POST", "/api/activities", userID, nil, body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.StatusCode != 201 {
+		t.Fatalf("expected status code 201, got %d: %s", response.StatusCode, response.Body)
+	}
+	var a cardio.Activity
+	json.Unmarshal([]byte(response.Body), &a)
+	return a
+}
+
+func TestLambdaHandler_Activities(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("import derives heart rate stats", func(t *testing.T) {
+		// Act
+		a := createActivity(t, newTestHandler(), "user-1", runActivityBody)
+
+		// Assert
+		if a.AverageHR != 145 || a.MaxHR != 165 || a.Source != "manual" {
+			t.Errorf("unexpected activity: %+v", a)
+		}
+	})
+
+	t.Run("detail includes zones when configured in the profile", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/profile", "user-1", nil,
+			`{"unit":"kg","barWeight":20,"heartRate":{"method":"max","maxHr":200}}`))
+		a := createActivity(t, h, "user-1", runActivityBody)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/activities/"+a.ID, "user-1", nil, ""))
+
+		// Assert
+		var detail cardio.Activity
+		json.Unmarshal([]byte(response.Body), &detail)
+		if detail.Zones == nil {
+			t.Fatal("expected zone summary")
+		}
+		if detail.Zones.Zones[1].Seconds != 600 || detail.Zones.Zones[2].Seconds != 600 || detail.Zones.Zones[3].Seconds != 600 {
+			t.Errorf("unexpected zones: %+v", detail.Zones.Zones)
+		}
+	})
+
+	t.Run("detail omits zones without configuration", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		a := createActivity(t, h, "user-1", runActivityBody)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/activities/"+a.ID, "user-1", nil, ""))
+
+		// Assert
+		var detail cardio.Activity
+		json.Unmarshal([]byte(response.Body), &detail)
+		if detail.Zones != nil {
+			t.Errorf("expected no zones, got %+v", detail.Zones)
+		}
+	})
+
+	t.Run("import summarizes run samples against the profile's running zones", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/profile", "user-1", nil,
+			`{"unit":"kg","barWeight":20,"running":{"thresholdPace":300}}`))
+		body := `{
+			"sport": "run",
+			"startTime": "2024-03-05T07:00:00Z",
+			"durationSeconds": 600,
+			"distanceMeters": 2500,
+			"samples": [{"offset": 0, "distance": 0}, {"offset": 300, "distance": 1250}, {"offset": 600, "distance": 2500}]
+		}`
+
+		// Act
+		a := createActivity(t, h, "user-1", body)
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/stats/cardio/weekly", "user-1", map[string]string{"week": "2024-03-05"}, ""))
+
+		// Assert
+		if a.Run == nil || a.Run.AveragePace != 240 || a.Run.PaceZones == nil || a.Run.Load != 50 {
+			t.Fatalf("unexpected run summary: %+v", a.Run)
+		}
+		var weekly cardio.WeeklySummary
+		json.Unmarshal([]byte(response.Body), &weekly)
+		if weekly.Run == nil || weekly.Run.DistanceMeters != 2500 || weekly.Run.Load != 50 {
+			t.Errorf("unexpected weekly summary: %+v", weekly)
+		}
+	})
+
+	t.Run("import summarizes pool swim lengths", func(t *testing.T) {
+		// Arrange
+		body := `{
+			"sport": "swim",
+			"startTime": "2024-03-06T06:30:00Z",
+			"durationSeconds": 120,
+			"poolLengthMeters": 25,
+			"lengths": [
+				{"offset": 0, "seconds": 20, "stroke": "freestyle", "strokes": 15},
+				{"offset": 20, "seconds": 40, "rest": true},
+				{"offset": 60, "seconds": 25, "stroke": "breaststroke", "strokes": 9}
+			]
+		}`
+
+		// Act
+		a := createActivity(t, newTestHandler(), "user-1", body)
+
+		// Assert
+		if a.Swim == nil || a.Swim.Lengths != 2 || a.Swim.PacePer100m != 90 || a.Swim.AverageSWOLF != 34.5 || a.DistanceMeters != 50 {
+			t.Errorf("unexpected swim: %+v, distance %v", a.Swim, a.DistanceMeters)
+		}
+	})
+
+	t.Run("FIT import rejects files without an activity", func(t *testing.T) {
+		// Arrange
+		file := fit.Encode(fit.Workout{Name: "Swim", Sport: fit.SportSwimming, Steps: []fit.Step{{DurationType: fit.DurationOpen}}})
+		event := apiEvent("POST", "/api/activities/fit", "user-1", nil, base64.StdEncoding.EncodeToString(file))
+		event["isBase64Encoded"] = true
+
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, event)
+
+		// Assert
+		if response.StatusCode != 400 || !strings.Contains(response.Body, "no activity session") {
+			t.Errorf("expected status code 400, got %d: %s", response.StatusCode, response.Body)
+		}
+	})
+
+	t.Run("import resolves duplicates with the requested strategy", func(t *testing.T) {
+		// Arrange
+		duplicate := `{"sport": "run", "source": "strava", "startTime": "2024-03-05T07:01:30Z", "durationSeconds": 1815, "distanceMeters": 5040}`
+		tests := []struct {
+			strategy   string
+			wantStatus int
+			wantCount  int
+			wantSource string
+		}{
+			{"skip", 200, 1, "manual"},
+			{"replace", 200, 1, "strava"},
+			{"keep-both", 201, 2, "manual"},
+			{"", 201, 2, "manual"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.strategy, func(t *testing.T) {
+				h := newTestHandler()
+				original := createActivity(t, h, "user-1", runActivityBody)
+				query := map[string]string{}
+				if tt.strategy != "" {
+					query["duplicates"] = tt.strategy
+				}
+
+				// Act
+				response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/activities", "user-1", query, duplicate))
+
+				// Assert
+				if response.StatusCode != tt.wantStatus {
+					t.Fatalf("expected status code %d, got %d: %s", tt.wantStatus, response.StatusCode, response.Body)
+				}
+				list, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/activities", "user-1", nil, ""))
+				var activities []cardio.Activity
+				json.Unmarshal([]byte(list.Body), &activities)
+				if len(activities) != tt.wantCount {
+					t.Fatalf("expected %d activities, got %d", tt.wantCount, len(activities))
+				}
+				stored, _ := h.activities.Get(ctx, "user-1", original.ID)
+				if stored.Source != tt.wantSource {
+					t.Errorf("expected the original's ID to hold a %s activity, got %+v", tt.wantSource, stored)
+				}
+			})
+		}
+	})
+
+	t.Run("import rejects unknown duplicate strategies", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("POST", "/api/activities", "user-1", map[string]string{"duplicates": "merge"}, runActivityBody))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d: %s", response.StatusCode, response.Body)
+		}
+	})
+
+	t.Run("weekly cardio sums the week's load", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/profile", "user-1", nil,
+			`{"unit":"kg","barWeight":20,"heartRate":{"method":"max","maxHr":200}}`))
+		createActivity(t, h, "user-1", runActivityBody)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/stats/cardio/weekly", "user-1", map[string]string{"week": "2024-03-07"}, ""))
+
+		// Assert
+		var weekly cardio.WeeklySummary
+		json.Unmarshal([]byte(response.Body), &weekly)
+		if weekly.WeekStart != "2024-03-04" || weekly.Activities != 1 || weekly.Zones == nil || weekly.Zones.Load != 90 {
+			t.Errorf("unexpected weekly summary: %+v", weekly)
+		}
+	})
+
+	t.Run("rejects invalid activities", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("POST", "/api/activities", "user-1", nil, `{"sport":"run"}`))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+)
+
+// handleRunJob runs a scheduled job on demand, such as re-running weekly reports
+// after a failed night
+func (h *LambdaHandler) handleRunJob(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	// Per-user jobs such as export rendering are dispatched by their own endpoints
+	job := event.PathParameters["job"]
+	if job != JobWeeklyReports && job != JobRotateProfileKeys && job != JobMigrateItems && job != JobExportWarehouse && job != JobAggregatePercentiles && job != JobPurgeMessages && job != JobRankExercises && job != JobFinishSessions {
+		return h.createErrorResponse(400, fmt.Sprintf("job %q cannot be run on demand", job)), nil
+	}
+	h.logger.Info().
+		Str("function", "handleRunJob").
+		Str("user_id", userID).
+		Str("job", job).
+		Msg("Job run requested")
+
+	return h.runJob(ctx, JobEvent{Job: job})
+}
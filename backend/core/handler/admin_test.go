@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"athlete-forge/auth"
+	"github.com/rs/zerolog"
+)
+
+// cognitoEvent builds an API event authenticated by Cognito with extra claims
+func cognitoEvent(method, path, userID string, claims map[string]interface{}) map[string]interface{} {
+	event := apiEvent(method, path, userID, nil, "")
+	all := map[string]interface{}{"sub": userID}
+	for k, v := range claims {
+		all[k] = v
+	}
+	event["requestContext"] = map[string]interface{}{"authorizer": map[string]interface{}{"claims": all}}
+	return event
+}
+
+func TestLambdaHandler_Scopes(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		event       map[string]interface{}
+		wantStatus  int
+		wantMissing string
+	}{
+		{name: "anonymous", event: apiEvent("GET", "/api/workouts", "", nil, ""), wantStatus: 401},
+		{name: "default scopes read", event: apiEvent("GET", "/api/workouts", "user-1", nil, ""), wantStatus: 200},
+		{name: "read-only token writes", event: cognitoEvent("POST", "/api/calendar/reset", "user-1", map[string]interface{}{"scope": "workouts:read"}), wantStatus: 403, wantMissing: auth.ScopeWorkoutsWrite},
+		{name: "read-only token sends telemetry", event: cognitoEvent("POST", "/api/telemetry", "user-1", map[string]interface{}{"scope": "workouts:read"}), wantStatus: 403, wantMissing: auth.ScopeWorkoutsWrite},
+		{name: "user runs admin job", event: apiEvent("POST", "/api/admin/jobs/weekly-reports", "user-1", nil, ""), wantStatus: 403, wantMissing: auth.ScopeAdmin},
+		{name: "admin group runs admin job", event: cognitoEvent("POST", "/api/admin/jobs/weekly-reports", "user-1", map[string]interface{}{"cognito:groups": "[coach admin]"}), wantStatus: 200},
+		{name: "admin rotates profile keys", event: cognitoEvent("POST", "/api/admin/jobs/rotate-profile-keys", "user-1", map[string]interface{}{"cognito:groups": "admin"}), wantStatus: 200},
+		{name: "admin runs per-user job", event: cognitoEvent("POST", "/api/admin/jobs/render-export", "user-1", map[string]interface{}{"cognito:groups": "admin"}), wantStatus: 400},
+		{name: "unscoped route", event: apiEvent("GET", "/api/tools/plates", "", map[string]string{"weight": "100"}, ""), wantStatus: 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			response, err := newTestHandler().HandleRequest(ctx, tt.event)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if response.StatusCode != tt.wantStatus {
+				t.Fatalf("expected status code %d, got %d: %s", tt.wantStatus, response.StatusCode, response.Body)
+			}
+			if tt.wantMissing != "" {
+				var body map[string]interface{}
+				json.Unmarshal([]byte(response.Body), &body)
+				if body["error"] != "insufficient_scope" || body["missingScope"] != tt.wantMissing {
+					t.Errorf("unexpected error body: %s", response.Body)
+				}
+				if response.Headers["WWW-Authenticate"] == "" {
+					t.Error("expected a WWW-Authenticate header")
+				}
+			}
+		})
+	}
+
+	t.Run("session tokens carry the account's scopes", func(t *testing.T) {
+		// Arrange
+		h := NewLambdaHandler(zerolog.Nop(), WithAuthProvider(stubProvider{}), WithSessionSecret([]byte("secret")))
+		session := signIn(t, h, "sam")
+
+		// Act
+		userResponse, _ := h.HandleRequest(ctx, withBearer(apiEvent("POST", "/api/admin/jobs/weekly-reports", "", nil, ""), session.Token))
+		user, _ := h.accounts.Get(ctx, session.User.ID)
+		user.Scopes = []string{auth.ScopeAdmin}
+		h.accounts.Save(ctx, user)
+		_, refreshed := refreshTokens(t, h, session.RefreshToken)
+		adminResponse, _ := h.HandleRequest(ctx, withBearer(apiEvent("POST", "/api/admin/jobs/weekly-reports", "", nil, ""), refreshed.Token))
+
+		// Assert
+		if userResponse.StatusCode != 403 {
+			t.Errorf("expected status code 403 before the grant, got %d", userResponse.StatusCode)
+		}
+		if adminResponse.StatusCode != 200 {
+			t.Errorf("expected refreshed token to carry admin, got %d: %s", adminResponse.StatusCode, adminResponse.Body)
+		}
+	})
+}
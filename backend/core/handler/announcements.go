@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"athlete-forge/announcement"
+	"athlete-forge/store"
+)
+
+// UserAnnouncement is a live announcement and when the user read it, if they
+// have
+type UserAnnouncement struct {
+	announcement.Announcement
+	ReadAt *time.Time `json:"readAt,omitempty"`
+}
+
+// AnnouncementsResponse is the user's announcement feed, newest first
+type AnnouncementsResponse struct {
+	Unread        int                `json:"unread"`
+	Announcements []UserAnnouncement `json:"announcements"`
+}
+
+// handleListAnnouncements returns the live announcements, marking the ones the
+// user has read
+func (h *LambdaHandler) handleListAnnouncements(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	live, err := h.announcements.Live(ctx, time.Now().UTC())
+	if err != nil {
+		return Response{}, err
+	}
+	reads, err := h.announcements.Read(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+
+	response := AnnouncementsResponse{Announcements: make([]UserAnnouncement, 0, len(live))}
+	for _, a := range live {
+		entry := UserAnnouncement{Announcement: a}
+		if readAt, ok := reads[a.ID]; ok {
+			entry.ReadAt = &readAt
+		} else {
+			response.Unread++
+		}
+		response.Announcements = append(response.Announcements, entry)
+	}
+	return h.createJSONResponse(200, response)
+}
+
+// handleReadAnnouncement marks a live announcement read for the user
+func (h *LambdaHandler) handleReadAnnouncement(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	now := time.Now().UTC()
+	a, err := h.announcements.Get(ctx, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) || (err == nil && !a.Live(now)) {
+		return h.createErrorResponse(404, "Announcement not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	readAt, err := h.announcements.MarkRead(ctx, userID, a.ID, now)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, UserAnnouncement{Announcement: *a, ReadAt: &readAt})
+}
+
+// handleAdminListAnnouncements returns every announcement, scheduled and
+// expired ones included
+func (h *LambdaHandler) handleAdminListAnnouncements(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	if _, errResponse := h.requireUser(event); errResponse != nil {
+		return *errResponse, nil
+	}
+
+	announcements, err := h.announcements.List(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, announcements)
+}
+
+// handleCreateAnnouncement publishes or schedules an announcement
+func (h *LambdaHandler) handleCreateAnnouncement(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var a announcement.Announcement
+	if err := decodeBody(event, &a); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	a.ID = ""
+	if err := a.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if err := h.announcements.Save(ctx, &a, time.Now().UTC()); err != nil {
+		return Response{}, err
+	}
+
+	h.logger.Info().
+		Str("function", "handleCreateAnnouncement").
+		Str("user_id", userID).
+		Str("announcement_id", a.ID).
+		Msg("Announcement created")
+
+	return h.createJSONResponse(201, a)
+}
+
+// handleUpdateAnnouncement replaces an announcement. Users who read it keep
+// it marked read, so a correction is not shown as new
+func (h *LambdaHandler) handleUpdateAnnouncement(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	existing, err := h.announcements.Get(ctx, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Announcement not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	var a announcement.Announcement
+	if err := decodeBody(event, &a); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	a.ID, a.CreatedAt = existing.ID, existing.CreatedAt
+	if err := a.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if err := h.announcements.Save(ctx, &a, time.Now().UTC()); err != nil {
+		return Response{}, err
+	}
+
+	h.logger.Info().
+		Str("function", "handleUpdateAnnouncement").
+		Str("user_id", userID).
+		Str("announcement_id", a.ID).
+		Msg("Announcement updated")
+
+	return h.createJSONResponse(200, a)
+}
+
+// handleDeleteAnnouncement withdraws an announcement
+func (h *LambdaHandler) handleDeleteAnnouncement(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	if _, errResponse := h.requireUser(event); errResponse != nil {
+		return *errResponse, nil
+	}
+
+	a, err := h.announcements.Get(ctx, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Announcement not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	if err := h.announcements.Delete(ctx, a.ID); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, a)
+}
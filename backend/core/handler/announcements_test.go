@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestLambdaHandler_Announcements(t *testing.T) {
+	ctx := context.Background()
+	admin := map[string]interface{}{"cognito:groups": "admin"}
+
+	// create publishes an announcement as an admin, returning its ID
+	create := func(t *testing.T, h *LambdaHandler, body string) string {
+		t.Helper()
+		event := cognitoEvent("POST", "/api/admin/announcements", "ops", admin)
+		event["body"] = body
+		response, _ := h.HandleRequest(ctx, event)
+		if response.StatusCode != 201 {
+			t.Fatalf("expected status code 201, got %d: %s", response.StatusCode, response.Body)
+		}
+		var created struct {
+			ID string `json:"id"`
+		}
+		json.Unmarshal([]byte(response.Body), &created)
+		return created.ID
+	}
+	published := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+
+	t.Run("tracks which announcements each user has read", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		id := create(t, h, `{"kind":"release","title":"2.4","body":"Rest timers sync across devices","publishedAt":"`+published+`"}`)
+		create(t, h, `{"kind":"maintenance","title":"Downtime","publishedAt":"`+published+`"}`)
+
+		// Act
+		read, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/announcements/"+id+"/read", "user-1", nil, ""))
+		feed, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/announcements", "user-1", nil, ""))
+		other, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/announcements", "user-2", nil, ""))
+
+		// Assert
+		if read.StatusCode != 200 || feed.StatusCode != 200 {
+			t.Fatalf("unexpected responses: %d %s and %d %s", read.StatusCode, read.Body, feed.StatusCode, feed.Body)
+		}
+		var mine, theirs AnnouncementsResponse
+		json.Unmarshal([]byte(feed.Body), &mine)
+		json.Unmarshal([]byte(other.Body), &theirs)
+		if mine.Unread != 1 || len(mine.Announcements) != 2 || theirs.Unread != 2 {
+			t.Errorf("expected 1 and 2 unread, got %+v and %+v", mine, theirs)
+		}
+	})
+
+	t.Run("hides scheduled announcements until they are published", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		scheduled := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		id := create(t, h, `{"kind":"notice","title":"New programs","publishedAt":"`+scheduled+`"}`)
+
+		// Act
+		feed, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/announcements", "user-1", nil, ""))
+		read, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/announcements/"+id+"/read", "user-1", nil, ""))
+
+		// Assert
+		var response AnnouncementsResponse
+		json.Unmarshal([]byte(feed.Body), &response)
+		if len(response.Announcements) != 0 || read.StatusCode != 404 {
+			t.Errorf("expected the scheduled announcement hidden, got %s and %d", feed.Body, read.StatusCode)
+		}
+	})
+
+	t.Run("only admins manage announcements", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("POST", "/api/admin/announcements", "user-1", nil, `{"kind":"notice","title":"Hi","publishedAt":"`+published+`"}`))
+
+		// Assert
+		if response.StatusCode != 403 {
+			t.Errorf("expected status code 403, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("rejects invalid announcements", func(t *testing.T) {
+		// Arrange
+		event := cognitoEvent("POST", "/api/admin/announcements", "ops", admin)
+		event["body"] = `{"kind":"promo","title":"Sale","publishedAt":"` + published + `"}`
+
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, event)
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,414 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"athlete-forge/auth"
+	"athlete-forge/store"
+)
+
+// TokenResponse carries a session token and the refresh token that renews it
+type TokenResponse struct {
+	Token            string    `json:"token"`
+	TokenType        string    `json:"tokenType"`
+	ExpiresAt        time.Time `json:"expiresAt"`
+	RefreshToken     string    `json:"refreshToken"`
+	RefreshExpiresAt time.Time `json:"refreshExpiresAt"`
+	SessionID        string    `json:"sessionId"`
+	Scope            string    `json:"scope"`
+}
+
+// SignInResponse is returned after a provider sign-in
+type SignInResponse struct {
+	TokenResponse
+	Created bool       `json:"created"`
+	User    *auth.User `json:"user"`
+}
+
+// RefreshRequest is the body for renewing a session token
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// SessionInfo describes a signed-in device without its token hashes.
+// OpenConnections is how many realtime connections the device has open
+type SessionInfo struct {
+	ID              string    `json:"id"`
+	Provider        string    `json:"provider,omitempty"`
+	UserAgent       string    `json:"userAgent,omitempty"`
+	CreatedAt       time.Time `json:"createdAt"`
+	LastUsedAt      time.Time `json:"lastUsedAt"`
+	ExpiresAt       time.Time `json:"expiresAt"`
+	Current         bool      `json:"current"`
+	OpenConnections int       `json:"openConnections"`
+}
+
+// ConnectionsResponse is everything with access to the user's account: the
+// devices signed in to it, the identities that sign in to it and the
+// third-party services connected to it, each of which can be revoked
+type ConnectionsResponse struct {
+	Devices      []SessionInfo    `json:"devices"`
+	Identities   []auth.Identity  `json:"identities"`
+	Integrations []ConnectionInfo `json:"integrations"`
+}
+
+// RevokeResponse reports how many sessions were signed out
+type RevokeResponse struct {
+	Revoked int `json:"revoked"`
+}
+
+// handleSignIn exchanges provider credentials for a session token, creating the
+// account on first sign-in
+func (h *LambdaHandler) handleSignIn(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	identity, errResponse, err := h.authenticate(ctx, event)
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	now := time.Now().UTC()
+	user, created, err := h.accounts.SignIn(ctx, *identity, now)
+	if err != nil {
+		return Response{}, err
+	}
+	session, refreshToken, err := h.authSessions.Create(ctx, user.ID, identity.Provider, header(event, "User-Agent"), now)
+	if err != nil {
+		return Response{}, err
+	}
+	tokens, err := h.issueTokens(session, refreshToken, auth.GrantedScopes(user), now)
+	if err != nil {
+		return Response{}, err
+	}
+
+	h.logger.Info().
+		Str("function", "handleSignIn").
+		Str("provider", identity.Provider).
+		Str("user_id", user.ID).
+		Bool("created", created).
+		Msg("User signed in")
+
+	return h.createJSONResponse(200, SignInResponse{TokenResponse: *tokens, Created: created, User: user})
+}
+
+// handleRefresh rotates a refresh token and issues a new session token
+func (h *LambdaHandler) handleRefresh(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	var req RefreshRequest
+	if err := decodeBody(event, &req); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	now := time.Now().UTC()
+	session, refreshToken, err := h.authSessions.Refresh(ctx, req.RefreshToken, now)
+	if errors.Is(err, auth.ErrRefreshTokenReused) {
+		h.logger.Warn().
+			Str("function", "handleRefresh").
+			Msg("Rotated refresh token reused, session revoked")
+		return h.createErrorResponse(401, err.Error()), nil
+	}
+	if errors.Is(err, auth.ErrInvalidRefreshToken) {
+		return h.createErrorResponse(401, err.Error()), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	// Scopes are read from the account on each refresh so grants and removals apply
+	// without signing in again
+	user, err := h.accounts.Get(ctx, session.UserID)
+	if err != nil {
+		return Response{}, err
+	}
+	tokens, err := h.issueTokens(session, refreshToken, auth.GrantedScopes(user), now)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, tokens)
+}
+
+// handleLogout signs out the session the request was made with
+func (h *LambdaHandler) handleLogout(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	if event.session == nil {
+		return h.createErrorResponse(401, "Session token required"), nil
+	}
+	if err := h.authSessions.Revoke(ctx, event.session.Subject, event.session.SessionID); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, RevokeResponse{Revoked: 1})
+}
+
+// handleLogoutAll signs out every session of the user, including the current one
+func (h *LambdaHandler) handleLogoutAll(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	revoked, err := h.authSessions.RevokeAll(ctx, userID, time.Now().UTC())
+	if err != nil {
+		return Response{}, err
+	}
+
+	h.logger.Info().
+		Str("function", "handleLogoutAll").
+		Str("user_id", userID).
+		Int("revoked", revoked).
+		Msg("All sessions revoked")
+
+	return h.createJSONResponse(200, RevokeResponse{Revoked: revoked})
+}
+
+// handleListSessions returns the user's signed-in devices
+func (h *LambdaHandler) handleListSessions(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	infos, err := h.sessionInfos(ctx, event, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, infos)
+}
+
+// handleRevokeSession signs out one of the user's devices
+func (h *LambdaHandler) handleRevokeSession(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	id := event.PathParameters["id"]
+	if _, err := h.authSessions.Get(ctx, userID, id, time.Now().UTC()); errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Session not found"), nil
+	} else if err != nil {
+		return Response{}, err
+	}
+	if err := h.authSessions.Revoke(ctx, userID, id); err != nil {
+		return Response{}, err
+	}
+	if err := h.closeConnections(ctx, userID, id); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, RevokeResponse{Revoked: 1})
+}
+
+// handleListConnections returns the devices, sign-in identities and
+// integrations with access to the user's account
+func (h *LambdaHandler) handleListConnections(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	response := ConnectionsResponse{Identities: []auth.Identity{}, Integrations: []ConnectionInfo{}}
+	devices, err := h.sessionInfos(ctx, event, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	response.Devices = devices
+
+	user, err := h.accounts.Get(ctx, userID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return Response{}, err
+	}
+	if user != nil {
+		response.Identities = append(response.Identities, user.Identities...)
+	}
+
+	connections, err := h.integrations.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	for _, c := range connections {
+		response.Integrations = append(response.Integrations, connectionInfo(c))
+	}
+	return h.createJSONResponse(200, response)
+}
+
+// handleUnlinkIdentity removes a provider's identity from the signed-in user's
+// account and signs out the devices that signed in with it
+func (h *LambdaHandler) handleUnlinkIdentity(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	provider := event.PathParameters["provider"]
+	user, err := h.accounts.Unlink(ctx, userID, provider)
+	if errors.Is(err, auth.ErrNotLinked) {
+		return h.createErrorResponse(404, err.Error()), nil
+	}
+	if errors.Is(err, auth.ErrLastIdentity) {
+		return h.createErrorResponse(409, err.Error()), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	now := time.Now().UTC()
+	sessions, err := h.authSessions.List(ctx, userID, now)
+	if err != nil {
+		return Response{}, err
+	}
+	revoked := 0
+	for _, s := range sessions {
+		if s.Provider != provider {
+			continue
+		}
+		if err := h.authSessions.Revoke(ctx, userID, s.ID); err != nil {
+			return Response{}, err
+		}
+		if err := h.closeConnections(ctx, userID, s.ID); err != nil {
+			return Response{}, err
+		}
+		revoked++
+	}
+
+	h.logger.Info().
+		Str("function", "handleUnlinkIdentity").
+		Str("user_id", userID).
+		Str("provider", provider).
+		Int("revoked", revoked).
+		Msg("Identity unlinked")
+
+	return h.createJSONResponse(200, user)
+}
+
+// sessionInfos describes the user's signed-in devices, marking the one event
+// was made from
+func (h *LambdaHandler) sessionInfos(ctx context.Context, event *APIGatewayProxyEvent, userID string) ([]SessionInfo, error) {
+	sessions, err := h.authSessions.List(ctx, userID, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	connections, err := h.hub.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	open := map[string]int{}
+	for _, c := range connections {
+		open[c.SessionID]++
+	}
+
+	infos := make([]SessionInfo, 0, len(sessions))
+	for _, s := range sessions {
+		infos = append(infos, SessionInfo{
+			ID:              s.ID,
+			Provider:        s.Provider,
+			UserAgent:       s.UserAgent,
+			CreatedAt:       s.CreatedAt,
+			LastUsedAt:      s.LastUsedAt,
+			ExpiresAt:       s.ExpiresAt,
+			Current:         event.session != nil && event.session.SessionID == s.ID,
+			OpenConnections: open[s.ID],
+		})
+	}
+	return infos, nil
+}
+
+// closeConnections forgets the realtime connections opened with a revoked
+// session, so nothing more is sent to the device
+func (h *LambdaHandler) closeConnections(ctx context.Context, userID, sessionID string) error {
+	connections, err := h.hub.List(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, c := range connections {
+		if c.SessionID == sessionID {
+			if err := h.hub.Disconnect(ctx, c.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// issueTokens signs a session token granting scopes alongside the session's new refresh token
+func (h *LambdaHandler) issueTokens(session *auth.Session, refreshToken string, scopes []string, now time.Time) (*TokenResponse, error) {
+	token, expires, err := h.sessions.Issue(session.UserID, session.ID, scopes, now)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenResponse{
+		Token:            token,
+		TokenType:        "Bearer",
+		ExpiresAt:        expires,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: session.ExpiresAt,
+		SessionID:        session.ID,
+		Scope:            strings.Join(scopes, " "),
+	}, nil
+}
+
+// handleLinkIdentity adds a provider identity to the signed-in user's account
+func (h *LambdaHandler) handleLinkIdentity(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	identity, errResponse, err := h.authenticate(ctx, event)
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	user, err := h.accounts.Link(ctx, userID, *identity, time.Now().UTC())
+	if errors.Is(err, auth.ErrIdentityLinked) {
+		return h.createErrorResponse(409, err.Error()), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, user)
+}
+
+// handleGetAccount returns the signed-in user's account and linked identities
+func (h *LambdaHandler) handleGetAccount(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	user, err := h.accounts.Get(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Account not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, user)
+}
+
+// authenticate verifies the request's credentials with the provider named in the path
+func (h *LambdaHandler) authenticate(ctx context.Context, event *APIGatewayProxyEvent) (*auth.Identity, *Response, error) {
+	provider, ok := h.providers[event.PathParameters["provider"]]
+	if !ok {
+		response := h.createErrorResponse(404, "Unknown sign-in provider")
+		return nil, &response, nil
+	}
+
+	var creds auth.Credentials
+	if err := decodeBody(event, &creds); err != nil {
+		response := h.createErrorResponse(400, err.Error())
+		return nil, &response, nil
+	}
+
+	identity, err := provider.Authenticate(ctx, creds)
+	if errors.Is(err, auth.ErrInvalidCredentials) {
+		response := h.createErrorResponse(401, err.Error())
+		return nil, &response, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return identity, nil, nil
+}
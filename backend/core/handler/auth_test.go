@@ -0,0 +1,370 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"athlete-forge/auth"
+)
+
+// stubProvider accepts the ID token "valid-<subject>" as that subject
+type stubProvider struct{}
+
+func (stubProvider) Name() string { return auth.ProviderGoogle }
+
+func (stubProvider) Authenticate(ctx context.Context, creds auth.Credentials) (*auth.Identity, error) {
+	subject, ok := strings.CutPrefix(creds.IDToken, "valid-")
+	if !ok || subject == "" {
+		return nil, auth.ErrInvalidCredentials
+	}
+	return &auth.Identity{Provider: auth.ProviderGoogle, Subject: subject, Email: subject + "@example.com", EmailVerified: true}, nil
+}
+
+// withBearer adds a session token to an API event
+func withBearer(event map[string]interface{}, token string) map[string]interface{} {
+	event["headers"] = map[string]string{"authorization": "Bearer " + token}
+	return event
+}
+
+// signIn signs in through the stub provider and returns the session
+func signIn(t *testing.T, h *LambdaHandler, subject string) SignInResponse {
+	t.Helper()
+	response, err := h.HandleRequest(context.Background(), apiEvent("POST", "/api/auth/google", "", nil, `{"idToken": "valid-`+subject+`"}`))
+	if err != nil || response.StatusCode != 200 {
+		t.Fatalf("sign-in failed %d: %s (%v)", response.StatusCode, response.Body, err)
+	}
+	var session SignInResponse
+	if err := json.Unmarshal([]byte(response.Body), &session); err != nil {
+		t.Fatalf("failed to parse sign-in JSON: %v", err)
+	}
+	return session
+}
+
+// refreshTokens exchanges a refresh token through the API
+func refreshTokens(t *testing.T, h *LambdaHandler, refreshToken string) (Response, TokenResponse) {
+	t.Helper()
+	response, err := h.HandleRequest(context.Background(), apiEvent("POST", "/api/auth/refresh", "", nil, `{"refreshToken": "`+refreshToken+`"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var tokens TokenResponse
+	json.Unmarshal([]byte(response.Body), &tokens)
+	return response, tokens
+}
+
+func TestLambdaHandler_Auth(t *testing.T) {
+	ctx := context.Background()
+	newHandler := func() *LambdaHandler {
+		return NewLambdaHandler(zerolog.Nop(), WithAuthProvider(stubProvider{}), WithSessionSecret([]byte("secret")))
+	}
+
+	t.Run("session tokens authenticate API requests", func(t *testing.T) {
+		// Arrange
+		h := newHandler()
+		session := signIn(t, h, "sam")
+
+		// Act
+		response, _ := h.HandleRequest(ctx, withBearer(apiEvent("GET", "/api/auth/me", "", nil, ""), session.Token))
+
+		// Assert
+		if !session.Created || session.TokenType != "Bearer" {
+			t.Errorf("unexpected session: %+v", session)
+		}
+		if response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", response.StatusCode, response.Body)
+		}
+		var user auth.User
+		json.Unmarshal([]byte(response.Body), &user)
+		if user.ID != session.User.ID || user.Email != "sam@example.com" {
+			t.Errorf("unexpected account: %+v", user)
+		}
+	})
+
+	t.Run("signing in again reuses the account", func(t *testing.T) {
+		// Arrange
+		h := newHandler()
+		first := signIn(t, h, "sam")
+
+		// Act
+		second := signIn(t, h, "sam")
+
+		// Assert
+		if second.Created || second.User.ID != first.User.ID {
+			t.Errorf("expected the same account, got %+v", second.User)
+		}
+	})
+
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "unknown provider", path: "/api/auth/myspace", body: `{"idToken": "valid-sam"}`, wantStatus: 404},
+		{name: "rejected credentials", path: "/api/auth/google", body: `{"idToken": "forged"}`, wantStatus: 401},
+		{name: "missing body", path: "/api/auth/google", wantStatus: 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			response, _ := newHandler().HandleRequest(ctx, apiEvent("POST", tt.path, "", nil, tt.body))
+
+			// Assert
+			if response.StatusCode != tt.wantStatus {
+				t.Errorf("expected status code %d, got %d: %s", tt.wantStatus, response.StatusCode, response.Body)
+			}
+		})
+	}
+
+	t.Run("rejects tampered session tokens", func(t *testing.T) {
+		// Arrange
+		h := newHandler()
+		session := signIn(t, h, "sam")
+
+		// Act
+		response, _ := h.HandleRequest(ctx, withBearer(apiEvent("GET", "/api/profile", "", nil, ""), session.Token+"x"))
+
+		// Assert
+		if response.StatusCode != 401 {
+			t.Errorf("expected status code 401, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("links an identity to the signed-in user", func(t *testing.T) {
+		// Arrange
+		h := newHandler()
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/auth/google/link", "cognito-user", nil, `{"idToken": "valid-sam"}`))
+		session := signIn(t, h, "sam")
+
+		// Assert
+		if response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", response.StatusCode, response.Body)
+		}
+		if session.User.ID != "cognito-user" {
+			t.Errorf("expected sign-in to reach the linked user, got %+v", session.User)
+		}
+	})
+
+	t.Run("refuses to link an identity owned by another user", func(t *testing.T) {
+		// Arrange
+		h := newHandler()
+		signIn(t, h, "sam")
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/auth/google/link", "cognito-user", nil, `{"idToken": "valid-sam"}`))
+
+		// Assert
+		if response.StatusCode != 409 {
+			t.Errorf("expected status code 409, got %d", response.StatusCode)
+		}
+	})
+}
+
+func TestLambdaHandler_Sessions(t *testing.T) {
+	ctx := context.Background()
+	newHandler := func() *LambdaHandler {
+		return NewLambdaHandler(zerolog.Nop(), WithAuthProvider(stubProvider{}), WithSessionSecret([]byte("secret")))
+	}
+
+	t.Run("refresh rotates the refresh token", func(t *testing.T) {
+		// Arrange
+		h := newHandler()
+		session := signIn(t, h, "sam")
+
+		// Act
+		response, tokens := refreshTokens(t, h, session.RefreshToken)
+		reused, _ := refreshTokens(t, h, session.RefreshToken)
+
+		// Assert
+		if response.StatusCode != 200 || tokens.RefreshToken == session.RefreshToken || tokens.SessionID != session.SessionID {
+			t.Fatalf("unexpected refresh %d: %s", response.StatusCode, response.Body)
+		}
+		if reused.StatusCode != 401 {
+			t.Errorf("expected reused refresh token to be rejected, got %d", reused.StatusCode)
+		}
+		if after, _ := h.HandleRequest(ctx, withBearer(apiEvent("GET", "/api/auth/me", "", nil, ""), tokens.Token)); after.StatusCode != 401 {
+			t.Errorf("expected reuse to revoke the session, got %d", after.StatusCode)
+		}
+	})
+
+	t.Run("logout-all signs out every device", func(t *testing.T) {
+		// Arrange
+		h := newHandler()
+		phone := signIn(t, h, "sam")
+		laptop := signIn(t, h, "sam")
+
+		// Act
+		response, _ := h.HandleRequest(ctx, withBearer(apiEvent("POST", "/api/auth/logout-all", "", nil, ""), laptop.Token))
+
+		// Assert
+		if response.StatusCode != 200 || response.Body != `{"revoked":2}` {
+			t.Fatalf("unexpected response %d: %s", response.StatusCode, response.Body)
+		}
+		if after, _ := h.HandleRequest(ctx, withBearer(apiEvent("GET", "/api/auth/me", "", nil, ""), phone.Token)); after.StatusCode != 401 {
+			t.Errorf("expected the phone's session token to stop working, got %d", after.StatusCode)
+		}
+		if after, _ := refreshTokens(t, h, phone.RefreshToken); after.StatusCode != 401 {
+			t.Errorf("expected the phone's refresh token to stop working, got %d", after.StatusCode)
+		}
+	})
+
+	t.Run("lists sessions and signs out a remote device", func(t *testing.T) {
+		// Arrange
+		h := newHandler()
+		phone := signIn(t, h, "sam")
+		laptop := signIn(t, h, "sam")
+
+		// Act
+		listResponse, _ := h.HandleRequest(ctx, withBearer(apiEvent("GET", "/api/auth/sessions", "", nil, ""), laptop.Token))
+		revokeResponse, _ := h.HandleRequest(ctx, withBearer(apiEvent("DELETE", "/api/auth/sessions/"+phone.SessionID, "", nil, ""), laptop.Token))
+
+		// Assert
+		var sessions []SessionInfo
+		json.Unmarshal([]byte(listResponse.Body), &sessions)
+		if len(sessions) != 2 {
+			t.Fatalf("expected 2 sessions, got %s", listResponse.Body)
+		}
+		for _, s := range sessions {
+			if s.Current != (s.ID == laptop.SessionID) {
+				t.Errorf("unexpected current flag on %+v", s)
+			}
+		}
+		if revokeResponse.StatusCode != 200 {
+			t.Errorf("expected status code 200, got %d", revokeResponse.StatusCode)
+		}
+		if after, _ := h.HandleRequest(ctx, withBearer(apiEvent("GET", "/api/auth/me", "", nil, ""), laptop.Token)); after.StatusCode != 200 {
+			t.Errorf("expected the current session to remain, got %d", after.StatusCode)
+		}
+	})
+
+	t.Run("logout ends only the current session", func(t *testing.T) {
+		// Arrange
+		h := newHandler()
+		phone := signIn(t, h, "sam")
+		laptop := signIn(t, h, "sam")
+
+		// Act
+		h.HandleRequest(ctx, withBearer(apiEvent("POST", "/api/auth/logout", "", nil, ""), phone.Token))
+
+		// Assert
+		if after, _ := h.HandleRequest(ctx, withBearer(apiEvent("GET", "/api/auth/me", "", nil, ""), phone.Token)); after.StatusCode != 401 {
+			t.Errorf("expected the phone to be signed out, got %d", after.StatusCode)
+		}
+		if after, _ := h.HandleRequest(ctx, withBearer(apiEvent("GET", "/api/auth/me", "", nil, ""), laptop.Token)); after.StatusCode != 200 {
+			t.Errorf("expected the laptop to stay signed in, got %d", after.StatusCode)
+		}
+	})
+}
+
+// stubAppleProvider accepts the ID token "valid-<subject>" as that Apple subject
+type stubAppleProvider struct{}
+
+func (stubAppleProvider) Name() string { return auth.ProviderApple }
+
+func (stubAppleProvider) Authenticate(ctx context.Context, creds auth.Credentials) (*auth.Identity, error) {
+	subject, ok := strings.CutPrefix(creds.IDToken, "valid-")
+	if !ok || subject == "" {
+		return nil, auth.ErrInvalidCredentials
+	}
+	return &auth.Identity{Provider: auth.ProviderApple, Subject: subject, Email: subject + "@example.com", EmailVerified: true}, nil
+}
+
+func TestLambdaHandler_Connections(t *testing.T) {
+	ctx := context.Background()
+	newHandler := func() *LambdaHandler {
+		return NewLambdaHandler(zerolog.Nop(), WithAuthProvider(stubProvider{}), WithAuthProvider(stubAppleProvider{}), WithSessionSecret([]byte("secret")), WithIntegrationAdapter(stubGarmin{}))
+	}
+	appleSignIn := func(t *testing.T, h *LambdaHandler, subject string) SignInResponse {
+		t.Helper()
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/auth/apple", "", nil, `{"idToken": "valid-`+subject+`"}`))
+		var session SignInResponse
+		json.Unmarshal([]byte(response.Body), &session)
+		return session
+	}
+
+	t.Run("lists devices, identities and integrations", func(t *testing.T) {
+		// Arrange
+		h := newHandler()
+		phone := signIn(t, h, "sam")
+		h.HandleRequest(ctx, webSocketEventFor("CONNECT", "phone-ws", map[string]string{"token": phone.Token}, ""))
+		h.HandleRequest(ctx, withBearer(apiEvent("PUT", "/api/integrations/garmin", "", nil, `{"accessToken":"token-garmin-1"}`), phone.Token))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, withBearer(apiEvent("GET", "/api/auth/connections", "", nil, ""), phone.Token))
+
+		// Assert
+		var connections ConnectionsResponse
+		json.Unmarshal([]byte(response.Body), &connections)
+		if response.StatusCode != 200 || len(connections.Devices) != 1 || len(connections.Identities) != 1 || len(connections.Integrations) != 1 {
+			t.Fatalf("unexpected connections %d: %s", response.StatusCode, response.Body)
+		}
+		if !connections.Devices[0].Current || connections.Devices[0].OpenConnections != 1 {
+			t.Errorf("expected the current device with its open connection, got %+v", connections.Devices[0])
+		}
+	})
+
+	t.Run("signing out a device closes its realtime connections", func(t *testing.T) {
+		// Arrange
+		h := newHandler()
+		phone := signIn(t, h, "sam")
+		laptop := signIn(t, h, "sam")
+		h.HandleRequest(ctx, webSocketEventFor("CONNECT", "phone-ws", map[string]string{"token": phone.Token}, ""))
+		h.HandleRequest(ctx, webSocketEventFor("CONNECT", "laptop-ws", map[string]string{"token": laptop.Token}, ""))
+
+		// Act
+		h.HandleRequest(ctx, withBearer(apiEvent("DELETE", "/api/auth/sessions/"+phone.SessionID, "", nil, ""), laptop.Token))
+
+		// Assert
+		connections, _ := h.hub.List(ctx, laptop.User.ID)
+		if len(connections) != 1 || connections[0].ID != "laptop-ws" {
+			t.Errorf("expected only the laptop's connection to stay open, got %+v", connections)
+		}
+	})
+
+	t.Run("unlinking an identity signs out its devices and stops email linking", func(t *testing.T) {
+		// Arrange
+		h := newHandler()
+		google := signIn(t, h, "sam")
+		apple := appleSignIn(t, h, "sam")
+
+		// Act
+		response, _ := h.HandleRequest(ctx, withBearer(apiEvent("DELETE", "/api/auth/identities/apple", "", nil, ""), google.Token))
+		again := appleSignIn(t, h, "sam")
+
+		// Assert
+		var user auth.User
+		json.Unmarshal([]byte(response.Body), &user)
+		if response.StatusCode != 200 || len(user.Identities) != 1 || user.Identities[0].Provider != auth.ProviderGoogle {
+			t.Fatalf("unexpected unlink %d: %s", response.StatusCode, response.Body)
+		}
+		if after, _ := h.HandleRequest(ctx, withBearer(apiEvent("GET", "/api/auth/me", "", nil, ""), apple.Token)); after.StatusCode != 401 {
+			t.Errorf("expected the Apple session to be signed out, got %d", after.StatusCode)
+		}
+		if after, _ := h.HandleRequest(ctx, withBearer(apiEvent("GET", "/api/auth/me", "", nil, ""), google.Token)); after.StatusCode != 200 {
+			t.Errorf("expected the Google session to stay signed in, got %d", after.StatusCode)
+		}
+		if !again.Created || again.User.ID == google.User.ID {
+			t.Errorf("expected signing in with the unlinked identity to create a new account, got %+v", again.User)
+		}
+	})
+
+	t.Run("keeps the only sign-in method", func(t *testing.T) {
+		// Arrange
+		h := newHandler()
+		google := signIn(t, h, "sam")
+
+		// Act
+		last, _ := h.HandleRequest(ctx, withBearer(apiEvent("DELETE", "/api/auth/identities/google", "", nil, ""), google.Token))
+		missing, _ := h.HandleRequest(ctx, withBearer(apiEvent("DELETE", "/api/auth/identities/apple", "", nil, ""), google.Token))
+
+		// Assert
+		if last.StatusCode != 409 || missing.StatusCode != 404 {
+			t.Errorf("expected 409 and 404, got %d and %d", last.StatusCode, missing.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"athlete-forge/autocomplete"
+)
+
+const (
+	// suggestionsTTL is how long a container keeps its index of the catalog
+	// before rebuilding it with the latest popularity
+	suggestionsTTL = 15 * time.Minute
+
+	// defaultSuggestions and maxSuggestions bound the names suggested per query
+	defaultSuggestions = 10
+	maxSuggestions     = 25
+
+	// maxQueryLength bounds an autocomplete query; no exercise name is longer
+	maxQueryLength = 100
+)
+
+// handleAutocompleteExercises suggests catalog exercises for ?q=, the text
+// typed so far, best match first and the most popular first among equal
+// matches. ?limit= caps the suggestions
+func (h *LambdaHandler) handleAutocompleteExercises(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	if _, errResponse := h.requireUser(event); errResponse != nil {
+		return *errResponse, nil
+	}
+	query := event.QueryStringParameters["q"]
+	if len(query) > maxQueryLength {
+		return h.createErrorResponse(400, fmt.Sprintf("q must be at most %d characters", maxQueryLength)), nil
+	}
+	limit := defaultSuggestions
+	if raw := event.QueryStringParameters["limit"]; raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxSuggestions {
+			return h.createErrorResponse(400, fmt.Sprintf("limit must be between 1 and %d", maxSuggestions)), nil
+		}
+		limit = n
+	}
+
+	index, err := h.suggestions.Index(ctx, time.Now().UTC())
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, index.Suggest(query, limit))
+}
+
+// runRankExercises recounts how many users trained each catalog exercise
+// recently, which ranks autocomplete suggestions. A failure for one user is
+// logged and leaves them out of this run
+func (h *LambdaHandler) runRankExercises(ctx context.Context, now time.Time) (JobResult, error) {
+	result := JobResult{Job: JobRankExercises}
+	users, err := h.users.List(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	tally := autocomplete.NewTally(now)
+	for _, user := range users {
+		workouts, err := h.workouts.List(ctx, user.UserID)
+		if err != nil {
+			result.Failed++
+			h.logger.Error().
+				Err(err).
+				Str("user_id", user.UserID).
+				Msg("Failed to load workouts for exercise popularity")
+			continue
+		}
+		if tally.Add(workouts) {
+			result.Processed++
+		}
+	}
+	if err := h.popularity.Save(ctx, tally.Popularity(now)); err != nil {
+		return result, err
+	}
+	h.suggestions.Reset()
+	return result, nil
+}
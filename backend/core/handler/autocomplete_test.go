@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"athlete-forge/autocomplete"
+)
+
+func TestLambdaHandler_AutocompleteExercises(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("suggests catalog exercises for the text typed so far", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/exercises/autocomplete", "user-1", map[string]string{"q": "dead"}, ""))
+
+		// Assert
+		if response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", response.StatusCode, response.Body)
+		}
+		var suggestions []autocomplete.Suggestion
+		json.Unmarshal([]byte(response.Body), &suggestions)
+		if len(suggestions) < 3 || suggestions[0] != (autocomplete.Suggestion{Name: "deadlift", Match: autocomplete.MatchPrefix}) {
+			t.Errorf("unexpected suggestions: %s", response.Body)
+		}
+	})
+
+	t.Run("rejects an invalid limit", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/exercises/autocomplete", "user-1", map[string]string{"q": "s", "limit": "100"}, ""))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("ranks suggestions by popularity once the ranking job has run", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		for _, userID := range []string{"lifter-1", "lifter-2"} {
+			createWorkout(t, h, userID, `{"status":"completed","exercises":[{"name":"Goblet Squat","sets":[{"reps":10,"weight":24}]}]}`)
+			h.users.Touch(ctx, userID, time.Now())
+		}
+		query := map[string]string{"q": "squat", "limit": "2"}
+
+		// Act
+		before, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/exercises/autocomplete", "user-1", query, ""))
+		result, err := h.runRankExercises(ctx, time.Now().UTC())
+		after, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/exercises/autocomplete", "user-1", query, ""))
+
+		// Assert
+		if err != nil || result.Processed != 2 {
+			t.Fatalf("expected both lifters counted, got %+v %v", result, err)
+		}
+		var unranked, ranked []autocomplete.Suggestion
+		json.Unmarshal([]byte(before.Body), &unranked)
+		json.Unmarshal([]byte(after.Body), &ranked)
+		if len(unranked) != 2 || unranked[0].Name != "squat" || unranked[1].Name != "back squat" {
+			t.Errorf("expected shorter names first before ranking, got %s", before.Body)
+		}
+		if len(ranked) != 2 || ranked[0].Name != "squat" || ranked[1].Name != "goblet squat" {
+			t.Errorf("expected the popular goblet squat after the prefix match, got %s", after.Body)
+		}
+	})
+}
@@ -0,0 +1,410 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"athlete-forge/backfill"
+	"athlete-forge/jobs"
+	"athlete-forge/store"
+	"athlete-forge/syncindex"
+	"athlete-forge/workout"
+)
+
+// defaultBackfillRate is how many workouts a second a backfill writes, unless
+// BACKFILL_WRITES_PER_SECOND says otherwise
+const defaultBackfillRate = 100
+
+// BackfillChunkResponse is returned when a chunk is uploaded: the chunk as
+// recorded and the first of its records left out
+type BackfillChunkResponse struct {
+	backfill.Chunk
+	Rejections []backfill.Rejection `json:"rejections"`
+}
+
+// handleCreateBackfill starts a backfill of the user's history from another
+// platform, which then takes chunks until it is committed
+func (h *LambdaHandler) handleCreateBackfill(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var req struct {
+		Source string `json:"source"`
+	}
+	if err := decodeBody(event, &req); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	now := time.Now().UTC()
+	b := backfill.Backfill{
+		UserID:    userID,
+		Source:    strings.TrimSpace(req.Source),
+		Status:    backfill.StatusUploading,
+		Chunks:    []backfill.Chunk{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := b.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if err := h.backfills.Save(ctx, &b); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(201, b)
+}
+
+// handleListBackfills returns the user's backfills
+func (h *LambdaHandler) handleListBackfills(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	backfills, err := h.backfills.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, backfills)
+}
+
+// handleGetBackfill returns a backfill's status, progress and report, with the
+// chunks uploaded so far while it is uploading
+func (h *LambdaHandler) handleGetBackfill(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	b, errResponse, err := h.loadBackfill(ctx, userID, event.PathParameters["id"])
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	if b.Status == backfill.StatusUploading {
+		if b.Chunks, err = h.backfills.Chunks(ctx, userID, b.ID); err != nil {
+			return Response{}, err
+		}
+	}
+	return h.createJSONResponse(200, b)
+}
+
+// handlePutBackfillChunk validates a numbered chunk of the history and keeps
+// the workouts accepted until the backfill is committed. Uploading a number
+// again replaces the chunk, so a failed upload can be retried, and chunks can
+// be uploaded in parallel
+func (h *LambdaHandler) handlePutBackfillChunk(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	number, err := strconv.Atoi(event.PathParameters["number"])
+	if err != nil || number < 1 || number > backfill.MaxChunks {
+		return h.createErrorResponse(400, fmt.Sprintf("chunk number must be between 1 and %d", backfill.MaxChunks)), nil
+	}
+	b, errResponse, err := h.loadBackfill(ctx, userID, event.PathParameters["id"])
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	if b.Status != backfill.StatusUploading {
+		return h.createErrorResponse(409, "Backfill has already been committed"), nil
+	}
+
+	var req struct {
+		Workouts []backfill.Record `json:"workouts"`
+	}
+	if err := decodeBody(event, &req); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if len(req.Workouts) == 0 || len(req.Workouts) > backfill.MaxChunkRecords {
+		return h.createErrorResponse(400, fmt.Sprintf("a chunk must have between 1 and %d workouts", backfill.MaxChunkRecords)), nil
+	}
+
+	now := time.Now().UTC()
+	accepted, rejections := backfill.Accept(userID, b.Source, req.Workouts, now)
+	data, err := json.Marshal(accepted)
+	if err != nil {
+		return Response{}, err
+	}
+	if err := h.blobs.Put(ctx, backfill.ChunkKey(userID, b.ID, number), "application/json", data); err != nil {
+		return Response{}, fmt.Errorf("failed to store backfill chunk: %w", err)
+	}
+	c := backfill.Chunk{Number: number, Records: len(req.Workouts), Accepted: len(accepted), UploadedAt: now}
+	if err := h.backfills.SaveChunk(ctx, userID, b.ID, c); err != nil {
+		return Response{}, err
+	}
+
+	if rejections == nil {
+		rejections = []backfill.Rejection{}
+	}
+	return h.createJSONResponse(200, BackfillChunkResponse{Chunk: c, Rejections: rejections})
+}
+
+// handleCommitBackfill ends the upload and queues writing the chunks uploaded
+func (h *LambdaHandler) handleCommitBackfill(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	b, errResponse, err := h.loadBackfill(ctx, userID, event.PathParameters["id"])
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	if b.Status != backfill.StatusUploading {
+		return h.createErrorResponse(409, "Backfill has already been committed"), nil
+	}
+	chunks, err := h.backfills.Chunks(ctx, userID, b.ID)
+	if err != nil {
+		return Response{}, err
+	}
+	if err := b.Commit(chunks); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	job := jobs.New(userID, JobBackfill, time.Now().UTC())
+	job.SubjectID = b.ID
+	b.JobID = job.ID
+	if err := h.saveBackfill(ctx, b); err != nil {
+		return Response{}, err
+	}
+	if err := h.enqueue(ctx, job, JobEvent{Job: JobBackfill, UserID: userID, ID: b.ID}); err != nil {
+		return Response{}, err
+	}
+
+	// Jobs run in-process locally, so the backfill may already be finished
+	current, err := h.backfills.Get(ctx, userID, b.ID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(202, current)
+}
+
+// loadBackfill returns the user's backfill id, or a 404 response
+func (h *LambdaHandler) loadBackfill(ctx context.Context, userID, id string) (*backfill.Backfill, *Response, error) {
+	b, err := h.backfills.Get(ctx, userID, id)
+	if errors.Is(err, store.ErrNotFound) {
+		response := h.createErrorResponse(404, "Backfill not found")
+		return nil, &response, nil
+	}
+	return b, nil, err
+}
+
+// runBackfill writes every chunk of a committed backfill in one go
+func (h *LambdaHandler) runBackfill(ctx context.Context, userID, id string, track progress) (JobResult, error) {
+	state := TaskState{Job: JobBackfill, UserID: userID, ID: id, StartedAt: time.Now().UTC()}
+	b, err := h.advanceBackfill(ctx, &state, func() bool { return true }, track)
+	result := JobResult{Job: JobBackfill, Processed: state.Processed, Failed: state.Failed}
+	if b != nil && b.Status == backfill.StatusFailed {
+		result.Error = b.Error
+	}
+	return result, err
+}
+
+// stepBackfill writes chunks of a committed backfill until more stops it
+func (h *LambdaHandler) stepBackfill(ctx context.Context, state *TaskState, more func() bool, track progress) error {
+	_, err := h.advanceBackfill(ctx, state, more, track)
+	return err
+}
+
+// advanceBackfill writes the backfill's chunks from the first not yet written
+// until more stops it, saving the backfill after each chunk. Writes are
+// idempotent, so a redelivered job resumes a running backfill rather than
+// skipping it. Once every chunk is written the backfill is completed with its
+// report and the user's stats are rebuilt; no workout events are published,
+// since the history is not new training. A chunk that cannot be written stops
+// the backfill and is recorded on it with the workouts written so far
+func (h *LambdaHandler) advanceBackfill(ctx context.Context, state *TaskState, more func() bool, track progress) (*backfill.Backfill, error) {
+	b, err := h.backfills.Get(ctx, state.UserID, state.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load backfill %s: %w", state.ID, err)
+	}
+	switch b.Status {
+	case backfill.StatusPending:
+		b.Status = backfill.StatusRunning
+		if err := h.saveBackfill(ctx, b); err != nil {
+			return b, err
+		}
+	case backfill.StatusRunning:
+	default:
+		state.Done = true
+		return b, nil
+	}
+
+	pacer := backfill.NewPacer(h.backfillRate)
+	state.Total = len(b.Chunks)
+	state.Cursor, state.Processed = b.Processed, b.Report.Written
+	track(ctx, state.Cursor, state.Total)
+	for b.Processed < len(b.Chunks) && more() {
+		if writeErr := h.writeBackfillChunk(ctx, b, b.Chunks[b.Processed], pacer); writeErr != nil {
+			return b, h.failBackfill(ctx, state, b, writeErr)
+		}
+		b.Advance()
+		if err := h.saveBackfill(ctx, b); err != nil {
+			return b, err
+		}
+		state.Cursor, state.Processed = b.Processed, b.Report.Written
+		track(ctx, state.Cursor, state.Total)
+	}
+	if b.Processed < len(b.Chunks) {
+		return b, nil
+	}
+
+	now := time.Now().UTC()
+	b.Finish(now)
+	if err := h.saveBackfill(ctx, b); err != nil {
+		return b, err
+	}
+	state.Done = true
+	h.logger.Info().
+		Str("user_id", b.UserID).
+		Str("backfill_id", b.ID).
+		Int("written", b.Report.Written).
+		Int("verified", b.Report.Verified).
+		Bool("consistent", b.Report.Consistent).
+		Msg("Backfill completed")
+
+	if err := h.touchUser(ctx, b.UserID, now); err != nil {
+		return b, err
+	}
+	if !h.streamDerived {
+		if err := h.dispatch(ctx, JobEvent{Job: JobRecomputeStats, UserID: b.UserID, ClientRequestID: clientRequestID(ctx)}); err != nil {
+			h.logger.Warn().
+				Err(err).
+				Str("user_id", b.UserID).
+				Str("backfill_id", b.ID).
+				Msg("Failed to queue recomputing stats after backfill")
+		}
+	}
+	return b, nil
+}
+
+// writeBackfillChunk writes a chunk's workouts in batches paced by pacer, then
+// reads them back to count those verified in the backfill's report
+func (h *LambdaHandler) writeBackfillChunk(ctx context.Context, b *backfill.Backfill, c backfill.Chunk, pacer *backfill.Pacer) error {
+	if c.Accepted == 0 {
+		return nil
+	}
+	data, err := h.blobs.Read(ctx, backfill.ChunkKey(b.UserID, b.ID, c.Number))
+	if err != nil {
+		return fmt.Errorf("failed to read chunk %d: %w", c.Number, err)
+	}
+	var workouts []workout.Workout
+	if err := json.Unmarshal(data, &workouts); err != nil {
+		return fmt.Errorf("failed to decode chunk %d: %w", c.Number, err)
+	}
+
+	for start := 0; start < len(workouts); start += store.MaxBatchWrite {
+		batch := workouts[start:min(start+store.MaxBatchWrite, len(workouts))]
+		if err := pacer.Wait(ctx, len(batch)); err != nil {
+			return err
+		}
+		if err := h.syncBackfilled(ctx, b.UserID, batch); err != nil {
+			return fmt.Errorf("failed to write chunk %d: %w", c.Number, err)
+		}
+		if err := h.workouts.SaveMany(ctx, batch); err != nil {
+			return fmt.Errorf("failed to write chunk %d: %w", c.Number, err)
+		}
+		if err := h.indexSynced(ctx, batch); err != nil {
+			return fmt.Errorf("failed to write chunk %d: %w", c.Number, err)
+		}
+		b.Report.Written += len(batch)
+	}
+
+	for start := 0; start < len(workouts); start += store.MaxBatchGet {
+		batch := workouts[start:min(start+store.MaxBatchGet, len(workouts))]
+		ids := make([]string, len(batch))
+		for i, w := range batch {
+			ids[i] = w.ID
+		}
+		stored, err := h.workouts.GetMany(ctx, b.UserID, ids)
+		if err != nil {
+			return fmt.Errorf("failed to verify chunk %d: %w", c.Number, err)
+		}
+		for _, w := range batch {
+			got, ok := stored[w.ID]
+			switch {
+			case !ok:
+				b.Report.Discrepant(backfill.Discrepancy{WorkoutID: w.ID, Chunk: c.Number, Problem: backfill.ProblemMissing})
+			case !backfill.Unchanged(w, got):
+				b.Report.Discrepant(backfill.Discrepancy{WorkoutID: w.ID, Chunk: c.Number, Problem: backfill.ProblemMismatched})
+			default:
+				b.Report.Verified++
+			}
+		}
+	}
+	return nil
+}
+
+// syncBackfilled marks the workouts in batch synced now, so the warehouse
+// export picks the history up although it was completed long before its last
+// run. Workouts backfilled before keep when they were synced, so history
+// backfilled again is not exported twice
+func (h *LambdaHandler) syncBackfilled(ctx context.Context, userID string, batch []workout.Workout) error {
+	ids := make([]string, len(batch))
+	for i, w := range batch {
+		ids[i] = w.ID
+	}
+	stored, err := h.workouts.GetMany(ctx, userID, ids)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	for i := range batch {
+		if got, ok := stored[batch[i].ID]; ok && got.SyncedAt != nil {
+			batch[i].SyncedAt = got.SyncedAt
+		} else {
+			batch[i].SyncedAt = &now
+		}
+	}
+	return nil
+}
+
+// indexSynced adds the workouts in batch to the synced workout index under
+// their syncedAt, so those synced before are indexed where they already were
+func (h *LambdaHandler) indexSynced(ctx context.Context, batch []workout.Workout) error {
+	entries := make([]syncindex.Entry, 0, len(batch))
+	for _, w := range batch {
+		if w.Status == workout.StatusCompleted && w.SyncedAt != nil {
+			entries = append(entries, syncindex.Entry{UserID: w.UserID, WorkoutID: w.ID, SyncedAt: *w.SyncedAt})
+		}
+	}
+	return h.synced.AddMany(ctx, entries)
+}
+
+// failBackfill records on the backfill that it stopped on writeErr and counts
+// the failure on state, ending the job
+func (h *LambdaHandler) failBackfill(ctx context.Context, state *TaskState, b *backfill.Backfill, writeErr error) error {
+	h.logger.Error().
+		Err(writeErr).
+		Str("user_id", b.UserID).
+		Str("backfill_id", b.ID).
+		Msg("Failed to write backfill")
+
+	now := time.Now().UTC()
+	b.Status = backfill.StatusFailed
+	b.Error = fmt.Sprintf("Backfill stopped after %d of %d chunks", b.Processed, len(b.Chunks))
+	b.CompletedAt = &now
+	state.Failed++
+	state.Done = true
+	return h.saveBackfill(ctx, b)
+}
+
+func (h *LambdaHandler) saveBackfill(ctx context.Context, b *backfill.Backfill) error {
+	b.UpdatedAt = time.Now().UTC()
+	return h.backfills.Save(ctx, b)
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"athlete-forge/backfill"
+	"athlete-forge/blob"
+	"athlete-forge/jobs"
+	"athlete-forge/workout"
+)
+
+func TestLambdaHandler_Backfills(t *testing.T) {
+	ctx := context.Background()
+	// chunk returns a chunk of count weekly workouts from the other platform,
+	// the first a week after from
+	chunk := func(from time.Time, first, count int) string {
+		var records []string
+		for i := first; i < first+count; i++ {
+			started := from.AddDate(0, 0, 7*i).Format(time.RFC3339)
+			completed := from.AddDate(0, 0, 7*i).Add(time.Hour).Format(time.RFC3339)
+			records = append(records, fmt.Sprintf(`{"sourceId":"w-%d","startedAt":%q,"completedAt":%q,"exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]}]}`, i, started, completed))
+		}
+		return `{"workouts":[` + strings.Join(records, ",") + `]}`
+	}
+	from := time.Now().UTC().AddDate(-3, 0, 0).Truncate(time.Second)
+	decode := func(response Response) backfill.Backfill {
+		var b backfill.Backfill
+		json.Unmarshal([]byte(response.Body), &b)
+		return b
+	}
+	// start creates a backfill from OtherApp and uploads chunks to it
+	start := func(t *testing.T, h *LambdaHandler, chunks ...string) backfill.Backfill {
+		t.Helper()
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/backfills", "user-1", nil, `{"source":"OtherApp"}`))
+		if response.StatusCode != 201 {
+			t.Fatalf("failed to create backfill %d: %s", response.StatusCode, response.Body)
+		}
+		b := decode(response)
+		for i, body := range chunks {
+			response, _ := h.HandleRequest(ctx, apiEvent("PUT", fmt.Sprintf("/api/backfills/%s/chunks/%d", b.ID, i+1), "user-1", nil, body))
+			if response.StatusCode != 200 {
+				t.Fatalf("failed to upload chunk %d: %d %s", i+1, response.StatusCode, response.Body)
+			}
+		}
+		return b
+	}
+
+	t.Run("writes every chunk and reports a consistent history", func(t *testing.T) {
+		// Arrange
+		h := NewLambdaHandler(zerolog.Nop(), WithBackfillWriteRate(0))
+		b := start(t, h, chunk(from, 0, 60), chunk(from, 60, 40))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/backfills/"+b.ID+"/commit", "user-1", nil, ""))
+
+		// Assert
+		if response.StatusCode != 202 {
+			t.Fatalf("expected status code 202, got %d: %s", response.StatusCode, response.Body)
+		}
+		b = decode(response)
+		if b.Status != backfill.StatusCompleted || b.Processed != 2 || b.Progress != 100 {
+			t.Errorf("unexpected backfill: %+v", b)
+		}
+		if r := b.Report; r == nil || r.Records != 100 || r.Written != 100 || r.Verified != 100 || !r.Consistent {
+			t.Errorf("unexpected report: %+v", b.Report)
+		}
+		workouts, _ := h.workouts.List(ctx, "user-1")
+		if len(workouts) != 100 || !workouts[0].StartedAt.Equal(from) || workouts[0].Status != workout.StatusCompleted {
+			t.Errorf("expected the history in order, got %d workouts", len(workouts))
+		}
+		if stored, _ := h.reports.ListWeekly(ctx, "user-1"); len(stored) == 0 {
+			t.Error("expected stats recomputed over the history")
+		}
+		if j, _ := h.jobs.Get(ctx, "user-1", b.JobID); j.Status != jobs.StatusSucceeded {
+			t.Errorf("unexpected job: %+v", j)
+		}
+	})
+
+	t.Run("rejects invalid records and does not duplicate history backfilled again", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		first := start(t, h, chunk(from, 0, 3))
+		h.HandleRequest(ctx, apiEvent("POST", "/api/backfills/"+first.ID+"/commit", "user-1", nil, ""))
+		again := start(t, h)
+		body := strings.Replace(chunk(from, 0, 4), `"sourceId":"w-3",`, "", 1)
+
+		// Act
+		uploaded, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/backfills/"+again.ID+"/chunks/1", "user-1", nil, body))
+		committed, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/backfills/"+again.ID+"/commit", "user-1", nil, ""))
+
+		// Assert
+		var c BackfillChunkResponse
+		json.Unmarshal([]byte(uploaded.Body), &c)
+		if c.Records != 4 || c.Accepted != 3 || len(c.Rejections) != 1 || c.Rejections[0].Index != 3 {
+			t.Errorf("unexpected chunk: %s", uploaded.Body)
+		}
+		if r := decode(committed).Report; r.Rejected != 1 || r.Written != 3 || !r.Consistent {
+			t.Errorf("unexpected report: %+v", r)
+		}
+		if workouts, _ := h.workouts.List(ctx, "user-1"); len(workouts) != 3 {
+			t.Errorf("expected the history written once, got %d workouts", len(workouts))
+		}
+	})
+
+	t.Run("exports the history to the warehouse once", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		h.runExportWarehouse(ctx, time.Now().UTC())
+		first := start(t, h, chunk(from, 0, 3))
+		h.HandleRequest(ctx, apiEvent("POST", "/api/backfills/"+first.ID+"/commit", "user-1", nil, ""))
+
+		// Act
+		exported, err := h.runExportWarehouse(ctx, time.Now().UTC())
+		again := start(t, h, chunk(from, 0, 3))
+		h.HandleRequest(ctx, apiEvent("POST", "/api/backfills/"+again.ID+"/commit", "user-1", nil, ""))
+		reexported, _ := h.runExportWarehouse(ctx, time.Now().UTC())
+
+		// Assert
+		if err != nil || exported.Processed != 6 {
+			t.Errorf("expected three workouts and their sets exported, got %+v %v", exported, err)
+		}
+		if reexported.Processed != 0 {
+			t.Errorf("expected history backfilled again left out, got %+v", reexported)
+		}
+	})
+
+	t.Run("only takes chunks until committed", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		empty := start(t, h)
+		b := start(t, h, chunk(from, 0, 1))
+		h.HandleRequest(ctx, apiEvent("POST", "/api/backfills/"+b.ID+"/commit", "user-1", nil, ""))
+
+		// Act
+		emptyCommit, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/backfills/"+empty.ID+"/commit", "user-1", nil, ""))
+		late, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/backfills/"+b.ID+"/chunks/2", "user-1", nil, chunk(from, 1, 1)))
+		outOfRange, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/backfills/"+empty.ID+"/chunks/0", "user-1", nil, chunk(from, 1, 1)))
+		other, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/backfills/"+b.ID, "user-2", nil, ""))
+
+		// Assert
+		if emptyCommit.StatusCode != 400 || late.StatusCode != 409 || outOfRange.StatusCode != 400 || other.StatusCode != 404 {
+			t.Errorf("expected 400, 409, 400 and 404, got %d, %d, %d and %d", emptyCommit.StatusCode, late.StatusCode, outOfRange.StatusCode, other.StatusCode)
+		}
+	})
+
+	t.Run("records a chunk that cannot be written on the backfill", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		b := start(t, h, chunk(from, 0, 2))
+		h.blobs = blob.NewMemoryStore()
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/backfills/"+b.ID+"/commit", "user-1", nil, ""))
+
+		// Assert
+		b = decode(response)
+		if b.Status != backfill.StatusFailed || b.Error == "" || b.Processed != 0 {
+			t.Errorf("unexpected backfill: %+v", b)
+		}
+		if j, _ := h.jobs.Get(ctx, "user-1", b.JobID); j.Status != jobs.StatusFailed {
+			t.Errorf("expected the job failed, got %+v", j)
+		}
+	})
+
+	t.Run("writes a chunk per item when run in steps", func(t *testing.T) {
+		// Arrange
+		machine := &recordingDispatcher{}
+		h := NewLambdaHandler(zerolog.Nop(), WithStateMachine(machine))
+		b := start(t, h, chunk(from, 0, 2), chunk(from, 2, 2))
+		h.HandleRequest(ctx, apiEvent("POST", "/api/backfills/"+b.ID+"/commit", "user-1", nil, ""))
+		state, _ := h.HandleTask(ctx, TaskEvent{Task: TaskStart, State: machine.events[0].(TaskState)})
+		budget := 1
+
+		// Act
+		err := h.stepBackfill(ctx, &state, func() bool { budget--; return budget >= 0 }, func(context.Context, int, int) {})
+		midway, _ := h.backfills.Get(ctx, "user-1", b.ID)
+		state, _ = h.HandleTask(ctx, TaskEvent{Task: TaskStep, State: state})
+
+		// Assert
+		if err != nil || midway.Processed != 1 || midway.Status != backfill.StatusRunning {
+			t.Fatalf("expected one chunk written, got %+v %v", midway, err)
+		}
+		done, _ := h.backfills.Get(ctx, "user-1", b.ID)
+		if !state.Done || state.Processed != 4 || done.Status != backfill.StatusCompleted {
+			t.Errorf("expected the rest written in the next step, got %+v %+v", state, done)
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"athlete-forge/program"
+	"athlete-forge/progression"
+	"athlete-forge/store"
+	"athlete-forge/tools"
+)
+
+// SessionBlock is the block a session falls in and the week of it
+type SessionBlock struct {
+	Block      program.Block      `json:"block"`
+	Microcycle program.Microcycle `json:"microcycle"`
+}
+
+// PlannedWeek is a week of a block with the program's prescriptions scaled to it
+type PlannedWeek struct {
+	program.Microcycle
+	Exercises []program.Prescription `json:"exercises"`
+}
+
+// PlannedBlock is a block with its planned weeks
+type PlannedBlock struct {
+	program.Block
+	Weeks []PlannedWeek `json:"weeks"`
+}
+
+// PeriodizationResponse is a program's block plan, in date order. Current is
+// today's block and week, if any
+type PeriodizationResponse struct {
+	ProgramID string         `json:"programId"`
+	Current   *SessionBlock  `json:"current,omitempty"`
+	Blocks    []PlannedBlock `json:"blocks"`
+}
+
+// handleGetBlocks returns the program's blocks week by week, with the
+// intensity and volume planned for each and its current prescriptions scaled
+// to them
+func (h *LambdaHandler) handleGetBlocks(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	p, err := h.programs.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Program not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	prof, err := h.profiles.Get(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, periodization(p, time.Now().UTC(), prof.LoadRounding()))
+}
+
+// handlePutBlocks replaces the program's blocks; an empty list removes them
+func (h *LambdaHandler) handlePutBlocks(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var blocks []program.Block
+	if err := decodeBody(event, &blocks); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if err := program.ValidateBlocks(blocks); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	p, err := h.programs.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Program not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	p.Blocks = blocks
+	p.UpdatedAt = time.Now().UTC()
+	if err := h.programs.Save(ctx, p); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, p)
+}
+
+// periodization lays p's blocks out week by week, in date order, from its
+// prescriptions as they stand
+func periodization(p *program.Program, now time.Time, rounding tools.Rounding) PeriodizationResponse {
+	response := PeriodizationResponse{ProgramID: p.ID, Blocks: []PlannedBlock{}}
+	if block, week := p.BlockOn(now); block != nil {
+		response.Current = &SessionBlock{Block: *block, Microcycle: week}
+	}
+
+	blocks := append([]program.Block(nil), p.Blocks...)
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].StartDate < blocks[j].StartDate })
+	for _, block := range blocks {
+		planned := PlannedBlock{Block: block, Weeks: []PlannedWeek{}}
+		for _, week := range block.Microcycles() {
+			exercises := progression.RoundLoads(progression.Periodize(p.Exercises, week), rounding)
+			planned.Weeks = append(planned.Weeks, PlannedWeek{Microcycle: week, Exercises: exercises})
+		}
+		response.Blocks = append(response.Blocks, planned)
+	}
+	return response
+}
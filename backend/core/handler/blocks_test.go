@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+const blocksBody = `[
+	{"name": "Volume", "type": "accumulation", "startDate": "2024-03-04", "endDate": "2024-03-17", "intensityStep": 0.05},
+	{"type": "deload", "startDate": "2024-03-18", "endDate": "2024-03-24"}
+]`
+
+func TestLambdaHandler_Blocks(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("plans each week of the blocks", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", linearProgramBody)
+		if response, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/programs/"+p.ID+"/blocks", "user-1", nil, blocksBody)); response.StatusCode != 200 {
+			t.Fatalf("failed to set blocks: %d %s", response.StatusCode, response.Body)
+		}
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("GET", "/api/programs/"+p.ID+"/blocks", "user-1", nil, ""))
+
+		// Assert
+		if err != nil || response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s (%v)", response.StatusCode, response.Body, err)
+		}
+		var plan PeriodizationResponse
+		json.Unmarshal([]byte(response.Body), &plan)
+		if len(plan.Blocks) != 2 || len(plan.Blocks[0].Weeks) != 2 || len(plan.Blocks[1].Weeks) != 1 {
+			t.Fatalf("unexpected plan: %+v", plan)
+		}
+		second := plan.Blocks[0].Weeks[1]
+		if second.Intensity != 0.95 || second.Exercises[0].Weight != 95 || second.Exercises[0].Sets != 4 {
+			t.Errorf("unexpected second accumulation week: %+v", second)
+		}
+		deload := plan.Blocks[1].Weeks[0].Exercises[0]
+		if deload.Weight != 60 || deload.Sets != 2 {
+			t.Errorf("unexpected deload week: %+v", deload)
+		}
+	})
+
+	t.Run("next session is scaled to the block's week", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", linearProgramBody)
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/programs/"+p.ID+"/blocks", "user-1", nil, blocksBody))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/programs/"+p.ID+"/next-session", "user-1", map[string]string{"date": "2024-03-20"}, ""))
+
+		// Assert
+		var session NextSessionResponse
+		json.Unmarshal([]byte(response.Body), &session)
+		if session.Block == nil || session.Block.Block.Type != "deload" || session.Exercises[0].Weight != 60 {
+			t.Errorf("unexpected session: %s", response.Body)
+		}
+	})
+
+	t.Run("rejects overlapping blocks", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", linearProgramBody)
+		body := `[{"type": "accumulation", "startDate": "2024-03-04", "endDate": "2024-03-17"}, {"type": "deload", "startDate": "2024-03-17", "endDate": "2024-03-24"}]`
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/programs/"+p.ID+"/blocks", "user-1", nil, body))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"athlete-forge/bulkedit"
+	"athlete-forge/calendar"
+	"athlete-forge/jobs"
+	"athlete-forge/store"
+	"athlete-forge/summary"
+	"athlete-forge/workout"
+)
+
+// bulkEditCheckpoint is how many workouts are edited between progress saves
+const bulkEditCheckpoint = 20
+
+// handleCreateBulkEdit queues a retroactive edit of the user's workout history
+func (h *LambdaHandler) handleCreateBulkEdit(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var e bulkedit.Edit
+	if err := decodeBody(event, &e); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	now := time.Now().UTC()
+	job := jobs.New(userID, JobBulkEdit, now)
+	e = bulkedit.Edit{
+		UserID:      userID,
+		Operation:   e.Operation,
+		From:        e.From,
+		To:          e.To,
+		FromUnit:    e.FromUnit,
+		ToUnit:      e.ToUnit,
+		Exercise:    e.Exercise,
+		Replacement: e.Replacement,
+		Status:      bulkedit.StatusPending,
+		JobID:       job.ID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := e.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if err := h.bulkEdits.Save(ctx, &e); err != nil {
+		return Response{}, err
+	}
+
+	job.SubjectID = e.ID
+	if err := h.enqueue(ctx, job, JobEvent{Job: JobBulkEdit, UserID: userID, ID: e.ID}); err != nil {
+		return Response{}, err
+	}
+
+	// Jobs run in-process locally, so the edit may already be finished
+	current, err := h.bulkEdits.Get(ctx, userID, e.ID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(202, current)
+}
+
+// handleListBulkEdits returns the user's bulk edits
+func (h *LambdaHandler) handleListBulkEdits(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	edits, err := h.bulkEdits.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, edits)
+}
+
+// handleGetBulkEdit returns a bulk edit's status and progress
+func (h *LambdaHandler) handleGetBulkEdit(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	e, err := h.bulkEdits.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Bulk edit not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, e)
+}
+
+// runBulkEdit applies a pending edit to every matching workout, saving progress
+// every bulkEditCheckpoint workouts. Only pending edits run, so a redelivered job
+// cannot apply a unit conversion twice; a failure stops the edit and is recorded
+// on it with the workouts edited so far
+func (h *LambdaHandler) runBulkEdit(ctx context.Context, userID, id string, track progress) (JobResult, error) {
+	result := JobResult{Job: JobBulkEdit}
+	e, err := h.bulkEdits.Get(ctx, userID, id)
+	if err != nil {
+		return result, fmt.Errorf("failed to load bulk edit %s: %w", id, err)
+	}
+	switch e.Status {
+	case bulkedit.StatusPending:
+	case bulkedit.StatusRunning:
+		return h.interruptBulkEdit(ctx, e)
+	case bulkedit.StatusFailed:
+		result.Error = e.Error
+		result.Failed++
+		return result, nil
+	default:
+		result.Processed = e.Changed
+		return result, nil
+	}
+
+	workouts, err := h.workouts.List(ctx, userID)
+	if err != nil {
+		return result, err
+	}
+	var matching []int
+	for i, w := range workouts {
+		if e.Matches(w) {
+			matching = append(matching, i)
+		}
+	}
+
+	e.Status = bulkedit.StatusRunning
+	e.Total = len(matching)
+	if err := h.saveBulkEdit(ctx, e); err != nil {
+		return result, err
+	}
+	track(ctx, 0, e.Total)
+
+	var editErr error
+	for _, i := range matching {
+		w := &workouts[i]
+		changed := e.Apply(w)
+		if changed {
+			if editErr = h.workouts.Save(ctx, w); editErr != nil {
+				break
+			}
+		}
+		e.Advance(changed)
+		if e.Processed%bulkEditCheckpoint == 0 && e.Processed < e.Total {
+			if err := h.saveBulkEdit(ctx, e); err != nil {
+				return result, err
+			}
+			track(ctx, e.Processed, e.Total)
+		}
+	}
+	// Recalculations change no workouts, so what they count is what they rebuilt
+	if editErr == nil && e.Operation == bulkedit.OpRecalculateBodyweight {
+		e.Changed, editErr = h.recalculateBodyweight(ctx, e, workouts, matching)
+	}
+
+	now := time.Now().UTC()
+	e.CompletedAt = &now
+	if editErr != nil {
+		e.Status = bulkedit.StatusFailed
+		e.Error = fmt.Sprintf("Edit stopped after %d of %d workouts", e.Processed, e.Total)
+		result.Error = e.Error
+		result.Failed++
+		h.logger.Error().
+			Err(editErr).
+			Str("user_id", userID).
+			Str("bulk_edit_id", id).
+			Msg("Failed to apply bulk edit")
+	} else {
+		e.Status = bulkedit.StatusCompleted
+		e.Progress = 100
+		result.Processed = e.Changed
+	}
+	return result, h.saveBulkEdit(ctx, e)
+}
+
+// recalculateBodyweight rebuilds the stored weekly reports of the past weeks,
+// and the month views when the stream maintains them, holding the matching
+// workouts with bodyweight exercises, so they count the bodyweight now logged
+// for their day. It returns how many reports and month views it rebuilt
+func (h *LambdaHandler) recalculateBodyweight(ctx context.Context, e *bulkedit.Edit, workouts []workout.Workout, matching []int) (int, error) {
+	weeks := map[time.Time]bool{}
+	months := map[string]bool{}
+	for _, i := range matching {
+		if !e.Recalculates(workouts[i]) {
+			continue
+		}
+		at := summary.CompletedAt(workouts[i])
+		weeks[at] = true
+		months[calendar.MonthOf(at)] = true
+	}
+	if len(weeks) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now().UTC()
+	rebuilt, err := h.rebuildWeeklyReports(ctx, e.UserID, weeks, now)
+	if err != nil || !h.streamDerived {
+		return rebuilt, err
+	}
+	views, err := h.rebuildMonths(ctx, e.UserID, months, now)
+	return rebuilt + views, err
+}
+
+// interruptBulkEdit fails an edit found running when its job is delivered
+// again, as when the Lambda running it timed out. Which workouts it already
+// edited is not recorded, and edited workouts can still match, so resuming
+// could convert a weight twice; the user is told how far it got instead
+func (h *LambdaHandler) interruptBulkEdit(ctx context.Context, e *bulkedit.Edit) (JobResult, error) {
+	result := JobResult{Job: JobBulkEdit}
+	now := time.Now().UTC()
+	e.Status = bulkedit.StatusFailed
+	e.CompletedAt = &now
+	e.Error = fmt.Sprintf("Edit was interrupted after at least %d of %d workouts", e.Processed, e.Total)
+	result.Error = e.Error
+	result.Failed++
+	h.logger.Error().
+		Str("user_id", e.UserID).
+		Str("bulk_edit_id", e.ID).
+		Int("processed", e.Processed).
+		Int("total", e.Total).
+		Msg("Bulk edit was interrupted")
+	return result, h.saveBulkEdit(ctx, e)
+}
+
+func (h *LambdaHandler) saveBulkEdit(ctx context.Context, e *bulkedit.Edit) error {
+	e.UpdatedAt = time.Now().UTC()
+	return h.bulkEdits.Save(ctx, e)
+}
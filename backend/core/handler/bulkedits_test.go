@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"athlete-forge/bulkedit"
+	"athlete-forge/jobs"
+	"athlete-forge/workout"
+)
+
+func TestLambdaHandler_BulkEdits(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("swaps an exercise across history and reports progress", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		createWorkout(t, h, "user-1", `{"exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]}]}`)
+		createWorkout(t, h, "user-1", `{"exercises":[{"name":"Bench","sets":[{"reps":5,"weight":80}]}]}`)
+		createWorkout(t, h, "user-2", `{"exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]}]}`)
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("POST", "/api/bulk-edits", "user-1", nil,
+			`{"operation":"swap-exercise","exercise":"squat","replacement":"Back Squat"}`))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if response.StatusCode != 202 {
+			t.Fatalf("expected status code 202, got %d: %s", response.StatusCode, response.Body)
+		}
+		var e bulkedit.Edit
+		json.Unmarshal([]byte(response.Body), &e)
+		if e.Status != bulkedit.StatusCompleted || e.Total != 2 || e.Changed != 1 || e.Progress != 100 {
+			t.Errorf("unexpected edit: %+v", e)
+		}
+
+		listed, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/workouts", "user-2", nil, ""))
+		var others []workout.Workout
+		json.Unmarshal([]byte(listed.Body), &others)
+		if len(others) != 1 || others[0].Exercises[0].Name != "Squat" {
+			t.Errorf("expected other users' workouts untouched, got %+v", others)
+		}
+	})
+
+	t.Run("recalculates stored reports with a newly logged bodyweight", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		createWorkout(t, h, "user-1", `{"status":"completed","startedAt":"2024-03-05T18:00:00Z","completedAt":"2024-03-05T19:00:00Z",
+			"exercises":[{"name":"Pull-Up","sets":[{"reps":5,"weight":10}]}]}`)
+		h.HandleRequest(ctx, apiEvent("GET", "/api/reports/weekly", "user-1", map[string]string{"week": "2024-03-04"}, ""))
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/logs/2024-03-01", "user-1", nil, `{"bodyweight":80}`))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/bulk-edits", "user-1", nil,
+			`{"operation":"recalculate-bodyweight","from":"2024-03-01"}`))
+
+		// Assert
+		var e bulkedit.Edit
+		json.Unmarshal([]byte(response.Body), &e)
+		if response.StatusCode != 202 || e.Status != bulkedit.StatusCompleted || e.Total != 1 || e.Changed != 1 {
+			t.Errorf("unexpected edit %d: %s", response.StatusCode, response.Body)
+		}
+		w, err := h.reports.GetWeekly(ctx, "user-1", "2024-03-04")
+		if err != nil || w.Summary.Volume != 450 {
+			t.Errorf("expected the stored report to count the bodyweight, got %+v (%v)", w, err)
+		}
+		listed, _ := h.workouts.List(ctx, "user-1")
+		if listed[0].Exercises[0].Sets[0].Weight != 10 {
+			t.Errorf("expected the workout left as logged, got %+v", listed[0].Exercises[0].Sets[0])
+		}
+	})
+
+	t.Run("rejects invalid edits", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("POST", "/api/bulk-edits", "user-1", nil,
+			`{"operation":"convert-units","fromUnit":"kg","toUnit":"kg"}`))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("a redelivered edit found running fails instead of succeeding empty", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		createWorkout(t, h, "user-1", `{"exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]}]}`)
+		created, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/bulk-edits", "user-1", nil,
+			`{"operation":"convert-units","fromUnit":"lb","toUnit":"kg"}`))
+		var e bulkedit.Edit
+		json.Unmarshal([]byte(created.Body), &e)
+		e.Status, e.Processed, e.Total, e.CompletedAt = bulkedit.StatusRunning, 20, 45, nil
+		h.bulkEdits.Save(ctx, &e)
+
+		// Act
+		_, err := h.runJob(ctx, JobEvent{Job: JobBulkEdit, UserID: "user-1", ID: e.ID, JobID: e.JobID})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got, _ := h.bulkEdits.Get(ctx, "user-1", e.ID)
+		if got.Status != bulkedit.StatusFailed || got.Error != "Edit was interrupted after at least 20 of 45 workouts" {
+			t.Errorf("expected the edit failed with its progress, got %+v", got)
+		}
+		if j, _ := h.jobs.Get(ctx, "user-1", e.JobID); j.Status != jobs.StatusFailed || j.Error != got.Error {
+			t.Errorf("expected the job failed, got %+v", j)
+		}
+		w, _ := h.workouts.List(ctx, "user-1")
+		if w[0].Exercises[0].Sets[0].Weight != 45.36 {
+			t.Errorf("expected the weight converted once, got %v", w[0].Exercises[0].Sets[0].Weight)
+		}
+	})
+
+	t.Run("edits are private to their owner", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		created, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/bulk-edits", "user-1", nil,
+			`{"operation":"convert-units","fromUnit":"lb","toUnit":"kg"}`))
+		var e bulkedit.Edit
+		json.Unmarshal([]byte(created.Body), &e)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/bulk-edits/"+e.ID, "user-2", nil, ""))
+
+		// Assert
+		if response.StatusCode != 404 {
+			t.Errorf("expected status code 404, got %d", response.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"athlete-forge/exercise"
+)
+
+// Cache-Control policies for routes whose responses a shared cache such as the
+// CloudFront distribution in front of the API may store
+const (
+	// catalogCacheControl covers data that only changes with a deployment
+	catalogCacheControl = "public, max-age=86400"
+
+	// feedCacheControl covers share links, whose unguessable URL is the only
+	// access check; a revoked link is invalidated rather than left to expire
+	feedCacheControl = "public, max-age=900"
+
+	// profileCacheControl covers public profile pages; saving the settings
+	// invalidates the page, so the short lifetime only bounds how stale new
+	// workouts can be
+	profileCacheControl = "public, max-age=300"
+)
+
+// applyCaching marks a successful GET on a cacheable route with the route's
+// Cache-Control and an ETag of the body, and answers a matching If-None-Match
+// with 304. It runs after content negotiation, so each format has its own ETag
+func (h *LambdaHandler) applyCaching(event *APIGatewayProxyEvent, r *route, response Response) Response {
+	if r.cacheControl == "" || response.StatusCode != 200 || (event.HTTPMethod != "GET" && event.HTTPMethod != "HEAD") {
+		return response
+	}
+
+	sum := sha256.Sum256([]byte(response.Body))
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	response.Headers["Cache-Control"] = r.cacheControl
+	response.Headers["ETag"] = etag
+	if response.Headers["Vary"] == "" {
+		response.Headers["Vary"] = "Accept"
+	}
+
+	if etagMatches(header(event, "If-None-Match"), etag) {
+		response.StatusCode = 304
+		response.Body = ""
+		response.IsBase64Encoded = false
+	}
+	return response
+}
+
+// etagMatches reports whether an If-None-Match header lists etag; weak
+// comparison is used, as RFC 9110 requires for If-None-Match
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// handleExerciseCatalog returns every catalog exercise with its media. It is the
+// same for every caller, so it needs no authentication and can be cached by
+// the CDN; media links outlive the cached copy
+func (h *LambdaHandler) handleExerciseCatalog(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	results, err := h.catalogExercises(ctx, exercise.Search(""), catalogCacheAge)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, results)
+}
+
+// InvalidateRequest lists API paths to drop from the CDN's caches
+type InvalidateRequest struct {
+	Paths []string `json:"paths"`
+}
+
+// handleInvalidateCache removes paths from the CDN's caches, for when cached
+// responses must change before they expire
+func (h *LambdaHandler) handleInvalidateCache(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	var req InvalidateRequest
+	if err := decodeBody(event, &req); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if h.cdn == nil {
+		return h.createErrorResponse(400, "no CDN is configured"), nil
+	}
+	if len(req.Paths) == 0 {
+		return h.createErrorResponse(400, "paths is required"), nil
+	}
+	for _, path := range req.Paths {
+		if !strings.HasPrefix(path, "/api/") {
+			return h.createErrorResponse(400, "paths must begin with /api/"), nil
+		}
+	}
+
+	if err := h.cdn.Invalidate(ctx, req.Paths...); err != nil {
+		return Response{}, err
+	}
+	h.logger.Info().
+		Str("function", "handleInvalidateCache").
+		Str("user_id", userID).
+		Strs("paths", req.Paths).
+		Msg("CDN invalidation requested")
+
+	return h.createJSONResponse(202, req)
+}
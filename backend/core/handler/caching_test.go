@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+// recordingInvalidator records the paths invalidated in the CDN
+type recordingInvalidator struct {
+	paths []string
+}
+
+func (r *recordingInvalidator) Invalidate(ctx context.Context, paths ...string) error {
+	r.paths = append(r.paths, paths...)
+	return nil
+}
+
+func TestLambdaHandler_HTTPCaching(t *testing.T) {
+	ctx := context.Background()
+	admin := map[string]interface{}{"cognito:groups": "admin"}
+
+	t.Run("the exercise catalog is public and cacheable", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("GET", "/api/exercises/catalog", "", nil, ""))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if response.StatusCode != 200 || !strings.Contains(response.Body, `"name":"back squat"`) {
+			t.Fatalf("expected the catalog, got %d %s", response.StatusCode, response.Body)
+		}
+		if response.Headers["Cache-Control"] != catalogCacheControl || response.Headers["ETag"] == "" || response.Headers["Vary"] != "Accept" {
+			t.Errorf("expected caching headers, got %v", response.Headers)
+		}
+	})
+
+	t.Run("a matching If-None-Match returns 304 without a body", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		first, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/exercises/catalog", "", nil, ""))
+		event := apiEvent("GET", "/api/exercises/catalog", "", nil, "")
+		event["headers"] = map[string]string{"If-None-Match": `"stale", W/` + first.Headers["ETag"]}
+
+		// Act
+		response, _ := h.HandleRequest(ctx, event)
+
+		// Assert
+		if response.StatusCode != 304 || response.Body != "" {
+			t.Errorf("expected an empty 304, got %d %q", response.StatusCode, response.Body)
+		}
+		if response.Headers["ETag"] != first.Headers["ETag"] {
+			t.Errorf("expected the same ETag, got %s", response.Headers["ETag"])
+		}
+	})
+
+	t.Run("each format has its own ETag", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		event := apiEvent("GET", "/api/exercises/catalog", "", nil, "")
+		event["headers"] = map[string]string{"Accept": "text/csv"}
+
+		// Act
+		jsonResponse, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/exercises/catalog", "", nil, ""))
+		csvResponse, _ := h.HandleRequest(ctx, event)
+
+		// Assert
+		if jsonResponse.Headers["ETag"] == csvResponse.Headers["ETag"] {
+			t.Error("expected JSON and CSV to have different ETags")
+		}
+	})
+
+	t.Run("other endpoints are not marked cacheable", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/workouts", "user-1", nil, ""))
+
+		// Assert
+		if response.Headers["Cache-Control"] != "" || response.Headers["ETag"] != "" {
+			t.Errorf("expected no caching headers, got %v", response.Headers)
+		}
+	})
+
+	t.Run("resetting a calendar invalidates the revoked feed", func(t *testing.T) {
+		// Arrange
+		invalidator := &recordingInvalidator{}
+		h := NewLambdaHandler(zerolog.Nop(), WithCDN(invalidator))
+		c := getCalendar(t, h, "GET", "/api/calendar", "user-1")
+		feed, _ := h.HandleRequest(ctx, apiEvent("GET", feedPath(c.URL), "", nil, ""))
+
+		// Act
+		getCalendar(t, h, "POST", "/api/calendar/reset", "user-1")
+
+		// Assert
+		if feed.Headers["Cache-Control"] != feedCacheControl {
+			t.Errorf("expected the feed to be cacheable, got %v", feed.Headers)
+		}
+		if len(invalidator.paths) != 1 || invalidator.paths[0] != "/api/calendar/feeds/user-1/*" {
+			t.Errorf("expected the user's feeds to be invalidated, got %v", invalidator.paths)
+		}
+	})
+
+	t.Run("admins can invalidate paths", func(t *testing.T) {
+		// Arrange
+		invalidator := &recordingInvalidator{}
+		h := NewLambdaHandler(zerolog.Nop(), WithCDN(invalidator))
+		event := cognitoEvent("POST", "/api/admin/cache/invalidate", "user-1", admin)
+		event["body"] = `{"paths": ["/api/exercises/catalog"]}`
+
+		// Act
+		response, err := h.HandleRequest(ctx, event)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if response.StatusCode != 202 {
+			t.Fatalf("expected status 202, got %d: %s", response.StatusCode, response.Body)
+		}
+		if len(invalidator.paths) != 1 || invalidator.paths[0] != "/api/exercises/catalog" {
+			t.Errorf("unexpected invalidation %v", invalidator.paths)
+		}
+	})
+
+	t.Run("invalidation is refused without a CDN or outside the API", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			handler *LambdaHandler
+			body    string
+		}{
+			{"no CDN", newTestHandler(), `{"paths": ["/api/exercises/catalog"]}`},
+			{"path outside the API", NewLambdaHandler(zerolog.Nop(), WithCDN(&recordingInvalidator{})), `{"paths": ["/index.html"]}`},
+			{"no paths", NewLambdaHandler(zerolog.Nop(), WithCDN(&recordingInvalidator{})), `{"paths": []}`},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// Arrange
+				event := cognitoEvent("POST", "/api/admin/cache/invalidate", "user-1", admin)
+				event["body"] = tt.body
+
+				// Act
+				response, _ := tt.handler.HandleRequest(ctx, event)
+
+				// Assert
+				if response.StatusCode != 400 {
+					t.Errorf("expected status 400, got %d", response.StatusCode)
+				}
+			})
+		}
+	})
+}
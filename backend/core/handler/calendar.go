@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"athlete-forge/calendar"
+)
+
+// CalendarResponse describes the user's calendar subscription
+type CalendarResponse struct {
+	URL       string `json:"url"`
+	WebcalURL string `json:"webcalUrl"`
+}
+
+// handleGetCalendar returns the user's signed feed URL for subscribing in a
+// calendar app, or with month the view of that month
+func (h *LambdaHandler) handleGetCalendar(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	if raw := event.QueryStringParameters["month"]; raw != "" {
+		return h.monthView(ctx, userID, raw)
+	}
+
+	settings, err := h.calendars.Get(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, h.calendarResponse(event, userID, settings.Version))
+}
+
+// monthView returns the user's calendar view of month with per-day summaries.
+// When the table's stream maintains month views the stored view is read;
+// otherwise, or before the stream has seen a change in the month, it is built
+// from the user's workouts. Program schedules are added on every read, so they
+// are current without rebuilding the months they cover
+func (h *LambdaHandler) monthView(ctx context.Context, userID, raw string) (Response, error) {
+	month, err := calendar.ParseMonth(raw)
+	if err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	var view *calendar.Month
+	if h.streamDerived {
+		stored, ok, err := h.calendars.GetMonth(ctx, userID, raw)
+		if err != nil {
+			return Response{}, err
+		}
+		if ok {
+			view = stored
+		}
+	}
+	if view == nil {
+		workouts, err := h.loadedWorkouts(ctx, userID)
+		if err != nil {
+			return Response{}, err
+		}
+		built := calendar.BuildMonth(userID, month, workouts, time.Now().UTC())
+		view = &built
+	}
+
+	programs, err := h.programs.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	view.AddSchedules(programs)
+	return h.createJSONResponse(200, view)
+}
+
+// handleResetCalendar revokes the user's current feed URL and returns a new one
+func (h *LambdaHandler) handleResetCalendar(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	settings, err := h.calendars.Get(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	settings.Version++
+	settings.UpdatedAt = time.Now().UTC()
+	if err := h.calendars.Save(ctx, settings); err != nil {
+		return Response{}, err
+	}
+
+	// The revoked URL stops verifying here at once, but the CDN may still hold
+	// a copy of its feed
+	if h.cdn != nil {
+		if err := h.cdn.Invalidate(ctx, "/api/calendar/feeds/"+userID+"/*"); err != nil {
+			h.logger.Warn().
+				Err(err).
+				Str("user_id", userID).
+				Msg("Failed to invalidate revoked calendar feed")
+		}
+	}
+	return h.createJSONResponse(200, h.calendarResponse(event, userID, settings.Version))
+}
+
+// handleCalendarFeed serves the iCalendar feed; calendar apps cannot send our auth
+// headers, so the signed token in the path authorizes the request instead
+func (h *LambdaHandler) handleCalendarFeed(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID := event.PathParameters["userId"]
+	token := strings.TrimSuffix(event.PathParameters["token"], ".ics")
+
+	settings, err := h.calendars.Get(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	if !h.signer.Verify(userID, settings.Version, token) {
+		return h.createErrorResponse(404, "Calendar not found"), nil
+	}
+
+	programs, err := h.programs.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	workouts, err := h.workouts.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+
+	feed := calendar.Feed{Name: "Training", Events: calendar.Events(programs, workouts)}
+	return Response{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type":                "text/calendar; charset=utf-8",
+			"Content-Disposition":         `inline; filename="training.ics"`,
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: calendar.Render(feed, time.Now().UTC()),
+	}, nil
+}
+
+// calendarResponse builds the feed URLs for userID's current token
+func (h *LambdaHandler) calendarResponse(event *APIGatewayProxyEvent, userID string, version int) CalendarResponse {
+	base := h.publicURL
+	if base == "" {
+		// The Host header is chosen by the client, so the URL is built from the
+		// domain API Gateway received the request on instead
+		base = "https://" + event.RequestContext.DomainName + "/" + event.RequestContext.Stage
+	}
+	url := base + "/api/calendar/feeds/" + userID + "/" + h.signer.Token(userID, version) + ".ics"
+	return CalendarResponse{
+		URL:       url,
+		WebcalURL: "webcal://" + strings.TrimPrefix(strings.TrimPrefix(url, "https://"), "http://"),
+	}
+}
@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"athlete-forge/calendar"
+	"athlete-forge/workout"
+)
+
+const scheduleBody = `{"days": ["mon", "thu"], "startTime": "07:00", "durationMinutes": 60, "timeZone": "Europe/London"}`
+
+// getCalendar returns the user's feed URLs
+func getCalendar(t *testing.T, h *LambdaHandler, method, path, userID string) CalendarResponse {
+	t.Helper()
+	response, err := h.HandleRequest(context.Background(), apiEvent(method, path, userID, nil, ""))
+	if err != nil || response.StatusCode != 200 {
+		t.Fatalf("unexpected response %d: %s (%v)", response.StatusCode, response.Body, err)
+	}
+	var c CalendarResponse
+	if err := json.Unmarshal([]byte(response.Body), &c); err != nil {
+		t.Fatalf("failed to parse calendar JSON: %v", err)
+	}
+	return c
+}
+
+// feedPath strips the base URL from a feed URL
+func feedPath(url string) string {
+	return url[strings.Index(url, "/api/"):]
+}
+
+func TestLambdaHandler_Calendar(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("feed includes scheduled programs and planned workouts", func(t *testing.T) {
+		// Arrange
+		h := NewLambdaHandler(zerolog.Nop(), WithPublicURL("https://example.com/"))
+		p := createProgram(t, h, "user-1", percentageProgramBody)
+		if response, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/programs/"+p.ID+"/schedule", "user-1", nil, scheduleBody)); response.StatusCode != 200 {
+			t.Fatalf("failed to set schedule: %d %s", response.StatusCode, response.Body)
+		}
+		planned := `{"status": "planned", "scheduledAt": "2024-03-09T09:00:00Z", "exercises": [{"name": "Row"}]}`
+		if response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/workouts", "user-1", nil, planned)); response.StatusCode != 201 {
+			t.Fatalf("failed to plan workout: %d %s", response.StatusCode, response.Body)
+		}
+		c := getCalendar(t, h, "GET", "/api/calendar", "user-1")
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("GET", feedPath(c.URL), "", nil, ""))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.HasPrefix(c.URL, "https://example.com/api/calendar/feeds/user-1/") || !strings.HasPrefix(c.WebcalURL, "webcal://example.com/") {
+			t.Errorf("unexpected feed URLs: %+v", c)
+		}
+		if response.StatusCode != 200 || response.Headers["Content-Type"] != "text/calendar; charset=utf-8" {
+			t.Fatalf("unexpected response %d: %v", response.StatusCode, response.Headers)
+		}
+		for _, want := range []string{"SUMMARY:Wave", "RRULE:FREQ=WEEKLY;BYDAY=MO,TH", "SUMMARY:Row", "DTSTART:20240309T090000Z"} {
+			if !strings.Contains(response.Body, want) {
+				t.Errorf("expected %q in feed:\n%s", want, response.Body)
+			}
+		}
+	})
+
+	t.Run("removing a schedule drops the program from the feed", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", percentageProgramBody)
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/programs/"+p.ID+"/schedule", "user-1", nil, scheduleBody))
+		h.HandleRequest(ctx, apiEvent("DELETE", "/api/programs/"+p.ID+"/schedule", "user-1", nil, ""))
+		c := getCalendar(t, h, "GET", "/api/calendar", "user-1")
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", feedPath(c.URL), "", nil, ""))
+
+		// Assert
+		if response.StatusCode != 200 || strings.Contains(response.Body, "BEGIN:VEVENT") {
+			t.Errorf("expected an empty feed, got %d:\n%s", response.StatusCode, response.Body)
+		}
+	})
+
+	t.Run("rejects invalid schedules", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", percentageProgramBody)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/programs/"+p.ID+"/schedule", "user-1", nil, `{"days": ["someday"]}`))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("reset revokes the previous feed URL", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		old := getCalendar(t, h, "GET", "/api/calendar", "user-1")
+
+		// Act
+		current := getCalendar(t, h, "POST", "/api/calendar/reset", "user-1")
+
+		// Assert
+		if response, _ := h.HandleRequest(ctx, apiEvent("GET", feedPath(old.URL), "", nil, "")); response.StatusCode != 404 {
+			t.Errorf("expected revoked URL to return 404, got %d", response.StatusCode)
+		}
+		if response, _ := h.HandleRequest(ctx, apiEvent("GET", feedPath(current.URL), "", nil, "")); response.StatusCode != 200 {
+			t.Errorf("expected new URL to return 200, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("builds feed URLs from the API Gateway domain, not the Host header, without a public URL", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		event := apiEvent("GET", "/api/calendar", "user-1", nil, "")
+		event["headers"] = map[string]string{"Host": "attacker.example"}
+		requestContext := event["requestContext"].(map[string]interface{})
+		requestContext["domainName"] = "abc123.execute-api.eu-west-1.amazonaws.com"
+		requestContext["stage"] = "prod"
+
+		// Act
+		response, err := h.HandleRequest(ctx, event)
+
+		// Assert
+		if err != nil || response.StatusCode != 200 {
+			t.Fatalf("unexpected response %d: %s (%v)", response.StatusCode, response.Body, err)
+		}
+		var c CalendarResponse
+		if err := json.Unmarshal([]byte(response.Body), &c); err != nil {
+			t.Fatalf("failed to parse calendar JSON: %v", err)
+		}
+		if !strings.HasPrefix(c.URL, "https://abc123.execute-api.eu-west-1.amazonaws.com/prod/api/calendar/feeds/user-1/") {
+			t.Errorf("unexpected feed URL: %s", c.URL)
+		}
+	})
+
+	t.Run("month view summarizes days with their planned sessions", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", percentageProgramBody)
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/programs/"+p.ID+"/schedule", "user-1", nil, scheduleBody))
+		completed := time.Date(2099, 3, 9, 10, 0, 0, 0, time.UTC)
+		h.workouts.Save(ctx, &workout.Workout{ID: "w1", UserID: "user-1", Status: workout.StatusCompleted, StartedAt: completed.Add(-time.Hour), CompletedAt: &completed,
+			Exercises: []workout.Exercise{{Name: "Row", Sets: []workout.Set{{Reps: 10, Weight: 60}}}}})
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("GET", "/api/calendar", "user-1", map[string]string{"month": "2099-03"}, ""))
+
+		// Assert
+		if err != nil || response.StatusCode != 200 {
+			t.Fatalf("unexpected response %d: %s (%v)", response.StatusCode, response.Body, err)
+		}
+		var m calendar.Month
+		json.Unmarshal([]byte(response.Body), &m)
+		day := m.Days[8]
+		if !day.Trained || day.Volume != 600 || len(day.MuscleGroups) != 2 || day.MuscleGroups[0] != "back" {
+			t.Errorf("unexpected day: %+v", day)
+		}
+		if m.Planned != 9 || m.Completed != 1 {
+			t.Errorf("expected the Mondays and Thursdays planned, got %+v", m)
+		}
+	})
+
+	t.Run("rejects malformed months", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("GET", "/api/calendar", "user-1", map[string]string{"month": "March"}, ""))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("rejects another user's token", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		c := getCalendar(t, h, "GET", "/api/calendar", "user-1")
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", strings.Replace(feedPath(c.URL), "user-1", "user-2", 1), "", nil, ""))
+
+		// Assert
+		if response.StatusCode != 404 {
+			t.Errorf("expected status code 404, got %d", response.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"athlete-forge/injury"
+	"athlete-forge/program"
+	"athlete-forge/progression"
+	"athlete-forge/readiness"
+	"athlete-forge/records"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+// CheckInResponse is a stored check-in with the adjustment it implies
+type CheckInResponse struct {
+	readiness.CheckIn
+	Adjustment readiness.Adjustment `json:"adjustment"`
+}
+
+// NextSessionResponse is a program's next session with effort prescriptions
+// converted to loads and weights prefilled from comparable past performances,
+// adjusted for active injuries and the day's readiness. RepsToBeat is what
+// each AMRAP set must do at its prescribed weight to set a record
+type NextSessionResponse struct {
+	ProgramID  string                       `json:"programId"`
+	Date       string                       `json:"date"`
+	Loads      []progression.LoadSuggestion `json:"loads,omitempty"`
+	Prefill    []progression.Prefill        `json:"prefill"`
+	Block      *SessionBlock                `json:"block,omitempty"`
+	Readiness  *readiness.Adjustment        `json:"readiness,omitempty"`
+	Injuries   []injury.Adjustment          `json:"injuries,omitempty"`
+	Exercises  []program.Prescription       `json:"exercises"`
+	RepsToBeat []records.RepsToBeat         `json:"repsToBeat,omitempty"`
+}
+
+// handleListCheckIns returns the user's check-ins, optionally bounded by ?from= and ?to=
+func (h *LambdaHandler) handleListCheckIns(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	checkIns, err := h.checkIns.List(ctx, userID, event.QueryStringParameters["from"], event.QueryStringParameters["to"])
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, checkIns)
+}
+
+// handleGetCheckIn returns the check-in for the date in the path
+func (h *LambdaHandler) handleGetCheckIn(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	c, err := h.checkIns.Get(ctx, userID, event.PathParameters["date"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Check-in not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, CheckInResponse{CheckIn: *c, Adjustment: readiness.AdjustmentFor(c.Score)})
+}
+
+// handlePutCheckIn creates or replaces the check-in for the date in the path,
+// keeping any recovery reading synced for that day
+func (h *LambdaHandler) handlePutCheckIn(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var c readiness.CheckIn
+	if err := decodeBody(event, &c); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	c.UserID = userID
+	c.Date = event.PathParameters["date"]
+	c.UpdatedAt = time.Now().UTC()
+
+	// Recovery comes from wearable syncs only, so the stored reading is kept
+	existing, err := h.checkIns.Get(ctx, userID, c.Date)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return Response{}, err
+	}
+	c.Recovery = nil
+	if existing != nil {
+		c.Recovery = existing.Recovery
+	}
+
+	if err := c.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if err := h.checkIns.Save(ctx, &c); err != nil {
+		return Response{}, err
+	}
+
+	h.logger.Info().
+		Str("function", "handlePutCheckIn").
+		Str("user_id", userID).
+		Str("date", c.Date).
+		Int("score", c.Score).
+		Msg("Check-in saved")
+
+	return h.createJSONResponse(200, CheckInResponse{CheckIn: c, Adjustment: readiness.AdjustmentFor(c.Score)})
+}
+
+// handleNextSession returns the program's next session for ?date= (default today)
+func (h *LambdaHandler) handleNextSession(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	p, err := h.programs.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Program not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	date := event.QueryStringParameters["date"]
+	if date == "" {
+		date = time.Now().UTC().Format(readiness.DateLayout)
+	}
+	day, err := time.Parse(readiness.DateLayout, date)
+	if err != nil {
+		return h.createErrorResponse(400, "date must be in YYYY-MM-DD format"), nil
+	}
+
+	session, err := h.nextSession(ctx, userID, p, day)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, session)
+}
+
+// nextSession builds p's next prescriptions for day: effort prescriptions are
+// converted to loads, other weights are prefilled from the most recent
+// comparable performance, loads and sets are scaled to the week of the
+// program's block covering day, exercises contraindicated by active injuries are
+// reduced, substituted or dropped, and loads are reduced when that day's
+// check-in shows low readiness. Every load is finally rounded to one the
+// user's equipment can be set to, and AMRAP sets are given the reps to beat
+func (h *LambdaHandler) nextSession(ctx context.Context, userID string, p *program.Program, day time.Time) (*NextSessionResponse, error) {
+	date := day.Format(readiness.DateLayout)
+	session := &NextSessionResponse{ProgramID: p.ID, Date: date}
+
+	workouts, err := h.workouts.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	end := day.AddDate(0, 0, 1)
+	recent := records.Between(workouts, end.Add(-progression.RecentWindow), end)
+	session.Exercises, session.Loads = progression.SuggestLoads(p.Exercises, recent)
+	session.Exercises, session.Prefill = progression.LastTime(session.Exercises, session.Loads, p.ID, workouts)
+	if block, week := p.BlockOn(day); block != nil {
+		session.Block = &SessionBlock{Block: *block, Microcycle: week}
+		session.Exercises = progression.Periodize(session.Exercises, week)
+	}
+
+	active, err := h.activeInjuries(ctx, userID, date)
+	if err != nil {
+		return nil, err
+	}
+	if len(active) > 0 {
+		session.Exercises, session.Injuries = injury.Adjust(session.Exercises, active)
+	}
+
+	c, err := h.checkIns.Get(ctx, userID, date)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+	if c != nil {
+		adjustment := readiness.AdjustmentFor(c.Score)
+		session.Readiness = &adjustment
+		session.Exercises = adjustment.Apply(session.Exercises)
+	}
+
+	prof, err := h.profiles.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	session.Exercises = progression.RoundLoads(session.Exercises, prof.LoadRounding())
+	for _, prescription := range session.Exercises {
+		if amrap(prescription) {
+			session.RepsToBeat = append(session.RepsToBeat, records.ToBeat(workouts, prescription.Exercise, prescription.Weight))
+		}
+	}
+	return session, nil
+}
+
+// amrap reports whether the prescription's last set is taken for as many reps
+// as possible, as it is when its training max is updated from AMRAP sets
+func amrap(p program.Prescription) bool {
+	return p.AMRAP || (p.Rule.Type == program.RulePercentage && p.Rule.TrainingMaxUpdate == program.TrainingMaxAMRAP)
+}
+
+// StartedSessionResponse is a workout started from a program's next session,
+// with where each exercise's target weight came from and the reps its AMRAP
+// sets must beat
+type StartedSessionResponse struct {
+	Workout    workout.Workout       `json:"workout"`
+	Prefill    []progression.Prefill `json:"prefill"`
+	RepsToBeat []records.RepsToBeat  `json:"repsToBeat,omitempty"`
+}
+
+// handleStartSession starts an active workout from the program's next session
+// for today, with each prescribed set filled in with its target reps and weight
+// and the last set of AMRAP prescriptions marked AMRAP
+func (h *LambdaHandler) handleStartSession(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	p, err := h.programs.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Program not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	now := time.Now().UTC()
+	session, err := h.nextSession(ctx, userID, p, now.Truncate(24*time.Hour))
+	if err != nil {
+		return Response{}, err
+	}
+
+	w := workout.Workout{UserID: userID, ProgramID: p.ID, GymID: p.GymID, Status: workout.StatusActive, StartedAt: now}
+	for _, prescription := range session.Exercises {
+		exercise := workout.Exercise{Name: prescription.Exercise, Sets: []workout.Set{}}
+		for i := 0; i < prescription.Sets; i++ {
+			exercise.Sets = append(exercise.Sets, workout.Set{Reps: prescription.Reps, Weight: prescription.Weight, Tempo: prescription.Tempo})
+		}
+		if amrap(prescription) && len(exercise.Sets) > 0 {
+			exercise.Sets[len(exercise.Sets)-1].AMRAP = true
+		}
+		w.Exercises = append(w.Exercises, exercise)
+	}
+	if err := w.Validate(); err != nil {
+		return h.createErrorResponse(422, err.Error()), nil
+	}
+	if err := h.workouts.Save(ctx, &w); err != nil {
+		return Response{}, err
+	}
+	if err := h.indexSession(ctx, &w); err != nil {
+		return Response{}, err
+	}
+	h.recordExerciseUse(ctx, nil, &w)
+	return h.createJSONResponse(201, StartedSessionResponse{Workout: w, Prefill: session.Prefill, RepsToBeat: session.RepsToBeat})
+}
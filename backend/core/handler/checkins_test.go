@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"athlete-forge/progression"
+	"athlete-forge/readiness"
+	"athlete-forge/workout"
+)
+
+func TestLambdaHandler_CheckIns(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("put scores the check-in and returns its adjustment", func(t *testing.T) {
+		// Act
+		response, err := newTestHandler().HandleRequest(ctx, apiEvent("PUT", "/api/checkins/2024-03-01", "user-1", nil,
+			`{"sleepHours":4,"sleepQuality":1,"soreness":5,"mood":2}`))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", response.StatusCode, response.Body)
+		}
+		var body CheckInResponse
+		json.Unmarshal([]byte(response.Body), &body)
+		if body.Date != "2024-03-01" || body.Adjustment.Level != readiness.LevelRecovery {
+			t.Errorf("unexpected check-in: %+v", body)
+		}
+	})
+
+	t.Run("put rejects out of range ratings", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("PUT", "/api/checkins/2024-03-01", "user-1", nil,
+			`{"sleepHours":8,"sleepQuality":9,"soreness":1,"mood":5}`))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("next session is reduced when readiness is low", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", linearProgramBody)
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/checkins/2024-03-01", "user-1", nil,
+			`{"sleepHours":3,"sleepQuality":1,"soreness":5,"mood":1}`))
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("GET", "/api/programs/"+p.ID+"/next-session", "user-1",
+			map[string]string{"date": "2024-03-01"}, ""))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var session NextSessionResponse
+		json.Unmarshal([]byte(response.Body), &session)
+		if session.Readiness == nil || session.Exercises[0].Weight != 90 || session.Exercises[0].Sets != 2 {
+			t.Errorf("expected reduced session, got %+v", session)
+		}
+	})
+
+	t.Run("next session is unchanged without a check-in", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", linearProgramBody)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/programs/"+p.ID+"/next-session", "user-1",
+			map[string]string{"date": "2024-03-01"}, ""))
+
+		// Assert
+		var session NextSessionResponse
+		json.Unmarshal([]byte(response.Body), &session)
+		if session.Readiness != nil || session.Exercises[0].Weight != 100 {
+			t.Errorf("expected unadjusted session, got %+v", session)
+		}
+	})
+
+	t.Run("next session loads are rounded to the profile's increments", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/profile", "user-1", nil,
+			`{"unit":"kg","barWeight":20,"rounding":{"barbell":15}}`))
+		p := createProgram(t, h, "user-1", linearProgramBody)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/programs/"+p.ID+"/next-session", "user-1",
+			map[string]string{"date": "2024-03-01"}, ""))
+
+		// Assert
+		var session NextSessionResponse
+		json.Unmarshal([]byte(response.Body), &session)
+		if session.Exercises[0].Weight != 105 {
+			t.Errorf("expected 100kg to round to a 15kg step, got %+v", session.Exercises)
+		}
+	})
+}
+
+func TestLambdaHandler_NextSession_Effort(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("converts rpe prescriptions to loads from recent sets", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", `{"name":"Effort","exercises":[{"exercise":"Squat","sets":3,"reps":5,"rpe":8,"rule":{"type":"rpe","targetRpe":8}}]}`)
+		created := createWorkout(t, h, "user-1", `{"exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]}]}`)
+		var w workout.Workout
+		json.Unmarshal([]byte(created.Body), &w)
+		h.HandleRequest(ctx, apiEvent("POST", "/api/workouts/"+w.ID+"/complete", "user-1", nil, ""))
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("GET", "/api/programs/"+p.ID+"/next-session", "user-1", nil, ""))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var session NextSessionResponse
+		json.Unmarshal([]byte(response.Body), &session)
+		if session.Exercises[0].Weight != 95 || len(session.Loads) != 1 || session.Loads[0].Estimated1RM != 116.7 {
+			t.Errorf("expected suggested load, got %+v", session)
+		}
+	})
+
+	t.Run("rejects malformed dates", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", linearProgramBody)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/programs/"+p.ID+"/next-session", "user-1",
+			map[string]string{"date": "yesterday"}, ""))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+}
+
+func TestLambdaHandler_StartSession(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("starts a workout prefilled from the last comparable session", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", linearProgramBody)
+		createWorkout(t, h, "user-1", `{"status":"completed","exercises":[{"name":"Squat","sets":[{"reps":5,"weight":110}]}]}`)
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("POST", "/api/programs/"+p.ID+"/sessions", "user-1", nil, ""))
+
+		// Assert
+		if err != nil || response.StatusCode != 201 {
+			t.Fatalf("unexpected response %d: %s (%v)", response.StatusCode, response.Body, err)
+		}
+		var started StartedSessionResponse
+		json.Unmarshal([]byte(response.Body), &started)
+		w := started.Workout
+		if w.ID == "" || w.ProgramID != p.ID || w.Status != workout.StatusActive || len(w.Exercises) != 1 || len(w.Exercises[0].Sets) != 3 {
+			t.Fatalf("unexpected workout: %+v", w)
+		}
+		if w.Exercises[0].Sets[0].Weight != 110 || started.Prefill[0].Source != progression.SourceLastTime {
+			t.Errorf("expected 110 from last time, got %+v", started.Prefill)
+		}
+		if stored, err := h.workouts.Get(ctx, "user-1", w.ID); err != nil || stored.Exercises[0].Sets[2].Reps != 5 {
+			t.Errorf("expected the workout saved, got %+v (%v)", stored, err)
+		}
+	})
+
+	t.Run("marks the last set of amrap prescriptions with the reps to beat", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", `{"name":"5/3/1","exercises":[{"exercise":"Squat","sets":3,"reps":5,"weight":100,"amrap":true,"rule":{"type":"linear","increment":2.5}}]}`)
+		createWorkout(t, h, "user-1", `{"status":"completed","exercises":[{"name":"Squat","sets":[{"reps":8,"weight":100},{"reps":6,"weight":105}]}]}`)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/programs/"+p.ID+"/sessions", "user-1", nil, ""))
+
+		// Assert
+		var started StartedSessionResponse
+		json.Unmarshal([]byte(response.Body), &started)
+		sets := started.Workout.Exercises[0].Sets
+		if len(sets) != 3 || sets[1].AMRAP || !sets[2].AMRAP {
+			t.Fatalf("expected only the last set marked amrap, got %+v", sets)
+		}
+		if len(started.RepsToBeat) != 1 || started.RepsToBeat[0].Reps != 8 || started.RepsToBeat[0].Estimated1RMReps != 9 {
+			t.Errorf("unexpected reps to beat: %+v", started.RepsToBeat)
+		}
+	})
+
+	t.Run("rejects unknown programs", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("POST", "/api/programs/missing/sessions", "user-1", nil, ""))
+
+		// Assert
+		if response.StatusCode != 404 {
+			t.Errorf("expected status code 404, got %d", response.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"athlete-forge/clientclock"
+	"athlete-forge/workout"
+)
+
+// clientTimeHeader carries the device's clock reading as it sends the request,
+// as an RFC 3339 timestamp, so times it logged can be corrected for its skew.
+// Apps replaying queued requests must send the time of the replay
+const clientTimeHeader = "X-Client-Time"
+
+// clientClockKey is the context key of the client clock
+type clientClockKey struct{}
+
+// withClientClock returns ctx carrying c, so handlers can convert the times
+// the request carries to server time
+func withClientClock(ctx context.Context, c clientclock.Clock) context.Context {
+	return context.WithValue(ctx, clientClockKey{}, c)
+}
+
+// clientClock returns the client clock ctx carries, or a clock without skew
+// received now for work that did not come from a request
+func clientClock(ctx context.Context) clientclock.Clock {
+	if c, ok := ctx.Value(clientClockKey{}).(clientclock.Clock); ok {
+		return c
+	}
+	return clientclock.New(time.Time{}, time.Now())
+}
+
+// clientClockOf returns the clock of a request received at receivedAt, or a
+// 400 response when its client time is malformed
+func (h *LambdaHandler) clientClockOf(event *APIGatewayProxyEvent, receivedAt time.Time) (clientclock.Clock, *Response) {
+	c, err := clientclock.Parse(header(event, clientTimeHeader), receivedAt)
+	if err != nil {
+		response := h.createErrorResponse(400, clientTimeHeader+" must be an RFC 3339 timestamp")
+		return clientclock.Clock{}, &response
+	}
+	if c.Unreliable() {
+		h.logger.Warn().
+			Dur("skew", c.Skew).
+			Msg("Client clock skew too large to correct")
+	}
+	return c, nil
+}
+
+// correctClientTimes converts the start and set times of w, as the device sent
+// them, to server time, keeping the device's times where they differ. Times
+// unchanged from current, the stored version if any, keep their stored device
+// time, so a client echoing a workout back does not have it corrected twice
+func correctClientTimes(ctx context.Context, current, w *workout.Workout) {
+	c := clientClock(ctx)
+
+	w.ClientStartedAt, w.ClientCompletedAt = nil, nil
+	if current != nil {
+		w.ClientCompletedAt = current.ClientCompletedAt
+	}
+	switch {
+	case w.StartedAt.IsZero():
+	case current != nil && w.StartedAt.Equal(current.StartedAt):
+		w.ClientStartedAt = current.ClientStartedAt
+	default:
+		w.StartedAt, w.ClientStartedAt = serverTime(c, w.StartedAt)
+	}
+
+	stored := map[int64]*time.Time{}
+	if current != nil {
+		for _, exercise := range current.Exercises {
+			for _, set := range exercise.Sets {
+				if set.LoggedAt != nil {
+					stored[set.LoggedAt.UnixNano()] = set.ClientLoggedAt
+				}
+			}
+		}
+	}
+	for i := range w.Exercises {
+		for j := range w.Exercises[i].Sets {
+			set := &w.Exercises[i].Sets[j]
+			if set.LoggedAt == nil {
+				set.ClientLoggedAt = nil
+				continue
+			}
+			if client, ok := stored[set.LoggedAt.UnixNano()]; ok {
+				set.ClientLoggedAt = client
+				continue
+			}
+			at, client := serverTime(c, *set.LoggedAt)
+			set.LoggedAt, set.ClientLoggedAt = &at, client
+		}
+	}
+}
+
+// serverTime converts t from the device's clock with c, returning the server
+// time and t when the two differ
+func serverTime(c clientclock.Clock, t time.Time) (time.Time, *time.Time) {
+	server := c.Server(t)
+	if server.Equal(t) {
+		return server, nil
+	}
+	client := t
+	return server, &client
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"athlete-forge/workout"
+)
+
+func TestLambdaHandler_ClientClock(t *testing.T) {
+	ctx := context.Background()
+	// skewed sends the request from a device whose clock is three hours behind
+	skewed := func(event map[string]interface{}) map[string]interface{} {
+		event["headers"] = map[string]string{"x-client-time": time.Now().Add(-3 * time.Hour).Format(time.RFC3339Nano)}
+		return event
+	}
+	decode := func(response Response) workout.Workout {
+		var w workout.Workout
+		json.Unmarshal([]byte(response.Body), &w)
+		return w
+	}
+	near := func(a, b time.Time) bool {
+		return a.Sub(b).Abs() < time.Minute
+	}
+	deviceStart := time.Now().Add(-4 * time.Hour).UTC().Truncate(time.Second)
+	deviceSet := deviceStart.Add(10 * time.Minute)
+	body := fmt.Sprintf(`{"status":"active","startedAt":%q,"exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100,"loggedAt":%q}]}]}`,
+		deviceStart.Format(time.RFC3339), deviceSet.Format(time.RFC3339))
+
+	t.Run("corrects logged times for the device's clock and keeps the device's", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		response, _ := h.HandleRequest(ctx, skewed(apiEvent("POST", "/api/workouts", "user-1", nil, body)))
+
+		// Assert
+		if response.StatusCode != 201 {
+			t.Fatalf("expected status code 201, got %d: %s", response.StatusCode, response.Body)
+		}
+		w := decode(response)
+		set := w.Exercises[0].Sets[0]
+		if !near(w.StartedAt, deviceStart.Add(3*time.Hour)) || w.ClientStartedAt == nil || !w.ClientStartedAt.Equal(deviceStart) {
+			t.Errorf("expected the start corrected by three hours, got %v from %v", w.StartedAt, w.ClientStartedAt)
+		}
+		if !near(*set.LoggedAt, deviceSet.Add(3*time.Hour)) || set.ClientLoggedAt == nil || !set.ClientLoggedAt.Equal(deviceSet) {
+			t.Errorf("expected the set corrected by three hours, got %v from %v", set.LoggedAt, set.ClientLoggedAt)
+		}
+	})
+
+	t.Run("leaves times without a client time as sent, but never in the future", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		future := time.Now().Add(2 * time.Hour).UTC().Format(time.RFC3339)
+
+		// Act
+		response := createWorkout(t, h, "user-1", `{"status":"active","startedAt":"`+deviceStart.Format(time.RFC3339)+`","exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100,"loggedAt":"`+future+`"}]}]}`)
+
+		// Assert
+		w := decode(response)
+		set := w.Exercises[0].Sets[0]
+		if !w.StartedAt.Equal(deviceStart) || w.ClientStartedAt != nil {
+			t.Errorf("expected the start kept, got %v from %v", w.StartedAt, w.ClientStartedAt)
+		}
+		if set.LoggedAt.After(time.Now()) || set.ClientLoggedAt == nil {
+			t.Errorf("expected the set bounded by the request, got %v from %v", set.LoggedAt, set.ClientLoggedAt)
+		}
+	})
+
+	t.Run("does not correct times a patch echoes back", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		created := decode(createWorkout(t, h, "user-1", body))
+		deviceNext := deviceSet.Add(5 * time.Minute)
+		created.Exercises[0].Sets = append(created.Exercises[0].Sets, workout.Set{Reps: 5, Weight: 105, LoggedAt: &deviceNext})
+		patch, _ := json.Marshal(map[string]interface{}{"exercises": created.Exercises})
+
+		// Act
+		response, _ := h.HandleRequest(ctx, skewed(apiEvent("PATCH", "/api/workouts/"+created.ID, "user-1", nil, string(patch))))
+
+		// Assert
+		w := decode(response)
+		echoed, added := w.Exercises[0].Sets[0], w.Exercises[0].Sets[1]
+		if !w.StartedAt.Equal(created.StartedAt) || !echoed.LoggedAt.Equal(deviceSet) || echoed.ClientLoggedAt != nil {
+			t.Errorf("expected echoed times kept, got %+v", w)
+		}
+		if !near(*added.LoggedAt, deviceNext.Add(3*time.Hour)) || added.ClientLoggedAt == nil || !added.ClientLoggedAt.Equal(deviceNext) {
+			t.Errorf("expected the new set corrected, got %v from %v", added.LoggedAt, added.ClientLoggedAt)
+		}
+	})
+
+	t.Run("completes at the device's completion time", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		w := decode(createWorkout(t, h, "user-1", body))
+		deviceEnd := deviceStart.Add(time.Hour)
+
+		// Act
+		early, _ := h.HandleRequest(ctx, skewed(apiEvent("POST", "/api/workouts/"+w.ID+"/complete", "user-1", nil, `{"completedAt":"`+deviceStart.Add(-4*time.Hour).Format(time.RFC3339)+`"}`)))
+		response, _ := h.HandleRequest(ctx, skewed(apiEvent("POST", "/api/workouts/"+w.ID+"/complete", "user-1", nil, `{"sessionRpe":8,"completedAt":"`+deviceEnd.Format(time.RFC3339)+`"}`)))
+
+		// Assert
+		if early.StatusCode != 400 || response.StatusCode != 200 {
+			t.Fatalf("expected 400 then 200, got %d and %d: %s", early.StatusCode, response.StatusCode, response.Body)
+		}
+		completed, _ := h.workouts.Get(ctx, "user-1", w.ID)
+		if !near(*completed.CompletedAt, deviceEnd.Add(3*time.Hour)) || !completed.ClientCompletedAt.Equal(deviceEnd) || completed.Ratings == nil {
+			t.Errorf("expected completion corrected by three hours, got %v from %v", completed.CompletedAt, completed.ClientCompletedAt)
+		}
+		if completed.SyncedAt == nil || !near(*completed.SyncedAt, time.Now()) {
+			t.Errorf("expected the completion synced at the server's time, got %v", completed.SyncedAt)
+		}
+	})
+
+	t.Run("lists workouts by when they took place", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		createWorkout(t, h, "user-1", `{"status":"completed"}`)
+		createWorkout(t, h, "user-1", `{"status":"completed","startedAt":"2024-01-08T18:00:00Z","completedAt":"2024-01-08T19:00:00Z"}`)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/workouts", "user-1", nil, ""))
+
+		// Assert
+		var workouts []workout.Workout
+		json.Unmarshal([]byte(response.Body), &workouts)
+		if len(workouts) != 2 || workouts[0].StartedAt.Year() != 2024 || workouts[0].CompletedAt.Hour() != 19 {
+			t.Errorf("expected the backfilled workout first, got %s", response.Body)
+		}
+	})
+
+	t.Run("rejects a malformed client time", func(t *testing.T) {
+		// Arrange
+		event := apiEvent("GET", "/api/workouts", "user-1", nil, "")
+		event["headers"] = map[string]string{"X-Client-Time": "five past six"}
+
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, event)
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"athlete-forge/clientconfig"
+)
+
+// WithClientConfig serves the client configuration cached in config, whose
+// minimum versions take precedence over those given to WithMinClientVersions
+func WithClientConfig(config *clientconfig.Cache) Option {
+	return func(h *LambdaHandler) {
+		h.clientConfig = config
+	}
+}
+
+// refreshClientConfig reloads the client configuration when it is due,
+// keeping the previous one when it cannot be loaded
+func (h *LambdaHandler) refreshClientConfig(ctx context.Context) {
+	if h.clientConfig == nil {
+		return
+	}
+	if err := h.clientConfig.Refresh(ctx, time.Now()); err != nil {
+		h.logger.Warn().
+			Err(err).
+			Msg("Failed to refresh client configuration")
+	}
+}
+
+// handleGetClientConfig returns the minimum supported versions, the features
+// turned off for the caller's release and the remote config values. The apps
+// fetch it at launch, before sign-in, so it is served to every release
+func (h *LambdaHandler) handleGetClientConfig(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	config, _ := clientconfig.Parse(nil)
+	if h.clientConfig != nil {
+		config = h.clientConfig.Current()
+	}
+	v, sent := clientVersion(ctx)
+	return h.createJSONResponse(200, config.For(v, sent, h.minimums()))
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"athlete-forge/clientconfig"
+	"athlete-forge/clientversion"
+)
+
+// configSource serves a fixed client configuration
+type configSource string
+
+func (s configSource) Load(ctx context.Context) ([]byte, error) {
+	return []byte(s), nil
+}
+
+func TestLambdaHandler_ClientConfig(t *testing.T) {
+	ctx := context.Background()
+	minimums, _ := clientversion.ParseMinimums("ios=2.0,android=2.0")
+	source := configSource(`{"minVersions": {"ios": "2.4"}, "killSwitches": [{"feature": "rest-timer-sync", "platforms": ["ios"], "toVersion": "2.5"}], "values": {"maxPhotoUploads": 5}}`)
+
+	// configEvent builds a request from the app release version
+	configEvent := func(path, version string) map[string]interface{} {
+		event := apiEvent("GET", path, "", nil, "")
+		event["headers"] = map[string]string{"x-client-version": version}
+		return event
+	}
+
+	t.Run("serves the configuration for the caller's release", func(t *testing.T) {
+		// Arrange
+		h := NewLambdaHandler(zerolog.Nop(), WithMinClientVersions(minimums), WithClientConfig(clientconfig.NewCache(source, clientconfig.DefaultInterval)))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, configEvent("/api/client/config", "ios/2.4.0"))
+
+		// Assert
+		if response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", response.StatusCode, response.Body)
+		}
+		var body clientconfig.Client
+		json.Unmarshal([]byte(response.Body), &body)
+		if body.MinVersions["ios"] != "2.4.0" || body.MinVersions["android"] != "2.0.0" {
+			t.Errorf("expected configured minimums over the defaults, got %v", body.MinVersions)
+		}
+		if body.UpgradeRequired || len(body.DisabledFeatures) != 1 || body.DisabledFeatures[0].Feature != "rest-timer-sync" {
+			t.Errorf("unexpected body: %s", response.Body)
+		}
+		if string(body.Values["maxPhotoUploads"]) != "5" {
+			t.Errorf("unexpected values: %v", body.Values)
+		}
+	})
+
+	t.Run("serves releases below the minimum, refusing them elsewhere", func(t *testing.T) {
+		// Arrange
+		h := NewLambdaHandler(zerolog.Nop(), WithMinClientVersions(minimums), WithClientConfig(clientconfig.NewCache(source, clientconfig.DefaultInterval)))
+
+		// Act
+		config, _ := h.HandleRequest(ctx, configEvent("/api/client/config", "ios/2.3"))
+		workouts, _ := h.HandleRequest(ctx, configEvent("/api/workouts", "ios/2.3"))
+
+		// Assert
+		if config.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", config.StatusCode, config.Body)
+		}
+		var body clientconfig.Client
+		json.Unmarshal([]byte(config.Body), &body)
+		if !body.UpgradeRequired {
+			t.Errorf("expected upgradeRequired, got %s", config.Body)
+		}
+		if workouts.StatusCode != 426 {
+			t.Errorf("expected status code 426, got %d", workouts.StatusCode)
+		}
+	})
+
+	t.Run("serves an empty configuration when none is set", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/client/config", "", nil, ""))
+
+		// Assert
+		if response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", response.StatusCode, response.Body)
+		}
+		var body clientconfig.Client
+		json.Unmarshal([]byte(response.Body), &body)
+		if body.UpgradeRequired || len(body.DisabledFeatures) != 0 || len(body.MinVersions) != 0 {
+			t.Errorf("unexpected body: %s", response.Body)
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"context"
+
+	"athlete-forge/clientversion"
+)
+
+// clientVersionHeader carries the app's platform and release, such as
+// ios/2.4.1. Requests without it, such as from scripts, are served as if from
+// a current client
+const clientVersionHeader = "X-Client-Version"
+
+// UpgradeRequiredResponse is the 426 body sent to releases older than their
+// platform's minimum, which the apps answer by asking the user to update
+type UpgradeRequiredResponse struct {
+	Error          string `json:"error"`
+	Message        string `json:"message"`
+	Platform       string `json:"platform"`
+	MinimumVersion string `json:"minimumVersion"`
+}
+
+// WithMinClientVersions refuses requests from releases older than minimums
+// with 426 Upgrade Required; every release is served when omitted
+func WithMinClientVersions(minimums clientversion.Minimums) Option {
+	return func(h *LambdaHandler) {
+		h.minVersions = minimums
+	}
+}
+
+// clientVersionKey is the context key of the client version
+type clientVersionKey struct{}
+
+// withClientVersion returns ctx carrying v, so handlers can shape responses
+// for the release that will read them
+func withClientVersion(ctx context.Context, v clientversion.Version) context.Context {
+	return context.WithValue(ctx, clientVersionKey{}, v)
+}
+
+// clientVersion returns the client version ctx carries, or false when the
+// request did not send one
+func clientVersion(ctx context.Context) (clientversion.Version, bool) {
+	v, ok := ctx.Value(clientVersionKey{}).(clientversion.Version)
+	return v, ok
+}
+
+// clientBefore reports whether the request came from a release older than
+// its platform's release in since, for leaving out fields or values those
+// releases cannot read. Requests without a version and platforms missing from
+// since count as current. Routes cached in the shared CDN cache must not
+// branch on it, since the cache does not vary on the header
+func clientBefore(ctx context.Context, since clientversion.Minimums) bool {
+	v, ok := clientVersion(ctx)
+	return ok && !since.Supports(v)
+}
+
+// clientVersionOf returns the request's client version and whether it sent
+// one, or a 400 response when it is malformed
+func (h *LambdaHandler) clientVersionOf(event *APIGatewayProxyEvent) (clientversion.Version, bool, *Response) {
+	raw := header(event, clientVersionHeader)
+	if raw == "" {
+		return clientversion.Version{}, false, nil
+	}
+	v, err := clientversion.Parse(raw)
+	if err != nil {
+		response := h.createErrorResponse(400, clientVersionHeader+": "+err.Error())
+		return clientversion.Version{}, false, &response
+	}
+	return v, true, nil
+}
+
+// minimums returns the oldest supported releases, those in the client
+// configuration taking precedence over the ones the handler was created with
+func (h *LambdaHandler) minimums() clientversion.Minimums {
+	if h.clientConfig == nil {
+		return h.minVersions
+	}
+	minimums := clientversion.Minimums{}
+	for platform, v := range h.minVersions {
+		minimums[platform] = v
+	}
+	for platform, v := range h.clientConfig.Current().Minimums() {
+		minimums[platform] = v
+	}
+	return minimums
+}
+
+// rejectOutdatedClient returns a 426 response when the request came from a
+// release no longer supported, unless r serves every release
+func (h *LambdaHandler) rejectOutdatedClient(ctx context.Context, r *route) *Response {
+	v, ok := clientVersion(ctx)
+	if !ok || r.anyVersion {
+		return nil
+	}
+	minimums := h.minimums()
+	if minimums.Supports(v) {
+		return nil
+	}
+	minimum := minimums[v.Platform]
+	response, err := h.createJSONResponse(426, UpgradeRequiredResponse{
+		Error:          "upgrade_required",
+		Message:        "This version of the app is no longer supported. Please update to continue",
+		Platform:       v.Platform,
+		MinimumVersion: minimum.Release(),
+	})
+	if err != nil {
+		response = h.createErrorResponse(426, "Upgrade required")
+	}
+	return &response
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"athlete-forge/clientversion"
+)
+
+func TestLambdaHandler_ClientVersion(t *testing.T) {
+	ctx := context.Background()
+	minimums, _ := clientversion.ParseMinimums("ios=2.4")
+
+	// versionEvent builds a request from the app release version
+	versionEvent := func(version string) map[string]interface{} {
+		event := apiEvent("GET", "/api/workouts", "user-1", nil, "")
+		event["headers"] = map[string]string{"x-client-version": version}
+		return event
+	}
+
+	t.Run("asks releases older than the minimum to upgrade", func(t *testing.T) {
+		// Arrange
+		h := NewLambdaHandler(zerolog.Nop(), WithMinClientVersions(minimums))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, versionEvent("ios/2.3.7"))
+
+		// Assert
+		if response.StatusCode != 426 {
+			t.Fatalf("expected status code 426, got %d: %s", response.StatusCode, response.Body)
+		}
+		var body UpgradeRequiredResponse
+		json.Unmarshal([]byte(response.Body), &body)
+		if body.Error != "upgrade_required" || body.Platform != "ios" || body.MinimumVersion != "2.4.0" {
+			t.Errorf("unexpected body: %s", response.Body)
+		}
+	})
+
+	t.Run("serves supported releases, other platforms and requests without a version", func(t *testing.T) {
+		// Arrange
+		h := NewLambdaHandler(zerolog.Nop(), WithMinClientVersions(minimums))
+
+		for _, event := range []map[string]interface{}{versionEvent("ios/2.4.0"), versionEvent("android/1.0"), apiEvent("GET", "/api/workouts", "user-1", nil, "")} {
+			// Act
+			response, _ := h.HandleRequest(ctx, event)
+
+			// Assert
+			if response.StatusCode != 200 {
+				t.Errorf("expected status code 200, got %d: %s", response.StatusCode, response.Body)
+			}
+		}
+	})
+
+	t.Run("rejects a malformed version", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, versionEvent("2.4"))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+}
+
+func TestClientBefore(t *testing.T) {
+	since, _ := clientversion.ParseMinimums("ios=2.5,android=3.0")
+	old, _ := clientversion.Parse("ios/2.4.9")
+	current, _ := clientversion.Parse("ios/2.5.0")
+	web, _ := clientversion.Parse("web/1.0")
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want bool
+	}{
+		{name: "older release", ctx: withClientVersion(context.Background(), old), want: true},
+		{name: "release since", ctx: withClientVersion(context.Background(), current)},
+		{name: "platform without a release", ctx: withClientVersion(context.Background(), web)},
+		{name: "no version", ctx: context.Background()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got := clientBefore(tt.ctx, since)
+
+			// Assert
+			if got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
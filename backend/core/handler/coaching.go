@@ -0,0 +1,291 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"athlete-forge/events"
+	"athlete-forge/handle"
+	"athlete-forge/messaging"
+	"athlete-forge/realtime"
+	"athlete-forge/store"
+)
+
+// messagesPageSize is how many messages a thread returns per page
+const messagesPageSize = 50
+
+// InviteRequest is the body for inviting an athlete by their handle
+type InviteRequest struct {
+	Handle string `json:"handle"`
+}
+
+// SendMessageRequest is the body for sending a message
+type SendMessageRequest struct {
+	Text string `json:"text"`
+}
+
+// ReadRequest is the body for marking a thread read up to a message
+type ReadRequest struct {
+	MessageID string `json:"messageId"`
+}
+
+// ThreadExport is everything a participant holds of a coaching link, for
+// retention and data export requests
+type ThreadExport struct {
+	Link       *messaging.Link     `json:"link"`
+	Messages   []messaging.Message `json:"messages"`
+	ExportedAt time.Time           `json:"exportedAt"`
+}
+
+// handleInviteAthlete has the caller, as a coach, invite the athlete with a
+// handle. Blocked users cannot be invited, and unknown handles look the same
+func (h *LambdaHandler) handleInviteAthlete(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var req InviteRequest
+	if err := decodeBody(event, &req); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	now := time.Now().UTC()
+	resolved, err := h.handles.Resolve(ctx, handle.Normalize(req.Handle), now)
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "User not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	blocked, err := h.social.Blocked(ctx, userID, resolved.UserID)
+	if err != nil {
+		return Response{}, err
+	}
+	if blocked {
+		return h.createErrorResponse(404, "User not found"), nil
+	}
+
+	link, err := h.coaching.Invite(ctx, userID, resolved.UserID, now)
+	if errors.Is(err, messaging.ErrSelf) {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if errors.Is(err, messaging.ErrLinked) {
+		return h.createErrorResponse(409, err.Error()), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	h.logger.Info().
+		Str("function", "handleInviteAthlete").
+		Str("user_id", userID).
+		Str("link_id", link.ID).
+		Msg("Athlete invited")
+
+	return h.createJSONResponse(201, link)
+}
+
+// handleListCoachingLinks returns the caller's links as coach and as athlete,
+// with their unread counts
+func (h *LambdaHandler) handleListCoachingLinks(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	links, err := h.coaching.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, links)
+}
+
+// handleAcceptCoachingLink has the invited athlete accept a coach's invite
+func (h *LambdaHandler) handleAcceptCoachingLink(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	link, err := h.coaching.Accept(ctx, userID, event.PathParameters["id"], time.Now().UTC())
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Link not found"), nil
+	}
+	if errors.Is(err, messaging.ErrNotAthlete) {
+		return h.createErrorResponse(403, err.Error()), nil
+	}
+	if errors.Is(err, messaging.ErrNotPending) {
+		return h.createErrorResponse(409, err.Error()), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, link)
+}
+
+// handleEndCoachingLink ends a link, or declines an invite, for both
+// participants
+func (h *LambdaHandler) handleEndCoachingLink(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	link, err := h.coaching.End(ctx, userID, event.PathParameters["id"], time.Now().UTC())
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Link not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, link)
+}
+
+// handleListMessages returns a page of a thread's messages, newest first; pass
+// the oldest message's ID as ?before= for the next page
+func (h *LambdaHandler) handleListMessages(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	id := event.PathParameters["id"]
+	if _, err := h.coaching.Get(ctx, userID, id); errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Link not found"), nil
+	} else if err != nil {
+		return Response{}, err
+	}
+	messages, err := h.coaching.Messages(ctx, userID, id, event.QueryStringParameters["before"], messagesPageSize, time.Now().UTC())
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, messages)
+}
+
+// handleSendMessage sends a message over an active link. Both participants'
+// connected devices receive it over the WebSocket API, and a MessageSent event
+// lets push notifications reach the recipient when they are not connected
+func (h *LambdaHandler) handleSendMessage(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var req SendMessageRequest
+	if err := decodeBody(event, &req); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	link, err := h.coaching.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Link not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	if _, err := messaging.ValidateText(req.Text); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	now := time.Now().UTC()
+	m, err := h.coaching.Send(ctx, link, userID, req.Text, now)
+	if errors.Is(err, messaging.ErrNotActive) {
+		return h.createErrorResponse(409, err.Error()), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	for _, participant := range []string{link.CoachID, link.AthleteID} {
+		if err := h.touchUser(ctx, participant, now); err != nil {
+			return Response{}, err
+		}
+		if _, err := h.hub.Broadcast(ctx, participant, realtime.Message{Type: messageCoaching, Data: m}); err != nil {
+			h.logger.Warn().
+				Err(err).
+				Str("user_id", participant).
+				Msg("Failed to broadcast message to every device")
+		}
+	}
+	h.publish(ctx, events.MessageSent(*link, *m))
+
+	h.logger.Info().
+		Str("function", "handleSendMessage").
+		Str("user_id", userID).
+		Str("link_id", link.ID).
+		Msg("Message sent")
+
+	return h.createJSONResponse(201, m)
+}
+
+// handleMarkRead records a read receipt up to a message, which the other
+// participant sees as their copy's peerReadUpTo
+func (h *LambdaHandler) handleMarkRead(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var req ReadRequest
+	if err := decodeBody(event, &req); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if req.MessageID == "" {
+		return h.createErrorResponse(400, "messageId is required"), nil
+	}
+	link, err := h.coaching.MarkRead(ctx, userID, event.PathParameters["id"], req.MessageID)
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Message not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, link)
+}
+
+// handleExportThread returns the caller's whole copy of a thread, for data
+// export requests. Messages past retention have already been left out
+func (h *LambdaHandler) handleExportThread(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	id := event.PathParameters["id"]
+	link, err := h.coaching.Get(ctx, userID, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Link not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	now := time.Now().UTC()
+	messages, err := h.coaching.Messages(ctx, userID, id, "", 0, now)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, ThreadExport{Link: link, Messages: messages, ExportedAt: now})
+}
+
+// runPurgeMessages deletes every active user's messages past retention. Each
+// participant holds their own copy, so each copy is purged from its own
+// partition
+func (h *LambdaHandler) runPurgeMessages(ctx context.Context, now time.Time) (JobResult, error) {
+	result := JobResult{Job: JobPurgeMessages}
+	users, err := h.users.List(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	for _, user := range users {
+		purged, err := h.coaching.Purge(ctx, user.UserID, now)
+		result.Processed += purged
+		if err != nil {
+			result.Failed++
+			h.logger.Error().
+				Err(err).
+				Str("user_id", user.UserID).
+				Msg("Failed to purge messages")
+		}
+	}
+	return result, nil
+}
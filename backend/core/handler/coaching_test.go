@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"athlete-forge/events"
+	"athlete-forge/messaging"
+)
+
+func TestLambdaHandler_Coaching(t *testing.T) {
+	ctx := context.Background()
+
+	// link has coach-1 invite athlete-1, who accepts unless the invite fails
+	link := func(t *testing.T, h *LambdaHandler) messaging.Link {
+		t.Helper()
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/auth/me/handle", "athlete-1", nil, `{"handle":"athlete"}`))
+		invite, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/coaching/invites", "coach-1", nil, `{"handle":"@Athlete"}`))
+		if invite.StatusCode != 201 {
+			t.Fatalf("expected status code 201, got %d: %s", invite.StatusCode, invite.Body)
+		}
+		var l messaging.Link
+		json.Unmarshal([]byte(invite.Body), &l)
+		accept, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/coaching/links/"+l.ID+"/accept", "athlete-1", nil, ""))
+		if accept.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", accept.StatusCode, accept.Body)
+		}
+		return l
+	}
+
+	t.Run("sends a message and publishes an event without its text", func(t *testing.T) {
+		// Arrange
+		publisher := &recordingPublisher{}
+		h := NewLambdaHandler(zerolog.Nop(), WithEventPublisher(publisher))
+		l := link(t, h)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/coaching/links/"+l.ID+"/messages", "coach-1", nil, `{"text":"Deload this week"}`))
+		list, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/coaching/links/"+l.ID+"/messages", "athlete-1", nil, ""))
+
+		// Assert
+		if response.StatusCode != 201 || list.StatusCode != 200 || !strings.Contains(list.Body, "Deload this week") {
+			t.Fatalf("unexpected responses: %d %s and %d %s", response.StatusCode, response.Body, list.StatusCode, list.Body)
+		}
+		if len(publisher.events) != 1 || publisher.events[0].Type != events.TypeMessageSent || publisher.events[0].UserID != "athlete-1" {
+			t.Fatalf("expected a MessageSent event for the athlete, got %+v", publisher.events)
+		}
+		detail, _ := publisher.events[0].Detail()
+		if strings.Contains(string(detail), "Deload") {
+			t.Errorf("expected the event to leave out the text: %s", detail)
+		}
+	})
+
+	t.Run("hides threads from other users", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		l := link(t, h)
+
+		for _, event := range []map[string]interface{}{
+			apiEvent("GET", "/api/coaching/links/"+l.ID+"/messages", "user-3", nil, ""),
+			apiEvent("POST", "/api/coaching/links/"+l.ID+"/messages", "user-3", nil, `{"text":"Hi"}`),
+			apiEvent("GET", "/api/coaching/links/"+l.ID+"/export", "user-3", nil, ""),
+		} {
+			// Act
+			response, _ := h.HandleRequest(ctx, event)
+
+			// Assert
+			if response.StatusCode != 404 {
+				t.Errorf("expected status code 404, got %d", response.StatusCode)
+			}
+		}
+	})
+
+	t.Run("refuses messages over an ended link", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		l := link(t, h)
+		h.HandleRequest(ctx, apiEvent("DELETE", "/api/coaching/links/"+l.ID, "athlete-1", nil, ""))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/coaching/links/"+l.ID+"/messages", "coach-1", nil, `{"text":"Hello?"}`))
+
+		// Assert
+		if response.StatusCode != 409 {
+			t.Errorf("expected status code 409, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("treats a blocked athlete as unknown", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/auth/me/handle", "athlete-1", nil, `{"handle":"athlete"}`))
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/social/blocks/coach-1", "athlete-1", nil, ""))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/coaching/invites", "coach-1", nil, `{"handle":"athlete"}`))
+
+		// Assert
+		if response.StatusCode != 404 {
+			t.Errorf("expected status code 404, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("purges messages past retention", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		l := link(t, h)
+		h.HandleRequest(ctx, apiEvent("POST", "/api/coaching/links/"+l.ID+"/messages", "coach-1", nil, `{"text":"Old news"}`))
+
+		// Act
+		result, err := h.runPurgeMessages(ctx, time.Now().Add(messaging.Retention))
+
+		// Assert
+		if err != nil || result.Processed != 2 || result.Failed != 0 {
+			t.Errorf("expected both copies purged, got %+v and %v", result, err)
+		}
+	})
+}
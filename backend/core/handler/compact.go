@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"athlete-forge/exercise"
+	"athlete-forge/injury"
+	"athlete-forge/profile"
+	"athlete-forge/program"
+	"athlete-forge/workout"
+)
+
+const (
+	// contentTypeCompact is the compact profile of JSON for watches and other
+	// clients with little memory or bandwidth
+	contentTypeCompact = "application/vnd.athlete-forge.compact+json"
+	compactPathPrefix  = "/api/compact/"
+	compactEnumsPath   = "/api/compact/enums"
+)
+
+// compactEnums numbers the values of enum fields by field name. A compact
+// response replaces a field's string value with its index here, so values may
+// only ever be appended. Fields of different resources that share a name share a
+// list; values not listed stay strings
+var compactEnums = map[string][]string{
+	"status": {
+		workout.StatusPlanned, workout.StatusActive, workout.StatusCompleted,
+		workout.TimerRunning, workout.TimerFinished, workout.TimerStopped,
+		workout.StatusAbandoned,
+	},
+	"type": {
+		workout.SetReps, workout.SetDuration, workout.SetDistance,
+		workout.GroupSuperset, workout.GroupGiantSet, workout.GroupCircuit, workout.GroupAMRAP,
+		program.RuleLinear, program.RuleDoubleProgression, program.RulePercentage, program.RuleRPE,
+	},
+	"unit":     {profile.UnitKilograms, profile.UnitPounds},
+	"severity": {injury.SeverityMild, injury.SeverityModerate, injury.SeveritySevere},
+	"bodyPart": {
+		exercise.BodyPartNeck, exercise.BodyPartShoulder, exercise.BodyPartElbow, exercise.BodyPartWrist, exercise.BodyPartChest,
+		exercise.BodyPartBack, exercise.BodyPartHip, exercise.BodyPartHamstring, exercise.BodyPartKnee, exercise.BodyPartAnkle,
+	},
+}
+
+// useCompactPath serves /api/compact/... as the route without the prefix in the
+// compact profile
+func useCompactPath(event *APIGatewayProxyEvent) {
+	if rest, ok := strings.CutPrefix(event.Path, compactPathPrefix); ok && event.Path != compactEnumsPath {
+		event.Path = "/api/" + rest
+		event.compact = true
+	}
+}
+
+// handleCompactEnums returns the numbering of enum values in compact responses
+func (h *LambdaHandler) handleCompactEnums(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	return h.createJSONResponse(200, compactEnums)
+}
+
+// compactJSON rewrites a JSON response in the compact profile. Nested objects
+// are flattened into their parent with dotted names, so {"location":
+// {"address": ...}} becomes {"location.address": ...}, and arrays keep their
+// elements flattened the same way. Nulls, false, empty strings, arrays and
+// objects, and _links are left out, timestamps become Unix seconds and enum
+// values become their index in compactEnums
+func compactJSON(body []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(compactValue(value))
+}
+
+// compactValue compacts a decoded JSON value
+func compactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		flat := map[string]interface{}{}
+		flattenInto(flat, "", v)
+		return flat
+	case []interface{}:
+		for i, element := range v {
+			v[i] = compactValue(element)
+		}
+		return v
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t.Unix()
+		}
+	}
+	return value
+}
+
+// flattenInto adds object's fields to flat under prefix, recursing into nested
+// objects and leaving out empty values
+func flattenInto(flat map[string]interface{}, prefix string, object map[string]interface{}) {
+	for name, value := range object {
+		if name == "_links" {
+			continue
+		}
+		key := prefix + name
+		switch v := value.(type) {
+		case nil:
+			continue
+		case bool:
+			if !v {
+				continue
+			}
+		case string:
+			if v == "" {
+				continue
+			}
+			if index := enumIndex(name, v); index >= 0 {
+				flat[key] = index
+				continue
+			}
+		case []interface{}:
+			if len(v) == 0 {
+				continue
+			}
+		case map[string]interface{}:
+			flattenInto(flat, key+".", v)
+			continue
+		}
+		flat[key] = compactValue(value)
+	}
+}
+
+// enumIndex returns the index of value among field's enum values, or -1
+func enumIndex(field, value string) int {
+	for i, candidate := range compactEnums[field] {
+		if candidate == value {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestCompactJSON(t *testing.T) {
+	// Arrange
+	body := `{"id":"w1","status":"completed","notes":"","deleted":false,"gymId":null,"startedAt":"2026-03-01T09:00:00Z",` +
+		`"location":{"address":"1 Main St","latitude":51.5},"_links":{"self":{"href":"/api/workouts/w1"}},` +
+		`"exercises":[{"name":"Squat","sets":[{"type":"reps","reps":5,"weight":102.5,"rpe":{"value":8}}]}],"tags":[],"status2":"completed"}`
+
+	// Act
+	compacted, err := compactJSON([]byte(body))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"exercises":[{"name":"Squat","sets":[{"reps":5,"rpe.value":8,"type":0,"weight":102.5}]}],"id":"w1",` +
+		`"location.address":"1 Main St","location.latitude":51.5,"startedAt":1772355600,"status":2,"status2":"completed"}`
+	if string(compacted) != expected {
+		t.Errorf("expected %s, got %s", expected, compacted)
+	}
+}
+
+func TestLambdaHandler_Compact(t *testing.T) {
+	ctx := context.Background()
+	setup := func(t *testing.T) (*LambdaHandler, string) {
+		h := newTestHandler()
+		created := createWorkout(t, h, "user-1", `{"exercises":[{"name":"Squat","sets":[{"type":"reps","reps":5,"weight":100}]}]}`)
+		var w struct {
+			ID string `json:"id"`
+		}
+		json.Unmarshal([]byte(created.Body), &w)
+		return h, w.ID
+	}
+
+	t.Run("compact routes serve the same handlers in the compact profile", func(t *testing.T) {
+		// Arrange
+		h, id := setup(t)
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("GET", "/api/compact/workouts/"+id, "user-1", nil, ""))
+
+		// Assert
+		if err != nil || response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d (%v): %s", response.StatusCode, err, response.Body)
+		}
+		if response.Headers["Content-Type"] != contentTypeCompact {
+			t.Errorf("expected the compact content type, got %v", response.Headers)
+		}
+		var w map[string]interface{}
+		json.Unmarshal([]byte(response.Body), &w)
+		if w["id"] != id || w["status"] != float64(1) {
+			t.Errorf("expected an active workout with status 1, got %v", w)
+		}
+		if _, ok := w["startedAt"].(float64); !ok {
+			t.Errorf("expected startedAt in Unix seconds, got %v", w["startedAt"])
+		}
+	})
+
+	t.Run("the compact profile is negotiated through Accept", func(t *testing.T) {
+		// Arrange
+		h, _ := setup(t)
+		event := apiEvent("GET", "/api/workouts", "user-1", nil, "")
+		event["headers"] = map[string]string{"Accept": contentTypeCompact}
+
+		// Act
+		response, _ := h.HandleRequest(ctx, event)
+
+		// Assert
+		if response.Headers["Content-Type"] != contentTypeCompact || response.Headers["Vary"] != "Accept" {
+			t.Errorf("expected compact JSON varying on Accept, got %v", response.Headers)
+		}
+		var list []map[string]interface{}
+		if err := json.Unmarshal([]byte(response.Body), &list); err != nil || len(list) != 1 || list[0]["status"] != float64(1) {
+			t.Errorf("unexpected compact list (%v): %s", err, response.Body)
+		}
+	})
+
+	t.Run("errors stay JSON", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/compact/workouts/missing", "user-1", nil, ""))
+
+		// Assert
+		if response.StatusCode != 404 || response.Headers["Content-Type"] != contentTypeJSON {
+			t.Errorf("expected a JSON 404, got %d %v", response.StatusCode, response.Headers)
+		}
+	})
+
+	t.Run("the enum numbering is published", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/compact/enums", "", nil, ""))
+
+		// Assert
+		var enums map[string][]string
+		json.Unmarshal([]byte(response.Body), &enums)
+		if response.StatusCode != 200 || len(enums["status"]) == 0 || enums["status"][1] != "active" {
+			t.Errorf("unexpected enums %d: %s", response.StatusCode, response.Body)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"athlete-forge/compliance"
+	"athlete-forge/store"
+	"athlete-forge/summary"
+	"athlete-forge/workout"
+)
+
+const (
+	defaultComplianceWeeks = 8
+	maxComplianceWeeks     = 52
+)
+
+// ComplianceResponse is a program's weekly compliance, oldest week first
+type ComplianceResponse struct {
+	ProgramID string            `json:"programId"`
+	Name      string            `json:"name"`
+	Weeks     []compliance.Week `json:"weeks"`
+}
+
+// handleProgramCompliance returns a program's compliance for the last ?weeks=
+// (default 8) weeks, ending with the current one
+func (h *LambdaHandler) handleProgramCompliance(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	n := defaultComplianceWeeks
+	if raw := event.QueryStringParameters["weeks"]; raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxComplianceWeeks {
+			return h.createErrorResponse(400, "weeks must be between 1 and "+strconv.Itoa(maxComplianceWeeks)), nil
+		}
+		n = parsed
+	}
+
+	p, err := h.programs.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Program not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	stored, err := h.compliance.List(ctx, userID, p.ID)
+	if err != nil {
+		return Response{}, err
+	}
+
+	current := summary.WeekStart(time.Now())
+	weeks := compliance.Weeks(stored, *p, current.AddDate(0, 0, -7*(n-1)), current)
+	return h.createJSONResponse(200, ComplianceResponse{ProgramID: p.ID, Name: p.Name, Weeks: weeks})
+}
+
+// recordCompliance counts a completed workout towards its program's compliance
+// for the week it was completed in. It runs as the completion is published,
+// before the program progresses, so the session is compared with what it
+// prescribed. Failures are logged rather than failing the completion
+func (h *LambdaHandler) recordCompliance(ctx context.Context, w workout.Workout) {
+	if w.ProgramID == "" || w.Status != workout.StatusCompleted {
+		return
+	}
+	logFailure := func(err error, msg string) {
+		h.logger.Warn().
+			Err(err).
+			Str("workout_id", w.ID).
+			Str("program_id", w.ProgramID).
+			Msg(msg)
+	}
+
+	p, err := h.programs.Get(ctx, w.UserID, w.ProgramID)
+	if errors.Is(err, store.ErrNotFound) {
+		return
+	}
+	if err != nil {
+		logFailure(err, "Failed to load program for compliance")
+		return
+	}
+
+	weekStart := compliance.WeekOf(w)
+	week, err := h.compliance.Get(ctx, w.UserID, p.ID, weekStart)
+	if errors.Is(err, store.ErrNotFound) {
+		fresh := compliance.NewWeek(*p, weekStart)
+		week, err = &fresh, nil
+	}
+	if err != nil {
+		logFailure(err, "Failed to load compliance")
+		return
+	}
+	if !compliance.Record(week, *p, w, time.Now()) {
+		return
+	}
+	if err := h.compliance.Save(ctx, week); err != nil {
+		logFailure(err, "Failed to save compliance")
+	}
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"athlete-forge/workout"
+)
+
+func TestLambdaHandler_ProgramCompliance(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("counts completed sessions against the schedule", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", linearProgramBody)
+		if response, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/programs/"+p.ID+"/schedule", "user-1", nil, scheduleBody)); response.StatusCode != 200 {
+			t.Fatalf("failed to set schedule: %d %s", response.StatusCode, response.Body)
+		}
+		created := createWorkout(t, h, "user-1", fmt.Sprintf(`{"programId":%q,"exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100},{"reps":5,"weight":100}]}]}`, p.ID))
+		var w workout.Workout
+		json.Unmarshal([]byte(created.Body), &w)
+		h.HandleRequest(ctx, apiEvent("POST", "/api/workouts/"+w.ID+"/complete", "user-1", nil, ""))
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("GET", "/api/programs/"+p.ID+"/compliance", "user-1", map[string]string{"weeks": "4"}, ""))
+
+		// Assert
+		if err != nil || response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s (%v)", response.StatusCode, response.Body, err)
+		}
+		var got ComplianceResponse
+		json.Unmarshal([]byte(response.Body), &got)
+		if len(got.Weeks) != 1 {
+			t.Fatalf("expected only the program's first week, got %+v", got.Weeks)
+		}
+		week := got.Weeks[0]
+		if week.SessionsPlanned != 2 || week.SessionsCompleted != 1 || week.SessionRate != 0.5 {
+			t.Errorf("unexpected sessions: %+v", week)
+		}
+		if week.PrescribedVolume != 1500 || week.PerformedVolume != 1000 || week.VolumeRate != 0.67 {
+			t.Errorf("expected volume against the prescription before progression, got %+v", week)
+		}
+	})
+
+	t.Run("rejects out of range weeks", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", linearProgramBody)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/programs/"+p.ID+"/compliance", "user-1", map[string]string{"weeks": "53"}, ""))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("unknown program returns 404", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/programs/missing/compliance", "user-1", nil, ""))
+
+		// Assert
+		if response.StatusCode != 404 {
+			t.Errorf("expected status code 404, got %d", response.StatusCode)
+		}
+	})
+}
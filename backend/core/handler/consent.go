@@ -0,0 +1,253 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"athlete-forge/consent"
+	"athlete-forge/profile"
+	"athlete-forge/store"
+)
+
+// ConsentStatus is whether a purpose is consented to. Version is the document
+// version the latest decision was made on, and ReviewRequired is set when the
+// user consented to a version that has since been replaced, so the app can
+// show them the new one
+type ConsentStatus struct {
+	Purpose        string     `json:"purpose"`
+	Granted        bool       `json:"granted"`
+	Version        int        `json:"version,omitempty"`
+	CurrentVersion int        `json:"currentVersion"`
+	ReviewRequired bool       `json:"reviewRequired"`
+	RecordedAt     *time.Time `json:"recordedAt,omitempty"`
+}
+
+// ConsentResponse is the user's consent to each purpose
+type ConsentResponse struct {
+	Consents []ConsentStatus `json:"consents"`
+}
+
+// ConsentRequest is the body for granting or withdrawing consent to a version
+// of a purpose's document
+type ConsentRequest struct {
+	Granted bool `json:"granted"`
+	Version int  `json:"version"`
+}
+
+// MarketingRecipient is a user who may be sent marketing emails
+type MarketingRecipient struct {
+	UserID string `json:"userId"`
+	Email  string `json:"email"`
+}
+
+// handleListConsentDocuments returns the current document for each purpose
+func (h *LambdaHandler) handleListConsentDocuments(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	documents := []consent.Document{}
+	for _, purpose := range consent.Purposes() {
+		d, _ := consent.Current(purpose)
+		documents = append(documents, d)
+	}
+	return h.createJSONResponse(200, documents)
+}
+
+// handleGetConsentDocument returns every version of a purpose's document,
+// oldest first
+func (h *LambdaHandler) handleGetConsentDocument(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	versions := consent.Versions(event.PathParameters["purpose"])
+	if len(versions) == 0 {
+		return h.createErrorResponse(404, "Consent purpose not found"), nil
+	}
+	return h.createJSONResponse(200, versions)
+}
+
+// handleGetConsents returns the user's consent to each purpose
+func (h *LambdaHandler) handleGetConsents(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	c, err := h.consents.Get(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	p, err := h.profiles.Get(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, consentResponse(c, p))
+}
+
+// handleGetConsentHistory returns every consent decision the user has made,
+// oldest first
+func (h *LambdaHandler) handleGetConsentHistory(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	c, err := h.consents.Get(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, c.History)
+}
+
+// handlePutConsent grants or withdraws consent to a purpose, keeping the
+// profile's matching opt-in field in step
+func (h *LambdaHandler) handlePutConsent(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	purpose := event.PathParameters["purpose"]
+	if _, ok := consent.Current(purpose); !ok {
+		return h.createErrorResponse(404, "Consent purpose not found"), nil
+	}
+	var req ConsentRequest
+	if err := decodeBody(event, &req); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	record := consent.Record{Purpose: purpose, Version: req.Version, Granted: req.Granted, Source: consent.SourceApp, RecordedAt: time.Now().UTC()}
+	if err := record.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	c, err := h.consents.Record(ctx, userID, record)
+	if err != nil {
+		return Response{}, err
+	}
+	p, err := h.profiles.Get(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	if optIn := profileOptIn(p, purpose); optIn != nil && *optIn != req.Granted {
+		*optIn = req.Granted
+		if err := h.profiles.Save(ctx, p); err != nil {
+			return Response{}, err
+		}
+	}
+
+	h.logger.Info().
+		Str("function", "handlePutConsent").
+		Str("user_id", userID).
+		Str("purpose", purpose).
+		Int("version", req.Version).
+		Bool("granted", req.Granted).
+		Msg("Consent recorded")
+
+	return h.createJSONResponse(200, consentResponse(c, p))
+}
+
+// handleListMarketingRecipients lists the active users who may be sent
+// marketing emails, for the email service's audience. Users are left out until
+// they consent to the current document, and when their account has no email
+func (h *LambdaHandler) handleListMarketingRecipients(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	users, err := h.users.List(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+
+	recipients := []MarketingRecipient{}
+	for _, user := range users {
+		allowed, err := h.consentAllows(ctx, user.UserID, consent.PurposeMarketingEmails)
+		if err != nil {
+			return Response{}, err
+		}
+		if !allowed {
+			continue
+		}
+		account, err := h.accounts.Get(ctx, user.UserID)
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return Response{}, err
+		}
+		if account.Email != "" {
+			recipients = append(recipients, MarketingRecipient{UserID: user.UserID, Email: account.Email})
+		}
+	}
+	return h.createJSONResponse(200, recipients)
+}
+
+// consentAllows reports whether userID has consented to the current document
+// for purpose; telemetry, marketing emails and strength comparisons check it
+// before using a user's data
+func (h *LambdaHandler) consentAllows(ctx context.Context, userID, purpose string) (bool, error) {
+	c, err := h.consents.Get(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	optedIn := false
+	switch purpose {
+	case consent.PurposeAnalytics:
+		// Read without decrypting the profile's sensitive fields
+		if optedIn, err = h.profiles.AnalyticsConsent(ctx, userID); err != nil {
+			return false, err
+		}
+	case consent.PurposePopulationComparisons:
+		p, err := h.profiles.Get(ctx, userID)
+		if err != nil {
+			return false, err
+		}
+		optedIn = p.StrengthComparison
+	}
+	return c.AllowsLegacy(purpose, optedIn), nil
+}
+
+// recordConsentChanges records the consent a profile update gave or withdrew
+// through its opt-in fields, against the current documents
+func (h *LambdaHandler) recordConsentChanges(ctx context.Context, userID string, previous, current *profile.Profile) error {
+	now := time.Now().UTC()
+	for _, purpose := range consent.Purposes() {
+		before, after := profileOptIn(previous, purpose), profileOptIn(current, purpose)
+		if after == nil || *before == *after {
+			continue
+		}
+		document, _ := consent.Current(purpose)
+		record := consent.Record{Purpose: purpose, Version: document.Version, Granted: *after, Source: consent.SourceProfile, RecordedAt: now}
+		if _, err := h.consents.Record(ctx, userID, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// profileOptIn returns the profile field that opts in to purpose, or nil for
+// purposes the profile has no field for
+func profileOptIn(p *profile.Profile, purpose string) *bool {
+	switch purpose {
+	case consent.PurposeAnalytics:
+		return &p.AnalyticsConsent
+	case consent.PurposePopulationComparisons:
+		return &p.StrengthComparison
+	}
+	return nil
+}
+
+// consentResponse reports c for each purpose, counting p's opt-ins for
+// purposes with no decision on record
+func consentResponse(c *consent.Consents, p *profile.Profile) ConsentResponse {
+	response := ConsentResponse{Consents: []ConsentStatus{}}
+	for _, purpose := range consent.Purposes() {
+		document, _ := consent.Current(purpose)
+		status := ConsentStatus{Purpose: purpose, CurrentVersion: document.Version}
+		optedIn := false
+		if optIn := profileOptIn(p, purpose); optIn != nil {
+			optedIn = *optIn
+		}
+		status.Granted = c.AllowsLegacy(purpose, optedIn)
+		if r, ok := c.Latest(purpose); ok {
+			recordedAt := r.RecordedAt
+			status.Version, status.RecordedAt = r.Version, &recordedAt
+			status.ReviewRequired = r.Granted && r.Version != document.Version
+		} else if status.Granted {
+			status.Version = document.Version
+		}
+		response.Consents = append(response.Consents, status)
+	}
+	return response
+}
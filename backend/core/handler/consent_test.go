@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"athlete-forge/auth"
+	"athlete-forge/consent"
+)
+
+func TestLambdaHandler_Consent(t *testing.T) {
+	ctx := context.Background()
+	batch := `{"platform":"ios","appVersion":"2.14.0","events":[{"name":"screen_viewed","timestamp":"` +
+		time.Now().UTC().Format(time.RFC3339) + `","properties":{"screen":"history"}}]}`
+
+	t.Run("consent through the endpoint enables telemetry and the profile opt-in", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		granted, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/consent/analytics", "user-1", nil, `{"granted":true,"version":1}`))
+		sent, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/telemetry", "user-1", nil, batch))
+
+		// Assert
+		if granted.StatusCode != 200 || sent.StatusCode != 202 {
+			t.Fatalf("expected 200 and 202, got %d and %d: %s", granted.StatusCode, sent.StatusCode, granted.Body)
+		}
+		var response ConsentResponse
+		json.Unmarshal([]byte(granted.Body), &response)
+		if len(response.Consents) != 3 || !response.Consents[0].Granted || response.Consents[1].Granted {
+			t.Errorf("expected only analytics granted, got %+v", response.Consents)
+		}
+		p, _ := h.profiles.Get(ctx, "user-1")
+		if !p.AnalyticsConsent {
+			t.Error("expected the profile opt-in to be set")
+		}
+	})
+
+	t.Run("withdrawing consent stops telemetry", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/consent/analytics", "user-1", nil, `{"granted":true,"version":1}`))
+
+		// Act
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/consent/analytics", "user-1", nil, `{"granted":false,"version":1}`))
+		sent, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/telemetry", "user-1", nil, batch))
+		history, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/consent/history", "user-1", nil, ""))
+
+		// Assert
+		if sent.StatusCode != 403 {
+			t.Errorf("expected 403, got %d", sent.StatusCode)
+		}
+		var records []consent.Record
+		json.Unmarshal([]byte(history.Body), &records)
+		if len(records) != 2 || !records[0].Granted || records[1].Granted {
+			t.Errorf("expected a grant then a withdrawal, got %+v", records)
+		}
+	})
+
+	t.Run("profile opt-ins are recorded as consent", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		body := `{"unit":"kg","barWeight":20,"plates":[{"weight":20,"pairs":2}],"strengthComparison":true}`
+
+		// Act
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/profile", "user-1", nil, body))
+
+		// Assert
+		c, _ := h.consents.Get(ctx, "user-1")
+		r, ok := c.Latest(consent.PurposePopulationComparisons)
+		if !ok || !r.Granted || r.Source != consent.SourceProfile || len(c.History) != 1 {
+			t.Errorf("expected one recorded opt-in from the profile, got %+v", c)
+		}
+	})
+
+	t.Run("withdrawing population comparisons stops percentiles", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		body := `{"unit":"kg","barWeight":20,"plates":[{"weight":20,"pairs":2}],"strengthComparison":true,"sex":"male","bodyweight":80,"birthYear":1990}`
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/profile", "user-1", nil, body))
+		before, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/stats/percentiles", "user-1", nil, ""))
+
+		// Act
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/consent/population-comparisons", "user-1", nil, `{"granted":false}`))
+		after, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/stats/percentiles", "user-1", nil, ""))
+
+		// Assert
+		if before.StatusCode != 200 || after.StatusCode != 403 {
+			t.Errorf("expected 200 then 403, got %d then %d", before.StatusCode, after.StatusCode)
+		}
+	})
+
+	t.Run("rejects consent to another version or purpose", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		stale, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/consent/analytics", "user-1", nil, `{"granted":true,"version":7}`))
+		unknown, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/consent/profiling", "user-1", nil, `{"granted":true,"version":1}`))
+
+		// Assert
+		if stale.StatusCode != 400 || unknown.StatusCode != 404 {
+			t.Errorf("expected 400 and 404, got %d and %d", stale.StatusCode, unknown.StatusCode)
+		}
+	})
+
+	t.Run("serves the consent documents", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		current, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/consent/documents", "", nil, ""))
+		versions, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/consent/documents/marketing-emails", "", nil, ""))
+
+		// Assert
+		var documents []consent.Document
+		json.Unmarshal([]byte(current.Body), &documents)
+		if len(documents) != 3 || versions.StatusCode != 200 {
+			t.Errorf("expected 3 documents and the versions, got %d and %d", len(documents), versions.StatusCode)
+		}
+	})
+
+	t.Run("marketing recipients are only consenting users with an email", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		for _, userID := range []string{"opted-in", "opted-out", "no-email"} {
+			email := userID + "@example.com"
+			if userID == "no-email" {
+				email = ""
+			}
+			h.accounts.Save(ctx, &auth.User{ID: userID, Email: email})
+			h.users.Touch(ctx, userID, time.Now())
+		}
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/consent/marketing-emails", "opted-in", nil, `{"granted":true,"version":1}`))
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/consent/marketing-emails", "no-email", nil, `{"granted":true,"version":1}`))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, cognitoEvent("GET", "/api/admin/consent/marketing-emails", "ops", map[string]interface{}{"cognito:groups": "admin"}))
+
+		// Assert
+		var recipients []MarketingRecipient
+		json.Unmarshal([]byte(response.Body), &recipients)
+		if response.StatusCode != 200 || len(recipients) != 1 || recipients[0].Email != "opted-in@example.com" {
+			t.Errorf("expected only the opted-in user, got %d: %s", response.StatusCode, response.Body)
+		}
+	})
+}
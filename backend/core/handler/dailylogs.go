@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"athlete-forge/dailylog"
+	"athlete-forge/store"
+)
+
+// WaterRequest is the body for quick-logging a drink
+type WaterRequest struct {
+	ML int `json:"ml"`
+}
+
+// handleListDailyLogs returns the user's daily logs, optionally bounded by ?from= and ?to=
+func (h *LambdaHandler) handleListDailyLogs(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	logs, err := h.dailyLogs.List(ctx, userID, event.QueryStringParameters["from"], event.QueryStringParameters["to"])
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, logs)
+}
+
+// handleGetDailyLog returns the log for the date in the path
+func (h *LambdaHandler) handleGetDailyLog(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	l, err := h.dailyLogs.Get(ctx, userID, event.PathParameters["date"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Daily log not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, l)
+}
+
+// handlePutDailyLog upserts the fields present in the body into the log for the date in the path
+func (h *LambdaHandler) handlePutDailyLog(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var update dailylog.Update
+	if err := decodeBody(event, &update); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	l, err := h.dailyLogs.GetOrNew(ctx, userID, event.PathParameters["date"])
+	if err != nil {
+		return Response{}, err
+	}
+	l.Apply(update)
+	return h.saveDailyLog(ctx, l)
+}
+
+// handleAddWater adds a drink to the hydration total for the date in the path
+func (h *LambdaHandler) handleAddWater(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var req WaterRequest
+	if err := decodeBody(event, &req); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if req.ML <= 0 {
+		return h.createErrorResponse(400, "ml must be positive"), nil
+	}
+
+	l, err := h.dailyLogs.GetOrNew(ctx, userID, event.PathParameters["date"])
+	if err != nil {
+		return Response{}, err
+	}
+	l.AddWater(req.ML)
+	return h.saveDailyLog(ctx, l)
+}
+
+// saveDailyLog validates and stores l, returning it as the response
+func (h *LambdaHandler) saveDailyLog(ctx context.Context, l *dailylog.Log) (Response, error) {
+	l.UpdatedAt = time.Now().UTC()
+	if err := l.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if err := h.dailyLogs.Save(ctx, l); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, l)
+}
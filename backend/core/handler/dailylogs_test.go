@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"athlete-forge/dailylog"
+	"athlete-forge/summary"
+)
+
+func TestLambdaHandler_DailyLogs(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("put merges fields into the day's log", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/logs/2024-03-05", "user-1", nil, `{"sleepHours":7.5}`))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/logs/2024-03-05", "user-1", nil, `{"steps":12000}`))
+
+		// Assert
+		var l dailylog.Log
+		json.Unmarshal([]byte(response.Body), &l)
+		if response.StatusCode != 200 || l.SleepHours == nil || *l.SleepHours != 7.5 || l.Steps == nil || *l.Steps != 12000 {
+			t.Errorf("unexpected response %d: %s", response.StatusCode, response.Body)
+		}
+	})
+
+	t.Run("water quick-log accumulates", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		h.HandleRequest(ctx, apiEvent("POST", "/api/logs/2024-03-05/water", "user-1", nil, `{"ml":500}`))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/logs/2024-03-05/water", "user-1", nil, `{"ml":250}`))
+
+		// Assert
+		var l dailylog.Log
+		json.Unmarshal([]byte(response.Body), &l)
+		if l.WaterML == nil || *l.WaterML != 750 {
+			t.Errorf("expected 750 ml, got %s", response.Body)
+		}
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("PUT", "/api/logs/2024-03-05", "user-1", nil, `{"sleepHours":30}`))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("weekly summary includes habits alongside workouts", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/logs/2024-03-05", "user-1", nil, `{"steps":10000,"sleepHours":8}`))
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/logs/2024-03-06", "user-1", nil, `{"steps":6000}`))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/stats/weekly", "user-1", map[string]string{"week": "2024-03-07"}, ""))
+
+		// Assert
+		var week summary.Week
+		json.Unmarshal([]byte(response.Body), &week)
+		if week.WeekStart != "2024-03-04" || week.Habits.DaysLogged != 2 || week.Habits.AverageSteps != 8000 || week.Habits.AverageSleepHours != 8 {
+			t.Errorf("unexpected summary: %s", response.Body)
+		}
+	})
+}
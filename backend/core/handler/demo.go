@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"hash/fnv"
+	"time"
+
+	"athlete-forge/demo"
+	"athlete-forge/jobs"
+)
+
+// handleSeedDemo fills the user's empty account with demo history in the
+// background, returning the tracked job to poll. Accounts that already have
+// workouts or programs are refused so demo data never mixes with real training
+func (h *LambdaHandler) handleSeedDemo(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	workouts, err := h.workouts.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	programs, err := h.programs.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	if len(workouts) > 0 || len(programs) > 0 {
+		return h.createErrorResponse(409, "demo data can only be added to an account without workouts or programs"), nil
+	}
+
+	job := jobs.New(userID, JobSeedDemo, time.Now().UTC())
+	if err := h.enqueue(ctx, job, JobEvent{Job: JobSeedDemo, UserID: userID}); err != nil {
+		return Response{}, err
+	}
+
+	// Jobs run in-process locally, so the job may already be finished
+	current, err := h.jobs.Get(ctx, userID, job.ID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(202, current)
+}
+
+// runSeedDemo saves userID's demo history, then indexes the user and compiles
+// the weekly reports it covers as if it had been logged over those weeks. The
+// history is seeded by user ID, so every run for a user generates the same items
+func (h *LambdaHandler) runSeedDemo(ctx context.Context, userID string, now time.Time, track progress) (JobResult, error) {
+	result := JobResult{Job: JobSeedDemo}
+	seed := fnv.New64a()
+	seed.Write([]byte(userID))
+	d := demo.Generate(userID, now, int64(seed.Sum64()))
+
+	total := d.Items()
+	saved := func() {
+		result.Processed++
+		track(ctx, result.Processed, total)
+	}
+	track(ctx, 0, total)
+	for i := range d.Workouts {
+		if err := h.workouts.Save(ctx, &d.Workouts[i]); err != nil {
+			return result, err
+		}
+		saved()
+	}
+	for i := range d.Activities {
+		if err := h.activities.Save(ctx, &d.Activities[i]); err != nil {
+			return result, err
+		}
+		saved()
+	}
+	for i := range d.CheckIns {
+		if err := h.checkIns.Save(ctx, &d.CheckIns[i]); err != nil {
+			return result, err
+		}
+		saved()
+	}
+	if err := h.programs.Save(ctx, &d.Program); err != nil {
+		return result, err
+	}
+	saved()
+
+	if err := h.touchUser(ctx, userID, now); err != nil {
+		return result, err
+	}
+	state := TaskState{Job: JobRecomputeStats, UserID: userID, StartedAt: now}
+	noProgress := func(context.Context, int, int) {}
+	if err := h.stepRecomputeStats(ctx, &state, func() bool { return true }, noProgress); err != nil {
+		return result, err
+	}
+	return result, nil
+}
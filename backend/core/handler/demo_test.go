@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"athlete-forge/demo"
+	"athlete-forge/jobs"
+)
+
+func TestLambdaHandler_SeedDemo(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("fills an empty account with history", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("POST", "/api/demo", "user-1", nil, ""))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if response.StatusCode != 202 {
+			t.Fatalf("expected status code 202, got %d: %s", response.StatusCode, response.Body)
+		}
+		var j jobs.Job
+		json.Unmarshal([]byte(response.Body), &j)
+		if j.Status != jobs.StatusSucceeded || j.Progress != 100 {
+			t.Errorf("unexpected job: %+v", j)
+		}
+		programs, _ := h.programs.List(ctx, "user-1")
+		if len(programs) != 1 || programs[0].Name != demo.ProgramName {
+			t.Errorf("expected the demo program, got %+v", programs)
+		}
+		workouts, _ := h.workouts.List(ctx, "user-1")
+		if len(workouts) != 3*demo.Weeks {
+			t.Errorf("expected weeks of workouts, got %d", len(workouts))
+		}
+		reports, _ := h.reports.ListWeekly(ctx, "user-1")
+		if len(reports) != demo.Weeks {
+			t.Errorf("expected weekly reports for the demo weeks, got %d", len(reports))
+		}
+		users, _ := h.users.List(ctx)
+		if len(users) != 1 {
+			t.Errorf("expected the user indexed, got %+v", users)
+		}
+	})
+
+	t.Run("refuses accounts with training", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		createWorkout(t, h, "user-1", `{"status":"active","exercises":[]}`)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/demo", "user-1", nil, ""))
+
+		// Assert
+		if response.StatusCode != 409 {
+			t.Errorf("expected status code 409, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("running the job again does not duplicate history", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		h.HandleRequest(ctx, apiEvent("POST", "/api/demo", "user-1", nil, ""))
+		before, _ := h.workouts.List(ctx, "user-1")
+
+		// Act
+		_, err := h.runJob(ctx, JobEvent{Job: JobSeedDemo, UserID: "user-1"})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		after, _ := h.workouts.List(ctx, "user-1")
+		if len(after) != len(before) {
+			t.Errorf("expected %d workouts, got %d", len(before), len(after))
+		}
+	})
+}
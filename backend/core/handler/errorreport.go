@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"athlete-forge/errreport"
+)
+
+// WithErrorReporting sends handler errors and panics to reporter, tagged with
+// the deployed release and environment. Without it they are reported as
+// structured log lines
+func WithErrorReporting(reporter errreport.Reporter, release, environment string) Option {
+	return func(h *LambdaHandler) {
+		h.errReporter = reporter
+		h.release = release
+		h.environment = environment
+	}
+}
+
+// report sends r, filling in what every report carries. Reporting is best
+// effort, so a failure is logged and the original failure handled as usual
+func (h *LambdaHandler) report(ctx context.Context, r errreport.Report) {
+	r.Time = time.Now().UTC()
+	r.Release = h.release
+	r.Environment = h.environment
+	if r.ClientRequestID == "" {
+		r.ClientRequestID = clientRequestID(ctx)
+	}
+	if err := h.errReporter.Report(ctx, r); err != nil {
+		h.logger.Warn().
+			Err(err).
+			Str("error", r.Message).
+			Msg("Failed to report error")
+	}
+}
+
+// errorReport describes err for reporting, with the stack from where it was
+// given one by errreport.WithStack or, when it carries none, the caller's
+func errorReport(err error) errreport.Report {
+	stack := errreport.StackOf(err)
+	if stack == nil {
+		stack = errreport.Stack(1)
+	}
+	return errreport.Report{Kind: errreport.KindError, Message: err.Error(), Stack: stack}
+}
+
+// requestReport describes a failure while serving event; r is nil when no route matched
+func (h *LambdaHandler) requestReport(kind, message string, event *APIGatewayProxyEvent, r *route) errreport.Report {
+	report := errreport.Report{
+		Kind:    kind,
+		Message: message,
+		Method:  event.HTTPMethod,
+		Path:    event.Path,
+		UserID:  h.userID(event),
+	}
+	if r != nil {
+		report.Route = r.pattern
+	}
+	return report
+}
+
+// recoverPanic reports a panic raised while handling event with its stack. API
+// requests get a 500 response; other events fail so Lambda retries them
+func (h *LambdaHandler) recoverPanic(ctx context.Context, event interface{}, recovered interface{}) (Response, error) {
+	// Skip recoverPanic and the deferred function calling it
+	stack := errreport.Stack(2)
+	message := fmt.Sprint(recovered)
+	h.logger.Error().
+		Str("panic", message).
+		Msg("Request handler panicked")
+
+	if job, ok := parseJobEvent(event); ok {
+		h.report(ctx, errreport.Report{Kind: errreport.KindPanic, Message: message, Stack: stack, Job: job.Job, UserID: job.UserID, ClientRequestID: job.ClientRequestID})
+		return Response{}, fmt.Errorf("panic: %s", message)
+	}
+	apiEvent, err := h.parseAPIGatewayEvent(event)
+	if err != nil || apiEvent.HTTPMethod == "" {
+		h.report(ctx, errreport.Report{Kind: errreport.KindPanic, Message: message, Stack: stack})
+		return Response{}, fmt.Errorf("panic: %s", message)
+	}
+
+	matchedRoute, _, _ := h.match(apiEvent)
+	r := h.requestReport(errreport.KindPanic, message, apiEvent, matchedRoute)
+	r.Stack = stack
+	if id, invalid := h.clientRequestIDOf(apiEvent); invalid == nil {
+		r.ClientRequestID = id
+	}
+	h.report(ctx, r)
+	return echoClientRequestID(h.createErrorResponse(500, "Internal server error"), r.ClientRequestID), nil
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"athlete-forge/errreport"
+)
+
+// recordingReporter records the reports it receives
+type recordingReporter struct {
+	reports []errreport.Report
+}
+
+func (r *recordingReporter) Report(ctx context.Context, report errreport.Report) error {
+	r.reports = append(r.reports, report)
+	return nil
+}
+
+func TestLambdaHandler_ErrorReporting(t *testing.T) {
+	ctx := context.Background()
+	newHandler := func(handle routeHandler) (*LambdaHandler, *recordingReporter) {
+		reporter := &recordingReporter{}
+		h := NewLambdaHandler(zerolog.Nop(), WithErrorReporting(reporter, "abc123", "production"))
+		for i := range h.routes {
+			if h.routes[i].method == "GET" && h.routes[i].pattern == "/api/workouts/{id}" {
+				h.routes[i].handle = handle
+			}
+		}
+		return h, reporter
+	}
+
+	t.Run("reports handler errors with the request's context", func(t *testing.T) {
+		// Arrange
+		h, reporter := newHandler(func(context.Context, *APIGatewayProxyEvent) (Response, error) {
+			return Response{}, errors.New("table unavailable")
+		})
+		event := apiEvent("GET", "/api/workouts/w1", "user-1", nil, "")
+		event["headers"] = map[string]string{"X-Client-Request-Id": "sync-42"}
+
+		// Act
+		response, err := h.HandleRequest(ctx, event)
+
+		// Assert
+		if err != nil || response.StatusCode != 500 {
+			t.Fatalf("expected status code 500, got %d, %v", response.StatusCode, err)
+		}
+		if len(reporter.reports) != 1 {
+			t.Fatalf("expected one report, got %d", len(reporter.reports))
+		}
+		r := reporter.reports[0]
+		if r.Kind != errreport.KindError || r.Message != "table unavailable" || r.Route != "/api/workouts/{id}" || r.UserID != "user-1" || r.ClientRequestID != "sync-42" || r.Release != "abc123" || r.Environment != "production" {
+			t.Errorf("unexpected report: %+v", r)
+		}
+	})
+
+	t.Run("reports the stack where the error was given one", func(t *testing.T) {
+		// Arrange
+		h, reporter := newHandler(func(context.Context, *APIGatewayProxyEvent) (Response, error) {
+			return Response{}, fmt.Errorf("failed to load workout: %w", errreport.WithStack(errors.New("throttled")))
+		})
+
+		// Act
+		h.HandleRequest(ctx, apiEvent("GET", "/api/workouts/w1", "user-1", nil, ""))
+
+		// Assert
+		if len(reporter.reports) != 1 {
+			t.Fatalf("expected one report, got %d", len(reporter.reports))
+		}
+		if stack := reporter.reports[0].Stack; len(stack) == 0 || !strings.Contains(stack[0].Function, "TestLambdaHandler_ErrorReporting") {
+			t.Errorf("expected the failing handler first, got %+v", stack)
+		}
+	})
+
+	t.Run("reports errors without a stack of their own with where they were reported", func(t *testing.T) {
+		// Arrange
+		h, reporter := newHandler(func(context.Context, *APIGatewayProxyEvent) (Response, error) {
+			return Response{}, errors.New("table unavailable")
+		})
+
+		// Act
+		h.HandleRequest(ctx, apiEvent("GET", "/api/workouts/w1", "user-1", nil, ""))
+
+		// Assert
+		if stack := reporter.reports[0].Stack; len(stack) == 0 || !strings.Contains(stack[0].Function, "handleRequest") {
+			t.Errorf("expected the reporting function first, got %+v", stack)
+		}
+	})
+
+	t.Run("recovers from panics and reports their stack", func(t *testing.T) {
+		// Arrange
+		h, reporter := newHandler(func(context.Context, *APIGatewayProxyEvent) (Response, error) {
+			panic("workout has no sets")
+		})
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("GET", "/api/workouts/w1", "user-1", nil, ""))
+
+		// Assert
+		if err != nil || response.StatusCode != 500 {
+			t.Fatalf("expected status code 500, got %d, %v", response.StatusCode, err)
+		}
+		if len(reporter.reports) != 1 {
+			t.Fatalf("expected one report, got %d", len(reporter.reports))
+		}
+		r := reporter.reports[0]
+		if r.Kind != errreport.KindPanic || r.Message != "workout has no sets" || r.Route != "/api/workouts/{id}" {
+			t.Errorf("unexpected report: %+v", r)
+		}
+		if len(r.Stack) == 0 || !strings.Contains(r.Stack[0].Function, "TestLambdaHandler_ErrorReporting") {
+			t.Errorf("expected the panicking function first, got %+v", r.Stack)
+		}
+	})
+
+	t.Run("panics in jobs fail the invocation so it is retried", func(t *testing.T) {
+		// Arrange
+		h, reporter := newHandler(nil)
+		h.users = nil
+
+		// Act
+		_, err := h.HandleRequest(ctx, map[string]interface{}{"job": JobWeeklyReports})
+
+		// Assert
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		if len(reporter.reports) != 1 || reporter.reports[0].Kind != errreport.KindPanic || reporter.reports[0].Job != JobWeeklyReports {
+			t.Errorf("unexpected reports: %+v", reporter.reports)
+		}
+	})
+}
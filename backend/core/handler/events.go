@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"context"
+
+	"athlete-forge/events"
+	"athlete-forge/workout"
+)
+
+// publish sends domain events to every publisher, tagged with the client request
+// ID of the request that caused them. Subscribers are told on a best-effort
+// basis, so a failure is logged rather than failing the request, and one
+// publisher failing does not stop the others
+func (h *LambdaHandler) publish(ctx context.Context, published ...events.Event) {
+	if len(h.publishers) == 0 || len(published) == 0 {
+		return
+	}
+	if id := clientRequestID(ctx); id != "" {
+		for i := range published {
+			published[i].ClientRequestID = id
+		}
+	}
+	for _, publisher := range h.publishers {
+		if err := publisher.Publish(ctx, published...); err != nil {
+			h.logger.Warn().
+				Err(err).
+				Int("events", len(published)).
+				Msg("Failed to publish events")
+		}
+	}
+}
+
+// publishCompletion publishes WorkoutCompleted for w, PRAchieved for each
+// record it set and AchievementUnlocked for each achievement those earn, and
+// counts w towards its program's compliance
+func (h *LambdaHandler) publishCompletion(ctx context.Context, w *workout.Workout) {
+	h.recordCompliance(ctx, *w)
+
+	history, err := h.loadedWorkouts(ctx, w.UserID)
+	if err != nil {
+		h.logger.Warn().
+			Err(err).
+			Str("workout_id", w.ID).
+			Msg("Failed to load history for PR events")
+		h.publish(ctx, events.WorkoutCompleted(*w))
+		return
+	}
+
+	completed := *w
+	for _, past := range history {
+		if past.ID == w.ID {
+			completed = past
+		}
+	}
+	published := []events.Event{events.WorkoutCompleted(completed)}
+	published = append(published, events.PRsAchieved(history, *w)...)
+	published = append(published, h.awardAchievements(ctx, w.UserID, published, history)...)
+	h.publish(ctx, published...)
+}
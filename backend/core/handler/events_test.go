@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"athlete-forge/events"
+)
+
+// recordingPublisher collects published events, failing when err is set
+type recordingPublisher struct {
+	events []events.Event
+	err    error
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, published ...events.Event) error {
+	p.events = append(p.events, published...)
+	return p.err
+}
+
+func TestLambdaHandler_DomainEvents(t *testing.T) {
+	ctx := context.Background()
+	squat := func(weight string) string {
+		return `{"status":"completed","exercises":[{"name":"Squat","sets":[{"reps":5,"weight":` + weight + `}]}]}`
+	}
+
+	t.Run("publishes completions and the PRs they set", func(t *testing.T) {
+		// Arrange
+		publisher := &recordingPublisher{}
+		h := NewLambdaHandler(zerolog.Nop(), WithEventPublisher(publisher))
+		createWorkout(t, h, "user-1", squat("100"))
+
+		// Act
+		createWorkout(t, h, "user-1", squat("110"))
+
+		// Assert
+		var types []string
+		for _, e := range publisher.events {
+			types = append(types, e.Type)
+		}
+		want := []string{events.TypeWorkoutCompleted, events.TypeAchievementUnlocked, events.TypeWorkoutCompleted, events.TypePRAchieved}
+		if strings.Join(types, ",") != strings.Join(want, ",") {
+			t.Errorf("unexpected events: %v", types)
+		}
+	})
+
+	t.Run("publishes assigned programs", func(t *testing.T) {
+		// Arrange
+		publisher := &recordingPublisher{}
+		h := NewLambdaHandler(zerolog.Nop(), WithEventPublisher(publisher))
+
+		// Act
+		p := createProgram(t, h, "user-1", linearProgramBody)
+
+		// Assert
+		if len(publisher.events) != 1 || publisher.events[0].Type != events.TypeProgramAssigned || publisher.events[0].Data.(events.ProgramAssignedData).ProgramID != p.ID {
+			t.Errorf("unexpected events: %+v", publisher.events)
+		}
+	})
+
+	t.Run("publishes to every destination when one fails", func(t *testing.T) {
+		// Arrange
+		bus := &recordingPublisher{err: errors.New("bus unavailable")}
+		stream := &recordingPublisher{}
+		h := NewLambdaHandler(zerolog.Nop(), WithEventPublisher(bus), WithEventPublisher(stream))
+
+		// Act
+		createProgram(t, h, "user-1", linearProgramBody)
+
+		// Assert
+		if len(bus.events) != 1 || len(stream.events) != 1 {
+			t.Errorf("expected the event sent to both publishers, got %d and %d", len(bus.events), len(stream.events))
+		}
+	})
+
+	t.Run("requests succeed when publishing fails", func(t *testing.T) {
+		// Arrange
+		publisher := &recordingPublisher{err: errors.New("bus unavailable")}
+		h := NewLambdaHandler(zerolog.Nop(), WithEventPublisher(publisher))
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("POST", "/api/workouts", "user-1", nil, squat("100")))
+
+		// Assert
+		if err != nil || response.StatusCode != 201 {
+			t.Errorf("expected the workout to be created, got %d: %s", response.StatusCode, response.Body)
+		}
+	})
+}
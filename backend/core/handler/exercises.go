@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"athlete-forge/exercise"
+	"athlete-forge/media"
+)
+
+// CatalogExercise is a catalog exercise with its place in the taxonomy and its
+// demonstration media. Search results also say whether the user has favorited
+// the exercise and when they last trained it
+type CatalogExercise struct {
+	exercise.Exercise
+	Category         string        `json:"category"`
+	PrimaryMuscles   []string      `json:"primaryMuscles"`
+	SecondaryMuscles []string      `json:"secondaryMuscles"`
+	Media            []media.Media `json:"media,omitempty"`
+	Favorite         bool          `json:"favorite,omitempty"`
+	LastUsedAt       *time.Time    `json:"lastUsedAt,omitempty"`
+}
+
+// catalogExercises describes exercises with their categories, muscle groups
+// and media, with media links lasting extra longer than the media's own TTL
+func (h *LambdaHandler) catalogExercises(ctx context.Context, exercises []exercise.Exercise, extra time.Duration) ([]CatalogExercise, error) {
+	all, err := h.exerciseMedia.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := h.signMedia(ctx, all, extra); err != nil {
+		return nil, err
+	}
+	byExercise := map[string][]media.Media{}
+	for _, m := range all {
+		byExercise[m.Exercise] = append(byExercise[m.Exercise], m)
+	}
+
+	results := make([]CatalogExercise, 0, len(exercises))
+	for _, e := range exercises {
+		results = append(results, CatalogExercise{
+			Exercise:         e,
+			Category:         e.Category(),
+			PrimaryMuscles:   e.PrimaryMuscles(),
+			SecondaryMuscles: e.SecondaryMuscles(),
+			Media:            byExercise[e.Name],
+		})
+	}
+	return results, nil
+}
+
+// catalogExercise returns the catalog exercise named by the {name} path
+// parameter, which may be URL-escaped or use hyphens for spaces
+func catalogExercise(event *APIGatewayProxyEvent) (exercise.Exercise, bool) {
+	name, err := url.PathUnescape(event.PathParameters["name"])
+	if err != nil {
+		return exercise.Exercise{}, false
+	}
+	if e, ok := exercise.Lookup(name); ok {
+		return e, true
+	}
+	return exercise.Lookup(strings.ReplaceAll(name, "-", " "))
+}
+
+// handleExerciseTaxonomy returns the movement categories, patterns and muscle
+// groups exercises are classified by. Like the catalog it is the same for
+// every caller, so it needs no authentication and can be cached by the CDN
+func (h *LambdaHandler) handleExerciseTaxonomy(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	return h.createJSONResponse(200, exercise.DescribeTaxonomy())
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"athlete-forge/exercise"
+)
+
+func TestLambdaHandler_ExerciseTaxonomy(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("the taxonomy is public and cacheable", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("GET", "/api/exercises/taxonomy", "", nil, ""))
+
+		// Assert
+		var taxonomy exercise.Taxonomy
+		json.Unmarshal([]byte(response.Body), &taxonomy)
+		if response.StatusCode != 200 || len(taxonomy.Categories) != 4 || len(taxonomy.Muscles) != len(exercise.Muscles) {
+			t.Fatalf("unexpected taxonomy %d: %s", response.StatusCode, response.Body)
+		}
+		if response.Headers["Cache-Control"] != catalogCacheControl {
+			t.Errorf("expected the taxonomy to be cacheable, got %v", response.Headers)
+		}
+	})
+
+	t.Run("catalog exercises carry their category and muscle groups", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("GET", "/api/exercises/catalog", "", nil, ""))
+
+		// Assert
+		var catalog []CatalogExercise
+		json.Unmarshal([]byte(response.Body), &catalog)
+		for _, e := range catalog {
+			if e.Name == "bench press" && (e.Category != exercise.CategoryPush || e.PrimaryMuscles[0] != exercise.MuscleChest || e.SecondaryMuscles[0] != exercise.MuscleShoulders) {
+				t.Errorf("unexpected bench press %+v", e)
+			}
+		}
+	})
+
+	t.Run("search filters by muscle group and category", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			query      map[string]string
+			wantStatus int
+			want       []string
+		}{
+			{"muscle", map[string]string{"q": "curl", "muscle": "biceps"}, 200, []string{"biceps curl"}},
+			{"category", map[string]string{"q": "press", "category": "push"}, 200, []string{"bench press", "dumbbell bench press", "floor press", "landmine press", "overhead press"}},
+			{"both", map[string]string{"muscle": "triceps", "category": "pull"}, 200, []string{}},
+			{"unknown muscle", map[string]string{"muscle": "forearms"}, 400, nil},
+			{"unknown category", map[string]string{"category": "carry"}, 400, nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// Act
+				response, _ := newTestHandler().HandleRequest(ctx, apiEvent("GET", "/api/exercises", "user-1", tt.query, ""))
+
+				// Assert
+				if response.StatusCode != tt.wantStatus {
+					t.Fatalf("expected status code %d, got %d: %s", tt.wantStatus, response.StatusCode, response.Body)
+				}
+				if tt.want == nil {
+					return
+				}
+				var results []CatalogExercise
+				json.Unmarshal([]byte(response.Body), &results)
+				names := []string{}
+				for _, e := range results {
+					names = append(names, e.Name)
+				}
+				if len(names) != len(tt.want) {
+					t.Fatalf("expected %v, got %v", tt.want, names)
+				}
+				for i := range names {
+					if names[i] != tt.want[i] {
+						t.Errorf("expected %v, got %v", tt.want, names)
+					}
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"athlete-forge/jobs"
+	"athlete-forge/l10n"
+	"athlete-forge/report"
+	"athlete-forge/store"
+)
+
+// exportLinkExpiry is how long export download links stay valid
+const exportLinkExpiry = time.Hour
+
+// ExportRequest is the body for requesting a training report export
+type ExportRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// handleCreateExport queues rendering of a training report PDF for a date range
+func (h *LambdaHandler) handleCreateExport(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var req ExportRequest
+	if err := decodeBody(event, &req); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	now := time.Now().UTC()
+	job := jobs.New(userID, JobRenderExport, now)
+	e := &report.Export{
+		UserID:    userID,
+		From:      req.From,
+		To:        req.To,
+		Status:    report.ExportPending,
+		JobID:     job.ID,
+		CreatedAt: now,
+	}
+	if _, _, err := e.Range(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if err := h.reports.SaveExport(ctx, e); err != nil {
+		return Response{}, err
+	}
+
+	job.SubjectID = e.ID
+	if err := h.enqueue(ctx, job, JobEvent{Job: JobRenderExport, UserID: userID, ID: e.ID}); err != nil {
+		return Response{}, err
+	}
+
+	// Jobs run in-process locally, so the export may already be rendered
+	current, err := h.reports.GetExport(ctx, userID, e.ID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.exportResponse(ctx, 202, current)
+}
+
+// handleGetExport returns an export's status and, once rendered, a download link
+func (h *LambdaHandler) handleGetExport(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	e, err := h.reports.GetExport(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Export not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	return h.exportResponse(ctx, 200, e)
+}
+
+// exportResponse adds a fresh pre-signed download link to ready exports
+func (h *LambdaHandler) exportResponse(ctx context.Context, status int, e *report.Export) (Response, error) {
+	if e.Status == report.ExportReady {
+		link, err := h.blobs.URL(ctx, e.Key, exportLinkExpiry)
+		if err != nil {
+			return Response{}, fmt.Errorf("failed to sign export link: %w", err)
+		}
+		e.DownloadURL = link
+	}
+	return h.createJSONResponse(status, e)
+}
+
+// runRenderExport renders an export's PDF to the blob store; rendering failures
+// are recorded on the export so the client stops polling
+func (h *LambdaHandler) runRenderExport(ctx context.Context, userID, id string) (JobResult, error) {
+	result := JobResult{Job: JobRenderExport}
+	e, err := h.reports.GetExport(ctx, userID, id)
+	if err != nil {
+		return result, fmt.Errorf("failed to load export %s: %w", id, err)
+	}
+	if e.Status != report.ExportPending {
+		return result, nil
+	}
+
+	renderErr := h.renderExport(ctx, e)
+	now := time.Now().UTC()
+	e.CompletedAt = &now
+	if renderErr != nil {
+		e.Status = report.ExportFailed
+		e.Error = "Report could not be generated"
+		result.Error = e.Error
+		result.Failed++
+		h.logger.Error().
+			Err(renderErr).
+			Str("user_id", userID).
+			Str("export_id", id).
+			Msg("Failed to render export")
+	} else {
+		e.Status = report.ExportReady
+		result.Processed++
+	}
+
+	if err := h.reports.SaveExport(ctx, e); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// renderExport builds the training report for e and uploads it as a PDF
+func (h *LambdaHandler) renderExport(ctx context.Context, e *report.Export) error {
+	from, to, err := e.Range()
+	if err != nil {
+		return err
+	}
+	in, err := h.summaryInput(ctx, e.UserID)
+	if err != nil {
+		return err
+	}
+	programs, err := h.programs.List(ctx, e.UserID)
+	if err != nil {
+		return err
+	}
+	p, err := h.profiles.Get(ctx, e.UserID)
+	if err != nil {
+		return err
+	}
+
+	weeks, err := h.compliance.List(ctx, e.UserID, "")
+	if err != nil {
+		return err
+	}
+
+	c := l10n.For(p.Locale)
+	training := report.BuildTraining(e.UserID, from, to, p.Unit, in, programs, weeks, c)
+	e.Key = e.ObjectKey()
+	return h.blobs.Put(ctx, e.Key, "application/pdf", report.RenderTrainingPDF(training, c))
+}
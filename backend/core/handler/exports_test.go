@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"athlete-forge/blob"
+	"athlete-forge/report"
+)
+
+// recordingDispatcher collects dispatched events instead of running them
+type recordingDispatcher struct {
+	events []interface{}
+}
+
+func (d *recordingDispatcher) Dispatch(ctx context.Context, event interface{}) error {
+	d.events = append(d.events, event)
+	return nil
+}
+
+func TestLambdaHandler_Exports(t *testing.T) {
+	ctx := context.Background()
+	today := time.Now().UTC().Format("2006-01-02")
+	body := `{"from":"` + time.Now().UTC().AddDate(0, 0, -30).Format("2006-01-02") + `","to":"` + today + `"}`
+
+	t.Run("renders the PDF and links to it", func(t *testing.T) {
+		// Arrange
+		blobs := blob.NewMemoryStore()
+		h := NewLambdaHandler(zerolog.Nop(), WithBlobStore(blobs))
+		createWorkout(t, h, "user-1", `{"status":"completed","exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]}]}`)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/reports/exports", "user-1", nil, body))
+
+		// Assert
+		if response.StatusCode != 202 {
+			t.Fatalf("expected status code 202, got %d: %s", response.StatusCode, response.Body)
+		}
+		var e report.Export
+		json.Unmarshal([]byte(response.Body), &e)
+		if e.Status != report.ExportReady || !strings.HasPrefix(e.DownloadURL, "memory://") {
+			t.Errorf("unexpected export: %s", response.Body)
+		}
+		obj, ok := blobs.Get("exports/user-1/" + e.ID + ".pdf")
+		if !ok || obj.ContentType != "application/pdf" || !bytes.HasPrefix(obj.Data, []byte("%PDF-")) {
+			t.Error("expected rendered PDF in the blob store")
+		}
+	})
+
+	t.Run("queues rendering when a dispatcher is configured", func(t *testing.T) {
+		// Arrange
+		dispatcher := &recordingDispatcher{}
+		h := NewLambdaHandler(zerolog.Nop(), WithDispatcher(dispatcher))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/reports/exports", "user-1", nil, body))
+		var e report.Export
+		json.Unmarshal([]byte(response.Body), &e)
+		status, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/reports/exports/"+e.ID, "user-1", nil, ""))
+
+		// Assert
+		if e.Status != report.ExportPending || e.DownloadURL != "" {
+			t.Errorf("expected pending export, got %s", response.Body)
+		}
+		if len(dispatcher.events) != 1 || e.JobID == "" || dispatcher.events[0] != (JobEvent{Job: JobRenderExport, UserID: "user-1", ID: e.ID, JobID: e.JobID}) {
+			t.Errorf("unexpected dispatched events: %+v", dispatcher.events)
+		}
+		if status.StatusCode != 200 {
+			t.Errorf("expected status code 200, got %d", status.StatusCode)
+		}
+	})
+
+	t.Run("dispatched job renders the export", func(t *testing.T) {
+		// Arrange
+		dispatcher := &recordingDispatcher{}
+		h := NewLambdaHandler(zerolog.Nop(), WithDispatcher(dispatcher))
+		h.HandleRequest(ctx, apiEvent("POST", "/api/reports/exports", "user-1", nil, body))
+
+		// Act
+		h.HandleRequest(ctx, dispatcher.events[0])
+
+		// Assert
+		job := dispatcher.events[0].(JobEvent)
+		e, _ := h.reports.GetExport(ctx, "user-1", job.ID)
+		if e.Status != report.ExportReady {
+			t.Errorf("expected ready export, got %+v", e)
+		}
+	})
+
+	t.Run("rejects invalid ranges", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("POST", "/api/reports/exports", "user-1", nil, `{"from":"2024-03-01","to":"2026-03-01"}`))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("hides other users' exports", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/reports/exports", "user-1", nil, body))
+		var e report.Export
+		json.Unmarshal([]byte(response.Body), &e)
+
+		// Act
+		other, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/reports/exports/"+e.ID, "user-2", nil, ""))
+
+		// Assert
+		if other.StatusCode != 404 {
+			t.Errorf("expected status code 404, got %d", other.StatusCode)
+		}
+	})
+}
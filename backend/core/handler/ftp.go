@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"athlete-forge/ftp"
+)
+
+// FTPResponse is a user's current FTP, how it has changed and a higher FTP
+// their recent rides suggest
+type FTPResponse struct {
+	FTP        int             `json:"ftp"`
+	History    []ftp.Entry     `json:"history"`
+	Suggestion *ftp.Suggestion `json:"suggestion,omitempty"`
+}
+
+// FTPRequest is the body for setting the user's FTP
+type FTPRequest struct {
+	Watts int `json:"watts"`
+}
+
+// handleGetFTP returns the user's FTP and its history, with a suggestion when
+// a ride in the last six weeks points to a higher FTP
+func (h *LambdaHandler) handleGetFTP(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	response, err := h.ftpResponse(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, response)
+}
+
+// handlePutFTP sets the user's FTP on their profile and records the change
+func (h *LambdaHandler) handlePutFTP(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var req FTPRequest
+	if err := decodeBody(event, &req); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if err := ftp.Validate(req.Watts); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if err := h.setFTP(ctx, userID, ftp.Entry{Watts: req.Watts, Source: ftp.SourceManual}); err != nil {
+		return Response{}, err
+	}
+
+	response, err := h.ftpResponse(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, response)
+}
+
+// handleAcceptFTPSuggestion sets the user's FTP to the one their recent rides
+// suggest
+func (h *LambdaHandler) handleAcceptFTPSuggestion(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	current, err := h.ftpResponse(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	if current.Suggestion == nil {
+		return h.createErrorResponse(404, "No FTP suggestion"), nil
+	}
+	entry := ftp.Entry{Watts: current.Suggestion.Watts, Source: ftp.SourceDetected, ActivityID: current.Suggestion.ActivityID}
+	if err := h.setFTP(ctx, userID, entry); err != nil {
+		return Response{}, err
+	}
+
+	response, err := h.ftpResponse(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, response)
+}
+
+// ftpResponse gathers the user's FTP, history and suggestion
+func (h *LambdaHandler) ftpResponse(ctx context.Context, userID string) (*FTPResponse, error) {
+	p, err := h.profiles.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := h.ftps.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	activities, err := h.activities.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &FTPResponse{
+		FTP:        p.FTP,
+		History:    history.Entries,
+		Suggestion: ftp.Detect(activities, p.FTP, time.Now().UTC()),
+	}, nil
+}
+
+// setFTP saves e's watts as the user's FTP and records the change
+func (h *LambdaHandler) setFTP(ctx context.Context, userID string, e ftp.Entry) error {
+	p, err := h.profiles.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	previous := p.FTP
+	p.FTP = e.Watts
+	if err := h.profiles.Save(ctx, p); err != nil {
+		return fmt.Errorf("failed to save profile: %w", err)
+	}
+	if previous == e.Watts {
+		return nil
+	}
+	e.SetAt = time.Now().UTC()
+	_, err = h.ftps.Record(ctx, userID, e)
+	return err
+}
+
+// recordFTPChange records a profile update that changed the user's FTP
+func (h *LambdaHandler) recordFTPChange(ctx context.Context, userID string, previous, current int) error {
+	if current == previous || current == 0 {
+		return nil
+	}
+	_, err := h.ftps.Record(ctx, userID, ftp.Entry{Watts: current, Source: ftp.SourceManual, SetAt: time.Now().UTC()})
+	return err
+}
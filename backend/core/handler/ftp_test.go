@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"athlete-forge/ftp"
+)
+
+// poweredRideBody returns an activity body for a ride starting at start
+// holding watts for seconds, with a power sample every 10 seconds
+func poweredRideBody(start time.Time, seconds, watts int) string {
+	samples := []string{`{"offset": 0, "distance": 0}`}
+	for s := 10; s <= seconds; s += 10 {
+		samples = append(samples, fmt.Sprintf(`{"offset": %d, "distance": %d, "power": %d}`, s, s*9, watts))
+	}
+	return fmt.Sprintf(`{"sport": "ride", "startTime": %q, "durationSeconds": %d, "samples": [%s]}`,
+		start.Format(time.RFC3339), seconds, strings.Join(samples, ","))
+}
+
+func TestLambdaHandler_FTP(t *testing.T) {
+	ctx := context.Background()
+	yesterday := time.Now().UTC().AddDate(0, 0, -1)
+
+	t.Run("profile FTP scores ride imports and is recorded in history", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/profile", "user-1", nil, `{"unit":"kg","barWeight":20,"ftp":250}`))
+
+		// Act
+		a := createActivity(t, h, "user-1", poweredRideBody(yesterday, 3600, 250))
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/cycling/ftp", "user-1", nil, ""))
+
+		// Assert
+		if a.Ride == nil || a.Ride.NormalizedPower != 250 || a.Ride.IntensityFactor != 1 || a.Ride.TSS != 100 {
+			t.Errorf("unexpected ride summary: %+v", a.Ride)
+		}
+		var result FTPResponse
+		json.Unmarshal([]byte(response.Body), &result)
+		if result.FTP != 250 || len(result.History) != 1 || result.History[0].Source != ftp.SourceManual || result.Suggestion != nil {
+			t.Errorf("unexpected FTP: %+v", result)
+		}
+	})
+
+	t.Run("suggests and accepts a higher FTP from a hard ride", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/cycling/ftp", "user-1", nil, `{"watts": 240}`))
+		a := createActivity(t, h, "user-1", poweredRideBody(yesterday, 1500, 280))
+
+		// Act
+		before, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/cycling/ftp", "user-1", nil, ""))
+		accepted, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/cycling/ftp/accept", "user-1", nil, ""))
+
+		// Assert
+		var suggested FTPResponse
+		json.Unmarshal([]byte(before.Body), &suggested)
+		if suggested.Suggestion == nil || suggested.Suggestion.Watts != 266 || suggested.Suggestion.ActivityID != a.ID {
+			t.Fatalf("unexpected suggestion: %+v", suggested.Suggestion)
+		}
+		var result FTPResponse
+		json.Unmarshal([]byte(accepted.Body), &result)
+		if result.FTP != 266 || len(result.History) != 2 || result.History[1].Source != ftp.SourceDetected || result.Suggestion != nil {
+			t.Errorf("unexpected FTP after accepting: %+v", result)
+		}
+	})
+
+	t.Run("accepting without a suggestion returns 404", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("POST", "/api/cycling/ftp/accept", "user-1", nil, ""))
+
+		// Assert
+		if response.StatusCode != 404 {
+			t.Errorf("expected status code 404, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("rejects an implausible FTP", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("PUT", "/api/cycling/ftp", "user-1", nil, `{"watts": 2000}`))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+}
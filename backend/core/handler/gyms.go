@@ -0,0 +1,272 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"athlete-forge/exercise"
+	"athlete-forge/gym"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+// handleListGyms returns the user's gyms
+func (h *LambdaHandler) handleListGyms(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	gyms, err := h.gyms.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, gyms)
+}
+
+// handleGetGym returns a single gym
+func (h *LambdaHandler) handleGetGym(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	g, err := h.gyms.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Gym not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, g)
+}
+
+// handleCreateGym adds a gym and its equipment
+func (h *LambdaHandler) handleCreateGym(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var g gym.Gym
+	if err := decodeBody(event, &g); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	now := time.Now().UTC()
+	g.ID = ""
+	g.UserID = userID
+	g.CreatedAt = now
+	g.UpdatedAt = now
+
+	if err := g.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if err := h.gyms.Save(ctx, &g); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(201, g)
+}
+
+// handlePutGym replaces a gym's name, equipment and default flag
+func (h *LambdaHandler) handlePutGym(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	existing, err := h.gyms.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Gym not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	var g gym.Gym
+	if err := decodeBody(event, &g); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	g.ID = existing.ID
+	g.UserID = userID
+	g.CreatedAt = existing.CreatedAt
+	g.UpdatedAt = time.Now().UTC()
+
+	if err := g.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if err := h.gyms.Save(ctx, &g); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, g)
+}
+
+// handleDeleteGym removes a gym, returning what was removed
+func (h *LambdaHandler) handleDeleteGym(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	g, err := h.gyms.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Gym not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	if err := h.gyms.Delete(ctx, userID, g.ID); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, g)
+}
+
+// handleGymCheckIn checks the user in at a gym: their active workout, the most
+// recently started if there are several, is tagged with the gym, or a workout is
+// started there when none is active
+func (h *LambdaHandler) handleGymCheckIn(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	g, err := h.gyms.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Gym not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	workouts, err := h.workouts.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var active *workout.Workout
+	for i := range workouts {
+		if workouts[i].Status == workout.StatusActive && (active == nil || !workouts[i].StartedAt.Before(active.StartedAt)) {
+			active = &workouts[i]
+		}
+	}
+	status := 200
+	if active == nil {
+		active = &workout.Workout{UserID: userID, Status: workout.StatusActive, StartedAt: time.Now().UTC(), Exercises: []workout.Exercise{}}
+		status = 201
+	}
+	active.GymID = g.ID
+	if err := h.workouts.Save(ctx, active); err != nil {
+		return Response{}, err
+	}
+	if status == 201 {
+		if err := h.indexSession(ctx, active); err != nil {
+			return Response{}, err
+		}
+	}
+
+	h.logger.Info().
+		Str("function", "handleGymCheckIn").
+		Str("user_id", userID).
+		Str("gym_id", g.ID).
+		Str("workout_id", active.ID).
+		Msg("Checked in at gym")
+
+	return h.createJSONResponse(status, active)
+}
+
+// handleGymStats returns how often the user trains at a gym and the lifts they
+// train there most
+func (h *LambdaHandler) handleGymStats(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	g, err := h.gyms.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Gym not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	workouts, err := h.loadedWorkouts(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, gym.BuildStats(g.ID, workouts, time.Now().UTC()))
+}
+
+// handleSearchExercises returns catalog exercises matching ?q=, limited to those
+// the gym in ?gymId= (default the user's default gym) has equipment for and,
+// with ?muscle= or ?category=, to those training that muscle group or in that
+// movement category, with their media. The user's favorites come first, then
+// the exercises they trained most recently, then the rest by name
+func (h *LambdaHandler) handleSearchExercises(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	muscle, category := event.QueryStringParameters["muscle"], event.QueryStringParameters["category"]
+	if _, ok := exercise.LookupMuscle(muscle); muscle != "" && !ok {
+		return h.createErrorResponse(400, fmt.Sprintf("unknown muscle group %q; see /api/exercises/taxonomy", muscle)), nil
+	}
+	if category != "" && !exercise.IsCategory(category) {
+		return h.createErrorResponse(400, fmt.Sprintf("unknown category %q; see /api/exercises/taxonomy", category)), nil
+	}
+
+	g, errResponse, err := h.selectGym(ctx, userID, event.QueryStringParameters["gymId"])
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	results := []exercise.Exercise{}
+	for _, e := range exercise.Search(event.QueryStringParameters["q"]) {
+		if g != nil && len(e.Missing(g.Equipment)) > 0 {
+			continue
+		}
+		if (muscle != "" && !e.Trains(muscle)) || (category != "" && e.Category() != category) {
+			continue
+		}
+		results = append(results, e)
+	}
+	s, err := h.shortlists.Get(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	s.Sort(results)
+	described, err := h.catalogExercises(ctx, results, 0)
+	if err != nil {
+		return Response{}, err
+	}
+	for i := range described {
+		described[i].Favorite = s.IsFavorite(described[i].Name)
+		described[i].LastUsedAt = s.LastUsed(described[i].Name)
+	}
+	return h.createJSONResponse(200, described)
+}
+
+// selectGym returns the gym with gymID, or the user's default gym when gymID is
+// empty; with no gym to select it returns nil and equipment is not filtered
+func (h *LambdaHandler) selectGym(ctx context.Context, userID, gymID string) (*gym.Gym, *Response, error) {
+	var g *gym.Gym
+	var err error
+	if gymID != "" {
+		g, err = h.gyms.Get(ctx, userID, gymID)
+		if errors.Is(err, store.ErrNotFound) {
+			response := h.createErrorResponse(404, "Gym not found")
+			return nil, &response, nil
+		}
+	} else {
+		g, err = h.gyms.Default(ctx, userID)
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, nil, nil
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return g, nil, nil
+}
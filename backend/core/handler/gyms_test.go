@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"athlete-forge/exercise"
+	"athlete-forge/gym"
+	"athlete-forge/workout"
+)
+
+func TestLambdaHandler_Gyms(t *testing.T) {
+	ctx := context.Background()
+	const garage = `{"name":"Garage","equipment":["dumbbells","bench","pull-up-bar"],"default":true}`
+	const program = `{"name":"Full Body","exercises":[
+		{"exercise":"Squat","sets":3,"reps":5,"weight":100,"rule":{"type":"linear","increment":2.5}},
+		{"exercise":"Pull-up","sets":3,"reps":8,"weight":0,"rule":{"type":"linear","increment":1}}
+	]}`
+
+	t.Run("searches only exercises the default gym supports", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		h.HandleRequest(ctx, apiEvent("POST", "/api/gyms", "user-1", nil, garage))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/exercises", "user-1", map[string]string{"q": "squat"}, ""))
+
+		// Assert
+		var results []exercise.Exercise
+		json.Unmarshal([]byte(response.Body), &results)
+		if response.StatusCode != 200 || len(results) != 1 || results[0].Name != "goblet squat" {
+			t.Errorf("unexpected results %d: %s", response.StatusCode, response.Body)
+		}
+	})
+
+	t.Run("program creation leaves out exercises the gym cannot support", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		h.HandleRequest(ctx, apiEvent("POST", "/api/gyms", "user-1", nil, garage))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/programs", "user-1", nil, program))
+
+		// Assert
+		var created ProgramResponse
+		json.Unmarshal([]byte(response.Body), &created)
+		if response.StatusCode != 201 || len(created.Exercises) != 1 || created.Exercises[0].Exercise != "Pull-up" || created.GymID == "" {
+			t.Fatalf("unexpected program %d: %s", response.StatusCode, response.Body)
+		}
+		if len(created.Warnings) != 1 || created.Warnings[0].Exercise != "Squat" {
+			t.Errorf("unexpected warnings: %+v", created.Warnings)
+		}
+	})
+
+	t.Run("users without gyms are not filtered", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("POST", "/api/programs", "user-1", nil, program))
+
+		// Assert
+		var created ProgramResponse
+		json.Unmarshal([]byte(response.Body), &created)
+		if len(created.Exercises) != 2 || len(created.Warnings) != 0 {
+			t.Errorf("unexpected program: %s", response.Body)
+		}
+	})
+
+	t.Run("unknown gym returns 404", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("GET", "/api/exercises", "user-1", map[string]string{"gymId": "missing"}, ""))
+
+		// Assert
+		if response.StatusCode != 404 {
+			t.Errorf("expected status code 404, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("checking in tags the active workout or starts one at the gym", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		created, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/gyms", "user-1", nil, `{"name":"Garage","location":{"address":"12 High Street","latitude":51.5,"longitude":-0.12}}`))
+		var g gym.Gym
+		json.Unmarshal([]byte(created.Body), &g)
+
+		// Act
+		started, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/gyms/"+g.ID+"/check-in", "user-1", nil, ""))
+		again, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/gyms/"+g.ID+"/check-in", "user-1", nil, ""))
+
+		// Assert
+		var first, second workout.Workout
+		json.Unmarshal([]byte(started.Body), &first)
+		json.Unmarshal([]byte(again.Body), &second)
+		if started.StatusCode != 201 || first.GymID != g.ID || first.Status != workout.StatusActive {
+			t.Fatalf("expected a workout started at the gym, got %d: %s", started.StatusCode, started.Body)
+		}
+		if again.StatusCode != 200 || second.ID != first.ID {
+			t.Errorf("expected the active workout tagged again, got %d: %s", again.StatusCode, again.Body)
+		}
+	})
+
+	t.Run("workouts can only be tagged with the user's own gyms", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		created, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/gyms", "user-1", nil, garage))
+		var g gym.Gym
+		json.Unmarshal([]byte(created.Body), &g)
+
+		// Act
+		own := createWorkout(t, h, "user-1", fmt.Sprintf(`{"gymId":%q,"exercises":[]}`, g.ID))
+		other := createWorkout(t, h, "user-2", fmt.Sprintf(`{"gymId":%q,"exercises":[]}`, g.ID))
+		listed, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/workouts", "user-1", map[string]string{"gymId": g.ID}, ""))
+
+		// Assert
+		if own.StatusCode != 201 || other.StatusCode != 400 {
+			t.Errorf("unexpected statuses: own %d, other %d", own.StatusCode, other.StatusCode)
+		}
+		var workouts []workout.Workout
+		json.Unmarshal([]byte(listed.Body), &workouts)
+		if len(workouts) != 1 || workouts[0].GymID != g.ID {
+			t.Errorf("expected the tagged workout, got %s", listed.Body)
+		}
+	})
+
+	t.Run("stats count completed workouts and the lifts trained at the gym", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		created, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/gyms", "user-1", nil, garage))
+		var g gym.Gym
+		json.Unmarshal([]byte(created.Body), &g)
+		createWorkout(t, h, "user-1", fmt.Sprintf(`{"gymId":%q,"status":"completed","exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100},{"reps":5,"weight":100}]},{"name":"Pull-up","sets":[{"reps":8,"weight":0}]}]}`, g.ID))
+		createWorkout(t, h, "user-1", fmt.Sprintf(`{"gymId":%q,"status":"completed","exercises":[{"name":"squat","sets":[{"reps":5,"weight":105}]}]}`, g.ID))
+		createWorkout(t, h, "user-1", `{"status":"completed","exercises":[{"name":"Deadlift","sets":[{"reps":5,"weight":140}]}]}`)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/gyms/"+g.ID+"/stats", "user-1", nil, ""))
+
+		// Assert
+		var stats gym.Stats
+		json.Unmarshal([]byte(response.Body), &stats)
+		if response.StatusCode != 200 || stats.Workouts != 2 || stats.RecentWorkouts != 2 || stats.WorkoutsPerWeek != 0.5 {
+			t.Fatalf("unexpected stats %d: %s", response.StatusCode, response.Body)
+		}
+		if len(stats.TopLifts) != 2 || stats.TopLifts[0].Exercise != "Squat" || stats.TopLifts[0].Sets != 3 || stats.TopLifts[0].Workouts != 2 {
+			t.Errorf("expected squat as the top lift, got %+v", stats.TopLifts)
+		}
+	})
+
+	t.Run("gym locations are validated", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("POST", "/api/gyms", "user-1", nil, `{"name":"Park","location":{"latitude":91}}`))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+}
@@ -2,34 +2,128 @@ package handler
 
 import (
 	"context"
+	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog"
+	"athlete-forge/achievement"
+	"athlete-forge/announcement"
+	"athlete-forge/autocomplete"
+	"athlete-forge/auth"
+	"athlete-forge/backfill"
+	"athlete-forge/blob"
+	"athlete-forge/bulkedit"
+	"athlete-forge/calendar"
+	"athlete-forge/cdn"
+	"athlete-forge/clientconfig"
+	"athlete-forge/clientversion"
+	"athlete-forge/cardio"
+	"athlete-forge/compliance"
+	"athlete-forge/consent"
+	"athlete-forge/dailylog"
+	"athlete-forge/dispatch"
+	"athlete-forge/envelope"
+	"athlete-forge/errreport"
+	"athlete-forge/events"
+	"athlete-forge/gym"
+	"athlete-forge/handle"
+	"athlete-forge/i18n"
+	"athlete-forge/idempotency"
+	"athlete-forge/injury"
+	"athlete-forge/instruction"
+	"athlete-forge/interval"
+	"athlete-forge/multisport"
+	"athlete-forge/ftp"
+	"athlete-forge/integration"
+	"athlete-forge/jobs"
+	"athlete-forge/logconfig"
+	"athlete-forge/marketplace"
+	"athlete-forge/media"
+	"athlete-forge/messaging"
+	"athlete-forge/metrics"
+	"athlete-forge/migrations"
+	"athlete-forge/moderation"
+	"athlete-forge/nutrition"
+	"athlete-forge/percentile"
+	"athlete-forge/profile"
+	"athlete-forge/publicprofile"
+	"athlete-forge/program"
+	"athlete-forge/readiness"
+	"athlete-forge/realtime"
+	"athlete-forge/region"
+	"athlete-forge/residency"
+	"athlete-forge/report"
+	"athlete-forge/sessionindex"
+	"athlete-forge/sharecard"
+	"athlete-forge/social"
+	"athlete-forge/share"
+	"athlete-forge/shortlist"
+	"athlete-forge/stepfn"
+	"athlete-forge/store"
+	"athlete-forge/syncindex"
+	"athlete-forge/telemetry"
+	"athlete-forge/trainingmax"
+	"athlete-forge/userindex"
+	"athlete-forge/warehouse"
+	"athlete-forge/webhook"
+	"athlete-forge/workout"
 )
 
 // APIGatewayProxyEvent represents the API Gateway proxy integration event
 type APIGatewayProxyEvent struct {
-	HTTPMethod string            `json:"httpMethod"`
-	Path       string            `json:"path"`
-	Headers    map[string]string `json:"headers"`
-	Body       string            `json:"body"`
+	HTTPMethod            string            `json:"httpMethod"`
+	Path                  string            `json:"path"`
+	Headers               map[string]string `json:"headers"`
+	QueryStringParameters map[string]string `json:"queryStringParameters"`
+	PathParameters        map[string]string `json:"pathParameters"`
+	RequestContext        RequestContext    `json:"requestContext"`
+	Body                  string            `json:"body"`
+	IsBase64Encoded       bool              `json:"isBase64Encoded"`
+
+	// session holds the verified claims of an active session token, set before routing
+	session *auth.SessionClaims
+	// compact is set for /api/compact/... requests, answered in the compact profile
+	compact bool
+}
+
+// RequestContext carries the API Gateway request metadata used by handlers
+type RequestContext struct {
+	RequestID  string                 `json:"requestId"`
+	DomainName string                 `json:"domainName"`
+	Stage      string                 `json:"stage"`
+	Authorizer map[string]interface{} `json:"authorizer"`
+}
+
+// UserID returns the authenticated user's ID from the Cognito authorizer claims,
+// or an empty string for anonymous requests
+func (e *APIGatewayProxyEvent) UserID() string {
+	claims, ok := e.RequestContext.Authorizer["claims"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	sub, _ := claims["sub"].(string)
+	return sub
 }
 
 // Response represents the Lambda function response structure
 type Response struct {
-	StatusCode int               `json:"statusCode"`
-	Headers    map[string]string `json:"headers,omitempty"`
-	Body       string            `json:"body"`
+	StatusCode      int               `json:"statusCode"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"isBase64Encoded,omitempty"`
 }
 
 // HealthCheckResponse represents the health check endpoint response
 type HealthCheckResponse struct {
-	Status    string `json:"status"`
-	Timestamp string `json:"timestamp"`
-	Version   string `json:"version,omitempty"`
-	Message   string `json:"message,omitempty"`
+	Status    string        `json:"status"`
+	Timestamp string        `json:"timestamp"`
+	Version   string        `json:"version,omitempty"`
+	Message   string        `json:"message,omitempty"`
+	Region    *RegionHealth `json:"region,omitempty"`
 }
 
 // Handler interface defines the contract for Lambda request handling
@@ -39,19 +133,449 @@ type Handler interface {
 
 // LambdaHandler implements the Handler interface
 type LambdaHandler struct {
-	logger zerolog.Logger
+	logger        zerolog.Logger
+	logLevels     *logconfig.Levels
+	scope         *requestScope
+	errReporter   errreport.Reporter
+	release       string
+	environment   string
+	store         store.Store
+	schemas       *migrations.Registry
+	migrated      *migrations.Store
+	profiles      *profile.Repository
+	programs      *program.Repository
+	workouts      *workout.Repository
+	checkIns      *readiness.Repository
+	injuries      *injury.Repository
+	intervals     *interval.Repository
+	ftps          *ftp.Repository
+	multisport    *multisport.Repository
+	gyms          *gym.Repository
+	bulkEdits     *bulkedit.Repository
+	backfills     *backfill.Repository
+	jobs          *jobs.Repository
+	activities    *cardio.Repository
+	dailyLogs     *dailylog.Repository
+	foodSource    nutrition.Source
+	foods         *nutrition.Catalog
+	diary         *nutrition.Diary
+	users         *userindex.Index
+	reports       *report.Repository
+	shareCards    *sharecard.Repository
+	profilePages  *publicprofile.Repository
+	handles       *handle.Registry
+	social        *social.Graph
+	coaching      *messaging.Repository
+	announcements *announcement.Repository
+	percentiles   *percentile.Repository
+	achievements  *achievement.Repository
+	compliance    *compliance.Repository
+	consents      *consent.Repository
+	trainingMaxes *trainingmax.Repository
+	blobs         blob.Store
+	warehouse     blob.Store
+	mediaFiles    blob.Store
+	mediaSigner   media.Signer
+	exerciseMedia *media.Repository
+	instructions  *instruction.Repository
+	popularity    *autocomplete.Repository
+	suggestions   *autocomplete.Cache
+	shortlists    *shortlist.Repository
+	openSessions  *sessionindex.Index
+	synced        *syncindex.Index
+	staleSession  time.Duration
+	backfillRate  int
+	watermarks    *warehouse.Repository
+	dispatcher    dispatch.Dispatcher
+	stateMachine  dispatch.Dispatcher
+	callbacks     stepfn.Callbacks
+	streamDerived bool
+	publishers    []events.Publisher
+	metrics       metrics.Recorder
+	telemetry     telemetry.Sink
+	anonymizer    *telemetry.Anonymizer
+	cdn           cdn.Invalidator
+	regionName    string
+	primaryRegion string
+	region        *region.Registry
+	residency     *residency.Router
+	idempotency   *idempotency.Repository
+	marketplace   *marketplace.Repository
+	moderation    *moderation.Repository
+	shares        *share.Repository
+	poster        realtime.Poster
+	hub           *realtime.Hub
+	maxBodySize   int
+	keys          envelope.KeyProvider
+	calendars     *calendar.Repository
+	signer        *calendar.Signer
+	publicURL     string
+	providers     map[string]auth.Provider
+	sessions      *auth.Sessions
+	accounts      *auth.Users
+	authSessions  *auth.SessionRepository
+	webhooks      map[string]webhook.Verifier
+	webhookInbox  *webhook.Inbox
+	adapters      map[string]integration.Adapter
+	wearables     map[string]integration.RecoverySource
+	integrations  *integration.Repository
+	routes        []route
+	shadowRoutes  map[string]bool
+	minVersions   clientversion.Minimums
+	clientConfig  *clientconfig.Cache
+	shadowStore   store.Store
+	shadowHandler *LambdaHandler
+}
+
+// Option configures optional LambdaHandler dependencies
+type Option func(*LambdaHandler)
+
+// WithStore sets the persistence backend; an in-memory store is used when omitted
+func WithStore(s store.Store) Option {
+	return func(h *LambdaHandler) {
+		h.store = s
+	}
+}
+
+// WithFoodSource sets the external food database; Open Food Facts is used when omitted
+func WithFoodSource(source nutrition.Source) Option {
+	return func(h *LambdaHandler) {
+		h.foodSource = source
+	}
+}
+
+// WithBlobStore sets where generated files are stored; an in-memory store is used when omitted
+func WithBlobStore(b blob.Store) Option {
+	return func(h *LambdaHandler) {
+		h.blobs = b
+	}
+}
+
+// WithExerciseMedia stores exercise demonstration media in files and signs
+// links to it with signer; without a signer, links come from files itself
+func WithExerciseMedia(files blob.Store, signer media.Signer) Option {
+	return func(h *LambdaHandler) {
+		h.mediaFiles = files
+		h.mediaSigner = signer
+	}
+}
+
+// WithWarehouseStore sets where the warehouse export writes its Parquet files;
+// an in-memory store is used when omitted
+func WithWarehouseStore(b blob.Store) Option {
+	return func(h *LambdaHandler) {
+		h.warehouse = b
+	}
+}
+
+// WithDispatcher sets how background jobs are queued; jobs run in-process when omitted
+func WithDispatcher(d dispatch.Dispatcher) Option {
+	return func(h *LambdaHandler) {
+		h.dispatcher = d
+	}
+}
+
+// WithStateMachine sets where jobs that run in steps are started; they are
+// dispatched like any other job when omitted
+func WithStateMachine(d dispatch.Dispatcher) Option {
+	return func(h *LambdaHandler) {
+		h.stateMachine = d
+	}
+}
+
+// WithTaskCallbacks sets how tasks started with a task token report heartbeats
+// and results back to the state machine
+func WithTaskCallbacks(c stepfn.Callbacks) Option {
+	return func(h *LambdaHandler) {
+		h.callbacks = c
+	}
+}
+
+// WithStreamDerivedData leaves maintaining derived data, such as the index of
+// active users and stored weekly reports, to the table's stream rather than the
+// request handlers
+func WithStreamDerivedData() Option {
+	return func(h *LambdaHandler) {
+		h.streamDerived = true
+	}
+}
+
+// WithEventPublisher adds a destination domain events are published to, such as
+// the event bus or an analytics stream; none are published when omitted
+func WithEventPublisher(p events.Publisher) Option {
+	return func(h *LambdaHandler) {
+		h.publishers = append(h.publishers, p)
+	}
+}
+
+// WithWebSocketPoster sets how messages reach devices connected over the
+// WebSocket API; none are sent when omitted
+func WithWebSocketPoster(p realtime.Poster) Option {
+	return func(h *LambdaHandler) {
+		h.poster = p
+	}
+}
+
+// WithCDN sets how cached API responses are invalidated in the CDN in front of
+// the API; nothing is invalidated when omitted
+func WithCDN(c cdn.Invalidator) Option {
+	return func(h *LambdaHandler) {
+		h.cdn = c
+	}
+}
+
+// WithRegion runs the handler as region name of an active-passive pair sharing a
+// global table, with primary active until another region is promoted
+func WithRegion(name, primary string) Option {
+	return func(h *LambdaHandler) {
+		h.regionName = name
+		h.primaryRegion = primary
+	}
+}
+
+// WithResidency keeps each user's data in the table of the region they are
+// pinned to, routing through r in place of the store set by WithStore. Lookups
+// of users' accounts, shares and connections, and the active user, open
+// session and synced workout indexes, are kept with the user they find
+func WithResidency(r *residency.Router) Option {
+	return func(h *LambdaHandler) {
+		r.Index(auth.LookupPKPrefixes...)
+		r.Index(share.PKPrefix, realtime.RefPKPrefix, userindex.PK, sessionindex.PKPrefix, syncindex.PKPrefix)
+		h.residency = r
+		h.store = r
+	}
+}
+
+// WithMaxBodySize sets the largest request body routes accept in bytes, unless a
+// route sets its own limit; 1MB is used when omitted
+func WithMaxBodySize(n int) Option {
+	return func(h *LambdaHandler) {
+		h.maxBodySize = n
+	}
+}
+
+// WithStaleSessionAfter sets how long a workout session can stay active before
+// the stale session job finishes it for the user
+func WithStaleSessionAfter(d time.Duration) Option {
+	return func(h *LambdaHandler) {
+		h.staleSession = d
+	}
+}
+
+// WithBackfillWriteRate sets how many workouts a second historical backfills
+// write, leaving the rest of the table's capacity for requests; zero writes
+// as fast as the table accepts
+func WithBackfillWriteRate(perSecond int) Option {
+	return func(h *LambdaHandler) {
+		h.backfillRate = perSecond
+	}
+}
+
+// WithKeyProvider sets the master key provider for encrypted profile fields; a
+// random in-memory key is used when omitted, so encrypted fields only stay
+// readable for the life of the process
+func WithKeyProvider(keys envelope.KeyProvider) Option {
+	return func(h *LambdaHandler) {
+		h.keys = keys
+	}
+}
+
+// WithCalendarSecret sets the key that signs calendar feed URLs; a random key is
+// used when omitted, so feed URLs only stay valid for the life of the process
+func WithCalendarSecret(secret []byte) Option {
+	return func(h *LambdaHandler) {
+		h.signer = calendar.NewSigner(secret)
+	}
+}
+
+// WithPublicURL sets the base URL shared links are built from; the API Gateway
+// domain and stage the request arrived on are used when omitted
+func WithPublicURL(url string) Option {
+	return func(h *LambdaHandler) {
+		h.publicURL = strings.TrimSuffix(url, "/")
+	}
+}
+
+// WithAuthProvider enables sign-in through an external identity provider
+func WithAuthProvider(p auth.Provider) Option {
+	return func(h *LambdaHandler) {
+		h.providers[p.Name()] = p
+	}
+}
+
+// WithSessionSecret sets the key that signs session tokens; a random key is used
+// when omitted, so sessions only last for the life of the process
+func WithSessionSecret(secret []byte) Option {
+	return func(h *LambdaHandler) {
+		h.sessions = auth.NewSessions(secret)
+	}
+}
+
+// WithWebhookVerifier accepts webhook deliveries from the verifier's provider;
+// deliveries from providers without a verifier are rejected
+func WithWebhookVerifier(v webhook.Verifier) Option {
+	return func(h *LambdaHandler) {
+		h.webhooks[v.Provider()] = v
+	}
+}
+
+// WithIntegrationAdapter imports the adapter's provider's webhook events with
+// it, replacing the built-in Garmin or Polar adapter; the built-in Polar
+// adapter cannot connect accounts without the app's client credentials
+func WithIntegrationAdapter(a integration.Adapter) Option {
+	return func(h *LambdaHandler) {
+		h.adapters[a.Provider()] = a
+	}
+}
+
+// WithRecoverySource syncs check-in recovery readings from the source's
+// provider with it, replacing the built-in Whoop or Oura source, which cannot
+// refresh expired tokens without the app's client credentials
+func WithRecoverySource(s integration.RecoverySource) Option {
+	return func(h *LambdaHandler) {
+		h.wearables[s.Provider()] = s
+	}
 }
 
 // NewLambdaHandler creates a new instance of LambdaHandler with configured logger
-func NewLambdaHandler(logger zerolog.Logger) *LambdaHandler {
-	return &LambdaHandler{
-		logger: logger,
+func NewLambdaHandler(logger zerolog.Logger, opts ...Option) *LambdaHandler {
+	h := &LambdaHandler{
+		logger:       logger,
+		store:        store.NewMemoryStore(),
+		foodSource:   nutrition.NewOpenFoodFacts(),
+		blobs:        blob.NewMemoryStore(),
+		warehouse:    blob.NewMemoryStore(),
+		mediaFiles:   blob.NewMemoryStore(),
+		maxBodySize:  defaultMaxBodySize,
+		staleSession: defaultStaleSession,
+		backfillRate: defaultBackfillRate,
+		providers:    map[string]auth.Provider{},
+		webhooks:     map[string]webhook.Verifier{},
+		adapters: map[string]integration.Adapter{
+			webhook.ProviderGarmin: integration.NewGarmin(),
+			webhook.ProviderPolar:  integration.NewPolar("", ""),
+		},
+		wearables: map[string]integration.RecoverySource{
+			integration.ProviderWhoop: integration.NewWhoop("", ""),
+			integration.ProviderOura:  integration.NewOura("", ""),
+		},
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.scope = &requestScope{}
+	h.logger = h.logger.Hook(h.scope)
+	if h.errReporter == nil {
+		h.errReporter = errreport.NewLog(h.logger)
+	}
+	if h.signer == nil {
+		h.signer = calendar.NewSigner(randomSecret())
+	}
+	if h.sessions == nil {
+		h.sessions = auth.NewSessions(randomSecret())
+	}
+	if h.anonymizer == nil {
+		h.anonymizer = telemetry.NewAnonymizer(randomSecret())
 	}
+	if h.keys == nil {
+		h.keys, _ = envelope.NewLocalKeys("local", map[string][]byte{"local": randomSecret()})
+	}
+	if h.schemas == nil {
+		h.schemas = schemaMigrations()
+	}
+	h.useStore(h.store)
+	h.registerRoutes()
+	if h.shadowStore != nil {
+		h.shadowHandler = h.newShadowHandler()
+	}
+	h.checkShadowRoutes()
+	return h
+}
+
+// useStore builds the repositories on s, upgrading items through the schema
+// migrations
+func (h *LambdaHandler) useStore(s store.Store) {
+	h.migrated = migrations.NewStore(s, h.schemas)
+	h.store = h.migrated
+
+	h.profiles = profile.NewRepository(h.store, envelope.NewCipher(h.keys))
+	h.programs = program.NewRepository(h.store)
+	h.workouts = workout.NewRepository(h.store)
+	h.checkIns = readiness.NewRepository(h.store)
+	h.injuries = injury.NewRepository(h.store)
+	h.intervals = interval.NewRepository(h.store)
+	h.ftps = ftp.NewRepository(h.store)
+	h.multisport = multisport.NewRepository(h.store)
+	h.gyms = gym.NewRepository(h.store)
+	h.exerciseMedia = media.NewRepository(h.store)
+	h.instructions = instruction.NewRepository(h.store)
+	h.popularity = autocomplete.NewRepository(h.store)
+	h.suggestions = autocomplete.NewCache(h.popularity, suggestionsTTL)
+	h.shortlists = shortlist.NewRepository(h.store)
+	h.openSessions = sessionindex.New(h.store)
+	h.synced = syncindex.New(h.store)
+	h.bulkEdits = bulkedit.NewRepository(h.store)
+	h.backfills = backfill.NewRepository(h.store)
+	h.jobs = jobs.NewRepository(h.store)
+	h.activities = cardio.NewRepository(h.store)
+	h.dailyLogs = dailylog.NewRepository(h.store)
+	h.foods = nutrition.NewCatalog(h.store, h.foodSource)
+	h.diary = nutrition.NewDiary(h.store)
+	h.users = userindex.New(h.store)
+	h.reports = report.NewRepository(h.store)
+	h.shareCards = sharecard.NewRepository(h.store)
+	h.profilePages = publicprofile.NewRepository(h.store)
+	h.handles = handle.New(h.store)
+	h.social = social.NewGraph(h.store)
+	h.coaching = messaging.NewRepository(h.store)
+	h.announcements = announcement.NewRepository(h.store)
+	h.percentiles = percentile.NewRepository(h.store)
+	h.achievements = achievement.NewRepository(h.store)
+	h.compliance = compliance.NewRepository(h.store)
+	h.consents = consent.NewRepository(h.store)
+	h.trainingMaxes = trainingmax.NewRepository(h.store)
+	h.watermarks = warehouse.NewRepository(h.store)
+	h.calendars = calendar.NewRepository(h.store)
+	h.accounts = auth.NewUsers(h.store)
+	h.authSessions = auth.NewSessionRepository(h.store)
+	h.webhookInbox = webhook.NewInbox(h.store)
+	h.integrations = integration.NewRepository(h.store, envelope.NewCipher(h.keys))
+	h.idempotency = idempotency.NewRepository(h.store)
+	h.marketplace = marketplace.NewRepository(h.store)
+	h.moderation = moderation.NewRepository(h.store)
+	h.shares = share.NewRepository(h.store)
+	h.hub = realtime.NewHub(h.store, h.poster)
+	if h.regionName != "" {
+		h.region = region.New(h.store, h.regionName, h.primaryRegion)
+	}
+}
+
+// randomSecret returns a signing key for when none is configured
+func randomSecret() []byte {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	return secret
 }
 
-// HandleRequest processes the Lambda request and routes to appropriate handler
-func (h *LambdaHandler) HandleRequest(ctx context.Context, event interface{}) (Response, error) {
+// HandleRequest processes the Lambda request and routes to appropriate handler,
+// reporting a panic instead of letting it kill the instance
+func (h *LambdaHandler) HandleRequest(ctx context.Context, event interface{}) (response Response, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			response, err = h.recoverPanic(ctx, event, recovered)
+		}
+	}()
+	return h.handleRequest(ctx, event)
+}
+
+// handleRequest dispatches event by its kind
+func (h *LambdaHandler) handleRequest(ctx context.Context, event interface{}) (Response, error) {
 	start := time.Now()
+	h.refreshLogLevels(ctx)
+	h.refreshClientConfig(ctx)
 	
 	// Log function start
 	h.logger.Info().
@@ -59,6 +583,64 @@ func (h *LambdaHandler) HandleRequest(ctx context.Context, event interface{}) (R
 		Time("start_time", start).
 		Msg("Lambda function execution started")
 
+	// Table changes arrive as DynamoDB Stream batches, queued jobs as SQS batches
+	// and scheduled jobs as EventBridge rule input rather than API requests
+	if records, ok := parseStreamEvent(event); ok {
+		response, err := h.processStream(ctx, records)
+		if err != nil {
+			h.logger.Error().
+				Err(err).
+				Int("records", len(records)).
+				Msg("Stream batch failed")
+			r := errorReport(err)
+			r.Job = "stream"
+			h.report(ctx, r)
+			return Response{}, err
+		}
+		return response, nil
+	}
+	if batch, ok := parseQueueEvent(event); ok {
+		response, err := h.runQueuedJobs(ctx, batch)
+		if err != nil {
+			h.logger.Error().
+				Err(err).
+				Int("jobs", len(batch)).
+				Msg("Queued job failed")
+			r := errorReport(err)
+			r.Job, r.UserID = batch[0].Job, batch[0].UserID
+			h.report(ctx, r)
+			return Response{}, err
+		}
+		return response, nil
+	}
+	if ws, ok := parseWebSocketEvent(event); ok {
+		response, err := h.handleWebSocket(ctx, ws)
+		if err != nil {
+			h.logger.Error().
+				Err(err).
+				Str("connection_id", ws.RequestContext.ConnectionID).
+				Str("event_type", ws.RequestContext.EventType).
+				Msg("WebSocket event failed")
+			h.report(ctx, errorReport(err))
+			return Response{}, err
+		}
+		return response, nil
+	}
+	if job, ok := parseJobEvent(event); ok {
+		response, err := h.runJob(ctx, job)
+		if err != nil {
+			h.logger.Error().
+				Err(err).
+				Str("job", job.Job).
+				Msg("Scheduled job failed")
+			r := errorReport(err)
+			r.Job, r.UserID = job.Job, job.UserID
+			h.report(ctx, r)
+			return Response{}, err
+		}
+		return response, nil
+	}
+
 	// Parse the API Gateway event
 	apiEvent, err := h.parseAPIGatewayEvent(event)
 	if err != nil {
@@ -70,18 +652,92 @@ func (h *LambdaHandler) HandleRequest(ctx context.Context, event interface{}) (R
 		return h.createErrorResponse(500, "Internal server error"), nil
 	}
 
+	// Tag the request's logs, events and jobs with the app's ID for it
+	requestID, invalid := h.clientRequestIDOf(apiEvent)
+	if invalid != nil {
+		return *invalid, nil
+	}
+	defer h.scope.begin(requestID)()
+	ctx = withClientRequestID(ctx, requestID)
+
 	// Log request details
 	h.logger.Info().
 		Str("method", apiEvent.HTTPMethod).
 		Str("path", apiEvent.Path).
+		Str("client_version", header(apiEvent, clientVersionHeader)).
 		Msg("Processing request")
 
+	// Let handlers shape responses for the release that sent the request
+	if apiEvent.HTTPMethod != "OPTIONS" {
+		version, sent, refused := h.clientVersionOf(apiEvent)
+		if refused != nil {
+			return echoClientRequestID(*refused, requestID), nil
+		}
+		if sent {
+			ctx = withClientVersion(ctx, version)
+		}
+
+		// Measure the device's clock so times it logged can be corrected
+		clock, malformed := h.clientClockOf(apiEvent, time.Now())
+		if malformed != nil {
+			return echoClientRequestID(*malformed, requestID), nil
+		}
+		ctx = withClientClock(ctx, clock)
+	}
+
+	// Answer in the caller's preferred language where a translation exists
+	ctx = withLocale(ctx, i18n.Negotiate(header(apiEvent, "Accept-Language")))
+
+	// Resolve the caller's session token before routing
+	if err := h.authenticateSession(ctx, apiEvent); err != nil {
+		h.logger.Error().
+			Err(err).
+			Str("path", apiEvent.Path).
+			Msg("Session lookup failed")
+
+		return h.createErrorResponse(500, "Internal server error"), nil
+	}
+
 	var response Response
 
-	// Route request based on path
-	switch apiEvent.Path {
-	case "/api/health":
-		response, err = h.HandleHealthCheck(ctx)
+	// Route request based on method and path
+	useCompactPath(apiEvent)
+	matchedRoute, methodNotAllowed, matched := h.match(apiEvent)
+	if matchedRoute != nil {
+		defer h.logRoute(matchedRoute)()
+	}
+	switch {
+	case apiEvent.HTTPMethod == "OPTIONS":
+		response = h.handlePreflight()
+	case methodNotAllowed != nil:
+		response = *methodNotAllowed
+	case matched:
+		var standby *Response
+		if outdated := h.rejectOutdatedClient(ctx, matchedRoute); outdated != nil {
+			response = *outdated
+		} else if denied := h.authorize(apiEvent, matchedRoute.scope); denied != nil {
+			response = *denied
+		} else if tooLarge := h.rejectLargeBody(apiEvent, matchedRoute); tooLarge != nil {
+			response = *tooLarge
+		} else if undecodable := h.decompressBody(apiEvent, matchedRoute); undecodable != nil {
+			response = *undecodable
+		} else if standby, err = h.rejectPassiveWrite(ctx, apiEvent, matchedRoute); standby != nil {
+			response = *standby
+		} else if err == nil {
+			response, err = h.handleIdempotent(ctx, apiEvent, matchedRoute)
+			if err == nil {
+				h.shadow(ctx, apiEvent, matchedRoute, response)
+			}
+		}
+		if err == nil {
+			response, err = h.addLinks(apiEvent, matchedRoute, response)
+		}
+		if err == nil {
+			response, err = h.negotiate(ctx, apiEvent, response)
+		}
+		if err == nil {
+			response = h.applyCaching(apiEvent, matchedRoute, response)
+		}
 	default:
 		// Default to Hello World for backward compatibility
 		response, err = h.handleHelloWorld(ctx)
@@ -92,9 +748,17 @@ func (h *LambdaHandler) HandleRequest(ctx context.Context, event interface{}) (R
 			Err(err).
 			Str("path", apiEvent.Path).
 			Msg("Request handler failed")
+		r := h.requestReport(errreport.KindError, err.Error(), apiEvent, matchedRoute)
+		r.Stack = errorReport(err).Stack
+		h.report(ctx, r)
 		
-		return h.createErrorResponse(500, "Internal server error"), nil
+		response = h.createErrorResponse(500, "Internal server error")
 	}
+	if matchedRoute != nil && apiEvent.HTTPMethod != "OPTIONS" {
+		h.recordUsage(ctx, apiEvent, matchedRoute, response, start)
+	}
+	response = h.localize(ctx, response)
+	response = echoClientRequestID(response, requestID)
 
 	// Calculate execution duration
 	duration := time.Since(start)
@@ -153,6 +817,26 @@ func (h *LambdaHandler) HandleHealthCheck(ctx context.Context) (Response, error)
 		Message:   "Service is healthy",
 	}
 
+	// Multi-region deployments report their role and replication lag; a store
+	// failure marks the region unhealthy so DNS failover moves traffic away
+	statusCode := 200
+	if h.region != nil {
+		regionHealth, degraded, err := h.regionHealth(ctx, time.Now().UTC())
+		switch {
+		case err != nil:
+			h.logger.Error().
+				Err(err).
+				Msg("Failed to check region health")
+			healthResponse.Status = "error"
+			healthResponse.Message = "Region is unhealthy"
+			statusCode = 503
+		case degraded:
+			healthResponse.Status = "degraded"
+			healthResponse.Message = "Replication from another region is behind"
+		}
+		healthResponse.Region = regionHealth
+	}
+
 	// Marshal response to JSON
 	responseBody, err := json.Marshal(healthResponse)
 	if err != nil {
@@ -165,7 +849,7 @@ func (h *LambdaHandler) HandleHealthCheck(ctx context.Context) (Response, error)
 
 	// Create HTTP response with CORS headers
 	response := Response{
-		StatusCode: 200,
+		StatusCode: statusCode,
 		Headers: map[string]string{
 			"Content-Type":                 "application/json",
 			"Access-Control-Allow-Origin":  "*",
@@ -245,4 +929,163 @@ func (h *LambdaHandler) createErrorResponse(statusCode int, message string) Resp
 		},
 		Body: string(responseBody),
 	}
-}
\ No newline at end of file
+}
+
+// createJSONResponse marshals payload into a JSON response with CORS headers
+func (h *LambdaHandler) createJSONResponse(statusCode int, payload interface{}) (Response, error) {
+	responseBody, err := json.Marshal(payload)
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to marshal response: %w", err)
+	}
+
+	return Response{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Methods": "GET, POST, PUT, PATCH, DELETE, OPTIONS",
+			"Access-Control-Allow-Headers": "Content-Type, Content-Encoding, Authorization, Idempotency-Key, X-Feature-Variants, X-Client-Request-Id, X-Client-Version, X-Client-Time",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handlePreflight answers CORS preflight requests for proxied routes
+func (h *LambdaHandler) handlePreflight() Response {
+	return Response{
+		StatusCode: 204,
+		Headers: map[string]string{
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Methods": "GET, POST, PUT, PATCH, DELETE, OPTIONS",
+			"Access-Control-Allow-Headers": "Content-Type, Content-Encoding, Authorization, Idempotency-Key, X-Feature-Variants, X-Client-Request-Id, X-Client-Version, X-Client-Time",
+		},
+	}
+}
+
+// decodeBody unmarshals the request body into v
+func decodeBody(event *APIGatewayProxyEvent, v interface{}) error {
+	if event.Body == "" {
+		return fmt.Errorf("request body is required")
+	}
+	if err := json.Unmarshal([]byte(event.Body), v); err != nil {
+		return fmt.Errorf("invalid JSON body: %w", err)
+	}
+	return nil
+}
+
+// authenticateSession verifies a session token in the Authorization header and
+// checks its session has not been revoked; invalid tokens leave the request anonymous
+func (h *LambdaHandler) authenticateSession(ctx context.Context, event *APIGatewayProxyEvent) error {
+	token := bearerToken(event)
+	if token == "" {
+		return nil
+	}
+	now := time.Now()
+	claims, err := h.sessions.Verify(token, now)
+	if err != nil {
+		return nil
+	}
+	_, err = h.authSessions.Get(ctx, claims.Subject, claims.SessionID, now)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	event.session = claims
+	return nil
+}
+
+// userID returns the caller's user ID from the Cognito authorizer claims or, for
+// provider sign-ins, from their session token
+func (h *LambdaHandler) userID(event *APIGatewayProxyEvent) string {
+	if userID := event.UserID(); userID != "" {
+		return userID
+	}
+	if event.session != nil {
+		return event.session.Subject
+	}
+	return ""
+}
+
+// scopes returns the scopes granted to the caller. Session tokens carry their own;
+// Cognito access tokens may carry a scope claim, and members of the Cognito admin
+// group are granted admin
+func (h *LambdaHandler) scopes(event *APIGatewayProxyEvent) []string {
+	if event.session != nil {
+		return event.session.Scopes()
+	}
+	claims, ok := event.RequestContext.Authorizer["claims"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	scopes := auth.DefaultScopes
+	if scope, _ := claims["scope"].(string); scope != "" {
+		scopes = auth.ParseScopes(scope)
+	}
+	// API Gateway flattens the groups array into a string such as "[admin coach]"
+	groups, _ := claims["cognito:groups"].(string)
+	for _, group := range strings.FieldsFunc(groups, func(r rune) bool { return r == ' ' || r == ',' || r == '[' || r == ']' }) {
+		if group == auth.ScopeAdmin {
+			return append(append([]string{}, scopes...), auth.ScopeAdmin)
+		}
+	}
+	return scopes
+}
+
+// authorize returns a 401 response for anonymous calls to scoped routes and a 403
+// response naming the missing scope when the caller is not granted it
+func (h *LambdaHandler) authorize(event *APIGatewayProxyEvent, scope string) *Response {
+	if scope == "" {
+		return nil
+	}
+	if _, errResponse := h.requireUser(event); errResponse != nil {
+		return errResponse
+	}
+	if auth.HasScope(h.scopes(event), scope) {
+		return nil
+	}
+
+	response, err := h.createJSONResponse(403, map[string]interface{}{
+		"status":       "error",
+		"timestamp":    time.Now().UTC().Format(time.RFC3339),
+		"message":      fmt.Sprintf("Missing required scope %q", scope),
+		"error":        "insufficient_scope",
+		"missingScope": scope,
+	})
+	if err != nil {
+		response = h.createErrorResponse(403, "Insufficient scope")
+	}
+	response.Headers["WWW-Authenticate"] = fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, scope)
+	return &response
+}
+
+// bearerToken returns the token from an "Authorization: Bearer" header
+func bearerToken(event *APIGatewayProxyEvent) string {
+	value := header(event, "Authorization")
+	if len(value) > 7 && strings.EqualFold(value[:7], "Bearer ") {
+		return strings.TrimSpace(value[7:])
+	}
+	return ""
+}
+
+// header returns the request header name, matched case-insensitively
+func header(event *APIGatewayProxyEvent, name string) string {
+	for key, value := range event.Headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// requireUser returns the authenticated user's ID, or a 401 response for anonymous requests
+func (h *LambdaHandler) requireUser(event *APIGatewayProxyEvent) (string, *Response) {
+	userID := h.userID(event)
+	if userID == "" {
+		response := h.createErrorResponse(401, "Authentication required")
+		return "", &response
+	}
+	return userID, nil
+}
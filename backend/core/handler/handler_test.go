@@ -396,3 +396,46 @@ func TestResponse_Structure(t *testing.T) {
 	})
 }
 
+
+// newTestHandler creates a handler backed by an in-memory store with logs captured
+func newTestHandler() *LambdaHandler {
+	var logBuffer bytes.Buffer
+	logger := zerolog.New(&logBuffer).With().Timestamp().Logger()
+	return NewLambdaHandler(logger)
+}
+
+// apiEvent builds an API Gateway event, authenticated as userID when it is not empty
+func apiEvent(method, path, userID string, query map[string]string, body string) map[string]interface{} {
+	event := map[string]interface{}{
+		"httpMethod":            method,
+		"path":                  path,
+		"queryStringParameters": query,
+		"body":                  body,
+	}
+	if userID != "" {
+		event["requestContext"] = map[string]interface{}{
+			"authorizer": map[string]interface{}{
+				"claims": map[string]interface{}{"sub": userID},
+			},
+		}
+	}
+	return event
+}
+
+func TestLambdaHandler_Preflight(t *testing.T) {
+	t.Run("answers OPTIONS with CORS headers", func(t *testing.T) {
+		// Act
+		response, err := newTestHandler().HandleRequest(context.Background(), apiEvent("OPTIONS", "/api/profile", "", nil, ""))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if response.StatusCode != 204 {
+			t.Errorf("expected status code 204, got %d", response.StatusCode)
+		}
+		if response.Headers["Access-Control-Allow-Origin"] != "*" {
+			t.Error("expected CORS origin header")
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"athlete-forge/handle"
+)
+
+// HandleRequest is the body for choosing a handle
+type HandleRequest struct {
+	Handle string `json:"handle"`
+}
+
+// HandleResponse is a handle and, when checking one, whether the caller could
+// choose it and why not
+type HandleResponse struct {
+	Handle    string `json:"handle"`
+	Available *bool  `json:"available,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// handleGetHandle returns the user's handle and when they can next change it
+func (h *LambdaHandler) handleGetHandle(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	account, err := h.handles.Get(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, account)
+}
+
+// handlePutHandle chooses the user's handle. The handle it replaces redirects
+// to the new one, so the cached public pages under both are dropped
+func (h *LambdaHandler) handlePutHandle(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var req HandleRequest
+	if err := decodeBody(event, &req); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	name := handle.Normalize(req.Handle)
+	if err := handle.Validate(name); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	previous, err := h.handles.Get(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	account, err := h.handles.Claim(ctx, userID, name, time.Now().UTC())
+	if errors.Is(err, handle.ErrTaken) {
+		return h.createErrorResponse(409, err.Error()), nil
+	}
+	if errors.Is(err, handle.ErrCooldown) {
+		response := h.createErrorResponse(429, err.Error())
+		response.Headers["Retry-After"] = strconv.Itoa(int(time.Until(*previous.ChangeableAt).Seconds()) + 1)
+		return response, nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	if previous.Handle != account.Handle {
+		handles := []string{account.Handle}
+		if previous.Handle != "" {
+			handles = append(handles, previous.Handle)
+		}
+		h.invalidatePublicProfiles(ctx, userID, handles...)
+
+		h.logger.Info().
+			Str("function", "handlePutHandle").
+			Str("user_id", userID).
+			Str("handle", account.Handle).
+			Msg("Handle changed")
+	}
+
+	return h.createJSONResponse(200, account)
+}
+
+// handleCheckHandle reports whether the user could choose a handle, without
+// claiming it
+func (h *LambdaHandler) handleCheckHandle(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	name := handle.Normalize(event.PathParameters["handle"])
+	available := false
+	response := HandleResponse{Handle: name, Available: &available}
+	if err := handle.Validate(name); err != nil {
+		response.Reason = err.Error()
+		return h.createJSONResponse(200, response)
+	}
+	err := h.handles.Available(ctx, userID, name, time.Now().UTC())
+	if errors.Is(err, handle.ErrTaken) {
+		response.Reason = err.Error()
+		return h.createJSONResponse(200, response)
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	available = true
+	return h.createJSONResponse(200, response)
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"athlete-forge/handle"
+)
+
+func TestLambdaHandler_Handles(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("chooses a handle", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		put, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/auth/me/handle", "user-1", nil, `{"handle":"@Lifter"}`))
+		get, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/auth/me/handle", "user-1", nil, ""))
+
+		// Assert
+		if put.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", put.StatusCode, put.Body)
+		}
+		var account handle.Account
+		json.Unmarshal([]byte(get.Body), &account)
+		if account.Handle != "lifter" || account.ChangeableAt == nil {
+			t.Errorf("unexpected account: %s", get.Body)
+		}
+	})
+
+	t.Run("rejects taken, reserved and recently changed handles", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/auth/me/handle", "user-1", nil, `{"handle":"lifter"}`))
+
+		// Act
+		taken, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/auth/me/handle", "user-2", nil, `{"handle":"lifter"}`))
+		reserved, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/auth/me/handle", "user-2", nil, `{"handle":"admin"}`))
+		cooldown, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/auth/me/handle", "user-1", nil, `{"handle":"squatter"}`))
+
+		// Assert
+		if taken.StatusCode != 409 || reserved.StatusCode != 400 || cooldown.StatusCode != 429 {
+			t.Errorf("expected status codes 409, 400 and 429, got %d, %d and %d", taken.StatusCode, reserved.StatusCode, cooldown.StatusCode)
+		}
+		if cooldown.Headers["Retry-After"] == "" {
+			t.Error("expected a Retry-After header")
+		}
+	})
+
+	t.Run("checks whether a handle is available", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/auth/me/handle", "user-1", nil, `{"handle":"lifter"}`))
+
+		for name, want := range map[string]bool{"lifter": false, "squatter": true, "root": false} {
+			// Act
+			response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/handles/"+name, "user-2", nil, ""))
+
+			// Assert
+			var check HandleResponse
+			json.Unmarshal([]byte(response.Body), &check)
+			if response.StatusCode != 200 || check.Available == nil || *check.Available != want {
+				t.Errorf("%s: expected available %v, got %d %s", name, want, response.StatusCode, response.Body)
+			}
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+
+	"athlete-forge/records"
+)
+
+const (
+	defaultHistorySessions = 3
+	maxHistorySessions     = 20
+)
+
+// ExerciseHistoryResponse is an exercise's recent sessions, newest first
+type ExerciseHistoryResponse struct {
+	Exercise string            `json:"exercise"`
+	Sessions []records.Session `json:"sessions"`
+}
+
+// handleExerciseHistory returns the last ?sessions= (default 3) completed
+// sessions of ?exercise= set by set, each with its change from the session
+// before, for showing what the user did last time
+func (h *LambdaHandler) handleExerciseHistory(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	name := event.QueryStringParameters["exercise"]
+	if name == "" {
+		return h.createErrorResponse(400, "exercise is required"), nil
+	}
+	n := defaultHistorySessions
+	if raw := event.QueryStringParameters["sessions"]; raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxHistorySessions {
+			return h.createErrorResponse(400, "sessions must be between 1 and "+strconv.Itoa(maxHistorySessions)), nil
+		}
+		n = parsed
+	}
+
+	workouts, err := h.loadedWorkouts(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, ExerciseHistoryResponse{Exercise: name, Sessions: records.Sessions(workouts, name, n)})
+}
+
+// RepRecordsResponse is an exercise's rep records, heaviest first
+type RepRecordsResponse struct {
+	Exercise string              `json:"exercise"`
+	Records  []records.RepRecord `json:"records"`
+}
+
+// handleRepRecords returns the most reps ?exercise= has been lifted for at
+// each weight, for showing the reps an AMRAP set has to beat
+func (h *LambdaHandler) handleRepRecords(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	name := event.QueryStringParameters["exercise"]
+	if name == "" {
+		return h.createErrorResponse(400, "exercise is required"), nil
+	}
+
+	workouts, err := h.loadedWorkouts(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, RepRecordsResponse{Exercise: name, Records: records.RepRecords(workouts, name)})
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestLambdaHandler_ExerciseHistory(t *testing.T) {
+	ctx := context.Background()
+	h := newTestHandler()
+	for _, weight := range []string{"100", "102.5", "105"} {
+		createWorkout(t, h, "user-1", `{"status":"completed","exercises":[{"name":"Squat","sets":[{"reps":5,"weight":`+weight+`}]}]}`)
+	}
+
+	t.Run("compares the latest sessions with the ones before", func(t *testing.T) {
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("GET", "/api/exercises/history", "user-1", map[string]string{"exercise": "squat", "sessions": "2"}, ""))
+
+		// Assert
+		if err != nil || response.StatusCode != 200 {
+			t.Fatalf("unexpected response %d: %s (%v)", response.StatusCode, response.Body, err)
+		}
+		var history ExerciseHistoryResponse
+		json.Unmarshal([]byte(response.Body), &history)
+		if len(history.Sessions) != 2 || history.Sessions[0].Sets[0].Weight != 105 || history.Sessions[1].Delta == nil || history.Sessions[1].Delta.Weight != 2.5 {
+			t.Errorf("unexpected history: %s", response.Body)
+		}
+	})
+
+	t.Run("loads bodyweight exercises with the logged bodyweight", func(t *testing.T) {
+		// Arrange
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/logs/2024-01-01", "user-1", nil, `{"bodyweight":80}`))
+		createWorkout(t, h, "user-1", `{"status":"completed","exercises":[{"name":"Pull-Up","sets":[{"reps":5,"weight":10}]}]}`)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/exercises/history", "user-1", map[string]string{"exercise": "pull-up"}, ""))
+
+		// Assert
+		var history ExerciseHistoryResponse
+		json.Unmarshal([]byte(response.Body), &history)
+		if len(history.Sessions) != 1 || history.Sessions[0].Sets[0].Weight != 90 {
+			t.Errorf("expected 80kg bodyweight plus 10kg added, got %s", response.Body)
+		}
+	})
+
+	t.Run("rejects a missing exercise or a bad session count", func(t *testing.T) {
+		for _, query := range []map[string]string{{}, {"exercise": "Squat", "sessions": "0"}, {"exercise": "Squat", "sessions": "many"}} {
+			// Act
+			response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/exercises/history", "user-1", query, ""))
+
+			// Assert
+			if response.StatusCode != 400 {
+				t.Errorf("%v: expected status code 400, got %d", query, response.StatusCode)
+			}
+		}
+	})
+}
+
+func TestLambdaHandler_RepRecords(t *testing.T) {
+	ctx := context.Background()
+	h := newTestHandler()
+	createWorkout(t, h, "user-1", `{"status":"completed","exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100},{"reps":9,"weight":100,"amrap":true}]}]}`)
+	createWorkout(t, h, "user-1", `{"status":"completed","exercises":[{"name":"Squat","sets":[{"reps":3,"weight":120}]}]}`)
+
+	t.Run("returns the most reps at each weight, heaviest first", func(t *testing.T) {
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("GET", "/api/exercises/rep-records", "user-1", map[string]string{"exercise": "squat"}, ""))
+
+		// Assert
+		if err != nil || response.StatusCode != 200 {
+			t.Fatalf("unexpected response %d: %s (%v)", response.StatusCode, response.Body, err)
+		}
+		var prs RepRecordsResponse
+		json.Unmarshal([]byte(response.Body), &prs)
+		if len(prs.Records) != 2 || prs.Records[0].Weight != 120 || prs.Records[1].Reps != 9 || !prs.Records[1].AMRAP {
+			t.Errorf("unexpected rep records: %s", response.Body)
+		}
+	})
+
+	t.Run("rejects a missing exercise", func(t *testing.T) {
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/exercises/rep-records", "user-1", nil, ""))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"athlete-forge/injury"
+	"athlete-forge/program"
+	"athlete-forge/progression"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+// handleListInjuries returns the user's injuries, optionally only those active on ?date=
+func (h *LambdaHandler) handleListInjuries(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	injuries, err := h.injuries.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	if date := event.QueryStringParameters["date"]; date != "" {
+		injuries = injury.Active(injuries, date)
+	}
+	return h.createJSONResponse(200, injuries)
+}
+
+// handleGetInjury returns a single injury
+func (h *LambdaHandler) handleGetInjury(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	i, err := h.injuries.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Injury not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, i)
+}
+
+// handleCreateInjury records a new injury
+func (h *LambdaHandler) handleCreateInjury(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var i injury.Injury
+	if err := decodeBody(event, &i); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	now := time.Now().UTC()
+	i.ID = ""
+	i.UserID = userID
+	i.CreatedAt = now
+	i.UpdatedAt = now
+
+	if err := i.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if err := h.injuries.Save(ctx, &i); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(201, i)
+}
+
+// handlePutInjury replaces an injury, e.g. to set its end date once recovered
+func (h *LambdaHandler) handlePutInjury(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	existing, err := h.injuries.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Injury not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	var i injury.Injury
+	if err := decodeBody(event, &i); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	i.ID = existing.ID
+	i.UserID = userID
+	i.CreatedAt = existing.CreatedAt
+	i.UpdatedAt = time.Now().UTC()
+
+	if err := i.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if err := h.injuries.Save(ctx, &i); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, i)
+}
+
+// handleDeleteInjury removes an injury recorded in error, returning what was removed
+func (h *LambdaHandler) handleDeleteInjury(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	i, err := h.injuries.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Injury not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	if err := h.injuries.Delete(ctx, userID, i.ID); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, i)
+}
+
+// activeInjuries returns the user's injuries active on date
+func (h *LambdaHandler) activeInjuries(ctx context.Context, userID, date string) ([]injury.Injury, error) {
+	injuries, err := h.injuries.List(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load injuries: %w", err)
+	}
+	return injury.Active(injuries, date), nil
+}
+
+// withoutInjured splits a completed workout into the exercises that may progress
+// and held changes for the program's exercises an active injury affects, so
+// lighter or modified sets performed while injured do not count against the program
+func withoutInjured(p *program.Program, w *workout.Workout, active []injury.Injury) (*workout.Workout, []progression.Change) {
+	progressing := *w
+	progressing.Exercises = nil
+	for _, exercise := range w.Exercises {
+		if _, ok := injury.Affecting(exercise.Name, active); !ok {
+			progressing.Exercises = append(progressing.Exercises, exercise)
+		}
+	}
+
+	held := []progression.Change{}
+	for _, prescription := range p.Exercises {
+		affecting, ok := injury.Affecting(prescription.Exercise, active)
+		if !ok {
+			continue
+		}
+		held = append(held, progression.Change{
+			Exercise:       prescription.Exercise,
+			Rule:           prescription.Rule.Type,
+			PreviousWeight: prescription.Weight,
+			NextWeight:     prescription.Weight,
+			PreviousReps:   prescription.Reps,
+			NextReps:       prescription.Reps,
+			Reason:         fmt.Sprintf("%s injury active, prescription held", affecting.BodyPart),
+		})
+	}
+	return &progressing, held
+}
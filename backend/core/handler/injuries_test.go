@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"athlete-forge/injury"
+	"athlete-forge/program"
+	"athlete-forge/workout"
+)
+
+func createInjury(t *testing.T, h *LambdaHandler, userID, body string) injury.Injury {
+	t.Helper()
+	response, err := h.HandleRequest(context.Background(), apiEvent("POST", "/api/injuries", userID, nil, body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.StatusCode != 201 {
+		t.Fatalf("expected status code 201, got %d: %s", response.StatusCode, response.Body)
+	}
+	var i injury.Injury
+	json.Unmarshal([]byte(response.Body), &i)
+	return i
+}
+
+func TestLambdaHandler_Injuries(t *testing.T) {
+	ctx := context.Background()
+	today := time.Now().UTC().Format(injury.DateLayout)
+
+	t.Run("records, resolves and deletes injuries", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		created := createInjury(t, h, "user-1", `{"bodyPart":"knee","severity":"moderate","startDate":"2026-01-05"}`)
+
+		// Act
+		resolved, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/injuries/"+created.ID, "user-1", nil, `{"bodyPart":"knee","severity":"moderate","startDate":"2026-01-05","endDate":"2026-02-01"}`))
+		active, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/injuries", "user-1", map[string]string{"date": "2026-03-01"}, ""))
+		deleted, _ := h.HandleRequest(ctx, apiEvent("DELETE", "/api/injuries/"+created.ID, "user-1", nil, ""))
+		missing, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/injuries/"+created.ID, "user-1", nil, ""))
+
+		// Assert
+		if resolved.StatusCode != 200 || active.Body != "[]" {
+			t.Errorf("expected resolved injury to be inactive, got %d and %s", resolved.StatusCode, active.Body)
+		}
+		if deleted.StatusCode != 200 || missing.StatusCode != 404 {
+			t.Errorf("expected delete then 404, got %d and %d", deleted.StatusCode, missing.StatusCode)
+		}
+	})
+
+	t.Run("rejects invalid injuries", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("POST", "/api/injuries", "user-1", nil, `{"bodyPart":"knee","severity":"ouch","startDate":"2026-01-05"}`))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("next session substitutes around an active injury", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", linearProgramBody)
+		createInjury(t, h, "user-1", fmt.Sprintf(`{"bodyPart":"back","severity":"severe","startDate":%q}`, today))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/programs/"+p.ID+"/next-session", "user-1", nil, ""))
+
+		// Assert
+		var session NextSessionResponse
+		json.Unmarshal([]byte(response.Body), &session)
+		if len(session.Exercises) != 1 || session.Exercises[0].Exercise != "belt squat" {
+			t.Fatalf("unexpected session: %s", response.Body)
+		}
+		if len(session.Injuries) != 1 || session.Injuries[0].Action != injury.ActionSubstituted {
+			t.Errorf("unexpected injury adjustments: %+v", session.Injuries)
+		}
+	})
+
+	t.Run("completion holds progression of injured exercises", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", linearProgramBody)
+		createInjury(t, h, "user-1", fmt.Sprintf(`{"bodyPart":"knee","severity":"mild","startDate":%q}`, today))
+		created := createWorkout(t, h, "user-1", fmt.Sprintf(`{"programId":%q,"exercises":[{"name":"Squat","sets":[{"reps":5,"weight":80},{"reps":5,"weight":80},{"reps":5,"weight":80}]}]}`, p.ID))
+		var w workout.Workout
+		json.Unmarshal([]byte(created.Body), &w)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/workouts/"+w.ID+"/complete", "user-1", nil, ""))
+
+		// Assert
+		var completion CompletionResponse
+		json.Unmarshal([]byte(response.Body), &completion)
+		if len(completion.Changes) != 1 || completion.Changes[0].NextWeight != 100 {
+			t.Errorf("expected squat held at 100, got %+v", completion.Changes)
+		}
+		stored, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/programs/"+p.ID, "user-1", nil, ""))
+		var held program.Program
+		json.Unmarshal([]byte(stored.Body), &held)
+		if held.Exercises[0].Weight != 100 || held.SessionsCompleted != 1 {
+			t.Errorf("expected program held, got %+v", held)
+		}
+	})
+}
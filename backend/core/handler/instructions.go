@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"athlete-forge/instruction"
+	"athlete-forge/store"
+)
+
+// InstructionsRequest is the content of an exercise's draft instructions, with
+// an optional note for the audit trail
+type InstructionsRequest struct {
+	instruction.Content
+	Note string `json:"note,omitempty"`
+}
+
+// InstructionNoteRequest is the optional note recorded when a draft is
+// published or discarded
+type InstructionNoteRequest struct {
+	Note string `json:"note"`
+}
+
+// handleGetInstructions returns the published instructions of a catalog
+// exercise; drafts are only visible through the admin API
+func (h *LambdaHandler) handleGetInstructions(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	if _, errResponse := h.requireUser(event); errResponse != nil {
+		return *errResponse, nil
+	}
+	e, ok := catalogExercise(event)
+	if !ok {
+		return h.createErrorResponse(404, "Exercise not found"), nil
+	}
+
+	v, err := h.instructions.Published(ctx, e.Name)
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "The exercise has no published instructions"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, v)
+}
+
+// handleListInstructionVersions returns every version of a catalog exercise's
+// instructions, its draft included, newest first
+func (h *LambdaHandler) handleListInstructionVersions(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	if _, errResponse := h.requireUser(event); errResponse != nil {
+		return *errResponse, nil
+	}
+	e, ok := catalogExercise(event)
+	if !ok {
+		return h.createErrorResponse(404, "Exercise not found"), nil
+	}
+
+	versions, err := h.instructions.Versions(ctx, e.Name)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, versions)
+}
+
+// handleInstructionAudit returns the audit trail of edits to a catalog
+// exercise's instructions, newest first
+func (h *LambdaHandler) handleInstructionAudit(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	if _, errResponse := h.requireUser(event); errResponse != nil {
+		return *errResponse, nil
+	}
+	e, ok := catalogExercise(event)
+	if !ok {
+		return h.createErrorResponse(404, "Exercise not found"), nil
+	}
+
+	entries, err := h.instructions.Audit(ctx, e.Name)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, entries)
+}
+
+// handleSaveInstructionDraft replaces the content of a catalog exercise's
+// draft instructions, starting a draft when there is none
+func (h *LambdaHandler) handleSaveInstructionDraft(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	adminID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	e, ok := catalogExercise(event)
+	if !ok {
+		return h.createErrorResponse(404, "Exercise not found"), nil
+	}
+	var req InstructionsRequest
+	if err := decodeBody(event, &req); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if err := req.Content.Normalize(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if err := instruction.ValidateNote(req.Note); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	draft, err := h.instructions.SaveDraft(ctx, e.Name, req.Content, adminID, req.Note, time.Now().UTC())
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, draft)
+}
+
+// handlePublishInstructionDraft makes a catalog exercise's draft the
+// instructions users see
+func (h *LambdaHandler) handlePublishInstructionDraft(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	return h.resolveInstructionDraft(ctx, event, h.instructions.Publish)
+}
+
+// handleDiscardInstructionDraft deletes a catalog exercise's draft, leaving
+// the published instructions as they are
+func (h *LambdaHandler) handleDiscardInstructionDraft(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	return h.resolveInstructionDraft(ctx, event, h.instructions.Discard)
+}
+
+// resolveInstructionDraft publishes or discards a catalog exercise's draft
+// with resolve, taking an optional note from the body, and returns the draft
+func (h *LambdaHandler) resolveInstructionDraft(ctx context.Context, event *APIGatewayProxyEvent, resolve func(ctx context.Context, exercise, adminID, note string, now time.Time) (*instruction.Version, error)) (Response, error) {
+	adminID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	e, ok := catalogExercise(event)
+	if !ok {
+		return h.createErrorResponse(404, "Exercise not found"), nil
+	}
+	var req InstructionNoteRequest
+	if event.Body != "" {
+		if err := decodeBody(event, &req); err != nil {
+			return h.createErrorResponse(400, err.Error()), nil
+		}
+	}
+	if err := instruction.ValidateNote(req.Note); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	v, err := resolve(ctx, e.Name, adminID, req.Note, time.Now().UTC())
+	if errors.Is(err, instruction.ErrNoDraft) {
+		return h.createErrorResponse(409, err.Error()), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	h.logger.Info().
+		Str("function", "resolveInstructionDraft").
+		Str("user_id", adminID).
+		Str("exercise", v.Exercise).
+		Int("version", v.Number).
+		Str("status", v.Status).
+		Msg("Exercise instruction draft resolved")
+	return h.createJSONResponse(200, v)
+}
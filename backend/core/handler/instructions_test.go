@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"athlete-forge/instruction"
+)
+
+func TestLambdaHandler_ExerciseInstructions(t *testing.T) {
+	ctx := context.Background()
+	admin := map[string]interface{}{"cognito:groups": "admin"}
+
+	// adminRequest sends body to path as an admin
+	adminRequest := func(h *LambdaHandler, method, path, body string) Response {
+		event := cognitoEvent(method, path, "ops", admin)
+		event["body"] = body
+		response, _ := h.HandleRequest(ctx, event)
+		return response
+	}
+
+	t.Run("users see instructions only once a draft is published", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		saved := adminRequest(h, "PUT", "/api/admin/exercises/back%20squat/instructions/draft", `{"summary":"Squat below parallel","steps":["Brace","Sit down"],"cues":["Knees out"]}`)
+		hidden, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/exercises/back-squat/instructions", "user-1", nil, ""))
+
+		// Act
+		published := adminRequest(h, "POST", "/api/admin/exercises/back%20squat/instructions/draft/publish", `{"note":"reviewed"}`)
+		shown, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/exercises/back-squat/instructions", "user-1", nil, ""))
+
+		// Assert
+		if saved.StatusCode != 200 || published.StatusCode != 200 {
+			t.Fatalf("unexpected responses %d %s and %d %s", saved.StatusCode, saved.Body, published.StatusCode, published.Body)
+		}
+		if hidden.StatusCode != 404 {
+			t.Errorf("expected the draft hidden, got %d %s", hidden.StatusCode, hidden.Body)
+		}
+		var v instruction.Version
+		json.Unmarshal([]byte(shown.Body), &v)
+		if shown.StatusCode != 200 || v.Number != 1 || v.Status != instruction.StatusPublished || v.Summary != "Squat below parallel" {
+			t.Errorf("unexpected instructions %d %s", shown.StatusCode, shown.Body)
+		}
+	})
+
+	t.Run("admins review versions and the audit trail", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		adminRequest(h, "PUT", "/api/admin/exercises/deadlift/instructions/draft", `{"summary":"Hinge"}`)
+		adminRequest(h, "POST", "/api/admin/exercises/deadlift/instructions/draft/publish", "")
+		adminRequest(h, "PUT", "/api/admin/exercises/deadlift/instructions/draft", `{"summary":"Hinge at the hips","note":"clarify"}`)
+
+		// Act
+		versions := adminRequest(h, "GET", "/api/admin/exercises/deadlift/instructions", "")
+		audit := adminRequest(h, "GET", "/api/admin/exercises/deadlift/instructions/audit", "")
+
+		// Assert
+		var listed []instruction.Version
+		json.Unmarshal([]byte(versions.Body), &listed)
+		if len(listed) != 2 || listed[0].Status != instruction.StatusDraft || listed[1].Status != instruction.StatusPublished {
+			t.Errorf("unexpected versions %s", versions.Body)
+		}
+		var entries []instruction.Entry
+		json.Unmarshal([]byte(audit.Body), &entries)
+		if len(entries) != 3 || entries[0].AdminID != "ops" {
+			t.Errorf("unexpected audit trail %s", audit.Body)
+		}
+	})
+
+	t.Run("rejects invalid changes", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			method     string
+			path       string
+			body       string
+			wantStatus int
+		}{
+			{"unknown exercise", "PUT", "/api/admin/exercises/zercher%20squat/instructions/draft", `{"summary":"Squat"}`, 404},
+			{"empty content", "PUT", "/api/admin/exercises/squat/instructions/draft", `{"cues":["Brace"]}`, 400},
+			{"publishing without a draft", "POST", "/api/admin/exercises/squat/instructions/draft/publish", "", 409},
+			{"discarding without a draft", "DELETE", "/api/admin/exercises/squat/instructions/draft", "", 409},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// Act
+				response := adminRequest(newTestHandler(), tt.method, tt.path, tt.body)
+
+				// Assert
+				if response.StatusCode != tt.wantStatus {
+					t.Errorf("expected status code %d, got %d: %s", tt.wantStatus, response.StatusCode, response.Body)
+				}
+			})
+		}
+	})
+
+	t.Run("only admins edit instructions", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("PUT", "/api/admin/exercises/squat/instructions/draft", "user-1", nil, `{"summary":"Squat"}`))
+
+		// Assert
+		if response.StatusCode != 403 {
+			t.Errorf("expected status code 403, got %d", response.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,366 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"athlete-forge/cardio"
+	"athlete-forge/integration"
+	"athlete-forge/readiness"
+	"athlete-forge/store"
+	"athlete-forge/webhook"
+)
+
+// recoveryBackfillDays is how many days of recovery readings are synced when a
+// wearable is connected
+const recoveryBackfillDays = 14
+
+// ConnectRequest is the body for connecting a provider account. The app
+// completes the provider's authorization and passes on the OAuth tokens, or
+// for Polar the authorization code and its redirect URI. The provider account
+// is looked up with them rather than taken from the app
+type ConnectRequest struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	Code         string `json:"code"`
+	RedirectURI  string `json:"redirectUri"`
+}
+
+// ConnectionInfo describes a connected provider account without its token
+type ConnectionInfo struct {
+	Provider       string    `json:"provider"`
+	ProviderUserID string    `json:"providerUserId"`
+	ConnectedAt    time.Time `json:"connectedAt"`
+}
+
+func connectionInfo(c integration.Connection) ConnectionInfo {
+	return ConnectionInfo{Provider: c.Provider, ProviderUserID: c.ProviderUserID, ConnectedAt: c.ConnectedAt}
+}
+
+// handleListIntegrations returns the provider accounts the user has connected
+func (h *LambdaHandler) handleListIntegrations(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	connections, err := h.integrations.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	infos := make([]ConnectionInfo, 0, len(connections))
+	for _, c := range connections {
+		infos = append(infos, connectionInfo(c))
+	}
+	return h.createJSONResponse(200, infos)
+}
+
+// handlePutIntegration connects the user's account with a provider, so its
+// webhook deliveries are imported as their activities or its recovery readings
+// are synced into their check-ins, starting with the last two weeks
+func (h *LambdaHandler) handlePutIntegration(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	provider := event.PathParameters["provider"]
+	_, imports := h.adapters[provider]
+	_, wearable := h.wearables[provider]
+	if !imports && !wearable {
+		return h.createErrorResponse(404, "Unknown integration provider"), nil
+	}
+
+	var req ConnectRequest
+	if err := decodeBody(event, &req); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	tokens, providerUserID, errResponse, err := h.providerAccount(ctx, provider, req)
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	c := &integration.Connection{Provider: provider, UserID: userID, ProviderUserID: providerUserID, ConnectedAt: time.Now().UTC()}
+	err = h.integrations.Connect(ctx, c, tokens)
+	if errors.Is(err, integration.ErrConnected) {
+		return h.createErrorResponse(409, "This account is connected to another user"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	h.logger.Info().
+		Str("function", "handlePutIntegration").
+		Str("user_id", userID).
+		Str("provider", provider).
+		Msg("Integration connected")
+
+	if wearable {
+		// The connection is saved, so a failed backfill only waits for the daily sync
+		if err := h.dispatch(ctx, JobEvent{Job: JobSyncRecovery, UserID: userID, ID: provider, ClientRequestID: clientRequestID(ctx)}); err != nil {
+			h.logger.Warn().
+				Err(err).
+				Str("user_id", userID).
+				Str("provider", provider).
+				Msg("Failed to queue recovery sync")
+		}
+	}
+	return h.createJSONResponse(200, connectionInfo(*c))
+}
+
+// providerAccount returns the tokens req grants with provider and the ID of the
+// provider account they belong to, as the provider reports it. The app's word
+// for the account is never taken, since it would let a user claim someone
+// else's account and receive their deliveries
+func (h *LambdaHandler) providerAccount(ctx context.Context, provider string, req ConnectRequest) (integration.Tokens, string, *Response, error) {
+	fail := func(status int, message string) (integration.Tokens, string, *Response, error) {
+		response := h.createErrorResponse(status, message)
+		return integration.Tokens{}, "", &response, nil
+	}
+
+	var client interface{} = h.adapters[provider]
+	if client == nil {
+		client = h.wearables[provider]
+	}
+	tokens := integration.Tokens{AccessToken: req.AccessToken, RefreshToken: req.RefreshToken}
+	var providerUserID string
+	var err error
+	switch c := client.(type) {
+	case integration.Authorizer:
+		if req.Code == "" {
+			return fail(400, "code is required")
+		}
+		tokens, providerUserID, err = c.Authorize(ctx, req.Code, req.RedirectURI)
+	case integration.Identifier:
+		if req.AccessToken == "" {
+			return fail(400, "accessToken is required")
+		}
+		providerUserID, err = c.UserID(ctx, req.AccessToken)
+	default:
+		return integration.Tokens{}, "", nil, fmt.Errorf("no way to identify %s accounts", provider)
+	}
+	if errors.Is(err, integration.ErrUnauthorized) || errors.Is(err, integration.ErrRejected) {
+		return fail(400, "The provider did not accept the authorization")
+	}
+	if err != nil {
+		h.logger.Warn().
+			Err(err).
+			Str("provider", provider).
+			Msg("Failed to identify provider account")
+		return fail(502, "Could not confirm the account with the provider")
+	}
+	return tokens, providerUserID, nil, nil
+}
+
+// handleDeleteIntegration disconnects the user's account with a provider;
+// activities already imported are kept
+func (h *LambdaHandler) handleDeleteIntegration(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	provider := event.PathParameters["provider"]
+	c, err := h.integrations.Get(ctx, userID, provider)
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Integration not connected"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	if err := h.integrations.Disconnect(ctx, userID, provider); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, connectionInfo(*c))
+}
+
+// runImportWebhooks imports provider's pending webhook events. Each activity is
+// saved under an ID derived from the provider's, so importing an event again
+// overwrites rather than duplicates. Activities of provider users no one has
+// connected are skipped. An event that fails stays pending for the next run
+func (h *LambdaHandler) runImportWebhooks(ctx context.Context, provider string) (JobResult, error) {
+	result := JobResult{Job: JobImportWebhooks}
+	adapter, ok := h.adapters[provider]
+	if !ok {
+		return result, fmt.Errorf("no integration adapter for %q", provider)
+	}
+	pending, err := h.webhookInbox.Pending(ctx, provider)
+	if err != nil {
+		return result, err
+	}
+
+	token := func(ctx context.Context, providerUserID string) (string, error) {
+		c, err := h.integrations.Owner(ctx, provider, providerUserID)
+		if err != nil {
+			return "", fmt.Errorf("no connection for %s user %s: %w", provider, providerUserID, err)
+		}
+		tokens, err := h.integrations.Tokens(ctx, c)
+		return tokens.AccessToken, err
+	}
+	for i := range pending {
+		e := &pending[i]
+		if err := h.importWebhookEvent(ctx, adapter, e, token); err != nil {
+			result.Failed++
+			h.logger.Error().
+				Err(err).
+				Str("provider", provider).
+				Str("key", e.Key).
+				Msg("Failed to import webhook event")
+			continue
+		}
+		if err := h.webhookInbox.MarkImported(ctx, e); err != nil {
+			return result, err
+		}
+		result.Processed++
+	}
+	return result, nil
+}
+
+// importWebhookEvent saves the activities in e for the users connected to
+// them, skipping those the user already has from another source
+func (h *LambdaHandler) importWebhookEvent(ctx context.Context, adapter integration.Adapter, e *webhook.Event, token integration.TokenFunc) error {
+	activities, err := adapter.Activities(ctx, *e, token)
+	if err != nil {
+		return err
+	}
+	for _, imported := range activities {
+		c, err := h.integrations.Owner(ctx, e.Provider, imported.ProviderUserID)
+		if errors.Is(err, store.ErrNotFound) {
+			h.logger.Info().
+				Str("provider", e.Provider).
+				Str("provider_user_id", imported.ProviderUserID).
+				Msg("Skipped activity of unconnected provider user")
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		a := imported.Activity
+		a.ID = e.Provider + "-" + imported.SourceID
+		a.UserID = c.UserID
+		a.Source = e.Provider
+		if err := a.Validate(); err != nil {
+			h.logger.Warn().
+				Err(err).
+				Str("provider", e.Provider).
+				Str("user_id", c.UserID).
+				Str("source_id", imported.SourceID).
+				Msg("Skipped invalid imported activity")
+			continue
+		}
+		existing, err := h.activities.List(ctx, c.UserID)
+		if err != nil {
+			return err
+		}
+		if duplicate := cardio.FindDuplicate(existing, &a); duplicate != nil {
+			h.logger.Info().
+				Str("provider", e.Provider).
+				Str("user_id", c.UserID).
+				Str("source_id", imported.SourceID).
+				Str("duplicate_of", duplicate.ID).
+				Msg("Skipped imported activity duplicating a stored one")
+			continue
+		}
+		a.FillHeartRateStats()
+		a.Run = nil
+		a.Ride = nil
+		a.Swim = nil
+		a.Route = nil
+		if err := h.summarizeSamples(ctx, &a); err != nil {
+			return err
+		}
+		if err := h.storeTrack(ctx, &a); err != nil {
+			return err
+		}
+		if err := h.activities.Save(ctx, &a); err != nil {
+			return err
+		}
+		if err := h.touchUser(ctx, c.UserID, time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runSyncRecovery syncs recovery readings into check-ins from the wearables users
+// have connected. A connection's sync backfills recoveryBackfillDays; the daily
+// sync, with no userID, covers the last two days of every active user, since
+// providers score a night's recovery the next morning
+func (h *LambdaHandler) runSyncRecovery(ctx context.Context, userID, provider string, now time.Time) (JobResult, error) {
+	result := JobResult{Job: JobSyncRecovery}
+	userIDs := []string{userID}
+	days := recoveryBackfillDays
+	if userID == "" {
+		users, err := h.users.List(ctx)
+		if err != nil {
+			return result, err
+		}
+		userIDs = make([]string, 0, len(users))
+		for _, user := range users {
+			userIDs = append(userIDs, user.UserID)
+		}
+		days = 2
+	}
+	from := now.AddDate(0, 0, 1-days).Format(readiness.DateLayout)
+	to := now.Format(readiness.DateLayout)
+
+	for _, id := range userIDs {
+		connections, err := h.integrations.List(ctx, id)
+		if err != nil {
+			return result, err
+		}
+		for i := range connections {
+			c := &connections[i]
+			source, ok := h.wearables[c.Provider]
+			if !ok || (provider != "" && c.Provider != provider) {
+				continue
+			}
+			if err := h.syncRecovery(ctx, source, c, from, to, now); err != nil {
+				result.Failed++
+				h.logger.Error().
+					Err(err).
+					Str("user_id", id).
+					Str("provider", c.Provider).
+					Msg("Failed to sync recovery")
+				continue
+			}
+			result.Processed++
+		}
+	}
+	return result, nil
+}
+
+// syncRecovery saves c's recovery readings from source between from and to,
+// refreshing the access token once if the provider rejects it
+func (h *LambdaHandler) syncRecovery(ctx context.Context, source integration.RecoverySource, c *integration.Connection, from, to string, now time.Time) error {
+	tokens, err := h.integrations.Tokens(ctx, c)
+	if err != nil {
+		return err
+	}
+	readings, err := source.Recovery(ctx, tokens.AccessToken, from, to)
+	if errors.Is(err, integration.ErrUnauthorized) && tokens.RefreshToken != "" {
+		if tokens, err = source.Refresh(ctx, tokens.RefreshToken); err != nil {
+			return err
+		}
+		if err := h.integrations.UpdateTokens(ctx, c, tokens); err != nil {
+			return err
+		}
+		readings, err = source.Recovery(ctx, tokens.AccessToken, from, to)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, reading := range readings {
+		reading.Recovery.SyncedAt = now
+		if err := h.checkIns.SaveRecovery(ctx, c.UserID, reading.Date, reading.Recovery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"athlete-forge/cardio"
+	"athlete-forge/integration"
+	"athlete-forge/readiness"
+	"athlete-forge/webhook"
+)
+
+// stubWearable reports a recovery score of 35 for every day, accepting only the
+// access token "fresh", which a refresh returns
+type stubWearable struct {
+	refreshed int
+}
+
+func (s *stubWearable) Provider() string { return integration.ProviderWhoop }
+
+func (s *stubWearable) Recovery(ctx context.Context, accessToken, from, to string) ([]integration.DailyRecovery, error) {
+	if accessToken != "fresh" {
+		return nil, integration.ErrUnauthorized
+	}
+	return []integration.DailyRecovery{
+		{Date: from, Recovery: readiness.Recovery{Source: integration.ProviderWhoop, Score: 35}},
+		{Date: to, Recovery: readiness.Recovery{Source: integration.ProviderWhoop, Score: 35}},
+	}, nil
+}
+
+func (s *stubWearable) UserID(ctx context.Context, accessToken string) (string, error) {
+	return "w-1", nil
+}
+
+func (s *stubWearable) Refresh(ctx context.Context, refreshToken string) (integration.Tokens, error) {
+	s.refreshed++
+	return integration.Tokens{AccessToken: "fresh", RefreshToken: refreshToken}, nil
+}
+
+// stubGarmin reads Garmin pushes, finding the Garmin user of an access token
+// "token-<Garmin user ID>"
+type stubGarmin struct {
+	integration.Garmin
+}
+
+func (stubGarmin) UserID(ctx context.Context, accessToken string) (string, error) {
+	userID, ok := strings.CutPrefix(accessToken, "token-")
+	if !ok {
+		return "", integration.ErrUnauthorized
+	}
+	return userID, nil
+}
+
+func TestLambdaHandler_Integrations(t *testing.T) {
+	ctx := context.Background()
+	h := NewLambdaHandler(zerolog.Nop(), WithWebhookVerifier(webhook.NewGarmin([]byte("shared-secret"))), WithIntegrationAdapter(stubGarmin{}))
+
+	deliver := func(body string) Response {
+		timestamp := fmt.Sprint(time.Now().Unix())
+		mac := hmac.New(sha256.New, []byte("shared-secret"))
+		mac.Write([]byte(timestamp + "." + body))
+		event := apiEvent("POST", "/api/webhooks/garmin", "", nil, body)
+		event["headers"] = map[string]string{
+			"x-garmin-timestamp": timestamp,
+			"x-garmin-signature": hex.EncodeToString(mac.Sum(nil)),
+		}
+		response, _ := h.HandleRequest(ctx, event)
+		return response
+	}
+	listActivities := func(userID string) []cardio.Activity {
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/activities", userID, nil, ""))
+		var activities []cardio.Activity
+		if err := json.Unmarshal([]byte(response.Body), &activities); err != nil {
+			t.Fatalf("failed to parse activities %d: %s", response.StatusCode, response.Body)
+		}
+		return activities
+	}
+
+	t.Run("connects the Garmin account the provider reports", func(t *testing.T) {
+		// Act
+		rejected, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/integrations/garmin", "runner", nil, `{"accessToken": "forged"}`))
+		response, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/integrations/garmin", "runner", nil, `{"accessToken": "token-garmin-user-1"}`))
+		list, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/integrations", "runner", nil, ""))
+
+		// Assert
+		if rejected.StatusCode != 400 || response.StatusCode != 200 {
+			t.Fatalf("expected status codes 400 and 200, got %d and %d: %s", rejected.StatusCode, response.StatusCode, response.Body)
+		}
+		var connections []ConnectionInfo
+		json.Unmarshal([]byte(list.Body), &connections)
+		if len(connections) != 1 || connections[0].Provider != "garmin" || connections[0].ProviderUserID != "garmin-user-1" {
+			t.Errorf("unexpected connections: %s", list.Body)
+		}
+	})
+
+	t.Run("imports delivered activities for the connected user", func(t *testing.T) {
+		// Arrange
+		body := `{"activities":[
+			{"userId":"garmin-user-1","summaryId":"5001968355","activityType":"TRAIL_RUNNING","startTimeInSeconds":1760000000,"durationInSeconds":1800,"distanceInMeters":5000,"averageHeartRateInBeatsPerMinute":150,"maxHeartRateInBeatsPerMinute":172},
+			{"userId":"garmin-user-2","summaryId":"5001968356","activityType":"CYCLING","startTimeInSeconds":1760000000,"durationInSeconds":3600}
+		]}`
+
+		// Act
+		response := deliver(body)
+
+		// Assert
+		if response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", response.StatusCode, response.Body)
+		}
+		activities := listActivities("runner")
+		if len(activities) != 1 {
+			t.Fatalf("expected 1 imported activity, got %d", len(activities))
+		}
+		a := activities[0]
+		if a.ID != "garmin-5001968355" || a.Source != "garmin" || a.Sport != "run" || a.DurationSeconds != 1800 || a.MaxHR != 172 {
+			t.Errorf("unexpected activity: %+v", a)
+		}
+		pending, _ := h.webhookInbox.Pending(ctx, webhook.ProviderGarmin)
+		if len(pending) != 0 {
+			t.Errorf("expected the event to be marked imported, %d still pending", len(pending))
+		}
+	})
+
+	t.Run("skips delivered activities already recorded", func(t *testing.T) {
+		// Arrange
+		createActivity(t, h, "runner", `{"sport":"ride","startTime":"2025-10-09T10:00:00Z","durationSeconds":3600,"distanceMeters":30000}`)
+		before := len(listActivities("runner"))
+		body := `{"activities":[{"userId":"garmin-user-1","summaryId":"5001968357","activityType":"CYCLING","startTimeInSeconds":1760004030,"durationInSeconds":3580,"distanceInMeters":30100}]}`
+
+		// Act
+		response := deliver(body)
+
+		// Assert
+		if response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", response.StatusCode, response.Body)
+		}
+		if after := len(listActivities("runner")); after != before {
+			t.Errorf("expected the duplicate to be skipped, activities went from %d to %d", before, after)
+		}
+	})
+
+	t.Run("rejects an account connected to another user", func(t *testing.T) {
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/integrations/garmin", "cyclist", nil, `{"accessToken": "token-garmin-user-1"}`))
+
+		// Assert
+		if response.StatusCode != 409 {
+			t.Errorf("expected status code 409, got %d: %s", response.StatusCode, response.Body)
+		}
+	})
+
+	t.Run("ignores the provider user ID the app claims", func(t *testing.T) {
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/integrations/garmin", "cyclist", nil, `{"providerUserId": "garmin-user-1", "accessToken": "token-garmin-user-2"}`))
+
+		// Assert
+		var c ConnectionInfo
+		json.Unmarshal([]byte(response.Body), &c)
+		if response.StatusCode != 200 || c.ProviderUserID != "garmin-user-2" {
+			t.Errorf("expected the cyclist's own account connected, got %d: %s", response.StatusCode, response.Body)
+		}
+	})
+
+	t.Run("rejects unknown providers", func(t *testing.T) {
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/integrations/strava", "runner", nil, `{"accessToken": "token-1"}`))
+
+		// Assert
+		if response.StatusCode != 404 {
+			t.Errorf("expected status code 404, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("disconnects and keeps imported activities", func(t *testing.T) {
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("DELETE", "/api/integrations/garmin", "runner", nil, ""))
+		again, _ := h.HandleRequest(ctx, apiEvent("DELETE", "/api/integrations/garmin", "runner", nil, ""))
+
+		// Assert
+		if response.StatusCode != 200 {
+			t.Errorf("expected status code 200, got %d: %s", response.StatusCode, response.Body)
+		}
+		if again.StatusCode != 404 {
+			t.Errorf("expected status code 404 once disconnected, got %d", again.StatusCode)
+		}
+		if len(listActivities("runner")) != 2 {
+			t.Error("expected imported activities to be kept")
+		}
+	})
+}
+
+func TestLambdaHandler_RecoverySync(t *testing.T) {
+	ctx := context.Background()
+	wearable := &stubWearable{}
+	h := NewLambdaHandler(zerolog.Nop(), WithRecoverySource(wearable))
+	today := time.Now().UTC().Format(readiness.DateLayout)
+
+	t.Run("requires an access token", func(t *testing.T) {
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/integrations/whoop", "sleeper", nil, `{"refreshToken": "refresh-me"}`))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("backfills recovery on connect, refreshing an expired token", func(t *testing.T) {
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/integrations/whoop", "sleeper", nil, `{"accessToken": "expired", "refreshToken": "refresh-me"}`))
+		checkIn, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/checkins/"+today, "sleeper", nil, ""))
+
+		// Assert
+		if response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", response.StatusCode, response.Body)
+		}
+		if wearable.refreshed != 1 {
+			t.Errorf("expected one token refresh, got %d", wearable.refreshed)
+		}
+		var c CheckInResponse
+		json.Unmarshal([]byte(checkIn.Body), &c)
+		if c.Recovery == nil || c.Score != 35 || c.Adjustment.Level != readiness.LevelRecovery {
+			t.Errorf("unexpected check-in: %s", checkIn.Body)
+		}
+	})
+
+	t.Run("keeps the synced reading when the user checks in", func(t *testing.T) {
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/checkins/"+today, "sleeper", nil,
+			`{"sleepHours": 8, "sleepQuality": 5, "soreness": 1, "mood": 5, "recovery": {"source": "whoop", "score": 100}}`))
+
+		// Assert
+		var c CheckInResponse
+		json.Unmarshal([]byte(response.Body), &c)
+		if c.Recovery == nil || c.Recovery.Score != 35 || c.Score != 76 {
+			t.Errorf("unexpected check-in %d: %s", response.StatusCode, response.Body)
+		}
+	})
+
+	t.Run("syncs with the refreshed token", func(t *testing.T) {
+		// Act
+		result, err := h.runSyncRecovery(ctx, "sleeper", "", time.Now().UTC())
+
+		// Assert
+		if err != nil || result.Processed != 1 || result.Failed != 0 {
+			t.Errorf("unexpected result %+v (%v)", result, err)
+		}
+		if wearable.refreshed != 1 {
+			t.Errorf("expected the refreshed token to be kept, got %d refreshes", wearable.refreshed)
+		}
+	})
+}
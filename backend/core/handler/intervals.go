@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"athlete-forge/fit"
+	"athlete-forge/interval"
+	"athlete-forge/store"
+)
+
+// unsafeFilename matches runs of characters kept out of download filenames
+var unsafeFilename = regexp.MustCompile(`[^a-z0-9]+`)
+
+// handleListIntervalWorkouts returns the user's structured cardio workouts
+func (h *LambdaHandler) handleListIntervalWorkouts(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	workouts, err := h.intervals.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, workouts)
+}
+
+// handleGetIntervalWorkout returns a single structured cardio workout
+func (h *LambdaHandler) handleGetIntervalWorkout(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	w, err := h.intervals.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Cardio workout not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, w)
+}
+
+// handleCreateIntervalWorkout builds a new structured cardio workout from its
+// warm-up, repeats and cool-down
+func (h *LambdaHandler) handleCreateIntervalWorkout(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var w interval.Workout
+	if err := decodeBody(event, &w); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	now := time.Now().UTC()
+	w.ID = ""
+	w.UserID = userID
+	w.CreatedAt = now
+	w.UpdatedAt = now
+
+	if err := w.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if err := h.intervals.Save(ctx, &w); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(201, w)
+}
+
+// handlePutIntervalWorkout replaces a structured cardio workout
+func (h *LambdaHandler) handlePutIntervalWorkout(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	existing, err := h.intervals.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Cardio workout not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	var w interval.Workout
+	if err := decodeBody(event, &w); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	w.ID = existing.ID
+	w.UserID = userID
+	w.CreatedAt = existing.CreatedAt
+	w.UpdatedAt = time.Now().UTC()
+
+	if err := w.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if err := h.intervals.Save(ctx, &w); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, w)
+}
+
+// handleDeleteIntervalWorkout removes a structured cardio workout, returning
+// what was removed
+func (h *LambdaHandler) handleDeleteIntervalWorkout(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	w, err := h.intervals.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Cardio workout not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	if err := h.intervals.Delete(ctx, userID, w.ID); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, w)
+}
+
+// handleIntervalWorkoutFIT downloads a structured cardio workout as a FIT
+// workout file, which Garmin Connect and devices can import
+func (h *LambdaHandler) handleIntervalWorkoutFIT(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	w, err := h.intervals.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Cardio workout not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	name := strings.Trim(unsafeFilename.ReplaceAllString(strings.ToLower(w.Name), "-"), "-")
+	if name == "" {
+		name = "workout"
+	}
+	return Response{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type":                fit.ContentType,
+			"Content-Disposition":         `attachment; filename="` + name + `.fit"`,
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body:            base64.StdEncoding.EncodeToString(w.FIT()),
+		IsBase64Encoded: true,
+	}, nil
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"athlete-forge/fit"
+	"athlete-forge/interval"
+)
+
+const intervalWorkoutBody = `{
+	"name": "Threshold 3x10",
+	"sport": "ride",
+	"steps": [
+		{"type": "warmup", "durationSeconds": 900},
+		{"type": "repeat", "repeat": 3, "steps": [
+			{"type": "interval", "durationSeconds": 600, "target": {"type": "power", "low": 240, "high": 260}},
+			{"type": "recovery", "durationSeconds": 300}
+		]},
+		{"type": "cooldown", "durationSeconds": 600}
+	]
+}`
+
+func TestLambdaHandler_IntervalWorkouts(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("builds a workout and exports it as a FIT file", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		created, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/cardio-workouts", "user-1", nil, intervalWorkoutBody))
+		var w interval.Workout
+		json.Unmarshal([]byte(created.Body), &w)
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("GET", "/api/cardio-workouts/"+w.ID+"/fit", "user-1", nil, ""))
+
+		// Assert
+		if created.StatusCode != 201 || w.ID == "" {
+			t.Fatalf("unexpected create response %d: %s", created.StatusCode, created.Body)
+		}
+		if err != nil || response.StatusCode != 200 || response.Headers["Content-Type"] != fit.ContentType || !response.IsBase64Encoded {
+			t.Fatalf("unexpected response %d: %v (%v)", response.StatusCode, response.Headers, err)
+		}
+		if response.Headers["Content-Disposition"] != `attachment; filename="threshold-3x10.fit"` {
+			t.Errorf("unexpected disposition %q", response.Headers["Content-Disposition"])
+		}
+		file, _ := base64.StdEncoding.DecodeString(response.Body)
+		if len(file) < 16 || string(file[8:12]) != ".FIT" || fit.CRC(file) != 0 {
+			t.Errorf("expected a FIT file, got % x", file)
+		}
+	})
+
+	t.Run("replaces and deletes workouts", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		created, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/cardio-workouts", "user-1", nil, intervalWorkoutBody))
+		var w interval.Workout
+		json.Unmarshal([]byte(created.Body), &w)
+
+		// Act
+		updated, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/cardio-workouts/"+w.ID, "user-1", nil, `{"name":"Easy spin","sport":"ride","steps":[{"type":"interval","durationSeconds":3600}]}`))
+		deleted, _ := h.HandleRequest(ctx, apiEvent("DELETE", "/api/cardio-workouts/"+w.ID, "user-1", nil, ""))
+		missing, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/cardio-workouts/"+w.ID, "user-1", nil, ""))
+
+		// Assert
+		var replaced interval.Workout
+		json.Unmarshal([]byte(updated.Body), &replaced)
+		if updated.StatusCode != 200 || replaced.Name != "Easy spin" || !replaced.CreatedAt.Equal(w.CreatedAt) {
+			t.Errorf("unexpected update %d: %s", updated.StatusCode, updated.Body)
+		}
+		if deleted.StatusCode != 200 || missing.StatusCode != 404 {
+			t.Errorf("expected the workout deleted, got %d then %d", deleted.StatusCode, missing.StatusCode)
+		}
+	})
+
+	t.Run("rejects invalid steps", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("POST", "/api/cardio-workouts", "user-1", nil, `{"name":"Bad","sport":"run","steps":[{"type":"repeat","repeat":1,"steps":[{"type":"interval"}]}]}`))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,513 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"athlete-forge/jobs"
+	"athlete-forge/realtime"
+	"athlete-forge/store"
+)
+
+// Job names; scheduled jobs are sent by EventBridge rules as {"job": "<name>"} and
+// background jobs are dispatched by request handlers
+const (
+	JobWeeklyReports        = "weekly-reports"
+	JobRenderExport         = "render-export"
+	JobRotateProfileKeys    = "rotate-profile-keys"
+	JobBulkEdit             = "bulk-edit"
+	JobRecomputeStats       = "recompute-stats"
+	JobRegionHeartbeat      = "region-heartbeat"
+	JobMigrateItems         = "migrate-items"
+	JobSeedDemo             = "seed-demo"
+	JobImportWebhooks       = "import-webhooks"
+	JobSyncRecovery         = "sync-recovery"
+	JobExportWarehouse      = "export-warehouse"
+	JobAggregatePercentiles = "aggregate-percentiles"
+	JobRenderShareCard      = "render-share-card"
+	JobPurgeMessages        = "purge-messages"
+	JobRankExercises        = "rank-exercises"
+	JobRecordExerciseUse    = "record-exercise-use"
+	JobFinishSessions       = "finish-sessions"
+	JobBackfill             = "backfill"
+)
+
+// activeOnly lists the scheduled jobs only the active region runs; the passive
+// region sees their results through replication
+var activeOnly = map[string]bool{
+	JobWeeklyReports:        true,
+	JobRotateProfileKeys:    true,
+	JobMigrateItems:         true,
+	JobSyncRecovery:         true,
+	JobExportWarehouse:      true,
+	JobAggregatePercentiles: true,
+	JobPurgeMessages:        true,
+	JobRankExercises:        true,
+	JobFinishSessions:       true,
+}
+
+// JobEvent invokes a job; UserID and ID identify the subject of background jobs
+// and JobID the tracked job users poll, if any. ClientRequestID is the app's ID
+// for the request that started the job, carried into the job's logs
+type JobEvent struct {
+	Job             string `json:"job"`
+	UserID          string `json:"userId,omitempty"`
+	ID              string `json:"id,omitempty"`
+	JobID           string `json:"jobId,omitempty"`
+	ClientRequestID string `json:"clientRequestId,omitempty"`
+}
+
+// JobResult reports how a job went; Error is a message safe to show the user
+// when the job as a whole failed
+type JobResult struct {
+	Job       string `json:"job"`
+	Processed int    `json:"processed"`
+	Failed    int    `json:"failed"`
+	Skipped   int    `json:"skipped,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// progress reports how far a tracked job has got through total items; it does
+// nothing for untracked jobs
+type progress func(ctx context.Context, processed, total int)
+
+// messageJobProgress is the type of messages carrying a tracked job's status
+// and progress
+const messageJobProgress = "jobProgress"
+
+// jobProgressInterval is the least time between progress messages of a job, so
+// jobs reporting every item do not flood devices; starting and finishing are
+// always sent
+const jobProgressInterval = time.Second
+
+// Long-polling a job waits up to maxJobWait, within API Gateway's 29 second
+// limit, reading the job every jobPollInterval and answering at least
+// jobWaitReserve before the invocation's deadline
+const (
+	maxJobWait      = 20 * time.Second
+	jobPollInterval = time.Second
+	jobWaitReserve  = 2 * time.Second
+)
+
+// jobRunner runs one job, reporting progress as it goes
+type jobRunner func(ctx context.Context, track progress) (JobResult, error)
+
+// parseJobEvent returns the job when event is a job invocation rather than an API request
+func parseJobEvent(event interface{}) (JobEvent, bool) {
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		return JobEvent{}, false
+	}
+	var job JobEvent
+	if err := json.Unmarshal(eventBytes, &job); err != nil || job.Job == "" {
+		return JobEvent{}, false
+	}
+	return job, true
+}
+
+// sqsEvent is the batch of messages Lambda receives from the jobs queue
+type sqsEvent struct {
+	Records []struct {
+		MessageID   string `json:"messageId"`
+		EventSource string `json:"eventSource"`
+		Body        string `json:"body"`
+	} `json:"Records"`
+}
+
+// parseQueueEvent returns the jobs in event when it is a batch from the jobs queue
+func parseQueueEvent(event interface{}) ([]JobEvent, bool) {
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		return nil, false
+	}
+	var batch sqsEvent
+	if err := json.Unmarshal(eventBytes, &batch); err != nil || len(batch.Records) == 0 {
+		return nil, false
+	}
+
+	events := make([]JobEvent, 0, len(batch.Records))
+	for _, record := range batch.Records {
+		if record.EventSource != "aws:sqs" {
+			return nil, false
+		}
+		var job JobEvent
+		if err := json.Unmarshal([]byte(record.Body), &job); err != nil || job.Job == "" {
+			return nil, false
+		}
+		events = append(events, job)
+	}
+	return events, true
+}
+
+// runQueuedJobs runs a batch from the jobs queue; any failure fails the batch so
+// the queue redelivers it, which is why the queue delivers one message at a time
+func (h *LambdaHandler) runQueuedJobs(ctx context.Context, batch []JobEvent) (Response, error) {
+	for _, job := range batch {
+		response, err := h.runJob(ctx, job)
+		if err != nil {
+			return Response{}, err
+		}
+		if response.StatusCode >= 400 {
+			h.logger.Error().
+				Str("job", job.Job).
+				Str("body", response.Body).
+				Msg("Queued job rejected")
+		}
+	}
+	return h.createJSONResponse(200, map[string]int{"jobs": len(batch)})
+}
+
+// dispatch queues a background job, running it in-process when no dispatcher is configured
+func (h *LambdaHandler) dispatch(ctx context.Context, job JobEvent) error {
+	if h.dispatcher != nil {
+		return h.dispatcher.Dispatch(ctx, job)
+	}
+	_, err := h.runJob(ctx, job)
+	return err
+}
+
+// enqueue saves j for the user to poll and dispatches event to run it, starting
+// the state machine instead for jobs that run in steps when one is configured
+func (h *LambdaHandler) enqueue(ctx context.Context, j *jobs.Job, event JobEvent) error {
+	if err := h.jobs.Save(ctx, j); err != nil {
+		return err
+	}
+	event.JobID = j.ID
+	event.ClientRequestID = clientRequestID(ctx)
+	if _, ok := h.stepperFor(event.Job); ok && h.stateMachine != nil {
+		return h.stateMachine.Dispatch(ctx, TaskState{Job: event.Job, UserID: event.UserID, ID: event.ID, JobID: event.JobID, ClientRequestID: event.ClientRequestID})
+	}
+	return h.dispatch(ctx, event)
+}
+
+// runnerFor returns the function that runs job, or false for unknown jobs
+func (h *LambdaHandler) runnerFor(job JobEvent) (jobRunner, bool) {
+	switch job.Job {
+	case JobWeeklyReports:
+		return func(ctx context.Context, _ progress) (JobResult, error) {
+			return h.runWeeklyReports(ctx, time.Now().UTC())
+		}, true
+	case JobRenderExport:
+		return func(ctx context.Context, _ progress) (JobResult, error) {
+			return h.runRenderExport(ctx, job.UserID, job.ID)
+		}, true
+	case JobRenderShareCard:
+		return func(ctx context.Context, _ progress) (JobResult, error) {
+			return h.runRenderShareCard(ctx, job.UserID, job.ID)
+		}, true
+	case JobRotateProfileKeys:
+		return func(ctx context.Context, _ progress) (JobResult, error) {
+			return h.runRotateProfileKeys(ctx)
+		}, true
+	case JobBulkEdit:
+		return func(ctx context.Context, track progress) (JobResult, error) {
+			return h.runBulkEdit(ctx, job.UserID, job.ID, track)
+		}, true
+	case JobRecomputeStats:
+		return func(ctx context.Context, track progress) (JobResult, error) {
+			return h.runRecomputeStats(ctx, job.UserID, time.Now().UTC(), track)
+		}, true
+	case JobBackfill:
+		return func(ctx context.Context, track progress) (JobResult, error) {
+			return h.runBackfill(ctx, job.UserID, job.ID, track)
+		}, true
+	case JobRegionHeartbeat:
+		return func(ctx context.Context, _ progress) (JobResult, error) {
+			return h.runRegionHeartbeat(ctx)
+		}, true
+	case JobSeedDemo:
+		return func(ctx context.Context, track progress) (JobResult, error) {
+			return h.runSeedDemo(ctx, job.UserID, time.Now().UTC(), track)
+		}, true
+	case JobMigrateItems:
+		return func(ctx context.Context, _ progress) (JobResult, error) {
+			return h.runMigrateItems(ctx)
+		}, true
+	case JobImportWebhooks:
+		return func(ctx context.Context, _ progress) (JobResult, error) {
+			return h.runImportWebhooks(ctx, job.ID)
+		}, true
+	case JobSyncRecovery:
+		return func(ctx context.Context, _ progress) (JobResult, error) {
+			return h.runSyncRecovery(ctx, job.UserID, job.ID, time.Now().UTC())
+		}, true
+	case JobExportWarehouse:
+		return func(ctx context.Context, _ progress) (JobResult, error) {
+			return h.runExportWarehouse(ctx, time.Now().UTC())
+		}, true
+	case JobAggregatePercentiles:
+		return func(ctx context.Context, _ progress) (JobResult, error) {
+			return h.runAggregatePercentiles(ctx, time.Now().UTC())
+		}, true
+	case JobPurgeMessages:
+		return func(ctx context.Context, _ progress) (JobResult, error) {
+			return h.runPurgeMessages(ctx, time.Now().UTC())
+		}, true
+	case JobRecordExerciseUse:
+		return func(ctx context.Context, _ progress) (JobResult, error) {
+			return h.runRecordExerciseUse(ctx, job.UserID, job.ID)
+		}, true
+	case JobFinishSessions:
+		return func(ctx context.Context, _ progress) (JobResult, error) {
+			return h.runFinishSessions(ctx, time.Now().UTC())
+		}, true
+	case JobRankExercises:
+		return func(ctx context.Context, _ progress) (JobResult, error) {
+			return h.runRankExercises(ctx, time.Now().UTC())
+		}, true
+	}
+	return nil, false
+}
+
+// runJob executes a job, keeping its tracked job up to date when it has one
+func (h *LambdaHandler) runJob(ctx context.Context, job JobEvent) (Response, error) {
+	if job.ClientRequestID != "" {
+		defer h.scope.begin(job.ClientRequestID)()
+		ctx = withClientRequestID(ctx, job.ClientRequestID)
+	}
+	run, ok := h.runnerFor(job)
+	if !ok {
+		return h.createErrorResponse(400, fmt.Sprintf("unknown job %q", job.Job)), nil
+	}
+	if activeOnly[job.Job] {
+		passive, err := h.isPassive(ctx)
+		if err != nil {
+			return Response{}, err
+		}
+		if passive {
+			h.logger.Info().
+				Str("job", job.Job).
+				Msg("Scheduled job skipped in passive region")
+			return h.createJSONResponse(200, JobResult{Job: job.Job})
+		}
+	}
+
+	tracked, err := h.startTracking(ctx, job)
+	if err != nil {
+		return Response{}, err
+	}
+	result, err := run(ctx, h.reporter(tracked))
+	if trackErr := h.finishTracking(ctx, tracked, result, err); trackErr != nil && err == nil {
+		err = trackErr
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	h.logger.Info().
+		Str("job", result.Job).
+		Int("processed", result.Processed).
+		Int("failed", result.Failed).
+		Msg("Scheduled job completed")
+
+	return h.createJSONResponse(200, result)
+}
+
+// startTracking marks the job's tracked job running, returning nil for untracked jobs
+func (h *LambdaHandler) startTracking(ctx context.Context, job JobEvent) (*jobs.Job, error) {
+	if job.JobID == "" {
+		return nil, nil
+	}
+	tracked, err := h.jobs.Get(ctx, job.UserID, job.JobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load job %s: %w", job.JobID, err)
+	}
+	tracked.Start(time.Now().UTC())
+	if err := h.jobs.Save(ctx, tracked); err != nil {
+		return nil, err
+	}
+	h.publishJob(ctx, tracked)
+	return tracked, nil
+}
+
+// reporter saves progress on tracked and sends it to the user's devices at
+// most every jobProgressInterval; progress is advisory, so a failed save is
+// logged rather than stopping the job
+func (h *LambdaHandler) reporter(tracked *jobs.Job) progress {
+	var published time.Time
+	return func(ctx context.Context, processed, total int) {
+		if tracked == nil {
+			return
+		}
+		now := time.Now().UTC()
+		tracked.Report(processed, total, now)
+		if err := h.jobs.Save(ctx, tracked); err != nil {
+			h.logger.Warn().
+				Err(err).
+				Str("job_id", tracked.ID).
+				Msg("Failed to save job progress")
+			return
+		}
+		if now.Sub(published) >= jobProgressInterval || processed >= total {
+			h.publishJob(ctx, tracked)
+			published = now
+		}
+	}
+}
+
+// publishJob broadcasts tracked to the user's connected devices, so they can
+// show live progress instead of polling. Like saving progress, a failed
+// broadcast is logged rather than stopping the job
+func (h *LambdaHandler) publishJob(ctx context.Context, tracked *jobs.Job) {
+	if _, err := h.hub.Broadcast(ctx, tracked.UserID, realtime.Message{Type: messageJobProgress, Data: tracked}); err != nil {
+		h.logger.Warn().
+			Err(err).
+			Str("job_id", tracked.ID).
+			Msg("Failed to broadcast job progress")
+	}
+}
+
+// finishTracking records the outcome on tracked. Errors returned by the job are
+// logged by the caller and shown to users as a generic message, so internal
+// details are not exposed
+func (h *LambdaHandler) finishTracking(ctx context.Context, tracked *jobs.Job, result JobResult, runErr error) error {
+	if tracked == nil {
+		return nil
+	}
+	now := time.Now().UTC()
+	switch {
+	case runErr != nil:
+		tracked.Fail("Job failed", now)
+	case result.Error != "":
+		tracked.Fail(result.Error, now)
+	default:
+		tracked.Succeed(result.Failed, now)
+	}
+	if err := h.jobs.Save(ctx, tracked); err != nil {
+		return err
+	}
+	h.publishJob(ctx, tracked)
+	return nil
+}
+
+// runWeeklyReports compiles last week's report for every active user, by the
+// first day of week of their locale; a failure for one user is logged and does
+// not stop the others
+func (h *LambdaHandler) runWeeklyReports(ctx context.Context, now time.Time) (JobResult, error) {
+	result := JobResult{Job: JobWeeklyReports}
+	users, err := h.users.List(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	for _, user := range users {
+		c, err := h.conventions(ctx, user.UserID)
+		if err == nil {
+			_, err = h.compileWeeklyReport(ctx, user.UserID, c.WeekStart(now).AddDate(0, 0, -7))
+		}
+		if err != nil {
+			result.Failed++
+			h.logger.Error().
+				Err(err).
+				Str("user_id", user.UserID).
+				Msg("Failed to compile weekly report")
+			continue
+		}
+		result.Processed++
+	}
+	return result, nil
+}
+
+// runRotateProfileKeys re-encrypts every profile whose sensitive fields were
+// sealed under an older master key; profiles already on the current key are
+// left alone, so the job can be re-run until nothing fails
+func (h *LambdaHandler) runRotateProfileKeys(ctx context.Context) (JobResult, error) {
+	result := JobResult{Job: JobRotateProfileKeys}
+	userIDs, err := h.profiles.Encrypted(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	for _, userID := range userIDs {
+		rotated, err := h.profiles.Reencrypt(ctx, userID)
+		if err != nil {
+			result.Failed++
+			h.logger.Error().
+				Err(err).
+				Str("user_id", userID).
+				Msg("Failed to re-encrypt profile")
+			continue
+		}
+		if rotated {
+			result.Processed++
+		}
+	}
+	return result, nil
+}
+
+// handleListJobs returns the user's tracked jobs
+func (h *LambdaHandler) handleListJobs(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	list, err := h.jobs.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, list)
+}
+
+// handleGetJob returns a tracked job's status and progress for polling. With
+// ?wait=<seconds> it long-polls, answering once the job has changed since
+// ?after=, its updatedAt as last seen, or since the request when omitted
+func (h *LambdaHandler) handleGetJob(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var wait time.Duration
+	if raw := event.QueryStringParameters["wait"]; raw != "" {
+		seconds, err := strconv.Atoi(raw)
+		if err != nil || seconds < 0 || time.Duration(seconds)*time.Second > maxJobWait {
+			return h.createErrorResponse(400, fmt.Sprintf("wait must be between 0 and %d seconds", int(maxJobWait/time.Second))), nil
+		}
+		wait = time.Duration(seconds) * time.Second
+	}
+	var after time.Time
+	if raw := event.QueryStringParameters["after"]; raw != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return h.createErrorResponse(400, "after must be an RFC 3339 timestamp"), nil
+		}
+		after = parsed
+	}
+
+	id := event.PathParameters["id"]
+	j, err := h.jobs.Get(ctx, userID, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Job not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	if after.IsZero() {
+		after = j.UpdatedAt
+	}
+
+	deadline := time.Now().Add(wait)
+	if d, ok := ctx.Deadline(); ok && d.Add(-jobWaitReserve).Before(deadline) {
+		deadline = d.Add(-jobWaitReserve)
+	}
+	for !j.Finished() && !j.UpdatedAt.After(after) {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return Response{}, ctx.Err()
+		case <-time.After(min(remaining, jobPollInterval)):
+		}
+		if j, err = h.jobs.Get(ctx, userID, id); err != nil {
+			return Response{}, err
+		}
+	}
+	return h.createJSONResponse(200, j)
+}
@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"athlete-forge/jobs"
+	"athlete-forge/migrations"
+	"athlete-forge/report"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+
+	"github.com/rs/zerolog"
+)
+
+func TestLambdaHandler_TrackedJobs(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("recomputes weekly reports and tracks progress", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		completed := time.Now().UTC().AddDate(0, 0, -21)
+		h.workouts.Save(ctx, &workout.Workout{UserID: "user-1", Status: workout.StatusCompleted, StartedAt: completed, CompletedAt: &completed,
+			Exercises: []workout.Exercise{{Name: "Squat", Sets: []workout.Set{{Reps: 5, Weight: 100}}}}})
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("POST", "/api/stats/recompute", "user-1", nil, ""))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if response.StatusCode != 202 {
+			t.Fatalf("expected status code 202, got %d: %s", response.StatusCode, response.Body)
+		}
+		var j jobs.Job
+		json.Unmarshal([]byte(response.Body), &j)
+		if j.Status != jobs.StatusSucceeded || j.Progress != 100 || j.Total < 3 || j.Processed != j.Total || j.Attempts != 1 {
+			t.Errorf("unexpected job: %+v", j)
+		}
+		stored, _ := h.reports.ListWeekly(ctx, "user-1")
+		if len(stored) != j.Total {
+			t.Errorf("expected %d stored reports, got %d", j.Total, len(stored))
+		}
+	})
+
+	t.Run("exports are polled through their job", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		created, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/reports/exports", "user-1", nil, `{"from":"2024-01-01","to":"2024-01-31"}`))
+		var e report.Export
+		json.Unmarshal([]byte(created.Body), &e)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/jobs/"+e.JobID, "user-1", nil, ""))
+
+		// Assert
+		var j jobs.Job
+		json.Unmarshal([]byte(response.Body), &j)
+		if response.StatusCode != 200 || j.Type != JobRenderExport || j.SubjectID != e.ID || j.Status != jobs.StatusSucceeded {
+			t.Errorf("unexpected job %d: %s", response.StatusCode, response.Body)
+		}
+	})
+
+	t.Run("jobs are private to their owner", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		created, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/stats/recompute", "user-1", nil, ""))
+		var j jobs.Job
+		json.Unmarshal([]byte(created.Body), &j)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/jobs/"+j.ID, "user-2", nil, ""))
+
+		// Assert
+		if response.StatusCode != 404 {
+			t.Errorf("expected status code 404, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("runs jobs delivered by the queue", func(t *testing.T) {
+		// Arrange
+		dispatcher := &recordingDispatcher{}
+		h := newTestHandler()
+		h.dispatcher = dispatcher
+		created, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/stats/recompute", "user-1", nil, ""))
+		var queued jobs.Job
+		json.Unmarshal([]byte(created.Body), &queued)
+		body, _ := json.Marshal(dispatcher.events[0])
+		event := map[string]interface{}{"Records": []interface{}{
+			map[string]interface{}{"messageId": "m1", "eventSource": "aws:sqs", "body": string(body)},
+		}}
+
+		// Act
+		_, err := h.HandleRequest(ctx, event)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		j, _ := h.jobs.Get(ctx, "user-1", queued.ID)
+		if queued.Status != jobs.StatusQueued || j.Status != jobs.StatusSucceeded {
+			t.Errorf("expected queued job to succeed, got %s then %s", queued.Status, j.Status)
+		}
+	})
+}
+
+func TestLambdaHandler_JobProgress(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("broadcasts progress to the user's devices", func(t *testing.T) {
+		// Arrange
+		poster := &recordingPoster{}
+		h := NewLambdaHandler(zerolog.Nop(), WithAuthProvider(stubProvider{}), WithSessionSecret([]byte("secret")), WithWebSocketPoster(poster))
+		session := signIn(t, h, "sam")
+		h.HandleRequest(ctx, webSocketEventFor("CONNECT", "phone", map[string]string{"token": session.Token}, ""))
+		completed := time.Now().UTC().AddDate(0, 0, -14)
+		h.workouts.Save(ctx, &workout.Workout{UserID: session.User.ID, Status: workout.StatusCompleted, StartedAt: completed, CompletedAt: &completed,
+			Exercises: []workout.Exercise{{Name: "Squat", Sets: []workout.Set{{Reps: 5, Weight: 100}}}}})
+
+		// Act
+		response, _ := h.HandleRequest(ctx, withBearer(apiEvent("POST", "/api/stats/recompute", "", nil, ""), session.Token))
+
+		// Assert
+		if response.StatusCode != 202 {
+			t.Fatalf("expected status code 202, got %d: %s", response.StatusCode, response.Body)
+		}
+		messages := poster.posted["phone"]
+		if len(messages) < 3 {
+			t.Fatalf("expected start, progress and finish messages, got %+v", messages)
+		}
+		var first, last jobs.Job
+		data, _ := json.Marshal(messages[0].Data)
+		json.Unmarshal(data, &first)
+		data, _ = json.Marshal(messages[len(messages)-1].Data)
+		json.Unmarshal(data, &last)
+		if messages[0].Type != messageJobProgress || first.Status != jobs.StatusRunning || first.StartedAt == nil {
+			t.Errorf("unexpected first message: %+v", messages[0])
+		}
+		if last.Status != jobs.StatusSucceeded || last.Processed != last.Total || last.ETA != nil {
+			t.Errorf("unexpected last message: %+v", messages[len(messages)-1])
+		}
+	})
+
+	t.Run("long-polls until the job changes or the wait ends", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		queued := jobs.New("user-1", JobRecomputeStats, time.Now().UTC())
+		h.jobs.Save(ctx, queued)
+		path := "/api/jobs/" + queued.ID
+
+		// Act
+		started := time.Now()
+		waited, _ := h.HandleRequest(ctx, apiEvent("GET", path, "user-1", map[string]string{"wait": "1"}, ""))
+		elapsed := time.Since(started)
+		changed, _ := h.HandleRequest(ctx, apiEvent("GET", path, "user-1", map[string]string{"wait": "20", "after": queued.UpdatedAt.Add(-time.Second).Format(time.RFC3339Nano)}, ""))
+		invalid, _ := h.HandleRequest(ctx, apiEvent("GET", path, "user-1", map[string]string{"wait": "60"}, ""))
+
+		// Assert
+		if waited.StatusCode != 200 || elapsed < time.Second {
+			t.Errorf("expected to wait a second for a change, got %d after %v", waited.StatusCode, elapsed)
+		}
+		if changed.StatusCode != 200 || time.Since(started) > 2*time.Second {
+			t.Errorf("expected a job changed since after to be returned at once, got %d", changed.StatusCode)
+		}
+		if invalid.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", invalid.StatusCode)
+		}
+	})
+}
+
+func TestLambdaHandler_SchemaMigrations(t *testing.T) {
+	ctx := context.Background()
+	setup := func(t *testing.T) (*LambdaHandler, *store.MemoryStore) {
+		t.Helper()
+		r := migrations.NewRegistry()
+		r.Register("WORKOUT#", migrations.Migration{
+			Version:     1,
+			Description: "rename note to notes",
+			Up: func(doc map[string]interface{}) error {
+				if note, ok := doc["note"]; ok {
+					doc["notes"] = note
+					delete(doc, "note")
+				}
+				return nil
+			},
+		})
+		backing := store.NewMemoryStore()
+		h := NewLambdaHandler(zerolog.Nop(), WithStore(backing), WithSchemaMigrations(r))
+		return h, backing
+	}
+
+	t.Run("items written under an older schema are upgraded when read", func(t *testing.T) {
+		// Arrange
+		h, backing := setup(t)
+		backing.Put(ctx, store.UserPK("user-1"), "WORKOUT#w1", map[string]interface{}{
+			"id": "w1", "userId": "user-1", "status": "active", "startedAt": "2024-01-01T10:00:00Z", "note": "felt strong",
+		})
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("GET", "/api/workouts/w1", "user-1", nil, ""))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var got map[string]interface{}
+		json.Unmarshal([]byte(response.Body), &got)
+		if got["notes"] != "felt strong" {
+			t.Errorf("expected migrated notes, got %s", response.Body)
+		}
+	})
+
+	t.Run("the migrate-items job rewrites outdated items", func(t *testing.T) {
+		// Arrange
+		h, backing := setup(t)
+		h.users.Touch(ctx, "user-1", time.Now())
+		backing.Put(ctx, store.UserPK("user-1"), "WORKOUT#w1", map[string]interface{}{
+			"id": "w1", "userId": "user-1", "status": "active", "startedAt": "2024-01-01T10:00:00Z", "note": "felt strong",
+		})
+
+		// Act
+		response, err := h.HandleRequest(ctx, cognitoEvent("POST", "/api/admin/jobs/migrate-items", "admin-1", map[string]interface{}{"cognito:groups": "admin"}))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var result JobResult
+		json.Unmarshal([]byte(response.Body), &result)
+		if response.StatusCode != 200 || result.Processed != 1 || result.Failed != 0 {
+			t.Fatalf("expected one item migrated, got %d: %s", response.StatusCode, response.Body)
+		}
+		var raw map[string]interface{}
+		backing.Get(ctx, store.UserPK("user-1"), "WORKOUT#w1", &raw)
+		if raw["notes"] != "felt strong" || raw[migrations.VersionField] != float64(1) {
+			t.Errorf("expected the stored item rewritten, got %v", raw)
+		}
+	})
+}
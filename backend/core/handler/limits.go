@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// defaultMaxBodySize is the largest request body a route accepts unless the
+	// handler or the route sets its own limit
+	defaultMaxBodySize = 1 << 20
+
+	// largeBodySize is the limit for routes taking bulk payloads, such as provider
+	// webhooks; Lambda rejects invocations over 6MB before they reach the handler
+	largeBodySize = 5 << 20
+)
+
+// bodySize returns the size of the request body once decoded, without decoding
+// it, so an oversized base64 body is rejected before it is copied again
+func bodySize(event *APIGatewayProxyEvent) int {
+	if event.IsBase64Encoded {
+		return base64.StdEncoding.DecodedLen(len(event.Body))
+	}
+	return len(event.Body)
+}
+
+// rejectLargeBody returns 413 when the request body exceeds the route's limit
+func (h *LambdaHandler) rejectLargeBody(event *APIGatewayProxyEvent, r *route) *Response {
+	limit := h.maxBody(r)
+	if bodySize(event) <= limit {
+		return nil
+	}
+
+	response := h.createErrorResponse(413, fmt.Sprintf("Request body is larger than the %s this endpoint accepts", formatSize(limit)))
+	return &response
+}
+
+// maxBody returns the largest body r accepts
+func (h *LambdaHandler) maxBody(r *route) int {
+	if r.maxBody != 0 {
+		return r.maxBody
+	}
+	return h.maxBodySize
+}
+
+// decompressBody replaces a gzip-encoded request body with its contents, so
+// handlers only see plain bodies. The decompressed body is held to the route's
+// size limit, so a small upload cannot expand into an exhausted memory limit.
+// Routes with rawBody keep the body as sent, since signatures over it must
+// verify
+func (h *LambdaHandler) decompressBody(event *APIGatewayProxyEvent, r *route) *Response {
+	encoding := strings.ToLower(strings.TrimSpace(header(event, "Content-Encoding")))
+	if encoding == "" || encoding == "identity" || r.rawBody || event.Body == "" {
+		return nil
+	}
+	if encoding != "gzip" && encoding != "x-gzip" {
+		response := h.createErrorResponse(415, fmt.Sprintf("Content-Encoding %q is not supported", encoding))
+		response.Headers["Accept-Encoding"] = "gzip"
+		return &response
+	}
+
+	compressed := []byte(event.Body)
+	if event.IsBase64Encoded {
+		var err error
+		if compressed, err = base64.StdEncoding.DecodeString(event.Body); err != nil {
+			response := h.createErrorResponse(400, "Request body is not valid base64")
+			return &response
+		}
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		response := h.createErrorResponse(400, "Request body is not valid gzip")
+		return &response
+	}
+	limit := h.maxBody(r)
+	body, err := io.ReadAll(io.LimitReader(reader, int64(limit)+1))
+	if err != nil {
+		response := h.createErrorResponse(400, "Request body is not valid gzip")
+		return &response
+	}
+	if len(body) > limit {
+		response := h.createErrorResponse(413, fmt.Sprintf("Decompressed request body is larger than the %s this endpoint accepts", formatSize(limit)))
+		return &response
+	}
+
+	event.Body, event.IsBase64Encoded = string(body), false
+	for name := range event.Headers {
+		if strings.EqualFold(name, "Content-Encoding") {
+			delete(event.Headers, name)
+		}
+	}
+	return nil
+}
+
+// formatSize renders n bytes in the largest whole unit that fits
+func formatSize(n int) string {
+	switch {
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%dMB", n>>20)
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return fmt.Sprintf("%dKB", n>>10)
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestLambdaHandler_BodyLimits(t *testing.T) {
+	ctx := context.Background()
+	oversized := `{"notes":"` + strings.Repeat("a", defaultMaxBodySize) + `"}`
+
+	t.Run("bodies over the default limit are rejected with 413", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("POST", "/api/workouts", "user-1", nil, oversized))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if response.StatusCode != 413 {
+			t.Errorf("expected status 413, got %d", response.StatusCode)
+		}
+		if !strings.Contains(response.Body, "1MB") {
+			t.Errorf("expected the limit in the error, got %s", response.Body)
+		}
+	})
+
+	t.Run("base64 bodies are measured by their decoded size", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		event := apiEvent("POST", "/api/workouts", "user-1", nil, base64.StdEncoding.EncodeToString(make([]byte, defaultMaxBodySize-2)))
+		event["isBase64Encoded"] = true
+
+		// Act
+		response, _ := h.HandleRequest(ctx, event)
+
+		// Assert
+		if response.StatusCode == 413 {
+			t.Errorf("expected a body under the limit once decoded to be accepted, got %s", response.Body)
+		}
+	})
+
+	t.Run("the handler limit is configurable", func(t *testing.T) {
+		// Arrange
+		h := NewLambdaHandler(zerolog.New(&bytes.Buffer{}), WithMaxBodySize(16))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/profile", "user-1", nil, `{"displayName":"Ada Lovelace"}`))
+
+		// Assert
+		if response.StatusCode != 413 || !strings.Contains(response.Body, "16 bytes") {
+			t.Errorf("expected 413 naming the 16 byte limit, got %d %s", response.StatusCode, response.Body)
+		}
+	})
+
+	t.Run("routes can accept larger bodies", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/webhooks/garmin", "", nil, oversized))
+
+		// Assert
+		if response.StatusCode == 413 {
+			t.Error("expected the webhook route to accept a body over the default limit")
+		}
+	})
+}
+
+// gzipped compresses body
+func gzipped(body string) string {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(body))
+	w.Close()
+	return buf.String()
+}
+
+func TestLambdaHandler_CompressedBodies(t *testing.T) {
+	ctx := context.Background()
+	encoded := func(method, path, encoding, body string) map[string]interface{} {
+		event := apiEvent(method, path, "user-1", nil, body)
+		event["headers"] = map[string]string{"Content-Encoding": encoding}
+		return event
+	}
+
+	t.Run("gzip bodies are decompressed before handling", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		body := base64.StdEncoding.EncodeToString([]byte(gzipped(`{"name":"Legs","exercises":[{"name":"Squat"}]}`)))
+		event := encoded("POST", "/api/workouts", "gzip", body)
+		event["isBase64Encoded"] = true
+
+		// Act
+		response, err := h.HandleRequest(ctx, event)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if response.StatusCode != 201 || !strings.Contains(response.Body, `"name":"Squat"`) {
+			t.Errorf("expected the workout to be created, got %d %s", response.StatusCode, response.Body)
+		}
+	})
+
+	t.Run("bodies that decompress past the limit are rejected", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		bomb := base64.StdEncoding.EncodeToString([]byte(gzipped(`{"notes":"` + strings.Repeat("a", 2*defaultMaxBodySize) + `"}`)))
+		event := encoded("POST", "/api/workouts", "gzip", bomb)
+		event["isBase64Encoded"] = true
+
+		// Act
+		response, _ := h.HandleRequest(ctx, event)
+
+		// Assert
+		if len(bomb) > defaultMaxBodySize {
+			t.Fatalf("expected the compressed body to be under the limit, got %d bytes", len(bomb))
+		}
+		if response.StatusCode != 413 || !strings.Contains(response.Body, "Decompressed") {
+			t.Errorf("expected 413 for the decompressed size, got %d %s", response.StatusCode, response.Body)
+		}
+	})
+
+	t.Run("invalid and unsupported encodings are rejected", func(t *testing.T) {
+		tests := []struct {
+			encoding   string
+			body       string
+			wantStatus int
+		}{
+			{"gzip", `{"name":"Legs"}`, 400},
+			{"br", `{"name":"Legs"}`, 415},
+		}
+		for _, tt := range tests {
+			t.Run(tt.encoding, func(t *testing.T) {
+				// Arrange
+				h := newTestHandler()
+
+				// Act
+				response, _ := h.HandleRequest(ctx, encoded("POST", "/api/workouts", tt.encoding, tt.body))
+
+				// Assert
+				if response.StatusCode != tt.wantStatus {
+					t.Errorf("expected status %d, got %d", tt.wantStatus, response.StatusCode)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// linksParam is the query parameter that asks for links in resource responses
+const linksParam = "links"
+
+// resource is a JSON object in a response body, with its fields left encoded
+type resource map[string]json.RawMessage
+
+// str returns the string field name, or "" when it is missing or not a string
+func (r resource) str(name string) string {
+	var s string
+	json.Unmarshal(r[name], &s)
+	return s
+}
+
+// link points from a resource to a route, filling the route pattern's
+// placeholders from params; method is the route's method, GET when empty
+type link struct {
+	rel     string
+	method  string
+	pattern string
+	params  map[string]string
+	query   url.Values
+	name    string
+}
+
+// linker returns the links of a resource served by a route
+type linker func(r resource) []link
+
+// linkJSON is a link as rendered in a response's _links
+type linkJSON struct {
+	Href   string `json:"href"`
+	Method string `json:"method,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+// href builds the path of the route l points to, or returns false when no such
+// route exists or a placeholder has no value, so a link can never point at a
+// path the router would not serve
+func (h *LambdaHandler) href(l link) (string, bool) {
+	method := l.method
+	if method == "" {
+		method = "GET"
+	}
+	found := false
+	for _, r := range h.routes {
+		if r.pattern == l.pattern && (r.method == method || r.method == "") {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return "", false
+	}
+
+	parts := strings.Split(l.pattern, "/")
+	for i, part := range parts {
+		if !strings.HasPrefix(part, "{") || !strings.HasSuffix(part, "}") {
+			continue
+		}
+		value := l.params[strings.Trim(part, "{}")]
+		if value == "" {
+			return "", false
+		}
+		parts[i] = url.PathEscape(value)
+	}
+	path := strings.Join(parts, "/")
+	if len(l.query) > 0 {
+		path += "?" + l.query.Encode()
+	}
+	return path, true
+}
+
+// renderLinks renders links as _links, keyed by relation; a relation with
+// several links, such as one per exercise, is a list
+func (h *LambdaHandler) renderLinks(links []link) json.RawMessage {
+	rendered := map[string][]linkJSON{}
+	var order []string
+	for _, l := range links {
+		path, ok := h.href(l)
+		if !ok {
+			continue
+		}
+		method := l.method
+		if method == "GET" {
+			method = ""
+		}
+		if _, ok := rendered[l.rel]; !ok {
+			order = append(order, l.rel)
+		}
+		rendered[l.rel] = append(rendered[l.rel], linkJSON{Href: path, Method: method, Name: l.name})
+	}
+
+	out := map[string]interface{}{}
+	for _, rel := range order {
+		if len(rendered[rel]) == 1 && rendered[rel][0].Name == "" {
+			out[rel] = rendered[rel][0]
+		} else {
+			out[rel] = rendered[rel]
+		}
+	}
+	data, _ := json.Marshal(out)
+	return data
+}
+
+// addLinks adds _links to the resource, or each resource of a list, in a
+// successful JSON response when the request asks for them with ?links=true
+func (h *LambdaHandler) addLinks(event *APIGatewayProxyEvent, r *route, response Response) (Response, error) {
+	if r.links == nil || event.QueryStringParameters[linksParam] != "true" ||
+		response.StatusCode < 200 || response.StatusCode >= 300 || response.Headers["Content-Type"] != contentTypeJSON {
+		return response, nil
+	}
+
+	body := bytes.TrimSpace([]byte(response.Body))
+	var encoded []byte
+	var err error
+	if bytes.HasPrefix(body, []byte("[")) {
+		var list []resource
+		if err := json.Unmarshal(body, &list); err != nil {
+			return response, nil
+		}
+		for _, item := range list {
+			item["_links"] = h.renderLinks(r.links(item))
+		}
+		encoded, err = json.Marshal(list)
+	} else {
+		var item resource
+		if err := json.Unmarshal(body, &item); err != nil {
+			return response, nil
+		}
+		item["_links"] = h.renderLinks(r.links(item))
+		encoded, err = json.Marshal(item)
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	response.Body = string(encoded)
+	return response, nil
+}
+
+// selfLink returns a linker with only the self link to the resource at pattern,
+// whose single placeholder is filled from the resource's id
+func selfLink(pattern string) linker {
+	return func(r resource) []link {
+		return []link{{rel: "self", pattern: pattern, params: map[string]string{"id": r.str("id")}}}
+	}
+}
+
+// workoutLinks links a workout to itself, completing it, its program and the
+// history of each of its exercises
+func workoutLinks(r resource) []link {
+	id := map[string]string{"id": r.str("id")}
+	links := []link{{rel: "self", pattern: "/api/workouts/{id}", params: id}}
+	if r.str("status") != "completed" {
+		links = append(links, link{rel: "complete", method: "POST", pattern: "/api/workouts/{id}/complete", params: id})
+	}
+	if programID := r.str("programId"); programID != "" {
+		links = append(links, link{rel: "program", pattern: "/api/programs/{id}", params: map[string]string{"id": programID}})
+	}
+
+	var exercises []struct {
+		Name string `json:"name"`
+	}
+	json.Unmarshal(r["exercises"], &exercises)
+	seen := map[string]bool{}
+	for _, e := range exercises {
+		if e.Name == "" || seen[strings.ToLower(e.Name)] {
+			continue
+		}
+		seen[strings.ToLower(e.Name)] = true
+		links = append(links, link{rel: "exerciseHistory", pattern: "/api/workouts", query: url.Values{"exercise": {e.Name}}, name: e.Name})
+	}
+	return links
+}
+
+// programLinks links a program to itself, its next session and its schedule
+func programLinks(r resource) []link {
+	id := map[string]string{"id": r.str("id")}
+	return []link{
+		{rel: "self", pattern: "/api/programs/{id}", params: id},
+		{rel: "next", pattern: "/api/programs/{id}/next-session", params: id},
+		{rel: "schedule", method: "PUT", pattern: "/api/programs/{id}/schedule", params: id},
+	}
+}
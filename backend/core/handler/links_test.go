@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestLambdaHandler_Links(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("links are only added when asked for", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		createWorkout(t, h, "user-1", `{"exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]}]}`)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/workouts", "user-1", nil, ""))
+
+		// Assert
+		if strings.Contains(response.Body, "_links") {
+			t.Errorf("expected no links, got %s", response.Body)
+		}
+	})
+
+	t.Run("links a workout to itself, its actions and each exercise's history", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		createWorkout(t, h, "user-1", `{"status":"completed","exercises":[{"name":"Bench","sets":[{"reps":5,"weight":80}]}]}`)
+		created := createWorkout(t, h, "user-1", `{"exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]},{"name":"Bench","sets":[{"reps":5,"weight":80}]}]}`)
+		var w struct {
+			ID string `json:"id"`
+		}
+		json.Unmarshal([]byte(created.Body), &w)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/workouts/"+w.ID, "user-1", map[string]string{"links": "true"}, ""))
+
+		// Assert
+		var body struct {
+			Links struct {
+				Self            linkJSON   `json:"self"`
+				Complete        linkJSON   `json:"complete"`
+				ExerciseHistory []linkJSON `json:"exerciseHistory"`
+			} `json:"_links"`
+		}
+		if err := json.Unmarshal([]byte(response.Body), &body); err != nil {
+			t.Fatalf("invalid body: %v", err)
+		}
+		if body.Links.Self.Href != "/api/workouts/"+w.ID || body.Links.Complete.Href != "/api/workouts/"+w.ID+"/complete" || body.Links.Complete.Method != "POST" {
+			t.Errorf("unexpected links: %s", response.Body)
+		}
+		if len(body.Links.ExerciseHistory) != 2 || body.Links.ExerciseHistory[1].Name != "Bench" {
+			t.Fatalf("expected a history link per exercise, got %+v", body.Links.ExerciseHistory)
+		}
+
+		// Act
+		history, _ := url.Parse(body.Links.ExerciseHistory[1].Href)
+		followed, _ := h.HandleRequest(ctx, apiEvent("GET", history.Path, "user-1", map[string]string{"exercise": history.Query().Get("exercise")}, ""))
+
+		// Assert
+		var workouts []map[string]interface{}
+		json.Unmarshal([]byte(followed.Body), &workouts)
+		if len(workouts) != 2 {
+			t.Errorf("expected both workouts with bench, got %d", len(workouts))
+		}
+	})
+
+	t.Run("links each resource of a list", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", linearProgramBody)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/programs", "user-1", map[string]string{"links": "true"}, ""))
+
+		// Assert
+		if !strings.Contains(response.Body, `"next":{"href":"/api/programs/`+p.ID+`/next-session"}`) {
+			t.Errorf("expected a next session link, got %s", response.Body)
+		}
+	})
+
+	t.Run("every link resolves to a route", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		r := resource{
+			"id":        json.RawMessage(`"r1"`),
+			"programId": json.RawMessage(`"p1"`),
+			"exercises": json.RawMessage(`[{"name":"Squat"}]`),
+		}
+
+		for _, rt := range h.routes {
+			if rt.links == nil {
+				continue
+			}
+			for _, l := range rt.links(r) {
+				// Act
+				_, ok := h.href(l)
+
+				// Assert
+				if !ok {
+					t.Errorf("%s %s links %s to %s %s, which is not a route", rt.method, rt.pattern, l.rel, l.method, l.pattern)
+				}
+			}
+		}
+	})
+}
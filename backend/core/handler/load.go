@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"athlete-forge/trainingload"
+)
+
+// handleTrainingLoad returns acute and chronic training load across lifting and
+// cardio as of ?date= (default today), with overtraining warnings
+func (h *LambdaHandler) handleTrainingLoad(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	asOf := time.Now().UTC()
+	if raw := event.QueryStringParameters["date"]; raw != "" {
+		parsed, err := time.Parse(trainingload.DateLayout, raw)
+		if err != nil {
+			return h.createErrorResponse(400, "date must be in YYYY-MM-DD format"), nil
+		}
+		asOf = parsed
+	}
+
+	workouts, err := h.workouts.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	activities, err := h.activities.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	p, err := h.profiles.Get(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+
+	report := trainingload.Calculate(trainingload.Sessions(workouts, activities, p.HeartRate), asOf)
+	if len(report.Warnings) > 0 {
+		h.logger.Info().
+			Str("user_id", userID).
+			Float64("ratio", report.Ratio).
+			Str("status", report.Status).
+			Msg("Training load warning")
+	}
+	return h.createJSONResponse(200, report)
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"athlete-forge/trainingload"
+)
+
+func TestLambdaHandler_TrainingLoad(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("combines lifting and cardio load by day", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		today := time.Now().UTC().Format(trainingload.DateLayout)
+		createActivity(t, h, "user-1", `{"sport":"run","startTime":"`+today+`T00:00:00Z","durationSeconds":1800}`)
+		createWorkout(t, h, "user-1", `{"status":"completed","exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100,"rpe":8}]}]}`)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/stats/load", "user-1", nil, ""))
+
+		// Assert
+		if response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", response.StatusCode, response.Body)
+		}
+		var report trainingload.Report
+		json.Unmarshal([]byte(response.Body), &report)
+		last := report.Days[len(report.Days)-1]
+		if last.Cardio != 60 || last.Lifting != 12 || last.Total != 72 {
+			t.Errorf("unexpected day: %+v", last)
+		}
+		if report.Status != trainingload.StatusInsufficientData {
+			t.Errorf("expected insufficient data, got %s", report.Status)
+		}
+	})
+
+	t.Run("rejects invalid dates", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("GET", "/api/stats/load", "user-1", map[string]string{"date": "yesterday"}, ""))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+}
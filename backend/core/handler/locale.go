@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+
+	"athlete-forge/i18n"
+	"athlete-forge/l10n"
+)
+
+// localeKey is the context key of the locale negotiated for the request
+type localeKey struct{}
+
+// withLocale returns ctx carrying locale, so handlers can translate the text
+// they return
+func withLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// locale returns the locale ctx carries, or the default for jobs and other
+// work outside a request
+func locale(ctx context.Context) string {
+	if l, ok := ctx.Value(localeKey{}).(string); ok {
+		return l
+	}
+	return i18n.Default
+}
+
+// localize translates the message of a JSON error response into the request's
+// locale and labels it with Content-Language. Messages without a translation,
+// such as validation errors naming fields, are left in English
+func (h *LambdaHandler) localize(ctx context.Context, response Response) Response {
+	l := locale(ctx)
+	if l == i18n.Default || response.StatusCode < 400 || response.Headers["Content-Type"] != contentTypeJSON {
+		return response
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(response.Body), &body); err != nil {
+		return response
+	}
+	message, ok := body["message"].(string)
+	if !ok {
+		return response
+	}
+	translated := i18n.T(l, message)
+	if translated == message {
+		return response
+	}
+	body["message"] = translated
+	localized, err := json.Marshal(body)
+	if err != nil {
+		return response
+	}
+	response.Body = string(localized)
+	response.Headers["Content-Language"] = l
+	return response
+}
+
+// conventions returns how userID's exports and reports write numbers and dates
+// and start their weeks, from their profile's locale
+func (h *LambdaHandler) conventions(ctx context.Context, userID string) (l10n.Conventions, error) {
+	p, err := h.profiles.Get(ctx, userID)
+	if err != nil {
+		return l10n.Conventions{}, err
+	}
+	return l10n.For(p.Locale), nil
+}
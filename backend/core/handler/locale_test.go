@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestLambdaHandler_Locale(t *testing.T) {
+	ctx := context.Background()
+
+	// localeEvent builds a request preferring language
+	localeEvent := func(path, language string) map[string]interface{} {
+		event := apiEvent("GET", path, "user-1", nil, "")
+		event["headers"] = map[string]string{"accept-language": language}
+		return event
+	}
+
+	t.Run("translates error messages into the preferred language", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		response, _ := h.HandleRequest(ctx, localeEvent("/api/workouts/missing", "de-AT,de;q=0.9,en;q=0.5"))
+
+		// Assert
+		if response.StatusCode != 404 {
+			t.Fatalf("expected status code 404, got %d: %s", response.StatusCode, response.Body)
+		}
+		var body map[string]interface{}
+		json.Unmarshal([]byte(response.Body), &body)
+		if body["message"] != "Training nicht gefunden" || body["status"] != "error" {
+			t.Errorf("unexpected body: %s", response.Body)
+		}
+		if response.Headers["Content-Language"] != "de" {
+			t.Errorf("expected Content-Language de, got %q", response.Headers["Content-Language"])
+		}
+	})
+
+	t.Run("leaves messages in English without a supported language", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		response, _ := h.HandleRequest(ctx, localeEvent("/api/workouts/missing", "ja"))
+
+		// Assert
+		var body map[string]interface{}
+		json.Unmarshal([]byte(response.Body), &body)
+		if body["message"] != "Workout not found" || response.Headers["Content-Language"] != "" {
+			t.Errorf("unexpected response: %s %v", response.Body, response.Headers)
+		}
+	})
+
+	t.Run("translates achievement names", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		response, _ := h.HandleRequest(ctx, localeEvent("/api/achievements", "fr"))
+
+		// Assert
+		if response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", response.StatusCode, response.Body)
+		}
+		var got []AchievementResponse
+		json.Unmarshal([]byte(response.Body), &got)
+		if len(got) == 0 || got[0].Name != "Première séance" || got[0].Description != "Terminez votre première séance" {
+			t.Errorf("unexpected achievements: %+v", got)
+		}
+	})
+}
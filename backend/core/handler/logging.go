@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"athlete-forge/logconfig"
+)
+
+// WithLogLevels changes the log level at runtime from levels' configuration,
+// which also enables verbose logging for a sample of one route's requests. The
+// handler's logger must write through levels.Writer
+func WithLogLevels(levels *logconfig.Levels) Option {
+	return func(h *LambdaHandler) {
+		h.logLevels = levels
+	}
+}
+
+// refreshLogLevels reloads the log configuration when it is due; on failure the
+// previous configuration stays in force
+func (h *LambdaHandler) refreshLogLevels(ctx context.Context) {
+	if h.logLevels == nil {
+		return
+	}
+	if err := h.logLevels.Refresh(ctx, time.Now()); err != nil {
+		h.logger.Warn().
+			Err(err).
+			Msg("Failed to refresh log configuration")
+	}
+}
+
+// logRoute applies r's configured log level for the rest of the request when the
+// request is sampled, returning the function that restores the level
+func (h *LambdaHandler) logRoute(r *route) func() {
+	if h.logLevels == nil {
+		return func() {}
+	}
+	return h.logLevels.Route(r.method + " " + r.pattern)
+}
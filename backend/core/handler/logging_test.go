@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"athlete-forge/logconfig"
+)
+
+// staticLogConfig is a log configuration source that never changes
+type staticLogConfig logconfig.Config
+
+func (c staticLogConfig) Load(ctx context.Context) (logconfig.Config, error) {
+	return logconfig.Config(c), nil
+}
+
+func TestLambdaHandler_LogLevels(t *testing.T) {
+	ctx := context.Background()
+	newHandler := func(config logconfig.Config) (*LambdaHandler, *bytes.Buffer) {
+		var logs bytes.Buffer
+		levels := logconfig.New(staticLogConfig(config), logconfig.DefaultInterval, zerolog.InfoLevel)
+		logger := zerolog.New(levels.Writer(&logs)).Level(zerolog.TraceLevel)
+		return NewLambdaHandler(logger, WithLogLevels(levels)), &logs
+	}
+
+	t.Run("applies the configured level without a redeploy", func(t *testing.T) {
+		// Arrange
+		h, logs := newHandler(logconfig.Config{Level: "warn"})
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("GET", "/api/tools/plates", "", map[string]string{"weight": "100"}, ""))
+
+		// Assert
+		if err != nil || response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d, %v", response.StatusCode, err)
+		}
+		if strings.Contains(logs.String(), "Processing request") {
+			t.Errorf("expected info logs to be dropped, got %s", logs.String())
+		}
+	})
+
+	t.Run("logs a configured route at its own level", func(t *testing.T) {
+		// Arrange
+		h, logs := newHandler(logconfig.Config{Level: "warn", Routes: map[string]logconfig.RouteConfig{
+			"GET /api/tools/plates": {Level: "info"},
+		}})
+
+		// Act
+		h.HandleRequest(ctx, apiEvent("GET", "/api/tools/warmup", "", map[string]string{"weight": "100"}, ""))
+		other := logs.String()
+		h.HandleRequest(ctx, apiEvent("GET", "/api/tools/plates", "", map[string]string{"weight": "100"}, ""))
+
+		// Assert
+		if strings.Contains(other, "Lambda function execution completed") {
+			t.Errorf("expected other routes at warn, got %s", other)
+		}
+		if !strings.Contains(logs.String(), `"path":"/api/tools/plates","status_code":200`) {
+			t.Errorf("expected the configured route logged at info, got %s", logs.String())
+		}
+		if h.logLevels.Level() != zerolog.WarnLevel {
+			t.Errorf("expected the level restored after the request, got %s", h.logLevels.Level())
+		}
+	})
+}
@@ -0,0 +1,441 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"athlete-forge/marketplace"
+	"athlete-forge/moderation"
+	"athlete-forge/program"
+	"athlete-forge/store"
+)
+
+// PublishTemplateRequest is the body for publishing one of the user's programs
+// to the marketplace; Name defaults to the program's name
+type PublishTemplateRequest struct {
+	ProgramID   string `json:"programId"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// handleBrowseTemplates returns the published templates matching ?q= in their
+// name, description or exercises, ordered by ?sort=downloads, rating or newest
+func (h *LambdaHandler) handleBrowseTemplates(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	listings, err := h.socialListings(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	query := event.QueryStringParameters["q"]
+	published := []marketplace.Listing{}
+	for _, l := range listings {
+		if l.Matches(query) {
+			published = append(published, l)
+		}
+	}
+	if err := marketplace.Sort(published, event.QueryStringParameters["sort"]); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	summaries := make([]marketplace.Summary, len(published))
+	for i := range published {
+		summaries[i] = published[i].Summary()
+	}
+	return h.createJSONResponse(200, summaries)
+}
+
+// handleGetTemplate returns a marketplace template with its prescriptions; hidden
+// templates are only shown to their author
+func (h *LambdaHandler) handleGetTemplate(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	l, errResponse, err := h.visibleListing(ctx, userID, event.PathParameters["id"])
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	return h.createJSONResponse(200, l.Public())
+}
+
+// handlePublishTemplate publishes one of the user's programs as a template
+func (h *LambdaHandler) handlePublishTemplate(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	errResponse, err := h.requireGoodStanding(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	var req PublishTemplateRequest
+	if err := decodeBody(event, &req); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if req.ProgramID == "" {
+		return h.createErrorResponse(400, "programId is required"), nil
+	}
+
+	p, err := h.programs.Get(ctx, userID, req.ProgramID)
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(400, "Program not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	now := time.Now().UTC()
+	l := &marketplace.Listing{
+		AuthorID:    userID,
+		Description: req.Description,
+		Template:    program.ToTemplate(p),
+		Status:      marketplace.StatusPublished,
+		PublishedAt: now,
+		UpdatedAt:   now,
+	}
+	if req.Name != "" {
+		l.Template.Name = req.Name
+	}
+	if err := l.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if err := h.marketplace.Save(ctx, l); err != nil {
+		return Response{}, err
+	}
+
+	h.logger.Info().
+		Str("function", "handlePublishTemplate").
+		Str("user_id", userID).
+		Str("program_id", p.ID).
+		Str("template_id", l.ID).
+		Msg("Template published")
+
+	return h.createJSONResponse(201, l.Public())
+}
+
+// handleUnpublishTemplate removes one of the user's templates, and its reviews,
+// from the marketplace; programs already cloned from it are unaffected
+func (h *LambdaHandler) handleUnpublishTemplate(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	l, err := h.marketplace.Get(ctx, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) || (err == nil && l.AuthorID != userID) {
+		return h.createErrorResponse(404, "Template not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	reviews, err := h.marketplace.ListReviews(ctx, l.ID)
+	if err != nil {
+		return Response{}, err
+	}
+	for _, review := range reviews {
+		if err := h.marketplace.DeleteReview(ctx, l.ID, review.UserID); err != nil {
+			return Response{}, err
+		}
+	}
+	if err := h.marketplace.Delete(ctx, l.ID); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, l.Public())
+}
+
+// handleCloneTemplate starts a program in the user's library from a published
+// template, for the gym in ?gymId= or the user's default gym, and counts the
+// download
+func (h *LambdaHandler) handleCloneTemplate(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	l, errResponse, err := h.visibleListing(ctx, userID, event.PathParameters["id"])
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	p, incompatibilities, err := program.FromTemplate(l.Template)
+	if err != nil {
+		return h.createErrorResponse(422, err.Error()), nil
+	}
+	p.GymID = event.QueryStringParameters["gymId"]
+	created, errResponse, err := h.startProgram(ctx, userID, p)
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	if err := h.marketplace.CountDownload(ctx, l); err != nil {
+		h.logger.Warn().
+			Err(err).
+			Str("function", "handleCloneTemplate").
+			Str("template_id", l.ID).
+			Msg("Failed to count template download")
+	}
+
+	h.logger.Info().
+		Str("function", "handleCloneTemplate").
+		Str("user_id", userID).
+		Str("template_id", l.ID).
+		Str("program_id", p.ID).
+		Msg("Template cloned")
+
+	return h.createJSONResponse(201, ProgramImportResponse{ProgramResponse: *created, Incompatibilities: incompatibilities})
+}
+
+// handleFlagTemplate reports a template for admin review, as a report of kind
+// template would
+func (h *LambdaHandler) handleFlagTemplate(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	return h.reportContent(ctx, event, moderation.Target{Kind: moderation.KindTemplate, ID: event.PathParameters["id"]})
+}
+
+// handleListFlaggedTemplates returns the templates awaiting admin review, with
+// the reasons they were flagged
+func (h *LambdaHandler) handleListFlaggedTemplates(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	if _, errResponse := h.requireUser(event); errResponse != nil {
+		return *errResponse, nil
+	}
+
+	listings, err := h.marketplace.List(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+	flagged := []marketplace.Listing{}
+	for _, l := range listings {
+		if l.Flagged {
+			flagged = append(flagged, l)
+		}
+	}
+	return h.createJSONResponse(200, flagged)
+}
+
+// handleReviewTemplate applies an admin's review decision to a template, closing
+// its moderation case
+func (h *LambdaHandler) handleReviewTemplate(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	target := moderation.Target{Kind: moderation.KindTemplate, ID: event.PathParameters["id"]}
+	errResponse, err := h.moderateContent(ctx, event, target)
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	l, err := h.marketplace.Get(ctx, target.ID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, l)
+}
+
+// ReviewRequest is the body for rating a template from 1 to 5
+type ReviewRequest struct {
+	Rating int    `json:"rating"`
+	Text   string `json:"text"`
+}
+
+// handleListReviews returns a template's published reviews, most recently
+// updated first
+func (h *LambdaHandler) handleListReviews(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	l, errResponse, err := h.visibleListing(ctx, userID, event.PathParameters["id"])
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	published, err := h.socialReviews(ctx, userID, l.ID)
+	if err != nil {
+		return Response{}, err
+	}
+	sort.SliceStable(published, func(i, j int) bool {
+		return published[i].UpdatedAt.After(published[j].UpdatedAt)
+	})
+	return h.createJSONResponse(200, published)
+}
+
+// handlePutReview rates a template, replacing the user's earlier review of it.
+// Authors cannot review their own templates, and editing a review an admin hid
+// leaves it hidden
+func (h *LambdaHandler) handlePutReview(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	errResponse, err := h.requireGoodStanding(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	var req ReviewRequest
+	if err := decodeBody(event, &req); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	l, errResponse, err := h.visibleListing(ctx, userID, event.PathParameters["id"])
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	if l.AuthorID == userID {
+		return h.createErrorResponse(403, "Authors cannot review their own templates"), nil
+	}
+	blocked, err := h.social.Blocked(ctx, userID, l.AuthorID)
+	if err != nil {
+		return Response{}, err
+	}
+	if blocked {
+		return h.createErrorResponse(403, "Templates of blocked users cannot be reviewed"), nil
+	}
+
+	now := time.Now().UTC()
+	review, err := h.marketplace.GetReview(ctx, l.ID, userID)
+	status := 200
+	if errors.Is(err, store.ErrNotFound) {
+		review = &marketplace.Review{TemplateID: l.ID, UserID: userID, Status: marketplace.StatusPublished, CreatedAt: now}
+		status = 201
+	} else if err != nil {
+		return Response{}, err
+	}
+	review.Rating = req.Rating
+	review.Text = req.Text
+	review.UpdatedAt = now
+	if err := review.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if err := h.marketplace.SaveReview(ctx, review); err != nil {
+		return Response{}, err
+	}
+	if err := h.rateTemplate(ctx, l); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(status, review.Public())
+}
+
+// handleDeleteReview removes the user's review of a template
+func (h *LambdaHandler) handleDeleteReview(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	templateID := event.PathParameters["id"]
+	review, err := h.marketplace.GetReview(ctx, templateID, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Review not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	if err := h.marketplace.DeleteReview(ctx, templateID, userID); err != nil {
+		return Response{}, err
+	}
+	l, err := h.marketplace.Get(ctx, templateID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return Response{}, err
+	}
+	if l != nil {
+		if err := h.rateTemplate(ctx, l); err != nil {
+			return Response{}, err
+		}
+	}
+	return h.createJSONResponse(200, review.Public())
+}
+
+// handleFlagReview reports another user's review of a template as abusive, as a
+// report of kind review would
+func (h *LambdaHandler) handleFlagReview(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	return h.reportContent(ctx, event, moderation.Target{Kind: moderation.KindReview, ID: event.PathParameters["id"], UserID: event.PathParameters["userId"]})
+}
+
+// handleListFlaggedReviews returns the template reviews awaiting admin review,
+// with the reasons they were flagged
+func (h *LambdaHandler) handleListFlaggedReviews(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	if _, errResponse := h.requireUser(event); errResponse != nil {
+		return *errResponse, nil
+	}
+
+	reviews, err := h.marketplace.ListReviews(ctx, "")
+	if err != nil {
+		return Response{}, err
+	}
+	flagged := []marketplace.Review{}
+	for _, review := range reviews {
+		if review.Flagged {
+			flagged = append(flagged, review)
+		}
+	}
+	return h.createJSONResponse(200, flagged)
+}
+
+// handleModerateReview applies an admin's decision to a template review,
+// closing its moderation case
+func (h *LambdaHandler) handleModerateReview(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	target := moderation.Target{Kind: moderation.KindReview, ID: event.PathParameters["id"], UserID: event.PathParameters["userId"]}
+	errResponse, err := h.moderateContent(ctx, event, target)
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	review, err := h.marketplace.GetReview(ctx, target.ID, target.UserID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, review)
+}
+
+// rateTemplate recomputes l's rating from its reviews and saves the rating
+// alone. Concurrent reviews each recompute from every stored review, so the
+// last to save counts them all
+func (h *LambdaHandler) rateTemplate(ctx context.Context, l *marketplace.Listing) error {
+	reviews, err := h.marketplace.ListReviews(ctx, l.ID)
+	if err != nil {
+		return err
+	}
+	l.Rate(reviews)
+	return h.marketplace.SaveRating(ctx, l)
+}
+
+// visibleListing loads the listing with id, returning a 404 response when it
+// does not exist or is hidden from userID
+func (h *LambdaHandler) visibleListing(ctx context.Context, userID, id string) (*marketplace.Listing, *Response, error) {
+	l, err := h.marketplace.Get(ctx, id)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && l.Status != marketplace.StatusPublished && l.AuthorID != userID) {
+		response := h.createErrorResponse(404, "Template not found")
+		return nil, &response, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return l, nil, nil
+}
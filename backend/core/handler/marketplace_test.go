@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"athlete-forge/marketplace"
+)
+
+// publishTemplate publishes a program through the API and returns the listing
+func publishTemplate(t *testing.T, h *LambdaHandler, userID, programID string) marketplace.Listing {
+	t.Helper()
+	body := fmt.Sprintf(`{"programId":%q,"description":"Three sessions a week"}`, programID)
+	response, err := h.HandleRequest(context.Background(), apiEvent("POST", "/api/marketplace/templates", userID, nil, body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.StatusCode != 201 {
+		t.Fatalf("expected status code 201, got %d: %s", response.StatusCode, response.Body)
+	}
+	var l marketplace.Listing
+	json.Unmarshal([]byte(response.Body), &l)
+	return l
+}
+
+// reviewTemplate applies an admin review action to a listing
+func reviewTemplate(t *testing.T, h *LambdaHandler, id, action string) Response {
+	t.Helper()
+	event := cognitoEvent("POST", "/api/admin/marketplace/templates/"+id+"/review", "ops", map[string]interface{}{"cognito:groups": "admin"})
+	event["body"] = fmt.Sprintf(`{"action":%q}`, action)
+	response, err := h.HandleRequest(context.Background(), event)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return response
+}
+
+func TestLambdaHandler_Marketplace(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("published templates can be browsed and cloned by other users", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", linearProgramBody)
+		l := publishTemplate(t, h, "user-1", p.ID)
+
+		// Act
+		browsed, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/marketplace/templates", "user-2", map[string]string{"q": "squat"}, ""))
+		cloned, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/marketplace/templates/"+l.ID+"/clone", "user-2", nil, ""))
+		fetched, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/marketplace/templates/"+l.ID, "user-2", nil, ""))
+
+		// Assert
+		var summaries []marketplace.Summary
+		json.Unmarshal([]byte(browsed.Body), &summaries)
+		if browsed.StatusCode != 200 || len(summaries) != 1 || summaries[0].Name != "Linear" || summaries[0].Exercises[0] != "Squat" {
+			t.Errorf("unexpected browse results: %d %s", browsed.StatusCode, browsed.Body)
+		}
+		var imported ProgramImportResponse
+		json.Unmarshal([]byte(cloned.Body), &imported)
+		if cloned.StatusCode != 201 || imported.UserID != "user-2" || imported.Name != "Linear" {
+			t.Errorf("unexpected clone: %d %s", cloned.StatusCode, cloned.Body)
+		}
+		var got marketplace.Listing
+		json.Unmarshal([]byte(fetched.Body), &got)
+		if got.Downloads != 1 {
+			t.Errorf("expected one download, got %d", got.Downloads)
+		}
+	})
+
+	t.Run("flagged templates are reviewed by admins and hidden ones leave the marketplace", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", linearProgramBody)
+		l := publishTemplate(t, h, "user-1", p.ID)
+		flag, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/marketplace/templates/"+l.ID+"/flag", "user-2", nil, `{"reason":"spam link in description"}`))
+		flagged, _ := h.HandleRequest(ctx, cognitoEvent("GET", "/api/admin/marketplace/flagged", "ops", map[string]interface{}{"cognito:groups": "admin"}))
+
+		// Act
+		reviewed := reviewTemplate(t, h, l.ID, "hide")
+
+		// Assert
+		if flag.StatusCode != 202 {
+			t.Fatalf("expected status code 202, got %d: %s", flag.StatusCode, flag.Body)
+		}
+		var queue []marketplace.Listing
+		json.Unmarshal([]byte(flagged.Body), &queue)
+		if len(queue) != 1 || queue[0].FlagReasons[0] != "spam link in description" {
+			t.Errorf("unexpected review queue: %s", flagged.Body)
+		}
+		if reviewed.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", reviewed.StatusCode, reviewed.Body)
+		}
+		browsed, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/marketplace/templates", "user-2", nil, ""))
+		other, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/marketplace/templates/"+l.ID, "user-2", nil, ""))
+		author, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/marketplace/templates/"+l.ID, "user-1", nil, ""))
+		if browsed.Body != "[]" || other.StatusCode != 404 || author.StatusCode != 200 {
+			t.Errorf("expected the template hidden from others, got %s, %d and %d", browsed.Body, other.StatusCode, author.StatusCode)
+		}
+	})
+
+	t.Run("users cannot review or unpublish others' templates", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", linearProgramBody)
+		l := publishTemplate(t, h, "user-1", p.ID)
+
+		// Act
+		review, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/admin/marketplace/templates/"+l.ID+"/review", "user-2", nil, `{"action":"hide"}`))
+		unpublish, _ := h.HandleRequest(ctx, apiEvent("DELETE", "/api/marketplace/templates/"+l.ID, "user-2", nil, ""))
+		own, _ := h.HandleRequest(ctx, apiEvent("DELETE", "/api/marketplace/templates/"+l.ID, "user-1", nil, ""))
+
+		// Assert
+		if review.StatusCode != 403 || unpublish.StatusCode != 404 || own.StatusCode != 200 {
+			t.Errorf("unexpected statuses: review %d, unpublish %d, own %d", review.StatusCode, unpublish.StatusCode, own.StatusCode)
+		}
+	})
+
+	t.Run("publishing requires one of the user's programs", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", linearProgramBody)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/marketplace/templates", "user-2", nil, fmt.Sprintf(`{"programId":%q}`, p.ID)))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+}
+
+func TestLambdaHandler_MarketplaceReviews(t *testing.T) {
+	ctx := context.Background()
+	admin := map[string]interface{}{"cognito:groups": "admin"}
+
+	// setup publishes a template by user-1
+	setup := func(t *testing.T) (*LambdaHandler, marketplace.Listing) {
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", linearProgramBody)
+		return h, publishTemplate(t, h, "user-1", p.ID)
+	}
+	rating := func(t *testing.T, h *LambdaHandler, id string) marketplace.Listing {
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/marketplace/templates/"+id, "user-1", nil, ""))
+		var l marketplace.Listing
+		json.Unmarshal([]byte(response.Body), &l)
+		return l
+	}
+
+	t.Run("each user has one review and the template's rating is their mean", func(t *testing.T) {
+		// Arrange
+		h, l := setup(t)
+		path := "/api/marketplace/templates/" + l.ID + "/reviews/mine"
+
+		// Act
+		first, _ := h.HandleRequest(ctx, apiEvent("PUT", path, "user-2", nil, `{"rating":2,"text":"too easy"}`))
+		second, _ := h.HandleRequest(ctx, apiEvent("PUT", path, "user-2", nil, `{"rating":5,"text":"got strong"}`))
+		h.HandleRequest(ctx, apiEvent("PUT", path, "user-3", nil, `{"rating":4}`))
+		listed, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/marketplace/templates/"+l.ID+"/reviews", "user-4", nil, ""))
+
+		// Assert
+		if first.StatusCode != 201 || second.StatusCode != 200 {
+			t.Errorf("expected 201 then 200, got %d and %d", first.StatusCode, second.StatusCode)
+		}
+		var reviews []marketplace.Review
+		json.Unmarshal([]byte(listed.Body), &reviews)
+		if len(reviews) != 2 || reviews[0].UserID != "user-3" {
+			t.Errorf("expected two reviews, newest first, got %s", listed.Body)
+		}
+		if got := rating(t, h, l.ID); got.Rating != 4.5 || got.RatingCount != 2 {
+			t.Errorf("expected rating 4.5 from 2 reviews, got %v from %d", got.Rating, got.RatingCount)
+		}
+	})
+
+	t.Run("authors cannot review their own templates", func(t *testing.T) {
+		// Arrange
+		h, l := setup(t)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/marketplace/templates/"+l.ID+"/reviews/mine", "user-1", nil, `{"rating":5}`))
+
+		// Assert
+		if response.StatusCode != 403 {
+			t.Errorf("expected status code 403, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("flagged reviews reach admins and hidden ones stop counting", func(t *testing.T) {
+		// Arrange
+		h, l := setup(t)
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/marketplace/templates/"+l.ID+"/reviews/mine", "user-2", nil, `{"rating":5}`))
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/marketplace/templates/"+l.ID+"/reviews/mine", "user-3", nil, `{"rating":1,"text":"abuse"}`))
+		flag, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/marketplace/templates/"+l.ID+"/reviews/user-3/flag", "user-1", nil, `{"reason":"abusive"}`))
+		queued, _ := h.HandleRequest(ctx, cognitoEvent("GET", "/api/admin/marketplace/reviews/flagged", "ops", admin))
+
+		// Act
+		event := cognitoEvent("POST", "/api/admin/marketplace/templates/"+l.ID+"/reviews/user-3/review", "ops", admin)
+		event["body"] = `{"action":"hide"}`
+		moderated, _ := h.HandleRequest(ctx, event)
+
+		// Assert
+		var queue []marketplace.Review
+		json.Unmarshal([]byte(queued.Body), &queue)
+		if flag.StatusCode != 202 || len(queue) != 1 || queue[0].UserID != "user-3" {
+			t.Errorf("expected the flagged review queued, got %d: %s", flag.StatusCode, queued.Body)
+		}
+		if moderated.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", moderated.StatusCode, moderated.Body)
+		}
+		if got := rating(t, h, l.ID); got.Rating != 5 || got.RatingCount != 1 {
+			t.Errorf("expected the hidden review left out of the rating, got %v from %d", got.Rating, got.RatingCount)
+		}
+		listed, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/marketplace/templates/"+l.ID+"/reviews", "user-4", nil, ""))
+		var reviews []marketplace.Review
+		json.Unmarshal([]byte(listed.Body), &reviews)
+		if len(reviews) != 1 {
+			t.Errorf("expected the hidden review left out of listings, got %s", listed.Body)
+		}
+	})
+
+	t.Run("deleting a review updates the rating", func(t *testing.T) {
+		// Arrange
+		h, l := setup(t)
+		path := "/api/marketplace/templates/" + l.ID + "/reviews/mine"
+		h.HandleRequest(ctx, apiEvent("PUT", path, "user-2", nil, `{"rating":3}`))
+
+		// Act
+		deleted, _ := h.HandleRequest(ctx, apiEvent("DELETE", path, "user-2", nil, ""))
+		again, _ := h.HandleRequest(ctx, apiEvent("DELETE", path, "user-2", nil, ""))
+
+		// Assert
+		if deleted.StatusCode != 200 || again.StatusCode != 404 {
+			t.Errorf("expected 200 then 404, got %d and %d", deleted.StatusCode, again.StatusCode)
+		}
+		if got := rating(t, h, l.ID); got.Rating != 0 || got.RatingCount != 0 {
+			t.Errorf("expected no rating, got %v from %d", got.Rating, got.RatingCount)
+		}
+	})
+}
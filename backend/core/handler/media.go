@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"athlete-forge/media"
+	"athlete-forge/store"
+)
+
+const (
+	// catalogPath is the CDN-cached catalog, which embeds signed media links
+	catalogPath = "/api/exercises/catalog"
+
+	// catalogCacheAge matches catalogCacheControl: a catalog served from the
+	// CDN's cache can be this old, so its media links must outlive it by as much
+	catalogCacheAge = 24 * time.Hour
+
+	// maxCaptionLength bounds a media caption
+	maxCaptionLength = 200
+)
+
+// signMedia sets the link and its expiry on each of list, signed by the CDN's
+// key when one is configured and presigned by the media store otherwise
+func (h *LambdaHandler) signMedia(ctx context.Context, list []media.Media, extra time.Duration) error {
+	now := time.Now().UTC()
+	for i := range list {
+		m := &list[i]
+		expires := m.Expiry(now, extra)
+		var link string
+		var err error
+		if h.mediaSigner != nil {
+			link, err = h.mediaSigner.Sign(ctx, m.ObjectKey(), expires)
+		} else {
+			link, err = h.mediaFiles.URL(ctx, m.ObjectKey(), expires.Sub(now))
+		}
+		if err != nil {
+			return err
+		}
+		m.URL, m.ExpiresAt = link, &expires
+	}
+	return nil
+}
+
+// handleListExerciseMedia returns a catalog exercise's media with signed links
+func (h *LambdaHandler) handleListExerciseMedia(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	if _, errResponse := h.requireUser(event); errResponse != nil {
+		return *errResponse, nil
+	}
+	e, ok := catalogExercise(event)
+	if !ok {
+		return h.createErrorResponse(404, "Exercise not found"), nil
+	}
+
+	list, err := h.exerciseMedia.List(ctx, e.Name)
+	if err != nil {
+		return Response{}, err
+	}
+	if err := h.signMedia(ctx, list, 0); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, list)
+}
+
+// handleUploadExerciseMedia stores a GIF, image or video demonstrating a
+// catalog exercise, sent as the raw or base64-encoded body with its
+// Content-Type, and an optional ?caption=
+func (h *LambdaHandler) handleUploadExerciseMedia(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	e, ok := catalogExercise(event)
+	if !ok {
+		return h.createErrorResponse(404, "Exercise not found"), nil
+	}
+
+	data := []byte(event.Body)
+	if event.IsBase64Encoded {
+		var err error
+		if data, err = base64.StdEncoding.DecodeString(event.Body); err != nil {
+			return h.createErrorResponse(400, "Request body is not valid base64"), nil
+		}
+	}
+	if len(data) == 0 {
+		return h.createErrorResponse(400, "request body is required"), nil
+	}
+	caption := strings.TrimSpace(event.QueryStringParameters["caption"])
+	if len(caption) > maxCaptionLength {
+		return h.createErrorResponse(400, "caption must be at most 200 characters"), nil
+	}
+
+	m, err := media.New(e.Name, header(event, "Content-Type"), len(data), userID, time.Now().UTC())
+	if err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	m.Caption = caption
+	if err := h.mediaFiles.Put(ctx, m.ObjectKey(), m.ContentType, data); err != nil {
+		return Response{}, err
+	}
+	if err := h.exerciseMedia.Save(ctx, m); err != nil {
+		return Response{}, err
+	}
+	h.invalidateCatalog(ctx)
+
+	h.logger.Info().
+		Str("function", "handleUploadExerciseMedia").
+		Str("user_id", userID).
+		Str("exercise", m.Exercise).
+		Str("media_id", m.ID).
+		Int("size", m.Size).
+		Msg("Exercise media uploaded")
+
+	list := []media.Media{*m}
+	if err := h.signMedia(ctx, list, 0); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(201, list[0])
+}
+
+// handleDeleteExerciseMedia detaches media from a catalog exercise. The file
+// is left in the bucket, since links already handed out stay valid until they
+// expire anyway
+func (h *LambdaHandler) handleDeleteExerciseMedia(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	e, ok := catalogExercise(event)
+	if !ok {
+		return h.createErrorResponse(404, "Exercise not found"), nil
+	}
+
+	m, err := h.exerciseMedia.Get(ctx, e.Name, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Media not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	if err := h.exerciseMedia.Delete(ctx, m.Exercise, m.ID); err != nil {
+		return Response{}, err
+	}
+	h.invalidateCatalog(ctx)
+
+	h.logger.Info().
+		Str("function", "handleDeleteExerciseMedia").
+		Str("user_id", userID).
+		Str("exercise", m.Exercise).
+		Str("media_id", m.ID).
+		Msg("Exercise media deleted")
+	return h.createJSONResponse(200, m)
+}
+
+// invalidateCatalog drops the cached catalog so that media changes show
+// before it expires. A failure is only logged, since it expires within a day
+func (h *LambdaHandler) invalidateCatalog(ctx context.Context) {
+	if h.cdn == nil {
+		return
+	}
+	if err := h.cdn.Invalidate(ctx, catalogPath); err != nil {
+		h.logger.Warn().
+			Err(err).
+			Msg("Failed to invalidate exercise catalog")
+	}
+}
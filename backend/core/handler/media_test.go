@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"athlete-forge/blob"
+	"athlete-forge/media"
+)
+
+func TestLambdaHandler_ExerciseMedia(t *testing.T) {
+	ctx := context.Background()
+	admin := map[string]interface{}{"cognito:groups": "admin"}
+	gif := base64.StdEncoding.EncodeToString([]byte("GIF89a"))
+
+	// upload sends body as an admin with contentType
+	upload := func(h *LambdaHandler, path, contentType, body string, query map[string]string) Response {
+		event := cognitoEvent("POST", path, "ops", admin)
+		event["headers"] = map[string]interface{}{"Content-Type": contentType}
+		event["queryStringParameters"] = query
+		event["body"] = body
+		event["isBase64Encoded"] = true
+		response, _ := h.HandleRequest(ctx, event)
+		return response
+	}
+
+	t.Run("admins upload media that the catalog and search link to", func(t *testing.T) {
+		// Arrange
+		files := blob.NewMemoryStore()
+		invalidator := &recordingInvalidator{}
+		h := NewLambdaHandler(zerolog.Nop(), WithExerciseMedia(files, nil), WithCDN(invalidator))
+
+		// Act
+		created := upload(h, "/api/admin/exercises/back%20squat/media", "image/gif", gif, map[string]string{"caption": "Side view"})
+		catalog, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/exercises/catalog", "", nil, ""))
+		search, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/exercises", "user-1", map[string]string{"q": "back squat"}, ""))
+		list, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/exercises/back-squat/media", "user-1", nil, ""))
+
+		// Assert
+		if created.StatusCode != 201 {
+			t.Fatalf("expected status code 201, got %d: %s", created.StatusCode, created.Body)
+		}
+		var m media.Media
+		json.Unmarshal([]byte(created.Body), &m)
+		if m.Exercise != "back squat" || m.Kind != media.KindGIF || m.Caption != "Side view" || m.URL == "" || m.ExpiresAt == nil {
+			t.Errorf("unexpected media %+v", m)
+		}
+		if object, ok := files.Get(m.ObjectKey()); !ok || string(object.Data) != "GIF89a" {
+			t.Errorf("expected the file stored, got %+v", object)
+		}
+		if len(invalidator.paths) != 1 || invalidator.paths[0] != "/api/exercises/catalog" {
+			t.Errorf("expected the catalog invalidated, got %v", invalidator.paths)
+		}
+
+		var cataloged, found []CatalogExercise
+		json.Unmarshal([]byte(catalog.Body), &cataloged)
+		json.Unmarshal([]byte(search.Body), &found)
+		for _, e := range cataloged {
+			if e.Name == "back squat" && (len(e.Media) != 1 || !e.Media[0].ExpiresAt.After(*m.ExpiresAt)) {
+				t.Errorf("expected the catalog link to outlive its cached copy, got %+v", e.Media)
+			}
+			if e.Name == "squat" && len(e.Media) != 0 {
+				t.Errorf("expected no media on squat, got %+v", e.Media)
+			}
+		}
+		if len(found) != 1 || len(found[0].Media) != 1 || found[0].Media[0].ID != m.ID {
+			t.Errorf("unexpected search results %s", search.Body)
+		}
+		if list.StatusCode != 200 || !strings.Contains(list.Body, m.ID) {
+			t.Errorf("unexpected media list %d %s", list.StatusCode, list.Body)
+		}
+	})
+
+	t.Run("signs links with the configured signer", func(t *testing.T) {
+		// Arrange
+		h := NewLambdaHandler(zerolog.Nop(), WithExerciseMedia(blob.NewMemoryStore(), stubMediaSigner{}))
+		upload(h, "/api/admin/exercises/push-up/media", "video/mp4", gif, nil)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/exercises/push-up/media", "user-1", nil, ""))
+
+		// Assert
+		var list []media.Media
+		json.Unmarshal([]byte(response.Body), &list)
+		if len(list) != 1 || !strings.HasPrefix(list[0].URL, "https://media.example.com/media/exercises/push-up/") {
+			t.Errorf("unexpected media %s", response.Body)
+		}
+	})
+
+	t.Run("admins delete media", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		var m media.Media
+		json.Unmarshal([]byte(upload(h, "/api/admin/exercises/deadlift/media", "image/png", gif, nil).Body), &m)
+
+		// Act
+		event := cognitoEvent("DELETE", "/api/admin/exercises/deadlift/media/"+m.ID, "ops", admin)
+		deleted, _ := h.HandleRequest(ctx, event)
+		again, _ := h.HandleRequest(ctx, event)
+		list, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/exercises/deadlift/media", "user-1", nil, ""))
+
+		// Assert
+		if deleted.StatusCode != 200 || again.StatusCode != 404 || list.Body != "[]" {
+			t.Errorf("unexpected responses %d, %d and %s", deleted.StatusCode, again.StatusCode, list.Body)
+		}
+	})
+
+	t.Run("rejects invalid uploads", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			path        string
+			contentType string
+			wantStatus  int
+		}{
+			{"unknown exercise", "/api/admin/exercises/zercher%20squat/media", "image/gif", 404},
+			{"unsupported type", "/api/admin/exercises/squat/media", "application/pdf", 400},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// Act
+				response := upload(newTestHandler(), tt.path, tt.contentType, gif, nil)
+
+				// Assert
+				if response.StatusCode != tt.wantStatus {
+					t.Errorf("expected status code %d, got %d: %s", tt.wantStatus, response.StatusCode, response.Body)
+				}
+			})
+		}
+	})
+
+	t.Run("only admins upload media", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("POST", "/api/admin/exercises/squat/media", "user-1", nil, gif))
+
+		// Assert
+		if response.StatusCode != 403 {
+			t.Errorf("expected status code 403, got %d", response.StatusCode)
+		}
+	})
+}
+
+// stubMediaSigner links to keys on a fixed domain without signing them
+type stubMediaSigner struct{}
+
+func (stubMediaSigner) Sign(ctx context.Context, key string, expires time.Time) (string, error) {
+	return "https://media.example.com/" + key, nil
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"athlete-forge/metrics"
+)
+
+// WithMetrics sets where product metrics for API requests are recorded; none are
+// when omitted
+func WithMetrics(r metrics.Recorder) Option {
+	return func(h *LambdaHandler) {
+		h.metrics = r
+	}
+}
+
+// recordUsage records which route served the request, for whom and under which
+// feature flag variants. Recording is best effort: a failure is logged and the
+// response is unaffected
+func (h *LambdaHandler) recordUsage(ctx context.Context, event *APIGatewayProxyEvent, r *route, response Response, start time.Time) {
+	if h.metrics == nil {
+		return
+	}
+	m := metrics.Metric{
+		Timestamp:  start.UTC(),
+		Method:     event.HTTPMethod,
+		Route:      r.pattern,
+		Status:     response.StatusCode,
+		DurationMs: time.Since(start).Milliseconds(),
+		Cohort:     metrics.Cohort(h.userID(event)),
+		Variants:   metrics.ParseVariants(header(event, "X-Feature-Variants")),
+	}
+	if err := h.metrics.Record(ctx, m); err != nil {
+		h.logger.Warn().
+			Err(err).
+			Str("route", r.pattern).
+			Msg("Failed to record usage metric")
+	}
+}
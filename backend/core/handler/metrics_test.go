@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"athlete-forge/metrics"
+)
+
+// recordingRecorder records the metrics it receives, failing when err is set
+type recordingRecorder struct {
+	metrics []metrics.Metric
+	err     error
+}
+
+func (r *recordingRecorder) Record(ctx context.Context, m ...metrics.Metric) error {
+	r.metrics = append(r.metrics, m...)
+	return r.err
+}
+
+func TestLambdaHandler_UsageMetrics(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("records the route, status, cohort and variants of each request", func(t *testing.T) {
+		// Arrange
+		recorder := &recordingRecorder{}
+		h := newTestHandler()
+		h.metrics = recorder
+		event := apiEvent("GET", "/api/workouts/w1", "6f1c2a7e-9d3b-4c1e-8a5f-2b7d9e0c4a11", nil, "")
+		event["headers"] = map[string]string{"X-Feature-Variants": "new-timer=treatment"}
+
+		// Act
+		h.HandleRequest(ctx, event)
+
+		// Assert
+		if len(recorder.metrics) != 1 {
+			t.Fatalf("expected one metric, got %d", len(recorder.metrics))
+		}
+		m := recorder.metrics[0]
+		if m.Method != "GET" || m.Route != "/api/workouts/{id}" || m.Status != 404 || m.Cohort != metrics.CohortCognito || m.Variants["new-timer"] != "treatment" {
+			t.Errorf("unexpected metric: %+v", m)
+		}
+	})
+
+	t.Run("unrouted requests, wrong methods and preflights are not recorded", func(t *testing.T) {
+		// Arrange
+		recorder := &recordingRecorder{}
+		h := newTestHandler()
+		h.metrics = recorder
+
+		// Act
+		h.HandleRequest(ctx, apiEvent("GET", "/", "", nil, ""))
+		h.HandleRequest(ctx, apiEvent("PATCH", "/api/workouts", "", nil, ""))
+		h.HandleRequest(ctx, apiEvent("OPTIONS", "/api/workouts", "", nil, ""))
+
+		// Assert
+		if len(recorder.metrics) != 0 {
+			t.Errorf("expected no metrics, got %+v", recorder.metrics)
+		}
+	})
+
+	t.Run("a recording failure does not fail the request", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		h.metrics = &recordingRecorder{err: errors.New("throttled")}
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("GET", "/api/tools/plates", "", map[string]string{"weight": "100"}, ""))
+
+		// Assert
+		if err != nil || response.StatusCode != 200 {
+			t.Errorf("expected status code 200, got %d, %v", response.StatusCode, err)
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"context"
+
+	"athlete-forge/migrations"
+	"athlete-forge/store"
+)
+
+// schemaMigrations returns the migrations of every kind of stored item. A change
+// to a stored document's shape registers a migration under the kind's sort key
+// prefix, such as:
+//
+//	r.Register(workout.SKPrefix, migrations.Migration{Version: 1, Description: "...", Up: ...})
+//
+// so items written before it are upgraded as they are read, and rewritten by the
+// migrate-items job
+func schemaMigrations() *migrations.Registry {
+	return migrations.NewRegistry()
+}
+
+// WithSchemaMigrations sets the migrations applied to stored items in place of the
+// registered ones
+func WithSchemaMigrations(r *migrations.Registry) Option {
+	return func(h *LambdaHandler) {
+		h.schemas = r
+	}
+}
+
+// upgradeItem brings an item from the table's stream up to its current schema,
+// since stream images are read without passing through the store
+func (h *LambdaHandler) upgradeItem(item *store.Item) (*store.Item, error) {
+	if item == nil {
+		return nil, nil
+	}
+	upgraded, _, err := h.schemas.Upgrade(*item)
+	if err != nil {
+		return nil, err
+	}
+	return &upgraded, nil
+}
+
+// runMigrateItems rewrites the items of every active user still on an older
+// schema; items are upgraded as they are read regardless, so this only saves
+// repeating the upgrade and lets a migration's code be retired. A failure for
+// one user is logged and does not stop the others, and the job can be re-run
+// until nothing fails
+func (h *LambdaHandler) runMigrateItems(ctx context.Context) (JobResult, error) {
+	result := JobResult{Job: JobMigrateItems}
+	users, err := h.users.List(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	for _, user := range users {
+		n, err := h.migrated.Backfill(ctx, store.UserPK(user.UserID))
+		if err != nil {
+			result.Failed++
+			h.logger.Error().
+				Err(err).
+				Str("user_id", user.UserID).
+				Msg("Failed to migrate items")
+			continue
+		}
+		result.Processed += n
+	}
+	return result, nil
+}
@@ -0,0 +1,463 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"athlete-forge/marketplace"
+	"athlete-forge/moderation"
+	"athlete-forge/store"
+)
+
+// FlagRequest is the body for reporting content for admin review
+type FlagRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ReportRequest is the body for reporting public content: a marketplace
+// template, a template review or a user
+type ReportRequest struct {
+	moderation.Target
+	Reason string `json:"reason"`
+}
+
+// ReportResponse tells a reporter which case their report joined
+type ReportResponse struct {
+	CaseID string `json:"caseId"`
+}
+
+// moderationApprove is the marketplace review endpoints' name for restoring
+// content, kept from before the moderation queue
+const moderationApprove = "approve"
+
+// ModerationRequest is the body for an admin's action on reported content, with
+// an optional note for the audit trail
+type ModerationRequest struct {
+	Action string `json:"action"`
+	Note   string `json:"note"`
+}
+
+// CaseResponse is a moderation case with its audit trail, newest first
+type CaseResponse struct {
+	moderation.Case
+	Audit []moderation.Entry `json:"audit"`
+}
+
+// handleReport reports public content for admin review
+func (h *LambdaHandler) handleReport(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	var req ReportRequest
+	if err := decodeBody(event, &req); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	return h.fileReport(ctx, event, req.Target, req.Reason)
+}
+
+// reportContent reports target with the reason in the request body
+func (h *LambdaHandler) reportContent(ctx context.Context, event *APIGatewayProxyEvent, target moderation.Target) (Response, error) {
+	var req FlagRequest
+	if err := decodeBody(event, &req); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	return h.fileReport(ctx, event, target, req.Reason)
+}
+
+// fileReport adds a report of target to its moderation case, flagging the
+// content for admins. Content must be visible to the reporter, and users cannot
+// report themselves or their own content
+func (h *LambdaHandler) fileReport(ctx context.Context, event *APIGatewayProxyEvent, target moderation.Target, reason string) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	errResponse, err := h.requireGoodStanding(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	if err := target.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	reason, err = moderation.ValidateReason(reason)
+	if err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	// The owner is resolved and self-reports refused before anything is flagged
+	ownerID := target.UserID
+	var l *marketplace.Listing
+	var review *marketplace.Review
+	switch target.Kind {
+	case moderation.KindTemplate:
+		l, errResponse, err = h.visibleListing(ctx, userID, target.ID)
+		if err != nil {
+			return Response{}, err
+		}
+		if errResponse != nil {
+			return *errResponse, nil
+		}
+		ownerID = l.AuthorID
+	case moderation.KindReview:
+		review, err = h.marketplace.GetReview(ctx, target.ID, target.UserID)
+		if errors.Is(err, store.ErrNotFound) || (err == nil && review.Status != marketplace.StatusPublished) {
+			return h.createErrorResponse(404, "Review not found"), nil
+		}
+		if err != nil {
+			return Response{}, err
+		}
+	}
+	if ownerID == userID {
+		return h.createErrorResponse(400, "You cannot report yourself or your own content"), nil
+	}
+
+	switch {
+	case l != nil:
+		if err := l.Flag(reason); err != nil {
+			return h.createErrorResponse(400, err.Error()), nil
+		}
+		l.UpdatedAt = time.Now().UTC()
+		if err := h.marketplace.Save(ctx, l); err != nil {
+			return Response{}, err
+		}
+	case review != nil:
+		if err := review.Flag(reason); err != nil {
+			return h.createErrorResponse(400, err.Error()), nil
+		}
+		if err := h.marketplace.SaveReview(ctx, review); err != nil {
+			return Response{}, err
+		}
+	}
+
+	c, err := h.moderation.File(ctx, target, ownerID, moderation.Report{
+		ReporterID: userID,
+		Reason:     reason,
+		ReportedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return Response{}, err
+	}
+
+	h.logger.Info().
+		Str("function", "fileReport").
+		Str("user_id", userID).
+		Str("case_id", c.ID).
+		Str("kind", target.Kind).
+		Int("reports", c.ReportCount).
+		Msg("Content reported for moderation")
+
+	return h.createJSONResponse(202, ReportResponse{CaseID: c.ID})
+}
+
+// handleGetStanding returns the user's own warnings and whether they are banned
+func (h *LambdaHandler) handleGetStanding(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	s, err := h.moderation.Standing(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, s)
+}
+
+// handleListCases returns the moderation queue: open cases, most reported first,
+// or those with ?status=closed, or all of them with ?status=all
+func (h *LambdaHandler) handleListCases(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	if _, errResponse := h.requireUser(event); errResponse != nil {
+		return *errResponse, nil
+	}
+
+	status := event.QueryStringParameters["status"]
+	switch status {
+	case "":
+		status = moderation.StatusOpen
+	case "all":
+		status = ""
+	case moderation.StatusOpen, moderation.StatusClosed:
+	default:
+		return h.createErrorResponse(400, "status must be open, closed or all"), nil
+	}
+	cases, err := h.moderation.ListCases(ctx, status)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, cases)
+}
+
+// handleGetCase returns a moderation case with the actions taken on it
+func (h *LambdaHandler) handleGetCase(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	if _, errResponse := h.requireUser(event); errResponse != nil {
+		return *errResponse, nil
+	}
+
+	c, err := h.moderation.GetCase(ctx, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Case not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	audit, err := h.moderation.Audit(ctx, c.ID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, CaseResponse{Case: *c, Audit: audit})
+}
+
+// handleCaseAction applies an admin's action to a case's content or its owner,
+// closing the case
+func (h *LambdaHandler) handleCaseAction(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	c, err := h.moderation.GetCase(ctx, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Case not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	errResponse, err := h.moderateContent(ctx, event, c.Target)
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	return h.handleGetCase(ctx, event)
+}
+
+// handleListAudit returns the audit trail of moderation actions, newest first
+func (h *LambdaHandler) handleListAudit(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	if _, errResponse := h.requireUser(event); errResponse != nil {
+		return *errResponse, nil
+	}
+
+	entries, err := h.moderation.Audit(ctx, "")
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, entries)
+}
+
+// moderateContent applies the admin action in the request body to target:
+// hide and restore change the content's visibility, warn and ban apply to its
+// owner, unban lifts a ban and dismiss changes nothing. Each clears the
+// content's flags, closes its case if it has one and is recorded in the audit
+// trail. The marketplace's approve is accepted as restore
+func (h *LambdaHandler) moderateContent(ctx context.Context, event *APIGatewayProxyEvent, target moderation.Target) (*Response, error) {
+	adminID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return errResponse, nil
+	}
+	var req ModerationRequest
+	if err := decodeBody(event, &req); err != nil {
+		response := h.createErrorResponse(400, err.Error())
+		return &response, nil
+	}
+	if req.Action == moderationApprove {
+		req.Action = moderation.ActionRestore
+	}
+	if err := moderation.ValidateNote(req.Note); err != nil {
+		response := h.createErrorResponse(400, err.Error())
+		return &response, nil
+	}
+
+	if target.Kind == moderation.KindUser && (req.Action == moderation.ActionHide || req.Action == moderation.ActionRestore) {
+		response := h.createErrorResponse(400, "users cannot be hidden or restored; warn or ban them")
+		return &response, nil
+	}
+
+	now := time.Now().UTC()
+	c, err := h.moderation.GetCase(ctx, moderation.CaseID(target))
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+
+	ownerID, errResponse, err := h.contentOwner(ctx, target)
+	if err != nil || errResponse != nil {
+		return errResponse, err
+	}
+	status := ""
+	switch req.Action {
+	case moderation.ActionHide:
+		status = marketplace.StatusHidden
+	case moderation.ActionRestore:
+		status = marketplace.StatusPublished
+	case moderation.ActionWarn:
+		warning := moderation.Warning{Note: req.Note, WarnedAt: now}
+		if c != nil {
+			warning.CaseID = c.ID
+		}
+		if _, err := h.moderation.Warn(ctx, ownerID, warning); err != nil {
+			return nil, err
+		}
+	case moderation.ActionBan:
+		if _, err := h.moderation.SetBanned(ctx, ownerID, true, now); err != nil {
+			return nil, err
+		}
+		if err := h.hideUserContent(ctx, ownerID); err != nil {
+			return nil, err
+		}
+	case moderation.ActionUnban:
+		if _, err := h.moderation.SetBanned(ctx, ownerID, false, now); err != nil {
+			return nil, err
+		}
+	case moderation.ActionDismiss:
+	default:
+		response := h.createErrorResponse(400, "action must be hide, restore, warn, ban, unban or dismiss")
+		return &response, nil
+	}
+	if err := h.settleContent(ctx, target, status); err != nil {
+		return nil, err
+	}
+
+	entry := &moderation.Entry{Target: target, OwnerID: ownerID, AdminID: adminID, Action: req.Action, Note: req.Note, At: now}
+	if c != nil {
+		entry.CaseID = c.ID
+		if c.Status == moderation.StatusOpen {
+			c.Status = moderation.StatusClosed
+			c.UpdatedAt = now
+			if err := h.moderation.SaveCase(ctx, c); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := h.moderation.Record(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	h.logger.Info().
+		Str("function", "moderateContent").
+		Str("user_id", adminID).
+		Str("owner_id", ownerID).
+		Str("kind", target.Kind).
+		Str("action", req.Action).
+		Msg("Content moderated")
+
+	return nil, nil
+}
+
+// contentOwner returns the user responsible for target, or a 404 response when
+// the content no longer exists
+func (h *LambdaHandler) contentOwner(ctx context.Context, target moderation.Target) (string, *Response, error) {
+	switch target.Kind {
+	case moderation.KindTemplate:
+		l, err := h.marketplace.Get(ctx, target.ID)
+		if errors.Is(err, store.ErrNotFound) {
+			response := h.createErrorResponse(404, "Template not found")
+			return "", &response, nil
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		return l.AuthorID, nil, nil
+	case moderation.KindReview:
+		if _, err := h.marketplace.GetReview(ctx, target.ID, target.UserID); errors.Is(err, store.ErrNotFound) {
+			response := h.createErrorResponse(404, "Review not found")
+			return "", &response, nil
+		} else if err != nil {
+			return "", nil, err
+		}
+	}
+	return target.UserID, nil, nil
+}
+
+// settleContent clears the flags on target's content, setting its status when
+// status is not empty, and updates the rating of a review's template
+func (h *LambdaHandler) settleContent(ctx context.Context, target moderation.Target, status string) error {
+	switch target.Kind {
+	case moderation.KindTemplate:
+		l, err := h.marketplace.Get(ctx, target.ID)
+		if err != nil {
+			return err
+		}
+		if status != "" {
+			l.Status = status
+		}
+		l.Moderation = marketplace.Moderation{}
+		l.UpdatedAt = time.Now().UTC()
+		return h.marketplace.Save(ctx, l)
+	case moderation.KindReview:
+		review, err := h.marketplace.GetReview(ctx, target.ID, target.UserID)
+		if err != nil {
+			return err
+		}
+		if status != "" {
+			review.Status = status
+		}
+		review.Moderation = marketplace.Moderation{}
+		if err := h.marketplace.SaveReview(ctx, review); err != nil {
+			return err
+		}
+		return h.rerate(ctx, review.TemplateID)
+	}
+	return nil
+}
+
+// hideUserContent hides the templates and reviews userID has published, for
+// when they are banned; lifting the ban leaves them hidden
+func (h *LambdaHandler) hideUserContent(ctx context.Context, userID string) error {
+	listings, err := h.marketplace.List(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range listings {
+		l := &listings[i]
+		if l.AuthorID != userID || l.Status != marketplace.StatusPublished {
+			continue
+		}
+		l.Status = marketplace.StatusHidden
+		l.UpdatedAt = time.Now().UTC()
+		if err := h.marketplace.Save(ctx, l); err != nil {
+			return err
+		}
+	}
+
+	reviews, err := h.marketplace.ListReviews(ctx, "")
+	if err != nil {
+		return err
+	}
+	for i := range reviews {
+		review := &reviews[i]
+		if review.UserID != userID || review.Status != marketplace.StatusPublished {
+			continue
+		}
+		review.Status = marketplace.StatusHidden
+		if err := h.marketplace.SaveReview(ctx, review); err != nil {
+			return err
+		}
+		if err := h.rerate(ctx, review.TemplateID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rerate updates the rating of the template with id, if it still exists
+func (h *LambdaHandler) rerate(ctx context.Context, id string) error {
+	l, err := h.marketplace.Get(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return h.rateTemplate(ctx, l)
+}
+
+// requireGoodStanding returns a 403 response when userID is banned from public
+// content
+func (h *LambdaHandler) requireGoodStanding(ctx context.Context, userID string) (*Response, error) {
+	s, err := h.moderation.Standing(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if s.Banned {
+		response := h.createErrorResponse(403, "Your account is banned from publishing, reviewing and reporting content")
+		return &response, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"athlete-forge/marketplace"
+	"athlete-forge/moderation"
+)
+
+// moderateCase applies an admin action to a moderation case
+func moderateCase(t *testing.T, h *LambdaHandler, caseID, action string) Response {
+	t.Helper()
+	event := cognitoEvent("POST", "/api/admin/moderation/cases/"+caseID+"/actions", "ops", map[string]interface{}{"cognito:groups": "admin"})
+	event["body"] = fmt.Sprintf(`{"action":%q,"note":"see the community guidelines"}`, action)
+	response, err := h.HandleRequest(context.Background(), event)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return response
+}
+
+func TestLambdaHandler_Moderation(t *testing.T) {
+	ctx := context.Background()
+	admin := map[string]interface{}{"cognito:groups": "admin"}
+
+	t.Run("reports of the same content join one case in the queue", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", linearProgramBody)
+		l := publishTemplate(t, h, "user-1", p.ID)
+		body := fmt.Sprintf(`{"kind":"template","id":%q,"reason":"spam"}`, l.ID)
+
+		// Act
+		first, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/moderation/reports", "user-2", nil, body))
+		second, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/marketplace/templates/"+l.ID+"/flag", "user-3", nil, `{"reason":"offensive name"}`))
+		user, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/moderation/reports", "user-2", nil, `{"kind":"user","userId":"user-4","reason":"offensive username"}`))
+		queue, _ := h.HandleRequest(ctx, cognitoEvent("GET", "/api/admin/moderation/cases", "ops", admin))
+
+		// Assert
+		var a, b ReportResponse
+		json.Unmarshal([]byte(first.Body), &a)
+		json.Unmarshal([]byte(second.Body), &b)
+		if first.StatusCode != 202 || second.StatusCode != 202 || user.StatusCode != 202 || a.CaseID != b.CaseID {
+			t.Fatalf("expected both reports in one case, got %d %s and %d %s", first.StatusCode, first.Body, second.StatusCode, second.Body)
+		}
+		var cases []moderation.Case
+		json.Unmarshal([]byte(queue.Body), &cases)
+		if len(cases) != 2 || cases[0].ID != a.CaseID || cases[0].ReportCount != 2 || cases[0].OwnerID != "user-1" {
+			t.Errorf("expected the template's case first with two reports, got %s", queue.Body)
+		}
+	})
+
+	t.Run("users cannot report their own content or unknown kinds", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", linearProgramBody)
+		l := publishTemplate(t, h, "user-1", p.ID)
+
+		// Act
+		own, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/moderation/reports", "user-1", nil, fmt.Sprintf(`{"kind":"template","id":%q,"reason":"test"}`, l.ID)))
+		unknown, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/moderation/reports", "user-2", nil, `{"kind":"workout","id":"w1","reason":"test"}`))
+		missing, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/moderation/reports", "user-2", nil, `{"kind":"template","id":"nope","reason":"test"}`))
+
+		// Assert
+		if own.StatusCode != 400 || unknown.StatusCode != 400 || missing.StatusCode != 404 {
+			t.Errorf("unexpected statuses: own %d, unknown %d, missing %d", own.StatusCode, unknown.StatusCode, missing.StatusCode)
+		}
+		if stored, _ := h.marketplace.Get(ctx, l.ID); stored.Flagged {
+			t.Errorf("expected the refused self-report to leave the template unflagged, got %+v", stored.Moderation)
+		}
+	})
+
+	t.Run("warnings and bans apply to the content's owner and are audited", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", linearProgramBody)
+		l := publishTemplate(t, h, "user-1", p.ID)
+		other := publishTemplate(t, h, "user-3", createProgram(t, h, "user-3", linearProgramBody).ID)
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/marketplace/templates/"+other.ID+"/reviews/mine", "user-1", nil, `{"rating":1}`))
+		reported, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/marketplace/templates/"+l.ID+"/flag", "user-2", nil, `{"reason":"abusive"}`))
+		var report ReportResponse
+		json.Unmarshal([]byte(reported.Body), &report)
+
+		// Act
+		warned := moderateCase(t, h, report.CaseID, "warn")
+		banned := moderateCase(t, h, report.CaseID, "ban")
+
+		// Assert
+		if warned.StatusCode != 200 || banned.StatusCode != 200 {
+			t.Fatalf("unexpected statuses: warn %d %s, ban %d %s", warned.StatusCode, warned.Body, banned.StatusCode, banned.Body)
+		}
+		var c CaseResponse
+		json.Unmarshal([]byte(banned.Body), &c)
+		if c.Status != moderation.StatusClosed || len(c.Audit) != 2 || c.Audit[0].Action != "ban" || c.Audit[0].AdminID != "ops" {
+			t.Errorf("expected a closed case with both actions audited, got %s", banned.Body)
+		}
+		standing, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/moderation/standing", "user-1", nil, ""))
+		var s moderation.Standing
+		json.Unmarshal([]byte(standing.Body), &s)
+		if !s.Banned || len(s.Warnings) != 1 || s.Warnings[0].CaseID != report.CaseID {
+			t.Errorf("expected a banned user with one warning, got %s", standing.Body)
+		}
+		browsed, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/marketplace/templates", "user-2", nil, ""))
+		var summaries []marketplace.Summary
+		json.Unmarshal([]byte(browsed.Body), &summaries)
+		if len(summaries) != 1 || summaries[0].ID != other.ID || summaries[0].RatingCount != 0 {
+			t.Errorf("expected the banned user's template and review hidden, got %s", browsed.Body)
+		}
+	})
+
+	t.Run("banned users cannot publish, review or report", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", linearProgramBody)
+		l := publishTemplate(t, h, "user-2", createProgram(t, h, "user-2", linearProgramBody).ID)
+		reported, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/moderation/reports", "user-2", nil, `{"kind":"user","userId":"user-1","reason":"spam account"}`))
+		var report ReportResponse
+		json.Unmarshal([]byte(reported.Body), &report)
+		moderateCase(t, h, report.CaseID, "ban")
+
+		// Act
+		publish, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/marketplace/templates", "user-1", nil, fmt.Sprintf(`{"programId":%q}`, p.ID)))
+		review, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/marketplace/templates/"+l.ID+"/reviews/mine", "user-1", nil, `{"rating":5}`))
+		flag, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/marketplace/templates/"+l.ID+"/flag", "user-1", nil, `{"reason":"spam"}`))
+		clone, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/marketplace/templates/"+l.ID+"/clone", "user-1", nil, ""))
+
+		// Assert
+		if publish.StatusCode != 403 || review.StatusCode != 403 || flag.StatusCode != 403 {
+			t.Errorf("unexpected statuses: publish %d, review %d, flag %d", publish.StatusCode, review.StatusCode, flag.StatusCode)
+		}
+		if clone.StatusCode != 201 {
+			t.Errorf("expected banned users to still clone templates, got %d", clone.StatusCode)
+		}
+	})
+
+	t.Run("users are warned or banned, not hidden", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		reported, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/moderation/reports", "user-2", nil, `{"kind":"user","userId":"user-1","reason":"offensive username"}`))
+		var report ReportResponse
+		json.Unmarshal([]byte(reported.Body), &report)
+
+		// Act
+		hidden := moderateCase(t, h, report.CaseID, "hide")
+		unknown := moderateCase(t, h, report.CaseID, "delete")
+
+		// Assert
+		if hidden.StatusCode != 400 || unknown.StatusCode != 400 {
+			t.Errorf("unexpected statuses: hide %d, unknown %d", hidden.StatusCode, unknown.StatusCode)
+		}
+	})
+
+	t.Run("the marketplace review endpoints close the content's case", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", linearProgramBody)
+		l := publishTemplate(t, h, "user-1", p.ID)
+		h.HandleRequest(ctx, apiEvent("POST", "/api/marketplace/templates/"+l.ID+"/flag", "user-2", nil, `{"reason":"spam"}`))
+
+		// Act
+		reviewed := reviewTemplate(t, h, l.ID, "approve")
+		queue, _ := h.HandleRequest(ctx, cognitoEvent("GET", "/api/admin/moderation/cases", "ops", admin))
+		audit, _ := h.HandleRequest(ctx, cognitoEvent("GET", "/api/admin/moderation/audit", "ops", admin))
+
+		// Assert
+		if reviewed.StatusCode != 200 || queue.Body != "[]" {
+			t.Errorf("expected the case closed, got %d and %s", reviewed.StatusCode, queue.Body)
+		}
+		var entries []moderation.Entry
+		json.Unmarshal([]byte(audit.Body), &entries)
+		if len(entries) != 1 || entries[0].Action != moderation.ActionRestore || entries[0].OwnerID != "user-1" {
+			t.Errorf("expected the approval audited as a restore, got %s", audit.Body)
+		}
+	})
+
+	t.Run("only admins see the moderation queue", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/admin/moderation/cases", "user-1", nil, ""))
+
+		// Assert
+		if response.StatusCode != 403 {
+			t.Errorf("expected status code 403, got %d", response.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"athlete-forge/multisport"
+	"athlete-forge/store"
+)
+
+// MultisportResponse is a multi-sport session with its combined summary
+type MultisportResponse struct {
+	multisport.Session
+	Summary multisport.Summary `json:"summary"`
+}
+
+// handleListMultisportSessions returns the user's multi-sport sessions
+func (h *LambdaHandler) handleListMultisportSessions(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	sessions, err := h.multisport.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, sessions)
+}
+
+// handleGetMultisportSession returns a multi-sport session with each segment's
+// analytics and the combined summary
+func (h *LambdaHandler) handleGetMultisportSession(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	s, err := h.multisport.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Multi-sport session not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	return h.multisportResponse(ctx, 200, s)
+}
+
+// handleCreateMultisportSession groups the user's activities and workouts into
+// a multi-sport session
+func (h *LambdaHandler) handleCreateMultisportSession(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var s multisport.Session
+	if err := decodeBody(event, &s); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	now := time.Now().UTC()
+	s.ID = ""
+	s.UserID = userID
+	s.CreatedAt = now
+	s.UpdatedAt = now
+	return h.saveMultisportSession(ctx, 201, &s)
+}
+
+// handlePutMultisportSession replaces a multi-sport session's name and segments
+func (h *LambdaHandler) handlePutMultisportSession(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	existing, err := h.multisport.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Multi-sport session not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	var s multisport.Session
+	if err := decodeBody(event, &s); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	s.ID = existing.ID
+	s.UserID = userID
+	s.CreatedAt = existing.CreatedAt
+	s.UpdatedAt = time.Now().UTC()
+	return h.saveMultisportSession(ctx, 200, &s)
+}
+
+// handleDeleteMultisportSession removes a multi-sport session, leaving its
+// activities and workouts in place, and returns what was removed
+func (h *LambdaHandler) handleDeleteMultisportSession(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	s, err := h.multisport.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Multi-sport session not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	if err := h.multisport.Delete(ctx, userID, s.ID); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, s)
+}
+
+// saveMultisportSession validates s, checks each segment refers to one of the
+// user's activities or workouts and saves it
+func (h *LambdaHandler) saveMultisportSession(ctx context.Context, status int, s *multisport.Session) (Response, error) {
+	if err := s.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	parts, err := h.multisportParts(ctx, s)
+	if err != nil {
+		return Response{}, err
+	}
+	for i, part := range parts {
+		if part.Activity == nil && part.Workout == nil {
+			return h.createErrorResponse(400, fmt.Sprintf("segment %d: %s %s not found", i+1, part.Segment.Type, part.Segment.ID)), nil
+		}
+	}
+	if err := h.multisport.Save(ctx, s); err != nil {
+		return Response{}, err
+	}
+	return h.multisportResponse(ctx, status, s)
+}
+
+// multisportResponse summarizes s with the user's heart rate zones
+func (h *LambdaHandler) multisportResponse(ctx context.Context, status int, s *multisport.Session) (Response, error) {
+	parts, err := h.multisportParts(ctx, s)
+	if err != nil {
+		return Response{}, err
+	}
+	p, err := h.profiles.Get(ctx, s.UserID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(status, MultisportResponse{Session: *s, Summary: multisport.Summarize(parts, p.HeartRate)})
+}
+
+// multisportParts loads the activity or workout of each of s's segments,
+// leaving both nil for segments whose target does not exist
+func (h *LambdaHandler) multisportParts(ctx context.Context, s *multisport.Session) ([]multisport.Part, error) {
+	parts := make([]multisport.Part, len(s.Segments))
+	for i, segment := range s.Segments {
+		parts[i].Segment = segment
+		var err error
+		switch segment.Type {
+		case multisport.SegmentActivity:
+			parts[i].Activity, err = h.activities.Get(ctx, s.UserID, segment.ID)
+		case multisport.SegmentWorkout:
+			parts[i].Workout, err = h.workouts.Get(ctx, s.UserID, segment.ID)
+		}
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return nil, err
+		}
+	}
+	return parts, nil
+}
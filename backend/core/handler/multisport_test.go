@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestLambdaHandler_MultisportSessions(t *testing.T) {
+	ctx := context.Background()
+	rideBody := `{"sport": "ride", "startTime": "2024-03-09T07:00:00Z", "durationSeconds": 3600, "distanceMeters": 30000}`
+	runBody := `{"sport": "run", "startTime": "2024-03-09T08:01:30Z", "durationSeconds": 1800, "distanceMeters": 6000}`
+
+	t.Run("creates a brick with per-segment and combined summaries", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		ride := createActivity(t, h, "user-1", rideBody)
+		run := createActivity(t, h, "user-1", runBody)
+		body := fmt.Sprintf(`{"name": "Saturday brick", "segments": [{"type": "activity", "id": %q}, {"type": "activity", "id": %q}]}`, ride.ID, run.ID)
+
+		// Act
+		created, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/multisport-sessions", "user-1", nil, body))
+		var session MultisportResponse
+		json.Unmarshal([]byte(created.Body), &session)
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/multisport-sessions/"+session.ID, "user-1", nil, ""))
+
+		// Assert
+		if created.StatusCode != 201 {
+			t.Fatalf("expected status code 201, got %d: %s", created.StatusCode, created.Body)
+		}
+		var fetched MultisportResponse
+		json.Unmarshal([]byte(response.Body), &fetched)
+		if fetched.Name != "Saturday brick" || fetched.Summary.TransitionSeconds != 90 || fetched.Summary.DistanceMeters != 36000 || len(fetched.Summary.Segments) != 2 {
+			t.Errorf("unexpected session: %+v", fetched)
+		}
+	})
+
+	t.Run("rejects segments the user does not have", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		ride := createActivity(t, h, "user-1", rideBody)
+		run := createActivity(t, h, "user-2", runBody)
+		body := fmt.Sprintf(`{"name": "Brick", "segments": [{"type": "activity", "id": %q}, {"type": "activity", "id": %q}]}`, ride.ID, run.ID)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/multisport-sessions", "user-1", nil, body))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d: %s", response.StatusCode, response.Body)
+		}
+	})
+
+	t.Run("combines lifting with a conditioning finisher", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		created := createWorkout(t, h, "user-1", `{"startedAt": "2024-03-09T07:00:00Z", "exercises": [{"name": "Squat", "sets": [{"reps": 5, "weight": 100}]}]}`)
+		var w struct{ ID string }
+		json.Unmarshal([]byte(created.Body), &w)
+		row := createActivity(t, h, "user-1", `{"sport": "row", "startTime": "2024-03-09T07:45:00Z", "durationSeconds": 600}`)
+		body := fmt.Sprintf(`{"name": "Legs and row", "segments": [{"type": "workout", "id": %q}, {"type": "activity", "id": %q, "label": "Finisher"}]}`, w.ID, row.ID)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/multisport-sessions", "user-1", nil, body))
+
+		// Assert
+		var session MultisportResponse
+		json.Unmarshal([]byte(response.Body), &session)
+		if len(session.Summary.Sports) != 2 || session.Summary.Sports[0] != "strength" || session.Summary.Segments[0].Volume != 500 || session.Summary.Segments[1].Label != "Finisher" {
+			t.Errorf("unexpected summary: %+v", session.Summary)
+		}
+	})
+
+	t.Run("delete removes the session but not its segments", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		ride := createActivity(t, h, "user-1", rideBody)
+		run := createActivity(t, h, "user-1", runBody)
+		body := fmt.Sprintf(`{"name": "Brick", "segments": [{"type": "activity", "id": %q}, {"type": "activity", "id": %q}]}`, ride.ID, run.ID)
+		created, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/multisport-sessions", "user-1", nil, body))
+		var session MultisportResponse
+		json.Unmarshal([]byte(created.Body), &session)
+
+		// Act
+		h.HandleRequest(ctx, apiEvent("DELETE", "/api/multisport-sessions/"+session.ID, "user-1", nil, ""))
+
+		// Assert
+		gone, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/multisport-sessions/"+session.ID, "user-1", nil, ""))
+		kept, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/activities/"+ride.ID, "user-1", nil, ""))
+		if gone.StatusCode != 404 || kept.StatusCode != 200 {
+			t.Errorf("expected the session gone and the ride kept, got %d and %d", gone.StatusCode, kept.StatusCode)
+		}
+	})
+}
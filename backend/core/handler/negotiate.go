@@ -0,0 +1,254 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"athlete-forge/i18n"
+	"athlete-forge/l10n"
+	"athlete-forge/msgpack"
+)
+
+const (
+	contentTypeJSON = "application/json"
+	contentTypeCSV  = "text/csv"
+)
+
+// mediaAliases maps other names clients use for a format to the one served
+var mediaAliases = map[string]string{
+	"application/x-msgpack":   msgpack.ContentType,
+	"application/vnd.msgpack": msgpack.ContentType,
+}
+
+// negotiate re-encodes a successful JSON response in the format the request's
+// Accept header prefers: compact JSON or MessagePack for any response, or CSV for
+// lists. Compact requests are compacted whatever the format. Errors and
+// responses in other formats are left as they are
+func (h *LambdaHandler) negotiate(ctx context.Context, event *APIGatewayProxyEvent, response Response) (Response, error) {
+	if response.StatusCode < 200 || response.StatusCode >= 300 || response.Headers["Content-Type"] != contentTypeJSON {
+		return response, nil
+	}
+	response.Headers["Vary"] = "Accept"
+
+	body := bytes.TrimSpace([]byte(response.Body))
+	available := []string{contentTypeJSON, contentTypeCompact, msgpack.ContentType}
+	if bytes.HasPrefix(body, []byte("[")) {
+		available = append(available, contentTypeCSV)
+	}
+
+	preferred := preferredType(header(event, "Accept"), available)
+	if event.compact || preferred == contentTypeCompact {
+		compacted, err := compactJSON(body)
+		if err != nil {
+			return Response{}, fmt.Errorf("failed to compact response: %w", err)
+		}
+		body = compacted
+		if preferred == contentTypeJSON {
+			preferred = contentTypeCompact
+		}
+	}
+
+	switch preferred {
+	case contentTypeJSON:
+		return response, nil
+	case contentTypeCompact:
+		response.Headers["Content-Type"] = contentTypeCompact
+		response.Body = string(body)
+		return response, nil
+	case msgpack.ContentType:
+		encoded, err := msgpack.FromJSON(body)
+		if err != nil {
+			return Response{}, fmt.Errorf("failed to encode response as MessagePack: %w", err)
+		}
+		response.Headers["Content-Type"] = msgpack.ContentType
+		response.Body = base64.StdEncoding.EncodeToString(encoded)
+		response.IsBase64Encoded = true
+		return response, nil
+	case contentTypeCSV:
+		c := l10n.For(i18n.Default)
+		if userID := h.userID(event); userID != "" {
+			var err error
+			if c, err = h.conventions(ctx, userID); err != nil {
+				return Response{}, err
+			}
+		}
+		encoded, err := jsonToCSV(body, c)
+		if err != nil {
+			return Response{}, fmt.Errorf("failed to encode response as CSV: %w", err)
+		}
+		response.Headers["Content-Type"] = contentTypeCSV + "; charset=utf-8"
+		response.Body = string(encoded)
+		return response, nil
+	}
+
+	notAcceptable := h.createErrorResponse(406, "This endpoint can respond with "+strings.Join(available, ", "))
+	notAcceptable.Headers["Vary"] = "Accept"
+	return notAcceptable, nil
+}
+
+// preferredType returns the type in available that accept ranks highest, keeping
+// the order of available between equal preferences, or "" when accept excludes
+// them all. A missing Accept header accepts anything
+func preferredType(accept string, available []string) string {
+	if strings.TrimSpace(accept) == "" {
+		return available[0]
+	}
+
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		r := mediaRange{mediaType: strings.ToLower(strings.TrimSpace(params[0])), q: 1}
+		if alias, ok := mediaAliases[r.mediaType]; ok {
+			r.mediaType = alias
+		}
+		for _, param := range params[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(name, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					r.q = q
+				}
+			}
+		}
+		ranges = append(ranges, r)
+	}
+
+	best, bestQ := "", 0.0
+	for _, candidate := range available {
+		// The most specific matching range sets the candidate's preference
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			s := matchSpecificity(r.mediaType, candidate)
+			if s > specificity {
+				q, specificity = r.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = candidate, q
+		}
+	}
+	return best
+}
+
+// matchSpecificity reports how specifically mediaRange matches mediaType: 2 for
+// an exact match, 1 for type/*, 0 for */* and -1 for no match
+func matchSpecificity(mediaRange, mediaType string) int {
+	switch {
+	case mediaRange == mediaType:
+		return 2
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*")):
+		return 1
+	}
+	return -1
+}
+
+// jsonToCSV converts a JSON array of objects into CSV with a header row. Columns
+// follow the order fields first appear in; nested values are written as JSON and
+// nulls as empty cells. Numbers and dates are written, and fields separated, as
+// spreadsheets expect in c's locale
+func jsonToCSV(body []byte, c l10n.Conventions) ([]byte, error) {
+	var rows []json.RawMessage
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, err
+	}
+
+	var columns []string
+	seen := map[string]bool{}
+	records := make([]map[string]json.RawMessage, 0, len(rows))
+	for _, row := range rows {
+		fields, order, err := decodeOrderedObject(row)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range order {
+			if !seen[name] {
+				seen[name] = true
+				columns = append(columns, name)
+			}
+		}
+		records = append(records, fields)
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = c.CSVSeparator()
+	if len(columns) > 0 {
+		w.Write(columns)
+	}
+	for _, fields := range records {
+		record := make([]string, len(columns))
+		for i, name := range columns {
+			record[i] = csvCell(fields[name], c)
+		}
+		w.Write(record)
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// decodeOrderedObject decodes a JSON object's fields along with their order
+func decodeOrderedObject(data json.RawMessage) (map[string]json.RawMessage, []string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, nil, fmt.Errorf("list element is not an object")
+	}
+
+	fields := map[string]json.RawMessage{}
+	var order []string
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		name := token.(string)
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, nil, err
+		}
+		if _, ok := fields[name]; !ok {
+			order = append(order, name)
+		}
+		fields[name] = value
+	}
+	return fields, order, nil
+}
+
+// csvCell renders a JSON value as a spreadsheet cell. Numbers take c's decimal
+// separator, without grouping so spreadsheets still read them as numbers, and
+// dates its date format; timestamps stay ISO 8601. Strings a spreadsheet would
+// evaluate as a formula, such as a note beginning with =, are prefixed with a
+// quote so they are shown as text
+func csvCell(value json.RawMessage, c l10n.Conventions) string {
+	if len(value) == 0 || string(value) == "null" {
+		return ""
+	}
+	var s string
+	if value[0] != '"' || json.Unmarshal(value, &s) != nil {
+		if isJSONNumber(value) {
+			return strings.Replace(string(value), ".", c.Decimal, 1)
+		}
+		return string(value)
+	}
+	s = c.DateString(s)
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// isJSONNumber reports whether value is a JSON number rather than a literal,
+// object or array
+func isJSONNumber(value json.RawMessage) bool {
+	return value[0] == '-' || (value[0] >= '0' && value[0] <= '9')
+}
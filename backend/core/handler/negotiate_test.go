@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func TestLambdaHandler_ContentNegotiation(t *testing.T) {
+	ctx := context.Background()
+	accepting := func(accept, path string) map[string]interface{} {
+		event := apiEvent("GET", path, "user-1", nil, "")
+		event["headers"] = map[string]string{"Accept": accept}
+		return event
+	}
+	setup := func(t *testing.T) *LambdaHandler {
+		h := newTestHandler()
+		createWorkout(t, h, "user-1", `{"name":"=HYPERLINK(\"x\")","exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]}]}`)
+		return h
+	}
+
+	t.Run("lists are returned as CSV with a header row", func(t *testing.T) {
+		// Arrange
+		h := setup(t)
+
+		// Act
+		response, err := h.HandleRequest(ctx, accepting("text/csv", "/api/workouts"))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if response.Headers["Content-Type"] != "text/csv; charset=utf-8" || response.Headers["Vary"] != "Accept" {
+			t.Fatalf("expected CSV varying on Accept, got %v", response.Headers)
+		}
+		rows, err := csv.NewReader(strings.NewReader(response.Body)).ReadAll()
+		if err != nil {
+			t.Fatalf("invalid CSV: %v", err)
+		}
+		if len(rows) != 2 || rows[0][0] != "id" {
+			t.Fatalf("expected a header and one row starting with id, got %v", rows)
+		}
+		for i, column := range rows[0] {
+			if column == "name" && rows[1][i] != `'=HYPERLINK("x")` {
+				t.Errorf("expected the formula to be escaped, got %q", rows[1][i])
+			}
+		}
+	})
+
+	t.Run("CSV follows the conventions of the profile's locale", func(t *testing.T) {
+		// Arrange
+		h := setup(t)
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/profile", "user-1", nil, `{"unit":"kg","barWeight":20,"plates":[],"locale":"de-AT"}`))
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/logs/2024-03-08", "user-1", nil, `{"sleepHours":7.5,"steps":12000}`))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, accepting("text/csv", "/api/logs"))
+
+		// Assert
+		reader := csv.NewReader(strings.NewReader(response.Body))
+		reader.Comma = ';'
+		rows, err := reader.ReadAll()
+		if err != nil || len(rows) != 2 {
+			t.Fatalf("expected a semicolon separated header and row, got %v: %s", err, response.Body)
+		}
+		cells := map[string]string{}
+		for i, column := range rows[0] {
+			cells[column] = rows[1][i]
+		}
+		if cells["date"] != "08.03.2024" || cells["sleepHours"] != "7,5" || cells["steps"] != "12000" {
+			t.Errorf("unexpected cells: %v", cells)
+		}
+	})
+
+	t.Run("responses are returned as base64 MessagePack", func(t *testing.T) {
+		// Arrange
+		h := setup(t)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, accepting("application/x-msgpack", "/api/workouts"))
+
+		// Assert
+		if response.Headers["Content-Type"] != "application/msgpack" || !response.IsBase64Encoded {
+			t.Fatalf("expected base64 MessagePack, got %v", response.Headers)
+		}
+		body, err := base64.StdEncoding.DecodeString(response.Body)
+		if err != nil || len(body) == 0 || body[0] != 0x91 {
+			t.Errorf("expected a one-element MessagePack array, got % x", body)
+		}
+	})
+
+	t.Run("quality values pick the preferred format", func(t *testing.T) {
+		// Arrange
+		h := setup(t)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, accepting("text/csv;q=0.5, application/json", "/api/workouts"))
+
+		// Assert
+		if response.Headers["Content-Type"] != "application/json" {
+			t.Errorf("expected JSON, got %s", response.Headers["Content-Type"])
+		}
+	})
+
+	t.Run("CSV is not acceptable for single resources", func(t *testing.T) {
+		// Arrange
+		h := setup(t)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, accepting("text/csv", "/api/profile"))
+
+		// Assert
+		if response.StatusCode != 406 {
+			t.Errorf("expected status 406, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("errors stay JSON", func(t *testing.T) {
+		// Arrange
+		h := setup(t)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, accepting("text/csv", "/api/workouts/missing"))
+
+		// Assert
+		if response.StatusCode != 404 || response.Headers["Content-Type"] != "application/json" {
+			t.Errorf("expected a JSON 404, got %d %s", response.StatusCode, response.Headers["Content-Type"])
+		}
+	})
+}
+
+func TestPreferredType(t *testing.T) {
+	available := []string{contentTypeJSON, "application/msgpack", contentTypeCSV}
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", contentTypeJSON},
+		{"*/*", contentTypeJSON},
+		{"text/*", contentTypeCSV},
+		{"text/html, */*;q=0.8", contentTypeJSON},
+		{"application/*;q=0.2, text/csv;q=0.1", "application/json"},
+		{"*/*, application/json;q=0", "application/msgpack"},
+		{"image/png", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			// Act
+			got := preferredType(tt.accept, available)
+
+			// Assert
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
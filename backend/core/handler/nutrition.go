@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"athlete-forge/nutrition"
+)
+
+// handleFoodLookup returns nutrition data for ?barcode= from the cached food database
+func (h *LambdaHandler) handleFoodLookup(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	if _, errResponse := h.requireUser(event); errResponse != nil {
+		return *errResponse, nil
+	}
+
+	barcode := event.QueryStringParameters["barcode"]
+	food, err := h.foods.Lookup(ctx, barcode)
+	switch {
+	case errors.Is(err, nutrition.ErrInvalidBarcode):
+		return h.createErrorResponse(400, err.Error()), nil
+	case errors.Is(err, nutrition.ErrFoodNotFound):
+		return h.createErrorResponse(404, "Food not found"), nil
+	case err != nil:
+		h.logger.Warn().Err(err).Str("barcode", barcode).Msg("Food database lookup failed")
+		return h.createErrorResponse(502, "Food database unavailable"), nil
+	}
+	return h.createJSONResponse(200, food)
+}
+
+// handleListNutritionDays returns the user's imported nutrition days, optionally
+// bounded by ?from= and ?to=
+func (h *LambdaHandler) handleListNutritionDays(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	days, err := h.diary.List(ctx, userID, event.QueryStringParameters["from"], event.QueryStringParameters["to"])
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, days)
+}
+
+// mappingParamPrefix prefixes the query parameters overriding the column read
+// into a field, as ?map.calories=Energy (kcal)
+const mappingParamPrefix = "map."
+
+// nutritionExport returns the importer for the request's source and the CSV
+// export in its body, with the column overrides sent, or an error response
+func (h *LambdaHandler) nutritionExport(event *APIGatewayProxyEvent) (*nutrition.Importer, string, nutrition.Mapping, *Response) {
+	importer, ok := nutrition.Importers[event.PathParameters["source"]]
+	if !ok {
+		response := h.createErrorResponse(404, "Unknown nutrition import source")
+		return nil, "", nil, &response
+	}
+
+	body := event.Body
+	if event.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(event.Body)
+		if err != nil {
+			response := h.createErrorResponse(400, "Request body is not valid base64")
+			return nil, "", nil, &response
+		}
+		body = string(decoded)
+	}
+	if body == "" {
+		response := h.createErrorResponse(400, "request body is required")
+		return nil, "", nil, &response
+	}
+
+	overrides := nutrition.Mapping{}
+	for name, value := range event.QueryStringParameters {
+		if field, ok := strings.CutPrefix(name, mappingParamPrefix); ok {
+			overrides[field] = value
+		}
+	}
+	return importer, body, overrides, nil
+}
+
+// handleNutritionImportPreview reads a food tracking app's CSV export without
+// importing it, returning its columns, the mapping proposed for them with any
+// ?map.<field>= overrides applied and its first days
+func (h *LambdaHandler) handleNutritionImportPreview(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	if _, errResponse := h.requireUser(event); errResponse != nil {
+		return *errResponse, nil
+	}
+	importer, body, overrides, errResponse := h.nutritionExport(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	preview, err := importer.Preview(strings.NewReader(body), overrides)
+	if err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	return h.createJSONResponse(200, preview)
+}
+
+// handleNutritionImport imports a food tracking app's CSV export into the user's
+// nutrition days, reading columns as previewed with any ?map.<field>=
+// overrides. Days already stored with different food are reported as
+// conflicts and kept unless ?replace=true
+func (h *LambdaHandler) handleNutritionImport(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	importer, body, overrides, errResponse := h.nutritionExport(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	source := importer.Source
+
+	days, err := importer.ParseMapped(strings.NewReader(body), overrides)
+	if err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	result, err := h.diary.Import(ctx, userID, source, days, event.QueryStringParameters["replace"] == "true", time.Now().UTC())
+	if err != nil {
+		return Response{}, err
+	}
+
+	h.logger.Info().
+		Str("function", "handleNutritionImport").
+		Str("user_id", userID).
+		Str("source", source).
+		Int("imported", len(result.Imported)+len(result.Replaced)).
+		Int("conflicts", len(result.Conflicts)).
+		Msg("Nutrition export imported")
+
+	return h.createJSONResponse(200, result)
+}
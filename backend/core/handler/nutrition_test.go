@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"athlete-forge/nutrition"
+)
+
+// stubFoodSource returns a fixed food for one barcode
+type stubFoodSource struct {
+	barcode string
+	err     error
+}
+
+func (s stubFoodSource) Lookup(ctx context.Context, barcode string) (*nutrition.Food, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if barcode != s.barcode {
+		return nil, nutrition.ErrFoodNotFound
+	}
+	return &nutrition.Food{Barcode: barcode, Name: "Greek Yogurt", Per100g: nutrition.Nutrients{Calories: 97, Protein: 9}}, nil
+}
+
+func TestLambdaHandler_FoodLookup(t *testing.T) {
+	ctx := context.Background()
+	h := NewLambdaHandler(zerolog.Nop(), WithFoodSource(stubFoodSource{barcode: "5000112637922"}))
+
+	tests := []struct {
+		name       string
+		barcode    string
+		wantStatus int
+	}{
+		{name: "known barcode", barcode: "5000112637922", wantStatus: 200},
+		{name: "unknown barcode", barcode: "12345678", wantStatus: 404},
+		{name: "invalid barcode", barcode: "abc", wantStatus: 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/nutrition/foods", "user-1", map[string]string{"barcode": tt.barcode}, ""))
+
+			// Assert
+			if response.StatusCode != tt.wantStatus {
+				t.Fatalf("expected status code %d, got %d: %s", tt.wantStatus, response.StatusCode, response.Body)
+			}
+			if tt.wantStatus == 200 {
+				var food nutrition.Food
+				json.Unmarshal([]byte(response.Body), &food)
+				if food.Name != "Greek Yogurt" {
+					t.Errorf("unexpected food: %+v", food)
+				}
+			}
+		})
+	}
+
+	t.Run("reports an unavailable food database", func(t *testing.T) {
+		// Arrange
+		h := NewLambdaHandler(zerolog.Nop(), WithFoodSource(stubFoodSource{err: errors.New("timeout")}))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/nutrition/foods", "user-1", map[string]string{"barcode": "12345678"}, ""))
+
+		// Assert
+		if response.StatusCode != 502 {
+			t.Errorf("expected status code 502, got %d", response.StatusCode)
+		}
+	})
+}
+
+func TestLambdaHandler_NutritionImport(t *testing.T) {
+	ctx := context.Background()
+	h := newTestHandler()
+	export := "Date,Meal,Calories,Fat (g),Carbohydrates (g),Protein (g)\n2024-03-01,Breakfast,400,10,50,25\n2024-03-01,Dinner,800,30,80,50\n"
+
+	t.Run("imports a MyFitnessPal export", func(t *testing.T) {
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/nutrition/imports/myfitnesspal", "eater", nil, export))
+		list, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/nutrition/days", "eater", map[string]string{"from": "2024-03-01"}, ""))
+
+		// Assert
+		var result nutrition.ImportResult
+		json.Unmarshal([]byte(response.Body), &result)
+		if response.StatusCode != 200 || len(result.Imported) != 1 {
+			t.Fatalf("unexpected response %d: %s", response.StatusCode, response.Body)
+		}
+		var days []nutrition.Day
+		json.Unmarshal([]byte(list.Body), &days)
+		if len(days) != 1 || days[0].Totals.Calories != 1200 || days[0].Source != nutrition.SourceMyFitnessPal {
+			t.Errorf("unexpected days: %s", list.Body)
+		}
+	})
+
+	t.Run("reports a changed day as a conflict", func(t *testing.T) {
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/nutrition/imports/cronometer", "eater", nil, "Date,Energy (kcal)\n2024-03-01,1500\n"))
+
+		// Assert
+		var result nutrition.ImportResult
+		json.Unmarshal([]byte(response.Body), &result)
+		if response.StatusCode != 200 || len(result.Conflicts) != 1 || len(result.Imported) != 0 {
+			t.Errorf("unexpected response %d: %s", response.StatusCode, response.Body)
+		}
+	})
+
+	t.Run("previews an export and imports it with the corrected mapping", func(t *testing.T) {
+		// Arrange
+		export := "Day,Kcal,Protein,Protein target\n2024-04-01,2000,150,160\n"
+		overrides := map[string]string{"map.protein": "Protein target"}
+
+		// Act
+		proposed, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/nutrition/imports/cronometer/preview", "previewer", nil, export))
+		corrected, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/nutrition/imports/cronometer/preview", "previewer", overrides, export))
+		committed, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/nutrition/imports/cronometer", "previewer", overrides, export))
+
+		// Assert
+		var preview nutrition.Preview
+		json.Unmarshal([]byte(proposed.Body), &preview)
+		if proposed.StatusCode != 200 || preview.Recognized || preview.Mapping[nutrition.FieldProtein] != "Protein" {
+			t.Fatalf("unexpected preview %d: %s", proposed.StatusCode, proposed.Body)
+		}
+		json.Unmarshal([]byte(corrected.Body), &preview)
+		if len(preview.Sample) != 1 || preview.Sample[0].Totals.Protein != 160 {
+			t.Errorf("unexpected corrected preview: %s", corrected.Body)
+		}
+		day, err := h.diary.Get(ctx, "previewer", "2024-04-01")
+		if committed.StatusCode != 200 || err != nil || day.Totals.Calories != 2000 || day.Totals.Protein != 160 {
+			t.Errorf("unexpected import %d: %s, %+v", committed.StatusCode, committed.Body, day)
+		}
+	})
+
+	t.Run("rejects overrides naming unknown columns", func(t *testing.T) {
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/nutrition/imports/myfitnesspal/preview", "eater", map[string]string{"map.fiber": "Fibre"}, export))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d: %s", response.StatusCode, response.Body)
+		}
+	})
+
+	t.Run("rejects unknown sources and unrecognized files", func(t *testing.T) {
+		// Act
+		unknown, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/nutrition/imports/loseit", "eater", nil, export))
+		unrecognized, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/nutrition/imports/cronometer", "eater", nil, export))
+
+		// Assert
+		if unknown.StatusCode != 404 {
+			t.Errorf("expected status code 404, got %d", unknown.StatusCode)
+		}
+		if unrecognized.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", unrecognized.StatusCode)
+		}
+	})
+}
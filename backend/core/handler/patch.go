@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime"
+	"strings"
+
+	"athlete-forge/jsonpatch"
+)
+
+// acceptPatch lists the patch formats PATCH routes take, advertised when a
+// request uses another
+const acceptPatch = jsonpatch.MergePatchType + ", " + jsonpatch.JSONPatchType
+
+// applyPatch applies the request's patch to current and decodes the result into
+// into, returning an error response when the patch is in an unsupported format,
+// cannot be applied or leaves fields the resource does not have. The body is a
+// JSON merge patch unless its Content-Type is application/json-patch+json; the
+// caller validates the result
+func (h *LambdaHandler) applyPatch(event *APIGatewayProxyEvent, current, into interface{}) *Response {
+	if event.Body == "" {
+		response := h.createErrorResponse(400, "request body is required")
+		return &response
+	}
+	doc, err := json.Marshal(current)
+	if err != nil {
+		response := h.createErrorResponse(500, "Internal server error")
+		return &response
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(header(event, "Content-Type"))
+	var patched []byte
+	switch strings.ToLower(mediaType) {
+	case "", contentTypeJSON, jsonpatch.MergePatchType:
+		patched, err = jsonpatch.MergePatch(doc, []byte(event.Body))
+	case jsonpatch.JSONPatchType:
+		patched, err = jsonpatch.Apply(doc, []byte(event.Body))
+	default:
+		response := h.createErrorResponse(415, "PATCH takes "+acceptPatch)
+		response.Headers["Accept-Patch"] = acceptPatch
+		return &response
+	}
+	if errors.Is(err, jsonpatch.ErrTestFailed) {
+		response := h.createErrorResponse(409, err.Error())
+		return &response
+	}
+	if err != nil {
+		response := h.createErrorResponse(400, err.Error())
+		return &response
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(into); err != nil {
+		response := h.createErrorResponse(400, "patched document is invalid: "+err.Error())
+		return &response
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"athlete-forge/profile"
+	"athlete-forge/workout"
+)
+
+func TestLambdaHandler_Patch(t *testing.T) {
+	ctx := context.Background()
+	patchEvent := func(path, contentType, body string) map[string]interface{} {
+		event := apiEvent("PATCH", path, "user-1", nil, body)
+		if contentType != "" {
+			event["headers"] = map[string]string{"Content-Type": contentType}
+		}
+		return event
+	}
+	newWorkout := func(t *testing.T, h *LambdaHandler) workout.Workout {
+		t.Helper()
+		response := createWorkout(t, h, "user-1", `{"notes":"Legs","exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100},{"reps":5,"weight":100}]}]}`)
+		var w workout.Workout
+		json.Unmarshal([]byte(response.Body), &w)
+		return w
+	}
+
+	t.Run("merge patches a workout, keeping fields the patch leaves out", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		w := newWorkout(t, h)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, patchEvent("/api/workouts/"+w.ID, "application/merge-patch+json", `{"notes":null,"id":"other"}`))
+
+		// Assert
+		if response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", response.StatusCode, response.Body)
+		}
+		saved, _ := h.workouts.Get(ctx, "user-1", w.ID)
+		if saved.Notes != "" || len(saved.Exercises[0].Sets) != 2 || saved.ID != w.ID {
+			t.Errorf("unexpected workout: %+v", saved)
+		}
+	})
+
+	t.Run("applies a JSON Patch to a workout", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		w := newWorkout(t, h)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, patchEvent("/api/workouts/"+w.ID, "application/json-patch+json",
+			`[{"op":"test","path":"/exercises/0/name","value":"Squat"},{"op":"replace","path":"/exercises/0/sets/1/reps","value":3},{"op":"remove","path":"/exercises/0/sets/0"}]`))
+
+		// Assert
+		if response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", response.StatusCode, response.Body)
+		}
+		saved, _ := h.workouts.Get(ctx, "user-1", w.ID)
+		if sets := saved.Exercises[0].Sets; len(sets) != 1 || sets[0].Reps != 3 {
+			t.Errorf("unexpected sets: %+v", sets)
+		}
+	})
+
+	t.Run("patches a single set", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		w := newWorkout(t, h)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, patchEvent("/api/workouts/"+w.ID+"/exercises/0/sets/1", "", `{"weight":102.5,"rpe":9}`))
+
+		// Assert
+		if response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", response.StatusCode, response.Body)
+		}
+		saved, _ := h.workouts.Get(ctx, "user-1", w.ID)
+		if set := saved.Exercises[0].Sets[1]; set.Weight != 102.5 || set.RPE != 9 || set.Reps != 5 || saved.Exercises[0].Sets[0].Weight != 100 {
+			t.Errorf("unexpected sets: %+v", saved.Exercises[0].Sets)
+		}
+	})
+
+	t.Run("rejects patches that leave an invalid workout", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		w := newWorkout(t, h)
+
+		tests := []struct {
+			name, path, contentType, body string
+			status                        int
+		}{
+			{"invalid result", "/api/workouts/" + w.ID, "", `{"exercises":[{"name":""}]}`, 400},
+			{"status change", "/api/workouts/" + w.ID, "", `{"status":"completed"}`, 400},
+			{"unknown field", "/api/workouts/" + w.ID, "", `{"note":"typo"}`, 400},
+			{"wrong type", "/api/workouts/" + w.ID + "/exercises/0/sets/0", "", `{"reps":"five"}`, 400},
+			{"failed test", "/api/workouts/" + w.ID, "application/json-patch+json", `[{"op":"test","path":"/notes","value":"Arms"}]`, 409},
+			{"unsupported format", "/api/workouts/" + w.ID, "text/plain", `notes=Arms`, 415},
+			{"missing set", "/api/workouts/" + w.ID + "/exercises/0/sets/2", "", `{"reps":3}`, 404},
+			{"missing workout", "/api/workouts/nope", "", `{"notes":"Arms"}`, 404},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// Act
+				response, _ := h.HandleRequest(ctx, patchEvent(tt.path, tt.contentType, tt.body))
+
+				// Assert
+				if response.StatusCode != tt.status {
+					t.Errorf("expected status code %d, got %d: %s", tt.status, response.StatusCode, response.Body)
+				}
+			})
+		}
+		saved, _ := h.workouts.Get(ctx, "user-1", w.ID)
+		if saved.Notes != "Legs" || saved.Status != w.Status {
+			t.Errorf("expected the workout unchanged, got %+v", saved)
+		}
+	})
+
+	t.Run("patches the profile from its defaults", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		response, _ := h.HandleRequest(ctx, patchEvent("/api/profile", "", `{"unit":"lb","healthNotes":"Asthma"}`))
+		invalid, _ := h.HandleRequest(ctx, patchEvent("/api/profile", "", `{"barWeight":-1}`))
+
+		// Assert
+		if response.StatusCode != 200 || invalid.StatusCode != 400 {
+			t.Fatalf("expected status codes 200 and 400, got %d and %d", response.StatusCode, invalid.StatusCode)
+		}
+		p, _ := h.profiles.Get(ctx, "user-1")
+		if p.Unit != profile.UnitPounds || p.HealthNotes != "Asthma" || len(p.Plates) == 0 {
+			t.Errorf("unexpected profile: %+v", p)
+		}
+	})
+}
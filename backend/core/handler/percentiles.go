@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"athlete-forge/consent"
+	"athlete-forge/percentile"
+)
+
+// LiftPercentile compares one of the user's lifts with the population. Cohort
+// is the narrowest cohort with enough lifters to publish, and Percentile is
+// omitted while no cohort has
+type LiftPercentile struct {
+	Lift           string             `json:"lift"`
+	Estimated1RMKg float64            `json:"estimated1rmKg"`
+	Percentile     *int               `json:"percentile,omitempty"`
+	Cohort         *percentile.Cohort `json:"cohort,omitempty"`
+	CohortSize     int                `json:"cohortSize,omitempty"`
+	ComputedAt     *time.Time         `json:"computedAt,omitempty"`
+}
+
+// PercentilesResponse is the user's cohort and how each of their lifts compares
+type PercentilesResponse struct {
+	Cohort percentile.Cohort `json:"cohort"`
+	Lifts  []LiftPercentile  `json:"lifts"`
+}
+
+// handleGetPercentiles ranks the user's best squat, bench press, deadlift and
+// overhead press against the published distributions of their cohort
+func (h *LambdaHandler) handleGetPercentiles(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	now := time.Now().UTC()
+	p, err := h.profiles.Get(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	c, err := h.consents.Get(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	if !c.AllowsLegacy(consent.PurposePopulationComparisons, p.StrengthComparison) {
+		return h.createErrorResponse(403, "strength comparison has not been enabled"), nil
+	}
+	cohort, err := percentile.CohortFor(p, now)
+	if errors.Is(err, percentile.ErrIncomplete) {
+		return h.createErrorResponse(422, err.Error()), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	workouts, err := h.workouts.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	bests := percentile.Bests(workouts, p.Unit, now)
+	lifts := make([]string, 0, len(bests))
+	for lift := range bests {
+		lifts = append(lifts, lift)
+	}
+	sort.Strings(lifts)
+
+	response := PercentilesResponse{Cohort: cohort, Lifts: []LiftPercentile{}}
+	for _, lift := range lifts {
+		result := LiftPercentile{Lift: lift, Estimated1RMKg: bests[lift]}
+		d, ok, err := h.percentiles.Find(ctx, cohort, lift)
+		if err != nil {
+			return Response{}, err
+		}
+		if ok {
+			rank := d.Rank(bests[lift])
+			result.Percentile = &rank
+			result.Cohort = &d.Cohort
+			result.CohortSize = d.Size
+			result.ComputedAt = &d.ComputedAt
+		}
+		response.Lifts = append(response.Lifts, result)
+	}
+	return h.createJSONResponse(200, response)
+}
+
+// runAggregatePercentiles rebuilds the published distributions from the bests
+// of every active user who has opted in and completed their demographics. Only
+// the distributions are stored; a failure for one user is logged and leaves
+// them out of this run
+func (h *LambdaHandler) runAggregatePercentiles(ctx context.Context, now time.Time) (JobResult, error) {
+	result := JobResult{Job: JobAggregatePercentiles}
+	users, err := h.users.List(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	aggregator := percentile.NewAggregator()
+	for _, user := range users {
+		p, err := h.profiles.Get(ctx, user.UserID)
+		if err != nil {
+			result.Failed++
+			h.logger.Error().
+				Err(err).
+				Str("user_id", user.UserID).
+				Msg("Failed to load profile for percentiles")
+			continue
+		}
+		c, err := h.consents.Get(ctx, user.UserID)
+		if err != nil {
+			result.Failed++
+			h.logger.Error().
+				Err(err).
+				Str("user_id", user.UserID).
+				Msg("Failed to load consents for percentiles")
+			continue
+		}
+		if !c.AllowsLegacy(consent.PurposePopulationComparisons, p.StrengthComparison) {
+			continue
+		}
+		cohort, err := percentile.CohortFor(p, now)
+		if err != nil {
+			continue
+		}
+		workouts, err := h.workouts.List(ctx, user.UserID)
+		if err != nil {
+			result.Failed++
+			h.logger.Error().
+				Err(err).
+				Str("user_id", user.UserID).
+				Msg("Failed to load workouts for percentiles")
+			continue
+		}
+		if bests := percentile.Bests(workouts, p.Unit, now); len(bests) > 0 {
+			aggregator.Add(cohort, bests)
+			result.Processed++
+		}
+	}
+	return result, h.percentiles.Replace(ctx, aggregator.Distributions(now))
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"athlete-forge/profile"
+)
+
+func TestLambdaHandler_Percentiles(t *testing.T) {
+	ctx := context.Background()
+	h := NewLambdaHandler(zerolog.Nop())
+	comparing := func(userID string) *profile.Profile {
+		p := profile.Default(userID, profile.UnitKilograms)
+		p.StrengthComparison = true
+		p.Sex, p.Bodyweight, p.BirthYear = profile.SexMale, 82, time.Now().Year()-30
+		return p
+	}
+	squat := func(weight int) string {
+		return fmt.Sprintf(`{"status":"completed","exercises":[{"name":"Squat","sets":[{"reps":1,"weight":%d}]}]}`, weight)
+	}
+
+	t.Run("requires opting in", func(t *testing.T) {
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/stats/percentiles", "stranger", nil, ""))
+
+		// Assert
+		if response.StatusCode != 403 {
+			t.Errorf("expected status code 403, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("requires the demographics that place the user in a cohort", func(t *testing.T) {
+		// Arrange
+		p := comparing("partial")
+		p.BirthYear = 0
+		h.profiles.Save(ctx, p)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/stats/percentiles", "partial", nil, ""))
+
+		// Assert
+		if response.StatusCode != 422 {
+			t.Errorf("expected status code 422, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("ranks lifts once the aggregation job has published the cohort", func(t *testing.T) {
+		// Arrange
+		for i := 0; i < 20; i++ {
+			userID := fmt.Sprintf("lifter-%d", i)
+			h.profiles.Save(ctx, comparing(userID))
+			createWorkout(t, h, userID, squat(100+5*i))
+			h.users.Touch(ctx, userID, time.Now())
+		}
+		createWorkout(t, h, "private", squat(500))
+		h.users.Touch(ctx, "private", time.Now())
+
+		// Act
+		before, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/stats/percentiles", "lifter-10", nil, ""))
+		result, err := h.runAggregatePercentiles(ctx, time.Now().UTC())
+		after, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/stats/percentiles", "lifter-10", nil, ""))
+
+		// Assert
+		if err != nil || result.Processed != 20 {
+			t.Fatalf("expected the 20 opted-in lifters aggregated, got %+v %v", result, err)
+		}
+		var unranked, ranked PercentilesResponse
+		json.Unmarshal([]byte(before.Body), &unranked)
+		json.Unmarshal([]byte(after.Body), &ranked)
+		if len(unranked.Lifts) != 1 || unranked.Lifts[0].Percentile != nil {
+			t.Errorf("expected no rank before aggregation, got %s", before.Body)
+		}
+		if len(ranked.Lifts) != 1 || ranked.Lifts[0].Percentile == nil || *ranked.Lifts[0].Percentile != 53 || ranked.Lifts[0].CohortSize != 20 {
+			t.Errorf("unexpected percentiles: %s", after.Body)
+		}
+		if ranked.Cohort.WeightClass != "83" || ranked.Cohort.AgeBand != "24-39" {
+			t.Errorf("unexpected cohort: %+v", ranked.Cohort)
+		}
+	})
+}
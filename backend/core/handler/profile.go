@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"athlete-forge/profile"
+)
+
+// handleGetProfile returns the authenticated user's profile
+func (h *LambdaHandler) handleGetProfile(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	p, err := h.profiles.Get(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, p)
+}
+
+// handlePutProfile replaces the authenticated user's profile
+func (h *LambdaHandler) handlePutProfile(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	start := time.Now()
+
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var p profile.Profile
+	if err := decodeBody(event, &p); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	p.UserID = userID
+
+	if err := p.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	current, err := h.profiles.Get(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	if err := h.profiles.Save(ctx, &p); err != nil {
+		return Response{}, fmt.Errorf("failed to save profile: %w", err)
+	}
+	if err := h.recordFTPChange(ctx, userID, current.FTP, p.FTP); err != nil {
+		return Response{}, err
+	}
+	if err := h.recordConsentChanges(ctx, userID, current, &p); err != nil {
+		return Response{}, err
+	}
+
+	h.logger.Info().
+		Str("function", "handlePutProfile").
+		Str("user_id", userID).
+		Dur("execution_duration", time.Since(start)).
+		Msg("Profile updated")
+
+	return h.createJSONResponse(200, p)
+}
+
+// handlePatchProfile updates part of the authenticated user's profile with a
+// JSON merge patch or JSON Patch, validating the result as a whole
+func (h *LambdaHandler) handlePatchProfile(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	current, err := h.profiles.Get(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	var p profile.Profile
+	if errResponse := h.applyPatch(event, current, &p); errResponse != nil {
+		return *errResponse, nil
+	}
+	p.UserID = userID
+
+	if err := p.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if err := h.profiles.Save(ctx, &p); err != nil {
+		return Response{}, fmt.Errorf("failed to save profile: %w", err)
+	}
+	if err := h.recordFTPChange(ctx, userID, current.FTP, p.FTP); err != nil {
+		return Response{}, err
+	}
+	if err := h.recordConsentChanges(ctx, userID, current, &p); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, p)
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"athlete-forge/profile"
+)
+
+func TestLambdaHandler_handleProfile(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("requires authentication", func(t *testing.T) {
+		// Act
+		response, err := newTestHandler().HandleRequest(ctx, apiEvent("GET", "/api/profile", "", nil, ""))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if response.StatusCode != 401 {
+			t.Errorf("expected status code 401, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("put then get returns the saved profile", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		body := `{"unit":"lb","barWeight":45,"plates":[{"weight":45,"pairs":3}],"healthNotes":"Type 1 diabetic"}`
+
+		// Act
+		putResponse, err := h.HandleRequest(ctx, apiEvent("PUT", "/api/profile", "user-1", nil, body))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		getResponse, err := h.HandleRequest(ctx, apiEvent("GET", "/api/profile", "user-1", nil, ""))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// Assert
+		if putResponse.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", putResponse.StatusCode, putResponse.Body)
+		}
+		var p profile.Profile
+		if err := json.Unmarshal([]byte(getResponse.Body), &p); err != nil {
+			t.Fatalf("failed to parse profile JSON: %v", err)
+		}
+		if p.UserID != "user-1" || p.Unit != "lb" || len(p.Plates) != 1 || p.HealthNotes != "Type 1 diabetic" {
+			t.Errorf("unexpected profile: %+v", p)
+		}
+	})
+
+	t.Run("rejects invalid profile", func(t *testing.T) {
+		// Act
+		response, err := newTestHandler().HandleRequest(ctx, apiEvent("PUT", "/api/profile", "user-1", nil, `{"unit":"stone"}`))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"athlete-forge/events"
+	"athlete-forge/gym"
+	"athlete-forge/program"
+	"athlete-forge/progression"
+	"athlete-forge/store"
+)
+
+// ProgramResponse is a created program with warnings for exercises left out
+// because the gym lacks their equipment
+type ProgramResponse struct {
+	program.Program
+	Warnings []gym.Warning `json:"warnings"`
+}
+
+// handleListPrograms returns the user's program instances
+func (h *LambdaHandler) handleListPrograms(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	programs, err := h.programs.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, programs)
+}
+
+// handleGetProgram returns a single program instance
+func (h *LambdaHandler) handleGetProgram(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	p, err := h.programs.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Program not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, p)
+}
+
+// handleCreateProgram starts a new program instance with its progression rules
+func (h *LambdaHandler) handleCreateProgram(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var p program.Program
+	if err := decodeBody(event, &p); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	created, errResponse, err := h.startProgram(ctx, userID, &p)
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	h.logger.Info().
+		Str("function", "handleCreateProgram").
+		Str("user_id", userID).
+		Str("program_id", p.ID).
+		Int("warnings", len(created.Warnings)).
+		Msg("Program created")
+
+	return h.createJSONResponse(201, created)
+}
+
+// startProgram saves p as a new program instance owned by userID, leaving out
+// exercises its gym lacks equipment for, and announces it
+func (h *LambdaHandler) startProgram(ctx context.Context, userID string, p *program.Program) (*ProgramResponse, *Response, error) {
+	now := time.Now().UTC()
+	p.ID = ""
+	p.UserID = userID
+	p.SessionsCompleted = 0
+	p.CreatedAt = now
+	p.UpdatedAt = now
+
+	// Exercises the gym lacks equipment for are left out and reported
+	g, errResponse, err := h.selectGym(ctx, userID, p.GymID)
+	if err != nil || errResponse != nil {
+		return nil, errResponse, err
+	}
+	created := &ProgramResponse{Warnings: []gym.Warning{}}
+	if g != nil {
+		p.GymID = g.ID
+		p.Exercises, created.Warnings = feasible(p.Exercises, g)
+		if len(p.Exercises) == 0 && len(created.Warnings) > 0 {
+			response := h.createErrorResponse(400, "no exercises in the program can be done with the gym's equipment")
+			return nil, &response, nil
+		}
+	}
+
+	// Percentage-based exercises take their starting load from the training max
+	for i := range p.Exercises {
+		if p.Exercises[i].Rule.Type == program.RulePercentage {
+			p.Exercises[i].Weight = progression.PercentageWeight(p.Exercises[i].Rule)
+		}
+	}
+
+	if err := p.Validate(); err != nil {
+		response := h.createErrorResponse(400, err.Error())
+		return nil, &response, nil
+	}
+	if err := h.programs.Save(ctx, p); err != nil {
+		return nil, nil, err
+	}
+	h.publish(ctx, events.ProgramAssigned(*p))
+
+	created.Program = *p
+	return created, nil, nil
+}
+
+// ProgramImportResponse is a program started from a template, with what the
+// template needed changing to be imported
+type ProgramImportResponse struct {
+	ProgramResponse
+	Incompatibilities []program.Incompatibility `json:"incompatibilities"`
+}
+
+// handleExportProgram returns a program as a template others can import
+func (h *LambdaHandler) handleExportProgram(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	p, err := h.programs.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Program not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, program.ToTemplate(p))
+}
+
+// handleImportProgram starts a program from a template, for the gym in ?gymId=
+// or the user's default gym. Incompatible templates, such as those of a newer
+// format version, are rejected with 422
+func (h *LambdaHandler) handleImportProgram(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var t program.Template
+	if err := decodeBody(event, &t); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	return h.importTemplate(ctx, userID, t, event.QueryStringParameters["gymId"])
+}
+
+// importTemplate starts a program owned by userID from t, for the gym with
+// gymID or the user's default gym
+func (h *LambdaHandler) importTemplate(ctx context.Context, userID string, t program.Template, gymID string) (Response, error) {
+	p, incompatibilities, err := program.FromTemplate(t)
+	if errors.Is(err, program.ErrIncompatibleTemplate) {
+		return h.createErrorResponse(422, err.Error()), nil
+	}
+	if err != nil {
+		for _, incompatibility := range incompatibilities {
+			err = fmt.Errorf("%w; exercise %q %s", err, incompatibility.Exercise, incompatibility.Message)
+		}
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	p.GymID = gymID
+	created, errResponse, err := h.startProgram(ctx, userID, p)
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	h.logger.Info().
+		Str("function", "importTemplate").
+		Str("user_id", userID).
+		Str("program_id", p.ID).
+		Int("warnings", len(created.Warnings)).
+		Int("incompatibilities", len(incompatibilities)).
+		Msg("Program imported")
+
+	return h.createJSONResponse(201, ProgramImportResponse{ProgramResponse: *created, Incompatibilities: incompatibilities})
+}
+
+// feasible splits prescriptions into those g has equipment for and warnings for the rest
+func feasible(prescriptions []program.Prescription, g *gym.Gym) ([]program.Prescription, []gym.Warning) {
+	names := make([]string, len(prescriptions))
+	for i, prescription := range prescriptions {
+		names[i] = prescription.Exercise
+	}
+	warnings := g.Check(names)
+
+	kept := make([]program.Prescription, 0, len(prescriptions))
+	for _, prescription := range prescriptions {
+		if !warned(warnings, prescription.Exercise) {
+			kept = append(kept, prescription)
+		}
+	}
+	return kept, warnings
+}
+
+func warned(warnings []gym.Warning, exercise string) bool {
+	for _, w := range warnings {
+		if w.Exercise == exercise {
+			return true
+		}
+	}
+	return false
+}
+
+// handlePutSchedule sets the weekly schedule the program's sessions appear at in the calendar feed
+func (h *LambdaHandler) handlePutSchedule(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var schedule program.Schedule
+	if err := decodeBody(event, &schedule); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if err := schedule.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	return h.updateSchedule(ctx, userID, event.PathParameters["id"], &schedule)
+}
+
+// handleDeleteSchedule removes the program's schedule, dropping it from the calendar feed
+func (h *LambdaHandler) handleDeleteSchedule(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	return h.updateSchedule(ctx, userID, event.PathParameters["id"], nil)
+}
+
+func (h *LambdaHandler) updateSchedule(ctx context.Context, userID, programID string, schedule *program.Schedule) (Response, error) {
+	p, err := h.programs.Get(ctx, userID, programID)
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Program not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	p.Schedule = schedule
+	p.UpdatedAt = time.Now().UTC()
+	if err := h.programs.Save(ctx, p); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, p)
+}
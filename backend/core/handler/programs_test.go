@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"athlete-forge/program"
+)
+
+const percentageProgramBody = `{
+	"name": "Wave",
+	"exercises": [
+		{"exercise": "Deadlift", "sets": 3, "reps": 5, "rule": {"type": "percentage", "trainingMax": 200, "percentages": [0.65, 0.75, 0.85], "increment": 5}}
+	]
+}`
+
+// createProgram creates a program through the API and returns it
+func createProgram(t *testing.T, h *LambdaHandler, userID, body string) program.Program {
+	t.Helper()
+	response, err := h.HandleRequest(context.Background(), apiEvent("POST", "/api/programs", userID, nil, body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.StatusCode != 201 {
+		t.Fatalf("expected status code 201, got %d: %s", response.StatusCode, response.Body)
+	}
+	var p program.Program
+	if err := json.Unmarshal([]byte(response.Body), &p); err != nil {
+		t.Fatalf("failed to parse program JSON: %v", err)
+	}
+	return p
+}
+
+func TestLambdaHandler_Programs(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("create computes the starting load for percentage rules", func(t *testing.T) {
+		// Act
+		p := createProgram(t, newTestHandler(), "user-1", percentageProgramBody)
+
+		// Assert
+		if p.ID == "" || p.UserID != "user-1" {
+			t.Errorf("expected ID and owner to be assigned, got %+v", p)
+		}
+		if p.Exercises[0].Weight != 130 {
+			t.Errorf("expected starting weight 130, got %v", p.Exercises[0].Weight)
+		}
+	})
+
+	t.Run("create rejects invalid rules", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("POST", "/api/programs", "user-1", nil,
+			`{"name":"Bad","exercises":[{"exercise":"Squat","sets":3,"reps":5,"rule":{"type":"linear"}}]}`))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("get of unknown program returns 404", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("GET", "/api/programs/missing", "user-1", nil, ""))
+
+		// Assert
+		if response.StatusCode != 404 {
+			t.Errorf("expected status code 404, got %d", response.StatusCode)
+		}
+	})
+}
+
+func TestLambdaHandler_ProgramTemplates(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("an exported program imports for another user", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", linearProgramBody)
+		exported, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/programs/"+p.ID+"/export", "user-1", nil, ""))
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("POST", "/api/programs/import", "user-2", nil, exported.Body))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if exported.StatusCode != 200 {
+			t.Fatalf("expected export status code 200, got %d: %s", exported.StatusCode, exported.Body)
+		}
+		if response.StatusCode != 201 {
+			t.Fatalf("expected status code 201, got %d: %s", response.StatusCode, response.Body)
+		}
+		var imported ProgramImportResponse
+		json.Unmarshal([]byte(response.Body), &imported)
+		if imported.ID == "" || imported.ID == p.ID || imported.UserID != "user-2" || imported.Name != p.Name || len(imported.Exercises) != 1 {
+			t.Errorf("unexpected imported program: %+v", imported.Program)
+		}
+		if imported.Incompatibilities == nil || len(imported.Incompatibilities) != 0 {
+			t.Errorf("expected an empty list of incompatibilities, got %v", imported.Incompatibilities)
+		}
+	})
+
+	t.Run("import reports incompatibilities", func(t *testing.T) {
+		// Arrange
+		body := `{"format":"athlete-forge/program-template","version":1,"name":"Community","exercises":[
+			{"exercise":"Zercher Squat","sets":3,"reps":5,"weight":80,"rule":{"type":"linear","increment":2.5}},
+			{"exercise":"Squat","sets":3,"reps":5,"weight":100,"rule":{"type":"wave"}}
+		]}`
+
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("POST", "/api/programs/import", "user-1", nil, body))
+
+		// Assert
+		if response.StatusCode != 201 {
+			t.Fatalf("expected status code 201, got %d: %s", response.StatusCode, response.Body)
+		}
+		var imported ProgramImportResponse
+		json.Unmarshal([]byte(response.Body), &imported)
+		if len(imported.Exercises) != 1 || len(imported.Incompatibilities) != 2 {
+			t.Errorf("unexpected import: %+v", imported)
+		}
+	})
+
+	t.Run("import rejects newer versions with 422 and unusable templates with 400", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		newer, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/programs/import", "user-1", nil,
+			`{"format":"athlete-forge/program-template","version":2,"name":"Future","exercises":[]}`))
+		unusable, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/programs/import", "user-1", nil,
+			`{"format":"athlete-forge/program-template","version":1,"name":"Bad","exercises":[{"exercise":"Squat","sets":3,"reps":5,"rule":{"type":"wave"}}]}`))
+
+		// Assert
+		if newer.StatusCode != 422 {
+			t.Errorf("expected status code 422, got %d: %s", newer.StatusCode, newer.Body)
+		}
+		if unusable.StatusCode != 400 || !strings.Contains(unusable.Body, "wave") {
+			t.Errorf("expected status code 400 naming the problem, got %d: %s", unusable.StatusCode, unusable.Body)
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"athlete-forge/publicprofile"
+	"athlete-forge/store"
+)
+
+// publicProfilePath is where a handle's public page is served
+const publicProfilePath = "/api/public/users/"
+
+// handleGetPublicProfileSettings returns what the user shows on their public page
+func (h *LambdaHandler) handleGetPublicProfileSettings(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	s, err := h.profilePages.Get(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, s)
+}
+
+// handlePutPublicProfileSettings replaces what the user shows on their public
+// page, dropping the cached page so hiding something takes effect at once. A
+// page can only be enabled once the user has a handle to be found by
+func (h *LambdaHandler) handlePutPublicProfileSettings(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var s publicprofile.Settings
+	if err := decodeBody(event, &s); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if err := s.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	account, err := h.handles.Get(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	if s.Enabled && account.Handle == "" {
+		return h.createErrorResponse(400, "choose a handle before enabling the public profile"), nil
+	}
+
+	s.UserID = userID
+	s.UpdatedAt = time.Now().UTC()
+	if err := h.profilePages.Save(ctx, &s); err != nil {
+		return Response{}, err
+	}
+	if account.Handle != "" {
+		h.invalidatePublicProfiles(ctx, userID, account.Handle)
+	}
+
+	h.logger.Info().
+		Str("function", "handlePutPublicProfileSettings").
+		Str("user_id", userID).
+		Bool("enabled", s.Enabled).
+		Msg("Public profile updated")
+
+	return h.createJSONResponse(200, s)
+}
+
+// invalidatePublicProfiles drops cached copies of the public pages at handles.
+// A failure is only logged, since the pages expire within minutes anyway
+func (h *LambdaHandler) invalidatePublicProfiles(ctx context.Context, userID string, handles ...string) {
+	if h.cdn == nil {
+		return
+	}
+	paths := make([]string, 0, len(handles))
+	for _, name := range handles {
+		paths = append(paths, publicProfilePath+name)
+	}
+	if err := h.cdn.Invalidate(ctx, paths...); err != nil {
+		h.logger.Warn().
+			Err(err).
+			Str("user_id", userID).
+			Msg("Failed to invalidate public profile")
+	}
+}
+
+// handleGetPublicProfile serves a handle's public page to anyone, signed in or
+// not. A handle the user has since replaced redirects to their current one.
+// Unknown handles, private pages and banned users all get the same 404, so the
+// response does not tell which handles exist
+func (h *LambdaHandler) handleGetPublicProfile(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	notFound := h.createErrorResponse(404, "Profile not found")
+	now := time.Now().UTC()
+	resolved, err := h.handles.Resolve(ctx, event.PathParameters["handle"], now)
+	if errors.Is(err, store.ErrNotFound) {
+		return notFound, nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	userID := resolved.UserID
+	s, err := h.profilePages.Get(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	if !s.Enabled {
+		return notFound, nil
+	}
+	standing, err := h.moderation.Standing(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	if standing.Banned {
+		return notFound, nil
+	}
+	if resolved.Redirect {
+		response, err := h.createJSONResponse(301, HandleResponse{Handle: resolved.Handle})
+		if err != nil {
+			return Response{}, err
+		}
+		response.Headers["Location"] = publicProfilePath + resolved.Handle
+		return response, nil
+	}
+
+	// Workouts are read as logged rather than bodyweight-adjusted, which would
+	// reveal the user's bodyweight through their bodyweight exercises
+	workouts, err := h.workouts.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	awards, err := h.achievements.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	p, err := h.profiles.Get(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, publicprofile.Build(resolved.Handle, s, workouts, awards, p.Unit, now))
+}
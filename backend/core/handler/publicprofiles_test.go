@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"athlete-forge/publicprofile"
+)
+
+func TestLambdaHandler_PublicProfiles(t *testing.T) {
+	ctx := context.Background()
+	settings := `{"enabled":true,"displayName":"Lifter","showWorkouts":true,"showRecords":true}`
+
+	t.Run("serves an enabled page to anonymous callers with cache headers", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		createCompletedWorkout(t, h, `{"status":"completed","notes":"private","exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]}]}`)
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/auth/me/handle", "user-1", nil, `{"handle":"Lifter"}`))
+		put, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/profile/public", "user-1", nil, settings))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/public/users/lifter", "", nil, ""))
+
+		// Assert
+		if put.StatusCode != 200 || response.StatusCode != 200 {
+			t.Fatalf("expected status codes 200, got %d and %d: %s", put.StatusCode, response.StatusCode, response.Body)
+		}
+		if response.Headers["Cache-Control"] != profileCacheControl || response.Headers["ETag"] == "" {
+			t.Errorf("expected cache headers, got %v", response.Headers)
+		}
+		var page publicprofile.Page
+		json.Unmarshal([]byte(response.Body), &page)
+		if len(page.RecentWorkouts) != 1 || len(page.Highlights) != 1 || page.Badges != nil {
+			t.Errorf("unexpected page: %s", response.Body)
+		}
+		if strings.Contains(response.Body, "private") || strings.Contains(response.Body, "user-1") {
+			t.Errorf("expected private fields to be left out: %s", response.Body)
+		}
+	})
+
+	t.Run("hides disabled pages, unknown handles and banned users alike", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/auth/me/handle", "user-1", nil, `{"handle":"hidden"}`))
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/auth/me/handle", "user-2", nil, `{"handle":"banned"}`))
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/profile/public", "user-2", nil, `{"enabled":true}`))
+		h.moderation.SetBanned(ctx, "user-2", true, time.Now())
+
+		for _, name := range []string{"hidden", "unknown", "banned"} {
+			// Act
+			response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/public/users/"+name, "", nil, ""))
+
+			// Assert
+			if response.StatusCode != 404 {
+				t.Errorf("%s: expected status code 404, got %d", name, response.StatusCode)
+			}
+		}
+	})
+
+	t.Run("redirects a replaced handle to the current one", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/auth/me/handle", "user-1", nil, `{"handle":"lifter"}`))
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/profile/public", "user-1", nil, settings))
+		account, _ := h.handles.Get(ctx, "user-1")
+		h.handles.Claim(ctx, "user-1", "squatter", account.ChangeableAt.Add(time.Second))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/public/users/lifter", "", nil, ""))
+
+		// Assert
+		if response.StatusCode != 301 || response.Headers["Location"] != "/api/public/users/squatter" {
+			t.Errorf("expected a redirect to the new handle, got %d %v", response.StatusCode, response.Headers)
+		}
+	})
+
+	t.Run("requires a handle to enable the page", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("PUT", "/api/profile/public", "user-1", nil, settings))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,289 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"athlete-forge/auth"
+	"athlete-forge/realtime"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+// WebSocket event types API Gateway sends for a connection's lifecycle
+const (
+	webSocketConnect    = "CONNECT"
+	webSocketDisconnect = "DISCONNECT"
+	webSocketMessage    = "MESSAGE"
+)
+
+// messageRestTimer is the type of messages carrying a workout's rest timer
+const messageRestTimer = "restTimer"
+
+// messageCoaching is the type of messages carrying a coaching message
+const messageCoaching = "coachingMessage"
+
+// webSocketEvent is an event from the API Gateway WebSocket API
+type webSocketEvent struct {
+	Headers               map[string]string `json:"headers"`
+	QueryStringParameters map[string]string `json:"queryStringParameters"`
+	Body                  string            `json:"body"`
+	RequestContext        struct {
+		EventType    string `json:"eventType"`
+		ConnectionID string `json:"connectionId"`
+		DomainName   string `json:"domainName"`
+		Stage        string `json:"stage"`
+	} `json:"requestContext"`
+}
+
+// parseWebSocketEvent returns event when it is from the WebSocket API
+func parseWebSocketEvent(event interface{}) (*webSocketEvent, bool) {
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		return nil, false
+	}
+	var ws webSocketEvent
+	if err := json.Unmarshal(eventBytes, &ws); err != nil || ws.RequestContext.ConnectionID == "" {
+		return nil, false
+	}
+	switch ws.RequestContext.EventType {
+	case webSocketConnect, webSocketDisconnect, webSocketMessage:
+		return &ws, true
+	}
+	return nil, false
+}
+
+// RestTimerRequest is a command to a workout's rest timer: start a countdown of
+// durationSeconds, adjust a running one by seconds, or stop it
+type RestTimerRequest struct {
+	Command         string `json:"command"`
+	Exercise        string `json:"exercise"`
+	DurationSeconds int    `json:"durationSeconds"`
+	Seconds         int    `json:"seconds"`
+}
+
+// webSocketRequest is a message a device sends over its connection
+type webSocketRequest struct {
+	Action    string `json:"action"`
+	WorkoutID string `json:"workoutId"`
+	RestTimerRequest
+}
+
+// RestTimerResponse is a rest timer with the server's clock, so devices can
+// count down in step whatever their own clocks say
+type RestTimerResponse struct {
+	workout.RestTimer
+	ServerTime time.Time `json:"serverTime"`
+}
+
+// handleWebSocket serves the WebSocket API. Connecting takes a session token in
+// ?token= or the Authorization header; messages control rest timers, whose
+// changes are broadcast to all of the user's devices, and failures are posted
+// back to the sending device
+func (h *LambdaHandler) handleWebSocket(ctx context.Context, ws *webSocketEvent) (Response, error) {
+	connectionID := ws.RequestContext.ConnectionID
+	switch ws.RequestContext.EventType {
+	case webSocketConnect:
+		return h.connectWebSocket(ctx, ws)
+	case webSocketDisconnect:
+		if err := h.hub.Disconnect(ctx, connectionID); err != nil {
+			return Response{}, err
+		}
+		return Response{StatusCode: 200}, nil
+	}
+
+	c, err := h.hub.Get(ctx, connectionID)
+	if errors.Is(err, store.ErrNotFound) {
+		return Response{StatusCode: 410}, nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	if _, err := h.authSessions.Get(ctx, c.UserID, c.SessionID, time.Now()); errors.Is(err, store.ErrNotFound) {
+		// The session was signed out; stop sending to the connection
+		return Response{StatusCode: 401}, h.hub.Disconnect(ctx, connectionID)
+	} else if err != nil {
+		return Response{}, err
+	}
+
+	var req webSocketRequest
+	if err := json.Unmarshal([]byte(ws.Body), &req); err != nil || req.Action != messageRestTimer {
+		return h.replyWebSocket(ctx, c, h.createErrorResponse(400, `messages must be JSON with "action": "restTimer"`))
+	}
+	if !auth.HasScope(c.Scopes, auth.ScopeWorkoutsWrite) {
+		return h.replyWebSocket(ctx, c, h.createErrorResponse(403, "Insufficient scope"))
+	}
+	if passive, err := h.isPassive(ctx); err != nil {
+		return Response{}, err
+	} else if passive {
+		return h.replyWebSocket(ctx, c, h.createErrorResponse(503, "This region is on standby; reconnect to the active region"))
+	}
+
+	_, errResponse, err := h.commandRestTimer(ctx, c.UserID, req.WorkoutID, req.RestTimerRequest)
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return h.replyWebSocket(ctx, c, *errResponse)
+	}
+	return Response{StatusCode: 200}, nil
+}
+
+// connectWebSocket records a connection opened with a valid session token
+func (h *LambdaHandler) connectWebSocket(ctx context.Context, ws *webSocketEvent) (Response, error) {
+	event := &APIGatewayProxyEvent{Headers: ws.Headers}
+	if token := ws.QueryStringParameters["token"]; token != "" {
+		event.Headers = map[string]string{"Authorization": "Bearer " + token}
+	}
+	if err := h.authenticateSession(ctx, event); err != nil {
+		return Response{}, err
+	}
+	if event.session == nil {
+		return Response{StatusCode: 401}, nil
+	}
+	scopes := event.session.Scopes()
+	if !auth.HasScope(scopes, auth.ScopeWorkoutsRead) {
+		return Response{StatusCode: 403}, nil
+	}
+
+	c := &realtime.Connection{
+		ID:          ws.RequestContext.ConnectionID,
+		UserID:      event.session.Subject,
+		SessionID:   event.session.SessionID,
+		Scopes:      scopes,
+		Endpoint:    "https://" + ws.RequestContext.DomainName + "/" + ws.RequestContext.Stage,
+		ConnectedAt: time.Now().UTC(),
+	}
+	if err := h.hub.Connect(ctx, c); err != nil {
+		return Response{}, err
+	}
+
+	h.logger.Info().
+		Str("function", "connectWebSocket").
+		Str("user_id", c.UserID).
+		Str("connection_id", c.ID).
+		Msg("WebSocket connected")
+
+	return Response{StatusCode: 200}, nil
+}
+
+// replyWebSocket posts the body of the error response to the connection a
+// message came from, since WebSocket clients never see the response itself
+func (h *LambdaHandler) replyWebSocket(ctx context.Context, c *realtime.Connection, response Response) (Response, error) {
+	if h.poster != nil {
+		data, err := json.Marshal(realtime.Message{Type: "error", Data: json.RawMessage(response.Body)})
+		if err != nil {
+			return Response{}, err
+		}
+		if err := h.poster.Post(ctx, c.Endpoint, c.ID, data); err != nil && !errors.Is(err, realtime.ErrGone) {
+			return Response{}, err
+		}
+	}
+	return Response{StatusCode: response.StatusCode}, nil
+}
+
+// handleGetRestTimer returns a workout's rest timer
+func (h *LambdaHandler) handleGetRestTimer(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	id := event.PathParameters["id"]
+	if _, err := h.workouts.Get(ctx, userID, id); errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Workout not found"), nil
+	} else if err != nil {
+		return Response{}, err
+	}
+	now := time.Now().UTC()
+	t, err := h.workouts.RestTimer(ctx, userID, id, now)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, RestTimerResponse{RestTimer: *t, ServerTime: now})
+}
+
+// handlePutRestTimer starts, adjusts or stops a workout's rest timer and
+// broadcasts it to the user's connected devices
+func (h *LambdaHandler) handlePutRestTimer(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	var req RestTimerRequest
+	if err := decodeBody(event, &req); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	timer, errResponse, err := h.commandRestTimer(ctx, userID, event.PathParameters["id"], req)
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	return h.createJSONResponse(200, timer)
+}
+
+// commandRestTimer applies req to the rest timer of userID's active workout
+// with workoutID, saves it and broadcasts it. A failed broadcast is logged, not
+// returned: the timer is saved and devices read it when they reconnect
+func (h *LambdaHandler) commandRestTimer(ctx context.Context, userID, workoutID string, req RestTimerRequest) (*RestTimerResponse, *Response, error) {
+	w, err := h.workouts.Get(ctx, userID, workoutID)
+	if errors.Is(err, store.ErrNotFound) {
+		response := h.createErrorResponse(404, "Workout not found")
+		return nil, &response, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if w.Status != workout.StatusActive {
+		response := h.createErrorResponse(400, "rest timers are only kept for active workouts")
+		return nil, &response, nil
+	}
+
+	now := time.Now().UTC()
+	t, err := h.workouts.RestTimer(ctx, userID, workoutID, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch req.Command {
+	case workout.TimerStart:
+		err = t.Start(req.Exercise, req.DurationSeconds, now)
+	case workout.TimerAdjust:
+		err = t.Adjust(req.Seconds, now)
+	case workout.TimerStop:
+		t.Stop(now)
+	default:
+		response := h.createErrorResponse(400, "command must be start, adjust or stop")
+		return nil, &response, nil
+	}
+	if err != nil {
+		response := h.createErrorResponse(400, err.Error())
+		return nil, &response, nil
+	}
+	if err := h.workouts.SaveRestTimer(ctx, userID, t); err != nil {
+		return nil, nil, err
+	}
+
+	timer := &RestTimerResponse{RestTimer: *t, ServerTime: now}
+	sent, err := h.hub.Broadcast(ctx, userID, realtime.Message{Type: messageRestTimer, Data: timer})
+	if err != nil {
+		h.logger.Warn().
+			Err(err).
+			Str("user_id", userID).
+			Msg("Failed to broadcast rest timer to every device")
+	}
+
+	h.logger.Info().
+		Str("function", "commandRestTimer").
+		Str("user_id", userID).
+		Str("workout_id", workoutID).
+		Str("command", req.Command).
+		Int("devices", sent).
+		Msg("Rest timer updated")
+
+	return timer, nil, nil
+}
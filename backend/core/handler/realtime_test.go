@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"athlete-forge/realtime"
+	"athlete-forge/workout"
+)
+
+// recordingPoster collects the messages posted to each connection
+type recordingPoster struct {
+	posted map[string][]realtime.Message
+}
+
+func (p *recordingPoster) Post(ctx context.Context, endpoint, connectionID string, data []byte) error {
+	var m realtime.Message
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	if p.posted == nil {
+		p.posted = map[string][]realtime.Message{}
+	}
+	p.posted[connectionID] = append(p.posted[connectionID], m)
+	return nil
+}
+
+// webSocketEventFor builds a WebSocket API event for connectionID
+func webSocketEventFor(eventType, connectionID string, query map[string]string, body string) map[string]interface{} {
+	return map[string]interface{}{
+		"requestContext": map[string]interface{}{
+			"eventType":    eventType,
+			"connectionId": connectionID,
+			"routeKey":     "$default",
+			"domainName":   "ws.example.com",
+			"stage":        "prod",
+		},
+		"queryStringParameters": query,
+		"body":                  body,
+	}
+}
+
+func TestLambdaHandler_RestTimer(t *testing.T) {
+	ctx := context.Background()
+	newHandler := func(poster *recordingPoster) *LambdaHandler {
+		return NewLambdaHandler(zerolog.Nop(), WithAuthProvider(stubProvider{}), WithSessionSecret([]byte("secret")), WithWebSocketPoster(poster))
+	}
+	connect := func(t *testing.T, h *LambdaHandler, connectionID, token string) Response {
+		t.Helper()
+		response, err := h.HandleRequest(ctx, webSocketEventFor("CONNECT", connectionID, map[string]string{"token": token}, ""))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return response
+	}
+	startWorkout := func(t *testing.T, h *LambdaHandler, token string) workout.Workout {
+		t.Helper()
+		response, _ := h.HandleRequest(ctx, withBearer(apiEvent("POST", "/api/workouts", "", nil, `{"exercises":[{"name":"Squat"}]}`), token))
+		if response.StatusCode != 201 {
+			t.Fatalf("failed to create workout %d: %s", response.StatusCode, response.Body)
+		}
+		var w workout.Workout
+		json.Unmarshal([]byte(response.Body), &w)
+		return w
+	}
+
+	t.Run("starting a timer broadcasts it to every connected device", func(t *testing.T) {
+		// Arrange
+		poster := &recordingPoster{}
+		h := newHandler(poster)
+		session := signIn(t, h, "sam")
+		w := startWorkout(t, h, session.Token)
+		connect(t, h, "phone", session.Token)
+		connect(t, h, "watch", session.Token)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, withBearer(apiEvent("PUT", "/api/workouts/"+w.ID+"/rest-timer", "", nil, `{"command":"start","exercise":"Squat","durationSeconds":120}`), session.Token))
+
+		// Assert
+		if response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", response.StatusCode, response.Body)
+		}
+		var timer RestTimerResponse
+		json.Unmarshal([]byte(response.Body), &timer)
+		if timer.Status != workout.TimerRunning || timer.EndsAt == nil || timer.ServerTime.IsZero() {
+			t.Errorf("unexpected timer: %+v", timer)
+		}
+		for _, device := range []string{"phone", "watch"} {
+			if messages := poster.posted[device]; len(messages) != 1 || messages[0].Type != "restTimer" {
+				t.Errorf("unexpected messages to %s: %+v", device, messages)
+			}
+		}
+	})
+
+	t.Run("a device adjusts the timer over its connection", func(t *testing.T) {
+		// Arrange
+		poster := &recordingPoster{}
+		h := newHandler(poster)
+		session := signIn(t, h, "sam")
+		w := startWorkout(t, h, session.Token)
+		connect(t, h, "watch", session.Token)
+		h.HandleRequest(ctx, withBearer(apiEvent("PUT", "/api/workouts/"+w.ID+"/rest-timer", "", nil, `{"command":"start","durationSeconds":90}`), session.Token))
+
+		// Act
+		response, err := h.HandleRequest(ctx, webSocketEventFor("MESSAGE", "watch", nil, `{"action":"restTimer","workoutId":"`+w.ID+`","command":"adjust","seconds":30}`))
+
+		// Assert
+		if err != nil || response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d (%v)", response.StatusCode, err)
+		}
+		got, _ := h.HandleRequest(ctx, withBearer(apiEvent("GET", "/api/workouts/"+w.ID+"/rest-timer", "", nil, ""), session.Token))
+		var timer RestTimerResponse
+		json.Unmarshal([]byte(got.Body), &timer)
+		if timer.DurationSeconds != 120 || timer.Status != workout.TimerRunning {
+			t.Errorf("unexpected timer: %+v", timer)
+		}
+		if messages := poster.posted["watch"]; len(messages) != 2 {
+			t.Errorf("expected both changes to reach the watch, got %+v", messages)
+		}
+	})
+
+	t.Run("failed commands are posted back to the sending device", func(t *testing.T) {
+		// Arrange
+		poster := &recordingPoster{}
+		h := newHandler(poster)
+		session := signIn(t, h, "sam")
+		w := startWorkout(t, h, session.Token)
+		connect(t, h, "watch", session.Token)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, webSocketEventFor("MESSAGE", "watch", nil, `{"action":"restTimer","workoutId":"`+w.ID+`","command":"adjust","seconds":30}`))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+		if messages := poster.posted["watch"]; len(messages) != 1 || messages[0].Type != "error" {
+			t.Errorf("unexpected messages: %+v", messages)
+		}
+	})
+
+	t.Run("connecting requires a valid session token", func(t *testing.T) {
+		// Arrange
+		h := newHandler(&recordingPoster{})
+
+		// Act
+		response := connect(t, h, "phone", "not-a-token")
+
+		// Assert
+		if response.StatusCode != 401 {
+			t.Errorf("expected status code 401, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("signing out closes connections to messages and disconnecting forgets them", func(t *testing.T) {
+		// Arrange
+		poster := &recordingPoster{}
+		h := newHandler(poster)
+		session := signIn(t, h, "sam")
+		w := startWorkout(t, h, session.Token)
+		connect(t, h, "phone", session.Token)
+		connect(t, h, "watch", session.Token)
+		h.HandleRequest(ctx, webSocketEventFor("DISCONNECT", "phone", nil, ""))
+		h.HandleRequest(ctx, withBearer(apiEvent("POST", "/api/auth/logout", "", nil, ""), session.Token))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, webSocketEventFor("MESSAGE", "watch", nil, `{"action":"restTimer","workoutId":"`+w.ID+`","command":"stop"}`))
+
+		// Assert
+		if response.StatusCode != 401 {
+			t.Errorf("expected status code 401, got %d", response.StatusCode)
+		}
+		connections, _ := h.hub.List(ctx, session.User.ID)
+		if len(connections) != 0 {
+			t.Errorf("expected no connections, got %+v", connections)
+		}
+	})
+
+	t.Run("timers are only kept for active workouts", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		created := createWorkout(t, h, "user-1", `{"status":"completed","exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]}]}`)
+		var completed struct {
+			Workout workout.Workout `json:"workout"`
+		}
+		json.Unmarshal([]byte(created.Body), &completed)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/workouts/"+completed.Workout.ID+"/rest-timer", "user-1", nil, `{"command":"start","durationSeconds":60}`))
+		missing, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/workouts/missing/rest-timer", "user-1", nil, ""))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d: %s", response.StatusCode, response.Body)
+		}
+		if missing.StatusCode != 404 {
+			t.Errorf("expected status code 404, got %d", missing.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"athlete-forge/idempotency"
+	"athlete-forge/region"
+	"athlete-forge/store"
+)
+
+// maxReplicationLag is the heartbeat age past which a peer region is reported as
+// degraded; heartbeats are written every minute, so this allows for a few misses
+const maxReplicationLag = 5 * time.Minute
+
+// RegionHealth is the multi-region part of the health check
+type RegionHealth struct {
+	Region      string       `json:"region"`
+	Role        string       `json:"role"`
+	Active      string       `json:"active"`
+	Replication []region.Lag `json:"replication"`
+}
+
+// regionHealth reports this region's role and how far behind replication from
+// each peer is, returning degraded when any peer's heartbeat is too old
+func (h *LambdaHandler) regionHealth(ctx context.Context, now time.Time) (*RegionHealth, bool, error) {
+	active, err := h.region.Active(ctx, now)
+	if err != nil {
+		return nil, false, err
+	}
+	lags, err := h.region.Lags(ctx, now)
+	if err != nil {
+		return nil, false, err
+	}
+
+	health := &RegionHealth{Region: h.region.Name(), Role: region.RolePassive, Active: active, Replication: lags}
+	if active == h.region.Name() {
+		health.Role = region.RoleActive
+	}
+	degraded := false
+	for _, lag := range lags {
+		if lag.LagSeconds > maxReplicationLag.Seconds() {
+			degraded = true
+		}
+	}
+	return health, degraded, nil
+}
+
+// isPassive reports whether this deployment is the passive region of a
+// multi-region pair; single-region deployments are always active
+func (h *LambdaHandler) isPassive(ctx context.Context) (bool, error) {
+	if h.region == nil {
+		return false, nil
+	}
+	role, err := h.region.Role(ctx, time.Now().UTC())
+	if err != nil {
+		return false, err
+	}
+	return role == region.RolePassive, nil
+}
+
+// rejectPassiveWrite refuses writes in the passive region, where they would race
+// the active region's under the global table's last-writer-wins replication.
+// Promoting the region is the one write it accepts
+func (h *LambdaHandler) rejectPassiveWrite(ctx context.Context, event *APIGatewayProxyEvent, r *route) (*Response, error) {
+	if r.reads(event) || r.pattern == "/api/admin/region/promote" {
+		return nil, nil
+	}
+	passive, err := h.isPassive(ctx)
+	if err != nil || !passive {
+		return nil, err
+	}
+
+	response := h.createErrorResponse(503, "This region is on standby; retry shortly")
+	response.Headers["Retry-After"] = "30"
+	return &response, nil
+}
+
+// handleIdempotent runs a write, replaying the stored response when the client
+// repeats its Idempotency-Key, so a retry after a timeout or failover does not
+// apply the write twice. Server errors are not stored, so they can be retried
+func (h *LambdaHandler) handleIdempotent(ctx context.Context, event *APIGatewayProxyEvent, r *route) (Response, error) {
+	key := header(event, "Idempotency-Key")
+	userID := h.userID(event)
+	if key == "" || userID == "" || r.reads(event) {
+		return r.handle(ctx, event)
+	}
+	if len(key) > idempotency.MaxKeyLength {
+		return h.createErrorResponse(400, "Idempotency-Key must be at most 255 characters"), nil
+	}
+
+	now := time.Now().UTC()
+	fingerprint := idempotency.Fingerprint(event.HTTPMethod, event.Path, event.Body)
+	rec, err := h.idempotency.Get(ctx, userID, key, fingerprint, now)
+	switch {
+	case errors.Is(err, idempotency.ErrMismatch):
+		return h.createErrorResponse(422, "Idempotency-Key was already used for a different request"), nil
+	case err == nil:
+		headers := map[string]string{"Idempotent-Replayed": "true"}
+		for name, value := range rec.Headers {
+			headers[name] = value
+		}
+		return Response{StatusCode: rec.StatusCode, Headers: headers, Body: rec.Body}, nil
+	case !errors.Is(err, store.ErrNotFound):
+		return Response{}, err
+	}
+
+	response, err := r.handle(ctx, event)
+	if err != nil || response.StatusCode >= 500 {
+		return response, err
+	}
+	rec = &idempotency.Record{Key: key, Fingerprint: fingerprint, StatusCode: response.StatusCode, Headers: response.Headers, Body: response.Body}
+	if err := h.idempotency.Save(ctx, userID, rec, now); err != nil {
+		h.logger.Warn().
+			Err(err).
+			Str("path", event.Path).
+			Msg("Failed to save idempotency record")
+	}
+	return response, nil
+}
+
+// handlePromoteRegion makes this region the active one, for failing over when the
+// active region is unavailable or failing back once it has caught up
+func (h *LambdaHandler) handlePromoteRegion(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	if h.region == nil {
+		return h.createErrorResponse(400, "multi-region is not configured"), nil
+	}
+
+	active, err := h.region.Promote(ctx, time.Now().UTC())
+	if err != nil {
+		return Response{}, err
+	}
+	h.logger.Warn().
+		Str("function", "handlePromoteRegion").
+		Str("user_id", userID).
+		Str("region", active.Region).
+		Msg("Region promoted to active")
+
+	return h.createJSONResponse(200, active)
+}
+
+// runRegionHeartbeat records this region's heartbeat for its peers' health checks
+func (h *LambdaHandler) runRegionHeartbeat(ctx context.Context) (JobResult, error) {
+	result := JobResult{Job: JobRegionHeartbeat}
+	if h.region == nil {
+		return result, nil
+	}
+	if err := h.region.Beat(ctx, time.Now().UTC()); err != nil {
+		return result, err
+	}
+	result.Processed++
+	return result, nil
+}
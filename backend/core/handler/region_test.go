@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"athlete-forge/region"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+func TestLambdaHandler_Regions(t *testing.T) {
+	ctx := context.Background()
+	workoutBody := `{"status":"active","exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]}]}`
+
+	// pair returns handlers for an active-passive pair sharing one table, as they
+	// would through a global table
+	pair := func() (*LambdaHandler, *LambdaHandler) {
+		s := store.NewMemoryStore()
+		primary := NewLambdaHandler(zerolog.Nop(), WithStore(s), WithRegion("eu-west-2", "eu-west-2"))
+		secondary := NewLambdaHandler(zerolog.Nop(), WithStore(s), WithRegion("eu-west-1", "eu-west-2"))
+		return primary, secondary
+	}
+
+	t.Run("the passive region serves reads but not writes", func(t *testing.T) {
+		// Arrange
+		_, secondary := pair()
+
+		// Act
+		read, _ := secondary.HandleRequest(ctx, apiEvent("GET", "/api/workouts", "user-1", nil, ""))
+		batchRead, _ := secondary.HandleRequest(ctx, apiEvent("POST", "/api/workouts:batchGet", "user-1", nil, `{"ids":["w1"]}`))
+		write, _ := secondary.HandleRequest(ctx, apiEvent("POST", "/api/workouts", "user-1", nil, workoutBody))
+
+		// Assert
+		if read.StatusCode != 200 || batchRead.StatusCode != 200 {
+			t.Errorf("expected reads to succeed, got %d and %d", read.StatusCode, batchRead.StatusCode)
+		}
+		if write.StatusCode != 503 || write.Headers["Retry-After"] == "" {
+			t.Errorf("expected writes to be refused, got %d: %s", write.StatusCode, write.Body)
+		}
+	})
+
+	t.Run("a promoted region accepts writes", func(t *testing.T) {
+		// Arrange
+		primary, secondary := pair()
+		admin := cognitoEvent("POST", "/api/admin/region/promote", "ops", map[string]interface{}{"cognito:groups": "admin"})
+
+		// Act
+		promoted, _ := secondary.HandleRequest(ctx, admin)
+		write, _ := secondary.HandleRequest(ctx, apiEvent("POST", "/api/workouts", "user-1", nil, workoutBody))
+
+		// Assert
+		if promoted.StatusCode != 200 || write.StatusCode != 201 {
+			t.Errorf("expected promotion and write to succeed, got %d and %d: %s", promoted.StatusCode, write.StatusCode, write.Body)
+		}
+		role, _ := primary.region.Role(ctx, time.Now().Add(time.Minute))
+		if role != region.RolePassive {
+			t.Errorf("expected the old primary to become passive, got %s", role)
+		}
+	})
+
+	t.Run("scheduled jobs only run in the active region", func(t *testing.T) {
+		// Arrange
+		primary, secondary := pair()
+		createWorkout(t, primary, "user-1", `{"status":"completed","exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]}]}`)
+
+		// Act
+		skipped, _ := secondary.HandleRequest(ctx, map[string]interface{}{"job": JobWeeklyReports})
+		ran, _ := primary.HandleRequest(ctx, map[string]interface{}{"job": JobWeeklyReports})
+
+		// Assert
+		var skippedResult, ranResult JobResult
+		json.Unmarshal([]byte(skipped.Body), &skippedResult)
+		json.Unmarshal([]byte(ran.Body), &ranResult)
+		if skippedResult.Processed != 0 || ranResult.Processed != 1 {
+			t.Errorf("expected only the active region to run, got %+v and %+v", skippedResult, ranResult)
+		}
+	})
+
+	t.Run("health reports role and replication lag", func(t *testing.T) {
+		// Arrange
+		primary, secondary := pair()
+		primary.HandleRequest(ctx, map[string]interface{}{"job": JobRegionHeartbeat})
+
+		// Act
+		healthy, _ := secondary.HandleRequest(ctx, apiEvent("GET", "/api/health", "", nil, ""))
+		primary.region.Beat(ctx, time.Now().Add(-10*time.Minute))
+		behind, _ := secondary.HandleRequest(ctx, apiEvent("GET", "/api/health", "", nil, ""))
+
+		// Assert
+		var got HealthCheckResponse
+		json.Unmarshal([]byte(healthy.Body), &got)
+		if healthy.StatusCode != 200 || got.Status != "ok" || got.Region == nil || got.Region.Role != region.RolePassive || got.Region.Active != "eu-west-2" {
+			t.Fatalf("unexpected health: %s", healthy.Body)
+		}
+		if len(got.Region.Replication) != 1 || got.Region.Replication[0].Region != "eu-west-2" || got.Region.Replication[0].LagSeconds > 5 {
+			t.Errorf("unexpected replication: %+v", got.Region.Replication)
+		}
+		json.Unmarshal([]byte(behind.Body), &got)
+		if behind.StatusCode != 200 || got.Status != "degraded" {
+			t.Errorf("expected degraded health, got %d: %s", behind.StatusCode, behind.Body)
+		}
+	})
+}
+
+func TestLambdaHandler_IdempotentWrites(t *testing.T) {
+	ctx := context.Background()
+	body := `{"status":"active","exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]}]}`
+	withKey := func(event map[string]interface{}, key string) map[string]interface{} {
+		event["headers"] = map[string]string{"Idempotency-Key": key}
+		return event
+	}
+
+	t.Run("replays the response to a repeated key", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		first, _ := h.HandleRequest(ctx, withKey(apiEvent("POST", "/api/workouts", "user-1", nil, body), "k1"))
+
+		// Act
+		retry, _ := h.HandleRequest(ctx, withKey(apiEvent("POST", "/api/workouts", "user-1", nil, body), "k1"))
+
+		// Assert
+		if retry.StatusCode != 201 || retry.Body != first.Body || retry.Headers["Idempotent-Replayed"] != "true" {
+			t.Errorf("expected the first response to be replayed, got %d: %s", retry.StatusCode, retry.Body)
+		}
+		workouts, _ := h.workouts.List(ctx, "user-1")
+		if len(workouts) != 1 {
+			t.Errorf("expected one workout, got %d", len(workouts))
+		}
+	})
+
+	t.Run("rejects a key reused for a different request", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		h.HandleRequest(ctx, withKey(apiEvent("POST", "/api/workouts", "user-1", nil, body), "k1"))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, withKey(apiEvent("POST", "/api/workouts", "user-1", nil, `{"status":"active","exercises":[]}`), "k1"))
+
+		// Assert
+		if response.StatusCode != 422 {
+			t.Errorf("expected status code 422, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("keys are scoped to the user", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		h.HandleRequest(ctx, withKey(apiEvent("POST", "/api/workouts", "user-1", nil, body), "k1"))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, withKey(apiEvent("POST", "/api/workouts", "user-2", nil, body), "k1"))
+
+		// Assert
+		var w workout.Workout
+		json.Unmarshal([]byte(response.Body), &w)
+		if response.StatusCode != 201 || w.UserID != "user-2" || response.Headers["Idempotent-Replayed"] != "" {
+			t.Errorf("expected a new workout for user-2, got %d: %s", response.StatusCode, response.Body)
+		}
+	})
+}
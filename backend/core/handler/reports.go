@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"athlete-forge/dailylog"
+	"athlete-forge/jobs"
+	"athlete-forge/report"
+	"athlete-forge/store"
+	"athlete-forge/summary"
+	"athlete-forge/workout"
+)
+
+// handleWeeklyReport returns the stored report for the week containing ?week=
+// (default last week), compiling and storing it first if the job has not run
+// yet. The current week is still open, so its report is compiled for each
+// request without being stored; future weeks get 400. Weeks start on the first
+// day of the user's locale
+func (h *LambdaHandler) handleWeeklyReport(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	now := time.Now().UTC()
+	day := now.AddDate(0, 0, -7)
+	if raw := event.QueryStringParameters["week"]; raw != "" {
+		parsed, err := time.Parse(dailylog.DateLayout, raw)
+		if err != nil {
+			return h.createErrorResponse(400, "week must be a date in YYYY-MM-DD format"), nil
+		}
+		day = parsed
+	}
+	c, err := h.conventions(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	weekStart := c.WeekStart(day)
+	currentWeek := c.WeekStart(now)
+	if weekStart.After(currentWeek) {
+		return h.createErrorResponse(400, "week must not be in the future"), nil
+	}
+	if weekStart.Equal(currentWeek) {
+		w, err := h.buildWeeklyReport(ctx, userID, weekStart)
+		if err != nil {
+			return Response{}, err
+		}
+		return h.createJSONResponse(200, w)
+	}
+
+	w, err := h.reports.GetWeekly(ctx, userID, weekStart.Format(dailylog.DateLayout))
+	if errors.Is(err, store.ErrNotFound) {
+		w, err = h.compileWeeklyReport(ctx, userID, weekStart)
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, w)
+}
+
+// compileWeeklyReport builds and stores userID's report for the week beginning weekStart
+func (h *LambdaHandler) compileWeeklyReport(ctx context.Context, userID string, weekStart time.Time) (*report.Weekly, error) {
+	w, err := h.buildWeeklyReport(ctx, userID, weekStart)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.reports.SaveWeekly(ctx, w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// buildWeeklyReport builds userID's report for the week beginning weekStart
+func (h *LambdaHandler) buildWeeklyReport(ctx context.Context, userID string, weekStart time.Time) (*report.Weekly, error) {
+	in, err := h.summaryInput(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	w := report.BuildWeekly(userID, weekStart, in, time.Now())
+	return &w, nil
+}
+
+// handleRecomputeStats queues rebuilding every stored weekly report, for use after
+// history has been edited or imported
+func (h *LambdaHandler) handleRecomputeStats(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	job := jobs.New(userID, JobRecomputeStats, time.Now().UTC())
+	if err := h.enqueue(ctx, job, JobEvent{Job: JobRecomputeStats, UserID: userID}); err != nil {
+		return Response{}, err
+	}
+
+	// Jobs run in-process locally, so the job may already be finished
+	current, err := h.jobs.Get(ctx, userID, job.ID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(202, current)
+}
+
+// runRecomputeStats rebuilds userID's weekly reports from the week of their first
+// workout or activity to last week in one go
+func (h *LambdaHandler) runRecomputeStats(ctx context.Context, userID string, now time.Time, track progress) (JobResult, error) {
+	state := TaskState{Job: JobRecomputeStats, UserID: userID, StartedAt: now}
+	err := h.stepRecomputeStats(ctx, &state, func() bool { return true }, track)
+	return JobResult{Job: JobRecomputeStats, Processed: state.Processed}, err
+}
+
+// stepRecomputeStats rebuilds weekly reports from the week at state.Cursor until
+// more stops it; weeks end at the week before state.StartedAt, so every step
+// agrees on the total
+func (h *LambdaHandler) stepRecomputeStats(ctx context.Context, state *TaskState, more func() bool, track progress) error {
+	in, err := h.summaryInput(ctx, state.UserID)
+	if err != nil {
+		return err
+	}
+
+	var first time.Time
+	for _, w := range in.Workouts {
+		if at := summary.CompletedAt(w); w.Status == workout.StatusCompleted && (first.IsZero() || at.Before(first)) {
+			first = at
+		}
+	}
+	for _, a := range in.Activities {
+		if first.IsZero() || a.StartTime.Before(first) {
+			first = a.StartTime
+		}
+	}
+
+	c, err := h.conventions(ctx, state.UserID)
+	if err != nil {
+		return err
+	}
+	var weeks []time.Time
+	if !first.IsZero() {
+		lastWeek := c.WeekStart(state.StartedAt).AddDate(0, 0, -7)
+		for week := c.WeekStart(first); !week.After(lastWeek); week = week.AddDate(0, 0, 7) {
+			weeks = append(weeks, week)
+		}
+	}
+
+	state.Total = len(weeks)
+	track(ctx, state.Cursor, state.Total)
+	for state.Cursor < len(weeks) && more() {
+		w := report.BuildWeekly(state.UserID, weeks[state.Cursor], in, state.StartedAt)
+		if err := h.reports.SaveWeekly(ctx, &w); err != nil {
+			return err
+		}
+		state.Cursor++
+		state.Processed++
+		track(ctx, state.Cursor, state.Total)
+	}
+	state.Done = state.Cursor >= len(weeks)
+	return nil
+}
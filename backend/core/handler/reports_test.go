@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"athlete-forge/report"
+	"athlete-forge/store"
+	"athlete-forge/summary"
+)
+
+func TestLambdaHandler_WeeklyReport(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("compiles the open week without storing it", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		createWorkout(t, h, "user-1", `{"status":"completed","exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]}]}`)
+		thisWeek := summary.WeekStart(time.Now()).Format("2006-01-02")
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/reports/weekly", "user-1", map[string]string{"week": thisWeek}, ""))
+
+		// Assert
+		var w report.Weekly
+		json.Unmarshal([]byte(response.Body), &w)
+		if response.StatusCode != 200 || w.WeekStart != thisWeek || w.Summary.Workouts != 1 || w.Streak != 1 {
+			t.Errorf("unexpected response %d: %s", response.StatusCode, response.Body)
+		}
+		if _, err := h.reports.GetWeekly(ctx, "user-1", thisWeek); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("expected no stored report, got %v", err)
+		}
+	})
+
+	t.Run("compiles and stores a finished week", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		lastWeek := summary.WeekStart(time.Now()).AddDate(0, 0, -7).Format("2006-01-02")
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/reports/weekly", "user-1", map[string]string{"week": lastWeek}, ""))
+
+		// Assert
+		if response.StatusCode != 200 {
+			t.Errorf("unexpected response %d: %s", response.StatusCode, response.Body)
+		}
+		if _, err := h.reports.GetWeekly(ctx, "user-1", lastWeek); err != nil {
+			t.Errorf("expected stored report, got %v", err)
+		}
+	})
+
+	t.Run("rejects future weeks", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		nextWeek := summary.WeekStart(time.Now()).AddDate(0, 0, 7).Format("2006-01-02")
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/reports/weekly", "user-1", map[string]string{"week": nextWeek}, ""))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d: %s", response.StatusCode, response.Body)
+		}
+		if _, err := h.reports.GetWeekly(ctx, "user-1", nextWeek); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("expected no stored report, got %v", err)
+		}
+	})
+
+	t.Run("starts weeks on the first day of the profile's locale", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/profile", "user-1", nil, `{"unit":"lb","barWeight":45,"plates":[],"locale":"en-US"}`))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/reports/weekly", "user-1", map[string]string{"week": "2024-03-20"}, ""))
+
+		// Assert
+		var w report.Weekly
+		json.Unmarshal([]byte(response.Body), &w)
+		if response.StatusCode != 200 || w.WeekStart != "2024-03-17" {
+			t.Errorf("expected the week starting Sunday 2024-03-17, got %d: %s", response.StatusCode, response.Body)
+		}
+	})
+
+	t.Run("requires authentication", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("GET", "/api/reports/weekly", "", nil, ""))
+
+		// Assert
+		if response.StatusCode != 401 {
+			t.Errorf("expected status code 401, got %d", response.StatusCode)
+		}
+	})
+}
+
+func TestLambdaHandler_Jobs(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("weekly reports job compiles last week for active users", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		createWorkout(t, h, "user-1", `{"status":"completed","exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]}]}`)
+		createActivity(t, h, "user-2", runActivityBody)
+
+		// Act
+		response, err := h.HandleRequest(ctx, map[string]interface{}{"job": JobWeeklyReports})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var result JobResult
+		json.Unmarshal([]byte(response.Body), &result)
+		if result.Processed != 2 || result.Failed != 0 {
+			t.Errorf("unexpected result: %+v", result)
+		}
+		lastWeek := summary.WeekStart(time.Now()).AddDate(0, 0, -7).Format("2006-01-02")
+		if _, err := h.reports.GetWeekly(ctx, "user-2", lastWeek); err != nil {
+			t.Errorf("expected stored report for user-2, got %v", err)
+		}
+	})
+
+	t.Run("rejects unknown jobs", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, map[string]interface{}{"job": "reticulate-splines"})
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"regexp"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// clientRequestIDHeader carries the ID the mobile app gives each request, echoed
+// in the response so the app's and the service's logs can be matched up
+const clientRequestIDHeader = "X-Client-Request-Id"
+
+// validClientRequestID admits UUIDs, ULIDs and similar IDs but nothing that could
+// forge log fields or bloat every log line
+var validClientRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+// clientRequestIDKey is the context key of the client request ID
+type clientRequestIDKey struct{}
+
+// withClientRequestID returns ctx carrying id, so work the request triggers,
+// such as events and background jobs, can carry it on
+func withClientRequestID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, clientRequestIDKey{}, id)
+}
+
+// clientRequestID returns the client request ID ctx carries, if any
+func clientRequestID(ctx context.Context) string {
+	id, _ := ctx.Value(clientRequestIDKey{}).(string)
+	return id
+}
+
+// requestScope holds the client request ID of the request or job being served
+// and adds it to every log event as a zerolog hook. It is per process, which
+// suits Lambda serving one request at a time per instance
+type requestScope struct {
+	mu sync.Mutex
+	id string
+}
+
+// begin sets the ID for the rest of the request, returning the function that
+// restores the previous one, since jobs without a queue run inside the request
+// that dispatched them
+func (s *requestScope) begin(id string) func() {
+	s.mu.Lock()
+	previous := s.id
+	s.id = id
+	s.mu.Unlock()
+	return func() { s.begin(previous) }
+}
+
+func (s *requestScope) Run(e *zerolog.Event, level zerolog.Level, msg string) {
+	s.mu.Lock()
+	id := s.id
+	s.mu.Unlock()
+	if id != "" {
+		e.Str("client_request_id", id)
+	}
+}
+
+// clientRequestIDOf returns the request's client request ID, or a 400 response
+// when it is malformed
+func (h *LambdaHandler) clientRequestIDOf(event *APIGatewayProxyEvent) (string, *Response) {
+	id := header(event, clientRequestIDHeader)
+	if id == "" || validClientRequestID.MatchString(id) {
+		return id, nil
+	}
+	response := h.createErrorResponse(400, clientRequestIDHeader+" must be 1 to 64 letters, digits, '.', '_', ':' or '-'")
+	return "", &response
+}
+
+// echoClientRequestID returns response with id in its headers, readable by
+// browsers as well as the app
+func echoClientRequestID(response Response, id string) Response {
+	if id == "" {
+		return response
+	}
+	headers := make(map[string]string, len(response.Headers)+2)
+	for k, v := range response.Headers {
+		headers[k] = v
+	}
+	headers[clientRequestIDHeader] = id
+	headers["Access-Control-Expose-Headers"] = clientRequestIDHeader
+	response.Headers = headers
+	return response
+}
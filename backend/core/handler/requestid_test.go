@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestLambdaHandler_ClientRequestID(t *testing.T) {
+	ctx := context.Background()
+	withRequestID := func(event map[string]interface{}, id string) map[string]interface{} {
+		event["headers"] = map[string]string{"x-client-request-id": id}
+		return event
+	}
+
+	t.Run("echoes the ID and adds it to the request's logs", func(t *testing.T) {
+		// Arrange
+		var logs bytes.Buffer
+		h := NewLambdaHandler(zerolog.New(&logs))
+
+		// Act
+		response, err := h.HandleRequest(ctx, withRequestID(apiEvent("GET", "/api/workouts", "user-1", nil, ""), "9b2f6c1e-4d7a-4e0b-8c3f-1a5d2e7b9f04"))
+		tagged := logs.String()
+		logs.Reset()
+		h.HandleRequest(ctx, apiEvent("GET", "/api/workouts", "user-1", nil, ""))
+
+		// Assert
+		if err != nil || response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d, %v", response.StatusCode, err)
+		}
+		if response.Headers["X-Client-Request-Id"] != "9b2f6c1e-4d7a-4e0b-8c3f-1a5d2e7b9f04" || response.Headers["Access-Control-Expose-Headers"] != "X-Client-Request-Id" {
+			t.Errorf("expected the ID echoed, got %v", response.Headers)
+		}
+		if !strings.Contains(tagged, `"client_request_id":"9b2f6c1e-4d7a-4e0b-8c3f-1a5d2e7b9f04","message":"Lambda function execution completed"`) {
+			t.Errorf("expected the request's logs tagged, got %s", tagged)
+		}
+		if strings.Contains(logs.String(), "client_request_id") {
+			t.Errorf("expected the next request's logs untagged, got %s", logs.String())
+		}
+	})
+
+	t.Run("rejects malformed IDs", func(t *testing.T) {
+		for _, id := range []string{strings.Repeat("a", 65), `abc","admin":true`, "with space"} {
+			// Act
+			response, _ := newTestHandler().HandleRequest(ctx, withRequestID(apiEvent("GET", "/api/workouts", "user-1", nil, ""), id))
+
+			// Assert
+			if response.StatusCode != 400 {
+				t.Errorf("expected status code 400 for %q, got %d", id, response.StatusCode)
+			}
+		}
+	})
+
+	t.Run("tags the events and background jobs the request causes", func(t *testing.T) {
+		// Arrange
+		publisher := &recordingPublisher{}
+		dispatcher := &recordingDispatcher{}
+		h := NewLambdaHandler(zerolog.Nop(), WithEventPublisher(publisher), WithDispatcher(dispatcher))
+
+		// Act
+		h.HandleRequest(ctx, withRequestID(apiEvent("POST", "/api/workouts", "user-1", nil, `{"status":"completed","exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]}]}`), "sync-42"))
+		h.HandleRequest(ctx, withRequestID(apiEvent("POST", "/api/stats/recompute", "user-1", nil, ""), "sync-43"))
+
+		// Assert
+		if len(publisher.events) == 0 || publisher.events[0].ClientRequestID != "sync-42" {
+			t.Errorf("expected events tagged sync-42, got %+v", publisher.events)
+		}
+		if len(dispatcher.events) != 2 || dispatcher.events[0].(JobEvent).ClientRequestID != "sync-42" || dispatcher.events[1].(JobEvent).ClientRequestID != "sync-43" {
+			t.Errorf("expected jobs tagged sync-42 and sync-43, got %+v", dispatcher.events)
+		}
+	})
+}
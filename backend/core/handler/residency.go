@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"athlete-forge/auth"
+	"athlete-forge/residency"
+	"athlete-forge/store"
+)
+
+// DataRegionResponse is the region a user's data is kept in and the regions it
+// could be kept in
+type DataRegionResponse struct {
+	Region  string   `json:"region"`
+	Regions []string `json:"regions"`
+}
+
+// DataRegionRequest is the body for pinning a user's data to a region
+type DataRegionRequest struct {
+	Region string `json:"region"`
+}
+
+// handleGetDataRegion returns the region the user's data is kept in
+func (h *LambdaHandler) handleGetDataRegion(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	if h.residency == nil {
+		return h.createErrorResponse(404, "Data residency is not configured"), nil
+	}
+
+	region, err := h.residency.Region(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, DataRegionResponse{Region: region, Regions: h.residency.Regions()})
+}
+
+// handlePutDataRegion pins the user's data to a region, moving their account
+// there. It is chosen straight after sign-up, since once the user has stored
+// anything else their data stays where it is
+func (h *LambdaHandler) handlePutDataRegion(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	if h.residency == nil {
+		return h.createErrorResponse(404, "Data residency is not configured"), nil
+	}
+
+	var req DataRegionRequest
+	if err := decodeBody(event, &req); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	// The account's identity and email lookups move with it
+	user, err := h.accounts.Get(ctx, userID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return Response{}, err
+	}
+	var linked []store.Key
+	if user != nil {
+		if err := h.accounts.Relink(ctx, user); err != nil {
+			return Response{}, err
+		}
+		linked = auth.LinkKeys(user)
+	}
+	err = h.residency.Pin(ctx, userID, req.Region, time.Now().UTC(), linked, auth.AccountSKPrefixes...)
+	if errors.Is(err, residency.ErrUnknownRegion) {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if errors.Is(err, residency.ErrHasData) {
+		return h.createErrorResponse(409, err.Error()), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	// The account shows its region; users who signed up through Cognito have none
+	if user != nil && user.Region != req.Region {
+		user.Region = req.Region
+		if err := h.accounts.Save(ctx, user); err != nil {
+			return Response{}, err
+		}
+	}
+
+	h.logger.Info().
+		Str("function", "handlePutDataRegion").
+		Str("user_id", userID).
+		Str("region", req.Region).
+		Msg("Data region pinned")
+
+	return h.createJSONResponse(200, DataRegionResponse{Region: req.Region, Regions: h.residency.Regions()})
+}
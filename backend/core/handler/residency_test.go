@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"athlete-forge/residency"
+	"athlete-forge/sessionindex"
+	"athlete-forge/share"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+func TestLambdaHandler_DataRegion(t *testing.T) {
+	ctx := context.Background()
+	workoutBody := `{"status":"active","exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]}]}`
+
+	// resident returns a handler keeping data in a US home table or an EU table
+	resident := func(t *testing.T) (*LambdaHandler, *store.MemoryStore, *store.MemoryStore) {
+		t.Helper()
+		us, eu := store.NewMemoryStore(), store.NewMemoryStore()
+		router, err := residency.NewRouter("us-east-1", map[string]store.Store{"us-east-1": us, "eu-west-1": eu})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return NewLambdaHandler(zerolog.Nop(), WithResidency(router)), us, eu
+	}
+
+	t.Run("keeps a pinned user's data in their region", func(t *testing.T) {
+		// Arrange
+		h, us, eu := resident(t)
+
+		// Act
+		pinned, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/auth/me/region", "user-1", nil, `{"region":"eu-west-1"}`))
+		created, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/workouts", "user-1", nil, workoutBody))
+		got, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/auth/me/region", "user-1", nil, ""))
+
+		// Assert
+		if pinned.StatusCode != 200 || created.StatusCode != 201 {
+			t.Fatalf("expected 200 and 201, got %d and %d: %s", pinned.StatusCode, created.StatusCode, created.Body)
+		}
+		var region DataRegionResponse
+		json.Unmarshal([]byte(got.Body), &region)
+		if region.Region != "eu-west-1" || len(region.Regions) != 2 {
+			t.Errorf("expected eu-west-1 of 2 regions, got %+v", region)
+		}
+		if items, _ := eu.Query(ctx, store.UserPK("user-1"), ""); len(items) == 0 {
+			t.Error("expected the workout in the EU table")
+		}
+		if items, _ := us.Query(ctx, store.UserPK("user-1"), ""); len(items) != 0 {
+			t.Errorf("expected nothing in the home table, got %d items", len(items))
+		}
+	})
+
+	t.Run("keeps a pinned user's shares and index entries in their region", func(t *testing.T) {
+		// Arrange
+		h, us, eu := resident(t)
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/auth/me/region", "user-1", nil, `{"region":"eu-west-1"}`))
+		var w workout.Workout
+		json.Unmarshal([]byte(createWorkout(t, h, "user-1", workoutBody).Body), &w)
+		h.users.Touch(ctx, "user-1", time.Now())
+		h.users.Touch(ctx, "user-2", time.Now())
+
+		// Act
+		s := createShare(t, h, "user-1", fmt.Sprintf(`{"workoutId":%q}`, w.ID))
+		redeemed, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/shares/"+s.Code+"/redeem", "user-2", nil, ""))
+
+		// Assert
+		if redeemed.StatusCode != 201 {
+			t.Fatalf("expected status code 201, got %d: %s", redeemed.StatusCode, redeemed.Body)
+		}
+		if items, _ := us.Query(ctx, share.PKPrefix+s.Code, ""); len(items) != 0 {
+			t.Errorf("expected no share in the home table, got %+v", items)
+		}
+		if items, _ := us.Query(ctx, "USERS", store.UserPK("user-1")); len(items) != 0 {
+			t.Errorf("expected the pinned user out of the home index, got %+v", items)
+		}
+		if items, _ := eu.Query(ctx, "USERS", store.UserPK("user-1")); len(items) != 1 {
+			t.Errorf("expected the pinned user in the EU index, got %+v", items)
+		}
+		if users, _ := h.users.List(ctx); len(users) != 2 {
+			t.Errorf("expected both users listed across regions, got %+v", users)
+		}
+		sessions := sessionindex.PKPrefix + "#" + w.StartedAt.UTC().Format("2006-01-02T15")
+		if items, _ := us.Query(ctx, sessions, ""); len(items) != 0 {
+			t.Errorf("expected the pinned user's session out of the home index, got %+v", items)
+		}
+		if open, _ := h.openSessions.StartedBefore(ctx, w.StartedAt, time.Now().Add(time.Hour)); len(open) != 1 {
+			t.Errorf("expected the session found in the EU index, got %+v", open)
+		}
+	})
+
+	t.Run("leaves pinned users out of the warehouse export", func(t *testing.T) {
+		// Arrange
+		h, _, _ := resident(t)
+		now := time.Now().UTC()
+		completed := now.Add(-time.Hour)
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/auth/me/region", "user-1", nil, `{"region":"eu-west-1"}`))
+		for _, userID := range []string{"user-1", "user-2"} {
+			h.users.Touch(ctx, userID, now)
+			h.workouts.Save(ctx, &workout.Workout{UserID: userID, Status: workout.StatusCompleted, StartedAt: completed.Add(-time.Hour), CompletedAt: &completed})
+		}
+
+		// Act
+		result, err := h.runExportWarehouse(ctx, now)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Skipped != 1 || result.Processed != 1 {
+			t.Errorf("expected the pinned user skipped and one workout exported, got %+v", result)
+		}
+	})
+
+	t.Run("refuses to move a user with data", func(t *testing.T) {
+		// Arrange
+		h, _, _ := resident(t)
+		h.HandleRequest(ctx, apiEvent("POST", "/api/workouts", "user-1", nil, workoutBody))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/auth/me/region", "user-1", nil, `{"region":"eu-west-1"}`))
+
+		// Assert
+		if response.StatusCode != 409 {
+			t.Errorf("expected 409, got %d: %s", response.StatusCode, response.Body)
+		}
+	})
+
+	t.Run("rejects unknown regions", func(t *testing.T) {
+		// Arrange
+		h, _, _ := resident(t)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/auth/me/region", "user-1", nil, `{"region":"mars-1"}`))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected 400, got %d: %s", response.StatusCode, response.Body)
+		}
+	})
+
+	t.Run("is not found without residency configured", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/auth/me/region", "user-1", nil, ""))
+
+		// Assert
+		if response.StatusCode != 404 {
+			t.Errorf("expected 404, got %d", response.StatusCode)
+		}
+	})
+}
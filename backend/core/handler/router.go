@@ -0,0 +1,299 @@
+package handler
+
+import (
+	"context"
+	"strings"
+
+	"athlete-forge/auth"
+	"athlete-forge/social"
+)
+
+// routeHandler handles a matched route
+type routeHandler func(ctx context.Context, event *APIGatewayProxyEvent) (Response, error)
+
+// route maps a method and path pattern to a handler; pattern segments in braces
+// such as {id} are captured into the event's PathParameters. Routes with a scope
+// are only served to callers granted it. Bodies larger than maxBody bytes are
+// rejected, using the handler's limit when it is zero, and gzip-encoded bodies are
+// decompressed unless rawBody is set. Successful responses of routes with a
+// cacheControl policy may be stored by shared caches. A rewrite of handle can be
+// set as shadow and compared against it before it replaces it. Routes serving
+// resources, or lists of them, give their links to related routes as links.
+// Routes that only read but take a body, such as batch gets, set readOnly so
+// they are served like GETs. Routes the apps need before they can upgrade set
+// anyVersion so they are served to releases below the minimum
+type route struct {
+	method       string
+	pattern      string
+	scope        string
+	maxBody      int
+	rawBody      bool
+	cacheControl string
+	handle       routeHandler
+	shadow       routeHandler
+	links        linker
+	readOnly     bool
+	anyVersion   bool
+}
+
+// reads reports whether a request to r only reads, so it is served on standby
+// and needs no idempotency
+func (r *route) reads(event *APIGatewayProxyEvent) bool {
+	return event.HTTPMethod == "" || event.HTTPMethod == "GET" || r.readOnly
+}
+
+// registerRoutes builds the route table
+func (h *LambdaHandler) registerRoutes() {
+	h.routes = []route{
+		{method: "", pattern: "/api/health", handle: func(ctx context.Context, _ *APIGatewayProxyEvent) (Response, error) {
+			return h.HandleHealthCheck(ctx)
+		}},
+		{method: "POST", pattern: "/api/auth/refresh", handle: h.handleRefresh},
+		{method: "POST", pattern: "/api/auth/logout", handle: h.handleLogout},
+		{method: "POST", pattern: "/api/auth/logout-all", handle: h.handleLogoutAll},
+		{method: "GET", pattern: "/api/auth/sessions", handle: h.handleListSessions},
+		{method: "DELETE", pattern: "/api/auth/sessions/{id}", handle: h.handleRevokeSession},
+		{method: "POST", pattern: "/api/auth/{provider}", handle: h.handleSignIn},
+		{method: "POST", pattern: "/api/auth/{provider}/link", handle: h.handleLinkIdentity},
+		{method: "DELETE", pattern: "/api/auth/identities/{provider}", handle: h.handleUnlinkIdentity},
+		{method: "GET", pattern: "/api/auth/connections", handle: h.handleListConnections},
+		{method: "GET", pattern: "/api/auth/me", handle: h.handleGetAccount},
+		{method: "GET", pattern: "/api/auth/me/region", handle: h.handleGetDataRegion},
+		{method: "PUT", pattern: "/api/auth/me/region", handle: h.handlePutDataRegion},
+		{method: "GET", pattern: "/api/auth/me/handle", handle: h.handleGetHandle},
+		{method: "PUT", pattern: "/api/auth/me/handle", handle: h.handlePutHandle},
+		{method: "GET", pattern: "/api/handles/{handle}", handle: h.handleCheckHandle},
+		{method: "GET", pattern: "/api/consent/documents", handle: h.handleListConsentDocuments},
+		{method: "GET", pattern: "/api/consent/documents/{purpose}", handle: h.handleGetConsentDocument},
+		{method: "GET", pattern: "/api/consent", scope: auth.ScopeWorkoutsRead, handle: h.handleGetConsents},
+		{method: "GET", pattern: "/api/consent/history", scope: auth.ScopeWorkoutsRead, handle: h.handleGetConsentHistory},
+		{method: "PUT", pattern: "/api/consent/{purpose}", scope: auth.ScopeWorkoutsWrite, handle: h.handlePutConsent},
+		{method: "GET", pattern: "/api/profile", scope: auth.ScopeWorkoutsRead, handle: h.handleGetProfile},
+		{method: "PUT", pattern: "/api/profile", scope: auth.ScopeWorkoutsWrite, handle: h.handlePutProfile},
+		{method: "PATCH", pattern: "/api/profile", scope: auth.ScopeWorkoutsWrite, handle: h.handlePatchProfile},
+		{method: "GET", pattern: "/api/profile/public", scope: auth.ScopeWorkoutsRead, handle: h.handleGetPublicProfileSettings},
+		{method: "PUT", pattern: "/api/profile/public", scope: auth.ScopeWorkoutsWrite, handle: h.handlePutPublicProfileSettings},
+		{method: "GET", pattern: "/api/public/users/{handle}", cacheControl: profileCacheControl, handle: h.handleGetPublicProfile},
+		{method: "GET", pattern: "/api/tools/plates", handle: h.handlePlates},
+		{method: "GET", pattern: "/api/tools/warmup", handle: h.handleWarmup},
+		{method: "GET", pattern: "/api/gyms", scope: auth.ScopeWorkoutsRead, handle: h.handleListGyms, links: selfLink("/api/gyms/{id}")},
+		{method: "POST", pattern: "/api/gyms", scope: auth.ScopeWorkoutsWrite, handle: h.handleCreateGym, links: selfLink("/api/gyms/{id}")},
+		{method: "GET", pattern: "/api/gyms/{id}", scope: auth.ScopeWorkoutsRead, handle: h.handleGetGym, links: selfLink("/api/gyms/{id}")},
+		{method: "PUT", pattern: "/api/gyms/{id}", scope: auth.ScopeWorkoutsWrite, handle: h.handlePutGym, links: selfLink("/api/gyms/{id}")},
+		{method: "DELETE", pattern: "/api/gyms/{id}", scope: auth.ScopeWorkoutsWrite, handle: h.handleDeleteGym},
+		{method: "POST", pattern: "/api/gyms/{id}/check-in", scope: auth.ScopeWorkoutsWrite, handle: h.handleGymCheckIn, links: workoutLinks},
+		{method: "GET", pattern: "/api/gyms/{id}/stats", scope: auth.ScopeWorkoutsRead, handle: h.handleGymStats},
+		{method: "GET", pattern: "/api/exercises", scope: auth.ScopeWorkoutsRead, handle: h.handleSearchExercises},
+		{method: "GET", pattern: "/api/exercises/history", scope: auth.ScopeWorkoutsRead, handle: h.handleExerciseHistory},
+		{method: "GET", pattern: "/api/exercises/rep-records", scope: auth.ScopeWorkoutsRead, handle: h.handleRepRecords},
+		{method: "GET", pattern: "/api/exercises/catalog", cacheControl: catalogCacheControl, handle: h.handleExerciseCatalog},
+		{method: "GET", pattern: "/api/exercises/favorites", scope: auth.ScopeWorkoutsRead, handle: h.handleListFavoriteExercises},
+		{method: "GET", pattern: "/api/exercises/recent", scope: auth.ScopeWorkoutsRead, handle: h.handleRecentExercises},
+		{method: "GET", pattern: "/api/exercises/autocomplete", scope: auth.ScopeWorkoutsRead, handle: h.handleAutocompleteExercises},
+		{method: "GET", pattern: "/api/exercises/taxonomy", cacheControl: catalogCacheControl, handle: h.handleExerciseTaxonomy},
+		{method: "GET", pattern: "/api/exercises/{name}/media", scope: auth.ScopeWorkoutsRead, handle: h.handleListExerciseMedia},
+		{method: "PUT", pattern: "/api/exercises/{name}/favorite", scope: auth.ScopeWorkoutsWrite, handle: h.handleFavoriteExercise},
+		{method: "DELETE", pattern: "/api/exercises/{name}/favorite", scope: auth.ScopeWorkoutsWrite, handle: h.handleUnfavoriteExercise},
+		{method: "GET", pattern: "/api/exercises/{name}/instructions", scope: auth.ScopeWorkoutsRead, handle: h.handleGetInstructions},
+		{method: "GET", pattern: compactEnumsPath, handle: h.handleCompactEnums},
+		{method: "GET", pattern: "/api/programs", scope: auth.ScopeWorkoutsRead, handle: h.handleListPrograms, links: programLinks},
+		{method: "POST", pattern: "/api/programs", scope: auth.ScopeWorkoutsWrite, handle: h.handleCreateProgram, links: programLinks},
+		{method: "GET", pattern: "/api/programs/{id}", scope: auth.ScopeWorkoutsRead, handle: h.handleGetProgram, links: programLinks},
+		{method: "POST", pattern: "/api/programs/import", scope: auth.ScopeWorkoutsWrite, handle: h.handleImportProgram, links: programLinks},
+		{method: "GET", pattern: "/api/programs/{id}/export", scope: auth.ScopeWorkoutsRead, handle: h.handleExportProgram},
+		{method: "GET", pattern: "/api/programs/{id}/compliance", scope: auth.ScopeWorkoutsRead, handle: h.handleProgramCompliance},
+		{method: "GET", pattern: "/api/programs/{id}/next-session", scope: auth.ScopeWorkoutsRead, handle: h.handleNextSession},
+		{method: "POST", pattern: "/api/programs/{id}/sessions", scope: auth.ScopeWorkoutsWrite, handle: h.handleStartSession},
+		{method: "GET", pattern: "/api/programs/{id}/blocks", scope: auth.ScopeWorkoutsRead, handle: h.handleGetBlocks},
+		{method: "PUT", pattern: "/api/programs/{id}/blocks", scope: auth.ScopeWorkoutsWrite, handle: h.handlePutBlocks, links: programLinks},
+		{method: "PUT", pattern: "/api/programs/{id}/schedule", scope: auth.ScopeWorkoutsWrite, handle: h.handlePutSchedule},
+		{method: "DELETE", pattern: "/api/programs/{id}/schedule", scope: auth.ScopeWorkoutsWrite, handle: h.handleDeleteSchedule},
+		{method: "GET", pattern: "/api/training-maxes", scope: auth.ScopeWorkoutsRead, handle: h.handleListTrainingMaxes},
+		{method: "PUT", pattern: "/api/training-maxes", scope: auth.ScopeWorkoutsWrite, handle: h.handlePutTrainingMax},
+		{method: "GET", pattern: "/api/marketplace/templates", scope: auth.ScopeWorkoutsRead, handle: h.handleBrowseTemplates, links: selfLink("/api/marketplace/templates/{id}")},
+		{method: "POST", pattern: "/api/marketplace/templates", scope: auth.ScopeWorkoutsWrite, handle: h.handlePublishTemplate, links: selfLink("/api/marketplace/templates/{id}")},
+		{method: "GET", pattern: "/api/marketplace/templates/{id}", scope: auth.ScopeWorkoutsRead, handle: h.handleGetTemplate, links: selfLink("/api/marketplace/templates/{id}")},
+		{method: "DELETE", pattern: "/api/marketplace/templates/{id}", scope: auth.ScopeWorkoutsWrite, handle: h.handleUnpublishTemplate},
+		{method: "POST", pattern: "/api/marketplace/templates/{id}/clone", scope: auth.ScopeWorkoutsWrite, handle: h.handleCloneTemplate, links: programLinks},
+		{method: "POST", pattern: "/api/marketplace/templates/{id}/flag", scope: auth.ScopeWorkoutsWrite, handle: h.handleFlagTemplate},
+		{method: "GET", pattern: "/api/marketplace/templates/{id}/reviews", scope: auth.ScopeWorkoutsRead, handle: h.handleListReviews},
+		{method: "PUT", pattern: "/api/marketplace/templates/{id}/reviews/mine", scope: auth.ScopeWorkoutsWrite, handle: h.handlePutReview},
+		{method: "DELETE", pattern: "/api/marketplace/templates/{id}/reviews/mine", scope: auth.ScopeWorkoutsWrite, handle: h.handleDeleteReview},
+		{method: "POST", pattern: "/api/marketplace/templates/{id}/reviews/{userId}/flag", scope: auth.ScopeWorkoutsWrite, handle: h.handleFlagReview},
+		{method: "POST", pattern: "/api/moderation/reports", scope: auth.ScopeWorkoutsWrite, handle: h.handleReport},
+		{method: "GET", pattern: "/api/moderation/standing", scope: auth.ScopeWorkoutsRead, handle: h.handleGetStanding},
+		{method: "GET", pattern: "/api/social/blocks", scope: auth.ScopeWorkoutsRead, handle: h.handleListRelations(social.KindBlock)},
+		{method: "PUT", pattern: "/api/social/blocks/{userId}", scope: auth.ScopeWorkoutsWrite, handle: h.handleAddRelation(social.KindBlock)},
+		{method: "DELETE", pattern: "/api/social/blocks/{userId}", scope: auth.ScopeWorkoutsWrite, handle: h.handleRemoveRelation(social.KindBlock)},
+		{method: "GET", pattern: "/api/social/mutes", scope: auth.ScopeWorkoutsRead, handle: h.handleListRelations(social.KindMute)},
+		{method: "PUT", pattern: "/api/social/mutes/{userId}", scope: auth.ScopeWorkoutsWrite, handle: h.handleAddRelation(social.KindMute)},
+		{method: "DELETE", pattern: "/api/social/mutes/{userId}", scope: auth.ScopeWorkoutsWrite, handle: h.handleRemoveRelation(social.KindMute)},
+		{method: "GET", pattern: "/api/client/config", anyVersion: true, handle: h.handleGetClientConfig},
+		{method: "GET", pattern: "/api/announcements", scope: auth.ScopeWorkoutsRead, handle: h.handleListAnnouncements},
+		{method: "PUT", pattern: "/api/announcements/{id}/read", scope: auth.ScopeWorkoutsWrite, handle: h.handleReadAnnouncement},
+		{method: "POST", pattern: "/api/coaching/invites", scope: auth.ScopeWorkoutsWrite, handle: h.handleInviteAthlete},
+		{method: "GET", pattern: "/api/coaching/links", scope: auth.ScopeWorkoutsRead, handle: h.handleListCoachingLinks},
+		{method: "POST", pattern: "/api/coaching/links/{id}/accept", scope: auth.ScopeWorkoutsWrite, handle: h.handleAcceptCoachingLink},
+		{method: "DELETE", pattern: "/api/coaching/links/{id}", scope: auth.ScopeWorkoutsWrite, handle: h.handleEndCoachingLink},
+		{method: "GET", pattern: "/api/coaching/links/{id}/messages", scope: auth.ScopeWorkoutsRead, handle: h.handleListMessages},
+		{method: "POST", pattern: "/api/coaching/links/{id}/messages", scope: auth.ScopeWorkoutsWrite, handle: h.handleSendMessage},
+		{method: "PUT", pattern: "/api/coaching/links/{id}/read", scope: auth.ScopeWorkoutsWrite, handle: h.handleMarkRead},
+		{method: "GET", pattern: "/api/coaching/links/{id}/export", scope: auth.ScopeWorkoutsRead, handle: h.handleExportThread},
+		{method: "POST", pattern: "/api/shares", scope: auth.ScopeWorkoutsWrite, handle: h.handleCreateShare},
+		{method: "GET", pattern: "/api/shares/{code}", scope: auth.ScopeWorkoutsRead, handle: h.handleGetShare},
+		{method: "DELETE", pattern: "/api/shares/{code}", scope: auth.ScopeWorkoutsWrite, handle: h.handleDeleteShare},
+		{method: "POST", pattern: "/api/shares/{code}/redeem", scope: auth.ScopeWorkoutsWrite, handle: h.handleRedeemShare},
+		{method: "GET", pattern: "/api/workouts", scope: auth.ScopeWorkoutsRead, handle: h.handleListWorkouts, links: workoutLinks},
+		{method: "POST", pattern: "/api/workouts", scope: auth.ScopeWorkoutsWrite, handle: h.handleCreateWorkout, links: workoutLinks},
+		{method: "POST", pattern: "/api/workouts:batchGet", scope: auth.ScopeWorkoutsRead, readOnly: true, handle: h.handleBatchGetWorkouts},
+		{method: "GET", pattern: "/api/workouts/{id}", scope: auth.ScopeWorkoutsRead, handle: h.handleGetWorkout, links: workoutLinks},
+		{method: "PATCH", pattern: "/api/workouts/{id}", scope: auth.ScopeWorkoutsWrite, handle: h.handlePatchWorkout, links: workoutLinks},
+		{method: "PATCH", pattern: "/api/workouts/{id}/exercises/{exercise}/sets/{set}", scope: auth.ScopeWorkoutsWrite, handle: h.handlePatchSet, links: workoutLinks},
+		{method: "PUT", pattern: "/api/workouts/{id}/exercises/{exercise}/sets/{set}/velocities", scope: auth.ScopeWorkoutsWrite, handle: h.handlePutSetVelocities},
+		{method: "POST", pattern: "/api/workouts/{id}/complete", scope: auth.ScopeWorkoutsWrite, handle: h.handleCompleteWorkout, links: workoutLinks},
+		{method: "GET", pattern: "/api/workouts/{id}/share-card", scope: auth.ScopeWorkoutsRead, handle: h.handleGetShareCard},
+		{method: "POST", pattern: "/api/workouts/{id}/share-card/image", scope: auth.ScopeWorkoutsWrite, handle: h.handleCreateShareCardImage},
+		{method: "GET", pattern: "/api/workouts/{id}/share-card/image", scope: auth.ScopeWorkoutsRead, handle: h.handleGetShareCardImage},
+		{method: "GET", pattern: "/api/workouts/{id}/rest-timer", scope: auth.ScopeWorkoutsRead, handle: h.handleGetRestTimer},
+		{method: "PUT", pattern: "/api/workouts/{id}/rest-timer", scope: auth.ScopeWorkoutsWrite, handle: h.handlePutRestTimer},
+		{method: "GET", pattern: "/api/bulk-edits", scope: auth.ScopeWorkoutsRead, handle: h.handleListBulkEdits, links: selfLink("/api/bulk-edits/{id}")},
+		{method: "POST", pattern: "/api/bulk-edits", scope: auth.ScopeWorkoutsWrite, handle: h.handleCreateBulkEdit},
+		{method: "GET", pattern: "/api/bulk-edits/{id}", scope: auth.ScopeWorkoutsRead, handle: h.handleGetBulkEdit, links: selfLink("/api/bulk-edits/{id}")},
+		{method: "GET", pattern: "/api/backfills", scope: auth.ScopeWorkoutsRead, handle: h.handleListBackfills, links: selfLink("/api/backfills/{id}")},
+		{method: "POST", pattern: "/api/backfills", scope: auth.ScopeWorkoutsWrite, handle: h.handleCreateBackfill, links: selfLink("/api/backfills/{id}")},
+		{method: "GET", pattern: "/api/backfills/{id}", scope: auth.ScopeWorkoutsRead, handle: h.handleGetBackfill, links: selfLink("/api/backfills/{id}")},
+		{method: "PUT", pattern: "/api/backfills/{id}/chunks/{number}", scope: auth.ScopeWorkoutsWrite, maxBody: largeBodySize, handle: h.handlePutBackfillChunk},
+		{method: "POST", pattern: "/api/backfills/{id}/commit", scope: auth.ScopeWorkoutsWrite, handle: h.handleCommitBackfill, links: selfLink("/api/backfills/{id}")},
+		{method: "GET", pattern: "/api/checkins", scope: auth.ScopeWorkoutsRead, handle: h.handleListCheckIns},
+		{method: "GET", pattern: "/api/checkins/{date}", scope: auth.ScopeWorkoutsRead, handle: h.handleGetCheckIn},
+		{method: "PUT", pattern: "/api/checkins/{date}", scope: auth.ScopeWorkoutsWrite, handle: h.handlePutCheckIn},
+		{method: "GET", pattern: "/api/injuries", scope: auth.ScopeWorkoutsRead, handle: h.handleListInjuries, links: selfLink("/api/injuries/{id}")},
+		{method: "POST", pattern: "/api/injuries", scope: auth.ScopeWorkoutsWrite, handle: h.handleCreateInjury, links: selfLink("/api/injuries/{id}")},
+		{method: "GET", pattern: "/api/injuries/{id}", scope: auth.ScopeWorkoutsRead, handle: h.handleGetInjury, links: selfLink("/api/injuries/{id}")},
+		{method: "PUT", pattern: "/api/injuries/{id}", scope: auth.ScopeWorkoutsWrite, handle: h.handlePutInjury, links: selfLink("/api/injuries/{id}")},
+		{method: "DELETE", pattern: "/api/injuries/{id}", scope: auth.ScopeWorkoutsWrite, handle: h.handleDeleteInjury},
+		{method: "GET", pattern: "/api/cardio-workouts", scope: auth.ScopeWorkoutsRead, handle: h.handleListIntervalWorkouts, links: selfLink("/api/cardio-workouts/{id}")},
+		{method: "POST", pattern: "/api/cardio-workouts", scope: auth.ScopeWorkoutsWrite, handle: h.handleCreateIntervalWorkout, links: selfLink("/api/cardio-workouts/{id}")},
+		{method: "GET", pattern: "/api/cardio-workouts/{id}", scope: auth.ScopeWorkoutsRead, handle: h.handleGetIntervalWorkout, links: selfLink("/api/cardio-workouts/{id}")},
+		{method: "PUT", pattern: "/api/cardio-workouts/{id}", scope: auth.ScopeWorkoutsWrite, handle: h.handlePutIntervalWorkout, links: selfLink("/api/cardio-workouts/{id}")},
+		{method: "DELETE", pattern: "/api/cardio-workouts/{id}", scope: auth.ScopeWorkoutsWrite, handle: h.handleDeleteIntervalWorkout},
+		{method: "GET", pattern: "/api/cardio-workouts/{id}/fit", scope: auth.ScopeWorkoutsRead, handle: h.handleIntervalWorkoutFIT},
+		{method: "GET", pattern: "/api/activities", scope: auth.ScopeWorkoutsRead, handle: h.handleListActivities, links: selfLink("/api/activities/{id}")},
+		{method: "POST", pattern: "/api/activities", scope: auth.ScopeWorkoutsWrite, maxBody: largeBodySize, handle: h.handleCreateActivity, links: selfLink("/api/activities/{id}")},
+		{method: "POST", pattern: "/api/activities/fit", scope: auth.ScopeWorkoutsWrite, maxBody: largeBodySize, handle: h.handleImportFITActivity, links: selfLink("/api/activities/{id}")},
+		{method: "GET", pattern: "/api/activities/{id}", scope: auth.ScopeWorkoutsRead, handle: h.handleGetActivity, links: selfLink("/api/activities/{id}")},
+		{method: "PUT", pattern: "/api/activities/{id}/track", scope: auth.ScopeWorkoutsWrite, maxBody: largeBodySize, handle: h.handlePutActivityTrack, links: selfLink("/api/activities/{id}")},
+		{method: "GET", pattern: "/api/activities/{id}/polyline", scope: auth.ScopeWorkoutsRead, handle: h.handleActivityPolyline},
+		{method: "GET", pattern: "/api/activities/{id}/elevation", scope: auth.ScopeWorkoutsRead, handle: h.handleActivityElevation},
+		{method: "GET", pattern: "/api/multisport-sessions", scope: auth.ScopeWorkoutsRead, handle: h.handleListMultisportSessions, links: selfLink("/api/multisport-sessions/{id}")},
+		{method: "POST", pattern: "/api/multisport-sessions", scope: auth.ScopeWorkoutsWrite, handle: h.handleCreateMultisportSession, links: selfLink("/api/multisport-sessions/{id}")},
+		{method: "GET", pattern: "/api/multisport-sessions/{id}", scope: auth.ScopeWorkoutsRead, handle: h.handleGetMultisportSession, links: selfLink("/api/multisport-sessions/{id}")},
+		{method: "PUT", pattern: "/api/multisport-sessions/{id}", scope: auth.ScopeWorkoutsWrite, handle: h.handlePutMultisportSession, links: selfLink("/api/multisport-sessions/{id}")},
+		{method: "DELETE", pattern: "/api/multisport-sessions/{id}", scope: auth.ScopeWorkoutsWrite, handle: h.handleDeleteMultisportSession},
+		{method: "GET", pattern: "/api/cycling/ftp", scope: auth.ScopeWorkoutsRead, handle: h.handleGetFTP},
+		{method: "PUT", pattern: "/api/cycling/ftp", scope: auth.ScopeWorkoutsWrite, handle: h.handlePutFTP},
+		{method: "POST", pattern: "/api/cycling/ftp/accept", scope: auth.ScopeWorkoutsWrite, handle: h.handleAcceptFTPSuggestion},
+		{method: "GET", pattern: "/api/achievements", scope: auth.ScopeWorkoutsRead, handle: h.handleListAchievements},
+		{method: "GET", pattern: "/api/stats/cardio/weekly", scope: auth.ScopeWorkoutsRead, handle: h.handleWeeklyCardio},
+		{method: "GET", pattern: "/api/stats/load", scope: auth.ScopeWorkoutsRead, handle: h.handleTrainingLoad},
+		{method: "GET", pattern: "/api/stats/percentiles", scope: auth.ScopeWorkoutsRead, handle: h.handleGetPercentiles},
+		{method: "GET", pattern: "/api/stats/weekly", scope: auth.ScopeWorkoutsRead, handle: h.handleWeeklySummary},
+		{method: "POST", pattern: "/api/stats/recompute", scope: auth.ScopeWorkoutsWrite, handle: h.handleRecomputeStats},
+		{method: "GET", pattern: "/api/reports/weekly", scope: auth.ScopeWorkoutsRead, handle: h.handleWeeklyReport},
+		{method: "POST", pattern: "/api/reports/exports", scope: auth.ScopeWorkoutsWrite, handle: h.handleCreateExport},
+		{method: "GET", pattern: "/api/reports/exports/{id}", scope: auth.ScopeWorkoutsRead, handle: h.handleGetExport},
+		{method: "POST", pattern: "/api/demo", scope: auth.ScopeWorkoutsWrite, handle: h.handleSeedDemo},
+		{method: "GET", pattern: "/api/jobs", scope: auth.ScopeWorkoutsRead, handle: h.handleListJobs, links: selfLink("/api/jobs/{id}")},
+		{method: "GET", pattern: "/api/jobs/{id}", scope: auth.ScopeWorkoutsRead, handle: h.handleGetJob, links: selfLink("/api/jobs/{id}")},
+		{method: "GET", pattern: "/api/logs", scope: auth.ScopeWorkoutsRead, handle: h.handleListDailyLogs},
+		{method: "GET", pattern: "/api/logs/{date}", scope: auth.ScopeWorkoutsRead, handle: h.handleGetDailyLog},
+		{method: "PUT", pattern: "/api/logs/{date}", scope: auth.ScopeWorkoutsWrite, handle: h.handlePutDailyLog},
+		{method: "POST", pattern: "/api/logs/{date}/water", scope: auth.ScopeWorkoutsWrite, handle: h.handleAddWater},
+		{method: "GET", pattern: "/api/nutrition/foods", scope: auth.ScopeWorkoutsRead, handle: h.handleFoodLookup},
+		{method: "GET", pattern: "/api/nutrition/days", scope: auth.ScopeWorkoutsRead, handle: h.handleListNutritionDays},
+		{method: "POST", pattern: "/api/nutrition/imports/{source}", scope: auth.ScopeWorkoutsWrite, maxBody: largeBodySize, handle: h.handleNutritionImport},
+		{method: "POST", pattern: "/api/nutrition/imports/{source}/preview", scope: auth.ScopeWorkoutsWrite, maxBody: largeBodySize, readOnly: true, handle: h.handleNutritionImportPreview},
+		{method: "POST", pattern: "/api/telemetry", scope: auth.ScopeWorkoutsWrite, handle: h.handleTelemetry},
+		{method: "GET", pattern: "/api/calendar", scope: auth.ScopeWorkoutsRead, handle: h.handleGetCalendar},
+		{method: "POST", pattern: "/api/calendar/reset", scope: auth.ScopeWorkoutsWrite, handle: h.handleResetCalendar},
+		{method: "GET", pattern: "/api/calendar/feeds/{userId}/{token}", cacheControl: feedCacheControl, handle: h.handleCalendarFeed},
+		{method: "GET", pattern: "/api/webhooks/{provider}", handle: h.handleWebhookChallenge},
+		{method: "POST", pattern: "/api/webhooks/{provider}", maxBody: largeBodySize, rawBody: true, handle: h.handleWebhook},
+		{method: "GET", pattern: "/api/integrations", scope: auth.ScopeWorkoutsRead, handle: h.handleListIntegrations},
+		{method: "PUT", pattern: "/api/integrations/{provider}", scope: auth.ScopeWorkoutsWrite, handle: h.handlePutIntegration},
+		{method: "DELETE", pattern: "/api/integrations/{provider}", scope: auth.ScopeWorkoutsWrite, handle: h.handleDeleteIntegration},
+		{method: "POST", pattern: "/api/admin/jobs/{job}", scope: auth.ScopeAdmin, handle: h.handleRunJob},
+		{method: "POST", pattern: "/api/admin/region/promote", scope: auth.ScopeAdmin, handle: h.handlePromoteRegion},
+		{method: "GET", pattern: "/api/admin/consent/marketing-emails", scope: auth.ScopeAdmin, handle: h.handleListMarketingRecipients},
+		{method: "GET", pattern: "/api/admin/marketplace/flagged", scope: auth.ScopeAdmin, handle: h.handleListFlaggedTemplates},
+		{method: "POST", pattern: "/api/admin/marketplace/templates/{id}/review", scope: auth.ScopeAdmin, handle: h.handleReviewTemplate},
+		{method: "GET", pattern: "/api/admin/marketplace/reviews/flagged", scope: auth.ScopeAdmin, handle: h.handleListFlaggedReviews},
+		{method: "POST", pattern: "/api/admin/marketplace/templates/{id}/reviews/{userId}/review", scope: auth.ScopeAdmin, handle: h.handleModerateReview},
+		{method: "GET", pattern: "/api/admin/moderation/cases", scope: auth.ScopeAdmin, handle: h.handleListCases},
+		{method: "GET", pattern: "/api/admin/moderation/cases/{id}", scope: auth.ScopeAdmin, handle: h.handleGetCase},
+		{method: "POST", pattern: "/api/admin/moderation/cases/{id}/actions", scope: auth.ScopeAdmin, handle: h.handleCaseAction},
+		{method: "GET", pattern: "/api/admin/moderation/audit", scope: auth.ScopeAdmin, handle: h.handleListAudit},
+		{method: "GET", pattern: "/api/admin/announcements", scope: auth.ScopeAdmin, handle: h.handleAdminListAnnouncements},
+		{method: "POST", pattern: "/api/admin/announcements", scope: auth.ScopeAdmin, handle: h.handleCreateAnnouncement},
+		{method: "PUT", pattern: "/api/admin/announcements/{id}", scope: auth.ScopeAdmin, handle: h.handleUpdateAnnouncement},
+		{method: "DELETE", pattern: "/api/admin/announcements/{id}", scope: auth.ScopeAdmin, handle: h.handleDeleteAnnouncement},
+		{method: "POST", pattern: "/api/admin/cache/invalidate", scope: auth.ScopeAdmin, handle: h.handleInvalidateCache},
+		{method: "POST", pattern: "/api/admin/exercises/{name}/media", scope: auth.ScopeAdmin, maxBody: largeBodySize, handle: h.handleUploadExerciseMedia},
+		{method: "DELETE", pattern: "/api/admin/exercises/{name}/media/{id}", scope: auth.ScopeAdmin, handle: h.handleDeleteExerciseMedia},
+		{method: "GET", pattern: "/api/admin/exercises/{name}/instructions", scope: auth.ScopeAdmin, handle: h.handleListInstructionVersions},
+		{method: "GET", pattern: "/api/admin/exercises/{name}/instructions/audit", scope: auth.ScopeAdmin, handle: h.handleInstructionAudit},
+		{method: "PUT", pattern: "/api/admin/exercises/{name}/instructions/draft", scope: auth.ScopeAdmin, handle: h.handleSaveInstructionDraft},
+		{method: "DELETE", pattern: "/api/admin/exercises/{name}/instructions/draft", scope: auth.ScopeAdmin, handle: h.handleDiscardInstructionDraft},
+		{method: "POST", pattern: "/api/admin/exercises/{name}/instructions/draft/publish", scope: auth.ScopeAdmin, handle: h.handlePublishInstructionDraft},
+	}
+}
+
+// match finds the route for the event, returning a 405 response when the path
+// exists but not for the request method, and ok=false when no pattern matches
+func (h *LambdaHandler) match(event *APIGatewayProxyEvent) (*route, *Response, bool) {
+	pathMatched := false
+	for i := range h.routes {
+		r := &h.routes[i]
+		params, ok := matchPattern(r.pattern, event.Path)
+		if !ok {
+			continue
+		}
+		pathMatched = true
+		if r.method != "" && r.method != event.HTTPMethod {
+			continue
+		}
+
+		event.PathParameters = params
+		return r, nil, true
+	}
+
+	if pathMatched {
+		response := h.createErrorResponse(405, "Method not allowed")
+		return nil, &response, true
+	}
+	return nil, nil, false
+}
+
+// matchPattern compares a route pattern against a request path segment by segment
+func matchPattern(pattern, path string) (map[string]string, bool) {
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(patternParts) != len(pathParts) {
+		return nil, false
+	}
+
+	params := map[string]string{}
+	for i, part := range patternParts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if pathParts[i] == "" {
+				return nil, false
+			}
+			params[strings.Trim(part, "{}")] = pathParts[i]
+			continue
+		}
+		if part != pathParts[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
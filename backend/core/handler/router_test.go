@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		path     string
+		expected map[string]string
+		ok       bool
+	}{
+		{name: "static match", pattern: "/api/programs", path: "/api/programs", expected: map[string]string{}, ok: true},
+		{name: "trailing slash", pattern: "/api/programs", path: "/api/programs/", expected: map[string]string{}, ok: true},
+		{name: "captures parameter", pattern: "/api/workouts/{id}/complete", path: "/api/workouts/abc/complete", expected: map[string]string{"id": "abc"}, ok: true},
+		{name: "segment count differs", pattern: "/api/workouts/{id}", path: "/api/workouts", ok: false},
+		{name: "static segment differs", pattern: "/api/workouts/{id}/complete", path: "/api/workouts/abc/cancel", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, ok := matchPattern(tt.pattern, tt.path)
+			if ok != tt.ok {
+				t.Fatalf("expected ok %v, got %v", tt.ok, ok)
+			}
+			for key, value := range tt.expected {
+				if params[key] != value {
+					t.Errorf("expected %s=%q, got %q", key, value, params[key])
+				}
+			}
+		})
+	}
+}
+
+func TestLambdaHandler_MethodNotAllowed(t *testing.T) {
+	t.Run("known path with unsupported method returns 405", func(t *testing.T) {
+		// Act
+		response, err := newTestHandler().HandleRequest(context.Background(), apiEvent("DELETE", "/api/profile", "user-1", nil, ""))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if response.StatusCode != 405 {
+			t.Errorf("expected status code 405, got %d", response.StatusCode)
+		}
+	})
+}
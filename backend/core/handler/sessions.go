@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"athlete-forge/events"
+	"athlete-forge/realtime"
+	"athlete-forge/sessionindex"
+	"athlete-forge/store"
+	"athlete-forge/trainingload"
+	"athlete-forge/workout"
+)
+
+// defaultStaleSession is how long a workout session can stay active before the
+// stale session job finishes it, unless STALE_SESSION_HOURS says otherwise
+const defaultStaleSession = 12 * time.Hour
+
+// staleSessionWindow is how long past the stale session limit the job keeps
+// looking for a session, such as one it keeps failing to finish
+const staleSessionWindow = 7 * 24 * time.Hour
+
+// messageWorkoutAutoFinished is the type of messages telling a user's devices
+// that a session they left open was finished for them
+const messageWorkoutAutoFinished = "workoutAutoFinished"
+
+// indexSession records w, when it is an active session, for the stale session job
+func (h *LambdaHandler) indexSession(ctx context.Context, w *workout.Workout) error {
+	if w.Status != workout.StatusActive {
+		return nil
+	}
+	return h.openSessions.Add(ctx, w.UserID, w.ID, w.StartedAt)
+}
+
+// runFinishSessions finishes the workout sessions left active for longer than
+// the stale session limit, so forgotten sessions do not distort stats. Sessions
+// that logged work are completed and progress their program; the rest are
+// abandoned. A failure for one session is logged and leaves it for the next run
+func (h *LambdaHandler) runFinishSessions(ctx context.Context, now time.Time) (JobResult, error) {
+	result := JobResult{Job: JobFinishSessions}
+	cutoff := now.Add(-h.staleSession)
+	entries, err := h.openSessions.StartedBefore(ctx, cutoff.Add(-staleSessionWindow), cutoff)
+	if err != nil {
+		return result, err
+	}
+
+	for _, entry := range entries {
+		finished, err := h.finishSession(ctx, entry, now)
+		if err != nil {
+			result.Failed++
+			h.logger.Error().
+				Err(err).
+				Str("user_id", entry.UserID).
+				Str("workout_id", entry.WorkoutID).
+				Msg("Failed to finish stale session")
+			continue
+		}
+		if finished {
+			result.Processed++
+		}
+	}
+	return result, nil
+}
+
+// finishSession finishes the session entry records if it is still active and
+// stale, reporting whether it did, and drops the entry once the session is no
+// longer active. A session whose start was moved later is indexed again under
+// its new start
+func (h *LambdaHandler) finishSession(ctx context.Context, entry sessionindex.Entry, now time.Time) (bool, error) {
+	w, err := h.workouts.Get(ctx, entry.UserID, entry.WorkoutID)
+	if errors.Is(err, store.ErrNotFound) {
+		return false, h.openSessions.Remove(ctx, entry)
+	}
+	if err != nil {
+		return false, err
+	}
+	if w.Status != workout.StatusActive {
+		return false, h.openSessions.Remove(ctx, entry)
+	}
+	if !w.StartedAt.Equal(entry.StartedAt) {
+		if err := h.indexSession(ctx, w); err != nil {
+			return false, err
+		}
+		if err := h.openSessions.Remove(ctx, entry); err != nil {
+			return false, err
+		}
+		if now.Sub(w.StartedAt) < h.staleSession {
+			return false, nil
+		}
+		entry = sessionindex.Entry{UserID: w.UserID, WorkoutID: w.ID, StartedAt: w.StartedAt}
+	}
+
+	w.AutoFinishedAt = &now
+	if w.Performed() {
+		if _, err := h.completeWorkout(ctx, w, h.estimatedEnd(*w), now); err != nil {
+			return false, err
+		}
+	} else {
+		w.Status = workout.StatusAbandoned
+		if err := h.workouts.Save(ctx, w); err != nil {
+			return false, err
+		}
+	}
+	if err := h.openSessions.Remove(ctx, entry); err != nil {
+		return false, err
+	}
+
+	h.publish(ctx, events.WorkoutAutoFinished(*w))
+	if _, err := h.hub.Broadcast(ctx, w.UserID, realtime.Message{Type: messageWorkoutAutoFinished, Data: w}); err != nil {
+		h.logger.Warn().
+			Err(err).
+			Str("workout_id", w.ID).
+			Msg("Failed to broadcast finished session")
+	}
+	h.logger.Info().
+		Str("user_id", w.UserID).
+		Str("workout_id", w.ID).
+		Str("status", w.Status).
+		Msg("Stale session finished")
+	return true, nil
+}
+
+// estimatedEnd is when a session left open probably ended: its start plus the
+// time its logged sets would take, at most the stale session limit
+func (h *LambdaHandler) estimatedEnd(w workout.Workout) time.Time {
+	minutes := math.Max(trainingload.EstimatedMinutes(w), 1)
+	duration := time.Duration(minutes * float64(time.Minute)).Round(time.Second)
+	if duration > h.staleSession {
+		duration = h.staleSession
+	}
+	return w.StartedAt.Add(duration)
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"athlete-forge/events"
+	"athlete-forge/workout"
+)
+
+func TestLambdaHandler_FinishSessions(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	start := func(t *testing.T, h *LambdaHandler, startedAt time.Time, sets string) workout.Workout {
+		t.Helper()
+		response := createWorkout(t, h, "user-1", `{"status":"active","startedAt":"`+startedAt.Format(time.RFC3339)+`","exercises":[{"name":"Squat","sets":[`+sets+`]}]}`)
+		if response.StatusCode != 201 {
+			t.Fatalf("failed to create workout %d: %s", response.StatusCode, response.Body)
+		}
+		var w workout.Workout
+		json.Unmarshal([]byte(response.Body), &w)
+		return w
+	}
+
+	t.Run("completes stale sessions with logged sets at their estimated end", func(t *testing.T) {
+		// Arrange
+		publisher := &recordingPublisher{}
+		h := NewLambdaHandler(zerolog.Nop(), WithEventPublisher(publisher))
+		started := now.Add(-20 * time.Hour).Truncate(time.Second)
+		w := start(t, h, started, `{"reps":5,"weight":100},{"reps":5,"weight":100}`)
+
+		// Act
+		result, err := h.runFinishSessions(ctx, now)
+
+		// Assert
+		if err != nil || result.Processed != 1 || result.Failed != 0 {
+			t.Fatalf("expected one session finished, got %+v %v", result, err)
+		}
+		finished, _ := h.workouts.Get(ctx, "user-1", w.ID)
+		if finished.Status != workout.StatusCompleted || finished.AutoFinishedAt == nil {
+			t.Fatalf("expected an auto-finished completion, got %+v", finished)
+		}
+		if !finished.CompletedAt.After(started) || finished.CompletedAt.After(started.Add(time.Hour)) {
+			t.Errorf("expected completion shortly after the start, got %v", finished.CompletedAt)
+		}
+		if users, _ := h.users.List(ctx); len(users) != 1 || !users[0].LastActiveAt.Equal(now) {
+			t.Errorf("expected the user recorded active when the job ran, got %+v", users)
+		}
+		var autoFinished *events.WorkoutAutoFinishedData
+		for _, e := range publisher.events {
+			if e.Type == events.TypeWorkoutAutoFinished {
+				data := e.Data.(events.WorkoutAutoFinishedData)
+				autoFinished = &data
+			}
+		}
+		if autoFinished == nil || autoFinished.Status != workout.StatusCompleted || autoFinished.WorkoutID != w.ID {
+			t.Errorf("expected a WorkoutAutoFinished event, got %+v", publisher.events)
+		}
+	})
+
+	t.Run("abandons stale sessions without logged sets", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		w := start(t, h, now.Add(-20*time.Hour), "")
+
+		// Act
+		result, _ := h.runFinishSessions(ctx, now)
+
+		// Assert
+		finished, _ := h.workouts.Get(ctx, "user-1", w.ID)
+		if result.Processed != 1 || finished.Status != workout.StatusAbandoned || finished.CompletedAt != nil {
+			t.Errorf("expected the session abandoned, got %+v", finished)
+		}
+	})
+
+	t.Run("leaves recent and finished sessions alone", func(t *testing.T) {
+		// Arrange
+		h := NewLambdaHandler(zerolog.Nop(), WithStaleSessionAfter(2*time.Hour))
+		recent := start(t, h, now.Add(-time.Hour), "")
+		done := start(t, h, now.Add(-5*time.Hour), `{"reps":5,"weight":100}`)
+		h.HandleRequest(ctx, apiEvent("POST", "/api/workouts/"+done.ID+"/complete", "user-1", nil, ""))
+
+		// Act
+		result, _ := h.runFinishSessions(ctx, now)
+		later, _ := h.runFinishSessions(ctx, now.Add(3*time.Hour))
+
+		// Assert
+		if result.Processed != 0 {
+			t.Errorf("expected nothing finished, got %+v", result)
+		}
+		finished, _ := h.workouts.Get(ctx, "user-1", recent.ID)
+		if later.Processed != 1 || finished.Status != workout.StatusAbandoned {
+			t.Errorf("expected the recent session finished once stale, got %+v %+v", later, finished)
+		}
+	})
+
+	t.Run("tells the user's devices", func(t *testing.T) {
+		// Arrange
+		poster := &recordingPoster{}
+		h := NewLambdaHandler(zerolog.Nop(), WithAuthProvider(stubProvider{}), WithSessionSecret([]byte("secret")), WithWebSocketPoster(poster))
+		session := signIn(t, h, "sam")
+		h.HandleRequest(ctx, webSocketEventFor("CONNECT", "phone", map[string]string{"token": session.Token}, ""))
+		response, _ := h.HandleRequest(ctx, withBearer(apiEvent("POST", "/api/workouts", "", nil, `{"status":"active","startedAt":"`+now.Add(-20*time.Hour).Format(time.RFC3339)+`"}`), session.Token))
+		if response.StatusCode != 201 {
+			t.Fatalf("failed to create workout %d: %s", response.StatusCode, response.Body)
+		}
+
+		// Act
+		h.runFinishSessions(ctx, now)
+
+		// Assert
+		if messages := poster.posted["phone"]; len(messages) != 1 || messages[0].Type != messageWorkoutAutoFinished {
+			t.Errorf("unexpected messages: %+v", messages)
+		}
+	})
+}
@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"athlete-forge/store"
+)
+
+// shadowTimeout bounds the shadow implementation, whose latency the caller pays
+// for although it never sees the result
+const shadowTimeout = 5 * time.Second
+
+// maxShadowDiffs bounds how many differences are logged for one request
+const maxShadowDiffs = 10
+
+// WithShadowRoutes runs the new implementation of each route, named as "GET
+// /api/workouts/{id}", alongside the current one, logging where their responses
+// differ while still serving the current one. Only GET routes can be shadowed,
+// since a shadowed write would be applied twice
+func WithShadowRoutes(routes ...string) Option {
+	return func(h *LambdaHandler) {
+		if h.shadowRoutes == nil {
+			h.shadowRoutes = map[string]bool{}
+		}
+		for _, r := range routes {
+			if r = strings.TrimSpace(r); r != "" {
+				h.shadowRoutes[r] = true
+			}
+		}
+	}
+}
+
+// WithShadowStore sets the candidate store for a storage layer change: shadowed
+// routes without their own new implementation run their current one against s
+func WithShadowStore(s store.Store) Option {
+	return func(h *LambdaHandler) {
+		h.shadowStore = s
+	}
+}
+
+// newShadowHandler returns a copy of the handler that reads and writes the
+// shadow store instead, with its side effects outside the store disabled
+func (h *LambdaHandler) newShadowHandler() *LambdaHandler {
+	candidate := *h
+	candidate.publishers = nil
+	candidate.cdn = nil
+	candidate.metrics = nil
+	candidate.telemetry = nil
+	candidate.shadowRoutes = nil
+	candidate.shadowStore = nil
+	candidate.useStore(h.shadowStore)
+	candidate.registerRoutes()
+	return &candidate
+}
+
+// checkShadowRoutes warns about configured shadow routes that will never be
+// shadowed, since a typo would otherwise silently disable the comparison
+func (h *LambdaHandler) checkShadowRoutes() {
+	for key := range h.shadowRoutes {
+		var shadowed *route
+		for i := range h.routes {
+			if r := &h.routes[i]; r.method+" "+r.pattern == key {
+				shadowed = r
+			}
+		}
+		switch {
+		case shadowed == nil:
+			h.logger.Warn().Str("route", key).Msg("Shadow route not found")
+		case shadowed.method != "GET":
+			h.logger.Warn().Str("route", key).Msg("Only GET routes can be shadowed")
+		case shadowed.shadow == nil && h.shadowStore == nil:
+			h.logger.Warn().Str("route", key).Msg("Shadow route has no new implementation or shadow store")
+		}
+	}
+}
+
+// shadowImplementation returns the new implementation of r when it is shadowed:
+// its own shadow handler, or the same route served from the shadow store
+func (h *LambdaHandler) shadowImplementation(r *route) routeHandler {
+	if r.method != "GET" || !h.shadowRoutes[r.method+" "+r.pattern] {
+		return nil
+	}
+	if r.shadow != nil {
+		return r.shadow
+	}
+	if h.shadowHandler == nil {
+		return nil
+	}
+	for i := range h.shadowHandler.routes {
+		if candidate := &h.shadowHandler.routes[i]; candidate.method == r.method && candidate.pattern == r.pattern {
+			return candidate.handle
+		}
+	}
+	return nil
+}
+
+// shadow runs the new implementation of r, if it is shadowed, on a copy of the
+// event and logs how its response differs from primary. Failures of the new
+// implementation, including panics, are logged and never reach the caller
+func (h *LambdaHandler) shadow(ctx context.Context, event *APIGatewayProxyEvent, r *route, primary Response) {
+	implementation := h.shadowImplementation(r)
+	if implementation == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, shadowTimeout)
+	defer cancel()
+	start := time.Now()
+	response, err := runShadow(ctx, implementation, copyEvent(event))
+	log := h.logger.With().
+		Str("route", r.method+" "+r.pattern).
+		Dur("shadow_duration", time.Since(start)).
+		Logger()
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Msg("Shadow implementation failed")
+		return
+	}
+
+	if diffs := compareResponses(primary, response); len(diffs) > 0 {
+		log.Warn().
+			Strs("diffs", diffs).
+			Msg("Shadow response differs")
+		return
+	}
+	log.Debug().Msg("Shadow response matches")
+}
+
+// runShadow calls implementation, turning a panic into an error
+func runShadow(ctx context.Context, implementation routeHandler, event *APIGatewayProxyEvent) (response Response, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return implementation(ctx, event)
+}
+
+// copyEvent copies event so the shadow implementation cannot change what the
+// current one saw
+func copyEvent(event *APIGatewayProxyEvent) *APIGatewayProxyEvent {
+	copied := *event
+	copied.Headers = copyMap(event.Headers)
+	copied.QueryStringParameters = copyMap(event.QueryStringParameters)
+	copied.PathParameters = copyMap(event.PathParameters)
+	return &copied
+}
+
+func copyMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+	return copied
+}
+
+// compareResponses lists how shadow differs from primary: status, content type
+// and, for JSON bodies, each differing value by its path such as
+// $.exercises[0].sets. Other bodies are compared byte for byte
+func compareResponses(primary, shadow Response) []string {
+	var diffs []string
+	if primary.StatusCode != shadow.StatusCode {
+		diffs = append(diffs, fmt.Sprintf("status: %d != %d", primary.StatusCode, shadow.StatusCode))
+	}
+	if primary.Headers["Content-Type"] != shadow.Headers["Content-Type"] {
+		diffs = append(diffs, fmt.Sprintf("Content-Type: %q != %q", primary.Headers["Content-Type"], shadow.Headers["Content-Type"]))
+	}
+
+	var primaryBody, shadowBody interface{}
+	if json.Unmarshal([]byte(primary.Body), &primaryBody) == nil && json.Unmarshal([]byte(shadow.Body), &shadowBody) == nil {
+		diffJSON("$", primaryBody, shadowBody, &diffs)
+	} else if primary.Body != shadow.Body {
+		diffs = append(diffs, "body differs")
+	}
+	if len(diffs) > maxShadowDiffs {
+		diffs = append(diffs[:maxShadowDiffs], fmt.Sprintf("and %d more", len(diffs)-maxShadowDiffs))
+	}
+	return diffs
+}
+
+// diffJSON appends the paths under path where decoded JSON values a and b differ
+func diffJSON(path string, a, b interface{}, diffs *[]string) {
+	if len(*diffs) > maxShadowDiffs {
+		return
+	}
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(a)+len(b))
+		for k := range a {
+			keys = append(keys, k)
+		}
+		for k := range b {
+			if _, ok := a[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			av, inA := a[k]
+			bv, inB := b[k]
+			switch {
+			case !inB:
+				*diffs = append(*diffs, path+"."+k+": missing from shadow")
+			case !inA:
+				*diffs = append(*diffs, path+"."+k+": only in shadow")
+			default:
+				diffJSON(path+"."+k, av, bv, diffs)
+			}
+		}
+		return
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		if len(a) != len(b) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: length %d != %d", path, len(a), len(b)))
+		}
+		for i := 0; i < len(a) && i < len(b); i++ {
+			diffJSON(fmt.Sprintf("%s[%d]", path, i), a[i], b[i], diffs)
+		}
+		return
+	}
+	if !reflect.DeepEqual(a, b) {
+		*diffs = append(*diffs, fmt.Sprintf("%s: %s != %s", path, shortJSON(a), shortJSON(b)))
+	}
+}
+
+// shortJSON renders a value for a diff, truncated so large values do not swamp
+// the log
+func shortJSON(v interface{}) string {
+	data, _ := json.Marshal(v)
+	if len(data) > 40 {
+		return string(bytes.TrimSpace(data[:40])) + "..."
+	}
+	return string(data)
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"athlete-forge/store"
+)
+
+func TestLambdaHandler_Shadow(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("serves the current response and logs where the shadow store differs", func(t *testing.T) {
+		// Arrange
+		var logs bytes.Buffer
+		shadowStore := store.NewMemoryStore()
+		h := NewLambdaHandler(zerolog.New(&logs), WithShadowRoutes("GET /api/workouts/{id}"), WithShadowStore(shadowStore))
+		created := createWorkout(t, h, "user-1", `{"exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]}]}`)
+		var w struct {
+			ID string `json:"id"`
+		}
+		json.Unmarshal([]byte(created.Body), &w)
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("GET", "/api/workouts/"+w.ID, "user-1", nil, ""))
+
+		// Assert
+		if err != nil || response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d, %v", response.StatusCode, err)
+		}
+		if !strings.Contains(logs.String(), "Shadow response differs") || !strings.Contains(logs.String(), "status: 200 != 404") {
+			t.Errorf("expected the status difference to be logged, got %s", logs.String())
+		}
+		items, _ := shadowStore.Query(ctx, store.UserPK("user-1"), "")
+		if len(items) != 0 {
+			t.Errorf("expected writes to reach only the current store, got %d shadow items", len(items))
+		}
+	})
+
+	t.Run("a failing shadow implementation does not affect the response", func(t *testing.T) {
+		// Arrange
+		var logs bytes.Buffer
+		h := NewLambdaHandler(zerolog.New(&logs), WithShadowRoutes("GET /api/workouts"))
+		for i := range h.routes {
+			if h.routes[i].method == "GET" && h.routes[i].pattern == "/api/workouts" {
+				h.routes[i].shadow = func(context.Context, *APIGatewayProxyEvent) (Response, error) {
+					panic("not implemented")
+				}
+			}
+		}
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("GET", "/api/workouts", "user-1", nil, ""))
+
+		// Assert
+		if err != nil || response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d, %v", response.StatusCode, err)
+		}
+		if !strings.Contains(logs.String(), "Shadow implementation failed") {
+			t.Errorf("expected the failure to be logged, got %s", logs.String())
+		}
+	})
+
+	t.Run("write routes are never shadowed", func(t *testing.T) {
+		// Arrange
+		var logs bytes.Buffer
+		shadowStore := store.NewMemoryStore()
+		h := NewLambdaHandler(zerolog.New(&logs), WithShadowRoutes("POST /api/workouts"), WithShadowStore(shadowStore))
+
+		// Act
+		createWorkout(t, h, "user-1", `{"exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]}]}`)
+
+		// Assert
+		if !strings.Contains(logs.String(), "Only GET routes can be shadowed") {
+			t.Errorf("expected a startup warning, got %s", logs.String())
+		}
+		items, _ := shadowStore.Query(ctx, store.UserPK("user-1"), "")
+		if len(items) != 0 || strings.Contains(logs.String(), "Shadow response") {
+			t.Errorf("expected the write not to be shadowed, got %d shadow items", len(items))
+		}
+	})
+}
+
+func TestCompareResponses(t *testing.T) {
+	jsonHeaders := map[string]string{"Content-Type": "application/json"}
+	tests := []struct {
+		name    string
+		primary Response
+		shadow  Response
+		want    []string
+	}{
+		{
+			name:    "equal JSON in a different key order",
+			primary: Response{StatusCode: 200, Headers: jsonHeaders, Body: `{"a":1,"b":[1,2]}`},
+			shadow:  Response{StatusCode: 200, Headers: jsonHeaders, Body: `{"b":[1,2],"a":1}`},
+		},
+		{
+			name:    "differing values by path",
+			primary: Response{StatusCode: 200, Headers: jsonHeaders, Body: `{"sets":[{"reps":5},{"reps":5}],"name":"Squat"}`},
+			shadow:  Response{StatusCode: 200, Headers: jsonHeaders, Body: `{"sets":[{"reps":5},{"reps":3}],"notes":""}`},
+			want:    []string{"$.name: missing from shadow", "$.notes: only in shadow", "$.sets[1].reps: 5 != 3"},
+		},
+		{
+			name:    "array lengths",
+			primary: Response{StatusCode: 200, Headers: jsonHeaders, Body: `[1,2]`},
+			shadow:  Response{StatusCode: 200, Headers: jsonHeaders, Body: `[1]`},
+			want:    []string{"$: length 2 != 1"},
+		},
+		{
+			name:    "status, content type and raw bodies",
+			primary: Response{StatusCode: 200, Headers: map[string]string{"Content-Type": "text/csv"}, Body: "a,b"},
+			shadow:  Response{StatusCode: 500, Headers: jsonHeaders, Body: `{"error":"boom"}`},
+			want:    []string{"status: 200 != 500", `Content-Type: "text/csv" != "application/json"`, "body differs"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got := compareResponses(tt.primary, tt.shadow)
+
+			// Assert
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
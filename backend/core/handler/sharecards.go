@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"athlete-forge/jobs"
+	"athlete-forge/sharecard"
+	"athlete-forge/store"
+)
+
+// shareCardLinkExpiry is how long share card image links stay valid
+const shareCardLinkExpiry = time.Hour
+
+// handleGetShareCard returns the share card of a completed workout, for clients
+// to draw a share image from
+func (h *LambdaHandler) handleGetShareCard(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	card, err := h.shareCard(ctx, userID, event.PathParameters["id"])
+	if errResponse := h.shareCardError(err); errResponse != nil {
+		return *errResponse, nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, card)
+}
+
+// handleCreateShareCardImage queues rendering of a completed workout's share
+// card as a PNG, replacing any image rendered before
+func (h *LambdaHandler) handleCreateShareCardImage(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	workoutID := event.PathParameters["id"]
+	_, err := h.shareCard(ctx, userID, workoutID)
+	if errResponse := h.shareCardError(err); errResponse != nil {
+		return *errResponse, nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	now := time.Now().UTC()
+	job := jobs.New(userID, JobRenderShareCard, now)
+	job.SubjectID = workoutID
+	image := &sharecard.Image{
+		WorkoutID: workoutID,
+		UserID:    userID,
+		Status:    sharecard.ImagePending,
+		JobID:     job.ID,
+		CreatedAt: now,
+	}
+	if err := h.shareCards.SaveImage(ctx, image); err != nil {
+		return Response{}, err
+	}
+	if err := h.enqueue(ctx, job, JobEvent{Job: JobRenderShareCard, UserID: userID, ID: workoutID}); err != nil {
+		return Response{}, err
+	}
+
+	// Jobs run in-process locally, so the image may already be rendered
+	current, err := h.shareCards.GetImage(ctx, userID, workoutID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.shareCardImageResponse(ctx, 202, current)
+}
+
+// handleGetShareCardImage returns a share card image's status and, once
+// rendered, a download link
+func (h *LambdaHandler) handleGetShareCardImage(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	image, err := h.shareCards.GetImage(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Share card image not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	return h.shareCardImageResponse(ctx, 200, image)
+}
+
+// shareCardImageResponse adds a fresh pre-signed download link to rendered images
+func (h *LambdaHandler) shareCardImageResponse(ctx context.Context, status int, image *sharecard.Image) (Response, error) {
+	if image.Status == sharecard.ImageReady {
+		link, err := h.blobs.URL(ctx, image.Key, shareCardLinkExpiry)
+		if err != nil {
+			return Response{}, fmt.Errorf("failed to sign share card link: %w", err)
+		}
+		image.DownloadURL = link
+	}
+	return h.createJSONResponse(status, image)
+}
+
+// shareCard builds the card of userID's workout, with personal records found
+// against the same bodyweight-adjusted history reports use
+func (h *LambdaHandler) shareCard(ctx context.Context, userID, workoutID string) (*sharecard.Card, error) {
+	w, err := h.workouts.Get(ctx, userID, workoutID)
+	if err != nil {
+		return nil, err
+	}
+	in, err := h.summaryInput(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	p, err := h.profiles.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return sharecard.Build(w, in.Workouts, p.Unit)
+}
+
+// shareCardError returns the response for errors building a share card that
+// are the client's to fix, or nil for other errors
+func (h *LambdaHandler) shareCardError(err error) *Response {
+	var response Response
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		response = h.createErrorResponse(404, "Workout not found")
+	case errors.Is(err, sharecard.ErrNotCompleted):
+		response = h.createErrorResponse(400, err.Error())
+	default:
+		return nil
+	}
+	return &response
+}
+
+// runRenderShareCard renders a workout's share card to the blob store;
+// rendering failures are recorded on the image so the client stops polling
+func (h *LambdaHandler) runRenderShareCard(ctx context.Context, userID, workoutID string) (JobResult, error) {
+	result := JobResult{Job: JobRenderShareCard}
+	image, err := h.shareCards.GetImage(ctx, userID, workoutID)
+	if err != nil {
+		return result, fmt.Errorf("failed to load share card image %s: %w", workoutID, err)
+	}
+	if image.Status != sharecard.ImagePending {
+		return result, nil
+	}
+
+	renderErr := h.renderShareCard(ctx, image)
+	now := time.Now().UTC()
+	image.CompletedAt = &now
+	if renderErr != nil {
+		image.Status = sharecard.ImageFailed
+		image.Error = "Share card could not be generated"
+		result.Error = image.Error
+		result.Failed++
+		h.logger.Error().
+			Err(renderErr).
+			Str("user_id", userID).
+			Str("workout_id", workoutID).
+			Msg("Failed to render share card")
+	} else {
+		image.Status = sharecard.ImageReady
+		result.Processed++
+	}
+
+	if err := h.shareCards.SaveImage(ctx, image); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// renderShareCard builds the card for image's workout and uploads it as a PNG
+func (h *LambdaHandler) renderShareCard(ctx context.Context, image *sharecard.Image) error {
+	card, err := h.shareCard(ctx, image.UserID, image.WorkoutID)
+	if err != nil {
+		return err
+	}
+	data, err := sharecard.Render(card)
+	if err != nil {
+		return err
+	}
+	image.Key = image.ObjectKey()
+	return h.blobs.Put(ctx, image.Key, sharecard.ContentType, data)
+}
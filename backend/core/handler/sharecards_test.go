@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"athlete-forge/blob"
+	"athlete-forge/sharecard"
+	"athlete-forge/workout"
+)
+
+// createCompletedWorkout creates a completed workout for user-1
+func createCompletedWorkout(t *testing.T, h *LambdaHandler, body string) *workout.Workout {
+	t.Helper()
+	var completed CompletionResponse
+	json.Unmarshal([]byte(createWorkout(t, h, "user-1", body).Body), &completed)
+	if completed.Workout == nil || completed.Workout.ID == "" {
+		t.Fatal("expected the workout to be created")
+	}
+	return completed.Workout
+}
+
+func TestLambdaHandler_ShareCards(t *testing.T) {
+	ctx := context.Background()
+	completedBody := `{"status":"completed","exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100},{"reps":3,"weight":110}]}]}`
+
+	t.Run("returns the card of a completed workout", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		w := createCompletedWorkout(t, h, completedBody)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/workouts/"+w.ID+"/share-card", "user-1", nil, ""))
+
+		// Assert
+		if response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", response.StatusCode, response.Body)
+		}
+		var card sharecard.Card
+		json.Unmarshal([]byte(response.Body), &card)
+		if card.WorkoutID != w.ID || card.Sets != 2 || card.Volume != 830 || card.Unit != "kg" {
+			t.Errorf("unexpected card: %s", response.Body)
+		}
+		if len(card.TopSets) != 1 || card.TopSets[0].Weight != 110 {
+			t.Errorf("unexpected top sets: %+v", card.TopSets)
+		}
+	})
+
+	t.Run("rejects workouts that are not completed", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		var w workout.Workout
+		json.Unmarshal([]byte(createWorkout(t, h, "user-1", `{"exercises":[{"name":"Squat","sets":[]}]}`).Body), &w)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/workouts/"+w.ID+"/share-card", "user-1", nil, ""))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("hides other users' workouts", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		w := createCompletedWorkout(t, h, completedBody)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/workouts/"+w.ID+"/share-card", "user-2", nil, ""))
+		image, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/workouts/"+w.ID+"/share-card/image", "user-2", nil, ""))
+
+		// Assert
+		if response.StatusCode != 404 || image.StatusCode != 404 {
+			t.Errorf("expected status codes 404, got %d and %d", response.StatusCode, image.StatusCode)
+		}
+	})
+
+	t.Run("renders the image and links to it", func(t *testing.T) {
+		// Arrange
+		blobs := blob.NewMemoryStore()
+		h := NewLambdaHandler(zerolog.Nop(), WithBlobStore(blobs))
+		w := createCompletedWorkout(t, h, completedBody)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/workouts/"+w.ID+"/share-card/image", "user-1", nil, ""))
+		status, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/workouts/"+w.ID+"/share-card/image", "user-1", nil, ""))
+
+		// Assert
+		if response.StatusCode != 202 {
+			t.Fatalf("expected status code 202, got %d: %s", response.StatusCode, response.Body)
+		}
+		var image sharecard.Image
+		json.Unmarshal([]byte(status.Body), &image)
+		if image.Status != sharecard.ImageReady || !strings.HasPrefix(image.DownloadURL, "memory://") {
+			t.Errorf("unexpected image: %s", status.Body)
+		}
+		obj, ok := blobs.Get("share-cards/user-1/" + w.ID + ".png")
+		if !ok || obj.ContentType != "image/png" || !bytes.HasPrefix(obj.Data, []byte("\x89PNG")) {
+			t.Error("expected rendered PNG in the blob store")
+		}
+	})
+
+	t.Run("queues rendering when a dispatcher is configured", func(t *testing.T) {
+		// Arrange
+		dispatcher := &recordingDispatcher{}
+		h := NewLambdaHandler(zerolog.Nop(), WithDispatcher(dispatcher))
+		w := createCompletedWorkout(t, h, completedBody)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/workouts/"+w.ID+"/share-card/image", "user-1", nil, ""))
+		var image sharecard.Image
+		json.Unmarshal([]byte(response.Body), &image)
+		h.HandleRequest(ctx, dispatcher.events[len(dispatcher.events)-1])
+		rendered, _ := h.shareCards.GetImage(ctx, "user-1", w.ID)
+
+		// Assert
+		if image.Status != sharecard.ImagePending || image.DownloadURL != "" {
+			t.Errorf("expected pending image, got %s", response.Body)
+		}
+		if job := dispatcher.events[len(dispatcher.events)-1]; job != (JobEvent{Job: JobRenderShareCard, UserID: "user-1", ID: w.ID, JobID: image.JobID}) {
+			t.Errorf("unexpected dispatched event: %+v", job)
+		}
+		if rendered.Status != sharecard.ImageReady {
+			t.Errorf("expected ready image, got %+v", rendered)
+		}
+	})
+
+	t.Run("reports images that were never requested", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("GET", "/api/workouts/w1/share-card/image", "user-1", nil, ""))
+
+		// Assert
+		if response.StatusCode != 404 {
+			t.Errorf("expected status code 404, got %d", response.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"athlete-forge/program"
+	"athlete-forge/share"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+// CreateShareRequest is the body for sharing one of the user's planned or active
+// workouts, or one of their programs as a template
+type CreateShareRequest struct {
+	WorkoutID string `json:"workoutId"`
+	ProgramID string `json:"programId"`
+}
+
+// handleCreateShare creates a short-lived code another device can redeem for a
+// copy of a pending workout or a program template, to show as a QR code or read
+// out
+func (h *LambdaHandler) handleCreateShare(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	var req CreateShareRequest
+	if err := decodeBody(event, &req); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if (req.WorkoutID == "") == (req.ProgramID == "") {
+		return h.createErrorResponse(400, "share either a workoutId or a programId"), nil
+	}
+
+	s := &share.Share{OwnerID: userID}
+	if req.WorkoutID != "" {
+		w, err := h.workouts.Get(ctx, userID, req.WorkoutID)
+		if errors.Is(err, store.ErrNotFound) {
+			return h.createErrorResponse(404, "Workout not found"), nil
+		}
+		if err != nil {
+			return Response{}, err
+		}
+		if w.Status == workout.StatusCompleted {
+			return h.createErrorResponse(400, "only planned or active workouts can be shared"), nil
+		}
+		s.Kind = share.KindWorkout
+		s.Workout = share.FromWorkout(w)
+	} else {
+		p, err := h.programs.Get(ctx, userID, req.ProgramID)
+		if errors.Is(err, store.ErrNotFound) {
+			return h.createErrorResponse(404, "Program not found"), nil
+		}
+		if err != nil {
+			return Response{}, err
+		}
+		t := program.ToTemplate(p)
+		s.Kind = share.KindTemplate
+		s.Template = &t
+	}
+
+	if err := h.shares.Create(ctx, s, time.Now().UTC()); err != nil {
+		return Response{}, err
+	}
+
+	h.logger.Info().
+		Str("function", "handleCreateShare").
+		Str("user_id", userID).
+		Str("kind", s.Kind).
+		Msg("Share code created")
+
+	return h.createJSONResponse(201, s)
+}
+
+// handleGetShare previews what a share code holds before it is redeemed
+func (h *LambdaHandler) handleGetShare(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	if _, errResponse := h.requireUser(event); errResponse != nil {
+		return *errResponse, nil
+	}
+
+	s, err := h.shares.Get(ctx, event.PathParameters["code"], time.Now().UTC())
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Share code not found or expired"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, s)
+}
+
+// handleRedeemShare copies what a share code holds into the user's account: a
+// workout is planned for now, ready to start, and a template starts a program
+// for the gym in ?gymId= or the user's default gym. Codes are used up once
+// redeemed
+func (h *LambdaHandler) handleRedeemShare(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	now := time.Now().UTC()
+	s, err := h.shares.Get(ctx, event.PathParameters["code"], now)
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Share code not found or expired"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	switch s.Kind {
+	case share.KindWorkout:
+		w := workout.Workout{
+			UserID:      userID,
+			Status:      workout.StatusPlanned,
+			ScheduledAt: &now,
+			Exercises:   s.Workout.Exercises,
+			Groups:      s.Workout.Groups,
+			Notes:       s.Workout.Notes,
+		}
+		if err := w.Validate(); err != nil {
+			return h.createErrorResponse(400, err.Error()), nil
+		}
+		if err := h.workouts.Save(ctx, &w); err != nil {
+			return Response{}, err
+		}
+		response, err = h.createJSONResponse(201, w)
+	case share.KindTemplate:
+		response, err = h.importTemplate(ctx, userID, *s.Template, event.QueryStringParameters["gymId"])
+	}
+	if err != nil || response.StatusCode != 201 {
+		return response, err
+	}
+
+	// Two devices redeeming at once can both get a copy; the code is still gone
+	// after either
+	if err := h.shares.Delete(ctx, s); err != nil {
+		return Response{}, err
+	}
+
+	h.logger.Info().
+		Str("function", "handleRedeemShare").
+		Str("user_id", userID).
+		Str("owner_id", s.OwnerID).
+		Str("kind", s.Kind).
+		Msg("Share code redeemed")
+
+	return response, nil
+}
+
+// handleDeleteShare withdraws one of the user's share codes before it is redeemed
+func (h *LambdaHandler) handleDeleteShare(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	s, err := h.shares.Get(ctx, event.PathParameters["code"], time.Now().UTC())
+	if errors.Is(err, store.ErrNotFound) || (err == nil && s.OwnerID != userID) {
+		return h.createErrorResponse(404, "Share code not found or expired"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	if err := h.shares.Delete(ctx, s); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, s)
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"athlete-forge/share"
+	"athlete-forge/workout"
+)
+
+// createShare shares a workout or program through the API and returns the share
+func createShare(t *testing.T, h *LambdaHandler, userID, body string) share.Share {
+	t.Helper()
+	response, err := h.HandleRequest(context.Background(), apiEvent("POST", "/api/shares", userID, nil, body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.StatusCode != 201 {
+		t.Fatalf("expected status code 201, got %d: %s", response.StatusCode, response.Body)
+	}
+	var s share.Share
+	json.Unmarshal([]byte(response.Body), &s)
+	return s
+}
+
+func TestLambdaHandler_Shares(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("a pending workout is redeemed once as a planned workout on another account", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		created := createWorkout(t, h, "coach", `{"notes":"Keep rests short","exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]}]}`)
+		var w workout.Workout
+		json.Unmarshal([]byte(created.Body), &w)
+		s := createShare(t, h, "coach", fmt.Sprintf(`{"workoutId":%q}`, w.ID))
+
+		// Act
+		preview, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/shares/"+s.Code, "athlete", nil, ""))
+		redeemed, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/shares/"+s.Code+"/redeem", "athlete", nil, ""))
+		again, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/shares/"+s.Code+"/redeem", "athlete", nil, ""))
+
+		// Assert
+		if preview.StatusCode != 200 {
+			t.Errorf("expected status code 200, got %d", preview.StatusCode)
+		}
+		var copied workout.Workout
+		json.Unmarshal([]byte(redeemed.Body), &copied)
+		if redeemed.StatusCode != 201 || copied.UserID != "athlete" || copied.Status != workout.StatusPlanned || copied.Notes != "Keep rests short" || len(copied.Exercises) != 1 {
+			t.Errorf("unexpected redeemed workout %d: %s", redeemed.StatusCode, redeemed.Body)
+		}
+		if again.StatusCode != 404 {
+			t.Errorf("expected the code used up, got %d", again.StatusCode)
+		}
+	})
+
+	t.Run("a program is redeemed as a template", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "coach", linearProgramBody)
+		s := createShare(t, h, "coach", fmt.Sprintf(`{"programId":%q}`, p.ID))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/shares/"+s.Code+"/redeem", "athlete", nil, ""))
+
+		// Assert
+		var imported ProgramImportResponse
+		json.Unmarshal([]byte(response.Body), &imported)
+		if response.StatusCode != 201 || imported.UserID != "athlete" || imported.Name != "Linear" {
+			t.Errorf("unexpected import %d: %s", response.StatusCode, response.Body)
+		}
+	})
+
+	t.Run("completed workouts and others' content cannot be shared", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		created := createWorkout(t, h, "coach", `{"status":"completed","exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]}]}`)
+		var completion CompletionResponse
+		json.Unmarshal([]byte(created.Body), &completion)
+
+		// Act
+		completed, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/shares", "coach", nil, fmt.Sprintf(`{"workoutId":%q}`, completion.Workout.ID)))
+		other, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/shares", "athlete", nil, fmt.Sprintf(`{"workoutId":%q}`, completion.Workout.ID)))
+		both, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/shares", "coach", nil, `{"workoutId":"a","programId":"b"}`))
+
+		// Assert
+		if completed.StatusCode != 400 || other.StatusCode != 404 || both.StatusCode != 400 {
+			t.Errorf("unexpected statuses: completed %d, other %d, both %d", completed.StatusCode, other.StatusCode, both.StatusCode)
+		}
+	})
+
+	t.Run("only the owner can withdraw a code", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "coach", linearProgramBody)
+		s := createShare(t, h, "coach", fmt.Sprintf(`{"programId":%q}`, p.ID))
+
+		// Act
+		other, _ := h.HandleRequest(ctx, apiEvent("DELETE", "/api/shares/"+s.Code, "athlete", nil, ""))
+		own, _ := h.HandleRequest(ctx, apiEvent("DELETE", "/api/shares/"+s.Code, "coach", nil, ""))
+		redeemed, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/shares/"+s.Code+"/redeem", "athlete", nil, ""))
+
+		// Assert
+		if other.StatusCode != 404 || own.StatusCode != 200 || redeemed.StatusCode != 404 {
+			t.Errorf("unexpected statuses: other %d, own %d, redeemed %d", other.StatusCode, own.StatusCode, redeemed.StatusCode)
+		}
+	})
+}
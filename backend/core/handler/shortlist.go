@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"athlete-forge/shortlist"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+// handleListFavoriteExercises returns the user's favorite exercises, most
+// recently added first
+func (h *LambdaHandler) handleListFavoriteExercises(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	s, err := h.shortlists.Get(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, s.Favorites)
+}
+
+// handleRecentExercises returns the catalog exercises the user trained most
+// recently, most recent first
+func (h *LambdaHandler) handleRecentExercises(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	s, err := h.shortlists.Get(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, s.Recent)
+}
+
+// handleFavoriteExercise adds a catalog exercise to the user's favorites,
+// returning them
+func (h *LambdaHandler) handleFavoriteExercise(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	e, ok := catalogExercise(event)
+	if !ok {
+		return h.createErrorResponse(404, "Exercise not found"), nil
+	}
+
+	favorites, err := h.shortlists.AddFavorite(ctx, userID, e.Name, time.Now().UTC())
+	if errors.Is(err, shortlist.ErrTooManyFavorites) {
+		return h.createErrorResponse(409, err.Error()), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, favorites)
+}
+
+// handleUnfavoriteExercise removes a catalog exercise from the user's
+// favorites, returning them
+func (h *LambdaHandler) handleUnfavoriteExercise(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	e, ok := catalogExercise(event)
+	if !ok {
+		return h.createErrorResponse(404, "Exercise not found"), nil
+	}
+
+	favorites, err := h.shortlists.RemoveFavorite(ctx, userID, e.Name)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, favorites)
+}
+
+// recordExerciseUse queues recording the exercises w trains as the user's
+// recent exercises, unless the table's stream records them. Nothing is queued
+// when before, the workout as it was, already trained them at the same time, so
+// logging sets does not queue a job each. The workout is saved, so a failure to
+// queue is only logged
+func (h *LambdaHandler) recordExerciseUse(ctx context.Context, before, w *workout.Workout) {
+	if h.streamDerived {
+		return
+	}
+	uses := shortlist.Uses(*w)
+	if len(uses) == 0 {
+		return
+	}
+	if before != nil {
+		previous := shortlist.Shortlist{Recent: shortlist.Uses(*before)}
+		if previous.Covers(uses) {
+			return
+		}
+	}
+	if err := h.dispatch(ctx, JobEvent{Job: JobRecordExerciseUse, UserID: w.UserID, ID: w.ID, ClientRequestID: clientRequestID(ctx)}); err != nil {
+		h.logger.Warn().
+			Err(err).
+			Str("user_id", w.UserID).
+			Str("workout_id", w.ID).
+			Msg("Failed to queue recording exercise use")
+	}
+}
+
+// runRecordExerciseUse records the exercises a workout trains as its user's
+// recent exercises; a workout deleted since is skipped
+func (h *LambdaHandler) runRecordExerciseUse(ctx context.Context, userID, workoutID string) (JobResult, error) {
+	result := JobResult{Job: JobRecordExerciseUse}
+	w, err := h.workouts.Get(ctx, userID, workoutID)
+	if errors.Is(err, store.ErrNotFound) {
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+	if err := h.shortlists.RecordUses(ctx, userID, shortlist.Uses(*w)); err != nil {
+		return result, err
+	}
+	result.Processed = 1
+	return result, nil
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"athlete-forge/shortlist"
+	"athlete-forge/workout"
+)
+
+func TestLambdaHandler_ExerciseShortlist(t *testing.T) {
+	ctx := context.Background()
+	search := func(h *LambdaHandler, q string) []CatalogExercise {
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/exercises", "user-1", map[string]string{"q": q}, ""))
+		var results []CatalogExercise
+		json.Unmarshal([]byte(response.Body), &results)
+		return results
+	}
+
+	t.Run("favorites and unfavorites catalog exercises", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		favorited, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/exercises/goblet-squat/favorite", "user-1", nil, ""))
+		unknown, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/exercises/moon-squat/favorite", "user-1", nil, ""))
+		listed, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/exercises/favorites", "user-1", nil, ""))
+		unfavorited, _ := h.HandleRequest(ctx, apiEvent("DELETE", "/api/exercises/goblet-squat/favorite", "user-1", nil, ""))
+
+		// Assert
+		if favorited.StatusCode != 200 || unknown.StatusCode != 404 {
+			t.Fatalf("expected 200 and 404, got %d and %d", favorited.StatusCode, unknown.StatusCode)
+		}
+		var favorites []shortlist.Favorite
+		json.Unmarshal([]byte(listed.Body), &favorites)
+		if len(favorites) != 1 || favorites[0].Exercise != "goblet squat" {
+			t.Errorf("expected goblet squat favorited, got %s", listed.Body)
+		}
+		if unfavorited.StatusCode != 200 || unfavorited.Body != "[]" {
+			t.Errorf("expected no favorites left, got %d %s", unfavorited.StatusCode, unfavorited.Body)
+		}
+	})
+
+	t.Run("records recent exercises from workout writes", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		createWorkout(t, h, "user-1", `{"status":"completed","exercises":[{"name":"Front Squat","sets":[{"reps":5,"weight":80}]},{"name":"Lunge","sets":[{"reps":10,"weight":20}]}]}`)
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/exercises/recent", "user-1", nil, ""))
+
+		// Assert
+		var recent []shortlist.Use
+		json.Unmarshal([]byte(response.Body), &recent)
+		if len(recent) != 2 || recent[0].Exercise != "front squat" || recent[1].Exercise != "lunge" {
+			t.Errorf("expected front squat and lunge, got %s", response.Body)
+		}
+	})
+
+	t.Run("orders search results by favorites, then recent use", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		createWorkout(t, h, "user-1", `{"status":"completed","exercises":[{"name":"Front Squat","sets":[{"reps":5,"weight":80}]}]}`)
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/exercises/goblet-squat/favorite", "user-1", nil, ""))
+
+		// Act
+		results := search(h, "squat")
+
+		// Assert
+		if len(results) < 4 || results[0].Name != "goblet squat" || results[1].Name != "front squat" || results[2].Name != "back squat" {
+			t.Fatalf("unexpected order: %+v", results)
+		}
+		if !results[0].Favorite || results[0].LastUsedAt != nil || results[1].Favorite || results[1].LastUsedAt == nil {
+			t.Errorf("expected favorite and last use flagged, got %+v and %+v", results[0], results[1])
+		}
+	})
+
+	t.Run("only queues a job when a write adds a use", func(t *testing.T) {
+		// Arrange
+		dispatcher := &recordingDispatcher{}
+		h := NewLambdaHandler(zerolog.Nop(), WithDispatcher(dispatcher))
+		var w workout.Workout
+		json.Unmarshal([]byte(createWorkout(t, h, "user-1", `{"status":"active","exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]}]}`).Body), &w)
+
+		// Act
+		h.HandleRequest(ctx, apiEvent("PATCH", fmt.Sprintf("/api/workouts/%s/exercises/0/sets/0", w.ID), "user-1", nil, `{"reps":6}`))
+		h.HandleRequest(ctx, apiEvent("PATCH", "/api/workouts/"+w.ID, "user-1", nil, `{"exercises":[{"name":"Squat","sets":[{"reps":6,"weight":100}]},{"name":"Lunge","sets":[]}]}`))
+
+		// Assert
+		if len(dispatcher.events) != 2 {
+			t.Fatalf("expected jobs for creating the workout and adding lunges, got %+v", dispatcher.events)
+		}
+		for _, event := range dispatcher.events {
+			if job := event.(JobEvent); job.Job != JobRecordExerciseUse || job.UserID != "user-1" || job.ID != w.ID {
+				t.Errorf("unexpected job %+v", job)
+			}
+		}
+	})
+
+	t.Run("leaves recent exercises to the stream", func(t *testing.T) {
+		// Arrange
+		h := NewLambdaHandler(zerolog.Nop(), WithStreamDerivedData())
+		at := time.Now().UTC()
+		w := &workout.Workout{ID: "w1", UserID: "user-1", Status: workout.StatusCompleted, StartedAt: at, CompletedAt: &at,
+			Exercises: []workout.Exercise{{Name: "Deadlift", Sets: []workout.Set{{Reps: 5, Weight: 140}}}}}
+		h.workouts.Save(ctx, w)
+		before, _ := h.shortlists.Get(ctx, "user-1")
+
+		// Act
+		_, err := h.HandleRequest(ctx, map[string]interface{}{"Records": []interface{}{streamRecord("INSERT", nil, w)}})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		after, _ := h.shortlists.Get(ctx, "user-1")
+		if len(before.Recent) != 0 || len(after.Recent) != 1 || after.Recent[0].Exercise != "deadlift" {
+			t.Errorf("expected deadlift recorded by the stream only, got %+v then %+v", before.Recent, after.Recent)
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"athlete-forge/marketplace"
+	"athlete-forge/social"
+	"athlete-forge/store"
+)
+
+// handleListRelations returns the users the caller has blocked or muted
+func (h *LambdaHandler) handleListRelations(kind string) routeHandler {
+	return func(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+		userID, errResponse := h.requireUser(event)
+		if errResponse != nil {
+			return *errResponse, nil
+		}
+
+		relations, err := h.social.List(ctx, userID, kind)
+		if err != nil {
+			return Response{}, err
+		}
+		return h.createJSONResponse(200, relations)
+	}
+}
+
+// handleAddRelation blocks or mutes the user in the path. Neither user is told
+func (h *LambdaHandler) handleAddRelation(kind string) routeHandler {
+	return func(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+		userID, errResponse := h.requireUser(event)
+		if errResponse != nil {
+			return *errResponse, nil
+		}
+
+		rel, err := h.social.Add(ctx, userID, event.PathParameters["userId"], kind, time.Now().UTC())
+		if errors.Is(err, social.ErrSelf) {
+			return h.createErrorResponse(400, err.Error()), nil
+		}
+		if err != nil {
+			return Response{}, err
+		}
+
+		h.logger.Info().
+			Str("function", "handleAddRelation").
+			Str("user_id", userID).
+			Str("kind", kind).
+			Msg("User hidden")
+
+		return h.createJSONResponse(200, rel)
+	}
+}
+
+// handleRemoveRelation lifts a block or mute of the user in the path
+func (h *LambdaHandler) handleRemoveRelation(kind string) routeHandler {
+	return func(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+		userID, errResponse := h.requireUser(event)
+		if errResponse != nil {
+			return *errResponse, nil
+		}
+
+		rel, err := h.social.Remove(ctx, userID, event.PathParameters["userId"], kind)
+		if errors.Is(err, store.ErrNotFound) {
+			return h.createErrorResponse(404, "No "+kind+" of this user"), nil
+		}
+		if err != nil {
+			return Response{}, err
+		}
+		return h.createJSONResponse(200, rel)
+	}
+}
+
+// The functions below are the social query layer: every read that shows one
+// user's content to another goes through them, so blocks and mutes are applied
+// in one place rather than by each endpoint
+
+// socialListings returns the published marketplace listings viewerID may see
+func (h *LambdaHandler) socialListings(ctx context.Context, viewerID string) ([]marketplace.Listing, error) {
+	filter, err := h.social.Filter(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	listings, err := h.marketplace.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	visible := []marketplace.Listing{}
+	for _, l := range listings {
+		if l.Status == marketplace.StatusPublished && !filter.Hides(l.AuthorID) {
+			visible = append(visible, l)
+		}
+	}
+	return visible, nil
+}
+
+// socialReviews returns the published reviews of a listing viewerID may see
+func (h *LambdaHandler) socialReviews(ctx context.Context, viewerID, listingID string) ([]marketplace.Review, error) {
+	filter, err := h.social.Filter(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	reviews, err := h.marketplace.ListReviews(ctx, listingID)
+	if err != nil {
+		return nil, err
+	}
+	visible := []marketplace.Review{}
+	for _, review := range reviews {
+		if review.Status == marketplace.StatusPublished && !filter.Hides(review.UserID) {
+			visible = append(visible, review.Public())
+		}
+	}
+	return visible, nil
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"athlete-forge/marketplace"
+	"athlete-forge/social"
+)
+
+func TestLambdaHandler_Social(t *testing.T) {
+	ctx := context.Background()
+
+	// browse returns how many templates userID sees in the marketplace
+	browse := func(h *LambdaHandler, userID string) int {
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/marketplace/templates", userID, nil, ""))
+		var summaries []marketplace.Summary
+		json.Unmarshal([]byte(response.Body), &summaries)
+		return len(summaries)
+	}
+
+	t.Run("a block hides each user's templates from the other", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		publishTemplate(t, h, "user-1", createProgram(t, h, "user-1", linearProgramBody).ID)
+		publishTemplate(t, h, "user-2", createProgram(t, h, "user-2", linearProgramBody).ID)
+
+		// Act
+		blocked, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/social/blocks/user-2", "user-1", nil, ""))
+
+		// Assert
+		if blocked.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", blocked.StatusCode, blocked.Body)
+		}
+		if browse(h, "user-1") != 1 || browse(h, "user-2") != 1 || browse(h, "user-3") != 2 {
+			t.Errorf("expected each user to see only their own template, got %d and %d", browse(h, "user-1"), browse(h, "user-2"))
+		}
+	})
+
+	t.Run("a mute only hides the muted user's content from the muter", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		l := publishTemplate(t, h, "user-1", createProgram(t, h, "user-1", linearProgramBody).ID)
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/marketplace/templates/"+l.ID+"/reviews/mine", "user-2", nil, `{"rating":1,"text":"spam"}`))
+
+		// Act
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/social/mutes/user-2", "user-3", nil, ""))
+		muted, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/marketplace/templates/"+l.ID+"/reviews", "user-3", nil, ""))
+		others, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/marketplace/templates/"+l.ID+"/reviews", "user-1", nil, ""))
+
+		// Assert
+		var reviews []marketplace.Review
+		json.Unmarshal([]byte(muted.Body), &reviews)
+		if len(reviews) != 0 {
+			t.Errorf("expected the muted review to be hidden, got %s", muted.Body)
+		}
+		json.Unmarshal([]byte(others.Body), &reviews)
+		if len(reviews) != 1 {
+			t.Errorf("expected others to see the review, got %s", others.Body)
+		}
+	})
+
+	t.Run("blocked users cannot review the blocker's templates", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		l := publishTemplate(t, h, "user-1", createProgram(t, h, "user-1", linearProgramBody).ID)
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/social/blocks/user-2", "user-1", nil, ""))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/marketplace/templates/"+l.ID+"/reviews/mine", "user-2", nil, `{"rating":1}`))
+
+		// Assert
+		if response.StatusCode != 403 {
+			t.Errorf("expected status code 403, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("lists and lifts blocks", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/social/blocks/user-2", "user-1", nil, ""))
+
+		// Act
+		listed, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/social/blocks", "user-1", nil, ""))
+		lifted, _ := h.HandleRequest(ctx, apiEvent("DELETE", "/api/social/blocks/user-2", "user-1", nil, ""))
+		again, _ := h.HandleRequest(ctx, apiEvent("DELETE", "/api/social/blocks/user-2", "user-1", nil, ""))
+		self, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/social/blocks/user-1", "user-1", nil, ""))
+
+		// Assert
+		var relations []social.Relation
+		json.Unmarshal([]byte(listed.Body), &relations)
+		if len(relations) != 1 || relations[0].UserID != "user-2" || relations[0].Kind != social.KindBlock {
+			t.Errorf("unexpected blocks: %s", listed.Body)
+		}
+		if lifted.StatusCode != 200 || again.StatusCode != 404 || self.StatusCode != 400 {
+			t.Errorf("expected status codes 200, 404 and 400, got %d, %d and %d", lifted.StatusCode, again.StatusCode, self.StatusCode)
+		}
+	})
+}
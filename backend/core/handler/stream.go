@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"athlete-forge/calendar"
+	"athlete-forge/cardio"
+	"athlete-forge/report"
+	"athlete-forge/shortlist"
+	"athlete-forge/store"
+	"athlete-forge/summary"
+	"athlete-forge/workout"
+)
+
+// streamEvent is the batch of changes Lambda receives from the table's stream
+type streamEvent struct {
+	Records []store.StreamRecord `json:"Records"`
+}
+
+// parseStreamEvent returns the records in event when it is a batch from the table's stream
+func parseStreamEvent(event interface{}) ([]store.StreamRecord, bool) {
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		return nil, false
+	}
+	var batch streamEvent
+	if err := json.Unmarshal(eventBytes, &batch); err != nil || len(batch.Records) == 0 {
+		return nil, false
+	}
+	for _, record := range batch.Records {
+		if record.EventSource != "aws:dynamodb" {
+			return nil, false
+		}
+	}
+	return batch.Records, true
+}
+
+// touchUser records userID as active for scheduled jobs, unless the table's
+// stream maintains the index
+func (h *LambdaHandler) touchUser(ctx context.Context, userID string, now time.Time) error {
+	if h.streamDerived {
+		return nil
+	}
+	return h.users.Touch(ctx, userID, now)
+}
+
+// processStream maintains data derived from a batch of table changes: the index
+// of active users, the stored weekly reports of past weeks whose completed
+// workouts or activities changed, the calendar month views of months whose
+// workouts changed and the recent exercises of workouts written. Both the old
+// and new item are considered, so moving a workout between weeks or months
+// rebuilds both. Any failure fails the batch so Lambda retries it; every update
+// is a rebuild or a merge of the latest uses, so retries are safe
+func (h *LambdaHandler) processStream(ctx context.Context, records []store.StreamRecord) (Response, error) {
+	// The passive region's stream carries the active region's replicated writes,
+	// including the derived data it has already maintained
+	passive, err := h.isPassive(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+	if passive {
+		return h.createJSONResponse(200, map[string]int{"records": len(records), "reports": 0})
+	}
+
+	now := time.Now().UTC()
+	active := map[string]bool{}
+	stale := map[string]map[time.Time]bool{}
+	months := map[string]map[string]bool{}
+	uses := map[string][]shortlist.Use{}
+
+	// Weeks start on each user's first day, so the times are kept until
+	// their weeks are worked out
+	markStale := func(userID string, at time.Time) {
+		if stale[userID] == nil {
+			stale[userID] = map[time.Time]bool{}
+		}
+		stale[userID][at] = true
+	}
+
+	markMonth := func(userID string, at time.Time) {
+		if months[userID] == nil {
+			months[userID] = map[string]bool{}
+		}
+		months[userID][calendar.MonthOf(at)] = true
+	}
+
+	for _, record := range records {
+		old, err := h.upgradeItem(record.Old())
+		if err != nil {
+			return Response{}, err
+		}
+		current, err := h.upgradeItem(record.New())
+		if err != nil {
+			return Response{}, err
+		}
+		for _, item := range []*store.Item{old, current} {
+			if item == nil {
+				continue
+			}
+			if w, ok := workout.FromItem(*item); ok {
+				if item == current {
+					uses[w.UserID] = append(uses[w.UserID], shortlist.Uses(*w)...)
+				}
+				if w.ScheduledAt != nil {
+					markMonth(w.UserID, *w.ScheduledAt)
+				}
+				if w.Status == workout.StatusCompleted {
+					markStale(w.UserID, summary.CompletedAt(*w))
+					markMonth(w.UserID, summary.CompletedAt(*w))
+					active[w.UserID] = active[w.UserID] || item == current
+				}
+			}
+			if a, ok := cardio.FromItem(*item); ok {
+				markStale(a.UserID, a.StartTime)
+				active[a.UserID] = active[a.UserID] || item == current
+			}
+		}
+	}
+
+	for userID, touched := range active {
+		if !touched {
+			continue
+		}
+		if err := h.users.Touch(ctx, userID, now); err != nil {
+			return Response{}, err
+		}
+	}
+
+	rebuilt := 0
+	for userID, changed := range stale {
+		n, err := h.rebuildWeeklyReports(ctx, userID, changed, now)
+		if err != nil {
+			return Response{}, err
+		}
+		rebuilt += n
+	}
+
+	views := 0
+	for userID, changed := range months {
+		n, err := h.rebuildMonths(ctx, userID, changed, now)
+		if err != nil {
+			return Response{}, err
+		}
+		views += n
+	}
+
+	for userID, used := range uses {
+		if err := h.shortlists.RecordUses(ctx, userID, used); err != nil {
+			return Response{}, err
+		}
+	}
+
+	h.logger.Info().
+		Int("records", len(records)).
+		Int("reports", rebuilt).
+		Int("months", views).
+		Msg("Stream batch processed")
+
+	return h.createJSONResponse(200, map[string]int{"records": len(records), "reports": rebuilt})
+}
+
+// rebuildWeeklyReports recompiles userID's reports for the weeks containing
+// changed, loading their history once. The current week's report is compiled
+// by the weekly job once it ends
+func (h *LambdaHandler) rebuildWeeklyReports(ctx context.Context, userID string, changed map[time.Time]bool, now time.Time) (int, error) {
+	c, err := h.conventions(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	thisWeek := c.WeekStart(now)
+	weeks := map[time.Time]bool{}
+	for at := range changed {
+		if week := c.WeekStart(at); week.Before(thisWeek) {
+			weeks[week] = true
+		}
+	}
+	if len(weeks) == 0 {
+		return 0, nil
+	}
+
+	in, err := h.summaryInput(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	starts := make([]time.Time, 0, len(weeks))
+	for week := range weeks {
+		starts = append(starts, week)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+
+	for _, week := range starts {
+		w := report.BuildWeekly(userID, week, in, now)
+		if err := h.reports.SaveWeekly(ctx, &w); err != nil {
+			return 0, err
+		}
+	}
+	return len(starts), nil
+}
+
+// rebuildMonths rebuilds userID's stored month views of months, loading their
+// workouts once
+func (h *LambdaHandler) rebuildMonths(ctx context.Context, userID string, months map[string]bool, now time.Time) (int, error) {
+	workouts, err := h.loadedWorkouts(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	for month := range months {
+		start, err := calendar.ParseMonth(month)
+		if err != nil {
+			return 0, err
+		}
+		m := calendar.BuildMonth(userID, start, workouts, now)
+		if err := h.calendars.SaveMonth(ctx, &m); err != nil {
+			return 0, err
+		}
+	}
+	return len(months), nil
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"athlete-forge/summary"
+	"athlete-forge/workout"
+)
+
+// streamRecord builds a stream record for a workout changing from old to
+// current; either may be nil
+func streamRecord(name string, old, current *workout.Workout) map[string]interface{} {
+	image := func(w *workout.Workout) map[string]interface{} {
+		data, _ := json.Marshal(w)
+		return map[string]interface{}{
+			"PK":   map[string]string{"S": "USER#" + w.UserID},
+			"SK":   map[string]string{"S": "WORKOUT#" + w.ID},
+			"data": map[string]string{"S": string(data)},
+		}
+	}
+	dynamodb := map[string]interface{}{}
+	if old != nil {
+		dynamodb["OldImage"] = image(old)
+	}
+	if current != nil {
+		dynamodb["NewImage"] = image(current)
+	}
+	return map[string]interface{}{"eventID": "1", "eventName": name, "eventSource": "aws:dynamodb", "dynamodb": dynamodb}
+}
+
+func TestLambdaHandler_Stream(t *testing.T) {
+	ctx := context.Background()
+	completedWorkout := func(id string, at time.Time) *workout.Workout {
+		return &workout.Workout{ID: id, UserID: "user-1", Status: workout.StatusCompleted, StartedAt: at, CompletedAt: &at,
+			Exercises: []workout.Exercise{{Name: "Squat", Sets: []workout.Set{{Reps: 5, Weight: 100}}}}}
+	}
+
+	t.Run("leaves the user index to the stream", func(t *testing.T) {
+		// Arrange
+		h := NewLambdaHandler(zerolog.Nop(), WithStreamDerivedData())
+		var created workout.Workout
+		json.Unmarshal([]byte(createWorkout(t, h, "user-1", `{"status":"active","exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]}]}`).Body), &created)
+		h.HandleRequest(ctx, apiEvent("POST", "/api/workouts/"+created.ID+"/complete", "user-1", nil, ""))
+		before, _ := h.users.List(ctx)
+		completed, _ := h.workouts.Get(ctx, "user-1", created.ID)
+		event := map[string]interface{}{"Records": []interface{}{streamRecord("MODIFY", &created, completed)}}
+
+		// Act
+		_, err := h.HandleRequest(ctx, event)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		after, _ := h.users.List(ctx)
+		if len(before) != 0 || len(after) != 1 || after[0].UserID != "user-1" {
+			t.Errorf("expected the stream to index the user, got %+v then %+v", before, after)
+		}
+	})
+
+	t.Run("rebuilds the reports of both weeks when a workout moves", func(t *testing.T) {
+		// Arrange
+		h := NewLambdaHandler(zerolog.Nop(), WithStreamDerivedData())
+		lastWeek := summary.WeekStart(time.Now().UTC()).AddDate(0, 0, -7)
+		old := completedWorkout("w1", lastWeek.AddDate(0, 0, -7).Add(10*time.Hour))
+		moved := completedWorkout("w1", lastWeek.Add(10*time.Hour))
+		h.workouts.Save(ctx, moved)
+		event := map[string]interface{}{"Records": []interface{}{
+			streamRecord("MODIFY", old, moved),
+			streamRecord("MODIFY", old, moved),
+		}}
+
+		// Act
+		response, err := h.HandleRequest(ctx, event)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if response.Body != `{"records":2,"reports":2}` {
+			t.Errorf("unexpected response: %s", response.Body)
+		}
+		earlier, _ := h.reports.GetWeekly(ctx, "user-1", lastWeek.AddDate(0, 0, -7).Format("2006-01-02"))
+		later, _ := h.reports.GetWeekly(ctx, "user-1", lastWeek.Format("2006-01-02"))
+		if earlier == nil || later == nil || earlier.Summary.Workouts != 0 || later.Summary.Workouts != 1 {
+			t.Errorf("unexpected reports: %+v %+v", earlier, later)
+		}
+	})
+
+	t.Run("rebuilds the month views a workout changes", func(t *testing.T) {
+		// Arrange
+		h := NewLambdaHandler(zerolog.Nop(), WithStreamDerivedData())
+		at := time.Date(2024, 3, 31, 18, 0, 0, 0, time.UTC)
+		old := completedWorkout("w1", at)
+		moved := completedWorkout("w1", at.Add(24*time.Hour))
+		h.workouts.Save(ctx, moved)
+
+		// Act
+		_, err := h.HandleRequest(ctx, map[string]interface{}{"Records": []interface{}{streamRecord("MODIFY", old, moved)}})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		march, _, _ := h.calendars.GetMonth(ctx, "user-1", "2024-03")
+		april, _, _ := h.calendars.GetMonth(ctx, "user-1", "2024-04")
+		if march == nil || april == nil || march.Completed != 0 || april.Completed != 1 {
+			t.Errorf("unexpected month views: %+v %+v", march, april)
+		}
+	})
+
+	t.Run("ignores the current week and other items", func(t *testing.T) {
+		// Arrange
+		h := NewLambdaHandler(zerolog.Nop(), WithStreamDerivedData())
+		event := map[string]interface{}{"Records": []interface{}{
+			streamRecord("INSERT", nil, completedWorkout("w1", time.Now().UTC())),
+			map[string]interface{}{"eventName": "INSERT", "eventSource": "aws:dynamodb", "dynamodb": map[string]interface{}{
+				"NewImage": map[string]interface{}{"PK": map[string]string{"S": "USERS"}, "SK": map[string]string{"S": "USER#user-1"}, "data": map[string]string{"S": "{}"}},
+			}},
+		}}
+
+		// Act
+		response, err := h.HandleRequest(ctx, event)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if response.Body != `{"records":2,"reports":0}` {
+			t.Errorf("unexpected response: %s", response.Body)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"athlete-forge/bodyweight"
+	"athlete-forge/dailylog"
+	"athlete-forge/summary"
+	"athlete-forge/workout"
+)
+
+// handleWeeklySummary returns workouts, cardio and daily habits for the week
+// containing ?week= (default this week)
+func (h *LambdaHandler) handleWeeklySummary(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	day := time.Now().UTC()
+	if raw := event.QueryStringParameters["week"]; raw != "" {
+		parsed, err := time.Parse(dailylog.DateLayout, raw)
+		if err != nil {
+			return h.createErrorResponse(400, "week must be a date in YYYY-MM-DD format"), nil
+		}
+		day = parsed
+	}
+
+	in, err := h.summaryInput(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, summary.Build(summary.WeekStart(day), in))
+}
+
+// summaryInput loads everything a user's summaries and reports are built from,
+// with bodyweight exercises loaded by what the user weighed on the day
+func (h *LambdaHandler) summaryInput(ctx context.Context, userID string) (summary.Input, error) {
+	workouts, err := h.workouts.List(ctx, userID)
+	if err != nil {
+		return summary.Input{}, err
+	}
+	activities, err := h.activities.List(ctx, userID)
+	if err != nil {
+		return summary.Input{}, err
+	}
+	logs, err := h.dailyLogs.List(ctx, userID, "", "")
+	if err != nil {
+		return summary.Input{}, err
+	}
+	p, err := h.profiles.Get(ctx, userID)
+	if err != nil {
+		return summary.Input{}, err
+	}
+
+	return summary.Input{
+		Workouts:   bodyweight.Load(workouts, bodyweight.NewHistory(logs, p.Bodyweight)),
+		Activities: activities,
+		Logs:       logs,
+		HeartRate:  p.HeartRate,
+	}, nil
+}
+
+// loadedWorkouts lists userID's workouts with bodyweight exercises loaded by
+// what the user weighed on the day, for volume and one-rep max estimates
+func (h *LambdaHandler) loadedWorkouts(ctx context.Context, userID string) ([]workout.Workout, error) {
+	workouts, err := h.workouts.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := h.dailyLogs.List(ctx, userID, "", "")
+	if err != nil {
+		return nil, err
+	}
+	p, err := h.profiles.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return bodyweight.Load(workouts, bodyweight.NewHistory(logs, p.Bodyweight)), nil
+}
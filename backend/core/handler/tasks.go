@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"athlete-forge/jobs"
+)
+
+// Step Functions tasks; the state machine runs start once, step until the state
+// is done, then finish, and fail from any state that errors
+const (
+	TaskStart  = "start"
+	TaskStep   = "step"
+	TaskFinish = "finish"
+	TaskFail   = "fail"
+)
+
+const (
+	// maxStepItems bounds the work done in one step so a step finishes well
+	// within the function timeout even without a deadline on the context
+	maxStepItems = 50
+
+	// stepReserve is the time left before the deadline at which a step stops
+	// taking new items, leaving room to save its progress
+	stepReserve = 30 * time.Second
+
+	// heartbeatInterval spaces task heartbeats well inside the state machine's
+	// 60 second heartbeat timeout
+	heartbeatInterval = 20 * time.Second
+)
+
+// TaskEvent is the payload a task state sends: the task to run, the execution's
+// state and, for tasks using the .waitForTaskToken integration, the token to
+// report heartbeats and the result with
+type TaskEvent struct {
+	Task      string    `json:"task"`
+	TaskToken string    `json:"taskToken,omitempty"`
+	State     TaskState `json:"state"`
+}
+
+// TaskState is the execution's input and every task's output, so each step picks
+// up where the last left off. Cursor counts the items already done and Error is
+// set by the state machine's catcher before the fail task
+type TaskState struct {
+	Job             string     `json:"job"`
+	UserID          string     `json:"userId,omitempty"`
+	ID              string     `json:"id,omitempty"`
+	JobID           string     `json:"jobId,omitempty"`
+	ClientRequestID string     `json:"clientRequestId,omitempty"`
+	StartedAt       time.Time  `json:"startedAt"`
+	Cursor          int        `json:"cursor"`
+	Total           int        `json:"total"`
+	Processed       int        `json:"processed"`
+	Failed          int        `json:"failed"`
+	Done            bool       `json:"done"`
+	Error           *TaskError `json:"error,omitempty"`
+}
+
+// TaskError is the error output Step Functions passes to a catcher
+type TaskError struct {
+	Error string `json:"Error"`
+	Cause string `json:"Cause"`
+}
+
+// stepRunner advances state by as many items as more allows, setting Done once
+// there is nothing left
+type stepRunner func(ctx context.Context, state *TaskState, more func() bool, track progress) error
+
+// stepperFor returns how job runs in steps, or false for jobs that run in one invocation
+func (h *LambdaHandler) stepperFor(job string) (stepRunner, bool) {
+	switch job {
+	case JobRecomputeStats:
+		return h.stepRecomputeStats, true
+	case JobBackfill:
+		return h.stepBackfill, true
+	}
+	return nil, false
+}
+
+// HandleTask is the entry point for Step Functions task states. Tasks invoked
+// with a task token report their result through the callbacks, so the
+// invocation itself always succeeds
+func (h *LambdaHandler) HandleTask(ctx context.Context, event TaskEvent) (TaskState, error) {
+	if id := event.State.ClientRequestID; id != "" {
+		defer h.scope.begin(id)()
+		ctx = withClientRequestID(ctx, id)
+	}
+	state, err := h.runTask(ctx, event)
+	if err != nil {
+		h.logger.Error().
+			Err(err).
+			Str("task", event.Task).
+			Str("job", event.State.Job).
+			Msg("Task failed")
+		r := errorReport(err)
+		r.Job, r.UserID = event.State.Job, event.State.UserID
+		h.report(ctx, r)
+	}
+	if event.TaskToken == "" || h.callbacks == nil {
+		return state, err
+	}
+
+	if err != nil {
+		err = h.callbacks.Fail(ctx, event.TaskToken, "TaskFailed", "Job failed")
+	} else {
+		err = h.callbacks.Succeed(ctx, event.TaskToken, state)
+	}
+	return state, err
+}
+
+// runTask runs one task against the execution's state
+func (h *LambdaHandler) runTask(ctx context.Context, event TaskEvent) (TaskState, error) {
+	state := event.State
+	step, ok := h.stepperFor(state.Job)
+	if !ok {
+		return state, fmt.Errorf("job %q does not run in steps", state.Job)
+	}
+
+	switch event.Task {
+	case TaskStart:
+		if _, err := h.startTracking(ctx, JobEvent{Job: state.Job, UserID: state.UserID, ID: state.ID, JobID: state.JobID}); err != nil {
+			return state, err
+		}
+		state.StartedAt = time.Now().UTC()
+		state.Cursor, state.Processed, state.Failed, state.Done = 0, 0, 0, false
+		return state, nil
+
+	case TaskStep:
+		tracked, err := h.trackedJob(ctx, state)
+		if err != nil {
+			return state, err
+		}
+		err = step(ctx, &state, stepBudget(ctx), h.heartbeat(event.TaskToken, h.reporter(tracked)))
+		return state, err
+
+	case TaskFinish, TaskFail:
+		tracked, err := h.trackedJob(ctx, state)
+		if err != nil {
+			return state, err
+		}
+		var runErr error
+		if event.Task == TaskFail {
+			runErr = errors.New("job failed")
+			if state.Error != nil {
+				h.logger.Error().
+					Str("job", state.Job).
+					Str("error", state.Error.Error).
+					Str("cause", state.Error.Cause).
+					Msg("Stepped job failed")
+			}
+		}
+		result := JobResult{Job: state.Job, Processed: state.Processed, Failed: state.Failed}
+		return state, h.finishTracking(ctx, tracked, result, runErr)
+	}
+	return state, fmt.Errorf("unknown task %q", event.Task)
+}
+
+// trackedJob loads the tracked job for state, returning nil for untracked jobs
+func (h *LambdaHandler) trackedJob(ctx context.Context, state TaskState) (*jobs.Job, error) {
+	if state.JobID == "" {
+		return nil, nil
+	}
+	tracked, err := h.jobs.Get(ctx, state.UserID, state.JobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load job %s: %w", state.JobID, err)
+	}
+	return tracked, nil
+}
+
+// stepBudget allows up to maxStepItems items, stopping early when the context
+// deadline is within stepReserve
+func stepBudget(ctx context.Context) func() bool {
+	deadline, hasDeadline := ctx.Deadline()
+	items := 0
+	return func() bool {
+		if items >= maxStepItems || hasDeadline && time.Until(deadline) < stepReserve {
+			return false
+		}
+		items++
+		return true
+	}
+}
+
+// heartbeat wraps track to also send task heartbeats, at most one per
+// heartbeatInterval; like progress, a failed heartbeat is only logged
+func (h *LambdaHandler) heartbeat(token string, track progress) progress {
+	if token == "" || h.callbacks == nil {
+		return track
+	}
+	var last time.Time
+	return func(ctx context.Context, processed, total int) {
+		track(ctx, processed, total)
+		if time.Since(last) < heartbeatInterval {
+			return
+		}
+		last = time.Now()
+		if err := h.callbacks.Heartbeat(ctx, token); err != nil {
+			h.logger.Warn().
+				Err(err).
+				Msg("Failed to send task heartbeat")
+		}
+	}
+}
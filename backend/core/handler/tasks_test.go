@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"athlete-forge/jobs"
+	"athlete-forge/workout"
+)
+
+// recordingCallbacks collects task callbacks instead of sending them
+type recordingCallbacks struct {
+	heartbeats int
+	outputs    []interface{}
+	failures   []string
+}
+
+func (c *recordingCallbacks) Heartbeat(ctx context.Context, token string) error {
+	c.heartbeats++
+	return nil
+}
+
+func (c *recordingCallbacks) Succeed(ctx context.Context, token string, output interface{}) error {
+	c.outputs = append(c.outputs, output)
+	return nil
+}
+
+func (c *recordingCallbacks) Fail(ctx context.Context, token, code, cause string) error {
+	c.failures = append(c.failures, code)
+	return nil
+}
+
+func TestLambdaHandler_HandleTask(t *testing.T) {
+	ctx := context.Background()
+
+	// startRecompute queues a recompute against a state machine for a user with
+	// workouts going back a year and a half, more than one step can rebuild
+	startRecompute := func(t *testing.T) (*LambdaHandler, TaskState) {
+		t.Helper()
+		machine := &recordingDispatcher{}
+		h := NewLambdaHandler(zerolog.Nop(), WithStateMachine(machine))
+		completed := time.Now().UTC().AddDate(0, 0, -7*80)
+		h.workouts.Save(ctx, &workout.Workout{UserID: "user-1", Status: workout.StatusCompleted, StartedAt: completed, CompletedAt: &completed,
+			Exercises: []workout.Exercise{{Name: "Squat", Sets: []workout.Set{{Reps: 5, Weight: 100}}}}})
+
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/stats/recompute", "user-1", nil, ""))
+		if response.StatusCode != 202 || len(machine.events) != 1 {
+			t.Fatalf("expected an execution to be started, got %d: %s", response.StatusCode, response.Body)
+		}
+		state, ok := machine.events[0].(TaskState)
+		if !ok {
+			t.Fatalf("unexpected execution input: %#v", machine.events[0])
+		}
+		return h, state
+	}
+
+	t.Run("runs a job across steps until done", func(t *testing.T) {
+		// Arrange
+		h, state := startRecompute(t)
+
+		// Act
+		state, err := h.HandleTask(ctx, TaskEvent{Task: TaskStart, State: state})
+		steps := 0
+		for err == nil && !state.Done {
+			state, err = h.HandleTask(ctx, TaskEvent{Task: TaskStep, State: state})
+			steps++
+		}
+		if err == nil {
+			state, err = h.HandleTask(ctx, TaskEvent{Task: TaskFinish, State: state})
+		}
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if steps != 2 || state.Total < 80 || state.Processed != state.Total {
+			t.Errorf("expected %d weeks in 2 steps, got %d steps: %+v", state.Total, steps, state)
+		}
+		j, _ := h.jobs.Get(ctx, "user-1", state.JobID)
+		if j.Status != jobs.StatusSucceeded || j.Processed != state.Total || j.Attempts != 1 {
+			t.Errorf("unexpected job: %+v", j)
+		}
+		stored, _ := h.reports.ListWeekly(ctx, "user-1")
+		if len(stored) != state.Total {
+			t.Errorf("expected %d stored reports, got %d", state.Total, len(stored))
+		}
+	})
+
+	t.Run("reports heartbeats and the result for task token invocations", func(t *testing.T) {
+		// Arrange
+		h, state := startRecompute(t)
+		callbacks := &recordingCallbacks{}
+		h.callbacks = callbacks
+		state, _ = h.HandleTask(ctx, TaskEvent{Task: TaskStart, State: state})
+
+		// Act
+		_, err := h.HandleTask(ctx, TaskEvent{Task: TaskStep, TaskToken: "token-1", State: state})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if callbacks.heartbeats != 1 || len(callbacks.outputs) != 1 {
+			t.Fatalf("expected a heartbeat and a result, got %+v", callbacks)
+		}
+		body, _ := json.Marshal(callbacks.outputs[0])
+		var output TaskState
+		json.Unmarshal(body, &output)
+		if output.Cursor != maxStepItems || output.Done {
+			t.Errorf("unexpected output: %s", body)
+		}
+	})
+
+	t.Run("fails the tracked job when the execution fails", func(t *testing.T) {
+		// Arrange
+		h, state := startRecompute(t)
+		state, _ = h.HandleTask(ctx, TaskEvent{Task: TaskStart, State: state})
+		state.Error = &TaskError{Error: "States.Timeout", Cause: "heartbeat timed out"}
+
+		// Act
+		_, err := h.HandleTask(ctx, TaskEvent{Task: TaskFail, State: state})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		j, _ := h.jobs.Get(ctx, "user-1", state.JobID)
+		if j.Status != jobs.StatusFailed || j.Error != "Job failed" {
+			t.Errorf("unexpected job: %+v", j)
+		}
+	})
+
+	t.Run("rejects jobs that do not run in steps", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		callbacks := &recordingCallbacks{}
+		h.callbacks = callbacks
+
+		// Act
+		_, err := h.HandleTask(ctx, TaskEvent{Task: TaskStep, TaskToken: "token-1", State: TaskState{Job: JobRenderExport}})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("expected the failure to be reported through the token, got %v", err)
+		}
+		if len(callbacks.failures) != 1 || len(callbacks.outputs) != 0 {
+			t.Errorf("unexpected callbacks: %+v", callbacks)
+		}
+	})
+}
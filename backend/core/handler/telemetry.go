@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"athlete-forge/consent"
+	"athlete-forge/telemetry"
+)
+
+// WithTelemetry sets where usage telemetry is delivered; accepted events are
+// discarded when omitted
+func WithTelemetry(s telemetry.Sink) Option {
+	return func(h *LambdaHandler) {
+		h.telemetry = s
+	}
+}
+
+// WithTelemetrySecret sets the key anonymous telemetry IDs are derived with; a
+// random key is used when omitted, so a user's anonymous ID changes on restart
+func WithTelemetrySecret(secret []byte) Option {
+	return func(h *LambdaHandler) {
+		h.anonymizer = telemetry.NewAnonymizer(secret)
+	}
+}
+
+// TelemetryResponse reports how many events were accepted
+type TelemetryResponse struct {
+	Accepted int `json:"accepted"`
+}
+
+// handleTelemetry accepts a batch of usage events from the app for users who
+// have consented to analytics. A batch with any invalid event is rejected whole,
+// so the app finds schema mistakes rather than silently losing events
+func (h *LambdaHandler) handleTelemetry(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	allowed, err := h.consentAllows(ctx, userID, consent.PurposeAnalytics)
+	if err != nil {
+		return Response{}, err
+	}
+	if !allowed {
+		return h.createErrorResponse(403, "analytics consent has not been given"), nil
+	}
+
+	var batch telemetry.Batch
+	if err := decodeBody(event, &batch); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	now := time.Now().UTC()
+	if err := batch.Validate(now); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	if h.telemetry != nil {
+		if err := h.telemetry.Send(ctx, h.anonymizer.Records(userID, batch, now)); err != nil {
+			return Response{}, err
+		}
+	}
+	return h.createJSONResponse(202, TelemetryResponse{Accepted: len(batch.Events)})
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"athlete-forge/telemetry"
+)
+
+// recordingSink records the telemetry it is sent
+type recordingSink struct {
+	records []telemetry.Record
+}
+
+func (s *recordingSink) Send(ctx context.Context, records []telemetry.Record) error {
+	s.records = append(s.records, records...)
+	return nil
+}
+
+func TestLambdaHandler_Telemetry(t *testing.T) {
+	ctx := context.Background()
+	batch := `{"platform":"ios","appVersion":"2.14.0","events":[{"name":"screen_viewed","timestamp":"` +
+		time.Now().UTC().Format(time.RFC3339) + `","properties":{"screen":"history"}}]}`
+	setup := func(t *testing.T, consent bool) (*LambdaHandler, *recordingSink) {
+		t.Helper()
+		sink := &recordingSink{}
+		h := newTestHandler()
+		h.telemetry = sink
+		if consent {
+			body := `{"unit":"kg","barWeight":20,"plates":[{"weight":20,"pairs":2}],"analyticsConsent":true}`
+			response, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/profile", "user-1", nil, body))
+			if response.StatusCode != 200 {
+				t.Fatalf("failed to save profile: %s", response.Body)
+			}
+		}
+		return h, sink
+	}
+
+	t.Run("anonymized events are sent for consenting users", func(t *testing.T) {
+		// Arrange
+		h, sink := setup(t, true)
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("POST", "/api/telemetry", "user-1", nil, batch))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if response.StatusCode != 202 {
+			t.Fatalf("expected status code 202, got %d: %s", response.StatusCode, response.Body)
+		}
+		if len(sink.records) != 1 || sink.records[0].Event != "screen_viewed" || sink.records[0].AnonymousID != h.anonymizer.AnonymousID("user-1") {
+			t.Errorf("unexpected records: %+v", sink.records)
+		}
+	})
+
+	t.Run("users without consent are refused", func(t *testing.T) {
+		// Arrange
+		h, sink := setup(t, false)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/telemetry", "user-1", nil, batch))
+
+		// Assert
+		if response.StatusCode != 403 || len(sink.records) != 0 {
+			t.Errorf("expected status code 403 and nothing sent, got %d and %d records", response.StatusCode, len(sink.records))
+		}
+	})
+
+	t.Run("batches failing the schema are rejected whole", func(t *testing.T) {
+		// Arrange
+		h, sink := setup(t, true)
+		invalid := strings.Replace(batch, `"screen":"history"`, `"screen":"history","email":"ada@example.com"`, 1)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/telemetry", "user-1", nil, invalid))
+
+		// Assert
+		if response.StatusCode != 400 || !strings.Contains(response.Body, "email") || len(sink.records) != 0 {
+			t.Errorf("expected status code 400 naming the property, got %d: %s", response.StatusCode, response.Body)
+		}
+	})
+}
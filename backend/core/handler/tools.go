@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"math"
+	"strconv"
+
+	"athlete-forge/profile"
+	"athlete-forge/tools"
+)
+
+// PlatesResponse is returned by the plate calculator endpoint
+type PlatesResponse struct {
+	Unit string `json:"unit"`
+	tools.PlateLoad
+}
+
+// WarmupResponse is returned by the warm-up generator endpoint
+type WarmupResponse struct {
+	Unit          string            `json:"unit"`
+	WorkingWeight float64           `json:"workingWeight"`
+	BarWeight     float64           `json:"barWeight"`
+	Sets          []tools.WarmupSet `json:"sets"`
+}
+
+// handlePlates computes plate loading for ?weight= using the caller's equipment
+func (h *LambdaHandler) handlePlates(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	equipment, err := h.toolsProfile(ctx, event)
+	if err != nil {
+		return Response{}, err
+	}
+
+	target, bar, errResponse := h.parseWeightQuery(event, equipment.BarWeight)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	load, err := tools.CalculatePlates(target, bar, equipment.Plates)
+	if err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	return h.createJSONResponse(200, PlatesResponse{Unit: equipment.Unit, PlateLoad: load})
+}
+
+// handleWarmup generates warm-up sets leading to the working weight in ?weight=,
+// in steps of the profile's barbell increment or the smallest plate pair,
+// whichever is larger
+func (h *LambdaHandler) handleWarmup(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	equipment, err := h.toolsProfile(ctx, event)
+	if err != nil {
+		return Response{}, err
+	}
+
+	working, bar, errResponse := h.parseWeightQuery(event, equipment.BarWeight)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	increment := math.Max(tools.SmallestIncrement(equipment.Plates), equipment.LoadRounding().Barbell)
+	sets, err := tools.GenerateWarmup(working, bar, increment, tools.DefaultWarmupScheme)
+	if err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	return h.createJSONResponse(200, WarmupResponse{
+		Unit:          equipment.Unit,
+		WorkingWeight: working,
+		BarWeight:     bar,
+		Sets:          sets,
+	})
+}
+
+// toolsProfile loads the caller's profile, falling back to defaults for anonymous requests
+func (h *LambdaHandler) toolsProfile(ctx context.Context, event *APIGatewayProxyEvent) (*profile.Profile, error) {
+	if userID := h.userID(event); userID != "" {
+		return h.profiles.Get(ctx, userID)
+	}
+	return profile.Default("", event.QueryStringParameters["unit"]), nil
+}
+
+// parseWeightQuery reads the required weight and optional bar override from
+// the query string; NaN and infinite weights are not numbers here
+func (h *LambdaHandler) parseWeightQuery(event *APIGatewayProxyEvent, defaultBar float64) (float64, float64, *Response) {
+	weight, err := strconv.ParseFloat(event.QueryStringParameters["weight"], 64)
+	if err != nil || math.IsNaN(weight) || math.IsInf(weight, 0) {
+		response := h.createErrorResponse(400, "weight query parameter must be a number")
+		return 0, 0, &response
+	}
+
+	bar := defaultBar
+	if raw, ok := event.QueryStringParameters["bar"]; ok {
+		bar, err = strconv.ParseFloat(raw, 64)
+		if err != nil || math.IsNaN(bar) || math.IsInf(bar, 0) {
+			response := h.createErrorResponse(400, "bar query parameter must be a number")
+			return 0, 0, &response
+		}
+	}
+	return weight, bar, nil
+}
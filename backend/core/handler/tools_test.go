@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestLambdaHandler_handlePlates(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("uses default kg equipment for anonymous requests", func(t *testing.T) {
+		// Act
+		response, err := newTestHandler().HandleRequest(ctx, apiEvent("GET", "/api/tools/plates", "", map[string]string{"weight": "100"}, ""))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", response.StatusCode, response.Body)
+		}
+		var body PlatesResponse
+		if err := json.Unmarshal([]byte(response.Body), &body); err != nil {
+			t.Fatalf("failed to parse JSON: %v", err)
+		}
+		if body.Unit != "kg" || body.AchievedWeight != 100 || !body.Exact {
+			t.Errorf("unexpected plate load: %+v", body)
+		}
+	})
+
+	t.Run("uses the plates saved in the user's profile", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		h.HandleRequest(ctx, apiEvent("PUT", "/api/profile", "user-1", nil, `{"unit":"kg","barWeight":15,"plates":[{"weight":10,"pairs":4}]}`))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/tools/plates", "user-1", map[string]string{"weight": "60"}, ""))
+
+		// Assert
+		var body PlatesResponse
+		json.Unmarshal([]byte(response.Body), &body)
+		if body.BarWeight != 15 || body.AchievedWeight != 55 || body.Exact {
+			t.Errorf("unexpected plate load: %+v", body)
+		}
+	})
+
+	t.Run("rejects missing weight", func(t *testing.T) {
+		// Act
+		response, _ := newTestHandler().HandleRequest(ctx, apiEvent("GET", "/api/tools/plates", "", nil, ""))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+}
+
+func TestLambdaHandler_handleWarmup(t *testing.T) {
+	t.Run("generates warm-up sets below the working weight", func(t *testing.T) {
+		// Act
+		response, err := newTestHandler().HandleRequest(context.Background(), apiEvent("GET", "/api/tools/warmup", "", map[string]string{"weight": "225", "unit": "lb"}, ""))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var body WarmupResponse
+		if err := json.Unmarshal([]byte(response.Body), &body); err != nil {
+			t.Fatalf("failed to parse JSON: %v", err)
+		}
+		if body.Unit != "lb" || body.BarWeight != 45 || len(body.Sets) != 4 {
+			t.Fatalf("unexpected warm-up: %+v", body)
+		}
+		for _, set := range body.Sets {
+			if set.Weight >= 225 {
+				t.Errorf("warm-up set %+v is not below the working weight", set)
+			}
+		}
+	})
+
+	t.Run("rejects weights that are not finite", func(t *testing.T) {
+		for _, query := range []map[string]string{{"weight": "100", "bar": "NaN"}, {"weight": "100", "bar": "Inf"}, {"weight": "NaN"}, {"weight": "-Inf"}} {
+			// Act
+			response, _ := newTestHandler().HandleRequest(context.Background(), apiEvent("GET", "/api/tools/warmup", "", query, ""))
+
+			// Assert
+			if response.StatusCode != 400 {
+				t.Errorf("expected status code 400 for %v, got %d", query, response.StatusCode)
+			}
+		}
+	})
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"athlete-forge/cardio"
+	"athlete-forge/store"
+	"athlete-forge/track"
+)
+
+// trackLinkExpiry is how long links to download a recorded track stay valid
+const trackLinkExpiry = time.Hour
+
+// PolylineResponse is an activity's route as an encoded polyline, with a link
+// to download the recorded track
+type PolylineResponse struct {
+	ActivityID     string       `json:"activityId"`
+	Polyline       string       `json:"polyline"`
+	PolylinePoints int          `json:"polylinePoints"`
+	Points         int          `json:"points"`
+	Bounds         track.Bounds `json:"bounds"`
+	DistanceMeters float64      `json:"distanceMeters"`
+	TrackURL       string       `json:"trackUrl"`
+}
+
+// ElevationResponse is an activity's elevation profile
+type ElevationResponse struct {
+	ActivityID          string               `json:"activityId"`
+	DistanceMeters      float64              `json:"distanceMeters"`
+	ElevationGainMeters float64              `json:"elevationGainMeters"`
+	ElevationLossMeters float64              `json:"elevationLossMeters"`
+	Profile             []track.ProfilePoint `json:"profile"`
+}
+
+// storeTrack writes the track sent with an activity to the blob store and
+// replaces it with its route summary, assigning the activity its ID so the
+// track can be stored under it
+func (h *LambdaHandler) storeTrack(ctx context.Context, a *cardio.Activity) error {
+	if len(a.Track) == 0 {
+		return nil
+	}
+	if a.ID == "" {
+		a.ID = store.NewID()
+	}
+	data, err := track.Marshal(a.Track)
+	if err != nil {
+		return err
+	}
+	key := track.Key(a.UserID, a.ID)
+	if err := h.blobs.Put(ctx, key, track.ContentType, data); err != nil {
+		return fmt.Errorf("failed to store track: %w", err)
+	}
+	summary := track.Summarize(key, a.Track)
+	a.Route = &summary
+	a.Track = nil
+	return nil
+}
+
+// handlePutActivityTrack attaches a GPS track to an activity, from a GPX file
+// when sent as application/gpx+xml and otherwise from a JSON array of points,
+// replacing any earlier track
+func (h *LambdaHandler) handlePutActivityTrack(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	a, err := h.activities.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Activity not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	body := []byte(event.Body)
+	if event.IsBase64Encoded {
+		if body, err = base64.StdEncoding.DecodeString(event.Body); err != nil {
+			return h.createErrorResponse(400, "Request body is not valid base64"), nil
+		}
+	}
+	var points []track.Point
+	contentType := strings.ToLower(header(event, "Content-Type"))
+	if strings.Contains(contentType, "gpx") || strings.Contains(contentType, "xml") {
+		if points, _, err = track.ParseGPX(body); err != nil {
+			return h.createErrorResponse(400, err.Error()), nil
+		}
+	} else if err := decodeBody(&APIGatewayProxyEvent{Body: string(body)}, &points); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if err := track.Validate(points); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	a.Track = points
+	if err := h.storeTrack(ctx, a); err != nil {
+		return Response{}, err
+	}
+	if err := h.activities.Save(ctx, a); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, a)
+}
+
+// routedActivity loads an activity for the route endpoints, returning a 404
+// response when it does not exist or has no track
+func (h *LambdaHandler) routedActivity(ctx context.Context, event *APIGatewayProxyEvent) (*cardio.Activity, *Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return nil, errResponse, nil
+	}
+
+	a, err := h.activities.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		response := h.createErrorResponse(404, "Activity not found")
+		return nil, &response, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if a.Route == nil {
+		response := h.createErrorResponse(404, "Activity has no GPS track")
+		return nil, &response, nil
+	}
+	return a, nil, nil
+}
+
+// handleActivityPolyline returns an activity's route as an encoded polyline
+// for drawing on a map, with a link to the recorded track
+func (h *LambdaHandler) handleActivityPolyline(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	a, errResponse, err := h.routedActivity(ctx, event)
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	link, err := h.blobs.URL(ctx, a.Route.Key, trackLinkExpiry)
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to sign track link: %w", err)
+	}
+	return h.createJSONResponse(200, PolylineResponse{
+		ActivityID:     a.ID,
+		Polyline:       a.Route.Polyline,
+		PolylinePoints: a.Route.PolylinePoints,
+		Points:         a.Route.Points,
+		Bounds:         a.Route.Bounds,
+		DistanceMeters: a.Route.DistanceMeters,
+		TrackURL:       link,
+	})
+}
+
+// handleActivityElevation returns an activity's elevation profile, which is
+// empty when the track recorded no altitudes
+func (h *LambdaHandler) handleActivityElevation(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	a, errResponse, err := h.routedActivity(ctx, event)
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	profile := a.Route.Profile
+	if profile == nil {
+		profile = []track.ProfilePoint{}
+	}
+	return h.createJSONResponse(200, ElevationResponse{
+		ActivityID:          a.ID,
+		DistanceMeters:      a.Route.DistanceMeters,
+		ElevationGainMeters: a.Route.ElevationGainMeters,
+		ElevationLossMeters: a.Route.ElevationLossMeters,
+		Profile:             profile,
+	})
+}
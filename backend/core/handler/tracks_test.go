@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"athlete-forge/blob"
+	"athlete-forge/cardio"
+)
+
+const trackedRunBody = `{
+	"sport": "run",
+	"startTime": "2024-03-05T07:00:00Z",
+	"durationSeconds": 600,
+	"distanceMeters": 2000,
+	"track": [
+		{"lat": 51.5000, "lon": -0.1200, "altitude": 10, "offset": 0},
+		{"lat": 51.5050, "lon": -0.1200, "altitude": 20, "offset": 150},
+		{"lat": 51.5100, "lon": -0.1200, "altitude": 30, "offset": 300},
+		{"lat": 51.5100, "lon": -0.1100, "altitude": 25, "offset": 600}
+	]
+}`
+
+const trackGPX = `<?xml version="1.0"?>
+<gpx version="1.1" xmlns="http://www.topografix.com/GPX/1/1">
+  <trk><trkseg>
+    <trkpt lat="51.5000" lon="-0.1200"><ele>5</ele><time>2024-03-05T07:00:00Z</time></trkpt>
+    <trkpt lat="51.5100" lon="-0.1200"><ele>15</ele><time>2024-03-05T07:05:00Z</time></trkpt>
+  </trkseg></trk>
+</gpx>`
+
+func TestLambdaHandler_ActivityTracks(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("import stores the track and keeps its route summary", func(t *testing.T) {
+		// Arrange
+		blobs := blob.NewMemoryStore()
+		h := NewLambdaHandler(zerolog.Nop(), WithBlobStore(blobs))
+
+		// Act
+		a := createActivity(t, h, "user-1", trackedRunBody)
+
+		// Assert
+		if a.Track != nil || a.Route == nil || a.Route.Points != 4 || a.Route.PolylinePoints != 3 {
+			t.Fatalf("unexpected route: %+v", a.Route)
+		}
+		obj, ok := blobs.Get("tracks/user-1/" + a.ID + ".json")
+		if !ok || obj.ContentType != "application/json" || !strings.Contains(string(obj.Data), `"lat":51.505`) {
+			t.Errorf("expected the whole track stored, got %+v", obj)
+		}
+	})
+
+	t.Run("polyline returns the encoded route and a track link", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		a := createActivity(t, h, "user-1", trackedRunBody)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/activities/"+a.ID+"/polyline", "user-1", nil, ""))
+
+		// Assert
+		if response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", response.StatusCode, response.Body)
+		}
+		var polyline PolylineResponse
+		json.Unmarshal([]byte(response.Body), &polyline)
+		if polyline.Polyline != a.Route.Polyline || polyline.Bounds.MaxLat != 51.51 || polyline.TrackURL == "" {
+			t.Errorf("unexpected polyline: %+v", polyline)
+		}
+	})
+
+	t.Run("elevation returns the profile", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		a := createActivity(t, h, "user-1", trackedRunBody)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/activities/"+a.ID+"/elevation", "user-1", nil, ""))
+
+		// Assert
+		var elevation ElevationResponse
+		json.Unmarshal([]byte(response.Body), &elevation)
+		if elevation.ElevationGainMeters != 20 || elevation.ElevationLossMeters != 5 || len(elevation.Profile) != 200 {
+			t.Errorf("unexpected elevation: %+v", elevation)
+		}
+		if elevation.Profile[0].Altitude != 10 || elevation.Profile[199].Altitude != 25 {
+			t.Errorf("unexpected profile ends: %+v, %+v", elevation.Profile[0], elevation.Profile[199])
+		}
+	})
+
+	t.Run("attaches a GPX track to an activity", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		a := createActivity(t, h, "user-1", runActivityBody)
+		event := apiEvent("PUT", "/api/activities/"+a.ID+"/track", "user-1", nil, trackGPX)
+		event["headers"] = map[string]string{"Content-Type": "application/gpx+xml"}
+
+		// Act
+		response, _ := h.HandleRequest(ctx, event)
+
+		// Assert
+		if response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", response.StatusCode, response.Body)
+		}
+		var updated cardio.Activity
+		json.Unmarshal([]byte(response.Body), &updated)
+		if updated.Route == nil || updated.Route.Points != 2 || updated.Route.ElevationGainMeters != 10 || updated.AverageHR != a.AverageHR {
+			t.Errorf("unexpected activity: %+v", updated)
+		}
+	})
+
+	t.Run("rejects an invalid track", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		a := createActivity(t, h, "user-1", runActivityBody)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/activities/"+a.ID+"/track", "user-1", nil, `[{"lat": 95, "lon": 0}, {"lat": 0, "lon": 0}]`))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("route endpoints return 404 without a track", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		a := createActivity(t, h, "user-1", runActivityBody)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/activities/"+a.ID+"/polyline", "user-1", nil, ""))
+
+		// Assert
+		if response.StatusCode != 404 {
+			t.Errorf("expected status code 404, got %d", response.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"athlete-forge/progression"
+	"athlete-forge/trainingmax"
+	"athlete-forge/workout"
+)
+
+// TrainingMaxRequest is the body for setting an exercise's training max
+type TrainingMaxRequest struct {
+	Exercise string  `json:"exercise"`
+	Value    float64 `json:"value"`
+	Note     string  `json:"note,omitempty"`
+}
+
+// TrainingMaxResponse is a training max just set and how many programs it fed
+type TrainingMaxResponse struct {
+	TrainingMax     *trainingmax.TrainingMax `json:"trainingMax"`
+	ProgramsUpdated int                      `json:"programsUpdated"`
+}
+
+// handleListTrainingMaxes returns the user's training maxes with their
+// history, or only ?exercise='s
+func (h *LambdaHandler) handleListTrainingMaxes(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	maxes, err := h.trainingMaxes.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	if name := strings.TrimSpace(event.QueryStringParameters["exercise"]); name != "" {
+		filtered := []trainingmax.TrainingMax{}
+		for _, t := range maxes {
+			if strings.EqualFold(t.Exercise, name) {
+				filtered = append(filtered, t)
+			}
+		}
+		maxes = filtered
+	}
+	return h.createJSONResponse(200, maxes)
+}
+
+// handlePutTrainingMax sets an exercise's training max and feeds it to every
+// percentage prescription of the exercise in the user's programs
+func (h *LambdaHandler) handlePutTrainingMax(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var req TrainingMaxRequest
+	if err := decodeBody(event, &req); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if strings.TrimSpace(req.Exercise) == "" {
+		return h.createErrorResponse(400, "exercise is required"), nil
+	}
+	if err := trainingmax.Validate(req.Value); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	now := time.Now().UTC()
+	t, err := h.trainingMaxes.Record(ctx, userID, req.Exercise, trainingmax.Entry{Value: req.Value, Source: trainingmax.SourceManual, Note: req.Note, SetAt: now})
+	if err != nil {
+		return Response{}, err
+	}
+	updated, err := h.feedTrainingMax(ctx, userID, req.Exercise, req.Value, "", now)
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, TrainingMaxResponse{TrainingMax: t, ProgramsUpdated: updated})
+}
+
+// recordTrainingMaxes records each training max a completed session moved in
+// programID and feeds it to the user's other programs
+func (h *LambdaHandler) recordTrainingMaxes(ctx context.Context, w *workout.Workout, programID string, changes []progression.Change) error {
+	now := time.Now().UTC()
+	for _, change := range changes {
+		if change.TrainingMax == nil {
+			continue
+		}
+		entry := trainingmax.Entry{Value: change.TrainingMax.Next, Source: change.TrainingMax.Source, Note: change.Reason, ProgramID: programID, WorkoutID: w.ID, SetAt: now}
+		if _, err := h.trainingMaxes.Record(ctx, w.UserID, change.Exercise, entry); err != nil {
+			return err
+		}
+		if _, err := h.feedTrainingMax(ctx, w.UserID, change.Exercise, change.TrainingMax.Next, programID, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// feedTrainingMax sets the training max of the exercise's percentage
+// prescriptions in each of the user's programs but skipID, returning how many
+// programs changed
+func (h *LambdaHandler) feedTrainingMax(ctx context.Context, userID, exercise string, value float64, skipID string, now time.Time) (int, error) {
+	programs, err := h.programs.List(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	updated := 0
+	for i := range programs {
+		p := &programs[i]
+		if p.ID == skipID || !trainingmax.Feed(p, exercise, value) {
+			continue
+		}
+		p.UpdatedAt = now
+		if err := h.programs.Save(ctx, p); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
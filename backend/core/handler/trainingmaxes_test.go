@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"athlete-forge/program"
+	"athlete-forge/trainingmax"
+	"athlete-forge/workout"
+)
+
+const amrapProgramBody = `{
+	"name": "Wave",
+	"exercises": [
+		{"exercise": "Squat", "sets": 3, "reps": 5, "weight": 85, "rule": {"type": "percentage", "trainingMax": 100, "percentages": [0.65, 0.75, 0.85], "step": 2, "trainingMaxUpdate": "amrap"}}
+	]
+}`
+
+const waveProgramBody = `{
+	"name": "Other wave",
+	"exercises": [
+		{"exercise": "Squat", "sets": 3, "reps": 5, "weight": 65, "rule": {"type": "percentage", "trainingMax": 100, "percentages": [0.65, 0.75, 0.85]}}
+	]
+}`
+
+func TestLambdaHandler_TrainingMaxes(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("setting a training max feeds percentage programs", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", waveProgramBody)
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("PUT", "/api/training-maxes", "user-1", nil, `{"exercise":"squat","value":120,"note":"retested"}`))
+
+		// Assert
+		if err != nil || response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s (%v)", response.StatusCode, response.Body, err)
+		}
+		var got TrainingMaxResponse
+		json.Unmarshal([]byte(response.Body), &got)
+		if got.ProgramsUpdated != 1 || got.TrainingMax.Value != 120 || got.TrainingMax.History[0].Source != trainingmax.SourceManual {
+			t.Errorf("unexpected response: %s", response.Body)
+		}
+		stored, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/programs/"+p.ID, "user-1", nil, ""))
+		var fed program.Program
+		json.Unmarshal([]byte(stored.Body), &fed)
+		if fed.Exercises[0].Rule.TrainingMax != 120 || fed.Exercises[0].Weight != 77.5 {
+			t.Errorf("expected the program fed, got %+v", fed.Exercises[0])
+		}
+	})
+
+	t.Run("an amrap set updates the training max and its history", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", amrapProgramBody)
+		other := createProgram(t, h, "user-1", waveProgramBody)
+		created := createWorkout(t, h, "user-1", fmt.Sprintf(`{"programId":%q,"exercises":[{"name":"Squat","sets":[{"reps":5,"weight":85},{"reps":5,"weight":85},{"reps":10,"weight":85}]}]}`, p.ID))
+		var w workout.Workout
+		json.Unmarshal([]byte(created.Body), &w)
+		h.HandleRequest(ctx, apiEvent("POST", "/api/workouts/"+w.ID+"/complete", "user-1", nil, ""))
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/training-maxes", "user-1", map[string]string{"exercise": "Squat"}, ""))
+
+		// Assert
+		var maxes []trainingmax.TrainingMax
+		json.Unmarshal([]byte(response.Body), &maxes)
+		if len(maxes) != 1 || maxes[0].Value != 102.5 || maxes[0].History[0].WorkoutID != w.ID || maxes[0].History[0].Source != program.TrainingMaxAMRAP {
+			t.Fatalf("unexpected training maxes: %s", response.Body)
+		}
+		stored, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/programs/"+other.ID, "user-1", nil, ""))
+		var fed program.Program
+		json.Unmarshal([]byte(stored.Body), &fed)
+		if fed.Exercises[0].Rule.TrainingMax != 102.5 {
+			t.Errorf("expected the other program fed, got %+v", fed.Exercises[0])
+		}
+	})
+
+	t.Run("rejects a non-positive value", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("PUT", "/api/training-maxes", "user-1", nil, `{"exercise":"Squat","value":0}`))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+}
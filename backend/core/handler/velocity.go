@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"context"
+
+	"athlete-forge/workout"
+)
+
+// VelocityRequest is the body for logging a set's rep velocities
+type VelocityRequest struct {
+	Velocities []float64 `json:"velocities"`
+}
+
+// SetVelocityResponse is a set with its logged velocities and the velocity it
+// lost from the fastest rep to the last
+type SetVelocityResponse struct {
+	Set          workout.Set `json:"set"`
+	VelocityLoss float64     `json:"velocityLoss"`
+}
+
+// handlePutSetVelocities replaces the rep velocities of one set of a workout,
+// addressed like handlePatchSet, such as when a bar velocity device syncs
+func (h *LambdaHandler) handlePutSetVelocities(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var req VelocityRequest
+	if err := decodeBody(event, &req); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	w, exercise, set, errResponse, err := h.findSet(ctx, userID, event)
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	updated := w.Exercises[exercise].Sets[set]
+	updated.Velocities = req.Velocities
+	if err := updated.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	w.Exercises[exercise].Sets[set] = updated
+	if err := h.workouts.Save(ctx, w); err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, SetVelocityResponse{Set: updated, VelocityLoss: updated.VelocityLoss()})
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"athlete-forge/workout"
+)
+
+func TestLambdaHandler_SetVelocities(t *testing.T) {
+	ctx := context.Background()
+	h := newTestHandler()
+	response := createWorkout(t, h, "user-1", `{"exercises":[{"name":"Squat","sets":[{"reps":3,"weight":140}]}]}`)
+	var w workout.Workout
+	json.Unmarshal([]byte(response.Body), &w)
+	path := "/api/workouts/" + w.ID + "/exercises/0/sets/0/velocities"
+
+	t.Run("logs rep velocities and reports the velocity loss", func(t *testing.T) {
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("PUT", path, "user-1", nil, `{"velocities":[0.6,0.56,0.48]}`))
+
+		// Assert
+		if err != nil || response.StatusCode != 200 {
+			t.Fatalf("unexpected response %d: %s (%v)", response.StatusCode, response.Body, err)
+		}
+		var result SetVelocityResponse
+		json.Unmarshal([]byte(response.Body), &result)
+		if len(result.Set.Velocities) != 3 || result.VelocityLoss != 20 {
+			t.Errorf("unexpected result: %s", response.Body)
+		}
+		saved, _ := h.workouts.Get(ctx, "user-1", w.ID)
+		if len(saved.Exercises[0].Sets[0].Velocities) != 3 {
+			t.Errorf("expected the velocities to be saved, got %+v", saved.Exercises[0].Sets[0])
+		}
+	})
+
+	t.Run("rejects more velocities than reps and unknown sets", func(t *testing.T) {
+		for p, want := range map[string]int{path: 400, "/api/workouts/" + w.ID + "/exercises/0/sets/3/velocities": 404} {
+			// Act
+			response, _ := h.HandleRequest(ctx, apiEvent("PUT", p, "user-1", nil, `{"velocities":[0.6,0.56,0.48,0.4]}`))
+
+			// Assert
+			if response.StatusCode != want {
+				t.Errorf("%s: expected status code %d, got %d", p, want, response.StatusCode)
+			}
+		}
+	})
+}
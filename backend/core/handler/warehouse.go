@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"athlete-forge/dailylog"
+	"athlete-forge/parquet"
+	"athlete-forge/readiness"
+	"athlete-forge/store"
+	"athlete-forge/warehouse"
+	"athlete-forge/workout"
+)
+
+// runExportWarehouse exports the workouts synced completed and the days ended
+// since the last export as Parquet files for Athena. Workouts are found in the
+// index of synced workouts, except on the first run after it was introduced,
+// which reads every user's history once. The watermark only advances once
+// every file is written, so a failed run is retried from the same point; unlike
+// other scheduled jobs a failure for one user fails the run, since skipping
+// them would leave their rows out of the warehouse for good. The warehouse is
+// in the home region, so users pinned to another region are left out of it
+// and counted as skipped
+func (h *LambdaHandler) runExportWarehouse(ctx context.Context, now time.Time) (JobResult, error) {
+	result := JobResult{Job: JobExportWarehouse}
+	since, err := h.watermarks.Watermark(ctx)
+	if err != nil {
+		return result, err
+	}
+	users, err := h.users.List(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	// Metrics are exported once their day has ended in UTC, so late check-ins
+	// and logs for the current day are picked up by the next run
+	next := warehouse.Watermark{WorkoutsThrough: now.UTC(), MetricsThrough: now.UTC().AddDate(0, 0, -1).Format(readiness.DateLayout), Indexed: true}
+	batch := warehouse.NewBatch(since, now)
+	for _, user := range users {
+		home, err := h.inHomeRegion(ctx, user.UserID)
+		if err != nil {
+			return result, err
+		}
+		if !home {
+			result.Skipped++
+			continue
+		}
+		if err := h.exportUser(ctx, batch, user.UserID, since, next); err != nil {
+			h.logger.Error().
+				Err(err).
+				Str("user_id", user.UserID).
+				Msg("Failed to export user to warehouse")
+			return result, err
+		}
+	}
+	if since.Indexed {
+		if err := h.exportSynced(ctx, batch, since, next); err != nil {
+			return result, err
+		}
+	}
+
+	files, err := batch.Files()
+	if err != nil {
+		return result, err
+	}
+	for _, file := range files {
+		if err := h.warehouse.Put(ctx, file.Key, parquet.ContentType, file.Data); err != nil {
+			return result, err
+		}
+		result.Processed += file.Rows
+	}
+	return result, h.watermarks.SaveWatermark(ctx, next)
+}
+
+// inHomeRegion reports whether userID's data is kept in the home region, as
+// every user's is without residency
+func (h *LambdaHandler) inHomeRegion(ctx context.Context, userID string) (bool, error) {
+	if h.residency == nil {
+		return true, nil
+	}
+	region, err := h.residency.Region(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return region == h.residency.Home(), nil
+}
+
+// exportSynced adds the workouts the index lists as synced between since and
+// next to batch, loading each user's in batches rather than their history
+func (h *LambdaHandler) exportSynced(ctx context.Context, batch *warehouse.Batch, since, next warehouse.Watermark) error {
+	entries, err := h.synced.SyncedBetween(ctx, since.WorkoutsThrough, next.WorkoutsThrough)
+	if err != nil {
+		return err
+	}
+	var userIDs []string
+	byUser := map[string][]string{}
+	for _, e := range entries {
+		if byUser[e.UserID] == nil {
+			userIDs = append(userIDs, e.UserID)
+		}
+		byUser[e.UserID] = append(byUser[e.UserID], e.WorkoutID)
+	}
+
+	for _, userID := range userIDs {
+		home, err := h.inHomeRegion(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if !home {
+			continue
+		}
+		anonymousID := h.anonymizer.AnonymousID(userID)
+		ids := byUser[userID]
+		for start := 0; start < len(ids); start += store.MaxBatchGet {
+			chunk := ids[start:min(start+store.MaxBatchGet, len(ids))]
+			workouts, err := h.workouts.GetMany(ctx, userID, chunk)
+			if err != nil {
+				h.logger.Error().
+					Err(err).
+					Str("user_id", userID).
+					Msg("Failed to export user to warehouse")
+				return err
+			}
+			// Workouts deleted since they were synced are left out
+			for _, id := range chunk {
+				if w, ok := workouts[id]; ok && w.Status == workout.StatusCompleted && w.CompletedAt != nil {
+					batch.AddWorkout(anonymousID, &w)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// exportUser adds userID's days ended between since and next to batch and,
+// until the index of synced workouts is in use, their workouts synced
+// completed in that time
+func (h *LambdaHandler) exportUser(ctx context.Context, batch *warehouse.Batch, userID string, since, next warehouse.Watermark) error {
+	anonymousID := h.anonymizer.AnonymousID(userID)
+	if !since.Indexed {
+		if err := h.exportHistory(ctx, batch, anonymousID, userID, since, next); err != nil {
+			return err
+		}
+	}
+
+	from := ""
+	if since.MetricsThrough != "" {
+		day, err := time.Parse(readiness.DateLayout, since.MetricsThrough)
+		if err != nil {
+			return err
+		}
+		from = day.AddDate(0, 0, 1).Format(readiness.DateLayout)
+	}
+	checkIns, err := h.checkIns.List(ctx, userID, from, next.MetricsThrough)
+	if err != nil {
+		return err
+	}
+	logs, err := h.dailyLogs.List(ctx, userID, from, next.MetricsThrough)
+	if err != nil {
+		return err
+	}
+
+	var days []string
+	checkInsByDate := map[string]*readiness.CheckIn{}
+	for i := range checkIns {
+		checkInsByDate[checkIns[i].Date] = &checkIns[i]
+		days = append(days, checkIns[i].Date)
+	}
+	logsByDate := map[string]*dailylog.Log{}
+	for i := range logs {
+		logsByDate[logs[i].Date] = &logs[i]
+		if checkInsByDate[logs[i].Date] == nil {
+			days = append(days, logs[i].Date)
+		}
+	}
+	sort.Strings(days)
+	for _, date := range days {
+		batch.AddMetrics(anonymousID, date, checkInsByDate[date], logsByDate[date])
+	}
+	return nil
+}
+
+// exportHistory adds userID's workouts synced completed between since and next
+// to batch, reading their whole history
+func (h *LambdaHandler) exportHistory(ctx context.Context, batch *warehouse.Batch, anonymousID, userID string, since, next warehouse.Watermark) error {
+	workouts, err := h.workouts.List(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for i := range workouts {
+		w := &workouts[i]
+		if w.Status != workout.StatusCompleted || w.CompletedAt == nil {
+			continue
+		}
+		// Workouts completed offline can reach the server after later runs,
+		// so they are exported by when they were synced
+		synced := w.CompletedAt
+		if w.SyncedAt != nil {
+			synced = w.SyncedAt
+		}
+		if !synced.After(since.WorkoutsThrough) || synced.After(next.WorkoutsThrough) {
+			continue
+		}
+		batch.AddWorkout(anonymousID, w)
+	}
+	return nil
+}
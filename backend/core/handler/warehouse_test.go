@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"athlete-forge/blob"
+	"athlete-forge/parquet"
+	"athlete-forge/readiness"
+	"athlete-forge/workout"
+)
+
+func TestLambdaHandler_WarehouseExport(t *testing.T) {
+	ctx := context.Background()
+	files := blob.NewMemoryStore()
+	h := NewLambdaHandler(zerolog.Nop(), WithWarehouseStore(files))
+	now := time.Date(2024, 3, 12, 2, 0, 0, 0, time.UTC)
+	completed := now.Add(-12 * time.Hour)
+
+	h.users.Touch(ctx, "lifter", now)
+	h.workouts.Save(ctx, &workout.Workout{
+		ID: "w1", UserID: "lifter", Status: workout.StatusCompleted,
+		StartedAt: completed.Add(-time.Hour), CompletedAt: &completed,
+		Exercises: []workout.Exercise{{Name: "Squat", Sets: []workout.Set{{Reps: 5, Weight: 100}, {Reps: 5, Weight: 100, RPE: 8}}}},
+	})
+	h.workouts.Save(ctx, &workout.Workout{ID: "w2", UserID: "lifter", Status: workout.StatusActive, StartedAt: now})
+	h.checkIns.Save(ctx, &readiness.CheckIn{UserID: "lifter", Date: "2024-03-11", SleepHours: 8, SleepQuality: 4, Soreness: 2, Mood: 4})
+	h.checkIns.Save(ctx, &readiness.CheckIn{UserID: "lifter", Date: "2024-03-12", SleepHours: 8, SleepQuality: 4, Soreness: 2, Mood: 4})
+
+	t.Run("exports completed workouts, their sets and ended days partitioned by date", func(t *testing.T) {
+		// Act
+		result, err := h.runExportWarehouse(ctx, now)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Processed != 4 {
+			t.Errorf("expected 4 rows (a workout, two sets and a day), got %d", result.Processed)
+		}
+		run := "00010101T000000Z.parquet"
+		for _, key := range []string{
+			"warehouse/workouts/dt=2024-03-11/" + run,
+			"warehouse/sets/dt=2024-03-11/" + run,
+			"warehouse/daily_metrics/dt=2024-03-11/" + run,
+		} {
+			obj, ok := files.Get(key)
+			if !ok {
+				t.Errorf("expected a file at %s", key)
+				continue
+			}
+			if obj.ContentType != parquet.ContentType || !strings.HasPrefix(string(obj.Data), "PAR1") {
+				t.Errorf("expected a Parquet file at %s, got %s", key, obj.ContentType)
+			}
+		}
+		if _, ok := files.Get("warehouse/daily_metrics/dt=2024-03-12/" + run); ok {
+			t.Error("expected the current day to wait for the next run")
+		}
+	})
+
+	t.Run("the next run only exports what is new", func(t *testing.T) {
+		// Act
+		result, err := h.runExportWarehouse(ctx, now.Add(24*time.Hour))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Processed != 1 {
+			t.Errorf("expected only the newly ended day, got %d rows", result.Processed)
+		}
+		if _, ok := files.Get("warehouse/daily_metrics/dt=2024-03-12/20240312T020000Z.parquet"); !ok {
+			t.Error("expected the day exported under the run it started from")
+		}
+	})
+
+	t.Run("exports a workout completed offline when it is synced", func(t *testing.T) {
+		// Arrange
+		offline := now.Add(-36 * time.Hour)
+		synced := now.Add(36 * time.Hour)
+		h.workouts.Save(ctx, &workout.Workout{
+			ID: "w3", UserID: "lifter", Status: workout.StatusCompleted,
+			StartedAt: offline.Add(-time.Hour), CompletedAt: &offline, SyncedAt: &synced,
+			Exercises: []workout.Exercise{{Name: "Squat", Sets: []workout.Set{{Reps: 5, Weight: 100}}}},
+		})
+		h.synced.Add(ctx, "lifter", "w3", synced)
+
+		// Act
+		result, err := h.runExportWarehouse(ctx, now.Add(48*time.Hour))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Processed != 2 {
+			t.Errorf("expected the workout and its set, got %d rows", result.Processed)
+		}
+		if _, ok := files.Get("warehouse/workouts/dt=2024-03-10/20240313T020000Z.parquet"); !ok {
+			t.Error("expected the workout under the day it was completed")
+		}
+	})
+
+	t.Run("finds workouts in the index of synced workouts rather than users' history", func(t *testing.T) {
+		// Arrange
+		at := now.Add(60 * time.Hour)
+		for _, id := range []string{"w4", "w5"} {
+			h.workouts.Save(ctx, &workout.Workout{ID: id, UserID: "lifter", Status: workout.StatusCompleted, StartedAt: at.Add(-time.Hour), CompletedAt: &at, SyncedAt: &at})
+		}
+		h.synced.Add(ctx, "lifter", "w4", at)
+		h.synced.Add(ctx, "other", "gone", at)
+
+		// Act
+		result, err := h.runExportWarehouse(ctx, now.Add(72*time.Hour))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Processed != 1 {
+			t.Errorf("expected only the indexed workout, got %d rows", result.Processed)
+		}
+	})
+
+	t.Run("indexes workouts completed through the API", func(t *testing.T) {
+		// Arrange
+		h := NewLambdaHandler(zerolog.Nop(), WithWarehouseStore(blob.NewMemoryStore()))
+		h.runExportWarehouse(ctx, time.Now().UTC())
+		createWorkout(t, h, "lifter", `{"status":"completed","exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]}]}`)
+
+		// Act
+		result, err := h.runExportWarehouse(ctx, time.Now().UTC())
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Processed != 2 {
+			t.Errorf("expected the workout and its set, got %d rows", result.Processed)
+		}
+	})
+}
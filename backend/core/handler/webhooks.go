@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"athlete-forge/webhook"
+)
+
+// handleWebhookChallenge answers a provider's subscription validation request
+func (h *LambdaHandler) handleWebhookChallenge(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	verifier, ok := h.webhooks[event.PathParameters["provider"]]
+	if !ok {
+		return h.createErrorResponse(404, "Unknown webhook provider"), nil
+	}
+	challenger, ok := verifier.(webhook.Challenger)
+	if !ok {
+		return h.createErrorResponse(405, "Method not allowed"), nil
+	}
+
+	challenge, err := challenger.Challenge(event.QueryStringParameters)
+	if err != nil {
+		return h.createErrorResponse(403, "Subscription verification failed"), nil
+	}
+	return h.createJSONResponse(200, map[string]string{"hub.challenge": challenge})
+}
+
+// handleWebhook verifies a provider's event delivery and records it for import,
+// acknowledging repeats without recording them again. Providers with an
+// integration adapter have their pending events imported in the background
+func (h *LambdaHandler) handleWebhook(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	verifier, ok := h.webhooks[event.PathParameters["provider"]]
+	if !ok {
+		return h.createErrorResponse(404, "Unknown webhook provider"), nil
+	}
+
+	headers := map[string]string{}
+	for key, value := range event.Headers {
+		headers[http.CanonicalHeaderKey(key)] = value
+	}
+	request := webhook.Request{Headers: headers, Query: event.QueryStringParameters, Body: event.Body}
+
+	received, err := verifier.Verify(request, time.Now().UTC())
+	if errors.Is(err, webhook.ErrInvalidSignature) || errors.Is(err, webhook.ErrStale) {
+		h.logger.Warn().
+			Err(err).
+			Str("provider", verifier.Provider()).
+			Msg("Rejected webhook delivery")
+
+		return h.createErrorResponse(401, "Webhook verification failed"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	recorded, err := h.webhookInbox.Record(ctx, received)
+	if err != nil {
+		return Response{}, err
+	}
+	if !recorded {
+		h.logger.Info().
+			Str("provider", received.Provider).
+			Str("key", received.Key).
+			Msg("Ignored repeated webhook delivery")
+	}
+	if _, ok := h.adapters[received.Provider]; ok && recorded {
+		// The delivery is safely recorded, so a failed dispatch is left for the
+		// next delivery's import to pick up rather than failing this one
+		if err := h.dispatch(ctx, JobEvent{Job: JobImportWebhooks, ID: received.Provider}); err != nil {
+			h.logger.Warn().
+				Err(err).
+				Str("provider", received.Provider).
+				Msg("Failed to queue webhook import")
+		}
+	}
+	return h.createJSONResponse(200, map[string]bool{"recorded": recorded})
+}
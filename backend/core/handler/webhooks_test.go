@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"athlete-forge/webhook"
+)
+
+func TestLambdaHandler_Webhooks(t *testing.T) {
+	ctx := context.Background()
+	h := NewLambdaHandler(zerolog.Nop(),
+		WithWebhookVerifier(&webhook.Strava{VerifyToken: "verify-me", SubscriptionID: 120475}),
+		WithWebhookVerifier(webhook.NewGarmin([]byte("shared-secret"))))
+
+	garminEvent := func(secret, body string) map[string]interface{} {
+		timestamp := fmt.Sprint(time.Now().Unix())
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(timestamp + "." + body))
+		event := apiEvent("POST", "/api/webhooks/garmin", "", nil, body)
+		event["headers"] = map[string]string{
+			"x-garmin-timestamp": timestamp,
+			"x-garmin-signature": hex.EncodeToString(mac.Sum(nil)),
+		}
+		return event
+	}
+
+	t.Run("answers the Strava subscription challenge", func(t *testing.T) {
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/webhooks/strava", "", map[string]string{
+			"hub.mode": "subscribe", "hub.verify_token": "verify-me", "hub.challenge": "abc123",
+		}, ""))
+
+		// Assert
+		if response.StatusCode != 200 || response.Body != `{"hub.challenge":"abc123"}` {
+			t.Errorf("unexpected response %d: %s", response.StatusCode, response.Body)
+		}
+	})
+
+	t.Run("records a signed Garmin delivery once", func(t *testing.T) {
+		// Arrange
+		body := `{"activities":[{"summaryId":"5001968355"}]}`
+
+		// Act
+		first, _ := h.HandleRequest(ctx, garminEvent("shared-secret", body))
+		replay, _ := h.HandleRequest(ctx, garminEvent("shared-secret", body))
+
+		// Assert
+		if first.StatusCode != 200 || first.Body != `{"recorded":true}` {
+			t.Errorf("unexpected first response %d: %s", first.StatusCode, first.Body)
+		}
+		if replay.StatusCode != 200 || replay.Body != `{"recorded":false}` {
+			t.Errorf("unexpected replay response %d: %s", replay.StatusCode, replay.Body)
+		}
+	})
+
+	t.Run("rejects unverified deliveries", func(t *testing.T) {
+		// Act
+		forged, _ := h.HandleRequest(ctx, garminEvent("guess", `{"activities":[]}`))
+		unknown, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/webhooks/polar", "", nil, "{}"))
+
+		// Assert
+		if forged.StatusCode != 401 {
+			t.Errorf("expected status code 401 for a forged signature, got %d", forged.StatusCode)
+		}
+		if unknown.StatusCode != 404 {
+			t.Errorf("expected status code 404 for an unknown provider, got %d", unknown.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,437 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"athlete-forge/injury"
+	"athlete-forge/program"
+	"athlete-forge/progression"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+// CompletionResponse is returned when a workout is completed
+type CompletionResponse struct {
+	Workout *workout.Workout     `json:"workout"`
+	Program *program.Program     `json:"program,omitempty"`
+	Changes []progression.Change `json:"changes"`
+}
+
+// handleListWorkouts returns the user's workouts by when they took place; ?exercise= keeps
+// only those including that exercise, its history, and ?gymId= those performed
+// at that gym
+func (h *LambdaHandler) handleListWorkouts(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	workouts, err := h.workouts.List(ctx, userID)
+	if err != nil {
+		return Response{}, err
+	}
+	if name := event.QueryStringParameters["exercise"]; name != "" {
+		history := []workout.Workout{}
+		for _, w := range workouts {
+			if w.HasExercise(name) {
+				history = append(history, w)
+			}
+		}
+		workouts = history
+	}
+	if gymID := event.QueryStringParameters["gymId"]; gymID != "" {
+		atGym := []workout.Workout{}
+		for _, w := range workouts {
+			if w.GymID == gymID {
+				atGym = append(atGym, w)
+			}
+		}
+		workouts = atGym
+	}
+	return h.createJSONResponse(200, workouts)
+}
+
+// handleGetWorkout returns a single workout
+func (h *LambdaHandler) handleGetWorkout(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	w, err := h.workouts.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Workout not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, w)
+}
+
+// BatchGetRequest lists the IDs of resources to read in one request
+type BatchGetRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// BatchGetWorkoutsResponse holds the requested workouts that exist, in the order
+// asked for, and the IDs of those that do not
+type BatchGetWorkoutsResponse struct {
+	Found   []workout.Workout `json:"found"`
+	Missing []string          `json:"missing"`
+}
+
+// handleBatchGetWorkouts returns up to store.MaxBatchGet workouts by ID in one
+// request, so clients syncing or hydrating a feed need not fetch them one by one
+func (h *LambdaHandler) handleBatchGetWorkouts(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	var req BatchGetRequest
+	if err := decodeBody(event, &req); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if len(req.IDs) == 0 {
+		return h.createErrorResponse(400, "ids is required"), nil
+	}
+
+	ids := make([]string, 0, len(req.IDs))
+	seen := make(map[string]bool, len(req.IDs))
+	for _, id := range req.IDs {
+		if id == "" {
+			return h.createErrorResponse(400, "ids must not be empty"), nil
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > store.MaxBatchGet {
+		return h.createErrorResponse(400, fmt.Sprintf("ids must list at most %d workouts", store.MaxBatchGet)), nil
+	}
+
+	found, err := h.workouts.GetMany(ctx, userID, ids)
+	if err != nil {
+		return Response{}, err
+	}
+	resp := BatchGetWorkoutsResponse{Found: []workout.Workout{}, Missing: []string{}}
+	for _, id := range ids {
+		if w, ok := found[id]; ok {
+			resp.Found = append(resp.Found, w)
+		} else {
+			resp.Missing = append(resp.Missing, id)
+		}
+	}
+	return h.createJSONResponse(200, resp)
+}
+
+// handleCreateWorkout logs a workout; workouts created as completed run progression
+// immediately, at their completedAt if sent, and planned workouts are kept for
+// the calendar until they are completed. Times are corrected for the device's
+// clock skew
+func (h *LambdaHandler) handleCreateWorkout(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var w workout.Workout
+	if err := decodeBody(event, &w); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+
+	completeNow := w.Status == workout.StatusCompleted
+	clock := clientClock(ctx)
+	completedAt := clock.ReceivedAt
+	correctClientTimes(ctx, nil, &w)
+	if completeNow && w.CompletedAt != nil {
+		completedAt, w.ClientCompletedAt = serverTime(clock, *w.CompletedAt)
+		if w.StartedAt.IsZero() {
+			w.StartedAt = completedAt
+		}
+		if completedAt.Before(w.StartedAt) {
+			return h.createErrorResponse(400, "completedAt must not be before startedAt"), nil
+		}
+	}
+	w.ID = ""
+	w.UserID = userID
+	w.CompletedAt = nil
+	w.SyncedAt = nil
+	if w.Status == workout.StatusPlanned {
+		w.StartedAt = time.Time{}
+		w.ClientStartedAt = nil
+	} else {
+		w.Status = workout.StatusActive
+		if w.StartedAt.IsZero() {
+			w.StartedAt = clock.ReceivedAt
+		}
+	}
+
+	if err := w.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if w.ProgramID != "" {
+		if _, err := h.programs.Get(ctx, userID, w.ProgramID); errors.Is(err, store.ErrNotFound) {
+			return h.createErrorResponse(400, "programId does not refer to an existing program"), nil
+		} else if err != nil {
+			return Response{}, err
+		}
+	}
+	if w.GymID != "" {
+		if _, err := h.gyms.Get(ctx, userID, w.GymID); errors.Is(err, store.ErrNotFound) {
+			return h.createErrorResponse(400, "gymId does not refer to one of your gyms"), nil
+		} else if err != nil {
+			return Response{}, err
+		}
+	}
+
+	if completeNow {
+		completion, err := h.completeWorkout(ctx, &w, completedAt, clock.ReceivedAt)
+		if err != nil {
+			return Response{}, err
+		}
+		return h.createJSONResponse(201, completion)
+	}
+
+	if err := h.workouts.Save(ctx, &w); err != nil {
+		return Response{}, err
+	}
+	if err := h.indexSession(ctx, &w); err != nil {
+		return Response{}, err
+	}
+	h.recordExerciseUse(ctx, nil, &w)
+	return h.createJSONResponse(201, w)
+}
+
+// handlePatchWorkout updates part of a workout with a JSON merge patch or JSON
+// Patch. Status and completion go through the complete route, which runs
+// progression, so a patch cannot change them
+func (h *LambdaHandler) handlePatchWorkout(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	current, err := h.workouts.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Workout not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	var w workout.Workout
+	if errResponse := h.applyPatch(event, current, &w); errResponse != nil {
+		return *errResponse, nil
+	}
+	return h.savePatchedWorkout(ctx, current, &w)
+}
+
+// handlePatchSet updates one set of a workout, addressed by the exercise's and
+// set's positions from 0, with a JSON merge patch or JSON Patch
+func (h *LambdaHandler) handlePatchSet(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	current, exercise, set, errResponse, err := h.findSet(ctx, userID, event)
+	if err != nil {
+		return Response{}, err
+	}
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	var patchedSet workout.Set
+	if errResponse := h.applyPatch(event, current.Exercises[exercise].Sets[set], &patchedSet); errResponse != nil {
+		return *errResponse, nil
+	}
+	w := *current
+	w.Exercises = append([]workout.Exercise(nil), current.Exercises...)
+	w.Exercises[exercise].Sets = append([]workout.Set(nil), current.Exercises[exercise].Sets...)
+	w.Exercises[exercise].Sets[set] = patchedSet
+	return h.savePatchedWorkout(ctx, current, &w)
+}
+
+// findSet loads the workout in the path and resolves the positions of the
+// exercise and set in it, or returns a 404 response when any is not found
+func (h *LambdaHandler) findSet(ctx context.Context, userID string, event *APIGatewayProxyEvent) (*workout.Workout, int, int, *Response, error) {
+	notFound := func(message string) (*workout.Workout, int, int, *Response, error) {
+		response := h.createErrorResponse(404, message)
+		return nil, 0, 0, &response, nil
+	}
+
+	w, err := h.workouts.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return notFound("Workout not found")
+	}
+	if err != nil {
+		return nil, 0, 0, nil, err
+	}
+	exercise, err := strconv.Atoi(event.PathParameters["exercise"])
+	if err != nil || exercise < 0 || exercise >= len(w.Exercises) {
+		return notFound("Exercise not found")
+	}
+	set, err := strconv.Atoi(event.PathParameters["set"])
+	if err != nil || set < 0 || set >= len(w.Exercises[exercise].Sets) {
+		return notFound("Set not found")
+	}
+	return w, exercise, set, nil, nil
+}
+
+// savePatchedWorkout validates and saves w, the patched copy of current
+func (h *LambdaHandler) savePatchedWorkout(ctx context.Context, current, w *workout.Workout) (Response, error) {
+	sameCompletion := (w.CompletedAt == nil) == (current.CompletedAt == nil) &&
+		(w.CompletedAt == nil || w.CompletedAt.Equal(*current.CompletedAt))
+	if w.Status != current.Status || !sameCompletion {
+		return h.createErrorResponse(400, "status and completedAt cannot be patched; complete a workout with POST /api/workouts/{id}/complete"), nil
+	}
+	w.ID = current.ID
+	w.UserID = current.UserID
+	w.SyncedAt = current.SyncedAt
+	correctClientTimes(ctx, current, w)
+
+	if err := w.Validate(); err != nil {
+		return h.createErrorResponse(400, err.Error()), nil
+	}
+	if w.ProgramID != "" && w.ProgramID != current.ProgramID {
+		if _, err := h.programs.Get(ctx, w.UserID, w.ProgramID); errors.Is(err, store.ErrNotFound) {
+			return h.createErrorResponse(400, "programId does not refer to an existing program"), nil
+		} else if err != nil {
+			return Response{}, err
+		}
+	}
+	if w.GymID != "" && w.GymID != current.GymID {
+		if _, err := h.gyms.Get(ctx, w.UserID, w.GymID); errors.Is(err, store.ErrNotFound) {
+			return h.createErrorResponse(400, "gymId does not refer to one of your gyms"), nil
+		} else if err != nil {
+			return Response{}, err
+		}
+	}
+	if err := h.workouts.Save(ctx, w); err != nil {
+		return Response{}, err
+	}
+	h.recordExerciseUse(ctx, current, w)
+	return h.createJSONResponse(200, w)
+}
+
+// CompleteWorkoutRequest is the optional body of a completion: the session's
+// ratings, and when the device completed it if that was before the request,
+// such as while offline
+type CompleteWorkoutRequest struct {
+	workout.Ratings
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// handleCompleteWorkout completes an active workout and advances its program,
+// recording the session RPE, enjoyment and pump ratings in the body, if any
+func (h *LambdaHandler) handleCompleteWorkout(ctx context.Context, event *APIGatewayProxyEvent) (Response, error) {
+	userID, errResponse := h.requireUser(event)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	w, err := h.workouts.Get(ctx, userID, event.PathParameters["id"])
+	if errors.Is(err, store.ErrNotFound) {
+		return h.createErrorResponse(404, "Workout not found"), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	completedAt := clientClock(ctx).ReceivedAt
+	if event.Body != "" {
+		var request CompleteWorkoutRequest
+		if err := decodeBody(event, &request); err != nil {
+			return h.createErrorResponse(400, err.Error()), nil
+		}
+		if err := request.Ratings.Validate(); err != nil {
+			return h.createErrorResponse(400, err.Error()), nil
+		}
+		if request.Ratings != (workout.Ratings{}) {
+			w.Ratings = &request.Ratings
+		}
+		if request.CompletedAt != nil {
+			completedAt, w.ClientCompletedAt = serverTime(clientClock(ctx), *request.CompletedAt)
+			if !w.StartedAt.IsZero() && completedAt.Before(w.StartedAt) {
+				return h.createErrorResponse(400, "completedAt must not be before startedAt"), nil
+			}
+		}
+	}
+
+	completion, err := h.completeWorkout(ctx, w, completedAt, clientClock(ctx).ReceivedAt)
+	if errors.Is(err, workout.ErrAlreadyCompleted) {
+		return h.createErrorResponse(409, err.Error()), nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	return h.createJSONResponse(200, completion)
+}
+
+// completeWorkout marks w completed at completedAt and, when it belongs to a
+// program, computes and persists the next session's prescriptions. now is the
+// server time, which the workout is synced at and the user's activity and the
+// program's update are recorded at even when completedAt is in the past
+func (h *LambdaHandler) completeWorkout(ctx context.Context, w *workout.Workout, completedAt, now time.Time) (*CompletionResponse, error) {
+	if err := w.Complete(completedAt); err != nil {
+		return nil, err
+	}
+	w.SyncedAt = &now
+	if err := h.workouts.Save(ctx, w); err != nil {
+		return nil, err
+	}
+	if err := h.synced.Add(ctx, w.UserID, w.ID, now); err != nil {
+		return nil, err
+	}
+	if err := h.touchUser(ctx, w.UserID, now); err != nil {
+		return nil, err
+	}
+	h.recordExerciseUse(ctx, nil, w)
+	h.publishCompletion(ctx, w)
+
+	completion := &CompletionResponse{Workout: w, Changes: []progression.Change{}}
+	if w.ProgramID == "" {
+		return completion, nil
+	}
+
+	p, err := h.programs.Get(ctx, w.UserID, w.ProgramID)
+	if errors.Is(err, store.ErrNotFound) {
+		return completion, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load program for progression: %w", err)
+	}
+
+	active, err := h.activeInjuries(ctx, w.UserID, completedAt.Format(injury.DateLayout))
+	if err != nil {
+		return nil, err
+	}
+	prof, err := h.profiles.Get(ctx, w.UserID)
+	if err != nil {
+		return nil, err
+	}
+	progressing, held := withoutInjured(p, w, active)
+	completion.Changes = append(progression.Apply(p, progressing, prof.LoadRounding()), held...)
+	p.UpdatedAt = now
+	if err := h.programs.Save(ctx, p); err != nil {
+		return nil, fmt.Errorf("failed to save progressed program: %w", err)
+	}
+	completion.Program = p
+	if err := h.recordTrainingMaxes(ctx, w, p.ID, completion.Changes); err != nil {
+		return nil, fmt.Errorf("failed to record training maxes: %w", err)
+	}
+
+	h.logger.Info().
+		Str("function", "completeWorkout").
+		Str("workout_id", w.ID).
+		Str("program_id", p.ID).
+		Int("changes", len(completion.Changes)).
+		Msg("Program progressed after workout completion")
+
+	return completion, nil
+}
@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"athlete-forge/program"
+	"athlete-forge/workout"
+)
+
+const linearProgramBody = `{
+	"name": "Linear",
+	"exercises": [
+		{"exercise": "Squat", "sets": 3, "reps": 5, "weight": 100, "rule": {"type": "linear", "increment": 2.5}}
+	]
+}`
+
+// createWorkout creates a workout through the API and returns the response
+func createWorkout(t *testing.T, h *LambdaHandler, userID, body string) Response {
+	t.Helper()
+	response, err := h.HandleRequest(context.Background(), apiEvent("POST", "/api/workouts", userID, nil, body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return response
+}
+
+func TestLambdaHandler_CompleteWorkout(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("completion progresses and persists the program", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		p := createProgram(t, h, "user-1", linearProgramBody)
+		created := createWorkout(t, h, "user-1", fmt.Sprintf(`{"programId":%q,"exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100},{"reps":5,"weight":100},{"reps":5,"weight":100}]}]}`, p.ID))
+		var w workout.Workout
+		json.Unmarshal([]byte(created.Body), &w)
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("POST", "/api/workouts/"+w.ID+"/complete", "user-1", nil, ""))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", response.StatusCode, response.Body)
+		}
+		var completion CompletionResponse
+		json.Unmarshal([]byte(response.Body), &completion)
+		if completion.Workout.Status != workout.StatusCompleted || len(completion.Changes) != 1 {
+			t.Errorf("unexpected completion: %+v", completion)
+		}
+
+		stored, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/programs/"+p.ID, "user-1", nil, ""))
+		var progressed program.Program
+		json.Unmarshal([]byte(stored.Body), &progressed)
+		if progressed.Exercises[0].Weight != 102.5 || progressed.SessionsCompleted != 1 {
+			t.Errorf("expected persisted progression, got %+v", progressed)
+		}
+	})
+
+	t.Run("records ratings given on completion", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		created := createWorkout(t, h, "user-1", `{"exercises":[{"name":"Squat","sets":[{"reps":5,"weight":100}]}]}`)
+		var w workout.Workout
+		json.Unmarshal([]byte(created.Body), &w)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/workouts/"+w.ID+"/complete", "user-1", nil, `{"sessionRpe":8,"enjoyment":4}`))
+
+		// Assert
+		if response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", response.StatusCode, response.Body)
+		}
+		var completion CompletionResponse
+		json.Unmarshal([]byte(response.Body), &completion)
+		if r := completion.Workout.Ratings; r == nil || r.SessionRPE != 8 || r.Enjoyment != 4 || r.Pump != 0 {
+			t.Errorf("unexpected ratings: %+v", r)
+		}
+	})
+
+	t.Run("rejects ratings off their scale", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		created := createWorkout(t, h, "user-1", `{"exercises":[]}`)
+		var w workout.Workout
+		json.Unmarshal([]byte(created.Body), &w)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/workouts/"+w.ID+"/complete", "user-1", nil, `{"pump":6}`))
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("completing twice returns 409", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		created := createWorkout(t, h, "user-1", `{"status":"completed","exercises":[]}`)
+		var completion CompletionResponse
+		json.Unmarshal([]byte(created.Body), &completion)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/workouts/"+completion.Workout.ID+"/complete", "user-1", nil, ""))
+
+		// Assert
+		if response.StatusCode != 409 {
+			t.Errorf("expected status code 409, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("rejects workouts referencing unknown programs", func(t *testing.T) {
+		// Act
+		response := createWorkout(t, newTestHandler(), "user-1", `{"programId":"missing","exercises":[]}`)
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("workouts are private to their owner", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		created := createWorkout(t, h, "user-1", `{"exercises":[]}`)
+		var w workout.Workout
+		json.Unmarshal([]byte(created.Body), &w)
+
+		// Act
+		response, _ := h.HandleRequest(ctx, apiEvent("GET", "/api/workouts/"+w.ID, "user-2", nil, ""))
+
+		// Assert
+		if response.StatusCode != 404 {
+			t.Errorf("expected status code 404, got %d", response.StatusCode)
+		}
+	})
+}
+
+func TestLambdaHandler_CreateWorkout_Groups(t *testing.T) {
+	t.Run("stores a superset with its group", func(t *testing.T) {
+		// Act
+		response := createWorkout(t, newTestHandler(), "user-1", `{"groups":[{"id":"a","type":"superset","rounds":3,"restSeconds":90}],"exercises":[{"name":"Curl","group":"a","sets":[{"reps":10}]},{"name":"Pushdown","group":"a","sets":[{"reps":10}]}]}`)
+
+		// Assert
+		if response.StatusCode != 201 {
+			t.Fatalf("expected status code 201, got %d: %s", response.StatusCode, response.Body)
+		}
+		var w workout.Workout
+		json.Unmarshal([]byte(response.Body), &w)
+		if len(w.Groups) != 1 || w.Exercises[1].Group != "a" {
+			t.Errorf("unexpected workout: %+v", w)
+		}
+	})
+
+	t.Run("rejects a superset without a partner", func(t *testing.T) {
+		// Act
+		response := createWorkout(t, newTestHandler(), "user-1", `{"groups":[{"id":"a","type":"superset"}],"exercises":[{"name":"Curl","group":"a"}]}`)
+
+		// Assert
+		if response.StatusCode != 400 {
+			t.Errorf("expected status code 400, got %d", response.StatusCode)
+		}
+	})
+}
+
+func TestLambdaHandler_BatchGetWorkouts(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("returns found workouts in request order and missing ids", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		var first, second, other workout.Workout
+		json.Unmarshal([]byte(createWorkout(t, h, "user-1", `{"exercises":[]}`).Body), &first)
+		json.Unmarshal([]byte(createWorkout(t, h, "user-1", `{"exercises":[]}`).Body), &second)
+		json.Unmarshal([]byte(createWorkout(t, h, "user-2", `{"exercises":[]}`).Body), &other)
+		body := fmt.Sprintf(`{"ids":[%q,"missing",%q,%q,%q]}`, second.ID, first.ID, other.ID, second.ID)
+
+		// Act
+		response, err := h.HandleRequest(ctx, apiEvent("POST", "/api/workouts:batchGet", "user-1", nil, body))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if response.StatusCode != 200 {
+			t.Fatalf("expected status code 200, got %d: %s", response.StatusCode, response.Body)
+		}
+		var got BatchGetWorkoutsResponse
+		json.Unmarshal([]byte(response.Body), &got)
+		if len(got.Found) != 2 || got.Found[0].ID != second.ID || got.Found[1].ID != first.ID {
+			t.Errorf("unexpected found workouts: %+v", got.Found)
+		}
+		if len(got.Missing) != 2 || got.Missing[0] != "missing" || got.Missing[1] != other.ID {
+			t.Errorf("expected missing and other users' ids to be missing, got %v", got.Missing)
+		}
+	})
+
+	t.Run("rejects invalid id lists", func(t *testing.T) {
+		// Arrange
+		h := newTestHandler()
+		ids := make([]string, 101)
+		for i := range ids {
+			ids[i] = fmt.Sprintf("w%d", i)
+		}
+		tooMany, _ := json.Marshal(BatchGetRequest{IDs: ids})
+
+		for _, body := range []string{`{}`, `{"ids":[""]}`, string(tooMany)} {
+			// Act
+			response, _ := h.HandleRequest(ctx, apiEvent("POST", "/api/workouts:batchGet", "user-1", nil, body))
+
+			// Assert
+			if response.StatusCode != 400 {
+				t.Errorf("expected status code 400 for %.40s, got %d", body, response.StatusCode)
+			}
+		}
+	})
+}
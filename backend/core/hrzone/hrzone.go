@@ -0,0 +1,173 @@
+package hrzone
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Zone calculation methods
+const (
+	MethodMaxHR     = "max"
+	MethodThreshold = "threshold"
+)
+
+// zoneCount is the number of training zones
+const zoneCount = 5
+
+// maxHRBounds are the lower bounds of zones 1-5 as fractions of max HR
+var maxHRBounds = [zoneCount]float64{0.5, 0.6, 0.7, 0.8, 0.9}
+
+// thresholdBounds are the lower bounds of zones 2-5 as fractions of lactate threshold HR;
+// zone 1 covers everything below zone 2
+var thresholdBounds = [zoneCount]float64{0, 0.85, 0.90, 0.95, 1.0}
+
+// Config is a user's heart rate zone configuration
+type Config struct {
+	Method      string `json:"method"`
+	MaxHR       int    `json:"maxHr,omitempty"`
+	ThresholdHR int    `json:"thresholdHr,omitempty"`
+}
+
+// Validate checks the configuration has the heart rate its method needs
+func (c *Config) Validate() error {
+	switch c.Method {
+	case MethodMaxHR:
+		if c.MaxHR < 100 || c.MaxHR > 250 {
+			return errors.New("maxHr must be between 100 and 250")
+		}
+	case MethodThreshold:
+		if c.ThresholdHR < 80 || c.ThresholdHR > 230 {
+			return errors.New("thresholdHr must be between 80 and 230")
+		}
+	default:
+		return fmt.Errorf("heart rate zone method must be %q or %q", MethodMaxHR, MethodThreshold)
+	}
+	return nil
+}
+
+// Zone is a heart rate band; MaxBPM of 0 means unbounded
+type Zone struct {
+	Number int `json:"zone"`
+	MinBPM int `json:"minBpm"`
+	MaxBPM int `json:"maxBpm,omitempty"`
+}
+
+// Zones returns the five zones for the configuration
+func (c *Config) Zones() []Zone {
+	var bounds [zoneCount]float64
+	reference := 0
+	if c.Method == MethodThreshold {
+		bounds = thresholdBounds
+		reference = c.ThresholdHR
+	} else {
+		bounds = maxHRBounds
+		reference = c.MaxHR
+	}
+
+	zones := make([]Zone, zoneCount)
+	for i := range zones {
+		zones[i] = Zone{Number: i + 1, MinBPM: int(math.Round(bounds[i] * float64(reference)))}
+		if i+1 < zoneCount {
+			zones[i].MaxBPM = int(math.Round(bounds[i+1]*float64(reference))) - 1
+		}
+	}
+	return zones
+}
+
+// ZoneFor returns the zone number for bpm, or 0 when it is below zone 1
+func (c *Config) ZoneFor(bpm int) int {
+	zones := c.Zones()
+	for i := len(zones) - 1; i >= 0; i-- {
+		if bpm >= zones[i].MinBPM {
+			return zones[i].Number
+		}
+	}
+	return 0
+}
+
+// Sample is a heart rate reading at an offset in seconds from the activity start
+type Sample struct {
+	Offset int `json:"offset"`
+	BPM    int `json:"bpm"`
+}
+
+// ZoneTime is time accumulated in one zone
+type ZoneTime struct {
+	Zone    int `json:"zone"`
+	Seconds int `json:"seconds"`
+}
+
+// Summary is the time-in-zone breakdown for an activity or period
+type Summary struct {
+	Zones []ZoneTime `json:"zones"`
+
+	// BelowZones is time spent under zone 1
+	BelowZones int `json:"belowZonesSeconds"`
+
+	// Load is Edwards TRIMP: minutes in each zone weighted by the zone number
+	Load float64 `json:"load"`
+}
+
+// Summarize computes time in zone from samples, each lasting until the next sample;
+// without samples the whole duration is attributed to the average heart rate
+func (c *Config) Summarize(samples []Sample, durationSeconds, averageBPM int) Summary {
+	seconds := make([]int, zoneCount+1)
+
+	if len(samples) == 0 {
+		if averageBPM > 0 {
+			seconds[c.ZoneFor(averageBPM)] += durationSeconds
+		}
+		return SummaryOf(seconds)
+	}
+
+	for i, sample := range samples {
+		end := durationSeconds
+		if i+1 < len(samples) {
+			end = samples[i+1].Offset
+		}
+		if span := end - sample.Offset; span > 0 {
+			seconds[c.ZoneFor(sample.BPM)] += span
+		}
+	}
+	return SummaryOf(seconds)
+}
+
+// Add accumulates other into s
+func (s *Summary) Add(other Summary) {
+	if len(s.Zones) == 0 {
+		s.Zones = emptyZones()
+	}
+	for i, zone := range other.Zones {
+		s.Zones[i].Seconds += zone.Seconds
+	}
+	s.BelowZones += other.BelowZones
+	s.Load = math.Round((s.Load+other.Load)*10) / 10
+}
+
+// EmptySummary returns a summary with zero time in every zone
+func EmptySummary() Summary {
+	return Summary{Zones: emptyZones()}
+}
+
+func emptyZones() []ZoneTime {
+	zones := make([]ZoneTime, zoneCount)
+	for i := range zones {
+		zones[i].Zone = i + 1
+	}
+	return zones
+}
+
+// SummaryOf builds a summary from seconds in each zone, indexed by zone
+// number with index 0 the time below zone 1, so other kinds of zones can be
+// scored the same way
+func SummaryOf(seconds []int) Summary {
+	summary := Summary{Zones: emptyZones(), BelowZones: seconds[0]}
+	load := 0.0
+	for i := range summary.Zones {
+		summary.Zones[i].Seconds = seconds[i+1]
+		load += float64(seconds[i+1]) / 60 * float64(i+1)
+	}
+	summary.Load = math.Round(load*10) / 10
+	return summary
+}
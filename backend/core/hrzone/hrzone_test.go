@@ -0,0 +1,110 @@
+package hrzone
+
+import "testing"
+
+func TestConfig_Zones(t *testing.T) {
+	t.Run("max heart rate zones", func(t *testing.T) {
+		// Arrange
+		c := Config{Method: MethodMaxHR, MaxHR: 200}
+
+		// Act
+		zones := c.Zones()
+
+		// Assert
+		expected := []Zone{
+			{Number: 1, MinBPM: 100, MaxBPM: 119},
+			{Number: 2, MinBPM: 120, MaxBPM: 139},
+			{Number: 3, MinBPM: 140, MaxBPM: 159},
+			{Number: 4, MinBPM: 160, MaxBPM: 179},
+			{Number: 5, MinBPM: 180},
+		}
+		for i := range expected {
+			if zones[i] != expected[i] {
+				t.Errorf("zone %d: expected %+v, got %+v", i+1, expected[i], zones[i])
+			}
+		}
+	})
+
+	t.Run("threshold zones put everything below 85% in zone 1", func(t *testing.T) {
+		// Arrange
+		c := Config{Method: MethodThreshold, ThresholdHR: 170}
+
+		// Act & Assert
+		if z := c.ZoneFor(60); z != 1 {
+			t.Errorf("expected zone 1, got %d", z)
+		}
+		if z := c.ZoneFor(170); z != 5 {
+			t.Errorf("expected zone 5 at threshold, got %d", z)
+		}
+		if z := c.ZoneFor(155); z != 3 {
+			t.Errorf("expected zone 3, got %d", z)
+		}
+	})
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{name: "max method", config: Config{Method: MethodMaxHR, MaxHR: 190}},
+		{name: "max method missing max", config: Config{Method: MethodMaxHR}, wantErr: true},
+		{name: "threshold method", config: Config{Method: MethodThreshold, ThresholdHR: 165}},
+		{name: "unknown method", config: Config{Method: "karvonen"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestConfig_Summarize(t *testing.T) {
+	c := Config{Method: MethodMaxHR, MaxHR: 200}
+
+	t.Run("attributes each sample until the next", func(t *testing.T) {
+		// Arrange
+		samples := []Sample{{Offset: 0, BPM: 90}, {Offset: 60, BPM: 125}, {Offset: 360, BPM: 185}}
+
+		// Act
+		summary := c.Summarize(samples, 420, 0)
+
+		// Assert
+		if summary.BelowZones != 60 {
+			t.Errorf("expected 60s below zones, got %d", summary.BelowZones)
+		}
+		if summary.Zones[1].Seconds != 300 || summary.Zones[4].Seconds != 60 {
+			t.Errorf("unexpected zones: %+v", summary.Zones)
+		}
+		if summary.Load != 15 {
+			t.Errorf("expected load 15, got %v", summary.Load)
+		}
+	})
+
+	t.Run("falls back to average heart rate without samples", func(t *testing.T) {
+		// Act
+		summary := c.Summarize(nil, 1800, 145)
+
+		// Assert
+		if summary.Zones[2].Seconds != 1800 || summary.Load != 90 {
+			t.Errorf("unexpected summary: %+v", summary)
+		}
+	})
+
+	t.Run("add accumulates summaries", func(t *testing.T) {
+		// Arrange
+		total := EmptySummary()
+
+		// Act
+		total.Add(c.Summarize(nil, 600, 145))
+		total.Add(c.Summarize(nil, 600, 145))
+
+		// Assert
+		if total.Zones[2].Seconds != 1200 || total.Load != 60 {
+			t.Errorf("unexpected total: %+v", total)
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Default is the locale messages are written in, and the end of every
+// fallback chain
+const Default = "en"
+
+// catalogs holds the translations of each locale, one file per locale named
+// <locale>.json mapping each English message to its translation
+//
+//go:embed locales/*.json
+var catalogs embed.FS
+
+// messages maps each locale to its translations, keyed by the English message
+var messages = load()
+
+// parents are the locales a locale falls back to before its language, for
+// languages close enough to be understood
+var parents = map[string]string{
+	"gsw": "de",
+	"nn":  "nb",
+}
+
+// load reads the embedded catalogs
+func load() map[string]map[string]string {
+	files, _ := catalogs.ReadDir("locales")
+	loaded := make(map[string]map[string]string, len(files))
+	for _, file := range files {
+		data, err := catalogs.ReadFile(path.Join("locales", file.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var catalog map[string]string
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			panic(fmt.Sprintf("invalid catalog %s: %v", file.Name(), err))
+		}
+		loaded[strings.TrimSuffix(file.Name(), ".json")] = catalog
+	}
+	return loaded
+}
+
+// Supported returns the locales messages are available in, sorted
+func Supported() []string {
+	locales := []string{Default}
+	for locale := range messages {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// IsSupported reports whether locale has its own catalog or is the default
+func IsSupported(locale string) bool {
+	_, ok := messages[locale]
+	return ok || locale == Default
+}
+
+// Canonical returns a language tag lowercased with its subtags joined by
+// hyphens, such as pt-br for pt_BR
+func Canonical(tag string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+}
+
+// Chain returns the locales looked up for locale, most specific first: the
+// locale, its less specific tags, their parents and finally Default. de-at-x
+// falls back to de-at, de and en
+func Chain(locale string) []string {
+	var chain []string
+	seen := map[string]bool{}
+	for tag := Canonical(locale); tag != ""; {
+		if !seen[tag] {
+			chain = append(chain, tag)
+			seen[tag] = true
+		}
+		if i := strings.LastIndex(tag, "-"); i >= 0 {
+			tag = tag[:i]
+		} else {
+			tag = parents[tag]
+		}
+	}
+	if !seen[Default] {
+		chain = append(chain, Default)
+	}
+	return chain
+}
+
+// Resolve returns the most specific supported locale in locale's chain
+func Resolve(locale string) string {
+	for _, tag := range Chain(locale) {
+		if IsSupported(tag) {
+			return tag
+		}
+	}
+	return Default
+}
+
+// Negotiate returns the supported locale best matching an Accept-Language
+// header, such as "de-AT,de;q=0.9,en;q=0.5". Ranges are tried by quality, and
+// each through its fallback chain, so de-AT is answered in de. Default is
+// returned when no range matches
+func Negotiate(acceptLanguage string) string {
+	type languageRange struct {
+		tag string
+		q   float64
+	}
+	var ranges []languageRange
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		params := strings.Split(part, ";")
+		r := languageRange{tag: Canonical(params[0]), q: 1}
+		for _, param := range params[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(name, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					r.q = q
+				}
+			}
+		}
+		if r.tag != "" && r.q > 0 {
+			ranges = append(ranges, r)
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	for _, r := range ranges {
+		if r.tag == "*" {
+			return Default
+		}
+		for _, tag := range Chain(r.tag) {
+			if tag == Default && r.tag != Default && !strings.HasPrefix(r.tag, Default+"-") {
+				// Only an English range asks for English; others move on
+				// to the caller's next preference
+				break
+			}
+			if IsSupported(tag) {
+				return tag
+			}
+		}
+	}
+	return Default
+}
+
+// T translates message into locale, falling back through its chain to the
+// English message, and formats it with args when given
+func T(locale, message string, args ...any) string {
+	translated := message
+	for _, tag := range Chain(locale) {
+		if t, ok := messages[tag][message]; ok {
+			translated = t
+			break
+		}
+	}
+	if len(args) == 0 {
+		return translated
+	}
+	return fmt.Sprintf(translated, args...)
+}
@@ -0,0 +1,115 @@
+package i18n
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	tests := []struct {
+		name   string
+		locale string
+		want   []string
+	}{
+		{name: "language", locale: "de", want: []string{"de", "en"}},
+		{name: "region falls back to its language", locale: "de-AT", want: []string{"de-at", "de", "en"}},
+		{name: "underscore separated tag", locale: "es_MX", want: []string{"es-mx", "es", "en"}},
+		{name: "language with a parent", locale: "gsw-CH", want: []string{"gsw-ch", "gsw", "de", "en"}},
+		{name: "default", locale: "en-GB", want: []string{"en-gb", "en"}},
+		{name: "empty", locale: "", want: []string{"en"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got := Chain(tt.locale)
+
+			// Assert
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{name: "no header", header: "", want: "en"},
+		{name: "supported language", header: "fr", want: "fr"},
+		{name: "region of a supported language", header: "de-AT,de;q=0.9", want: "de"},
+		{name: "highest quality first", header: "fr;q=0.5,es;q=0.8", want: "es"},
+		{name: "unsupported language moves on to the next preference", header: "ja,fr;q=0.7", want: "fr"},
+		{name: "English preferred over a translation", header: "en-US,de;q=0.8", want: "en"},
+		{name: "refused language", header: "de;q=0,fr;q=0.1", want: "fr"},
+		{name: "wildcard", header: "ja,*;q=0.5", want: "en"},
+		{name: "nothing supported", header: "ja,ko", want: "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got := Negotiate(tt.header)
+
+			// Assert
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestT(t *testing.T) {
+	tests := []struct {
+		name    string
+		locale  string
+		message string
+		args    []any
+		want    string
+	}{
+		{name: "translated", locale: "de", message: "Workout not found", want: "Training nicht gefunden"},
+		{name: "through the fallback chain", locale: "fr-CA", message: "Workout not found", want: "Séance introuvable"},
+		{name: "formatted", locale: "es", message: "Streak: %v weeks", args: []any{3}, want: "Racha: 3 semanas"},
+		{name: "untranslated message stays English", locale: "de", message: "name is required", want: "name is required"},
+		{name: "default locale", locale: "en", message: "Streak: %v weeks", args: []any{3}, want: "Streak: 3 weeks"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got := T(tt.locale, tt.message, tt.args...)
+
+			// Assert
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCatalogs(t *testing.T) {
+	t.Run("every translation keeps its message's format verbs", func(t *testing.T) {
+		for locale, catalog := range messages {
+			for message, translated := range catalog {
+				if verbs(message) != verbs(translated) {
+					t.Errorf("%s: %q has verbs %q, its translation %q has %q", locale, message, verbs(message), translated, verbs(translated))
+				}
+			}
+		}
+	})
+}
+
+// verbs returns the format verbs in s, in order
+func verbs(s string) string {
+	var found []byte
+	for i := 0; i < len(s)-1; i++ {
+		if s[i] == '%' {
+			found = append(found, s[i+1])
+			i++
+		}
+	}
+	return string(found)
+}
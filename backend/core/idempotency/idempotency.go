@@ -0,0 +1,78 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"athlete-forge/store"
+)
+
+const recordSKPrefix = "IDEMPOTENCY#"
+
+// Retention is how long a response is replayed for its key
+const Retention = 24 * time.Hour
+
+// MaxKeyLength bounds client-chosen keys
+const MaxKeyLength = 255
+
+// ErrMismatch is returned when a key is reused for a different request
+var ErrMismatch = errors.New("idempotency key was used for a different request")
+
+// Record is the response to a write made with an idempotency key. It is stored
+// in the user's partition, so with global tables a retry sent to the other
+// region after failover replays it once replicated
+type Record struct {
+	Key         string            `json:"key"`
+	Fingerprint string            `json:"fingerprint"`
+	StatusCode  int               `json:"statusCode"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        string            `json:"body"`
+	CreatedAt   time.Time         `json:"createdAt"`
+	ExpiresAt   time.Time         `json:"expiresAt"`
+}
+
+// Fingerprint identifies a request by its method, path and body
+func Fingerprint(method, path, body string) string {
+	sum := sha256.Sum256([]byte(method + " " + path + "\n" + body))
+	return hex.EncodeToString(sum[:])
+}
+
+// Repository loads and saves idempotency records
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Get returns userID's unexpired record for key, ErrNotFound when there is none
+// and ErrMismatch when it was made for a request with a different fingerprint
+func (r *Repository) Get(ctx context.Context, userID, key, fingerprint string, now time.Time) (*Record, error) {
+	var rec Record
+	if err := r.store.Get(ctx, store.UserPK(userID), recordSKPrefix+key, &rec); err != nil {
+		return nil, err
+	}
+	if !now.Before(rec.ExpiresAt) {
+		return nil, store.ErrNotFound
+	}
+	if rec.Fingerprint != fingerprint {
+		return nil, ErrMismatch
+	}
+	return &rec, nil
+}
+
+// Save stores rec for userID, replayable until Retention after now
+func (r *Repository) Save(ctx context.Context, userID string, rec *Record, now time.Time) error {
+	rec.CreatedAt = now.UTC()
+	rec.ExpiresAt = rec.CreatedAt.Add(Retention)
+	if err := r.store.Put(ctx, store.UserPK(userID), recordSKPrefix+rec.Key, rec); err != nil {
+		return fmt.Errorf("failed to save idempotency record: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+)
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	fingerprint := Fingerprint("POST", "/api/workouts", `{"status":"active"}`)
+
+	tests := []struct {
+		name        string
+		fingerprint string
+		at          time.Time
+		wantErr     error
+	}{
+		{name: "replays the same request", fingerprint: fingerprint, at: now.Add(time.Hour)},
+		{name: "rejects a different request", fingerprint: Fingerprint("POST", "/api/workouts", `{}`), at: now.Add(time.Hour), wantErr: ErrMismatch},
+		{name: "forgets expired keys", fingerprint: fingerprint, at: now.Add(Retention), wantErr: store.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			repo := NewRepository(store.NewMemoryStore())
+			repo.Save(ctx, "user-1", &Record{Key: "k1", Fingerprint: fingerprint, StatusCode: 201, Body: `{"id":"w1"}`}, now)
+
+			// Act
+			rec, err := repo.Get(ctx, "user-1", "k1", tt.fingerprint, tt.at)
+
+			// Assert
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr == nil && (rec.StatusCode != 201 || rec.Body != `{"id":"w1"}`) {
+				t.Errorf("unexpected record: %+v", rec)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package injury
+
+import (
+	"fmt"
+	"strings"
+
+	"athlete-forge/exercise"
+	"athlete-forge/program"
+	"athlete-forge/progression"
+)
+
+// mildLoadFactor is the fraction of load kept for exercises affected by a mild injury
+const mildLoadFactor = 0.8
+
+// Actions taken on a contraindicated exercise
+const (
+	ActionReduced     = "reduced"
+	ActionSubstituted = "substituted"
+	ActionExcluded    = "excluded"
+)
+
+// Adjustment records how one prescription was changed for an active injury
+type Adjustment struct {
+	Exercise   string `json:"exercise"`
+	Action     string `json:"action"`
+	Substitute string `json:"substitute,omitempty"`
+	InjuryID   string `json:"injuryId"`
+	BodyPart   string `json:"bodyPart"`
+	Reason     string `json:"reason"`
+}
+
+// Affecting returns the first of the active injuries that contraindicates the exercise
+func Affecting(name string, active []Injury) (*Injury, bool) {
+	for i := range active {
+		if conflict(name, active[i]) {
+			return &active[i], true
+		}
+	}
+	return nil, false
+}
+
+// Adjust returns the prescriptions with contraindicated exercises changed for the
+// active injuries. Exercises affected only by mild injuries keep 80% of their
+// load; otherwise they are swapped for the first substitute no active injury
+// rules out, with the weight left for the lifter to choose, or dropped
+func Adjust(prescriptions []program.Prescription, active []Injury) ([]program.Prescription, []Adjustment) {
+	adjusted := make([]program.Prescription, 0, len(prescriptions))
+	adjustments := []Adjustment{}
+	for _, p := range prescriptions {
+		injury, mildOnly := worst(p.Exercise, active)
+		if injury == nil {
+			adjusted = append(adjusted, p)
+			continue
+		}
+
+		adjustment := Adjustment{Exercise: p.Exercise, InjuryID: injury.ID, BodyPart: injury.BodyPart}
+		switch substitute := substituteFor(p.Exercise, active); {
+		case mildOnly:
+			p.Weight = progression.RoundForRule(p.Weight*mildLoadFactor, p.Rule)
+			adjustment.Action = ActionReduced
+			adjustment.Reason = fmt.Sprintf("Mild %s injury: load reduced by 20%%", injury.BodyPart)
+			adjusted = append(adjusted, p)
+		case substitute != "":
+			p.Exercise = substitute
+			p.Weight = 0
+			adjustment.Action = ActionSubstituted
+			adjustment.Substitute = substitute
+			adjustment.Reason = fmt.Sprintf("%s %s injury: %s avoids it; choose a manageable load", capitalize(injury.Severity), injury.BodyPart, substitute)
+			adjusted = append(adjusted, p)
+		default:
+			adjustment.Action = ActionExcluded
+			adjustment.Reason = fmt.Sprintf("%s %s injury: no safe substitute, skip this exercise", capitalize(injury.Severity), injury.BodyPart)
+		}
+		adjustments = append(adjustments, adjustment)
+	}
+	return adjusted, adjustments
+}
+
+// worst returns the most severe active injury contraindicating the exercise and
+// whether every conflict is a mild injury's body part rather than a restriction
+func worst(name string, active []Injury) (*Injury, bool) {
+	var found *Injury
+	mildOnly := true
+	for i := range active {
+		injury := &active[i]
+		if !conflict(name, *injury) {
+			continue
+		}
+		if injury.Severity != SeverityMild || restricted(name, *injury) {
+			mildOnly = false
+		}
+		if found == nil || rank(injury.Severity) > rank(found.Severity) {
+			found = injury
+		}
+	}
+	return found, mildOnly
+}
+
+// restricted reports whether one of i's restrictions names the exercise or its pattern
+func restricted(name string, i Injury) bool {
+	return conflict(name, Injury{Restrictions: i.Restrictions})
+}
+
+// substituteFor returns the first substitute for the exercise that no active
+// injury contraindicates, or "" when there is none
+func substituteFor(name string, active []Injury) string {
+	e, ok := exercise.Lookup(name)
+	if !ok {
+		return ""
+	}
+	for _, candidate := range e.Substitutes {
+		if _, affected := Affecting(candidate, active); !affected {
+			return candidate
+		}
+	}
+	return ""
+}
+
+func rank(severity string) int {
+	switch severity {
+	case SeveritySevere:
+		return 3
+	case SeverityModerate:
+		return 2
+	default:
+		return 1
+	}
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,23 @@
+package injury
+
+import (
+	"strings"
+
+	"athlete-forge/exercise"
+)
+
+// conflict reports whether the named exercise is contraindicated by i, either
+// because it loads the injured body part or because a restriction names it or
+// its movement pattern. Exercises missing from the catalog are only matched by
+// restrictions naming them
+func conflict(name string, i Injury) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	e, known := exercise.Lookup(name)
+	for _, restriction := range i.Restrictions {
+		restriction = strings.ToLower(strings.TrimSpace(restriction))
+		if restriction == name || (known && restriction == e.Pattern) {
+			return true
+		}
+	}
+	return known && contains(e.BodyParts, i.BodyPart)
+}
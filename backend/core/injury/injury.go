@@ -0,0 +1,175 @@
+package injury
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"athlete-forge/exercise"
+	"athlete-forge/store"
+)
+
+const (
+	injurySKPrefix = "INJURY#"
+
+	// DateLayout is the format of injury start and end dates
+	DateLayout = "2006-01-02"
+)
+
+// Severity levels; mild injuries reduce load on affected exercises, moderate and
+// severe ones substitute or exclude them
+const (
+	SeverityMild     = "mild"
+	SeverityModerate = "moderate"
+	SeveritySevere   = "severe"
+)
+
+// Body parts an injury can affect
+const (
+	BodyPartNeck      = exercise.BodyPartNeck
+	BodyPartShoulder  = exercise.BodyPartShoulder
+	BodyPartElbow     = exercise.BodyPartElbow
+	BodyPartWrist     = exercise.BodyPartWrist
+	BodyPartChest     = exercise.BodyPartChest
+	BodyPartBack      = exercise.BodyPartBack
+	BodyPartHip       = exercise.BodyPartHip
+	BodyPartHamstring = exercise.BodyPartHamstring
+	BodyPartKnee      = exercise.BodyPartKnee
+	BodyPartAnkle     = exercise.BodyPartAnkle
+)
+
+// BodyParts lists the supported body parts
+var BodyParts = []string{
+	BodyPartNeck, BodyPartShoulder, BodyPartElbow, BodyPartWrist, BodyPartChest,
+	BodyPartBack, BodyPartHip, BodyPartHamstring, BodyPartKnee, BodyPartAnkle,
+}
+
+// Injury is a current or past injury or limitation. Restrictions name movement
+// patterns (see exercise.Patterns) or exercises to avoid beyond those loading the body part
+type Injury struct {
+	ID           string    `json:"id"`
+	UserID       string    `json:"userId"`
+	BodyPart     string    `json:"bodyPart"`
+	Severity     string    `json:"severity"`
+	StartDate    string    `json:"startDate"`
+	EndDate      string    `json:"endDate,omitempty"`
+	Restrictions []string  `json:"restrictions,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// Validate checks the injury's body part, severity and dates
+func (i *Injury) Validate() error {
+	if !contains(BodyParts, i.BodyPart) {
+		return fmt.Errorf("bodyPart must be one of %s", strings.Join(BodyParts, ", "))
+	}
+	switch i.Severity {
+	case SeverityMild, SeverityModerate, SeveritySevere:
+	default:
+		return fmt.Errorf("severity must be %q, %q or %q", SeverityMild, SeverityModerate, SeveritySevere)
+	}
+	if _, err := time.Parse(DateLayout, i.StartDate); err != nil {
+		return errors.New("startDate must be in YYYY-MM-DD format")
+	}
+	if i.EndDate != "" {
+		if _, err := time.Parse(DateLayout, i.EndDate); err != nil {
+			return errors.New("endDate must be in YYYY-MM-DD format")
+		}
+		if i.EndDate < i.StartDate {
+			return errors.New("endDate must not be before startDate")
+		}
+	}
+	for _, restriction := range i.Restrictions {
+		if strings.TrimSpace(restriction) == "" {
+			return errors.New("restrictions must not be empty")
+		}
+	}
+	return nil
+}
+
+// ActiveOn reports whether the injury applies on date, a YYYY-MM-DD string;
+// injuries without an end date stay active
+func (i *Injury) ActiveOn(date string) bool {
+	return i.StartDate <= date && (i.EndDate == "" || date <= i.EndDate)
+}
+
+// Active returns the injuries that apply on date
+func Active(injuries []Injury, date string) []Injury {
+	active := []Injury{}
+	for _, i := range injuries {
+		if i.ActiveOn(date) {
+			active = append(active, i)
+		}
+	}
+	return active
+}
+
+// Repository loads and saves injuries
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Get returns the injury with id owned by userID
+func (r *Repository) Get(ctx context.Context, userID, id string) (*Injury, error) {
+	var i Injury
+	if err := r.store.Get(ctx, store.UserPK(userID), injurySKPrefix+id, &i); err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+// List returns all of userID's injuries, oldest first
+func (r *Repository) List(ctx context.Context, userID string) ([]Injury, error) {
+	items, err := r.store.Query(ctx, store.UserPK(userID), injurySKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list injuries: %w", err)
+	}
+
+	injuries := make([]Injury, 0, len(items))
+	for _, item := range items {
+		var i Injury
+		if err := item.Decode(&i); err != nil {
+			return nil, err
+		}
+		injuries = append(injuries, i)
+	}
+	return injuries, nil
+}
+
+// Save validates and stores i, assigning an ID to new injuries
+func (r *Repository) Save(ctx context.Context, i *Injury) error {
+	if err := i.Validate(); err != nil {
+		return err
+	}
+	if i.ID == "" {
+		i.ID = store.NewID()
+	}
+	if err := r.store.Put(ctx, store.UserPK(i.UserID), injurySKPrefix+i.ID, i); err != nil {
+		return fmt.Errorf("failed to save injury: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the injury with id owned by userID
+func (r *Repository) Delete(ctx context.Context, userID, id string) error {
+	if err := r.store.Delete(ctx, store.UserPK(userID), injurySKPrefix+id); err != nil {
+		return fmt.Errorf("failed to delete injury: %w", err)
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package injury
+
+import (
+	"context"
+	"testing"
+
+	"athlete-forge/program"
+	"athlete-forge/store"
+)
+
+func TestInjury_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		injury  Injury
+		wantErr bool
+	}{
+		{name: "valid", injury: Injury{BodyPart: BodyPartKnee, Severity: SeverityModerate, StartDate: "2026-03-01"}},
+		{name: "unknown body part", injury: Injury{BodyPart: "spleen", Severity: SeverityMild, StartDate: "2026-03-01"}, wantErr: true},
+		{name: "unknown severity", injury: Injury{BodyPart: BodyPartKnee, Severity: "bad", StartDate: "2026-03-01"}, wantErr: true},
+		{name: "end before start", injury: Injury{BodyPart: BodyPartKnee, Severity: SeverityMild, StartDate: "2026-03-01", EndDate: "2026-02-01"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			err := tt.injury.Validate()
+
+			// Assert
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestActive(t *testing.T) {
+	// Arrange
+	injuries := []Injury{
+		{ID: "healed", StartDate: "2026-01-01", EndDate: "2026-02-01"},
+		{ID: "ongoing", StartDate: "2026-02-15"},
+		{ID: "future", StartDate: "2026-04-01"},
+	}
+
+	// Act
+	active := Active(injuries, "2026-03-02")
+
+	// Assert
+	if len(active) != 1 || active[0].ID != "ongoing" {
+		t.Errorf("expected only the ongoing injury, got %+v", active)
+	}
+}
+
+func TestAdjust(t *testing.T) {
+	linear := program.Rule{Type: program.RuleLinear, Increment: 2.5}
+	session := []program.Prescription{
+		{Exercise: "Squat", Sets: 3, Reps: 5, Weight: 100, Rule: linear},
+		{Exercise: "Bench Press", Sets: 3, Reps: 5, Weight: 80, Rule: linear},
+		{Exercise: "Pull-up", Sets: 3, Reps: 8, Weight: 0, Rule: linear},
+	}
+
+	t.Run("substitutes around a moderate back injury", func(t *testing.T) {
+		// Arrange
+		active := []Injury{{ID: "i1", BodyPart: BodyPartBack, Severity: SeverityModerate}}
+
+		// Act
+		adjusted, adjustments := Adjust(session, active)
+
+		// Assert
+		if len(adjusted) != 3 || adjusted[0].Exercise != "belt squat" || adjusted[0].Weight != 0 || adjusted[0].Sets != 3 {
+			t.Fatalf("unexpected session: %+v", adjusted)
+		}
+		if len(adjustments) != 1 || adjustments[0].Action != ActionSubstituted || adjustments[0].InjuryID != "i1" {
+			t.Errorf("unexpected adjustments: %+v", adjustments)
+		}
+	})
+
+	t.Run("excludes exercises with no safe substitute", func(t *testing.T) {
+		// Arrange
+		active := []Injury{{ID: "i1", BodyPart: BodyPartShoulder, Severity: SeveritySevere}}
+
+		// Act
+		adjusted, adjustments := Adjust(session, active)
+
+		// Assert
+		if len(adjusted) != 2 || adjusted[1].Exercise != "floor press" {
+			t.Fatalf("unexpected session: %+v", adjusted)
+		}
+		if len(adjustments) != 2 || adjustments[1].Exercise != "Pull-up" || adjustments[1].Action != ActionExcluded {
+			t.Errorf("unexpected adjustments: %+v", adjustments)
+		}
+	})
+
+	t.Run("reduces load for mild injuries", func(t *testing.T) {
+		// Arrange
+		active := []Injury{{ID: "i1", BodyPart: BodyPartKnee, Severity: SeverityMild}}
+
+		// Act
+		adjusted, adjustments := Adjust(session, active)
+
+		// Assert
+		if adjusted[0].Exercise != "Squat" || adjusted[0].Weight != 80 {
+			t.Errorf("expected squat at 80, got %+v", adjusted[0])
+		}
+		if len(adjustments) != 1 || adjustments[0].Action != ActionReduced {
+			t.Errorf("unexpected adjustments: %+v", adjustments)
+		}
+	})
+
+	t.Run("honours restrictions on patterns and unknown exercises", func(t *testing.T) {
+		// Arrange
+		active := []Injury{{ID: "i1", BodyPart: BodyPartAnkle, Severity: SeverityMild, Restrictions: []string{"vertical-pull", "Zercher Carry"}}}
+		custom := append([]program.Prescription{{Exercise: "Zercher Carry", Sets: 3, Reps: 1, Weight: 60, Rule: linear}}, session...)
+
+		// Act
+		adjusted, adjustments := Adjust(custom, active)
+
+		// Assert
+		if len(adjusted) != 2 || adjusted[0].Exercise != "Squat" || adjusted[1].Exercise != "Bench Press" {
+			t.Errorf("unexpected session: %+v", adjusted)
+		}
+		if len(adjustments) != 2 {
+			t.Errorf("unexpected adjustments: %+v", adjustments)
+		}
+	})
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("saves, lists and deletes injuries", func(t *testing.T) {
+		// Arrange
+		repo := NewRepository(store.NewMemoryStore())
+		i := &Injury{UserID: "user-1", BodyPart: BodyPartKnee, Severity: SeverityMild, StartDate: "2026-03-01"}
+
+		// Act
+		if err := repo.Save(ctx, i); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		listed, _ := repo.List(ctx, "user-1")
+		repo.Delete(ctx, "user-1", i.ID)
+		remaining, _ := repo.List(ctx, "user-1")
+
+		// Assert
+		if i.ID == "" || len(listed) != 1 || listed[0].BodyPart != BodyPartKnee {
+			t.Errorf("unexpected injuries: %+v", listed)
+		}
+		if len(remaining) != 0 {
+			t.Errorf("expected injury deleted, got %+v", remaining)
+		}
+	})
+}
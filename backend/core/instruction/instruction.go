@@ -0,0 +1,340 @@
+package instruction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"athlete-forge/store"
+)
+
+const (
+	instructionPK   = "EXERCISE_INSTRUCTIONS"
+	versionSKPrefix = "VERSION#"
+	auditSKPrefix   = "AUDIT#"
+	maxSummarySize  = 1000
+	maxLines        = 20
+	maxLineSize     = 500
+	maxNoteSize     = 2000
+)
+
+// Version statuses. Each exercise has at most one draft, edited until it is
+// published, and at most one published version; publishing supersedes the
+// version it replaces
+const (
+	StatusDraft      = "draft"
+	StatusPublished  = "published"
+	StatusSuperseded = "superseded"
+)
+
+// Audited actions on an exercise's instructions
+const (
+	ActionCreate  = "create"
+	ActionEdit    = "edit"
+	ActionPublish = "publish"
+	ActionDiscard = "discard"
+)
+
+// ErrNoDraft is returned when publishing or discarding an exercise's draft
+// that does not exist
+var ErrNoDraft = errors.New("the exercise has no draft instructions")
+
+// Content is what users are shown about performing an exercise: a summary,
+// the steps in order, the coaching cues and the common mistakes to avoid
+type Content struct {
+	Summary  string   `json:"summary"`
+	Steps    []string `json:"steps"`
+	Cues     []string `json:"cues"`
+	Mistakes []string `json:"mistakes"`
+}
+
+// Normalize trims c's text, dropping empty lines, and checks it has a summary
+// or steps and is not too long
+func (c *Content) Normalize() error {
+	c.Summary = strings.TrimSpace(c.Summary)
+	if len(c.Summary) > maxSummarySize {
+		return fmt.Errorf("summary must be at most %d characters", maxSummarySize)
+	}
+	for _, field := range []struct {
+		name  string
+		lines *[]string
+	}{{"steps", &c.Steps}, {"cues", &c.Cues}, {"mistakes", &c.Mistakes}} {
+		kept := []string{}
+		for _, line := range *field.lines {
+			if line = strings.TrimSpace(line); line != "" {
+				kept = append(kept, line)
+			}
+		}
+		if len(kept) > maxLines {
+			return fmt.Errorf("%s must have at most %d entries", field.name, maxLines)
+		}
+		for _, line := range kept {
+			if len(line) > maxLineSize {
+				return fmt.Errorf("each of %s must be at most %d characters", field.name, maxLineSize)
+			}
+		}
+		*field.lines = kept
+	}
+	if c.Summary == "" && len(c.Steps) == 0 {
+		return errors.New("summary or steps is required")
+	}
+	return nil
+}
+
+// changes returns the fields of c that differ from previous, in field order
+func (c Content) changes(previous Content) []string {
+	changed := []string{}
+	if c.Summary != previous.Summary {
+		changed = append(changed, "summary")
+	}
+	for _, field := range []struct {
+		name      string
+		now, then []string
+	}{{"steps", c.Steps, previous.Steps}, {"cues", c.Cues, previous.Cues}, {"mistakes", c.Mistakes, previous.Mistakes}} {
+		if strings.Join(field.now, "\x00") != strings.Join(field.then, "\x00") || len(field.now) != len(field.then) {
+			changed = append(changed, field.name)
+		}
+	}
+	return changed
+}
+
+// ValidateNote checks an admin's note on a change is not too long
+func ValidateNote(note string) error {
+	if len(note) > maxNoteSize {
+		return fmt.Errorf("note must be at most %d characters", maxNoteSize)
+	}
+	return nil
+}
+
+// Version is one numbered revision of a catalog exercise's instructions
+type Version struct {
+	Exercise string `json:"exercise"`
+	Number   int    `json:"version"`
+	Status   string `json:"status"`
+	Content
+	CreatedBy   string     `json:"createdBy"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedBy   string     `json:"updatedBy"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	PublishedBy string     `json:"publishedBy,omitempty"`
+	PublishedAt *time.Time `json:"publishedAt,omitempty"`
+}
+
+// Entry is a record in the audit trail of edits to an exercise's instructions.
+// Changes names the content fields an edit or a new draft changed from the
+// version before it
+type Entry struct {
+	ID       string    `json:"id"`
+	Exercise string    `json:"exercise"`
+	Version  int       `json:"version"`
+	AdminID  string    `json:"adminId"`
+	Action   string    `json:"action"`
+	Changes  []string  `json:"changes,omitempty"`
+	Note     string    `json:"note,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// Repository stores the versions of exercises' instructions and the audit
+// trail of edits to them. Each is held in one partition, which suits a catalog
+// edited by a handful of admins
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// versionSK returns the sort key of exercise's version number, zero-padded so
+// versions sort in order
+func versionSK(exercise string, number int) string {
+	return fmt.Sprintf("%s%s#%06d", versionSKPrefix, exercise, number)
+}
+
+// Versions returns exercise's versions, newest first
+func (r *Repository) Versions(ctx context.Context, exercise string) ([]Version, error) {
+	items, err := r.store.Query(ctx, instructionPK, versionSKPrefix+exercise+"#")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list instruction versions: %w", err)
+	}
+
+	versions := make([]Version, 0, len(items))
+	for i := len(items) - 1; i >= 0; i-- {
+		var v Version
+		if err := items[i].Decode(&v); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// Published returns exercise's published version, or store.ErrNotFound when
+// none has been published
+func (r *Repository) Published(ctx context.Context, exercise string) (*Version, error) {
+	return r.withStatus(ctx, exercise, StatusPublished)
+}
+
+// Draft returns exercise's draft, or store.ErrNotFound when it has none
+func (r *Repository) Draft(ctx context.Context, exercise string) (*Version, error) {
+	return r.withStatus(ctx, exercise, StatusDraft)
+}
+
+// withStatus returns exercise's newest version with status
+func (r *Repository) withStatus(ctx context.Context, exercise, status string) (*Version, error) {
+	versions, err := r.Versions(ctx, exercise)
+	if err != nil {
+		return nil, err
+	}
+	for i := range versions {
+		if versions[i].Status == status {
+			return &versions[i], nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+// SaveDraft replaces the content of exercise's draft, starting a new draft
+// numbered after the newest version when there is none, and records the edit
+// with the fields it changed. An edit changing nothing is not recorded
+func (r *Repository) SaveDraft(ctx context.Context, exercise string, content Content, adminID, note string, now time.Time) (*Version, error) {
+	versions, err := r.Versions(ctx, exercise)
+	if err != nil {
+		return nil, err
+	}
+
+	var draft *Version
+	var previous Content
+	action := ActionCreate
+	if len(versions) > 0 {
+		previous = versions[0].Content
+		if versions[0].Status == StatusDraft {
+			draft = &versions[0]
+			action = ActionEdit
+		}
+	}
+	if draft == nil {
+		number := 1
+		if len(versions) > 0 {
+			number = versions[0].Number + 1
+		}
+		draft = &Version{Exercise: exercise, Number: number, Status: StatusDraft, CreatedBy: adminID, CreatedAt: now}
+	}
+
+	changes := content.changes(previous)
+	if action == ActionEdit && len(changes) == 0 {
+		return draft, nil
+	}
+	draft.Content = content
+	draft.UpdatedBy, draft.UpdatedAt = adminID, now
+	if err := r.save(ctx, draft); err != nil {
+		return nil, err
+	}
+	entry := &Entry{Exercise: exercise, Version: draft.Number, AdminID: adminID, Action: action, Changes: changes, Note: note, At: now}
+	if err := r.record(ctx, entry); err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+// Publish makes exercise's draft the version users see, superseding the
+// version published before it, and records it; ErrNoDraft is returned when
+// there is no draft
+func (r *Repository) Publish(ctx context.Context, exercise, adminID, note string, now time.Time) (*Version, error) {
+	versions, err := r.Versions(ctx, exercise)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 || versions[0].Status != StatusDraft {
+		return nil, ErrNoDraft
+	}
+
+	draft := &versions[0]
+	for i := range versions[1:] {
+		v := &versions[i+1]
+		if v.Status == StatusPublished {
+			v.Status = StatusSuperseded
+			if err := r.save(ctx, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	draft.Status = StatusPublished
+	draft.PublishedBy, draft.PublishedAt = adminID, &now
+	if err := r.save(ctx, draft); err != nil {
+		return nil, err
+	}
+	entry := &Entry{Exercise: exercise, Version: draft.Number, AdminID: adminID, Action: ActionPublish, Note: note, At: now}
+	if err := r.record(ctx, entry); err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+// Discard deletes exercise's draft and records it; ErrNoDraft is returned when
+// there is no draft
+func (r *Repository) Discard(ctx context.Context, exercise, adminID, note string, now time.Time) (*Version, error) {
+	draft, err := r.Draft(ctx, exercise)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrNoDraft
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := r.store.Delete(ctx, instructionPK, versionSK(exercise, draft.Number)); err != nil {
+		return nil, fmt.Errorf("failed to discard draft instructions: %w", err)
+	}
+	entry := &Entry{Exercise: exercise, Version: draft.Number, AdminID: adminID, Action: ActionDiscard, Note: note, At: now}
+	if err := r.record(ctx, entry); err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+// save stores v
+func (r *Repository) save(ctx context.Context, v *Version) error {
+	if err := r.store.Put(ctx, instructionPK, versionSK(v.Exercise, v.Number), v); err != nil {
+		return fmt.Errorf("failed to save instructions: %w", err)
+	}
+	return nil
+}
+
+// record appends e to the audit trail
+func (r *Repository) record(ctx context.Context, e *Entry) error {
+	e.ID = store.NewID()
+	sk := auditSKPrefix + e.Exercise + "#" + e.ID
+	if err := r.store.Put(ctx, instructionPK, sk, e); err != nil {
+		return fmt.Errorf("failed to record instruction edit: %w", err)
+	}
+	return nil
+}
+
+// Audit returns the audit trail of edits to exercise's instructions, or to
+// every exercise's when exercise is empty, newest first
+func (r *Repository) Audit(ctx context.Context, exercise string) ([]Entry, error) {
+	prefix := auditSKPrefix
+	if exercise != "" {
+		prefix += exercise + "#"
+	}
+	items, err := r.store.Query(ctx, instructionPK, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list instruction edits: %w", err)
+	}
+
+	entries := []Entry{}
+	for _, item := range items {
+		var e Entry
+		if err := item.Decode(&e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].At.After(entries[j].At)
+	})
+	return entries, nil
+}
@@ -0,0 +1,137 @@
+package instruction
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+)
+
+func TestContent_Normalize(t *testing.T) {
+	t.Run("trims text and drops empty lines", func(t *testing.T) {
+		// Arrange
+		c := Content{Summary: " Squat to depth ", Steps: []string{" Brace ", "", "Sit down"}}
+
+		// Act
+		err := c.Normalize()
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if c.Summary != "Squat to depth" || !reflect.DeepEqual(c.Steps, []string{"Brace", "Sit down"}) || c.Cues == nil {
+			t.Errorf("unexpected content %+v", c)
+		}
+	})
+
+	t.Run("rejects invalid content", func(t *testing.T) {
+		steps := make([]string, maxLines+1)
+		for i := range steps {
+			steps[i] = "step"
+		}
+		cases := map[string]Content{
+			"empty":          {Cues: []string{"Chest up"}},
+			"long summary":   {Summary: strings.Repeat("a", maxSummarySize+1)},
+			"too many steps": {Steps: steps},
+			"long cue":       {Summary: "Squat", Cues: []string{strings.Repeat("a", maxLineSize+1)}},
+		}
+		for name, c := range cases {
+			if err := c.Normalize(); err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+		}
+	})
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	first := Content{Summary: "Squat", Steps: []string{"Brace", "Sit down"}, Cues: []string{}, Mistakes: []string{}}
+	second := Content{Summary: "Squat", Steps: []string{"Brace", "Sit down"}, Cues: []string{"Knees out"}, Mistakes: []string{}}
+
+	t.Run("drafts stay hidden until published, which supersedes the previous version", func(t *testing.T) {
+		// Arrange
+		r := NewRepository(store.NewMemoryStore())
+		if _, err := r.SaveDraft(ctx, "squat", first, "admin-1", "", now); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := r.Publish(ctx, "squat", "admin-2", "reviewed", now.Add(time.Hour)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// Act
+		draft, err := r.SaveDraft(ctx, "squat", second, "admin-1", "", now.Add(2*time.Hour))
+		before, _ := r.Published(ctx, "squat")
+		published, _ := r.Publish(ctx, "squat", "admin-2", "", now.Add(3*time.Hour))
+		versions, _ := r.Versions(ctx, "squat")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if draft.Number != 2 || draft.Status != StatusDraft {
+			t.Errorf("unexpected draft %+v", draft)
+		}
+		if before.Number != 1 || published.Number != 2 || published.PublishedBy != "admin-2" {
+			t.Errorf("expected version 1 then 2 published, got %+v and %+v", before, published)
+		}
+		if len(versions) != 2 || versions[0].Status != StatusPublished || versions[1].Status != StatusSuperseded {
+			t.Errorf("unexpected versions %+v", versions)
+		}
+	})
+
+	t.Run("audits edits with the fields they changed", func(t *testing.T) {
+		// Arrange
+		r := NewRepository(store.NewMemoryStore())
+		r.SaveDraft(ctx, "squat", first, "admin-1", "", now)
+		r.Publish(ctx, "squat", "admin-2", "", now.Add(time.Hour))
+		r.SaveDraft(ctx, "squat", second, "admin-1", "add a cue", now.Add(2*time.Hour))
+		r.SaveDraft(ctx, "squat", second, "admin-1", "", now.Add(3*time.Hour))
+
+		// Act
+		entries, err := r.Audit(ctx, "squat")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(entries) != 3 {
+			t.Fatalf("expected 3 entries, the unchanged edit left out, got %+v", entries)
+		}
+		if entries[0].Action != ActionCreate || entries[0].Version != 2 || !reflect.DeepEqual(entries[0].Changes, []string{"cues"}) || entries[0].Note != "add a cue" {
+			t.Errorf("unexpected entry %+v", entries[0])
+		}
+		if entries[1].Action != ActionPublish || entries[2].Action != ActionCreate {
+			t.Errorf("unexpected entries %+v", entries)
+		}
+	})
+
+	t.Run("discards the draft, keeping the published version", func(t *testing.T) {
+		// Arrange
+		r := NewRepository(store.NewMemoryStore())
+		r.SaveDraft(ctx, "squat", first, "admin-1", "", now)
+		r.Publish(ctx, "squat", "admin-1", "", now)
+		r.SaveDraft(ctx, "squat", second, "admin-1", "", now)
+
+		// Act
+		_, err := r.Discard(ctx, "squat", "admin-1", "", now)
+		_, again := r.Discard(ctx, "squat", "admin-1", "", now)
+		_, publish := r.Publish(ctx, "squat", "admin-1", "", now)
+		published, _ := r.Published(ctx, "squat")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !errors.Is(again, ErrNoDraft) || !errors.Is(publish, ErrNoDraft) {
+			t.Errorf("expected ErrNoDraft, got %v and %v", again, publish)
+		}
+		if published.Number != 1 || len(published.Cues) != 0 {
+			t.Errorf("unexpected published version %+v", published)
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"athlete-forge/cardio"
+	"athlete-forge/webhook"
+)
+
+// GarminAPI is the Garmin Health API base URL
+const GarminAPI = "https://apis.garmin.com"
+
+// Garmin reads the activity summaries Garmin Health API pushes. A push can
+// carry summaries for several users, each naming its Garmin user ID
+type Garmin struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewGarmin creates a Garmin adapter calling the Health API
+func NewGarmin() *Garmin {
+	return &Garmin{
+		BaseURL:    GarminAPI,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type garminPush struct {
+	Activities []garminActivity `json:"activities"`
+}
+
+type garminActivity struct {
+	UserID                           string  `json:"userId"`
+	SummaryID                        string  `json:"summaryId"`
+	ActivityType                     string  `json:"activityType"`
+	StartTimeInSeconds               int64   `json:"startTimeInSeconds"`
+	DurationInSeconds                int     `json:"durationInSeconds"`
+	DistanceInMeters                 float64 `json:"distanceInMeters"`
+	AverageHeartRateInBeatsPerMinute int     `json:"averageHeartRateInBeatsPerMinute"`
+	MaxHeartRateInBeatsPerMinute     int     `json:"maxHeartRateInBeatsPerMinute"`
+}
+
+// Provider returns the provider name
+func (Garmin) Provider() string {
+	return webhook.ProviderGarmin
+}
+
+// UserID returns the Garmin user ID accessToken was granted for
+func (g Garmin) UserID(ctx context.Context, accessToken string) (string, error) {
+	var user struct {
+		UserID string `json:"userId"`
+	}
+	if err := getJSON(ctx, g.HTTPClient, strings.TrimRight(g.BaseURL, "/")+"/wellness-api/rest/user/id", accessToken, &user); err != nil {
+		return "", fmt.Errorf("failed to fetch Garmin user: %w", err)
+	}
+	if user.UserID == "" {
+		return "", errors.New("Garmin user has no ID")
+	}
+	return user.UserID, nil
+}
+
+// Activities maps the push's activity summaries; other summaries, such as
+// dailies and sleep, are ignored
+func (Garmin) Activities(ctx context.Context, e webhook.Event, _ TokenFunc) ([]Activity, error) {
+	var push garminPush
+	if err := json.Unmarshal([]byte(e.Body), &push); err != nil {
+		return nil, fmt.Errorf("failed to decode Garmin push: %w", err)
+	}
+
+	activities := make([]Activity, 0, len(push.Activities))
+	for _, a := range push.Activities {
+		activities = append(activities, Activity{
+			ProviderUserID: a.UserID,
+			SourceID:       a.SummaryID,
+			Activity: cardio.Activity{
+				Sport:           sportFromType(a.ActivityType),
+				StartTime:       time.Unix(a.StartTimeInSeconds, 0).UTC(),
+				DurationSeconds: a.DurationInSeconds,
+				DistanceMeters:  a.DistanceInMeters,
+				AverageHR:       a.AverageHeartRateInBeatsPerMinute,
+				MaxHR:           a.MaxHeartRateInBeatsPerMinute,
+			},
+		})
+	}
+	return activities, nil
+}
@@ -0,0 +1,58 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxResponseSize bounds the provider API responses read
+const maxResponseSize = 1 << 20
+
+// ErrUnauthorized is returned when a provider rejects the access token, which
+// has usually expired
+var ErrUnauthorized = errors.New("provider rejected the access token")
+
+// ErrRejected is returned when a provider rejects a request as invalid, such as
+// an authorization code that has expired or was already used
+var ErrRejected = errors.New("provider rejected the request")
+
+// getJSON fetches rawURL with accessToken and decodes the JSON response into v
+func getJSON(ctx context.Context, client *http.Client, rawURL, accessToken string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	return doJSON(client, req, v)
+}
+
+// doJSON sends req and decodes the JSON response into v
+func doJSON(client *http.Client, req *http.Request, v interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return fmt.Errorf("failed to read response from %s: %w", req.URL.Host, err)
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return ErrUnauthorized
+	}
+	if resp.StatusCode == http.StatusBadRequest {
+		return fmt.Errorf("%s returned 400 for %s: %w", req.URL.Host, req.URL.Path, ErrRejected)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d for %s", req.URL.Host, resp.StatusCode, req.URL.Path)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", req.URL.Host, err)
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"athlete-forge/cardio"
+	"athlete-forge/envelope"
+	"athlete-forge/store"
+	"athlete-forge/webhook"
+)
+
+const (
+	connectionSKPrefix = "INTEGRATION#"
+	ownerPKPrefix      = "INTEGRATION#"
+	ownerSK            = "OWNER"
+)
+
+// ErrConnected is returned when connecting a provider account that is already
+// connected to another user
+var ErrConnected = errors.New("provider account is connected to another user")
+
+// Connection links a user to their account with a provider, so the provider's
+// webhook deliveries, which name only the provider's user ID, reach them. Token
+// and Refresh are the user's provider OAuth tokens, sealed, for providers whose
+// data must be fetched
+type Connection struct {
+	Provider       string           `json:"provider"`
+	UserID         string           `json:"userId"`
+	ProviderUserID string           `json:"providerUserId"`
+	Token          *envelope.Sealed `json:"token,omitempty"`
+	Refresh        *envelope.Sealed `json:"refresh,omitempty"`
+	ConnectedAt    time.Time        `json:"connectedAt"`
+}
+
+// Tokens are a user's OAuth tokens with a provider; either may be empty
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+// Activity is a cardio activity read from a provider's event, with the provider
+// user it belongs to and the provider's ID for it
+type Activity struct {
+	ProviderUserID string
+	SourceID       string
+	Activity       cardio.Activity
+}
+
+// TokenFunc returns the access token of the user connected as providerUserID
+type TokenFunc func(ctx context.Context, providerUserID string) (string, error)
+
+// Adapter reads cardio activities from one provider's webhook events
+type Adapter interface {
+	Provider() string
+	// Activities returns the activities e carries or announces; events with
+	// nothing to import return none
+	Activities(ctx context.Context, e webhook.Event, token TokenFunc) ([]Activity, error)
+}
+
+// Identifier finds the provider account an access token was granted for, so
+// the account a user connects is the provider's word rather than the app's
+type Identifier interface {
+	Provider() string
+	// UserID returns the provider's ID of the user accessToken belongs to; a
+	// rejected token returns ErrUnauthorized
+	UserID(ctx context.Context, accessToken string) (string, error)
+}
+
+// Authorizer exchanges the authorization code the app received for the user's
+// tokens, for providers that name the user only in the exchange
+type Authorizer interface {
+	Provider() string
+	// Authorize returns the tokens code grants and the provider's ID of their
+	// user; a rejected code returns ErrRejected
+	Authorize(ctx context.Context, code, redirectURI string) (Tokens, string, error)
+}
+
+// Repository stores users' provider connections, with a lookup item per
+// provider account finding its user
+type Repository struct {
+	store  store.Store
+	cipher *envelope.Cipher
+}
+
+// NewRepository creates a Repository backed by s, sealing access tokens with c
+func NewRepository(s store.Store, c *envelope.Cipher) *Repository {
+	return &Repository{store: s, cipher: c}
+}
+
+// Connect saves c, replacing the user's earlier connection to the provider, with
+// its tokens sealed
+func (r *Repository) Connect(ctx context.Context, c *Connection, tokens Tokens) error {
+	owner, err := r.Owner(ctx, c.Provider, c.ProviderUserID)
+	if err == nil && owner.UserID != c.UserID {
+		return ErrConnected
+	}
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+	if err := r.Disconnect(ctx, c.UserID, c.Provider); err != nil {
+		return err
+	}
+	return r.UpdateTokens(ctx, c, tokens)
+}
+
+// UpdateTokens seals tokens into c and saves it, as after a token refresh
+func (r *Repository) UpdateTokens(ctx context.Context, c *Connection, tokens Tokens) error {
+	var err error
+	if c.Token, err = r.seal(ctx, tokens.AccessToken, c.UserID); err != nil {
+		return fmt.Errorf("failed to encrypt access token: %w", err)
+	}
+	if c.Refresh, err = r.seal(ctx, tokens.RefreshToken, c.UserID); err != nil {
+		return fmt.Errorf("failed to encrypt refresh token: %w", err)
+	}
+	if err := r.store.Put(ctx, store.UserPK(c.UserID), connectionSKPrefix+c.Provider, c); err != nil {
+		return fmt.Errorf("failed to save connection: %w", err)
+	}
+	if err := r.store.Put(ctx, ownerKey(c.Provider, c.ProviderUserID), ownerSK, c); err != nil {
+		return fmt.Errorf("failed to save connection: %w", err)
+	}
+	return nil
+}
+
+// Get returns userID's connection to provider
+func (r *Repository) Get(ctx context.Context, userID, provider string) (*Connection, error) {
+	var c Connection
+	if err := r.store.Get(ctx, store.UserPK(userID), connectionSKPrefix+provider, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// List returns userID's connections
+func (r *Repository) List(ctx context.Context, userID string) ([]Connection, error) {
+	items, err := r.store.Query(ctx, store.UserPK(userID), connectionSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list connections: %w", err)
+	}
+
+	connections := make([]Connection, 0, len(items))
+	for _, item := range items {
+		var c Connection
+		if err := item.Decode(&c); err != nil {
+			return nil, err
+		}
+		connections = append(connections, c)
+	}
+	return connections, nil
+}
+
+// Disconnect removes userID's connection to provider; it is not an error when
+// there is none
+func (r *Repository) Disconnect(ctx context.Context, userID, provider string) error {
+	c, err := r.Get(ctx, userID, provider)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := r.store.Delete(ctx, ownerKey(provider, c.ProviderUserID), ownerSK); err != nil {
+		return fmt.Errorf("failed to delete connection: %w", err)
+	}
+	if err := r.store.Delete(ctx, store.UserPK(userID), connectionSKPrefix+provider); err != nil {
+		return fmt.Errorf("failed to delete connection: %w", err)
+	}
+	return nil
+}
+
+// Owner returns the connection of the user connected to provider as
+// providerUserID
+func (r *Repository) Owner(ctx context.Context, provider, providerUserID string) (*Connection, error) {
+	var c Connection
+	if err := r.store.Get(ctx, ownerKey(provider, providerUserID), ownerSK, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Tokens opens c's tokens
+func (r *Repository) Tokens(ctx context.Context, c *Connection) (Tokens, error) {
+	var tokens Tokens
+	var err error
+	if tokens.AccessToken, err = r.open(ctx, c.Token, c.UserID); err != nil {
+		return Tokens{}, fmt.Errorf("failed to decrypt access token: %w", err)
+	}
+	if tokens.RefreshToken, err = r.open(ctx, c.Refresh, c.UserID); err != nil {
+		return Tokens{}, fmt.Errorf("failed to decrypt refresh token: %w", err)
+	}
+	return tokens, nil
+}
+
+// seal seals token for userID, returning nil for an empty token
+func (r *Repository) seal(ctx context.Context, token, userID string) (*envelope.Sealed, error) {
+	if token == "" {
+		return nil, nil
+	}
+	return r.cipher.Seal(ctx, []byte(token), userID)
+}
+
+// open opens a sealed token, returning "" when there is none
+func (r *Repository) open(ctx context.Context, s *envelope.Sealed, userID string) (string, error) {
+	if s == nil {
+		return "", nil
+	}
+	token, err := r.cipher.Open(ctx, s, userID)
+	if err != nil {
+		return "", err
+	}
+	return string(token), nil
+}
+
+func ownerKey(provider, providerUserID string) string {
+	return ownerPKPrefix + provider + "#" + providerUserID
+}
+
+// sportFromType maps a provider's sport name, such as TRAIL_RUNNING or
+// INDOOR_ROWING, to a cardio sport by the words in it, since providers name
+// many variants of each
+func sportFromType(name string) string {
+	name = strings.ToUpper(name)
+	switch {
+	case strings.Contains(name, "RUN"):
+		return "run"
+	case strings.Contains(name, "CYCL"), strings.Contains(name, "BIK"):
+		return "ride"
+	case strings.Contains(name, "SWIM"):
+		return "swim"
+	case strings.Contains(name, "ROW"):
+		return "row"
+	case strings.Contains(name, "WALK"):
+		return "walk"
+	case strings.Contains(name, "HIK"):
+		return "hike"
+	}
+	return "other"
+}
@@ -0,0 +1,341 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"athlete-forge/envelope"
+	"athlete-forge/store"
+	"athlete-forge/webhook"
+)
+
+func newTestRepository(t *testing.T) *Repository {
+	t.Helper()
+	keys, err := envelope.NewLocalKeys("test", map[string][]byte{"test": []byte("0123456789abcdef0123456789abcdef")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return NewRepository(store.NewMemoryStore(), envelope.NewCipher(keys))
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("finds the owner of a connected account and opens its token", func(t *testing.T) {
+		// Arrange
+		repo := newTestRepository(t)
+
+		// Act
+		err := repo.Connect(ctx, &Connection{Provider: "polar", UserID: "user-1", ProviderUserID: "475"}, Tokens{AccessToken: "secret-token"})
+		owner, ownerErr := repo.Owner(ctx, "polar", "475")
+
+		// Assert
+		if err != nil || ownerErr != nil {
+			t.Fatalf("unexpected errors: %v, %v", err, ownerErr)
+		}
+		if owner.UserID != "user-1" || owner.Token == nil {
+			t.Fatalf("unexpected owner: %+v", owner)
+		}
+		tokens, err := repo.Tokens(ctx, owner)
+		if err != nil || tokens.AccessToken != "secret-token" || tokens.RefreshToken != "" {
+			t.Errorf("expected the access token back, got %+v (%v)", tokens, err)
+		}
+	})
+
+	t.Run("refuses an account connected to another user", func(t *testing.T) {
+		// Arrange
+		repo := newTestRepository(t)
+		repo.Connect(ctx, &Connection{Provider: "garmin", UserID: "user-1", ProviderUserID: "g-1"}, Tokens{})
+
+		// Act
+		err := repo.Connect(ctx, &Connection{Provider: "garmin", UserID: "user-2", ProviderUserID: "g-1"}, Tokens{})
+
+		// Assert
+		if !errors.Is(err, ErrConnected) {
+			t.Errorf("expected ErrConnected, got %v", err)
+		}
+	})
+
+	t.Run("releases the old account when reconnecting", func(t *testing.T) {
+		// Arrange
+		repo := newTestRepository(t)
+		repo.Connect(ctx, &Connection{Provider: "garmin", UserID: "user-1", ProviderUserID: "g-1"}, Tokens{})
+
+		// Act
+		repo.Connect(ctx, &Connection{Provider: "garmin", UserID: "user-1", ProviderUserID: "g-2"}, Tokens{})
+		_, oldErr := repo.Owner(ctx, "garmin", "g-1")
+		connections, _ := repo.List(ctx, "user-1")
+
+		// Assert
+		if !errors.Is(oldErr, store.ErrNotFound) {
+			t.Errorf("expected the old account to be released, got %v", oldErr)
+		}
+		if len(connections) != 1 || connections[0].ProviderUserID != "g-2" {
+			t.Errorf("unexpected connections: %+v", connections)
+		}
+	})
+}
+
+func TestGarmin_Activities(t *testing.T) {
+	// Arrange
+	e := webhook.Event{Body: `{"activities":[{"userId":"g-1","summaryId":"42","activityType":"INDOOR_ROWING","startTimeInSeconds":1760000000,"durationInSeconds":1200,"distanceInMeters":4000}]}`}
+
+	// Act
+	activities, err := Garmin{}.Activities(context.Background(), e, nil)
+
+	// Assert
+	if err != nil || len(activities) != 1 {
+		t.Fatalf("expected 1 activity, got %d (%v)", len(activities), err)
+	}
+	a := activities[0]
+	if a.ProviderUserID != "g-1" || a.SourceID != "42" || a.Activity.Sport != "row" || !a.Activity.StartTime.Equal(time.Unix(1760000000, 0)) {
+		t.Errorf("unexpected activity: %+v", a)
+	}
+}
+
+func TestPolar_Activities(t *testing.T) {
+	ctx := context.Background()
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		if r.URL.Path != "/v3/exercises/abc" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"id":"abc","start_time":"2026-10-12T08:30:00","start_time_utc_offset":120,"duration":"PT1H2M3.4S","distance":10000,"sport":"RUNNING","heart_rate":{"average":140,"maximum":181}}`))
+	}))
+	defer server.Close()
+	p := &Polar{BaseURL: server.URL, HTTPClient: server.Client()}
+	token := func(ctx context.Context, providerUserID string) (string, error) {
+		return "token-" + providerUserID, nil
+	}
+
+	t.Run("fetches an announced exercise", func(t *testing.T) {
+		// Arrange
+		e := webhook.Event{Body: `{"event":"EXERCISE","user_id":475,"entity_id":"abc","timestamp":"2026-10-12T09:40:00Z"}`}
+
+		// Act
+		activities, err := p.Activities(ctx, e, token)
+
+		// Assert
+		if err != nil || len(activities) != 1 {
+			t.Fatalf("expected 1 activity, got %d (%v)", len(activities), err)
+		}
+		if authorization != "Bearer token-475" {
+			t.Errorf("unexpected authorization %q", authorization)
+		}
+		a := activities[0].Activity
+		if !a.StartTime.Equal(time.Date(2026, 10, 12, 6, 30, 0, 0, time.UTC)) || a.DurationSeconds != 3723 || a.Sport != "run" || a.MaxHR != 181 {
+			t.Errorf("unexpected activity: %+v", a)
+		}
+	})
+
+	t.Run("ignores events without an exercise", func(t *testing.T) {
+		// Act
+		activities, err := p.Activities(ctx, webhook.Event{Body: `{"event":"PING","timestamp":"2026-10-12T09:40:00Z"}`}, token)
+
+		// Assert
+		if err != nil || len(activities) != 0 {
+			t.Errorf("expected no activities, got %d (%v)", len(activities), err)
+		}
+	})
+}
+
+func TestPolar_Authorize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		id, secret, _ := r.BasicAuth()
+		if id != "client" || secret != "client-secret" || r.PostForm.Get("grant_type") != "authorization_code" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.PostForm.Get("code") != "code-1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"access_token":"polar-token","token_type":"bearer","x_user_id":475}`))
+	}))
+	defer server.Close()
+	p := &Polar{TokenURL: server.URL, ClientID: "client", ClientSecret: "client-secret", HTTPClient: server.Client()}
+
+	t.Run("returns the token and the user the exchange names", func(t *testing.T) {
+		// Act
+		tokens, userID, err := p.Authorize(context.Background(), "code-1", "app://polar")
+
+		// Assert
+		if err != nil || tokens.AccessToken != "polar-token" || userID != "475" {
+			t.Errorf("unexpected exchange %+v %q (%v)", tokens, userID, err)
+		}
+	})
+
+	t.Run("reports a rejected code", func(t *testing.T) {
+		// Act
+		_, _, err := p.Authorize(context.Background(), "used", "app://polar")
+
+		// Assert
+		if !errors.Is(err, ErrRejected) {
+			t.Errorf("expected ErrRejected, got %v", err)
+		}
+	})
+}
+
+func TestIdentifier_UserID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer user-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/wellness-api/rest/user/id":
+			w.Write([]byte(`{"userId":"g-1"}`))
+		case "/developer/v2/user/profile/basic":
+			w.Write([]byte(`{"user_id":10129,"email":"a@example.com"}`))
+		case "/v2/usercollection/personal_info":
+			w.Write([]byte(`{"id":"oura-1","age":31}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	identifiers := map[string]Identifier{
+		"g-1":    &Garmin{BaseURL: server.URL, HTTPClient: server.Client()},
+		"10129":  &Whoop{OAuth: OAuth{HTTPClient: server.Client()}, BaseURL: server.URL},
+		"oura-1": &Oura{OAuth: OAuth{HTTPClient: server.Client()}, BaseURL: server.URL},
+	}
+
+	for want, identifier := range identifiers {
+		t.Run("finds the "+identifier.Provider()+" user", func(t *testing.T) {
+			// Act
+			userID, err := identifier.UserID(context.Background(), "user-token")
+			_, forgedErr := identifier.UserID(context.Background(), "forged")
+
+			// Assert
+			if err != nil || userID != want {
+				t.Errorf("expected %s, got %q (%v)", want, userID, err)
+			}
+			if !errors.Is(forgedErr, ErrUnauthorized) {
+				t.Errorf("expected ErrUnauthorized, got %v", forgedErr)
+			}
+		})
+	}
+}
+
+func TestWhoop_Recovery(t *testing.T) {
+	// Arrange
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer whoop-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		query = r.URL.RawQuery
+		w.Write([]byte(`{"records":[
+			{"created_at":"2026-10-12T06:10:00Z","score_state":"SCORED","score":{"recovery_score":66,"resting_heart_rate":52,"hrv_rmssd_milli":71.26}},
+			{"created_at":"2026-10-13T06:10:00Z","score_state":"PENDING_SCORE"}
+		]}`))
+	}))
+	defer server.Close()
+	w := &Whoop{OAuth: OAuth{HTTPClient: server.Client()}, BaseURL: server.URL}
+
+	// Act
+	readings, err := w.Recovery(context.Background(), "whoop-token", "2026-10-12", "2026-10-13")
+	_, expiredErr := w.Recovery(context.Background(), "expired", "2026-10-12", "2026-10-13")
+
+	// Assert
+	if err != nil || len(readings) != 1 {
+		t.Fatalf("expected 1 scored reading, got %d (%v)", len(readings), err)
+	}
+	r := readings[0]
+	if r.Date != "2026-10-12" || r.Recovery.Score != 66 || r.Recovery.RestingHR != 52 || r.Recovery.HRV != 71.3 {
+		t.Errorf("unexpected reading: %+v", r)
+	}
+	if !strings.Contains(query, "end=2026-10-14T00%3A00%3A00Z") {
+		t.Errorf("expected the range to end after the last day, got %s", query)
+	}
+	if !errors.Is(expiredErr, ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized, got %v", expiredErr)
+	}
+}
+
+func TestOura_Recovery(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/usercollection/sleep":
+			w.Write([]byte(`{"data":[
+				{"day":"2026-10-12","type":"long_sleep","total_sleep_duration":27000,"average_hrv":48,"lowest_heart_rate":47},
+				{"day":"2026-10-12","type":"late_nap","total_sleep_duration":1800}
+			]}`))
+		case r.URL.Query().Get("next_token") == "":
+			w.Write([]byte(`{"data":[{"day":"2026-10-12","score":81}],"next_token":"page-2"}`))
+		default:
+			w.Write([]byte(`{"data":[{"day":"2026-10-13","score":null}]}`))
+		}
+	}))
+	defer server.Close()
+	o := &Oura{OAuth: OAuth{HTTPClient: server.Client()}, BaseURL: server.URL}
+
+	// Act
+	readings, err := o.Recovery(context.Background(), "oura-token", "2026-10-12", "2026-10-13")
+
+	// Assert
+	if err != nil || len(readings) != 1 {
+		t.Fatalf("expected 1 scored reading, got %d (%v)", len(readings), err)
+	}
+	r := readings[0].Recovery
+	if readings[0].Date != "2026-10-12" || r.Score != 81 || r.SleepHours != 7.5 || r.HRV != 48 || r.RestingHR != 47 {
+		t.Errorf("unexpected reading: %+v", readings[0])
+	}
+}
+
+func TestOAuth_Refresh(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("grant_type") != "refresh_token" || r.PostForm.Get("client_secret") != "client-secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.PostForm.Get("refresh_token") == "rotating" {
+			w.Write([]byte(`{"access_token":"new-access","refresh_token":"new-refresh"}`))
+			return
+		}
+		w.Write([]byte(`{"access_token":"new-access"}`))
+	}))
+	defer server.Close()
+	o := &OAuth{TokenURL: server.URL, ClientID: "client", ClientSecret: "client-secret", HTTPClient: server.Client()}
+
+	t.Run("returns rotated tokens", func(t *testing.T) {
+		// Act
+		tokens, err := o.Refresh(context.Background(), "rotating")
+
+		// Assert
+		if err != nil || tokens != (Tokens{AccessToken: "new-access", RefreshToken: "new-refresh"}) {
+			t.Errorf("unexpected tokens %+v (%v)", tokens, err)
+		}
+	})
+
+	t.Run("keeps a refresh token the provider does not rotate", func(t *testing.T) {
+		// Act
+		tokens, err := o.Refresh(context.Background(), "long-lived")
+
+		// Assert
+		if err != nil || tokens.RefreshToken != "long-lived" {
+			t.Errorf("unexpected tokens %+v (%v)", tokens, err)
+		}
+	})
+
+	t.Run("needs client credentials", func(t *testing.T) {
+		// Act
+		_, err := (&OAuth{TokenURL: server.URL, HTTPClient: server.Client()}).Refresh(context.Background(), "rotating")
+
+		// Assert
+		if err == nil {
+			t.Error("expected an error without client credentials")
+		}
+	})
+}
@@ -0,0 +1,148 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"athlete-forge/readiness"
+)
+
+// OuraAPI is the Oura API base URL
+const OuraAPI = "https://api.ouraring.com"
+
+// ProviderOura identifies Oura connections
+const ProviderOura = "oura"
+
+// ouraLongSleep is the type of a night's main sleep, as opposed to naps
+const ouraLongSleep = "long_sleep"
+
+// Oura pulls daily readiness scores, with the night's sleep, from the Oura API
+type Oura struct {
+	OAuth
+	BaseURL string
+}
+
+type ouraReadiness struct {
+	Data []struct {
+		Day   string `json:"day"`
+		Score *int   `json:"score"`
+	} `json:"data"`
+	NextToken string `json:"next_token"`
+}
+
+type ouraSleep struct {
+	Data []struct {
+		Day                string  `json:"day"`
+		Type               string  `json:"type"`
+		TotalSleepDuration int     `json:"total_sleep_duration"`
+		AverageHRV         float64 `json:"average_hrv"`
+		LowestHeartRate    int     `json:"lowest_heart_rate"`
+	} `json:"data"`
+	NextToken string `json:"next_token"`
+}
+
+// NewOura creates an Oura source refreshing tokens with the app's client credentials
+func NewOura(clientID, clientSecret string) *Oura {
+	return &Oura{
+		OAuth: OAuth{
+			TokenURL:     OuraAPI + "/oauth/token",
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			HTTPClient:   &http.Client{Timeout: 10 * time.Second},
+		},
+		BaseURL: OuraAPI,
+	}
+}
+
+// Provider returns the provider name
+func (o *Oura) Provider() string {
+	return ProviderOura
+}
+
+// UserID returns the Oura user ID accessToken was granted for
+func (o *Oura) UserID(ctx context.Context, accessToken string) (string, error) {
+	var info struct {
+		ID string `json:"id"`
+	}
+	if err := getJSON(ctx, o.HTTPClient, o.url("personal_info", nil), accessToken, &info); err != nil {
+		return "", fmt.Errorf("failed to fetch Oura personal info: %w", err)
+	}
+	if info.ID == "" {
+		return "", errors.New("Oura personal info has no user ID")
+	}
+	return info.ID, nil
+}
+
+// Recovery returns the readiness scores of the days from and to, with the HRV,
+// lowest heart rate and duration of each day's main sleep. Days Oura has not
+// scored are left out
+func (o *Oura) Recovery(ctx context.Context, accessToken, from, to string) ([]DailyRecovery, error) {
+	query := url.Values{"start_date": {from}, "end_date": {to}}
+
+	type night struct {
+		hours     float64
+		hrv       float64
+		restingHR int
+	}
+	nights := map[string]night{}
+	for {
+		var page ouraSleep
+		if err := getJSON(ctx, o.HTTPClient, o.url("sleep", query), accessToken, &page); err != nil {
+			return nil, fmt.Errorf("failed to fetch Oura sleep: %w", err)
+		}
+		for _, s := range page.Data {
+			if s.Type != ouraLongSleep {
+				continue
+			}
+			nights[s.Day] = night{
+				hours:     math.Round(float64(s.TotalSleepDuration)/360) / 10,
+				hrv:       s.AverageHRV,
+				restingHR: s.LowestHeartRate,
+			}
+		}
+		if page.NextToken == "" {
+			break
+		}
+		query.Set("next_token", page.NextToken)
+	}
+
+	query.Del("next_token")
+	var readings []DailyRecovery
+	for {
+		var page ouraReadiness
+		if err := getJSON(ctx, o.HTTPClient, o.url("daily_readiness", query), accessToken, &page); err != nil {
+			return nil, fmt.Errorf("failed to fetch Oura readiness: %w", err)
+		}
+		for _, r := range page.Data {
+			if r.Score == nil {
+				continue
+			}
+			n := nights[r.Day]
+			readings = append(readings, DailyRecovery{
+				Date: r.Day,
+				Recovery: readiness.Recovery{
+					Source:     ProviderOura,
+					Score:      *r.Score,
+					HRV:        n.hrv,
+					RestingHR:  n.restingHR,
+					SleepHours: n.hours,
+				},
+			})
+		}
+		if page.NextToken == "" {
+			return readings, nil
+		}
+		query.Set("next_token", page.NextToken)
+	}
+}
+
+// url builds the URL of a usercollection endpoint
+func (o *Oura) url(collection string, query url.Values) string {
+	return strings.TrimRight(o.BaseURL, "/") + "/v2/usercollection/" + collection + "?" + query.Encode()
+}
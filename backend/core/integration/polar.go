@@ -0,0 +1,199 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"athlete-forge/cardio"
+	"athlete-forge/webhook"
+)
+
+// PolarAPI is the Polar AccessLink API base URL
+const PolarAPI = "https://www.polaraccesslink.com"
+
+// PolarTokenURL is Polar's OAuth token endpoint
+const PolarTokenURL = "https://polarremote.com/v2/oauth2/token"
+
+// polarExerciseEvent announces a new exercise; other events, such as the PING
+// sent when the webhook is created, carry nothing to import
+const polarExerciseEvent = "EXERCISE"
+
+// isoDuration matches the ISO 8601 durations AccessLink reports, such as PT1H2M3.5S
+var isoDuration = regexp.MustCompile(`^PT(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?$`)
+
+// Polar fetches the exercises Polar AccessLink webhooks announce, with the
+// connected user's access token. Exercises are always fetched from BaseURL, not
+// the URL in the delivery, so a token is only ever sent to Polar. Connecting
+// exchanges the app's authorization code with the app's client credentials,
+// since only the exchange names the Polar user
+type Polar struct {
+	BaseURL      string
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	HTTPClient   *http.Client
+}
+
+type polarToken struct {
+	AccessToken string `json:"access_token"`
+	UserID      int64  `json:"x_user_id"`
+}
+
+type polarNotification struct {
+	Event    string `json:"event"`
+	UserID   int64  `json:"user_id"`
+	EntityID string `json:"entity_id"`
+}
+
+type polarExercise struct {
+	ID                 string  `json:"id"`
+	StartTime          string  `json:"start_time"`
+	StartTimeUTCOffset int     `json:"start_time_utc_offset"`
+	Duration           string  `json:"duration"`
+	Distance           float64 `json:"distance"`
+	Sport              string  `json:"sport"`
+	DetailedSportInfo  string  `json:"detailed_sport_info"`
+	HeartRate          struct {
+		Average int `json:"average"`
+		Maximum int `json:"maximum"`
+	} `json:"heart_rate"`
+}
+
+// NewPolar creates a Polar adapter calling the AccessLink API, exchanging
+// authorization codes with the app's client credentials
+func NewPolar(clientID, clientSecret string) *Polar {
+	return &Polar{
+		BaseURL:      PolarAPI,
+		TokenURL:     PolarTokenURL,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		HTTPClient:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Provider returns the provider name
+func (p *Polar) Provider() string {
+	return webhook.ProviderPolar
+}
+
+// Authorize exchanges code for the user's access token and Polar user ID.
+// AccessLink tokens do not expire, so there is no refresh token
+func (p *Polar) Authorize(ctx context.Context, code, redirectURI string) (Tokens, string, error) {
+	if p.ClientID == "" || p.ClientSecret == "" {
+		return Tokens{}, "", errors.New("no OAuth client credentials configured")
+	}
+	form := url.Values{"grant_type": {"authorization_code"}, "code": {code}}
+	if redirectURI != "" {
+		form.Set("redirect_uri", redirectURI)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Tokens{}, "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.SetBasicAuth(p.ClientID, p.ClientSecret)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token polarToken
+	if err := doJSON(p.HTTPClient, req, &token); err != nil {
+		return Tokens{}, "", fmt.Errorf("failed to exchange Polar authorization code: %w", err)
+	}
+	if token.AccessToken == "" || token.UserID == 0 {
+		return Tokens{}, "", errors.New("Polar token response has no access token or user")
+	}
+	return Tokens{AccessToken: token.AccessToken}, strconv.FormatInt(token.UserID, 10), nil
+}
+
+// Activities fetches the exercise an EXERCISE event announces
+func (p *Polar) Activities(ctx context.Context, e webhook.Event, token TokenFunc) ([]Activity, error) {
+	var n polarNotification
+	if err := json.Unmarshal([]byte(e.Body), &n); err != nil {
+		return nil, fmt.Errorf("failed to decode Polar notification: %w", err)
+	}
+	if n.Event != polarExerciseEvent {
+		return nil, nil
+	}
+	if n.EntityID == "" || n.UserID == 0 {
+		return nil, errors.New("Polar exercise notification has no exercise or user")
+	}
+
+	providerUserID := strconv.FormatInt(n.UserID, 10)
+	accessToken, err := token(ctx, providerUserID)
+	if err != nil {
+		return nil, err
+	}
+	exercise, err := p.exercise(ctx, n.EntityID, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	activity, err := exercise.activity()
+	if err != nil {
+		return nil, err
+	}
+	return []Activity{{ProviderUserID: providerUserID, SourceID: n.EntityID, Activity: activity}}, nil
+}
+
+// exercise fetches exercise id from AccessLink
+func (p *Polar) exercise(ctx context.Context, id, accessToken string) (*polarExercise, error) {
+	var exercise polarExercise
+	if err := getJSON(ctx, p.HTTPClient, strings.TrimRight(p.BaseURL, "/")+"/v3/exercises/"+url.PathEscape(id), accessToken, &exercise); err != nil {
+		return nil, fmt.Errorf("failed to fetch Polar exercise %s: %w", id, err)
+	}
+	return &exercise, nil
+}
+
+// activity maps the exercise to a cardio activity. AccessLink gives the start
+// in local time with its offset from UTC in minutes
+func (e *polarExercise) activity() (cardio.Activity, error) {
+	local, err := time.Parse("2006-01-02T15:04:05", e.StartTime)
+	if err != nil {
+		return cardio.Activity{}, fmt.Errorf("invalid Polar start_time %q", e.StartTime)
+	}
+	duration, err := parseISODuration(e.Duration)
+	if err != nil {
+		return cardio.Activity{}, err
+	}
+
+	sport := e.DetailedSportInfo
+	if sport == "" {
+		sport = e.Sport
+	}
+	return cardio.Activity{
+		Sport:           sportFromType(sport),
+		StartTime:       local.Add(-time.Duration(e.StartTimeUTCOffset) * time.Minute).UTC(),
+		DurationSeconds: int(duration.Round(time.Second) / time.Second),
+		DistanceMeters:  e.Distance,
+		AverageHR:       e.HeartRate.Average,
+		MaxHR:           e.HeartRate.Maximum,
+	}, nil
+}
+
+// parseISODuration parses an ISO 8601 duration of hours, minutes and seconds
+func parseISODuration(s string) (time.Duration, error) {
+	m := isoDuration.FindStringSubmatch(s)
+	if m == nil || s == "PT" {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	var d time.Duration
+	if m[1] != "" {
+		hours, _ := strconv.Atoi(m[1])
+		d += time.Duration(hours) * time.Hour
+	}
+	if m[2] != "" {
+		minutes, _ := strconv.Atoi(m[2])
+		d += time.Duration(minutes) * time.Minute
+	}
+	if m[3] != "" {
+		seconds, _ := strconv.ParseFloat(m[3], 64)
+		d += time.Duration(seconds * float64(time.Second))
+	}
+	return d, nil
+}
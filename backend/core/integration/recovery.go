@@ -0,0 +1,81 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"athlete-forge/readiness"
+)
+
+// DailyRecovery is a provider's recovery reading for one day
+type DailyRecovery struct {
+	Date     string
+	Recovery readiness.Recovery
+}
+
+// RecoverySource pulls daily recovery readings from a provider's API with the
+// user's OAuth tokens
+type RecoverySource interface {
+	Provider() string
+	// Recovery returns the readings for the days from and to inclusive, in
+	// readiness.DateLayout; an expired access token returns ErrUnauthorized
+	Recovery(ctx context.Context, accessToken, from, to string) ([]DailyRecovery, error)
+	// Refresh exchanges a refresh token for new tokens
+	Refresh(ctx context.Context, refreshToken string) (Tokens, error)
+}
+
+// OAuth refreshes access tokens at a provider's token endpoint with the app's
+// client credentials
+type OAuth struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	// Scope is sent with the refresh when the provider requires it
+	Scope      string
+	HTTPClient *http.Client
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Refresh exchanges refreshToken for new tokens. Providers that do not rotate
+// refresh tokens return none, so refreshToken is kept
+func (o *OAuth) Refresh(ctx context.Context, refreshToken string) (Tokens, error) {
+	if o.ClientID == "" || o.ClientSecret == "" {
+		return Tokens{}, errors.New("no OAuth client credentials configured")
+	}
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+		"client_id":     {o.ClientID},
+		"client_secret": {o.ClientSecret},
+	}
+	if o.Scope != "" {
+		form.Set("scope", o.Scope)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Tokens{}, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token tokenResponse
+	if err := doJSON(o.HTTPClient, req, &token); err != nil {
+		return Tokens{}, fmt.Errorf("failed to refresh access token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return Tokens{}, errors.New("token response has no access token")
+	}
+	tokens := Tokens{AccessToken: token.AccessToken, RefreshToken: token.RefreshToken}
+	if tokens.RefreshToken == "" {
+		tokens.RefreshToken = refreshToken
+	}
+	return tokens, nil
+}
@@ -0,0 +1,121 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"athlete-forge/readiness"
+)
+
+// WhoopAPI is the Whoop developer API base URL
+const WhoopAPI = "https://api.prod.whoop.com"
+
+// ProviderWhoop identifies Whoop connections
+const ProviderWhoop = "whoop"
+
+// whoopScored marks a recovery Whoop has finished scoring
+const whoopScored = "SCORED"
+
+// Whoop pulls recovery scores from the Whoop developer API
+type Whoop struct {
+	OAuth
+	BaseURL string
+}
+
+type whoopRecoveries struct {
+	Records []struct {
+		CreatedAt  time.Time `json:"created_at"`
+		ScoreState string    `json:"score_state"`
+		Score      struct {
+			RecoveryScore    float64 `json:"recovery_score"`
+			RestingHeartRate float64 `json:"resting_heart_rate"`
+			HRVRMSSDMilli    float64 `json:"hrv_rmssd_milli"`
+		} `json:"score"`
+	} `json:"records"`
+	NextToken string `json:"next_token"`
+}
+
+// NewWhoop creates a Whoop source refreshing tokens with the app's client credentials
+func NewWhoop(clientID, clientSecret string) *Whoop {
+	return &Whoop{
+		OAuth: OAuth{
+			TokenURL:     WhoopAPI + "/oauth/oauth2/token",
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Scope:        "offline",
+			HTTPClient:   &http.Client{Timeout: 10 * time.Second},
+		},
+		BaseURL: WhoopAPI,
+	}
+}
+
+// Provider returns the provider name
+func (w *Whoop) Provider() string {
+	return ProviderWhoop
+}
+
+// UserID returns the Whoop user ID accessToken was granted for
+func (w *Whoop) UserID(ctx context.Context, accessToken string) (string, error) {
+	var profile struct {
+		UserID int64 `json:"user_id"`
+	}
+	if err := getJSON(ctx, w.HTTPClient, strings.TrimRight(w.BaseURL, "/")+"/developer/v2/user/profile/basic", accessToken, &profile); err != nil {
+		return "", fmt.Errorf("failed to fetch Whoop profile: %w", err)
+	}
+	if profile.UserID == 0 {
+		return "", errors.New("Whoop profile has no user ID")
+	}
+	return strconv.FormatInt(profile.UserID, 10), nil
+}
+
+// Recovery returns the scored recoveries created between from and to. Whoop
+// records when a recovery was scored, on waking, but not the user's time zone,
+// so recoveries are dated in UTC
+func (w *Whoop) Recovery(ctx context.Context, accessToken, from, to string) ([]DailyRecovery, error) {
+	start, err := time.Parse(readiness.DateLayout, from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from date %q", from)
+	}
+	end, err := time.Parse(readiness.DateLayout, to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to date %q", to)
+	}
+
+	query := url.Values{
+		"start": {start.Format(time.RFC3339)},
+		"end":   {end.AddDate(0, 0, 1).Format(time.RFC3339)},
+		"limit": {"25"},
+	}
+	var readings []DailyRecovery
+	for {
+		var page whoopRecoveries
+		if err := getJSON(ctx, w.HTTPClient, strings.TrimRight(w.BaseURL, "/")+"/developer/v2/recovery?"+query.Encode(), accessToken, &page); err != nil {
+			return nil, fmt.Errorf("failed to fetch Whoop recoveries: %w", err)
+		}
+		for _, r := range page.Records {
+			if r.ScoreState != whoopScored {
+				continue
+			}
+			readings = append(readings, DailyRecovery{
+				Date: r.CreatedAt.UTC().Format(readiness.DateLayout),
+				Recovery: readiness.Recovery{
+					Source:    ProviderWhoop,
+					Score:     int(math.Round(r.Score.RecoveryScore)),
+					HRV:       math.Round(r.Score.HRVRMSSDMilli*10) / 10,
+					RestingHR: int(math.Round(r.Score.RestingHeartRate)),
+				},
+			})
+		}
+		if page.NextToken == "" {
+			return readings, nil
+		}
+		query.Set("nextToken", page.NextToken)
+	}
+}
@@ -164,7 +164,7 @@ func TestLambdaIntegrationWithEnvironmentVariables(t *testing.T) {
 
 			// Set up complete Lambda environment with environment configuration
 			var logBuffer bytes.Buffer
-			logger := configureLogger() // Use the actual configureLogger function
+			logger, _ := configureLogger() // Use the actual configureLogger function
 			logger = logger.Output(&logBuffer) // Redirect output to buffer for testing
 
 			// Create handler instance
@@ -0,0 +1,275 @@
+package interval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"athlete-forge/fit"
+	"athlete-forge/store"
+)
+
+const workoutSKPrefix = "INTERVAL#"
+
+// Step types. Repeat steps run their own steps Repeat times
+const (
+	StepWarmup   = "warmup"
+	StepInterval = "interval"
+	StepRecovery = "recovery"
+	StepRest     = "rest"
+	StepCooldown = "cooldown"
+	StepRepeat   = "repeat"
+)
+
+// Target types: pace in seconds per kilometre, heart rate in beats per minute
+// and power in watts
+const (
+	TargetPace      = "pace"
+	TargetHeartRate = "heart-rate"
+	TargetPower     = "power"
+)
+
+// Limits on a workout's shape
+const (
+	maxSteps     = 100
+	maxRepeat    = 99
+	maxNameRunes = 100
+)
+
+// stepIntensities maps step types to the intensity written to FIT files
+var stepIntensities = map[string]uint8{
+	StepWarmup:   fit.IntensityWarmup,
+	StepInterval: fit.IntensityInterval,
+	StepRecovery: fit.IntensityRecovery,
+	StepRest:     fit.IntensityRest,
+	StepCooldown: fit.IntensityCooldown,
+}
+
+// targetBounds are the lowest and highest values a target of each type accepts
+var targetBounds = map[string]struct{ low, high float64 }{
+	TargetPace:      {low: 60, high: 1200},
+	TargetHeartRate: {low: 30, high: 250},
+	TargetPower:     {low: 1, high: 2500},
+}
+
+// fitSports maps the sports a workout can be built for to FIT sports
+var fitSports = map[string]uint8{
+	"run":  fit.SportRunning,
+	"ride": fit.SportCycling,
+	"swim": fit.SportSwimming,
+	"row":  fit.SportRowing,
+	"walk": fit.SportWalking,
+	"hike": fit.SportHiking,
+}
+
+// Target is the range a step is performed in. For pace, Low is the faster
+// end, so a 4:00-4:15 /km target is Low 240 and High 255
+type Target struct {
+	Type string  `json:"type"`
+	Low  float64 `json:"low"`
+	High float64 `json:"high"`
+}
+
+// Step is one part of a structured cardio workout. A step lasts
+// DurationSeconds or DistanceMeters, or until the lap button is pressed when
+// it sets neither, optionally within a Target. Repeat steps instead run their
+// Steps Repeat times
+type Step struct {
+	Type            string  `json:"type"`
+	Name            string  `json:"name,omitempty"`
+	DurationSeconds int     `json:"durationSeconds,omitempty"`
+	DistanceMeters  float64 `json:"distanceMeters,omitempty"`
+	Target          *Target `json:"target,omitempty"`
+	Repeat          int     `json:"repeat,omitempty"`
+	Steps           []Step  `json:"steps,omitempty"`
+}
+
+// Workout is a structured cardio workout: a warm-up, repeated work and
+// recovery intervals with targets, and a cool-down, which can be exported to
+// a FIT file and followed on a device
+type Workout struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"userId"`
+	Name      string    `json:"name"`
+	Sport     string    `json:"sport"`
+	Steps     []Step    `json:"steps"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Validate checks the workout's sport and steps
+func (w *Workout) Validate() error {
+	if strings.TrimSpace(w.Name) == "" || len([]rune(w.Name)) > maxNameRunes {
+		return fmt.Errorf("name is required and must be at most %d characters", maxNameRunes)
+	}
+	if _, ok := fitSports[w.Sport]; !ok {
+		return fmt.Errorf("unsupported sport %q; use run, ride, swim, row, walk or hike", w.Sport)
+	}
+	if len(w.Steps) == 0 {
+		return errors.New("workout must have at least one step")
+	}
+	for i, step := range w.Steps {
+		if err := step.validate(true); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	if n := len(flatten(w.Steps)); n > maxSteps {
+		return fmt.Errorf("workout must have at most %d steps, each repeat counting as one, got %d", maxSteps, n)
+	}
+	return nil
+}
+
+func (s *Step) validate(top bool) error {
+	if s.Type == StepRepeat {
+		if !top {
+			return errors.New("repeats cannot be nested")
+		}
+		if s.Repeat < 2 || s.Repeat > maxRepeat {
+			return fmt.Errorf("repeat must be between 2 and %d", maxRepeat)
+		}
+		if len(s.Steps) == 0 || s.DurationSeconds != 0 || s.DistanceMeters != 0 || s.Target != nil {
+			return errors.New("repeat steps take steps to repeat and no duration, distance or target of their own")
+		}
+		for i, step := range s.Steps {
+			if err := step.validate(false); err != nil {
+				return fmt.Errorf("repeated step %d: %w", i+1, err)
+			}
+		}
+		return nil
+	}
+
+	if _, ok := stepIntensities[s.Type]; !ok {
+		return fmt.Errorf("unknown step type %q", s.Type)
+	}
+	if s.Repeat != 0 || len(s.Steps) > 0 {
+		return errors.New("only repeat steps take repeat and steps")
+	}
+	if s.DurationSeconds < 0 || s.DistanceMeters < 0 || math.IsNaN(s.DistanceMeters) {
+		return errors.New("durationSeconds and distanceMeters must not be negative")
+	}
+	if s.DurationSeconds > 0 && s.DistanceMeters > 0 {
+		return errors.New("a step lasts a duration or a distance, not both")
+	}
+	if s.Target != nil {
+		bounds, ok := targetBounds[s.Target.Type]
+		if !ok {
+			return fmt.Errorf("target type must be %q, %q or %q", TargetPace, TargetHeartRate, TargetPower)
+		}
+		if s.Target.Low < bounds.low || s.Target.High > bounds.high || s.Target.High < s.Target.Low {
+			return fmt.Errorf("%s target must run from low to high within %g and %g", s.Target.Type, bounds.low, bounds.high)
+		}
+	}
+	return nil
+}
+
+// FIT encodes the workout as a FIT workout file. Each repeat becomes its steps
+// followed by a step repeating them
+func (w *Workout) FIT() []byte {
+	return fit.Encode(fit.Workout{Name: w.Name, Sport: fitSports[w.Sport], Steps: flatten(w.Steps), CreatedAt: w.UpdatedAt})
+}
+
+// flatten lays steps out as FIT steps in order
+func flatten(steps []Step) []fit.Step {
+	flat := []fit.Step{}
+	for _, step := range steps {
+		if step.Type != StepRepeat {
+			flat = append(flat, step.toFIT())
+			continue
+		}
+		start := len(flat)
+		for _, repeated := range step.Steps {
+			flat = append(flat, repeated.toFIT())
+		}
+		flat = append(flat, fit.Step{Name: step.Name, DurationType: fit.DurationRepeat, DurationValue: uint32(start), TargetValue: uint32(step.Repeat)})
+	}
+	return flat
+}
+
+// toFIT converts a step that is not a repeat
+func (s Step) toFIT() fit.Step {
+	step := fit.Step{Name: s.Name, Intensity: stepIntensities[s.Type], DurationType: fit.DurationOpen, TargetType: fit.TargetOpen}
+	switch {
+	case s.DurationSeconds > 0:
+		step.DurationType, step.DurationValue = fit.DurationTime, uint32(s.DurationSeconds)*1000
+	case s.DistanceMeters > 0:
+		step.DurationType, step.DurationValue = fit.DurationDistance, uint32(math.Round(s.DistanceMeters*100))
+	}
+	if s.Target == nil {
+		return step
+	}
+	switch s.Target.Type {
+	case TargetPace:
+		// The slower pace is the lower speed, in millimetres per second
+		step.TargetType = fit.TargetSpeed
+		step.TargetLow, step.TargetHigh = uint32(math.Round(1e6/s.Target.High)), uint32(math.Round(1e6/s.Target.Low))
+	case TargetHeartRate:
+		step.TargetType = fit.TargetHeartRate
+		step.TargetLow, step.TargetHigh = uint32(s.Target.Low)+fit.HeartRateOffset, uint32(s.Target.High)+fit.HeartRateOffset
+	case TargetPower:
+		step.TargetType = fit.TargetPower
+		step.TargetLow, step.TargetHigh = uint32(s.Target.Low)+fit.PowerOffset, uint32(s.Target.High)+fit.PowerOffset
+	}
+	return step
+}
+
+// Repository loads and saves interval workouts
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Get returns the workout with id owned by userID
+func (r *Repository) Get(ctx context.Context, userID, id string) (*Workout, error) {
+	var w Workout
+	if err := r.store.Get(ctx, store.UserPK(userID), workoutSKPrefix+id, &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// List returns all of userID's interval workouts, oldest first
+func (r *Repository) List(ctx context.Context, userID string) ([]Workout, error) {
+	items, err := r.store.Query(ctx, store.UserPK(userID), workoutSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list interval workouts: %w", err)
+	}
+
+	workouts := make([]Workout, 0, len(items))
+	for _, item := range items {
+		var w Workout
+		if err := item.Decode(&w); err != nil {
+			return nil, err
+		}
+		workouts = append(workouts, w)
+	}
+	return workouts, nil
+}
+
+// Save validates and stores w, assigning an ID to new workouts
+func (r *Repository) Save(ctx context.Context, w *Workout) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+	if w.ID == "" {
+		w.ID = store.NewID()
+	}
+	if err := r.store.Put(ctx, store.UserPK(w.UserID), workoutSKPrefix+w.ID, w); err != nil {
+		return fmt.Errorf("failed to save interval workout: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the workout with id owned by userID
+func (r *Repository) Delete(ctx context.Context, userID, id string) error {
+	if err := r.store.Delete(ctx, store.UserPK(userID), workoutSKPrefix+id); err != nil {
+		return fmt.Errorf("failed to delete interval workout: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package interval
+
+import (
+	"context"
+	"testing"
+
+	"athlete-forge/fit"
+	"athlete-forge/store"
+)
+
+func trackSession() Workout {
+	return Workout{
+		UserID: "user-1", Name: "6x400m", Sport: "run",
+		Steps: []Step{
+			{Type: StepWarmup, DurationSeconds: 600},
+			{Type: StepRepeat, Repeat: 6, Steps: []Step{
+				{Type: StepInterval, DistanceMeters: 400, Target: &Target{Type: TargetPace, Low: 200, High: 250}},
+				{Type: StepRecovery, DurationSeconds: 90, Target: &Target{Type: TargetHeartRate, Low: 100, High: 140}},
+			}},
+			{Type: StepCooldown},
+		},
+	}
+}
+
+func TestWorkout_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(w *Workout)
+		wantErr bool
+	}{
+		{name: "warm-up, repeats and cool-down", modify: func(w *Workout) {}},
+		{name: "power targets", modify: func(w *Workout) {
+			w.Sport = "ride"
+			w.Steps[0].Target = &Target{Type: TargetPower, Low: 150, High: 180}
+		}},
+		{name: "unknown sport", modify: func(w *Workout) { w.Sport = "skate" }, wantErr: true},
+		{name: "no steps", modify: func(w *Workout) { w.Steps = nil }, wantErr: true},
+		{name: "duration and distance", modify: func(w *Workout) { w.Steps[0].DistanceMeters = 1000 }, wantErr: true},
+		{name: "single repeat", modify: func(w *Workout) { w.Steps[1].Repeat = 1 }, wantErr: true},
+		{name: "nested repeat", modify: func(w *Workout) {
+			w.Steps[1].Steps = append(w.Steps[1].Steps, Step{Type: StepRepeat, Repeat: 2, Steps: []Step{{Type: StepInterval}}})
+		}, wantErr: true},
+		{name: "inverted target", modify: func(w *Workout) { w.Steps[1].Steps[0].Target.High = 150 }, wantErr: true},
+		{name: "implausible heart rate", modify: func(w *Workout) { w.Steps[1].Steps[1].Target.High = 300 }, wantErr: true},
+		{name: "too many steps", modify: func(w *Workout) {
+			for len(w.Steps[1].Steps) < 98 {
+				w.Steps[1].Steps = append(w.Steps[1].Steps, Step{Type: StepInterval, DurationSeconds: 30})
+			}
+		}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := trackSession()
+			tt.modify(&w)
+			if err := w.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	// Arrange
+	w := trackSession()
+
+	// Act
+	steps := flatten(w.Steps)
+
+	// Assert
+	if len(steps) != 5 {
+		t.Fatalf("expected 5 steps, got %+v", steps)
+	}
+	if steps[0].DurationType != fit.DurationTime || steps[0].DurationValue != 600000 || steps[0].Intensity != fit.IntensityWarmup {
+		t.Errorf("unexpected warm-up: %+v", steps[0])
+	}
+	if interval := steps[1]; interval.DurationType != fit.DurationDistance || interval.DurationValue != 40000 || interval.TargetType != fit.TargetSpeed || interval.TargetLow != 4000 || interval.TargetHigh != 5000 {
+		t.Errorf("expected 400m at 4-5 m/s, got %+v", interval)
+	}
+	if recovery := steps[2]; recovery.TargetType != fit.TargetHeartRate || recovery.TargetLow != 200 || recovery.TargetHigh != 240 {
+		t.Errorf("expected heart rates offset by 100, got %+v", recovery)
+	}
+	if repeat := steps[3]; repeat.DurationType != fit.DurationRepeat || repeat.DurationValue != 1 || repeat.TargetValue != 6 {
+		t.Errorf("expected a repeat back to step 1 six times, got %+v", repeat)
+	}
+	if steps[4].DurationType != fit.DurationOpen || steps[4].Intensity != fit.IntensityCooldown {
+		t.Errorf("expected an open cool-down, got %+v", steps[4])
+	}
+}
+
+func TestRepository(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	r := NewRepository(store.NewMemoryStore())
+	w := trackSession()
+
+	// Act
+	err := r.Save(ctx, &w)
+	stored, getErr := r.Get(ctx, "user-1", w.ID)
+
+	// Assert
+	if err != nil || getErr != nil || w.ID == "" || len(stored.Steps[1].Steps) != 2 {
+		t.Fatalf("unexpected round trip: %+v (%v, %v)", stored, err, getErr)
+	}
+	if err := r.Delete(ctx, "user-1", w.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if all, _ := r.List(ctx, "user-1"); len(all) != 0 {
+		t.Errorf("expected the workout deleted, got %+v", all)
+	}
+}
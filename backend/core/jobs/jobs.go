@@ -0,0 +1,163 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"athlete-forge/store"
+)
+
+const jobSKPrefix = "JOB#"
+
+// Job statuses
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Job tracks a background job a user can poll. SubjectID is the export, bulk edit
+// or other record the job works on, if any. Attempts counts how often a worker has
+// started the job, which exceeds one when the queue redelivers it after a failure.
+// While it runs, ETA estimates when it will finish from its rate so far
+type Job struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"userId"`
+	Type        string     `json:"type"`
+	SubjectID   string     `json:"subjectId,omitempty"`
+	Status      string     `json:"status"`
+	Progress    int        `json:"progress"`
+	Processed   int        `json:"processed"`
+	Total       int        `json:"total"`
+	Failed      int        `json:"failed"`
+	Attempts    int        `json:"attempts"`
+	Error       string     `json:"error,omitempty"`
+	ETA         *time.Time `json:"eta,omitempty"`
+	Remaining   int        `json:"remainingSeconds,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// New returns a queued job of jobType for userID with its ID assigned, so the
+// subject can reference the job before either is saved
+func New(userID, jobType string, now time.Time) *Job {
+	return &Job{
+		ID:        store.NewID(),
+		UserID:    userID,
+		Type:      jobType,
+		Status:    StatusQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Start marks the job running for another attempt
+func (j *Job) Start(now time.Time) {
+	j.Status = StatusRunning
+	j.Attempts++
+	j.Error = ""
+	j.CompletedAt = nil
+	j.clearETA()
+	j.UpdatedAt = now
+	j.StartedAt = &now
+}
+
+// Report records progress through total items, estimating when the rest will
+// be done at the rate items have been processed since the attempt started
+func (j *Job) Report(processed, total int, now time.Time) {
+	j.Processed = processed
+	j.Total = total
+	if total > 0 {
+		j.Progress = min(processed*100/total, 100)
+	}
+	j.UpdatedAt = now
+
+	j.clearETA()
+	if j.StartedAt == nil || processed <= 0 || processed >= total {
+		return
+	}
+	elapsed := now.Sub(*j.StartedAt)
+	remaining := time.Duration(float64(elapsed) * float64(total-processed) / float64(processed))
+	eta := now.Add(remaining).Truncate(time.Second)
+	j.ETA = &eta
+	j.Remaining = int(remaining.Round(time.Second) / time.Second)
+}
+
+// clearETA drops the estimate, for jobs not running or without a rate yet
+func (j *Job) clearETA() {
+	j.ETA = nil
+	j.Remaining = 0
+}
+
+// Finished reports whether the job has succeeded or failed
+func (j *Job) Finished() bool {
+	return j.Status == StatusSucceeded || j.Status == StatusFailed
+}
+
+// Succeed marks the job finished; failed counts items the job skipped
+func (j *Job) Succeed(failed int, now time.Time) {
+	j.Status = StatusSucceeded
+	j.Progress = 100
+	j.Failed = failed
+	j.clearETA()
+	j.UpdatedAt = now
+	j.CompletedAt = &now
+}
+
+// Fail marks the job failed with a message safe to show the user
+func (j *Job) Fail(message string, now time.Time) {
+	j.Status = StatusFailed
+	j.Error = message
+	j.clearETA()
+	j.UpdatedAt = now
+	j.CompletedAt = &now
+}
+
+// Repository loads and saves jobs
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Get returns the job with id owned by userID
+func (r *Repository) Get(ctx context.Context, userID, id string) (*Job, error) {
+	var j Job
+	if err := r.store.Get(ctx, store.UserPK(userID), jobSKPrefix+id, &j); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// List returns all of userID's jobs
+func (r *Repository) List(ctx context.Context, userID string) ([]Job, error) {
+	items, err := r.store.Query(ctx, store.UserPK(userID), jobSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	jobs := make([]Job, 0, len(items))
+	for _, item := range items {
+		var j Job
+		if err := item.Decode(&j); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, nil
+}
+
+// Save stores j
+func (r *Repository) Save(ctx context.Context, j *Job) error {
+	if err := r.store.Put(ctx, store.UserPK(j.UserID), jobSKPrefix+j.ID, j); err != nil {
+		return fmt.Errorf("failed to save job: %w", err)
+	}
+	return nil
+}
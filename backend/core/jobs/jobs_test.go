@@ -0,0 +1,83 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+)
+
+func TestJob_Lifecycle(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("reports progress as a percentage", func(t *testing.T) {
+		// Arrange
+		j := New("user-1", "bulk-edit", now)
+		j.Start(now)
+
+		// Act
+		j.Report(1, 3, now)
+
+		// Assert
+		if j.Status != StatusRunning || j.Progress != 33 || j.Attempts != 1 {
+			t.Errorf("unexpected job: %+v", j)
+		}
+	})
+
+	t.Run("estimates the finish from the rate so far", func(t *testing.T) {
+		// Arrange
+		j := New("user-1", "recompute-stats", now)
+		j.Start(now)
+
+		// Act
+		j.Report(25, 100, now.Add(30*time.Second))
+		estimated := *j.ETA
+		j.Succeed(0, now.Add(2*time.Minute))
+
+		// Assert
+		if !estimated.Equal(now.Add(2*time.Minute)) {
+			t.Errorf("expected an ETA 90s on, got %v", estimated)
+		}
+		if j.ETA != nil || j.Remaining != 0 {
+			t.Errorf("expected no ETA once finished, got %+v", j)
+		}
+	})
+
+	t.Run("a retry clears the previous failure", func(t *testing.T) {
+		// Arrange
+		j := New("user-1", "bulk-edit", now)
+		j.Start(now)
+		j.Fail("Job failed", now)
+
+		// Act
+		j.Start(now.Add(time.Minute))
+
+		// Assert
+		if j.Status != StatusRunning || j.Error != "" || j.CompletedAt != nil || j.Attempts != 2 {
+			t.Errorf("unexpected job: %+v", j)
+		}
+	})
+}
+
+func TestRepository(t *testing.T) {
+	t.Run("jobs are stored per user", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		r := NewRepository(store.NewMemoryStore())
+		j := New("user-1", "recompute-stats", time.Now())
+		r.Save(ctx, j)
+
+		// Act
+		_, err := r.Get(ctx, "user-2", j.ID)
+		list, _ := r.List(ctx, "user-1")
+
+		// Assert
+		if err != store.ErrNotFound {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+		if len(list) != 1 || list[0].ID != j.ID {
+			t.Errorf("unexpected jobs: %+v", list)
+		}
+	})
+}
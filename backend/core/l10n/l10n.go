@@ -0,0 +1,201 @@
+package l10n
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"athlete-forge/i18n"
+)
+
+// nbsp is the no-break space French groups digits with and several locales
+// put before %; unlike the narrow one, it is in the PDF fonts' character set
+const nbsp = "\u00a0"
+
+// Conventions are how a locale writes numbers and dates, and which day it
+// starts the week on
+type Conventions struct {
+	// Locale is the tag the conventions were found under
+	Locale string
+
+	// Decimal separates whole numbers from fractions, and Group thousands;
+	// numbers are not grouped when Group is empty
+	Decimal string
+	Group   string
+
+	// PercentSpace goes between a number and its percent sign
+	PercentSpace string
+
+	// DateLayout writes a date in full, DayMonthLayout without its year and
+	// LongLayout with its month named, all as time layouts. English month names
+	// in LongLayout are replaced with Months
+	DateLayout     string
+	DayMonthLayout string
+	LongLayout     string
+	Months         []string
+
+	// FirstDay is the day weeks start on
+	FirstDay time.Weekday
+}
+
+// conventions are the known locales' conventions. en keeps the service's
+// locale-neutral formats, ISO dates and weeks starting on Monday, for users
+// without a locale
+var conventions = map[string]Conventions{
+	"en": {
+		Decimal: ".", DateLayout: "2006-01-02", DayMonthLayout: "01-02", LongLayout: "2 Jan 2006",
+		FirstDay: time.Monday,
+	},
+	"en-us": {
+		Decimal: ".", Group: ",", DateLayout: "01/02/2006", DayMonthLayout: "1/2", LongLayout: "Jan 2, 2006",
+		FirstDay: time.Sunday,
+	},
+	"en-ca": {
+		Decimal: ".", Group: ",", DateLayout: "2006-01-02", DayMonthLayout: "01-02", LongLayout: "Jan 2, 2006",
+		FirstDay: time.Sunday,
+	},
+	"en-gb": {
+		Decimal: ".", Group: ",", DateLayout: "02/01/2006", DayMonthLayout: "2/1", LongLayout: "2 Jan 2006",
+		FirstDay: time.Monday,
+	},
+	"en-au": {
+		Decimal: ".", Group: ",", DateLayout: "02/01/2006", DayMonthLayout: "2/1", LongLayout: "2 Jan 2006",
+		FirstDay: time.Monday,
+	},
+	"de": {
+		Decimal: ",", Group: ".", PercentSpace: nbsp, DateLayout: "02.01.2006", DayMonthLayout: "2.1.", LongLayout: "2. January 2006",
+		Months:   []string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		FirstDay: time.Monday,
+	},
+	"de-ch": {
+		Decimal: ".", Group: "'", DateLayout: "02.01.2006", DayMonthLayout: "2.1.", LongLayout: "2. January 2006",
+		Months:   []string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		FirstDay: time.Monday,
+	},
+	"fr": {
+		Decimal: ",", Group: nbsp, PercentSpace: nbsp, DateLayout: "02/01/2006", DayMonthLayout: "02/01", LongLayout: "2 January 2006",
+		Months:   []string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		FirstDay: time.Monday,
+	},
+	"fr-ca": {
+		Decimal: ",", Group: nbsp, PercentSpace: nbsp, DateLayout: "2006-01-02", DayMonthLayout: "01-02", LongLayout: "2 January 2006",
+		Months:   []string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		FirstDay: time.Sunday,
+	},
+	"es": {
+		Decimal: ",", Group: ".", PercentSpace: nbsp, DateLayout: "02/01/2006", DayMonthLayout: "2/1", LongLayout: "2 de January de 2006",
+		Months:   []string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		FirstDay: time.Monday,
+	},
+	"es-mx": {
+		Decimal: ".", Group: ",", DateLayout: "02/01/2006", DayMonthLayout: "2/1", LongLayout: "2 de January de 2006",
+		Months:   []string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		FirstDay: time.Sunday,
+	},
+}
+
+// tag matches a language tag such as de, en-US or es-419
+var tag = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// Valid reports whether locale is a well-formed language tag. Tags without
+// conventions of their own are valid and use their language's, or en's
+func Valid(locale string) bool {
+	return tag.MatchString(i18n.Canonical(locale))
+}
+
+// For returns the conventions of the most specific locale in locale's
+// fallback chain, so de-at uses de's and an empty locale en's
+func For(locale string) Conventions {
+	for _, t := range i18n.Chain(locale) {
+		if c, ok := conventions[t]; ok {
+			c.Locale = t
+			return c
+		}
+	}
+	c := conventions[i18n.Default]
+	c.Locale = i18n.Default
+	return c
+}
+
+// Number writes v with at most decimals fraction digits, without trailing
+// zeros, such as 1.234,5 in de
+func (c Conventions) Number(v float64, decimals int) string {
+	s := fmt.Sprintf("%.*f", decimals, v)
+	if strings.Contains(s, ".") {
+		s = strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+	}
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, fraction, _ := strings.Cut(s, ".")
+	if sign == "-" && strings.Trim(whole+fraction, "0") == "" {
+		sign = ""
+	}
+	if c.Group != "" && len(whole) > 3 {
+		var grouped strings.Builder
+		for i, digit := range whole {
+			if i > 0 && (len(whole)-i)%3 == 0 {
+				grouped.WriteString(c.Group)
+			}
+			grouped.WriteRune(digit)
+		}
+		whole = grouped.String()
+	}
+	if fraction == "" {
+		return sign + whole
+	}
+	return sign + whole + c.Decimal + fraction
+}
+
+// Percent writes rate, a fraction, as a whole percentage such as 85 %
+func (c Conventions) Percent(rate float64) string {
+	return fmt.Sprintf("%d%s%%", int(math.Round(rate*100)), c.PercentSpace)
+}
+
+// Date writes t's date in full
+func (c Conventions) Date(t time.Time) string {
+	return t.Format(c.DateLayout)
+}
+
+// DayMonth writes t's day and month, for labels where the year is known
+func (c Conventions) DayMonth(t time.Time) string {
+	return t.Format(c.DayMonthLayout)
+}
+
+// LongDate writes t's date with its month named, such as 18. März 2024
+func (c Conventions) LongDate(t time.Time) string {
+	s := t.Format(c.LongLayout)
+	if len(c.Months) == 12 {
+		s = strings.Replace(s, t.Month().String(), c.Months[t.Month()-1], 1)
+	}
+	return s
+}
+
+// DateString writes a YYYY-MM-DD date, returning s as it is when it is not
+// one
+func (c Conventions) DateString(s string) string {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return s
+	}
+	return c.Date(t)
+}
+
+// WeekStart returns 00:00 UTC on the first day of the week containing t
+func (c Conventions) WeekStart(t time.Time) time.Time {
+	t = t.UTC()
+	offset := (int(t.Weekday()) - int(c.FirstDay) + 7) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC)
+}
+
+// CSVSeparator is the field separator spreadsheets expect in the locale: a
+// semicolon where the comma is the decimal separator
+func (c Conventions) CSVSeparator() rune {
+	if c.Decimal == "," {
+		return ';'
+	}
+	return ','
+}
@@ -0,0 +1,139 @@
+package l10n
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFor(t *testing.T) {
+	tests := []struct {
+		name   string
+		locale string
+		want   string
+	}{
+		{name: "own conventions", locale: "en-US", want: "en-us"},
+		{name: "language's conventions", locale: "de-AT", want: "de"},
+		{name: "language with a parent", locale: "gsw", want: "de"},
+		{name: "unknown language", locale: "ja-JP", want: "en"},
+		{name: "no locale", locale: "", want: "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got := For(tt.locale)
+
+			// Assert
+			if got.Locale != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got.Locale)
+			}
+		})
+	}
+}
+
+func TestConventions_Number(t *testing.T) {
+	tests := []struct {
+		name     string
+		locale   string
+		value    float64
+		decimals int
+		want     string
+	}{
+		{name: "default is ungrouped", locale: "", value: 12345.5, decimals: 2, want: "12345.5"},
+		{name: "grouped thousands", locale: "en-US", value: 1234567.25, decimals: 2, want: "1,234,567.25"},
+		{name: "decimal comma", locale: "de", value: 1234.5, decimals: 2, want: "1.234,5"},
+		{name: "no-break space groups", locale: "fr", value: 12500, decimals: 2, want: "12\u00a0500"},
+		{name: "trailing zeros dropped", locale: "es", value: 102.50, decimals: 2, want: "102,5"},
+		{name: "rounded to decimals", locale: "de", value: 119.583, decimals: 1, want: "119,6"},
+		{name: "negative", locale: "de", value: -1500, decimals: 0, want: "-1.500"},
+		{name: "negative zero", locale: "", value: -0.001, decimals: 2, want: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got := For(tt.locale).Number(tt.value, tt.decimals)
+
+			// Assert
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestConventions_Dates(t *testing.T) {
+	day := time.Date(2024, 3, 8, 18, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		locale   string
+		date     string
+		dayMonth string
+		long     string
+		percent  string
+	}{
+		{locale: "", date: "2024-03-08", dayMonth: "03-08", long: "8 Mar 2024", percent: "75%"},
+		{locale: "en-US", date: "03/08/2024", dayMonth: "3/8", long: "Mar 8, 2024", percent: "75%"},
+		{locale: "en-GB", date: "08/03/2024", dayMonth: "8/3", long: "8 Mar 2024", percent: "75%"},
+		{locale: "de", date: "08.03.2024", dayMonth: "8.3.", long: "8. März 2024", percent: "75\u00a0%"},
+		{locale: "fr", date: "08/03/2024", dayMonth: "08/03", long: "8 mars 2024", percent: "75\u00a0%"},
+		{locale: "es", date: "08/03/2024", dayMonth: "8/3", long: "8 de marzo de 2024", percent: "75\u00a0%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			// Arrange
+			c := For(tt.locale)
+
+			// Act
+			date, dayMonth, long, percent := c.Date(day), c.DayMonth(day), c.LongDate(day), c.Percent(0.75)
+
+			// Assert
+			if date != tt.date || dayMonth != tt.dayMonth || long != tt.long || percent != tt.percent {
+				t.Errorf("expected %q %q %q %q, got %q %q %q %q", tt.date, tt.dayMonth, tt.long, tt.percent, date, dayMonth, long, percent)
+			}
+			if got := c.DateString("2024-03-08"); got != tt.date {
+				t.Errorf("expected %q, got %q", tt.date, got)
+			}
+		})
+	}
+}
+
+func TestConventions_WeekStart(t *testing.T) {
+	tests := []struct {
+		name   string
+		locale string
+		at     time.Time
+		want   time.Time
+	}{
+		{name: "Monday weeks", locale: "de", at: time.Date(2024, 3, 17, 20, 0, 0, 0, time.UTC), want: time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)},
+		{name: "Sunday weeks", locale: "en-US", at: time.Date(2024, 3, 17, 20, 0, 0, 0, time.UTC), want: time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC)},
+		{name: "Sunday weeks midweek", locale: "en-US", at: time.Date(2024, 3, 20, 8, 0, 0, 0, time.UTC), want: time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got := For(tt.locale).WeekStart(tt.at)
+
+			// Assert
+			if !got.Equal(tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValid(t *testing.T) {
+	for tag, want := range map[string]bool{"en": true, "en-US": true, "es_419": true, "de-CH-1996": true, "": false, "english": false, "en-": false, "e1": false} {
+		t.Run(tag, func(t *testing.T) {
+			// Act
+			got := Valid(tag)
+
+			// Assert
+			if got != want {
+				t.Errorf("expected %v, got %v", want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package logconfig
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"athlete-forge/awsapi"
+)
+
+// DefaultInterval is how often the configuration is reloaded; a change takes
+// effect on each warm instance within this long
+const DefaultInterval = time.Minute
+
+// Config is the logging configuration, stored as JSON such as
+// {"level": "warn", "routes": {"GET /api/workouts/{id}": {"level": "debug", "sample": 0.1}}}
+type Config struct {
+	// Level applies to everything not covered by a route; empty keeps the level
+	// the function was deployed with
+	Level  string                 `json:"level,omitempty"`
+	Routes map[string]RouteConfig `json:"routes,omitempty"`
+}
+
+// RouteConfig sets the level for a fraction of the requests to one route, named
+// by method and pattern
+type RouteConfig struct {
+	Level string `json:"level"`
+	// Sample is the fraction of requests logged at Level, from 0 to 1; 0 means all
+	Sample float64 `json:"sample,omitempty"`
+}
+
+// parsed is a validated Config
+type parsed struct {
+	level  zerolog.Level
+	routes map[string]parsedRoute
+}
+
+type parsedRoute struct {
+	level  zerolog.Level
+	sample float64
+}
+
+// parse validates c, falling back to fallback where it sets no level
+func (c Config) parse(fallback zerolog.Level) (parsed, error) {
+	p := parsed{level: fallback, routes: map[string]parsedRoute{}}
+	if c.Level != "" {
+		level, err := zerolog.ParseLevel(c.Level)
+		if err != nil {
+			return parsed{}, fmt.Errorf("invalid level %q", c.Level)
+		}
+		p.level = level
+	}
+	for route, rc := range c.Routes {
+		level, err := zerolog.ParseLevel(rc.Level)
+		if err != nil || rc.Level == "" {
+			return parsed{}, fmt.Errorf("invalid level %q for %s", rc.Level, route)
+		}
+		if rc.Sample < 0 || rc.Sample > 1 {
+			return parsed{}, fmt.Errorf("sample for %s must be between 0 and 1", route)
+		}
+		if rc.Sample == 0 {
+			rc.Sample = 1
+		}
+		p.routes[route] = parsedRoute{level: level, sample: rc.Sample}
+	}
+	return p, nil
+}
+
+// Source loads the current configuration
+type Source interface {
+	Load(ctx context.Context) (Config, error)
+}
+
+// Parameter loads the configuration from an SSM parameter holding its JSON
+type Parameter struct {
+	client *awsapi.Client
+	name   string
+}
+
+// NewParameter creates a source reading the SSM parameter called name
+func NewParameter(client *awsapi.Client, name string) *Parameter {
+	return &Parameter{client: client, name: name}
+}
+
+// Load reads and decodes the parameter; an empty value is an empty Config
+func (p *Parameter) Load(ctx context.Context) (Config, error) {
+	var out struct {
+		Parameter struct {
+			Value string `json:"Value"`
+		} `json:"Parameter"`
+	}
+	if err := p.client.Call(ctx, awsapi.SSM, "GetParameter", map[string]string{"Name": p.name}, &out); err != nil {
+		return Config{}, fmt.Errorf("failed to read log configuration: %w", err)
+	}
+	var c Config
+	if out.Parameter.Value == "" {
+		return c, nil
+	}
+	if err := json.Unmarshal([]byte(out.Parameter.Value), &c); err != nil {
+		return Config{}, fmt.Errorf("failed to decode log configuration: %w", err)
+	}
+	return c, nil
+}
+
+// Levels decides which log events are written, from a configuration reloaded
+// from its source as requests arrive. Loggers must write through Writer at a
+// level low enough to pass everything Levels may enable. The level is per
+// process, which suits Lambda serving one request at a time per instance
+type Levels struct {
+	source   Source
+	interval time.Duration
+	fallback zerolog.Level
+	random   func() float64
+
+	mu       sync.Mutex
+	config   parsed
+	loadedAt time.Time
+
+	// current is the level in force for the request being served
+	current atomic.Int32
+}
+
+// New creates Levels loading from source every interval, starting from, and
+// falling back to, the deployed level fallback
+func New(source Source, interval time.Duration, fallback zerolog.Level) *Levels {
+	l := &Levels{
+		source:   source,
+		interval: interval,
+		fallback: fallback,
+		random:   rand.Float64,
+		config:   parsed{level: fallback},
+	}
+	l.current.Store(int32(fallback))
+	return l
+}
+
+// Refresh reloads the configuration when it is older than the interval and
+// resets the level to the configured one. A failed load keeps the previous
+// configuration and is not retried until the interval passes again, so a
+// missing parameter does not add a call to every request
+func (l *Levels) Refresh(ctx context.Context, now time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	defer func() { l.current.Store(int32(l.config.level)) }()
+	if !l.loadedAt.IsZero() && now.Sub(l.loadedAt) < l.interval {
+		return nil
+	}
+	l.loadedAt = now
+
+	c, err := l.source.Load(ctx)
+	if err != nil {
+		return err
+	}
+	p, err := c.parse(l.fallback)
+	if err != nil {
+		return fmt.Errorf("invalid log configuration: %w", err)
+	}
+	l.config = p
+	return nil
+}
+
+// Route applies route's level when it is configured and this request is
+// sampled, returning a function that restores the configured level
+func (l *Levels) Route(route string) (restore func()) {
+	l.mu.Lock()
+	rc, ok := l.config.routes[route]
+	level := l.config.level
+	l.mu.Unlock()
+	if ok && l.random() < rc.sample {
+		l.current.Store(int32(rc.level))
+	}
+	return func() { l.current.Store(int32(level)) }
+}
+
+// Level returns the level in force
+func (l *Levels) Level() zerolog.Level {
+	return zerolog.Level(l.current.Load())
+}
+
+// Writer wraps w so that events below the level in force are dropped
+func (l *Levels) Writer(w io.Writer) zerolog.LevelWriter {
+	return &filter{levels: l, w: w}
+}
+
+// filter is a writer dropping events below the level in force
+type filter struct {
+	levels *Levels
+	w      io.Writer
+}
+
+func (f *filter) Write(p []byte) (int, error) {
+	return f.w.Write(p)
+}
+
+func (f *filter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level < f.levels.Level() {
+		return len(p), nil
+	}
+	return f.w.Write(p)
+}
@@ -0,0 +1,169 @@
+package logconfig
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"athlete-forge/awsapi"
+)
+
+// staticSource returns config, or err when set, counting loads
+type staticSource struct {
+	config Config
+	err    error
+	loads  int
+}
+
+func (s *staticSource) Load(ctx context.Context) (Config, error) {
+	s.loads++
+	return s.config, s.err
+}
+
+func TestLevels(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	t.Run("applies the configured level to the writer", func(t *testing.T) {
+		// Arrange
+		levels := New(&staticSource{config: Config{Level: "warn"}}, DefaultInterval, zerolog.InfoLevel)
+		var out bytes.Buffer
+		logger := zerolog.New(levels.Writer(&out)).Level(zerolog.TraceLevel)
+
+		// Act
+		logger.Info().Msg("before")
+		err := levels.Refresh(ctx, now)
+		logger.Info().Msg("hidden")
+		logger.Warn().Msg("shown")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(out.String(), "before") || strings.Contains(out.String(), "hidden") || !strings.Contains(out.String(), "shown") {
+			t.Errorf("unexpected output: %s", out.String())
+		}
+	})
+
+	t.Run("reloads only once the interval has passed", func(t *testing.T) {
+		// Arrange
+		source := &staticSource{config: Config{Level: "warn"}}
+		levels := New(source, DefaultInterval, zerolog.InfoLevel)
+
+		// Act
+		levels.Refresh(ctx, now)
+		source.config.Level = "debug"
+		levels.Refresh(ctx, now.Add(30*time.Second))
+		stale := levels.Level()
+		levels.Refresh(ctx, now.Add(DefaultInterval))
+
+		// Assert
+		if source.loads != 2 || stale != zerolog.WarnLevel || levels.Level() != zerolog.DebugLevel {
+			t.Errorf("expected two loads ending at debug, got %d loads, %s then %s", source.loads, stale, levels.Level())
+		}
+	})
+
+	t.Run("keeps the previous configuration when a load fails or is invalid", func(t *testing.T) {
+		// Arrange
+		source := &staticSource{config: Config{Level: "error"}}
+		levels := New(source, DefaultInterval, zerolog.InfoLevel)
+		levels.Refresh(ctx, now)
+
+		// Act
+		source.err = errors.New("throttled")
+		loadErr := levels.Refresh(ctx, now.Add(DefaultInterval))
+		source.err = nil
+		source.config = Config{Level: "loud"}
+		parseErr := levels.Refresh(ctx, now.Add(2*DefaultInterval))
+
+		// Assert
+		if loadErr == nil || parseErr == nil {
+			t.Fatalf("expected errors, got %v and %v", loadErr, parseErr)
+		}
+		if levels.Level() != zerolog.ErrorLevel {
+			t.Errorf("expected error level kept, got %s", levels.Level())
+		}
+	})
+
+	t.Run("an empty configuration uses the deployed level", func(t *testing.T) {
+		// Arrange
+		levels := New(&staticSource{}, DefaultInterval, zerolog.InfoLevel)
+
+		// Act
+		levels.Refresh(ctx, now)
+
+		// Assert
+		if levels.Level() != zerolog.InfoLevel {
+			t.Errorf("expected info, got %s", levels.Level())
+		}
+	})
+
+	t.Run("enables a route's level for sampled requests until restored", func(t *testing.T) {
+		// Arrange
+		levels := New(&staticSource{config: Config{Level: "info", Routes: map[string]RouteConfig{
+			"GET /api/workouts/{id}": {Level: "debug", Sample: 0.25},
+		}}}, DefaultInterval, zerolog.InfoLevel)
+		levels.Refresh(ctx, now)
+
+		// Act
+		levels.random = func() float64 { return 0.1 }
+		restore := levels.Route("GET /api/workouts/{id}")
+		sampled := levels.Level()
+		restore()
+		levels.random = func() float64 { return 0.5 }
+		levels.Route("GET /api/workouts/{id}")
+		unsampled := levels.Level()
+		levels.Route("GET /api/workouts")
+		other := levels.Level()
+
+		// Assert
+		if sampled != zerolog.DebugLevel || unsampled != zerolog.InfoLevel || other != zerolog.InfoLevel {
+			t.Errorf("unexpected levels: sampled %s, unsampled %s, other %s", sampled, unsampled, other)
+		}
+	})
+
+	t.Run("rejects samples outside 0 to 1", func(t *testing.T) {
+		// Arrange
+		levels := New(&staticSource{config: Config{Routes: map[string]RouteConfig{
+			"GET /api/workouts": {Level: "debug", Sample: 2},
+		}}}, DefaultInterval, zerolog.InfoLevel)
+
+		// Act
+		err := levels.Refresh(ctx, now)
+
+		// Assert
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestParameter_Load(t *testing.T) {
+	// Arrange
+	var target string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target = r.Header.Get("X-Amz-Target")
+		w.Write([]byte(`{"Parameter":{"Value":"{\"level\":\"warn\",\"routes\":{\"GET /api/workouts\":{\"level\":\"debug\"}}}"}}`))
+	}))
+	defer server.Close()
+	client := awsapi.NewClient("us-east-1", awsapi.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	client.Endpoint = func(string) string { return server.URL }
+
+	// Act
+	c, err := NewParameter(client, "/workout-tracker/dev/log-config").Load(context.Background())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if target != "AmazonSSM.GetParameter" || c.Level != "warn" || c.Routes["GET /api/workouts"].Level != "debug" {
+		t.Errorf("unexpected config %+v from %s", c, target)
+	}
+}
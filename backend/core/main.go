@@ -1,29 +1,393 @@
 package main
 
 import (
+	"io"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/rs/zerolog"
+	"athlete-forge/auth"
+	"athlete-forge/awsapi"
+	"athlete-forge/blob"
+	"athlete-forge/cache"
+	"athlete-forge/cdn"
+	"athlete-forge/clientconfig"
+	"athlete-forge/clientversion"
+	"athlete-forge/dispatch"
+	"athlete-forge/envelope"
+	"athlete-forge/errreport"
+	"athlete-forge/events"
+	"athlete-forge/firehose"
 	"athlete-forge/handler"
+	"athlete-forge/integration"
+	"athlete-forge/logconfig"
+	"athlete-forge/media"
+	"athlete-forge/metrics"
+	"athlete-forge/profile"
+	"athlete-forge/program"
+	"athlete-forge/realtime"
+	"athlete-forge/residency"
+	"athlete-forge/stepfn"
+	"athlete-forge/store"
+	"athlete-forge/telemetry"
+	"athlete-forge/webhook"
 )
 
 func main() {
 	// Configure zerolog with appropriate settings
-	logger := configureLogger()
+	logger, levels := configureLogger()
 
 	// Log Lambda initialization
 	logger.Info().Msg("Initializing Lambda function")
 
 	// Create handler instance
-	lambdaHandler := handler.NewLambdaHandler(logger)
+	s := configureStore(logger)
+	opts := []handler.Option{handler.WithStore(s)}
+	opts = append(opts, configureBackgroundJobs(logger)...)
+	opts = append(opts, configureResidency(logger, s)...)
+	opts = append(opts, configureStateMachine()...)
+	if size, err := strconv.Atoi(os.Getenv("MAX_BODY_SIZE")); err == nil && size > 0 {
+		opts = append(opts, handler.WithMaxBodySize(size))
+	}
+	if hours, err := strconv.Atoi(os.Getenv("STALE_SESSION_HOURS")); err == nil && hours > 0 {
+		opts = append(opts, handler.WithStaleSessionAfter(time.Duration(hours)*time.Hour))
+	}
+	if rate, err := strconv.Atoi(os.Getenv("BACKFILL_WRITES_PER_SECOND")); err == nil && rate >= 0 {
+		opts = append(opts, handler.WithBackfillWriteRate(rate))
+	}
+	if os.Getenv("STREAM_DERIVED_DATA") == "true" {
+		opts = append(opts, handler.WithStreamDerivedData())
+	}
+	if minimums := os.Getenv("MIN_CLIENT_VERSIONS"); minimums != "" {
+		if parsed, err := clientversion.ParseMinimums(minimums); err != nil {
+			logger.Warn().Err(err).Msg("Invalid MIN_CLIENT_VERSIONS, every client version will be served")
+		} else {
+			opts = append(opts, handler.WithMinClientVersions(parsed))
+		}
+	}
+	// The AppConfig Lambda extension serves the client configuration profile
+	// at CLIENT_CONFIG_PATH from its local copy
+	if path := os.Getenv("CLIENT_CONFIG_PATH"); path != "" {
+		port := os.Getenv("AWS_APPCONFIG_EXTENSION_HTTP_PORT")
+		if port == "" {
+			port = clientconfig.DefaultExtensionPort
+		}
+		source := clientconfig.NewExtension(port, path)
+		opts = append(opts, handler.WithClientConfig(clientconfig.NewCache(source, clientconfig.DefaultInterval)))
+	}
+	if primary := os.Getenv("PRIMARY_REGION"); primary != "" {
+		opts = append(opts, handler.WithRegion(os.Getenv("AWS_REGION"), primary))
+	}
+	if parameter := os.Getenv("CDN_DISTRIBUTION_PARAM"); parameter != "" {
+		// CloudFront is global and signs for us-east-1 whatever region this runs in
+		cloudFront := awsapi.NewClient("us-east-1", awsapi.CredentialsFromEnv())
+		opts = append(opts, handler.WithCDN(cdn.NewCloudFrontFromParameter(cloudFront, awsapi.NewClientFromEnv(), parameter)))
+	}
+	if busName := os.Getenv("EVENT_BUS_NAME"); busName != "" {
+		opts = append(opts, handler.WithEventPublisher(events.NewEventBridge(awsapi.NewClientFromEnv(), busName)))
+	}
+	if stream := os.Getenv("EVENTS_STREAM"); stream != "" {
+		opts = append(opts, handler.WithEventPublisher(events.NewFirehose(firehose.NewStream(awsapi.NewClientFromEnv(), stream))))
+	}
+	if os.Getenv("WEBSOCKET_API_ID") != "" {
+		opts = append(opts, handler.WithWebSocketPoster(realtime.NewAPIGateway(awsapi.NewClientFromEnv())))
+	}
+	opts = append(opts, configureSharing(logger)...)
+	opts = append(opts, configureMedia(logger)...)
+	opts = append(opts, configureTelemetry(logger)...)
+	opts = append(opts, configureAuth(logger)...)
+	opts = append(opts, configureWebhooks(logger)...)
+	opts = append(opts, configureWearables()...)
+	opts = append(opts, configureEncryption(logger)...)
+	opts = append(opts, configureShadow(logger)...)
+	opts = append(opts, configureErrorReporting(logger)...)
+	if levels != nil {
+		opts = append(opts, handler.WithLogLevels(levels))
+	}
+	lambdaHandler := handler.NewLambdaHandler(logger, opts...)
 
-	// Wire handler to Lambda runtime and start
+	// Wire handler to Lambda runtime and start; the function deployed with the
+	// "task" handler serves Step Functions task states from the same binary
+	if os.Getenv("_HANDLER") == "task" {
+		lambda.Start(lambdaHandler.HandleTask)
+	}
 	lambda.Start(lambdaHandler.HandleRequest)
 }
 
+// readCacheTTL bounds how long a cached read can miss a write that bypassed the
+// cache, such as one replicated from another region
+const readCacheTTL = 5 * time.Minute
+
+// configureStore selects DynamoDB when TABLE_NAME is set, otherwise an in-memory
+// store, and caches profile and program reads in the Redis cluster at
+// CACHE_ENDPOINT when READ_CACHE is enabled
+func configureStore(logger zerolog.Logger) store.Store {
+	tableName := os.Getenv("TABLE_NAME")
+	if tableName == "" {
+		logger.Warn().Msg("TABLE_NAME not set, using in-memory store")
+		return store.NewMemoryStore()
+	}
+
+	s := store.Store(store.NewDynamoStore(awsapi.NewClientFromEnv(), tableName))
+	if os.Getenv("READ_CACHE") != "true" {
+		return s
+	}
+	redis, err := cache.NewRedis(os.Getenv("CACHE_ENDPOINT"))
+	if err != nil {
+		logger.Error().Err(err).Msg("Read cache disabled")
+		return s
+	}
+	return store.NewCachedStore(s, redis, readCacheTTL, profile.SK, program.SKPrefix)
+}
+
+// configureBackgroundJobs stores generated files in REPORTS_BUCKET and queues
+// background jobs on JOBS_QUEUE_URL, falling back to invoking this function
+// asynchronously when running in Lambda without a queue
+func configureBackgroundJobs(logger zerolog.Logger) []handler.Option {
+	var opts []handler.Option
+	if bucket := os.Getenv("REPORTS_BUCKET"); bucket != "" {
+		opts = append(opts, handler.WithBlobStore(blob.NewS3Store(awsapi.NewClientFromEnv(), bucket)))
+	} else {
+		logger.Warn().Msg("REPORTS_BUCKET not set, using in-memory file store")
+	}
+
+	if queueURL := os.Getenv("JOBS_QUEUE_URL"); queueURL != "" {
+		opts = append(opts, handler.WithDispatcher(dispatch.NewSQSDispatcher(awsapi.NewClientFromEnv(), queueURL)))
+	} else if functionName := os.Getenv("AWS_LAMBDA_FUNCTION_NAME"); functionName != "" {
+		logger.Warn().Msg("JOBS_QUEUE_URL not set, invoking background jobs directly")
+		opts = append(opts, handler.WithDispatcher(dispatch.NewLambdaDispatcher(awsapi.NewClientFromEnv(), functionName)))
+	}
+	return opts
+}
+
+// configureResidency keeps users pinned to another region in that region's
+// table and bucket, named as comma-separated region=name pairs in
+// REGIONAL_TABLES and REGIONAL_BUCKETS; this deployment's AWS_REGION is home to
+// s, REPORTS_BUCKET and everyone without a pin
+func configureResidency(logger zerolog.Logger, s store.Store) []handler.Option {
+	tableNames := regionalNames(os.Getenv("REGIONAL_TABLES"))
+	if len(tableNames) == 0 {
+		return nil
+	}
+	home := os.Getenv("AWS_REGION")
+	tables := map[string]store.Store{home: s}
+	for region, name := range tableNames {
+		tables[region] = store.NewDynamoStore(awsapi.NewClient(region, awsapi.CredentialsFromEnv()), name)
+	}
+	router, err := residency.NewRouter(home, tables)
+	if err != nil {
+		logger.Error().Err(err).Msg("Data residency disabled")
+		return nil
+	}
+	opts := []handler.Option{handler.WithResidency(router)}
+
+	// Files of users pinned to a region without a bucket fail to store rather
+	// than leave their region
+	if bucket := os.Getenv("REPORTS_BUCKET"); bucket != "" {
+		buckets := map[string]blob.Store{home: blob.NewS3Store(awsapi.NewClientFromEnv(), bucket)}
+		for region, name := range regionalNames(os.Getenv("REGIONAL_BUCKETS")) {
+			buckets[region] = blob.NewS3Store(awsapi.NewClient(region, awsapi.CredentialsFromEnv()), name)
+		}
+		blobs, err := router.Blobs(buckets)
+		if err != nil {
+			logger.Error().Err(err).Msg("Data residency disabled")
+			return nil
+		}
+		opts = append(opts, handler.WithBlobStore(blobs))
+	}
+	logger.Info().Strs("regions", router.Regions()).Msg("Data residency enabled")
+	return opts
+}
+
+// regionalNames parses comma-separated region=name pairs
+func regionalNames(value string) map[string]string {
+	names := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		if region, name, ok := strings.Cut(strings.TrimSpace(pair), "="); ok && region != "" && name != "" {
+			names[region] = name
+		}
+	}
+	return names
+}
+
+// configureStateMachine runs jobs that take more than one invocation as
+// executions of JOBS_STATE_MACHINE_ARN, whose tasks report back through the
+// Step Functions API
+func configureStateMachine() []handler.Option {
+	opts := []handler.Option{handler.WithTaskCallbacks(stepfn.NewClient(awsapi.NewClientFromEnv()))}
+	if arn := os.Getenv("JOBS_STATE_MACHINE_ARN"); arn != "" {
+		opts = append(opts, handler.WithStateMachine(dispatch.NewStepFunctionsDispatcher(awsapi.NewClientFromEnv(), arn)))
+	}
+	return opts
+}
+
+// configureSharing sets the key that signs calendar feed URLs and the public base
+// URL they are built from
+func configureSharing(logger zerolog.Logger) []handler.Option {
+	var opts []handler.Option
+	if secret := os.Getenv("CALENDAR_SECRET"); secret != "" {
+		opts = append(opts, handler.WithCalendarSecret([]byte(secret)))
+	} else {
+		logger.Warn().Msg("CALENDAR_SECRET not set, calendar feed URLs will change on restart")
+	}
+
+	if publicURL := os.Getenv("PUBLIC_URL"); publicURL != "" {
+		opts = append(opts, handler.WithPublicURL(publicURL))
+	}
+	return opts
+}
+
+// configureMedia stores exercise media in MEDIA_BUCKET, served from
+// MEDIA_DOMAIN through CloudFront with links signed by MEDIA_KEY_PAIR_ID, whose
+// private key is in the SSM parameter MEDIA_KEY_PARAM
+func configureMedia(logger zerolog.Logger) []handler.Option {
+	bucket := os.Getenv("MEDIA_BUCKET")
+	if bucket == "" {
+		logger.Warn().Msg("MEDIA_BUCKET not set, using in-memory exercise media store")
+		return nil
+	}
+	files := blob.NewS3Store(awsapi.NewClientFromEnv(), bucket)
+
+	domain, keyPairID, parameter := os.Getenv("MEDIA_DOMAIN"), os.Getenv("MEDIA_KEY_PAIR_ID"), os.Getenv("MEDIA_KEY_PARAM")
+	if domain == "" || keyPairID == "" || parameter == "" {
+		logger.Warn().Msg("MEDIA_DOMAIN, MEDIA_KEY_PAIR_ID or MEDIA_KEY_PARAM not set, presigning exercise media links from the bucket")
+		return []handler.Option{handler.WithExerciseMedia(files, nil)}
+	}
+	signer := media.NewCloudFrontFromParameter(awsapi.NewClientFromEnv(), parameter, domain, keyPairID)
+	return []handler.Option{handler.WithExerciseMedia(files, signer)}
+}
+
+// configureTelemetry sends usage telemetry to the Firehose stream at
+// TELEMETRY_STREAM, pseudonymizing users with TELEMETRY_SECRET, per-request
+// product metrics to the stream at METRICS_STREAM and warehouse exports to
+// WAREHOUSE_BUCKET
+func configureTelemetry(logger zerolog.Logger) []handler.Option {
+	var opts []handler.Option
+	if bucket := os.Getenv("WAREHOUSE_BUCKET"); bucket != "" {
+		opts = append(opts, handler.WithWarehouseStore(blob.NewS3Store(awsapi.NewClientFromEnv(), bucket)))
+	}
+	if stream := os.Getenv("TELEMETRY_STREAM"); stream != "" {
+		opts = append(opts, handler.WithTelemetry(telemetry.NewFirehose(firehose.NewStream(awsapi.NewClientFromEnv(), stream))))
+	}
+	if stream := os.Getenv("METRICS_STREAM"); stream != "" {
+		opts = append(opts, handler.WithMetrics(metrics.NewFirehose(firehose.NewStream(awsapi.NewClientFromEnv(), stream))))
+	}
+	if secret := os.Getenv("TELEMETRY_SECRET"); secret != "" {
+		opts = append(opts, handler.WithTelemetrySecret([]byte(secret)))
+	} else {
+		logger.Warn().Msg("TELEMETRY_SECRET not set, anonymous telemetry IDs will change on restart")
+	}
+	return opts
+}
+
+// configureErrorReporting sends errors and panics to the Sentry project at
+// SENTRY_DSN, tagged with RELEASE and ENVIRONMENT; without it they are reported
+// as structured log lines
+func configureErrorReporting(logger zerolog.Logger) []handler.Option {
+	var reporter errreport.Reporter = errreport.NewLog(logger)
+	if dsn := os.Getenv("SENTRY_DSN"); dsn != "" {
+		sentry, err := errreport.NewSentry(dsn)
+		if err != nil {
+			logger.Error().Err(err).Msg("Sentry reporting disabled")
+		} else {
+			reporter = sentry
+		}
+	}
+	return []handler.Option{handler.WithErrorReporting(reporter, os.Getenv("RELEASE"), os.Getenv("ENVIRONMENT"))}
+}
+
+// configureShadow runs the new implementations of the comma-separated
+// SHADOW_ROUTES alongside the current ones, reading the DynamoDB table at
+// SHADOW_TABLE_NAME for routes whose change is the storage layer
+func configureShadow(logger zerolog.Logger) []handler.Option {
+	var opts []handler.Option
+	if routes := os.Getenv("SHADOW_ROUTES"); routes != "" {
+		opts = append(opts, handler.WithShadowRoutes(strings.Split(routes, ",")...))
+	}
+	if tableName := os.Getenv("SHADOW_TABLE_NAME"); tableName != "" {
+		logger.Info().Str("table", tableName).Msg("Shadow store enabled")
+		opts = append(opts, handler.WithShadowStore(store.NewDynamoStore(awsapi.NewClientFromEnv(), tableName)))
+	}
+	return opts
+}
+
+// configureAuth enables Sign in with Google and Apple for the providers whose
+// client settings are present, and sets the key that signs session tokens
+func configureAuth(logger zerolog.Logger) []handler.Option {
+	var opts []handler.Option
+	if secret := os.Getenv("SESSION_SECRET"); secret != "" {
+		opts = append(opts, handler.WithSessionSecret([]byte(secret)))
+	} else {
+		logger.Warn().Msg("SESSION_SECRET not set, sessions will end on restart")
+	}
+
+	if clientID := os.Getenv("GOOGLE_CLIENT_ID"); clientID != "" {
+		opts = append(opts, handler.WithAuthProvider(auth.NewGoogle(clientID, os.Getenv("GOOGLE_CLIENT_SECRET"))))
+	}
+	if clientID := os.Getenv("APPLE_CLIENT_ID"); clientID != "" {
+		apple, err := auth.NewApple(clientID, os.Getenv("APPLE_TEAM_ID"), os.Getenv("APPLE_KEY_ID"), []byte(os.Getenv("APPLE_PRIVATE_KEY")))
+		if err != nil {
+			logger.Error().Err(err).Msg("Sign in with Apple disabled")
+		} else {
+			opts = append(opts, handler.WithAuthProvider(apple))
+		}
+	}
+	return opts
+}
+
+// configureWebhooks accepts Strava, Garmin and Polar webhook deliveries for the providers
+// whose verification settings are present, and gives the Polar adapter the
+// app's OAuth client credentials so users can connect their accounts
+func configureWebhooks(logger zerolog.Logger) []handler.Option {
+	var opts []handler.Option
+	if token := os.Getenv("STRAVA_VERIFY_TOKEN"); token != "" {
+		subscriptionID, err := strconv.ParseInt(os.Getenv("STRAVA_SUBSCRIPTION_ID"), 10, 64)
+		if err != nil {
+			logger.Warn().Msg("STRAVA_SUBSCRIPTION_ID not set, Strava events will be rejected until the subscription is created")
+		}
+		opts = append(opts, handler.WithWebhookVerifier(&webhook.Strava{VerifyToken: token, SubscriptionID: subscriptionID}))
+	}
+	if secret := os.Getenv("GARMIN_WEBHOOK_SECRET"); secret != "" {
+		opts = append(opts, handler.WithWebhookVerifier(webhook.NewGarmin([]byte(secret))))
+	}
+	if secret := os.Getenv("POLAR_WEBHOOK_SECRET"); secret != "" {
+		opts = append(opts, handler.WithWebhookVerifier(webhook.NewPolar([]byte(secret))))
+	}
+	if clientID := os.Getenv("POLAR_CLIENT_ID"); clientID != "" {
+		opts = append(opts, handler.WithIntegrationAdapter(integration.NewPolar(clientID, os.Getenv("POLAR_CLIENT_SECRET"))))
+	}
+	return opts
+}
+
+// configureWearables gives the Whoop and Oura recovery sources the app's OAuth
+// client credentials, so they can refresh users' expired access tokens
+func configureWearables() []handler.Option {
+	var opts []handler.Option
+	if clientID := os.Getenv("WHOOP_CLIENT_ID"); clientID != "" {
+		opts = append(opts, handler.WithRecoverySource(integration.NewWhoop(clientID, os.Getenv("WHOOP_CLIENT_SECRET"))))
+	}
+	if clientID := os.Getenv("OURA_CLIENT_ID"); clientID != "" {
+		opts = append(opts, handler.WithRecoverySource(integration.NewOura(clientID, os.Getenv("OURA_CLIENT_SECRET"))))
+	}
+	return opts
+}
+
+// configureEncryption wraps the keys for encrypted profile fields with the KMS key
+// in PROFILE_KMS_KEY_ID
+func configureEncryption(logger zerolog.Logger) []handler.Option {
+	keyID := os.Getenv("PROFILE_KMS_KEY_ID")
+	if keyID == "" {
+		logger.Warn().Msg("PROFILE_KMS_KEY_ID not set, encrypted profile fields will be unreadable after restart")
+		return nil
+	}
+	return []handler.Option{handler.WithKeyProvider(envelope.NewKMS(awsapi.NewClientFromEnv(), keyID))}
+}
+
 // configureLogger sets up zerolog with appropriate configuration for Lambda
-func configureLogger() zerolog.Logger {
+func configureLogger() (zerolog.Logger, *logconfig.Levels) {
 	// Set log level from environment variable, default to INFO
 	logLevel := zerolog.InfoLevel
 	if level := os.Getenv("LOG_LEVEL"); level != "" {
@@ -32,14 +396,24 @@ func configureLogger() zerolog.Logger {
 		}
 	}
 
+	// The SSM parameter at LOG_CONFIG_PARAM overrides LOG_LEVEL at runtime, so
+	// the logger passes every level and the writer filters
+	var levels *logconfig.Levels
+	var out io.Writer = os.Stdout
+	if parameter := os.Getenv("LOG_CONFIG_PARAM"); parameter != "" {
+		levels = logconfig.New(logconfig.NewParameter(awsapi.NewClientFromEnv(), parameter), logconfig.DefaultInterval, logLevel)
+		out = levels.Writer(os.Stdout)
+		logLevel = zerolog.TraceLevel
+	}
+
 	// Configure zerolog for Lambda environment
 	// Use JSON output for structured logging in CloudWatch
-	logger := zerolog.New(os.Stdout).
+	logger := zerolog.New(out).
 		Level(logLevel).
 		With().
 		Timestamp().
 		Str("service", "athlete-forge").
 		Logger()
 
-	return logger
+	return logger, levels
 }
\ No newline at end of file
@@ -0,0 +1,285 @@
+package marketplace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"athlete-forge/program"
+	"athlete-forge/store"
+)
+
+const (
+	marketplacePK     = "MARKETPLACE"
+	listingSKPrefix   = "TEMPLATE#"
+	downloadsSKPrefix = "DOWNLOADS#"
+	maxDescription    = 2000
+	maxFlagReasons    = 20
+	maxFlagReasonSize = 500
+)
+
+// Listing statuses
+const (
+	StatusPublished = "published"
+	StatusHidden    = "hidden"
+)
+
+// Sort orders for browsing
+const (
+	SortDownloads = "downloads"
+	SortRating    = "rating"
+	SortNewest    = "newest"
+)
+
+// Moderation records users flagging content for admin review. Flagged content
+// stays visible until an admin hides it
+type Moderation struct {
+	Flagged     bool     `json:"flagged,omitempty"`
+	FlagReasons []string `json:"flagReasons,omitempty"`
+}
+
+// Flag marks the content for admin review, keeping the first reasons given
+func (m *Moderation) Flag(reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return errors.New("reason is required")
+	}
+	if len(reason) > maxFlagReasonSize {
+		return fmt.Errorf("reason must be at most %d characters", maxFlagReasonSize)
+	}
+	m.Flagged = true
+	if len(m.FlagReasons) < maxFlagReasons {
+		m.FlagReasons = append(m.FlagReasons, reason)
+	}
+	return nil
+}
+
+// Listing is a program template published for any user to browse and clone
+type Listing struct {
+	ID          string           `json:"id"`
+	AuthorID    string           `json:"authorId"`
+	Description string           `json:"description,omitempty"`
+	Template    program.Template `json:"template"`
+	Status      string           `json:"status"`
+	Downloads   int              `json:"downloads"`
+	Rating      float64          `json:"rating"`
+	RatingCount int              `json:"ratingCount"`
+	Moderation
+	PublishedAt time.Time `json:"publishedAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// Summary is a listing as shown when browsing, without its template's
+// prescriptions or moderation details
+type Summary struct {
+	ID          string    `json:"id"`
+	AuthorID    string    `json:"authorId"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Exercises   []string  `json:"exercises"`
+	Downloads   int       `json:"downloads"`
+	Rating      float64   `json:"rating"`
+	RatingCount int       `json:"ratingCount"`
+	PublishedAt time.Time `json:"publishedAt"`
+}
+
+// Summary returns l as shown when browsing
+func (l *Listing) Summary() Summary {
+	exercises := make([]string, len(l.Template.Exercises))
+	for i, prescription := range l.Template.Exercises {
+		exercises[i] = prescription.Exercise
+	}
+	return Summary{
+		ID:          l.ID,
+		AuthorID:    l.AuthorID,
+		Name:        l.Template.Name,
+		Description: l.Description,
+		Exercises:   exercises,
+		Downloads:   l.Downloads,
+		Rating:      l.Rating,
+		RatingCount: l.RatingCount,
+		PublishedAt: l.PublishedAt,
+	}
+}
+
+// Public returns l without its moderation details, as shown to users
+func (l Listing) Public() Listing {
+	l.Moderation = Moderation{}
+	return l
+}
+
+// Validate checks the listing's description and that its template imports
+func (l *Listing) Validate() error {
+	if len(l.Description) > maxDescription {
+		return fmt.Errorf("description must be at most %d characters", maxDescription)
+	}
+	if l.Status != StatusPublished && l.Status != StatusHidden {
+		return fmt.Errorf("unknown listing status %q", l.Status)
+	}
+	if _, _, err := program.FromTemplate(l.Template); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Matches reports whether the listing's name, description or exercises
+// contain query, ignoring case
+func (l *Listing) Matches(query string) bool {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return true
+	}
+	if strings.Contains(strings.ToLower(l.Template.Name), query) || strings.Contains(strings.ToLower(l.Description), query) {
+		return true
+	}
+	for _, prescription := range l.Template.Exercises {
+		if strings.Contains(strings.ToLower(prescription.Exercise), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sort orders listings by order, most downloaded first by default, breaking
+// ties with the newest first
+func Sort(listings []Listing, order string) error {
+	var key func(l *Listing) [2]float64
+	switch order {
+	case "", SortDownloads:
+		key = func(l *Listing) [2]float64 { return [2]float64{float64(l.Downloads)} }
+	case SortRating:
+		key = func(l *Listing) [2]float64 { return [2]float64{l.Rating, float64(l.RatingCount)} }
+	case SortNewest:
+		key = func(l *Listing) [2]float64 { return [2]float64{} }
+	default:
+		return fmt.Errorf("unknown sort %q; use %s, %s or %s", order, SortDownloads, SortRating, SortNewest)
+	}
+	sort.SliceStable(listings, func(i, j int) bool {
+		a, b := key(&listings[i]), key(&listings[j])
+		if a != b {
+			return a[0] > b[0] || (a[0] == b[0] && a[1] > b[1])
+		}
+		return listings[i].PublishedAt.After(listings[j].PublishedAt)
+	})
+	return nil
+}
+
+// downloadCount is a listing's download count, kept in its own item so that
+// counting a clone never writes back a listing an admin has since hidden
+type downloadCount struct {
+	Downloads int `json:"downloads"`
+}
+
+// Repository loads and saves listings. Every listing is held in one partition,
+// so browsing reads them all; that suits a catalog of thousands of templates
+// and would need an index to grow beyond it
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Get returns the listing with id
+func (r *Repository) Get(ctx context.Context, id string) (*Listing, error) {
+	var l Listing
+	if err := r.store.Get(ctx, marketplacePK, listingSKPrefix+id, &l); err != nil {
+		return nil, err
+	}
+	var count downloadCount
+	err := r.store.Get(ctx, marketplacePK, downloadsSKPrefix+id, &count)
+	if err == nil {
+		l.Downloads = count.Downloads
+	} else if !errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("failed to load template downloads: %w", err)
+	}
+	if err := r.loadRating(ctx, &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// List returns every listing, hidden ones included
+func (r *Repository) List(ctx context.Context) ([]Listing, error) {
+	items, err := r.store.Query(ctx, marketplacePK, listingSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list marketplace templates: %w", err)
+	}
+	counts, err := r.store.Query(ctx, marketplacePK, downloadsSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list template downloads: %w", err)
+	}
+	downloads := make(map[string]int, len(counts))
+	for _, item := range counts {
+		var count downloadCount
+		if err := item.Decode(&count); err != nil {
+			return nil, err
+		}
+		downloads[strings.TrimPrefix(item.SK, downloadsSKPrefix)] = count.Downloads
+	}
+	ratings, err := r.listRatings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	listings := make([]Listing, 0, len(items))
+	for _, item := range items {
+		var l Listing
+		if err := item.Decode(&l); err != nil {
+			return nil, err
+		}
+		if count, ok := downloads[l.ID]; ok {
+			l.Downloads = count
+		}
+		if aggregate, ok := ratings[l.ID]; ok {
+			l.Rating, l.RatingCount = aggregate.Rating, aggregate.RatingCount
+		}
+		listings = append(listings, l)
+	}
+	return listings, nil
+}
+
+// CountDownload adds one to l's download count, writing only the count. The
+// count is read, incremented and written back, so concurrent clones can
+// undercount; it is a popularity signal, not a ledger
+func (r *Repository) CountDownload(ctx context.Context, l *Listing) error {
+	count := downloadCount{Downloads: l.Downloads + 1}
+	if err := r.store.Put(ctx, marketplacePK, downloadsSKPrefix+l.ID, count); err != nil {
+		return fmt.Errorf("failed to count template download: %w", err)
+	}
+	l.Downloads = count.Downloads
+	return nil
+}
+
+// Save validates and stores l, assigning an ID to new listings
+func (r *Repository) Save(ctx context.Context, l *Listing) error {
+	if err := l.Validate(); err != nil {
+		return err
+	}
+	if l.ID == "" {
+		l.ID = store.NewID()
+	}
+	if err := r.store.Put(ctx, marketplacePK, listingSKPrefix+l.ID, l); err != nil {
+		return fmt.Errorf("failed to save marketplace template: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the listing with id, its download count and its rating
+func (r *Repository) Delete(ctx context.Context, id string) error {
+	if err := r.store.Delete(ctx, marketplacePK, listingSKPrefix+id); err != nil {
+		return fmt.Errorf("failed to delete marketplace template: %w", err)
+	}
+	if err := r.store.Delete(ctx, marketplacePK, downloadsSKPrefix+id); err != nil {
+		return fmt.Errorf("failed to delete template downloads: %w", err)
+	}
+	if err := r.store.Delete(ctx, marketplacePK, ratingSKPrefix+id); err != nil {
+		return fmt.Errorf("failed to delete template rating: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package marketplace
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"athlete-forge/program"
+	"athlete-forge/store"
+)
+
+func testListing(name string, downloads int, publishedAt time.Time) Listing {
+	return Listing{
+		AuthorID: "user-1",
+		Template: program.Template{
+			Format:  program.TemplateFormat,
+			Version: program.TemplateVersion,
+			Name:    name,
+			Exercises: []program.Prescription{
+				{Exercise: "Squat", Sets: 5, Reps: 5, Weight: 100, Rule: program.Rule{Type: program.RuleLinear, Increment: 2.5}},
+			},
+		},
+		Status:      StatusPublished,
+		Downloads:   downloads,
+		PublishedAt: publishedAt,
+	}
+}
+
+func TestSort(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	listings := func() []Listing {
+		old := testListing("Old", 5, start)
+		rated := testListing("Rated", 1, start.Add(time.Hour))
+		rated.Rating, rated.RatingCount = 4.5, 10
+		newest := testListing("Newest", 5, start.Add(2*time.Hour))
+		return []Listing{old, rated, newest}
+	}
+
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{order: "", want: []string{"Newest", "Old", "Rated"}},
+		{order: SortRating, want: []string{"Rated", "Newest", "Old"}},
+		{order: SortNewest, want: []string{"Newest", "Rated", "Old"}},
+	}
+	for _, tt := range tests {
+		t.Run("sorts by "+tt.order, func(t *testing.T) {
+			// Arrange
+			got := listings()
+
+			// Act
+			err := Sort(got, tt.order)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for i, name := range tt.want {
+				if got[i].Template.Name != name {
+					t.Errorf("expected %s at %d, got %s", name, i, got[i].Template.Name)
+				}
+			}
+		})
+	}
+
+	t.Run("rejects unknown orders", func(t *testing.T) {
+		if err := Sort(listings(), "alphabetical"); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestListing(t *testing.T) {
+	t.Run("matches name, description and exercises ignoring case", func(t *testing.T) {
+		// Arrange
+		l := testListing("Starting Strength", 0, time.Now())
+		l.Description = "A novice program"
+
+		// Act & Assert
+		for _, query := range []string{"", "starting", "NOVICE", "squat"} {
+			if !l.Matches(query) {
+				t.Errorf("expected %q to match", query)
+			}
+		}
+		if l.Matches("deadlift") {
+			t.Error("expected deadlift not to match")
+		}
+	})
+
+	t.Run("flagging keeps a bounded number of reasons", func(t *testing.T) {
+		// Arrange
+		l := testListing("Spam", 0, time.Now())
+
+		// Act
+		for i := 0; i < maxFlagReasons+5; i++ {
+			l.Flag("spam")
+		}
+		err := l.Flag(" ")
+
+		// Assert
+		if err == nil {
+			t.Error("expected a blank reason to be rejected")
+		}
+		if !l.Flagged || len(l.FlagReasons) != maxFlagReasons {
+			t.Errorf("expected %d reasons, got %d", maxFlagReasons, len(l.FlagReasons))
+		}
+		if public := l.Public(); public.Flagged || public.FlagReasons != nil {
+			t.Errorf("expected public view without moderation details, got %+v", public)
+		}
+	})
+
+	t.Run("validation rejects long descriptions and unusable templates", func(t *testing.T) {
+		// Arrange
+		long := testListing("Long", 0, time.Now())
+		long.Description = strings.Repeat("a", maxDescription+1)
+		empty := testListing("Empty", 0, time.Now())
+		empty.Template.Exercises = nil
+
+		// Act & Assert
+		for _, l := range []Listing{long, empty} {
+			if err := l.Validate(); err == nil {
+				t.Errorf("expected %s to be rejected", l.Template.Name)
+			}
+		}
+	})
+}
+
+func TestRepository(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := NewRepository(store.NewMemoryStore())
+	l := testListing("Shared", 0, time.Now())
+
+	// Act
+	if err := repo.Save(ctx, &l); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := repo.Get(ctx, l.ID)
+	listings, _ := repo.List(ctx)
+
+	// Assert
+	if err != nil || got.Template.Name != "Shared" {
+		t.Errorf("expected saved listing, got %+v (%v)", got, err)
+	}
+	if len(listings) != 1 {
+		t.Errorf("expected one listing, got %d", len(listings))
+	}
+}
+
+func TestRepository_CountDownload(t *testing.T) {
+	t.Run("counting a download keeps moderation saved since the listing was read", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		repo := NewRepository(store.NewMemoryStore())
+		l := testListing("Shared", 3, time.Now())
+		repo.Save(ctx, &l)
+		stale, _ := repo.Get(ctx, l.ID)
+		hidden := l
+		hidden.Status = StatusHidden
+		repo.Save(ctx, &hidden)
+
+		// Act
+		err := repo.CountDownload(ctx, stale)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got, _ := repo.Get(ctx, l.ID)
+		listings, _ := repo.List(ctx)
+		if got.Status != StatusHidden || got.Downloads != 4 {
+			t.Errorf("expected a hidden listing with 4 downloads, got %s with %d", got.Status, got.Downloads)
+		}
+		if len(listings) != 1 || listings[0].Downloads != 4 {
+			t.Errorf("expected the count when listing, got %+v", listings)
+		}
+	})
+
+	t.Run("deleting a listing removes its count", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		repo := NewRepository(store.NewMemoryStore())
+		l := testListing("Shared", 0, time.Now())
+		repo.Save(ctx, &l)
+		repo.CountDownload(ctx, &l)
+
+		// Act
+		err := repo.Delete(ctx, l.ID)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if counts, _ := repo.store.Query(ctx, marketplacePK, downloadsSKPrefix); len(counts) != 0 {
+			t.Errorf("expected no counts left, got %d", len(counts))
+		}
+	})
+}
@@ -0,0 +1,179 @@
+package marketplace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"athlete-forge/store"
+)
+
+const (
+	reviewSKPrefix = "REVIEW#"
+	ratingSKPrefix = "RATING#"
+	maxReviewText  = 2000
+)
+
+// Review is a user's rating of a template, from 1 to 5, with optional text. A
+// user has one review per template, so reviewing again replaces it. Hidden
+// reviews are left out of listings and of the template's rating
+type Review struct {
+	TemplateID string `json:"templateId"`
+	UserID     string `json:"userId"`
+	Rating     int    `json:"rating"`
+	Text       string `json:"text,omitempty"`
+	Status     string `json:"status"`
+	Moderation
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Public returns r without its moderation details, as shown to users
+func (r Review) Public() Review {
+	r.Moderation = Moderation{}
+	return r
+}
+
+// Validate checks the rating, text and status
+func (r *Review) Validate() error {
+	if r.Rating < 1 || r.Rating > 5 {
+		return errors.New("rating must be between 1 and 5")
+	}
+	if len(r.Text) > maxReviewText {
+		return fmt.Errorf("text must be at most %d characters", maxReviewText)
+	}
+	if r.Status != StatusPublished && r.Status != StatusHidden {
+		return fmt.Errorf("unknown review status %q", r.Status)
+	}
+	return nil
+}
+
+// Rate sets the listing's rating to the mean of its published reviews,
+// rounded to one decimal place, and its rating count to how many there are
+func (l *Listing) Rate(reviews []Review) {
+	total, count := 0, 0
+	for _, r := range reviews {
+		if r.TemplateID == l.ID && r.Status == StatusPublished {
+			total += r.Rating
+			count++
+		}
+	}
+	l.RatingCount = count
+	l.Rating = 0
+	if count > 0 {
+		l.Rating = math.Round(float64(total)/float64(count)*10) / 10
+	}
+}
+
+// ratingAggregate is a listing's rating, kept in its own item so that
+// recomputing it never writes back a listing an admin has since hidden
+type ratingAggregate struct {
+	Rating      float64 `json:"rating"`
+	RatingCount int     `json:"ratingCount"`
+}
+
+// SaveRating stores l's rating and rating count, leaving the listing itself
+// untouched
+func (r *Repository) SaveRating(ctx context.Context, l *Listing) error {
+	aggregate := ratingAggregate{Rating: l.Rating, RatingCount: l.RatingCount}
+	if err := r.store.Put(ctx, marketplacePK, ratingSKPrefix+l.ID, aggregate); err != nil {
+		return fmt.Errorf("failed to save template rating: %w", err)
+	}
+	return nil
+}
+
+// loadRating sets l's rating from its aggregate, keeping the rating stored on
+// listings rated before aggregates were kept apart
+func (r *Repository) loadRating(ctx context.Context, l *Listing) error {
+	var aggregate ratingAggregate
+	err := r.store.Get(ctx, marketplacePK, ratingSKPrefix+l.ID, &aggregate)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load template rating: %w", err)
+	}
+	l.Rating, l.RatingCount = aggregate.Rating, aggregate.RatingCount
+	return nil
+}
+
+// listRatings returns every listing's rating aggregate by listing ID
+func (r *Repository) listRatings(ctx context.Context) (map[string]ratingAggregate, error) {
+	items, err := r.store.Query(ctx, marketplacePK, ratingSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list template ratings: %w", err)
+	}
+	ratings := make(map[string]ratingAggregate, len(items))
+	for _, item := range items {
+		var aggregate ratingAggregate
+		if err := item.Decode(&aggregate); err != nil {
+			return nil, err
+		}
+		ratings[strings.TrimPrefix(item.SK, ratingSKPrefix)] = aggregate
+	}
+	return ratings, nil
+}
+
+// reviewSK orders a template's reviews together, keyed by reviewer so each
+// user has at most one
+func reviewSK(templateID, userID string) string {
+	return reviewSKPrefix + templateID + "#" + userID
+}
+
+// GetReview returns userID's review of the template with templateID
+func (r *Repository) GetReview(ctx context.Context, templateID, userID string) (*Review, error) {
+	var review Review
+	if err := r.store.Get(ctx, marketplacePK, reviewSK(templateID, userID), &review); err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// ListReviews returns the reviews of the template with templateID, hidden ones
+// included, or of every template when templateID is empty
+func (r *Repository) ListReviews(ctx context.Context, templateID string) ([]Review, error) {
+	prefix := reviewSKPrefix
+	if templateID != "" {
+		prefix = reviewSK(templateID, "")
+	}
+	items, err := r.store.Query(ctx, marketplacePK, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list template reviews: %w", err)
+	}
+
+	reviews := make([]Review, 0, len(items))
+	for _, item := range items {
+		var review Review
+		if err := item.Decode(&review); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, nil
+}
+
+// SaveReview validates and stores review, replacing the user's earlier review
+// of the template
+func (r *Repository) SaveReview(ctx context.Context, review *Review) error {
+	if err := review.Validate(); err != nil {
+		return err
+	}
+	if review.TemplateID == "" || review.UserID == "" {
+		return errors.New("review must belong to a template and a user")
+	}
+	if err := r.store.Put(ctx, marketplacePK, reviewSK(review.TemplateID, review.UserID), review); err != nil {
+		return fmt.Errorf("failed to save template review: %w", err)
+	}
+	return nil
+}
+
+// DeleteReview removes userID's review of the template with templateID
+func (r *Repository) DeleteReview(ctx context.Context, templateID, userID string) error {
+	if err := r.store.Delete(ctx, marketplacePK, reviewSK(templateID, userID)); err != nil {
+		return fmt.Errorf("failed to delete template review: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package marketplace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+)
+
+func TestListing_Rate(t *testing.T) {
+	// Arrange
+	l := testListing("Rated", 0, time.Now())
+	l.ID = "t1"
+	reviews := []Review{
+		{TemplateID: "t1", UserID: "a", Rating: 5, Status: StatusPublished},
+		{TemplateID: "t1", UserID: "b", Rating: 4, Status: StatusPublished},
+		{TemplateID: "t1", UserID: "c", Rating: 4, Status: StatusPublished},
+		{TemplateID: "t1", UserID: "d", Rating: 1, Status: StatusHidden},
+		{TemplateID: "t2", UserID: "a", Rating: 1, Status: StatusPublished},
+	}
+
+	// Act
+	l.Rate(reviews)
+
+	// Assert
+	if l.Rating != 4.3 || l.RatingCount != 3 {
+		t.Errorf("expected 4.3 from 3 reviews, got %v from %d", l.Rating, l.RatingCount)
+	}
+}
+
+func TestReview_Validate(t *testing.T) {
+	for _, rating := range []int{0, 6} {
+		r := Review{TemplateID: "t1", UserID: "a", Rating: rating, Status: StatusPublished}
+		if err := r.Validate(); err == nil {
+			t.Errorf("expected rating %d to be rejected", rating)
+		}
+	}
+}
+
+func TestRepository_Reviews(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := NewRepository(store.NewMemoryStore())
+	repo.SaveReview(ctx, &Review{TemplateID: "t1", UserID: "a", Rating: 2, Status: StatusPublished})
+	repo.SaveReview(ctx, &Review{TemplateID: "t2", UserID: "a", Rating: 3, Status: StatusPublished})
+
+	// Act
+	err := repo.SaveReview(ctx, &Review{TemplateID: "t1", UserID: "a", Rating: 5, Status: StatusPublished})
+	own, _ := repo.ListReviews(ctx, "t1")
+	all, _ := repo.ListReviews(ctx, "")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(own) != 1 || own[0].Rating != 5 {
+		t.Errorf("expected the second review to replace the first, got %+v", own)
+	}
+	if len(all) != 2 {
+		t.Errorf("expected reviews of every template, got %+v", all)
+	}
+}
+
+func TestRepository_SaveRating(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := NewRepository(store.NewMemoryStore())
+	l := testListing("Rated", 0, time.Now())
+	repo.Save(ctx, &l)
+	stale, _ := repo.Get(ctx, l.ID)
+	hidden := l
+	hidden.Status = StatusHidden
+	repo.Save(ctx, &hidden)
+	stale.Rate([]Review{{TemplateID: l.ID, UserID: "a", Rating: 4, Status: StatusPublished}})
+
+	// Act
+	err := repo.SaveRating(ctx, stale)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, _ := repo.Get(ctx, l.ID)
+	listings, _ := repo.List(ctx)
+	if got.Status != StatusHidden || got.Rating != 4 || got.RatingCount != 1 {
+		t.Errorf("expected a hidden listing rated 4 once, got %s rated %v from %d", got.Status, got.Rating, got.RatingCount)
+	}
+	if len(listings) != 1 || listings[0].Rating != 4 {
+		t.Errorf("expected the rating when listing, got %+v", listings)
+	}
+}
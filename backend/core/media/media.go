@@ -0,0 +1,159 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"athlete-forge/store"
+)
+
+const (
+	mediaPK       = "EXERCISE_MEDIA"
+	mediaSKPrefix = "MEDIA#"
+)
+
+// Kinds of demonstration media
+const (
+	KindGIF   = "gif"
+	KindImage = "image"
+	KindVideo = "video"
+)
+
+// contentTypes are the accepted upload types with their kind and file extension
+var contentTypes = map[string]struct{ kind, extension string }{
+	"image/gif":  {KindGIF, "gif"},
+	"image/png":  {KindImage, "png"},
+	"image/jpeg": {KindImage, "jpg"},
+	"image/webp": {KindImage, "webp"},
+	"video/mp4":  {KindVideo, "mp4"},
+	"video/webm": {KindVideo, "webm"},
+}
+
+// ErrUnsupportedType is returned for uploads of a content type other than GIF,
+// PNG, JPEG, WebP, MP4 or WebM
+var ErrUnsupportedType = errors.New("media must be image/gif, image/png, image/jpeg, image/webp, video/mp4 or video/webm")
+
+// ttls are how long links to each kind of media stay valid. Videos are the
+// costliest to serve, so their links are the shortest lived
+var ttls = map[string]time.Duration{
+	KindGIF:   24 * time.Hour,
+	KindImage: 24 * time.Hour,
+	KindVideo: 6 * time.Hour,
+}
+
+// Media is a demonstration of a catalog exercise stored in the media bucket.
+// URL and ExpiresAt are the signed link to it, set when it is read
+type Media struct {
+	ID          string     `json:"id"`
+	Exercise    string     `json:"exercise"`
+	Kind        string     `json:"kind"`
+	ContentType string     `json:"contentType"`
+	Size        int        `json:"size"`
+	Caption     string     `json:"caption,omitempty"`
+	UploadedBy  string     `json:"uploadedBy"`
+	UploadedAt  time.Time  `json:"uploadedAt"`
+	URL         string     `json:"url,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+}
+
+// New returns media of contentType for the catalog exercise named exercise,
+// with its ID assigned, or ErrUnsupportedType
+func New(exercise, contentType string, size int, uploadedBy string, now time.Time) (*Media, error) {
+	contentType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	t, ok := contentTypes[contentType]
+	if !ok {
+		return nil, ErrUnsupportedType
+	}
+	return &Media{
+		ID:          store.NewID(),
+		Exercise:    exercise,
+		Kind:        t.kind,
+		ContentType: contentType,
+		Size:        size,
+		UploadedBy:  uploadedBy,
+		UploadedAt:  now,
+	}, nil
+}
+
+// ObjectKey returns where the file is stored, which is also its path on the
+// CDN serving the media bucket
+func (m *Media) ObjectKey() string {
+	return "media/exercises/" + strings.ReplaceAll(m.Exercise, " ", "-") + "/" + m.ID + "." + contentTypes[m.ContentType].extension
+}
+
+// Expiry returns when a link to m signed at now should expire: its kind's TTL
+// plus extra, rounded up to the hour so that links signed within the same hour
+// are identical and clients' caches hold on to the file
+func (m *Media) Expiry(now time.Time, extra time.Duration) time.Time {
+	return now.Add(ttls[m.Kind] + extra).Truncate(time.Hour).Add(time.Hour)
+}
+
+// Repository loads and saves exercise media records; all of them share one
+// partition, since the catalog is small and is read whole
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// sk returns the sort key of exercise's media with id
+func sk(exercise, id string) string {
+	return mediaSKPrefix + exercise + "#" + id
+}
+
+// Get returns the media with id of exercise
+func (r *Repository) Get(ctx context.Context, exercise, id string) (*Media, error) {
+	var m Media
+	if err := r.store.Get(ctx, mediaPK, sk(exercise, id), &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// List returns exercise's media in upload order, or every exercise's media by
+// exercise when exercise is empty
+func (r *Repository) List(ctx context.Context, exercise string) ([]Media, error) {
+	prefix := mediaSKPrefix
+	if exercise != "" {
+		prefix = sk(exercise, "")
+	}
+	items, err := r.store.Query(ctx, mediaPK, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exercise media: %w", err)
+	}
+
+	list := make([]Media, 0, len(items))
+	for _, item := range items {
+		var m Media
+		if err := item.Decode(&m); err != nil {
+			return nil, err
+		}
+		list = append(list, m)
+	}
+	return list, nil
+}
+
+// Save stores m without its signed link
+func (r *Repository) Save(ctx context.Context, m *Media) error {
+	stored := *m
+	stored.URL = ""
+	stored.ExpiresAt = nil
+	if err := r.store.Put(ctx, mediaPK, sk(m.Exercise, m.ID), stored); err != nil {
+		return fmt.Errorf("failed to save exercise media: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the media record with id of exercise
+func (r *Repository) Delete(ctx context.Context, exercise, id string) error {
+	if err := r.store.Delete(ctx, mediaPK, sk(exercise, id)); err != nil {
+		return fmt.Errorf("failed to delete exercise media: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+)
+
+func TestNew(t *testing.T) {
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+
+	t.Run("classifies the content type and names the object after the exercise", func(t *testing.T) {
+		// Act
+		m, err := New("back squat", "Video/MP4; codecs=avc1", 1024, "admin", now)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if m.Kind != KindVideo || m.ContentType != "video/mp4" {
+			t.Errorf("unexpected kind %q and type %q", m.Kind, m.ContentType)
+		}
+		if want := "media/exercises/back-squat/" + m.ID + ".mp4"; m.ObjectKey() != want {
+			t.Errorf("expected key %s, got %s", want, m.ObjectKey())
+		}
+	})
+
+	t.Run("rejects other content types", func(t *testing.T) {
+		// Act
+		_, err := New("squat", "application/pdf", 10, "admin", now)
+
+		// Assert
+		if err != ErrUnsupportedType {
+			t.Errorf("expected ErrUnsupportedType, got %v", err)
+		}
+	})
+}
+
+func TestMedia_Expiry(t *testing.T) {
+	now := time.Date(2024, 3, 4, 8, 25, 0, 0, time.UTC)
+	cases := []struct {
+		kind  string
+		extra time.Duration
+		want  time.Time
+	}{
+		{KindGIF, 0, time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC)},
+		{KindVideo, 0, time.Date(2024, 3, 4, 15, 0, 0, 0, time.UTC)},
+		{KindVideo, 24 * time.Hour, time.Date(2024, 3, 5, 15, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		m := Media{Kind: c.kind}
+		if got := m.Expiry(now, c.extra); !got.Equal(c.want) {
+			t.Errorf("%s +%s: expected %s, got %s", c.kind, c.extra, c.want, got)
+		}
+	}
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+
+	t.Run("lists one exercise's media or all of it, without signed links", func(t *testing.T) {
+		// Arrange
+		r := NewRepository(store.NewMemoryStore())
+		row, _ := New("row", "image/gif", 10, "admin", now)
+		expires := now.Add(time.Hour)
+		row.URL, row.ExpiresAt = "https://cdn.example.com/x", &expires
+		rowing, _ := New("rowing", "image/png", 10, "admin", now)
+		for _, m := range []*Media{row, rowing} {
+			if err := r.Save(ctx, m); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		// Act
+		rows, err := r.List(ctx, "row")
+		all, _ := r.List(ctx, "")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(rows) != 1 || rows[0].ID != row.ID || rows[0].URL != "" || rows[0].ExpiresAt != nil {
+			t.Errorf("unexpected media: %+v", rows)
+		}
+		if len(all) != 2 {
+			t.Errorf("expected 2 media, got %d", len(all))
+		}
+	})
+
+	t.Run("deletes media", func(t *testing.T) {
+		// Arrange
+		r := NewRepository(store.NewMemoryStore())
+		m, _ := New("squat", "image/webp", 10, "admin", now)
+		_ = r.Save(ctx, m)
+
+		// Act
+		err := r.Delete(ctx, "squat", m.ID)
+		_, getErr := r.Get(ctx, "squat", m.ID)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !errors.Is(getErr, store.ErrNotFound) {
+			t.Errorf("expected not found, got %v", getErr)
+		}
+	})
+}
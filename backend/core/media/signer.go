@@ -0,0 +1,154 @@
+package media
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"athlete-forge/awsapi"
+)
+
+// Signer turns the key of a stored file into a link that downloads it until
+// expires
+type Signer interface {
+	Sign(ctx context.Context, key string, expires time.Time) (string, error)
+}
+
+// CloudFront signs links to the media behind a CloudFront distribution whose
+// media behavior trusts the key group holding keyPairID, using a canned
+// policy. The private key is read from an SSM SecureString parameter on the
+// first signature, so it stays out of the function's environment
+type CloudFront struct {
+	domain    string
+	keyPairID string
+
+	ssm       *awsapi.Client
+	parameter string
+
+	mu  sync.Mutex
+	key *rsa.PrivateKey
+}
+
+// NewCloudFront creates a signer for links on domain, signing with the PEM
+// private key of the CloudFront public key keyPairID
+func NewCloudFront(domain, keyPairID string, privateKey []byte) (*CloudFront, error) {
+	key, err := parsePrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &CloudFront{domain: domain, keyPairID: keyPairID, key: key}, nil
+}
+
+// NewCloudFrontFromParameter creates a signer for links on domain whose
+// private key for keyPairID is stored in the SSM parameter named parameter
+func NewCloudFrontFromParameter(ssm *awsapi.Client, parameter, domain, keyPairID string) *CloudFront {
+	return &CloudFront{domain: domain, keyPairID: keyPairID, ssm: ssm, parameter: parameter}
+}
+
+// cannedPolicy is the policy CloudFront checks a canned signature against
+type cannedPolicy struct {
+	Statement []policyStatement `json:"Statement"`
+}
+
+type policyStatement struct {
+	Resource  string `json:"Resource"`
+	Condition struct {
+		DateLessThan struct {
+			EpochTime int64 `json:"AWS:EpochTime"`
+		} `json:"DateLessThan"`
+	} `json:"Condition"`
+}
+
+// Sign returns the link to key with a canned policy expiring at expires
+func (c *CloudFront) Sign(ctx context.Context, key string, expires time.Time) (string, error) {
+	privateKey, err := c.privateKey(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	resource := "https://" + c.domain + "/" + strings.Join(segments, "/")
+
+	statement := policyStatement{Resource: resource}
+	statement.Condition.DateLessThan.EpochTime = expires.Unix()
+	policy, err := json.Marshal(cannedPolicy{Statement: []policyStatement{statement}})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal policy: %w", err)
+	}
+	digest := sha1.Sum(policy)
+	signature, err := rsa.SignPKCS1v15(nil, privateKey, crypto.SHA1, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign media link: %w", err)
+	}
+
+	query := "Expires=" + strconv.FormatInt(expires.Unix(), 10) +
+		"&Signature=" + urlSafe(signature) +
+		"&Key-Pair-Id=" + c.keyPairID
+	return resource + "?" + query, nil
+}
+
+// urlSafe encodes a signature in CloudFront's URL-safe base64
+func urlSafe(data []byte) string {
+	return strings.NewReplacer("+", "-", "=", "_", "/", "~").Replace(base64.StdEncoding.EncodeToString(data))
+}
+
+// privateKey returns the signing key, reading it from the parameter the first
+// time; a failed read is retried on the next signature
+func (c *CloudFront) privateKey(ctx context.Context) (*rsa.PrivateKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.key != nil {
+		return c.key, nil
+	}
+
+	var out struct {
+		Parameter struct {
+			Value string `json:"Value"`
+		} `json:"Parameter"`
+	}
+	in := map[string]interface{}{"Name": c.parameter, "WithDecryption": true}
+	if err := c.ssm.Call(ctx, awsapi.SSM, "GetParameter", in, &out); err != nil {
+		return nil, fmt.Errorf("failed to read media signing key: %w", err)
+	}
+	key, err := parsePrivateKey([]byte(out.Parameter.Value))
+	if err != nil {
+		return nil, err
+	}
+	c.key = key
+	return key, nil
+}
+
+// parsePrivateKey decodes a PEM RSA private key in PKCS #1 or PKCS #8 form
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("media signing key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse media signing key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("media signing key must be an RSA key")
+	}
+	return key, nil
+}
@@ -0,0 +1,69 @@
+package media
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCloudFront_Sign(t *testing.T) {
+	ctx := context.Background()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	expires := time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC)
+
+	t.Run("signs a canned policy for the object's link", func(t *testing.T) {
+		// Arrange
+		signer, err := NewCloudFront("media.example.com", "K2JCJMDEHXQW5F", pemKey)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// Act
+		link, err := signer.Sign(ctx, "media/exercises/back-squat/abc.gif", expires)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resource, rawQuery, _ := strings.Cut(link, "?")
+		if resource != "https://media.example.com/media/exercises/back-squat/abc.gif" {
+			t.Errorf("unexpected resource %s", resource)
+		}
+		query, _ := url.ParseQuery(rawQuery)
+		if query.Get("Expires") != "1709629200" || query.Get("Key-Pair-Id") != "K2JCJMDEHXQW5F" {
+			t.Errorf("unexpected query %s", rawQuery)
+		}
+		signature, err := base64.StdEncoding.DecodeString(strings.NewReplacer("-", "+", "_", "=", "~", "/").Replace(query.Get("Signature")))
+		if err != nil {
+			t.Fatalf("signature is not URL-safe base64: %v", err)
+		}
+		policy := `{"Statement":[{"Resource":"` + resource + `","Condition":{"DateLessThan":{"AWS:EpochTime":1709629200}}}]}`
+		digest := sha1.Sum([]byte(policy))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA1, digest[:], signature); err != nil {
+			t.Errorf("signature does not verify: %v", err)
+		}
+	})
+
+	t.Run("rejects a key that is not PEM", func(t *testing.T) {
+		// Act
+		_, err := NewCloudFront("media.example.com", "K2JCJMDEHXQW5F", []byte("not a key"))
+
+		// Assert
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
@@ -0,0 +1,363 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"athlete-forge/store"
+)
+
+// Link statuses. A coach invites an athlete, the link is active once the
+// athlete accepts, and either of them can end it
+const (
+	StatusPending = "pending"
+	StatusActive  = "active"
+	StatusEnded   = "ended"
+)
+
+const (
+	linkSKPrefix    = "COACHING#LINK#"
+	messageSKPrefix = "COACHING#MESSAGE#"
+
+	// MaxText bounds a message's length in characters
+	MaxText = 2000
+
+	// Retention is how long messages are kept before they are deleted
+	Retention = 365 * 24 * time.Hour
+)
+
+var (
+	// ErrSelf is returned when a user invites themselves
+	ErrSelf = errors.New("cannot coach yourself")
+
+	// ErrLinked is returned when inviting an athlete the coach already has a
+	// pending or active link with
+	ErrLinked = errors.New("already linked with this athlete")
+
+	// ErrNotActive is returned when messaging over a link that is not active
+	ErrNotActive = errors.New("link is not active")
+
+	// ErrNotPending is returned when accepting a link that is not pending
+	ErrNotPending = errors.New("link is not pending")
+
+	// ErrNotAthlete is returned when anyone but the invited athlete accepts
+	ErrNotAthlete = errors.New("only the invited athlete can accept")
+)
+
+// Link is a coach–athlete relationship and its message thread, as one of its
+// two participants sees it. Each participant holds their own copy, with what
+// they have read as ReadUpTo and what the other has read as PeerReadUpTo
+type Link struct {
+	ID            string     `json:"id"`
+	CoachID       string     `json:"coachId"`
+	AthleteID     string     `json:"athleteId"`
+	Status        string     `json:"status"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	AcceptedAt    *time.Time `json:"acceptedAt,omitempty"`
+	EndedAt       *time.Time `json:"endedAt,omitempty"`
+	LastMessageAt *time.Time `json:"lastMessageAt,omitempty"`
+	ReadUpTo      string     `json:"readUpTo,omitempty"`
+	PeerReadUpTo  string     `json:"peerReadUpTo,omitempty"`
+	Unread        int        `json:"unread"`
+}
+
+// Peer returns the other participant of l from userID's side
+func (l *Link) Peer(userID string) string {
+	if userID == l.CoachID {
+		return l.AthleteID
+	}
+	return l.CoachID
+}
+
+// Message is a message sent over a link. IDs sort by when they were sent
+type Message struct {
+	ID       string    `json:"id"`
+	LinkID   string    `json:"linkId"`
+	SenderID string    `json:"senderId"`
+	Text     string    `json:"text"`
+	SentAt   time.Time `json:"sentAt"`
+}
+
+// expired reports whether m is past retention at now
+func (m *Message) expired(now time.Time) bool {
+	return !now.Before(m.SentAt.Add(Retention))
+}
+
+// ValidateText trims text and checks it can be sent
+func ValidateText(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", errors.New("text is required")
+	}
+	if len([]rune(text)) > MaxText {
+		return "", fmt.Errorf("text must be at most %d characters", MaxText)
+	}
+	return text, nil
+}
+
+// Repository stores links and messages. Both are written to each
+// participant's partition, so each user's copy stays in their data region and
+// can be exported or deleted with the rest of their data
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Invite links coachID to athleteID, pending the athlete's acceptance
+func (r *Repository) Invite(ctx context.Context, coachID, athleteID string, now time.Time) (*Link, error) {
+	if coachID == athleteID {
+		return nil, ErrSelf
+	}
+	links, err := r.List(ctx, coachID)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range links {
+		if l.Status != StatusEnded && l.Peer(coachID) == athleteID {
+			return nil, ErrLinked
+		}
+	}
+
+	l := &Link{ID: store.NewID(), CoachID: coachID, AthleteID: athleteID, Status: StatusPending, CreatedAt: now}
+	if err := r.saveBoth(ctx, l, func(*Link) {}); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Get returns userID's copy of link id, or store.ErrNotFound when they are not
+// one of its participants
+func (r *Repository) Get(ctx context.Context, userID, id string) (*Link, error) {
+	var l Link
+	if err := r.store.Get(ctx, store.UserPK(userID), linkSKPrefix+id, &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// List returns userID's links with their unread counts, most recently active
+// first
+func (r *Repository) List(ctx context.Context, userID string) ([]Link, error) {
+	items, err := r.store.Query(ctx, store.UserPK(userID), linkSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list links: %w", err)
+	}
+	links := make([]Link, 0, len(items))
+	for _, item := range items {
+		var l Link
+		if err := item.Decode(&l); err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+
+	messages, err := r.query(ctx, userID, messageSKPrefix)
+	if err != nil {
+		return nil, err
+	}
+	for i := range links {
+		for _, m := range messages {
+			if m.LinkID == links[i].ID && m.SenderID != userID && m.ID > links[i].ReadUpTo {
+				links[i].Unread++
+			}
+		}
+	}
+	sort.SliceStable(links, func(i, j int) bool { return activity(links[i]).After(activity(links[j])) })
+	return links, nil
+}
+
+// activity is when l last changed, for ordering links
+func activity(l Link) time.Time {
+	if l.LastMessageAt != nil {
+		return *l.LastMessageAt
+	}
+	return l.CreatedAt
+}
+
+// Accept activates a pending link; only its athlete can accept it
+func (r *Repository) Accept(ctx context.Context, athleteID, id string, now time.Time) (*Link, error) {
+	l, err := r.Get(ctx, athleteID, id)
+	if err != nil {
+		return nil, err
+	}
+	if l.AthleteID != athleteID {
+		return nil, ErrNotAthlete
+	}
+	if l.Status != StatusPending {
+		return nil, ErrNotPending
+	}
+	err = r.saveBoth(ctx, l, func(copy *Link) {
+		copy.Status = StatusActive
+		copy.AcceptedAt = &now
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.Get(ctx, athleteID, id)
+}
+
+// End ends a link for both participants. Its messages are kept until they
+// expire, but no more can be sent
+func (r *Repository) End(ctx context.Context, userID, id string, now time.Time) (*Link, error) {
+	l, err := r.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if l.Status == StatusEnded {
+		return l, nil
+	}
+	err = r.saveBoth(ctx, l, func(copy *Link) {
+		copy.Status = StatusEnded
+		copy.EndedAt = &now
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.Get(ctx, userID, id)
+}
+
+// Send stores a message from senderID over their link l, which must be active
+func (r *Repository) Send(ctx context.Context, l *Link, senderID, text string, now time.Time) (*Message, error) {
+	if l.Status != StatusActive {
+		return nil, ErrNotActive
+	}
+	text, err := ValidateText(text)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Message{ID: store.NewIDAt(now), LinkID: l.ID, SenderID: senderID, Text: text, SentAt: now}
+	for _, userID := range []string{l.CoachID, l.AthleteID} {
+		if err := r.store.Put(ctx, store.UserPK(userID), messageSKPrefix+l.ID+"#"+m.ID, m); err != nil {
+			return nil, fmt.Errorf("failed to save message: %w", err)
+		}
+	}
+	err = r.saveBoth(ctx, l, func(copy *Link) {
+		copy.LastMessageAt = &now
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Messages returns userID's copy of link id's messages sent before the message
+// before, newest first, at most limit of them; an empty before starts from the
+// newest and a limit of zero returns every message. Expired messages are left
+// out although they may not have been deleted yet
+func (r *Repository) Messages(ctx context.Context, userID, id, before string, limit int, now time.Time) ([]Message, error) {
+	messages, err := r.query(ctx, userID, messageSKPrefix+id+"#")
+	if err != nil {
+		return nil, err
+	}
+	page := []Message{}
+	for i := len(messages) - 1; i >= 0 && (limit == 0 || len(page) < limit); i-- {
+		m := messages[i]
+		if (before == "" || m.ID < before) && !m.expired(now) {
+			page = append(page, m)
+		}
+	}
+	return page, nil
+}
+
+// MarkRead records that userID has read link id up to and including messageID,
+// telling the other participant through their PeerReadUpTo. Read receipts
+// never move backwards
+func (r *Repository) MarkRead(ctx context.Context, userID, id, messageID string) (*Link, error) {
+	l, err := r.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	var m Message
+	if err := r.store.Get(ctx, store.UserPK(userID), messageSKPrefix+id+"#"+messageID, &m); err != nil {
+		return nil, err
+	}
+	if messageID <= l.ReadUpTo {
+		return l, nil
+	}
+
+	peer := l.Peer(userID)
+	var theirs Link
+	if err := r.store.Get(ctx, store.UserPK(peer), linkSKPrefix+id, &theirs); err != nil {
+		return nil, fmt.Errorf("failed to load link: %w", err)
+	}
+	theirs.PeerReadUpTo = messageID
+	if err := r.store.Put(ctx, store.UserPK(peer), linkSKPrefix+id, theirs); err != nil {
+		return nil, fmt.Errorf("failed to save read receipt: %w", err)
+	}
+	l.ReadUpTo = messageID
+	if err := r.store.Put(ctx, store.UserPK(userID), linkSKPrefix+id, l); err != nil {
+		return nil, fmt.Errorf("failed to save read receipt: %w", err)
+	}
+	return l, nil
+}
+
+// Purge deletes userID's copies of messages past retention at now, returning
+// how many it deleted
+func (r *Repository) Purge(ctx context.Context, userID string, now time.Time) (int, error) {
+	items, err := r.store.Query(ctx, store.UserPK(userID), messageSKPrefix)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list messages: %w", err)
+	}
+	purged := 0
+	for _, item := range items {
+		var m Message
+		if err := item.Decode(&m); err != nil {
+			return purged, err
+		}
+		if !m.expired(now) {
+			continue
+		}
+		if err := r.store.Delete(ctx, item.PK, item.SK); err != nil {
+			return purged, fmt.Errorf("failed to delete message: %w", err)
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// saveBoth applies change to each participant's copy of l and stores it
+func (r *Repository) saveBoth(ctx context.Context, l *Link, change func(*Link)) error {
+	for _, userID := range []string{l.CoachID, l.AthleteID} {
+		var copy Link
+		err := r.store.Get(ctx, store.UserPK(userID), linkSKPrefix+l.ID, &copy)
+		if errors.Is(err, store.ErrNotFound) {
+			copy = *l
+			copy.ReadUpTo, copy.PeerReadUpTo, copy.Unread = "", "", 0
+		} else if err != nil {
+			return fmt.Errorf("failed to load link: %w", err)
+		}
+		change(&copy)
+		copy.Unread = 0
+		if err := r.store.Put(ctx, store.UserPK(userID), linkSKPrefix+l.ID, copy); err != nil {
+			return fmt.Errorf("failed to save link: %w", err)
+		}
+	}
+	return nil
+}
+
+// query returns the messages under userID's prefix, oldest first
+func (r *Repository) query(ctx context.Context, userID, prefix string) ([]Message, error) {
+	items, err := r.store.Query(ctx, store.UserPK(userID), prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	messages := make([]Message, 0, len(items))
+	for _, item := range items {
+		var m Message
+		if err := item.Decode(&m); err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	sort.SliceStable(messages, func(i, j int) bool { return messages[i].ID < messages[j].ID })
+	return messages, nil
+}
@@ -0,0 +1,154 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+)
+
+func TestValidateText(t *testing.T) {
+	for _, text := range []string{"", "   ", strings.Repeat("a", MaxText+1)} {
+		if _, err := ValidateText(text); err == nil {
+			t.Errorf("expected an error for %d characters", len(text))
+		}
+	}
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+
+	// active links coach-1 to athlete-1
+	active := func(t *testing.T) (*Repository, *Link) {
+		t.Helper()
+		r := NewRepository(store.NewMemoryStore())
+		l, err := r.Invite(ctx, "coach-1", "athlete-1", now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		l, err = r.Accept(ctx, "athlete-1", l.ID, now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return r, l
+	}
+
+	t.Run("gives both users a copy of an invite only the athlete can accept", func(t *testing.T) {
+		// Arrange
+		r := NewRepository(store.NewMemoryStore())
+		l, _ := r.Invite(ctx, "coach-1", "athlete-1", now)
+
+		// Act
+		_, coachErr := r.Accept(ctx, "coach-1", l.ID, now)
+		accepted, err := r.Accept(ctx, "athlete-1", l.ID, now)
+		coachCopy, _ := r.Get(ctx, "coach-1", l.ID)
+
+		// Assert
+		if !errors.Is(coachErr, ErrNotAthlete) {
+			t.Errorf("expected ErrNotAthlete, got %v", coachErr)
+		}
+		if err != nil || accepted.Status != StatusActive || coachCopy.Status != StatusActive {
+			t.Errorf("expected both copies active, got %+v, %+v and %v", accepted, coachCopy, err)
+		}
+	})
+
+	t.Run("refuses a second link with the same athlete until the first ends", func(t *testing.T) {
+		// Arrange
+		r, l := active(t)
+
+		// Act
+		_, linkedErr := r.Invite(ctx, "coach-1", "athlete-1", now)
+		r.End(ctx, "athlete-1", l.ID, now)
+		_, err := r.Invite(ctx, "coach-1", "athlete-1", now)
+		_, selfErr := r.Invite(ctx, "coach-1", "coach-1", now)
+
+		// Assert
+		if !errors.Is(linkedErr, ErrLinked) || err != nil || !errors.Is(selfErr, ErrSelf) {
+			t.Errorf("unexpected errors: %v, %v and %v", linkedErr, err, selfErr)
+		}
+	})
+
+	t.Run("sends only over active links", func(t *testing.T) {
+		// Arrange
+		r := NewRepository(store.NewMemoryStore())
+		l, _ := r.Invite(ctx, "coach-1", "athlete-1", now)
+
+		// Act
+		_, err := r.Send(ctx, l, "coach-1", "Hello", now)
+
+		// Assert
+		if !errors.Is(err, ErrNotActive) {
+			t.Errorf("expected ErrNotActive, got %v", err)
+		}
+	})
+
+	t.Run("counts unread messages until they are marked read", func(t *testing.T) {
+		// Arrange
+		r, l := active(t)
+		r.Send(ctx, l, "coach-1", "Squats today", now)
+		second, _ := r.Send(ctx, l, "coach-1", "Go heavy", now.Add(time.Minute))
+		r.Send(ctx, l, "athlete-1", "On it", now.Add(2*time.Minute))
+
+		// Act
+		before, _ := r.List(ctx, "athlete-1")
+		read, err := r.MarkRead(ctx, "athlete-1", l.ID, second.ID)
+		after, _ := r.List(ctx, "athlete-1")
+		coach, _ := r.Get(ctx, "coach-1", l.ID)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if before[0].Unread != 2 || after[0].Unread != 0 || read.ReadUpTo != second.ID {
+			t.Errorf("expected 2 then 0 unread, got %+v and %+v", before[0], after[0])
+		}
+		if coach.PeerReadUpTo != second.ID {
+			t.Errorf("expected the coach to see the read receipt, got %+v", coach)
+		}
+	})
+
+	t.Run("pages messages newest first", func(t *testing.T) {
+		// Arrange
+		r, l := active(t)
+		for i := 0; i < 3; i++ {
+			r.Send(ctx, l, "coach-1", "Set "+string(rune('1'+i)), now.Add(time.Duration(i)*time.Minute))
+		}
+
+		// Act
+		first, _ := r.Messages(ctx, "athlete-1", l.ID, "", 2, now)
+		next, _ := r.Messages(ctx, "athlete-1", l.ID, first[1].ID, 2, now)
+
+		// Assert
+		if len(first) != 2 || first[0].Text != "Set 3" || len(next) != 1 || next[0].Text != "Set 1" {
+			t.Errorf("unexpected pages: %+v and %+v", first, next)
+		}
+	})
+
+	t.Run("leaves out and purges messages past retention", func(t *testing.T) {
+		// Arrange
+		r, l := active(t)
+		r.Send(ctx, l, "coach-1", "Old", now)
+		r.Send(ctx, l, "coach-1", "New", now.Add(24*time.Hour))
+		later := now.Add(Retention)
+
+		// Act
+		visible, _ := r.Messages(ctx, "athlete-1", l.ID, "", 0, later)
+		purged, err := r.Purge(ctx, "athlete-1", later)
+		coachCopies, _ := r.Messages(ctx, "coach-1", l.ID, "", 0, now)
+
+		// Assert
+		if err != nil || purged != 1 {
+			t.Fatalf("expected 1 message purged, got %d and %v", purged, err)
+		}
+		if len(visible) != 1 || visible[0].Text != "New" {
+			t.Errorf("unexpected messages: %+v", visible)
+		}
+		if len(coachCopies) != 2 {
+			t.Errorf("expected the coach's copies to be purged from their own partition, got %+v", coachCopies)
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"athlete-forge/firehose"
+	"athlete-forge/store"
+)
+
+// Cohorts of callers that are not provider accounts
+const (
+	CohortAnonymous = "anonymous"
+	CohortCognito   = "cognito"
+)
+
+// maxVariants bounds how many feature flag variants one request may report
+const maxVariants = 10
+
+// variantPattern matches flag names and variant names
+var variantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,39}$`)
+
+// Metric is one API request as product analytics sees it: which feature was
+// used, by which cohort, under which feature flag variants. It carries nothing
+// identifying the user
+type Metric struct {
+	Timestamp  time.Time         `json:"timestamp"`
+	Method     string            `json:"method"`
+	Route      string            `json:"route"`
+	Status     int               `json:"status"`
+	DurationMs int64             `json:"durationMs"`
+	Cohort     string            `json:"cohort"`
+	Variants   map[string]string `json:"variants,omitempty"`
+}
+
+// Recorder receives product metrics
+type Recorder interface {
+	// Record delivers metrics, failing if any of them was not accepted
+	Record(ctx context.Context, metrics ...Metric) error
+}
+
+// Firehose records metrics to a Kinesis Data Firehose stream, which buffers
+// them into S3
+type Firehose struct {
+	stream *firehose.Stream
+}
+
+// NewFirehose creates a recorder writing to stream
+func NewFirehose(stream *firehose.Stream) *Firehose {
+	return &Firehose{stream: stream}
+}
+
+// Record puts metrics on the stream
+func (f *Firehose) Record(ctx context.Context, metrics ...Metric) error {
+	values := make([]interface{}, len(metrics))
+	for i, m := range metrics {
+		values[i] = m
+	}
+	return f.stream.Put(ctx, values...)
+}
+
+// Cohort groups a caller by signup month, such as 2024-03, for accounts created
+// through provider sign-in, whose IDs record when they were created. Cognito
+// users, whose IDs do not, and anonymous callers form their own cohorts
+func Cohort(userID string) string {
+	if userID == "" {
+		return CohortAnonymous
+	}
+	created, ok := store.IDTime(userID)
+	if !ok {
+		return CohortCognito
+	}
+	return created.Format("2006-01")
+}
+
+// ParseVariants reads the feature flag variants the app evaluated for a request
+// from a header such as "new-timer=treatment, onboarding-v2=control". Malformed
+// entries are skipped, as are any beyond the first ten
+func ParseVariants(header string) map[string]string {
+	var variants map[string]string
+	for _, entry := range strings.Split(header, ",") {
+		flag, variant, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		flag, variant = strings.ToLower(strings.TrimSpace(flag)), strings.ToLower(strings.TrimSpace(variant))
+		if !ok || !variantPattern.MatchString(flag) || !variantPattern.MatchString(variant) {
+			continue
+		}
+		if variants == nil {
+			variants = map[string]string{}
+		}
+		if len(variants) == maxVariants {
+			break
+		}
+		variants[flag] = variant
+	}
+	return variants
+}
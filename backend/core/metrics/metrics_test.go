@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCohort(t *testing.T) {
+	tests := []struct {
+		name   string
+		userID string
+		want   string
+	}{
+		{name: "anonymous caller", userID: "", want: CohortAnonymous},
+		{name: "cognito user", userID: "6f1c2a7e-9d3b-4c1e-8a5f-2b7d9e0c4a11", want: CohortCognito},
+		{name: "provider account", userID: "018e3f1a2b000123456789", want: time.UnixMilli(0x018e3f1a2b00).UTC().Format("2006-01")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got := Cohort(tt.userID)
+
+			// Assert
+			if got != tt.want {
+				t.Errorf("expected cohort %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseVariants(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   map[string]string
+	}{
+		{name: "no header", header: "", want: nil},
+		{name: "several flags", header: "new-timer=treatment, Onboarding-V2=control", want: map[string]string{"new-timer": "treatment", "onboarding-v2": "control"}},
+		{name: "malformed entries skipped", header: "new-timer, =control, plates=<script>, warmup=b", want: map[string]string{"warmup": "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got := ParseVariants(tt.header)
+
+			// Assert
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("at most ten flags are kept", func(t *testing.T) {
+		// Arrange
+		entries := make([]string, 15)
+		for i := range entries {
+			entries[i] = string(rune('a'+i)) + "=on"
+		}
+
+		// Act
+		got := ParseVariants(strings.Join(entries, ","))
+
+		// Assert
+		if len(got) != maxVariants {
+			t.Errorf("expected %d variants, got %d", maxVariants, len(got))
+		}
+	})
+}
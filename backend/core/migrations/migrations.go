@@ -0,0 +1,282 @@
+package migrations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"athlete-forge/store"
+)
+
+// VersionField is the field of each stored document recording the schema version
+// it was written under; documents without it are version 0
+const VersionField = "schemaVersion"
+
+// Migration upgrades a stored document from the previous version to Version. Up
+// edits the decoded document in place
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(doc map[string]interface{}) error
+}
+
+// kind is the migrations of the items whose sort keys begin with prefix
+type kind struct {
+	prefix     string
+	migrations []Migration
+}
+
+// Registry holds the schema migrations of each kind of item, identified by sort
+// key prefix
+type Registry struct {
+	kinds []kind
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds the migrations of the items under skPrefix, which must be
+// numbered from 1 in order; the last one is the kind's current version
+func (r *Registry) Register(skPrefix string, migrations ...Migration) error {
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return fmt.Errorf("migration %d of %s must be version %d, got %d", i+1, skPrefix, i+1, m.Version)
+		}
+		if m.Up == nil {
+			return fmt.Errorf("migration %d of %s has no Up", m.Version, skPrefix)
+		}
+	}
+	for _, k := range r.kinds {
+		if k.prefix == skPrefix {
+			return fmt.Errorf("migrations of %s are already registered", skPrefix)
+		}
+	}
+	r.kinds = append(r.kinds, kind{prefix: skPrefix, migrations: migrations})
+	return nil
+}
+
+// kindOf returns the kind sk belongs to, preferring the longest matching prefix
+func (r *Registry) kindOf(sk string) *kind {
+	var match *kind
+	for i := range r.kinds {
+		k := &r.kinds[i]
+		if strings.HasPrefix(sk, k.prefix) && (match == nil || len(k.prefix) > len(match.prefix)) {
+			match = k
+		}
+	}
+	return match
+}
+
+// Current returns the schema version items at sk are written under, or 0 when
+// their kind has no migrations
+func (r *Registry) Current(sk string) int {
+	k := r.kindOf(sk)
+	if k == nil {
+		return 0
+	}
+	return len(k.migrations)
+}
+
+// Upgrade applies the migrations item's document is missing, reporting whether
+// it changed
+func (r *Registry) Upgrade(item store.Item) (store.Item, bool, error) {
+	k := r.kindOf(item.SK)
+	if k == nil || len(k.migrations) == 0 {
+		return item, false, nil
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(item.Data, &doc); err != nil {
+		return item, false, fmt.Errorf("failed to decode item %s/%s for migration: %w", item.PK, item.SK, err)
+	}
+	version := 0
+	if v, ok := doc[VersionField].(float64); ok {
+		version = int(v)
+	}
+	if version >= len(k.migrations) {
+		return item, false, nil
+	}
+
+	for _, m := range k.migrations[version:] {
+		if err := m.Up(doc); err != nil {
+			return item, false, fmt.Errorf("failed to migrate item %s/%s to version %d: %w", item.PK, item.SK, m.Version, err)
+		}
+	}
+	doc[VersionField] = len(k.migrations)
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return item, false, fmt.Errorf("failed to encode migrated item %s/%s: %w", item.PK, item.SK, err)
+	}
+	item.Data = data
+	return item, true, nil
+}
+
+// stamp marks data as written under sk's current version
+func (r *Registry) stamp(sk string, data []byte) ([]byte, error) {
+	version := r.Current(sk)
+	if version == 0 {
+		return data, nil
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	doc[VersionField] = json.RawMessage(fmt.Sprint(version))
+	return json.Marshal(doc)
+}
+
+// Store upgrades items written under older schemas as they are read and stamps
+// items with their current version as they are written, so a schema change
+// takes effect without rewriting the table. The stored item is only rewritten
+// when the application next saves it, or by Backfill
+type Store struct {
+	store    store.Store
+	registry *Registry
+}
+
+// NewStore wraps s so items are migrated with r
+func NewStore(s store.Store, r *Registry) *Store {
+	return &Store{store: s, registry: r}
+}
+
+// Get loads the item at pk/sk into out, upgraded to its current version
+func (s *Store) Get(ctx context.Context, pk, sk string, out interface{}) error {
+	if s.registry.Current(sk) == 0 {
+		return s.store.Get(ctx, pk, sk, out)
+	}
+	var data json.RawMessage
+	if err := s.store.Get(ctx, pk, sk, &data); err != nil {
+		return err
+	}
+	item, _, err := s.registry.Upgrade(store.Item{PK: pk, SK: sk, Data: data})
+	if err != nil {
+		return err
+	}
+	return item.Decode(out)
+}
+
+// Put writes v at pk/sk stamped with its current version
+func (s *Store) Put(ctx context.Context, pk, sk string, v interface{}) error {
+	if s.registry.Current(sk) == 0 {
+		return s.store.Put(ctx, pk, sk, v)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal item %s/%s: %w", pk, sk, err)
+	}
+	stamped, err := s.registry.stamp(sk, data)
+	if err != nil {
+		return fmt.Errorf("failed to stamp item %s/%s: %w", pk, sk, err)
+	}
+	return s.store.Put(ctx, pk, sk, json.RawMessage(stamped))
+}
+
+// Create writes v at pk/sk stamped with its current version, unless an item
+// is already there
+func (s *Store) Create(ctx context.Context, pk, sk string, v interface{}) error {
+	if s.registry.Current(sk) == 0 {
+		return store.Create(ctx, s.store, pk, sk, v)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal item %s/%s: %w", pk, sk, err)
+	}
+	stamped, err := s.registry.stamp(sk, data)
+	if err != nil {
+		return fmt.Errorf("failed to stamp item %s/%s: %w", pk, sk, err)
+	}
+	return store.Create(ctx, s.store, pk, sk, json.RawMessage(stamped))
+}
+
+// BatchPut writes each item stamped with its current version
+func (s *Store) BatchPut(ctx context.Context, writes []store.Write) error {
+	stamped := make([]store.Write, len(writes))
+	for i, w := range writes {
+		stamped[i] = w
+		if s.registry.Current(w.SK) == 0 {
+			continue
+		}
+		data, err := json.Marshal(w.Value)
+		if err != nil {
+			return fmt.Errorf("failed to marshal item %s/%s: %w", w.PK, w.SK, err)
+		}
+		current, err := s.registry.stamp(w.SK, data)
+		if err != nil {
+			return fmt.Errorf("failed to stamp item %s/%s: %w", w.PK, w.SK, err)
+		}
+		stamped[i].Value = json.RawMessage(current)
+	}
+	return store.BatchPut(ctx, s.store, stamped)
+}
+
+// Query returns the items under pk whose sort key begins with skPrefix, each
+// upgraded to its current version
+func (s *Store) Query(ctx context.Context, pk, skPrefix string) ([]store.Item, error) {
+	items, err := s.store.Query(ctx, pk, skPrefix)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		if items[i], _, err = s.registry.Upgrade(items[i]); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// BatchGet returns the items at keys that exist, each upgraded to its current
+// version
+func (s *Store) BatchGet(ctx context.Context, keys []store.Key) ([]store.Item, error) {
+	items, err := s.store.BatchGet(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		if items[i], _, err = s.registry.Upgrade(items[i]); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// Delete removes the item at pk/sk
+func (s *Store) Delete(ctx context.Context, pk, sk string) error {
+	return s.store.Delete(ctx, pk, sk)
+}
+
+// Backfill rewrites every item under pk still on an older version, returning
+// how many it rewrote. It is safe to re-run, since items already on their
+// current version are left alone
+func (s *Store) Backfill(ctx context.Context, pk string) (int, error) {
+	rewritten := 0
+	for _, k := range s.registry.kinds {
+		if len(k.migrations) == 0 {
+			continue
+		}
+		items, err := s.store.Query(ctx, pk, k.prefix)
+		if err != nil {
+			return rewritten, fmt.Errorf("failed to list %s items: %w", k.prefix, err)
+		}
+		for _, item := range items {
+			// A longer registered prefix owns the item
+			if s.registry.kindOf(item.SK).prefix != k.prefix {
+				continue
+			}
+			upgraded, changed, err := s.registry.Upgrade(item)
+			if err != nil {
+				return rewritten, err
+			}
+			if !changed {
+				continue
+			}
+			if err := s.store.Put(ctx, pk, item.SK, upgraded.Data); err != nil {
+				return rewritten, fmt.Errorf("failed to rewrite migrated item: %w", err)
+			}
+			rewritten++
+		}
+	}
+	return rewritten, nil
+}
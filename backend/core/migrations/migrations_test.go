@@ -0,0 +1,245 @@
+package migrations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"athlete-forge/store"
+)
+
+type testDoc struct {
+	Name  string `json:"name"`
+	Title string `json:"title"`
+}
+
+// renameTitle moves the name field to title
+func renameTitle(doc map[string]interface{}) error {
+	doc["title"] = doc["name"]
+	delete(doc, "name")
+	return nil
+}
+
+func testRegistry(t *testing.T) *Registry {
+	t.Helper()
+	r := NewRegistry()
+	err := r.Register("PROGRAM#",
+		Migration{Version: 1, Description: "rename name to title", Up: renameTitle},
+		Migration{Version: 2, Description: "uppercase title", Up: func(doc map[string]interface{}) error {
+			if title, ok := doc["title"].(string); ok && title == "strength" {
+				doc["title"] = "Strength"
+			}
+			return nil
+		}},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return r
+}
+
+// rawVersion returns the schema version stored at pk/sk
+func rawVersion(t *testing.T, s store.Store, pk, sk string) interface{} {
+	t.Helper()
+	var doc map[string]interface{}
+	if err := s.Get(context.Background(), pk, sk, &doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return doc[VersionField]
+}
+
+func TestRegistry(t *testing.T) {
+	t.Run("upgrades unversioned items through every migration", func(t *testing.T) {
+		// Arrange
+		r := testRegistry(t)
+		item := store.Item{PK: "USER#1", SK: "PROGRAM#a", Data: json.RawMessage(`{"name":"strength"}`)}
+
+		// Act
+		upgraded, changed, err := r.Upgrade(item)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var doc map[string]interface{}
+		json.Unmarshal(upgraded.Data, &doc)
+		if !changed || doc["title"] != "Strength" || doc["name"] != nil || doc[VersionField] != float64(2) {
+			t.Errorf("unexpected upgrade: %s (changed %v)", upgraded.Data, changed)
+		}
+	})
+
+	t.Run("applies only the migrations an item is missing", func(t *testing.T) {
+		// Arrange
+		r := testRegistry(t)
+		item := store.Item{SK: "PROGRAM#a", Data: json.RawMessage(`{"title":"strength","name":"kept","schemaVersion":1}`)}
+
+		// Act
+		upgraded, _, _ := r.Upgrade(item)
+
+		// Assert
+		var doc map[string]interface{}
+		json.Unmarshal(upgraded.Data, &doc)
+		if doc["name"] != "kept" || doc["title"] != "Strength" {
+			t.Errorf("expected only version 2 applied, got %s", upgraded.Data)
+		}
+	})
+
+	t.Run("leaves current and unregistered items alone", func(t *testing.T) {
+		// Arrange
+		r := testRegistry(t)
+		items := []store.Item{
+			{SK: "PROGRAM#a", Data: json.RawMessage(`{"title":"x","schemaVersion":2}`)},
+			{SK: "WORKOUT#a", Data: json.RawMessage(`{"name":"x"}`)},
+		}
+
+		for _, item := range items {
+			// Act
+			upgraded, changed, err := r.Upgrade(item)
+
+			// Assert
+			if err != nil || changed || string(upgraded.Data) != string(item.Data) {
+				t.Errorf("expected %s unchanged, got %s (changed %v, err %v)", item.SK, upgraded.Data, changed, err)
+			}
+		}
+	})
+
+	t.Run("the longest matching prefix owns an item", func(t *testing.T) {
+		// Arrange
+		r := testRegistry(t)
+		r.Register("PROGRAM#TEMPLATE#", Migration{Version: 1, Up: func(map[string]interface{}) error { return nil }})
+
+		// Act
+		current := r.Current("PROGRAM#TEMPLATE#a")
+
+		// Assert
+		if current != 1 {
+			t.Errorf("expected version 1, got %d", current)
+		}
+	})
+
+	t.Run("rejects migrations out of order", func(t *testing.T) {
+		// Act
+		err := NewRegistry().Register("PROGRAM#", Migration{Version: 2, Up: renameTitle})
+
+		// Assert
+		if err == nil {
+			t.Error("expected an error for a migration not numbered from 1")
+		}
+	})
+
+	t.Run("reports failed migrations", func(t *testing.T) {
+		// Arrange
+		r := NewRegistry()
+		r.Register("PROGRAM#", Migration{Version: 1, Up: func(map[string]interface{}) error { return errors.New("bad item") }})
+
+		// Act
+		_, _, err := r.Upgrade(store.Item{SK: "PROGRAM#a", Data: json.RawMessage(`{}`)})
+
+		// Assert
+		if err == nil {
+			t.Error("expected the migration's error")
+		}
+	})
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reads upgrade old items without rewriting them", func(t *testing.T) {
+		// Arrange
+		backing := store.NewMemoryStore()
+		backing.Put(ctx, "USER#1", "PROGRAM#a", map[string]string{"name": "strength"})
+		s := NewStore(backing, testRegistry(t))
+
+		// Act
+		var got testDoc
+		err := s.Get(ctx, "USER#1", "PROGRAM#a", &got)
+		items, queryErr := s.Query(ctx, "USER#1", "PROGRAM#")
+
+		// Assert
+		if err != nil || queryErr != nil {
+			t.Fatalf("unexpected errors: %v, %v", err, queryErr)
+		}
+		if got.Title != "Strength" {
+			t.Errorf("expected upgraded title, got %+v", got)
+		}
+		var queried testDoc
+		items[0].Decode(&queried)
+		if queried.Title != "Strength" {
+			t.Errorf("expected upgraded query result, got %s", items[0].Data)
+		}
+		if v := rawVersion(t, backing, "USER#1", "PROGRAM#a"); v != nil {
+			t.Errorf("expected the stored item untouched, got version %v", v)
+		}
+	})
+
+	t.Run("writes are stamped with the current version", func(t *testing.T) {
+		// Arrange
+		backing := store.NewMemoryStore()
+		s := NewStore(backing, testRegistry(t))
+
+		// Act
+		err := s.Put(ctx, "USER#1", "PROGRAM#a", testDoc{Title: "strength"})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if v := rawVersion(t, backing, "USER#1", "PROGRAM#a"); v != float64(2) {
+			t.Errorf("expected version 2, got %v", v)
+		}
+		var got testDoc
+		s.Get(ctx, "USER#1", "PROGRAM#a", &got)
+		if got.Title != "strength" {
+			t.Errorf("expected a stamped item not to be migrated again, got %+v", got)
+		}
+	})
+
+	t.Run("batch writes are stamped with the current version", func(t *testing.T) {
+		// Arrange
+		backing := store.NewMemoryStore()
+		s := NewStore(backing, testRegistry(t))
+
+		// Act
+		err := store.BatchPut(ctx, s, []store.Write{{PK: "USER#1", SK: "PROGRAM#a", Value: testDoc{Title: "strength"}}, {PK: "USER#1", SK: "OTHER#b", Value: testDoc{Title: "kept"}}})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if v := rawVersion(t, backing, "USER#1", "PROGRAM#a"); v != float64(2) {
+			t.Errorf("expected version 2, got %v", v)
+		}
+		if v := rawVersion(t, backing, "USER#1", "OTHER#b"); v != nil {
+			t.Errorf("expected an unversioned item left unstamped, got %v", v)
+		}
+	})
+
+	t.Run("backfill rewrites only outdated items", func(t *testing.T) {
+		// Arrange
+		backing := store.NewMemoryStore()
+		s := NewStore(backing, testRegistry(t))
+		backing.Put(ctx, "USER#1", "PROGRAM#a", map[string]string{"name": "strength"})
+		s.Put(ctx, "USER#1", "PROGRAM#b", testDoc{Title: "hypertrophy"})
+		backing.Put(ctx, "USER#1", "WORKOUT#1", map[string]string{"name": "legs"})
+
+		// Act
+		first, err := s.Backfill(ctx, "USER#1")
+		second, _ := s.Backfill(ctx, "USER#1")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if first != 1 || second != 0 {
+			t.Errorf("expected 1 then 0 items rewritten, got %d then %d", first, second)
+		}
+		if v := rawVersion(t, backing, "USER#1", "PROGRAM#a"); v != float64(2) {
+			t.Errorf("expected the outdated item rewritten at version 2, got %v", v)
+		}
+		if v := rawVersion(t, backing, "USER#1", "WORKOUT#1"); v != nil {
+			t.Errorf("expected unregistered items untouched, got version %v", v)
+		}
+	})
+}
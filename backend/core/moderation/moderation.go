@@ -0,0 +1,322 @@
+package moderation
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"athlete-forge/store"
+)
+
+const (
+	moderationPK    = "MODERATION"
+	caseSKPrefix    = "CASE#"
+	auditSKPrefix   = "AUDIT#"
+	standingSK      = "STANDING"
+	maxReasonSize   = 500
+	maxNoteSize     = 2000
+	maxCaseReports  = 50
+	maxUserWarnings = 100
+)
+
+// Kinds of content that can be reported
+const (
+	KindTemplate = "template"
+	KindReview   = "review"
+	KindUser     = "user"
+)
+
+// Case statuses
+const (
+	StatusOpen   = "open"
+	StatusClosed = "closed"
+)
+
+// Admin actions on a case
+const (
+	ActionHide    = "hide"
+	ActionRestore = "restore"
+	ActionWarn    = "warn"
+	ActionBan     = "ban"
+	ActionUnban   = "unban"
+	ActionDismiss = "dismiss"
+)
+
+// Target identifies reported content: a marketplace template by ID, a review by
+// its template's ID and the reviewer's user ID, or a user, for their account
+// name, by user ID
+type Target struct {
+	Kind   string `json:"kind"`
+	ID     string `json:"id,omitempty"`
+	UserID string `json:"userId,omitempty"`
+}
+
+// Validate checks the target has the IDs its kind needs
+func (t Target) Validate() error {
+	switch t.Kind {
+	case KindTemplate:
+		if t.ID == "" {
+			return errors.New("templates are reported by id")
+		}
+	case KindReview:
+		if t.ID == "" || t.UserID == "" {
+			return errors.New("reviews are reported by their template's id and the reviewer's userId")
+		}
+	case KindUser:
+		if t.UserID == "" {
+			return errors.New("users are reported by userId")
+		}
+	default:
+		return fmt.Errorf("unknown kind %q; use %s, %s or %s", t.Kind, KindTemplate, KindReview, KindUser)
+	}
+	return nil
+}
+
+// CaseID returns the ID of the case holding reports of t; each target has one
+// case, reopened when it is reported again after being closed
+func CaseID(t Target) string {
+	sum := sha256.Sum256([]byte(t.Kind + "\x00" + t.ID + "\x00" + t.UserID))
+	return hex.EncodeToString(sum[:10])
+}
+
+// Report is one user's report of content
+type Report struct {
+	ReporterID string    `json:"reporterId"`
+	Reason     string    `json:"reason"`
+	ReportedAt time.Time `json:"reportedAt"`
+}
+
+// Case gathers the reports of one piece of content for an admin to act on.
+// OwnerID is the user responsible for the content, who warnings and bans apply
+// to. Reports keeps the first reports of the case; ReportCount counts them all
+type Case struct {
+	ID          string    `json:"id"`
+	Target      Target    `json:"target"`
+	OwnerID     string    `json:"ownerId"`
+	Status      string    `json:"status"`
+	Reports     []Report  `json:"reports"`
+	ReportCount int       `json:"reportCount"`
+	OpenedAt    time.Time `json:"openedAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// Entry is a record in the audit trail of admin moderation actions
+type Entry struct {
+	ID      string    `json:"id"`
+	CaseID  string    `json:"caseId,omitempty"`
+	Target  Target    `json:"target"`
+	OwnerID string    `json:"ownerId,omitempty"`
+	AdminID string    `json:"adminId"`
+	Action  string    `json:"action"`
+	Note    string    `json:"note,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// Standing is a user's record of moderation: the warnings they have been given
+// and whether they are banned from publishing public content
+type Standing struct {
+	UserID    string     `json:"userId"`
+	Warnings  []Warning  `json:"warnings"`
+	Banned    bool       `json:"banned"`
+	BannedAt  *time.Time `json:"bannedAt,omitempty"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// Warning is a warning given to a user about their content
+type Warning struct {
+	CaseID   string    `json:"caseId,omitempty"`
+	Note     string    `json:"note,omitempty"`
+	WarnedAt time.Time `json:"warnedAt"`
+}
+
+// ValidateReason trims reason and checks it is present and not too long
+func ValidateReason(reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return "", errors.New("reason is required")
+	}
+	if len(reason) > maxReasonSize {
+		return "", fmt.Errorf("reason must be at most %d characters", maxReasonSize)
+	}
+	return reason, nil
+}
+
+// ValidateNote checks an admin's note on an action is not too long
+func ValidateNote(note string) error {
+	if len(note) > maxNoteSize {
+		return fmt.Errorf("note must be at most %d characters", maxNoteSize)
+	}
+	return nil
+}
+
+// Repository stores the moderation queue, the audit trail and users' standing.
+// Cases and the audit trail are each held in one partition, which suits the
+// volume of reports a small community produces
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// File adds report to the case for target, opening the case, or reopening a
+// closed one, as needed
+func (r *Repository) File(ctx context.Context, target Target, ownerID string, report Report) (*Case, error) {
+	if err := target.Validate(); err != nil {
+		return nil, err
+	}
+	id := CaseID(target)
+	c, err := r.GetCase(ctx, id)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c = &Case{ID: id, Target: target, Reports: []Report{}}
+	case err != nil:
+		return nil, err
+	}
+	if c.Status != StatusOpen {
+		c.Status = StatusOpen
+		c.OpenedAt = report.ReportedAt
+	}
+	c.OwnerID = ownerID
+	if len(c.Reports) < maxCaseReports {
+		c.Reports = append(c.Reports, report)
+	}
+	c.ReportCount++
+	c.UpdatedAt = report.ReportedAt
+	if err := r.SaveCase(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetCase returns the case with id
+func (r *Repository) GetCase(ctx context.Context, id string) (*Case, error) {
+	var c Case
+	if err := r.store.Get(ctx, moderationPK, caseSKPrefix+id, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ListCases returns the cases with status, or every case when status is empty,
+// most reported first and then oldest first
+func (r *Repository) ListCases(ctx context.Context, status string) ([]Case, error) {
+	items, err := r.store.Query(ctx, moderationPK, caseSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list moderation cases: %w", err)
+	}
+
+	cases := []Case{}
+	for _, item := range items {
+		var c Case
+		if err := item.Decode(&c); err != nil {
+			return nil, err
+		}
+		if status == "" || c.Status == status {
+			cases = append(cases, c)
+		}
+	}
+	sort.SliceStable(cases, func(i, j int) bool {
+		if cases[i].ReportCount != cases[j].ReportCount {
+			return cases[i].ReportCount > cases[j].ReportCount
+		}
+		return cases[i].OpenedAt.Before(cases[j].OpenedAt)
+	})
+	return cases, nil
+}
+
+// SaveCase stores c
+func (r *Repository) SaveCase(ctx context.Context, c *Case) error {
+	if err := r.store.Put(ctx, moderationPK, caseSKPrefix+c.ID, c); err != nil {
+		return fmt.Errorf("failed to save moderation case: %w", err)
+	}
+	return nil
+}
+
+// Record appends e to the audit trail
+func (r *Repository) Record(ctx context.Context, e *Entry) error {
+	if e.ID == "" {
+		e.ID = store.NewID()
+	}
+	if err := r.store.Put(ctx, moderationPK, auditSKPrefix+e.ID, e); err != nil {
+		return fmt.Errorf("failed to record moderation action: %w", err)
+	}
+	return nil
+}
+
+// Audit returns the audit trail, newest first, optionally only the entries
+// about one case
+func (r *Repository) Audit(ctx context.Context, caseID string) ([]Entry, error) {
+	items, err := r.store.Query(ctx, moderationPK, auditSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list moderation actions: %w", err)
+	}
+
+	entries := []Entry{}
+	for _, item := range items {
+		var e Entry
+		if err := item.Decode(&e); err != nil {
+			return nil, err
+		}
+		if caseID == "" || e.CaseID == caseID {
+			entries = append(entries, e)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].At.After(entries[j].At)
+	})
+	return entries, nil
+}
+
+// Standing returns userID's standing, which is clean for users never moderated
+func (r *Repository) Standing(ctx context.Context, userID string) (*Standing, error) {
+	s := Standing{UserID: userID, Warnings: []Warning{}}
+	err := r.store.Get(ctx, store.UserPK(userID), standingSK, &s)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Warn adds a warning to userID's standing, keeping the most recent ones
+func (r *Repository) Warn(ctx context.Context, userID string, w Warning) (*Standing, error) {
+	s, err := r.Standing(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.Warnings = append(s.Warnings, w)
+	if len(s.Warnings) > maxUserWarnings {
+		s.Warnings = s.Warnings[len(s.Warnings)-maxUserWarnings:]
+	}
+	s.UpdatedAt = w.WarnedAt
+	return s, r.saveStanding(ctx, s)
+}
+
+// SetBanned bans or reinstates userID
+func (r *Repository) SetBanned(ctx context.Context, userID string, banned bool, now time.Time) (*Standing, error) {
+	s, err := r.Standing(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.Banned = banned
+	s.BannedAt = nil
+	if banned {
+		s.BannedAt = &now
+	}
+	s.UpdatedAt = now
+	return s, r.saveStanding(ctx, s)
+}
+
+func (r *Repository) saveStanding(ctx context.Context, s *Standing) error {
+	if err := r.store.Put(ctx, store.UserPK(s.UserID), standingSK, s); err != nil {
+		return fmt.Errorf("failed to save moderation standing: %w", err)
+	}
+	return nil
+}
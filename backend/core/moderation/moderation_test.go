@@ -0,0 +1,143 @@
+package moderation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+)
+
+func TestTarget_Validate(t *testing.T) {
+	valid := []Target{
+		{Kind: KindTemplate, ID: "t1"},
+		{Kind: KindReview, ID: "t1", UserID: "a"},
+		{Kind: KindUser, UserID: "a"},
+	}
+	for _, target := range valid {
+		if err := target.Validate(); err != nil {
+			t.Errorf("expected %+v to be valid, got %v", target, err)
+		}
+	}
+	invalid := []Target{
+		{Kind: KindTemplate},
+		{Kind: KindReview, ID: "t1"},
+		{Kind: KindUser, ID: "a"},
+		{Kind: "workout", ID: "w1"},
+	}
+	for _, target := range invalid {
+		if err := target.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", target)
+		}
+	}
+}
+
+func TestRepository_File(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := NewRepository(store.NewMemoryStore())
+	target := Target{Kind: KindTemplate, ID: "t1"}
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	repo.File(ctx, target, "author", Report{ReporterID: "a", Reason: "spam", ReportedAt: start})
+	c, _ := repo.File(ctx, target, "author", Report{ReporterID: "b", Reason: "spam", ReportedAt: start.Add(time.Hour)})
+	c.Status = StatusClosed
+	repo.SaveCase(ctx, c)
+
+	// Act
+	reopened, err := repo.File(ctx, target, "author", Report{ReporterID: "c", Reason: "still spam", ReportedAt: start.Add(48 * time.Hour)})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reopened.ID != CaseID(target) || reopened.Status != StatusOpen || reopened.ReportCount != 3 || len(reopened.Reports) != 3 {
+		t.Errorf("expected the case reopened with three reports, got %+v", reopened)
+	}
+	if !reopened.OpenedAt.Equal(start.Add(48 * time.Hour)) {
+		t.Errorf("expected the case reopened at the new report, got %v", reopened.OpenedAt)
+	}
+}
+
+func TestRepository_ListCases(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := NewRepository(store.NewMemoryStore())
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	repo.File(ctx, Target{Kind: KindUser, UserID: "old"}, "old", Report{ReporterID: "a", Reason: "name", ReportedAt: start})
+	repo.File(ctx, Target{Kind: KindUser, UserID: "new"}, "new", Report{ReporterID: "a", Reason: "name", ReportedAt: start.Add(time.Hour)})
+	for _, reporter := range []string{"a", "b"} {
+		repo.File(ctx, Target{Kind: KindTemplate, ID: "t1"}, "author", Report{ReporterID: reporter, Reason: "spam", ReportedAt: start.Add(2 * time.Hour)})
+	}
+	closed, _ := repo.File(ctx, Target{Kind: KindTemplate, ID: "t2"}, "author", Report{ReporterID: "a", Reason: "spam", ReportedAt: start})
+	closed.Status = StatusClosed
+	repo.SaveCase(ctx, closed)
+
+	// Act
+	open, err := repo.ListCases(ctx, StatusOpen)
+	all, _ := repo.ListCases(ctx, "")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(open) != 3 || open[0].Target.ID != "t1" || open[1].Target.UserID != "old" || open[2].Target.UserID != "new" {
+		t.Errorf("expected the most reported case first and then the oldest, got %+v", open)
+	}
+	if len(all) != 4 {
+		t.Errorf("expected every case, got %d", len(all))
+	}
+}
+
+func TestRepository_Audit(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := NewRepository(store.NewMemoryStore())
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	repo.Record(ctx, &Entry{CaseID: "c1", AdminID: "ops", Action: ActionWarn, At: start})
+	repo.Record(ctx, &Entry{CaseID: "c2", AdminID: "ops", Action: ActionDismiss, At: start.Add(time.Minute)})
+	repo.Record(ctx, &Entry{CaseID: "c1", AdminID: "ops", Action: ActionBan, At: start.Add(time.Hour)})
+
+	// Act
+	all, err := repo.Audit(ctx, "")
+	c1, _ := repo.Audit(ctx, "c1")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(all) != 3 || all[0].Action != ActionBan {
+		t.Errorf("expected the newest entry first, got %+v", all)
+	}
+	if len(c1) != 2 || c1[0].Action != ActionBan || c1[1].Action != ActionWarn {
+		t.Errorf("expected the case's entries newest first, got %+v", c1)
+	}
+}
+
+func TestRepository_Standing(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := NewRepository(store.NewMemoryStore())
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	// Act
+	clean, err := repo.Standing(ctx, "a")
+	repo.Warn(ctx, "a", Warning{CaseID: "c1", Note: "keep it civil", WarnedAt: now})
+	repo.SetBanned(ctx, "a", true, now)
+	banned, _ := repo.Standing(ctx, "a")
+	repo.SetBanned(ctx, "a", false, now.Add(time.Hour))
+	reinstated, _ := repo.Standing(ctx, "a")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if clean.Banned || len(clean.Warnings) != 0 {
+		t.Errorf("expected a clean standing, got %+v", clean)
+	}
+	if !banned.Banned || banned.BannedAt == nil || len(banned.Warnings) != 1 {
+		t.Errorf("expected a banned user with one warning, got %+v", banned)
+	}
+	if reinstated.Banned || reinstated.BannedAt != nil || len(reinstated.Warnings) != 1 {
+		t.Errorf("expected the ban lifted and the warning kept, got %+v", reinstated)
+	}
+}
@@ -0,0 +1,69 @@
+package msgpack
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFromJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want []byte
+	}{
+		{"null", `null`, []byte{0xc0}},
+		{"booleans", `[true,false]`, []byte{0x92, 0xc3, 0xc2}},
+		{"positive fixint", `7`, []byte{0x07}},
+		{"negative fixint", `-3`, []byte{0xfd}},
+		{"uint8", `200`, []byte{0xcc, 0xc8}},
+		{"uint16", `1000`, []byte{0xcd, 0x03, 0xe8}},
+		{"int8", `-100`, []byte{0xd0, 0x9c}},
+		{"float64", `1.5`, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"fixstr", `"squat"`, []byte{0xa5, 's', 'q', 'u', 'a', 't'}},
+		{"map with sorted keys", `{"reps":5,"name":"a"}`, []byte{0x82, 0xa4, 'n', 'a', 'm', 'e', 0xa1, 'a', 0xa4, 'r', 'e', 'p', 's', 0x05}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got, err := FromJSON([]byte(tt.json))
+
+			// Assert
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("expected % x, got % x", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("long strings and arrays use sized headers", func(t *testing.T) {
+		// Arrange
+		long := strings.Repeat("a", 40)
+		array := "[" + strings.TrimSuffix(strings.Repeat("1,", 16), ",") + "]"
+
+		// Act
+		str, _ := FromJSON([]byte(`"` + long + `"`))
+		arr, _ := FromJSON([]byte(array))
+
+		// Assert
+		if !bytes.Equal(str[:2], []byte{0xd9, 40}) {
+			t.Errorf("expected str8 header, got % x", str[:2])
+		}
+		if !bytes.Equal(arr[:3], []byte{0xdc, 0x00, 0x10}) {
+			t.Errorf("expected array16 header, got % x", arr[:3])
+		}
+	})
+
+	t.Run("invalid JSON is an error", func(t *testing.T) {
+		// Act
+		_, err := FromJSON([]byte(`{"name":`))
+
+		// Assert
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
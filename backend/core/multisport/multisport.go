@@ -0,0 +1,265 @@
+package multisport
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"athlete-forge/cardio"
+	"athlete-forge/hrzone"
+	"athlete-forge/store"
+	"athlete-forge/trainingload"
+	"athlete-forge/workout"
+)
+
+const sessionSKPrefix = "MULTISPORT#"
+
+// Segment types: a cardio activity or a lifting workout
+const (
+	SegmentActivity = "activity"
+	SegmentWorkout  = "workout"
+)
+
+// SportStrength is the sport reported for lifting segments
+const SportStrength = "strength"
+
+// Limits on a session's shape
+const (
+	minSegments  = 2
+	maxSegments  = 10
+	maxNameRunes = 100
+)
+
+// Segment is one part of a multi-sport session, referring to an activity or
+// workout the user logged
+type Segment struct {
+	Type  string `json:"type"`
+	ID    string `json:"id"`
+	Label string `json:"label,omitempty"`
+}
+
+// Session groups activities and workouts done back to back, such as a bike
+// and run brick or lifting followed by a conditioning finisher
+type Session struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"userId"`
+	Name      string    `json:"name"`
+	Segments  []Segment `json:"segments"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Validate checks the session's name and segments
+func (s *Session) Validate() error {
+	if strings.TrimSpace(s.Name) == "" || len([]rune(s.Name)) > maxNameRunes {
+		return fmt.Errorf("name is required and must be at most %d characters", maxNameRunes)
+	}
+	if len(s.Segments) < minSegments || len(s.Segments) > maxSegments {
+		return fmt.Errorf("a session must have between %d and %d segments", minSegments, maxSegments)
+	}
+	seen := map[Segment]bool{}
+	for i, segment := range s.Segments {
+		if segment.Type != SegmentActivity && segment.Type != SegmentWorkout {
+			return fmt.Errorf("segment %d: type must be %q or %q", i+1, SegmentActivity, SegmentWorkout)
+		}
+		if segment.ID == "" {
+			return fmt.Errorf("segment %d: id is required", i+1)
+		}
+		key := Segment{Type: segment.Type, ID: segment.ID}
+		if seen[key] {
+			return fmt.Errorf("segment %d: %s %s is already in the session", i+1, segment.Type, segment.ID)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// Part is a segment with the activity or workout it refers to, both nil when
+// that has since been deleted
+type Part struct {
+	Segment  Segment
+	Activity *cardio.Activity
+	Workout  *workout.Workout
+}
+
+// SegmentSummary is one segment's analytics. TransitionSeconds is the gap
+// since the previous segment ended, such as the time in transition between
+// bike and run. Lifting segments report their performed sets and volume;
+// activity segments carry their run, ride or swim summary. Missing segments
+// refer to an activity or workout that no longer exists
+type SegmentSummary struct {
+	Type              string              `json:"type"`
+	ID                string              `json:"id"`
+	Label             string              `json:"label,omitempty"`
+	Sport             string              `json:"sport,omitempty"`
+	StartTime         *time.Time          `json:"startTime,omitempty"`
+	DurationSeconds   int                 `json:"durationSeconds"`
+	TransitionSeconds int                 `json:"transitionSeconds"`
+	DistanceMeters    float64             `json:"distanceMeters,omitempty"`
+	Sets              int                 `json:"sets,omitempty"`
+	Volume            float64             `json:"volume,omitempty"`
+	Load              float64             `json:"load"`
+	Run               *cardio.RunSummary  `json:"run,omitempty"`
+	Ride              *cardio.RideSummary `json:"ride,omitempty"`
+	Swim              *cardio.SwimSummary `json:"swim,omitempty"`
+	Missing           bool                `json:"missing,omitempty"`
+}
+
+// Summary combines a session's segments, in the order they started. Elapsed
+// time runs from the first segment's start to the last one's end, and is the
+// active time in segments plus the transitions between them
+type Summary struct {
+	StartTime         *time.Time       `json:"startTime,omitempty"`
+	ElapsedSeconds    int              `json:"elapsedSeconds"`
+	ActiveSeconds     int              `json:"activeSeconds"`
+	TransitionSeconds int              `json:"transitionSeconds"`
+	DistanceMeters    float64          `json:"distanceMeters"`
+	Load              float64          `json:"load"`
+	Sports            []string         `json:"sports"`
+	Segments          []SegmentSummary `json:"segments"`
+}
+
+// Summarize scores each part and combines them, with cardio load scored
+// against config as in training load
+func Summarize(parts []Part, config *hrzone.Config) Summary {
+	type timed struct {
+		summary    SegmentSummary
+		start, end time.Time
+	}
+	var present []timed
+	var missing []SegmentSummary
+	for _, part := range parts {
+		s := SegmentSummary{Type: part.Segment.Type, ID: part.Segment.ID, Label: part.Segment.Label}
+		var start, end time.Time
+		switch {
+		case part.Activity != nil:
+			a := part.Activity
+			start, end = a.StartTime, a.StartTime.Add(time.Duration(a.DurationSeconds)*time.Second)
+			s.Sport, s.DistanceMeters = a.Sport, a.DistanceMeters
+			s.Load = trainingload.CardioLoad(*a, config)
+			s.Run, s.Ride, s.Swim = a.Run, a.Ride, a.Swim
+		case part.Workout != nil:
+			w := part.Workout
+			start, end = w.StartedAt, w.StartedAt
+			if w.CompletedAt != nil && w.CompletedAt.After(w.StartedAt) {
+				end = *w.CompletedAt
+			}
+			s.Sport = SportStrength
+			s.Sets, s.Volume = performed(*w)
+			s.Load = trainingload.LiftingLoad(*w)
+		default:
+			s.Missing = true
+			missing = append(missing, s)
+			continue
+		}
+		s.StartTime = &start
+		s.DurationSeconds = int(end.Sub(start) / time.Second)
+		present = append(present, timed{summary: s, start: start, end: end})
+	}
+	sort.SliceStable(present, func(i, j int) bool { return present[i].start.Before(present[j].start) })
+
+	summary := Summary{Sports: []string{}, Segments: []SegmentSummary{}}
+	var finish time.Time
+	seenSports := map[string]bool{}
+	for i, p := range present {
+		if i > 0 && p.start.After(finish) {
+			p.summary.TransitionSeconds = int(p.start.Sub(finish) / time.Second)
+		}
+		if i == 0 || p.end.After(finish) {
+			finish = p.end
+		}
+		summary.ActiveSeconds += p.summary.DurationSeconds
+		summary.TransitionSeconds += p.summary.TransitionSeconds
+		summary.DistanceMeters += p.summary.DistanceMeters
+		summary.Load += p.summary.Load
+		if !seenSports[p.summary.Sport] {
+			seenSports[p.summary.Sport] = true
+			summary.Sports = append(summary.Sports, p.summary.Sport)
+		}
+		summary.Segments = append(summary.Segments, p.summary)
+	}
+	if len(present) > 0 {
+		summary.StartTime = &present[0].start
+		summary.ElapsedSeconds = int(finish.Sub(present[0].start) / time.Second)
+	}
+	summary.Load = math.Round(summary.Load*10) / 10
+	summary.Segments = append(summary.Segments, missing...)
+	return summary
+}
+
+// performed counts a workout's performed sets and their volume
+func performed(w workout.Workout) (int, float64) {
+	sets, volume := 0, 0.0
+	for _, exercise := range w.Exercises {
+		for _, set := range exercise.Sets {
+			if set.Performed() {
+				sets++
+				volume += set.Volume()
+			}
+		}
+	}
+	return sets, volume
+}
+
+// Repository loads and saves multi-sport sessions
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Get returns the session with id owned by userID
+func (r *Repository) Get(ctx context.Context, userID, id string) (*Session, error) {
+	var s Session
+	if err := r.store.Get(ctx, store.UserPK(userID), sessionSKPrefix+id, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// List returns all of userID's multi-sport sessions, oldest first
+func (r *Repository) List(ctx context.Context, userID string) ([]Session, error) {
+	items, err := r.store.Query(ctx, store.UserPK(userID), sessionSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list multi-sport sessions: %w", err)
+	}
+
+	sessions := make([]Session, 0, len(items))
+	for _, item := range items {
+		var s Session
+		if err := item.Decode(&s); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+// Save validates and stores s, assigning an ID to new sessions
+func (r *Repository) Save(ctx context.Context, s *Session) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	if s.ID == "" {
+		s.ID = store.NewID()
+	}
+	if err := r.store.Put(ctx, store.UserPK(s.UserID), sessionSKPrefix+s.ID, s); err != nil {
+		return fmt.Errorf("failed to save multi-sport session: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the session with id owned by userID, leaving its activities
+// and workouts in place
+func (r *Repository) Delete(ctx context.Context, userID, id string) error {
+	if err := r.store.Delete(ctx, store.UserPK(userID), sessionSKPrefix+id); err != nil {
+		return fmt.Errorf("failed to delete multi-sport session: %w", err)
+	}
+	return nil
+}
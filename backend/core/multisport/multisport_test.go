@@ -0,0 +1,88 @@
+package multisport
+
+import (
+	"testing"
+	"time"
+
+	"athlete-forge/cardio"
+	"athlete-forge/workout"
+)
+
+func TestSession_Validate(t *testing.T) {
+	ride := Segment{Type: SegmentActivity, ID: "ride-1"}
+	run := Segment{Type: SegmentActivity, ID: "run-1"}
+	tests := []struct {
+		name    string
+		session Session
+		wantErr bool
+	}{
+		{name: "brick", session: Session{Name: "Brick", Segments: []Segment{ride, run}}},
+		{name: "missing name", session: Session{Segments: []Segment{ride, run}}, wantErr: true},
+		{name: "single segment", session: Session{Name: "Ride", Segments: []Segment{ride}}, wantErr: true},
+		{name: "unknown type", session: Session{Name: "Brick", Segments: []Segment{ride, {Type: "yoga", ID: "y"}}}, wantErr: true},
+		{name: "repeated segment", session: Session{Name: "Brick", Segments: []Segment{ride, ride}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.session.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	start := time.Date(2024, 3, 9, 7, 0, 0, 0, time.UTC)
+
+	t.Run("combines a brick in start order with its transition", func(t *testing.T) {
+		// Arrange
+		ride := cardio.Activity{ID: "ride-1", Sport: "ride", StartTime: start, DurationSeconds: 3600, DistanceMeters: 30000}
+		run := cardio.Activity{ID: "run-1", Sport: "run", StartTime: start.Add(3690 * time.Second), DurationSeconds: 1800, DistanceMeters: 6000}
+		parts := []Part{
+			{Segment: Segment{Type: SegmentActivity, ID: "run-1", Label: "Run off the bike"}, Activity: &run},
+			{Segment: Segment{Type: SegmentActivity, ID: "ride-1"}, Activity: &ride},
+		}
+
+		// Act
+		s := Summarize(parts, nil)
+
+		// Assert
+		if s.ElapsedSeconds != 5490 || s.ActiveSeconds != 5400 || s.TransitionSeconds != 90 || s.DistanceMeters != 36000 {
+			t.Errorf("unexpected totals: %+v", s)
+		}
+		if len(s.Segments) != 2 || s.Segments[0].ID != "ride-1" || s.Segments[1].TransitionSeconds != 90 || s.Segments[1].Label != "Run off the bike" {
+			t.Errorf("unexpected segments: %+v", s.Segments)
+		}
+		if len(s.Sports) != 2 || s.Sports[0] != "ride" || s.Sports[1] != "run" || s.Load != 180 || !s.StartTime.Equal(start) {
+			t.Errorf("unexpected sports, load or start: %+v", s)
+		}
+	})
+
+	t.Run("scores lifting segments and reports missing ones last", func(t *testing.T) {
+		// Arrange
+		completed := start.Add(45 * time.Minute)
+		lifting := workout.Workout{ID: "w-1", Status: workout.StatusCompleted, StartedAt: start, CompletedAt: &completed, Exercises: []workout.Exercise{
+			{Name: "Squat", Sets: []workout.Set{{Reps: 5, Weight: 100}, {Reps: 5, Weight: 100}}},
+		}}
+		finisher := cardio.Activity{ID: "row-1", Sport: "row", StartTime: completed.Add(2 * time.Minute), DurationSeconds: 600}
+		parts := []Part{
+			{Segment: Segment{Type: SegmentWorkout, ID: "w-1"}, Workout: &lifting},
+			{Segment: Segment{Type: SegmentActivity, ID: "gone"}},
+			{Segment: Segment{Type: SegmentActivity, ID: "row-1"}, Activity: &finisher},
+		}
+
+		// Act
+		s := Summarize(parts, nil)
+
+		// Assert
+		if len(s.Segments) != 3 || s.Segments[0].Sport != SportStrength || s.Segments[0].Sets != 2 || s.Segments[0].Volume != 1000 {
+			t.Fatalf("unexpected lifting segment: %+v", s.Segments)
+		}
+		if s.Segments[1].ID != "row-1" || s.Segments[1].TransitionSeconds != 120 || !s.Segments[2].Missing {
+			t.Errorf("unexpected segments: %+v", s.Segments)
+		}
+		if s.ActiveSeconds != 3300 || s.ElapsedSeconds != 3420 {
+			t.Errorf("unexpected totals: %+v", s)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package nutrition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"athlete-forge/store"
+)
+
+const foodSK = "FOOD"
+
+// Cache lifetimes; misses expire sooner so newly added products appear quickly
+const (
+	FoundTTL    = 30 * 24 * time.Hour
+	NotFoundTTL = 24 * time.Hour
+)
+
+// cacheEntry is a cached lookup result, including misses
+type cacheEntry struct {
+	Food      *Food     `json:"food,omitempty"`
+	NotFound  bool      `json:"notFound,omitempty"`
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+func (e cacheEntry) fresh(now time.Time) bool {
+	ttl := FoundTTL
+	if e.NotFound {
+		ttl = NotFoundTTL
+	}
+	return now.Sub(e.FetchedAt) < ttl
+}
+
+func (e cacheEntry) result() (*Food, error) {
+	if e.NotFound || e.Food == nil {
+		return nil, ErrFoodNotFound
+	}
+	return e.Food, nil
+}
+
+// Catalog looks foods up through a read-through cache so repeated scans of the
+// same product do not count against the external database's rate limits
+type Catalog struct {
+	store  store.Store
+	source Source
+	now    func() time.Time
+}
+
+// NewCatalog creates a Catalog caching source lookups in s
+func NewCatalog(s store.Store, source Source) *Catalog {
+	return &Catalog{store: s, source: source, now: time.Now}
+}
+
+// foodPK returns the partition key for a cached barcode; foods are shared by all users
+func foodPK(barcode string) string {
+	return "FOOD#" + barcode
+}
+
+// Lookup returns the food for barcode from the cache when fresh, otherwise from the
+// source. A stale cached food is returned if the source is unavailable
+func (c *Catalog) Lookup(ctx context.Context, barcode string) (*Food, error) {
+	if err := ValidateBarcode(barcode); err != nil {
+		return nil, err
+	}
+
+	var cached cacheEntry
+	err := c.store.Get(ctx, foodPK(barcode), foodSK, &cached)
+	hasCached := err == nil
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("failed to read food cache: %w", err)
+	}
+	if hasCached && cached.fresh(c.now()) {
+		return cached.result()
+	}
+
+	food, err := c.source.Lookup(ctx, barcode)
+	switch {
+	case errors.Is(err, ErrFoodNotFound):
+		entry := cacheEntry{NotFound: true, FetchedAt: c.now().UTC()}
+		if err := c.store.Put(ctx, foodPK(barcode), foodSK, entry); err != nil {
+			return nil, fmt.Errorf("failed to cache food: %w", err)
+		}
+		return nil, ErrFoodNotFound
+	case err != nil:
+		if hasCached && cached.Food != nil {
+			return cached.Food, nil
+		}
+		return nil, err
+	}
+
+	entry := cacheEntry{Food: food, FetchedAt: c.now().UTC()}
+	if err := c.store.Put(ctx, foodPK(barcode), foodSK, entry); err != nil {
+		return nil, fmt.Errorf("failed to cache food: %w", err)
+	}
+	return food, nil
+}
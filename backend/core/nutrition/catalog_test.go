@@ -0,0 +1,101 @@
+package nutrition
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+)
+
+// fakeSource returns a fixed result and counts lookups
+type fakeSource struct {
+	food  *Food
+	err   error
+	calls int
+}
+
+func (f *fakeSource) Lookup(ctx context.Context, barcode string) (*Food, error) {
+	f.calls++
+	return f.food, f.err
+}
+
+func TestCatalog_Lookup(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	newCatalog := func(source Source) *Catalog {
+		c := NewCatalog(store.NewMemoryStore(), source)
+		c.now = func() time.Time { return now }
+		return c
+	}
+
+	t.Run("serves repeat lookups from the cache", func(t *testing.T) {
+		// Arrange
+		source := &fakeSource{food: &Food{Barcode: "12345678", Name: "Rice"}}
+		catalog := newCatalog(source)
+
+		// Act
+		catalog.Lookup(ctx, "12345678")
+		food, err := catalog.Lookup(ctx, "12345678")
+
+		// Assert
+		if err != nil || food.Name != "Rice" {
+			t.Fatalf("unexpected result: %+v %v", food, err)
+		}
+		if source.calls != 1 {
+			t.Errorf("expected 1 source call, got %d", source.calls)
+		}
+	})
+
+	t.Run("caches misses for a shorter period", func(t *testing.T) {
+		// Arrange
+		source := &fakeSource{err: ErrFoodNotFound}
+		catalog := newCatalog(source)
+
+		// Act
+		_, first := catalog.Lookup(ctx, "12345678")
+		_, second := catalog.Lookup(ctx, "12345678")
+		now = now.Add(NotFoundTTL)
+		catalog.Lookup(ctx, "12345678")
+
+		// Assert
+		if !errors.Is(first, ErrFoodNotFound) || !errors.Is(second, ErrFoodNotFound) {
+			t.Errorf("expected ErrFoodNotFound, got %v and %v", first, second)
+		}
+		if source.calls != 2 {
+			t.Errorf("expected 2 source calls, got %d", source.calls)
+		}
+	})
+
+	t.Run("falls back to stale entries when the source fails", func(t *testing.T) {
+		// Arrange
+		source := &fakeSource{food: &Food{Barcode: "12345678", Name: "Rice"}}
+		catalog := newCatalog(source)
+		catalog.Lookup(ctx, "12345678")
+		now = now.Add(FoundTTL + time.Hour)
+		source.food, source.err = nil, errors.New("rate limited")
+
+		// Act
+		food, err := catalog.Lookup(ctx, "12345678")
+
+		// Assert
+		if err != nil || food.Name != "Rice" {
+			t.Errorf("expected stale food, got %+v %v", food, err)
+		}
+	})
+
+	t.Run("rejects invalid barcodes without a lookup", func(t *testing.T) {
+		// Arrange
+		source := &fakeSource{}
+
+		// Act
+		_, err := newCatalog(source).Lookup(ctx, "abc")
+
+		// Assert
+		if !errors.Is(err, ErrInvalidBarcode) || source.calls != 0 {
+			t.Errorf("expected ErrInvalidBarcode without lookup, got %v after %d calls", err, source.calls)
+		}
+	})
+}
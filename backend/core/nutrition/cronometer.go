@@ -0,0 +1,30 @@
+package nutrition
+
+// cronometer reads the Servings export, which has a row for each food eaten and
+// names its meal in Group, and the Daily Nutrition export of daily totals
+var cronometer = &Importer{
+	Source: SourceCronometer,
+	Layouts: []Layout{
+		{
+			Date:          "Day",
+			Meal:          "Group",
+			Calories:      "Energy (kcal)",
+			Protein:       "Protein (g)",
+			Carbohydrates: "Carbs (g)",
+			Fat:           "Fat (g)",
+			Fiber:         "Fiber (g)",
+			Sugar:         "Sugars (g)",
+			SodiumMG:      "Sodium (mg)",
+		},
+		{
+			Date:          "Date",
+			Calories:      "Energy (kcal)",
+			Protein:       "Protein (g)",
+			Carbohydrates: "Carbs (g)",
+			Fat:           "Fat (g)",
+			Fiber:         "Fiber (g)",
+			Sugar:         "Sugars (g)",
+			SodiumMG:      "Sodium (mg)",
+		},
+	},
+}
@@ -0,0 +1,139 @@
+package nutrition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"athlete-forge/store"
+)
+
+const (
+	diarySKPrefix = "NUTRITION#"
+
+	// DateLayout is the format of diary dates
+	DateLayout = "2006-01-02"
+)
+
+// Meal is what was eaten at one meal of a diary day
+type Meal struct {
+	Name      string    `json:"name"`
+	Nutrients Nutrients `json:"nutrients"`
+}
+
+// Day is what a user ate on one day, as imported from a food tracking app.
+// Meals are empty when the app exports only daily totals
+type Day struct {
+	UserID     string    `json:"userId"`
+	Date       string    `json:"date"`
+	Source     string    `json:"source"`
+	Meals      []Meal    `json:"meals,omitempty"`
+	Totals     Nutrients `json:"totals"`
+	ImportedAt time.Time `json:"importedAt"`
+}
+
+// sameFood reports whether d and o record the same meals and totals,
+// whichever app they came from
+func (d *Day) sameFood(o *Day) bool {
+	if d.Totals != o.Totals || len(d.Meals) != len(o.Meals) {
+		return false
+	}
+	for i := range d.Meals {
+		if d.Meals[i] != o.Meals[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Conflict is a day in an import that differs from the day already stored
+type Conflict struct {
+	Date           string `json:"date"`
+	ExistingSource string `json:"existingSource"`
+}
+
+// ImportResult reports what an import did with each day in the export. Days
+// already stored with the same food are unchanged; days stored with different
+// food are conflicts unless the import replaces them
+type ImportResult struct {
+	Source    string     `json:"source"`
+	Imported  []string   `json:"imported"`
+	Replaced  []string   `json:"replaced"`
+	Unchanged []string   `json:"unchanged"`
+	Conflicts []Conflict `json:"conflicts"`
+}
+
+// Diary loads and saves users' nutrition days
+type Diary struct {
+	store store.Store
+}
+
+// NewDiary creates a Diary backed by s
+func NewDiary(s store.Store) *Diary {
+	return &Diary{store: s}
+}
+
+// Get returns userID's day for date
+func (d *Diary) Get(ctx context.Context, userID, date string) (*Day, error) {
+	var day Day
+	if err := d.store.Get(ctx, store.UserPK(userID), diarySKPrefix+date, &day); err != nil {
+		return nil, err
+	}
+	return &day, nil
+}
+
+// List returns userID's days between from and to inclusive, oldest first;
+// empty bounds are open-ended
+func (d *Diary) List(ctx context.Context, userID, from, to string) ([]Day, error) {
+	items, err := d.store.Query(ctx, store.UserPK(userID), diarySKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nutrition days: %w", err)
+	}
+
+	days := []Day{}
+	for _, item := range items {
+		var day Day
+		if err := item.Decode(&day); err != nil {
+			return nil, err
+		}
+		if (from != "" && day.Date < from) || (to != "" && day.Date > to) {
+			continue
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// Import saves parsed days for userID, detecting the days already stored.
+// Stored days with different food are only overwritten when replace is set
+func (d *Diary) Import(ctx context.Context, userID, source string, days []Day, replace bool, now time.Time) (*ImportResult, error) {
+	result := &ImportResult{Source: source, Imported: []string{}, Replaced: []string{}, Unchanged: []string{}, Conflicts: []Conflict{}}
+	for i := range days {
+		day := days[i]
+		existing, err := d.Get(ctx, userID, day.Date)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return nil, err
+		}
+		switch {
+		case existing == nil:
+			result.Imported = append(result.Imported, day.Date)
+		case existing.sameFood(&day):
+			result.Unchanged = append(result.Unchanged, day.Date)
+			continue
+		case replace:
+			result.Replaced = append(result.Replaced, day.Date)
+		default:
+			result.Conflicts = append(result.Conflicts, Conflict{Date: day.Date, ExistingSource: existing.Source})
+			continue
+		}
+
+		day.UserID = userID
+		day.Source = source
+		day.ImportedAt = now
+		if err := d.store.Put(ctx, store.UserPK(userID), diarySKPrefix+day.Date, &day); err != nil {
+			return nil, fmt.Errorf("failed to save nutrition day: %w", err)
+		}
+	}
+	return result, nil
+}
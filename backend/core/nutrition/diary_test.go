@@ -0,0 +1,59 @@
+package nutrition
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+)
+
+func TestDiary_Import(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+	day := func(date string, calories float64) Day {
+		return Day{Date: date, Totals: Nutrients{Calories: calories}}
+	}
+
+	t.Run("detects days already imported", func(t *testing.T) {
+		// Arrange
+		diary := NewDiary(store.NewMemoryStore())
+		diary.Import(ctx, "user-1", SourceMyFitnessPal, []Day{day("2024-03-01", 2000), day("2024-03-02", 2200)}, false, now)
+
+		// Act
+		result, err := diary.Import(ctx, "user-1", SourceCronometer, []Day{day("2024-03-01", 2000), day("2024-03-02", 1800), day("2024-03-03", 2500)}, false, now)
+		kept, _ := diary.Get(ctx, "user-1", "2024-03-02")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(result.Imported) != 1 || result.Imported[0] != "2024-03-03" {
+			t.Errorf("unexpected imported days: %v", result.Imported)
+		}
+		if len(result.Unchanged) != 1 || result.Unchanged[0] != "2024-03-01" {
+			t.Errorf("unexpected unchanged days: %v", result.Unchanged)
+		}
+		if len(result.Conflicts) != 1 || result.Conflicts[0] != (Conflict{Date: "2024-03-02", ExistingSource: SourceMyFitnessPal}) {
+			t.Errorf("unexpected conflicts: %v", result.Conflicts)
+		}
+		if kept.Totals.Calories != 2200 {
+			t.Errorf("expected the conflicting day to be kept, got %+v", kept)
+		}
+	})
+
+	t.Run("replaces conflicting days when asked", func(t *testing.T) {
+		// Arrange
+		diary := NewDiary(store.NewMemoryStore())
+		diary.Import(ctx, "user-1", SourceMyFitnessPal, []Day{day("2024-03-01", 2000)}, false, now)
+
+		// Act
+		result, _ := diary.Import(ctx, "user-1", SourceCronometer, []Day{day("2024-03-01", 1900)}, true, now)
+		replaced, _ := diary.Get(ctx, "user-1", "2024-03-01")
+
+		// Assert
+		if len(result.Replaced) != 1 || replaced.Source != SourceCronometer || replaced.Totals.Calories != 1900 {
+			t.Errorf("unexpected replacement: %+v, %+v", result, replaced)
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package nutrition
+
+import (
+	"context"
+	"errors"
+	"math"
+	"regexp"
+)
+
+// ErrFoodNotFound is returned when no food exists for a barcode
+var ErrFoodNotFound = errors.New("food not found")
+
+// ErrInvalidBarcode is returned for barcodes that are not 8-14 digits (EAN-8, UPC-A, EAN-13, GTIN-14)
+var ErrInvalidBarcode = errors.New("barcode must be 8 to 14 digits")
+
+var barcodePattern = regexp.MustCompile(`^[0-9]{8,14}$`)
+
+// Nutrients are nutrient amounts, per 100 g or 100 ml for foods and eaten for
+// diary days; energy is in kcal and the rest in grams
+type Nutrients struct {
+	Calories      float64 `json:"calories"`
+	Protein       float64 `json:"protein"`
+	Carbohydrates float64 `json:"carbohydrates"`
+	Fat           float64 `json:"fat"`
+	Fiber         float64 `json:"fiber,omitempty"`
+	Sugar         float64 `json:"sugar,omitempty"`
+	Salt          float64 `json:"salt,omitempty"`
+}
+
+// Food is a packaged food identified by barcode
+type Food struct {
+	Barcode     string    `json:"barcode"`
+	Name        string    `json:"name"`
+	Brand       string    `json:"brand,omitempty"`
+	ServingSize string    `json:"servingSize,omitempty"`
+	Per100g     Nutrients `json:"per100g"`
+	Source      string    `json:"source"`
+}
+
+// Source looks foods up in an external database
+type Source interface {
+	// Lookup returns the food for barcode, or ErrFoodNotFound when the database has no entry
+	Lookup(ctx context.Context, barcode string) (*Food, error)
+}
+
+// ValidateBarcode checks barcode is a plausible retail barcode
+func ValidateBarcode(barcode string) error {
+	if !barcodePattern.MatchString(barcode) {
+		return ErrInvalidBarcode
+	}
+	return nil
+}
+
+// Add adds o's amounts to n
+func (n *Nutrients) Add(o Nutrients) {
+	n.Calories += o.Calories
+	n.Protein += o.Protein
+	n.Carbohydrates += o.Carbohydrates
+	n.Fat += o.Fat
+	n.Fiber += o.Fiber
+	n.Sugar += o.Sugar
+	n.Salt += o.Salt
+}
+
+// Rounded returns n with each amount rounded to one decimal place
+func (n Nutrients) Rounded() Nutrients {
+	round := func(v float64) float64 { return math.Round(v*10) / 10 }
+	return Nutrients{
+		Calories:      round(n.Calories),
+		Protein:       round(n.Protein),
+		Carbohydrates: round(n.Carbohydrates),
+		Fat:           round(n.Fat),
+		Fiber:         round(n.Fiber),
+		Sugar:         round(n.Sugar),
+		Salt:          round(n.Salt),
+	}
+}
@@ -0,0 +1,266 @@
+package nutrition
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Food tracking apps whose CSV exports can be imported
+const (
+	SourceMyFitnessPal = "myfitnesspal"
+	SourceCronometer   = "cronometer"
+)
+
+// saltPerSodium converts sodium to salt by mass
+const saltPerSodium = 2.5
+
+// ErrUnrecognizedExport is returned for CSV files whose header matches none of
+// the source's export layouts
+var ErrUnrecognizedExport = errors.New("file is not a recognized export")
+
+// dateLayouts are the date formats food tracking apps export, ISO first, then
+// the US format MyFitnessPal uses for US accounts
+var dateLayouts = []string{DateLayout, "1/2/2006", "01/02/2006"}
+
+// Layout maps the columns of one CSV export to diary fields by header, matched
+// case-insensitively. Meal is empty for exports of daily totals, and nutrients
+// with no header are left zero
+type Layout struct {
+	Date          string
+	Meal          string
+	Calories      string
+	Protein       string
+	Carbohydrates string
+	Fat           string
+	Fiber         string
+	Sugar         string
+	SodiumMG      string
+}
+
+// Importer reads the CSV exports of one food tracking app into diary days
+type Importer struct {
+	Source  string
+	Layouts []Layout
+}
+
+// Importers lists the importer for each source
+var Importers = map[string]*Importer{
+	SourceMyFitnessPal: myFitnessPal,
+	SourceCronometer:   cronometer,
+}
+
+// columns are the indexes of the mapped fields in an export, -1 when absent
+type columns struct {
+	date, meal                                                  int
+	calories, protein, carbohydrates, fat, fiber, sugar, sodium int
+}
+
+// export is a read CSV file with the mapping its rows are read with
+type export struct {
+	header     []string
+	records    [][]string
+	lines      []int
+	mapping    Mapping
+	recognized bool
+}
+
+// Parse reads an export into days, oldest first, totalling the rows of each day
+// by meal. Errors name the line of the row that failed
+func (im *Importer) Parse(r io.Reader) ([]Day, error) {
+	return im.ParseMapped(r, nil)
+}
+
+// ParseMapped reads an export like Parse, with overrides replacing the columns
+// read into some fields. An export none of the source's layouts recognize is
+// read with the proposed mapping, but only when overrides confirm it
+func (im *Importer) ParseMapped(r io.Reader, overrides Mapping) ([]Day, error) {
+	e, err := im.read(r, overrides)
+	if err != nil {
+		return nil, err
+	}
+	if !e.recognized && len(overrides) == 0 {
+		return nil, ErrUnrecognizedExport
+	}
+	return e.days()
+}
+
+// read reads an export's rows and maps its columns with the first layout it
+// matches, or the mapping guessed from its header, and overrides
+func (im *Importer) read(r io.Reader, overrides Mapping) (*export, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	if err := overrides.validate(header); err != nil {
+		return nil, err
+	}
+
+	e := &export{header: header}
+	for _, l := range im.Layouts {
+		if m := l.mapping().present(header); m.complete() {
+			e.mapping, e.recognized = m, true
+			break
+		}
+	}
+	if !e.recognized {
+		e.mapping = guess(header)
+	}
+	for field, column := range overrides {
+		if column == "" {
+			delete(e.mapping, field)
+			continue
+		}
+		e.mapping[field] = column
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		e.records = append(e.records, record)
+		e.lines = append(e.lines, line)
+	}
+	return e, nil
+}
+
+// days reads the export's rows into days, oldest first
+func (e *export) days() ([]Day, error) {
+	cols, err := e.mapping.columns(e.header)
+	if err != nil {
+		return nil, err
+	}
+	days := map[string]*Day{}
+	for i, record := range e.records {
+		if err := addRow(days, record, cols); err != nil {
+			return nil, fmt.Errorf("line %d: %w", e.lines[i], err)
+		}
+	}
+
+	parsed := make([]Day, 0, len(days))
+	for _, day := range days {
+		day.Totals = day.Totals.Rounded()
+		for i := range day.Meals {
+			day.Meals[i].Nutrients = day.Meals[i].Nutrients.Rounded()
+		}
+		parsed = append(parsed, *day)
+	}
+	sort.Slice(parsed, func(i, j int) bool { return parsed[i].Date < parsed[j].Date })
+	return parsed, nil
+}
+
+// addRow adds a row's nutrients to its day and meal
+func addRow(days map[string]*Day, record []string, cols columns) error {
+	date, err := parseDate(field(record, cols.date))
+	if err != nil {
+		return err
+	}
+
+	var n Nutrients
+	amounts := []struct {
+		name   string
+		column int
+		target *float64
+	}{
+		{"calories", cols.calories, &n.Calories},
+		{"protein", cols.protein, &n.Protein},
+		{"carbohydrates", cols.carbohydrates, &n.Carbohydrates},
+		{"fat", cols.fat, &n.Fat},
+		{"fiber", cols.fiber, &n.Fiber},
+		{"sugar", cols.sugar, &n.Sugar},
+		{"sodium", cols.sodium, &n.Salt},
+	}
+	for _, a := range amounts {
+		value, err := parseAmount(field(record, a.column))
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", a.name, err)
+		}
+		*a.target = value
+	}
+	n.Salt = n.Salt / 1000 * saltPerSodium
+
+	day, ok := days[date]
+	if !ok {
+		day = &Day{Date: date}
+		days[date] = day
+	}
+	day.Totals.Add(n)
+	if cols.meal < 0 {
+		return nil
+	}
+	name := strings.TrimSpace(field(record, cols.meal))
+	if name == "" {
+		name = "Uncategorized"
+	}
+	for i := range day.Meals {
+		if day.Meals[i].Name == name {
+			day.Meals[i].Nutrients.Add(n)
+			return nil
+		}
+	}
+	day.Meals = append(day.Meals, Meal{Name: name, Nutrients: n})
+	return nil
+}
+
+// field returns record's value at column, or "" when the column is absent
+func field(record []string, column int) string {
+	if column < 0 || column >= len(record) {
+		return ""
+	}
+	return record[column]
+}
+
+// parseDate parses an exported date into DateLayout
+func parseDate(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Format(DateLayout), nil
+		}
+	}
+	return "", fmt.Errorf("invalid date %q", value)
+}
+
+// parseAmount parses an exported amount; empty cells are zero and thousands
+// separators are ignored
+func parseAmount(value string) (float64, error) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), ",", "")
+	if value == "" {
+		return 0, nil
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return 0, fmt.Errorf("%q is not a number", value)
+	}
+	if amount < 0 {
+		return 0, fmt.Errorf("%q is negative", value)
+	}
+	return amount, nil
+}
@@ -0,0 +1,170 @@
+package nutrition
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const myFitnessPalExport = `Date,Meal,Calories,Fat (g),Saturated Fat,Sodium (mg),Carbohydrates (g),Fiber,Sugar,Protein (g),Note
+2024-03-02,Breakfast,420,12,3,400,55,6,10,25,
+2024-03-01,Breakfast,350.5,10,2,200,40,5,8,20,
+2024-03-01,Lunch,"1,050",40,12,1200,100,8,12,60.25,
+2024-03-01,Breakfast,100,2,0,0,15,1,5,3,
+`
+
+func TestImporter_Parse(t *testing.T) {
+	t.Run("totals MyFitnessPal meals by day", func(t *testing.T) {
+		// Act
+		days, err := Importers[SourceMyFitnessPal].Parse(strings.NewReader(myFitnessPalExport))
+
+		// Assert
+		if err != nil || len(days) != 2 {
+			t.Fatalf("expected 2 days, got %d (%v)", len(days), err)
+		}
+		day := days[0]
+		if day.Date != "2024-03-01" || len(day.Meals) != 2 || day.Meals[0].Name != "Breakfast" || day.Meals[0].Nutrients.Calories != 450.5 {
+			t.Errorf("unexpected day: %+v", day)
+		}
+		if day.Totals.Calories != 1500.5 || day.Totals.Protein != 83.3 || day.Totals.Salt != 3.5 {
+			t.Errorf("unexpected totals: %+v", day.Totals)
+		}
+	})
+
+	t.Run("reads Cronometer servings and daily totals", func(t *testing.T) {
+		// Arrange
+		servings := "\ufeffDay,Time,Group,Food Name,Amount,Energy (kcal),Carbs (g),Fat (g),Protein (g),Sugars (g)\n" +
+			"2024-03-01,08:00,Breakfast,Oats,50 g,190,33,3.5,6.5,0.5\n" +
+			"2024-03-01,08:00,Breakfast,Milk,200 ml,100,10,4,7,10\n"
+		daily := "Date,Energy (kcal),Carbs (g),Fat (g),Protein (g),Completed\n2024-03-01,2100,250,70,140,true\n"
+
+		// Act
+		fromServings, servingsErr := Importers[SourceCronometer].Parse(strings.NewReader(servings))
+		fromDaily, dailyErr := Importers[SourceCronometer].Parse(strings.NewReader(daily))
+
+		// Assert
+		if servingsErr != nil || len(fromServings) != 1 || len(fromServings[0].Meals) != 1 || fromServings[0].Totals.Calories != 290 {
+			t.Errorf("unexpected servings import: %+v (%v)", fromServings, servingsErr)
+		}
+		if dailyErr != nil || len(fromDaily) != 1 || fromDaily[0].Meals != nil || fromDaily[0].Totals.Protein != 140 {
+			t.Errorf("unexpected daily import: %+v (%v)", fromDaily, dailyErr)
+		}
+	})
+
+	t.Run("rejects another app's export", func(t *testing.T) {
+		// Act
+		_, err := Importers[SourceCronometer].Parse(strings.NewReader(myFitnessPalExport))
+
+		// Assert
+		if !errors.Is(err, ErrUnrecognizedExport) {
+			t.Errorf("expected ErrUnrecognizedExport, got %v", err)
+		}
+	})
+
+	t.Run("names the line of an invalid row", func(t *testing.T) {
+		// Arrange
+		export := "Date,Meal,Calories\n2024-03-01,Lunch,500\n2024-03-02,Dinner,lots\n"
+
+		// Act
+		_, err := Importers[SourceMyFitnessPal].Parse(strings.NewReader(export))
+
+		// Assert
+		if err == nil || !strings.HasPrefix(err.Error(), "line 3:") {
+			t.Errorf("expected an error on line 3, got %v", err)
+		}
+	})
+
+	t.Run("rejects amounts that are not finite", func(t *testing.T) {
+		for _, amount := range []string{"NaN", "Inf", "+Inf", "-inf"} {
+			// Arrange
+			export := "Date,Meal,Calories\n2024-03-01,Lunch," + amount + "\n"
+
+			// Act
+			_, err := Importers[SourceMyFitnessPal].Parse(strings.NewReader(export))
+
+			// Assert
+			if err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+				t.Errorf("expected %s rejected on line 2, got %v", amount, err)
+			}
+		}
+	})
+}
+
+func TestImporter_Preview(t *testing.T) {
+	t.Run("proposes the recognized layout's columns", func(t *testing.T) {
+		// Act
+		preview, err := Importers[SourceMyFitnessPal].Preview(strings.NewReader(myFitnessPalExport), nil)
+
+		// Assert
+		if err != nil || !preview.Recognized || preview.Rows != 4 || preview.Days != 2 || len(preview.Sample) != 2 {
+			t.Fatalf("unexpected preview: %+v (%v)", preview, err)
+		}
+		if preview.Mapping[FieldCalories] != "Calories" || preview.Mapping[FieldSodium] != "Sodium (mg)" {
+			t.Errorf("unexpected mapping: %v", preview.Mapping)
+		}
+		if strings.Join(preview.Unmapped, ",") != "Saturated Fat,Note" {
+			t.Errorf("unexpected unmapped columns: %v", preview.Unmapped)
+		}
+	})
+
+	t.Run("guesses the columns of an unrecognized export", func(t *testing.T) {
+		// Arrange
+		export := "Day,Saturated Fat,Fat,Kcal,Carbs,Prot\n2024-03-01,3,20,900,100,40\n"
+
+		// Act
+		preview, err := Importers[SourceCronometer].Preview(strings.NewReader(export), nil)
+
+		// Assert
+		if err != nil || preview.Recognized || preview.Problem != "" {
+			t.Fatalf("unexpected preview: %+v (%v)", preview, err)
+		}
+		m := preview.Mapping
+		if m[FieldDate] != "Day" || m[FieldCalories] != "Kcal" || m[FieldFat] != "Fat" || m[FieldCarbohydrates] != "Carbs" || m[FieldProtein] != "" {
+			t.Errorf("unexpected mapping: %v", m)
+		}
+	})
+
+	t.Run("applies overrides and reports an incomplete mapping", func(t *testing.T) {
+		// Act
+		overridden, err := Importers[SourceMyFitnessPal].Preview(strings.NewReader(myFitnessPalExport), Mapping{FieldMeal: "", FieldFat: "Saturated Fat"})
+		incomplete, _ := Importers[SourceMyFitnessPal].Preview(strings.NewReader(myFitnessPalExport), Mapping{FieldDate: ""})
+
+		// Assert
+		if err != nil || overridden.Sample[0].Meals != nil || overridden.Sample[0].Totals.Fat != 14 {
+			t.Errorf("unexpected overridden preview: %+v (%v)", overridden, err)
+		}
+		if incomplete.Problem != ErrUnmappedColumns.Error() {
+			t.Errorf("expected an unmapped columns problem, got %q", incomplete.Problem)
+		}
+	})
+
+	t.Run("rejects overrides naming unknown fields or columns", func(t *testing.T) {
+		// Act
+		_, unknownField := Importers[SourceMyFitnessPal].Preview(strings.NewReader(myFitnessPalExport), Mapping{"alcohol": "Note"})
+		_, unknownColumn := Importers[SourceMyFitnessPal].Preview(strings.NewReader(myFitnessPalExport), Mapping{FieldFiber: "Fibre"})
+
+		// Assert
+		if unknownField == nil || unknownColumn == nil {
+			t.Errorf("expected errors, got %v and %v", unknownField, unknownColumn)
+		}
+	})
+}
+
+func TestImporter_ParseMapped(t *testing.T) {
+	t.Run("reads an unrecognized export only with overrides", func(t *testing.T) {
+		// Arrange
+		export := "When,Energy,Protein\n2024-03-01,1800,120\n"
+
+		// Act
+		_, unconfirmed := Importers[SourceMyFitnessPal].ParseMapped(strings.NewReader(export), nil)
+		days, err := Importers[SourceMyFitnessPal].ParseMapped(strings.NewReader(export), Mapping{FieldDate: "When"})
+
+		// Assert
+		if !errors.Is(unconfirmed, ErrUnrecognizedExport) {
+			t.Errorf("expected ErrUnrecognizedExport, got %v", unconfirmed)
+		}
+		if err != nil || len(days) != 1 || days[0].Totals.Calories != 1800 || days[0].Totals.Protein != 120 {
+			t.Errorf("unexpected days: %+v (%v)", days, err)
+		}
+	})
+}
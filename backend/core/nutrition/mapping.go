@@ -0,0 +1,220 @@
+package nutrition
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Diary fields an export's columns are read into
+const (
+	FieldDate          = "date"
+	FieldMeal          = "meal"
+	FieldCalories      = "calories"
+	FieldProtein       = "protein"
+	FieldCarbohydrates = "carbohydrates"
+	FieldFat           = "fat"
+	FieldFiber         = "fiber"
+	FieldSugar         = "sugar"
+	FieldSodium        = "sodium"
+)
+
+// Fields lists the diary fields in the order columns are proposed for them
+var Fields = []string{
+	FieldDate, FieldMeal, FieldCalories, FieldProtein, FieldCarbohydrates,
+	FieldFat, FieldFiber, FieldSugar, FieldSodium,
+}
+
+// ErrUnmappedColumns is returned when no column is mapped to the date or calories
+var ErrUnmappedColumns = errors.New("the date and calories columns must be mapped")
+
+// fieldKeywords are the words a header is proposed for a field by, for exports
+// no layout recognizes
+var fieldKeywords = map[string][]string{
+	FieldDate:          {"date", "day"},
+	FieldMeal:          {"meal", "group"},
+	FieldCalories:      {"calorie", "energy", "kcal"},
+	FieldProtein:       {"protein"},
+	FieldCarbohydrates: {"carb"},
+	FieldFat:           {"fat"},
+	FieldFiber:         {"fiber", "fibre"},
+	FieldSugar:         {"sugar"},
+	FieldSodium:        {"sodium"},
+}
+
+// Mapping names the column read into each diary field by its header. Fields
+// without a column are left out, and nutrients without one are zero
+type Mapping map[string]string
+
+// mapping returns the layout's columns as a Mapping
+func (l Layout) mapping() Mapping {
+	m := Mapping{}
+	for field, column := range map[string]string{
+		FieldDate: l.Date, FieldMeal: l.Meal, FieldCalories: l.Calories, FieldProtein: l.Protein,
+		FieldCarbohydrates: l.Carbohydrates, FieldFat: l.Fat, FieldFiber: l.Fiber, FieldSugar: l.Sugar,
+		FieldSodium: l.SodiumMG,
+	} {
+		if column != "" {
+			m[field] = column
+		}
+	}
+	return m
+}
+
+// present returns the part of m whose columns are in header, using the
+// header's spelling of them
+func (m Mapping) present(header []string) Mapping {
+	kept := Mapping{}
+	for field, column := range m {
+		if i := index(header, column); i >= 0 {
+			kept[field] = header[i]
+		}
+	}
+	return kept
+}
+
+// complete reports whether m maps the date and calories
+func (m Mapping) complete() bool {
+	return m[FieldDate] != "" && m[FieldCalories] != ""
+}
+
+// validate checks that m only maps known fields, to columns in header or to
+// "" to leave them unmapped
+func (m Mapping) validate(header []string) error {
+	for field, column := range m {
+		if _, ok := fieldKeywords[field]; !ok {
+			return fmt.Errorf("unknown field %q; fields are %s", field, strings.Join(Fields, ", "))
+		}
+		if column != "" && index(header, column) < 0 {
+			return fmt.Errorf("%s: the file has no column %q", field, column)
+		}
+	}
+	return nil
+}
+
+// columns returns the indexes of m's columns in header
+func (m Mapping) columns(header []string) (columns, error) {
+	if !m.complete() {
+		return columns{}, ErrUnmappedColumns
+	}
+	at := func(field string) int {
+		if m[field] == "" {
+			return -1
+		}
+		return index(header, m[field])
+	}
+	return columns{
+		date: at(FieldDate), meal: at(FieldMeal),
+		calories: at(FieldCalories), protein: at(FieldProtein), carbohydrates: at(FieldCarbohydrates),
+		fat: at(FieldFat), fiber: at(FieldFiber), sugar: at(FieldSugar), sodium: at(FieldSodium),
+	}, nil
+}
+
+// unmapped returns the columns of header m does not read, in file order
+func (m Mapping) unmapped(header []string) []string {
+	used := map[string]bool{}
+	for _, column := range m {
+		used[strings.ToLower(column)] = true
+	}
+	unmapped := []string{}
+	for _, column := range header {
+		if !used[strings.ToLower(column)] {
+			unmapped = append(unmapped, column)
+		}
+	}
+	return unmapped
+}
+
+// guess proposes a column for each field from the words in header, taking the
+// shortest header containing one of the field's keywords so that "Fat (g)"
+// wins over "Saturated Fat". Each column is proposed for one field at most
+func guess(header []string) Mapping {
+	m := Mapping{}
+	taken := map[int]bool{}
+	for _, field := range Fields {
+		best := -1
+		for i, column := range header {
+			if taken[i] || !containsAny(strings.ToLower(column), fieldKeywords[field]) {
+				continue
+			}
+			if best < 0 || len(column) < len(header[best]) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			m[field] = header[best]
+			taken[best] = true
+		}
+	}
+	return m
+}
+
+// containsAny reports whether s contains one of words
+func containsAny(s string, words []string) bool {
+	for _, word := range words {
+		if strings.Contains(s, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// index returns the position of column in header, matched case-insensitively,
+// or -1
+func index(header []string, column string) int {
+	for i, h := range header {
+		if strings.EqualFold(h, strings.TrimSpace(column)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// previewDays is how many of an export's days a preview shows
+const previewDays = 5
+
+// Preview is what importing an export would read, for the user to check and
+// correct the mapping before committing it
+type Preview struct {
+	Source     string   `json:"source"`
+	Columns    []string `json:"columns"`
+	Recognized bool     `json:"recognized"`
+	Mapping    Mapping  `json:"mapping"`
+	Unmapped   []string `json:"unmapped"`
+	Rows       int      `json:"rows"`
+	Days       int      `json:"days"`
+	Sample     []Day    `json:"sample"`
+	Problem    string   `json:"problem,omitempty"`
+}
+
+// Preview reads an export as ParseMapped would, reporting its columns, the
+// mapping proposed for them with overrides applied and its first days. A
+// mapping that cannot be imported, or a row that cannot be read, is reported
+// as the preview's Problem rather than an error
+func (im *Importer) Preview(r io.Reader, overrides Mapping) (*Preview, error) {
+	e, err := im.read(r, overrides)
+	if err != nil {
+		return nil, err
+	}
+	p := &Preview{
+		Source:     im.Source,
+		Columns:    e.header,
+		Recognized: e.recognized,
+		Mapping:    e.mapping,
+		Unmapped:   e.mapping.unmapped(e.header),
+		Rows:       len(e.records),
+		Sample:     []Day{},
+	}
+	days, err := e.days()
+	if err != nil {
+		p.Problem = err.Error()
+		return p, nil
+	}
+	p.Days = len(days)
+	if len(days) > previewDays {
+		days = days[:previewDays]
+	}
+	p.Sample = days
+	return p, nil
+}
@@ -0,0 +1,18 @@
+package nutrition
+
+// myFitnessPal reads the Nutrition Summary export, which has a row for each meal
+// of each day
+var myFitnessPal = &Importer{
+	Source: SourceMyFitnessPal,
+	Layouts: []Layout{{
+		Date:          "Date",
+		Meal:          "Meal",
+		Calories:      "Calories",
+		Protein:       "Protein (g)",
+		Carbohydrates: "Carbohydrates (g)",
+		Fat:           "Fat (g)",
+		Fiber:         "Fiber",
+		Sugar:         "Sugar",
+		SodiumMG:      "Sodium (mg)",
+	}},
+}
@@ -0,0 +1,112 @@
+package nutrition
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SourceOpenFoodFacts identifies foods fetched from Open Food Facts
+const SourceOpenFoodFacts = "openfoodfacts"
+
+// openFoodFactsFields limits the product response to the fields we map
+const openFoodFactsFields = "code,product_name,brands,serving_size,nutriments"
+
+// OpenFoodFacts looks foods up in the Open Food Facts product database
+type OpenFoodFacts struct {
+	BaseURL    string
+	UserAgent  string
+	HTTPClient *http.Client
+}
+
+// NewOpenFoodFacts creates a client for the public Open Food Facts API, which asks
+// callers to identify themselves with a descriptive User-Agent
+func NewOpenFoodFacts() *OpenFoodFacts {
+	return &OpenFoodFacts{
+		BaseURL:    "https://world.openfoodfacts.org",
+		UserAgent:  "AthleteForge/1.0 (workout tracker)",
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+type offResponse struct {
+	Status  int        `json:"status"`
+	Product offProduct `json:"product"`
+}
+
+type offProduct struct {
+	Code        string                 `json:"code"`
+	ProductName string                 `json:"product_name"`
+	Brands      string                 `json:"brands"`
+	ServingSize string                 `json:"serving_size"`
+	Nutriments  map[string]interface{} `json:"nutriments"`
+}
+
+// Lookup fetches the product for barcode
+func (o *OpenFoodFacts) Lookup(ctx context.Context, barcode string) (*Food, error) {
+	endpoint := fmt.Sprintf("%s/api/v2/product/%s.json?fields=%s",
+		strings.TrimRight(o.BaseURL, "/"), url.PathEscape(barcode), url.QueryEscape(openFoodFactsFields))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build food lookup request: %w", err)
+	}
+	req.Header.Set("User-Agent", o.UserAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := o.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("food lookup failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrFoodNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("food lookup returned status %d", resp.StatusCode)
+	}
+
+	var body offResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode food lookup response: %w", err)
+	}
+	if body.Status != 1 {
+		return nil, ErrFoodNotFound
+	}
+
+	p := body.Product
+	return &Food{
+		Barcode:     barcode,
+		Name:        strings.TrimSpace(p.ProductName),
+		Brand:       strings.TrimSpace(strings.Split(p.Brands, ",")[0]),
+		ServingSize: p.ServingSize,
+		Source:      SourceOpenFoodFacts,
+		Per100g: Nutrients{
+			Calories:      nutriment(p.Nutriments, "energy-kcal_100g"),
+			Protein:       nutriment(p.Nutriments, "proteins_100g"),
+			Carbohydrates: nutriment(p.Nutriments, "carbohydrates_100g"),
+			Fat:           nutriment(p.Nutriments, "fat_100g"),
+			Fiber:         nutriment(p.Nutriments, "fiber_100g"),
+			Sugar:         nutriment(p.Nutriments, "sugars_100g"),
+			Salt:          nutriment(p.Nutriments, "salt_100g"),
+		},
+	}, nil
+}
+
+// nutriment reads a numeric nutriment, which Open Food Facts returns as either a
+// number or a string depending on how the product was entered
+func nutriment(nutriments map[string]interface{}, key string) float64 {
+	switch v := nutriments[key].(type) {
+	case float64:
+		return v
+	case string:
+		var f float64
+		fmt.Sscanf(v, "%g", &f)
+		return f
+	}
+	return 0
+}
@@ -0,0 +1,62 @@
+package nutrition
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestOpenFoodFacts(t *testing.T, handler http.HandlerFunc) *OpenFoodFacts {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := NewOpenFoodFacts()
+	client.BaseURL = server.URL
+	return client
+}
+
+func TestOpenFoodFacts_Lookup(t *testing.T) {
+	t.Run("maps product nutriments", func(t *testing.T) {
+		// Arrange
+		var path, userAgent string
+		client := newTestOpenFoodFacts(t, func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			userAgent = r.Header.Get("User-Agent")
+			w.Write([]byte(`{"status":1,"product":{"product_name":"Oat Drink","brands":"Oatly, Oatly AB","serving_size":"250 ml",
+				"nutriments":{"energy-kcal_100g":46,"proteins_100g":"1.0","carbohydrates_100g":6.7,"fat_100g":1.5,"sugars_100g":4}}}`))
+		})
+
+		// Act
+		food, err := client.Lookup(context.Background(), "7394376616037")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if path != "/api/v2/product/7394376616037.json" || userAgent == "" {
+			t.Errorf("unexpected request: path %q user agent %q", path, userAgent)
+		}
+		if food.Name != "Oat Drink" || food.Brand != "Oatly" || food.Per100g.Calories != 46 || food.Per100g.Protein != 1 || food.Source != SourceOpenFoodFacts {
+			t.Errorf("unexpected food: %+v", food)
+		}
+	})
+
+	t.Run("returns ErrFoodNotFound for unknown products", func(t *testing.T) {
+		// Arrange
+		client := newTestOpenFoodFacts(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"status":0,"status_verbose":"product not found"}`))
+		})
+
+		// Act
+		_, err := client.Lookup(context.Background(), "00000000")
+
+		// Assert
+		if !errors.Is(err, ErrFoodNotFound) {
+			t.Errorf("expected ErrFoodNotFound, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,318 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ContentType is the media type of Parquet files
+const ContentType = "application/vnd.apache.parquet"
+
+// magic opens and closes every Parquet file
+const magic = "PAR1"
+
+// createdBy names the writer in the file metadata
+const createdBy = "athlete-forge parquet writer"
+
+// Type is a column's type, mapped to a Parquet physical type and, where needed,
+// the converted type readers such as Athena use to interpret it
+type Type int
+
+// Column types
+const (
+	// Int64 takes int or int64 values
+	Int64 Type = iota
+	// Double takes float64 values
+	Double
+	// String takes string values, stored as UTF-8 byte arrays
+	String
+	// Timestamp takes time.Time values, stored as milliseconds since the epoch
+	Timestamp
+	// Date takes YYYY-MM-DD strings or time.Time values, stored as days since the epoch
+	Date
+)
+
+// Parquet physical types
+const (
+	physicalInt32     = 1
+	physicalInt64     = 2
+	physicalDouble    = 5
+	physicalByteArray = 6
+)
+
+// Parquet converted types
+const (
+	convertedNone            = -1
+	convertedUTF8            = 0
+	convertedDate            = 6
+	convertedTimestampMillis = 9
+)
+
+// Parquet repetition types, encodings and page types
+const (
+	repetitionRequired = 0
+	repetitionOptional = 1
+	encodingPlain      = 0
+	encodingRLE        = 3
+	pageData           = 0
+	codecUncompressed  = 0
+)
+
+// Column describes one column; Optional columns accept nil values
+type Column struct {
+	Name     string
+	Type     Type
+	Optional bool
+}
+
+func (c Column) physical() int32 {
+	switch c.Type {
+	case Double:
+		return physicalDouble
+	case String:
+		return physicalByteArray
+	case Date:
+		return physicalInt32
+	}
+	return physicalInt64
+}
+
+func (c Column) converted() int32 {
+	switch c.Type {
+	case String:
+		return convertedUTF8
+	case Timestamp:
+		return convertedTimestampMillis
+	case Date:
+		return convertedDate
+	}
+	return convertedNone
+}
+
+// Write encodes rows, each holding a value per column in order, as a Parquet
+// file with one row group and one uncompressed, PLAIN-encoded page per column.
+// It suits the modest batches of a periodic export; large tables should be
+// split across files
+func Write(columns []Column, rows [][]interface{}) ([]byte, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("parquet: no columns")
+	}
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			return nil, fmt.Errorf("parquet: row %d has %d values, want %d", i, len(row), len(columns))
+		}
+	}
+
+	var file bytes.Buffer
+	file.WriteString(magic)
+	chunks := make([]chunk, len(columns))
+	for i, column := range columns {
+		page, err := encodePage(column, i, rows)
+		if err != nil {
+			return nil, err
+		}
+		header := pageHeader(len(page), len(rows))
+		chunks[i] = chunk{offset: int64(file.Len()), size: int64(len(header) + len(page))}
+		file.Write(header)
+		file.Write(page)
+	}
+
+	footer := fileMetadata(columns, chunks, len(rows))
+	file.Write(footer)
+	binary.Write(&file, binary.LittleEndian, uint32(len(footer)))
+	file.WriteString(magic)
+	return file.Bytes(), nil
+}
+
+// chunk locates a column's data in the file
+type chunk struct {
+	offset int64
+	size   int64
+}
+
+// encodePage encodes column index of rows as a v1 data page: definition levels
+// for optional columns, then the PLAIN-encoded non-null values
+func encodePage(column Column, index int, rows [][]interface{}) ([]byte, error) {
+	var values bytes.Buffer
+	defined := make([]bool, len(rows))
+	for r, row := range rows {
+		v := row[index]
+		if v == nil {
+			if !column.Optional {
+				return nil, fmt.Errorf("parquet: row %d: %s is required", r, column.Name)
+			}
+			continue
+		}
+		defined[r] = true
+		if err := encodeValue(&values, column.Type, v); err != nil {
+			return nil, fmt.Errorf("parquet: row %d: %s: %w", r, column.Name, err)
+		}
+	}
+
+	var page bytes.Buffer
+	if column.Optional {
+		levels := definitionLevels(defined)
+		binary.Write(&page, binary.LittleEndian, uint32(len(levels)))
+		page.Write(levels)
+	}
+	page.Write(values.Bytes())
+	return page.Bytes(), nil
+}
+
+// encodeValue appends v in PLAIN encoding
+func encodeValue(buf *bytes.Buffer, t Type, v interface{}) error {
+	switch t {
+	case Int64:
+		switch n := v.(type) {
+		case int:
+			binary.Write(buf, binary.LittleEndian, int64(n))
+		case int64:
+			binary.Write(buf, binary.LittleEndian, n)
+		default:
+			return fmt.Errorf("want an integer, got %T", v)
+		}
+	case Double:
+		f, ok := v.(float64)
+		if !ok {
+			return fmt.Errorf("want a float64, got %T", v)
+		}
+		binary.Write(buf, binary.LittleEndian, math.Float64bits(f))
+	case String:
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("want a string, got %T", v)
+		}
+		binary.Write(buf, binary.LittleEndian, uint32(len(s)))
+		buf.WriteString(s)
+	case Timestamp:
+		ts, ok := v.(time.Time)
+		if !ok {
+			return fmt.Errorf("want a time.Time, got %T", v)
+		}
+		binary.Write(buf, binary.LittleEndian, ts.UnixMilli())
+	case Date:
+		day, err := epochDay(v)
+		if err != nil {
+			return err
+		}
+		binary.Write(buf, binary.LittleEndian, day)
+	}
+	return nil
+}
+
+// epochDay returns the days since 1970-01-01 of a date string or time
+func epochDay(v interface{}) (int32, error) {
+	var t time.Time
+	switch d := v.(type) {
+	case time.Time:
+		t = d.UTC()
+	case string:
+		var err error
+		if t, err = time.Parse("2006-01-02", d); err != nil {
+			return 0, fmt.Errorf("invalid date %q", d)
+		}
+	default:
+		return 0, fmt.Errorf("want a date, got %T", v)
+	}
+	return int32(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400), nil
+}
+
+// definitionLevels encodes which rows have a value with the RLE hybrid
+// encoding, as a run per stretch of rows that all have or all lack one
+func definitionLevels(defined []bool) []byte {
+	var buf bytes.Buffer
+	for start := 0; start < len(defined); {
+		end := start
+		for end < len(defined) && defined[end] == defined[start] {
+			end++
+		}
+		writeUvarint(&buf, uint64(end-start)<<1)
+		if defined[start] {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+		start = end
+	}
+	return buf.Bytes()
+}
+
+// pageHeader encodes the header of a data page of size bytes holding values
+func pageHeader(size, values int) []byte {
+	var t compact
+	t.i32(1, pageData)
+	t.i32(2, int32(size))
+	t.i32(3, int32(size))
+	t.beginStruct(5)
+	t.i32(1, int32(values))
+	t.i32(2, encodingPlain)
+	t.i32(3, encodingRLE)
+	t.i32(4, encodingRLE)
+	t.endStruct()
+	t.stop()
+	return t.buf.Bytes()
+}
+
+// fileMetadata encodes the footer describing the schema and the row group
+func fileMetadata(columns []Column, chunks []chunk, rows int) []byte {
+	var t compact
+	t.i32(1, 1)
+
+	t.listHeader(2, typeStruct, len(columns)+1)
+	t.beginElement()
+	t.binary(4, "schema")
+	t.i32(5, int32(len(columns)))
+	t.endElement()
+	for _, c := range columns {
+		t.beginElement()
+		t.i32(1, c.physical())
+		repetition := int32(repetitionRequired)
+		if c.Optional {
+			repetition = repetitionOptional
+		}
+		t.i32(3, repetition)
+		t.binary(4, c.Name)
+		if converted := c.converted(); converted != convertedNone {
+			t.i32(6, converted)
+		}
+		t.endElement()
+	}
+	t.i64(3, int64(rows))
+
+	var total int64
+	for _, c := range chunks {
+		total += c.size
+	}
+	t.listHeader(4, typeStruct, 1)
+	t.beginElement()
+	t.listHeader(1, typeStruct, len(columns))
+	for i, c := range columns {
+		t.beginElement()
+		t.i64(2, chunks[i].offset)
+		t.beginStruct(3)
+		t.i32(1, c.physical())
+		t.listHeader(2, typeI32, 2)
+		t.writeZigzag(encodingPlain)
+		t.writeZigzag(encodingRLE)
+		t.listHeader(3, typeBinary, 1)
+		t.writeString(c.Name)
+		t.i32(4, codecUncompressed)
+		t.i64(5, int64(rows))
+		t.i64(6, chunks[i].size)
+		t.i64(7, chunks[i].size)
+		t.i64(9, chunks[i].offset)
+		t.endStruct()
+		t.endElement()
+	}
+	t.i64(2, total)
+	t.i64(3, int64(rows))
+	t.endElement()
+
+	t.binary(6, createdBy)
+	t.stop()
+	return t.buf.Bytes()
+}
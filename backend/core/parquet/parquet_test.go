@@ -0,0 +1,167 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+)
+
+// reader decodes Thrift compact structs into maps of field ID to value, so
+// tests can check the metadata without a Parquet library
+type reader struct {
+	r *bytes.Reader
+}
+
+func (d reader) zigzag(t *testing.T) int64 {
+	v, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		t.Fatalf("bad varint: %v", err)
+	}
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (d reader) value(t *testing.T, valueType byte) interface{} {
+	switch valueType {
+	case typeI32, typeI64:
+		return d.zigzag(t)
+	case typeBinary:
+		n, _ := binary.ReadUvarint(d.r)
+		b := make([]byte, n)
+		d.r.Read(b)
+		return string(b)
+	case typeList:
+		header, _ := d.r.ReadByte()
+		n := int(header >> 4)
+		if n == 15 {
+			size, _ := binary.ReadUvarint(d.r)
+			n = int(size)
+		}
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = d.value(t, header&0x0f)
+		}
+		return list
+	case typeStruct:
+		fields := map[int16]interface{}{}
+		var last int16
+		for {
+			header, _ := d.r.ReadByte()
+			if header == 0 {
+				return fields
+			}
+			id := last + int16(header>>4)
+			if header>>4 == 0 {
+				id = int16(d.zigzag(t))
+			}
+			fields[id] = d.value(t, header&0x0f)
+			last = id
+		}
+	}
+	t.Fatalf("unexpected thrift type %d", valueType)
+	return nil
+}
+
+func TestWrite(t *testing.T) {
+	// Arrange
+	columns := []Column{
+		{Name: "user_id", Type: String},
+		{Name: "reps", Type: Int64},
+		{Name: "weight", Type: Double, Optional: true},
+		{Name: "completed_at", Type: Timestamp},
+		{Name: "dt", Type: Date},
+	}
+	completed := time.Date(2024, 3, 1, 18, 30, 0, 0, time.UTC)
+	rows := [][]interface{}{
+		{"a1", 5, 100.5, completed, "2024-03-01"},
+		{"a1", 8, nil, completed, "2024-03-01"},
+		{"b2", int64(3), 140.0, completed, completed},
+	}
+
+	// Act
+	file, err := Write(columns, rows)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(file[:4]) != magic || string(file[len(file)-4:]) != magic {
+		t.Fatal("expected the file to start and end with PAR1")
+	}
+	footerSize := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	footer := file[len(file)-8-footerSize : len(file)-8]
+	meta := reader{bytes.NewReader(footer)}.value(t, typeStruct).(map[int16]interface{})
+
+	if meta[3] != int64(3) {
+		t.Errorf("expected 3 rows, got %v", meta[3])
+	}
+	schema := meta[2].([]interface{})
+	if len(schema) != 6 || schema[0].(map[int16]interface{})[5] != int64(5) {
+		t.Fatalf("unexpected schema: %v", schema)
+	}
+	weight := schema[3].(map[int16]interface{})
+	if weight[4] != "weight" || weight[1] != int64(physicalDouble) || weight[3] != int64(repetitionOptional) {
+		t.Errorf("unexpected weight column: %v", weight)
+	}
+	if dt := schema[5].(map[int16]interface{}); dt[6] != int64(convertedDate) {
+		t.Errorf("unexpected dt column: %v", dt)
+	}
+
+	chunks := meta[4].([]interface{})[0].(map[int16]interface{})[1].([]interface{})
+	if len(chunks) != len(columns) {
+		t.Fatalf("expected a column chunk per column, got %d", len(chunks))
+	}
+
+	t.Run("required column values", func(t *testing.T) {
+		columnMeta := chunks[1].(map[int16]interface{})[3].(map[int16]interface{})
+		page := bytes.NewReader(file[columnMeta[9].(int64):])
+		header := reader{page}.value(t, typeStruct).(map[int16]interface{})
+		if header[5].(map[int16]interface{})[1] != int64(3) {
+			t.Fatalf("unexpected page header: %v", header)
+		}
+		values := make([]int64, 3)
+		binary.Read(page, binary.LittleEndian, values)
+		if values[0] != 5 || values[1] != 8 || values[2] != 3 {
+			t.Errorf("unexpected reps: %v", values)
+		}
+	})
+
+	t.Run("optional column levels and values", func(t *testing.T) {
+		columnMeta := chunks[2].(map[int16]interface{})[3].(map[int16]interface{})
+		page := bytes.NewReader(file[columnMeta[9].(int64):])
+		reader{page}.value(t, typeStruct)
+		var levelsSize uint32
+		binary.Read(page, binary.LittleEndian, &levelsSize)
+		levels := make([]byte, levelsSize)
+		page.Read(levels)
+		if !bytes.Equal(levels, []byte{2, 1, 2, 0, 2, 1}) {
+			t.Errorf("unexpected definition levels: %v", levels)
+		}
+		var bits [2]uint64
+		binary.Read(page, binary.LittleEndian, &bits)
+		if math.Float64frombits(bits[0]) != 100.5 || math.Float64frombits(bits[1]) != 140 {
+			t.Errorf("unexpected weights: %v", bits)
+		}
+	})
+}
+
+func TestWrite_RejectsInvalidRows(t *testing.T) {
+	columns := []Column{{Name: "reps", Type: Int64}}
+	tests := []struct {
+		name string
+		rows [][]interface{}
+	}{
+		{name: "missing required value", rows: [][]interface{}{{nil}}},
+		{name: "wrong type", rows: [][]interface{}{{"five"}}},
+		{name: "wrong width", rows: [][]interface{}{{1, 2}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Write(columns, tt.rows); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
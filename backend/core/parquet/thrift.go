@@ -0,0 +1,101 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Thrift compact protocol field and element types
+const (
+	typeI32    = 5
+	typeI64    = 6
+	typeBinary = 8
+	typeList   = 9
+	typeStruct = 12
+)
+
+// compact writes the Thrift compact protocol Parquet uses for its metadata.
+// Field IDs are written as deltas from the previous field of the same struct,
+// so nested structs keep their enclosing struct's last ID on a stack
+type compact struct {
+	buf    bytes.Buffer
+	lastID int16
+	stack  []int16
+}
+
+func (t *compact) field(id int16, fieldType byte) {
+	if delta := id - t.lastID; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | fieldType)
+	} else {
+		t.buf.WriteByte(fieldType)
+		t.writeZigzag(int64(id))
+	}
+	t.lastID = id
+}
+
+func (t *compact) i32(id int16, v int32) {
+	t.field(id, typeI32)
+	t.writeZigzag(int64(v))
+}
+
+func (t *compact) i64(id int16, v int64) {
+	t.field(id, typeI64)
+	t.writeZigzag(v)
+}
+
+func (t *compact) binary(id int16, s string) {
+	t.field(id, typeBinary)
+	t.writeString(s)
+}
+
+// listHeader starts list field id of n elements of elementType
+func (t *compact) listHeader(id int16, elementType byte, n int) {
+	t.field(id, typeList)
+	if n < 15 {
+		t.buf.WriteByte(byte(n)<<4 | elementType)
+		return
+	}
+	t.buf.WriteByte(0xf0 | elementType)
+	writeUvarint(&t.buf, uint64(n))
+}
+
+// beginStruct starts struct field id; endStruct closes it
+func (t *compact) beginStruct(id int16) {
+	t.field(id, typeStruct)
+	t.beginElement()
+}
+
+func (t *compact) endStruct() {
+	t.endElement()
+}
+
+// beginElement starts a struct that is a list element, which has no field header
+func (t *compact) beginElement() {
+	t.stack = append(t.stack, t.lastID)
+	t.lastID = 0
+}
+
+func (t *compact) endElement() {
+	t.stop()
+	t.lastID = t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+// stop ends the current struct
+func (t *compact) stop() {
+	t.buf.WriteByte(0)
+}
+
+func (t *compact) writeString(s string) {
+	writeUvarint(&t.buf, uint64(len(s)))
+	t.buf.WriteString(s)
+}
+
+func (t *compact) writeZigzag(v int64) {
+	writeUvarint(&t.buf, uint64((v<<1)^(v>>63)))
+}
+
+func writeUvarint(buf *bytes.Buffer, v uint64) {
+	var scratch [binary.MaxVarintLen64]byte
+	buf.Write(scratch[:binary.PutUvarint(scratch[:], v)])
+}
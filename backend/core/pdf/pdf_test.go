@@ -0,0 +1,64 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestDocument_Bytes(t *testing.T) {
+	t.Run("writes a valid cross-reference table", func(t *testing.T) {
+		// Arrange
+		doc := New()
+		page := doc.AddPage()
+		page.Text(50, 800, 18, true, "Report (draft)")
+		page.Line(50, 790, 545, 790, 1, Gray)
+		doc.AddPage().FillRect(50, 50, 100, 20, Blue)
+
+		// Act
+		out := doc.Bytes()
+
+		// Assert
+		if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+			t.Fatal("missing PDF header or trailer")
+		}
+		if !bytes.Contains(out, []byte(`(Report \(draft\)) Tj`)) {
+			t.Error("expected escaped text operator")
+		}
+		if !bytes.Contains(out, []byte("/Count 2")) {
+			t.Error("expected two pages")
+		}
+
+		startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+		if startxref == nil {
+			t.Fatal("missing startxref")
+		}
+		offset, _ := strconv.Atoi(string(startxref[1]))
+		if !bytes.HasPrefix(out[offset:], []byte("xref\n")) {
+			t.Error("startxref does not point at the xref table")
+		}
+		for i, entry := range regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out, -1) {
+			objOffset, _ := strconv.Atoi(string(entry[1]))
+			if !bytes.HasPrefix(out[objOffset:], []byte(fmt.Sprintf("%d 0 obj", i+1))) {
+				t.Errorf("xref entry %d does not point at its object", i+1)
+			}
+		}
+	})
+}
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{in: `a\b`, want: `a\\b`},
+		{in: "café", want: `caf\351`},
+		{in: "☃", want: "?"},
+	}
+	for _, tt := range tests {
+		if got := escape(tt.in); got != tt.want {
+			t.Errorf("escape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package percentile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"athlete-forge/profile"
+	"athlete-forge/records"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+const (
+	distributionPK       = "PERCENTILES"
+	distributionSKPrefix = "COHORT#"
+)
+
+// MinCohortSize is the fewest lifters a distribution is published for, so no
+// distribution describes a handful of identifiable people
+const MinCohortSize = 20
+
+// step is the gap between published percentiles. Only the 5th to the 95th are
+// kept, since the extremes would be a single lifter's best
+const step = 5
+
+// All marks a cohort dimension that is not narrowed, such as every age band
+const All = "all"
+
+// Lifts are the compared lifts, keyed by the names records are kept under
+var Lifts = map[string]string{
+	"squat":          "squat",
+	"back squat":     "squat",
+	"bench press":    "bench press",
+	"deadlift":       "deadlift",
+	"overhead press": "overhead press",
+}
+
+// weightClasses are the upper limits in kilograms of the IPF weight classes;
+// heavier lifters are in the open class above the last
+var weightClasses = map[string][]float64{
+	profile.SexMale:   {59, 66, 74, 83, 93, 105, 120},
+	profile.SexFemale: {47, 52, 57, 63, 69, 76, 84},
+}
+
+// ErrIncomplete is returned when a profile lacks the sex, bodyweight or birth
+// year that place it in a cohort
+var ErrIncomplete = errors.New("sex, bodyweight and birthYear are required for strength comparison")
+
+// Cohort is a group of lifters compared with each other: a sex, an IPF weight
+// class such as "83" or "120+" and an age band such as "24-39"
+type Cohort struct {
+	Sex         string `json:"sex"`
+	WeightClass string `json:"weightClass"`
+	AgeBand     string `json:"ageBand"`
+}
+
+// CohortFor places p in its cohort as of now
+func CohortFor(p *profile.Profile, now time.Time) (Cohort, error) {
+	if p.Sex == "" || p.Bodyweight <= 0 || p.BirthYear <= 0 {
+		return Cohort{}, ErrIncomplete
+	}
+	return Cohort{Sex: p.Sex, WeightClass: weightClass(p.Sex, p.BodyweightKg()), AgeBand: ageBand(now.Year() - p.BirthYear)}, nil
+}
+
+// Broader returns c followed by the cohorts it falls back to when too few
+// lifters share it: every age in its weight class, then every lifter of its sex
+func (c Cohort) Broader() []Cohort {
+	return []Cohort{
+		c,
+		{Sex: c.Sex, WeightClass: c.WeightClass, AgeBand: All},
+		{Sex: c.Sex, WeightClass: All, AgeBand: All},
+	}
+}
+
+func (c Cohort) key() string {
+	return c.Sex + "#" + c.WeightClass + "#" + c.AgeBand
+}
+
+func weightClass(sex string, kg float64) string {
+	classes := weightClasses[sex]
+	for _, limit := range classes {
+		if kg <= limit {
+			return strconv.Itoa(int(limit))
+		}
+	}
+	return strconv.Itoa(int(classes[len(classes)-1])) + "+"
+}
+
+// ageBand follows the IPF age divisions, folding sub-juniors into juniors
+func ageBand(age int) string {
+	switch {
+	case age < 24:
+		return "under-24"
+	case age < 40:
+		return "24-39"
+	case age < 50:
+		return "40-49"
+	case age < 60:
+		return "50-59"
+	}
+	return "60+"
+}
+
+// Bests returns the user's best estimated one-rep max in kilograms for each
+// compared lift, from workouts logged in unit
+func Bests(workouts []workout.Workout, unit string, now time.Time) map[string]float64 {
+	bests := map[string]float64{}
+	for name, record := range records.Best(workouts, now) {
+		lift, ok := Lifts[name]
+		if !ok {
+			continue
+		}
+		e1rm := profile.Kilograms(record.Estimated1RM, unit)
+		if e1rm > bests[lift] {
+			bests[lift] = e1rm
+		}
+	}
+	return bests
+}
+
+// Distribution is the published spread of a lift's best estimated one-rep max
+// in a cohort: Size lifters and the kilograms at every fifth percentile from
+// the 5th to the 95th, rounded to half a kilogram
+type Distribution struct {
+	Cohort      Cohort    `json:"cohort"`
+	Lift        string    `json:"lift"`
+	Size        int       `json:"size"`
+	Percentiles []float64 `json:"percentiles"`
+	ComputedAt  time.Time `json:"computedAt"`
+}
+
+// Rank returns the percentile of kg in the distribution, interpolating between
+// the published percentiles; lifts at or beyond the 95th rank 98, and ranks are
+// clamped to 1-99
+func (d Distribution) Rank(kg float64) int {
+	points := d.Percentiles
+	if len(points) == 0 {
+		return 0
+	}
+	var rank float64
+	i := sort.Search(len(points), func(i int) bool { return points[i] > kg })
+	switch {
+	case i == 0:
+		rank = step * kg / points[0]
+	case i == len(points):
+		rank = 100 - step/2.0
+	default:
+		lower, upper := points[i-1], points[i]
+		rank = float64(i*step) + step*(kg-lower)/(upper-lower)
+	}
+	return int(math.Max(1, math.Min(99, math.Round(rank))))
+}
+
+// series identifies the values of one lift in one cohort
+type series struct {
+	cohort Cohort
+	lift   string
+}
+
+// Aggregator collects lifters' bests into each cohort they count towards
+type Aggregator struct {
+	values map[series][]float64
+}
+
+// NewAggregator creates an empty Aggregator
+func NewAggregator() *Aggregator {
+	return &Aggregator{values: map[series][]float64{}}
+}
+
+// Add counts a lifter's bests towards c and the cohorts it falls back to
+func (a *Aggregator) Add(c Cohort, bests map[string]float64) {
+	for _, cohort := range c.Broader() {
+		for lift, kg := range bests {
+			k := series{cohort: cohort, lift: lift}
+			a.values[k] = append(a.values[k], kg)
+		}
+	}
+}
+
+// Distributions returns a distribution per cohort and lift with at least
+// MinCohortSize lifters; the individual values are not kept
+func (a *Aggregator) Distributions(now time.Time) []Distribution {
+	var out []Distribution
+	for k, values := range a.values {
+		if len(values) < MinCohortSize {
+			continue
+		}
+		sort.Float64s(values)
+		d := Distribution{Cohort: k.cohort, Lift: k.lift, Size: len(values), ComputedAt: now.UTC()}
+		for p := step; p < 100; p += step {
+			d.Percentiles = append(d.Percentiles, math.Round(quantile(values, float64(p)/100)*2)/2)
+		}
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return distributionSK(out[i].Cohort, out[i].Lift) < distributionSK(out[j].Cohort, out[j].Lift)
+	})
+	return out
+}
+
+// quantile interpolates the q quantile of sorted values
+func quantile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	if lower+1 >= len(sorted) {
+		return sorted[lower]
+	}
+	return sorted[lower] + (sorted[lower+1]-sorted[lower])*(pos-float64(lower))
+}
+
+// Repository loads and saves the published distributions
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+func distributionSK(c Cohort, lift string) string {
+	return distributionSKPrefix + c.key() + "#" + lift
+}
+
+// Replace publishes distributions, removing any earlier ones not among them,
+// such as a cohort that has fallen below MinCohortSize
+func (r *Repository) Replace(ctx context.Context, distributions []Distribution) error {
+	items, err := r.store.Query(ctx, distributionPK, distributionSKPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to list distributions: %w", err)
+	}
+	current := map[string]bool{}
+	for _, d := range distributions {
+		sk := distributionSK(d.Cohort, d.Lift)
+		current[sk] = true
+		if err := r.store.Put(ctx, distributionPK, sk, d); err != nil {
+			return fmt.Errorf("failed to save distribution: %w", err)
+		}
+	}
+	for _, item := range items {
+		if current[item.SK] {
+			continue
+		}
+		if err := r.store.Delete(ctx, distributionPK, item.SK); err != nil {
+			return fmt.Errorf("failed to remove distribution: %w", err)
+		}
+	}
+	return nil
+}
+
+// Find returns the distribution of lift for the narrowest of c's cohorts that
+// has one, or false when none is published
+func (r *Repository) Find(ctx context.Context, c Cohort, lift string) (Distribution, bool, error) {
+	for _, cohort := range c.Broader() {
+		var d Distribution
+		err := r.store.Get(ctx, distributionPK, distributionSK(cohort, lift), &d)
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return Distribution{}, false, fmt.Errorf("failed to load distribution: %w", err)
+		}
+		return d, true, nil
+	}
+	return Distribution{}, false, nil
+}
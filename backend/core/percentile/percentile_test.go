@@ -0,0 +1,144 @@
+package percentile
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"athlete-forge/profile"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+func TestCohortFor(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("places lifters by sex, IPF weight class and age band", func(t *testing.T) {
+		cases := []struct {
+			sex        string
+			unit       string
+			bodyweight float64
+			birthYear  int
+			want       Cohort
+		}{
+			{profile.SexMale, profile.UnitKilograms, 82.5, 1994, Cohort{profile.SexMale, "83", "24-39"}},
+			{profile.SexMale, profile.UnitKilograms, 130, 1980, Cohort{profile.SexMale, "120+", "40-49"}},
+			{profile.SexFemale, profile.UnitPounds, 150, 2003, Cohort{profile.SexFemale, "69", "under-24"}},
+			{profile.SexFemale, profile.UnitKilograms, 47, 1960, Cohort{profile.SexFemale, "47", "60+"}},
+		}
+		for _, c := range cases {
+			// Act
+			got, err := CohortFor(&profile.Profile{Unit: c.unit, Sex: c.sex, Bodyweight: c.bodyweight, BirthYear: c.birthYear}, now)
+
+			// Assert
+			if err != nil || got != c.want {
+				t.Errorf("%s %v%s born %d: expected %+v, got %+v (%v)", c.sex, c.bodyweight, c.unit, c.birthYear, c.want, got, err)
+			}
+		}
+	})
+
+	t.Run("requires every demographic", func(t *testing.T) {
+		// Act
+		_, err := CohortFor(&profile.Profile{Unit: profile.UnitKilograms, Sex: profile.SexMale, Bodyweight: 80}, now)
+
+		// Assert
+		if err != ErrIncomplete {
+			t.Errorf("expected ErrIncomplete, got %v", err)
+		}
+	})
+}
+
+func TestBests(t *testing.T) {
+	// Arrange
+	completed := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	workouts := []workout.Workout{{
+		ID: "w1", Status: workout.StatusCompleted, CompletedAt: &completed,
+		Exercises: []workout.Exercise{
+			{Name: "Back Squat", Sets: []workout.Set{{Reps: 1, Weight: 220.5}}},
+			{Name: "Biceps Curl", Sets: []workout.Set{{Reps: 1, Weight: 50}}},
+		},
+	}}
+
+	// Act
+	bests := Bests(workouts, profile.UnitPounds, completed.Add(time.Hour))
+
+	// Assert
+	if len(bests) != 1 || bests["squat"] < 99.9 || bests["squat"] > 100.1 {
+		t.Errorf("expected only a 100kg squat, got %v", bests)
+	}
+}
+
+func TestAggregator(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	cohort := Cohort{Sex: profile.SexMale, WeightClass: "83", AgeBand: "24-39"}
+
+	t.Run("publishes the 5th to 95th percentiles of cohorts large enough", func(t *testing.T) {
+		// Arrange
+		a := NewAggregator()
+		for i := 0; i < 21; i++ {
+			a.Add(cohort, map[string]float64{"squat": float64(100 + 5*i)})
+		}
+		a.Add(Cohort{Sex: profile.SexMale, WeightClass: "93", AgeBand: "24-39"}, map[string]float64{"squat": 300})
+
+		// Act
+		distributions := a.Distributions(now)
+
+		// Assert
+		if len(distributions) != 3 {
+			t.Fatalf("expected the cohort and both fallbacks but not the lone lifter's, got %d", len(distributions))
+		}
+		exact := distributions[0]
+		if exact.Cohort != cohort || exact.Size != 21 || len(exact.Percentiles) != 19 {
+			t.Fatalf("unexpected distribution: %+v", exact)
+		}
+		if exact.Percentiles[0] != 105 || exact.Percentiles[9] != 150 || exact.Percentiles[18] != 195 {
+			t.Errorf("unexpected percentiles: %v", exact.Percentiles)
+		}
+		if distributions[2].Cohort.WeightClass != All || distributions[2].Size != 22 {
+			t.Errorf("expected the sex-wide cohort to count every lifter, got %+v", distributions[2])
+		}
+	})
+
+	t.Run("ranks lifts between the published percentiles", func(t *testing.T) {
+		// Arrange
+		d := Distribution{Percentiles: []float64{105, 110, 115, 120, 125, 130, 135, 140, 145, 150, 155, 160, 165, 170, 175, 180, 185, 190, 195}}
+
+		// Act and assert
+		for kg, want := range map[float64]int{150: 50, 152.5: 53, 52.5: 3, 10: 1, 195: 98, 400: 98} {
+			if got := d.Rank(kg); got != want {
+				t.Errorf("Rank(%v): expected %d, got %d", kg, want, got)
+			}
+		}
+	})
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+	r := NewRepository(store.NewMemoryStore())
+	cohort := Cohort{Sex: profile.SexFemale, WeightClass: "63", AgeBand: "40-49"}
+	fallback := Cohort{Sex: profile.SexFemale, WeightClass: "63", AgeBand: All}
+
+	t.Run("finds the narrowest published cohort", func(t *testing.T) {
+		// Arrange
+		r.Replace(ctx, []Distribution{{Cohort: fallback, Lift: "deadlift", Size: 40, Percentiles: []float64{80}}})
+
+		// Act
+		d, ok, err := r.Find(ctx, cohort, "deadlift")
+
+		// Assert
+		if err != nil || !ok || d.Cohort != fallback {
+			t.Errorf("expected the age-wide cohort, got %+v %v %v", d, ok, err)
+		}
+	})
+
+	t.Run("removes distributions that are no longer published", func(t *testing.T) {
+		// Act
+		r.Replace(ctx, nil)
+		_, ok, err := r.Find(ctx, cohort, "deadlift")
+
+		// Assert
+		if err != nil || ok {
+			t.Errorf("expected no distribution, got %v %v", ok, err)
+		}
+	})
+}
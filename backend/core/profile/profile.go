@@ -0,0 +1,375 @@
+package profile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"athlete-forge/envelope"
+	"athlete-forge/ftp"
+	"athlete-forge/hrzone"
+	"athlete-forge/l10n"
+	"athlete-forge/runzone"
+	"athlete-forge/store"
+	"athlete-forge/tools"
+)
+
+const (
+	profileSK = "PROFILE"
+
+	// encryptedPK indexes the users whose profiles hold sealed fields, so key
+	// rotation can find them
+	encryptedPK = "ENCRYPTED#PROFILE"
+)
+
+// SK is the sort key of each user's profile, for configuring read-through caches
+const SK = profileSK
+
+// maxNoteLength bounds the free-text health fields
+const maxNoteLength = 4000
+
+const poundsPerKilogram = 2.20462262
+
+// Weight units supported by the profile
+const (
+	UnitKilograms = "kg"
+	UnitPounds    = "lb"
+)
+
+// Sexes for strength comparison, which compares lifters of the same sex
+const (
+	SexMale   = "male"
+	SexFemale = "female"
+)
+
+// Profile holds a user's training preferences and equipment
+type Profile struct {
+	UserID    string         `json:"userId"`
+	Unit      string         `json:"unit"`
+	BarWeight float64        `json:"barWeight"`
+	Plates    []tools.Plate  `json:"plates"`
+	HeartRate *hrzone.Config `json:"heartRate,omitempty"`
+
+	// Locale is the user's language and region, such as de-AT, that emails,
+	// reports and exports are written in; en's formats are used when empty
+	Locale string `json:"locale,omitempty"`
+
+	// Running sets the threshold pace and power that runs' pace and power
+	// zones are scored against
+	Running *runzone.Config `json:"running,omitempty"`
+
+	// FTP is the cycling functional threshold power, in watts, rides'
+	// intensity factor and TSS are scored against
+	FTP int `json:"ftp,omitempty"`
+
+	// Rounding sets the load increments of the user's equipment; increments
+	// left at zero use the unit's defaults
+	Rounding *tools.Rounding `json:"rounding,omitempty"`
+
+	// AnalyticsConsent opts the user in to anonymized usage telemetry; it is off
+	// until they turn it on
+	AnalyticsConsent bool `json:"analyticsConsent,omitempty"`
+
+	// StrengthComparison opts the user in to comparing their lifts with other
+	// users' and to their lifts counting towards the anonymized population; it
+	// is off until they turn it on
+	StrengthComparison bool `json:"strengthComparison,omitempty"`
+
+	// Sensitive fields, stored encrypted. Sex, Bodyweight, in the profile's
+	// unit, and BirthYear place the user in a strength comparison cohort
+	HealthNotes   string  `json:"healthNotes,omitempty"`
+	InjuryHistory string  `json:"injuryHistory,omitempty"`
+	Sex           string  `json:"sex,omitempty"`
+	Bodyweight    float64 `json:"bodyweight,omitempty"`
+	BirthYear     int     `json:"birthYear,omitempty"`
+}
+
+// sensitiveFields are sealed together into one encrypted attribute
+type sensitiveFields struct {
+	HealthNotes   string  `json:"healthNotes,omitempty"`
+	InjuryHistory string  `json:"injuryHistory,omitempty"`
+	Sex           string  `json:"sex,omitempty"`
+	Bodyweight    float64 `json:"bodyweight,omitempty"`
+	BirthYear     int     `json:"birthYear,omitempty"`
+}
+
+// storedProfile is the persisted form of a Profile, with its sensitive fields
+// cleared and held in Sensitive instead
+type storedProfile struct {
+	Profile
+	Sensitive *envelope.Sealed `json:"sensitive,omitempty"`
+}
+
+// Default returns the profile used until a user saves their own
+func Default(userID, unit string) *Profile {
+	if unit == UnitPounds {
+		return &Profile{
+			UserID:    userID,
+			Unit:      UnitPounds,
+			BarWeight: 45,
+			Plates: []tools.Plate{
+				{Weight: 45, Pairs: 4},
+				{Weight: 35, Pairs: 1},
+				{Weight: 25, Pairs: 1},
+				{Weight: 10, Pairs: 2},
+				{Weight: 5, Pairs: 1},
+				{Weight: 2.5, Pairs: 1},
+			},
+			Rounding: defaultRounding(UnitPounds),
+		}
+	}
+
+	return &Profile{
+		UserID:    userID,
+		Unit:      UnitKilograms,
+		BarWeight: 20,
+		Plates: []tools.Plate{
+			{Weight: 25, Pairs: 4},
+			{Weight: 20, Pairs: 1},
+			{Weight: 15, Pairs: 1},
+			{Weight: 10, Pairs: 1},
+			{Weight: 5, Pairs: 1},
+			{Weight: 2.5, Pairs: 1},
+			{Weight: 1.25, Pairs: 1},
+		},
+		Rounding: defaultRounding(UnitKilograms),
+	}
+}
+
+// defaultRounding returns the increments of common equipment in unit: the
+// smallest plate pair on a barbell, the step between fixed dumbbells and the
+// step between plates of a selectorized stack
+func defaultRounding(unit string) *tools.Rounding {
+	if unit == UnitPounds {
+		return &tools.Rounding{Barbell: 5, Dumbbell: 5, Machine: 10}
+	}
+	return &tools.Rounding{Barbell: 2.5, Dumbbell: 1, Machine: 5}
+}
+
+// LoadRounding returns the profile's rounding with any unset increment taken
+// from the unit's defaults
+func (p *Profile) LoadRounding() tools.Rounding {
+	r := *defaultRounding(p.Unit)
+	if p.Rounding == nil {
+		return r
+	}
+	if p.Rounding.Barbell > 0 {
+		r.Barbell = p.Rounding.Barbell
+	}
+	if p.Rounding.Dumbbell > 0 {
+		r.Dumbbell = p.Rounding.Dumbbell
+	}
+	if p.Rounding.Machine > 0 {
+		r.Machine = p.Rounding.Machine
+	}
+	r.Stacks = p.Rounding.Stacks
+	return r
+}
+
+// Validate checks the profile for values the calculators cannot use
+func (p *Profile) Validate() error {
+	if p.Unit != UnitKilograms && p.Unit != UnitPounds {
+		return fmt.Errorf("unit must be %q or %q", UnitKilograms, UnitPounds)
+	}
+	if p.Locale != "" && !l10n.Valid(p.Locale) {
+		return errors.New("locale must be a language tag such as en-US")
+	}
+	if p.BarWeight < 0 {
+		return errors.New("barWeight must not be negative")
+	}
+	if err := tools.ValidatePlates(p.Plates); err != nil {
+		return err
+	}
+	if p.Rounding != nil {
+		if err := p.Rounding.Validate(); err != nil {
+			return err
+		}
+	}
+	if len([]rune(p.HealthNotes)) > maxNoteLength || len([]rune(p.InjuryHistory)) > maxNoteLength {
+		return fmt.Errorf("healthNotes and injuryHistory must be at most %d characters", maxNoteLength)
+	}
+	if p.HeartRate != nil {
+		if err := p.HeartRate.Validate(); err != nil {
+			return err
+		}
+	}
+	if p.Running != nil {
+		if err := p.Running.Validate(); err != nil {
+			return err
+		}
+	}
+	if p.FTP != 0 {
+		if err := ftp.Validate(p.FTP); err != nil {
+			return err
+		}
+	}
+	if p.Sex != "" && p.Sex != SexMale && p.Sex != SexFemale {
+		return fmt.Errorf("sex must be %q or %q", SexMale, SexFemale)
+	}
+	if p.Bodyweight < 0 || p.BirthYear < 0 {
+		return errors.New("bodyweight and birthYear must not be negative")
+	}
+	return nil
+}
+
+// BodyweightKg returns the user's bodyweight in kilograms, or 0 when not set
+func (p *Profile) BodyweightKg() float64 {
+	return Kilograms(p.Bodyweight, p.Unit)
+}
+
+// Kilograms converts weight in unit to kilograms
+func Kilograms(weight float64, unit string) float64 {
+	if unit == UnitPounds {
+		return weight / poundsPerKilogram
+	}
+	return weight
+}
+
+// Repository loads and saves profiles, encrypting sensitive fields on save and
+// decrypting them on load
+type Repository struct {
+	store  store.Store
+	cipher *envelope.Cipher
+}
+
+// NewRepository creates a Repository backed by s that seals sensitive fields with c
+func NewRepository(s store.Store, c *envelope.Cipher) *Repository {
+	return &Repository{store: s, cipher: c}
+}
+
+// Get returns the stored profile for userID, or the default profile if none is saved
+func (r *Repository) Get(ctx context.Context, userID string) (*Profile, error) {
+	stored, err := r.load(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return Default(userID, UnitKilograms), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p := stored.Profile
+	if stored.Sensitive != nil {
+		plaintext, err := r.cipher.Open(ctx, stored.Sensitive, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt profile: %w", err)
+		}
+		var fields sensitiveFields
+		if err := json.Unmarshal(plaintext, &fields); err != nil {
+			return nil, fmt.Errorf("failed to decode profile: %w", err)
+		}
+		p.HealthNotes = fields.HealthNotes
+		p.InjuryHistory = fields.InjuryHistory
+		p.Sex = fields.Sex
+		p.Bodyweight = fields.Bodyweight
+		p.BirthYear = fields.BirthYear
+	}
+	return &p, nil
+}
+
+// AnalyticsConsent reports whether userID has opted in to usage telemetry,
+// without decrypting their sensitive fields
+func (r *Repository) AnalyticsConsent(ctx context.Context, userID string) (bool, error) {
+	stored, err := r.load(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return stored.AnalyticsConsent, nil
+}
+
+// Save validates and stores p
+func (r *Repository) Save(ctx context.Context, p *Profile) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	stored := storedProfile{Profile: *p}
+	stored.HealthNotes, stored.InjuryHistory = "", ""
+	stored.Sex, stored.Bodyweight, stored.BirthYear = "", 0, 0
+	fields := sensitiveFields{
+		HealthNotes:   p.HealthNotes,
+		InjuryHistory: p.InjuryHistory,
+		Sex:           p.Sex,
+		Bodyweight:    p.Bodyweight,
+		BirthYear:     p.BirthYear,
+	}
+	if fields != (sensitiveFields{}) {
+		plaintext, err := json.Marshal(fields)
+		if err != nil {
+			return fmt.Errorf("failed to encode profile: %w", err)
+		}
+		stored.Sensitive, err = r.cipher.Seal(ctx, plaintext, p.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt profile: %w", err)
+		}
+	}
+
+	if err := r.store.Put(ctx, store.UserPK(p.UserID), profileSK, stored); err != nil {
+		return fmt.Errorf("failed to save profile: %w", err)
+	}
+	var err error
+	if stored.Sensitive != nil {
+		err = r.store.Put(ctx, encryptedPK, store.UserPK(p.UserID), map[string]string{"userId": p.UserID})
+	} else {
+		err = r.store.Delete(ctx, encryptedPK, store.UserPK(p.UserID))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to index encrypted profile: %w", err)
+	}
+	return nil
+}
+
+// Reencrypt re-seals userID's sensitive fields when they were sealed under an
+// older master key, reporting whether the profile was rewritten
+func (r *Repository) Reencrypt(ctx context.Context, userID string) (bool, error) {
+	stored, err := r.load(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if stored.Sensitive == nil {
+		return false, nil
+	}
+
+	stale, err := r.cipher.NeedsRotation(ctx, stored.Sensitive)
+	if err != nil || !stale {
+		return false, err
+	}
+	p, err := r.Get(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return true, r.Save(ctx, p)
+}
+
+// Encrypted returns the IDs of users whose profiles hold sealed fields
+func (r *Repository) Encrypted(ctx context.Context) ([]string, error) {
+	items, err := r.store.Query(ctx, encryptedPK, store.UserPK(""))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list encrypted profiles: %w", err)
+	}
+	userIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		userIDs = append(userIDs, strings.TrimPrefix(item.SK, store.UserPK("")))
+	}
+	return userIDs, nil
+}
+
+// load reads the stored form of userID's profile
+func (r *Repository) load(ctx context.Context, userID string) (*storedProfile, error) {
+	var stored storedProfile
+	err := r.store.Get(ctx, store.UserPK(userID), profileSK, &stored)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load profile: %w", err)
+	}
+	return &stored, nil
+}
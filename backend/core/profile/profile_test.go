@@ -0,0 +1,280 @@
+package profile
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"athlete-forge/envelope"
+	"athlete-forge/hrzone"
+	"athlete-forge/store"
+	"athlete-forge/tools"
+)
+
+// testKeys are fixed master keys so repositories over one store share them
+var testKeys = map[string][]byte{
+	"key-1": []byte("0123456789abcdef0123456789abcdef"),
+	"key-2": []byte("fedcba9876543210fedcba9876543210"),
+}
+
+func newTestRepository(t *testing.T, s store.Store, currentKey string) *Repository {
+	t.Helper()
+	keys, err := envelope.NewLocalKeys(currentKey, testKeys)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return NewRepository(s, envelope.NewCipher(keys))
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("returns default profile when none is saved", func(t *testing.T) {
+		// Arrange
+		repo := newTestRepository(t, store.NewMemoryStore(), "key-1")
+
+		// Act
+		p, err := repo.Get(ctx, "user-1")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if p.UserID != "user-1" || p.Unit != UnitKilograms || p.BarWeight != 20 {
+			t.Errorf("unexpected default profile: %+v", p)
+		}
+	})
+
+	t.Run("saves and reloads a custom profile", func(t *testing.T) {
+		// Arrange
+		repo := newTestRepository(t, store.NewMemoryStore(), "key-1")
+		custom := &Profile{
+			UserID:    "user-1",
+			Unit:      UnitPounds,
+			BarWeight: 35,
+			Plates:    []tools.Plate{{Weight: 45, Pairs: 2}},
+		}
+
+		// Act
+		if err := repo.Save(ctx, custom); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		p, err := repo.Get(ctx, "user-1")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if p.Unit != UnitPounds || p.BarWeight != 35 || len(p.Plates) != 1 {
+			t.Errorf("unexpected profile: %+v", p)
+		}
+	})
+
+	t.Run("rejects invalid profiles", func(t *testing.T) {
+		// Arrange
+		repo := newTestRepository(t, store.NewMemoryStore(), "key-1")
+
+		// Act
+		err := repo.Save(ctx, &Profile{UserID: "user-1", Unit: "stone"})
+
+		// Assert
+		if err == nil {
+			t.Error("expected validation error for unknown unit")
+		}
+	})
+}
+
+func TestRepository_Encryption(t *testing.T) {
+	ctx := context.Background()
+	custom := func() *Profile {
+		p := Default("user-1", UnitKilograms)
+		p.HealthNotes = "Asthma, carries inhaler"
+		p.InjuryHistory = "Left ACL reconstruction 2021"
+		return p
+	}
+
+	t.Run("stores sensitive fields encrypted and reads them back", func(t *testing.T) {
+		// Arrange
+		s := store.NewMemoryStore()
+		repo := newTestRepository(t, s, "key-1")
+
+		// Act
+		if err := repo.Save(ctx, custom()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		items, _ := s.Query(ctx, store.UserPK("user-1"), profileSK)
+		p, err := repo.Get(ctx, "user-1")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(items) != 1 || strings.Contains(string(items[0].Data), "ACL") || strings.Contains(string(items[0].Data), "Asthma") {
+			t.Errorf("expected sensitive fields encrypted at rest, got %s", items[0].Data)
+		}
+		if p.HealthNotes != "Asthma, carries inhaler" || p.InjuryHistory != "Left ACL reconstruction 2021" || p.BarWeight != 20 {
+			t.Errorf("unexpected profile: %+v", p)
+		}
+	})
+
+	t.Run("stores comparison demographics encrypted", func(t *testing.T) {
+		// Arrange
+		s := store.NewMemoryStore()
+		repo := newTestRepository(t, s, "key-1")
+		saved := Default("user-1", UnitPounds)
+		saved.StrengthComparison = true
+		saved.Sex, saved.Bodyweight, saved.BirthYear = SexFemale, 150, 1990
+
+		// Act
+		if err := repo.Save(ctx, saved); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		items, _ := s.Query(ctx, store.UserPK("user-1"), profileSK)
+		p, _ := repo.Get(ctx, "user-1")
+
+		// Assert
+		if strings.Contains(string(items[0].Data), SexFemale) || strings.Contains(string(items[0].Data), "1990") {
+			t.Errorf("expected demographics encrypted at rest, got %s", items[0].Data)
+		}
+		if !p.StrengthComparison || p.Sex != SexFemale || p.BirthYear != 1990 || math.Abs(p.BodyweightKg()-68) > 0.1 {
+			t.Errorf("unexpected profile: %+v", p)
+		}
+	})
+
+	t.Run("refuses sealed fields moved to another user", func(t *testing.T) {
+		// Arrange
+		s := store.NewMemoryStore()
+		repo := newTestRepository(t, s, "key-1")
+		repo.Save(ctx, custom())
+		var stored storedProfile
+		s.Get(ctx, store.UserPK("user-1"), profileSK, &stored)
+		stored.UserID = "user-2"
+		s.Put(ctx, store.UserPK("user-2"), profileSK, stored)
+
+		// Act
+		_, err := repo.Get(ctx, "user-2")
+
+		// Assert
+		if err == nil {
+			t.Error("expected error decrypting another user's fields")
+		}
+	})
+
+	t.Run("re-encrypts under the current key after rotation", func(t *testing.T) {
+		// Arrange
+		s := store.NewMemoryStore()
+		newTestRepository(t, s, "key-1").Save(ctx, custom())
+		newTestRepository(t, s, "key-1").Save(ctx, Default("user-2", UnitKilograms))
+		rotated := newTestRepository(t, s, "key-2")
+
+		// Act
+		userIDs, _ := rotated.Encrypted(ctx)
+		first, err := rotated.Reencrypt(ctx, "user-1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		second, _ := rotated.Reencrypt(ctx, "user-1")
+		var stored storedProfile
+		s.Get(ctx, store.UserPK("user-1"), profileSK, &stored)
+		p, _ := rotated.Get(ctx, "user-1")
+
+		// Assert
+		if len(userIDs) != 1 || userIDs[0] != "user-1" {
+			t.Errorf("expected only user-1 indexed, got %v", userIDs)
+		}
+		if !first || second {
+			t.Errorf("expected one re-encryption, got %v then %v", first, second)
+		}
+		if stored.Sensitive.KeyID != "key-2" || p.InjuryHistory != "Left ACL reconstruction 2021" {
+			t.Errorf("unexpected profile after rotation: key %s, %+v", stored.Sensitive.KeyID, p)
+		}
+	})
+}
+
+func TestProfile_Validate(t *testing.T) {
+	t.Run("validates heart rate zone configuration", func(t *testing.T) {
+		// Arrange
+		p := Default("user-1", UnitKilograms)
+		p.HeartRate = &hrzone.Config{Method: hrzone.MethodMaxHR}
+
+		// Act
+		err := p.Validate()
+
+		// Assert
+		if err == nil {
+			t.Error("expected error for max method without maxHr")
+		}
+	})
+
+	t.Run("rejects an unknown sex", func(t *testing.T) {
+		// Arrange
+		p := Default("user-1", UnitKilograms)
+		p.Sex = "other"
+
+		// Act
+		err := p.Validate()
+
+		// Assert
+		if err == nil {
+			t.Error("expected error for an unknown sex")
+		}
+	})
+	t.Run("rejects empty machine stacks", func(t *testing.T) {
+		// Arrange
+		p := Default("user-1", UnitKilograms)
+		p.Rounding.Stacks = map[string][]float64{"Lat Pulldown": {}}
+
+		// Act
+		err := p.Validate()
+
+		// Assert
+		if err == nil {
+			t.Error("expected error for a stack without weights")
+		}
+	})
+
+	t.Run("rejects plates the calculator cannot search", func(t *testing.T) {
+		// Arrange
+		p := Default("user-1", UnitKilograms)
+		p.Plates = append(p.Plates, tools.Plate{Weight: 0.05, Pairs: 1})
+
+		// Act
+		err := p.Validate()
+
+		// Assert
+		if err == nil {
+			t.Error("expected error for a plate below the minimum weight")
+		}
+	})
+
+	t.Run("counts health notes in characters, not bytes", func(t *testing.T) {
+		// Arrange
+		p := Default("user-1", UnitKilograms)
+		p.HealthNotes = strings.Repeat("é", maxNoteLength)
+		p.InjuryHistory = strings.Repeat("膝", maxNoteLength)
+
+		// Act
+		err := p.Validate()
+
+		// Assert
+		if err != nil {
+			t.Errorf("expected notes of %d characters to be accepted, got %v", maxNoteLength, err)
+		}
+	})
+}
+
+func TestProfile_LoadRounding(t *testing.T) {
+	t.Run("fills unset increments from the unit's defaults", func(t *testing.T) {
+		// Arrange
+		p := &Profile{Unit: UnitPounds, Rounding: &tools.Rounding{Dumbbell: 2.5}}
+
+		// Act
+		r := p.LoadRounding()
+
+		// Assert
+		if r.Barbell != 5 || r.Dumbbell != 2.5 || r.Machine != 10 {
+			t.Errorf("unexpected rounding: %+v", r)
+		}
+	})
+}
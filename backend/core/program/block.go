@@ -0,0 +1,181 @@
+package program
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// DateLayout is the format of block start and end dates
+const DateLayout = "2006-01-02"
+
+// Block types
+const (
+	BlockAccumulation    = "accumulation"
+	BlockIntensification = "intensification"
+	BlockDeload          = "deload"
+)
+
+// maxBlockDays is the longest a block can run: half a year
+const maxBlockDays = 182
+
+// Intensity and volume bounds for a block's weeks
+const (
+	maxIntensity     = 1.2
+	maxIntensityStep = 0.1
+	maxVolume        = 2
+)
+
+// blockDefaults are each block type's first-week intensity and volume when
+// the block sets neither: accumulation trades load for more sets, and
+// intensification the reverse, while a deload cuts both
+var blockDefaults = map[string]struct{ intensity, volume float64 }{
+	BlockAccumulation:    {intensity: 0.9, volume: 1.2},
+	BlockIntensification: {intensity: 1, volume: 0.8},
+	BlockDeload:          {intensity: 0.6, volume: 0.5},
+}
+
+// Block is a mesocycle of a program: a run of weeks from StartDate to EndDate
+// inclusive with one training emphasis. The program is the macrocycle, and each
+// week of a block is a microcycle. Intensity is the load of the block's first
+// week as a fraction of the prescribed weight, and IntensityStep is added to
+// it each week after; Volume scales the prescribed sets. Intensity and Volume
+// default by type
+type Block struct {
+	Name          string  `json:"name,omitempty"`
+	Type          string  `json:"type"`
+	StartDate     string  `json:"startDate"`
+	EndDate       string  `json:"endDate"`
+	Intensity     float64 `json:"intensity,omitempty"`
+	IntensityStep float64 `json:"intensityStep,omitempty"`
+	Volume        float64 `json:"volume,omitempty"`
+}
+
+// Microcycle is one week of a block with its planned intensity and volume.
+// Week counts from 1, and the last week may be shorter than seven days
+type Microcycle struct {
+	Week      int     `json:"week"`
+	StartDate string  `json:"startDate"`
+	EndDate   string  `json:"endDate"`
+	Intensity float64 `json:"intensity"`
+	Volume    float64 `json:"volume"`
+}
+
+// Validate checks the block's type, dates and planned intensity and volume
+func (b *Block) Validate() error {
+	if _, ok := blockDefaults[b.Type]; !ok {
+		return fmt.Errorf("unknown block type %q; use accumulation, intensification or deload", b.Type)
+	}
+	start, end, err := b.dates()
+	if err != nil {
+		return err
+	}
+	if end.Before(start) {
+		return fmt.Errorf("block %s ends before it starts", b.StartDate)
+	}
+	if end.Sub(start) >= maxBlockDays*24*time.Hour {
+		return fmt.Errorf("block %s must not run longer than %d days", b.StartDate, maxBlockDays)
+	}
+	if b.Intensity < 0 || b.Intensity > maxIntensity {
+		return errors.New("block intensity must be a fraction of the prescribed weight no greater than 1.2")
+	}
+	if math.Abs(b.IntensityStep) > maxIntensityStep {
+		return errors.New("block intensityStep must be between -0.1 and 0.1")
+	}
+	if b.Volume < 0 || b.Volume > maxVolume {
+		return errors.New("block volume must be a fraction of the prescribed sets no greater than 2")
+	}
+	return nil
+}
+
+// Microcycles returns the block's weeks in order
+func (b *Block) Microcycles() []Microcycle {
+	start, end, err := b.dates()
+	if err != nil {
+		return nil
+	}
+	var weeks []Microcycle
+	for week, day := 1, start; !day.After(end); week, day = week+1, day.AddDate(0, 0, 7) {
+		weeks = append(weeks, b.microcycle(week, day, end))
+	}
+	return weeks
+}
+
+// On returns the microcycle of the block containing day, and false when the
+// block does not cover it
+func (b *Block) On(day time.Time) (Microcycle, bool) {
+	start, end, err := b.dates()
+	date := day.Format(DateLayout)
+	if err != nil || date < b.StartDate || date > b.EndDate {
+		return Microcycle{}, false
+	}
+	midnight, _ := time.Parse(DateLayout, date)
+	week := int(midnight.Sub(start).Hours()/24)/7 + 1
+	return b.microcycle(week, start.AddDate(0, 0, 7*(week-1)), end), true
+}
+
+func (b *Block) microcycle(week int, start, blockEnd time.Time) Microcycle {
+	defaults := blockDefaults[b.Type]
+	intensity, volume := b.Intensity, b.Volume
+	if intensity == 0 {
+		intensity = defaults.intensity
+	}
+	if volume == 0 {
+		volume = defaults.volume
+	}
+	intensity = math.Min(math.Max(intensity+b.IntensityStep*float64(week-1), 0.1), maxIntensity)
+
+	end := start.AddDate(0, 0, 6)
+	if end.After(blockEnd) {
+		end = blockEnd
+	}
+	return Microcycle{
+		Week:      week,
+		StartDate: start.Format(DateLayout),
+		EndDate:   end.Format(DateLayout),
+		Intensity: math.Round(intensity*1000) / 1000,
+		Volume:    volume,
+	}
+}
+
+func (b *Block) dates() (time.Time, time.Time, error) {
+	start, err := time.Parse(DateLayout, b.StartDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("block startDate %q must be YYYY-MM-DD", b.StartDate)
+	}
+	end, err := time.Parse(DateLayout, b.EndDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("block endDate %q must be YYYY-MM-DD", b.EndDate)
+	}
+	return start, end, nil
+}
+
+// ValidateBlocks checks each block and that no two blocks overlap
+func ValidateBlocks(blocks []Block) error {
+	for i := range blocks {
+		if err := blocks[i].Validate(); err != nil {
+			return err
+		}
+	}
+	ordered := append([]Block(nil), blocks...)
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].StartDate < ordered[j].StartDate })
+	for i := 1; i < len(ordered); i++ {
+		if ordered[i].StartDate <= ordered[i-1].EndDate {
+			return fmt.Errorf("block starting %s overlaps the block starting %s", ordered[i].StartDate, ordered[i-1].StartDate)
+		}
+	}
+	return nil
+}
+
+// BlockOn returns the block covering day and the microcycle of it containing
+// day, or nil when the program has no block then
+func (p *Program) BlockOn(day time.Time) (*Block, Microcycle) {
+	for i := range p.Blocks {
+		if m, ok := p.Blocks[i].On(day); ok {
+			return &p.Blocks[i], m
+		}
+	}
+	return nil, Microcycle{}
+}
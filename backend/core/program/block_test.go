@@ -0,0 +1,82 @@
+package program
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateBlocks(t *testing.T) {
+	tests := []struct {
+		name    string
+		blocks  []Block
+		wantErr bool
+	}{
+		{name: "valid", blocks: []Block{
+			{Type: BlockAccumulation, StartDate: "2024-03-04", EndDate: "2024-03-31"},
+			{Type: BlockIntensification, StartDate: "2024-04-01", EndDate: "2024-04-21", Intensity: 0.95, IntensityStep: 0.025},
+			{Type: BlockDeload, StartDate: "2024-04-22", EndDate: "2024-04-28"},
+		}},
+		{name: "unknown type", blocks: []Block{{Type: "peaking", StartDate: "2024-03-04", EndDate: "2024-03-31"}}, wantErr: true},
+		{name: "malformed date", blocks: []Block{{Type: BlockDeload, StartDate: "04/03/2024", EndDate: "2024-03-31"}}, wantErr: true},
+		{name: "ends before it starts", blocks: []Block{{Type: BlockDeload, StartDate: "2024-03-10", EndDate: "2024-03-04"}}, wantErr: true},
+		{name: "longer than half a year", blocks: []Block{{Type: BlockAccumulation, StartDate: "2024-01-01", EndDate: "2024-07-31"}}, wantErr: true},
+		{name: "intensity too high", blocks: []Block{{Type: BlockIntensification, StartDate: "2024-03-04", EndDate: "2024-03-31", Intensity: 1.5}}, wantErr: true},
+		{name: "overlapping", blocks: []Block{
+			{Type: BlockDeload, StartDate: "2024-04-01", EndDate: "2024-04-07"},
+			{Type: BlockAccumulation, StartDate: "2024-03-04", EndDate: "2024-04-01"},
+		}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateBlocks(tt.blocks); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestBlock_Microcycles(t *testing.T) {
+	t.Run("ramps intensity week by week from the type's default", func(t *testing.T) {
+		// Arrange
+		b := Block{Type: BlockAccumulation, StartDate: "2024-03-04", EndDate: "2024-03-20", IntensityStep: 0.025}
+
+		// Act
+		weeks := b.Microcycles()
+
+		// Assert
+		if len(weeks) != 3 || weeks[0].Intensity != 0.9 || weeks[2].Intensity != 0.95 || weeks[0].Volume != 1.2 {
+			t.Errorf("unexpected weeks: %+v", weeks)
+		}
+		if weeks[2].StartDate != "2024-03-18" || weeks[2].EndDate != "2024-03-20" {
+			t.Errorf("expected the last week cut short at the block's end, got %+v", weeks[2])
+		}
+	})
+}
+
+func TestProgram_BlockOn(t *testing.T) {
+	p := Program{Blocks: []Block{
+		{Type: BlockAccumulation, StartDate: "2024-03-04", EndDate: "2024-03-31"},
+		{Type: BlockDeload, StartDate: "2024-04-01", EndDate: "2024-04-07"},
+	}}
+
+	t.Run("finds the block and week containing the day", func(t *testing.T) {
+		// Act
+		block, week := p.BlockOn(time.Date(2024, 3, 19, 18, 0, 0, 0, time.UTC))
+
+		// Assert
+		if block == nil || block.Type != BlockAccumulation || week.Week != 3 || week.StartDate != "2024-03-18" {
+			t.Errorf("unexpected block %+v, week %+v", block, week)
+		}
+	})
+
+	t.Run("days outside every block have none", func(t *testing.T) {
+		// Act
+		block, _ := p.BlockOn(time.Date(2024, 4, 8, 0, 0, 0, 0, time.UTC))
+
+		// Assert
+		if block != nil {
+			t.Errorf("expected no block, got %+v", block)
+		}
+	})
+}
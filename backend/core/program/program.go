@@ -0,0 +1,248 @@
+package program
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"athlete-forge/store"
+	"athlete-forge/tempo"
+)
+
+const programSKPrefix = "PROGRAM#"
+
+// SKPrefix begins the sort key of each program, for configuring read-through caches
+const SKPrefix = programSKPrefix
+
+// Progression rule types
+const (
+	RuleLinear            = "linear"
+	RuleDoubleProgression = "double"
+	RulePercentage        = "percentage"
+	RuleRPE               = "rpe"
+)
+
+// How a percentage rule's training max is updated: by its increment at the end
+// of each cycle whose last session hit its reps, from the estimated 1RM of the
+// last set of each session taken as many reps as possible, or from the best
+// estimated 1RM of test weeks, the steps of the wave at or above the training max
+const (
+	TrainingMaxCycle = "cycle"
+	TrainingMaxAMRAP = "amrap"
+	TrainingMaxTest  = "test"
+)
+
+// Rule configures how an exercise's prescription advances after each session
+type Rule struct {
+	Type string `json:"type"`
+
+	// Increment is the load added on success (linear, double) or to the training max per cycle (percentage)
+	Increment float64 `json:"increment,omitempty"`
+
+	// RoundTo is the loading granularity applied to computed weights
+	RoundTo float64 `json:"roundTo,omitempty"`
+
+	// MinReps and MaxReps bound the rep range for double progression
+	MinReps int `json:"minReps,omitempty"`
+	MaxReps int `json:"maxReps,omitempty"`
+
+	// TrainingMax, Percentages and Step drive percentage-based waves.
+	// TrainingMaxUpdate picks how the training max is updated, cycle by
+	// default, and TrainingMaxPercent is the fraction of an estimated 1RM the
+	// amrap and test updates set it to, 0.9 by default
+	TrainingMax        float64   `json:"trainingMax,omitempty"`
+	Percentages        []float64 `json:"percentages,omitempty"`
+	Step               int       `json:"step,omitempty"`
+	TrainingMaxUpdate  string    `json:"trainingMaxUpdate,omitempty"`
+	TrainingMaxPercent float64   `json:"trainingMaxPercent,omitempty"`
+
+	// TargetRPE is the effort level RPE autoregulation steers towards
+	TargetRPE float64 `json:"targetRpe,omitempty"`
+
+	// TargetVelocity, in metres per second, and VelocityLossLimit, a percentage,
+	// autoregulate any rule by the bar velocities logged: load is steered towards
+	// the target velocity and is not increased after a set loses more than the limit
+	TargetVelocity    float64 `json:"targetVelocity,omitempty"`
+	VelocityLossLimit float64 `json:"velocityLossLimit,omitempty"`
+
+	// Failures counts consecutive missed linear sessions before a deload
+	Failures int `json:"failures,omitempty"`
+}
+
+// Prescription is the planned work for one exercise in the next session. Sets can be
+// prescribed by effort instead of load with RPE or reps in reserve (RIR); Weight is
+// then a fallback used until the exercise has a recent estimated 1RM. Tempo is
+// optional tempo notation such as 3-1-1-0. AMRAP takes the last set for as many
+// reps as possible, with Reps the minimum, as in a 5/3/1 "5+" set
+type Prescription struct {
+	Exercise string  `json:"exercise"`
+	Sets     int     `json:"sets"`
+	Reps     int     `json:"reps"`
+	Weight   float64 `json:"weight"`
+	AMRAP    bool    `json:"amrap,omitempty"`
+	RPE      float64 `json:"rpe,omitempty"`
+	RIR      *int    `json:"rir,omitempty"`
+	Tempo    string  `json:"tempo,omitempty"`
+	Rule     Rule    `json:"rule"`
+}
+
+// Effort returns the prescribed RPE, converting RIR as 10 minus reps in reserve,
+// or 0 when the prescription is by load
+func (p *Prescription) Effort() float64 {
+	if p.RIR != nil {
+		return float64(10 - *p.RIR)
+	}
+	return p.RPE
+}
+
+// Program is a user's running program instance. Blocks periodize it into
+// mesocycles, which scale its prescriptions in the weeks they cover
+type Program struct {
+	ID                string         `json:"id"`
+	UserID            string         `json:"userId"`
+	Name              string         `json:"name"`
+	Exercises         []Prescription `json:"exercises"`
+	SessionsCompleted int            `json:"sessionsCompleted"`
+	Schedule          *Schedule      `json:"schedule,omitempty"`
+	Blocks            []Block        `json:"blocks,omitempty"`
+	GymID             string         `json:"gymId,omitempty"`
+	CreatedAt         time.Time      `json:"createdAt"`
+	UpdatedAt         time.Time      `json:"updatedAt"`
+}
+
+// Validate checks the program and each exercise's rule configuration
+func (p *Program) Validate() error {
+	if p.Name == "" {
+		return errors.New("program name is required")
+	}
+	if len(p.Exercises) == 0 {
+		return errors.New("program must have at least one exercise")
+	}
+	for _, prescription := range p.Exercises {
+		if err := prescription.Validate(); err != nil {
+			return fmt.Errorf("exercise %q: %w", prescription.Exercise, err)
+		}
+	}
+	if p.Schedule != nil {
+		if err := p.Schedule.Validate(); err != nil {
+			return err
+		}
+	}
+	return ValidateBlocks(p.Blocks)
+}
+
+// Validate checks that the prescription can be progressed by its rule
+func (p *Prescription) Validate() error {
+	if p.Exercise == "" {
+		return errors.New("exercise name is required")
+	}
+	if p.Sets <= 0 || p.Reps <= 0 || p.Weight < 0 {
+		return errors.New("sets and reps must be positive and weight must not be negative")
+	}
+	if p.RPE != 0 && p.RIR != nil {
+		return errors.New("prescribe effort by rpe or rir, not both")
+	}
+	if p.RPE != 0 && (p.RPE < 1 || p.RPE > 10) {
+		return errors.New("rpe must be between 1 and 10")
+	}
+	if p.RIR != nil && (*p.RIR < 0 || *p.RIR > 9) {
+		return errors.New("rir must be between 0 and 9")
+	}
+	if err := tempo.Validate(p.Tempo); err != nil {
+		return err
+	}
+
+	rule := p.Rule
+	if rule.TargetVelocity < 0 || rule.TargetVelocity > 5 {
+		return errors.New("targetVelocity must be between 0 and 5 m/s")
+	}
+	if rule.VelocityLossLimit < 0 || rule.VelocityLossLimit > 100 {
+		return errors.New("velocityLossLimit must be a percentage between 0 and 100")
+	}
+	switch rule.Type {
+	case RuleLinear:
+		if rule.Increment <= 0 {
+			return errors.New("linear progression requires a positive increment")
+		}
+	case RuleDoubleProgression:
+		if rule.Increment <= 0 || rule.MinReps <= 0 || rule.MaxReps < rule.MinReps {
+			return errors.New("double progression requires a positive increment and a valid rep range")
+		}
+	case RulePercentage:
+		if rule.TrainingMax <= 0 || len(rule.Percentages) == 0 {
+			return errors.New("percentage progression requires a training max and percentages")
+		}
+		for _, pct := range rule.Percentages {
+			if pct <= 0 || pct > 1.2 {
+				return errors.New("percentages must be fractions of the training max, e.g. 0.85")
+			}
+		}
+		switch rule.TrainingMaxUpdate {
+		case "", TrainingMaxCycle, TrainingMaxAMRAP, TrainingMaxTest:
+		default:
+			return fmt.Errorf("unknown trainingMaxUpdate %q; use cycle, amrap or test", rule.TrainingMaxUpdate)
+		}
+		if rule.TrainingMaxPercent != 0 && (rule.TrainingMaxPercent < 0.8 || rule.TrainingMaxPercent > 1) {
+			return errors.New("trainingMaxPercent must be between 0.8 and 1")
+		}
+	case RuleRPE:
+		if rule.TargetRPE < 1 || rule.TargetRPE > 10 {
+			return errors.New("rpe progression requires a target rpe between 1 and 10")
+		}
+	default:
+		return fmt.Errorf("unknown progression rule %q", rule.Type)
+	}
+	return nil
+}
+
+// Repository loads and saves program instances
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Get returns the program with id owned by userID
+func (r *Repository) Get(ctx context.Context, userID, id string) (*Program, error) {
+	var p Program
+	if err := r.store.Get(ctx, store.UserPK(userID), programSKPrefix+id, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// List returns all of userID's programs
+func (r *Repository) List(ctx context.Context, userID string) ([]Program, error) {
+	items, err := r.store.Query(ctx, store.UserPK(userID), programSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list programs: %w", err)
+	}
+
+	programs := make([]Program, 0, len(items))
+	for _, item := range items {
+		var p Program
+		if err := item.Decode(&p); err != nil {
+			return nil, err
+		}
+		programs = append(programs, p)
+	}
+	return programs, nil
+}
+
+// Save validates and stores p, assigning an ID to new programs
+func (r *Repository) Save(ctx context.Context, p *Program) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	if p.ID == "" {
+		p.ID = store.NewID()
+	}
+	if err := r.store.Put(ctx, store.UserPK(p.UserID), programSKPrefix+p.ID, p); err != nil {
+		return fmt.Errorf("failed to save program: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package program
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"athlete-forge/store"
+)
+
+func validProgram() *Program {
+	return &Program{
+		UserID: "user-1",
+		Name:   "5x5",
+		Exercises: []Prescription{
+			{Exercise: "Squat", Sets: 5, Reps: 5, Weight: 100, Rule: Rule{Type: RuleLinear, Increment: 2.5}},
+		},
+	}
+}
+
+func TestPrescription_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr bool
+	}{
+		{name: "linear with increment", rule: Rule{Type: RuleLinear, Increment: 2.5}},
+		{name: "linear without increment", rule: Rule{Type: RuleLinear}, wantErr: true},
+		{name: "double with valid range", rule: Rule{Type: RuleDoubleProgression, Increment: 2.5, MinReps: 8, MaxReps: 12}},
+		{name: "double with inverted range", rule: Rule{Type: RuleDoubleProgression, Increment: 2.5, MinReps: 12, MaxReps: 8}, wantErr: true},
+		{name: "percentage with wave", rule: Rule{Type: RulePercentage, TrainingMax: 200, Percentages: []float64{0.7, 0.8}}},
+		{name: "percentage given whole numbers", rule: Rule{Type: RulePercentage, TrainingMax: 200, Percentages: []float64{70}}, wantErr: true},
+		{name: "rpe with target", rule: Rule{Type: RuleRPE, TargetRPE: 8}},
+		{name: "rpe out of range", rule: Rule{Type: RuleRPE, TargetRPE: 11}, wantErr: true},
+		{name: "unknown rule", rule: Rule{Type: "magic"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Prescription{Exercise: "Squat", Sets: 3, Reps: 5, Weight: 100, Rule: tt.rule}
+			if err := p.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestPrescription_Effort(t *testing.T) {
+	rir := func(v int) *int { return &v }
+	tests := []struct {
+		name       string
+		rpe        float64
+		rir        *int
+		wantEffort float64
+		wantErr    bool
+	}{
+		{name: "by load"},
+		{name: "by rpe", rpe: 8, wantEffort: 8},
+		{name: "by rir", rir: rir(2), wantEffort: 8},
+		{name: "rir to failure", rir: rir(0), wantEffort: 10},
+		{name: "rpe and rir", rpe: 8, rir: rir(2), wantEffort: 8, wantErr: true},
+		{name: "rpe out of range", rpe: 12, wantEffort: 12, wantErr: true},
+		{name: "negative rir", rir: rir(-1), wantEffort: 11, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Prescription{Exercise: "Squat", Sets: 3, Reps: 5, RPE: tt.rpe, RIR: tt.rir, Rule: Rule{Type: RuleRPE, TargetRPE: 8}}
+			if err := p.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got := p.Effort(); got != tt.wantEffort {
+				t.Errorf("expected effort %v, got %v", tt.wantEffort, got)
+			}
+		})
+	}
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("save assigns an ID and get returns the program", func(t *testing.T) {
+		// Arrange
+		repo := NewRepository(store.NewMemoryStore())
+		p := validProgram()
+
+		// Act
+		if err := repo.Save(ctx, p); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got, err := repo.Get(ctx, "user-1", p.ID)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if p.ID == "" || got.Name != "5x5" {
+			t.Errorf("unexpected program: %+v", got)
+		}
+	})
+
+	t.Run("get of another user's program is not found", func(t *testing.T) {
+		// Arrange
+		repo := NewRepository(store.NewMemoryStore())
+		p := validProgram()
+		repo.Save(ctx, p)
+
+		// Act
+		_, err := repo.Get(ctx, "user-2", p.ID)
+
+		// Assert
+		if !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package program
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	// The Lambda runtime has no zoneinfo database, so time zones are embedded
+	_ "time/tzdata"
+)
+
+// Weekdays in schedule order, as used in Schedule.Days
+var Weekdays = []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}
+
+var startTimePattern = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+// Schedule is when a program's sessions are planned each week
+type Schedule struct {
+	Days            []string `json:"days"`
+	StartTime       string   `json:"startTime"`
+	DurationMinutes int      `json:"durationMinutes"`
+	TimeZone        string   `json:"timeZone"`
+}
+
+// Validate checks the days, time of day and time zone
+func (s *Schedule) Validate() error {
+	if len(s.Days) == 0 {
+		return errors.New("schedule must include at least one day")
+	}
+	seen := map[string]bool{}
+	for _, day := range s.Days {
+		if WeekdayIndex(day) < 0 {
+			return fmt.Errorf("unknown schedule day %q; use mon, tue, wed, thu, fri, sat or sun", day)
+		}
+		if seen[day] {
+			return fmt.Errorf("schedule day %q is repeated", day)
+		}
+		seen[day] = true
+	}
+	if !startTimePattern.MatchString(s.StartTime) {
+		return errors.New("schedule startTime must be HH:MM")
+	}
+	if s.DurationMinutes <= 0 || s.DurationMinutes > 600 {
+		return errors.New("schedule durationMinutes must be between 1 and 600")
+	}
+	if _, err := time.LoadLocation(s.TimeZone); err != nil || s.TimeZone == "" {
+		return fmt.Errorf("unknown schedule timeZone %q", s.TimeZone)
+	}
+	return nil
+}
+
+// WeekdayIndex returns day's position in Weekdays, or -1 when it is not a weekday
+func WeekdayIndex(day string) int {
+	for i, d := range Weekdays {
+		if d == day {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,28 @@
+package program
+
+import "testing"
+
+func TestSchedule_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule Schedule
+		wantErr  bool
+	}{
+		{name: "valid", schedule: Schedule{Days: []string{"mon", "wed", "fri"}, StartTime: "07:30", DurationMinutes: 60, TimeZone: "Europe/London"}},
+		{name: "no days", schedule: Schedule{StartTime: "07:30", DurationMinutes: 60, TimeZone: "UTC"}, wantErr: true},
+		{name: "unknown day", schedule: Schedule{Days: []string{"monday"}, StartTime: "07:30", DurationMinutes: 60, TimeZone: "UTC"}, wantErr: true},
+		{name: "repeated day", schedule: Schedule{Days: []string{"mon", "mon"}, StartTime: "07:30", DurationMinutes: 60, TimeZone: "UTC"}, wantErr: true},
+		{name: "bad start time", schedule: Schedule{Days: []string{"mon"}, StartTime: "7:30pm", DurationMinutes: 60, TimeZone: "UTC"}, wantErr: true},
+		{name: "no duration", schedule: Schedule{Days: []string{"mon"}, StartTime: "07:30", TimeZone: "UTC"}, wantErr: true},
+		{name: "unknown time zone", schedule: Schedule{Days: []string{"mon"}, StartTime: "07:30", DurationMinutes: 60, TimeZone: "Mars/Olympus"}, wantErr: true},
+		{name: "missing time zone", schedule: Schedule{Days: []string{"mon"}, StartTime: "07:30", DurationMinutes: 60}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.schedule.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package program
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"athlete-forge/exercise"
+)
+
+// TemplateFormat identifies a document as a portable program template
+const TemplateFormat = "athlete-forge/program-template"
+
+// TemplateVersion is the version of the template format this build writes and
+// the newest it reads. Fields added within a version are ignored by older
+// readers; a change they could not ignore gets a new version
+const TemplateVersion = 1
+
+// ErrIncompatibleTemplate is returned for documents that are not templates this
+// build can read, such as those of a newer version
+var ErrIncompatibleTemplate = errors.New("incompatible program template")
+
+// Template is a program in a portable form that can be shared and imported by
+// other users. It keeps the prescriptions and their progression rules but none
+// of the owner's state: IDs, gym, schedule, sessions completed and where each
+// rule has got to
+type Template struct {
+	Format    string         `json:"format"`
+	Version   int            `json:"version"`
+	Name      string         `json:"name"`
+	Exercises []Prescription `json:"exercises"`
+}
+
+// Incompatibility is a part of an imported template that was changed or left
+// out because this build cannot use it as written
+type Incompatibility struct {
+	Exercise string `json:"exercise,omitempty"`
+	Message  string `json:"message"`
+}
+
+// ToTemplate returns p as a template for sharing
+func ToTemplate(p *Program) Template {
+	t := Template{
+		Format:    TemplateFormat,
+		Version:   TemplateVersion,
+		Name:      p.Name,
+		Exercises: make([]Prescription, len(p.Exercises)),
+	}
+	for i, prescription := range p.Exercises {
+		prescription.Rule.Step = 0
+		prescription.Rule.Failures = 0
+		t.Exercises[i] = prescription
+	}
+	return t
+}
+
+// FromTemplate returns the program t describes, without an owner, and what it
+// had to change or leave out. Exercise names are resolved against the exercise
+// catalog, ignoring case, so substitutions and equipment checks apply to them;
+// names it does not know are kept as custom exercises and reported. Exercises
+// whose prescription is invalid, such as those using a progression rule this
+// build does not know, are left out. It returns ErrIncompatibleTemplate when t
+// is not a template of a supported version, and a validation error when
+// nothing usable remains
+func FromTemplate(t Template) (*Program, []Incompatibility, error) {
+	if t.Format != TemplateFormat {
+		return nil, nil, fmt.Errorf("%w: format must be %q", ErrIncompatibleTemplate, TemplateFormat)
+	}
+	if t.Version < 1 || t.Version > TemplateVersion {
+		return nil, nil, fmt.Errorf("%w: version %d is not supported; the newest supported is %d", ErrIncompatibleTemplate, t.Version, TemplateVersion)
+	}
+
+	p := &Program{Name: t.Name, Exercises: []Prescription{}}
+	incompatibilities := []Incompatibility{}
+	for _, prescription := range t.Exercises {
+		prescription.Exercise = strings.TrimSpace(prescription.Exercise)
+		prescription.Rule.Step = 0
+		prescription.Rule.Failures = 0
+		if err := prescription.Validate(); err != nil {
+			incompatibilities = append(incompatibilities, Incompatibility{
+				Exercise: prescription.Exercise,
+				Message:  "left out: " + err.Error(),
+			})
+			continue
+		}
+		if known, ok := exercise.Lookup(prescription.Exercise); ok {
+			prescription.Exercise = known.Name
+		} else {
+			incompatibilities = append(incompatibilities, Incompatibility{
+				Exercise: prescription.Exercise,
+				Message:  "not in the exercise catalog; kept as a custom exercise without substitutes or equipment checks",
+			})
+		}
+		p.Exercises = append(p.Exercises, prescription)
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, incompatibilities, err
+	}
+	return p, incompatibilities, nil
+}
@@ -0,0 +1,76 @@
+package program
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTemplate(t *testing.T) {
+	t.Run("export leaves out the owner's state and import restores the program", func(t *testing.T) {
+		// Arrange
+		p := validProgram()
+		p.ID = "p1"
+		p.SessionsCompleted = 7
+		p.GymID = "g1"
+		p.Exercises[0].Rule.Failures = 2
+
+		// Act
+		exported := ToTemplate(p)
+		imported, incompatibilities, err := FromTemplate(exported)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if exported.Format != TemplateFormat || exported.Version != TemplateVersion || exported.Exercises[0].Rule.Failures != 0 {
+			t.Errorf("unexpected template: %+v", exported)
+		}
+		if p.Exercises[0].Rule.Failures != 2 {
+			t.Error("expected exporting to leave the program unchanged")
+		}
+		if imported.ID != "" || imported.UserID != "" || imported.GymID != "" || imported.SessionsCompleted != 0 || imported.Name != p.Name {
+			t.Errorf("expected a new program without an owner, got %+v", imported)
+		}
+		if len(incompatibilities) != 0 {
+			t.Errorf("expected no incompatibilities, got %+v", incompatibilities)
+		}
+	})
+
+	t.Run("import names catalog exercises as the catalog does, reports custom ones and leaves out invalid ones", func(t *testing.T) {
+		// Arrange
+		tmpl := Template{Format: TemplateFormat, Version: 1, Name: "Community", Exercises: []Prescription{
+			{Exercise: " Squat ", Sets: 5, Reps: 5, Weight: 100, Rule: Rule{Type: RuleLinear, Increment: 2.5}},
+			{Exercise: "Zercher Squat", Sets: 3, Reps: 5, Weight: 80, Rule: Rule{Type: RuleLinear, Increment: 2.5}},
+			{Exercise: "Bench Press", Sets: 3, Reps: 5, Weight: 60, Rule: Rule{Type: "wave"}},
+		}}
+
+		// Act
+		p, incompatibilities, err := FromTemplate(tmpl)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(p.Exercises) != 2 || p.Exercises[0].Exercise != "squat" || p.Exercises[1].Exercise != "Zercher Squat" {
+			t.Errorf("unexpected exercises: %+v", p.Exercises)
+		}
+		if len(incompatibilities) != 2 || incompatibilities[0].Exercise != "Zercher Squat" || incompatibilities[1].Exercise != "Bench Press" {
+			t.Errorf("unexpected incompatibilities: %+v", incompatibilities)
+		}
+	})
+
+	t.Run("import rejects other formats and newer versions", func(t *testing.T) {
+		for _, tmpl := range []Template{
+			{Format: "other", Version: 1},
+			{Format: TemplateFormat, Version: TemplateVersion + 1},
+		} {
+			// Act
+			_, _, err := FromTemplate(tmpl)
+
+			// Assert
+			if !errors.Is(err, ErrIncompatibleTemplate) {
+				t.Errorf("expected ErrIncompatibleTemplate for %+v, got %v", tmpl, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package progression
+
+import (
+	"math"
+
+	"athlete-forge/program"
+	"athlete-forge/workout"
+)
+
+// Periodize returns copies of the prescriptions scaled to microcycle m of a
+// block: each weight by its intensity, rounded to the rule's granularity, and
+// each set count by its volume, keeping at least one set
+func Periodize(prescriptions []program.Prescription, m program.Microcycle) []program.Prescription {
+	scaled := make([]program.Prescription, len(prescriptions))
+	for i, p := range prescriptions {
+		if m.Intensity > 0 && m.Intensity != 1 {
+			p.Weight = RoundForRule(p.Weight*m.Intensity, p.Rule)
+		}
+		if m.Volume > 0 && m.Volume != 1 {
+			p.Sets = max(int(math.Round(float64(p.Sets)*m.Volume)), 1)
+		}
+		scaled[i] = p
+	}
+	return scaled
+}
+
+// inBlock restates the sets performed in microcycle m against p's own
+// prescription, so its rule judges them by the week's plan: sets that reached
+// the week's load count as reaching p's weight, and the week's set count is the
+// target. The returned prescription has the week's set count
+func inBlock(p program.Prescription, sets []workout.Set, m program.Microcycle) (program.Prescription, []workout.Set) {
+	planned := Periodize([]program.Prescription{p}, m)[0]
+	restated := make([]workout.Set, len(sets))
+	for i, set := range sets {
+		if set.Weight >= planned.Weight && set.Weight < p.Weight {
+			set.Weight = p.Weight
+		}
+		restated[i] = set
+	}
+	p.Sets = planned.Sets
+	return p, restated
+}
@@ -0,0 +1,54 @@
+package progression
+
+import (
+	"strings"
+	"time"
+
+	"athlete-forge/program"
+	"athlete-forge/records"
+)
+
+// RecentWindow is how far back estimated 1RMs are taken from when converting effort
+// prescriptions to loads; older bests no longer reflect current strength
+const RecentWindow = 42 * 24 * time.Hour
+
+// LoadSuggestion records the load computed for an effort-prescribed exercise and
+// the set it was estimated from
+type LoadSuggestion struct {
+	Exercise     string    `json:"exercise"`
+	Reps         int       `json:"reps"`
+	RPE          float64   `json:"rpe"`
+	Estimated1RM float64   `json:"estimated1rm"`
+	Weight       float64   `json:"weight"`
+	WorkoutID    string    `json:"workoutId"`
+	Date         time.Time `json:"date"`
+}
+
+// SuggestLoads sets the weight of every effort-prescribed exercise from its recent
+// best in recent, keyed as by records.Between. Exercises without a recent best keep
+// their fallback weight and are not reported
+func SuggestLoads(prescriptions []program.Prescription, recent map[string]records.Record) ([]program.Prescription, []LoadSuggestion) {
+	suggested := make([]program.Prescription, len(prescriptions))
+	copy(suggested, prescriptions)
+
+	suggestions := []LoadSuggestion{}
+	for i, p := range suggested {
+		rpe := p.Effort()
+		record, ok := recent[strings.ToLower(p.Exercise)]
+		if rpe == 0 || !ok {
+			continue
+		}
+
+		suggested[i].Weight = RoundForRule(records.LoadFor(record.Estimated1RM, p.Reps, rpe), p.Rule)
+		suggestions = append(suggestions, LoadSuggestion{
+			Exercise:     p.Exercise,
+			Reps:         p.Reps,
+			RPE:          rpe,
+			Estimated1RM: record.Estimated1RM,
+			Weight:       suggested[i].Weight,
+			WorkoutID:    record.WorkoutID,
+			Date:         record.Date,
+		})
+	}
+	return suggested, suggestions
+}
@@ -0,0 +1,42 @@
+package progression
+
+import (
+	"testing"
+
+	"athlete-forge/program"
+	"athlete-forge/records"
+)
+
+func TestSuggestLoads(t *testing.T) {
+	rir := 2
+	prescriptions := []program.Prescription{
+		{Exercise: "Squat", Sets: 3, Reps: 5, RPE: 8, Rule: program.Rule{Type: program.RuleRPE, TargetRPE: 8}},
+		{Exercise: "Bench", Sets: 3, Reps: 5, RIR: &rir, Weight: 60, Rule: program.Rule{Type: program.RuleRPE, TargetRPE: 8}},
+		{Exercise: "Row", Sets: 3, Reps: 8, Weight: 50, Rule: program.Rule{Type: program.RuleLinear, Increment: 2.5}},
+	}
+
+	t.Run("converts effort to load from the recent estimated 1RM", func(t *testing.T) {
+		// Arrange
+		recent := map[string]records.Record{
+			"squat": {Exercise: "Squat", Estimated1RM: 140, WorkoutID: "w1"},
+			"row":   {Exercise: "Row", Estimated1RM: 90, WorkoutID: "w1"},
+		}
+
+		// Act
+		suggested, loads := SuggestLoads(prescriptions, recent)
+
+		// Assert
+		if suggested[0].Weight != 112.5 {
+			t.Errorf("expected squat load 112.5, got %v", suggested[0].Weight)
+		}
+		if suggested[1].Weight != 60 || suggested[2].Weight != 50 {
+			t.Errorf("expected fallback and load prescriptions unchanged, got %+v", suggested)
+		}
+		if len(loads) != 1 || loads[0].Exercise != "Squat" || loads[0].WorkoutID != "w1" {
+			t.Errorf("unexpected suggestions: %+v", loads)
+		}
+		if prescriptions[0].Weight != 0 {
+			t.Errorf("expected input prescriptions untouched, got %+v", prescriptions[0])
+		}
+	})
+}
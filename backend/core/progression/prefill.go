@@ -0,0 +1,127 @@
+package progression
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"athlete-forge/program"
+	"athlete-forge/workout"
+)
+
+// Where a prefilled weight came from, in order of precedence
+const (
+	SourceEffort     = "effort"
+	SourceProgram    = "program"
+	SourceLastTime   = "last-time"
+	SourcePrescribed = "prescribed"
+)
+
+// Rep range bands used to decide whether a past set is comparable with a
+// prescription that has no range of its own
+const (
+	strengthMaxReps    = 5
+	hypertrophyMaxReps = 12
+)
+
+// Prefill records where an exercise's target weight came from. WorkoutID and
+// Date identify the comparable performance it was based on, if any
+type Prefill struct {
+	Exercise  string     `json:"exercise"`
+	Weight    float64    `json:"weight"`
+	Source    string     `json:"source"`
+	WorkoutID string     `json:"workoutId,omitempty"`
+	Date      *time.Time `json:"date,omitempty"`
+}
+
+// LastTime sets the target weight of each prescription from the most recent
+// comparable performance: the latest completed session of the exercise with a
+// set in the prescription's rep range. For each exercise, in order:
+//
+//  1. an effort prescription with a load in suggested keeps that load;
+//  2. when the comparable performance was logged in programID, the program's
+//     weight is kept, since its rule has already progressed from it;
+//  3. otherwise the heaviest comparable set's weight is used;
+//  4. with no comparable performance the prescribed weight is kept.
+//
+// prescriptions are not modified
+func LastTime(prescriptions []program.Prescription, suggested []LoadSuggestion, programID string, workouts []workout.Workout) ([]program.Prescription, []Prefill) {
+	filled := make([]program.Prescription, len(prescriptions))
+	copy(filled, prescriptions)
+
+	fromEffort := map[string]bool{}
+	for _, s := range suggested {
+		fromEffort[strings.ToLower(s.Exercise)] = true
+	}
+
+	completed := make([]workout.Workout, 0, len(workouts))
+	for _, w := range workouts {
+		if w.Status == workout.StatusCompleted && w.CompletedAt != nil {
+			completed = append(completed, w)
+		}
+	}
+	sort.Slice(completed, func(i, j int) bool { return completed[i].CompletedAt.After(*completed[j].CompletedAt) })
+
+	prefills := make([]Prefill, 0, len(filled))
+	for i, p := range filled {
+		prefill := Prefill{Exercise: p.Exercise, Weight: p.Weight, Source: SourcePrescribed}
+		if fromEffort[strings.ToLower(p.Exercise)] {
+			prefill.Source = SourceEffort
+			prefills = append(prefills, prefill)
+			continue
+		}
+		if w, weight, ok := comparable(completed, p); ok {
+			prefill.WorkoutID, prefill.Date = w.ID, w.CompletedAt
+			prefill.Source = SourceProgram
+			if w.ProgramID != programID {
+				prefill.Source = SourceLastTime
+				prefill.Weight = weight
+				filled[i].Weight = weight
+			}
+		}
+		prefills = append(prefills, prefill)
+	}
+	return filled, prefills
+}
+
+// comparable returns the latest of completed, newest first, with a set of p's
+// exercise in its rep range, and the heaviest such set's weight
+func comparable(completed []workout.Workout, p program.Prescription) (workout.Workout, float64, bool) {
+	low, high := repRange(p)
+	for _, w := range completed {
+		heaviest, found := 0.0, false
+		for _, exercise := range w.Exercises {
+			if !strings.EqualFold(exercise.Name, p.Exercise) {
+				continue
+			}
+			for _, set := range exercise.Sets {
+				if !set.Estimable() || set.Reps < low || set.Reps > high {
+					continue
+				}
+				if !found || set.Weight > heaviest {
+					heaviest, found = set.Weight, true
+				}
+			}
+		}
+		if found {
+			return w, heaviest, true
+		}
+	}
+	return workout.Workout{}, 0, false
+}
+
+// repRange returns the reps comparable with p's: a double progression's range,
+// or else the band p's reps fall in: 1-5, 6-12 or more than 12
+func repRange(p program.Prescription) (int, int) {
+	if p.Rule.Type == program.RuleDoubleProgression && p.Rule.MinReps > 0 && p.Rule.MaxReps >= p.Rule.MinReps {
+		return p.Rule.MinReps, p.Rule.MaxReps
+	}
+	switch {
+	case p.Reps <= strengthMaxReps:
+		return 1, strengthMaxReps
+	case p.Reps <= hypertrophyMaxReps:
+		return strengthMaxReps + 1, hypertrophyMaxReps
+	}
+	return hypertrophyMaxReps + 1, math.MaxInt
+}
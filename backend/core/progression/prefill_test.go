@@ -0,0 +1,76 @@
+package progression
+
+import (
+	"testing"
+	"time"
+
+	"athlete-forge/program"
+	"athlete-forge/workout"
+)
+
+func TestLastTime(t *testing.T) {
+	completed := func(id, programID string, day int, sets ...workout.Set) workout.Workout {
+		at := time.Date(2024, 3, day, 18, 0, 0, 0, time.UTC)
+		return workout.Workout{ID: id, ProgramID: programID, Status: workout.StatusCompleted, CompletedAt: &at,
+			Exercises: []workout.Exercise{{Name: "Squat", Sets: sets}}}
+	}
+	squat := program.Prescription{Exercise: "Squat", Sets: 3, Reps: 5, Weight: 60, Rule: program.Rule{Type: program.RuleLinear, Increment: 2.5}}
+
+	t.Run("uses the heaviest set of the latest session in the same rep range", func(t *testing.T) {
+		// Arrange
+		workouts := []workout.Workout{
+			completed("w1", "", 1, workout.Set{Reps: 5, Weight: 90}),
+			completed("w2", "", 3, workout.Set{Reps: 3, Weight: 100}, workout.Set{Reps: 5, Weight: 95}),
+			completed("w3", "", 5, workout.Set{Reps: 10, Weight: 70}),
+		}
+
+		// Act
+		filled, prefills := LastTime([]program.Prescription{squat}, nil, "p1", workouts)
+
+		// Assert
+		if filled[0].Weight != 100 || prefills[0].Source != SourceLastTime || prefills[0].WorkoutID != "w2" {
+			t.Errorf("expected 100 from w2, got %v from %+v", filled[0].Weight, prefills[0])
+		}
+	})
+
+	t.Run("keeps the program's weight when its rule progressed from the performance", func(t *testing.T) {
+		// Act
+		filled, prefills := LastTime([]program.Prescription{squat}, nil, "p1", []workout.Workout{completed("w1", "p1", 1, workout.Set{Reps: 5, Weight: 57.5})})
+
+		// Assert
+		if filled[0].Weight != 60 || prefills[0].Source != SourceProgram {
+			t.Errorf("expected the program's 60, got %v from %+v", filled[0].Weight, prefills[0])
+		}
+	})
+
+	t.Run("effort loads come first and unknown exercises keep their prescription", func(t *testing.T) {
+		// Arrange
+		bench := program.Prescription{Exercise: "Bench Press", Sets: 3, Reps: 8, Weight: 40}
+		effort := squat
+		effort.Weight = 97.5
+
+		// Act
+		filled, prefills := LastTime([]program.Prescription{effort, bench}, []LoadSuggestion{{Exercise: "squat"}}, "p1", []workout.Workout{completed("w1", "", 1, workout.Set{Reps: 5, Weight: 120})})
+
+		// Assert
+		if filled[0].Weight != 97.5 || prefills[0].Source != SourceEffort {
+			t.Errorf("expected the effort load kept, got %v from %+v", filled[0].Weight, prefills[0])
+		}
+		if filled[1].Weight != 40 || prefills[1].Source != SourcePrescribed {
+			t.Errorf("expected the prescribed weight, got %v from %+v", filled[1].Weight, prefills[1])
+		}
+	})
+
+	t.Run("double progression compares within its own range", func(t *testing.T) {
+		// Arrange
+		double := program.Prescription{Exercise: "Squat", Sets: 3, Reps: 8, Weight: 50, Rule: program.Rule{Type: program.RuleDoubleProgression, MinReps: 8, MaxReps: 15}}
+
+		// Act
+		filled, _ := LastTime([]program.Prescription{double}, nil, "p1", []workout.Workout{completed("w1", "", 1, workout.Set{Reps: 14, Weight: 55}, workout.Set{Reps: 6, Weight: 70})})
+
+		// Assert
+		if filled[0].Weight != 55 {
+			t.Errorf("expected the 14 rep set, got %v", filled[0].Weight)
+		}
+	})
+}
@@ -0,0 +1,247 @@
+package progression
+
+import (
+	"math"
+	"strings"
+
+	"athlete-forge/program"
+	"athlete-forge/tools"
+	"athlete-forge/workout"
+)
+
+const (
+	// defaultRoundTo is the loading granularity when a rule does not set RoundTo
+	defaultRoundTo = 2.5
+
+	// linearDeloadAfter is the number of consecutive misses that trigger a deload
+	linearDeloadAfter = 3
+
+	// linearDeloadFactor is the fraction of load kept after a deload
+	linearDeloadFactor = 0.9
+
+	// rpeLoadPerPoint approximates the load change for one point of RPE difference
+	rpeLoadPerPoint = 0.035
+)
+
+// Change records how one exercise's prescription moved after a session
+type Change struct {
+	Exercise       string  `json:"exercise"`
+	Rule           string  `json:"rule"`
+	PreviousWeight float64 `json:"previousWeight"`
+	NextWeight     float64 `json:"nextWeight"`
+	PreviousReps   int     `json:"previousReps"`
+	NextReps       int     `json:"nextReps"`
+	Reason         string  `json:"reason"`
+
+	// TrainingMax is set when a percentage rule's training max moved
+	TrainingMax *TrainingMaxChange `json:"trainingMax,omitempty"`
+}
+
+// Apply advances every prescription in p that was performed in w and returns the
+// changes. Each rule's result is autoregulated by any bar velocities logged and
+// rounded to the rule's granularity, then rounded by r to a load the exercise's
+// equipment can be set to. Sessions in one of p's blocks are judged against
+// that week's plan, and sessions in a deload block hold every prescription
+func Apply(p *program.Program, w *workout.Workout, r tools.Rounding) []Change {
+	performed := make(map[string]workout.Exercise, len(w.Exercises))
+	for _, exercise := range w.Exercises {
+		performed[strings.ToLower(exercise.Name)] = exercise
+	}
+	at := w.StartedAt
+	if w.CompletedAt != nil {
+		at = *w.CompletedAt
+	}
+	block, week := p.BlockOn(at)
+
+	changes := []Change{}
+	for i, prescription := range p.Exercises {
+		exercise, ok := performed[strings.ToLower(prescription.Exercise)]
+		if !ok || len(exercise.Sets) == 0 {
+			continue
+		}
+		if block != nil && block.Type == program.BlockDeload {
+			changes = append(changes, Change{
+				Exercise:       prescription.Exercise,
+				Rule:           prescription.Rule.Type,
+				PreviousWeight: prescription.Weight,
+				NextWeight:     prescription.Weight,
+				PreviousReps:   prescription.Reps,
+				NextReps:       prescription.Reps,
+				Reason:         "deload block, prescription held",
+			})
+			continue
+		}
+
+		judged, sets := prescription, exercise.Sets
+		if block != nil {
+			judged, sets = inBlock(prescription, sets, week)
+		}
+		next, reason := Next(judged, sets)
+		next.Sets = prescription.Sets
+		if adjusted, why, ok := Autoregulate(prescription, next, exercise.Sets); ok {
+			next, reason = adjusted, why
+		}
+		next.Weight = RoundLoad(next.Weight, next.Exercise, r)
+		p.Exercises[i] = next
+		changes = append(changes, Change{
+			Exercise:       prescription.Exercise,
+			Rule:           prescription.Rule.Type,
+			PreviousWeight: prescription.Weight,
+			NextWeight:     next.Weight,
+			PreviousReps:   prescription.Reps,
+			NextReps:       next.Reps,
+			Reason:         reason,
+			TrainingMax:    trainingMaxChange(prescription, next),
+		})
+	}
+
+	p.SessionsCompleted++
+	return changes
+}
+
+// Next computes the prescription for the following session from the sets performed
+func Next(p program.Prescription, sets []workout.Set) (program.Prescription, string) {
+	switch p.Rule.Type {
+	case program.RuleLinear:
+		return nextLinear(p, sets)
+	case program.RuleDoubleProgression:
+		return nextDouble(p, sets)
+	case program.RulePercentage:
+		return nextPercentage(p, sets)
+	case program.RuleRPE:
+		return nextRPE(p, sets)
+	default:
+		return p, "unknown rule, prescription unchanged"
+	}
+}
+
+// nextLinear adds the increment when every prescribed set hit its reps and deloads
+// after repeated misses
+func nextLinear(p program.Prescription, sets []workout.Set) (program.Prescription, string) {
+	if hitTarget(p, sets, p.Reps) {
+		p.Weight = roundTo(p.Weight+p.Rule.Increment, rounding(p.Rule, p.Rule.Increment))
+		p.Rule.Failures = 0
+		return p, "all sets completed, load increased"
+	}
+
+	p.Rule.Failures++
+	if p.Rule.Failures >= linearDeloadAfter {
+		p.Weight = roundTo(p.Weight*linearDeloadFactor, rounding(p.Rule, p.Rule.Increment))
+		p.Rule.Failures = 0
+		return p, "repeated misses, load deloaded"
+	}
+	return p, "target missed, load repeated"
+}
+
+// nextDouble adds reps within the range and only adds load once every set reaches the top
+func nextDouble(p program.Prescription, sets []workout.Set) (program.Prescription, string) {
+	if hitTarget(p, sets, p.Rule.MaxReps) {
+		p.Weight = roundTo(p.Weight+p.Rule.Increment, rounding(p.Rule, p.Rule.Increment))
+		p.Reps = p.Rule.MinReps
+		return p, "top of rep range reached, load increased and reps reset"
+	}
+
+	if hitTarget(p, sets, p.Reps) && p.Reps < p.Rule.MaxReps {
+		p.Reps++
+		return p, "rep target met, reps increased"
+	}
+	return p, "rep target missed, prescription repeated"
+}
+
+// nextPercentage steps through the percentage wave, updating the training max
+// from AMRAP sets or test weeks when the rule asks for it, or else raising it
+// at the end of each cycle
+func nextPercentage(p program.Prescription, sets []workout.Set) (program.Prescription, string) {
+	reason := "advanced to next week of the wave"
+	source := TrainingMaxSource(p.Rule)
+	tested := p.Rule.Step < len(p.Rule.Percentages) && p.Rule.Percentages[p.Rule.Step] >= 1
+
+	updated := false
+	switch {
+	case source == program.TrainingMaxAMRAP:
+		p, reason, updated = fromAMRAP(p, sets, reason)
+	case source == program.TrainingMaxTest && tested:
+		p, reason, updated = fromTest(p, sets, reason)
+	}
+
+	p.Rule.Step++
+	if p.Rule.Step >= len(p.Rule.Percentages) {
+		p.Rule.Step = 0
+		switch {
+		case source != program.TrainingMaxCycle && updated:
+		case source != program.TrainingMaxCycle:
+			reason = "cycle completed"
+		case hitTarget(p, sets, p.Reps):
+			p.Rule.TrainingMax += p.Rule.Increment
+			reason = "cycle completed, training max increased"
+		default:
+			reason = "cycle completed with missed reps, training max held"
+		}
+	}
+
+	p.Weight = PercentageWeight(p.Rule)
+	return p, reason
+}
+
+// nextRPE scales load by the gap between the target and the last set's reported RPE
+func nextRPE(p program.Prescription, sets []workout.Set) (program.Prescription, string) {
+	last := sets[len(sets)-1]
+	if last.RPE == 0 {
+		return p, "no rpe reported, prescription repeated"
+	}
+
+	adjustment := (p.Rule.TargetRPE - last.RPE) * rpeLoadPerPoint
+	base := last.Weight
+	if base == 0 {
+		base = p.Weight
+	}
+	p.Weight = roundTo(base*(1+adjustment), rounding(p.Rule, defaultRoundTo))
+
+	switch {
+	case adjustment > 0:
+		return p, "effort below target, load increased"
+	case adjustment < 0:
+		return p, "effort above target, load reduced"
+	default:
+		return p, "effort on target, load held"
+	}
+}
+
+// PercentageWeight returns the load for the rule's current step of the wave
+func PercentageWeight(rule program.Rule) float64 {
+	if len(rule.Percentages) == 0 {
+		return 0
+	}
+	step := rule.Step % len(rule.Percentages)
+	return roundTo(rule.TrainingMax*rule.Percentages[step], rounding(rule, defaultRoundTo))
+}
+
+// hitTarget reports whether the prescribed number of sets reached reps at no less than the prescribed weight
+func hitTarget(p program.Prescription, sets []workout.Set, reps int) bool {
+	successful := 0
+	for _, set := range sets {
+		if set.Reps >= reps && set.Weight >= p.Weight {
+			successful++
+		}
+	}
+	return successful >= p.Sets
+}
+
+// RoundForRule rounds weight to the rule's loading granularity
+func RoundForRule(weight float64, rule program.Rule) float64 {
+	return roundTo(weight, rounding(rule, defaultRoundTo))
+}
+
+func rounding(rule program.Rule, fallback float64) float64 {
+	if rule.RoundTo > 0 {
+		return rule.RoundTo
+	}
+	return fallback
+}
+
+func roundTo(weight, step float64) float64 {
+	if step <= 0 {
+		return weight
+	}
+	return math.Round(math.Round(weight/step)*step*100) / 100
+}
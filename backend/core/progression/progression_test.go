@@ -0,0 +1,236 @@
+package progression
+
+import (
+	"testing"
+	"time"
+
+	"athlete-forge/program"
+	"athlete-forge/tools"
+	"athlete-forge/workout"
+)
+
+func sets(n, reps int, weight float64) []workout.Set {
+	result := make([]workout.Set, n)
+	for i := range result {
+		result[i] = workout.Set{Reps: reps, Weight: weight}
+	}
+	return result
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name           string
+		prescription   program.Prescription
+		performed      []workout.Set
+		expectedWeight float64
+		expectedReps   int
+	}{
+		{
+			name:           "linear adds increment on success",
+			prescription:   program.Prescription{Sets: 3, Reps: 5, Weight: 100, Rule: program.Rule{Type: program.RuleLinear, Increment: 2.5}},
+			performed:      sets(3, 5, 100),
+			expectedWeight: 102.5,
+			expectedReps:   5,
+		},
+		{
+			name:           "linear repeats load on a miss",
+			prescription:   program.Prescription{Sets: 3, Reps: 5, Weight: 100, Rule: program.Rule{Type: program.RuleLinear, Increment: 2.5}},
+			performed:      append(sets(2, 5, 100), workout.Set{Reps: 3, Weight: 100}),
+			expectedWeight: 100,
+			expectedReps:   5,
+		},
+		{
+			name:           "linear deloads after the third consecutive miss",
+			prescription:   program.Prescription{Sets: 3, Reps: 5, Weight: 100, Rule: program.Rule{Type: program.RuleLinear, Increment: 2.5, Failures: 2}},
+			performed:      sets(3, 4, 100),
+			expectedWeight: 90,
+			expectedReps:   5,
+		},
+		{
+			name:           "double progression adds a rep inside the range",
+			prescription:   program.Prescription{Sets: 3, Reps: 8, Weight: 50, Rule: program.Rule{Type: program.RuleDoubleProgression, Increment: 2.5, MinReps: 8, MaxReps: 12}},
+			performed:      sets(3, 8, 50),
+			expectedWeight: 50,
+			expectedReps:   9,
+		},
+		{
+			name:           "double progression adds load at the top of the range",
+			prescription:   program.Prescription{Sets: 3, Reps: 12, Weight: 50, Rule: program.Rule{Type: program.RuleDoubleProgression, Increment: 2.5, MinReps: 8, MaxReps: 12}},
+			performed:      sets(3, 12, 50),
+			expectedWeight: 52.5,
+			expectedReps:   8,
+		},
+		{
+			name:           "percentage advances through the wave",
+			prescription:   program.Prescription{Sets: 3, Reps: 5, Weight: 130, Rule: program.Rule{Type: program.RulePercentage, TrainingMax: 200, Percentages: []float64{0.65, 0.75, 0.85}, Increment: 5}},
+			performed:      sets(3, 5, 130),
+			expectedWeight: 150,
+			expectedReps:   5,
+		},
+		{
+			name:           "percentage raises the training max after a completed cycle",
+			prescription:   program.Prescription{Sets: 3, Reps: 5, Weight: 170, Rule: program.Rule{Type: program.RulePercentage, TrainingMax: 200, Percentages: []float64{0.65, 0.75, 0.85}, Increment: 10, Step: 2}},
+			performed:      sets(3, 5, 170),
+			expectedWeight: 137.5,
+			expectedReps:   5,
+		},
+		{
+			name:           "percentage raises the training max from an amrap set",
+			prescription:   program.Prescription{Sets: 3, Reps: 5, Weight: 85, Rule: program.Rule{Type: program.RulePercentage, TrainingMax: 100, Percentages: []float64{0.65, 0.75, 0.85}, Step: 2, TrainingMaxUpdate: program.TrainingMaxAMRAP}},
+			performed:      append(sets(2, 5, 85), workout.Set{Reps: 10, Weight: 85}),
+			expectedWeight: 67.5,
+			expectedReps:   5,
+		},
+		{
+			name:           "percentage updates the training max from the set marked amrap, not a later back-off set",
+			prescription:   program.Prescription{Sets: 3, Reps: 5, Weight: 85, AMRAP: true, Rule: program.Rule{Type: program.RulePercentage, TrainingMax: 100, Percentages: []float64{0.65, 0.75, 0.85}, Step: 2, TrainingMaxUpdate: program.TrainingMaxAMRAP}},
+			performed:      append(sets(2, 5, 85), workout.Set{Reps: 10, Weight: 85, AMRAP: true}, workout.Set{Reps: 5, Weight: 60}),
+			expectedWeight: 67.5,
+			expectedReps:   5,
+		},
+		{
+			name:           "percentage lowers the training max after an amrap set misses its reps",
+			prescription:   program.Prescription{Sets: 3, Reps: 5, Weight: 85, Rule: program.Rule{Type: program.RulePercentage, TrainingMax: 100, Percentages: []float64{0.65, 0.75, 0.85}, Step: 2, TrainingMaxUpdate: program.TrainingMaxAMRAP}},
+			performed:      append(sets(2, 5, 85), workout.Set{Reps: 3, Weight: 85}),
+			expectedWeight: 55,
+			expectedReps:   5,
+		},
+		{
+			name:           "percentage sets the training max from a test week",
+			prescription:   program.Prescription{Sets: 1, Reps: 1, Weight: 200, Rule: program.Rule{Type: program.RulePercentage, TrainingMax: 200, Percentages: []float64{0.7, 0.8, 1}, Step: 2, TrainingMaxUpdate: program.TrainingMaxTest}},
+			performed:      []workout.Set{{Reps: 1, Weight: 210}},
+			expectedWeight: 132.5,
+			expectedReps:   1,
+		},
+		{
+			name:           "rpe reduces load when the session was harder than targeted",
+			prescription:   program.Prescription{Sets: 3, Reps: 5, Weight: 100, Rule: program.Rule{Type: program.RuleRPE, TargetRPE: 8}},
+			performed:      []workout.Set{{Reps: 5, Weight: 100, RPE: 8}, {Reps: 5, Weight: 100, RPE: 10}},
+			expectedWeight: 92.5,
+			expectedReps:   5,
+		},
+		{
+			name:           "rpe increases load when the session was easier than targeted",
+			prescription:   program.Prescription{Sets: 3, Reps: 5, Weight: 100, Rule: program.Rule{Type: program.RuleRPE, TargetRPE: 8}},
+			performed:      []workout.Set{{Reps: 5, Weight: 100, RPE: 6}},
+			expectedWeight: 107.5,
+			expectedReps:   5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			next, reason := Next(tt.prescription, tt.performed)
+
+			// Assert
+			if next.Weight != tt.expectedWeight {
+				t.Errorf("expected weight %v, got %v (%s)", tt.expectedWeight, next.Weight, reason)
+			}
+			if next.Reps != tt.expectedReps {
+				t.Errorf("expected reps %d, got %d (%s)", tt.expectedReps, next.Reps, reason)
+			}
+			if reason == "" {
+				t.Error("expected a reason to be given")
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	t.Run("updates performed exercises and counts the session", func(t *testing.T) {
+		// Arrange
+		p := &program.Program{
+			Exercises: []program.Prescription{
+				{Exercise: "Squat", Sets: 3, Reps: 5, Weight: 100, Rule: program.Rule{Type: program.RuleLinear, Increment: 5}},
+				{Exercise: "Bench Press", Sets: 3, Reps: 5, Weight: 60, Rule: program.Rule{Type: program.RuleLinear, Increment: 2.5}},
+			},
+		}
+		w := &workout.Workout{Exercises: []workout.Exercise{{Name: "squat", Sets: sets(3, 5, 100)}}}
+
+		// Act
+		changes := Apply(p, w, tools.Rounding{})
+
+		// Assert
+		if len(changes) != 1 || changes[0].Exercise != "Squat" || changes[0].NextWeight != 105 {
+			t.Errorf("unexpected changes: %+v", changes)
+		}
+		if p.Exercises[0].Weight != 105 || p.Exercises[1].Weight != 60 {
+			t.Errorf("unexpected prescriptions: %+v", p.Exercises)
+		}
+		if p.SessionsCompleted != 1 {
+			t.Errorf("expected 1 session completed, got %d", p.SessionsCompleted)
+		}
+	})
+
+	t.Run("rounds progressed loads to the equipment's increments", func(t *testing.T) {
+		// Arrange
+		p := &program.Program{
+			Exercises: []program.Prescription{
+				{Exercise: "Dumbbell Bench Press", Sets: 3, Reps: 8, Weight: 20, Rule: program.Rule{Type: program.RuleLinear, Increment: 2.5}},
+			},
+		}
+		w := &workout.Workout{Exercises: []workout.Exercise{{Name: "Dumbbell Bench Press", Sets: sets(3, 8, 20)}}}
+
+		// Act
+		changes := Apply(p, w, tools.Rounding{Barbell: 2.5, Dumbbell: 2})
+
+		// Assert
+		if len(changes) != 1 || changes[0].NextWeight != 22 || p.Exercises[0].Weight != 22 {
+			t.Errorf("expected 22.5 to round to the 22kg dumbbells, got %+v", changes)
+		}
+	})
+
+	t.Run("reports training max changes", func(t *testing.T) {
+		// Arrange
+		p := &program.Program{Exercises: []program.Prescription{
+			{Exercise: "Squat", Sets: 3, Reps: 5, Weight: 85, Rule: program.Rule{Type: program.RulePercentage, TrainingMax: 100, Percentages: []float64{0.65, 0.75, 0.85}, Step: 2, TrainingMaxUpdate: program.TrainingMaxAMRAP}},
+		}}
+		w := &workout.Workout{Exercises: []workout.Exercise{{Name: "Squat", Sets: append(sets(2, 5, 85), workout.Set{Reps: 10, Weight: 85})}}}
+
+		// Act
+		changes := Apply(p, w, tools.Rounding{})
+
+		// Assert
+		want := TrainingMaxChange{Previous: 100, Next: 102.5, Source: program.TrainingMaxAMRAP}
+		if len(changes) != 1 || changes[0].TrainingMax == nil || *changes[0].TrainingMax != want {
+			t.Errorf("expected training max change %+v, got %+v", want, changes)
+		}
+	})
+
+	t.Run("judges sessions in a block by the week's plan", func(t *testing.T) {
+		// Arrange
+		completed := time.Date(2024, 3, 6, 18, 0, 0, 0, time.UTC)
+		p := &program.Program{
+			Exercises: []program.Prescription{{Exercise: "Squat", Sets: 3, Reps: 5, Weight: 100, Rule: program.Rule{Type: program.RuleLinear, Increment: 5}}},
+			Blocks:    []program.Block{{Type: program.BlockIntensification, StartDate: "2024-03-04", EndDate: "2024-03-31", Intensity: 1.05}},
+		}
+		w := &workout.Workout{CompletedAt: &completed, Exercises: []workout.Exercise{{Name: "Squat", Sets: sets(2, 5, 105)}}}
+
+		// Act
+		changes := Apply(p, w, tools.Rounding{})
+
+		// Assert
+		if len(changes) != 1 || changes[0].NextWeight != 105 || p.Exercises[0].Sets != 3 {
+			t.Errorf("expected two sets at 105 to meet the week's plan, got %+v, %+v", changes, p.Exercises)
+		}
+	})
+
+	t.Run("holds prescriptions in a deload block", func(t *testing.T) {
+		// Arrange
+		completed := time.Date(2024, 3, 6, 18, 0, 0, 0, time.UTC)
+		p := &program.Program{
+			Exercises: []program.Prescription{{Exercise: "Squat", Sets: 3, Reps: 5, Weight: 100, Rule: program.Rule{Type: program.RuleLinear, Increment: 5, Failures: 2}}},
+			Blocks:    []program.Block{{Type: program.BlockDeload, StartDate: "2024-03-04", EndDate: "2024-03-10"}},
+		}
+		w := &workout.Workout{CompletedAt: &completed, Exercises: []workout.Exercise{{Name: "Squat", Sets: sets(2, 5, 60)}}}
+
+		// Act
+		changes := Apply(p, w, tools.Rounding{})
+
+		// Assert
+		if len(changes) != 1 || changes[0].NextWeight != 100 || p.Exercises[0].Rule.Failures != 2 || p.SessionsCompleted != 1 {
+			t.Errorf("expected the prescription held, got %+v, %+v", changes, p.Exercises)
+		}
+	})
+}
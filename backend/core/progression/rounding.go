@@ -0,0 +1,56 @@
+package progression
+
+import (
+	"athlete-forge/exercise"
+	"athlete-forge/program"
+	"athlete-forge/tools"
+)
+
+// loadings maps the equipment that carries an exercise's load to the kind of
+// increment it is adjusted by. Plate-loaded machines load like a barbell
+var loadings = map[string]string{
+	exercise.EquipmentBarbell:      tools.LoadingBarbell,
+	exercise.EquipmentTrapBar:      tools.LoadingBarbell,
+	exercise.EquipmentLandmine:     tools.LoadingBarbell,
+	exercise.EquipmentLegPress:     tools.LoadingBarbell,
+	exercise.EquipmentBeltSquat:    tools.LoadingBarbell,
+	exercise.EquipmentSled:         tools.LoadingBarbell,
+	exercise.EquipmentDumbbells:    tools.LoadingDumbbell,
+	exercise.EquipmentKettlebell:   tools.LoadingDumbbell,
+	exercise.EquipmentCable:        tools.LoadingMachine,
+	exercise.EquipmentLegCurl:      tools.LoadingMachine,
+	exercise.EquipmentLegExtension: tools.LoadingMachine,
+}
+
+// Loading returns the kind of equipment the named exercise is loaded on, from
+// the first of its catalog equipment that carries load, or "" when it is not
+// in the catalog or is loaded by bodyweight or bands
+func Loading(name string) string {
+	known, ok := exercise.Lookup(name)
+	if !ok {
+		return ""
+	}
+	for _, equipment := range known.Equipment {
+		if kind, ok := loadings[equipment]; ok {
+			return kind
+		}
+	}
+	return ""
+}
+
+// RoundLoad rounds weight to the nearest load the named exercise's equipment
+// can be set to
+func RoundLoad(weight float64, name string, r tools.Rounding) float64 {
+	return r.Round(weight, name, Loading(name))
+}
+
+// RoundLoads returns a copy of prescriptions with each weight rounded to a
+// load its exercise's equipment can be set to
+func RoundLoads(prescriptions []program.Prescription, r tools.Rounding) []program.Prescription {
+	rounded := make([]program.Prescription, len(prescriptions))
+	for i, p := range prescriptions {
+		p.Weight = RoundLoad(p.Weight, p.Exercise, r)
+		rounded[i] = p
+	}
+	return rounded
+}
@@ -0,0 +1,51 @@
+package progression
+
+import (
+	"testing"
+
+	"athlete-forge/program"
+	"athlete-forge/tools"
+)
+
+func TestRoundLoads(t *testing.T) {
+	r := tools.Rounding{
+		Barbell: 2.5, Dumbbell: 1, Machine: 5,
+		Stacks: map[string][]float64{"Leg Curl": {20, 27, 34, 41}},
+	}
+
+	t.Run("rounds each exercise by the equipment it is loaded on", func(t *testing.T) {
+		// Arrange
+		prescriptions := []program.Prescription{
+			{Exercise: "Squat", Weight: 101.2},
+			{Exercise: "Goblet Squat", Weight: 23.4},
+			{Exercise: "Leg Extension", Weight: 41},
+			{Exercise: "Leg Curl", Weight: 30},
+			{Exercise: "Pull-Up", Weight: 7.3},
+			{Exercise: "Zercher Squat", Weight: 61.1},
+		}
+
+		// Act
+		rounded := RoundLoads(prescriptions, r)
+
+		// Assert
+		expected := []float64{100, 23, 40, 27, 7.3, 61.1}
+		for i, want := range expected {
+			if rounded[i].Weight != want {
+				t.Errorf("%s: expected %v, got %v", rounded[i].Exercise, want, rounded[i].Weight)
+			}
+		}
+		if prescriptions[0].Weight != 101.2 {
+			t.Error("expected the prescriptions not to be modified")
+		}
+	})
+
+	t.Run("never rounds a load down to nothing", func(t *testing.T) {
+		// Act
+		rounded := RoundLoad(1, "Bench Press", r)
+
+		// Assert
+		if rounded != 2.5 {
+			t.Errorf("expected the smallest increment, got %v", rounded)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package progression
+
+import (
+	"athlete-forge/program"
+	"athlete-forge/records"
+	"athlete-forge/workout"
+)
+
+// defaultTrainingMaxPercent is the fraction of an estimated 1RM a training max
+// is set to from a performance
+const defaultTrainingMaxPercent = 0.9
+
+// TrainingMaxChange records a percentage rule's training max moving after a
+// session, and which update moved it
+type TrainingMaxChange struct {
+	Previous float64 `json:"previous"`
+	Next     float64 `json:"next"`
+	Source   string  `json:"source"`
+}
+
+// TrainingMaxSource returns how rule's training max is updated
+func TrainingMaxSource(rule program.Rule) string {
+	if rule.TrainingMaxUpdate == "" {
+		return program.TrainingMaxCycle
+	}
+	return rule.TrainingMaxUpdate
+}
+
+// TrainingMaxFrom returns the training max an estimated one-rep max supports
+// under rule, rounded to its granularity
+func TrainingMaxFrom(e1rm float64, rule program.Rule) float64 {
+	percent := rule.TrainingMaxPercent
+	if percent == 0 {
+		percent = defaultTrainingMaxPercent
+	}
+	return RoundForRule(e1rm*percent, rule)
+}
+
+// fromAMRAP updates p's training max from the last set marked AMRAP, or the
+// last set performed when none is, taken as many reps as possible: it is
+// raised when the set's estimated 1RM supports a higher one, and lowered to
+// what the set supports when the set missed the prescribed reps. It reports whether the training max moved, returning reason
+// when it did not
+func fromAMRAP(p program.Prescription, sets []workout.Set, reason string) (program.Prescription, string, bool) {
+	last := lastAMRAP(sets)
+	if last == nil {
+		return p, reason, false
+	}
+
+	supported := TrainingMaxFrom(records.EstimatedOneRepMax(last.Load(), last.Reps), p.Rule)
+	switch {
+	case last.Reps >= p.Reps && supported > p.Rule.TrainingMax:
+		p.Rule.TrainingMax = supported
+		return p, "amrap set beat the training max, training max raised", true
+	case last.Reps < p.Reps && supported < p.Rule.TrainingMax:
+		p.Rule.TrainingMax = supported
+		return p, "amrap set missed its reps, training max lowered", true
+	}
+	return p, reason, false
+}
+
+// lastAMRAP returns the last estimable set marked AMRAP, or the last estimable
+// set when none is marked, or nil when no set is estimable
+func lastAMRAP(sets []workout.Set) *workout.Set {
+	var last, amrap *workout.Set
+	for i := range sets {
+		if !sets[i].Estimable() {
+			continue
+		}
+		last = &sets[i]
+		if sets[i].AMRAP {
+			amrap = &sets[i]
+		}
+	}
+	if amrap != nil {
+		return amrap
+	}
+	return last
+}
+
+// fromTest sets p's training max from the best estimated 1RM of a test week's
+// sets, reporting false and returning reason when none can be estimated
+func fromTest(p program.Prescription, sets []workout.Set, reason string) (program.Prescription, string, bool) {
+	best := 0.0
+	for _, set := range sets {
+		if set.Estimable() {
+			best = max(best, records.EstimatedOneRepMax(set.Load(), set.Reps))
+		}
+	}
+	if best == 0 {
+		return p, reason, false
+	}
+	p.Rule.TrainingMax = TrainingMaxFrom(best, p.Rule)
+	return p, "test week, training max set from the tested 1RM", true
+}
+
+// trainingMaxChange returns how a percentage rule's training max moved from
+// previous to next, or nil when it did not
+func trainingMaxChange(previous, next program.Prescription) *TrainingMaxChange {
+	if previous.Rule.Type != program.RulePercentage || next.Rule.TrainingMax == previous.Rule.TrainingMax {
+		return nil
+	}
+	return &TrainingMaxChange{Previous: previous.Rule.TrainingMax, Next: next.Rule.TrainingMax, Source: TrainingMaxSource(previous.Rule)}
+}
@@ -0,0 +1,80 @@
+package progression
+
+import (
+	"math"
+
+	"athlete-forge/program"
+	"athlete-forge/workout"
+)
+
+const (
+	// velocityTolerance is how far from the target velocity, in metres per
+	// second, the bar can move before load is adjusted
+	velocityTolerance = 0.03
+
+	// velocityLoadPerMS approximates the fraction of load that changes the bar's
+	// velocity by one metre per second, so 0.05 m/s is about 5% of the load
+	velocityLoadPerMS = 1.0
+
+	// maxVelocityAdjustment caps a session's velocity-based load change
+	maxVelocityAdjustment = 0.1
+)
+
+// Autoregulate adjusts next, the prescription p's rule computed from sets, by
+// the bar velocities logged in them. With a target velocity, the load is
+// steered by how much faster or slower than the target the working sets'
+// fastest reps moved. With a velocity loss limit, the load is then not raised
+// above p's when any set lost more velocity than the limit. It reports false
+// when neither applies or no velocities were logged
+func Autoregulate(p, next program.Prescription, sets []workout.Set) (program.Prescription, string, bool) {
+	reason, adjusted := "", false
+
+	if p.Rule.TargetVelocity > 0 {
+		if v, ok := workingVelocity(sets, p.Weight); ok {
+			gap := v - p.Rule.TargetVelocity
+			switch {
+			case gap > velocityTolerance:
+				reason = "bar faster than target velocity, load increased"
+			case gap < -velocityTolerance:
+				reason = "bar slower than target velocity, load reduced"
+			default:
+				reason, gap = "bar on target velocity, load held", 0
+			}
+			adjustment := math.Max(-maxVelocityAdjustment, math.Min(maxVelocityAdjustment, gap*velocityLoadPerMS))
+			next.Weight = RoundForRule(p.Weight*(1+adjustment), p.Rule)
+			adjusted = true
+		}
+	}
+
+	if p.Rule.VelocityLossLimit > 0 && next.Weight > p.Weight {
+		for _, set := range sets {
+			if set.VelocityLoss() > p.Rule.VelocityLossLimit {
+				next.Weight = p.Weight
+				reason, adjusted = "velocity loss above limit, load held", true
+				break
+			}
+		}
+	}
+	return next, reason, adjusted
+}
+
+// workingVelocity averages the fastest rep of each set at weight or heavier
+// that has velocities logged
+func workingVelocity(sets []workout.Set, weight float64) (float64, bool) {
+	total, count := 0.0, 0
+	for _, set := range sets {
+		if len(set.Velocities) == 0 || set.Weight < weight {
+			continue
+		}
+		fastest := set.Velocities[0]
+		for _, v := range set.Velocities[1:] {
+			fastest = math.Max(fastest, v)
+		}
+		total += fastest
+		count++
+	}
+	if count == 0 {
+		return 0, false
+	}
+	return total / float64(count), true
+}
@@ -0,0 +1,62 @@
+package progression
+
+import (
+	"testing"
+
+	"athlete-forge/program"
+)
+
+func TestAutoregulate(t *testing.T) {
+	prescription := program.Prescription{Exercise: "Squat", Sets: 3, Reps: 3, Weight: 140, Rule: program.Rule{Type: program.RuleLinear, Increment: 5}}
+
+	t.Run("steers load towards the target velocity", func(t *testing.T) {
+		// Arrange
+		p := prescription
+		p.Rule.TargetVelocity = 0.5
+		sets := sets(3, 3, 140)
+		sets[0].Velocities = []float64{0.58, 0.56, 0.55}
+		sets[1].Velocities = []float64{0.56, 0.54, 0.52}
+		next, _ := Next(p, sets)
+
+		// Act
+		adjusted, reason, ok := Autoregulate(p, next, sets)
+
+		// Assert
+		if !ok || adjusted.Weight != 150 {
+			t.Errorf("expected 0.07 m/s too fast to add about 7%%, got %v (%s)", adjusted.Weight, reason)
+		}
+	})
+
+	t.Run("holds load after too much velocity loss", func(t *testing.T) {
+		// Arrange
+		p := prescription
+		p.Rule.VelocityLossLimit = 20
+		sets := sets(3, 3, 140)
+		sets[2].Velocities = []float64{0.6, 0.52, 0.45}
+		next, _ := Next(p, sets)
+
+		// Act
+		adjusted, _, ok := Autoregulate(p, next, sets)
+
+		// Assert
+		if !ok || adjusted.Weight != 140 {
+			t.Errorf("expected the 25%% loss to hold 140, got %v", adjusted.Weight)
+		}
+	})
+
+	t.Run("leaves the rule's prescription without velocities", func(t *testing.T) {
+		// Arrange
+		p := prescription
+		p.Rule.TargetVelocity, p.Rule.VelocityLossLimit = 0.5, 20
+		sets := sets(3, 3, 140)
+		next, _ := Next(p, sets)
+
+		// Act
+		adjusted, _, ok := Autoregulate(p, next, sets)
+
+		// Assert
+		if ok || adjusted.Weight != 145 {
+			t.Errorf("expected the linear increase, got %v (%v)", adjusted.Weight, ok)
+		}
+	})
+}
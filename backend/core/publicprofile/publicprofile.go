@@ -0,0 +1,211 @@
+package publicprofile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"athlete-forge/achievement"
+	"athlete-forge/records"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+const (
+	settingsSK = "PUBLIC_PROFILE"
+
+	maxDisplayName = 50
+	maxBio         = 280
+
+	// recentWorkouts and maxHighlights bound what a page lists, keeping it a
+	// snapshot rather than a training log
+	recentWorkouts = 5
+	maxHighlights  = 5
+
+	// dateLayout is how dates are shown on a page; times of day are left out
+	// so a page does not reveal when someone trains
+	dateLayout = "2006-01-02"
+)
+
+// Settings are what a user chooses to show on their public page. Nothing is
+// public until Enabled is set, and each section is shown only when its own
+// switch is on. The page is found by the user's handle, which is chosen
+// separately
+type Settings struct {
+	UserID           string    `json:"userId"`
+	Enabled          bool      `json:"enabled"`
+	DisplayName      string    `json:"displayName,omitempty"`
+	Bio              string    `json:"bio,omitempty"`
+	ShowWorkouts     bool      `json:"showWorkouts"`
+	ShowRecords      bool      `json:"showRecords"`
+	ShowAchievements bool      `json:"showAchievements"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+// Validate checks the settings
+func (s *Settings) Validate() error {
+	if len([]rune(s.DisplayName)) > maxDisplayName {
+		return fmt.Errorf("displayName must be at most %d characters", maxDisplayName)
+	}
+	if len([]rune(s.Bio)) > maxBio {
+		return fmt.Errorf("bio must be at most %d characters", maxBio)
+	}
+	return nil
+}
+
+// Workout is a completed workout as a page shows it: the day, the exercises
+// and the totals, without notes, ratings, gyms or anything identifying it
+type Workout struct {
+	Date      string   `json:"date"`
+	Exercises []string `json:"exercises"`
+	Sets      int      `json:"sets"`
+	Volume    float64  `json:"volume"`
+}
+
+// Highlight is an exercise's best set, estimated one-rep max first
+type Highlight struct {
+	Exercise     string  `json:"exercise"`
+	Weight       float64 `json:"weight"`
+	Reps         int     `json:"reps"`
+	Estimated1RM float64 `json:"estimated1rm"`
+	Date         string  `json:"date"`
+}
+
+// Badge is an achievement the user holds
+type Badge struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	AwardedOn   string `json:"awardedOn"`
+}
+
+// Page is a user's public profile. It is built field by field from what the
+// settings allow rather than by hiding fields of stored records, so new
+// fields on workouts or profiles never become public by accident
+type Page struct {
+	Handle         string      `json:"handle"`
+	DisplayName    string      `json:"displayName,omitempty"`
+	Bio            string      `json:"bio,omitempty"`
+	Unit           string      `json:"unit"`
+	RecentWorkouts []Workout   `json:"recentWorkouts,omitempty"`
+	Highlights     []Highlight `json:"highlights,omitempty"`
+	Badges         []Badge     `json:"badges,omitempty"`
+}
+
+// Build returns the page found by handle for s from the user's workouts and
+// awards, with weights in unit
+func Build(handle string, s *Settings, workouts []workout.Workout, awards []achievement.Award, unit string, now time.Time) Page {
+	page := Page{Handle: handle, DisplayName: s.DisplayName, Bio: s.Bio, Unit: unit}
+	if s.ShowWorkouts {
+		page.RecentWorkouts = recent(workouts)
+	}
+	if s.ShowRecords {
+		page.Highlights = highlights(workouts, now)
+	}
+	if s.ShowAchievements {
+		page.Badges = badges(awards)
+	}
+	return page
+}
+
+// recent returns the latest completed workouts, newest first
+func recent(workouts []workout.Workout) []Workout {
+	var completed []workout.Workout
+	for _, w := range workouts {
+		if w.Status == workout.StatusCompleted && w.CompletedAt != nil {
+			completed = append(completed, w)
+		}
+	}
+	sort.Slice(completed, func(i, j int) bool { return completed[i].CompletedAt.After(*completed[j].CompletedAt) })
+
+	list := []Workout{}
+	for _, w := range completed[:min(len(completed), recentWorkouts)] {
+		summary := Workout{Date: w.CompletedAt.Format(dateLayout), Exercises: []string{}}
+		for _, exercise := range w.Exercises {
+			performed := 0
+			for _, set := range exercise.Sets {
+				if set.Performed() {
+					performed++
+					summary.Volume += set.Volume()
+				}
+			}
+			if performed > 0 {
+				summary.Exercises = append(summary.Exercises, exercise.Name)
+				summary.Sets += performed
+			}
+		}
+		summary.Volume = math.Round(summary.Volume*10) / 10
+		list = append(list, summary)
+	}
+	return list
+}
+
+// highlights returns the exercises with the highest estimated one-rep maxes
+func highlights(workouts []workout.Workout, now time.Time) []Highlight {
+	best := records.Best(workouts, now)
+	list := make([]Highlight, 0, len(best))
+	for _, r := range best {
+		list = append(list, Highlight{Exercise: r.Exercise, Weight: r.Weight, Reps: r.Reps, Estimated1RM: r.Estimated1RM, Date: r.Date.Format(dateLayout)})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Estimated1RM != list[j].Estimated1RM {
+			return list[i].Estimated1RM > list[j].Estimated1RM
+		}
+		return list[i].Exercise < list[j].Exercise
+	})
+	return list[:min(len(list), maxHighlights)]
+}
+
+// badges returns the achievements in awards that are still defined, earliest
+// first
+func badges(awards []achievement.Award) []Badge {
+	sorted := append([]achievement.Award(nil), awards...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].AwardedAt.Before(sorted[j].AwardedAt) })
+
+	list := []Badge{}
+	for _, a := range sorted {
+		rule, ok := achievement.Find(a.ID)
+		if !ok {
+			continue
+		}
+		list = append(list, Badge{ID: rule.ID, Name: rule.Name, Description: rule.Description, AwardedOn: a.AwardedAt.Format(dateLayout)})
+	}
+	return list
+}
+
+// Repository loads and saves public profile settings
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Get returns userID's settings, which are private when none are stored
+func (r *Repository) Get(ctx context.Context, userID string) (*Settings, error) {
+	var s Settings
+	err := r.store.Get(ctx, store.UserPK(userID), settingsSK, &s)
+	if errors.Is(err, store.ErrNotFound) {
+		return &Settings{UserID: userID}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load public profile: %w", err)
+	}
+	return &s, nil
+}
+
+// Save validates and stores s
+func (r *Repository) Save(ctx context.Context, s *Settings) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	if err := r.store.Put(ctx, store.UserPK(s.UserID), settingsSK, s); err != nil {
+		return fmt.Errorf("failed to save public profile: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package publicprofile
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"athlete-forge/achievement"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+func TestSettings_Validate(t *testing.T) {
+	cases := map[string]Settings{
+		"long display name": {DisplayName: string(make([]rune, maxDisplayName+1))},
+		"long bio":          {Bio: string(make([]rune, maxBio+1))},
+	}
+	for name, s := range cases {
+		if err := s.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	completed := func(day int, exercises ...workout.Exercise) workout.Workout {
+		at := time.Date(2024, 3, day, 18, 30, 0, 0, time.UTC)
+		return workout.Workout{ID: "w", Status: workout.StatusCompleted, CompletedAt: &at, Notes: "felt tired", GymID: "gym-1", Exercises: exercises}
+	}
+	workouts := []workout.Workout{
+		completed(1, workout.Exercise{Name: "Squat", Sets: []workout.Set{{Reps: 5, Weight: 100}}}),
+		completed(3, workout.Exercise{Name: "Bench", Sets: []workout.Set{{Reps: 5, Weight: 80}, {Reps: 0, Weight: 90}}}),
+		{ID: "planned", Status: workout.StatusPlanned, Exercises: []workout.Exercise{{Name: "Deadlift"}}},
+	}
+	awards := []achievement.Award{{ID: achievement.FirstWorkout, UserID: "user-1", AwardedAt: now}, {ID: "retired", AwardedAt: now}}
+
+	t.Run("shows only the sections the user enabled", func(t *testing.T) {
+		// Arrange
+		s := &Settings{Enabled: true, DisplayName: "Lifter", ShowWorkouts: true}
+
+		// Act
+		page := Build("lifter", s, workouts, awards, "kg", now)
+
+		// Assert
+		if page.Handle != "lifter" || page.DisplayName != "Lifter" || page.Unit != "kg" {
+			t.Errorf("unexpected page: %+v", page)
+		}
+		if page.Highlights != nil || page.Badges != nil {
+			t.Errorf("expected hidden sections to be left out, got %+v", page)
+		}
+	})
+
+	t.Run("lists completed workouts newest first without times of day", func(t *testing.T) {
+		// Act
+		page := Build("lifter", &Settings{ShowWorkouts: true}, workouts, nil, "kg", now)
+
+		// Assert
+		if len(page.RecentWorkouts) != 2 {
+			t.Fatalf("expected 2 workouts, got %+v", page.RecentWorkouts)
+		}
+		bench := page.RecentWorkouts[0]
+		if bench.Date != "2024-03-03" || bench.Sets != 1 || bench.Volume != 400 || bench.Exercises[0] != "Bench" {
+			t.Errorf("unexpected workout: %+v", bench)
+		}
+	})
+
+	t.Run("ranks highlights and skips undefined badges", func(t *testing.T) {
+		// Act
+		page := Build("lifter", &Settings{ShowRecords: true, ShowAchievements: true}, workouts, awards, "kg", now)
+
+		// Assert
+		if len(page.Highlights) != 2 || page.Highlights[0].Exercise != "Squat" {
+			t.Errorf("unexpected highlights: %+v", page.Highlights)
+		}
+		if len(page.Badges) != 1 || page.Badges[0].Name != "First Workout" || page.Badges[0].AwardedOn != "2024-03-10" {
+			t.Errorf("unexpected badges: %+v", page.Badges)
+		}
+	})
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("returns private settings when none are stored", func(t *testing.T) {
+		// Act
+		s, err := NewRepository(store.NewMemoryStore()).Get(ctx, "user-1")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if s.Enabled || s.UserID != "user-1" {
+			t.Errorf("unexpected settings: %+v", s)
+		}
+	})
+
+	t.Run("saves settings", func(t *testing.T) {
+		// Arrange
+		r := NewRepository(store.NewMemoryStore())
+
+		// Act
+		err := r.Save(ctx, &Settings{UserID: "user-1", Enabled: true, ShowRecords: true})
+		s, _ := r.Get(ctx, "user-1")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !s.Enabled || !s.ShowRecords {
+			t.Errorf("unexpected settings: %+v", s)
+		}
+	})
+}
@@ -0,0 +1,258 @@
+package readiness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"athlete-forge/program"
+	"athlete-forge/progression"
+	"athlete-forge/store"
+)
+
+const (
+	checkInSKPrefix = "CHECKIN#"
+
+	// DateLayout is the format of check-in dates
+	DateLayout = "2006-01-02"
+)
+
+// Readiness levels derived from the score
+const (
+	LevelNormal   = "normal"
+	LevelReduced  = "reduced"
+	LevelRecovery = "recovery"
+)
+
+// recoveryWeight is the weight of a wearable's recovery score alongside the
+// subjective ratings, about as much as sleep and soreness together
+const recoveryWeight = 45
+
+// Recovery is an objective recovery reading synced from a wearable. Score is the
+// provider's 0-100 recovery or readiness score
+type Recovery struct {
+	Source     string    `json:"source"`
+	Score      int       `json:"score"`
+	HRV        float64   `json:"hrvMs,omitempty"`
+	RestingHR  int       `json:"restingHr,omitempty"`
+	SleepHours float64   `json:"sleepHours,omitempty"`
+	SyncedAt   time.Time `json:"syncedAt"`
+}
+
+// CheckIn is a user's readiness for one day; ratings are 1 (worst) to 5 (best)
+// except soreness and stress, where 5 is the most sore or stressed. Recovery is
+// set by wearable syncs, and a day with it needs no subjective ratings
+type CheckIn struct {
+	UserID       string    `json:"userId"`
+	Date         string    `json:"date"`
+	SleepHours   float64   `json:"sleepHours"`
+	SleepQuality int       `json:"sleepQuality"`
+	Soreness     int       `json:"soreness"`
+	Mood         int       `json:"mood"`
+	Stress       int       `json:"stress,omitempty"`
+	Energy       int       `json:"energy,omitempty"`
+	Notes        string    `json:"notes,omitempty"`
+	Recovery     *Recovery `json:"recovery,omitempty"`
+	Score        int       `json:"score"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// Validate checks the check-in ratings and date
+func (c *CheckIn) Validate() error {
+	if _, err := time.Parse(DateLayout, c.Date); err != nil {
+		return fmt.Errorf("date must be in YYYY-MM-DD format")
+	}
+	if c.SleepHours < 0 || c.SleepHours > 24 {
+		return errors.New("sleepHours must be between 0 and 24")
+	}
+	if c.Recovery != nil && (c.Recovery.Score < 0 || c.Recovery.Score > 100) {
+		return errors.New("recovery score must be between 0 and 100")
+	}
+	if c.Subjective() || c.Recovery == nil {
+		required := map[string]int{"sleepQuality": c.SleepQuality, "soreness": c.Soreness, "mood": c.Mood}
+		for name, rating := range required {
+			if rating < 1 || rating > 5 {
+				return fmt.Errorf("%s must be between 1 and 5", name)
+			}
+		}
+	}
+	optional := map[string]int{"stress": c.Stress, "energy": c.Energy}
+	for name, rating := range optional {
+		if rating != 0 && (rating < 1 || rating > 5) {
+			return fmt.Errorf("%s must be between 1 and 5 when provided", name)
+		}
+	}
+	return nil
+}
+
+// Subjective reports whether the user has rated the day themselves
+func (c *CheckIn) Subjective() bool {
+	return c.SleepQuality != 0 || c.Soreness != 0 || c.Mood != 0
+}
+
+// Score combines the check-in into a 0-100 readiness score, weighting sleep and
+// soreness most heavily and ignoring optional ratings that were not provided. A
+// synced recovery score counts alongside the ratings, or alone on a day the user
+// has not rated
+func Score(c CheckIn) int {
+	if !c.Subjective() && c.Recovery != nil {
+		return c.Recovery.Score
+	}
+
+	type component struct {
+		weight float64
+		value  float64
+	}
+	components := []component{
+		{weight: 25, value: math.Min(c.SleepHours/8, 1)},
+		{weight: 15, value: positive(c.SleepQuality)},
+		{weight: 20, value: inverted(c.Soreness)},
+		{weight: 15, value: positive(c.Mood)},
+	}
+	if c.Stress != 0 {
+		components = append(components, component{weight: 10, value: inverted(c.Stress)})
+	}
+	if c.Energy != 0 {
+		components = append(components, component{weight: 15, value: positive(c.Energy)})
+	}
+	if c.Recovery != nil {
+		components = append(components, component{weight: recoveryWeight, value: float64(c.Recovery.Score) / 100})
+	}
+
+	var total, weights float64
+	for _, comp := range components {
+		total += comp.weight * comp.value
+		weights += comp.weight
+	}
+	return int(math.Round(total / weights * 100))
+}
+
+func positive(rating int) float64 {
+	return float64(rating-1) / 4
+}
+
+func inverted(rating int) float64 {
+	return float64(5-rating) / 4
+}
+
+// Adjustment is how a session prescription should be modified for a readiness score
+type Adjustment struct {
+	Level          string  `json:"level"`
+	Score          int     `json:"score"`
+	LoadFactor     float64 `json:"loadFactor"`
+	SetReduction   int     `json:"setReduction"`
+	Recommendation string  `json:"recommendation"`
+}
+
+// AdjustmentFor maps a readiness score to a prescription adjustment
+func AdjustmentFor(score int) Adjustment {
+	switch {
+	case score < 40:
+		return Adjustment{
+			Level:          LevelRecovery,
+			Score:          score,
+			LoadFactor:     0.9,
+			SetReduction:   1,
+			Recommendation: "Readiness is low: reduce load by 10% and drop a set, or take a recovery day",
+		}
+	case score < 60:
+		return Adjustment{
+			Level:          LevelReduced,
+			Score:          score,
+			LoadFactor:     0.95,
+			Recommendation: "Readiness is below normal: reduce load by 5% and keep effort submaximal",
+		}
+	default:
+		return Adjustment{
+			Level:          LevelNormal,
+			Score:          score,
+			LoadFactor:     1,
+			Recommendation: "Readiness is good: train as prescribed",
+		}
+	}
+}
+
+// Apply returns copies of the prescriptions scaled by the adjustment, keeping at least one set
+func (a Adjustment) Apply(prescriptions []program.Prescription) []program.Prescription {
+	adjusted := make([]program.Prescription, len(prescriptions))
+	for i, p := range prescriptions {
+		if a.LoadFactor != 1 {
+			p.Weight = progression.RoundForRule(p.Weight*a.LoadFactor, p.Rule)
+		}
+		if p.Sets-a.SetReduction >= 1 {
+			p.Sets -= a.SetReduction
+		}
+		adjusted[i] = p
+	}
+	return adjusted
+}
+
+// Repository loads and saves daily check-ins
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Get returns userID's check-in for date
+func (r *Repository) Get(ctx context.Context, userID, date string) (*CheckIn, error) {
+	var c CheckIn
+	if err := r.store.Get(ctx, store.UserPK(userID), checkInSKPrefix+date, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// List returns userID's check-ins between from and to inclusive, oldest first;
+// empty bounds are open-ended
+func (r *Repository) List(ctx context.Context, userID, from, to string) ([]CheckIn, error) {
+	items, err := r.store.Query(ctx, store.UserPK(userID), checkInSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list check-ins: %w", err)
+	}
+
+	checkIns := []CheckIn{}
+	for _, item := range items {
+		var c CheckIn
+		if err := item.Decode(&c); err != nil {
+			return nil, err
+		}
+		if (from != "" && c.Date < from) || (to != "" && c.Date > to) {
+			continue
+		}
+		checkIns = append(checkIns, c)
+	}
+	return checkIns, nil
+}
+
+// Save validates c, computes its score and upserts it for the user and date
+func (r *Repository) Save(ctx context.Context, c *CheckIn) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	c.Score = Score(*c)
+	if err := r.store.Put(ctx, store.UserPK(c.UserID), checkInSKPrefix+c.Date, c); err != nil {
+		return fmt.Errorf("failed to save check-in: %w", err)
+	}
+	return nil
+}
+
+// SaveRecovery sets the recovery reading on userID's check-in for date, creating
+// a check-in with only the reading when the user has not checked in that day
+func (r *Repository) SaveRecovery(ctx context.Context, userID, date string, recovery Recovery) error {
+	c, err := r.Get(ctx, userID, date)
+	if errors.Is(err, store.ErrNotFound) {
+		c, err = &CheckIn{UserID: userID, Date: date}, nil
+	}
+	if err != nil {
+		return err
+	}
+	c.Recovery = &recovery
+	c.UpdatedAt = recovery.SyncedAt
+	return r.Save(ctx, c)
+}
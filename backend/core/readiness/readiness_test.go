@@ -0,0 +1,160 @@
+package readiness
+
+import (
+	"context"
+	"testing"
+
+	"athlete-forge/program"
+	"athlete-forge/store"
+)
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name     string
+		checkIn  CheckIn
+		expected int
+	}{
+		{
+			name:     "best possible day",
+			checkIn:  CheckIn{SleepHours: 9, SleepQuality: 5, Soreness: 1, Mood: 5, Stress: 1, Energy: 5},
+			expected: 100,
+		},
+		{
+			name:     "worst possible day",
+			checkIn:  CheckIn{SleepHours: 0, SleepQuality: 1, Soreness: 5, Mood: 1, Stress: 5, Energy: 1},
+			expected: 0,
+		},
+		{
+			name:     "optional ratings omitted are not penalised",
+			checkIn:  CheckIn{SleepHours: 8, SleepQuality: 5, Soreness: 1, Mood: 5},
+			expected: 100,
+		},
+		{
+			name:     "short sleep and soreness lower the score",
+			checkIn:  CheckIn{SleepHours: 4, SleepQuality: 2, Soreness: 4, Mood: 3},
+			expected: 38,
+		},
+		{
+			name:     "a day with only a recovery reading scores as the reading",
+			checkIn:  CheckIn{Recovery: &Recovery{Source: "whoop", Score: 70}},
+			expected: 70,
+		},
+		{
+			name:     "a poor recovery reading pulls down a good subjective day",
+			checkIn:  CheckIn{SleepHours: 8, SleepQuality: 5, Soreness: 1, Mood: 5, Recovery: &Recovery{Source: "oura", Score: 20}},
+			expected: 70,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Score(tt.checkIn); got != tt.expected {
+				t.Errorf("expected score %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestAdjustment_Apply(t *testing.T) {
+	prescriptions := []program.Prescription{
+		{Exercise: "Squat", Sets: 3, Reps: 5, Weight: 100},
+		{Exercise: "Curl", Sets: 1, Reps: 10, Weight: 20},
+	}
+
+	t.Run("low readiness reduces load and sets", func(t *testing.T) {
+		// Act
+		adjusted := AdjustmentFor(30).Apply(prescriptions)
+
+		// Assert
+		if adjusted[0].Weight != 90 || adjusted[0].Sets != 2 {
+			t.Errorf("unexpected adjustment: %+v", adjusted[0])
+		}
+		if adjusted[1].Sets != 1 {
+			t.Errorf("expected at least one set to remain, got %d", adjusted[1].Sets)
+		}
+		if prescriptions[0].Weight != 100 {
+			t.Error("expected original prescriptions to be unchanged")
+		}
+	})
+
+	t.Run("normal readiness leaves prescriptions unchanged", func(t *testing.T) {
+		// Act
+		adjusted := AdjustmentFor(80).Apply(prescriptions)
+
+		// Assert
+		if adjusted[0].Weight != 100 || adjusted[0].Sets != 3 {
+			t.Errorf("unexpected adjustment: %+v", adjusted[0])
+		}
+	})
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("save upserts by date and computes the score", func(t *testing.T) {
+		// Arrange
+		repo := NewRepository(store.NewMemoryStore())
+		first := &CheckIn{UserID: "user-1", Date: "2024-03-01", SleepHours: 4, SleepQuality: 2, Soreness: 4, Mood: 3}
+		second := &CheckIn{UserID: "user-1", Date: "2024-03-01", SleepHours: 8, SleepQuality: 5, Soreness: 1, Mood: 5}
+
+		// Act
+		repo.Save(ctx, first)
+		repo.Save(ctx, second)
+		checkIns, err := repo.List(ctx, "user-1", "", "")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(checkIns) != 1 || checkIns[0].Score != 100 {
+			t.Errorf("expected a single upserted check-in, got %+v", checkIns)
+		}
+	})
+
+	t.Run("list filters by date range", func(t *testing.T) {
+		// Arrange
+		repo := NewRepository(store.NewMemoryStore())
+		for _, date := range []string{"2024-03-01", "2024-03-02", "2024-03-03"} {
+			repo.Save(ctx, &CheckIn{UserID: "user-1", Date: date, SleepHours: 8, SleepQuality: 3, Soreness: 3, Mood: 3})
+		}
+
+		// Act
+		checkIns, _ := repo.List(ctx, "user-1", "2024-03-02", "2024-03-03")
+
+		// Assert
+		if len(checkIns) != 2 || checkIns[0].Date != "2024-03-02" {
+			t.Errorf("unexpected check-ins: %+v", checkIns)
+		}
+	})
+
+	t.Run("save recovery keeps the day's ratings", func(t *testing.T) {
+		// Arrange
+		repo := NewRepository(store.NewMemoryStore())
+		repo.Save(ctx, &CheckIn{UserID: "user-1", Date: "2024-03-01", SleepHours: 8, SleepQuality: 5, Soreness: 1, Mood: 5})
+
+		// Act
+		err := repo.SaveRecovery(ctx, "user-1", "2024-03-01", Recovery{Source: "oura", Score: 20})
+		rated, _ := repo.Get(ctx, "user-1", "2024-03-01")
+		newErr := repo.SaveRecovery(ctx, "user-1", "2024-03-02", Recovery{Source: "oura", Score: 55})
+		unrated, _ := repo.Get(ctx, "user-1", "2024-03-02")
+
+		// Assert
+		if err != nil || newErr != nil {
+			t.Fatalf("unexpected errors: %v, %v", err, newErr)
+		}
+		if rated.Mood != 5 || rated.Recovery == nil || rated.Score != 70 {
+			t.Errorf("unexpected rated check-in: %+v", rated)
+		}
+		if unrated.Subjective() || unrated.Score != 55 {
+			t.Errorf("unexpected unrated check-in: %+v", unrated)
+		}
+	})
+
+	t.Run("rejects invalid dates", func(t *testing.T) {
+		repo := NewRepository(store.NewMemoryStore())
+		err := repo.Save(ctx, &CheckIn{UserID: "user-1", Date: "01/03/2024", SleepQuality: 3, Soreness: 3, Mood: 3})
+		if err == nil {
+			t.Error("expected validation error")
+		}
+	})
+}
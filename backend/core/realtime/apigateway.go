@@ -0,0 +1,45 @@
+package realtime
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"athlete-forge/awsapi"
+)
+
+// APIGateway posts to WebSocket connections through the API Gateway management
+// API
+type APIGateway struct {
+	client *awsapi.Client
+}
+
+// NewAPIGateway creates a Poster signing its requests with client
+func NewAPIGateway(client *awsapi.Client) *APIGateway {
+	return &APIGateway{client: client}
+}
+
+// Post calls PostToConnection, mapping 410 Gone to ErrGone
+func (a *APIGateway) Post(ctx context.Context, endpoint, connectionID string, data []byte) error {
+	target := strings.TrimRight(endpoint, "/") + "/@connections/" + url.PathEscape(connectionID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create PostToConnection request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.ContentLength = int64(len(data))
+
+	_, err = a.client.Do(req, data, "execute-api")
+	var apiErr *awsapi.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusGone {
+		return ErrGone
+	}
+	if err != nil {
+		return fmt.Errorf("failed to post to connection %s: %w", connectionID, err)
+	}
+	return nil
+}
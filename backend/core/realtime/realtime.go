@@ -0,0 +1,179 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"athlete-forge/store"
+)
+
+const (
+	connectionSKPrefix = "WSCONN#"
+	connectionPKPrefix = "WSCONN#"
+)
+
+// RefPKPrefix is the partition of the items finding a connection's user from
+// its ID, each keyed by that user so it can be kept in the user's region
+const RefPKPrefix = connectionPKPrefix
+
+// ErrGone is returned by a Poster for connections that have closed
+var ErrGone = errors.New("connection is gone")
+
+// Connection is a device's open WebSocket connection. Endpoint is the API
+// Gateway management endpoint messages to it are posted through, and SessionID
+// the sign-in session it was opened with, so signing out closes it to messages
+type Connection struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"userId"`
+	SessionID   string    `json:"sessionId"`
+	Scopes      []string  `json:"scopes"`
+	Endpoint    string    `json:"endpoint"`
+	ConnectedAt time.Time `json:"connectedAt"`
+}
+
+// Message is what is sent to a user's devices: a type naming what changed and
+// its current state
+type Message struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// Poster sends data to one open connection
+type Poster interface {
+	// Post sends data to connectionID through endpoint, returning ErrGone when
+	// the connection has closed
+	Post(ctx context.Context, endpoint, connectionID string, data []byte) error
+}
+
+// Hub tracks users' open connections and sends messages to all of them. Each
+// connection is stored under its user, to broadcast, and under its own ID, since
+// disconnects and messages arrive with only the connection ID
+type Hub struct {
+	store  store.Store
+	poster Poster
+}
+
+// NewHub creates a Hub backed by s that sends through poster; without a poster
+// messages are dropped
+func NewHub(s store.Store, poster Poster) *Hub {
+	return &Hub{store: s, poster: poster}
+}
+
+// connectionRef finds a connection's user from its ID
+type connectionRef struct {
+	UserID string `json:"userId"`
+}
+
+// Connect records c as open
+func (h *Hub) Connect(ctx context.Context, c *Connection) error {
+	if err := h.store.Put(ctx, store.UserPK(c.UserID), connectionSKPrefix+c.ID, c); err != nil {
+		return fmt.Errorf("failed to save connection: %w", err)
+	}
+	if err := h.store.Put(ctx, connectionPKPrefix+c.ID, store.UserPK(c.UserID), connectionRef{UserID: c.UserID}); err != nil {
+		return fmt.Errorf("failed to save connection: %w", err)
+	}
+	return nil
+}
+
+// ref finds the user of the connection with id and the sort key it is found
+// under
+func (h *Hub) ref(ctx context.Context, id string) (connectionRef, string, error) {
+	items, err := h.store.Query(ctx, connectionPKPrefix+id, "")
+	if err != nil {
+		return connectionRef{}, "", fmt.Errorf("failed to load connection: %w", err)
+	}
+	if len(items) == 0 {
+		return connectionRef{}, "", store.ErrNotFound
+	}
+	var ref connectionRef
+	if err := items[0].Decode(&ref); err != nil {
+		return connectionRef{}, "", err
+	}
+	return ref, items[0].SK, nil
+}
+
+// Get returns the open connection with id
+func (h *Hub) Get(ctx context.Context, id string) (*Connection, error) {
+	ref, _, err := h.ref(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var c Connection
+	if err := h.store.Get(ctx, store.UserPK(ref.UserID), connectionSKPrefix+id, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Disconnect forgets the connection with id; unknown connections are ignored
+func (h *Hub) Disconnect(ctx context.Context, id string) error {
+	ref, refSK, err := h.ref(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := h.store.Delete(ctx, store.UserPK(ref.UserID), connectionSKPrefix+id); err != nil {
+		return fmt.Errorf("failed to delete connection: %w", err)
+	}
+	if err := h.store.Delete(ctx, connectionPKPrefix+id, refSK); err != nil {
+		return fmt.Errorf("failed to delete connection: %w", err)
+	}
+	return nil
+}
+
+// List returns userID's open connections
+func (h *Hub) List(ctx context.Context, userID string) ([]Connection, error) {
+	items, err := h.store.Query(ctx, store.UserPK(userID), connectionSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list connections: %w", err)
+	}
+
+	connections := make([]Connection, 0, len(items))
+	for _, item := range items {
+		var c Connection
+		if err := item.Decode(&c); err != nil {
+			return nil, err
+		}
+		connections = append(connections, c)
+	}
+	return connections, nil
+}
+
+// Broadcast sends m to every one of userID's open connections and returns how
+// many it reached. Connections found to be gone are forgotten; other failures
+// are returned after every connection has been tried
+func (h *Hub) Broadcast(ctx context.Context, userID string, m Message) (int, error) {
+	if h.poster == nil {
+		return 0, nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal message: %w", err)
+	}
+	connections, err := h.List(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var errs []error
+	for _, c := range connections {
+		err := h.poster.Post(ctx, c.Endpoint, c.ID, data)
+		switch {
+		case errors.Is(err, ErrGone):
+			if err := h.Disconnect(ctx, c.ID); err != nil {
+				errs = append(errs, err)
+			}
+		case err != nil:
+			errs = append(errs, err)
+		default:
+			sent++
+		}
+	}
+	return sent, errors.Join(errs...)
+}
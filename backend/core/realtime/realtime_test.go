@@ -0,0 +1,116 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+)
+
+// fakePoster records posted messages, failing for the connections in errs
+type fakePoster struct {
+	posted map[string][]string
+	errs   map[string]error
+}
+
+func (p *fakePoster) Post(ctx context.Context, endpoint, connectionID string, data []byte) error {
+	if err := p.errs[connectionID]; err != nil {
+		return err
+	}
+	if p.posted == nil {
+		p.posted = map[string][]string{}
+	}
+	p.posted[connectionID] = append(p.posted[connectionID], string(data))
+	return nil
+}
+
+func TestHub(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	connect := func(t *testing.T, hub *Hub, id, userID string) {
+		t.Helper()
+		if err := hub.Connect(ctx, &Connection{ID: id, UserID: userID, Endpoint: "https://ws.example.com/prod", ConnectedAt: now}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	t.Run("broadcasts to every connection of the user", func(t *testing.T) {
+		// Arrange
+		poster := &fakePoster{}
+		hub := NewHub(store.NewMemoryStore(), poster)
+		connect(t, hub, "phone", "user-1")
+		connect(t, hub, "watch", "user-1")
+		connect(t, hub, "other", "user-2")
+
+		// Act
+		sent, err := hub.Broadcast(ctx, "user-1", Message{Type: "restTimer", Data: map[string]int{"durationSeconds": 90}})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if sent != 2 || len(poster.posted["phone"]) != 1 || len(poster.posted["watch"]) != 1 || len(poster.posted["other"]) != 0 {
+			t.Errorf("unexpected posts (%d sent): %v", sent, poster.posted)
+		}
+		if got := poster.posted["phone"][0]; got != `{"type":"restTimer","data":{"durationSeconds":90}}` {
+			t.Errorf("unexpected message: %s", got)
+		}
+	})
+
+	t.Run("forgets connections that are gone", func(t *testing.T) {
+		// Arrange
+		poster := &fakePoster{errs: map[string]error{"stale": ErrGone}}
+		hub := NewHub(store.NewMemoryStore(), poster)
+		connect(t, hub, "phone", "user-1")
+		connect(t, hub, "stale", "user-1")
+
+		// Act
+		sent, err := hub.Broadcast(ctx, "user-1", Message{Type: "restTimer"})
+
+		// Assert
+		if err != nil || sent != 1 {
+			t.Fatalf("expected 1 sent without error, got %d (%v)", sent, err)
+		}
+		if _, err := hub.Get(ctx, "stale"); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("expected the gone connection to be forgotten, got %v", err)
+		}
+		connections, _ := hub.List(ctx, "user-1")
+		if len(connections) != 1 || connections[0].ID != "phone" {
+			t.Errorf("unexpected connections: %+v", connections)
+		}
+	})
+
+	t.Run("returns other failures after trying every connection", func(t *testing.T) {
+		// Arrange
+		poster := &fakePoster{errs: map[string]error{"phone": errors.New("throttled")}}
+		hub := NewHub(store.NewMemoryStore(), poster)
+		connect(t, hub, "phone", "user-1")
+		connect(t, hub, "watch", "user-1")
+
+		// Act
+		sent, err := hub.Broadcast(ctx, "user-1", Message{Type: "restTimer"})
+
+		// Assert
+		if err == nil || sent != 1 {
+			t.Errorf("expected 1 sent and an error, got %d (%v)", sent, err)
+		}
+		if _, err := hub.Get(ctx, "phone"); err != nil {
+			t.Errorf("expected the failing connection to be kept, got %v", err)
+		}
+	})
+
+	t.Run("disconnecting an unknown connection is not an error", func(t *testing.T) {
+		// Arrange
+		hub := NewHub(store.NewMemoryStore(), nil)
+
+		// Act
+		err := hub.Disconnect(ctx, "missing")
+
+		// Assert
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
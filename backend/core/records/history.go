@@ -0,0 +1,110 @@
+package records
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"athlete-forge/workout"
+)
+
+// Delta is the change from the previous session: positive when weight, reps or
+// the estimated one-rep max went up
+type Delta struct {
+	Weight       float64 `json:"weight"`
+	Reps         int     `json:"reps"`
+	Estimated1RM float64 `json:"estimated1rm"`
+}
+
+// SessionSet is a set of a past session. Delta compares it with the set in the
+// same position of the previous session, and is omitted when that session had
+// fewer sets
+type SessionSet struct {
+	Weight       float64 `json:"weight"`
+	Reps         int     `json:"reps"`
+	Estimated1RM float64 `json:"estimated1rm"`
+	Delta        *Delta  `json:"delta,omitempty"`
+}
+
+// Session is an exercise's sets in one completed workout. Estimated1RM is the
+// session's best estimate, and Delta compares its top set with the previous
+// session's; the oldest session returned has no deltas when it is the first
+type Session struct {
+	WorkoutID    string       `json:"workoutId"`
+	Date         time.Time    `json:"date"`
+	Sets         []SessionSet `json:"sets"`
+	Estimated1RM float64      `json:"estimated1rm"`
+	Delta        *Delta       `json:"delta,omitempty"`
+}
+
+// Sessions returns the last n completed sessions of the named exercise, newest
+// first, each compared with the session before it. An exercise logged twice in
+// a workout counts as one session with the sets of both
+func Sessions(workouts []workout.Workout, name string, n int) []Session {
+	all := []Session{}
+	for _, w := range workouts {
+		if w.Status != workout.StatusCompleted || w.CompletedAt == nil {
+			continue
+		}
+		session := Session{WorkoutID: w.ID, Date: *w.CompletedAt, Sets: []SessionSet{}}
+		for _, exercise := range w.Exercises {
+			if !strings.EqualFold(exercise.Name, name) {
+				continue
+			}
+			for _, set := range exercise.Sets {
+				if !set.Performed() {
+					continue
+				}
+				e1rm := 0.0
+				if set.Estimable() {
+					e1rm = EstimatedOneRepMax(set.Load(), set.Reps)
+				}
+				session.Sets = append(session.Sets, SessionSet{Weight: set.Weight, Reps: set.Reps, Estimated1RM: e1rm})
+				session.Estimated1RM = math.Max(session.Estimated1RM, e1rm)
+			}
+		}
+		if len(session.Sets) > 0 {
+			all = append(all, session)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Date.Before(all[j].Date) })
+
+	for i := 1; i < len(all); i++ {
+		previous, current := all[i-1], &all[i]
+		for j := range current.Sets {
+			if j < len(previous.Sets) {
+				current.Sets[j].Delta = delta(previous.Sets[j], current.Sets[j])
+			}
+		}
+		current.Delta = delta(top(previous), top(*current))
+	}
+
+	if len(all) > n {
+		all = all[len(all)-n:]
+	}
+	for i, j := 0, len(all)-1; i < j; i, j = i+1, j-1 {
+		all[i], all[j] = all[j], all[i]
+	}
+	return all
+}
+
+// top returns the session's set with the best estimated one-rep max, or its
+// heaviest when none can be estimated
+func top(s Session) SessionSet {
+	best := s.Sets[0]
+	for _, set := range s.Sets[1:] {
+		if set.Estimated1RM > best.Estimated1RM || (set.Estimated1RM == best.Estimated1RM && set.Weight > best.Weight) {
+			best = set
+		}
+	}
+	return best
+}
+
+func delta(previous, current SessionSet) *Delta {
+	return &Delta{
+		Weight:       math.Round((current.Weight-previous.Weight)*100) / 100,
+		Reps:         current.Reps - previous.Reps,
+		Estimated1RM: math.Round((current.Estimated1RM-previous.Estimated1RM)*10) / 10,
+	}
+}
@@ -0,0 +1,58 @@
+package records
+
+import (
+	"testing"
+	"time"
+
+	"athlete-forge/workout"
+)
+
+func TestSessions(t *testing.T) {
+	completed := func(id string, day int, exercises ...workout.Exercise) workout.Workout {
+		at := time.Date(2024, 3, day, 18, 0, 0, 0, time.UTC)
+		return workout.Workout{ID: id, Status: workout.StatusCompleted, CompletedAt: &at, Exercises: exercises}
+	}
+	squat := func(sets ...workout.Set) workout.Exercise {
+		return workout.Exercise{Name: "Squat", Sets: sets}
+	}
+	workouts := []workout.Workout{
+		completed("w3", 8, squat(workout.Set{Reps: 5, Weight: 105}, workout.Set{Reps: 4, Weight: 105})),
+		completed("w1", 1, squat(workout.Set{Reps: 5, Weight: 95})),
+		completed("w2", 4, squat(workout.Set{Reps: 5, Weight: 100}, workout.Set{Reps: 5, Weight: 100})),
+		completed("w4", 9, workout.Exercise{Name: "Bench Press", Sets: []workout.Set{{Reps: 5, Weight: 80}}}),
+		{ID: "active", Status: workout.StatusActive, Exercises: []workout.Exercise{squat(workout.Set{Reps: 5, Weight: 110})}},
+	}
+
+	t.Run("returns the latest sessions newest first with set-by-set deltas", func(t *testing.T) {
+		// Act
+		sessions := Sessions(workouts, "squat", 2)
+
+		// Assert
+		if len(sessions) != 2 || sessions[0].WorkoutID != "w3" || sessions[1].WorkoutID != "w2" {
+			t.Fatalf("unexpected sessions: %+v", sessions)
+		}
+		latest := sessions[0]
+		if d := latest.Sets[0].Delta; d == nil || d.Weight != 5 || d.Reps != 0 || d.Estimated1RM != 5.8 {
+			t.Errorf("unexpected first set delta: %+v", d)
+		}
+		if d := latest.Sets[1].Delta; d == nil || d.Weight != 5 || d.Reps != -1 {
+			t.Errorf("unexpected second set delta: %+v", d)
+		}
+		if sessions[1].Sets[1].Delta != nil {
+			t.Errorf("expected no delta for a set the previous session did not have, got %+v", sessions[1].Sets[1].Delta)
+		}
+		if sessions[1].Delta == nil || sessions[1].Delta.Weight != 5 {
+			t.Errorf("expected the oldest returned session compared with the one before it, got %+v", sessions[1].Delta)
+		}
+	})
+
+	t.Run("a first session has nothing to compare with", func(t *testing.T) {
+		// Act
+		sessions := Sessions(workouts, "Squat", 10)
+
+		// Assert
+		if len(sessions) != 3 || sessions[2].Delta != nil || sessions[2].Sets[0].Delta != nil {
+			t.Errorf("unexpected sessions: %+v", sessions)
+		}
+	})
+}
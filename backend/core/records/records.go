@@ -0,0 +1,103 @@
+package records
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"athlete-forge/workout"
+)
+
+// EstimatedOneRepMax estimates a one-rep max with the Epley formula; sets above
+// twelve reps are too far from a single to estimate reliably and return 0
+func EstimatedOneRepMax(weight float64, reps int) float64 {
+	if reps <= 0 || reps > 12 || weight <= 0 {
+		return 0
+	}
+	if reps == 1 {
+		return weight
+	}
+	return math.Round(weight*(1+float64(reps)/30)*10) / 10
+}
+
+// LoadFor inverts EstimatedOneRepMax to the weight that leaves 10 - rpe reps in
+// reserve after reps, so a set of 5 at RPE 8 is treated as a 7 rep max
+func LoadFor(e1rm float64, reps int, rpe float64) float64 {
+	if e1rm <= 0 || reps <= 0 {
+		return 0
+	}
+	toFailure := float64(reps) + 10 - rpe
+	if toFailure <= 1 {
+		return e1rm
+	}
+	return e1rm / (1 + toFailure/30)
+}
+
+// Record is the best estimated one-rep max set for an exercise. Weight is the
+// weight logged, and Estimated1RM is estimated from the set's load, which
+// counts accommodating resistance
+type Record struct {
+	Exercise     string    `json:"exercise"`
+	Weight       float64   `json:"weight"`
+	Reps         int       `json:"reps"`
+	Estimated1RM float64   `json:"estimated1rm"`
+	WorkoutID    string    `json:"workoutId"`
+	Date         time.Time `json:"date"`
+}
+
+// Best returns each exercise's best set across completed workouts finished before
+// end, keyed by lower-cased exercise name
+func Best(workouts []workout.Workout, end time.Time) map[string]Record {
+	return Between(workouts, time.Time{}, end)
+}
+
+// Between returns each exercise's best set across completed workouts finished from
+// start until before end, keyed by lower-cased exercise name
+func Between(workouts []workout.Workout, start, end time.Time) map[string]Record {
+	best := map[string]Record{}
+	for _, w := range workouts {
+		if w.Status != workout.StatusCompleted || w.CompletedAt == nil || w.CompletedAt.Before(start) || !w.CompletedAt.Before(end) {
+			continue
+		}
+		for _, exercise := range w.Exercises {
+			key := strings.ToLower(exercise.Name)
+			for _, set := range exercise.Sets {
+				if !set.Estimable() {
+					continue
+				}
+				e1rm := EstimatedOneRepMax(set.Load(), set.Reps)
+				if e1rm == 0 || e1rm <= best[key].Estimated1RM {
+					continue
+				}
+				best[key] = Record{
+					Exercise:     exercise.Name,
+					Weight:       set.Weight,
+					Reps:         set.Reps,
+					Estimated1RM: e1rm,
+					WorkoutID:    w.ID,
+					Date:         *w.CompletedAt,
+				}
+			}
+		}
+	}
+	return best
+}
+
+// New returns records set between start and end that beat every earlier set for the
+// exercise, ordered by exercise name; an exercise's first ever session is not a record
+func New(workouts []workout.Workout, start, end time.Time) []Record {
+	before := Best(workouts, start)
+	after := Best(workouts, end)
+
+	prs := []Record{}
+	for key, record := range after {
+		previous, ok := before[key]
+		if !ok || record.Estimated1RM <= previous.Estimated1RM {
+			continue
+		}
+		prs = append(prs, record)
+	}
+	sort.Slice(prs, func(i, j int) bool { return prs[i].Exercise < prs[j].Exercise })
+	return prs
+}
@@ -0,0 +1,119 @@
+package records
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"athlete-forge/workout"
+)
+
+func TestEstimatedOneRepMax(t *testing.T) {
+	tests := []struct {
+		weight float64
+		reps   int
+		want   float64
+	}{
+		{weight: 100, reps: 1, want: 100},
+		{weight: 100, reps: 5, want: 116.7},
+		{weight: 100, reps: 15, want: 0},
+		{weight: 0, reps: 5, want: 0},
+	}
+	for _, tt := range tests {
+		if got := EstimatedOneRepMax(tt.weight, tt.reps); got != tt.want {
+			t.Errorf("EstimatedOneRepMax(%v, %d) = %v, want %v", tt.weight, tt.reps, got, tt.want)
+		}
+	}
+}
+
+func TestLoadFor(t *testing.T) {
+	tests := []struct {
+		e1rm float64
+		reps int
+		rpe  float64
+		want float64
+	}{
+		{e1rm: 120, reps: 1, rpe: 10, want: 120},
+		{e1rm: 120, reps: 5, rpe: 10, want: 102.9},
+		{e1rm: 120, reps: 5, rpe: 8, want: 97.3},
+		{e1rm: 0, reps: 5, rpe: 8, want: 0},
+	}
+	for _, tt := range tests {
+		if got := math.Round(LoadFor(tt.e1rm, tt.reps, tt.rpe)*10) / 10; got != tt.want {
+			t.Errorf("LoadFor(%v, %d, %v) = %v, want %v", tt.e1rm, tt.reps, tt.rpe, got, tt.want)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	completed := func(id string, day int, name string, sets ...workout.Set) workout.Workout {
+		at := time.Date(2024, 3, day, 18, 0, 0, 0, time.UTC)
+		return workout.Workout{ID: id, Status: workout.StatusCompleted, CompletedAt: &at, Exercises: []workout.Exercise{{Name: name, Sets: sets}}}
+	}
+
+	t.Run("reports exercises beaten within the period", func(t *testing.T) {
+		// Arrange
+		workouts := []workout.Workout{
+			completed("w1", 1, "Squat", workout.Set{Reps: 5, Weight: 100}),
+			completed("w2", 1, "Bench", workout.Set{Reps: 5, Weight: 80}),
+			completed("w3", 5, "squat", workout.Set{Reps: 3, Weight: 110}),
+			completed("w4", 5, "Bench", workout.Set{Reps: 5, Weight: 77.5}),
+			completed("w5", 6, "Deadlift", workout.Set{Reps: 5, Weight: 140}),
+		}
+		start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+		// Act
+		prs := New(workouts, start, start.AddDate(0, 0, 7))
+
+		// Assert
+		if len(prs) != 1 || prs[0].WorkoutID != "w3" || prs[0].Estimated1RM != 121 {
+			t.Errorf("unexpected records: %+v", prs)
+		}
+	})
+}
+
+func TestBetween(t *testing.T) {
+	t.Run("ignores workouts outside the window", func(t *testing.T) {
+		// Arrange
+		old := time.Date(2024, 1, 1, 18, 0, 0, 0, time.UTC)
+		recent := time.Date(2024, 3, 1, 18, 0, 0, 0, time.UTC)
+		workouts := []workout.Workout{
+			{ID: "w1", Status: workout.StatusCompleted, CompletedAt: &old, Exercises: []workout.Exercise{{Name: "Squat", Sets: []workout.Set{{Reps: 5, Weight: 140}}}}},
+			{ID: "w2", Status: workout.StatusCompleted, CompletedAt: &recent, Exercises: []workout.Exercise{{Name: "Squat", Sets: []workout.Set{{Reps: 5, Weight: 120}}}}},
+		}
+
+		// Act
+		best := Between(workouts, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC))
+
+		// Assert
+		if best["squat"].WorkoutID != "w2" || best["squat"].Estimated1RM != 140 {
+			t.Errorf("unexpected best: %+v", best)
+		}
+	})
+	t.Run("ignores assisted sets", func(t *testing.T) {
+		// Arrange
+		at := time.Date(2024, 3, 1, 18, 0, 0, 0, time.UTC)
+		workouts := []workout.Workout{{ID: "w1", Status: workout.StatusCompleted, CompletedAt: &at, Exercises: []workout.Exercise{{Name: "Pull-Up", Sets: []workout.Set{{Reps: 8, Weight: 40, Assistance: 30}}}}}}
+
+		// Act
+		best := Between(workouts, time.Time{}, at.Add(time.Hour))
+
+		// Assert
+		if len(best) != 0 {
+			t.Errorf("expected no records, got %+v", best)
+		}
+	})
+	t.Run("estimates from the load including chains and bands", func(t *testing.T) {
+		// Arrange
+		at := time.Date(2024, 3, 1, 18, 0, 0, 0, time.UTC)
+		workouts := []workout.Workout{{ID: "w1", Status: workout.StatusCompleted, CompletedAt: &at, Exercises: []workout.Exercise{{Name: "Squat", Sets: []workout.Set{{Reps: 1, Weight: 140, Chains: 40}}}}}}
+
+		// Act
+		best := Between(workouts, time.Time{}, at.Add(time.Hour))
+
+		// Assert
+		if best["squat"].Estimated1RM != 160 || best["squat"].Weight != 140 {
+			t.Errorf("expected a 160kg estimate for 140kg with 40kg of chains, got %+v", best["squat"])
+		}
+	})
+}
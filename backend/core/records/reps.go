@@ -0,0 +1,107 @@
+package records
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"athlete-forge/workout"
+)
+
+// RepRecord is the most reps an exercise has been lifted for at Weight, the
+// load moved counting accommodating resistance. AMRAP reports whether the set
+// was taken for as many reps as possible
+type RepRecord struct {
+	Exercise  string    `json:"exercise"`
+	Weight    float64   `json:"weight"`
+	Reps      int       `json:"reps"`
+	AMRAP     bool      `json:"amrap,omitempty"`
+	WorkoutID string    `json:"workoutId"`
+	Date      time.Time `json:"date"`
+}
+
+// RepsToBeat is what a set of an exercise at Weight must do to set a record.
+// Reps is the rep record at Weight or heavier, which one more rep beats, and
+// Record the set that holds it; both are empty when the exercise has never been
+// lifted that heavy. Estimated1RMReps is the fewest reps at Weight whose
+// estimate beats the best Estimated1RM, or 0 when it would take more than 12
+type RepsToBeat struct {
+	Exercise         string     `json:"exercise"`
+	Weight           float64    `json:"weight"`
+	Reps             int        `json:"reps"`
+	Record           *RepRecord `json:"record,omitempty"`
+	Estimated1RM     float64    `json:"estimated1rm,omitempty"`
+	Estimated1RMReps int        `json:"estimated1rmReps,omitempty"`
+}
+
+// RepRecords returns the named exercise's rep records across completed
+// workouts, heaviest first: for each weight, the most reps lifted with it,
+// dropping any record a heavier set matched. The first set to reach a record
+// holds it
+func RepRecords(workouts []workout.Workout, name string) []RepRecord {
+	completed := make([]workout.Workout, 0, len(workouts))
+	for _, w := range workouts {
+		if w.Status == workout.StatusCompleted && w.CompletedAt != nil {
+			completed = append(completed, w)
+		}
+	}
+	sort.SliceStable(completed, func(i, j int) bool { return completed[i].CompletedAt.Before(*completed[j].CompletedAt) })
+
+	byWeight := map[float64]RepRecord{}
+	for _, w := range completed {
+		for _, exercise := range w.Exercises {
+			if !strings.EqualFold(exercise.Name, name) {
+				continue
+			}
+			for _, set := range exercise.Sets {
+				if !set.Estimable() || set.Reps <= 0 || set.Load() <= 0 {
+					continue
+				}
+				load := set.Load()
+				if set.Reps <= byWeight[load].Reps {
+					continue
+				}
+				byWeight[load] = RepRecord{Exercise: exercise.Name, Weight: load, Reps: set.Reps, AMRAP: set.AMRAP, WorkoutID: w.ID, Date: *w.CompletedAt}
+			}
+		}
+	}
+
+	all := make([]RepRecord, 0, len(byWeight))
+	for _, record := range byWeight {
+		all = append(all, record)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Weight > all[j].Weight })
+
+	kept := []RepRecord{}
+	for _, record := range all {
+		if len(kept) > 0 && record.Reps <= kept[len(kept)-1].Reps {
+			continue
+		}
+		kept = append(kept, record)
+	}
+	return kept
+}
+
+// ToBeat returns what a set of the named exercise at weight must do to beat
+// its rep record there and its best estimated one-rep max
+func ToBeat(workouts []workout.Workout, name string, weight float64) RepsToBeat {
+	toBeat := RepsToBeat{Exercise: name, Weight: weight}
+	for _, record := range RepRecords(workouts, name) {
+		if record.Weight < weight {
+			break
+		}
+		record := record
+		toBeat.Reps, toBeat.Record = record.Reps, &record
+	}
+
+	toBeat.Estimated1RM = Best(workouts, time.Now().UTC())[strings.ToLower(name)].Estimated1RM
+	if toBeat.Estimated1RM > 0 {
+		for reps := 1; reps <= 12; reps++ {
+			if EstimatedOneRepMax(weight, reps) > toBeat.Estimated1RM {
+				toBeat.Estimated1RMReps = reps
+				break
+			}
+		}
+	}
+	return toBeat
+}
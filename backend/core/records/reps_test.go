@@ -0,0 +1,57 @@
+package records
+
+import (
+	"testing"
+	"time"
+
+	"athlete-forge/workout"
+)
+
+func TestRepRecords(t *testing.T) {
+	completed := func(id string, day int, sets ...workout.Set) workout.Workout {
+		at := time.Date(2024, 3, day, 18, 0, 0, 0, time.UTC)
+		return workout.Workout{ID: id, Status: workout.StatusCompleted, CompletedAt: &at, Exercises: []workout.Exercise{{Name: "Squat", Sets: sets}}}
+	}
+	workouts := []workout.Workout{
+		completed("w2", 8, workout.Set{Reps: 8, Weight: 100, AMRAP: true}, workout.Set{Reps: 8, Weight: 90}),
+		completed("w1", 1, workout.Set{Reps: 5, Weight: 100}, workout.Set{Reps: 3, Weight: 110}, workout.Set{Reps: 3, Weight: 105}),
+		completed("w3", 15, workout.Set{Reps: 8, Weight: 100}),
+		{ID: "active", Status: workout.StatusActive, Exercises: []workout.Exercise{{Name: "Squat", Sets: []workout.Set{{Reps: 12, Weight: 100}}}}},
+	}
+
+	t.Run("keeps the most reps at each weight that no heavier set matched", func(t *testing.T) {
+		// Act
+		prs := RepRecords(workouts, "squat")
+
+		// Assert
+		if len(prs) != 2 || prs[0].Weight != 110 || prs[0].Reps != 3 || prs[1].Weight != 100 || prs[1].Reps != 8 {
+			t.Fatalf("unexpected rep records: %+v", prs)
+		}
+		if prs[1].WorkoutID != "w2" || !prs[1].AMRAP {
+			t.Errorf("expected the first set to reach the record to hold it, got %+v", prs[1])
+		}
+	})
+
+	t.Run("reps to beat come from the record at the weight or heavier", func(t *testing.T) {
+		// Act
+		toBeat := ToBeat(workouts, "Squat", 105)
+
+		// Assert
+		if toBeat.Reps != 3 || toBeat.Record == nil || toBeat.Record.Weight != 110 {
+			t.Errorf("unexpected rep record to beat: %+v", toBeat)
+		}
+		if toBeat.Estimated1RM != 126.7 || toBeat.Estimated1RMReps != 7 {
+			t.Errorf("expected 7 reps to beat a 126.7 estimate, got %+v", toBeat)
+		}
+	})
+
+	t.Run("nothing to beat above the heaviest set", func(t *testing.T) {
+		// Act
+		toBeat := ToBeat(workouts, "Squat", 120)
+
+		// Assert
+		if toBeat.Reps != 0 || toBeat.Record != nil || toBeat.Estimated1RMReps != 2 {
+			t.Errorf("unexpected reps to beat: %+v", toBeat)
+		}
+	})
+}
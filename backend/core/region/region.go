@@ -0,0 +1,152 @@
+package region
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"athlete-forge/store"
+)
+
+const (
+	regionPK           = "REGION"
+	activeSK           = "ACTIVE"
+	heartbeatSKPrefix  = "HEARTBEAT#"
+	defaultActiveCache = 30 * time.Second
+)
+
+// Roles in an active-passive pair
+const (
+	RoleActive  = "active"
+	RolePassive = "passive"
+)
+
+// Active records which region serves writes; it is stored in the global table so
+// a promotion made in either region reaches the other once it can replicate
+type Active struct {
+	Region     string    `json:"region"`
+	PromotedAt time.Time `json:"promotedAt"`
+}
+
+// Heartbeat is the last time a region wrote to the global table; its age in
+// another region bounds how far replication from it is behind
+type Heartbeat struct {
+	Region string    `json:"region"`
+	At     time.Time `json:"at"`
+}
+
+// Lag is how long ago a peer region's newest heartbeat was written, as seen by
+// this region's replica
+type Lag struct {
+	Region        string    `json:"region"`
+	LastHeartbeat time.Time `json:"lastHeartbeat"`
+	LagSeconds    float64   `json:"lagSeconds"`
+}
+
+// Registry tracks the region this deployment runs in, which region is active and
+// each region's heartbeats
+type Registry struct {
+	store   store.Store
+	name    string
+	primary string
+
+	mu        sync.Mutex
+	active    string
+	checkedAt time.Time
+	cacheFor  time.Duration
+}
+
+// New creates a Registry for the deployment in region name; primary is active
+// until a promotion is recorded
+func New(s store.Store, name, primary string) *Registry {
+	return &Registry{store: s, name: name, primary: primary, cacheFor: defaultActiveCache}
+}
+
+// Name returns the region this deployment runs in
+func (r *Registry) Name() string {
+	return r.name
+}
+
+// Active returns the active region; it is cached briefly, so a promotion takes up
+// to 30 seconds to be seen by running functions
+func (r *Registry) Active(ctx context.Context, now time.Time) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.active != "" && now.Sub(r.checkedAt) < r.cacheFor {
+		return r.active, nil
+	}
+
+	var a Active
+	err := r.store.Get(ctx, regionPK, activeSK, &a)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		a.Region = r.primary
+	case err != nil:
+		return "", fmt.Errorf("failed to load active region: %w", err)
+	}
+	r.active, r.checkedAt = a.Region, now
+	return r.active, nil
+}
+
+// Role returns whether this deployment is the active or passive region
+func (r *Registry) Role(ctx context.Context, now time.Time) (string, error) {
+	active, err := r.Active(ctx, now)
+	if err != nil {
+		return "", err
+	}
+	if active == r.name {
+		return RoleActive, nil
+	}
+	return RolePassive, nil
+}
+
+// Promote makes this deployment's region the active one
+func (r *Registry) Promote(ctx context.Context, now time.Time) (*Active, error) {
+	a := &Active{Region: r.name, PromotedAt: now.UTC()}
+	if err := r.store.Put(ctx, regionPK, activeSK, a); err != nil {
+		return nil, fmt.Errorf("failed to promote region: %w", err)
+	}
+
+	r.mu.Lock()
+	r.active, r.checkedAt = r.name, now
+	r.mu.Unlock()
+	return a, nil
+}
+
+// Beat records a heartbeat for this deployment's region
+func (r *Registry) Beat(ctx context.Context, now time.Time) error {
+	beat := Heartbeat{Region: r.name, At: now.UTC()}
+	if err := r.store.Put(ctx, regionPK, heartbeatSKPrefix+r.name, beat); err != nil {
+		return fmt.Errorf("failed to record heartbeat: %w", err)
+	}
+	return nil
+}
+
+// Lags reports the age of every other region's newest heartbeat, ordered by region
+func (r *Registry) Lags(ctx context.Context, now time.Time) ([]Lag, error) {
+	items, err := r.store.Query(ctx, regionPK, heartbeatSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list heartbeats: %w", err)
+	}
+
+	lags := []Lag{}
+	for _, item := range items {
+		var beat Heartbeat
+		if err := item.Decode(&beat); err != nil {
+			return nil, err
+		}
+		if beat.Region == "" {
+			beat.Region = strings.TrimPrefix(item.SK, heartbeatSKPrefix)
+		}
+		if beat.Region == r.name {
+			continue
+		}
+		lags = append(lags, Lag{Region: beat.Region, LastHeartbeat: beat.At, LagSeconds: now.Sub(beat.At).Round(time.Second).Seconds()})
+	}
+	sort.Slice(lags, func(i, j int) bool { return lags[i].Region < lags[j].Region })
+	return lags, nil
+}
@@ -0,0 +1,75 @@
+package region
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+)
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+
+	t.Run("the primary is active until another region is promoted", func(t *testing.T) {
+		// Arrange
+		s := store.NewMemoryStore()
+		primary := New(s, "eu-west-2", "eu-west-2")
+		secondary := New(s, "eu-west-1", "eu-west-2")
+		before, _ := secondary.Role(ctx, now)
+
+		// Act
+		_, err := secondary.Promote(ctx, now)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		after, _ := secondary.Role(ctx, now)
+		if before != RolePassive || after != RoleActive {
+			t.Errorf("expected passive then active, got %s then %s", before, after)
+		}
+		cached, _ := primary.Role(ctx, now)
+		if cached != RolePassive {
+			t.Errorf("expected the old primary to see the promotion, got %s", cached)
+		}
+	})
+
+	t.Run("caches the active region", func(t *testing.T) {
+		// Arrange
+		s := store.NewMemoryStore()
+		primary := New(s, "eu-west-2", "eu-west-2")
+		primary.Role(ctx, now)
+		New(s, "eu-west-1", "eu-west-2").Promote(ctx, now)
+
+		// Act
+		cached, _ := primary.Role(ctx, now.Add(10*time.Second))
+		refreshed, _ := primary.Role(ctx, now.Add(time.Minute))
+
+		// Assert
+		if cached != RoleActive || refreshed != RolePassive {
+			t.Errorf("expected active from cache then passive, got %s then %s", cached, refreshed)
+		}
+	})
+
+	t.Run("reports the age of other regions' heartbeats", func(t *testing.T) {
+		// Arrange
+		s := store.NewMemoryStore()
+		primary := New(s, "eu-west-2", "eu-west-2")
+		secondary := New(s, "eu-west-1", "eu-west-2")
+		primary.Beat(ctx, now)
+		secondary.Beat(ctx, now.Add(-90*time.Second))
+
+		// Act
+		lags, err := primary.Lags(ctx, now)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(lags) != 1 || lags[0].Region != "eu-west-1" || lags[0].LagSeconds != 90 {
+			t.Errorf("unexpected lags: %+v", lags)
+		}
+	})
+}
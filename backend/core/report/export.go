@@ -0,0 +1,95 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"athlete-forge/dailylog"
+	"athlete-forge/store"
+)
+
+const exportSKPrefix = "EXPORT#"
+
+// MaxExportDays is the longest date range an export may cover
+const MaxExportDays = 366
+
+// Export statuses
+const (
+	ExportPending = "pending"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+)
+
+// Export is a request to render a training report PDF
+type Export struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"userId"`
+	From        string     `json:"from"`
+	To          string     `json:"to"`
+	Status      string     `json:"status"`
+	JobID       string     `json:"jobId,omitempty"`
+	Key         string     `json:"-"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	DownloadURL string     `json:"downloadUrl,omitempty"`
+}
+
+// storedExport keeps the object key, which is hidden from API responses
+type storedExport struct {
+	Export
+	Key string `json:"key,omitempty"`
+}
+
+// Range parses and validates the export's date range
+func (e *Export) Range() (time.Time, time.Time, error) {
+	from, err := time.Parse(dailylog.DateLayout, e.From)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("from must be in YYYY-MM-DD format")
+	}
+	to, err := time.Parse(dailylog.DateLayout, e.To)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("to must be in YYYY-MM-DD format")
+	}
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, errors.New("to must not be before from")
+	}
+	if to.Sub(from) >= MaxExportDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("exports may cover at most %d days", MaxExportDays)
+	}
+	return from, to, nil
+}
+
+// ObjectKey returns where the rendered PDF is stored
+func (e *Export) ObjectKey() string {
+	return fmt.Sprintf("exports/%s/%s.pdf", e.UserID, e.ID)
+}
+
+// GetExport returns userID's export with id
+func (r *Repository) GetExport(ctx context.Context, userID, id string) (*Export, error) {
+	var stored storedExport
+	if err := r.store.Get(ctx, store.UserPK(userID), exportSKPrefix+id, &stored); err != nil {
+		return nil, err
+	}
+	stored.Export.Key = stored.Key
+	return &stored.Export, nil
+}
+
+// SaveExport validates and stores e, assigning an ID to new exports
+func (r *Repository) SaveExport(ctx context.Context, e *Export) error {
+	if _, _, err := e.Range(); err != nil {
+		return err
+	}
+	if e.ID == "" {
+		e.ID = store.NewID()
+	}
+
+	stored := storedExport{Export: *e, Key: e.Key}
+	stored.DownloadURL = ""
+	if err := r.store.Put(ctx, store.UserPK(e.UserID), exportSKPrefix+e.ID, stored); err != nil {
+		return fmt.Errorf("failed to save export: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"athlete-forge/dailylog"
+	"athlete-forge/l10n"
+	"athlete-forge/pdf"
+)
+
+// Page layout in points
+const (
+	pageMargin  = 50
+	contentTop  = pdf.A4Height - 50
+	chartHeight = 110
+)
+
+var contentWidth = pdf.A4Width - 2*pageMargin
+
+// layout tracks the current page and vertical position while rendering
+type layout struct {
+	doc  *pdf.Document
+	page *pdf.Page
+	y    float64
+}
+
+// need starts a new page unless height points remain above the bottom margin
+func (l *layout) need(height float64) {
+	if l.page == nil || l.y-height < pageMargin {
+		l.page = l.doc.AddPage()
+		l.y = contentTop
+	}
+}
+
+func (l *layout) line(size float64, bold bool, text string) {
+	l.need(size + 6)
+	l.y -= size + 6
+	l.page.Text(pageMargin, l.y, size, bold, text)
+}
+
+func (l *layout) heading(text string) {
+	l.need(60)
+	l.y -= 14
+	l.line(14, true, text)
+	l.y -= 4
+	l.page.Line(pageMargin, l.y, pageMargin+contentWidth, l.y, 0.5, pdf.Gray)
+}
+
+// RenderTrainingPDF lays t out as a printable A4 document, writing numbers
+// and dates by c
+func RenderTrainingPDF(t Training, c l10n.Conventions) []byte {
+	l := &layout{doc: pdf.New()}
+
+	l.line(20, true, "Training report")
+	l.line(11, false, fmt.Sprintf("%s to %s", c.DateString(t.From), c.DateString(t.To)))
+
+	l.heading("Compliance")
+	l.line(11, false, fmt.Sprintf("Sessions completed: %d of %d (%s)", t.Compliance.Completed, t.Compliance.Started, c.Percent(t.Compliance.Rate)))
+	for _, p := range t.Programs {
+		name := p.Name
+		if name == "" {
+			name = p.ProgramID
+		}
+		l.line(11, false, fmt.Sprintf("  %s: %d of %d (%s)", name, p.Completed, p.Started, c.Percent(p.Rate)))
+		for _, week := range p.Weeks {
+			sessions := fmt.Sprintf("%d sessions", week.SessionsCompleted)
+			if week.SessionsPlanned > 0 {
+				sessions = fmt.Sprintf("%d of %d sessions (%s)", week.SessionsCompleted, week.SessionsPlanned, c.Percent(week.SessionRate))
+			}
+			l.line(9, false, fmt.Sprintf("    Week of %s: %s, %s of prescribed volume", c.DateString(week.WeekStart), sessions, c.Percent(week.VolumeRate)))
+		}
+	}
+
+	l.heading("Personal records")
+	if len(t.PersonalRecords) == 0 {
+		l.line(11, false, "No new personal records in this period")
+	}
+	for _, r := range t.PersonalRecords {
+		l.line(11, false, fmt.Sprintf("  %s: %s %s x %d on %s (est. 1RM %s %s)",
+			r.Exercise, c.Number(r.Weight, 2), t.Unit, r.Reps, c.Date(r.Date), c.Number(r.Estimated1RM, 2), t.Unit))
+	}
+
+	if len(t.WeeklyLoad) > 0 {
+		l.heading("Weekly training load")
+		barChart(l, t.WeeklyLoad, c)
+	}
+
+	if len(t.Progress) > 0 {
+		l.heading("Estimated 1RM progress")
+		for _, p := range t.Progress {
+			lineChart(l, p, t.Unit, c)
+		}
+	}
+
+	return l.doc.Bytes()
+}
+
+// barChart draws one bar per week scaled to the heaviest week
+func barChart(l *layout, weeks []WeekLoad, c l10n.Conventions) {
+	l.need(chartHeight + 30)
+	top := l.y - 10
+	bottom := top - chartHeight
+
+	maxLoad := 0.0
+	for _, w := range weeks {
+		maxLoad = math.Max(maxLoad, w.Load)
+	}
+
+	slot := contentWidth / float64(len(weeks))
+	labelEvery := int(math.Ceil(float64(len(weeks)) / 8))
+	for i, w := range weeks {
+		x := pageMargin + float64(i)*slot
+		if maxLoad > 0 && w.Load > 0 {
+			l.page.FillRect(x+slot*0.15, bottom, slot*0.7, w.Load/maxLoad*chartHeight, pdf.Blue)
+		}
+		if i%labelEvery == 0 {
+			l.page.Text(x+slot*0.15, bottom-12, 7, false, dayMonth(c, w.WeekStart))
+		}
+	}
+	l.page.Line(pageMargin, bottom, pageMargin+contentWidth, bottom, 0.5, pdf.Black)
+	l.page.Text(pageMargin, top+2, 8, false, fmt.Sprintf("max %s", c.Number(maxLoad, 2)))
+	l.y = bottom - 20
+}
+
+// lineChart plots an exercise's estimated 1RM over its sessions
+func lineChart(l *layout, p ExerciseProgress, unit string, c l10n.Conventions) {
+	l.need(chartHeight + 40)
+	l.line(11, true, p.Exercise)
+	top := l.y - 8
+	bottom := top - chartHeight + 20
+
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, point := range p.Points {
+		low = math.Min(low, point.Estimated1RM)
+		high = math.Max(high, point.Estimated1RM)
+	}
+	span := high - low
+	if span == 0 {
+		span = 1
+	}
+
+	step := 0.0
+	if len(p.Points) > 1 {
+		step = contentWidth / float64(len(p.Points)-1)
+	}
+	coords := make([]float64, 0, 2*len(p.Points))
+	for i, point := range p.Points {
+		coords = append(coords, pageMargin+float64(i)*step, bottom+(point.Estimated1RM-low)/span*(top-bottom))
+	}
+
+	l.page.Line(pageMargin, bottom, pageMargin+contentWidth, bottom, 0.5, pdf.Gray)
+	if len(coords) == 2 {
+		l.page.FillRect(coords[0]-2, coords[1]-2, 4, 4, pdf.Blue)
+	}
+	l.page.Polyline(coords, 1.5, pdf.Blue)
+
+	first, last := p.Points[0], p.Points[len(p.Points)-1]
+	l.page.Text(pageMargin, bottom-12, 8, false, fmt.Sprintf("%s: %s %s", c.DateString(first.Date), c.Number(first.Estimated1RM, 2), unit))
+	l.page.Text(pageMargin+contentWidth-130, bottom-12, 8, false, fmt.Sprintf("%s: %s %s", c.DateString(last.Date), c.Number(last.Estimated1RM, 2), unit))
+	l.y = bottom - 24
+}
+
+// dayMonth writes a YYYY-MM-DD date without its year, for chart labels
+func dayMonth(c l10n.Conventions, date string) string {
+	t, err := time.Parse(dailylog.DateLayout, date)
+	if err != nil {
+		return date
+	}
+	return c.DayMonth(t)
+}
@@ -0,0 +1,97 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"athlete-forge/i18n"
+	"athlete-forge/l10n"
+)
+
+// Rendered is a report formatted for a message, such as an email
+type Rendered struct {
+	Subject string
+	Text    string
+}
+
+// weeklyTemplate is the weekly report message; t translates its text into the
+// locale it is rendered for, and number and date write values by its
+// conventions
+var weeklyTemplate = template.Must(template.New("weekly").Funcs(localeFuncs(i18n.Default)).Funcs(template.FuncMap{
+	"minutes": func(seconds int) int { return seconds / 60 },
+}).Parse(strings.TrimSpace(`
+{{t "Your week of %s" (date .WeekStart)}}
+
+{{t "Workouts: %v (%v sets, %s %s volume)" .Summary.Workouts .Summary.Sets (number .Summary.Volume) .Unit}}
+{{t "Cardio: %v activities, %v min" .Summary.Cardio.Activities (minutes .Summary.Cardio.DurationSeconds)}}
+{{if eq .Streak 1}}{{t "Streak: %v week" .Streak}}{{else}}{{t "Streak: %v weeks" .Streak}}{{end}}
+{{- if .PersonalRecords}}
+
+{{t "Personal records:"}}
+{{- range .PersonalRecords}}
+  - {{t "%s: %s %s x %v (est. 1RM %s %s)" .Exercise (number .Weight) $.Unit .Reps (number .Estimated1RM) $.Unit}}
+{{- end}}
+{{- end}}
+
+{{t "Training load:"}}
+{{- range .LoadTrend}}
+  - {{date .WeekStart}}: {{number .Load}}
+{{- end}}
+{{t "Load status: %s" .LoadStatus}}
+{{- if .Ratings.Rated}}
+
+{{t "Ratings (%v workouts rated):" .Ratings.Rated}}{{if .Ratings.SessionRPE}} {{t "session RPE %v" (number .Ratings.SessionRPE)}}{{end}}{{if .Ratings.Enjoyment}}, {{t "enjoyment %v/5" (number .Ratings.Enjoyment)}}{{end}}{{if .Ratings.Pump}}, {{t "pump %v/5" (number .Ratings.Pump)}}{{end}}
+{{- end}}
+{{- if .Summary.Habits.DaysLogged}}
+
+{{t "Habits (%v days logged): %v h sleep, %v steps, %v ml water per day" .Summary.Habits.DaysLogged (number .Summary.Habits.AverageSleepHours) (number .Summary.Habits.AverageSteps) (number .Summary.Habits.AverageWaterML)}}
+{{- end}}
+`)))
+
+// Render formats w as a plain-text message in locale, falling back to English
+// for text it has no translation of, with numbers and dates written by the
+// locale's conventions; unit labels weights
+func Render(w Weekly, unit, locale string) (Rendered, error) {
+	tmpl, err := weeklyTemplate.Clone()
+	if err != nil {
+		return Rendered{}, fmt.Errorf("failed to render weekly report: %w", err)
+	}
+	tmpl.Funcs(localeFuncs(locale))
+
+	var buf bytes.Buffer
+	data := struct {
+		Weekly
+		Unit string
+	}{Weekly: w, Unit: unit}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return Rendered{}, fmt.Errorf("failed to render weekly report: %w", err)
+	}
+
+	subject := i18n.T(locale, "Your weekly training summary")
+	if start, err := time.Parse("2006-01-02", w.WeekStart); err == nil {
+		subject = i18n.T(locale, "Your training week of %s", l10n.For(locale).LongDate(start))
+	}
+	return Rendered{Subject: subject, Text: buf.String() + "\n"}, nil
+}
+
+// localeFuncs are the template functions translating text into locale and
+// writing numbers and YYYY-MM-DD dates by its conventions
+func localeFuncs(locale string) template.FuncMap {
+	c := l10n.For(locale)
+	return template.FuncMap{
+		"t":    func(message string, args ...any) string { return i18n.T(locale, message, args...) },
+		"date": c.DateString,
+		"number": func(v any) string {
+			switch n := v.(type) {
+			case int:
+				return c.Number(float64(n), 0)
+			case float64:
+				return c.Number(n, 2)
+			}
+			return fmt.Sprint(v)
+		},
+	}
+}
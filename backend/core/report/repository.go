@@ -0,0 +1,55 @@
+package report
+
+import (
+	"context"
+	"fmt"
+
+	"athlete-forge/store"
+)
+
+const weeklySKPrefix = "REPORT#WEEKLY#"
+
+// Repository loads and saves compiled reports
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// GetWeekly returns userID's weekly report for the week starting weekStart
+func (r *Repository) GetWeekly(ctx context.Context, userID, weekStart string) (*Weekly, error) {
+	var w Weekly
+	if err := r.store.Get(ctx, store.UserPK(userID), weeklySKPrefix+weekStart, &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// ListWeekly returns userID's weekly reports, oldest first
+func (r *Repository) ListWeekly(ctx context.Context, userID string) ([]Weekly, error) {
+	items, err := r.store.Query(ctx, store.UserPK(userID), weeklySKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list weekly reports: %w", err)
+	}
+
+	reports := make([]Weekly, 0, len(items))
+	for _, item := range items {
+		var w Weekly
+		if err := item.Decode(&w); err != nil {
+			return nil, err
+		}
+		reports = append(reports, w)
+	}
+	return reports, nil
+}
+
+// SaveWeekly stores w, replacing any earlier report for the same week
+func (r *Repository) SaveWeekly(ctx context.Context, w *Weekly) error {
+	if err := r.store.Put(ctx, store.UserPK(w.UserID), weeklySKPrefix+w.WeekStart, w); err != nil {
+		return fmt.Errorf("failed to save weekly report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package report
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"athlete-forge/compliance"
+	"athlete-forge/dailylog"
+	"athlete-forge/l10n"
+	"athlete-forge/program"
+	"athlete-forge/records"
+	"athlete-forge/summary"
+	"athlete-forge/trainingload"
+	"athlete-forge/workout"
+)
+
+// maxProgressExercises limits the progress charts to the most frequently trained exercises
+const maxProgressExercises = 6
+
+// Compliance compares sessions started with sessions completed
+type Compliance struct {
+	Started   int     `json:"started"`
+	Completed int     `json:"completed"`
+	Rate      float64 `json:"rate"`
+}
+
+// ProgramCompliance is compliance for workouts performed as part of one
+// program, with the program's weekly compliance against its plan
+type ProgramCompliance struct {
+	ProgramID string `json:"programId"`
+	Name      string `json:"name"`
+	Compliance
+	Weeks []compliance.Week `json:"weeks"`
+}
+
+// ProgressPoint is an exercise's best estimated 1RM in one workout
+type ProgressPoint struct {
+	Date         string  `json:"date"`
+	Estimated1RM float64 `json:"estimated1rm"`
+}
+
+// ExerciseProgress is the estimated 1RM series for one exercise
+type ExerciseProgress struct {
+	Exercise string          `json:"exercise"`
+	Points   []ProgressPoint `json:"points"`
+}
+
+// Training is a coach-facing report covering a date range
+type Training struct {
+	UserID          string              `json:"userId"`
+	From            string              `json:"from"`
+	To              string              `json:"to"`
+	Unit            string              `json:"unit"`
+	Compliance      Compliance          `json:"compliance"`
+	Programs        []ProgramCompliance `json:"programs"`
+	Progress        []ExerciseProgress  `json:"progress"`
+	WeeklyLoad      []WeekLoad          `json:"weeklyLoad"`
+	PersonalRecords []records.Record    `json:"personalRecords"`
+}
+
+// BuildTraining compiles the report for workouts started between from and to
+// inclusive. weeks is the user's stored weekly program compliance; the weeks
+// overlapping the range are reported with their programs. Weekly load is
+// totalled over weeks starting on c's first day
+func BuildTraining(userID string, from, to time.Time, unit string, in summary.Input, programs []program.Program, weeks []compliance.Week, c l10n.Conventions) Training {
+	end := to.AddDate(0, 0, 1)
+	t := Training{
+		UserID:          userID,
+		From:            from.Format(dailylog.DateLayout),
+		To:              to.Format(dailylog.DateLayout),
+		Unit:            unit,
+		Programs:        []ProgramCompliance{},
+		Progress:        []ExerciseProgress{},
+		PersonalRecords: records.New(in.Workouts, from, end),
+	}
+
+	names := map[string]string{}
+	for _, p := range programs {
+		names[p.ID] = p.Name
+	}
+	byProgram := map[string]*ProgramCompliance{}
+	var programOrder []string
+	var inRange []workout.Workout
+
+	for _, w := range in.Workouts {
+		if w.StartedAt.Before(from) || !w.StartedAt.Before(end) {
+			continue
+		}
+		inRange = append(inRange, w)
+		completed := w.Status == workout.StatusCompleted
+		t.Compliance.add(completed)
+
+		if w.ProgramID == "" {
+			continue
+		}
+		pc, ok := byProgram[w.ProgramID]
+		if !ok {
+			pc = &ProgramCompliance{ProgramID: w.ProgramID, Name: names[w.ProgramID], Weeks: []compliance.Week{}}
+			byProgram[w.ProgramID] = pc
+			programOrder = append(programOrder, w.ProgramID)
+		}
+		pc.add(completed)
+	}
+	first := summary.WeekStart(from)
+	for _, week := range weeks {
+		start, err := time.Parse(dailylog.DateLayout, week.WeekStart)
+		if pc, ok := byProgram[week.ProgramID]; ok && err == nil && !start.Before(first) && start.Before(end) {
+			pc.Weeks = append(pc.Weeks, week)
+		}
+	}
+	for _, id := range programOrder {
+		t.Programs = append(t.Programs, *byProgram[id])
+	}
+
+	t.Progress = progress(inRange)
+	t.WeeklyLoad = weeklyLoad(trainingload.Sessions(in.Workouts, in.Activities, in.HeartRate), from, end, c.WeekStart)
+	return t
+}
+
+func (c *Compliance) add(completed bool) {
+	c.Started++
+	if completed {
+		c.Completed++
+	}
+	c.Rate = math.Round(float64(c.Completed)/float64(c.Started)*100) / 100
+}
+
+// progress builds estimated 1RM series for the most frequently performed exercises
+func progress(workouts []workout.Workout) []ExerciseProgress {
+	series := map[string]*ExerciseProgress{}
+	for _, w := range workouts {
+		if w.Status != workout.StatusCompleted {
+			continue
+		}
+		date := summary.CompletedAt(w).Format(dailylog.DateLayout)
+		for _, exercise := range w.Exercises {
+			best := 0.0
+			for _, set := range exercise.Sets {
+				if set.Estimable() {
+					best = math.Max(best, records.EstimatedOneRepMax(set.Load(), set.Reps))
+				}
+			}
+			if best == 0 {
+				continue
+			}
+			key := strings.ToLower(exercise.Name)
+			if series[key] == nil {
+				series[key] = &ExerciseProgress{Exercise: exercise.Name}
+			}
+			series[key].Points = append(series[key].Points, ProgressPoint{Date: date, Estimated1RM: best})
+		}
+	}
+
+	all := make([]ExerciseProgress, 0, len(series))
+	for _, s := range series {
+		sort.SliceStable(s.Points, func(i, j int) bool { return s.Points[i].Date < s.Points[j].Date })
+		all = append(all, *s)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if len(all[i].Points) != len(all[j].Points) {
+			return len(all[i].Points) > len(all[j].Points)
+		}
+		return all[i].Exercise < all[j].Exercise
+	})
+	if len(all) > maxProgressExercises {
+		all = all[:maxProgressExercises]
+	}
+	return all
+}
+
+// weeklyLoad totals session load for each week, as weekStart begins them,
+// overlapping from to end
+func weeklyLoad(sessions []trainingload.Session, from, end time.Time, weekStart func(time.Time) time.Time) []WeekLoad {
+	var weeks []WeekLoad
+	index := map[string]int{}
+	for week := weekStart(from); week.Before(end); week = week.AddDate(0, 0, 7) {
+		key := week.Format(dailylog.DateLayout)
+		index[key] = len(weeks)
+		weeks = append(weeks, WeekLoad{WeekStart: key})
+	}
+	for _, s := range sessions {
+		if s.At.Before(from) || !s.At.Before(end) {
+			continue
+		}
+		if i, ok := index[weekStart(s.At).Format(dailylog.DateLayout)]; ok {
+			weeks[i].Load = math.Round((weeks[i].Load+s.Load)*10) / 10
+		}
+	}
+	return weeks
+}
@@ -0,0 +1,153 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"athlete-forge/compliance"
+	"athlete-forge/l10n"
+	"athlete-forge/program"
+	"athlete-forge/store"
+	"athlete-forge/summary"
+	"athlete-forge/workout"
+)
+
+func TestBuildTraining(t *testing.T) {
+	t.Run("computes compliance and progress within the range", func(t *testing.T) {
+		// Arrange
+		from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+		skipped := completedWorkout("w3", time.Date(2024, 3, 20, 18, 0, 0, 0, time.UTC), 0)
+		skipped.Status, skipped.CompletedAt = workout.StatusActive, nil
+		in := summary.Input{Workouts: []workout.Workout{
+			completedWorkout("w0", time.Date(2024, 2, 20, 18, 0, 0, 0, time.UTC), 95),
+			completedWorkout("w1", time.Date(2024, 3, 6, 18, 0, 0, 0, time.UTC), 100),
+			completedWorkout("w2", time.Date(2024, 3, 13, 18, 0, 0, 0, time.UTC), 105),
+			skipped,
+		}}
+		for i := range in.Workouts {
+			in.Workouts[i].ProgramID = "p1"
+		}
+		programs := []program.Program{{ID: "p1", Name: "5x5"}}
+		weeks := []compliance.Week{
+			{ProgramID: "p1", WeekStart: "2024-02-19", SessionsCompleted: 1},
+			{ProgramID: "p1", WeekStart: "2024-02-26", SessionsCompleted: 1},
+			{ProgramID: "p1", WeekStart: "2024-03-11", SessionsCompleted: 1},
+			{ProgramID: "p2", WeekStart: "2024-03-11", SessionsCompleted: 1},
+		}
+
+		// Act
+		report := BuildTraining("user-1", from, to, "kg", in, programs, weeks, l10n.For(""))
+
+		// Assert
+		if report.Compliance != (Compliance{Started: 3, Completed: 2, Rate: 0.67}) {
+			t.Errorf("unexpected compliance: %+v", report.Compliance)
+		}
+		if len(report.Programs) != 1 || report.Programs[0].Name != "5x5" || report.Programs[0].Started != 3 {
+			t.Errorf("unexpected program compliance: %+v", report.Programs)
+		}
+		if got := report.Programs[0].Weeks; len(got) != 2 || got[0].WeekStart != "2024-02-26" || got[1].WeekStart != "2024-03-11" {
+			t.Errorf("unexpected weekly compliance: %+v", got)
+		}
+		if len(report.Progress) != 1 || len(report.Progress[0].Points) != 2 || report.Progress[0].Points[1].Estimated1RM != 122.5 {
+			t.Errorf("unexpected progress: %+v", report.Progress)
+		}
+		if len(report.WeeklyLoad) != 5 || report.WeeklyLoad[0].WeekStart != "2024-02-26" {
+			t.Errorf("unexpected weekly load: %+v", report.WeeklyLoad)
+		}
+		if len(report.PersonalRecords) != 1 || report.PersonalRecords[0].WorkoutID != "w2" {
+			t.Errorf("unexpected records: %+v", report.PersonalRecords)
+		}
+	})
+}
+
+func TestRenderTrainingPDF(t *testing.T) {
+	t.Run("renders charts for each exercise", func(t *testing.T) {
+		// Arrange
+		report := Training{
+			From: "2024-03-01", To: "2024-03-31", Unit: "kg",
+			Compliance: Compliance{Started: 4, Completed: 3, Rate: 0.75},
+			WeeklyLoad: []WeekLoad{{WeekStart: "2024-02-26", Load: 120}, {WeekStart: "2024-03-04", Load: 240}},
+		}
+		for _, name := range []string{"Squat", "Bench", "Deadlift", "Press", "Row", "Chin-up"} {
+			report.Progress = append(report.Progress, ExerciseProgress{Exercise: name, Points: []ProgressPoint{
+				{Date: "2024-03-04", Estimated1RM: 100}, {Date: "2024-03-11", Estimated1RM: 105},
+			}})
+		}
+
+		// Act
+		out := RenderTrainingPDF(report, l10n.For(""))
+
+		// Assert
+		if !bytes.HasPrefix(out, []byte("%PDF-")) {
+			t.Fatal("expected a PDF document")
+		}
+		if !bytes.Contains(out, []byte("(Sessions completed: 3 of 4 \\(75%\\)) Tj")) {
+			t.Error("expected compliance line")
+		}
+		if !bytes.Contains(out, []byte("/Count 2")) {
+			t.Error("expected progress charts to flow onto a second page")
+		}
+	})
+
+	t.Run("writes numbers and dates by the locale", func(t *testing.T) {
+		// Arrange
+		report := Training{
+			From: "2024-03-01", To: "2024-03-31", Unit: "kg",
+			Compliance: Compliance{Started: 4, Completed: 3, Rate: 0.75},
+			Progress: []ExerciseProgress{{Exercise: "Squat", Points: []ProgressPoint{
+				{Date: "2024-03-04", Estimated1RM: 102.5}, {Date: "2024-03-11", Estimated1RM: 105},
+			}}},
+		}
+
+		// Act
+		out := RenderTrainingPDF(report, l10n.For("de-AT"))
+
+		// Assert
+		for _, want := range []string{"(01.03.2024 to 31.03.2024) Tj", "(Sessions completed: 3 of 4 \\(75\\240%\\)) Tj", "(04.03.2024: 102,5 kg) Tj"} {
+			if !bytes.Contains(out, []byte(want)) {
+				t.Errorf("expected %q in the document", want)
+			}
+		}
+	})
+}
+
+func TestRepository_Export(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("keeps the object key out of responses but in storage", func(t *testing.T) {
+		// Arrange
+		repo := NewRepository(store.NewMemoryStore())
+		e := &Export{UserID: "user-1", From: "2024-03-01", To: "2024-03-31", Status: ExportPending}
+		e.Key = e.ObjectKey()
+
+		// Act
+		if err := repo.SaveExport(ctx, e); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		loaded, err := repo.GetExport(ctx, "user-1", e.ID)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if loaded.Key == "" || loaded.Status != ExportPending {
+			t.Errorf("unexpected export: %+v", loaded)
+		}
+	})
+
+	t.Run("rejects invalid ranges", func(t *testing.T) {
+		// Arrange
+		repo := NewRepository(store.NewMemoryStore())
+
+		// Act
+		err := repo.SaveExport(ctx, &Export{UserID: "user-1", From: "2024-03-31", To: "2024-03-01"})
+
+		// Assert
+		if err == nil {
+			t.Error("expected error for reversed range")
+		}
+	})
+}
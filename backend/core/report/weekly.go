@@ -0,0 +1,142 @@
+package report
+
+import (
+	"math"
+	"time"
+
+	"athlete-forge/dailylog"
+	"athlete-forge/records"
+	"athlete-forge/summary"
+	"athlete-forge/trainingload"
+	"athlete-forge/workout"
+)
+
+// trendWeeks is the number of weeks of load shown in the trend, ending with the report week
+const trendWeeks = 4
+
+// WeekLoad is the total training load for one week
+type WeekLoad struct {
+	WeekStart string  `json:"weekStart"`
+	Load      float64 `json:"load"`
+}
+
+// RatingSummary averages the ratings of the week's completed workouts. Rated
+// counts the workouts with any rating, and each average counts only the
+// workouts given that rating
+type RatingSummary struct {
+	Rated      int     `json:"rated"`
+	SessionRPE float64 `json:"averageSessionRpe"`
+	Enjoyment  float64 `json:"averageEnjoyment"`
+	Pump       float64 `json:"averagePump"`
+}
+
+// Weekly is a user's compiled report for one week
+type Weekly struct {
+	UserID          string           `json:"userId"`
+	WeekStart       string           `json:"weekStart"`
+	Summary         summary.Week     `json:"summary"`
+	PersonalRecords []records.Record `json:"personalRecords"`
+	LoadTrend       []WeekLoad       `json:"loadTrend"`
+	LoadStatus      string           `json:"loadStatus"`
+	Ratings         RatingSummary    `json:"ratings"`
+	Streak          int              `json:"streakWeeks"`
+	GeneratedAt     time.Time        `json:"generatedAt"`
+}
+
+// BuildWeekly compiles the report for the week beginning weekStart
+func BuildWeekly(userID string, weekStart time.Time, in summary.Input, now time.Time) Weekly {
+	weekEnd := weekStart.AddDate(0, 0, 7)
+	sessions := trainingload.Sessions(in.Workouts, in.Activities, in.HeartRate)
+
+	return Weekly{
+		UserID:          userID,
+		WeekStart:       weekStart.Format(dailylog.DateLayout),
+		Summary:         summary.Build(weekStart, in),
+		PersonalRecords: records.New(in.Workouts, weekStart, weekEnd),
+		LoadTrend:       loadTrend(sessions, weekStart),
+		LoadStatus:      trainingload.Calculate(sessions, weekEnd.AddDate(0, 0, -1)).Status,
+		Ratings:         rateWeek(in.Workouts, weekStart, weekEnd),
+		Streak:          streak(sessions, weekStart),
+		GeneratedAt:     now.UTC(),
+	}
+}
+
+// rateWeek averages the ratings of the workouts completed from weekStart until before weekEnd
+func rateWeek(workouts []workout.Workout, weekStart, weekEnd time.Time) RatingSummary {
+	var rated RatingSummary
+	var rpe, enjoyment, pump float64
+	var rpeCount, enjoymentCount, pumpCount int
+	for _, w := range workouts {
+		at := summary.CompletedAt(w)
+		if w.Status != workout.StatusCompleted || w.Ratings == nil || at.Before(weekStart) || !at.Before(weekEnd) {
+			continue
+		}
+		r := *w.Ratings
+		if r == (workout.Ratings{}) {
+			continue
+		}
+		rated.Rated++
+		if r.SessionRPE > 0 {
+			rpe += r.SessionRPE
+			rpeCount++
+		}
+		if r.Enjoyment > 0 {
+			enjoyment += float64(r.Enjoyment)
+			enjoymentCount++
+		}
+		if r.Pump > 0 {
+			pump += float64(r.Pump)
+			pumpCount++
+		}
+	}
+	rated.SessionRPE = average(rpe, rpeCount)
+	rated.Enjoyment = average(enjoyment, enjoymentCount)
+	rated.Pump = average(pump, pumpCount)
+	return rated
+}
+
+func average(total float64, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+	return math.Round(total/float64(count)*10) / 10
+}
+
+// loadTrend totals session load for the trend weeks ending with weekStart's week
+func loadTrend(sessions []trainingload.Session, weekStart time.Time) []WeekLoad {
+	trend := make([]WeekLoad, trendWeeks)
+	first := weekStart.AddDate(0, 0, -7*(trendWeeks-1))
+	for i := range trend {
+		trend[i].WeekStart = first.AddDate(0, 0, 7*i).Format(dailylog.DateLayout)
+	}
+	for _, s := range sessions {
+		if s.At.Before(first) {
+			continue
+		}
+		i := int(s.At.Sub(first).Hours() / 24 / 7)
+		if i < trendWeeks {
+			trend[i].Load += s.Load
+		}
+	}
+	for i := range trend {
+		trend[i].Load = math.Round(trend[i].Load*10) / 10
+	}
+	return trend
+}
+
+// streak counts consecutive weeks with at least one session, ending with weekStart's
+// week; a week without training so far ends the streak at zero
+func streak(sessions []trainingload.Session, weekStart time.Time) int {
+	// Weeks are counted back from weekStart rather than by calendar week, so
+	// reports can start their weeks on any day
+	trained := map[int]bool{}
+	for _, s := range sessions {
+		trained[int(math.Floor(s.At.Sub(weekStart).Hours()/24/7))] = true
+	}
+
+	count := 0
+	for week := 0; trained[-week]; week++ {
+		count++
+	}
+	return count
+}
@@ -0,0 +1,179 @@
+package report
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"athlete-forge/cardio"
+	"athlete-forge/store"
+	"athlete-forge/summary"
+	"athlete-forge/workout"
+)
+
+func completedWorkout(id string, at time.Time, weight float64) workout.Workout {
+	return workout.Workout{
+		ID:          id,
+		Status:      workout.StatusCompleted,
+		StartedAt:   at.Add(-time.Hour),
+		CompletedAt: &at,
+		Exercises:   []workout.Exercise{{Name: "Squat", Sets: []workout.Set{{Reps: 5, Weight: weight, RPE: 8}}}},
+	}
+}
+
+func TestBuildWeekly(t *testing.T) {
+	weekStart := time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2024, 3, 25, 6, 0, 0, 0, time.UTC)
+
+	t.Run("compiles records, trend and streak", func(t *testing.T) {
+		// Arrange
+		in := summary.Input{
+			Workouts: []workout.Workout{
+				completedWorkout("w1", time.Date(2024, 3, 6, 18, 0, 0, 0, time.UTC), 100),
+				completedWorkout("w2", time.Date(2024, 3, 13, 18, 0, 0, 0, time.UTC), 102.5),
+				completedWorkout("w3", time.Date(2024, 3, 20, 18, 0, 0, 0, time.UTC), 105),
+			},
+			Activities: []cardio.Activity{{StartTime: time.Date(2024, 3, 21, 7, 0, 0, 0, time.UTC), DurationSeconds: 1800}},
+		}
+
+		// Act
+		w := BuildWeekly("user-1", weekStart, in, now)
+
+		// Assert
+		if w.WeekStart != "2024-03-18" || w.Summary.Workouts != 1 || w.Streak != 3 {
+			t.Errorf("unexpected report: %+v", w)
+		}
+		if len(w.PersonalRecords) != 1 || w.PersonalRecords[0].WorkoutID != "w3" {
+			t.Errorf("unexpected records: %+v", w.PersonalRecords)
+		}
+		if len(w.LoadTrend) != 4 || w.LoadTrend[0].WeekStart != "2024-02-26" || w.LoadTrend[0].Load != 0 || w.LoadTrend[3].Load != 300 {
+			t.Errorf("unexpected load trend: %+v", w.LoadTrend)
+		}
+	})
+
+	t.Run("counts weeks from a week start on any day", func(t *testing.T) {
+		// Arrange
+		sunday := time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC)
+		in := summary.Input{Workouts: []workout.Workout{
+			completedWorkout("w1", time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC), 100),
+			completedWorkout("w2", time.Date(2024, 3, 17, 9, 0, 0, 0, time.UTC), 100),
+		}}
+
+		// Act
+		w := BuildWeekly("user-1", sunday, in, now)
+
+		// Assert
+		if w.WeekStart != "2024-03-17" || w.Summary.Workouts != 1 || w.Streak != 2 {
+			t.Errorf("unexpected report: %+v", w)
+		}
+	})
+
+	t.Run("averages the week's ratings", func(t *testing.T) {
+		// Arrange
+		rated := func(w workout.Workout, r workout.Ratings) workout.Workout {
+			w.Ratings = &r
+			return w
+		}
+		in := summary.Input{Workouts: []workout.Workout{
+			rated(completedWorkout("w1", time.Date(2024, 3, 13, 18, 0, 0, 0, time.UTC), 100), workout.Ratings{SessionRPE: 10, Enjoyment: 1}),
+			rated(completedWorkout("w2", time.Date(2024, 3, 19, 18, 0, 0, 0, time.UTC), 100), workout.Ratings{SessionRPE: 8, Enjoyment: 4, Pump: 3}),
+			rated(completedWorkout("w3", time.Date(2024, 3, 21, 18, 0, 0, 0, time.UTC), 100), workout.Ratings{SessionRPE: 7, Enjoyment: 5}),
+			completedWorkout("w4", time.Date(2024, 3, 22, 18, 0, 0, 0, time.UTC), 100),
+		}}
+
+		// Act
+		w := BuildWeekly("user-1", weekStart, in, now)
+
+		// Assert
+		want := RatingSummary{Rated: 2, SessionRPE: 7.5, Enjoyment: 4.5, Pump: 3}
+		if w.Ratings != want {
+			t.Errorf("expected ratings %+v, got %+v", want, w.Ratings)
+		}
+	})
+
+	t.Run("streak is zero for a week without training", func(t *testing.T) {
+		// Arrange
+		in := summary.Input{Workouts: []workout.Workout{completedWorkout("w1", time.Date(2024, 3, 13, 18, 0, 0, 0, time.UTC), 100)}}
+
+		// Act
+		w := BuildWeekly("user-1", weekStart, in, now)
+
+		// Assert
+		if w.Streak != 0 {
+			t.Errorf("expected streak 0, got %d", w.Streak)
+		}
+	})
+}
+
+func TestRender(t *testing.T) {
+	t.Run("formats the summary for email", func(t *testing.T) {
+		// Arrange
+		in := summary.Input{Workouts: []workout.Workout{
+			completedWorkout("w1", time.Date(2024, 3, 13, 18, 0, 0, 0, time.UTC), 100),
+			completedWorkout("w2", time.Date(2024, 3, 20, 18, 0, 0, 0, time.UTC), 102.5),
+		}}
+		w := BuildWeekly("user-1", time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC), in, time.Now())
+
+		// Act
+		rendered, err := Render(w, "kg", "en")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if rendered.Subject != "Your training week of 18 Mar 2024" {
+			t.Errorf("unexpected subject %q", rendered.Subject)
+		}
+		for _, want := range []string{"Workouts: 1 (1 sets, 512.5 kg volume)", "Streak: 2 weeks", "Squat: 102.5 kg x 5 (est. 1RM 119.6 kg)"} {
+			if !strings.Contains(rendered.Text, want) {
+				t.Errorf("expected %q in:\n%s", want, rendered.Text)
+			}
+		}
+	})
+
+	t.Run("translates the message and writes numbers and dates by the locale", func(t *testing.T) {
+		// Arrange
+		in := summary.Input{Workouts: []workout.Workout{
+			completedWorkout("w1", time.Date(2024, 3, 20, 18, 0, 0, 0, time.UTC), 100),
+		}}
+		w := BuildWeekly("user-1", time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC), in, time.Now())
+
+		// Act
+		rendered, err := Render(w, "kg", "de-AT")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if rendered.Subject != "Deine Trainingswoche vom 18. März 2024" {
+			t.Errorf("unexpected subject %q", rendered.Subject)
+		}
+		for _, want := range []string{"Deine Woche vom 18.03.2024", "Trainings: 1 (1 Sätze, 500 kg Volumen)", "Serie: 1 Woche", "Trainingsbelastung:"} {
+			if !strings.Contains(rendered.Text, want) {
+				t.Errorf("expected %q in:\n%s", want, rendered.Text)
+			}
+		}
+	})
+}
+
+func TestRepository(t *testing.T) {
+	t.Run("saving a week again replaces the report", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		repo := NewRepository(store.NewMemoryStore())
+
+		// Act
+		repo.SaveWeekly(ctx, &Weekly{UserID: "user-1", WeekStart: "2024-03-18", Streak: 1})
+		repo.SaveWeekly(ctx, &Weekly{UserID: "user-1", WeekStart: "2024-03-18", Streak: 2})
+		reports, err := repo.ListWeekly(ctx, "user-1")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(reports) != 1 || reports[0].Streak != 2 {
+			t.Errorf("unexpected reports: %+v", reports)
+		}
+	})
+}
@@ -0,0 +1,400 @@
+package residency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"athlete-forge/blob"
+	"athlete-forge/store"
+)
+
+const (
+	pinPKPrefix  = "RESIDENCY#"
+	pinSK        = "RESIDENCY"
+	userPKPrefix = "USER#"
+)
+
+// ErrUnavailable is returned for operations on a user pinned to a region this
+// deployment has no table or bucket for; their data is never read from or
+// written to another region instead
+var ErrUnavailable = errors.New("user's data region is not available in this deployment")
+
+// ErrUnknownRegion is returned when pinning a user to a region that is not configured
+var ErrUnknownRegion = errors.New("unknown data region")
+
+// ErrHasData is returned when moving a user who has stored data other than their
+// account to another region
+var ErrHasData = errors.New("data region can only be changed before any data is stored")
+
+// Pin records the region a user's data is kept in. Pins live in the home
+// region's table, which every deployment reads, so a user's data can be found
+// before knowing where it is
+type Pin struct {
+	UserID   string    `json:"userId"`
+	Region   string    `json:"region"`
+	PinnedAt time.Time `json:"pinnedAt"`
+}
+
+// Router is a store.Store that keeps each user's partition in the table of the
+// region they are pinned to, and everything else, including users without a
+// pin, in the home region's table. Residency is enforced here rather than by
+// callers, so every repository built on the Router stays in region.
+//
+// Indexes, partitions that find users' data by something other than the user
+// such as an email or share code, are kept in every region: each item goes to
+// the region of the user its sort key ends with, as USER#{id}, and queries of
+// an index read it from every region
+type Router struct {
+	home    string
+	tables  map[string]store.Store
+	indexes []string
+
+	mu   sync.Mutex
+	pins map[string]string
+}
+
+// NewRouter routes between tables by region; tables must include home
+func NewRouter(home string, tables map[string]store.Store) (*Router, error) {
+	if _, ok := tables[home]; !ok {
+		return nil, fmt.Errorf("no table for home region %s", home)
+	}
+	return &Router{home: home, tables: tables, pins: map[string]string{}}, nil
+}
+
+// Index keeps the partitions under each of prefixes as indexes, their items in
+// the region of the user their sort key names. Items whose sort key names no
+// user, such as ones written before the partition was an index, stay at home
+func (r *Router) Index(prefixes ...string) {
+	r.indexes = append(r.indexes, prefixes...)
+}
+
+// Home returns the region users without a pin are kept in
+func (r *Router) Home() string {
+	return r.home
+}
+
+// Regions returns the regions data can be pinned to, in name order
+func (r *Router) Regions() []string {
+	regions := make([]string, 0, len(r.tables))
+	for region := range r.tables {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// Region returns the region userID's data is kept in. Pins are cached once
+// read, since one only changes before the user has data; users without a pin
+// are looked up every time so a new pin is seen straight away
+func (r *Router) Region(ctx context.Context, userID string) (string, error) {
+	r.mu.Lock()
+	region, ok := r.pins[userID]
+	r.mu.Unlock()
+	if ok {
+		return region, nil
+	}
+
+	var pin Pin
+	err := r.tables[r.home].Get(ctx, pinPKPrefix+userID, pinSK, &pin)
+	if errors.Is(err, store.ErrNotFound) {
+		return r.home, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load data region: %w", err)
+	}
+	r.mu.Lock()
+	r.pins[userID] = pin.Region
+	r.mu.Unlock()
+	return pin.Region, nil
+}
+
+// Pin keeps userID's data in region from now on. A user can only move while
+// every item they have is under one of movable, the sort key prefixes of items
+// such as their account that exist from sign-up; those items, and the items at
+// linked in indexes, such as their email lookup, are copied to the new region
+// before the pin is written and removed from the old one after
+func (r *Router) Pin(ctx context.Context, userID, region string, now time.Time, linked []store.Key, movable ...string) error {
+	to, ok := r.tables[region]
+	if !ok {
+		return fmt.Errorf("%w %q; use one of %s", ErrUnknownRegion, region, strings.Join(r.Regions(), ", "))
+	}
+	current, err := r.Region(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if current == region {
+		return nil
+	}
+	from, ok := r.tables[current]
+	if !ok {
+		return ErrUnavailable
+	}
+
+	pk := store.UserPK(userID)
+	items, err := from.Query(ctx, pk, "")
+	if err != nil {
+		return fmt.Errorf("failed to list data to move: %w", err)
+	}
+	for _, item := range items {
+		if !hasPrefix(item.SK, movable) {
+			return ErrHasData
+		}
+	}
+	if len(linked) > 0 {
+		found, err := from.BatchGet(ctx, linked)
+		if err != nil {
+			return fmt.Errorf("failed to list data to move: %w", err)
+		}
+		items = append(items, found...)
+	}
+	for _, item := range items {
+		if err := to.Put(ctx, item.PK, item.SK, item.Data); err != nil {
+			return fmt.Errorf("failed to move %s: %w", item.SK, err)
+		}
+	}
+	if err := r.tables[r.home].Put(ctx, pinPKPrefix+userID, pinSK, Pin{UserID: userID, Region: region, PinnedAt: now}); err != nil {
+		return fmt.Errorf("failed to save data region: %w", err)
+	}
+	r.mu.Lock()
+	r.pins[userID] = region
+	r.mu.Unlock()
+	for _, item := range items {
+		if err := from.Delete(ctx, item.PK, item.SK); err != nil {
+			return fmt.Errorf("failed to remove moved %s: %w", item.SK, err)
+		}
+	}
+	return nil
+}
+
+// table returns the table holding the item at pk/sk
+func (r *Router) table(ctx context.Context, pk, sk string) (store.Store, error) {
+	userID, ok := strings.CutPrefix(pk, userPKPrefix)
+	if !ok && r.index(pk) {
+		userID, ok = owner(sk)
+	}
+	if !ok {
+		return r.tables[r.home], nil
+	}
+	region, err := r.Region(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	table, ok := r.tables[region]
+	if !ok {
+		return nil, ErrUnavailable
+	}
+	return table, nil
+}
+
+// index reports whether pk is the partition of an index
+func (r *Router) index(pk string) bool {
+	return hasPrefix(pk, r.indexes)
+}
+
+// owner returns the user an index item's sort key ends with
+func owner(sk string) (string, bool) {
+	i := strings.LastIndex(sk, userPKPrefix)
+	if i < 0 || (i > 0 && sk[i-1] != '#') {
+		return "", false
+	}
+	return sk[i+len(userPKPrefix):], true
+}
+
+// Get loads the item at pk/sk from the table holding it
+func (r *Router) Get(ctx context.Context, pk, sk string, out interface{}) error {
+	table, err := r.table(ctx, pk, sk)
+	if err != nil {
+		return err
+	}
+	return table.Get(ctx, pk, sk, out)
+}
+
+// Put writes v at pk/sk in the table holding it
+func (r *Router) Put(ctx context.Context, pk, sk string, v interface{}) error {
+	table, err := r.table(ctx, pk, sk)
+	if err != nil {
+		return err
+	}
+	return table.Put(ctx, pk, sk, v)
+}
+
+// Create writes v at pk/sk in the table holding it unless an item is already
+// there
+func (r *Router) Create(ctx context.Context, pk, sk string, v interface{}) error {
+	table, err := r.table(ctx, pk, sk)
+	if err != nil {
+		return err
+	}
+	return store.Create(ctx, table, pk, sk, v)
+}
+
+// Query returns the items under pk from the table holding it, or from every
+// region's table in sort key order when pk is an index
+func (r *Router) Query(ctx context.Context, pk, skPrefix string) ([]store.Item, error) {
+	if !r.index(pk) {
+		table, err := r.table(ctx, pk, "")
+		if err != nil {
+			return nil, err
+		}
+		return table.Query(ctx, pk, skPrefix)
+	}
+
+	// An item is only written to another region once its user is pinned
+	// there, so it replaces a copy left at home from before the pin
+	var items []store.Item
+	seen := map[string]int{}
+	regions := []string{r.home}
+	for _, region := range r.Regions() {
+		if region != r.home {
+			regions = append(regions, region)
+		}
+	}
+	for _, region := range regions {
+		found, err := r.tables[region].Query(ctx, pk, skPrefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range found {
+			if i, ok := seen[item.SK]; ok {
+				items[i] = item
+				continue
+			}
+			seen[item.SK] = len(items)
+			items = append(items, item)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].SK < items[j].SK
+	})
+	return items, nil
+}
+
+// Delete removes the item at pk/sk from the table holding it
+func (r *Router) Delete(ctx context.Context, pk, sk string) error {
+	table, err := r.table(ctx, pk, sk)
+	if err != nil {
+		return err
+	}
+	return table.Delete(ctx, pk, sk)
+}
+
+// BatchPut writes each item to the table holding its partition, one batch per
+// table
+func (r *Router) BatchPut(ctx context.Context, writes []store.Write) error {
+	var order []store.Store
+	batches := map[store.Store][]store.Write{}
+	for _, w := range writes {
+		table, err := r.table(ctx, w.PK, w.SK)
+		if err != nil {
+			return err
+		}
+		if _, ok := batches[table]; !ok {
+			order = append(order, table)
+		}
+		batches[table] = append(batches[table], w)
+	}
+
+	for _, table := range order {
+		if err := store.BatchPut(ctx, table, batches[table]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BatchGet reads keys from the tables holding them, one batch per table
+func (r *Router) BatchGet(ctx context.Context, keys []store.Key) ([]store.Item, error) {
+	var order []store.Store
+	batches := map[store.Store][]store.Key{}
+	for _, key := range keys {
+		table, err := r.table(ctx, key.PK, key.SK)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := batches[table]; !ok {
+			order = append(order, table)
+		}
+		batches[table] = append(batches[table], key)
+	}
+
+	var items []store.Item
+	for _, table := range order {
+		found, err := table.BatchGet(ctx, batches[table])
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, found...)
+	}
+	return items, nil
+}
+
+// Blobs returns a blob.Store that keeps each user's files in the bucket of the
+// region they are pinned to. Keys are laid out as kind/userID/name, such as
+// tracks/{userId}/{activityId}.json; keys without a user go to the home
+// region's bucket, which buckets must include
+func (r *Router) Blobs(buckets map[string]blob.Store) (blob.Store, error) {
+	if _, ok := buckets[r.home]; !ok {
+		return nil, fmt.Errorf("no bucket for home region %s", r.home)
+	}
+	return &blobRouter{router: r, buckets: buckets}, nil
+}
+
+type blobRouter struct {
+	router  *Router
+	buckets map[string]blob.Store
+}
+
+// bucket returns the bucket holding key
+func (b *blobRouter) bucket(ctx context.Context, key string) (blob.Store, error) {
+	parts := strings.SplitN(key, "/", 3)
+	if len(parts) < 3 {
+		return b.buckets[b.router.home], nil
+	}
+	region, err := b.router.Region(ctx, parts[1])
+	if err != nil {
+		return nil, err
+	}
+	bucket, ok := b.buckets[region]
+	if !ok {
+		return nil, ErrUnavailable
+	}
+	return bucket, nil
+}
+
+func (b *blobRouter) Put(ctx context.Context, key, contentType string, data []byte) error {
+	bucket, err := b.bucket(ctx, key)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(ctx, key, contentType, data)
+}
+
+func (b *blobRouter) Read(ctx context.Context, key string) ([]byte, error) {
+	bucket, err := b.bucket(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return bucket.Read(ctx, key)
+}
+
+func (b *blobRouter) URL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	bucket, err := b.bucket(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	return bucket.URL(ctx, key, expires)
+}
+
+func hasPrefix(sk string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(sk, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,311 @@
+package residency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"athlete-forge/blob"
+	"athlete-forge/store"
+)
+
+func TestRouter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+
+	// regions returns a router between a US home table and an EU table
+	regions := func(t *testing.T) (*Router, *store.MemoryStore, *store.MemoryStore) {
+		t.Helper()
+		us, eu := store.NewMemoryStore(), store.NewMemoryStore()
+		r, err := NewRouter("us-east-1", map[string]store.Store{"us-east-1": us, "eu-west-1": eu})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return r, us, eu
+	}
+
+	t.Run("keeps users without a pin in the home region", func(t *testing.T) {
+		// Arrange
+		r, us, eu := regions(t)
+
+		// Act
+		err := r.Put(ctx, store.UserPK("user-1"), "PROFILE", map[string]string{"name": "Sam"})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if items, _ := us.Query(ctx, store.UserPK("user-1"), ""); len(items) != 1 {
+			t.Errorf("expected the item in the home table, got %d items", len(items))
+		}
+		if items, _ := eu.Query(ctx, store.UserPK("user-1"), ""); len(items) != 0 {
+			t.Errorf("expected nothing in the EU table, got %d items", len(items))
+		}
+	})
+
+	t.Run("moves a new user's account to their pinned region", func(t *testing.T) {
+		// Arrange
+		r, us, eu := regions(t)
+		r.Put(ctx, store.UserPK("user-1"), "ACCOUNT", map[string]string{"id": "user-1"})
+
+		// Act
+		err := r.Pin(ctx, "user-1", "eu-west-1", now, nil, "ACCOUNT")
+		r.Put(ctx, store.UserPK("user-1"), "PROFILE", map[string]string{"name": "Sam"})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if items, _ := us.Query(ctx, store.UserPK("user-1"), ""); len(items) != 0 {
+			t.Errorf("expected nothing left in the home table, got %d items", len(items))
+		}
+		if items, _ := eu.Query(ctx, store.UserPK("user-1"), ""); len(items) != 2 {
+			t.Errorf("expected the account and profile in the EU table, got %d items", len(items))
+		}
+		var account map[string]string
+		if err := r.Get(ctx, store.UserPK("user-1"), "ACCOUNT", &account); err != nil || account["id"] != "user-1" {
+			t.Errorf("expected the moved account to be read back, got %v (%v)", account, err)
+		}
+	})
+
+	t.Run("keeps pins and other items in the home region", func(t *testing.T) {
+		// Arrange
+		r, us, eu := regions(t)
+		r.Pin(ctx, "user-1", "eu-west-1", now, nil)
+
+		// Act
+		r.Put(ctx, "ACTIVE_USERS", "user-1", map[string]string{"id": "user-1"})
+
+		// Assert
+		if items, _ := us.Query(ctx, "ACTIVE_USERS", ""); len(items) != 1 {
+			t.Errorf("expected the index item in the home table, got %d items", len(items))
+		}
+		if items, _ := us.Query(ctx, pinPKPrefix+"user-1", ""); len(items) != 1 {
+			t.Errorf("expected the pin in the home table, got %d items", len(items))
+		}
+		if items, _ := eu.Query(ctx, pinPKPrefix+"user-1", ""); len(items) != 0 {
+			t.Errorf("expected no pin in the EU table, got %d items", len(items))
+		}
+	})
+
+	t.Run("keeps index items in their user's region and reads them from every region", func(t *testing.T) {
+		// Arrange
+		r, us, eu := regions(t)
+		r.Index("EMAIL#")
+		r.Pin(ctx, "user-2", "eu-west-1", now, nil)
+		r.Put(ctx, "EMAIL#sam@example.com", "EMAIL", map[string]string{"userId": "user-0"})
+		r.Put(ctx, "EMAIL#sam@example.com", store.UserPK("user-1"), map[string]string{"userId": "user-1"})
+
+		// Act
+		err := r.Put(ctx, "EMAIL#sam@example.com", store.UserPK("user-2"), map[string]string{"userId": "user-2"})
+		items, queryErr := r.Query(ctx, "EMAIL#sam@example.com", "")
+
+		// Assert
+		if err != nil || queryErr != nil {
+			t.Fatalf("unexpected errors: %v, %v", err, queryErr)
+		}
+		if found, _ := eu.Query(ctx, "EMAIL#sam@example.com", ""); len(found) != 1 || found[0].SK != store.UserPK("user-2") {
+			t.Errorf("expected only the pinned user's item in the EU table, got %+v", found)
+		}
+		if found, _ := us.Query(ctx, "EMAIL#sam@example.com", ""); len(found) != 2 {
+			t.Errorf("expected the other items in the home table, got %+v", found)
+		}
+		if len(items) != 3 || items[0].SK != "EMAIL" || items[2].SK != store.UserPK("user-2") {
+			t.Errorf("expected every region's items in sort key order, got %+v", items)
+		}
+	})
+
+	t.Run("moves a new user's index items with them", func(t *testing.T) {
+		// Arrange
+		r, us, eu := regions(t)
+		r.Index("EMAIL#")
+		key := store.Key{PK: "EMAIL#sam@example.com", SK: store.UserPK("user-1")}
+		r.Put(ctx, key.PK, key.SK, map[string]string{"userId": "user-1"})
+
+		// Act
+		err := r.Pin(ctx, "user-1", "eu-west-1", now, []store.Key{key})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if found, _ := us.Query(ctx, key.PK, ""); len(found) != 0 {
+			t.Errorf("expected nothing left in the home table, got %+v", found)
+		}
+		if found, _ := eu.Query(ctx, key.PK, ""); len(found) != 1 {
+			t.Errorf("expected the lookup in the EU table, got %+v", found)
+		}
+	})
+
+	t.Run("another deployment sees a pin", func(t *testing.T) {
+		// Arrange
+		r, us, eu := regions(t)
+		other, _ := NewRouter("us-east-1", map[string]store.Store{"us-east-1": us, "eu-west-1": eu})
+		r.Pin(ctx, "user-1", "eu-west-1", now, nil)
+
+		// Act
+		region, err := other.Region(ctx, "user-1")
+
+		// Assert
+		if err != nil || region != "eu-west-1" {
+			t.Errorf("expected eu-west-1, got %q (%v)", region, err)
+		}
+	})
+
+	t.Run("refuses to move users with data", func(t *testing.T) {
+		// Arrange
+		r, _, eu := regions(t)
+		r.Put(ctx, store.UserPK("user-1"), "ACCOUNT", map[string]string{"id": "user-1"})
+		r.Put(ctx, store.UserPK("user-1"), "WORKOUT#w1", map[string]string{"id": "w1"})
+
+		// Act
+		err := r.Pin(ctx, "user-1", "eu-west-1", now, nil, "ACCOUNT")
+
+		// Assert
+		if !errors.Is(err, ErrHasData) {
+			t.Errorf("expected ErrHasData, got %v", err)
+		}
+		if region, _ := r.Region(ctx, "user-1"); region != "us-east-1" {
+			t.Errorf("expected the user to stay in us-east-1, got %s", region)
+		}
+		if items, _ := eu.Query(ctx, store.UserPK("user-1"), ""); len(items) != 0 {
+			t.Errorf("expected nothing copied, got %d items", len(items))
+		}
+	})
+
+	t.Run("rejects unknown regions", func(t *testing.T) {
+		// Arrange
+		r, _, _ := regions(t)
+
+		// Act
+		err := r.Pin(ctx, "user-1", "ap-south-1", now, nil)
+
+		// Assert
+		if !errors.Is(err, ErrUnknownRegion) {
+			t.Errorf("expected ErrUnknownRegion, got %v", err)
+		}
+	})
+
+	t.Run("fails closed for regions this deployment lacks", func(t *testing.T) {
+		// Arrange
+		r, us, _ := regions(t)
+		r.Pin(ctx, "user-1", "eu-west-1", now, nil)
+		usOnly, _ := NewRouter("us-east-1", map[string]store.Store{"us-east-1": us})
+
+		// Act
+		putErr := usOnly.Put(ctx, store.UserPK("user-1"), "PROFILE", map[string]string{"name": "Sam"})
+		_, queryErr := usOnly.Query(ctx, store.UserPK("user-1"), "")
+
+		// Assert
+		if !errors.Is(putErr, ErrUnavailable) || !errors.Is(queryErr, ErrUnavailable) {
+			t.Errorf("expected ErrUnavailable, got %v and %v", putErr, queryErr)
+		}
+		if items, _ := us.Query(ctx, store.UserPK("user-1"), ""); len(items) != 0 {
+			t.Errorf("expected nothing written to the home table, got %d items", len(items))
+		}
+	})
+
+	t.Run("batch gets read each region's table", func(t *testing.T) {
+		// Arrange
+		r, _, _ := regions(t)
+		r.Pin(ctx, "user-2", "eu-west-1", now, nil)
+		r.Put(ctx, store.UserPK("user-1"), "PROFILE", map[string]string{"name": "Sam"})
+		r.Put(ctx, store.UserPK("user-2"), "PROFILE", map[string]string{"name": "Alex"})
+
+		// Act
+		items, err := r.BatchGet(ctx, []store.Key{
+			{PK: store.UserPK("user-1"), SK: "PROFILE"},
+			{PK: store.UserPK("user-2"), SK: "PROFILE"},
+		})
+
+		// Assert
+		if err != nil || len(items) != 2 {
+			t.Errorf("expected both profiles, got %d (%v)", len(items), err)
+		}
+	})
+
+	t.Run("batch puts write each region's table", func(t *testing.T) {
+		// Arrange
+		r, us, eu := regions(t)
+		r.Pin(ctx, "user-2", "eu-west-1", now, nil)
+
+		// Act
+		err := r.BatchPut(ctx, []store.Write{
+			{PK: store.UserPK("user-1"), SK: "PROFILE", Value: map[string]string{"name": "Sam"}},
+			{PK: store.UserPK("user-2"), SK: "PROFILE", Value: map[string]string{"name": "Alex"}},
+		})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if items, _ := us.Query(ctx, store.UserPK("user-2"), "PROFILE"); len(items) != 0 {
+			t.Error("expected the pinned user's profile to stay out of the home table")
+		}
+		if items, _ := eu.Query(ctx, store.UserPK("user-2"), "PROFILE"); len(items) != 1 {
+			t.Error("expected the pinned user's profile in the EU table")
+		}
+	})
+
+	t.Run("requires a home table", func(t *testing.T) {
+		// Act
+		_, err := NewRouter("us-east-1", map[string]store.Store{"eu-west-1": store.NewMemoryStore()})
+
+		// Assert
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestRouter_Blobs(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+
+	t.Run("stores users' files in their region's bucket", func(t *testing.T) {
+		// Arrange
+		r, _ := NewRouter("us-east-1", map[string]store.Store{"us-east-1": store.NewMemoryStore(), "eu-west-1": store.NewMemoryStore()})
+		r.Pin(ctx, "user-2", "eu-west-1", now, nil)
+		us, eu := blob.NewMemoryStore(), blob.NewMemoryStore()
+		blobs, _ := r.Blobs(map[string]blob.Store{"us-east-1": us, "eu-west-1": eu})
+
+		// Act
+		blobs.Put(ctx, "tracks/user-1/a1.json", "application/json", []byte("[]"))
+		blobs.Put(ctx, "tracks/user-2/a2.json", "application/json", []byte("[]"))
+		blobs.Put(ctx, "manifest.json", "application/json", []byte("{}"))
+
+		// Assert
+		if _, ok := us.Get("tracks/user-1/a1.json"); !ok {
+			t.Error("expected the unpinned user's track in the home bucket")
+		}
+		if _, ok := eu.Get("tracks/user-2/a2.json"); !ok {
+			t.Error("expected the pinned user's track in the EU bucket")
+		}
+		if _, ok := us.Get("tracks/user-2/a2.json"); ok {
+			t.Error("expected the pinned user's track to stay out of the home bucket")
+		}
+		if _, ok := us.Get("manifest.json"); !ok {
+			t.Error("expected keys without a user in the home bucket")
+		}
+	})
+
+	t.Run("fails closed for regions without a bucket", func(t *testing.T) {
+		// Arrange
+		r, _ := NewRouter("us-east-1", map[string]store.Store{"us-east-1": store.NewMemoryStore(), "eu-west-1": store.NewMemoryStore()})
+		r.Pin(ctx, "user-2", "eu-west-1", now, nil)
+		us := blob.NewMemoryStore()
+		blobs, _ := r.Blobs(map[string]blob.Store{"us-east-1": us})
+
+		// Act
+		err := blobs.Put(ctx, "exports/user-2/e1.pdf", "application/pdf", []byte("%PDF"))
+
+		// Assert
+		if !errors.Is(err, ErrUnavailable) {
+			t.Errorf("expected ErrUnavailable, got %v", err)
+		}
+		if _, ok := us.Get("exports/user-2/e1.pdf"); ok {
+			t.Error("expected nothing stored in the home bucket")
+		}
+	})
+}
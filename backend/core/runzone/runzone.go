@@ -0,0 +1,116 @@
+package runzone
+
+import (
+	"errors"
+	"math"
+)
+
+// zoneCount is the number of training zones
+const zoneCount = 5
+
+// speedBounds are the lower bounds of zones 1-5 as fractions of threshold
+// speed, from Friel's run pace zones at 129%, 114%, 106% and 99% of threshold
+// pace; zone 1 covers everything slower than zone 2
+var speedBounds = [zoneCount]float64{0, 0.78, 0.88, 0.94, 1.01}
+
+// powerBounds are the lower bounds of zones 1-5 as fractions of threshold
+// running power, as in Stryd's easy, moderate, threshold, interval and
+// repetition zones
+var powerBounds = [zoneCount]float64{0, 0.8, 0.9, 1, 1.15}
+
+// Config is a user's running thresholds: the pace, in seconds per kilometre,
+// and the power, in watts, they can hold for about an hour. Either may be
+// left unset
+type Config struct {
+	ThresholdPace  int `json:"thresholdPace,omitempty"`
+	ThresholdPower int `json:"thresholdPower,omitempty"`
+}
+
+// Validate checks the thresholds are plausible
+func (c *Config) Validate() error {
+	if c.ThresholdPace == 0 && c.ThresholdPower == 0 {
+		return errors.New("running zones need a thresholdPace or thresholdPower")
+	}
+	if c.ThresholdPace != 0 && (c.ThresholdPace < 150 || c.ThresholdPace > 900) {
+		return errors.New("thresholdPace must be between 150 and 900 seconds per kilometre")
+	}
+	if c.ThresholdPower != 0 && (c.ThresholdPower < 50 || c.ThresholdPower > 700) {
+		return errors.New("thresholdPower must be between 50 and 700 watts")
+	}
+	return nil
+}
+
+// PaceZone is a pace band in seconds per kilometre; a SlowestPace or
+// FastestPace of 0 is unbounded
+type PaceZone struct {
+	Number      int `json:"zone"`
+	SlowestPace int `json:"slowestPace,omitempty"`
+	FastestPace int `json:"fastestPace,omitempty"`
+}
+
+// PowerZone is a power band in watts; a MaxWatts of 0 is unbounded
+type PowerZone struct {
+	Number   int `json:"zone"`
+	MinWatts int `json:"minWatts"`
+	MaxWatts int `json:"maxWatts,omitempty"`
+}
+
+// PaceZones returns the five pace zones, or nil without a threshold pace
+func (c *Config) PaceZones() []PaceZone {
+	if c.ThresholdPace == 0 {
+		return nil
+	}
+	zones := make([]PaceZone, zoneCount)
+	for i := range zones {
+		zones[i].Number = i + 1
+		if i > 0 {
+			zones[i].SlowestPace = int(math.Round(float64(c.ThresholdPace) / speedBounds[i]))
+		}
+		if i+1 < zoneCount {
+			zones[i].FastestPace = int(math.Round(float64(c.ThresholdPace)/speedBounds[i+1])) + 1
+		}
+	}
+	return zones
+}
+
+// PowerZones returns the five power zones, or nil without a threshold power
+func (c *Config) PowerZones() []PowerZone {
+	if c.ThresholdPower == 0 {
+		return nil
+	}
+	zones := make([]PowerZone, zoneCount)
+	for i := range zones {
+		zones[i] = PowerZone{Number: i + 1, MinWatts: int(math.Round(powerBounds[i] * float64(c.ThresholdPower)))}
+		if i+1 < zoneCount {
+			zones[i].MaxWatts = int(math.Round(powerBounds[i+1]*float64(c.ThresholdPower))) - 1
+		}
+	}
+	return zones
+}
+
+// ZoneForSpeed returns the pace zone for a speed in metres per second, or 0
+// without a threshold pace
+func (c *Config) ZoneForSpeed(metresPerSecond float64) int {
+	if c.ThresholdPace == 0 {
+		return 0
+	}
+	return zoneFor(metresPerSecond/(1000/float64(c.ThresholdPace)), speedBounds)
+}
+
+// ZoneForPower returns the power zone for watts, or 0 without a threshold
+// power
+func (c *Config) ZoneForPower(watts int) int {
+	if c.ThresholdPower == 0 {
+		return 0
+	}
+	return zoneFor(float64(watts)/float64(c.ThresholdPower), powerBounds)
+}
+
+func zoneFor(fraction float64, bounds [zoneCount]float64) int {
+	for i := zoneCount - 1; i >= 0; i-- {
+		if fraction >= bounds[i] {
+			return i + 1
+		}
+	}
+	return 1
+}
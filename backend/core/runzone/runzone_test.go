@@ -0,0 +1,109 @@
+package runzone
+
+import "testing"
+
+func TestConfig_PaceZones(t *testing.T) {
+	t.Run("bands paces around the threshold pace", func(t *testing.T) {
+		// Arrange
+		c := Config{ThresholdPace: 300}
+
+		// Act
+		zones := c.PaceZones()
+
+		// Assert
+		expected := []PaceZone{
+			{Number: 1, FastestPace: 386},
+			{Number: 2, SlowestPace: 385, FastestPace: 342},
+			{Number: 3, SlowestPace: 341, FastestPace: 320},
+			{Number: 4, SlowestPace: 319, FastestPace: 298},
+			{Number: 5, SlowestPace: 297},
+		}
+		for i := range expected {
+			if zones[i] != expected[i] {
+				t.Errorf("zone %d: expected %+v, got %+v", i+1, expected[i], zones[i])
+			}
+		}
+	})
+
+	t.Run("returns nil without a threshold pace", func(t *testing.T) {
+		if zones := (&Config{ThresholdPower: 250}).PaceZones(); zones != nil {
+			t.Errorf("expected no zones, got %+v", zones)
+		}
+	})
+}
+
+func TestConfig_PowerZones(t *testing.T) {
+	// Arrange
+	c := Config{ThresholdPower: 250}
+
+	// Act
+	zones := c.PowerZones()
+
+	// Assert
+	expected := []PowerZone{
+		{Number: 1, MinWatts: 0, MaxWatts: 199},
+		{Number: 2, MinWatts: 200, MaxWatts: 224},
+		{Number: 3, MinWatts: 225, MaxWatts: 249},
+		{Number: 4, MinWatts: 250, MaxWatts: 287},
+		{Number: 5, MinWatts: 288},
+	}
+	for i := range expected {
+		if zones[i] != expected[i] {
+			t.Errorf("zone %d: expected %+v, got %+v", i+1, expected[i], zones[i])
+		}
+	}
+}
+
+func TestConfig_ZoneFor(t *testing.T) {
+	c := Config{ThresholdPace: 300, ThresholdPower: 250}
+
+	t.Run("scores speed against threshold speed", func(t *testing.T) {
+		if z := c.ZoneForSpeed(2); z != 1 {
+			t.Errorf("expected zone 1 at 8:20 /km, got %d", z)
+		}
+		if z := c.ZoneForSpeed(3.1); z != 3 {
+			t.Errorf("expected zone 3 at 5:23 /km, got %d", z)
+		}
+		if z := c.ZoneForSpeed(4); z != 5 {
+			t.Errorf("expected zone 5 at 4:10 /km, got %d", z)
+		}
+	})
+
+	t.Run("scores power against threshold power", func(t *testing.T) {
+		if z := c.ZoneForPower(230); z != 3 {
+			t.Errorf("expected zone 3, got %d", z)
+		}
+		if z := c.ZoneForPower(300); z != 5 {
+			t.Errorf("expected zone 5, got %d", z)
+		}
+	})
+
+	t.Run("returns 0 without thresholds", func(t *testing.T) {
+		var empty Config
+		if empty.ZoneForSpeed(4) != 0 || empty.ZoneForPower(300) != 0 {
+			t.Error("expected zone 0 without thresholds")
+		}
+	})
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{name: "threshold pace", config: Config{ThresholdPace: 270}},
+		{name: "threshold power", config: Config{ThresholdPower: 260}},
+		{name: "both", config: Config{ThresholdPace: 270, ThresholdPower: 260}},
+		{name: "neither", config: Config{}, wantErr: true},
+		{name: "pace too fast", config: Config{ThresholdPace: 100}, wantErr: true},
+		{name: "power too high", config: Config{ThresholdPower: 900}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package sessionindex
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"athlete-forge/store"
+)
+
+const (
+	indexPK         = "OPEN_SESSIONS"
+	sessionSKPrefix = "SESSION#"
+
+	// startLayout is fixed-width, so sort keys order sessions by start
+	startLayout = "2006-01-02T15:04:05.000000000Z"
+
+	// bucketLayout names the hour a partition holds sessions started in
+	bucketLayout = "2006-01-02T15"
+)
+
+// PKPrefix starts the index's partitions, one per hour of starts, whose sort
+// keys end with the user so each entry can be kept in its user's region
+const PKPrefix = indexPK
+
+// Entry records that a user started a workout session, which may since have
+// been completed
+type Entry struct {
+	UserID    string    `json:"userId"`
+	WorkoutID string    `json:"workoutId"`
+	StartedAt time.Time `json:"startedAt"`
+
+	// legacy is set on entries read from the single partition used before
+	// sessions were bucketed by hour
+	legacy bool
+}
+
+// Index lists the workout sessions users have started, oldest first, so the
+// stale session job visits only those rather than every user's history. Entries
+// are added as sessions start, into a partition per hour so no one partition
+// takes every start, and removed by the job once it finds the session finished
+// or has finished it, so the partitions hold only recent sessions
+type Index struct {
+	store store.Store
+}
+
+// New creates an Index backed by s
+func New(s store.Store) *Index {
+	return &Index{store: s}
+}
+
+// bucketPK is the partition of sessions started in the hour of startedAt
+func bucketPK(startedAt time.Time) string {
+	return indexPK + "#" + startedAt.UTC().Format(bucketLayout)
+}
+
+func sessionSK(e Entry) string {
+	return fmt.Sprintf("%s%s#%s#%s", sessionSKPrefix, e.StartedAt.UTC().Format(startLayout), e.WorkoutID, store.UserPK(e.UserID))
+}
+
+func legacySessionSK(e Entry) string {
+	return fmt.Sprintf("%s%s#%s#%s", sessionSKPrefix, e.StartedAt.UTC().Format(startLayout), e.UserID, e.WorkoutID)
+}
+
+// Add records that userID started workoutID at startedAt
+func (i *Index) Add(ctx context.Context, userID, workoutID string, startedAt time.Time) error {
+	e := Entry{UserID: userID, WorkoutID: workoutID, StartedAt: startedAt.UTC()}
+	if err := i.store.Put(ctx, bucketPK(e.StartedAt), sessionSK(e), e); err != nil {
+		return fmt.Errorf("failed to index session: %w", err)
+	}
+	return nil
+}
+
+// Remove forgets e
+func (i *Index) Remove(ctx context.Context, e Entry) error {
+	pk, sk := bucketPK(e.StartedAt), sessionSK(e)
+	if e.legacy {
+		pk, sk = indexPK, legacySessionSK(e)
+	}
+	if err := i.store.Delete(ctx, pk, sk); err != nil {
+		return fmt.Errorf("failed to remove session from index: %w", err)
+	}
+	return nil
+}
+
+// StartedBefore returns the sessions started from since and before cutoff,
+// oldest first, reading the partitions of the hours between them. Entries
+// left in the single partition used before are returned whenever they started
+func (i *Index) StartedBefore(ctx context.Context, since, cutoff time.Time) ([]Entry, error) {
+	entries, err := i.started(ctx, indexPK, cutoff, true)
+	if err != nil {
+		return nil, err
+	}
+	for hour := since.UTC().Truncate(time.Hour); hour.Before(cutoff); hour = hour.Add(time.Hour) {
+		found, err := i.started(ctx, bucketPK(hour), cutoff, false)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, found...)
+	}
+	sort.SliceStable(entries, func(a, b int) bool {
+		return entries[a].StartedAt.Before(entries[b].StartedAt)
+	})
+	return entries, nil
+}
+
+// started returns the entries in partition pk started before cutoff
+func (i *Index) started(ctx context.Context, pk string, cutoff time.Time, legacy bool) ([]Entry, error) {
+	items, err := i.store.Query(ctx, pk, sessionSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	entries := []Entry{}
+	for _, item := range items {
+		var e Entry
+		if err := item.Decode(&e); err != nil {
+			return nil, err
+		}
+		if !e.StartedAt.Before(cutoff) {
+			break
+		}
+		e.legacy = legacy
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
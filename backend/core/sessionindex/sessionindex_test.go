@@ -0,0 +1,75 @@
+package sessionindex
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+)
+
+func TestIndex(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 5, 4, 18, 0, 0, 0, time.UTC)
+
+	t.Run("lists sessions started before the cutoff, oldest first", func(t *testing.T) {
+		// Arrange
+		index := New(store.NewMemoryStore())
+		index.Add(ctx, "user-2", "w2", now.Add(-13*time.Hour))
+		index.Add(ctx, "user-1", "w1", now.Add(-30*time.Hour))
+		index.Add(ctx, "user-1", "w3", now.Add(-time.Hour))
+
+		// Act
+		entries, err := index.StartedBefore(ctx, now.Add(-48*time.Hour), now.Add(-12*time.Hour))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(entries) != 2 || entries[0].WorkoutID != "w1" || entries[1].WorkoutID != "w2" {
+			t.Errorf("expected w1 then w2, got %+v", entries)
+		}
+	})
+
+	t.Run("forgets removed sessions", func(t *testing.T) {
+		// Arrange
+		index := New(store.NewMemoryStore())
+		index.Add(ctx, "user-1", "w1", now.Add(-30*time.Hour))
+		entries, _ := index.StartedBefore(ctx, now.Add(-48*time.Hour), now)
+
+		// Act
+		err := index.Remove(ctx, entries[0])
+		after, _ := index.StartedBefore(ctx, now.Add(-48*time.Hour), now)
+
+		// Assert
+		if err != nil || len(after) != 0 {
+			t.Errorf("expected no sessions left, got %+v %v", after, err)
+		}
+	})
+
+	t.Run("buckets sessions by hour and drains the partition used before", func(t *testing.T) {
+		// Arrange
+		s := store.NewMemoryStore()
+		index := New(s)
+		index.Add(ctx, "user-1", "w1", now.Add(-30*time.Hour))
+		old := Entry{UserID: "user-2", WorkoutID: "w2", StartedAt: now.Add(-40 * time.Hour)}
+		s.Put(ctx, indexPK, legacySessionSK(old), old)
+
+		// Act
+		entries, err := index.StartedBefore(ctx, now.Add(-36*time.Hour), now)
+		for _, e := range entries {
+			index.Remove(ctx, e)
+		}
+
+		// Assert
+		if err != nil || len(entries) != 2 || entries[0].WorkoutID != "w2" {
+			t.Fatalf("expected w2 then w1, got %+v %v", entries, err)
+		}
+		if items, _ := s.Query(ctx, "OPEN_SESSIONS#2026-05-03T12", ""); len(items) != 0 {
+			t.Errorf("expected the bucket emptied, got %+v", items)
+		}
+		if items, _ := s.Query(ctx, indexPK, ""); len(items) != 0 {
+			t.Errorf("expected the old partition emptied, got %+v", items)
+		}
+	})
+}
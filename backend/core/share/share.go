@@ -0,0 +1,161 @@
+package share
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"athlete-forge/program"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+const (
+	sharePKPrefix = "SHARE#"
+
+	// codeAlphabet is Crockford's base32, which leaves out I, L, O and U so codes
+	// read aloud or typed from a screen are not mistaken
+	codeAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	codeLength   = 8
+
+	// TTL is how long a share code can be redeemed; codes are for handing a
+	// session across the gym floor, not for publishing it
+	TTL = 15 * time.Minute
+)
+
+// Kinds of content that can be shared
+const (
+	KindWorkout  = "workout"
+	KindTemplate = "template"
+)
+
+// Share is content one user hands to another device through a short code: a
+// pending workout, copied without its owner's IDs and progress, or a program
+// template
+type Share struct {
+	Code      string            `json:"code"`
+	OwnerID   string            `json:"ownerId"`
+	Kind      string            `json:"kind"`
+	Workout   *Workout          `json:"workout,omitempty"`
+	Template  *program.Template `json:"template,omitempty"`
+	CreatedAt time.Time         `json:"createdAt"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+}
+
+// Workout is the part of a workout that is shared: what to perform, not who
+// performed it or when
+type Workout struct {
+	Exercises []workout.Exercise `json:"exercises"`
+	Groups    []workout.Group    `json:"groups,omitempty"`
+	Notes     string             `json:"notes,omitempty"`
+}
+
+// FromWorkout returns the shareable part of w
+func FromWorkout(w *workout.Workout) *Workout {
+	return &Workout{Exercises: w.Exercises, Groups: w.Groups, Notes: w.Notes}
+}
+
+// Validate checks the share has content of its kind
+func (s *Share) Validate() error {
+	switch s.Kind {
+	case KindWorkout:
+		if s.Workout == nil {
+			return errors.New("workout share has no workout")
+		}
+	case KindTemplate:
+		if s.Template == nil {
+			return errors.New("template share has no template")
+		}
+	default:
+		return fmt.Errorf("unknown share kind %q", s.Kind)
+	}
+	return nil
+}
+
+// NormalizeCode upper-cases code and drops the spaces and dashes users type
+// between its groups, mapping the letters Crockford's base32 reads as digits
+func NormalizeCode(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.NewReplacer(" ", "", "-", "", "I", "1", "L", "1", "O", "0").Replace(code)
+	return code
+}
+
+// newCode returns a random share code
+func newCode() (string, error) {
+	var random [codeLength]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return "", fmt.Errorf("failed to generate share code: %w", err)
+	}
+	code := make([]byte, codeLength)
+	for i, b := range random {
+		code[i] = codeAlphabet[int(b)%len(codeAlphabet)]
+	}
+	return string(code), nil
+}
+
+// PKPrefix is the partition of shares, each keyed by its code and then by its
+// owner, so a share can be kept in its owner's region
+const PKPrefix = sharePKPrefix
+
+// Repository stores shares under their code, so any user holding the code can
+// find one
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Create validates s, gives it a new code and stores it to expire after TTL
+func (r *Repository) Create(ctx context.Context, s *Share, now time.Time) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	code, err := newCode()
+	if err != nil {
+		return err
+	}
+	s.Code = code
+	s.CreatedAt = now
+	s.ExpiresAt = now.Add(TTL)
+	if err := r.store.Put(ctx, sharePKPrefix+s.Code, store.UserPK(s.OwnerID), s); err != nil {
+		return fmt.Errorf("failed to save share: %w", err)
+	}
+	return nil
+}
+
+// Get returns the unexpired share with code; expired shares are not found
+func (r *Repository) Get(ctx context.Context, code string, now time.Time) (*Share, error) {
+	code = NormalizeCode(code)
+	if len(code) != codeLength {
+		return nil, store.ErrNotFound
+	}
+	items, err := r.store.Query(ctx, sharePKPrefix+code, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load share: %w", err)
+	}
+	if len(items) == 0 {
+		return nil, store.ErrNotFound
+	}
+	var s Share
+	if err := items[0].Decode(&s); err != nil {
+		return nil, err
+	}
+	if !now.Before(s.ExpiresAt) {
+		return nil, store.ErrNotFound
+	}
+	return &s, nil
+}
+
+// Delete removes s, once it is redeemed or withdrawn
+func (r *Repository) Delete(ctx context.Context, s *Share) error {
+	if err := r.store.Delete(ctx, sharePKPrefix+NormalizeCode(s.Code), store.UserPK(s.OwnerID)); err != nil {
+		return fmt.Errorf("failed to delete share: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package share
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+func TestNormalizeCode(t *testing.T) {
+	if got := NormalizeCode("ab1o-x2l9 "); got != "AB10X219" {
+		t.Errorf("expected AB10X219, got %q", got)
+	}
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	newShare := func() *Share {
+		return &Share{OwnerID: "coach", Kind: KindWorkout, Workout: &Workout{Exercises: []workout.Exercise{{Name: "Squat"}}}}
+	}
+
+	t.Run("codes are found however they are typed until they expire", func(t *testing.T) {
+		// Arrange
+		repo := NewRepository(store.NewMemoryStore())
+		s := newShare()
+
+		// Act
+		err := repo.Create(ctx, s, now)
+		typed := strings.ToLower(s.Code[:4]) + "-" + s.Code[4:]
+		found, findErr := repo.Get(ctx, typed, now.Add(TTL-time.Second))
+		_, expiredErr := repo.Get(ctx, s.Code, now.Add(TTL))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(s.Code) != codeLength || strings.ContainsAny(s.Code, "ILOU") {
+			t.Errorf("unexpected code %q", s.Code)
+		}
+		if findErr != nil || found.OwnerID != "coach" {
+			t.Errorf("expected the share, got %+v (%v)", found, findErr)
+		}
+		if !errors.Is(expiredErr, store.ErrNotFound) {
+			t.Errorf("expected expired code not found, got %v", expiredErr)
+		}
+	})
+
+	t.Run("rejects shares without content", func(t *testing.T) {
+		// Act
+		err := NewRepository(store.NewMemoryStore()).Create(ctx, &Share{OwnerID: "coach", Kind: KindTemplate}, now)
+
+		// Assert
+		if err == nil {
+			t.Error("expected error for a template share without a template")
+		}
+	})
+}
@@ -0,0 +1,205 @@
+package sharecard
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+	"strings"
+)
+
+// Card images are square, the size social apps show without cropping
+const (
+	imageSize = 1080
+	margin    = 72
+	rowHeight = 88
+)
+
+var (
+	background = color.RGBA{17, 24, 39, 255}
+	foreground = color.RGBA{255, 255, 255, 255}
+	muted      = color.RGBA{156, 163, 175, 255}
+	accent     = color.RGBA{41, 97, 181, 255}
+	highlight  = color.RGBA{245, 180, 0, 255}
+)
+
+// glyphs is a 5x7 pixel font of the characters cards use; each row's five
+// pixels are its low bits, leftmost first. Text is drawn in capitals and other
+// characters as a question mark
+var glyphs = map[rune][7]uint8{
+	' ':  {},
+	'A':  {0b01110, 0b10001, 0b10001, 0b11111, 0b10001, 0b10001, 0b10001},
+	'B':  {0b11110, 0b10001, 0b10001, 0b11110, 0b10001, 0b10001, 0b11110},
+	'C':  {0b01110, 0b10001, 0b10000, 0b10000, 0b10000, 0b10001, 0b01110},
+	'D':  {0b11110, 0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b11110},
+	'E':  {0b11111, 0b10000, 0b10000, 0b11110, 0b10000, 0b10000, 0b11111},
+	'F':  {0b11111, 0b10000, 0b10000, 0b11110, 0b10000, 0b10000, 0b10000},
+	'G':  {0b01110, 0b10001, 0b10000, 0b10111, 0b10001, 0b10001, 0b01111},
+	'H':  {0b10001, 0b10001, 0b10001, 0b11111, 0b10001, 0b10001, 0b10001},
+	'I':  {0b01110, 0b00100, 0b00100, 0b00100, 0b00100, 0b00100, 0b01110},
+	'J':  {0b00111, 0b00010, 0b00010, 0b00010, 0b00010, 0b10010, 0b01100},
+	'K':  {0b10001, 0b10010, 0b10100, 0b11000, 0b10100, 0b10010, 0b10001},
+	'L':  {0b10000, 0b10000, 0b10000, 0b10000, 0b10000, 0b10000, 0b11111},
+	'M':  {0b10001, 0b11011, 0b10101, 0b10101, 0b10001, 0b10001, 0b10001},
+	'N':  {0b10001, 0b10001, 0b11001, 0b10101, 0b10011, 0b10001, 0b10001},
+	'O':  {0b01110, 0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b01110},
+	'P':  {0b11110, 0b10001, 0b10001, 0b11110, 0b10000, 0b10000, 0b10000},
+	'Q':  {0b01110, 0b10001, 0b10001, 0b10001, 0b10101, 0b10010, 0b01101},
+	'R':  {0b11110, 0b10001, 0b10001, 0b11110, 0b10100, 0b10010, 0b10001},
+	'S':  {0b01111, 0b10000, 0b10000, 0b01110, 0b00001, 0b00001, 0b11110},
+	'T':  {0b11111, 0b00100, 0b00100, 0b00100, 0b00100, 0b00100, 0b00100},
+	'U':  {0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b01110},
+	'V':  {0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b01010, 0b00100},
+	'W':  {0b10001, 0b10001, 0b10001, 0b10101, 0b10101, 0b10101, 0b01010},
+	'X':  {0b10001, 0b10001, 0b01010, 0b00100, 0b01010, 0b10001, 0b10001},
+	'Y':  {0b10001, 0b10001, 0b01010, 0b00100, 0b00100, 0b00100, 0b00100},
+	'Z':  {0b11111, 0b00001, 0b00010, 0b00100, 0b01000, 0b10000, 0b11111},
+	'0':  {0b01110, 0b10001, 0b10011, 0b10101, 0b11001, 0b10001, 0b01110},
+	'1':  {0b00100, 0b01100, 0b00100, 0b00100, 0b00100, 0b00100, 0b01110},
+	'2':  {0b01110, 0b10001, 0b00001, 0b00010, 0b00100, 0b01000, 0b11111},
+	'3':  {0b11111, 0b00010, 0b00100, 0b00010, 0b00001, 0b10001, 0b01110},
+	'4':  {0b00010, 0b00110, 0b01010, 0b10010, 0b11111, 0b00010, 0b00010},
+	'5':  {0b11111, 0b10000, 0b11110, 0b00001, 0b00001, 0b10001, 0b01110},
+	'6':  {0b00110, 0b01000, 0b10000, 0b11110, 0b10001, 0b10001, 0b01110},
+	'7':  {0b11111, 0b00001, 0b00010, 0b00100, 0b01000, 0b01000, 0b01000},
+	'8':  {0b01110, 0b10001, 0b10001, 0b01110, 0b10001, 0b10001, 0b01110},
+	'9':  {0b01110, 0b10001, 0b10001, 0b01111, 0b00001, 0b00010, 0b01100},
+	'.':  {0, 0, 0, 0, 0, 0b01100, 0b01100},
+	',':  {0, 0, 0, 0, 0b01100, 0b00100, 0b01000},
+	':':  {0, 0b01100, 0b01100, 0, 0b01100, 0b01100, 0},
+	'-':  {0, 0, 0, 0b11111, 0, 0, 0},
+	'+':  {0, 0b00100, 0b00100, 0b11111, 0b00100, 0b00100, 0},
+	'/':  {0b00001, 0b00010, 0b00010, 0b00100, 0b01000, 0b01000, 0b10000},
+	'\'': {0b00100, 0b00100, 0b01000, 0, 0, 0, 0},
+	'(':  {0b00010, 0b00100, 0b01000, 0b01000, 0b01000, 0b00100, 0b00010},
+	')':  {0b01000, 0b00100, 0b00010, 0b00010, 0b00010, 0b00100, 0b01000},
+	'#':  {0b01010, 0b01010, 0b11111, 0b01010, 0b11111, 0b01010, 0b01010},
+	'%':  {0b11000, 0b11001, 0b00010, 0b00100, 0b01000, 0b10011, 0b00011},
+	'&':  {0b01100, 0b10010, 0b10100, 0b01000, 0b10101, 0b10010, 0b01101},
+	'?':  {0b01110, 0b10001, 0b00001, 0b00010, 0b00100, 0, 0b00100},
+}
+
+// Render draws c as a PNG: the date, the time, volume and sets, then the top
+// sets with personal records marked
+func Render(c *Card) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, imageSize, imageSize))
+	draw.Draw(img, img.Bounds(), &image.Uniform{background}, image.Point{}, draw.Src)
+	fill(img, image.Rect(0, 0, imageSize, 24), accent)
+
+	text(img, margin, 96, 6, muted, c.CompletedAt.Format("Mon 2 Jan 2006"))
+	stats := []struct{ value, label string }{
+		{duration(c.DurationSeconds), "TIME"},
+		{volume(c.Volume), "VOLUME " + c.Unit},
+		{strconv.Itoa(c.Sets), "SETS"},
+	}
+	column := (imageSize - 2*margin) / len(stats)
+	for i, stat := range stats {
+		x := margin + i*column
+		text(img, x, 184, 7, foreground, stat.value)
+		text(img, x, 264, 4, muted, stat.label)
+	}
+	fill(img, image.Rect(margin, 336, imageSize-margin, 340), muted)
+
+	y := 384
+	for _, top := range c.TopSets {
+		detail := setDetail(top, c.Unit)
+		detailX := imageSize - margin - width(detail, 5)
+		text(img, detailX, y, 5, accent, detail)
+		nameWidth := detailX - margin - 30
+		if top.Record {
+			text(img, margin, y, 5, highlight, "PR")
+			text(img, margin+90, y, 5, foreground, truncate(top.Exercise, nameWidth-90, 5))
+		} else {
+			text(img, margin, y, 5, foreground, truncate(top.Exercise, nameWidth, 5))
+		}
+		y += rowHeight
+	}
+	if c.MoreExercises > 0 {
+		text(img, margin, y, 4, muted, fmt.Sprintf("+%d MORE", c.MoreExercises))
+	}
+	text(img, margin, imageSize-margin-28, 4, muted, "ATHLETE FORGE")
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode share card: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// text draws s with its top-left corner at x, y, each font pixel scale pixels
+// square
+func text(img *image.RGBA, x, y, scale int, c color.RGBA, s string) {
+	for _, r := range strings.ToUpper(s) {
+		glyph, ok := glyphs[r]
+		if !ok {
+			glyph = glyphs['?']
+		}
+		for row, bits := range glyph {
+			for col := 0; col < 5; col++ {
+				if bits&(1<<(4-col)) != 0 {
+					fill(img, image.Rect(x+col*scale, y+row*scale, x+(col+1)*scale, y+(row+1)*scale), c)
+				}
+			}
+		}
+		x += 6 * scale
+	}
+}
+
+// width returns how wide s is drawn at scale
+func width(s string, scale int) int {
+	n := len([]rune(s))
+	if n == 0 {
+		return 0
+	}
+	return (6*n - 1) * scale
+}
+
+// truncate shortens s with an ellipsis to fit within limit pixels at scale
+func truncate(s string, limit, scale int) string {
+	if width(s, scale) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && width(string(runes)+"...", scale) > limit {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "..."
+}
+
+func fill(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	draw.Draw(img, r, &image.Uniform{c}, image.Point{}, draw.Src)
+}
+
+// setDetail describes a top set, such as 100 KG X 5, or 12 REPS for
+// unloaded sets
+func setDetail(top TopSet, unit string) string {
+	switch {
+	case top.Weight > 0 && top.Reps > 0:
+		return fmt.Sprintf("%s %s X %d", strconv.FormatFloat(top.Weight, 'f', -1, 64), unit, top.Reps)
+	case top.Reps > 0:
+		return fmt.Sprintf("%d REPS", top.Reps)
+	case top.Sets == 1:
+		return "1 SET"
+	}
+	return fmt.Sprintf("%d SETS", top.Sets)
+}
+
+// duration formats seconds as 1H 05M, or 45M under an hour
+func duration(seconds int) string {
+	minutes := seconds / 60
+	if minutes < 60 {
+		return fmt.Sprintf("%dM", minutes)
+	}
+	return fmt.Sprintf("%dH %02dM", minutes/60, minutes%60)
+}
+
+// volume formats a volume to fit its column, in thousands from 100,000
+func volume(v float64) string {
+	if v >= 100000 {
+		return fmt.Sprintf("%.0fK", v/1000)
+	}
+	return fmt.Sprintf("%.0f", v)
+}
@@ -0,0 +1,192 @@
+package sharecard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"athlete-forge/records"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+const imageSKPrefix = "SHARECARD#"
+
+// maxTopSets bounds the exercises listed on a card, so every card fits the
+// same layout
+const maxTopSets = 6
+
+// ContentType is the content type of rendered card images
+const ContentType = "image/png"
+
+// ErrNotCompleted is returned when building a card for a workout that has not
+// been completed
+var ErrNotCompleted = errors.New("only completed workouts have a share card")
+
+// Image statuses
+const (
+	ImagePending = "pending"
+	ImageReady   = "ready"
+	ImageFailed  = "failed"
+)
+
+// TopSet is the best set of an exercise in the workout: the set with the
+// highest estimated one-rep max, or the heaviest set for exercises without one.
+// Record marks a set that is a personal record
+type TopSet struct {
+	Exercise     string  `json:"exercise"`
+	Weight       float64 `json:"weight"`
+	Reps         int     `json:"reps"`
+	Estimated1RM float64 `json:"estimated1rm,omitempty"`
+	Sets         int     `json:"sets"`
+	Record       bool    `json:"record"`
+}
+
+// Card is everything a share image shows about a completed workout, worked
+// out once here so every client draws the same numbers. Volume is in Unit;
+// TopSets lists up to maxTopSets exercises in the order they were performed,
+// and MoreExercises counts the rest
+type Card struct {
+	WorkoutID       string           `json:"workoutId"`
+	CompletedAt     time.Time        `json:"completedAt"`
+	DurationSeconds int              `json:"durationSeconds"`
+	Unit            string           `json:"unit"`
+	Exercises       int              `json:"exercises"`
+	Sets            int              `json:"sets"`
+	Reps            int              `json:"reps"`
+	Volume          float64          `json:"volume"`
+	TopSets         []TopSet         `json:"topSets"`
+	MoreExercises   int              `json:"moreExercises"`
+	Records         []records.Record `json:"records"`
+	Ratings         *workout.Ratings `json:"ratings,omitempty"`
+}
+
+// Build returns the card for w, finding its personal records against history,
+// the user's workouts, which may include w
+func Build(w *workout.Workout, history []workout.Workout, unit string) (*Card, error) {
+	if w.Status != workout.StatusCompleted || w.CompletedAt == nil {
+		return nil, ErrNotCompleted
+	}
+	completed := *w.CompletedAt
+
+	prs := []records.Record{}
+	recorded := map[string]bool{}
+	for _, record := range records.New(history, completed, completed.Add(time.Nanosecond)) {
+		if record.WorkoutID == w.ID {
+			prs = append(prs, record)
+			recorded[record.Exercise] = true
+		}
+	}
+
+	card := &Card{
+		WorkoutID:       w.ID,
+		CompletedAt:     completed,
+		DurationSeconds: max(int(completed.Sub(w.StartedAt).Seconds()), 0),
+		Unit:            unit,
+		TopSets:         []TopSet{},
+		Records:         prs,
+		Ratings:         w.Ratings,
+	}
+	for _, exercise := range w.Exercises {
+		top, ok := topSet(exercise)
+		if !ok {
+			continue
+		}
+		for _, set := range exercise.Sets {
+			if set.Performed() {
+				card.Sets++
+				card.Reps += set.Reps
+				card.Volume += set.Volume()
+			}
+		}
+		card.Exercises++
+		top.Record = recorded[exercise.Name]
+		if len(card.TopSets) < maxTopSets {
+			card.TopSets = append(card.TopSets, top)
+		} else {
+			card.MoreExercises++
+		}
+	}
+	card.Volume = math.Round(card.Volume*10) / 10
+	return card, nil
+}
+
+// topSet returns the best performed set of exercise, or false when none was
+// performed
+func topSet(exercise workout.Exercise) (TopSet, bool) {
+	top := TopSet{Exercise: exercise.Name}
+	found := false
+	for _, set := range exercise.Sets {
+		if !set.Performed() {
+			continue
+		}
+		top.Sets++
+		e1rm := 0.0
+		if set.Estimable() {
+			e1rm = records.EstimatedOneRepMax(set.Load(), set.Reps)
+		}
+		better := e1rm > top.Estimated1RM || (e1rm == top.Estimated1RM && set.Weight > top.Weight)
+		if !found || better {
+			top.Weight, top.Reps, top.Estimated1RM = set.Weight, set.Reps, e1rm
+			found = true
+		}
+	}
+	return top, found
+}
+
+// Image is a request to render a workout's card as a PNG. Each workout has at
+// most one, replaced when the image is rendered again
+type Image struct {
+	WorkoutID   string     `json:"workoutId"`
+	UserID      string     `json:"userId"`
+	Status      string     `json:"status"`
+	JobID       string     `json:"jobId,omitempty"`
+	Key         string     `json:"-"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	DownloadURL string     `json:"downloadUrl,omitempty"`
+}
+
+// storedImage keeps the object key, which is hidden from API responses
+type storedImage struct {
+	Image
+	Key string `json:"key,omitempty"`
+}
+
+// ObjectKey returns where the rendered image is stored
+func (i *Image) ObjectKey() string {
+	return fmt.Sprintf("share-cards/%s/%s.png", i.UserID, i.WorkoutID)
+}
+
+// Repository loads and saves card image requests
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// GetImage returns the image of userID's workout
+func (r *Repository) GetImage(ctx context.Context, userID, workoutID string) (*Image, error) {
+	var stored storedImage
+	if err := r.store.Get(ctx, store.UserPK(userID), imageSKPrefix+workoutID, &stored); err != nil {
+		return nil, err
+	}
+	stored.Image.Key = stored.Key
+	return &stored.Image, nil
+}
+
+// SaveImage stores i, replacing any earlier image of the workout
+func (r *Repository) SaveImage(ctx context.Context, i *Image) error {
+	stored := storedImage{Image: *i, Key: i.Key}
+	stored.DownloadURL = ""
+	if err := r.store.Put(ctx, store.UserPK(i.UserID), imageSKPrefix+i.WorkoutID, stored); err != nil {
+		return fmt.Errorf("failed to save share card image: %w", err)
+	}
+	return nil
+}
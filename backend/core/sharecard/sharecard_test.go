@@ -0,0 +1,164 @@
+package sharecard
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/png"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+func TestBuild(t *testing.T) {
+	completed := func(id string, day int, exercises ...workout.Exercise) workout.Workout {
+		started := time.Date(2024, 3, day, 17, 0, 0, 0, time.UTC)
+		at := started.Add(65 * time.Minute)
+		return workout.Workout{ID: id, Status: workout.StatusCompleted, StartedAt: started, CompletedAt: &at, Exercises: exercises}
+	}
+
+	t.Run("summarizes the workout with its top sets and records", func(t *testing.T) {
+		// Arrange
+		w := completed("w2", 5,
+			workout.Exercise{Name: "Squat", Sets: []workout.Set{{Reps: 5, Weight: 100}, {Reps: 3, Weight: 110}, {Reps: 0, Weight: 120}}},
+			workout.Exercise{Name: "Bench", Sets: []workout.Set{{Reps: 5, Weight: 70}}},
+			workout.Exercise{Name: "Plank", Sets: []workout.Set{{Type: workout.SetDuration, DurationSeconds: 60}}},
+		)
+		history := []workout.Workout{
+			completed("w1", 1,
+				workout.Exercise{Name: "Squat", Sets: []workout.Set{{Reps: 5, Weight: 100}}},
+				workout.Exercise{Name: "Bench", Sets: []workout.Set{{Reps: 5, Weight: 80}}},
+			),
+			w,
+		}
+
+		// Act
+		card, err := Build(&w, history, "kg")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if card.DurationSeconds != 3900 || card.Exercises != 3 || card.Sets != 4 || card.Reps != 13 || card.Volume != 1180 {
+			t.Errorf("unexpected totals: %+v", card)
+		}
+		squat := card.TopSets[0]
+		if squat.Weight != 110 || squat.Reps != 3 || squat.Sets != 2 || !squat.Record {
+			t.Errorf("unexpected squat top set: %+v", squat)
+		}
+		if card.TopSets[1].Record || card.TopSets[2].Exercise != "Plank" {
+			t.Errorf("unexpected top sets: %+v", card.TopSets)
+		}
+		if len(card.Records) != 1 || card.Records[0].Exercise != "Squat" {
+			t.Errorf("expected only the squat record, got %+v", card.Records)
+		}
+	})
+
+	t.Run("lists a limited number of exercises", func(t *testing.T) {
+		// Arrange
+		var exercises []workout.Exercise
+		for _, name := range []string{"A", "B", "C", "D", "E", "F", "G", "H"} {
+			exercises = append(exercises, workout.Exercise{Name: name, Sets: []workout.Set{{Reps: 10, Weight: 20}}})
+		}
+		w := completed("w1", 1, exercises...)
+
+		// Act
+		card, _ := Build(&w, nil, "kg")
+
+		// Assert
+		if len(card.TopSets) != maxTopSets || card.MoreExercises != 2 {
+			t.Errorf("expected %d top sets and 2 more, got %d and %d", maxTopSets, len(card.TopSets), card.MoreExercises)
+		}
+	})
+
+	t.Run("rejects workouts that are not completed", func(t *testing.T) {
+		// Arrange
+		w := workout.Workout{ID: "w1", Status: workout.StatusActive}
+
+		// Act
+		_, err := Build(&w, nil, "kg")
+
+		// Assert
+		if !errors.Is(err, ErrNotCompleted) {
+			t.Errorf("expected ErrNotCompleted, got %v", err)
+		}
+	})
+}
+
+func TestRender(t *testing.T) {
+	// Arrange
+	card := &Card{
+		CompletedAt:     time.Date(2024, 3, 5, 18, 5, 0, 0, time.UTC),
+		DurationSeconds: 3900,
+		Unit:            "kg",
+		Sets:            12,
+		Volume:          8450.5,
+		TopSets:         []TopSet{{Exercise: "Romanian Deadlift With A Very Long Name", Weight: 102.5, Reps: 8, Sets: 3, Record: true}},
+		MoreExercises:   3,
+	}
+
+	// Act
+	data, err := Render(card)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("expected a PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != imageSize || b.Dy() != imageSize {
+		t.Errorf("expected a %dx%d image, got %v", imageSize, imageSize, b)
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	if got := duration(3900); got != "1H 05M" {
+		t.Errorf("duration(3900) = %q", got)
+	}
+	if got := duration(2700); got != "45M" {
+		t.Errorf("duration(2700) = %q", got)
+	}
+	if got := setDetail(TopSet{Weight: 102.5, Reps: 8}, "kg"); got != "102.5 kg X 8" {
+		t.Errorf("setDetail = %q", got)
+	}
+	if got := truncate("Romanian Deadlift", width("Romanian", 5), 5); got != "Roman..." {
+		t.Errorf("truncate = %q", got)
+	}
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("keeps the object key out of responses", func(t *testing.T) {
+		// Arrange
+		repo := NewRepository(store.NewMemoryStore())
+		image := &Image{WorkoutID: "w1", UserID: "user-1", Status: ImageReady, DownloadURL: "https://example.com"}
+		image.Key = image.ObjectKey()
+
+		// Act
+		err := repo.SaveImage(ctx, image)
+		loaded, _ := repo.GetImage(ctx, "user-1", "w1")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if loaded.Key != "share-cards/user-1/w1.png" || loaded.DownloadURL != "" {
+			t.Errorf("unexpected image: %+v", loaded)
+		}
+	})
+
+	t.Run("reports missing images", func(t *testing.T) {
+		// Act
+		_, err := NewRepository(store.NewMemoryStore()).GetImage(ctx, "user-1", "w1")
+
+		// Assert
+		if !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+}
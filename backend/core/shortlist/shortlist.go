@@ -0,0 +1,247 @@
+package shortlist
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"athlete-forge/exercise"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+const (
+	shortlistSKPrefix = "EXERCISES#"
+	recentSK          = shortlistSKPrefix + "RECENT"
+	favoritesSK       = shortlistSKPrefix + "FAVORITES"
+)
+
+// MaxRecent is how many recently used exercises are kept, and MaxFavorites how
+// many exercises a user can favorite
+const (
+	MaxRecent    = 20
+	MaxFavorites = 100
+)
+
+// ErrTooManyFavorites is returned when favoriting more than MaxFavorites
+// exercises
+var ErrTooManyFavorites = fmt.Errorf("at most %d exercises can be favorites", MaxFavorites)
+
+// Use is when a catalog exercise was last trained
+type Use struct {
+	Exercise string    `json:"exercise"`
+	UsedAt   time.Time `json:"usedAt"`
+}
+
+// Favorite is a catalog exercise the user has favorited
+type Favorite struct {
+	Exercise string    `json:"exercise"`
+	AddedAt  time.Time `json:"addedAt"`
+}
+
+// Shortlist is the user's favorite exercises, most recently added first, and
+// recently used exercises, most recently used first
+type Shortlist struct {
+	Favorites []Favorite `json:"favorites"`
+	Recent    []Use      `json:"recent"`
+}
+
+// recent and favorites are stored as separate items, so recording a use from a
+// workout write never overwrites a favorite added at the same time
+type recent struct {
+	Uses []Use `json:"uses"`
+}
+
+type favorites struct {
+	Exercises []Favorite `json:"exercises"`
+}
+
+// Uses returns the catalog exercises w trained, each at the time w was
+// completed or else started. Planned workouts train nothing yet, and exercises
+// outside the catalog are left out since search only returns the catalog
+func Uses(w workout.Workout) []Use {
+	if w.Status == workout.StatusPlanned || w.StartedAt.IsZero() {
+		return nil
+	}
+	at := w.StartedAt
+	if w.CompletedAt != nil {
+		at = *w.CompletedAt
+	}
+	uses := []Use{}
+	seen := map[string]bool{}
+	for _, e := range w.Exercises {
+		if known, ok := exercise.Lookup(e.Name); ok && !seen[known.Name] {
+			seen[known.Name] = true
+			uses = append(uses, Use{Exercise: known.Name, UsedAt: at})
+		}
+	}
+	return uses
+}
+
+// Covers reports whether every use in uses is in s's recent exercises at the
+// same or a later time, so recording them would change nothing
+func (s *Shortlist) Covers(uses []Use) bool {
+	for _, u := range uses {
+		last := s.LastUsed(u.Exercise)
+		if last == nil || last.Before(u.UsedAt) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsFavorite reports whether name is one of s's favorites
+func (s *Shortlist) IsFavorite(name string) bool {
+	for _, f := range s.Favorites {
+		if f.Exercise == name {
+			return true
+		}
+	}
+	return false
+}
+
+// LastUsed returns when name was last used, or nil when it is not recent
+func (s *Shortlist) LastUsed(name string) *time.Time {
+	for _, u := range s.Recent {
+		if u.Exercise == name {
+			at := u.UsedAt
+			return &at
+		}
+	}
+	return nil
+}
+
+// Sort orders exercises for the user: favorites first, then recently used
+// exercises, most recent first, then the rest in their current order
+func (s *Shortlist) Sort(exercises []exercise.Exercise) {
+	rank := map[string]int{}
+	for _, f := range s.Favorites {
+		rank[f.Exercise] = -len(s.Recent) - 1
+	}
+	for i, u := range s.Recent {
+		if _, ok := rank[u.Exercise]; !ok {
+			rank[u.Exercise] = i - len(s.Recent)
+		}
+	}
+	sort.SliceStable(exercises, func(i, j int) bool {
+		return rank[exercises[i].Name] < rank[exercises[j].Name]
+	})
+}
+
+// Repository stores each user's shortlist as two small items in their
+// partition, read together with one query
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Get returns userID's shortlist, empty when they have none
+func (r *Repository) Get(ctx context.Context, userID string) (*Shortlist, error) {
+	items, err := r.store.Query(ctx, store.UserPK(userID), shortlistSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load exercise shortlist: %w", err)
+	}
+	s := &Shortlist{Favorites: []Favorite{}, Recent: []Use{}}
+	for _, item := range items {
+		switch item.SK {
+		case recentSK:
+			var r recent
+			if err := item.Decode(&r); err != nil {
+				return nil, err
+			}
+			s.Recent = append(s.Recent, r.Uses...)
+		case favoritesSK:
+			var f favorites
+			if err := item.Decode(&f); err != nil {
+				return nil, err
+			}
+			s.Favorites = append(s.Favorites, f.Exercises...)
+		}
+	}
+	return s, nil
+}
+
+// RecordUses merges uses into userID's recent exercises, keeping each
+// exercise's latest use and only the MaxRecent most recent. Recording the same
+// uses again changes nothing, so it is safe to retry, and nothing is written
+// when nothing changes
+func (r *Repository) RecordUses(ctx context.Context, userID string, uses []Use) error {
+	s, err := r.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if s.Covers(uses) {
+		return nil
+	}
+
+	latest := map[string]time.Time{}
+	for _, u := range append(s.Recent, uses...) {
+		if at, ok := latest[u.Exercise]; !ok || u.UsedAt.After(at) {
+			latest[u.Exercise] = u.UsedAt
+		}
+	}
+	merged := make([]Use, 0, len(latest))
+	for name, at := range latest {
+		merged = append(merged, Use{Exercise: name, UsedAt: at})
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		if !merged[i].UsedAt.Equal(merged[j].UsedAt) {
+			return merged[i].UsedAt.After(merged[j].UsedAt)
+		}
+		return merged[i].Exercise < merged[j].Exercise
+	})
+	if len(merged) > MaxRecent {
+		merged = merged[:MaxRecent]
+	}
+	if err := r.store.Put(ctx, store.UserPK(userID), recentSK, recent{Uses: merged}); err != nil {
+		return fmt.Errorf("failed to save recent exercises: %w", err)
+	}
+	return nil
+}
+
+// AddFavorite favorites the catalog exercise name for userID, returning their
+// favorites; favoriting an exercise twice keeps it where it was
+func (r *Repository) AddFavorite(ctx context.Context, userID, name string, now time.Time) ([]Favorite, error) {
+	s, err := r.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if s.IsFavorite(name) {
+		return s.Favorites, nil
+	}
+	if len(s.Favorites) >= MaxFavorites {
+		return nil, ErrTooManyFavorites
+	}
+	list := append([]Favorite{{Exercise: name, AddedAt: now}}, s.Favorites...)
+	return list, r.saveFavorites(ctx, userID, list)
+}
+
+// RemoveFavorite unfavorites name for userID, returning their favorites
+func (r *Repository) RemoveFavorite(ctx context.Context, userID, name string) ([]Favorite, error) {
+	s, err := r.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	list := []Favorite{}
+	for _, f := range s.Favorites {
+		if f.Exercise != name {
+			list = append(list, f)
+		}
+	}
+	if len(list) == len(s.Favorites) {
+		return list, nil
+	}
+	return list, r.saveFavorites(ctx, userID, list)
+}
+
+func (r *Repository) saveFavorites(ctx context.Context, userID string, list []Favorite) error {
+	if err := r.store.Put(ctx, store.UserPK(userID), favoritesSK, favorites{Exercises: list}); err != nil {
+		return fmt.Errorf("failed to save favorite exercises: %w", err)
+	}
+	return nil
+}
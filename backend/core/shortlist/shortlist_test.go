@@ -0,0 +1,162 @@
+package shortlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"athlete-forge/exercise"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+func TestUses(t *testing.T) {
+	started := time.Date(2026, 5, 4, 18, 0, 0, 0, time.UTC)
+	completed := started.Add(time.Hour)
+
+	t.Run("uses each catalog exercise at the completion time", func(t *testing.T) {
+		// Arrange
+		w := workout.Workout{Status: workout.StatusCompleted, StartedAt: started, CompletedAt: &completed, Exercises: []workout.Exercise{
+			{Name: "Squat"}, {Name: "my own lift"}, {Name: "squat"}, {Name: "Bench Press"},
+		}}
+
+		// Act
+		uses := Uses(w)
+
+		// Assert
+		expected := []Use{{Exercise: "squat", UsedAt: completed}, {Exercise: "bench press", UsedAt: completed}}
+		if !reflect.DeepEqual(uses, expected) {
+			t.Errorf("expected %+v, got %+v", expected, uses)
+		}
+	})
+
+	t.Run("uses nothing in a planned workout", func(t *testing.T) {
+		// Arrange
+		w := workout.Workout{Status: workout.StatusPlanned, ScheduledAt: &started, Exercises: []workout.Exercise{{Name: "squat"}}}
+
+		// Act
+		uses := Uses(w)
+
+		// Assert
+		if len(uses) != 0 {
+			t.Errorf("expected no uses, got %+v", uses)
+		}
+	})
+}
+
+func TestShortlist_Sort(t *testing.T) {
+	t.Run("puts favorites first, then recent exercises", func(t *testing.T) {
+		// Arrange
+		now := time.Now().UTC()
+		s := &Shortlist{
+			Favorites: []Favorite{{Exercise: "lunge", AddedAt: now}},
+			Recent:    []Use{{Exercise: "squat", UsedAt: now}, {Exercise: "lunge", UsedAt: now}, {Exercise: "deadlift", UsedAt: now.Add(-time.Hour)}},
+		}
+		exercises := []exercise.Exercise{}
+		for _, name := range []string{"bench press", "deadlift", "lunge", "row", "squat"} {
+			e, _ := exercise.Lookup(name)
+			exercises = append(exercises, e)
+		}
+
+		// Act
+		s.Sort(exercises)
+
+		// Assert
+		names := []string{}
+		for _, e := range exercises {
+			names = append(names, e.Name)
+		}
+		expected := []string{"lunge", "squat", "deadlift", "bench press", "row"}
+		if !reflect.DeepEqual(names, expected) {
+			t.Errorf("expected %v, got %v", expected, names)
+		}
+	})
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 5, 4, 18, 0, 0, 0, time.UTC)
+
+	t.Run("keeps the latest use of the most recent exercises", func(t *testing.T) {
+		// Arrange
+		r := NewRepository(store.NewMemoryStore())
+		r.RecordUses(ctx, "user-1", []Use{{Exercise: "squat", UsedAt: now}, {Exercise: "deadlift", UsedAt: now.Add(-time.Hour)}})
+
+		// Act
+		err := r.RecordUses(ctx, "user-1", []Use{{Exercise: "squat", UsedAt: now.Add(-24 * time.Hour)}, {Exercise: "deadlift", UsedAt: now.Add(time.Hour)}})
+		s, _ := r.Get(ctx, "user-1")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := []Use{{Exercise: "deadlift", UsedAt: now.Add(time.Hour)}, {Exercise: "squat", UsedAt: now}}
+		if !reflect.DeepEqual(s.Recent, expected) {
+			t.Errorf("expected %+v, got %+v", expected, s.Recent)
+		}
+	})
+
+	t.Run("keeps at most MaxRecent exercises", func(t *testing.T) {
+		// Arrange
+		r := NewRepository(store.NewMemoryStore())
+		uses := []Use{}
+		for i := 0; i < MaxRecent+5; i++ {
+			uses = append(uses, Use{Exercise: fmt.Sprintf("exercise %02d", i), UsedAt: now.Add(time.Duration(i) * time.Minute)})
+		}
+
+		// Act
+		r.RecordUses(ctx, "user-1", uses)
+		s, _ := r.Get(ctx, "user-1")
+
+		// Assert
+		if len(s.Recent) != MaxRecent || s.Recent[0].Exercise != "exercise 24" {
+			t.Errorf("expected the %d most recent, got %+v", MaxRecent, s.Recent)
+		}
+	})
+
+	t.Run("adds and removes favorites without touching recent exercises", func(t *testing.T) {
+		// Arrange
+		r := NewRepository(store.NewMemoryStore())
+		r.RecordUses(ctx, "user-1", []Use{{Exercise: "squat", UsedAt: now}})
+
+		// Act
+		r.AddFavorite(ctx, "user-1", "squat", now)
+		r.AddFavorite(ctx, "user-1", "lunge", now.Add(time.Minute))
+		again, _ := r.AddFavorite(ctx, "user-1", "squat", now.Add(2*time.Minute))
+		removed, err := r.RemoveFavorite(ctx, "user-1", "squat")
+		s, _ := r.Get(ctx, "user-1")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(again) != 2 || again[0].Exercise != "lunge" || !again[1].AddedAt.Equal(now) {
+			t.Errorf("expected favoriting twice to keep the favorite, got %+v", again)
+		}
+		if !reflect.DeepEqual(removed, s.Favorites) || len(removed) != 1 || removed[0].Exercise != "lunge" {
+			t.Errorf("expected only lunge left, got %+v", s.Favorites)
+		}
+		if len(s.Recent) != 1 {
+			t.Errorf("expected recent exercises kept, got %+v", s.Recent)
+		}
+	})
+
+	t.Run("limits the number of favorites", func(t *testing.T) {
+		// Arrange
+		r := NewRepository(store.NewMemoryStore())
+		for i := 0; i < MaxFavorites; i++ {
+			r.AddFavorite(ctx, "user-1", fmt.Sprintf("exercise %03d", i), now)
+		}
+
+		// Act
+		_, err := r.AddFavorite(ctx, "user-1", "squat", now)
+
+		// Assert
+		if !errors.Is(err, ErrTooManyFavorites) {
+			t.Errorf("expected ErrTooManyFavorites, got %v", err)
+		}
+	})
+}
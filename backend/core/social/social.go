@@ -0,0 +1,196 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"athlete-forge/store"
+)
+
+// Kinds of relation a user can have to another
+const (
+	KindBlock = "block"
+	KindMute  = "mute"
+)
+
+// Relations are stored under one sort key prefix, so a viewer's filter is read
+// with a single query
+const (
+	relationSKPrefix  = "SOCIAL#"
+	blockSKPrefix     = relationSKPrefix + "BLOCK#"
+	muteSKPrefix      = relationSKPrefix + "MUTE#"
+	blockedBySKPrefix = relationSKPrefix + "BLOCKED_BY#"
+)
+
+// ErrSelf is returned when a user blocks or mutes themselves
+var ErrSelf = errors.New("cannot block or mute yourself")
+
+// Relation is a user another user has blocked or muted
+type Relation struct {
+	UserID    string    `json:"userId"`
+	Kind      string    `json:"kind"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// skPrefix returns the sort key prefix relations of kind are stored under
+func skPrefix(kind string) (string, error) {
+	switch kind {
+	case KindBlock:
+		return blockSKPrefix, nil
+	case KindMute:
+		return muteSKPrefix, nil
+	}
+	return "", fmt.Errorf("unknown relation %q", kind)
+}
+
+// Filter is who a viewer should not see content from. Every social read,
+// such as marketplace listings and reviews, goes through one, so a block or
+// mute applies everywhere at once
+type Filter struct {
+	hidden map[string]bool
+}
+
+// Hides reports whether content by userID is kept from the viewer
+func (f Filter) Hides(userID string) bool {
+	return f.hidden[userID]
+}
+
+// Graph stores the blocks and mutes between users. A block hides each user
+// from the other, so it is kept under both: the blocker's BLOCK# item and the
+// blocked user's BLOCKED_BY# item. A mute only hides the muted user from the
+// one who muted them, and is only kept under the latter
+type Graph struct {
+	store store.Store
+}
+
+// NewGraph creates a Graph backed by s
+func NewGraph(s store.Store) *Graph {
+	return &Graph{store: s}
+}
+
+// Add has userID block or mute targetID from now; adding an existing relation
+// keeps its original date
+func (g *Graph) Add(ctx context.Context, userID, targetID, kind string, now time.Time) (*Relation, error) {
+	prefix, err := skPrefix(kind)
+	if err != nil {
+		return nil, err
+	}
+	if userID == targetID {
+		return nil, ErrSelf
+	}
+	var existing Relation
+	err = g.store.Get(ctx, store.UserPK(userID), prefix+targetID, &existing)
+	if err == nil {
+		return &existing, nil
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("failed to load %s: %w", kind, err)
+	}
+
+	rel := &Relation{UserID: targetID, Kind: kind, CreatedAt: now}
+	if kind == KindBlock {
+		// The reverse item is written first, so a failure never leaves a block
+		// that only hides in one direction
+		reverse := Relation{UserID: userID, Kind: kind, CreatedAt: now}
+		if err := g.store.Put(ctx, store.UserPK(targetID), blockedBySKPrefix+userID, reverse); err != nil {
+			return nil, fmt.Errorf("failed to save block: %w", err)
+		}
+	}
+	if err := g.store.Put(ctx, store.UserPK(userID), prefix+targetID, rel); err != nil {
+		return nil, fmt.Errorf("failed to save %s: %w", kind, err)
+	}
+	return rel, nil
+}
+
+// Remove lifts userID's block or mute of targetID, returning it, or
+// store.ErrNotFound when there is none
+func (g *Graph) Remove(ctx context.Context, userID, targetID, kind string) (*Relation, error) {
+	prefix, err := skPrefix(kind)
+	if err != nil {
+		return nil, err
+	}
+	var rel Relation
+	if err := g.store.Get(ctx, store.UserPK(userID), prefix+targetID, &rel); err != nil {
+		return nil, err
+	}
+	if err := g.store.Delete(ctx, store.UserPK(userID), prefix+targetID); err != nil {
+		return nil, fmt.Errorf("failed to remove %s: %w", kind, err)
+	}
+	if kind == KindBlock {
+		if err := g.store.Delete(ctx, store.UserPK(targetID), blockedBySKPrefix+userID); err != nil {
+			return nil, fmt.Errorf("failed to remove block: %w", err)
+		}
+	}
+	return &rel, nil
+}
+
+// List returns the users userID has blocked or muted, most recent first
+func (g *Graph) List(ctx context.Context, userID, kind string) ([]Relation, error) {
+	prefix, err := skPrefix(kind)
+	if err != nil {
+		return nil, err
+	}
+	relations, err := g.query(ctx, userID, prefix)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(relations, func(i, j int) bool { return relations[i].CreatedAt.After(relations[j].CreatedAt) })
+	return relations, nil
+}
+
+// Blocked reports whether either of two users has blocked the other
+func (g *Graph) Blocked(ctx context.Context, userID, otherID string) (bool, error) {
+	for _, sk := range []string{blockSKPrefix + otherID, blockedBySKPrefix + otherID} {
+		var rel Relation
+		err := g.store.Get(ctx, store.UserPK(userID), sk, &rel)
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, store.ErrNotFound) {
+			return false, fmt.Errorf("failed to load block: %w", err)
+		}
+	}
+	return false, nil
+}
+
+// Filter returns who viewerID should not see: the users they blocked or muted
+// and the users who blocked them. Anonymous viewers see everyone
+func (g *Graph) Filter(ctx context.Context, viewerID string) (Filter, error) {
+	f := Filter{hidden: map[string]bool{}}
+	if viewerID == "" {
+		return f, nil
+	}
+	items, err := g.store.Query(ctx, store.UserPK(viewerID), relationSKPrefix)
+	if err != nil {
+		return f, fmt.Errorf("failed to load blocks: %w", err)
+	}
+	for _, item := range items {
+		for _, prefix := range []string{blockSKPrefix, muteSKPrefix, blockedBySKPrefix} {
+			if userID, ok := strings.CutPrefix(item.SK, prefix); ok {
+				f.hidden[userID] = true
+			}
+		}
+	}
+	return f, nil
+}
+
+// query returns the relations stored under userID's prefix
+func (g *Graph) query(ctx context.Context, userID, prefix string) ([]Relation, error) {
+	items, err := g.store.Query(ctx, store.UserPK(userID), prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list relations: %w", err)
+	}
+	relations := []Relation{}
+	for _, item := range items {
+		var rel Relation
+		if err := item.Decode(&rel); err != nil {
+			return nil, err
+		}
+		relations = append(relations, rel)
+	}
+	return relations, nil
+}
@@ -0,0 +1,90 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+)
+
+func TestGraph(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+
+	t.Run("a block hides both users from each other", func(t *testing.T) {
+		// Arrange
+		g := NewGraph(store.NewMemoryStore())
+
+		// Act
+		g.Add(ctx, "user-1", "user-2", KindBlock, now)
+		blocker, _ := g.Filter(ctx, "user-1")
+		blocked, _ := g.Filter(ctx, "user-2")
+		other, _ := g.Filter(ctx, "user-3")
+
+		// Assert
+		if !blocker.Hides("user-2") || !blocked.Hides("user-1") {
+			t.Error("expected each user to be hidden from the other")
+		}
+		if other.Hides("user-1") || other.Hides("user-2") {
+			t.Error("expected other users to see both")
+		}
+	})
+
+	t.Run("a mute only hides the muted user from the muter", func(t *testing.T) {
+		// Arrange
+		g := NewGraph(store.NewMemoryStore())
+
+		// Act
+		g.Add(ctx, "user-1", "user-2", KindMute, now)
+		muter, _ := g.Filter(ctx, "user-1")
+		muted, _ := g.Filter(ctx, "user-2")
+		blocked, _ := g.Blocked(ctx, "user-1", "user-2")
+
+		// Assert
+		if !muter.Hides("user-2") || muted.Hides("user-1") || blocked {
+			t.Error("expected the mute to hide user-2 from user-1 only")
+		}
+	})
+
+	t.Run("removing a block lifts it in both directions", func(t *testing.T) {
+		// Arrange
+		g := NewGraph(store.NewMemoryStore())
+		g.Add(ctx, "user-1", "user-2", KindBlock, now)
+
+		// Act
+		_, err := g.Remove(ctx, "user-1", "user-2", KindBlock)
+		blocked, _ := g.Blocked(ctx, "user-2", "user-1")
+		_, againErr := g.Remove(ctx, "user-1", "user-2", KindBlock)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if blocked {
+			t.Error("expected the block to be lifted")
+		}
+		if !errors.Is(againErr, store.ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", againErr)
+		}
+	})
+
+	t.Run("keeps the original date of a repeated block", func(t *testing.T) {
+		// Arrange
+		g := NewGraph(store.NewMemoryStore())
+		g.Add(ctx, "user-1", "user-2", KindBlock, now)
+
+		// Act
+		rel, _ := g.Add(ctx, "user-1", "user-2", KindBlock, now.Add(time.Hour))
+		_, selfErr := g.Add(ctx, "user-1", "user-1", KindMute, now)
+
+		// Assert
+		if !rel.CreatedAt.Equal(now) {
+			t.Errorf("expected the original date, got %v", rel.CreatedAt)
+		}
+		if !errors.Is(selfErr, ErrSelf) {
+			t.Errorf("expected ErrSelf, got %v", selfErr)
+		}
+	})
+}
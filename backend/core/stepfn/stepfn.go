@@ -0,0 +1,63 @@
+package stepfn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"athlete-forge/awsapi"
+)
+
+// Callbacks reports on a task started with the .waitForTaskToken integration,
+// where the state machine waits for the task token rather than the invocation
+type Callbacks interface {
+	// Heartbeat tells the state machine the task is still running, resetting its
+	// heartbeat timeout
+	Heartbeat(ctx context.Context, token string) error
+	// Succeed completes the task with output as its result
+	Succeed(ctx context.Context, token string, output interface{}) error
+	// Fail completes the task with an error the state machine can catch or retry
+	Fail(ctx context.Context, token, code, cause string) error
+}
+
+// Client sends task callbacks through the Step Functions API
+type Client struct {
+	client *awsapi.Client
+}
+
+// NewClient creates a Client
+func NewClient(client *awsapi.Client) *Client {
+	return &Client{client: client}
+}
+
+// Heartbeat calls SendTaskHeartbeat
+func (c *Client) Heartbeat(ctx context.Context, token string) error {
+	in := map[string]string{"taskToken": token}
+	if err := c.client.Call(ctx, awsapi.StepFunctions, "SendTaskHeartbeat", in, nil); err != nil {
+		return fmt.Errorf("failed to send task heartbeat: %w", err)
+	}
+	return nil
+}
+
+// Succeed calls SendTaskSuccess with output encoded as JSON
+func (c *Client) Succeed(ctx context.Context, token string, output interface{}) error {
+	body, err := json.Marshal(output)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task output: %w", err)
+	}
+
+	in := map[string]string{"taskToken": token, "output": string(body)}
+	if err := c.client.Call(ctx, awsapi.StepFunctions, "SendTaskSuccess", in, nil); err != nil {
+		return fmt.Errorf("failed to send task success: %w", err)
+	}
+	return nil
+}
+
+// Fail calls SendTaskFailure
+func (c *Client) Fail(ctx context.Context, token, code, cause string) error {
+	in := map[string]string{"taskToken": token, "error": code, "cause": cause}
+	if err := c.client.Call(ctx, awsapi.StepFunctions, "SendTaskFailure", in, nil); err != nil {
+		return fmt.Errorf("failed to send task failure: %w", err)
+	}
+	return nil
+}
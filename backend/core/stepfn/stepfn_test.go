@@ -0,0 +1,73 @@
+package stepfn
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"athlete-forge/awsapi"
+)
+
+func TestClient(t *testing.T) {
+	newClient := func(t *testing.T, target, body *string) *Client {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*target = r.Header.Get("X-Amz-Target")
+			data, _ := io.ReadAll(r.Body)
+			*body = string(data)
+			w.Write([]byte(`{}`))
+		}))
+		t.Cleanup(server.Close)
+		client := awsapi.NewClient("eu-west-2", awsapi.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"})
+		client.Endpoint = func(string) string { return server.URL }
+		return NewClient(client)
+	}
+
+	tests := []struct {
+		name       string
+		call       func(c *Client) error
+		wantTarget string
+		wantBody   string
+	}{
+		{
+			name:       "sends heartbeats",
+			call:       func(c *Client) error { return c.Heartbeat(context.Background(), "token-1") },
+			wantTarget: "AWSStepFunctions.SendTaskHeartbeat",
+			wantBody:   `{"taskToken":"token-1"}`,
+		},
+		{
+			name: "sends the output as JSON on success",
+			call: func(c *Client) error {
+				return c.Succeed(context.Background(), "token-1", map[string]bool{"done": true})
+			},
+			wantTarget: "AWSStepFunctions.SendTaskSuccess",
+			wantBody:   `{"output":"{\"done\":true}","taskToken":"token-1"}`,
+		},
+		{
+			name:       "sends the error and cause on failure",
+			call:       func(c *Client) error { return c.Fail(context.Background(), "token-1", "TaskFailed", "boom") },
+			wantTarget: "AWSStepFunctions.SendTaskFailure",
+			wantBody:   `{"cause":"boom","error":"TaskFailed","taskToken":"token-1"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var target, body string
+			c := newClient(t, &target, &body)
+
+			// Act
+			err := tt.call(c)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if target != tt.wantTarget || body != tt.wantBody {
+				t.Errorf("unexpected request: %s %s", target, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Cache holds serialized reads for a CachedStore, such as an ElastiCache Redis
+// cluster shared by every function instance
+type Cache interface {
+	// Get returns the value at key and whether it was present
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Set stores value at key, expiring it after ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// Delete removes keys; deleting a missing key is not an error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// absent is cached for items that do not exist, so users without a saved item
+// do not miss the cache on every read; no stored document marshals to null
+var absent = []byte("null")
+
+// CachedStore is a read-through cache in front of a Store for hot items. Gets of
+// items whose sort key begins with one of its prefixes, and queries for exactly
+// one of those prefixes, are served from the cache; writes through the CachedStore
+// invalidate them. Writes that bypass it, such as replication from another region,
+// are seen once the cached entry expires
+type CachedStore struct {
+	store    Store
+	cache    Cache
+	ttl      time.Duration
+	prefixes []string
+}
+
+// NewCachedStore caches reads from s of items under skPrefixes in c for up to ttl
+func NewCachedStore(s Store, c Cache, ttl time.Duration, skPrefixes ...string) *CachedStore {
+	return &CachedStore{store: s, cache: c, ttl: ttl, prefixes: skPrefixes}
+}
+
+// Get loads the item at pk/sk into out, from the cache when it holds it
+func (c *CachedStore) Get(ctx context.Context, pk, sk string, out interface{}) error {
+	if !c.caches(sk) {
+		return c.store.Get(ctx, pk, sk, out)
+	}
+
+	key := itemKey(pk, sk)
+	if data, ok, err := c.cache.Get(ctx, key); err == nil && ok {
+		if string(data) == string(absent) {
+			return ErrNotFound
+		}
+		return Item{PK: pk, SK: sk, Data: data}.Decode(out)
+	}
+
+	var data json.RawMessage
+	err := c.store.Get(ctx, pk, sk, &data)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		c.cache.Set(ctx, key, absent, c.ttl)
+		return err
+	case err != nil:
+		return err
+	}
+	c.cache.Set(ctx, key, data, c.ttl)
+	return Item{PK: pk, SK: sk, Data: data}.Decode(out)
+}
+
+// Put writes v at pk/sk and invalidates the cached reads it changes
+func (c *CachedStore) Put(ctx context.Context, pk, sk string, v interface{}) error {
+	if err := c.store.Put(ctx, pk, sk, v); err != nil {
+		return err
+	}
+	return c.invalidate(ctx, pk, sk)
+}
+
+// Query returns items under pk whose sort key begins with skPrefix, from the cache
+// when it holds them
+func (c *CachedStore) Query(ctx context.Context, pk, skPrefix string) ([]Item, error) {
+	if !c.cachesQuery(skPrefix) {
+		return c.store.Query(ctx, pk, skPrefix)
+	}
+
+	key := queryKey(pk, skPrefix)
+	if data, ok, err := c.cache.Get(ctx, key); err == nil && ok {
+		var items []Item
+		if err := json.Unmarshal(data, &items); err == nil {
+			return items, nil
+		}
+	}
+
+	items, err := c.store.Query(ctx, pk, skPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if data, err := json.Marshal(items); err == nil {
+		c.cache.Set(ctx, key, data, c.ttl)
+	}
+	return items, nil
+}
+
+// Create writes v at pk/sk unless an item is already there, and invalidates
+// the cached reads it changes
+func (c *CachedStore) Create(ctx context.Context, pk, sk string, v interface{}) error {
+	if err := Create(ctx, c.store, pk, sk, v); err != nil {
+		return err
+	}
+	return c.invalidate(ctx, pk, sk)
+}
+
+// Delete removes the item at pk/sk and invalidates the cached reads it changes
+func (c *CachedStore) Delete(ctx context.Context, pk, sk string) error {
+	if err := c.store.Delete(ctx, pk, sk); err != nil {
+		return err
+	}
+	return c.invalidate(ctx, pk, sk)
+}
+
+// BatchPut writes each item and invalidates the cached reads they change
+func (c *CachedStore) BatchPut(ctx context.Context, writes []Write) error {
+	if err := BatchPut(ctx, c.store, writes); err != nil {
+		return err
+	}
+	for _, w := range writes {
+		if err := c.invalidate(ctx, w.PK, w.SK); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BatchGet returns the items at keys that exist, always from the store; batch
+// reads are already a single round trip and would otherwise need a cache read
+// per key
+func (c *CachedStore) BatchGet(ctx context.Context, keys []Key) ([]Item, error) {
+	return c.store.BatchGet(ctx, keys)
+}
+
+// invalidate drops the cached item at pk/sk and the cached queries that include
+// it. A failure is returned although the write succeeded, since the cache would
+// otherwise serve the old item until it expired; repeating the write is safe
+func (c *CachedStore) invalidate(ctx context.Context, pk, sk string) error {
+	var keys []string
+	for _, prefix := range c.prefixes {
+		if strings.HasPrefix(sk, prefix) {
+			keys = append(keys, queryKey(pk, prefix))
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	keys = append(keys, itemKey(pk, sk))
+	if err := c.cache.Delete(ctx, keys...); err != nil {
+		return fmt.Errorf("failed to invalidate cached item %s/%s: %w", pk, sk, err)
+	}
+	return nil
+}
+
+// caches reports whether gets of sk are cached
+func (c *CachedStore) caches(sk string) bool {
+	for _, prefix := range c.prefixes {
+		if strings.HasPrefix(sk, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// cachesQuery reports whether queries for skPrefix are cached; only the configured
+// prefixes themselves are, so a write knows every cached query it changes
+func (c *CachedStore) cachesQuery(skPrefix string) bool {
+	for _, prefix := range c.prefixes {
+		if skPrefix == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+func itemKey(pk, sk string) string {
+	return "item|" + pk + "|" + sk
+}
+
+func queryKey(pk, skPrefix string) string {
+	return "query|" + pk + "|" + skPrefix
+}
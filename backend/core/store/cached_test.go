@@ -0,0 +1,186 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// mapCache is an in-process Cache that can be made to fail
+type mapCache struct {
+	values map[string][]byte
+	err    error
+}
+
+func (m *mapCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if m.err != nil {
+		return nil, false, m.err
+	}
+	value, ok := m.values[key]
+	return value, ok, nil
+}
+
+func (m *mapCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.values[key] = value
+	return nil
+}
+
+func (m *mapCache) Delete(ctx context.Context, keys ...string) error {
+	if m.err != nil {
+		return m.err
+	}
+	for _, key := range keys {
+		delete(m.values, key)
+	}
+	return nil
+}
+
+// countingStore counts the reads that reach the underlying store
+type countingStore struct {
+	*MemoryStore
+	reads int
+}
+
+func (c *countingStore) Get(ctx context.Context, pk, sk string, out interface{}) error {
+	c.reads++
+	return c.MemoryStore.Get(ctx, pk, sk, out)
+}
+
+func (c *countingStore) Query(ctx context.Context, pk, skPrefix string) ([]Item, error) {
+	c.reads++
+	return c.MemoryStore.Query(ctx, pk, skPrefix)
+}
+
+func TestCachedStore(t *testing.T) {
+	ctx := context.Background()
+	setup := func() (*CachedStore, *countingStore, *mapCache) {
+		backing := &countingStore{MemoryStore: NewMemoryStore()}
+		c := &mapCache{values: map[string][]byte{}}
+		return NewCachedStore(backing, c, time.Minute, "PROFILE", "PROGRAM#"), backing, c
+	}
+
+	t.Run("repeated gets of cached items read the store once", func(t *testing.T) {
+		// Arrange
+		s, backing, _ := setup()
+		s.Put(ctx, "USER#1", "PROFILE", testDoc{Name: "ada"})
+
+		// Act
+		var first, second testDoc
+		s.Get(ctx, "USER#1", "PROFILE", &first)
+		err := s.Get(ctx, "USER#1", "PROFILE", &second)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if backing.reads != 1 {
+			t.Errorf("expected 1 store read, got %d", backing.reads)
+		}
+		if second.Name != "ada" {
+			t.Errorf("expected cached name 'ada', got %q", second.Name)
+		}
+	})
+
+	t.Run("missing items are cached as absent", func(t *testing.T) {
+		// Arrange
+		s, backing, _ := setup()
+
+		// Act
+		s.Get(ctx, "USER#1", "PROFILE", &testDoc{})
+		err := s.Get(ctx, "USER#1", "PROFILE", &testDoc{})
+
+		// Assert
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+		if backing.reads != 1 {
+			t.Errorf("expected 1 store read, got %d", backing.reads)
+		}
+	})
+
+	t.Run("writes invalidate the item and queries that include it", func(t *testing.T) {
+		// Arrange
+		s, _, _ := setup()
+		s.Put(ctx, "USER#1", "PROFILE", testDoc{Name: "ada"})
+		s.Put(ctx, "USER#1", "PROGRAM#a", testDoc{Name: "strength"})
+		s.Get(ctx, "USER#1", "PROFILE", &testDoc{})
+		s.Query(ctx, "USER#1", "PROGRAM#")
+
+		// Act
+		s.Put(ctx, "USER#1", "PROFILE", testDoc{Name: "grace"})
+		s.Put(ctx, "USER#1", "PROGRAM#b", testDoc{Name: "hypertrophy"})
+		s.Delete(ctx, "USER#1", "PROGRAM#a")
+		var got testDoc
+		s.Get(ctx, "USER#1", "PROFILE", &got)
+		items, err := s.Query(ctx, "USER#1", "PROGRAM#")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Name != "grace" {
+			t.Errorf("expected updated name 'grace', got %q", got.Name)
+		}
+		if len(items) != 1 || items[0].SK != "PROGRAM#b" {
+			t.Errorf("expected only PROGRAM#b, got %+v", items)
+		}
+	})
+
+	t.Run("other items and narrower queries bypass the cache", func(t *testing.T) {
+		// Arrange
+		s, backing, c := setup()
+		s.Put(ctx, "USER#1", "WORKOUT#1", testDoc{Name: "legs"})
+		s.Put(ctx, "USER#1", "PROGRAM#a", testDoc{Name: "strength"})
+
+		// Act
+		s.Get(ctx, "USER#1", "WORKOUT#1", &testDoc{})
+		s.Get(ctx, "USER#1", "WORKOUT#1", &testDoc{})
+		s.Query(ctx, "USER#1", "PROGRAM#a")
+		s.Query(ctx, "USER#1", "PROGRAM#a")
+
+		// Assert
+		if backing.reads != 4 {
+			t.Errorf("expected 4 store reads, got %d", backing.reads)
+		}
+		if len(c.values) != 0 {
+			t.Errorf("expected nothing cached, got %d entries", len(c.values))
+		}
+	})
+
+	t.Run("reads fall back to the store when the cache fails", func(t *testing.T) {
+		// Arrange
+		s, _, c := setup()
+		s.Put(ctx, "USER#1", "PROFILE", testDoc{Name: "ada"})
+		c.err = errors.New("connection refused")
+
+		// Act
+		var got testDoc
+		err := s.Get(ctx, "USER#1", "PROFILE", &got)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Name != "ada" {
+			t.Errorf("expected name 'ada', got %q", got.Name)
+		}
+	})
+
+	t.Run("a failed invalidation is reported", func(t *testing.T) {
+		// Arrange
+		s, _, c := setup()
+		c.err = errors.New("connection refused")
+
+		// Act
+		err := s.Put(ctx, "USER#1", "PROFILE", testDoc{Name: "ada"})
+
+		// Assert
+		if err == nil {
+			t.Error("expected an invalidation error")
+		}
+	})
+}
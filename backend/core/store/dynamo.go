@@ -0,0 +1,274 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"athlete-forge/awsapi"
+)
+
+// attributeValue is the DynamoDB wire representation of a string attribute
+type attributeValue struct {
+	S string `json:"S,omitempty"`
+}
+
+// dynamoItem is the attribute map for a stored record
+type dynamoItem map[string]attributeValue
+
+// DynamoStore implements Store against a DynamoDB table keyed on PK/SK
+type DynamoStore struct {
+	client    *awsapi.Client
+	tableName string
+}
+
+// NewDynamoStore creates a DynamoStore for tableName
+func NewDynamoStore(client *awsapi.Client, tableName string) *DynamoStore {
+	return &DynamoStore{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+// Get loads the item at pk/sk into out
+func (d *DynamoStore) Get(ctx context.Context, pk, sk string, out interface{}) error {
+	var resp struct {
+		Item dynamoItem `json:"Item"`
+	}
+	err := d.client.Call(ctx, awsapi.DynamoDB, "GetItem", map[string]interface{}{
+		"TableName":      d.tableName,
+		"Key":            keyOf(pk, sk),
+		"ConsistentRead": true,
+	}, &resp)
+	if err != nil {
+		return fmt.Errorf("failed to get item %s/%s: %w", pk, sk, err)
+	}
+
+	if resp.Item == nil {
+		return ErrNotFound
+	}
+	return toItem(resp.Item).Decode(out)
+}
+
+// Put writes v at pk/sk
+func (d *DynamoStore) Put(ctx context.Context, pk, sk string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal item %s/%s: %w", pk, sk, err)
+	}
+
+	item := keyOf(pk, sk)
+	item["data"] = attributeValue{S: string(data)}
+
+	err = d.client.Call(ctx, awsapi.DynamoDB, "PutItem", map[string]interface{}{
+		"TableName": d.tableName,
+		"Item":      item,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to put item %s/%s: %w", pk, sk, err)
+	}
+	return nil
+}
+
+// Create writes v at pk/sk with a condition that no item is there yet
+func (d *DynamoStore) Create(ctx context.Context, pk, sk string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal item %s/%s: %w", pk, sk, err)
+	}
+
+	item := keyOf(pk, sk)
+	item["data"] = attributeValue{S: string(data)}
+
+	err = d.client.Call(ctx, awsapi.DynamoDB, "PutItem", map[string]interface{}{
+		"TableName":           d.tableName,
+		"Item":                item,
+		"ConditionExpression": "attribute_not_exists(PK)",
+	}, nil)
+	if awsapi.IsErrorCode(err, "ConditionalCheckFailedException") {
+		return ErrExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create item %s/%s: %w", pk, sk, err)
+	}
+	return nil
+}
+
+// Query returns items under pk whose sort key begins with skPrefix, following pagination
+func (d *DynamoStore) Query(ctx context.Context, pk, skPrefix string) ([]Item, error) {
+	var items []Item
+	var startKey dynamoItem
+
+	for {
+		input := map[string]interface{}{
+			"TableName":              d.tableName,
+			"KeyConditionExpression": "PK = :pk AND begins_with(SK, :sk)",
+			"ExpressionAttributeValues": map[string]attributeValue{
+				":pk": {S: pk},
+				":sk": {S: skPrefix},
+			},
+		}
+		if startKey != nil {
+			input["ExclusiveStartKey"] = startKey
+		}
+
+		var resp struct {
+			Items            []dynamoItem `json:"Items"`
+			LastEvaluatedKey dynamoItem   `json:"LastEvaluatedKey"`
+		}
+		if err := d.client.Call(ctx, awsapi.DynamoDB, "Query", input, &resp); err != nil {
+			return nil, fmt.Errorf("failed to query %s: %w", pk, err)
+		}
+
+		for _, raw := range resp.Items {
+			items = append(items, toItem(raw))
+		}
+
+		if len(resp.LastEvaluatedKey) == 0 {
+			return items, nil
+		}
+		startKey = resp.LastEvaluatedKey
+	}
+}
+
+// Delete removes the item at pk/sk
+func (d *DynamoStore) Delete(ctx context.Context, pk, sk string) error {
+	err := d.client.Call(ctx, awsapi.DynamoDB, "DeleteItem", map[string]interface{}{
+		"TableName": d.tableName,
+		"Key":       keyOf(pk, sk),
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete item %s/%s: %w", pk, sk, err)
+	}
+	return nil
+}
+
+// maxBatchAttempts bounds how many times BatchGet and BatchPut ask again for
+// keys or items DynamoDB left unprocessed, such as when a request is throttled
+const maxBatchAttempts = 5
+
+// batchBackoff is the wait before a batch request first asks again for what
+// DynamoDB left unprocessed; it doubles with each attempt, so a throttled
+// table gets time to recover
+const batchBackoff = 50 * time.Millisecond
+
+// batchWait waits out the backoff before the given attempt of a batch request,
+// returning early with the context's error if it is done first
+func batchWait(ctx context.Context, attempt int) error {
+	if attempt <= 1 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(batchBackoff << (attempt - 2)):
+		return nil
+	}
+}
+
+// BatchGet returns the items at keys that exist, reading MaxBatchGet keys per
+// request and retrying the keys DynamoDB leaves unprocessed with exponential
+// backoff
+func (d *DynamoStore) BatchGet(ctx context.Context, keys []Key) ([]Item, error) {
+	var items []Item
+	for start := 0; start < len(keys); start += MaxBatchGet {
+		end := start + MaxBatchGet
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		pending := make([]dynamoItem, 0, end-start)
+		for _, k := range keys[start:end] {
+			pending = append(pending, keyOf(k.PK, k.SK))
+		}
+
+		for attempt := 1; len(pending) > 0; attempt++ {
+			if attempt > maxBatchAttempts {
+				return nil, fmt.Errorf("failed to batch get items: %d keys still unprocessed after %d attempts", len(pending), maxBatchAttempts)
+			}
+			if err := batchWait(ctx, attempt); err != nil {
+				return nil, err
+			}
+
+			var resp struct {
+				Responses       map[string][]dynamoItem `json:"Responses"`
+				UnprocessedKeys map[string]struct {
+					Keys []dynamoItem `json:"Keys"`
+				} `json:"UnprocessedKeys"`
+			}
+			err := d.client.Call(ctx, awsapi.DynamoDB, "BatchGetItem", map[string]interface{}{
+				"RequestItems": map[string]interface{}{
+					d.tableName: map[string]interface{}{
+						"Keys":           pending,
+						"ConsistentRead": true,
+					},
+				},
+			}, &resp)
+			if err != nil {
+				return nil, fmt.Errorf("failed to batch get items: %w", err)
+			}
+
+			for _, raw := range resp.Responses[d.tableName] {
+				items = append(items, toItem(raw))
+			}
+			pending = resp.UnprocessedKeys[d.tableName].Keys
+		}
+	}
+	return items, nil
+}
+
+// BatchPut writes MaxBatchWrite items per request, retrying the items
+// DynamoDB leaves unprocessed with exponential backoff
+func (d *DynamoStore) BatchPut(ctx context.Context, writes []Write) error {
+	for start := 0; start < len(writes); start += MaxBatchWrite {
+		end := min(start+MaxBatchWrite, len(writes))
+
+		pending := make([]map[string]interface{}, 0, end-start)
+		for _, w := range writes[start:end] {
+			data, err := json.Marshal(w.Value)
+			if err != nil {
+				return fmt.Errorf("failed to marshal item %s/%s: %w", w.PK, w.SK, err)
+			}
+			item := keyOf(w.PK, w.SK)
+			item["data"] = attributeValue{S: string(data)}
+			pending = append(pending, map[string]interface{}{"PutRequest": map[string]interface{}{"Item": item}})
+		}
+
+		for attempt := 1; len(pending) > 0; attempt++ {
+			if attempt > maxBatchAttempts {
+				return fmt.Errorf("failed to batch write items: %d items still unprocessed after %d attempts", len(pending), maxBatchAttempts)
+			}
+			if err := batchWait(ctx, attempt); err != nil {
+				return err
+			}
+
+			var resp struct {
+				UnprocessedItems map[string][]map[string]interface{} `json:"UnprocessedItems"`
+			}
+			err := d.client.Call(ctx, awsapi.DynamoDB, "BatchWriteItem", map[string]interface{}{
+				"RequestItems": map[string]interface{}{d.tableName: pending},
+			}, &resp)
+			if err != nil {
+				return fmt.Errorf("failed to batch write items: %w", err)
+			}
+			pending = resp.UnprocessedItems[d.tableName]
+		}
+	}
+	return nil
+}
+
+func keyOf(pk, sk string) dynamoItem {
+	return dynamoItem{
+		"PK": {S: pk},
+		"SK": {S: sk},
+	}
+}
+
+func toItem(raw dynamoItem) Item {
+	return Item{
+		PK:   raw["PK"].S,
+		SK:   raw["SK"].S,
+		Data: json.RawMessage(raw["data"].S),
+	}
+}
@@ -0,0 +1,292 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"athlete-forge/awsapi"
+)
+
+func newTestDynamoStore(t *testing.T, handler http.HandlerFunc) *DynamoStore {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := awsapi.NewClient("eu-west-2", awsapi.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	client.Endpoint = func(string) string { return server.URL }
+	return NewDynamoStore(client, "workout-tracker")
+}
+
+func TestDynamoStore_Get(t *testing.T) {
+	t.Run("decodes the data attribute", func(t *testing.T) {
+		// Arrange
+		s := newTestDynamoStore(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"Item":{"PK":{"S":"USER#1"},"SK":{"S":"PROFILE"},"data":{"S":"{\"name\":\"bench\"}"}}}`))
+		})
+
+		// Act
+		var got testDoc
+		err := s.Get(context.Background(), "USER#1", "PROFILE", &got)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Name != "bench" {
+			t.Errorf("expected name 'bench', got %q", got.Name)
+		}
+	})
+
+	t.Run("returns ErrNotFound when item is absent", func(t *testing.T) {
+		// Arrange
+		s := newTestDynamoStore(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{}`))
+		})
+
+		// Act
+		err := s.Get(context.Background(), "USER#1", "PROFILE", &testDoc{})
+
+		// Assert
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+}
+
+func TestDynamoStore_Query(t *testing.T) {
+	t.Run("follows LastEvaluatedKey pagination", func(t *testing.T) {
+		// Arrange
+		calls := 0
+		s := newTestDynamoStore(t, func(w http.ResponseWriter, r *http.Request) {
+			var input map[string]json.RawMessage
+			json.NewDecoder(r.Body).Decode(&input)
+			calls++
+			if _, ok := input["ExclusiveStartKey"]; !ok {
+				w.Write([]byte(`{"Items":[{"PK":{"S":"USER#1"},"SK":{"S":"W#1"},"data":{"S":"{}"}}],"LastEvaluatedKey":{"PK":{"S":"USER#1"},"SK":{"S":"W#1"}}}`))
+				return
+			}
+			w.Write([]byte(`{"Items":[{"PK":{"S":"USER#1"},"SK":{"S":"W#2"},"data":{"S":"{}"}}]}`))
+		})
+
+		// Act
+		items, err := s.Query(context.Background(), "USER#1", "W#")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if calls != 2 {
+			t.Errorf("expected 2 query calls, got %d", calls)
+		}
+		if len(items) != 2 || items[1].SK != "W#2" {
+			t.Errorf("unexpected items: %+v", items)
+		}
+	})
+}
+
+func TestDynamoStore_Put(t *testing.T) {
+	t.Run("writes key and data attributes", func(t *testing.T) {
+		// Arrange
+		var input struct {
+			TableName string
+			Item      map[string]map[string]string
+		}
+		s := newTestDynamoStore(t, func(w http.ResponseWriter, r *http.Request) {
+			json.NewDecoder(r.Body).Decode(&input)
+			w.Write([]byte(`{}`))
+		})
+
+		// Act
+		err := s.Put(context.Background(), "USER#1", "PROFILE", testDoc{Name: "row"})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if input.TableName != "workout-tracker" {
+			t.Errorf("unexpected table %q", input.TableName)
+		}
+		if input.Item["PK"]["S"] != "USER#1" || input.Item["data"]["S"] != `{"name":"row"}` {
+			t.Errorf("unexpected item: %+v", input.Item)
+		}
+	})
+}
+
+func TestDynamoStore_Create(t *testing.T) {
+	t.Run("writes only when the item is absent", func(t *testing.T) {
+		// Arrange
+		var input struct {
+			ConditionExpression string
+		}
+		s := newTestDynamoStore(t, func(w http.ResponseWriter, r *http.Request) {
+			json.NewDecoder(r.Body).Decode(&input)
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ConditionalCheckFailedException","message":"The conditional request failed"}`))
+		})
+
+		// Act
+		err := s.Create(context.Background(), "HANDLE#lifter", "HANDLE", testDoc{Name: "row"})
+
+		// Assert
+		if !errors.Is(err, ErrExists) {
+			t.Errorf("expected ErrExists, got %v", err)
+		}
+		if input.ConditionExpression != "attribute_not_exists(PK)" {
+			t.Errorf("unexpected condition %q", input.ConditionExpression)
+		}
+	})
+}
+
+func TestDynamoStore_BatchGet(t *testing.T) {
+	t.Run("retries unprocessed keys", func(t *testing.T) {
+		// Arrange
+		var requested [][]map[string]map[string]string
+		s := newTestDynamoStore(t, func(w http.ResponseWriter, r *http.Request) {
+			var input struct {
+				RequestItems map[string]struct {
+					Keys []map[string]map[string]string
+				}
+			}
+			json.NewDecoder(r.Body).Decode(&input)
+			requested = append(requested, input.RequestItems["workout-tracker"].Keys)
+			if len(requested) == 1 {
+				w.Write([]byte(`{"Responses":{"workout-tracker":[{"PK":{"S":"USER#1"},"SK":{"S":"W#1"},"data":{"S":"{}"}}]},"UnprocessedKeys":{"workout-tracker":{"Keys":[{"PK":{"S":"USER#1"},"SK":{"S":"W#2"}}]}}}`))
+				return
+			}
+			w.Write([]byte(`{"Responses":{"workout-tracker":[{"PK":{"S":"USER#1"},"SK":{"S":"W#2"},"data":{"S":"{}"}}]}}`))
+		})
+
+		// Act
+		items, err := s.BatchGet(context.Background(), []Key{{PK: "USER#1", SK: "W#1"}, {PK: "USER#1", SK: "W#2"}, {PK: "USER#1", SK: "W#3"}})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(requested) != 2 || len(requested[0]) != 3 || len(requested[1]) != 1 || requested[1][0]["SK"]["S"] != "W#2" {
+			t.Errorf("unexpected requests: %+v", requested)
+		}
+		if len(items) != 2 || items[1].SK != "W#2" {
+			t.Errorf("unexpected items: %+v", items)
+		}
+	})
+
+	t.Run("backs off before asking again for unprocessed keys", func(t *testing.T) {
+		// Arrange
+		var at []time.Time
+		s := newTestDynamoStore(t, func(w http.ResponseWriter, r *http.Request) {
+			at = append(at, time.Now())
+			w.Write([]byte(`{"UnprocessedKeys":{"workout-tracker":{"Keys":[{"PK":{"S":"USER#1"},"SK":{"S":"W#1"}}]}}}`))
+		})
+
+		// Act
+		_, err := s.BatchGet(context.Background(), []Key{{PK: "USER#1", SK: "W#1"}})
+
+		// Assert
+		if err == nil || len(at) != maxBatchAttempts {
+			t.Fatalf("expected to give up after %d attempts, got %d: %v", maxBatchAttempts, len(at), err)
+		}
+		if wait := at[2].Sub(at[1]); wait < 2*batchBackoff {
+			t.Errorf("expected the backoff to double, waited %v", wait)
+		}
+	})
+
+	t.Run("reads at most MaxBatchGet keys per request", func(t *testing.T) {
+		// Arrange
+		var sizes []int
+		s := newTestDynamoStore(t, func(w http.ResponseWriter, r *http.Request) {
+			var input struct {
+				RequestItems map[string]struct {
+					Keys []json.RawMessage
+				}
+			}
+			json.NewDecoder(r.Body).Decode(&input)
+			sizes = append(sizes, len(input.RequestItems["workout-tracker"].Keys))
+			w.Write([]byte(`{}`))
+		})
+		keys := make([]Key, MaxBatchGet+1)
+		for i := range keys {
+			keys[i] = Key{PK: "USER#1", SK: fmt.Sprintf("W#%d", i)}
+		}
+
+		// Act
+		items, err := s.BatchGet(context.Background(), keys)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(sizes) != 2 || sizes[0] != MaxBatchGet || sizes[1] != 1 {
+			t.Errorf("unexpected request sizes: %v", sizes)
+		}
+		if len(items) != 0 {
+			t.Errorf("expected no items, got %+v", items)
+		}
+	})
+}
+
+func TestDynamoStore_BatchPut(t *testing.T) {
+	t.Run("retries unprocessed items in batches of MaxBatchWrite", func(t *testing.T) {
+		// Arrange
+		var sizes []int
+		s := newTestDynamoStore(t, func(w http.ResponseWriter, r *http.Request) {
+			var input struct {
+				RequestItems map[string][]struct {
+					PutRequest struct {
+						Item map[string]map[string]string
+					}
+				}
+			}
+			json.NewDecoder(r.Body).Decode(&input)
+			requests := input.RequestItems["workout-tracker"]
+			sizes = append(sizes, len(requests))
+			if len(sizes) == 1 {
+				unprocessed, _ := json.Marshal(map[string]interface{}{"workout-tracker": requests[:2]})
+				w.Write([]byte(`{"UnprocessedItems":` + string(unprocessed) + `}`))
+				return
+			}
+			if item := requests[0].PutRequest.Item; item["data"]["S"] == "" {
+				t.Errorf("expected the data attribute, got %+v", item)
+			}
+			w.Write([]byte(`{}`))
+		})
+		writes := make([]Write, MaxBatchWrite+1)
+		for i := range writes {
+			writes[i] = Write{PK: "USER#1", SK: fmt.Sprintf("W#%d", i), Value: map[string]int{"i": i}}
+		}
+
+		// Act
+		err := s.BatchPut(context.Background(), writes)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(sizes) != 3 || sizes[0] != MaxBatchWrite || sizes[1] != 2 || sizes[2] != 1 {
+			t.Errorf("unexpected request sizes: %v", sizes)
+		}
+	})
+
+	t.Run("gives up on items that stay unprocessed", func(t *testing.T) {
+		// Arrange
+		calls := 0
+		s := newTestDynamoStore(t, func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Write([]byte(`{"UnprocessedItems":{"workout-tracker":[{"PutRequest":{"Item":{}}}]}}`))
+		})
+
+		// Act
+		err := s.BatchPut(context.Background(), []Write{{PK: "USER#1", SK: "W#1", Value: 1}})
+
+		// Assert
+		if err == nil || calls != maxBatchAttempts {
+			t.Errorf("expected an error after %d attempts, got %v after %d", maxBatchAttempts, err, calls)
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MemoryStore is an in-process Store used for tests and local development
+type MemoryStore struct {
+	mu    sync.RWMutex
+	items map[string]map[string]json.RawMessage
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		items: make(map[string]map[string]json.RawMessage),
+	}
+}
+
+// Get loads the item at pk/sk into out
+func (m *MemoryStore) Get(ctx context.Context, pk, sk string, out interface{}) error {
+	m.mu.RLock()
+	data, ok := m.items[pk][sk]
+	m.mu.RUnlock()
+
+	if !ok {
+		return ErrNotFound
+	}
+	return Item{PK: pk, SK: sk, Data: data}.Decode(out)
+}
+
+// Put writes v at pk/sk
+func (m *MemoryStore) Put(ctx context.Context, pk, sk string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal item %s/%s: %w", pk, sk, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.items[pk] == nil {
+		m.items[pk] = make(map[string]json.RawMessage)
+	}
+	m.items[pk][sk] = data
+	return nil
+}
+
+// Create writes v at pk/sk unless an item is already there
+func (m *MemoryStore) Create(ctx context.Context, pk, sk string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal item %s/%s: %w", pk, sk, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.items[pk][sk]; ok {
+		return ErrExists
+	}
+	if m.items[pk] == nil {
+		m.items[pk] = make(map[string]json.RawMessage)
+	}
+	m.items[pk][sk] = data
+	return nil
+}
+
+// Query returns items under pk whose sort key begins with skPrefix
+func (m *MemoryStore) Query(ctx context.Context, pk, skPrefix string) ([]Item, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var items []Item
+	for sk, data := range m.items[pk] {
+		if strings.HasPrefix(sk, skPrefix) {
+			items = append(items, Item{PK: pk, SK: sk, Data: data})
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].SK < items[j].SK
+	})
+	return items, nil
+}
+
+// Delete removes the item at pk/sk
+func (m *MemoryStore) Delete(ctx context.Context, pk, sk string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.items[pk], sk)
+	return nil
+}
+
+// BatchPut writes each item
+func (m *MemoryStore) BatchPut(ctx context.Context, writes []Write) error {
+	for _, w := range writes {
+		if err := m.Put(ctx, w.PK, w.SK, w.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BatchGet returns the items at keys that exist
+func (m *MemoryStore) BatchGet(ctx context.Context, keys []Key) ([]Item, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var items []Item
+	for _, k := range keys {
+		if data, ok := m.items[k.PK][k.SK]; ok {
+			items = append(items, Item{PK: k.PK, SK: k.SK, Data: data})
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type testDoc struct {
+	Name string `json:"name"`
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("get returns ErrNotFound for missing items", func(t *testing.T) {
+		// Arrange
+		s := NewMemoryStore()
+
+		// Act
+		err := s.Get(ctx, "USER#1", "PROFILE", &testDoc{})
+
+		// Assert
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("put then get round-trips the document", func(t *testing.T) {
+		// Arrange
+		s := NewMemoryStore()
+
+		// Act
+		if err := s.Put(ctx, "USER#1", "PROFILE", testDoc{Name: "squat"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var got testDoc
+		err := s.Get(ctx, "USER#1", "PROFILE", &got)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Name != "squat" {
+			t.Errorf("expected name 'squat', got %q", got.Name)
+		}
+	})
+
+	t.Run("query filters by prefix and orders by sort key", func(t *testing.T) {
+		// Arrange
+		s := NewMemoryStore()
+		s.Put(ctx, "USER#1", "WORKOUT#2024-01-02", testDoc{Name: "b"})
+		s.Put(ctx, "USER#1", "WORKOUT#2024-01-01", testDoc{Name: "a"})
+		s.Put(ctx, "USER#1", "PROFILE", testDoc{Name: "p"})
+		s.Put(ctx, "USER#2", "WORKOUT#2024-01-01", testDoc{Name: "other"})
+
+		// Act
+		items, err := s.Query(ctx, "USER#1", "WORKOUT#")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(items) != 2 {
+			t.Fatalf("expected 2 items, got %d", len(items))
+		}
+		if items[0].SK != "WORKOUT#2024-01-01" || items[1].SK != "WORKOUT#2024-01-02" {
+			t.Errorf("unexpected order: %s, %s", items[0].SK, items[1].SK)
+		}
+	})
+
+	t.Run("delete removes the item", func(t *testing.T) {
+		// Arrange
+		s := NewMemoryStore()
+		s.Put(ctx, "USER#1", "PROFILE", testDoc{Name: "p"})
+
+		// Act
+		if err := s.Delete(ctx, "USER#1", "PROFILE"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// Assert
+		if err := s.Get(ctx, "USER#1", "PROFILE", &testDoc{}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound after delete, got %v", err)
+		}
+	})
+
+	t.Run("batch get leaves out missing items", func(t *testing.T) {
+		// Arrange
+		s := NewMemoryStore()
+		s.Put(ctx, "USER#1", "W#1", testDoc{Name: "a"})
+		s.Put(ctx, "USER#2", "W#2", testDoc{Name: "b"})
+
+		// Act
+		items, err := s.BatchGet(ctx, []Key{{PK: "USER#1", SK: "W#1"}, {PK: "USER#1", SK: "W#2"}})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(items) != 1 || items[0].SK != "W#1" {
+			t.Errorf("unexpected items: %+v", items)
+		}
+	})
+
+	t.Run("create refuses to replace an existing item", func(t *testing.T) {
+		// Arrange
+		s := NewMemoryStore()
+		s.Put(ctx, "HANDLE#lifter", "HANDLE", testDoc{Name: "a"})
+
+		// Act
+		err := Create(ctx, s, "HANDLE#lifter", "HANDLE", testDoc{Name: "b"})
+		var got testDoc
+		s.Get(ctx, "HANDLE#lifter", "HANDLE", &got)
+
+		// Assert
+		if !errors.Is(err, ErrExists) {
+			t.Errorf("expected ErrExists, got %v", err)
+		}
+		if got.Name != "a" {
+			t.Errorf("expected the item to be kept, got %q", got.Name)
+		}
+	})
+}
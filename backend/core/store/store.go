@@ -0,0 +1,152 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ErrNotFound is returned when an item does not exist
+var ErrNotFound = errors.New("item not found")
+
+// ErrExists is returned by Create when the item already exists
+var ErrExists = errors.New("item already exists")
+
+// Item is a single stored record in the single-table layout
+type Item struct {
+	PK   string          `json:"pk"`
+	SK   string          `json:"sk"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Decode unmarshals the item payload into v
+func (i Item) Decode(v interface{}) error {
+	if err := json.Unmarshal(i.Data, v); err != nil {
+		return fmt.Errorf("failed to decode item %s/%s: %w", i.PK, i.SK, err)
+	}
+	return nil
+}
+
+// Key addresses a single item by partition key and sort key
+type Key struct {
+	PK string
+	SK string
+}
+
+// MaxBatchGet is the most keys DynamoDB reads in one BatchGetItem request
+const MaxBatchGet = 100
+
+// Store persists JSON documents addressed by partition key and sort key,
+// mirroring the DynamoDB single-table design used in production
+type Store interface {
+	// Get loads the item at pk/sk into out, returning ErrNotFound when absent
+	Get(ctx context.Context, pk, sk string, out interface{}) error
+
+	// Put writes v at pk/sk, replacing any existing item
+	Put(ctx context.Context, pk, sk string, v interface{}) error
+
+	// Query returns all items under pk whose sort key begins with skPrefix, ordered by sort key
+	Query(ctx context.Context, pk, skPrefix string) ([]Item, error)
+
+	// Delete removes the item at pk/sk; deleting a missing item is not an error
+	Delete(ctx context.Context, pk, sk string) error
+
+	// BatchGet returns the items at keys that exist, in no particular order;
+	// missing items are left out rather than reported as errors
+	BatchGet(ctx context.Context, keys []Key) ([]Item, error)
+}
+
+// Creator is implemented by stores that can write an item only when it does
+// not exist yet, as one conditional write
+type Creator interface {
+	// Create writes v at pk/sk, returning ErrExists when an item is already there
+	Create(ctx context.Context, pk, sk string, v interface{}) error
+}
+
+// Create writes v at pk/sk unless an item is already there, in which case it
+// returns ErrExists. Stores that are not Creators are checked with a read
+// first, so two callers racing on the same key can both succeed
+func Create(ctx context.Context, s Store, pk, sk string, v interface{}) error {
+	if c, ok := s.(Creator); ok {
+		return c.Create(ctx, pk, sk, v)
+	}
+	var existing json.RawMessage
+	err := s.Get(ctx, pk, sk, &existing)
+	if err == nil {
+		return ErrExists
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return s.Put(ctx, pk, sk, v)
+}
+
+// MaxBatchWrite is the most items DynamoDB writes in one BatchWriteItem request
+const MaxBatchWrite = 25
+
+// Write is one item of a batch write
+type Write struct {
+	PK    string
+	SK    string
+	Value interface{}
+}
+
+// BatchWriter is implemented by stores that can write many items in a few
+// requests
+type BatchWriter interface {
+	// BatchPut writes each item, replacing any existing item at its key
+	BatchPut(ctx context.Context, writes []Write) error
+}
+
+// BatchPut writes each item, replacing any existing item at its key. Stores
+// that are not BatchWriters write the items one at a time
+func BatchPut(ctx context.Context, s Store, writes []Write) error {
+	if b, ok := s.(BatchWriter); ok {
+		return b.BatchPut(ctx, writes)
+	}
+	for _, w := range writes {
+		if err := s.Put(ctx, w.PK, w.SK, w.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UserPK returns the partition key holding all items owned by userID
+func UserPK(userID string) string {
+	return "USER#" + userID
+}
+
+// NewID returns a unique identifier that sorts by creation time
+func NewID() string {
+	return NewIDAt(time.Now())
+}
+
+// NewIDAt returns a unique identifier that sorts by at, for items whose time
+// is given rather than taken from the clock
+func NewIDAt(at time.Time) string {
+	var random [5]byte
+	rand.Read(random[:])
+	return fmt.Sprintf("%012x%s", at.UnixMilli(), hex.EncodeToString(random[:]))
+}
+
+// IDTime returns when an ID from NewID was created, or false for IDs made some
+// other way
+func IDTime(id string) (time.Time, bool) {
+	if len(id) != 22 {
+		return time.Time{}, false
+	}
+	ms, err := strconv.ParseInt(id[:12], 16, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if _, err := hex.DecodeString(id[12:]); err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(ms).UTC(), true
+}
@@ -0,0 +1,47 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIDTime(t *testing.T) {
+	t.Run("recovers when an ID was created", func(t *testing.T) {
+		// Arrange
+		before := time.Now().Add(-time.Millisecond)
+		id := NewID()
+
+		// Act
+		created, ok := IDTime(id)
+
+		// Assert
+		if !ok || created.Before(before) || created.After(time.Now()) {
+			t.Errorf("expected a creation time around now, got %v (ok %v)", created, ok)
+		}
+	})
+
+	t.Run("recovers the time an ID was created for", func(t *testing.T) {
+		// Arrange
+		at := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+
+		// Act
+		created, ok := IDTime(NewIDAt(at))
+
+		// Assert
+		if !ok || !created.Equal(at) {
+			t.Errorf("expected %v, got %v (ok %v)", at, created, ok)
+		}
+	})
+
+	t.Run("rejects IDs made another way", func(t *testing.T) {
+		for _, id := range []string{"", "user-1", "6f1c2a7e-9d3b-4c1e-8a5f-2b7d9e0c4a11", "0000000000zz0000000000"} {
+			// Act
+			_, ok := IDTime(id)
+
+			// Assert
+			if ok {
+				t.Errorf("expected %q to be rejected", id)
+			}
+		}
+	})
+}
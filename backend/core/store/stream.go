@@ -0,0 +1,39 @@
+package store
+
+// Stream event names
+const (
+	StreamInsert = "INSERT"
+	StreamModify = "MODIFY"
+	StreamRemove = "REMOVE"
+)
+
+// StreamRecord is one change from the table's DynamoDB Stream, which is
+// configured with the NEW_AND_OLD_IMAGES view
+type StreamRecord struct {
+	EventID     string `json:"eventID"`
+	EventName   string `json:"eventName"`
+	EventSource string `json:"eventSource"`
+	Dynamodb    struct {
+		Keys     dynamoItem `json:"Keys"`
+		NewImage dynamoItem `json:"NewImage,omitempty"`
+		OldImage dynamoItem `json:"OldImage,omitempty"`
+	} `json:"dynamodb"`
+}
+
+// Old returns the item as it was before the change, or nil for inserts
+func (r StreamRecord) Old() *Item {
+	return imageItem(r.Dynamodb.OldImage)
+}
+
+// New returns the item as it is after the change, or nil for removals
+func (r StreamRecord) New() *Item {
+	return imageItem(r.Dynamodb.NewImage)
+}
+
+func imageItem(image dynamoItem) *Item {
+	if image == nil {
+		return nil
+	}
+	item := toItem(image)
+	return &item
+}
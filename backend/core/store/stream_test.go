@@ -0,0 +1,44 @@
+package store
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestStreamRecord(t *testing.T) {
+	t.Run("decodes old and new images", func(t *testing.T) {
+		// Arrange
+		var record StreamRecord
+		json.Unmarshal([]byte(`{"eventID":"1","eventName":"MODIFY","eventSource":"aws:dynamodb","dynamodb":{
+			"Keys":{"PK":{"S":"USER#1"},"SK":{"S":"WORKOUT#1"}},
+			"OldImage":{"PK":{"S":"USER#1"},"SK":{"S":"WORKOUT#1"},"data":{"S":"{\"name\":\"old\"}"}},
+			"NewImage":{"PK":{"S":"USER#1"},"SK":{"S":"WORKOUT#1"},"data":{"S":"{\"name\":\"new\"}"}}}}`), &record)
+
+		// Act
+		old, current := record.Old(), record.New()
+
+		// Assert
+		var before, after testDoc
+		if old == nil || current == nil || old.Decode(&before) != nil || current.Decode(&after) != nil {
+			t.Fatalf("expected both images, got %+v and %+v", old, current)
+		}
+		if old.SK != "WORKOUT#1" || before.Name != "old" || after.Name != "new" {
+			t.Errorf("unexpected images: %+v %+v", before, after)
+		}
+	})
+
+	t.Run("has no new image for removals", func(t *testing.T) {
+		// Arrange
+		var record StreamRecord
+		json.Unmarshal([]byte(`{"eventName":"REMOVE","dynamodb":{"Keys":{"PK":{"S":"USER#1"},"SK":{"S":"WORKOUT#1"}},
+			"OldImage":{"PK":{"S":"USER#1"},"SK":{"S":"WORKOUT#1"},"data":{"S":"{}"}}}}`), &record)
+
+		// Act
+		current := record.New()
+
+		// Assert
+		if current != nil || record.Old() == nil {
+			t.Errorf("expected only an old image, got %+v", current)
+		}
+	})
+}
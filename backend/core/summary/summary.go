@@ -0,0 +1,88 @@
+package summary
+
+import (
+	"math"
+	"time"
+
+	"athlete-forge/cardio"
+	"athlete-forge/dailylog"
+	"athlete-forge/hrzone"
+	"athlete-forge/workout"
+)
+
+// Week combines a user's training and daily habits for one Monday-to-Sunday week.
+// Volume covers reps sets only; Rounds counts the rounds completed in supersets,
+// circuits and AMRAP blocks, TimeUnderTension the seconds of duration sets and sets
+// logged with a tempo, and DurationSeconds and DistanceMetres total the duration
+// and distance sets
+type Week struct {
+	WeekStart        string               `json:"weekStart"`
+	Workouts         int                  `json:"workouts"`
+	Sets             int                  `json:"sets"`
+	Volume           float64              `json:"volume"`
+	Rounds           int                  `json:"rounds"`
+	TimeUnderTension int                  `json:"timeUnderTension"`
+	DurationSeconds  int                  `json:"durationSeconds"`
+	DistanceMetres   float64              `json:"distanceMetres"`
+	Cardio           cardio.WeeklySummary `json:"cardio"`
+	Habits           dailylog.WeekStats   `json:"habits"`
+}
+
+// Input is the data a weekly summary is built from; entries outside the week are ignored
+type Input struct {
+	Workouts   []workout.Workout
+	Activities []cardio.Activity
+	Logs       []dailylog.Log
+	HeartRate  *hrzone.Config
+}
+
+// WeekStart returns the Monday 00:00 UTC beginning the week containing t
+func WeekStart(t time.Time) time.Time {
+	return cardio.WeekStart(t)
+}
+
+// Build summarizes the week beginning weekStart
+func Build(weekStart time.Time, in Input) Week {
+	weekEnd := weekStart.AddDate(0, 0, 7)
+	week := Week{
+		WeekStart: weekStart.Format(dailylog.DateLayout),
+		Cardio:    cardio.Weekly(in.Activities, weekStart, in.HeartRate),
+	}
+
+	for _, w := range in.Workouts {
+		if w.Status != workout.StatusCompleted || !inWeek(CompletedAt(w), weekStart, weekEnd) {
+			continue
+		}
+		week.Workouts++
+		for _, block := range w.Blocks() {
+			week.Sets += block.Sets
+			week.Volume += block.Volume
+			week.Rounds += block.Rounds
+			week.TimeUnderTension += block.TimeUnderTension
+			week.DurationSeconds += block.DurationSeconds
+			week.DistanceMetres += block.DistanceMetres
+		}
+	}
+	week.Volume = math.Round(week.Volume*10) / 10
+	week.DistanceMetres = math.Round(week.DistanceMetres*10) / 10
+
+	from, to := week.WeekStart, weekEnd.AddDate(0, 0, -1).Format(dailylog.DateLayout)
+	var logs []dailylog.Log
+	for _, l := range in.Logs {
+		if l.Date >= from && l.Date <= to {
+			logs = append(logs, l)
+		}
+	}
+	week.Habits = dailylog.Summarize(logs)
+	return week
+}
+
+// CompletedAt returns when a workout was finished, falling back to its start
+// time, by the rule of workout.Workout.At
+func CompletedAt(w workout.Workout) time.Time {
+	return w.At()
+}
+
+func inWeek(t, start, end time.Time) bool {
+	return !t.Before(start) && t.Before(end)
+}
@@ -0,0 +1,92 @@
+package summary
+
+import (
+	"testing"
+	"time"
+
+	"athlete-forge/cardio"
+	"athlete-forge/dailylog"
+	"athlete-forge/workout"
+)
+
+func TestBuild(t *testing.T) {
+	t.Run("includes only the week's workouts, activities and logs", func(t *testing.T) {
+		// Arrange
+		weekStart := WeekStart(time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC))
+		inWeek := time.Date(2024, 3, 5, 19, 0, 0, 0, time.UTC)
+		before := time.Date(2024, 3, 3, 19, 0, 0, 0, time.UTC)
+		squat := []workout.Exercise{{Name: "Squat", Sets: []workout.Set{{Reps: 5, Weight: 100}, {Reps: 5, Weight: 100}}}}
+		steps := 8000
+		in := Input{
+			Workouts: []workout.Workout{
+				{Status: workout.StatusCompleted, StartedAt: inWeek, CompletedAt: &inWeek, Exercises: squat},
+				{Status: workout.StatusCompleted, StartedAt: before, CompletedAt: &before, Exercises: squat},
+				{Status: workout.StatusActive, StartedAt: inWeek, Exercises: squat},
+			},
+			Activities: []cardio.Activity{{StartTime: inWeek, DurationSeconds: 1200}},
+			Logs: []dailylog.Log{
+				{Date: "2024-03-03", Steps: &steps},
+				{Date: "2024-03-10", Steps: &steps},
+			},
+		}
+
+		// Act
+		week := Build(weekStart, in)
+
+		// Assert
+		if week.WeekStart != "2024-03-04" || week.Workouts != 1 || week.Sets != 2 || week.Volume != 1000 {
+			t.Errorf("unexpected training totals: %+v", week)
+		}
+		if week.Cardio.Activities != 1 {
+			t.Errorf("expected 1 cardio activity, got %d", week.Cardio.Activities)
+		}
+		if week.Habits.DaysLogged != 1 || week.Habits.TotalSteps != 8000 {
+			t.Errorf("unexpected habits: %+v", week.Habits)
+		}
+	})
+	t.Run("totals grouped rounds and time under tension", func(t *testing.T) {
+		// Arrange
+		weekStart := WeekStart(time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC))
+		at := time.Date(2024, 3, 5, 19, 0, 0, 0, time.UTC)
+		sets := []workout.Set{{Reps: 10, Weight: 20, Tempo: "3-1-1-0"}, {Reps: 10, Weight: 20, Tempo: "3-1-1-0"}}
+		in := Input{Workouts: []workout.Workout{{
+			Status:      workout.StatusCompleted,
+			StartedAt:   at,
+			CompletedAt: &at,
+			Groups:      []workout.Group{{ID: "a", Type: workout.GroupSuperset}},
+			Exercises:   []workout.Exercise{{Name: "Curl", Group: "a", Sets: sets}, {Name: "Pushdown", Group: "a", Sets: sets}},
+		}}}
+
+		// Act
+		week := Build(weekStart, in)
+
+		// Assert
+		if week.Rounds != 2 || week.Sets != 4 || week.TimeUnderTension != 200 {
+			t.Errorf("unexpected training totals: %+v", week)
+		}
+	})
+	t.Run("totals duration and distance sets apart from volume", func(t *testing.T) {
+		// Arrange
+		weekStart := WeekStart(time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC))
+		at := time.Date(2024, 3, 5, 19, 0, 0, 0, time.UTC)
+		in := Input{Workouts: []workout.Workout{{
+			Status:      workout.StatusCompleted,
+			StartedAt:   at,
+			CompletedAt: &at,
+			Exercises: []workout.Exercise{
+				{Name: "Plank", Sets: []workout.Set{{Type: workout.SetDuration, DurationSeconds: 60}, {Type: workout.SetDuration, DurationSeconds: 45}}},
+				{Name: "Sled Push", Sets: []workout.Set{{Type: workout.SetDistance, Distance: 20, DistanceUnit: "m", Weight: 100}}},
+				{Name: "Farmer Carry", Sets: []workout.Set{{Type: workout.SetDistance, Distance: 50, DistanceUnit: "yd", Weight: 30}}},
+				{Name: "Pull-Up", Sets: []workout.Set{{Reps: 8, Band: "green"}}},
+			},
+		}}}
+
+		// Act
+		week := Build(weekStart, in)
+
+		// Assert
+		if week.Volume != 0 || week.Sets != 5 || week.DurationSeconds != 105 || week.TimeUnderTension != 105 || week.DistanceMetres != 65.7 {
+			t.Errorf("unexpected training totals: %+v", week)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package syncindex
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"athlete-forge/store"
+)
+
+const (
+	indexPK         = "SYNCED_WORKOUTS"
+	workoutSKPrefix = "WORKOUT#"
+
+	// syncedLayout is fixed-width, so sort keys order workouts by sync time
+	syncedLayout = "2006-01-02T15:04:05.000000000Z"
+
+	// bucketLayout names the day a partition holds workouts synced on
+	bucketLayout = "2006-01-02"
+)
+
+// PKPrefix starts the index's partitions, one per day of syncs, whose sort keys
+// end with the user so each entry can be kept in its user's region
+const PKPrefix = indexPK
+
+// Entry records that a user's workout was synced completed
+type Entry struct {
+	UserID    string    `json:"userId"`
+	WorkoutID string    `json:"workoutId"`
+	SyncedAt  time.Time `json:"syncedAt"`
+}
+
+// Index lists completed workouts by when they were synced, so the warehouse
+// export reads the workouts synced since its last run rather than every user's
+// history. Entries are added as workouts are completed or backfilled, into a
+// partition per UTC day, and kept; a workout is synced once, so an entry
+// written again lands on the same key
+type Index struct {
+	store store.Store
+}
+
+// New creates an Index backed by s
+func New(s store.Store) *Index {
+	return &Index{store: s}
+}
+
+// bucketPK is the partition of workouts synced on the day of syncedAt
+func bucketPK(syncedAt time.Time) string {
+	return indexPK + "#" + syncedAt.UTC().Format(bucketLayout)
+}
+
+func workoutSK(e Entry) string {
+	return fmt.Sprintf("%s%s#%s#%s", workoutSKPrefix, e.SyncedAt.UTC().Format(syncedLayout), e.WorkoutID, store.UserPK(e.UserID))
+}
+
+// Add records that userID's workoutID was synced at syncedAt
+func (i *Index) Add(ctx context.Context, userID, workoutID string, syncedAt time.Time) error {
+	e := Entry{UserID: userID, WorkoutID: workoutID, SyncedAt: syncedAt.UTC()}
+	if err := i.store.Put(ctx, bucketPK(e.SyncedAt), workoutSK(e), e); err != nil {
+		return fmt.Errorf("failed to index synced workout: %w", err)
+	}
+	return nil
+}
+
+// AddMany records each of entries, as Add does, in as few writes as the store
+// allows
+func (i *Index) AddMany(ctx context.Context, entries []Entry) error {
+	writes := make([]store.Write, len(entries))
+	for n, e := range entries {
+		e.SyncedAt = e.SyncedAt.UTC()
+		writes[n] = store.Write{PK: bucketPK(e.SyncedAt), SK: workoutSK(e), Value: e}
+	}
+	for start := 0; start < len(writes); start += store.MaxBatchWrite {
+		if err := store.BatchPut(ctx, i.store, writes[start:min(start+store.MaxBatchWrite, len(writes))]); err != nil {
+			return fmt.Errorf("failed to index synced workouts: %w", err)
+		}
+	}
+	return nil
+}
+
+// SyncedBetween returns the workouts synced after after and up to through,
+// oldest first, reading the partitions of the days between them
+func (i *Index) SyncedBetween(ctx context.Context, after, through time.Time) ([]Entry, error) {
+	entries := []Entry{}
+	last := through.UTC().Format(bucketLayout)
+	for day := after.UTC().Truncate(24 * time.Hour); day.Format(bucketLayout) <= last; day = day.AddDate(0, 0, 1) {
+		items, err := i.store.Query(ctx, bucketPK(day), workoutSKPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list synced workouts: %w", err)
+		}
+		for _, item := range items {
+			var e Entry
+			if err := item.Decode(&e); err != nil {
+				return nil, err
+			}
+			if e.SyncedAt.After(after) && !e.SyncedAt.After(through) {
+				entries = append(entries, e)
+			}
+		}
+	}
+	sort.SliceStable(entries, func(a, b int) bool {
+		return entries[a].SyncedAt.Before(entries[b].SyncedAt)
+	})
+	return entries, nil
+}
@@ -0,0 +1,58 @@
+package syncindex
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+)
+
+func TestIndex(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 5, 4, 3, 0, 0, 0, time.UTC)
+
+	t.Run("lists workouts synced since the last run, oldest first", func(t *testing.T) {
+		// Arrange
+		index := New(store.NewMemoryStore())
+		lastRun := now.AddDate(0, 0, -1)
+		index.Add(ctx, "user-2", "w2", now.Add(-2*time.Hour))
+		index.Add(ctx, "user-1", "w1", now.Add(-20*time.Hour))
+		index.Add(ctx, "user-1", "w0", lastRun)
+		index.Add(ctx, "user-1", "w3", now.Add(time.Minute))
+
+		// Act
+		entries, err := index.SyncedBetween(ctx, lastRun, now)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(entries) != 2 || entries[0].WorkoutID != "w1" || entries[1].WorkoutID != "w2" {
+			t.Errorf("expected w1 then w2, got %+v", entries)
+		}
+	})
+
+	t.Run("indexes many workouts at once and rewrites them in place", func(t *testing.T) {
+		// Arrange
+		s := store.NewMemoryStore()
+		index := New(s)
+		entries := make([]Entry, 30)
+		for i := range entries {
+			entries[i] = Entry{UserID: "user-1", WorkoutID: string(rune('a' + i)), SyncedAt: now.Add(-time.Hour)}
+		}
+
+		// Act
+		err := index.AddMany(ctx, entries)
+		index.AddMany(ctx, entries[:5])
+		found, _ := index.SyncedBetween(ctx, now.AddDate(0, 0, -1), now)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(found) != 30 {
+			t.Errorf("expected 30 entries, got %d", len(found))
+		}
+	})
+}
@@ -0,0 +1,33 @@
+package telemetry
+
+import (
+	"context"
+
+	"athlete-forge/firehose"
+)
+
+// Sink delivers telemetry records for analysis
+type Sink interface {
+	// Send delivers records, failing if any of them was not accepted
+	Send(ctx context.Context, records []Record) error
+}
+
+// Firehose delivers records to a Kinesis Data Firehose stream, which buffers
+// them into S3
+type Firehose struct {
+	stream *firehose.Stream
+}
+
+// NewFirehose creates a sink writing to stream
+func NewFirehose(stream *firehose.Stream) *Firehose {
+	return &Firehose{stream: stream}
+}
+
+// Send puts records on the stream
+func (f *Firehose) Send(ctx context.Context, records []Record) error {
+	values := make([]interface{}, len(records))
+	for i, r := range records {
+		values[i] = r
+	}
+	return f.stream.Put(ctx, values...)
+}
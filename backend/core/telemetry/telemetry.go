@@ -0,0 +1,186 @@
+package telemetry
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// MaxEvents is the most events one batch may carry
+const MaxEvents = 100
+
+// Event timestamps are accepted from a week ago, so events the app queued while
+// offline are kept, up to a few minutes ahead to allow for clock skew
+const (
+	maxAge  = 7 * 24 * time.Hour
+	maxSkew = 5 * time.Minute
+)
+
+// maxStringLength bounds string properties, which are enumerations such as
+// screen names rather than free text
+const maxStringLength = 64
+
+// Property types
+const (
+	typeString  = "string"
+	typeNumber  = "number"
+	typeBoolean = "boolean"
+)
+
+// schemas lists the events the app may send and the type of each property they
+// may carry. Properties are deliberately coarse: nothing identifying, free text
+// or tied to a specific workout is collected
+var schemas = map[string]map[string]string{
+	"app_opened":          {"coldStart": typeBoolean},
+	"screen_viewed":       {"screen": typeString},
+	"feature_used":        {"feature": typeString},
+	"workout_started":     {"fromProgram": typeBoolean},
+	"workout_completed":   {"durationMinutes": typeNumber, "exercises": typeNumber, "sets": typeNumber},
+	"program_created":     {"rule": typeString, "exercises": typeNumber},
+	"checkin_saved":       {},
+	"export_requested":    {"days": typeNumber},
+	"error_shown":         {"code": typeString, "screen": typeString},
+	"onboarding_step":     {"step": typeString, "completed": typeBoolean},
+	"notification_opened": {"kind": typeString},
+}
+
+// platforms are the apps that send telemetry
+var platforms = map[string]bool{"ios": true, "android": true, "web": true}
+
+// appVersionPattern matches a semantic app version such as 2.14.0
+var appVersionPattern = regexp.MustCompile(`^\d{1,3}\.\d{1,3}\.\d{1,3}$`)
+
+// Event is one usage event as the app sends it
+type Event struct {
+	Name       string                 `json:"name"`
+	Timestamp  time.Time              `json:"timestamp"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// Batch is a set of events sent together by one app install
+type Batch struct {
+	Platform   string  `json:"platform"`
+	AppVersion string  `json:"appVersion"`
+	Events     []Event `json:"events"`
+}
+
+// Validate checks the batch against the event schemas as of now
+func (b *Batch) Validate(now time.Time) error {
+	if !platforms[b.Platform] {
+		return errors.New("platform must be ios, android or web")
+	}
+	if !appVersionPattern.MatchString(b.AppVersion) {
+		return errors.New("appVersion must be a version such as 2.14.0")
+	}
+	if len(b.Events) == 0 {
+		return errors.New("at least one event is required")
+	}
+	if len(b.Events) > MaxEvents {
+		return fmt.Errorf("a batch may carry at most %d events", MaxEvents)
+	}
+	for i, e := range b.Events {
+		if err := e.validate(now); err != nil {
+			return fmt.Errorf("events[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// validate checks the event against its schema
+func (e *Event) validate(now time.Time) error {
+	schema, ok := schemas[e.Name]
+	if !ok {
+		return fmt.Errorf("unknown event %q", e.Name)
+	}
+	if e.Timestamp.IsZero() {
+		return errors.New("timestamp is required")
+	}
+	if e.Timestamp.Before(now.Add(-maxAge)) {
+		return errors.New("timestamp must be within the last 7 days")
+	}
+	if e.Timestamp.After(now.Add(maxSkew)) {
+		return errors.New("timestamp must not be in the future")
+	}
+
+	names := make([]string, 0, len(e.Properties))
+	for name := range e.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		want, ok := schema[name]
+		if !ok {
+			return fmt.Errorf("%s does not have a %q property", e.Name, name)
+		}
+		if !hasType(e.Properties[name], want) {
+			return fmt.Errorf("property %q must be a %s", name, want)
+		}
+	}
+	return nil
+}
+
+// hasType reports whether a decoded JSON value is of the schema type want
+func hasType(value interface{}, want string) bool {
+	switch v := value.(type) {
+	case string:
+		return want == typeString && v != "" && len(v) <= maxStringLength
+	case float64:
+		return want == typeNumber
+	case bool:
+		return want == typeBoolean
+	}
+	return false
+}
+
+// Record is an anonymized event as it is delivered for analysis. The user is
+// replaced by an ID that is stable for the user, so usage can be counted per
+// person, but cannot be linked back to the account without the secret
+type Record struct {
+	Event       string                 `json:"event"`
+	Timestamp   time.Time              `json:"timestamp"`
+	ReceivedAt  time.Time              `json:"receivedAt"`
+	AnonymousID string                 `json:"anonymousId"`
+	Platform    string                 `json:"platform"`
+	AppVersion  string                 `json:"appVersion"`
+	Properties  map[string]interface{} `json:"properties,omitempty"`
+}
+
+// Anonymizer turns batches into records, pseudonymizing the user
+type Anonymizer struct {
+	secret []byte
+}
+
+// NewAnonymizer creates an Anonymizer that derives anonymous IDs with secret
+func NewAnonymizer(secret []byte) *Anonymizer {
+	return &Anonymizer{secret: secret}
+}
+
+// Records returns userID's batch as records received at receivedAt
+func (a *Anonymizer) Records(userID string, b Batch, receivedAt time.Time) []Record {
+	id := a.AnonymousID(userID)
+	records := make([]Record, 0, len(b.Events))
+	for _, e := range b.Events {
+		records = append(records, Record{
+			Event:       e.Name,
+			Timestamp:   e.Timestamp.UTC(),
+			ReceivedAt:  receivedAt.UTC(),
+			AnonymousID: id,
+			Platform:    b.Platform,
+			AppVersion:  b.AppVersion,
+			Properties:  e.Properties,
+		})
+	}
+	return records
+}
+
+// AnonymousID returns the keyed hash standing in for userID in records
+func (a *Anonymizer) AnonymousID(userID string) string {
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(userID))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
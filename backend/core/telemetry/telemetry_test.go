@@ -0,0 +1,75 @@
+package telemetry
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBatch_Validate(t *testing.T) {
+	now := time.Date(2024, 3, 14, 12, 0, 0, 0, time.UTC)
+	valid := func() Batch {
+		return Batch{Platform: "ios", AppVersion: "2.14.0", Events: []Event{
+			{Name: "screen_viewed", Timestamp: now.Add(-time.Hour), Properties: map[string]interface{}{"screen": "history"}},
+			{Name: "workout_completed", Timestamp: now, Properties: map[string]interface{}{"durationMinutes": 55.0, "sets": 18.0}},
+		}}
+	}
+
+	tests := []struct {
+		name    string
+		edit    func(b *Batch)
+		wantErr string
+	}{
+		{name: "valid batch", edit: func(b *Batch) {}},
+		{name: "unknown platform", edit: func(b *Batch) { b.Platform = "watch" }, wantErr: "platform"},
+		{name: "invalid app version", edit: func(b *Batch) { b.AppVersion = "latest" }, wantErr: "appVersion"},
+		{name: "no events", edit: func(b *Batch) { b.Events = nil }, wantErr: "at least one"},
+		{name: "unknown event", edit: func(b *Batch) { b.Events[1].Name = "purchase" }, wantErr: `events[1]: unknown event "purchase"`},
+		{name: "undeclared property", edit: func(b *Batch) { b.Events[0].Properties["email"] = "ada@example.com" }, wantErr: `"email"`},
+		{name: "wrongly typed property", edit: func(b *Batch) { b.Events[1].Properties["sets"] = "18" }, wantErr: "must be a number"},
+		{name: "long string property", edit: func(b *Batch) { b.Events[0].Properties["screen"] = strings.Repeat("a", 65) }, wantErr: "must be a string"},
+		{name: "old event", edit: func(b *Batch) { b.Events[0].Timestamp = now.AddDate(0, 0, -8) }, wantErr: "timestamp must be within the last 7 days"},
+		{name: "future event", edit: func(b *Batch) { b.Events[0].Timestamp = now.Add(time.Hour) }, wantErr: "timestamp must not be in the future"},
+		{name: "too many events", edit: func(b *Batch) { b.Events = make([]Event, MaxEvents+1) }, wantErr: "at most"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			b := valid()
+			tt.edit(&b)
+
+			// Act
+			err := b.Validate(now)
+
+			// Assert
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestAnonymizer(t *testing.T) {
+	t.Run("replaces the user with a stable keyed ID", func(t *testing.T) {
+		// Arrange
+		a := NewAnonymizer([]byte("secret"))
+		b := Batch{Platform: "android", AppVersion: "1.0.0", Events: []Event{{Name: "app_opened"}, {Name: "checkin_saved"}}}
+
+		// Act
+		records := a.Records("user-1", b, time.Now())
+
+		// Assert
+		if len(records) != 2 || records[0].AnonymousID != records[1].AnonymousID {
+			t.Fatalf("expected one anonymous ID across the batch, got %+v", records)
+		}
+		if id := records[0].AnonymousID; strings.Contains(id, "user-1") || len(id) != 32 {
+			t.Errorf("unexpected anonymous ID %q", id)
+		}
+		if other := NewAnonymizer([]byte("other")).AnonymousID("user-1"); other == records[0].AnonymousID {
+			t.Error("expected the ID to depend on the secret")
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package tempo
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// maxPhaseSeconds bounds a single phase; longer holds are better logged as
+// duration sets
+const maxPhaseSeconds = 60
+
+// ErrInvalid is returned for notation that is not four dash-separated phases
+var ErrInvalid = errors.New("tempo must be four phases in seconds, such as 3-1-1-0, with X for explosive")
+
+// Tempo is a rep's phase lengths in seconds, written eccentric-pause-concentric-pause,
+// so 3-1-1-0 lowers for three seconds, pauses for one and lifts in one. An X phase
+// is explosive and counts as zero seconds
+type Tempo struct {
+	Eccentric   int
+	BottomPause int
+	Concentric  int
+	TopPause    int
+}
+
+// Parse reads tempo notation such as 3-1-X-0
+func Parse(notation string) (Tempo, error) {
+	phases := strings.Split(notation, "-")
+	if len(phases) != 4 {
+		return Tempo{}, ErrInvalid
+	}
+
+	var seconds [4]int
+	for i, phase := range phases {
+		if strings.EqualFold(phase, "x") {
+			continue
+		}
+		n, err := strconv.Atoi(phase)
+		if err != nil || n < 0 || n > maxPhaseSeconds {
+			return Tempo{}, ErrInvalid
+		}
+		seconds[i] = n
+	}
+	return Tempo{Eccentric: seconds[0], BottomPause: seconds[1], Concentric: seconds[2], TopPause: seconds[3]}, nil
+}
+
+// Validate checks notation, allowing it to be empty
+func Validate(notation string) error {
+	if notation == "" {
+		return nil
+	}
+	_, err := Parse(notation)
+	return err
+}
+
+// RepSeconds is the time under tension of one rep
+func (t Tempo) RepSeconds() int {
+	return t.Eccentric + t.BottomPause + t.Concentric + t.TopPause
+}
+
+// TimeUnderTension returns the seconds under tension for reps performed at
+// notation, or 0 when the notation is empty or invalid
+func TimeUnderTension(notation string, reps int) int {
+	t, err := Parse(notation)
+	if err != nil || reps <= 0 {
+		return 0
+	}
+	return t.RepSeconds() * reps
+}
@@ -0,0 +1,39 @@
+package tempo
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		notation string
+		want     Tempo
+		wantErr  bool
+	}{
+		{notation: "3-1-1-0", want: Tempo{Eccentric: 3, BottomPause: 1, Concentric: 1}},
+		{notation: "4-0-X-1", want: Tempo{Eccentric: 4, TopPause: 1}},
+		{notation: "3110", wantErr: true},
+		{notation: "3-1-1", wantErr: true},
+		{notation: "3-1-a-0", wantErr: true},
+		{notation: "3--1-0", wantErr: true},
+		{notation: "90-0-1-0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.notation, func(t *testing.T) {
+			got, err := Parse(tt.notation)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTimeUnderTension(t *testing.T) {
+	if got := TimeUnderTension("3-1-1-0", 8); got != 40 {
+		t.Errorf("expected 40 seconds, got %d", got)
+	}
+	if got := TimeUnderTension("", 8); got != 0 {
+		t.Errorf("expected 0 seconds without a tempo, got %d", got)
+	}
+}
@@ -0,0 +1,191 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	// weightScale converts weights to integer hundredths so plate maths is exact
+	weightScale = 100
+
+	// MaxWeight bounds calculator inputs to keep the search table small
+	MaxWeight = 1500
+
+	// MaxPlateSizes and MaxPlatePairs bound the plates a calculation searches,
+	// which together with MaxWeight bound its cost
+	MaxPlateSizes = 20
+	MaxPlatePairs = 20
+
+	// MinPlateWeight is the lightest plate, below any fractional plate sold
+	MinPlateWeight = 0.25
+)
+
+var (
+	// ErrInvalidWeight is returned for non-positive, non-finite or oversized weights
+	ErrInvalidWeight = errors.New("weight must be a positive number no greater than 1500")
+
+	// ErrBelowBarWeight is returned when the target cannot be reached with an empty bar
+	ErrBelowBarWeight = errors.New("target weight is below the bar weight")
+)
+
+// Plate is a plate denomination and how many pairs of it are available
+type Plate struct {
+	Weight float64 `json:"weight"`
+	Pairs  int     `json:"pairs"`
+}
+
+// PlateLoad describes how to load a bar for a target weight
+type PlateLoad struct {
+	TargetWeight   float64 `json:"targetWeight"`
+	BarWeight      float64 `json:"barWeight"`
+	AchievedWeight float64 `json:"achievedWeight"`
+	PerSide        []Plate `json:"perSide"`
+	Remainder      float64 `json:"remainder"`
+	Exact          bool    `json:"exact"`
+}
+
+// CalculatePlates finds the heaviest loading not exceeding target using the available
+// plates, preferring the fewest plates when several loadings weigh the same
+func CalculatePlates(target, bar float64, available []Plate) (PlateLoad, error) {
+	if !validWeight(target) || target > MaxWeight || !validBar(bar) {
+		return PlateLoad{}, ErrInvalidWeight
+	}
+	if target < bar {
+		return PlateLoad{}, ErrBelowBarWeight
+	}
+
+	plates := normalizePlates(available)
+	perSideTarget := toUnits(target-bar) / 2
+
+	// best[s] holds the minimum plate count reaching per-side sum s, or -1 if
+	// unreachable, over the plates considered so far. used[p][s] is how many
+	// pairs of plate p the best loading of s takes, so the loading can be
+	// walked back from the last plate to the first
+	best := make([]int, perSideTarget+1)
+	for i := range best {
+		best[i] = -1
+	}
+	best[0] = 0
+	used := make([][]uint8, len(plates))
+
+	for p, plate := range plates {
+		w := toUnits(plate.Weight)
+		usable := plate.Pairs
+		if limit := perSideTarget / w; usable > limit {
+			usable = limit
+		}
+		next := append([]int(nil), best...)
+		used[p] = make([]uint8, perSideTarget+1)
+		for s := w; s <= perSideTarget; s++ {
+			// Lighter plates replace heavier ones only for fewer plates
+			for n := 1; n <= usable && n*w <= s; n++ {
+				prev := best[s-n*w]
+				if prev < 0 {
+					continue
+				}
+				if next[s] < 0 || prev+n < next[s] {
+					next[s] = prev + n
+					used[p][s] = uint8(n)
+				}
+			}
+		}
+		best = next
+	}
+
+	reached := perSideTarget
+	for reached > 0 && best[reached] < 0 {
+		reached--
+	}
+
+	var perSide []Plate
+	for p, s := len(plates)-1, reached; p >= 0; p-- {
+		if n := int(used[p][s]); n > 0 {
+			perSide = append([]Plate{{Weight: plates[p].Weight, Pairs: n}}, perSide...)
+			s -= n * toUnits(plates[p].Weight)
+		}
+	}
+
+	achieved := bar + fromUnits(reached*2)
+	return PlateLoad{
+		TargetWeight:   target,
+		BarWeight:      bar,
+		AchievedWeight: achieved,
+		PerSide:        perSide,
+		Remainder:      round2(target - achieved),
+		Exact:          toUnits(achieved) == toUnits(target),
+	}, nil
+}
+
+// SmallestIncrement returns the smallest total weight change the plates allow
+func SmallestIncrement(available []Plate) float64 {
+	plates := normalizePlates(available)
+	if len(plates) == 0 {
+		return 0
+	}
+	return plates[len(plates)-1].Weight * 2
+}
+
+// ValidatePlates checks plates are few enough, and heavy enough, to calculate
+// loadings with
+func ValidatePlates(plates []Plate) error {
+	if len(plates) > MaxPlateSizes {
+		return fmt.Errorf("at most %d plate sizes are allowed", MaxPlateSizes)
+	}
+	for _, plate := range plates {
+		if !validWeight(plate.Weight) || plate.Weight < MinPlateWeight || plate.Weight > MaxWeight {
+			return fmt.Errorf("plates must weigh between %g and %d", MinPlateWeight, MaxWeight)
+		}
+		if plate.Pairs < 0 || plate.Pairs > MaxPlatePairs {
+			return fmt.Errorf("plates must have between 0 and %d pairs", MaxPlatePairs)
+		}
+	}
+	return nil
+}
+
+// normalizePlates drops unusable entries, merges duplicates and sorts heaviest
+// first, keeping within MaxPlateSizes and MaxPlatePairs for plates saved
+// before they were enforced
+func normalizePlates(available []Plate) []Plate {
+	pairs := make(map[int]int)
+	for _, plate := range available {
+		if validWeight(plate.Weight) && toUnits(plate.Weight) >= toUnits(MinPlateWeight) && plate.Pairs > 0 {
+			pairs[toUnits(plate.Weight)] += plate.Pairs
+		}
+	}
+
+	plates := make([]Plate, 0, len(pairs))
+	for units, count := range pairs {
+		plates = append(plates, Plate{Weight: fromUnits(units), Pairs: min(count, MaxPlatePairs)})
+	}
+	sort.Slice(plates, func(i, j int) bool {
+		return plates[i].Weight > plates[j].Weight
+	})
+	if len(plates) > MaxPlateSizes {
+		plates = plates[:MaxPlateSizes]
+	}
+	return plates
+}
+
+func validWeight(w float64) bool {
+	return w > 0 && !math.IsNaN(w) && !math.IsInf(w, 0)
+}
+
+// validBar reports whether bar is a bar weight, which may be zero
+func validBar(bar float64) bool {
+	return bar == 0 || validWeight(bar)
+}
+
+func toUnits(w float64) int {
+	return int(math.Round(w * weightScale))
+}
+
+func fromUnits(u int) float64 {
+	return float64(u) / weightScale
+}
+
+func round2(w float64) float64 {
+	return math.Round(w*weightScale) / weightScale
+}
@@ -0,0 +1,179 @@
+package tools
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var standardKgPlates = []Plate{
+	{Weight: 25, Pairs: 4},
+	{Weight: 20, Pairs: 1},
+	{Weight: 10, Pairs: 1},
+	{Weight: 5, Pairs: 1},
+	{Weight: 2.5, Pairs: 1},
+	{Weight: 1.25, Pairs: 1},
+}
+
+func TestCalculatePlates(t *testing.T) {
+	tests := []struct {
+		name            string
+		target          float64
+		bar             float64
+		plates          []Plate
+		expectedPerSide []Plate
+		expectedWeight  float64
+		expectedExact   bool
+		expectedErr     error
+	}{
+		{
+			name:            "exact loading with mixed plates",
+			target:          102.5,
+			bar:             20,
+			plates:          standardKgPlates,
+			expectedPerSide: []Plate{{Weight: 25, Pairs: 1}, {Weight: 10, Pairs: 1}, {Weight: 5, Pairs: 1}, {Weight: 1.25, Pairs: 1}},
+			expectedWeight:  102.5,
+			expectedExact:   true,
+		},
+		{
+			name:            "empty bar needs no plates",
+			target:          20,
+			bar:             20,
+			plates:          standardKgPlates,
+			expectedPerSide: nil,
+			expectedWeight:  20,
+			expectedExact:   true,
+		},
+		{
+			name:            "falls back to heaviest achievable when target is not loadable",
+			target:          101,
+			bar:             20,
+			plates:          standardKgPlates,
+			expectedPerSide: []Plate{{Weight: 25, Pairs: 1}, {Weight: 10, Pairs: 1}, {Weight: 5, Pairs: 1}},
+			expectedWeight:  100,
+			expectedExact:   false,
+		},
+		{
+			name:            "finds combinations greedy selection would miss",
+			target:          60,
+			bar:             20,
+			plates:          []Plate{{Weight: 15, Pairs: 1}, {Weight: 10, Pairs: 2}},
+			expectedPerSide: []Plate{{Weight: 10, Pairs: 2}},
+			expectedWeight:  60,
+			expectedExact:   true,
+		},
+		{
+			name:        "rejects target below bar",
+			target:      15,
+			bar:         20,
+			plates:      standardKgPlates,
+			expectedErr: ErrBelowBarWeight,
+		},
+		{
+			name:        "rejects non-positive target",
+			target:      0,
+			bar:         20,
+			plates:      standardKgPlates,
+			expectedErr: ErrInvalidWeight,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			load, err := CalculatePlates(tt.target, tt.bar, tt.plates)
+
+			// Assert
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if load.AchievedWeight != tt.expectedWeight {
+				t.Errorf("expected achieved weight %v, got %v", tt.expectedWeight, load.AchievedWeight)
+			}
+			if load.Exact != tt.expectedExact {
+				t.Errorf("expected exact %v, got %v", tt.expectedExact, load.Exact)
+			}
+			if len(load.PerSide) != len(tt.expectedPerSide) {
+				t.Fatalf("expected per side %+v, got %+v", tt.expectedPerSide, load.PerSide)
+			}
+			for i := range load.PerSide {
+				if load.PerSide[i] != tt.expectedPerSide[i] {
+					t.Errorf("expected per side %+v, got %+v", tt.expectedPerSide, load.PerSide)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestCalculatePlates_Bounds(t *testing.T) {
+	t.Run("searches only the allowed plates quickly at the heaviest target", func(t *testing.T) {
+		// Arrange
+		var plates []Plate
+		for i := 0; i < 200; i++ {
+			plates = append(plates, Plate{Weight: 0.01 * float64(i+1), Pairs: 1000})
+		}
+		started := time.Now()
+
+		// Act
+		load, err := CalculatePlates(MaxWeight, 20, plates)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if elapsed := time.Since(started); elapsed > 2*time.Second {
+			t.Errorf("expected a bounded search, took %v", elapsed)
+		}
+		for _, plate := range load.PerSide {
+			if plate.Weight < MinPlateWeight || plate.Pairs > MaxPlatePairs {
+				t.Errorf("unexpected plates per side: %+v", load.PerSide)
+			}
+		}
+	})
+}
+
+func TestValidatePlates(t *testing.T) {
+	tooMany := make([]Plate, MaxPlateSizes+1)
+	for i := range tooMany {
+		tooMany[i] = Plate{Weight: float64(i + 1), Pairs: 1}
+	}
+	tests := []struct {
+		name   string
+		plates []Plate
+		valid  bool
+	}{
+		{name: "standard plates", plates: standardKgPlates, valid: true},
+		{name: "too many sizes", plates: tooMany},
+		{name: "too many pairs", plates: []Plate{{Weight: 20, Pairs: MaxPlatePairs + 1}}},
+		{name: "lighter than the smallest plate", plates: []Plate{{Weight: 0.01, Pairs: 1}}},
+		{name: "negative pairs", plates: []Plate{{Weight: 20, Pairs: -1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			err := ValidatePlates(tt.plates)
+
+			// Assert
+			if (err == nil) != tt.valid {
+				t.Errorf("expected valid %v, got %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestSmallestIncrement(t *testing.T) {
+	if got := SmallestIncrement(standardKgPlates); got != 2.5 {
+		t.Errorf("expected smallest increment 2.5, got %v", got)
+	}
+	if got := SmallestIncrement(nil); got != 0 {
+		t.Errorf("expected 0 for no plates, got %v", got)
+	}
+}
@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+// Kinds of equipment with their own loading increments
+const (
+	LoadingBarbell  = "barbell"
+	LoadingDumbbell = "dumbbell"
+	LoadingMachine  = "machine"
+)
+
+// Rounding holds the smallest load change available on each kind of equipment,
+// so computed loads can be rounded to ones that can actually be set up. Stacks
+// lists the selectable weights of particular machines, keyed by exercise name;
+// an exercise with a stack is rounded to its nearest weight instead of to Machine
+type Rounding struct {
+	Barbell  float64              `json:"barbell"`
+	Dumbbell float64              `json:"dumbbell"`
+	Machine  float64              `json:"machine"`
+	Stacks   map[string][]float64 `json:"stacks,omitempty"`
+}
+
+// Validate checks the increments and stacks are positive weights
+func (r Rounding) Validate() error {
+	if r.Barbell < 0 || r.Dumbbell < 0 || r.Machine < 0 {
+		return errors.New("rounding increments must not be negative")
+	}
+	for name, stack := range r.Stacks {
+		if strings.TrimSpace(name) == "" || len(stack) == 0 {
+			return errors.New("rounding stacks must name an exercise and list its weights")
+		}
+		for _, weight := range stack {
+			if !validWeight(weight) || weight > MaxWeight {
+				return errors.New("rounding stack weights must be positive numbers no greater than 1500")
+			}
+		}
+	}
+	return nil
+}
+
+// Round returns the achievable load nearest weight for the named exercise,
+// loaded on equipment of the given kind. Weights for unknown kinds, or kinds
+// without an increment, are returned unchanged
+func (r Rounding) Round(weight float64, name, kind string) float64 {
+	if weight <= 0 {
+		return weight
+	}
+	if stack := r.stack(name); kind == LoadingMachine && len(stack) > 0 {
+		nearest := stack[0]
+		for _, w := range stack[1:] {
+			if math.Abs(w-weight) < math.Abs(nearest-weight) {
+				nearest = w
+			}
+		}
+		return nearest
+	}
+
+	step := r.Increment(kind)
+	if step <= 0 {
+		return weight
+	}
+	rounded := fromUnits(int(math.Round(weight/step)) * toUnits(step))
+	if rounded <= 0 {
+		return round2(step)
+	}
+	return rounded
+}
+
+// Increment returns the increment for kind, or 0 for unknown kinds
+func (r Rounding) Increment(kind string) float64 {
+	switch kind {
+	case LoadingBarbell:
+		return r.Barbell
+	case LoadingDumbbell:
+		return r.Dumbbell
+	case LoadingMachine:
+		return r.Machine
+	}
+	return 0
+}
+
+func (r Rounding) stack(name string) []float64 {
+	for exercise, stack := range r.Stacks {
+		if strings.EqualFold(strings.TrimSpace(exercise), strings.TrimSpace(name)) {
+			return stack
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package tools
+
+import "math"
+
+// WarmupStep is one step of a warm-up scheme expressed as a fraction of the working weight
+type WarmupStep struct {
+	Percent float64
+	Reps    int
+}
+
+// DefaultWarmupScheme ramps from the empty bar to the working weight in four steps
+var DefaultWarmupScheme = []WarmupStep{
+	{Percent: 0, Reps: 10},
+	{Percent: 0.4, Reps: 5},
+	{Percent: 0.6, Reps: 3},
+	{Percent: 0.8, Reps: 2},
+}
+
+// WarmupSet is a generated warm-up set
+type WarmupSet struct {
+	Weight  float64 `json:"weight"`
+	Reps    int     `json:"reps"`
+	Percent int     `json:"percent"`
+}
+
+// GenerateWarmup builds warm-up sets leading to working, rounding each load down to a
+// multiple of increment above the bar and dropping sets that would not progress
+func GenerateWarmup(working, bar, increment float64, scheme []WarmupStep) ([]WarmupSet, error) {
+	if !validWeight(working) || working > MaxWeight || !validBar(bar) {
+		return nil, ErrInvalidWeight
+	}
+	if working < bar {
+		return nil, ErrBelowBarWeight
+	}
+
+	sets := []WarmupSet{}
+	last := -1.0
+	for _, step := range scheme {
+		weight := roundDownToIncrement(working*step.Percent, bar, increment)
+		if weight <= last || weight >= working {
+			continue
+		}
+		sets = append(sets, WarmupSet{
+			Weight:  weight,
+			Reps:    step.Reps,
+			Percent: int(math.Round(weight / working * 100)),
+		})
+		last = weight
+	}
+	return sets, nil
+}
+
+// roundDownToIncrement clamps weight to the bar and rounds the plate load to increment
+func roundDownToIncrement(weight, bar, increment float64) float64 {
+	if weight <= bar {
+		return bar
+	}
+	if increment <= 0 {
+		return round2(weight)
+	}
+	steps := math.Floor(toUnitsFloat(weight-bar) / toUnitsFloat(increment))
+	return round2(bar + steps*increment)
+}
+
+func toUnitsFloat(w float64) float64 {
+	return math.Round(w * weightScale)
+}
@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGenerateWarmup(t *testing.T) {
+	t.Run("ramps from the bar using the default scheme", func(t *testing.T) {
+		// Act
+		sets, err := GenerateWarmup(140, 20, 2.5, DefaultWarmupScheme)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := []WarmupSet{
+			{Weight: 20, Reps: 10, Percent: 14},
+			{Weight: 55, Reps: 5, Percent: 39},
+			{Weight: 82.5, Reps: 3, Percent: 59},
+			{Weight: 110, Reps: 2, Percent: 79},
+		}
+		if len(sets) != len(expected) {
+			t.Fatalf("expected %d sets, got %+v", len(expected), sets)
+		}
+		for i := range expected {
+			if sets[i] != expected[i] {
+				t.Errorf("set %d: expected %+v, got %+v", i, expected[i], sets[i])
+			}
+		}
+	})
+
+	t.Run("drops steps that do not progress for light working weights", func(t *testing.T) {
+		// Act
+		sets, err := GenerateWarmup(30, 20, 2.5, DefaultWarmupScheme)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(sets) != 2 || sets[0].Weight != 20 || sets[1].Weight != 22.5 {
+			t.Errorf("unexpected sets: %+v", sets)
+		}
+	})
+
+	t.Run("working weight equal to the bar needs no warm-up", func(t *testing.T) {
+		// Act
+		sets, err := GenerateWarmup(20, 20, 2.5, DefaultWarmupScheme)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(sets) != 0 {
+			t.Errorf("expected no sets, got %+v", sets)
+		}
+	})
+
+	t.Run("rejects a bar weight that is not finite", func(t *testing.T) {
+		if _, err := GenerateWarmup(100, math.NaN(), 2.5, DefaultWarmupScheme); err != ErrInvalidWeight {
+			t.Errorf("expected ErrInvalidWeight, got %v", err)
+		}
+	})
+
+	t.Run("rejects working weight below bar", func(t *testing.T) {
+		if _, err := GenerateWarmup(10, 20, 2.5, DefaultWarmupScheme); err != ErrBelowBarWeight {
+			t.Errorf("expected ErrBelowBarWeight, got %v", err)
+		}
+	})
+}
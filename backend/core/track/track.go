@@ -0,0 +1,334 @@
+package track
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// ContentType is the content type of a stored track
+const ContentType = "application/json"
+
+// GPXContentType is the content type of GPX uploads
+const GPXContentType = "application/gpx+xml"
+
+const (
+	// maxPoints bounds the points in a track
+	maxPoints = 50000
+
+	// maxPolylinePoints bounds the points kept in a summary's polyline, so
+	// activities stay small enough to store and list
+	maxPolylinePoints = 1000
+
+	// simplifyTolerance is the distance in metres a simplified polyline may
+	// stray from the recorded track before its tolerance is doubled to fit
+	// maxPolylinePoints
+	simplifyTolerance = 5.0
+
+	// profilePoints is the number of points an elevation profile is resampled
+	// to, spaced evenly by distance
+	profilePoints = 200
+
+	// climbThreshold is the change in altitude, in metres, counted as a climb
+	// or descent, so GPS altitude noise does not add up to phantom climbing
+	climbThreshold = 3.0
+
+	// earthRadius is the mean radius of the earth in metres
+	earthRadius = 6371008.8
+)
+
+// Point is a recorded GPS position, Offset seconds from the start of the
+// track, with the altitude in metres or 0 when the device did not record it
+type Point struct {
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+	Altitude float64 `json:"altitude,omitempty"`
+	Offset   int     `json:"offset,omitempty"`
+}
+
+// Bounds is the box a track fits within
+type Bounds struct {
+	MinLat float64 `json:"minLat"`
+	MinLon float64 `json:"minLon"`
+	MaxLat float64 `json:"maxLat"`
+	MaxLon float64 `json:"maxLon"`
+}
+
+// ProfilePoint is the altitude Distance metres into a track
+type ProfilePoint struct {
+	Distance float64 `json:"distance"`
+	Altitude float64 `json:"altitude"`
+}
+
+// Summary is what an activity stores about its track: a simplified, encoded
+// polyline for drawing the route, its bounds and an elevation profile. The
+// recorded track is stored whole at Key
+type Summary struct {
+	Key                 string         `json:"key"`
+	Points              int            `json:"points"`
+	Polyline            string         `json:"polyline"`
+	PolylinePoints      int            `json:"polylinePoints"`
+	Bounds              Bounds         `json:"bounds"`
+	DistanceMeters      float64        `json:"distanceMeters"`
+	ElevationGainMeters float64        `json:"elevationGainMeters"`
+	ElevationLossMeters float64        `json:"elevationLossMeters"`
+	Profile             []ProfilePoint `json:"profile,omitempty"`
+}
+
+// Key returns where the track of userID's activity is stored
+func Key(userID, activityID string) string {
+	return fmt.Sprintf("tracks/%s/%s.json", userID, activityID)
+}
+
+// Validate checks points are positions in time order
+func Validate(points []Point) error {
+	if len(points) < 2 {
+		return errors.New("a track needs at least 2 points")
+	}
+	if len(points) > maxPoints {
+		return fmt.Errorf("a track can have at most %d points", maxPoints)
+	}
+	for i, p := range points {
+		if math.IsNaN(p.Lat) || math.IsNaN(p.Lon) || p.Lat < -90 || p.Lat > 90 || p.Lon < -180 || p.Lon > 180 {
+			return fmt.Errorf("point %d is not a valid latitude and longitude", i+1)
+		}
+		if p.Offset < 0 || (i > 0 && p.Offset < points[i-1].Offset) {
+			return errors.New("point offsets must not decrease")
+		}
+	}
+	return nil
+}
+
+// Marshal encodes points for storage
+func Marshal(points []Point) ([]byte, error) {
+	return json.Marshal(points)
+}
+
+// Summarize builds the summary of a valid track stored at key
+func Summarize(key string, points []Point) Summary {
+	distances := cumulative(points)
+	simplified := simplify(points, simplifyTolerance)
+	for tolerance := simplifyTolerance * 2; len(simplified) > maxPolylinePoints; tolerance *= 2 {
+		simplified = simplify(points, tolerance)
+	}
+
+	summary := Summary{
+		Key:            key,
+		Points:         len(points),
+		Polyline:       Encode(simplified),
+		PolylinePoints: len(simplified),
+		Bounds:         bounds(points),
+		DistanceMeters: math.Round(distances[len(distances)-1]*10) / 10,
+		Profile:        profile(points, distances),
+	}
+	summary.ElevationGainMeters, summary.ElevationLossMeters = climbing(points)
+	return summary
+}
+
+// Encode encodes points as a Google encoded polyline with 5 decimal places,
+// the format map libraries draw routes from
+func Encode(points []Point) string {
+	var b strings.Builder
+	var lastLat, lastLon int
+	for _, p := range points {
+		lat, lon := int(math.Round(p.Lat*1e5)), int(math.Round(p.Lon*1e5))
+		encodeValue(&b, lat-lastLat)
+		encodeValue(&b, lon-lastLon)
+		lastLat, lastLon = lat, lon
+	}
+	return b.String()
+}
+
+func encodeValue(b *strings.Builder, v int) {
+	shifted := v << 1
+	if v < 0 {
+		shifted = ^shifted
+	}
+	for shifted >= 0x20 {
+		b.WriteByte(byte((0x20 | (shifted & 0x1f)) + 63))
+		shifted >>= 5
+	}
+	b.WriteByte(byte(shifted + 63))
+}
+
+// Distance returns the great-circle distance between a and b in metres
+func Distance(a, b Point) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat, dLon := lat2-lat1, (b.Lon-a.Lon)*math.Pi/180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// cumulative returns the distance covered by each point
+func cumulative(points []Point) []float64 {
+	distances := make([]float64, len(points))
+	for i := 1; i < len(points); i++ {
+		distances[i] = distances[i-1] + Distance(points[i-1], points[i])
+	}
+	return distances
+}
+
+// simplify keeps the points needed for the route to stay within tolerance
+// metres of the track, using Douglas-Peucker
+func simplify(points []Point, tolerance float64) []Point {
+	keep := make([]bool, len(points))
+	keep[0], keep[len(points)-1] = true, true
+	type span struct{ first, last int }
+	stack := []span{{0, len(points) - 1}}
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		farthest, distance := 0, 0.0
+		for i := s.first + 1; i < s.last; i++ {
+			if d := offTrack(points[i], points[s.first], points[s.last]); d > distance {
+				farthest, distance = i, d
+			}
+		}
+		if distance > tolerance {
+			keep[farthest] = true
+			stack = append(stack, span{s.first, farthest}, span{farthest, s.last})
+		}
+	}
+
+	simplified := []Point{}
+	for i, p := range points {
+		if keep[i] {
+			simplified = append(simplified, p)
+		}
+	}
+	return simplified
+}
+
+// offTrack returns how far p lies from the segment from a to b in metres, on
+// a flat projection around a that is accurate over a segment's length
+func offTrack(p, a, b Point) float64 {
+	scale := math.Cos(a.Lat * math.Pi / 180)
+	project := func(q Point) (float64, float64) {
+		return (q.Lon - a.Lon) * math.Pi / 180 * earthRadius * scale, (q.Lat - a.Lat) * math.Pi / 180 * earthRadius
+	}
+	px, py := project(p)
+	bx, by := project(b)
+	length := bx*bx + by*by
+	if length == 0 {
+		return math.Hypot(px, py)
+	}
+	t := math.Max(0, math.Min(1, (px*bx+py*by)/length))
+	return math.Hypot(px-t*bx, py-t*by)
+}
+
+func bounds(points []Point) Bounds {
+	b := Bounds{MinLat: points[0].Lat, MinLon: points[0].Lon, MaxLat: points[0].Lat, MaxLon: points[0].Lon}
+	for _, p := range points[1:] {
+		b.MinLat, b.MaxLat = math.Min(b.MinLat, p.Lat), math.Max(b.MaxLat, p.Lat)
+		b.MinLon, b.MaxLon = math.Min(b.MinLon, p.Lon), math.Max(b.MaxLon, p.Lon)
+	}
+	return b
+}
+
+// climbing totals the altitude gained and lost between points with an
+// altitude, ignoring changes smaller than climbThreshold
+func climbing(points []Point) (gain, loss float64) {
+	reference, started := 0.0, false
+	for _, p := range points {
+		if p.Altitude == 0 {
+			continue
+		}
+		if !started {
+			reference, started = p.Altitude, true
+			continue
+		}
+		switch change := p.Altitude - reference; {
+		case change >= climbThreshold:
+			gain += change
+			reference = p.Altitude
+		case change <= -climbThreshold:
+			loss -= change
+			reference = p.Altitude
+		}
+	}
+	return math.Round(gain*10) / 10, math.Round(loss*10) / 10
+}
+
+// profile resamples the altitudes of points to profilePoints evenly spaced by
+// distance, interpolating between recorded points; it is nil when the track
+// has no altitudes
+func profile(points []Point, distances []float64) []ProfilePoint {
+	var withAltitude []int
+	for i, p := range points {
+		if p.Altitude != 0 {
+			withAltitude = append(withAltitude, i)
+		}
+	}
+	total := distances[len(distances)-1]
+	if len(withAltitude) < 2 || total == 0 {
+		return nil
+	}
+
+	profile := make([]ProfilePoint, 0, profilePoints)
+	next := 0
+	for n := 0; n < profilePoints; n++ {
+		at := total * float64(n) / float64(profilePoints-1)
+		for next+1 < len(withAltitude)-1 && distances[withAltitude[next+1]] < at {
+			next++
+		}
+		a, b := withAltitude[next], withAltitude[next+1]
+		altitude := points[a].Altitude
+		if span := distances[b] - distances[a]; span > 0 {
+			fraction := math.Max(0, math.Min(1, (at-distances[a])/span))
+			altitude += fraction * (points[b].Altitude - points[a].Altitude)
+		}
+		profile = append(profile, ProfilePoint{Distance: math.Round(at*10) / 10, Altitude: math.Round(altitude*10) / 10})
+	}
+	return profile
+}
+
+// gpx is the part of a GPX file holding recorded tracks
+type gpx struct {
+	Tracks []struct {
+		Segments []struct {
+			Points []struct {
+				Lat  float64   `xml:"lat,attr"`
+				Lon  float64   `xml:"lon,attr"`
+				Ele  float64   `xml:"ele"`
+				Time time.Time `xml:"time"`
+			} `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+}
+
+// ParseGPX reads the track points of a GPX file, joining its tracks and
+// segments in order, and returns them with the time of the first point,
+// which is zero when the file has no times
+func ParseGPX(data []byte) ([]Point, time.Time, error) {
+	var file gpx
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	if err := decoder.Decode(&file); err != nil {
+		return nil, time.Time{}, fmt.Errorf("invalid GPX file: %w", err)
+	}
+
+	var points []Point
+	var start time.Time
+	for _, trk := range file.Tracks {
+		for _, segment := range trk.Segments {
+			for _, p := range segment.Points {
+				point := Point{Lat: p.Lat, Lon: p.Lon, Altitude: p.Ele}
+				if !p.Time.IsZero() {
+					if start.IsZero() {
+						start = p.Time
+					}
+					point.Offset = int(p.Time.Sub(start).Seconds())
+				}
+				points = append(points, point)
+			}
+		}
+	}
+	if len(points) == 0 {
+		return nil, time.Time{}, errors.New("GPX file has no track points")
+	}
+	return points, start.UTC(), nil
+}
@@ -0,0 +1,150 @@
+package track
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEncode(t *testing.T) {
+	// Arrange
+	points := []Point{{Lat: 38.5, Lon: -120.2}, {Lat: 40.7, Lon: -120.95}, {Lat: 43.252, Lon: -126.453}}
+
+	// Act
+	polyline := Encode(points)
+
+	// Assert
+	if polyline != "_p~iF~ps|U_ulLnnqC_mqNvxq`@" {
+		t.Errorf("unexpected polyline %q", polyline)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		points  []Point
+		wantErr bool
+	}{
+		{name: "valid", points: []Point{{Lat: 51.5, Lon: -0.1}, {Lat: 51.6, Lon: -0.1, Offset: 60}}},
+		{name: "single point", points: []Point{{Lat: 51.5, Lon: -0.1}}, wantErr: true},
+		{name: "latitude out of range", points: []Point{{Lat: 91, Lon: 0}, {Lat: 51.6, Lon: 0}}, wantErr: true},
+		{name: "offsets going backwards", points: []Point{{Lat: 51.5, Offset: 60}, {Lat: 51.6, Offset: 30}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.points); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// line returns n points heading north from the equator 10 metres apart, at
+// altitude(i)
+func line(n int, altitude func(i int) float64) []Point {
+	points := make([]Point, n)
+	for i := range points {
+		points[i] = Point{Lat: float64(i) * 10 / earthRadius * 180 / math.Pi, Altitude: altitude(i), Offset: i * 3}
+	}
+	return points
+}
+
+func TestSummarize(t *testing.T) {
+	t.Run("simplifies a straight line to its ends", func(t *testing.T) {
+		// Arrange
+		points := line(101, func(i int) float64 { return 100 })
+
+		// Act
+		s := Summarize("tracks/u/a.json", points)
+
+		// Assert
+		if s.Points != 101 || s.PolylinePoints != 2 || math.Abs(s.DistanceMeters-1000) > 0.1 {
+			t.Errorf("unexpected summary: %+v", s)
+		}
+		if s.Bounds.MinLat != 0 || s.Bounds.MaxLat != points[100].Lat {
+			t.Errorf("unexpected bounds: %+v", s.Bounds)
+		}
+	})
+
+	t.Run("keeps corners", func(t *testing.T) {
+		// Arrange
+		points := []Point{{Lat: 0, Lon: 0}, {Lat: 0.001, Lon: 0}, {Lat: 0.002, Lon: 0}, {Lat: 0.002, Lon: 0.001}}
+
+		// Act
+		s := Summarize("tracks/u/a.json", points)
+
+		// Assert
+		if s.PolylinePoints != 3 {
+			t.Errorf("expected the corner kept, got %d points", s.PolylinePoints)
+		}
+	})
+
+	t.Run("ignores altitude noise and profiles by distance", func(t *testing.T) {
+		// Arrange: a 50 m climb with a metre of jitter, then a 20 m descent
+		points := line(101, func(i int) float64 {
+			if i <= 50 {
+				return 100 + float64(i) + float64(i%2)
+			}
+			return 150 - float64(i-50)*0.4
+		})
+
+		// Act
+		s := Summarize("tracks/u/a.json", points)
+
+		// Assert
+		if s.ElevationGainMeters < 45 || s.ElevationGainMeters > 51 || s.ElevationLossMeters < 15 || s.ElevationLossMeters > 21 {
+			t.Errorf("unexpected climbing: gain %v loss %v", s.ElevationGainMeters, s.ElevationLossMeters)
+		}
+		if len(s.Profile) != profilePoints || s.Profile[0].Altitude != 100 || s.Profile[len(s.Profile)-1].Altitude != 130 {
+			t.Fatalf("unexpected profile ends: %+v", s.Profile)
+		}
+		if last := s.Profile[len(s.Profile)-1]; math.Abs(last.Distance-s.DistanceMeters) > 0.1 {
+			t.Errorf("expected the profile to end at the track's distance, got %v", last.Distance)
+		}
+	})
+
+	t.Run("omits the profile without altitudes", func(t *testing.T) {
+		s := Summarize("tracks/u/a.json", line(10, func(int) float64 { return 0 }))
+		if s.Profile != nil || s.ElevationGainMeters != 0 {
+			t.Errorf("expected no elevation data, got %+v", s)
+		}
+	})
+}
+
+func TestParseGPX(t *testing.T) {
+	t.Run("joins segments and offsets points from the first time", func(t *testing.T) {
+		// Arrange
+		file := []byte(`<?xml version="1.0"?>
+<gpx version="1.1" xmlns="http://www.topografix.com/GPX/1/1">
+  <trk><name>Morning run</name>
+    <trkseg>
+      <trkpt lat="51.5000" lon="-0.1200"><ele>12.5</ele><time>2024-03-05T07:00:00Z</time></trkpt>
+      <trkpt lat="51.5010" lon="-0.1200"><ele>13.0</ele><time>2024-03-05T07:00:30Z</time></trkpt>
+    </trkseg>
+    <trkseg>
+      <trkpt lat="51.5020" lon="-0.1210"><ele>14.0</ele><time>2024-03-05T07:01:00Z</time></trkpt>
+    </trkseg>
+  </trk>
+</gpx>`)
+
+		// Act
+		points, start, err := ParseGPX(file)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !start.Equal(time.Date(2024, 3, 5, 7, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected start %v", start)
+		}
+		if len(points) != 3 || points[2] != (Point{Lat: 51.502, Lon: -0.121, Altitude: 14, Offset: 60}) {
+			t.Errorf("unexpected points: %+v", points)
+		}
+	})
+
+	t.Run("rejects files without track points", func(t *testing.T) {
+		if _, _, err := ParseGPX([]byte(`<gpx><wpt lat="1" lon="2"/></gpx>`)); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
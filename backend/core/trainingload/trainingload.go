@@ -0,0 +1,285 @@
+package trainingload
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"athlete-forge/cardio"
+	"athlete-forge/hrzone"
+	"athlete-forge/workout"
+)
+
+// DateLayout is the format of daily load dates
+const DateLayout = "2006-01-02"
+
+// Rolling window lengths in days
+const (
+	AcuteDays   = 7
+	ChronicDays = 28
+)
+
+// Acute:chronic workload ratio bands
+const (
+	StatusInsufficientData = "insufficient-data"
+	StatusLow              = "low"
+	StatusOptimal          = "optimal"
+	StatusElevated         = "elevated"
+	StatusHigh             = "high"
+)
+
+const (
+	// lowRatio and elevatedRatio bound the 0.8-1.3 "sweet spot"
+	lowRatio      = 0.8
+	elevatedRatio = 1.3
+
+	// highRatio is the ratio above which injury and overtraining risk rise sharply
+	highRatio = 1.5
+
+	// defaultRPE is assumed for sets logged without an RPE
+	defaultRPE = 7
+
+	// minutesPerSet estimates session length when a workout has no usable timestamps
+	minutesPerSet = 3
+
+	// groupedSetMinutes and defaultRoundRest estimate grouped work, where sets run
+	// back to back and rest is only taken between rounds
+	groupedSetMinutes = 1
+	defaultRoundRest  = 2
+
+	// maxSessionMinutes caps lifting sessions left open for hours
+	maxSessionMinutes = 180
+
+	// unzonedWeight is the per-minute load for cardio without heart rate zones,
+	// equivalent to steady zone 2 work
+	unzonedWeight = 2
+)
+
+// Sources of load
+const (
+	SourceLifting = "lifting"
+	SourceCardio  = "cardio"
+)
+
+// Session is the load contributed by one workout or activity
+type Session struct {
+	Source string    `json:"source"`
+	ID     string    `json:"id"`
+	Date   string    `json:"date"`
+	Load   float64   `json:"load"`
+	At     time.Time `json:"-"`
+}
+
+// DayLoad is the total load for one day
+type DayLoad struct {
+	Date    string  `json:"date"`
+	Lifting float64 `json:"lifting"`
+	Cardio  float64 `json:"cardio"`
+	Total   float64 `json:"total"`
+}
+
+// Report is the rolling training load as of one day
+type Report struct {
+	Date     string    `json:"date"`
+	Acute    float64   `json:"acute"`
+	Chronic  float64   `json:"chronic"`
+	Ratio    float64   `json:"ratio"`
+	Status   string    `json:"status"`
+	Warnings []string  `json:"warnings"`
+	Days     []DayLoad `json:"days"`
+}
+
+// LiftingLoad scores a completed workout on the same scale as Edwards TRIMP using
+// session RPE: minutes multiplied by half the session RPE, so an RPE 10 minute
+// counts like a zone 5 minute. The session RPE is the athlete's rating of the
+// workout, or else the average set RPE weighted by reps so heavier volume
+// dominates, with duration and distance sets counting as one rep; duration comes
+// from the workout timestamps or is estimated per set
+func LiftingLoad(w workout.Workout) float64 {
+	var sets, reps int
+	var weightedRPE float64
+	for _, exercise := range w.Exercises {
+		for _, set := range exercise.Sets {
+			if !set.Performed() {
+				continue
+			}
+			rpe := set.RPE
+			if rpe == 0 {
+				rpe = defaultRPE
+			}
+			weight := max(set.Reps, 1)
+			sets++
+			reps += weight
+			weightedRPE += rpe * float64(weight)
+		}
+	}
+	if sets == 0 {
+		return 0
+	}
+
+	// Workouts logged after the fact are completed moments after they start, so
+	// timestamps are only trusted when they allow at least a minute per set
+	minutes := EstimatedMinutes(w)
+	if w.CompletedAt != nil {
+		if elapsed := w.CompletedAt.Sub(w.StartedAt).Minutes(); elapsed >= float64(sets) {
+			minutes = math.Min(elapsed, maxSessionMinutes)
+		}
+	}
+	sessionRPE := weightedRPE / float64(reps)
+	if w.Ratings != nil && w.Ratings.SessionRPE > 0 {
+		sessionRPE = w.Ratings.SessionRPE
+	}
+	return round(minutes * sessionRPE / 2)
+}
+
+// EstimatedMinutes estimates a workout's length from its blocks: standalone sets
+// include their rest, grouped sets run back to back with rest between rounds, and
+// AMRAP blocks last their time cap. Sets that logged no work are not counted
+func EstimatedMinutes(w workout.Workout) float64 {
+	var minutes float64
+	for _, block := range w.Blocks() {
+		sets := 0
+		for _, exercise := range block.Exercises {
+			for _, set := range exercise.Sets {
+				if set.Performed() {
+					sets++
+				}
+			}
+		}
+
+		switch {
+		case block.Group == nil:
+			minutes += float64(sets * minutesPerSet)
+		case block.Group.Type == workout.GroupAMRAP:
+			minutes += float64(block.Group.TimeCapSeconds) / 60
+		default:
+			rest := float64(defaultRoundRest)
+			if block.Group.RestSeconds > 0 {
+				rest = float64(block.Group.RestSeconds) / 60
+			}
+			minutes += float64(sets*groupedSetMinutes) + float64(block.Rounds)*rest
+		}
+	}
+	return minutes
+}
+
+// CardioLoad scores an activity with its TRIMP when config is set, otherwise
+// with the load of its pace or power zones for runs that have one, or else as
+// steady zone 2 work for its duration
+func CardioLoad(a cardio.Activity, config *hrzone.Config) float64 {
+	if config != nil && (len(a.HeartRate) > 0 || a.AverageHR > 0) {
+		return a.Summarize(*config).Load
+	}
+	if a.Run != nil && a.Run.Load > 0 {
+		return a.Run.Load
+	}
+	return round(float64(a.DurationSeconds) / 60 * unzonedWeight)
+}
+
+// Sessions converts completed workouts and activities into dated load entries
+func Sessions(workouts []workout.Workout, activities []cardio.Activity, config *hrzone.Config) []Session {
+	sessions := make([]Session, 0, len(workouts)+len(activities))
+	for _, w := range workouts {
+		if w.Status != workout.StatusCompleted {
+			continue
+		}
+		sessions = append(sessions, Session{Source: SourceLifting, ID: w.ID, At: w.At(), Load: LiftingLoad(w)})
+	}
+	for _, a := range activities {
+		sessions = append(sessions, Session{Source: SourceCardio, ID: a.ID, At: a.StartTime, Load: CardioLoad(a, config)})
+	}
+
+	for i := range sessions {
+		sessions[i].Date = sessions[i].At.UTC().Format(DateLayout)
+	}
+	sort.SliceStable(sessions, func(i, j int) bool { return sessions[i].At.Before(sessions[j].At) })
+	return sessions
+}
+
+// Calculate builds the report for the day containing asOf from the chronic window
+// of sessions ending that day
+func Calculate(sessions []Session, asOf time.Time) Report {
+	asOf = asOf.UTC()
+	end := time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, time.UTC)
+	start := end.AddDate(0, 0, -(ChronicDays - 1))
+
+	days := make([]DayLoad, ChronicDays)
+	index := make(map[string]int, ChronicDays)
+	for i := range days {
+		days[i].Date = start.AddDate(0, 0, i).Format(DateLayout)
+		index[days[i].Date] = i
+	}
+
+	firstSession := ""
+	for _, s := range sessions {
+		if firstSession == "" || s.Date < firstSession {
+			firstSession = s.Date
+		}
+		i, ok := index[s.Date]
+		if !ok {
+			continue
+		}
+		switch s.Source {
+		case SourceLifting:
+			days[i].Lifting = round(days[i].Lifting + s.Load)
+		default:
+			days[i].Cardio = round(days[i].Cardio + s.Load)
+		}
+		days[i].Total = round(days[i].Lifting + days[i].Cardio)
+	}
+
+	var acute, chronic float64
+	for i, day := range days {
+		chronic += day.Total
+		if i >= ChronicDays-AcuteDays {
+			acute += day.Total
+		}
+	}
+
+	report := Report{
+		Date:     end.Format(DateLayout),
+		Acute:    round(acute / AcuteDays),
+		Chronic:  round(chronic / ChronicDays),
+		Days:     days,
+		Warnings: []string{},
+	}
+
+	// A ratio against less than two weeks of history mostly reflects the missing
+	// history rather than a spike
+	if firstSession == "" || firstSession > end.AddDate(0, 0, -14).Format(DateLayout) || report.Chronic == 0 {
+		report.Status = StatusInsufficientData
+		return report
+	}
+
+	report.Ratio = math.Round(report.Acute/report.Chronic*100) / 100
+	switch {
+	case report.Ratio > highRatio:
+		report.Status = StatusHigh
+		report.Warnings = append(report.Warnings, "Acute load is more than 1.5x your chronic load; consider a deload or rest day to reduce overtraining and injury risk")
+	case report.Ratio > elevatedRatio:
+		report.Status = StatusElevated
+		report.Warnings = append(report.Warnings, "Acute load is rising faster than your chronic load; avoid further increases this week")
+	case report.Ratio < lowRatio:
+		report.Status = StatusLow
+	default:
+		report.Status = StatusOptimal
+	}
+
+	if report.Status != StatusHigh && consecutiveTrainingDays(days) >= 7 {
+		report.Warnings = append(report.Warnings, "You have trained 7 or more days in a row; schedule a rest day")
+	}
+	return report
+}
+
+// consecutiveTrainingDays counts the run of days with load ending on the last day
+func consecutiveTrainingDays(days []DayLoad) int {
+	count := 0
+	for i := len(days) - 1; i >= 0 && days[i].Total > 0; i-- {
+		count++
+	}
+	return count
+}
+
+func round(v float64) float64 {
+	return math.Round(v*10) / 10
+}
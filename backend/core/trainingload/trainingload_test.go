@@ -0,0 +1,211 @@
+package trainingload
+
+import (
+	"testing"
+	"time"
+
+	"athlete-forge/cardio"
+	"athlete-forge/hrzone"
+	"athlete-forge/workout"
+)
+
+func TestLiftingLoad(t *testing.T) {
+	started := time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC)
+
+	t.Run("uses session duration and rep-weighted RPE", func(t *testing.T) {
+		// Arrange
+		completed := started.Add(60 * time.Minute)
+		w := workout.Workout{
+			StartedAt:   started,
+			CompletedAt: &completed,
+			Exercises: []workout.Exercise{{Name: "Squat", Sets: []workout.Set{
+				{Reps: 5, Weight: 100, RPE: 8},
+				{Reps: 5, Weight: 100, RPE: 6},
+			}}},
+		}
+
+		// Act
+		load := LiftingLoad(w)
+
+		// Assert
+		if load != 210 {
+			t.Errorf("expected load 210, got %v", load)
+		}
+	})
+
+	t.Run("prefers the session RPE rating to set RPE", func(t *testing.T) {
+		// Arrange
+		completed := started.Add(60 * time.Minute)
+		w := workout.Workout{
+			StartedAt:   started,
+			CompletedAt: &completed,
+			Ratings:     &workout.Ratings{SessionRPE: 9},
+			Exercises:   []workout.Exercise{{Name: "Squat", Sets: []workout.Set{{Reps: 5, Weight: 100, RPE: 6}}}},
+		}
+
+		// Act
+		load := LiftingLoad(w)
+
+		// Assert
+		if load != 270 {
+			t.Errorf("expected load 270, got %v", load)
+		}
+	})
+
+	t.Run("estimates duration from set count", func(t *testing.T) {
+		// Arrange
+		w := workout.Workout{
+			StartedAt: started,
+			Exercises: []workout.Exercise{{Name: "Bench", Sets: []workout.Set{{Reps: 5}, {Reps: 5}, {Reps: 0}}}},
+		}
+
+		// Act
+		load := LiftingLoad(w)
+
+		// Assert
+		if load != 21 {
+			t.Errorf("expected load 21, got %v", load)
+		}
+	})
+
+	t.Run("counts duration and distance sets", func(t *testing.T) {
+		// Arrange
+		w := workout.Workout{
+			StartedAt: started,
+			Exercises: []workout.Exercise{
+				{Name: "Plank", Sets: []workout.Set{{Type: workout.SetDuration, DurationSeconds: 60, RPE: 8}}},
+				{Name: "Sled Push", Sets: []workout.Set{{Type: workout.SetDistance, Distance: 20, DistanceUnit: "m", RPE: 8}}},
+			},
+		}
+
+		// Act
+		load := LiftingLoad(w)
+
+		// Assert
+		if load != 24 {
+			t.Errorf("expected load 24, got %v", load)
+		}
+	})
+
+	t.Run("estimates grouped sets back to back with rest between rounds", func(t *testing.T) {
+		// Arrange
+		sets := []workout.Set{{Reps: 10}, {Reps: 10}, {Reps: 10}}
+		w := workout.Workout{
+			StartedAt: started,
+			Groups:    []workout.Group{{ID: "a", Type: workout.GroupSuperset, Rounds: 3, RestSeconds: 90}},
+			Exercises: []workout.Exercise{{Name: "Curl", Group: "a", Sets: sets}, {Name: "Pushdown", Group: "a", Sets: sets}},
+		}
+
+		// Act
+		load := LiftingLoad(w)
+
+		// Assert
+		if load != 36.8 {
+			t.Errorf("expected load 36.8, got %v", load)
+		}
+	})
+}
+
+func TestCardioLoad(t *testing.T) {
+	a := cardio.Activity{DurationSeconds: 1800, AverageHR: 145}
+
+	t.Run("uses TRIMP with zones", func(t *testing.T) {
+		if load := CardioLoad(a, &hrzone.Config{Method: hrzone.MethodMaxHR, MaxHR: 200}); load != 90 {
+			t.Errorf("expected load 90, got %v", load)
+		}
+	})
+
+	t.Run("uses the run's zone load without heart rate zones", func(t *testing.T) {
+		run := a
+		run.Run = &cardio.RunSummary{Load: 75}
+		if load := CardioLoad(run, nil); load != 75 {
+			t.Errorf("expected load 75, got %v", load)
+		}
+	})
+
+	t.Run("falls back to zone 2 without zones", func(t *testing.T) {
+		if load := CardioLoad(a, nil); load != 60 {
+			t.Errorf("expected load 60, got %v", load)
+		}
+	})
+}
+
+func TestCalculate(t *testing.T) {
+	asOf := time.Date(2024, 3, 28, 12, 0, 0, 0, time.UTC)
+	day := func(offset int) time.Time { return asOf.AddDate(0, 0, -offset) }
+
+	t.Run("reports insufficient data without two weeks of history", func(t *testing.T) {
+		// Arrange
+		sessions := []Session{{Source: SourceCardio, At: day(3), Load: 100}}
+		sessions[0].Date = sessions[0].At.Format(DateLayout)
+
+		// Act
+		report := Calculate(sessions, asOf)
+
+		// Assert
+		if report.Status != StatusInsufficientData || report.Ratio != 0 {
+			t.Errorf("unexpected report: %+v", report)
+		}
+	})
+
+	t.Run("steady training is optimal", func(t *testing.T) {
+		// Arrange
+		var sessions []Session
+		for i := 0; i < ChronicDays; i += 2 {
+			sessions = append(sessions, Session{Source: SourceLifting, At: day(i), Date: day(i).Format(DateLayout), Load: 100})
+		}
+
+		// Act
+		report := Calculate(sessions, asOf)
+
+		// Assert
+		if report.Status != StatusOptimal || len(report.Warnings) != 0 {
+			t.Errorf("unexpected report: %+v", report)
+		}
+		if report.Days[ChronicDays-1].Lifting != 100 || report.Days[ChronicDays-1].Date != "2024-03-28" {
+			t.Errorf("unexpected last day: %+v", report.Days[ChronicDays-1])
+		}
+	})
+
+	t.Run("warns when acute load spikes", func(t *testing.T) {
+		// Arrange
+		var sessions []Session
+		for i := 21; i < ChronicDays; i++ {
+			sessions = append(sessions, Session{Source: SourceCardio, At: day(i), Date: day(i).Format(DateLayout), Load: 30})
+		}
+		for i := 0; i < AcuteDays; i++ {
+			sessions = append(sessions, Session{Source: SourceLifting, At: day(i), Date: day(i).Format(DateLayout), Load: 150})
+		}
+
+		// Act
+		report := Calculate(sessions, asOf)
+
+		// Assert
+		if report.Status != StatusHigh {
+			t.Errorf("expected high status, got %s (ratio %v)", report.Status, report.Ratio)
+		}
+		if len(report.Warnings) != 1 {
+			t.Errorf("expected one warning, got %v", report.Warnings)
+		}
+	})
+}
+
+func TestSessions(t *testing.T) {
+	t.Run("skips active workouts and orders by time", func(t *testing.T) {
+		// Arrange
+		completed := time.Date(2024, 3, 5, 19, 0, 0, 0, time.UTC)
+		workouts := []workout.Workout{
+			{ID: "w1", Status: workout.StatusCompleted, StartedAt: completed.Add(-time.Hour), CompletedAt: &completed},
+			{ID: "w2", Status: workout.StatusActive, StartedAt: completed},
+		}
+		activities := []cardio.Activity{{ID: "a1", StartTime: time.Date(2024, 3, 4, 7, 0, 0, 0, time.UTC), DurationSeconds: 600}}
+
+		// Act
+		sessions := Sessions(workouts, activities, nil)
+
+		// Assert
+		if len(sessions) != 2 || sessions[0].ID != "a1" || sessions[1].ID != "w1" || sessions[1].Date != "2024-03-05" {
+			t.Errorf("unexpected sessions: %+v", sessions)
+		}
+	})
+}
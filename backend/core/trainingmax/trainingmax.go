@@ -0,0 +1,137 @@
+package trainingmax
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"athlete-forge/program"
+	"athlete-forge/progression"
+	"athlete-forge/store"
+	"athlete-forge/tools"
+)
+
+const trainingMaxSKPrefix = "TRAININGMAX#"
+
+// maxHistory is how many changes are kept per exercise, oldest dropped first
+const maxHistory = 100
+
+// SourceManual marks a training max set by the user; automatic changes are
+// sourced by the program rule's update, such as program.TrainingMaxAMRAP
+const SourceManual = "manual"
+
+// Entry is one change to a training max. WorkoutID and ProgramID identify the
+// session and program an automatic change came from
+type Entry struct {
+	Value     float64   `json:"value"`
+	Source    string    `json:"source"`
+	Note      string    `json:"note,omitempty"`
+	ProgramID string    `json:"programId,omitempty"`
+	WorkoutID string    `json:"workoutId,omitempty"`
+	SetAt     time.Time `json:"setAt"`
+}
+
+// TrainingMax is a user's current training max for an exercise, the load
+// percentage programs work from, with its changes oldest first
+type TrainingMax struct {
+	UserID    string    `json:"userId"`
+	Exercise  string    `json:"exercise"`
+	Value     float64   `json:"value"`
+	History   []Entry   `json:"history"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Validate checks a training max value is a usable weight
+func Validate(value float64) error {
+	if value <= 0 || value > tools.MaxWeight || math.IsNaN(value) {
+		return errors.New("training max must be a positive weight no greater than 1500")
+	}
+	return nil
+}
+
+// Set records e as the training max's latest value
+func (t *TrainingMax) Set(e Entry) {
+	t.Value = e.Value
+	t.UpdatedAt = e.SetAt
+	t.History = append(t.History, e)
+	if len(t.History) > maxHistory {
+		t.History = t.History[len(t.History)-maxHistory:]
+	}
+}
+
+// Feed sets the training max of each of p's percentage prescriptions of
+// exercise to value and recomputes its weight, reporting whether any changed
+func Feed(p *program.Program, exercise string, value float64) bool {
+	changed := false
+	for i, prescription := range p.Exercises {
+		if prescription.Rule.Type != program.RulePercentage || !strings.EqualFold(prescription.Exercise, exercise) || prescription.Rule.TrainingMax == value {
+			continue
+		}
+		p.Exercises[i].Rule.TrainingMax = value
+		p.Exercises[i].Weight = progression.PercentageWeight(p.Exercises[i].Rule)
+		changed = true
+	}
+	return changed
+}
+
+// key is the sort key suffix of an exercise's training max
+func key(exercise string) string {
+	return strings.ToLower(strings.TrimSpace(exercise))
+}
+
+// Repository loads and saves users' training maxes
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Get returns userID's training max for exercise
+func (r *Repository) Get(ctx context.Context, userID, exercise string) (*TrainingMax, error) {
+	var t TrainingMax
+	if err := r.store.Get(ctx, store.UserPK(userID), trainingMaxSKPrefix+key(exercise), &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// List returns all of userID's training maxes
+func (r *Repository) List(ctx context.Context, userID string) ([]TrainingMax, error) {
+	items, err := r.store.Query(ctx, store.UserPK(userID), trainingMaxSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list training maxes: %w", err)
+	}
+
+	maxes := make([]TrainingMax, 0, len(items))
+	for _, item := range items {
+		var t TrainingMax
+		if err := item.Decode(&t); err != nil {
+			return nil, err
+		}
+		maxes = append(maxes, t)
+	}
+	return maxes, nil
+}
+
+// Record adds e to userID's training max for exercise, creating it if needed,
+// and returns the updated training max
+func (r *Repository) Record(ctx context.Context, userID, exercise string, e Entry) (*TrainingMax, error) {
+	t, err := r.Get(ctx, userID, exercise)
+	if errors.Is(err, store.ErrNotFound) {
+		t, err = &TrainingMax{UserID: userID, Exercise: strings.TrimSpace(exercise)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load training max: %w", err)
+	}
+	t.Set(e)
+	if err := r.store.Put(ctx, store.UserPK(userID), trainingMaxSKPrefix+key(exercise), t); err != nil {
+		return nil, fmt.Errorf("failed to save training max: %w", err)
+	}
+	return t, nil
+}
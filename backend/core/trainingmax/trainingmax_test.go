@@ -0,0 +1,53 @@
+package trainingmax
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"athlete-forge/program"
+	"athlete-forge/store"
+)
+
+func TestFeed(t *testing.T) {
+	t.Run("updates the exercise's percentage prescriptions", func(t *testing.T) {
+		// Arrange
+		wave := program.Rule{Type: program.RulePercentage, TrainingMax: 100, Percentages: []float64{0.65, 0.75}, Step: 1}
+		p := &program.Program{Exercises: []program.Prescription{
+			{Exercise: "Squat", Sets: 3, Reps: 5, Weight: 75, Rule: wave},
+			{Exercise: "squat", Sets: 3, Reps: 5, Weight: 100, Rule: program.Rule{Type: program.RuleLinear, Increment: 5}},
+			{Exercise: "Bench Press", Sets: 3, Reps: 5, Weight: 75, Rule: wave},
+		}}
+
+		// Act
+		changed := Feed(p, "SQUAT", 120)
+
+		// Assert
+		if !changed || p.Exercises[0].Rule.TrainingMax != 120 || p.Exercises[0].Weight != 90 {
+			t.Errorf("expected the squat wave fed, got %+v", p.Exercises[0])
+		}
+		if p.Exercises[1].Weight != 100 || p.Exercises[2].Rule.TrainingMax != 100 {
+			t.Errorf("expected other prescriptions untouched, got %+v", p.Exercises)
+		}
+	})
+}
+
+func TestRepository_Record(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	r := NewRepository(store.NewMemoryStore())
+	at := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	r.Record(ctx, "user-1", "Squat", Entry{Value: 100, Source: SourceManual, SetAt: at})
+
+	// Act
+	got, err := r.Record(ctx, "user-1", " squat ", Entry{Value: 105, Source: program.TrainingMaxAMRAP, SetAt: at.AddDate(0, 0, 7)})
+	maxes, _ := r.List(ctx, "user-1")
+
+	// Assert
+	if err != nil || got.Value != 105 || got.Exercise != "Squat" || len(got.History) != 2 || got.History[0].Value != 100 {
+		t.Errorf("unexpected training max: %+v (%v)", got, err)
+	}
+	if len(maxes) != 1 {
+		t.Errorf("expected one training max per exercise, got %+v", maxes)
+	}
+}
@@ -0,0 +1,66 @@
+package userindex
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"athlete-forge/store"
+)
+
+const (
+	indexPK      = "USERS"
+	userSKPrefix = "USER#"
+)
+
+// PK is the partition of the index, keyed by user so each entry can be kept in
+// its user's region
+const PK = indexPK
+
+// Entry records that a user has training data
+type Entry struct {
+	UserID       string    `json:"userId"`
+	LastActiveAt time.Time `json:"lastActiveAt"`
+}
+
+// Index lists users with training data so scheduled jobs can visit each of them
+// without scanning the table
+type Index struct {
+	store store.Store
+}
+
+// New creates an Index backed by s
+func New(s store.Store) *Index {
+	return &Index{store: s}
+}
+
+// Touch records userID as active at now
+func (i *Index) Touch(ctx context.Context, userID string, now time.Time) error {
+	entry := Entry{UserID: userID, LastActiveAt: now.UTC()}
+	if err := i.store.Put(ctx, indexPK, userSKPrefix+userID, entry); err != nil {
+		return fmt.Errorf("failed to index user: %w", err)
+	}
+	return nil
+}
+
+// List returns every indexed user ordered by user ID
+func (i *Index) List(ctx context.Context) ([]Entry, error) {
+	items, err := i.store.Query(ctx, indexPK, userSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	entries := make([]Entry, 0, len(items))
+	for _, item := range items {
+		var e Entry
+		if err := item.Decode(&e); err != nil {
+			return nil, err
+		}
+		if e.UserID == "" {
+			e.UserID = strings.TrimPrefix(item.SK, userSKPrefix)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
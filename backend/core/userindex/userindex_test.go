@@ -0,0 +1,32 @@
+package userindex
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+)
+
+func TestIndex(t *testing.T) {
+	t.Run("lists each touched user once", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		index := New(store.NewMemoryStore())
+		now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+
+		// Act
+		index.Touch(ctx, "user-2", now)
+		index.Touch(ctx, "user-1", now)
+		index.Touch(ctx, "user-2", now.Add(time.Hour))
+		entries, err := index.List(ctx)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(entries) != 2 || entries[0].UserID != "user-1" || !entries[1].LastActiveAt.Equal(now.Add(time.Hour)) {
+			t.Errorf("unexpected entries: %+v", entries)
+		}
+	})
+}
@@ -0,0 +1,247 @@
+package warehouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"athlete-forge/dailylog"
+	"athlete-forge/parquet"
+	"athlete-forge/readiness"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+const (
+	watermarkPK = "WAREHOUSE"
+	watermarkSK = "WATERMARK"
+
+	// Prefix is the key prefix of exported files
+	Prefix = "warehouse/"
+)
+
+// Exported tables
+const (
+	TableWorkouts = "workouts"
+	TableSets     = "sets"
+	TableMetrics  = "daily_metrics"
+)
+
+// columns are each table's columns. Users are identified by their anonymous
+// telemetry ID, so exports can be joined with telemetry without naming anyone
+var columns = map[string][]parquet.Column{
+	TableWorkouts: {
+		{Name: "workout_id", Type: parquet.String},
+		{Name: "anonymous_id", Type: parquet.String},
+		{Name: "program_id", Type: parquet.String, Optional: true},
+		{Name: "gym_id", Type: parquet.String, Optional: true},
+		{Name: "started_at", Type: parquet.Timestamp},
+		{Name: "completed_at", Type: parquet.Timestamp},
+		{Name: "duration_minutes", Type: parquet.Double},
+		{Name: "exercises", Type: parquet.Int64},
+		{Name: "sets", Type: parquet.Int64},
+		{Name: "volume", Type: parquet.Double},
+		{Name: "exported_at", Type: parquet.Timestamp},
+	},
+	TableSets: {
+		{Name: "workout_id", Type: parquet.String},
+		{Name: "anonymous_id", Type: parquet.String},
+		{Name: "exercise", Type: parquet.String},
+		{Name: "exercise_index", Type: parquet.Int64},
+		{Name: "set_index", Type: parquet.Int64},
+		{Name: "set_type", Type: parquet.String},
+		{Name: "reps", Type: parquet.Int64},
+		{Name: "weight", Type: parquet.Double},
+		{Name: "rpe", Type: parquet.Double, Optional: true},
+		{Name: "duration_seconds", Type: parquet.Int64, Optional: true},
+		{Name: "distance", Type: parquet.Double, Optional: true},
+		{Name: "completed_at", Type: parquet.Timestamp},
+		{Name: "exported_at", Type: parquet.Timestamp},
+	},
+	TableMetrics: {
+		{Name: "anonymous_id", Type: parquet.String},
+		{Name: "date", Type: parquet.Date},
+		{Name: "readiness_score", Type: parquet.Int64, Optional: true},
+		{Name: "recovery_score", Type: parquet.Int64, Optional: true},
+		{Name: "sleep_hours", Type: parquet.Double, Optional: true},
+		{Name: "water_ml", Type: parquet.Int64, Optional: true},
+		{Name: "steps", Type: parquet.Int64, Optional: true},
+		{Name: "exported_at", Type: parquet.Timestamp},
+	},
+}
+
+// Watermark records how far the export has got. Workouts completed up to
+// WorkoutsThrough and metrics for days up to MetricsThrough have been exported.
+// Indexed is set once a run has read every user's history, after which the
+// index of synced workouts holds everything later runs need
+type Watermark struct {
+	WorkoutsThrough time.Time `json:"workoutsThrough"`
+	MetricsThrough  string    `json:"metricsThrough,omitempty"`
+	Indexed         bool      `json:"indexed,omitempty"`
+}
+
+// File is an exported Parquet file and its key
+type File struct {
+	Key  string
+	Rows int
+	Data []byte
+}
+
+// Batch collects the rows of one export run, partitioned by table and date
+type Batch struct {
+	exportedAt time.Time
+	runID      string
+	rows       map[string]map[string][][]interface{}
+}
+
+// NewBatch creates a batch for a run at exportedAt picking up from since. Files
+// are named after since rather than the run time, so a run retried after a
+// failed upload overwrites the failed run's files instead of duplicating rows
+func NewBatch(since Watermark, exportedAt time.Time) *Batch {
+	return &Batch{
+		exportedAt: exportedAt.UTC(),
+		runID:      since.WorkoutsThrough.UTC().Format("20060102T150405Z"),
+		rows:       map[string]map[string][][]interface{}{},
+	}
+}
+
+func (b *Batch) add(table, date string, row []interface{}) {
+	if b.rows[table] == nil {
+		b.rows[table] = map[string][][]interface{}{}
+	}
+	b.rows[table][date] = append(b.rows[table][date], row)
+}
+
+// AddWorkout adds a completed workout and its sets, partitioned by the day it
+// was completed
+func (b *Batch) AddWorkout(anonymousID string, w *workout.Workout) {
+	if w.CompletedAt == nil {
+		return
+	}
+	completed := w.CompletedAt.UTC()
+	date := completed.Format(readiness.DateLayout)
+
+	var sets int
+	var volume float64
+	for e, exercise := range w.Exercises {
+		for s, set := range exercise.Sets {
+			sets++
+			volume += set.Volume()
+			setType := set.Type
+			if setType == "" {
+				setType = workout.SetReps
+			}
+			b.add(TableSets, date, []interface{}{
+				w.ID, anonymousID, exercise.Name, e, s, setType, set.Reps, set.Weight,
+				optionalFloat(set.RPE), optionalInt(set.DurationSeconds), optionalFloat(set.Distance),
+				completed, b.exportedAt,
+			})
+		}
+	}
+	b.add(TableWorkouts, date, []interface{}{
+		w.ID, anonymousID, optionalString(w.ProgramID), optionalString(w.GymID),
+		w.StartedAt.UTC(), completed, completed.Sub(w.StartedAt).Minutes(),
+		len(w.Exercises), sets, volume, b.exportedAt,
+	})
+}
+
+// AddMetrics adds a day's check-in and daily log totals; either may be nil
+func (b *Batch) AddMetrics(anonymousID, date string, c *readiness.CheckIn, l *dailylog.Log) {
+	row := []interface{}{anonymousID, date, nil, nil, nil, nil, nil, b.exportedAt}
+	if c != nil {
+		row[2] = c.Score
+		if c.Recovery != nil {
+			row[3] = c.Recovery.Score
+		}
+		if c.Subjective() {
+			row[4] = c.SleepHours
+		}
+	}
+	if l != nil {
+		if l.SleepHours != nil {
+			row[4] = *l.SleepHours
+		}
+		if l.WaterML != nil {
+			row[5] = *l.WaterML
+		}
+		if l.Steps != nil {
+			row[6] = *l.Steps
+		}
+	}
+	b.add(TableMetrics, date, row)
+}
+
+// Files encodes the batch as a Parquet file per table and date, keyed
+// warehouse/<table>/dt=<date>/<run>.parquet so Athena can prune by partition
+func (b *Batch) Files() ([]File, error) {
+	var files []File
+	for table, partitions := range b.rows {
+		for date, rows := range partitions {
+			data, err := parquet.Write(columns[table], rows)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode %s for %s: %w", table, date, err)
+			}
+			files = append(files, File{
+				Key:  fmt.Sprintf("%s%s/dt=%s/%s.parquet", Prefix, table, date, b.runID),
+				Rows: len(rows),
+				Data: data,
+			})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Key < files[j].Key })
+	return files, nil
+}
+
+func optionalString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func optionalInt(n int) interface{} {
+	if n == 0 {
+		return nil
+	}
+	return n
+}
+
+func optionalFloat(f float64) interface{} {
+	if f == 0 {
+		return nil
+	}
+	return f
+}
+
+// Repository loads and saves the export watermark
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Watermark returns the export watermark, which is zero before the first export
+func (r *Repository) Watermark(ctx context.Context) (Watermark, error) {
+	var w Watermark
+	err := r.store.Get(ctx, watermarkPK, watermarkSK, &w)
+	if errors.Is(err, store.ErrNotFound) {
+		return Watermark{}, nil
+	}
+	if err != nil {
+		return Watermark{}, fmt.Errorf("failed to load export watermark: %w", err)
+	}
+	return w, nil
+}
+
+// SaveWatermark records how far the export has got
+func (r *Repository) SaveWatermark(ctx context.Context, w Watermark) error {
+	if err := r.store.Put(ctx, watermarkPK, watermarkSK, w); err != nil {
+		return fmt.Errorf("failed to save export watermark: %w", err)
+	}
+	return nil
+}
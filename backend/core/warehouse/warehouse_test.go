@@ -0,0 +1,87 @@
+package warehouse
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"athlete-forge/dailylog"
+	"athlete-forge/readiness"
+	"athlete-forge/store"
+	"athlete-forge/workout"
+)
+
+func TestBatch_Files(t *testing.T) {
+	exportedAt := time.Date(2024, 3, 12, 2, 0, 0, 0, time.UTC)
+	since := Watermark{WorkoutsThrough: time.Date(2024, 3, 11, 2, 0, 0, 0, time.UTC)}
+	completed := time.Date(2024, 3, 11, 18, 0, 0, 0, time.UTC)
+
+	t.Run("partitions each table by date and skips unfinished workouts", func(t *testing.T) {
+		// Arrange
+		b := NewBatch(since, exportedAt)
+		b.AddWorkout("anon-1", &workout.Workout{
+			ID: "w1", StartedAt: completed.Add(-time.Hour), CompletedAt: &completed,
+			Exercises: []workout.Exercise{{Name: "Squat", Sets: []workout.Set{{Reps: 5, Weight: 100}}}},
+		})
+		b.AddWorkout("anon-1", &workout.Workout{ID: "w2", StartedAt: completed})
+		steps := 9000
+		b.AddMetrics("anon-1", "2024-03-10", nil, &dailylog.Log{Steps: &steps})
+
+		// Act
+		files, err := b.Files()
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := []string{
+			"warehouse/daily_metrics/dt=2024-03-10/20240311T020000Z.parquet",
+			"warehouse/sets/dt=2024-03-11/20240311T020000Z.parquet",
+			"warehouse/workouts/dt=2024-03-11/20240311T020000Z.parquet",
+		}
+		if len(files) != len(want) {
+			t.Fatalf("expected %d files, got %d", len(want), len(files))
+		}
+		for i, f := range files {
+			if f.Key != want[i] || f.Rows != 1 {
+				t.Errorf("file %d: expected %s with 1 row, got %s with %d", i, want[i], f.Key, f.Rows)
+			}
+		}
+	})
+
+	t.Run("keeps unrated recovery-only days' sleep out of the metrics", func(t *testing.T) {
+		// Arrange
+		b := NewBatch(since, exportedAt)
+		c := &readiness.CheckIn{Score: 40, Recovery: &readiness.Recovery{Score: 40}}
+
+		// Act
+		b.AddMetrics("anon-1", "2024-03-10", c, nil)
+
+		// Assert
+		row := b.rows[TableMetrics]["2024-03-10"][0]
+		if row[2] != 40 || row[3] != 40 || row[4] != nil {
+			t.Errorf("unexpected metrics row: %v", row)
+		}
+	})
+}
+
+func TestRepository_Watermark(t *testing.T) {
+	ctx := context.Background()
+	r := NewRepository(store.NewMemoryStore())
+
+	// Act
+	before, err := r.Watermark(ctx)
+	saveErr := r.SaveWatermark(ctx, Watermark{WorkoutsThrough: time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC), MetricsThrough: "2024-03-11"})
+	after, _ := r.Watermark(ctx)
+
+	// Assert
+	if err != nil || saveErr != nil {
+		t.Fatalf("unexpected errors: %v, %v", err, saveErr)
+	}
+	if !before.WorkoutsThrough.IsZero() || before.MetricsThrough != "" {
+		t.Errorf("expected a zero watermark before the first export, got %+v", before)
+	}
+	if after.MetricsThrough != "2024-03-11" {
+		t.Errorf("expected the saved watermark, got %+v", after)
+	}
+}
@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ProviderGarmin identifies Garmin webhook deliveries
+const ProviderGarmin = "garmin"
+
+// Default Garmin signature headers
+const (
+	GarminSignatureHeader = "X-Garmin-Signature"
+	GarminTimestampHeader = "X-Garmin-Timestamp"
+)
+
+// garminTolerance is the allowed difference between the signed timestamp and now
+const garminTolerance = 5 * time.Minute
+
+// Garmin verifies HMAC-SHA256 signatures over "<timestamp>.<body>", sent hex encoded
+// with the Unix timestamp in separate headers. The timestamp is signed, so a captured
+// delivery cannot be replayed once it leaves the tolerance window, and the inbox
+// rejects repeats within it
+type Garmin struct {
+	Secret          []byte
+	SignatureHeader string
+	TimestampHeader string
+}
+
+// NewGarmin creates a Garmin verifier using the default headers
+func NewGarmin(secret []byte) *Garmin {
+	return &Garmin{Secret: secret, SignatureHeader: GarminSignatureHeader, TimestampHeader: GarminTimestampHeader}
+}
+
+// Provider returns the provider name
+func (g *Garmin) Provider() string {
+	return ProviderGarmin
+}
+
+// Verify checks a delivery's signature and timestamp
+func (g *Garmin) Verify(req Request, now time.Time) (*Event, error) {
+	if len(g.Secret) == 0 {
+		return nil, fmt.Errorf("%w: no secret configured", ErrInvalidSignature)
+	}
+	timestamp := req.Headers[g.TimestampHeader]
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing timestamp", ErrInvalidSignature)
+	}
+	signature, err := hex.DecodeString(req.Headers[g.SignatureHeader])
+	if err != nil || len(signature) == 0 {
+		return nil, fmt.Errorf("%w: missing signature", ErrInvalidSignature)
+	}
+
+	mac := hmac.New(sha256.New, g.Secret)
+	mac.Write([]byte(timestamp + "." + req.Body))
+	if !hmac.Equal(mac.Sum(nil), signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	signedAt := time.Unix(seconds, 0).UTC()
+	if now.Sub(signedAt) > garminTolerance || signedAt.Sub(now) > garminTolerance {
+		return nil, ErrStale
+	}
+
+	return &Event{
+		Provider:   ProviderGarmin,
+		Key:        bodyKey(req.Body),
+		OccurredAt: signedAt,
+		ReceivedAt: now,
+		Body:       req.Body,
+	}, nil
+}
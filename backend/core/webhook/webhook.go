@@ -0,0 +1,128 @@
+package webhook
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"athlete-forge/store"
+)
+
+const (
+	inboxPKPrefix = "WEBHOOK#"
+	eventSKPrefix = "EVENT#"
+)
+
+// Event statuses
+const (
+	StatusPending  = "pending"
+	StatusImported = "imported"
+)
+
+var (
+	// ErrInvalidSignature is returned when a delivery fails its provider's verification
+	ErrInvalidSignature = errors.New("webhook signature is invalid")
+
+	// ErrStale is returned for deliveries outside the provider's replay window
+	ErrStale = errors.New("webhook delivery is too old")
+)
+
+// Request is the part of an inbound HTTP request verifiers inspect; Headers keys
+// are canonicalized as by http.CanonicalHeaderKey
+type Request struct {
+	Headers map[string]string
+	Query   map[string]string
+	Body    string
+}
+
+// Event is a verified delivery waiting to be imported
+type Event struct {
+	Provider   string    `json:"provider"`
+	Key        string    `json:"key"`
+	OwnerID    string    `json:"ownerId,omitempty"`
+	Type       string    `json:"type,omitempty"`
+	OccurredAt time.Time `json:"occurredAt"`
+	ReceivedAt time.Time `json:"receivedAt"`
+	Status     string    `json:"status"`
+	Body       string    `json:"body"`
+}
+
+// Verifier checks that a delivery came from its provider and describes it
+type Verifier interface {
+	Provider() string
+	Verify(req Request, now time.Time) (*Event, error)
+}
+
+// Challenger is implemented by verifiers whose provider confirms a subscription
+// by asking the endpoint to echo a challenge
+type Challenger interface {
+	Challenge(query map[string]string) (string, error)
+}
+
+// Inbox records verified events once each, rejecting replays of earlier deliveries
+type Inbox struct {
+	store store.Store
+}
+
+// NewInbox creates an Inbox backed by s
+func NewInbox(s store.Store) *Inbox {
+	return &Inbox{store: s}
+}
+
+// Record stores e as pending and reports false when an event with the same key
+// was already recorded
+func (i *Inbox) Record(ctx context.Context, e *Event) (bool, error) {
+	pk := inboxPKPrefix + e.Provider
+	var existing Event
+	err := i.store.Get(ctx, pk, eventSKPrefix+e.Key, &existing)
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return false, fmt.Errorf("failed to check webhook event: %w", err)
+	}
+
+	e.Status = StatusPending
+	if err := i.store.Put(ctx, pk, eventSKPrefix+e.Key, e); err != nil {
+		return false, fmt.Errorf("failed to record webhook event: %w", err)
+	}
+	return true, nil
+}
+
+// Pending returns provider's events that have not been imported, oldest first
+func (i *Inbox) Pending(ctx context.Context, provider string) ([]Event, error) {
+	items, err := i.store.Query(ctx, inboxPKPrefix+provider, eventSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook events: %w", err)
+	}
+
+	events := []Event{}
+	for _, item := range items {
+		var e Event
+		if err := item.Decode(&e); err != nil {
+			return nil, err
+		}
+		if e.Status == StatusPending {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+// MarkImported records that e has been imported, so it is no longer pending
+func (i *Inbox) MarkImported(ctx context.Context, e *Event) error {
+	e.Status = StatusImported
+	if err := i.store.Put(ctx, inboxPKPrefix+e.Provider, eventSKPrefix+e.Key, e); err != nil {
+		return fmt.Errorf("failed to update webhook event: %w", err)
+	}
+	return nil
+}
+
+// bodyKey identifies a delivery by its content when the provider sends no ID
+func bodyKey(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
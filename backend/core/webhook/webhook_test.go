@@ -0,0 +1,240 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+)
+
+func garminRequest(secret, timestamp, body string) Request {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + body))
+	return Request{
+		Headers: map[string]string{
+			GarminTimestampHeader: timestamp,
+			GarminSignatureHeader: hex.EncodeToString(mac.Sum(nil)),
+		},
+		Body: body,
+	}
+}
+
+func TestStrava_Challenge(t *testing.T) {
+	strava := &Strava{VerifyToken: "verify-me", SubscriptionID: 120475}
+
+	tests := []struct {
+		name    string
+		query   map[string]string
+		wantErr bool
+	}{
+		{name: "matching token", query: map[string]string{"hub.mode": "subscribe", "hub.verify_token": "verify-me", "hub.challenge": "15f7d1a91c1f40f8a748fd134752feb3"}},
+		{name: "wrong token", query: map[string]string{"hub.mode": "subscribe", "hub.verify_token": "guess", "hub.challenge": "x"}, wantErr: true},
+		{name: "wrong mode", query: map[string]string{"hub.mode": "unsubscribe", "hub.verify_token": "verify-me", "hub.challenge": "x"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			challenge, err := strava.Challenge(tt.query)
+
+			// Assert
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.wantErr && challenge != tt.query["hub.challenge"] {
+				t.Errorf("expected challenge echoed, got %q", challenge)
+			}
+		})
+	}
+}
+
+func TestStrava_Verify(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	strava := &Strava{VerifyToken: "verify-me", SubscriptionID: 120475}
+	event := func(subscriptionID int64, at time.Time) Request {
+		return Request{Body: fmt.Sprintf(`{"aspect_type":"create","event_time":%d,"object_id":1360128428,"object_type":"activity","owner_id":134815,"subscription_id":%d,"updates":{}}`, at.Unix(), subscriptionID)}
+	}
+
+	t.Run("accepts events for our subscription", func(t *testing.T) {
+		// Act
+		e, err := strava.Verify(event(120475, now.Add(-time.Minute)), now)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if e.OwnerID != "134815" || e.Type != "activity.create" || e.Key == "" {
+			t.Errorf("unexpected event: %+v", e)
+		}
+	})
+
+	t.Run("rejects other subscriptions", func(t *testing.T) {
+		// Act
+		_, err := strava.Verify(event(999, now), now)
+
+		// Assert
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("expected ErrInvalidSignature, got %v", err)
+		}
+	})
+
+	t.Run("rejects stale events", func(t *testing.T) {
+		// Act
+		_, err := strava.Verify(event(120475, now.Add(-48*time.Hour)), now)
+
+		// Assert
+		if !errors.Is(err, ErrStale) {
+			t.Errorf("expected ErrStale, got %v", err)
+		}
+	})
+}
+
+func TestGarmin_Verify(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	garmin := NewGarmin([]byte("shared-secret"))
+	body := `{"activities":[{"userId":"4aacafe82427c251df9c9592d0c06768","summaryId":"5001968355"}]}`
+	timestamp := fmt.Sprint(now.Unix())
+
+	t.Run("accepts a valid signature", func(t *testing.T) {
+		// Act
+		e, err := garmin.Verify(garminRequest("shared-secret", timestamp, body), now)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if e.Provider != ProviderGarmin || e.Key != bodyKey(body) {
+			t.Errorf("unexpected event: %+v", e)
+		}
+	})
+
+	t.Run("rejects a tampered body", func(t *testing.T) {
+		// Arrange
+		request := garminRequest("shared-secret", timestamp, body)
+		request.Body = `{"activities":[]}`
+
+		// Act
+		_, err := garmin.Verify(request, now)
+
+		// Assert
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("expected ErrInvalidSignature, got %v", err)
+		}
+	})
+
+	t.Run("rejects the wrong secret", func(t *testing.T) {
+		// Act
+		_, err := garmin.Verify(garminRequest("other-secret", timestamp, body), now)
+
+		// Assert
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("expected ErrInvalidSignature, got %v", err)
+		}
+	})
+
+	t.Run("rejects old signatures", func(t *testing.T) {
+		// Arrange
+		old := fmt.Sprint(now.Add(-10 * time.Minute).Unix())
+
+		// Act
+		_, err := garmin.Verify(garminRequest("shared-secret", old, body), now)
+
+		// Assert
+		if !errors.Is(err, ErrStale) {
+			t.Errorf("expected ErrStale, got %v", err)
+		}
+	})
+}
+
+func TestPolar_Verify(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	polar := NewPolar([]byte("signature-key"))
+	signed := func(secret, body string) Request {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+		return Request{Headers: map[string]string{PolarSignatureHeader: hex.EncodeToString(mac.Sum(nil))}, Body: body}
+	}
+	body := `{"event":"EXERCISE","user_id":475,"entity_id":"aQlC83","timestamp":"2026-03-02T11:58:00Z","url":"https://www.polaraccesslink.com/v3/exercises/aQlC83"}`
+
+	t.Run("accepts a signed event keyed by its entity", func(t *testing.T) {
+		// Act
+		e, err := polar.Verify(signed("signature-key", body), now)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if e.Provider != ProviderPolar || e.Key != "EXERCISE-aQlC83" || e.OwnerID != "475" || e.Type != "EXERCISE" {
+			t.Errorf("unexpected event: %+v", e)
+		}
+	})
+
+	t.Run("rejects the wrong secret", func(t *testing.T) {
+		// Act
+		_, err := polar.Verify(signed("other-key", body), now)
+
+		// Assert
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("expected ErrInvalidSignature, got %v", err)
+		}
+	})
+
+	t.Run("rejects old events", func(t *testing.T) {
+		// Act
+		_, err := polar.Verify(signed("signature-key", body), now.Add(48*time.Hour))
+
+		// Assert
+		if !errors.Is(err, ErrStale) {
+			t.Errorf("expected ErrStale, got %v", err)
+		}
+	})
+}
+
+func TestInbox_Record(t *testing.T) {
+	ctx := context.Background()
+	inbox := NewInbox(store.NewMemoryStore())
+	event := func() *Event {
+		return &Event{Provider: ProviderStrava, Key: "activity-1-create-1772452800", Body: "{}"}
+	}
+
+	// Act
+	first, err := inbox.Record(ctx, event())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, _ := inbox.Record(ctx, event())
+	pending, _ := inbox.Pending(ctx, ProviderStrava)
+
+	// Assert
+	if !first || second {
+		t.Errorf("expected only the first delivery recorded, got %v and %v", first, second)
+	}
+	if len(pending) != 1 || pending[0].Status != StatusPending {
+		t.Errorf("unexpected pending events: %+v", pending)
+	}
+}
+
+func TestInbox_MarkImported(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	inbox := NewInbox(store.NewMemoryStore())
+	e := &Event{Provider: ProviderGarmin, Key: "body-hash", Body: "{}"}
+	inbox.Record(ctx, e)
+
+	// Act
+	err := inbox.MarkImported(ctx, e)
+
+	// Assert
+	pending, _ := inbox.Pending(ctx, ProviderGarmin)
+	if err != nil || len(pending) != 0 {
+		t.Errorf("expected no pending events once imported, got %+v (%v)", pending, err)
+	}
+	if repeated, _ := inbox.Record(ctx, &Event{Provider: ProviderGarmin, Key: "body-hash"}); repeated {
+		t.Error("expected an imported event to still catch repeats")
+	}
+}
@@ -0,0 +1,168 @@
+package workout
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Group types
+const (
+	GroupSuperset = "superset"
+	GroupGiantSet = "giant-set"
+	GroupCircuit  = "circuit"
+	GroupAMRAP    = "amrap"
+)
+
+// Group performs its exercises back to back in rounds, one set of each per round,
+// resting only between rounds. Exercises join a group by naming its ID and must
+// be listed consecutively in the workout, which sets the order within the group.
+// AMRAP groups repeat their rounds for TimeCapSeconds and record how many were done
+type Group struct {
+	ID              string `json:"id"`
+	Type            string `json:"type"`
+	Rounds          int    `json:"rounds,omitempty"`
+	RestSeconds     int    `json:"restSeconds,omitempty"`
+	TimeCapSeconds  int    `json:"timeCapSeconds,omitempty"`
+	RoundsCompleted int    `json:"roundsCompleted,omitempty"`
+	ExtraReps       int    `json:"extraReps,omitempty"`
+}
+
+// Block is one step of a workout: a group with its exercises, or a single
+// exercise performed on its own, with its sets totalled by type
+type Block struct {
+	Group            *Group     `json:"group,omitempty"`
+	Exercises        []Exercise `json:"exercises"`
+	Rounds           int        `json:"rounds"`
+	Sets             int        `json:"sets"`
+	Volume           float64    `json:"volume"`
+	TimeUnderTension int        `json:"timeUnderTension"`
+	DurationSeconds  int        `json:"durationSeconds"`
+	DistanceMetres   float64    `json:"distanceMetres"`
+}
+
+// validateGroups checks group definitions and that each group's exercises are
+// consecutive and of a size its type allows
+func (w *Workout) validateGroups() error {
+	groups := make(map[string]*Group, len(w.Groups))
+	for i := range w.Groups {
+		g := &w.Groups[i]
+		if g.ID == "" {
+			return errors.New("group id is required")
+		}
+		if _, ok := groups[g.ID]; ok {
+			return fmt.Errorf("group %q is defined twice", g.ID)
+		}
+		if err := g.validate(); err != nil {
+			return fmt.Errorf("group %q: %w", g.ID, err)
+		}
+		groups[g.ID] = g
+	}
+
+	members := map[string]int{}
+	finished := map[string]bool{}
+	previous := ""
+	for _, exercise := range w.Exercises {
+		id := exercise.Group
+		if id != previous && previous != "" {
+			finished[previous] = true
+		}
+		previous = id
+		if id == "" {
+			continue
+		}
+		g, ok := groups[id]
+		if !ok {
+			return fmt.Errorf("exercise %q references unknown group %q", exercise.Name, id)
+		}
+		if finished[id] {
+			return fmt.Errorf("group %q exercises must be listed together", id)
+		}
+		if g.Rounds > 0 && len(exercise.Sets) > g.Rounds {
+			return fmt.Errorf("exercise %q has more sets than group %q has rounds", exercise.Name, id)
+		}
+		members[id]++
+	}
+
+	for _, g := range w.Groups {
+		count := members[g.ID]
+		switch {
+		case count == 0:
+			return fmt.Errorf("group %q has no exercises", g.ID)
+		case g.Type == GroupSuperset && count != 2:
+			return fmt.Errorf("superset %q must have exactly 2 exercises", g.ID)
+		case g.Type == GroupGiantSet && count < 3:
+			return fmt.Errorf("giant set %q must have at least 3 exercises", g.ID)
+		case g.Type == GroupCircuit && count < 2:
+			return fmt.Errorf("circuit %q must have at least 2 exercises", g.ID)
+		}
+	}
+	return nil
+}
+
+func (g *Group) validate() error {
+	switch g.Type {
+	case GroupSuperset, GroupGiantSet, GroupCircuit:
+		if g.TimeCapSeconds != 0 || g.RoundsCompleted != 0 || g.ExtraReps != 0 {
+			return errors.New("time cap and completed rounds only apply to amrap groups")
+		}
+	case GroupAMRAP:
+		if g.TimeCapSeconds <= 0 {
+			return errors.New("amrap groups require a positive timeCapSeconds")
+		}
+		if g.Rounds != 0 {
+			return errors.New("amrap groups record roundsCompleted instead of rounds")
+		}
+	default:
+		return fmt.Errorf("type must be %q, %q, %q or %q", GroupSuperset, GroupGiantSet, GroupCircuit, GroupAMRAP)
+	}
+	if g.Rounds < 0 || g.RestSeconds < 0 || g.RoundsCompleted < 0 || g.ExtraReps < 0 {
+		return errors.New("rounds, rest and reps must not be negative")
+	}
+	return nil
+}
+
+// Blocks splits the workout into its groups and standalone exercises, in order.
+// A group's rounds are those every exercise logged a set for, or the recorded
+// rounds for AMRAP groups
+func (w *Workout) Blocks() []Block {
+	groups := make(map[string]*Group, len(w.Groups))
+	for i := range w.Groups {
+		groups[w.Groups[i].ID] = &w.Groups[i]
+	}
+
+	blocks := []Block{}
+	for _, exercise := range w.Exercises {
+		g := groups[exercise.Group]
+		if g != nil && len(blocks) > 0 && blocks[len(blocks)-1].Group == g {
+			blocks[len(blocks)-1].Exercises = append(blocks[len(blocks)-1].Exercises, exercise)
+			continue
+		}
+		blocks = append(blocks, Block{Group: g, Exercises: []Exercise{exercise}})
+	}
+
+	for i := range blocks {
+		b := &blocks[i]
+		rounds := math.MaxInt
+		for _, exercise := range b.Exercises {
+			rounds = min(rounds, len(exercise.Sets))
+			for _, set := range exercise.Sets {
+				b.Sets++
+				b.Volume += set.Volume()
+				b.TimeUnderTension += set.TimeUnderTension()
+				b.DistanceMetres += set.Metres()
+				if set.Type == SetDuration {
+					b.DurationSeconds += set.DurationSeconds
+				}
+			}
+		}
+		b.Volume = math.Round(b.Volume*10) / 10
+		b.DistanceMetres = math.Round(b.DistanceMetres*10) / 10
+		if b.Group != nil && b.Group.Type == GroupAMRAP {
+			b.Rounds = b.Group.RoundsCompleted
+		} else if b.Group != nil {
+			b.Rounds = rounds
+		}
+	}
+	return blocks
+}
@@ -0,0 +1,189 @@
+package workout
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"athlete-forge/tempo"
+)
+
+// Set types; an empty type is a reps set
+const (
+	SetReps     = "reps"
+	SetDuration = "duration"
+	SetDistance = "distance"
+)
+
+// accommodatingAverage is the share of chain weight and peak band tension
+// counted as load: chains pile on the floor and bands slacken at the bottom of
+// the lift, so on average about half of either is lifted
+const accommodatingAverage = 0.5
+
+// MaxVelocity bounds logged bar velocities, in metres per second
+const MaxVelocity = 5
+
+// Distance units and their length in metres
+var distanceUnits = map[string]float64{
+	"m":  1,
+	"km": 1000,
+	"yd": 0.9144,
+	"mi": 1609.344,
+}
+
+// Set is a single logged set. Reps sets record reps at Weight, optionally with a
+// Tempo such as 3-1-1-0 and Assistance from a machine or Band that is subtracted
+// from the load. Accommodating resistance adds to it: Chains is the weight of
+// chains hung on the bar and BandTension the tension of bands anchored to it at
+// lockout, both in the set's unit. Velocities are the mean concentric bar
+// velocities of the reps, in metres per second, as measured by a device such as
+// OpenBarbell. AMRAP marks a reps set taken for as many reps as possible, so its
+// Reps are a performance rather than a target. Duration sets such as planks record DurationSeconds, and distance
+// sets such as sled pushes and carries record Distance in DistanceUnit; both may
+// carry an external Weight. LoggedAt is when the set was performed in server
+// time, and ClientLoggedAt the device's time for it when the two differ
+type Set struct {
+	Type            string    `json:"type,omitempty"`
+	Reps            int       `json:"reps"`
+	Weight          float64   `json:"weight"`
+	AMRAP           bool      `json:"amrap,omitempty"`
+	RPE             float64   `json:"rpe,omitempty"`
+	Tempo           string    `json:"tempo,omitempty"`
+	Assistance      float64   `json:"assistance,omitempty"`
+	Band            string    `json:"band,omitempty"`
+	Chains          float64   `json:"chains,omitempty"`
+	BandTension     float64   `json:"bandTension,omitempty"`
+	Velocities      []float64 `json:"velocities,omitempty"`
+	DurationSeconds int       `json:"durationSeconds,omitempty"`
+	Distance        float64   `json:"distance,omitempty"`
+	DistanceUnit    string    `json:"distanceUnit,omitempty"`
+
+	LoggedAt       *time.Time `json:"loggedAt,omitempty"`
+	ClientLoggedAt *time.Time `json:"clientLoggedAt,omitempty"`
+}
+
+// Validate checks the set has the measurements its type needs
+func (s Set) Validate() error {
+	if s.Reps < 0 || s.Weight < 0 || s.Assistance < 0 || s.Chains < 0 || s.BandTension < 0 || s.DurationSeconds < 0 || s.Distance < 0 {
+		return errors.New("set reps, weight, assistance, chains, band tension, duration and distance must not be negative")
+	}
+	if s.RPE != 0 && (s.RPE < 1 || s.RPE > 10) {
+		return errors.New("set rpe must be between 1 and 10")
+	}
+
+	switch s.Type {
+	case "", SetReps:
+		if s.DurationSeconds != 0 || s.Distance != 0 {
+			return errors.New("reps sets do not take a duration or distance")
+		}
+		if err := s.validateVelocities(); err != nil {
+			return err
+		}
+		return tempo.Validate(s.Tempo)
+	case SetDuration:
+		if s.DurationSeconds == 0 {
+			return errors.New("duration sets require durationSeconds")
+		}
+		if s.Reps != 0 || s.Distance != 0 {
+			return errors.New("duration sets do not take reps or distance")
+		}
+	case SetDistance:
+		if s.Distance == 0 {
+			return errors.New("distance sets require a distance")
+		}
+		if _, ok := distanceUnits[s.DistanceUnit]; !ok {
+			return errors.New(`distanceUnit must be "m", "km", "yd" or "mi"`)
+		}
+		if s.Reps != 0 {
+			return errors.New("distance sets do not take reps")
+		}
+	default:
+		return fmt.Errorf("set type must be %q, %q or %q", SetReps, SetDuration, SetDistance)
+	}
+
+	if s.AMRAP || s.Tempo != "" || s.Assistance != 0 || s.Band != "" || s.Chains != 0 || s.BandTension != 0 || len(s.Velocities) > 0 {
+		return errors.New("amrap, tempo, assistance, accommodating resistance and velocities only apply to reps sets")
+	}
+	return nil
+}
+
+func (s Set) validateVelocities() error {
+	if len(s.Velocities) > s.Reps {
+		return errors.New("set velocities must not outnumber its reps")
+	}
+	for _, v := range s.Velocities {
+		if v <= 0 || v > MaxVelocity {
+			return fmt.Errorf("set velocities must be positive and at most %d m/s", MaxVelocity)
+		}
+	}
+	return nil
+}
+
+// Performed reports whether the set logged any work
+func (s Set) Performed() bool {
+	return s.Reps > 0 || s.DurationSeconds > 0 || s.Distance > 0
+}
+
+// Assisted reports whether a machine or band took some of the load
+func (s Set) Assisted() bool {
+	return s.Assistance > 0 || s.Band != ""
+}
+
+// Estimable reports whether the set is a loaded reps set a one-rep max can be
+// estimated from
+func (s Set) Estimable() bool {
+	return (s.Type == "" || s.Type == SetReps) && !s.Assisted()
+}
+
+// Accommodating returns the estimated average load chains and bands add over
+// the lift
+func (s Set) Accommodating() float64 {
+	return (s.Chains + s.BandTension) * accommodatingAverage
+}
+
+// Load returns the average load moved: the weight plus accommodating
+// resistance, net of assistance
+func (s Set) Load() float64 {
+	return max(s.Weight+s.Accommodating()-s.Assistance, 0)
+}
+
+// Volume returns reps multiplied by the load; duration and distance sets have
+// no volume
+func (s Set) Volume() float64 {
+	if s.Type != "" && s.Type != SetReps {
+		return 0
+	}
+	return float64(s.Reps) * s.Load()
+}
+
+// VelocityLoss returns how far the last rep's velocity fell below the fastest
+// rep's, as a percentage, or 0 when fewer than two reps have velocities
+func (s Set) VelocityLoss() float64 {
+	if len(s.Velocities) < 2 {
+		return 0
+	}
+	fastest := s.Velocities[0]
+	for _, v := range s.Velocities[1:] {
+		fastest = max(fastest, v)
+	}
+	last := s.Velocities[len(s.Velocities)-1]
+	return math.Round((fastest-last)/fastest*1000) / 10
+}
+
+// Metres returns a distance set's distance in metres
+func (s Set) Metres() float64 {
+	if s.Type != SetDistance {
+		return 0
+	}
+	return s.Distance * distanceUnits[s.DistanceUnit]
+}
+
+// TimeUnderTension returns the set's seconds under tension: the whole of a
+// duration set, or reps at the set's tempo, and 0 otherwise
+func (s Set) TimeUnderTension() int {
+	if s.Type == SetDuration {
+		return s.DurationSeconds
+	}
+	return tempo.TimeUnderTension(s.Tempo, s.Reps)
+}
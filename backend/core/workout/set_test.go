@@ -0,0 +1,92 @@
+package workout
+
+import "testing"
+
+func TestSet_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		set     Set
+		wantErr bool
+	}{
+		{name: "reps", set: Set{Reps: 5, Weight: 100}},
+		{name: "assisted reps", set: Set{Reps: 8, Assistance: 20}},
+		{name: "banded reps", set: Set{Type: SetReps, Reps: 8, Band: "green"}},
+		{name: "reps against chains and bands", set: Set{Reps: 3, Weight: 140, Chains: 40, BandTension: 30}},
+		{name: "negative chains", set: Set{Reps: 3, Weight: 140, Chains: -40}, wantErr: true},
+		{name: "plank with bands", set: Set{Type: SetDuration, DurationSeconds: 60, BandTension: 20}, wantErr: true},
+		{name: "reps with velocities", set: Set{Reps: 3, Weight: 140, Velocities: []float64{0.62, 0.58, 0.51}}},
+		{name: "more velocities than reps", set: Set{Reps: 1, Weight: 140, Velocities: []float64{0.62, 0.58}}, wantErr: true},
+		{name: "implausible velocity", set: Set{Reps: 1, Weight: 140, Velocities: []float64{12}}, wantErr: true},
+		{name: "amrap reps", set: Set{Reps: 9, Weight: 85, AMRAP: true}},
+		{name: "amrap plank", set: Set{Type: SetDuration, DurationSeconds: 90, AMRAP: true}, wantErr: true},
+		{name: "reps with duration", set: Set{Reps: 5, DurationSeconds: 30}, wantErr: true},
+		{name: "plank", set: Set{Type: SetDuration, DurationSeconds: 60}},
+		{name: "weighted plank", set: Set{Type: SetDuration, DurationSeconds: 45, Weight: 10}},
+		{name: "duration without seconds", set: Set{Type: SetDuration}, wantErr: true},
+		{name: "duration with tempo", set: Set{Type: SetDuration, DurationSeconds: 60, Tempo: "3-1-1-0"}, wantErr: true},
+		{name: "sled push", set: Set{Type: SetDistance, Distance: 20, DistanceUnit: "m", Weight: 120}},
+		{name: "distance without unit", set: Set{Type: SetDistance, Distance: 20}, wantErr: true},
+		{name: "distance with reps", set: Set{Type: SetDistance, Distance: 20, DistanceUnit: "m", Reps: 2}, wantErr: true},
+		{name: "assisted carry", set: Set{Type: SetDistance, Distance: 40, DistanceUnit: "yd", Assistance: 10}, wantErr: true},
+		{name: "unknown type", set: Set{Type: "cluster", Reps: 5}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.set.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSet_Aggregates(t *testing.T) {
+	tests := []struct {
+		name    string
+		set     Set
+		volume  float64
+		metres  float64
+		seconds int
+	}{
+		{name: "reps", set: Set{Reps: 5, Weight: 100}, volume: 500},
+		{name: "reps with tempo", set: Set{Reps: 5, Weight: 100, Tempo: "3-0-1-0"}, volume: 500, seconds: 20},
+		{name: "assisted", set: Set{Reps: 5, Weight: 30, Assistance: 20}, volume: 50},
+		{name: "assistance above load", set: Set{Reps: 5, Assistance: 20}},
+		{name: "chains and bands", set: Set{Reps: 3, Weight: 140, Chains: 40, BandTension: 30}, volume: 525},
+		{name: "plank", set: Set{Type: SetDuration, DurationSeconds: 60, Weight: 10}, seconds: 60},
+		{name: "carry", set: Set{Type: SetDistance, Distance: 0.5, DistanceUnit: "km", Weight: 40}, metres: 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.set.Volume(); got != tt.volume {
+				t.Errorf("expected volume %v, got %v", tt.volume, got)
+			}
+			if got := tt.set.Metres(); got != tt.metres {
+				t.Errorf("expected %v metres, got %v", tt.metres, got)
+			}
+			if got := tt.set.TimeUnderTension(); got != tt.seconds {
+				t.Errorf("expected %d seconds under tension, got %d", tt.seconds, got)
+			}
+		})
+	}
+}
+
+func TestSet_VelocityLoss(t *testing.T) {
+	tests := []struct {
+		name       string
+		velocities []float64
+		want       float64
+	}{
+		{name: "from the fastest rep to the last", velocities: []float64{0.6, 0.64, 0.56, 0.48}, want: 25},
+		{name: "single rep", velocities: []float64{0.6}},
+		{name: "none logged"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Set{Reps: len(tt.velocities), Velocities: tt.velocities}).VelocityLoss(); got != tt.want {
+				t.Errorf("expected %v%%, got %v%%", tt.want, got)
+			}
+		})
+	}
+}
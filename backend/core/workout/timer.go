@@ -0,0 +1,110 @@
+package workout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"athlete-forge/store"
+)
+
+const (
+	restTimerSKPrefix = "RESTTIMER#"
+	maxRestSeconds    = 3600
+)
+
+// Rest timer statuses; a running timer past its end reads as finished
+const (
+	TimerRunning  = "running"
+	TimerFinished = "finished"
+	TimerStopped  = "stopped"
+)
+
+// Rest timer commands
+const (
+	TimerStart  = "start"
+	TimerAdjust = "adjust"
+	TimerStop   = "stop"
+)
+
+// ErrTimerNotRunning is returned when adjusting a timer that is not counting down
+var ErrTimerNotRunning = errors.New("rest timer is not running")
+
+// RestTimer is the countdown between sets of a workout, kept on the server so
+// every device the user has open shows the same one
+type RestTimer struct {
+	WorkoutID       string     `json:"workoutId"`
+	Status          string     `json:"status"`
+	Exercise        string     `json:"exercise,omitempty"`
+	DurationSeconds int        `json:"durationSeconds"`
+	StartedAt       *time.Time `json:"startedAt,omitempty"`
+	EndsAt          *time.Time `json:"endsAt,omitempty"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+// Start counts down durationSeconds from now, replacing any running countdown
+func (t *RestTimer) Start(exercise string, durationSeconds int, now time.Time) error {
+	if durationSeconds < 1 || durationSeconds > maxRestSeconds {
+		return fmt.Errorf("durationSeconds must be between 1 and %d", maxRestSeconds)
+	}
+	ends := now.Add(time.Duration(durationSeconds) * time.Second)
+	t.Status = TimerRunning
+	t.Exercise = exercise
+	t.DurationSeconds = durationSeconds
+	t.StartedAt = &now
+	t.EndsAt = &ends
+	t.UpdatedAt = now
+	return nil
+}
+
+// Adjust moves a running timer's end by seconds, which may be negative; moving
+// it to now or earlier finishes it
+func (t *RestTimer) Adjust(seconds int, now time.Time) error {
+	t.Settle(now)
+	if t.Status != TimerRunning {
+		return ErrTimerNotRunning
+	}
+	if t.DurationSeconds+seconds > maxRestSeconds {
+		return fmt.Errorf("rest cannot be longer than %d seconds", maxRestSeconds)
+	}
+	ends := t.EndsAt.Add(time.Duration(seconds) * time.Second)
+	t.DurationSeconds = max(t.DurationSeconds+seconds, 0)
+	t.EndsAt = &ends
+	t.UpdatedAt = now
+	t.Settle(now)
+	return nil
+}
+
+// Stop ends the countdown early
+func (t *RestTimer) Stop(now time.Time) {
+	t.Status = TimerStopped
+	t.UpdatedAt = now
+}
+
+// Settle marks a running timer whose end has passed as finished
+func (t *RestTimer) Settle(now time.Time) {
+	if t.Status == TimerRunning && !now.Before(*t.EndsAt) {
+		t.Status = TimerFinished
+	}
+}
+
+// RestTimer returns the rest timer of userID's workout with workoutID, stopped
+// if it has never been started
+func (r *Repository) RestTimer(ctx context.Context, userID, workoutID string, now time.Time) (*RestTimer, error) {
+	t := RestTimer{WorkoutID: workoutID, Status: TimerStopped}
+	err := r.store.Get(ctx, store.UserPK(userID), restTimerSKPrefix+workoutID, &t)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+	t.Settle(now)
+	return &t, nil
+}
+
+// SaveRestTimer stores userID's rest timer t
+func (r *Repository) SaveRestTimer(ctx context.Context, userID string, t *RestTimer) error {
+	if err := r.store.Put(ctx, store.UserPK(userID), restTimerSKPrefix+t.WorkoutID, t); err != nil {
+		return fmt.Errorf("failed to save rest timer: %w", err)
+	}
+	return nil
+}
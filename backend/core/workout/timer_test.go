@@ -0,0 +1,109 @@
+package workout
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+)
+
+func TestRestTimer(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	t.Run("runs until its end and then reads as finished", func(t *testing.T) {
+		// Arrange
+		timer := &RestTimer{WorkoutID: "w1", Status: TimerStopped}
+
+		// Act
+		err := timer.Start("Squat", 90, now)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if timer.Status != TimerRunning || !timer.EndsAt.Equal(now.Add(90*time.Second)) {
+			t.Errorf("unexpected timer: %+v", timer)
+		}
+		timer.Settle(now.Add(90 * time.Second))
+		if timer.Status != TimerFinished {
+			t.Errorf("expected finished, got %s", timer.Status)
+		}
+	})
+
+	t.Run("adjusting moves the end of a running timer", func(t *testing.T) {
+		// Arrange
+		timer := &RestTimer{WorkoutID: "w1"}
+		timer.Start("Bench Press", 90, now)
+
+		// Act
+		err := timer.Adjust(30, now.Add(10*time.Second))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if timer.DurationSeconds != 120 || !timer.EndsAt.Equal(now.Add(120*time.Second)) || timer.Status != TimerRunning {
+			t.Errorf("unexpected timer: %+v", timer)
+		}
+	})
+
+	t.Run("adjusting past now finishes the timer", func(t *testing.T) {
+		// Arrange
+		timer := &RestTimer{WorkoutID: "w1"}
+		timer.Start("Bench Press", 90, now)
+
+		// Act
+		err := timer.Adjust(-60, now.Add(45*time.Second))
+
+		// Assert
+		if err != nil || timer.Status != TimerFinished {
+			t.Errorf("expected finished without error, got %+v (%v)", timer, err)
+		}
+	})
+
+	t.Run("rejects adjusting a timer that is not running and bad durations", func(t *testing.T) {
+		// Arrange
+		timer := &RestTimer{WorkoutID: "w1", Status: TimerStopped}
+
+		// Act
+		adjustErr := timer.Adjust(30, now)
+		startErr := timer.Start("Squat", 0, now)
+
+		// Assert
+		if !errors.Is(adjustErr, ErrTimerNotRunning) {
+			t.Errorf("expected ErrTimerNotRunning, got %v", adjustErr)
+		}
+		if startErr == nil {
+			t.Error("expected an error for a zero duration")
+		}
+	})
+
+	t.Run("is stored per workout and stopped until started", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		repo := NewRepository(store.NewMemoryStore())
+		timer, _ := repo.RestTimer(ctx, "user-1", "w1", now)
+		timer.Start("Squat", 60, now)
+
+		// Act
+		err := repo.SaveRestTimer(ctx, "user-1", timer)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		saved, _ := repo.RestTimer(ctx, "user-1", "w1", now.Add(30*time.Second))
+		other, _ := repo.RestTimer(ctx, "user-1", "w2", now)
+		if saved.Status != TimerRunning || saved.Exercise != "Squat" {
+			t.Errorf("unexpected saved timer: %+v", saved)
+		}
+		if other.Status != TimerStopped || other.WorkoutID != "w2" {
+			t.Errorf("unexpected unstarted timer: %+v", other)
+		}
+		if finished, _ := repo.RestTimer(ctx, "user-1", "w1", now.Add(time.Minute)); finished.Status != TimerFinished {
+			t.Errorf("expected finished once its end passed, got %s", finished.Status)
+		}
+	})
+}
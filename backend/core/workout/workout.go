@@ -0,0 +1,273 @@
+package workout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"athlete-forge/store"
+)
+
+const workoutSKPrefix = "WORKOUT#"
+
+// Workout statuses. Abandoned workouts are sessions left open with nothing
+// performed, closed by the stale session job
+const (
+	StatusPlanned   = "planned"
+	StatusActive    = "active"
+	StatusCompleted = "completed"
+	StatusAbandoned = "abandoned"
+)
+
+// ErrAlreadyCompleted is returned when completing a workout twice
+var ErrAlreadyCompleted = errors.New("workout is already completed")
+
+// Exercise is an exercise performed in a workout with its logged sets; Group
+// names the group it is performed in, if any
+type Exercise struct {
+	Name  string `json:"name"`
+	Group string `json:"group,omitempty"`
+	Sets  []Set  `json:"sets"`
+}
+
+// Ratings are the athlete's subjective ratings of a whole workout, usually given
+// when it is completed: SessionRPE from 1 to 10, and Enjoyment and Pump from 1
+// to 5. Zero ratings were not given
+type Ratings struct {
+	SessionRPE float64 `json:"sessionRpe,omitempty"`
+	Enjoyment  int     `json:"enjoyment,omitempty"`
+	Pump       int     `json:"pump,omitempty"`
+}
+
+// Validate checks each rating given is on its scale
+func (r Ratings) Validate() error {
+	if r.SessionRPE != 0 && (r.SessionRPE < 1 || r.SessionRPE > 10) {
+		return errors.New("sessionRpe must be between 1 and 10")
+	}
+	if r.Enjoyment < 0 || r.Enjoyment > 5 || r.Pump < 0 || r.Pump > 5 {
+		return errors.New("enjoyment and pump must be between 1 and 5")
+	}
+	return nil
+}
+
+// Workout is a training session, optionally performed as part of a program and
+// tagged with the gym it was performed at. AutoFinishedAt is when a session left
+// open was completed or abandoned for the user. Times are server times;
+// ClientStartedAt and ClientCompletedAt keep the device's times when correcting
+// its clock changed them. SyncedAt is when the server completed it, which may
+// be long after CompletedAt for workouts logged offline
+type Workout struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"userId"`
+	ProgramID   string     `json:"programId,omitempty"`
+	GymID       string     `json:"gymId,omitempty"`
+	Status      string     `json:"status"`
+	ScheduledAt *time.Time `json:"scheduledAt,omitempty"`
+	StartedAt   time.Time  `json:"startedAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	Exercises   []Exercise `json:"exercises"`
+	Groups      []Group    `json:"groups,omitempty"`
+	Notes       string     `json:"notes,omitempty"`
+	Ratings     *Ratings   `json:"ratings,omitempty"`
+
+	AutoFinishedAt    *time.Time `json:"autoFinishedAt,omitempty"`
+	ClientStartedAt   *time.Time `json:"clientStartedAt,omitempty"`
+	ClientCompletedAt *time.Time `json:"clientCompletedAt,omitempty"`
+	SyncedAt          *time.Time `json:"syncedAt,omitempty"`
+}
+
+// At is when the workout took place, which orders workouts and places them in
+// streaks: when it was completed, else when it started, else when it is
+// scheduled
+func (w *Workout) At() time.Time {
+	switch {
+	case w.CompletedAt != nil:
+		return *w.CompletedAt
+	case !w.StartedAt.IsZero() || w.ScheduledAt == nil:
+		return w.StartedAt
+	default:
+		return *w.ScheduledAt
+	}
+}
+
+// SortByTime orders workouts by At, oldest first, and workouts at the same
+// time by ID, so by when they were created
+func SortByTime(workouts []Workout) {
+	sort.SliceStable(workouts, func(i, j int) bool {
+		a, b := workouts[i].At(), workouts[j].At()
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return workouts[i].ID < workouts[j].ID
+	})
+}
+
+// HasExercise reports whether the workout includes the exercise name, matched
+// case-insensitively
+func (w *Workout) HasExercise(name string) bool {
+	for _, exercise := range w.Exercises {
+		if strings.EqualFold(exercise.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the workout structure
+func (w *Workout) Validate() error {
+	if w.Status != StatusPlanned && w.Status != StatusActive && w.Status != StatusCompleted && w.Status != StatusAbandoned {
+		return fmt.Errorf("status must be %q, %q or %q", StatusPlanned, StatusActive, StatusCompleted)
+	}
+	if w.Status == StatusPlanned && w.ScheduledAt == nil {
+		return errors.New("scheduledAt is required for planned workouts")
+	}
+	if w.Ratings != nil {
+		if err := w.Ratings.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, exercise := range w.Exercises {
+		if exercise.Name == "" {
+			return errors.New("exercise name is required")
+		}
+		for _, set := range exercise.Sets {
+			if err := set.Validate(); err != nil {
+				return fmt.Errorf("exercise %q: %w", exercise.Name, err)
+			}
+		}
+	}
+	return w.validateGroups()
+}
+
+// Performed reports whether any set of the workout logged work
+func (w *Workout) Performed() bool {
+	for _, exercise := range w.Exercises {
+		for _, set := range exercise.Sets {
+			if set.Performed() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Complete marks the workout completed at now; planned workouts are treated as
+// started at now
+func (w *Workout) Complete(now time.Time) error {
+	if w.Status == StatusCompleted {
+		return ErrAlreadyCompleted
+	}
+	if w.StartedAt.IsZero() {
+		w.StartedAt = now
+	}
+	w.Status = StatusCompleted
+	w.CompletedAt = &now
+	return nil
+}
+
+// Repository loads and saves workouts
+type Repository struct {
+	store store.Store
+}
+
+// NewRepository creates a Repository backed by s
+func NewRepository(s store.Store) *Repository {
+	return &Repository{store: s}
+}
+
+// Get returns the workout with id owned by userID
+func (r *Repository) Get(ctx context.Context, userID, id string) (*Workout, error) {
+	var w Workout
+	if err := r.store.Get(ctx, store.UserPK(userID), workoutSKPrefix+id, &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// List returns all of userID's workouts in the order of SortByTime
+func (r *Repository) List(ctx context.Context, userID string) ([]Workout, error) {
+	items, err := r.store.Query(ctx, store.UserPK(userID), workoutSKPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workouts: %w", err)
+	}
+
+	workouts := make([]Workout, 0, len(items))
+	for _, item := range items {
+		var w Workout
+		if err := item.Decode(&w); err != nil {
+			return nil, err
+		}
+		workouts = append(workouts, w)
+	}
+	SortByTime(workouts)
+	return workouts, nil
+}
+
+// GetMany returns those of the workouts with ids owned by userID that exist,
+// keyed by ID, in one batch read; callers pass at most store.MaxBatchGet ids
+func (r *Repository) GetMany(ctx context.Context, userID string, ids []string) (map[string]Workout, error) {
+	keys := make([]store.Key, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, store.Key{PK: store.UserPK(userID), SK: workoutSKPrefix + id})
+	}
+	items, err := r.store.BatchGet(ctx, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workouts: %w", err)
+	}
+
+	workouts := make(map[string]Workout, len(items))
+	for _, item := range items {
+		var w Workout
+		if err := item.Decode(&w); err != nil {
+			return nil, err
+		}
+		workouts[strings.TrimPrefix(item.SK, workoutSKPrefix)] = w
+	}
+	return workouts, nil
+}
+
+// FromItem decodes a stored item, returning false when it is not a workout
+func FromItem(item store.Item) (*Workout, bool) {
+	if !strings.HasPrefix(item.SK, workoutSKPrefix) {
+		return nil, false
+	}
+	var w Workout
+	if err := item.Decode(&w); err != nil {
+		return nil, false
+	}
+	return &w, true
+}
+
+// SaveMany stores workouts in batches of store.MaxBatchWrite; each must
+// already be valid and have an ID
+func (r *Repository) SaveMany(ctx context.Context, workouts []Workout) error {
+	writes := make([]store.Write, len(workouts))
+	for i := range workouts {
+		w := &workouts[i]
+		if w.ID == "" {
+			return errors.New("workouts saved in a batch must have IDs")
+		}
+		writes[i] = store.Write{PK: store.UserPK(w.UserID), SK: workoutSKPrefix + w.ID, Value: w}
+	}
+	if err := store.BatchPut(ctx, r.store, writes); err != nil {
+		return fmt.Errorf("failed to save workouts: %w", err)
+	}
+	return nil
+}
+
+// Save validates and stores w, assigning an ID to new workouts
+func (r *Repository) Save(ctx context.Context, w *Workout) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+	if w.ID == "" {
+		w.ID = store.NewID()
+	}
+	if err := r.store.Put(ctx, store.UserPK(w.UserID), workoutSKPrefix+w.ID, w); err != nil {
+		return fmt.Errorf("failed to save workout: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package workout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"athlete-forge/store"
+)
+
+func TestWorkout_Complete(t *testing.T) {
+	t.Run("marks an active workout completed", func(t *testing.T) {
+		// Arrange
+		w := &Workout{Status: StatusActive}
+		now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+		// Act
+		err := w.Complete(now)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if w.Status != StatusCompleted || w.CompletedAt == nil || !w.CompletedAt.Equal(now) {
+			t.Errorf("unexpected workout: %+v", w)
+		}
+	})
+
+	t.Run("starts a planned workout when it is completed", func(t *testing.T) {
+		// Arrange
+		scheduled := time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC)
+		w := &Workout{Status: StatusPlanned, ScheduledAt: &scheduled}
+		now := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+
+		// Act
+		err := w.Complete(now)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if w.Status != StatusCompleted || !w.StartedAt.Equal(now) {
+			t.Errorf("unexpected workout: %+v", w)
+		}
+	})
+
+	t.Run("rejects completing twice", func(t *testing.T) {
+		w := &Workout{Status: StatusCompleted}
+		if err := w.Complete(time.Now()); err != ErrAlreadyCompleted {
+			t.Errorf("expected ErrAlreadyCompleted, got %v", err)
+		}
+	})
+}
+
+func TestWorkout_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		workout Workout
+		wantErr bool
+	}{
+		{name: "valid", workout: Workout{Status: StatusActive, Exercises: []Exercise{{Name: "Squat", Sets: []Set{{Reps: 5, Weight: 100, RPE: 8}}}}}},
+		{name: "unknown status", workout: Workout{Status: "paused"}, wantErr: true},
+		{name: "planned", workout: Workout{Status: StatusPlanned, ScheduledAt: &time.Time{}}},
+		{name: "planned without scheduledAt", workout: Workout{Status: StatusPlanned}, wantErr: true},
+		{name: "unnamed exercise", workout: Workout{Status: StatusActive, Exercises: []Exercise{{}}}, wantErr: true},
+		{name: "negative reps", workout: Workout{Status: StatusActive, Exercises: []Exercise{{Name: "Squat", Sets: []Set{{Reps: -1}}}}}, wantErr: true},
+		{name: "rpe out of range", workout: Workout{Status: StatusActive, Exercises: []Exercise{{Name: "Squat", Sets: []Set{{Reps: 5, RPE: 11}}}}}, wantErr: true},
+		{name: "tempo", workout: Workout{Status: StatusActive, Exercises: []Exercise{{Name: "Squat", Sets: []Set{{Reps: 5, Tempo: "3-1-X-0"}}}}}},
+		{name: "malformed tempo", workout: Workout{Status: StatusActive, Exercises: []Exercise{{Name: "Squat", Sets: []Set{{Reps: 5, Tempo: "slow"}}}}}, wantErr: true},
+		{name: "superset", workout: grouped(Group{ID: "a", Type: GroupSuperset, Rounds: 3}, "a", "a")},
+		{name: "superset with three exercises", workout: grouped(Group{ID: "a", Type: GroupSuperset}, "a", "a", "a"), wantErr: true},
+		{name: "giant set with two exercises", workout: grouped(Group{ID: "a", Type: GroupGiantSet}, "a", "a"), wantErr: true},
+		{name: "split group", workout: grouped(Group{ID: "a", Type: GroupCircuit}, "a", "", "a"), wantErr: true},
+		{name: "unknown group", workout: grouped(Group{ID: "a", Type: GroupCircuit}, "a", "a", "b"), wantErr: true},
+		{name: "more sets than rounds", workout: grouped(Group{ID: "a", Type: GroupCircuit, Rounds: 1}, "a", "a"), wantErr: true},
+		{name: "amrap", workout: grouped(Group{ID: "a", Type: GroupAMRAP, TimeCapSeconds: 600, RoundsCompleted: 4}, "a", "a")},
+		{name: "ratings", workout: Workout{Status: StatusCompleted, Ratings: &Ratings{SessionRPE: 7.5, Enjoyment: 4, Pump: 3}}},
+		{name: "session rpe out of range", workout: Workout{Status: StatusCompleted, Ratings: &Ratings{SessionRPE: 11}}, wantErr: true},
+		{name: "enjoyment out of range", workout: Workout{Status: StatusCompleted, Ratings: &Ratings{Enjoyment: 6}}, wantErr: true},
+		{name: "amrap without time cap", workout: grouped(Group{ID: "a", Type: GroupAMRAP}, "a", "a"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.workout.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestWorkout_Blocks(t *testing.T) {
+	t.Run("groups consecutive exercises and counts full rounds", func(t *testing.T) {
+		// Arrange
+		w := grouped(Group{ID: "a", Type: GroupSuperset, Rounds: 3}, "", "a", "a")
+		w.Exercises[2].Sets = w.Exercises[2].Sets[:1]
+
+		// Act
+		blocks := w.Blocks()
+
+		// Assert
+		if len(blocks) != 2 || blocks[0].Group != nil || blocks[1].Group == nil {
+			t.Fatalf("unexpected blocks: %+v", blocks)
+		}
+		if blocks[1].Rounds != 1 || blocks[1].Sets != 3 || blocks[1].Volume != 150 {
+			t.Errorf("unexpected superset block: %+v", blocks[1])
+		}
+	})
+
+	t.Run("uses recorded rounds for amrap groups", func(t *testing.T) {
+		// Arrange
+		w := grouped(Group{ID: "a", Type: GroupAMRAP, TimeCapSeconds: 600, RoundsCompleted: 7}, "a", "a")
+
+		// Act
+		blocks := w.Blocks()
+
+		// Assert
+		if len(blocks) != 1 || blocks[0].Rounds != 7 {
+			t.Errorf("unexpected blocks: %+v", blocks)
+		}
+	})
+}
+
+// grouped builds an active workout with one exercise per group ID, each with two
+// sets of 5x10, and the given group definition
+func grouped(g Group, ids ...string) Workout {
+	w := Workout{Status: StatusActive, Groups: []Group{g}}
+	for i, id := range ids {
+		w.Exercises = append(w.Exercises, Exercise{Name: string(rune('A' + i)), Group: id, Sets: []Set{{Reps: 5, Weight: 10}, {Reps: 5, Weight: 10}}})
+	}
+	return w
+}
+
+func TestRepository_List(t *testing.T) {
+	t.Run("lists only the user's workouts", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		repo := NewRepository(store.NewMemoryStore())
+		repo.Save(ctx, &Workout{UserID: "user-1", Status: StatusActive})
+		repo.Save(ctx, &Workout{UserID: "user-1", Status: StatusCompleted})
+		repo.Save(ctx, &Workout{UserID: "user-2", Status: StatusActive})
+
+		// Act
+		workouts, err := repo.List(ctx, "user-1")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(workouts) != 2 {
+			t.Errorf("expected 2 workouts, got %d", len(workouts))
+		}
+	})
+	t.Run("lists workouts by when they took place, whenever they were saved", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		repo := NewRepository(store.NewMemoryStore())
+		monday := time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC)
+		wednesday := monday.AddDate(0, 0, 2)
+		friday := monday.AddDate(0, 0, 4)
+		repo.Save(ctx, &Workout{ID: "a", UserID: "user-1", Status: StatusActive, StartedAt: wednesday})
+		repo.Save(ctx, &Workout{ID: "b", UserID: "user-1", Status: StatusPlanned, ScheduledAt: &friday})
+		repo.Save(ctx, &Workout{ID: "c", UserID: "user-1", Status: StatusCompleted, StartedAt: monday.Add(-time.Hour), CompletedAt: &monday})
+
+		// Act
+		workouts, _ := repo.List(ctx, "user-1")
+
+		// Assert
+		if len(workouts) != 3 || workouts[0].ID != "c" || workouts[1].ID != "a" || workouts[2].ID != "b" {
+			t.Errorf("expected c, a then b, got %+v", workouts)
+		}
+	})
+}
@@ -6,6 +6,18 @@ data "aws_region" "current" {}
 locals {
   environment = terraform.workspace
   domain_name = local.environment == "production" ? "workout-tracker.jamiekelly.com" : "workout-tracker-dev.jamiekelly.com"
+
+  # The distribution routes to the function, so the function finds the
+  # distribution's ID in this parameter rather than its environment
+  cdn_distribution_parameter = "/workout-tracker/${local.environment}/cdn-distribution-id"
+
+  # Edited by hand to change log levels without a redeploy
+  log_config_parameter = "/workout-tracker/${local.environment}/log-config"
+
+  # Exercise media links are only signed, and the media path only served, once
+  # a signing key pair is configured
+  media_signing           = var.media_signing_public_key != ""
+  media_signing_parameter = "/workout-tracker/${local.environment}/media-signing-key"
 }
 
 # Route 53 hosted zone data source
@@ -97,229 +109,1698 @@ resource "aws_cloudfront_origin_access_control" "frontend" {
   signing_protocol                  = "sigv4"
 }
 
-# S3 bucket policy to allow CloudFront access
-resource "aws_s3_bucket_policy" "frontend" {
-  bucket = aws_s3_bucket.frontend.id
+# S3 bucket policy to allow CloudFront access
+resource "aws_s3_bucket_policy" "frontend" {
+  bucket = aws_s3_bucket.frontend.id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Sid    = "AllowCloudFrontServicePrincipal"
+        Effect = "Allow"
+        Principal = {
+          Service = "cloudfront.amazonaws.com"
+        }
+        Action   = "s3:GetObject"
+        Resource = "${aws_s3_bucket.frontend.arn}/*"
+        Condition = {
+          StringEquals = {
+            "AWS:SourceArn" = aws_cloudfront_distribution.frontend.arn
+          }
+        }
+      }
+    ]
+  })
+}
+
+# CloudFront distribution for frontend hosting
+resource "aws_cloudfront_distribution" "frontend" {
+  aliases = [local.domain_name]
+
+  # S3 origin for frontend static content
+  origin {
+    domain_name              = aws_s3_bucket.frontend.bucket_regional_domain_name
+    origin_access_control_id = aws_cloudfront_origin_access_control.frontend.id
+    origin_id                = "S3-${aws_s3_bucket.frontend.bucket}"
+  }
+
+  # S3 origin for exercise media, readable only through signed links
+  origin {
+    domain_name              = aws_s3_bucket.media.bucket_regional_domain_name
+    origin_access_control_id = aws_cloudfront_origin_access_control.frontend.id
+    origin_id                = "S3-${aws_s3_bucket.media.bucket}"
+  }
+
+  # API Gateway origin for API endpoints
+  origin {
+    domain_name = "${aws_api_gateway_rest_api.workout_tracker_api.id}.execute-api.${data.aws_region.current.name}.amazonaws.com"
+    origin_id   = "APIGateway-${aws_api_gateway_rest_api.workout_tracker_api.id}"
+    origin_path = "/${aws_api_gateway_stage.workout_tracker_api.stage_name}"
+
+    custom_origin_config {
+      http_port              = 80
+      https_port             = 443
+      origin_protocol_policy = "https-only"
+      origin_ssl_protocols   = ["TLSv1.2"]
+    }
+  }
+
+  enabled             = true
+  is_ipv6_enabled     = true
+  default_root_object = "index.html"
+  price_class         = "PriceClass_100"
+
+  # Exercise demonstration media, served only to links the API signed with the
+  # media key group. Links carry their own expiry, so the query string is not
+  # part of the cache key and every signed link shares one cached copy
+  dynamic "ordered_cache_behavior" {
+    for_each = local.media_signing ? ["/media/*"] : []
+    content {
+      path_pattern           = ordered_cache_behavior.value
+      allowed_methods        = ["GET", "HEAD"]
+      cached_methods         = ["GET", "HEAD"]
+      target_origin_id       = "S3-${aws_s3_bucket.media.bucket}"
+      compress               = false
+      viewer_protocol_policy = "redirect-to-https"
+      trusted_key_groups     = [aws_cloudfront_key_group.media[0].id]
+
+      forwarded_values {
+        query_string = false
+        cookies {
+          forward = "none"
+        }
+      }
+
+      min_ttl     = 0
+      default_ttl = 86400
+      max_ttl     = 604800
+    }
+  }
+
+  # Public API responses that set Cache-Control, shared between all callers.
+  # Accept is forwarded because the responses vary on it
+  dynamic "ordered_cache_behavior" {
+    for_each = ["/api/exercises/catalog", "/api/exercises/taxonomy", "/api/calendar/feeds/*", "/api/public/*"]
+    content {
+      path_pattern           = ordered_cache_behavior.value
+      allowed_methods        = ["GET", "HEAD", "OPTIONS"]
+      cached_methods         = ["GET", "HEAD"]
+      target_origin_id       = "APIGateway-${aws_api_gateway_rest_api.workout_tracker_api.id}"
+      compress               = true
+      viewer_protocol_policy = "redirect-to-https"
+
+      forwarded_values {
+        query_string = false
+        headers      = ["Accept"]
+        cookies {
+          forward = "none"
+        }
+      }
+
+      min_ttl     = 0
+      default_ttl = 0
+      max_ttl     = 86400
+    }
+  }
+
+  # Ordered cache behavior for API endpoints
+  ordered_cache_behavior {
+    path_pattern           = "/api/*"
+    allowed_methods        = ["DELETE", "GET", "HEAD", "OPTIONS", "PATCH", "POST", "PUT"]
+    cached_methods         = ["GET", "HEAD"]
+    target_origin_id       = "APIGateway-${aws_api_gateway_rest_api.workout_tracker_api.id}"
+    compress               = true
+    viewer_protocol_policy = "redirect-to-https"
+
+    forwarded_values {
+      query_string = true
+      headers      = ["Authorization", "Content-Type", "Accept", "X-Client-Version", "X-Client-Time", "Accept-Language"]
+      cookies {
+        forward = "all"
+      }
+    }
+
+    # API responses should have minimal caching for dynamic content
+    min_ttl     = 0
+    default_ttl = 0
+    max_ttl     = 300 # 5 minutes maximum cache for API responses
+  }
+
+  default_cache_behavior {
+    allowed_methods        = ["DELETE", "GET", "HEAD", "OPTIONS", "PATCH", "POST", "PUT"]
+    cached_methods         = ["GET", "HEAD"]
+    target_origin_id       = "S3-${aws_s3_bucket.frontend.bucket}"
+    compress               = true
+    viewer_protocol_policy = "redirect-to-https"
+
+    forwarded_values {
+      query_string = false
+      cookies {
+        forward = "none"
+      }
+    }
+
+    min_ttl     = 0
+    default_ttl = 3600
+    max_ttl     = 86400
+  }
+
+  # Custom error response for SPA routing
+  custom_error_response {
+    error_code         = 404
+    response_code      = 200
+    response_page_path = "/index.html"
+  }
+
+  restrictions {
+    geo_restriction {
+      restriction_type = "none"
+    }
+  }
+
+  viewer_certificate {
+    acm_certificate_arn      = aws_acm_certificate_validation.frontend.certificate_arn
+    ssl_support_method       = "sni-only"
+    minimum_protocol_version = "TLSv1.2_2021"
+  }
+
+  tags = {
+    Name = "workout-tracker-frontend"
+  }
+}
+
+# S3 bucket for exercise demonstration media, uploaded by admins through the
+# API and served through the distribution
+resource "aws_s3_bucket" "media" {
+  bucket = "workout-tracker-kiro-media-${local.environment}-${random_id.bucket_suffix.hex}"
+
+  tags = {
+    Name        = "workout-tracker-media"
+    Environment = local.environment
+  }
+}
+
+resource "aws_s3_bucket_public_access_block" "media" {
+  bucket = aws_s3_bucket.media.id
+
+  block_public_acls       = true
+  block_public_policy     = true
+  ignore_public_acls      = true
+  restrict_public_buckets = true
+}
+
+resource "aws_s3_bucket_server_side_encryption_configuration" "media" {
+  bucket = aws_s3_bucket.media.id
+
+  rule {
+    apply_server_side_encryption_by_default {
+      sse_algorithm = "AES256"
+    }
+  }
+}
+
+resource "aws_s3_bucket_policy" "media" {
+  bucket = aws_s3_bucket.media.id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Sid    = "AllowCloudFrontServicePrincipal"
+        Effect = "Allow"
+        Principal = {
+          Service = "cloudfront.amazonaws.com"
+        }
+        Action   = "s3:GetObject"
+        Resource = "${aws_s3_bucket.media.arn}/*"
+        Condition = {
+          StringEquals = {
+            "AWS:SourceArn" = aws_cloudfront_distribution.frontend.arn
+          }
+        }
+      }
+    ]
+  })
+}
+
+# The key pair media links are signed with; CloudFront holds the public half
+# and the function reads the private half from SSM
+resource "aws_cloudfront_public_key" "media" {
+  count       = local.media_signing ? 1 : 0
+  name        = "workout-tracker-media-${local.environment}"
+  comment     = "Verifies signed exercise media links"
+  encoded_key = var.media_signing_public_key
+}
+
+resource "aws_cloudfront_key_group" "media" {
+  count = local.media_signing ? 1 : 0
+  name  = "workout-tracker-media-${local.environment}"
+  items = [aws_cloudfront_public_key.media[0].id]
+}
+
+resource "aws_ssm_parameter" "media_signing_key" {
+  count = local.media_signing ? 1 : 0
+  name  = local.media_signing_parameter
+  type  = "SecureString"
+  value = var.media_signing_private_key
+}
+
+# Lets the API store uploaded media and sign links to it
+resource "aws_iam_role_policy" "lambda_media" {
+  name = "workout-tracker-lambda-media-${local.environment}"
+  role = aws_iam_role.lambda_execution_role.id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect = "Allow"
+        Action = [
+          "s3:PutObject",
+          "s3:GetObject",
+        ]
+        Resource = "${aws_s3_bucket.media.arn}/*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = "ssm:GetParameter"
+        Resource = "arn:aws:ssm:${data.aws_region.current.name}:${data.aws_caller_identity.current.account_id}:parameter${local.media_signing_parameter}"
+      }
+    ]
+  })
+}
+
+resource "aws_ssm_parameter" "cdn_distribution_id" {
+  name  = local.cdn_distribution_parameter
+  type  = "String"
+  value = aws_cloudfront_distribution.frontend.id
+}
+
+# Lets the API invalidate cached responses, such as revoked calendar feeds
+resource "aws_iam_role_policy" "lambda_cdn" {
+  name = "workout-tracker-lambda-cdn-${local.environment}"
+  role = aws_iam_role.lambda_execution_role.id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = "ssm:GetParameter"
+        Resource = aws_ssm_parameter.cdn_distribution_id.arn
+      },
+      {
+        Effect   = "Allow"
+        Action   = "cloudfront:CreateInvalidation"
+        Resource = aws_cloudfront_distribution.frontend.arn
+      }
+    ]
+  })
+}
+
+# Runtime log configuration, such as {"level": "debug"} or {"routes": {"GET
+# /api/workouts/{id}": {"level": "debug", "sample": 0.1}}}. Terraform only creates
+# it; later edits are left alone
+resource "aws_ssm_parameter" "log_config" {
+  name  = local.log_config_parameter
+  type  = "String"
+  value = "{}"
+
+  lifecycle {
+    ignore_changes = [value]
+  }
+}
+
+resource "aws_iam_role_policy" "lambda_log_config" {
+  name = "workout-tracker-lambda-log-config-${local.environment}"
+  role = aws_iam_role.lambda_execution_role.id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = "ssm:GetParameter"
+        Resource = aws_ssm_parameter.log_config.arn
+      }
+    ]
+  })
+}
+
+# Output current AWS account information for verification
+output "aws_account_id" {
+  description = "Current AWS account ID"
+  value       = data.aws_caller_identity.current.account_id
+}
+
+output "aws_region" {
+  description = "Current AWS region"
+  value       = data.aws_region.current.name
+}
+
+# CloudFront distribution URL
+output "cloudfront_url" {
+  description = "CloudFront distribution URL for frontend access"
+  value       = "https://${aws_cloudfront_distribution.frontend.domain_name}"
+}
+
+# S3 bucket name for deployment script
+output "aws_s3_bucket_name" {
+  description = "S3 bucket name for frontend hosting"
+  value       = aws_s3_bucket.frontend.bucket
+}
+
+# Route 53 DNS A record for custom domain
+resource "aws_route53_record" "frontend" {
+  zone_id = data.aws_route53_zone.main.zone_id
+  name    = local.domain_name
+  type    = "A"
+
+  alias {
+    name                   = aws_cloudfront_distribution.frontend.domain_name
+    zone_id                = aws_cloudfront_distribution.frontend.hosted_zone_id
+    evaluate_target_health = false
+  }
+}
+
+# CloudFront distribution ID for cache invalidation
+output "cloudfront_distribution_id" {
+  description = "CloudFront distribution ID for cache invalidation"
+  value       = aws_cloudfront_distribution.frontend.id
+}
+
+# Custom domain URL based on environment
+output "custom_domain_url" {
+  description = "Custom domain URL for frontend access based on environment"
+  value       = "https://${local.domain_name}"
+}
+
+# Lambda function IAM role
+resource "aws_iam_role" "lambda_execution_role" {
+  name = "workout-tracker-lambda-execution-role-${local.environment}"
+
+  assume_role_policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Action = "sts:AssumeRole"
+        Effect = "Allow"
+        Principal = {
+          Service = "lambda.amazonaws.com"
+        }
+      }
+    ]
+  })
+
+  tags = {
+    Name        = "workout-tracker-lambda-execution-role"
+    Environment = local.environment
+  }
+}
+
+# Attach basic Lambda execution policy
+resource "aws_iam_role_policy_attachment" "lambda_basic_execution" {
+  role       = aws_iam_role.lambda_execution_role.name
+  policy_arn = "arn:aws:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole"
+}
+
+# DynamoDB single table for application data (PK/SK layout)
+resource "aws_dynamodb_table" "workout_tracker" {
+  name         = "workout-tracker-${local.environment}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "PK"
+  range_key    = "SK"
+
+  # Changes feed the function that maintains derived data
+  stream_enabled   = true
+  stream_view_type = "NEW_AND_OLD_IMAGES"
+
+  # Replicate to the passive region when one is configured
+  dynamic "replica" {
+    for_each = var.secondary_region == "" ? [] : [var.secondary_region]
+    content {
+      region_name            = replica.value
+      point_in_time_recovery = true
+    }
+  }
+
+  attribute {
+    name = "PK"
+    type = "S"
+  }
+
+  attribute {
+    name = "SK"
+    type = "S"
+  }
+
+  point_in_time_recovery {
+    enabled = true
+  }
+
+  tags = {
+    Name        = "workout-tracker-table"
+    Environment = local.environment
+  }
+}
+
+# Allow the Lambda function to read and write the application table
+resource "aws_iam_role_policy" "lambda_dynamodb" {
+  name = "workout-tracker-lambda-dynamodb-${local.environment}"
+  role = aws_iam_role.lambda_execution_role.id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect = "Allow"
+        Action = [
+          "dynamodb:GetItem",
+          "dynamodb:BatchGetItem",
+          "dynamodb:PutItem",
+          "dynamodb:DeleteItem",
+          "dynamodb:Query",
+        ]
+        Resource = aws_dynamodb_table.workout_tracker.arn
+      }
+    ]
+  })
+}
+
+# Maintain derived data (the active user index and weekly reports) from table
+# changes. A failing batch is split to isolate the bad record, which is sent to the
+# dead-letter queue after three retries so the stream keeps moving
+resource "aws_sqs_queue" "stream_dead_letter" {
+  name                      = "workout-tracker-stream-dlq-${local.environment}"
+  message_retention_seconds = 1209600
+
+  tags = {
+    Name        = "workout-tracker-stream-dlq"
+    Environment = local.environment
+  }
+}
+
+resource "aws_iam_role_policy" "lambda_dynamodb_stream" {
+  name = "workout-tracker-lambda-dynamodb-stream-${local.environment}"
+  role = aws_iam_role.lambda_execution_role.id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect = "Allow"
+        Action = [
+          "dynamodb:DescribeStream",
+          "dynamodb:GetRecords",
+          "dynamodb:GetShardIterator",
+          "dynamodb:ListStreams",
+        ]
+        Resource = aws_dynamodb_table.workout_tracker.stream_arn
+      },
+      {
+        Effect   = "Allow"
+        Action   = "sqs:SendMessage"
+        Resource = aws_sqs_queue.stream_dead_letter.arn
+      }
+    ]
+  })
+}
+
+resource "aws_lambda_event_source_mapping" "table_stream" {
+  event_source_arn               = aws_dynamodb_table.workout_tracker.stream_arn
+  function_name                  = aws_lambda_function.hello_world.arn
+  starting_position              = "LATEST"
+  batch_size                     = 100
+  maximum_retry_attempts         = 3
+  bisect_batch_on_function_error = true
+
+  destination_config {
+    on_failure {
+      destination_arn = aws_sqs_queue.stream_dead_letter.arn
+    }
+  }
+}
+
+# Master key for sensitive profile fields, which are envelope encrypted with data
+# keys it wraps. KMS rotates the key material yearly; old data keys stay decryptable
+resource "aws_kms_key" "profile" {
+  description             = "Workout tracker profile field encryption (${local.environment})"
+  enable_key_rotation     = true
+  deletion_window_in_days = 30
+
+  tags = {
+    Name        = "workout-tracker-profile"
+    Environment = local.environment
+  }
+}
+
+resource "aws_kms_alias" "profile" {
+  name          = "alias/workout-tracker-profile-${local.environment}"
+  target_key_id = aws_kms_key.profile.key_id
+}
+
+# Allow the Lambda function to generate and unwrap profile data keys
+resource "aws_iam_role_policy" "lambda_kms" {
+  name = "workout-tracker-lambda-kms-${local.environment}"
+  role = aws_iam_role.lambda_execution_role.id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect = "Allow"
+        Action = [
+          "kms:GenerateDataKey",
+          "kms:Decrypt",
+        ]
+        Resource = aws_kms_key.profile.arn
+      }
+    ]
+  })
+}
+
+# S3 bucket for generated report exports
+resource "aws_s3_bucket" "reports" {
+  bucket = "workout-tracker-kiro-reports-${local.environment}-${random_id.bucket_suffix.hex}"
+
+  tags = {
+    Name        = "workout-tracker-reports"
+    Environment = local.environment
+  }
+}
+
+resource "aws_s3_bucket_public_access_block" "reports" {
+  bucket = aws_s3_bucket.reports.id
+
+  block_public_acls       = true
+  block_public_policy     = true
+  ignore_public_acls      = true
+  restrict_public_buckets = true
+}
+
+resource "aws_s3_bucket_server_side_encryption_configuration" "reports" {
+  bucket = aws_s3_bucket.reports.id
+
+  rule {
+    apply_server_side_encryption_by_default {
+      sse_algorithm = "AES256"
+    }
+  }
+}
+
+# Exports are shared through short-lived links, so old files are removed, as
+# are backfill chunks, which are only kept until they are written
+resource "aws_s3_bucket_lifecycle_configuration" "reports" {
+  bucket = aws_s3_bucket.reports.id
+
+  rule {
+    id     = "expire-exports"
+    status = "Enabled"
+
+    filter {
+      prefix = "exports/"
+    }
+
+    expiration {
+      days = 30
+    }
+  }
+
+  rule {
+    id     = "expire-backfill-chunks"
+    status = "Enabled"
+
+    filter {
+      prefix = "backfills/"
+    }
+
+    expiration {
+      days = 30
+    }
+  }
+}
+
+# Allow the Lambda function to write exports and queue its own background jobs
+resource "aws_iam_role_policy" "lambda_background_jobs" {
+  name = "workout-tracker-lambda-background-jobs-${local.environment}"
+  role = aws_iam_role.lambda_execution_role.id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect = "Allow"
+        Action = [
+          "s3:PutObject",
+          "s3:GetObject",
+        ]
+        Resource = "${aws_s3_bucket.reports.arn}/*"
+      },
+      {
+        Effect   = "Allow"
+        Action   = "lambda:InvokeFunction"
+        Resource = aws_lambda_function.hello_world.arn
+      }
+    ]
+  })
+}
+
+# Queue for background jobs; the same function consumes it one message at a time
+# so a failed job is retried on its own and moved aside after repeated failures
+resource "aws_sqs_queue" "jobs_dead_letter" {
+  name                      = "workout-tracker-jobs-dlq-${local.environment}"
+  message_retention_seconds = 1209600
+
+  tags = {
+    Name        = "workout-tracker-jobs-dlq"
+    Environment = local.environment
+  }
+}
+
+resource "aws_sqs_queue" "jobs" {
+  name                       = "workout-tracker-jobs-${local.environment}"
+  visibility_timeout_seconds = 180
+
+  redrive_policy = jsonencode({
+    deadLetterTargetArn = aws_sqs_queue.jobs_dead_letter.arn
+    maxReceiveCount     = 3
+  })
+
+  tags = {
+    Name        = "workout-tracker-jobs"
+    Environment = local.environment
+  }
+}
+
+resource "aws_iam_role_policy" "lambda_jobs_queue" {
+  name = "workout-tracker-lambda-jobs-queue-${local.environment}"
+  role = aws_iam_role.lambda_execution_role.id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect = "Allow"
+        Action = [
+          "sqs:SendMessage",
+          "sqs:ReceiveMessage",
+          "sqs:DeleteMessage",
+          "sqs:GetQueueAttributes",
+        ]
+        Resource = aws_sqs_queue.jobs.arn
+      }
+    ]
+  })
+}
+
+resource "aws_lambda_event_source_mapping" "jobs" {
+  event_source_arn = aws_sqs_queue.jobs.arn
+  function_name    = aws_lambda_function.hello_world.arn
+  batch_size       = 1
+}
+
+# Jobs that outgrow one invocation run as a state machine over the same binary,
+# deployed with the "task" handler: start once, step until done, then finish
+resource "aws_lambda_function" "tasks" {
+  filename      = "../backend/core/athlete-forge.zip"
+  function_name = "workout-tracker-athlete-forge-tasks-${local.environment}"
+  role          = aws_iam_role.lambda_execution_role.arn
+  handler       = "task"
+  runtime       = "provided.al2"
+  architectures = ["arm64"]
+  timeout       = 300
+  memory_size   = 256
+
+  source_code_hash = filebase64sha256("../backend/core/athlete-forge.zip")
+
+  environment {
+    variables = {
+      ENVIRONMENT        = local.environment
+      TABLE_NAME         = aws_dynamodb_table.workout_tracker.name
+      REPORTS_BUCKET     = aws_s3_bucket.reports.bucket
+      PROFILE_KMS_KEY_ID = aws_kms_alias.profile.name
+
+      BACKFILL_WRITES_PER_SECOND = var.backfill_writes_per_second
+    }
+  }
+
+  tags = {
+    Name        = "workout-tracker-athlete-forge-tasks"
+    Environment = local.environment
+  }
+}
+
+resource "aws_iam_role" "jobs_state_machine" {
+  name = "workout-tracker-jobs-state-machine-${local.environment}"
+
+  assume_role_policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Action = "sts:AssumeRole"
+        Effect = "Allow"
+        Principal = {
+          Service = "states.amazonaws.com"
+        }
+      }
+    ]
+  })
+
+  tags = {
+    Name        = "workout-tracker-jobs-state-machine"
+    Environment = local.environment
+  }
+}
+
+resource "aws_iam_role_policy" "jobs_state_machine_invoke" {
+  name = "workout-tracker-jobs-state-machine-invoke-${local.environment}"
+  role = aws_iam_role.jobs_state_machine.id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = "lambda:InvokeFunction"
+        Resource = aws_lambda_function.tasks.arn
+      }
+    ]
+  })
+}
+
+# Steps use a task token so they can report heartbeats; a step silent for a
+# minute is treated as hung and retried
+resource "aws_sfn_state_machine" "jobs" {
+  name     = "workout-tracker-jobs-${local.environment}"
+  role_arn = aws_iam_role.jobs_state_machine.arn
+
+  definition = jsonencode({
+    Comment = "Runs long jobs in steps"
+    StartAt = "Start"
+    States = {
+      Start = {
+        Type       = "Task"
+        Resource   = "arn:aws:states:::lambda:invoke"
+        Parameters = { FunctionName = aws_lambda_function.tasks.arn, Payload = { task = "start", "state.$" = "$" } }
+        OutputPath = "$.Payload"
+        Catch      = [{ ErrorEquals = ["States.ALL"], ResultPath = "$.error", Next = "Fail" }]
+        Next       = "Step"
+      }
+      Step = {
+        Type             = "Task"
+        Resource         = "arn:aws:states:::lambda:invoke.waitForTaskToken"
+        Parameters       = { FunctionName = aws_lambda_function.tasks.arn, Payload = { task = "step", "taskToken.$" = "$$.Task.Token", "state.$" = "$" } }
+        HeartbeatSeconds = 60
+        Retry            = [{ ErrorEquals = ["States.Timeout", "States.TaskFailed"], MaxAttempts = 2, BackoffRate = 2, IntervalSeconds = 5 }]
+        Catch            = [{ ErrorEquals = ["States.ALL"], ResultPath = "$.error", Next = "Fail" }]
+        Next             = "Done?"
+      }
+      "Done?" = {
+        Type    = "Choice"
+        Choices = [{ Variable = "$.done", BooleanEquals = true, Next = "Finish" }]
+        Default = "Step"
+      }
+      Finish = {
+        Type       = "Task"
+        Resource   = "arn:aws:states:::lambda:invoke"
+        Parameters = { FunctionName = aws_lambda_function.tasks.arn, Payload = { task = "finish", "state.$" = "$" } }
+        OutputPath = "$.Payload"
+        End        = true
+      }
+      Fail = {
+        Type       = "Task"
+        Resource   = "arn:aws:states:::lambda:invoke"
+        Parameters = { FunctionName = aws_lambda_function.tasks.arn, Payload = { task = "fail", "state.$" = "$" } }
+        Next       = "Failed"
+      }
+      Failed = {
+        Type = "Fail"
+      }
+    }
+  })
+
+  tags = {
+    Name        = "workout-tracker-jobs"
+    Environment = local.environment
+  }
+}
+
+# Allow the API to start executions and the tasks to report back on them
+resource "aws_iam_role_policy" "lambda_state_machine" {
+  name = "workout-tracker-lambda-state-machine-${local.environment}"
+  role = aws_iam_role.lambda_execution_role.id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = "states:StartExecution"
+        Resource = aws_sfn_state_machine.jobs.arn
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "states:SendTaskHeartbeat",
+          "states:SendTaskSuccess",
+          "states:SendTaskFailure",
+        ]
+        Resource = "*"
+      }
+    ]
+  })
+}
+
+# Lambda function
+# Passive region for active-passive operation; the table is replicated there as a
+# global table when set. The primary region is the provider's region
+variable "secondary_region" {
+  description = "Region holding the passive replica, or empty for a single region"
+  type        = string
+  default     = ""
+}
+
+# Redis URL of an ElastiCache cluster caching profile and program reads, such as
+# rediss://:token@host:6379. The cluster and the VPC access it needs are managed
+# outside this stack; reads are uncached while it is empty
+variable "read_cache_endpoint" {
+  description = "Redis URL of the read cache, or empty to read DynamoDB directly"
+  type        = string
+  default     = ""
+  sensitive   = true
+}
+
+# GET routes, such as "GET /api/workouts/{id}", whose new implementation runs in
+# shadow alongside the current one, comma-separated
+variable "shadow_routes" {
+  description = "Comma-separated GET routes to run in shadow mode, or empty for none"
+  type        = string
+  default     = ""
+}
+
+# Domain events are also streamed to S3 through Firehose for analytics; the
+# buffering hints trade delivery latency against the number of objects written
+variable "stream_domain_events" {
+  description = "Whether domain events are streamed to the telemetry bucket as well as the event bus"
+  type        = bool
+  default     = true
+}
+
+variable "domain_events_buffer_size" {
+  description = "MiB of domain events Firehose buffers before writing an object"
+  type        = number
+  default     = 5
+}
+
+variable "domain_events_buffer_interval" {
+  description = "Seconds Firehose buffers domain events before writing an object"
+  type        = number
+  default     = 60
+}
+
+# Sign in with Google and Apple client settings; a provider is disabled while its client ID is empty
+variable "google_client_id" {
+  description = "OAuth client ID for Sign in with Google"
+  type        = string
+  default     = ""
+}
+
+variable "google_client_secret" {
+  description = "OAuth client secret for Sign in with Google"
+  type        = string
+  default     = ""
+  sensitive   = true
+}
+
+variable "apple_client_id" {
+  description = "Services ID for Sign in with Apple"
+  type        = string
+  default     = ""
+}
+
+variable "apple_team_id" {
+  description = "Apple developer team ID"
+  type        = string
+  default     = ""
+}
+
+variable "apple_key_id" {
+  description = "ID of the Sign in with Apple private key"
+  type        = string
+  default     = ""
+}
+
+variable "apple_private_key" {
+  description = "Sign in with Apple private key (.p8 PEM contents)"
+  type        = string
+  default     = ""
+  sensitive   = true
+}
+
+variable "media_signing_public_key" {
+  description = "PEM public key CloudFront verifies exercise media links with; media is served unsigned from presigned S3 links when empty"
+  type        = string
+  default     = ""
+}
+
+variable "media_signing_private_key" {
+  description = "PEM private key matching media_signing_public_key"
+  type        = string
+  default     = ""
+  sensitive   = true
+}
+
+variable "strava_subscription_id" {
+  description = "ID of the Strava push subscription, known once the subscription is created"
+  type        = string
+  default     = ""
+}
+
+# Sentry project errors and panics are reported to; they are reported as
+# structured log lines, searchable in CloudWatch Logs Insights, while it is empty
+variable "sentry_dsn" {
+  description = "Sentry DSN for error reporting, or empty to report to CloudWatch Logs only"
+  type        = string
+  default     = ""
+  sensitive   = true
+}
+
+# Oldest app releases still served, such as "ios=2.0,android=2.1.3"; older
+# releases are answered with 426 Upgrade Required
+variable "min_client_versions" {
+  description = "Comma-separated platform=release minimum client versions, or empty to serve every release"
+  type        = string
+  default     = ""
+}
+
+# Workout sessions left active this long are completed, or abandoned when
+# nothing was logged, by the hourly finish-sessions job
+variable "stale_session_hours" {
+  description = "Hours a workout session can stay active before it is finished for the user"
+  type        = number
+  default     = 12
+}
+
+# History backfills write at this rate so they leave the table's capacity for
+# users' requests; 0 writes as fast as the table accepts
+variable "backfill_writes_per_second" {
+  description = "Workouts a second a history backfill writes"
+  type        = number
+  default     = 100
+}
+
+# The client configuration served at /api/client/config: minimum versions,
+# feature kill switches and remote values, edited and rolled out in AppConfig.
+# Terraform only creates the first version; later ones are deployed there
+resource "aws_appconfig_application" "client" {
+  name        = "workout-tracker-client-${local.environment}"
+  description = "Configuration the apps fetch at launch"
+}
+
+resource "aws_appconfig_environment" "client" {
+  name           = local.environment
+  application_id = aws_appconfig_application.client.id
+}
+
+resource "aws_appconfig_configuration_profile" "client" {
+  name           = "client-config"
+  application_id = aws_appconfig_application.client.id
+  location_uri   = "hosted"
+  type           = "AWS.Freeform"
+}
+
+resource "aws_appconfig_hosted_configuration_version" "client" {
+  application_id           = aws_appconfig_application.client.id
+  configuration_profile_id = aws_appconfig_configuration_profile.client.configuration_profile_id
+  content_type             = "application/json"
+  content                  = jsonencode({ minVersions = {}, killSwitches = [], values = {} })
+
+  lifecycle {
+    ignore_changes = [content]
+  }
+}
+
+resource "aws_appconfig_deployment" "client" {
+  application_id           = aws_appconfig_application.client.id
+  environment_id           = aws_appconfig_environment.client.environment_id
+  configuration_profile_id = aws_appconfig_configuration_profile.client.configuration_profile_id
+  configuration_version    = aws_appconfig_hosted_configuration_version.client.version_number
+  deployment_strategy_id   = "AppConfig.AllAtOnce"
+
+  lifecycle {
+    ignore_changes = [configuration_version]
+  }
+}
+
+# ARN of the AWS AppConfig Lambda extension layer for this region and arm64,
+# as listed in the AppConfig documentation; the client configuration is not
+# served when empty
+variable "appconfig_extension_layer_arn" {
+  description = "ARN of the AppConfig Lambda extension layer, or empty to serve no client configuration"
+  type        = string
+  default     = ""
+}
+
+resource "aws_iam_role_policy" "lambda_client_config" {
+  name = "workout-tracker-lambda-client-config-${local.environment}"
+  role = aws_iam_role.lambda_execution_role.id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["appconfig:StartConfigurationSession", "appconfig:GetLatestConfiguration"]
+        Resource = "arn:aws:appconfig:${data.aws_region.current.name}:${data.aws_caller_identity.current.account_id}:application/${aws_appconfig_application.client.id}/environment/${aws_appconfig_environment.client.environment_id}/configuration/${aws_appconfig_configuration_profile.client.configuration_profile_id}"
+      }
+    ]
+  })
+}
+
+variable "garmin_webhook_secret" {
+  description = "Shared secret Garmin signs webhook deliveries with"
+  type        = string
+  default     = ""
+  sensitive   = true
+}
+
+# Whoop and Oura OAuth client settings, used to refresh users' access tokens
+variable "whoop_client_id" {
+  description = "OAuth client ID of the Whoop app"
+  type        = string
+  default     = ""
+}
+
+variable "whoop_client_secret" {
+  description = "OAuth client secret of the Whoop app"
+  type        = string
+  default     = ""
+  sensitive   = true
+}
+
+variable "oura_client_id" {
+  description = "OAuth client ID of the Oura app"
+  type        = string
+  default     = ""
+}
+
+variable "oura_client_secret" {
+  description = "OAuth client secret of the Oura app"
+  type        = string
+  default     = ""
+  sensitive   = true
+}
+
+# Polar OAuth client settings, used to exchange the app's authorization codes
+variable "polar_client_id" {
+  description = "OAuth client ID of the Polar AccessLink app"
+  type        = string
+  default     = ""
+}
+
+variable "polar_client_secret" {
+  description = "OAuth client secret of the Polar AccessLink app"
+  type        = string
+  default     = ""
+  sensitive   = true
+}
+
+variable "polar_webhook_secret" {
+  description = "Signature secret key returned when the Polar AccessLink webhook was created"
+  type        = string
+  default     = ""
+  sensitive   = true
+}
+
+# Token Strava echoes back when validating the webhook subscription
+resource "random_password" "strava_verify_token" {
+  length  = 32
+  special = false
+}
+
+# Signs session tokens issued after Sign in with Google or Apple
+resource "random_password" "session_secret" {
+  length  = 48
+  special = false
+}
+
+# Signs the per-user calendar feed URLs served without authentication
+resource "random_password" "calendar_secret" {
+  length  = 48
+  special = false
+}
+
+# Derives the anonymous IDs that stand in for users in telemetry
+resource "random_password" "telemetry_secret" {
+  length  = 48
+  special = false
+}
+
+resource "aws_lambda_function" "hello_world" {
+  filename      = "../backend/core/athlete-forge.zip"
+  function_name = "workout-tracker-athlete-forge-${local.environment}"
+  role          = aws_iam_role.lambda_execution_role.arn
+  handler       = "bootstrap"
+  runtime       = "provided.al2"
+  architectures = ["arm64"]
+  timeout       = 30
+  memory_size   = 128
+  layers        = var.appconfig_extension_layer_arn == "" ? [] : [var.appconfig_extension_layer_arn]
+
+  source_code_hash = filebase64sha256("../backend/core/athlete-forge.zip")
+
+  environment {
+    variables = {
+      ENVIRONMENT            = local.environment
+      RELEASE                = substr(filesha256("../backend/core/athlete-forge.zip"), 0, 12)
+      SENTRY_DSN             = var.sentry_dsn
+      TABLE_NAME             = aws_dynamodb_table.workout_tracker.name
+      REPORTS_BUCKET         = aws_s3_bucket.reports.bucket
+      MEDIA_BUCKET           = aws_s3_bucket.media.bucket
+      MEDIA_DOMAIN           = local.media_signing ? local.domain_name : ""
+      MEDIA_KEY_PAIR_ID      = local.media_signing ? aws_cloudfront_public_key.media[0].id : ""
+      MEDIA_KEY_PARAM        = local.media_signing ? aws_ssm_parameter.media_signing_key[0].name : ""
+      JOBS_QUEUE_URL         = aws_sqs_queue.jobs.url
+      JOBS_STATE_MACHINE_ARN = aws_sfn_state_machine.jobs.arn
+      STREAM_DERIVED_DATA    = "true"
+      EVENT_BUS_NAME         = aws_cloudwatch_event_bus.domain.name
+      EVENTS_STREAM          = var.stream_domain_events ? aws_kinesis_firehose_delivery_stream.domain_events[0].name : ""
+      PRIMARY_REGION         = var.secondary_region == "" ? "" : data.aws_region.current.name
+      READ_CACHE             = var.read_cache_endpoint == "" ? "false" : "true"
+      CDN_DISTRIBUTION_PARAM = local.cdn_distribution_parameter
+      LOG_CONFIG_PARAM       = local.log_config_parameter
+      CACHE_ENDPOINT         = var.read_cache_endpoint
+      CALENDAR_SECRET        = random_password.calendar_secret.result
+      PUBLIC_URL             = "https://${local.domain_name}"
+      SESSION_SECRET         = random_password.session_secret.result
+      GOOGLE_CLIENT_ID       = var.google_client_id
+      GOOGLE_CLIENT_SECRET   = var.google_client_secret
+      APPLE_CLIENT_ID        = var.apple_client_id
+      APPLE_TEAM_ID          = var.apple_team_id
+      APPLE_KEY_ID           = var.apple_key_id
+      APPLE_PRIVATE_KEY      = var.apple_private_key
+      STRAVA_VERIFY_TOKEN    = random_password.strava_verify_token.result
+      STRAVA_SUBSCRIPTION_ID = var.strava_subscription_id
+      GARMIN_WEBHOOK_SECRET  = var.garmin_webhook_secret
+      POLAR_WEBHOOK_SECRET   = var.polar_webhook_secret
+      POLAR_CLIENT_ID        = var.polar_client_id
+      POLAR_CLIENT_SECRET    = var.polar_client_secret
+      WHOOP_CLIENT_ID        = var.whoop_client_id
+      WHOOP_CLIENT_SECRET    = var.whoop_client_secret
+      OURA_CLIENT_ID         = var.oura_client_id
+      OURA_CLIENT_SECRET     = var.oura_client_secret
+      PROFILE_KMS_KEY_ID     = aws_kms_alias.profile.name
+      TELEMETRY_STREAM       = aws_kinesis_firehose_delivery_stream.telemetry.name
+      TELEMETRY_SECRET       = random_password.telemetry_secret.result
+      METRICS_STREAM         = aws_kinesis_firehose_delivery_stream.metrics.name
+      WAREHOUSE_BUCKET       = aws_s3_bucket.telemetry.bucket
+      SHADOW_ROUTES          = var.shadow_routes
+      MIN_CLIENT_VERSIONS    = var.min_client_versions
+      STALE_SESSION_HOURS    = var.stale_session_hours
+      CLIENT_CONFIG_PATH     = var.appconfig_extension_layer_arn == "" ? "" : "/applications/${aws_appconfig_application.client.name}/environments/${aws_appconfig_environment.client.name}/configurations/${aws_appconfig_configuration_profile.client.name}"
+      WEBSOCKET_API_ID       = aws_apigatewayv2_api.realtime.id
+
+      BACKFILL_WRITES_PER_SECOND = var.backfill_writes_per_second
+    }
+  }
+
+  tags = {
+    Name        = "workout-tracker-athlete-forge"
+    Environment = local.environment
+  }
+}
+
+# Domain events for other services to subscribe to, with their JSON schemas
+# registered so subscribers can generate bindings. A schema file per version is
+# registered under its own name, so a breaking v2 sits beside v1
+resource "aws_cloudwatch_event_bus" "domain" {
+  name = "workout-tracker-events-${local.environment}"
+
+  tags = {
+    Name        = "workout-tracker-events"
+    Environment = local.environment
+  }
+}
+
+resource "aws_schemas_registry" "domain" {
+  name        = "workout-tracker-events-${local.environment}"
+  description = "Schemas of events published by athlete-forge"
+}
+
+resource "aws_schemas_schema" "domain" {
+  for_each = fileset("../backend/core/events/schemas", "*.json")
+
+  name          = "athlete-forge@${trimsuffix(each.value, ".json")}"
+  registry_name = aws_schemas_registry.domain.name
+  type          = "JSONSchemaDraft4"
+  content       = file("../backend/core/events/schemas/${each.value}")
+}
+
+resource "aws_iam_role_policy" "lambda_events" {
+  name = "workout-tracker-lambda-events-${local.environment}"
+  role = aws_iam_role.lambda_execution_role.id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = "events:PutEvents"
+        Resource = aws_cloudwatch_event_bus.domain.arn
+      }
+    ]
+  })
+}
+
+# S3 bucket for anonymized usage telemetry and product metrics, written by Firehose
+resource "aws_s3_bucket" "telemetry" {
+  bucket = "workout-tracker-kiro-telemetry-${local.environment}-${random_id.bucket_suffix.hex}"
+
+  tags = {
+    Name        = "workout-tracker-telemetry"
+    Environment = local.environment
+  }
+}
+
+resource "aws_s3_bucket_public_access_block" "telemetry" {
+  bucket = aws_s3_bucket.telemetry.id
+
+  block_public_acls       = true
+  block_public_policy     = true
+  ignore_public_acls      = true
+  restrict_public_buckets = true
+}
+
+resource "aws_s3_bucket_server_side_encryption_configuration" "telemetry" {
+  bucket = aws_s3_bucket.telemetry.id
+
+  rule {
+    apply_server_side_encryption_by_default {
+      sse_algorithm = "AES256"
+    }
+  }
+}
+
+# Raw events are kept for a year
+resource "aws_s3_bucket_lifecycle_configuration" "telemetry" {
+  bucket = aws_s3_bucket.telemetry.id
+
+  rule {
+    id     = "expire-events"
+    status = "Enabled"
+
+    filter {
+      prefix = "events/"
+    }
+
+    expiration {
+      days = 365
+    }
+  }
+
+  rule {
+    id     = "expire-metrics"
+    status = "Enabled"
+
+    filter {
+      prefix = "metrics/"
+    }
+
+    expiration {
+      days = 365
+    }
+  }
+
+  rule {
+    id     = "expire-domain-events"
+    status = "Enabled"
+
+    filter {
+      prefix = "domain-events/"
+    }
+
+    expiration {
+      days = 365
+    }
+  }
+}
+
+resource "aws_iam_role" "telemetry_firehose" {
+  name = "workout-tracker-telemetry-firehose-${local.environment}"
+
+  assume_role_policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Action = "sts:AssumeRole"
+        Effect = "Allow"
+        Principal = {
+          Service = "firehose.amazonaws.com"
+        }
+      }
+    ]
+  })
+}
+
+resource "aws_iam_role_policy" "telemetry_firehose_s3" {
+  name = "workout-tracker-telemetry-firehose-s3-${local.environment}"
+  role = aws_iam_role.telemetry_firehose.id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect = "Allow"
+        Action = [
+          "s3:AbortMultipartUpload",
+          "s3:GetBucketLocation",
+          "s3:ListBucket",
+          "s3:ListBucketMultipartUploads",
+          "s3:PutObject",
+        ]
+        Resource = [
+          aws_s3_bucket.telemetry.arn,
+          "${aws_s3_bucket.telemetry.arn}/*",
+        ]
+      }
+    ]
+  })
+}
+
+# Buffers telemetry into gzipped newline-delimited JSON objects partitioned by
+# arrival date, ready for Athena
+resource "aws_kinesis_firehose_delivery_stream" "telemetry" {
+  name        = "workout-tracker-telemetry-${local.environment}"
+  destination = "extended_s3"
+
+  extended_s3_configuration {
+    role_arn            = aws_iam_role.telemetry_firehose.arn
+    bucket_arn          = aws_s3_bucket.telemetry.arn
+    prefix              = "events/dt=!{timestamp:yyyy-MM-dd}/"
+    error_output_prefix = "errors/!{firehose:error-output-type}/dt=!{timestamp:yyyy-MM-dd}/"
+    buffering_size      = 5
+    buffering_interval  = 300
+    compression_format  = "GZIP"
+  }
+
+  tags = {
+    Name        = "workout-tracker-telemetry"
+    Environment = local.environment
+  }
+}
+
+# Per-request product metrics, partitioned the same way under metrics/
+resource "aws_kinesis_firehose_delivery_stream" "metrics" {
+  name        = "workout-tracker-metrics-${local.environment}"
+  destination = "extended_s3"
+
+  extended_s3_configuration {
+    role_arn            = aws_iam_role.telemetry_firehose.arn
+    bucket_arn          = aws_s3_bucket.telemetry.arn
+    prefix              = "metrics/dt=!{timestamp:yyyy-MM-dd}/"
+    error_output_prefix = "metrics-errors/!{firehose:error-output-type}/dt=!{timestamp:yyyy-MM-dd}/"
+    buffering_size      = 5
+    buffering_interval  = 300
+    compression_format  = "GZIP"
+  }
+
+  tags = {
+    Name        = "workout-tracker-metrics"
+    Environment = local.environment
+  }
+}
+
+# Domain events, partitioned by arrival date under domain-events/
+resource "aws_kinesis_firehose_delivery_stream" "domain_events" {
+  count       = var.stream_domain_events ? 1 : 0
+  name        = "workout-tracker-domain-events-${local.environment}"
+  destination = "extended_s3"
+
+  extended_s3_configuration {
+    role_arn            = aws_iam_role.telemetry_firehose.arn
+    bucket_arn          = aws_s3_bucket.telemetry.arn
+    prefix              = "domain-events/dt=!{timestamp:yyyy-MM-dd}/"
+    error_output_prefix = "domain-events-errors/!{firehose:error-output-type}/dt=!{timestamp:yyyy-MM-dd}/"
+    buffering_size      = var.domain_events_buffer_size
+    buffering_interval  = var.domain_events_buffer_interval
+    compression_format  = "GZIP"
+  }
+
+  tags = {
+    Name        = "workout-tracker-domain-events"
+    Environment = local.environment
+  }
+}
+
+resource "aws_iam_role_policy" "lambda_telemetry" {
+  name = "workout-tracker-lambda-telemetry-${local.environment}"
+  role = aws_iam_role.lambda_execution_role.id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = "firehose:PutRecordBatch"
+        Resource = concat([
+          aws_kinesis_firehose_delivery_stream.telemetry.arn,
+          aws_kinesis_firehose_delivery_stream.metrics.arn,
+        ], aws_kinesis_firehose_delivery_stream.domain_events[*].arn)
+      }
+    ]
+  })
+}
+
+# Allow the Lambda function to write warehouse exports beside the telemetry
+resource "aws_iam_role_policy" "lambda_warehouse" {
+  name = "workout-tracker-lambda-warehouse-${local.environment}"
+  role = aws_iam_role.lambda_execution_role.id
 
   policy = jsonencode({
     Version = "2012-10-17"
     Statement = [
       {
-        Sid    = "AllowCloudFrontServicePrincipal"
-        Effect = "Allow"
-        Principal = {
-          Service = "cloudfront.amazonaws.com"
-        }
-        Action   = "s3:GetObject"
-        Resource = "${aws_s3_bucket.frontend.arn}/*"
-        Condition = {
-          StringEquals = {
-            "AWS:SourceArn" = aws_cloudfront_distribution.frontend.arn
-          }
-        }
+        Effect   = "Allow"
+        Action   = "s3:PutObject"
+        Resource = "${aws_s3_bucket.telemetry.arn}/warehouse/*"
       }
     ]
   })
 }
 
-# CloudFront distribution for frontend hosting
-resource "aws_cloudfront_distribution" "frontend" {
-  aliases = [local.domain_name]
-
-  # S3 origin for frontend static content
-  origin {
-    domain_name              = aws_s3_bucket.frontend.bucket_regional_domain_name
-    origin_access_control_id = aws_cloudfront_origin_access_control.frontend.id
-    origin_id                = "S3-${aws_s3_bucket.frontend.bucket}"
-  }
-
-  # API Gateway origin for API endpoints
-  origin {
-    domain_name = "${aws_api_gateway_rest_api.workout_tracker_api.id}.execute-api.${data.aws_region.current.name}.amazonaws.com"
-    origin_id   = "APIGateway-${aws_api_gateway_rest_api.workout_tracker_api.id}"
-    origin_path = "/${aws_api_gateway_stage.workout_tracker_api.stage_name}"
+# Export the previous day's training data to the warehouse each night
+resource "aws_cloudwatch_event_rule" "export_warehouse" {
+  name                = "workout-tracker-export-warehouse-${local.environment}"
+  description         = "Export training data to S3 as Parquet for Athena"
+  schedule_expression = "cron(0 3 * * ? *)"
 
-    custom_origin_config {
-      http_port              = 80
-      https_port             = 443
-      origin_protocol_policy = "https-only"
-      origin_ssl_protocols   = ["TLSv1.2"]
-    }
+  tags = {
+    Name        = "workout-tracker-export-warehouse"
+    Environment = local.environment
   }
+}
 
-  enabled             = true
-  is_ipv6_enabled     = true
-  default_root_object = "index.html"
-  price_class         = "PriceClass_100"
+resource "aws_cloudwatch_event_target" "export_warehouse" {
+  rule  = aws_cloudwatch_event_rule.export_warehouse.name
+  arn   = aws_lambda_function.hello_world.arn
+  input = jsonencode({ job = "export-warehouse" })
+}
 
-  # Ordered cache behavior for API endpoints
-  ordered_cache_behavior {
-    path_pattern           = "/api/*"
-    allowed_methods        = ["DELETE", "GET", "HEAD", "OPTIONS", "PATCH", "POST", "PUT"]
-    cached_methods         = ["GET", "HEAD"]
-    target_origin_id       = "APIGateway-${aws_api_gateway_rest_api.workout_tracker_api.id}"
-    compress               = true
-    viewer_protocol_policy = "redirect-to-https"
+resource "aws_lambda_permission" "export_warehouse_invoke" {
+  statement_id  = "AllowExecutionFromExportWarehouseRule"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.hello_world.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.export_warehouse.arn
+}
 
-    forwarded_values {
-      query_string = true
-      headers      = ["Authorization", "Content-Type", "Accept"]
-      cookies {
-        forward = "all"
-      }
-    }
+# Rebuild the strength comparison distributions once a week
+resource "aws_cloudwatch_event_rule" "aggregate_percentiles" {
+  name                = "workout-tracker-aggregate-percentiles-${local.environment}"
+  description         = "Aggregate anonymized lift percentiles"
+  schedule_expression = "cron(0 4 ? * SUN *)"
 
-    # API responses should have minimal caching for dynamic content
-    min_ttl     = 0
-    default_ttl = 0
-    max_ttl     = 300 # 5 minutes maximum cache for API responses
+  tags = {
+    Name        = "workout-tracker-aggregate-percentiles"
+    Environment = local.environment
   }
+}
 
-  default_cache_behavior {
-    allowed_methods        = ["DELETE", "GET", "HEAD", "OPTIONS", "PATCH", "POST", "PUT"]
-    cached_methods         = ["GET", "HEAD"]
-    target_origin_id       = "S3-${aws_s3_bucket.frontend.bucket}"
-    compress               = true
-    viewer_protocol_policy = "redirect-to-https"
+resource "aws_cloudwatch_event_target" "aggregate_percentiles" {
+  rule  = aws_cloudwatch_event_rule.aggregate_percentiles.name
+  arn   = aws_lambda_function.hello_world.arn
+  input = jsonencode({ job = "aggregate-percentiles" })
+}
 
-    forwarded_values {
-      query_string = false
-      cookies {
-        forward = "none"
-      }
-    }
+resource "aws_lambda_permission" "aggregate_percentiles_invoke" {
+  statement_id  = "AllowExecutionFromAggregatePercentilesRule"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.hello_world.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.aggregate_percentiles.arn
+}
 
-    min_ttl     = 0
-    default_ttl = 3600
-    max_ttl     = 86400
-  }
+# Rank exercises by recent use for autocomplete every night
+resource "aws_cloudwatch_event_rule" "rank_exercises" {
+  name                = "workout-tracker-rank-exercises-${local.environment}"
+  description         = "Rank catalog exercises by recent use"
+  schedule_expression = "cron(45 3 * * ? *)"
 
-  # Custom error response for SPA routing
-  custom_error_response {
-    error_code         = 404
-    response_code      = 200
-    response_page_path = "/index.html"
+  tags = {
+    Name        = "workout-tracker-rank-exercises"
+    Environment = local.environment
   }
+}
 
-  restrictions {
-    geo_restriction {
-      restriction_type = "none"
-    }
-  }
+resource "aws_cloudwatch_event_target" "rank_exercises" {
+  rule  = aws_cloudwatch_event_rule.rank_exercises.name
+  arn   = aws_lambda_function.hello_world.arn
+  input = jsonencode({ job = "rank-exercises" })
+}
 
-  viewer_certificate {
-    acm_certificate_arn      = aws_acm_certificate_validation.frontend.certificate_arn
-    ssl_support_method       = "sni-only"
-    minimum_protocol_version = "TLSv1.2_2021"
-  }
+resource "aws_lambda_permission" "rank_exercises_invoke" {
+  statement_id  = "AllowExecutionFromRankExercisesRule"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.hello_world.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.rank_exercises.arn
+}
+
+# Finish workout sessions left active past the stale session limit every hour
+resource "aws_cloudwatch_event_rule" "finish_sessions" {
+  name                = "workout-tracker-finish-sessions-${local.environment}"
+  description         = "Finish stale workout sessions"
+  schedule_expression = "cron(15 * * * ? *)"
 
   tags = {
-    Name = "workout-tracker-frontend"
+    Name        = "workout-tracker-finish-sessions"
+    Environment = local.environment
   }
 }
 
-# Output current AWS account information for verification
-output "aws_account_id" {
-  description = "Current AWS account ID"
-  value       = data.aws_caller_identity.current.account_id
+resource "aws_cloudwatch_event_target" "finish_sessions" {
+  rule  = aws_cloudwatch_event_rule.finish_sessions.name
+  arn   = aws_lambda_function.hello_world.arn
+  input = jsonencode({ job = "finish-sessions" })
 }
 
-output "aws_region" {
-  description = "Current AWS region"
-  value       = data.aws_region.current.name
+resource "aws_lambda_permission" "finish_sessions_invoke" {
+  statement_id  = "AllowExecutionFromFinishSessionsRule"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.hello_world.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.finish_sessions.arn
 }
 
-# CloudFront distribution URL
-output "cloudfront_url" {
-  description = "CloudFront distribution URL for frontend access"
-  value       = "https://${aws_cloudfront_distribution.frontend.domain_name}"
+# Delete coaching messages past retention every night
+resource "aws_cloudwatch_event_rule" "purge_messages" {
+  name                = "workout-tracker-purge-messages-${local.environment}"
+  description         = "Purge expired coaching messages"
+  schedule_expression = "cron(30 3 * * ? *)"
+
+  tags = {
+    Name        = "workout-tracker-purge-messages"
+    Environment = local.environment
+  }
 }
 
-# S3 bucket name for deployment script
-output "aws_s3_bucket_name" {
-  description = "S3 bucket name for frontend hosting"
-  value       = aws_s3_bucket.frontend.bucket
+resource "aws_cloudwatch_event_target" "purge_messages" {
+  rule  = aws_cloudwatch_event_rule.purge_messages.name
+  arn   = aws_lambda_function.hello_world.arn
+  input = jsonencode({ job = "purge-messages" })
 }
 
-# Route 53 DNS A record for custom domain
-resource "aws_route53_record" "frontend" {
-  zone_id = data.aws_route53_zone.main.zone_id
-  name    = local.domain_name
-  type    = "A"
+resource "aws_lambda_permission" "purge_messages_invoke" {
+  statement_id  = "AllowExecutionFromPurgeMessagesRule"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.hello_world.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.purge_messages.arn
+}
 
-  alias {
-    name                   = aws_cloudfront_distribution.frontend.domain_name
-    zone_id                = aws_cloudfront_distribution.frontend.hosted_zone_id
-    evaluate_target_health = false
+# Compile last week's reports early every Monday
+resource "aws_cloudwatch_event_rule" "weekly_reports" {
+  name                = "workout-tracker-weekly-reports-${local.environment}"
+  description         = "Compile weekly training reports"
+  schedule_expression = "cron(0 5 ? * MON *)"
+
+  tags = {
+    Name        = "workout-tracker-weekly-reports"
+    Environment = local.environment
   }
 }
 
-# CloudFront distribution ID for cache invalidation
-output "cloudfront_distribution_id" {
-  description = "CloudFront distribution ID for cache invalidation"
-  value       = aws_cloudfront_distribution.frontend.id
+resource "aws_cloudwatch_event_target" "weekly_reports" {
+  rule  = aws_cloudwatch_event_rule.weekly_reports.name
+  arn   = aws_lambda_function.hello_world.arn
+  input = jsonencode({ job = "weekly-reports" })
 }
 
-# Custom domain URL based on environment
-output "custom_domain_url" {
-  description = "Custom domain URL for frontend access based on environment"
-  value       = "https://${local.domain_name}"
+resource "aws_lambda_permission" "weekly_reports_invoke" {
+  statement_id  = "AllowExecutionFromWeeklyReportsRule"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.hello_world.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.weekly_reports.arn
 }
 
-# Lambda function IAM role
-resource "aws_iam_role" "lambda_execution_role" {
-  name = "workout-tracker-lambda-execution-role-${local.environment}"
-
-  assume_role_policy = jsonencode({
-    Version = "2012-10-17"
-    Statement = [
-      {
-        Action = "sts:AssumeRole"
-        Effect = "Allow"
-        Principal = {
-          Service = "lambda.amazonaws.com"
-        }
-      }
-    ]
-  })
+# Sync wearable recovery scores each morning, once most users' nights are scored
+resource "aws_cloudwatch_event_rule" "sync_recovery" {
+  name                = "workout-tracker-sync-recovery-${local.environment}"
+  description         = "Sync Whoop and Oura recovery into check-ins"
+  schedule_expression = "cron(0 10 * * ? *)"
 
   tags = {
-    Name        = "workout-tracker-lambda-execution-role"
+    Name        = "workout-tracker-sync-recovery"
     Environment = local.environment
   }
 }
 
-# Attach basic Lambda execution policy
-resource "aws_iam_role_policy_attachment" "lambda_basic_execution" {
-  role       = aws_iam_role.lambda_execution_role.name
-  policy_arn = "arn:aws:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole"
+resource "aws_cloudwatch_event_target" "sync_recovery" {
+  rule  = aws_cloudwatch_event_rule.sync_recovery.name
+  arn   = aws_lambda_function.hello_world.arn
+  input = jsonencode({ job = "sync-recovery" })
 }
 
-# Lambda function
-resource "aws_lambda_function" "hello_world" {
-  filename      = "../backend/core/athlete-forge.zip"
-  function_name = "workout-tracker-athlete-forge-${local.environment}"
-  role          = aws_iam_role.lambda_execution_role.arn
-  handler       = "bootstrap"
-  runtime       = "provided.al2"
-  architectures = ["arm64"]
-  timeout       = 30
-  memory_size   = 128
-
-  source_code_hash = filebase64sha256("../backend/core/athlete-forge.zip")
+resource "aws_lambda_permission" "sync_recovery_invoke" {
+  statement_id  = "AllowExecutionFromSyncRecoveryRule"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.hello_world.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.sync_recovery.arn
+}
 
-  environment {
-    variables = {
-      ENVIRONMENT = local.environment
-    }
-  }
+# Each region records a heartbeat every minute; the other region's health check
+# reports its age as the replication lag
+resource "aws_cloudwatch_event_rule" "region_heartbeat" {
+  count               = var.secondary_region == "" ? 0 : 1
+  name                = "workout-tracker-region-heartbeat-${local.environment}"
+  description         = "Record a replication heartbeat in the global table"
+  schedule_expression = "rate(1 minute)"
 
   tags = {
-    Name        = "workout-tracker-athlete-forge"
+    Name        = "workout-tracker-region-heartbeat"
     Environment = local.environment
   }
 }
 
+resource "aws_cloudwatch_event_target" "region_heartbeat" {
+  count = var.secondary_region == "" ? 0 : 1
+  rule  = aws_cloudwatch_event_rule.region_heartbeat[0].name
+  arn   = aws_lambda_function.hello_world.arn
+  input = jsonencode({ job = "region-heartbeat" })
+}
+
+resource "aws_lambda_permission" "region_heartbeat_invoke" {
+  count         = var.secondary_region == "" ? 0 : 1
+  statement_id  = "AllowExecutionFromRegionHeartbeatRule"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.hello_world.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.region_heartbeat[0].arn
+}
+
 # Lambda function outputs
 output "lambda_function_name" {
   description = "Name of the Lambda function"
@@ -336,11 +1817,23 @@ output "lambda_invoke_arn" {
   value       = aws_lambda_function.hello_world.invoke_arn
 }
 
+output "strava_verify_token" {
+  description = "Verify token to pass when creating the Strava push subscription"
+  value       = random_password.strava_verify_token.result
+  sensitive   = true
+}
+
 # API Gateway REST API
 resource "aws_api_gateway_rest_api" "workout_tracker_api" {
   name        = "workout-tracker-api-${local.environment}"
   description = "REST API for workout tracker application"
 
+  # MessagePack responses are returned base64-encoded and decoded to binary here.
+  # Request bodies of these types, such as gzip uploads sent as octet-stream
+  # and exercise media, reach the function base64-encoded rather than mangled
+  # as text
+  binary_media_types = ["application/msgpack", "application/x-msgpack", "application/vnd.msgpack", "application/octet-stream", "image/*", "video/*"]
+
   endpoint_configuration {
     types = ["REGIONAL"]
   }
@@ -373,6 +1866,32 @@ resource "aws_api_gateway_resource" "health" {
   path_part   = "health"
 }
 
+# API Gateway catch-all /api/{proxy+} resource routed by the Lambda handler
+resource "aws_api_gateway_resource" "api_proxy" {
+  rest_api_id = aws_api_gateway_rest_api.workout_tracker_api.id
+  parent_id   = aws_api_gateway_resource.api_root.id
+  path_part   = "{proxy+}"
+}
+
+# ANY method for /api/{proxy+}
+resource "aws_api_gateway_method" "api_proxy_any" {
+  rest_api_id   = aws_api_gateway_rest_api.workout_tracker_api.id
+  resource_id   = aws_api_gateway_resource.api_proxy.id
+  http_method   = "ANY"
+  authorization = "NONE"
+}
+
+# Lambda proxy integration for /api/{proxy+}
+resource "aws_api_gateway_integration" "api_proxy_lambda_integration" {
+  rest_api_id = aws_api_gateway_rest_api.workout_tracker_api.id
+  resource_id = aws_api_gateway_resource.api_proxy.id
+  http_method = aws_api_gateway_method.api_proxy_any.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = aws_lambda_function.hello_world.invoke_arn
+}
+
 # GET method for /api/test endpoint
 resource "aws_api_gateway_method" "test_get" {
   rest_api_id   = aws_api_gateway_rest_api.workout_tracker_api.id
@@ -513,6 +2032,8 @@ resource "aws_api_gateway_deployment" "workout_tracker_api" {
     aws_api_gateway_method_response.health_options_200,
     aws_api_gateway_integration_response.health_get_200,
     aws_api_gateway_integration_response.health_options_200,
+    aws_api_gateway_method.api_proxy_any,
+    aws_api_gateway_integration.api_proxy_lambda_integration,
   ]
 
   rest_api_id = aws_api_gateway_rest_api.workout_tracker_api.id
@@ -529,6 +2050,9 @@ resource "aws_api_gateway_deployment" "workout_tracker_api" {
       aws_api_gateway_integration.test_lambda_integration.id,
       aws_api_gateway_integration.health_lambda_integration.id,
       aws_api_gateway_integration.health_options_integration.id,
+      aws_api_gateway_resource.api_proxy.id,
+      aws_api_gateway_method.api_proxy_any.id,
+      aws_api_gateway_integration.api_proxy_lambda_integration.id,
     ]))
   }
 
@@ -655,4 +2179,66 @@ output "api_gateway_stage_name" {
 output "health_endpoint_url" {
   description = "Health check endpoint URL"
   value       = "https://${aws_api_gateway_rest_api.workout_tracker_api.id}.execute-api.${data.aws_region.current.name}.amazonaws.com/${aws_api_gateway_stage.workout_tracker_api.stage_name}/api/health"
-}
\ No newline at end of file
+}
+
+# WebSocket API keeping a user's devices in sync, such as a rest timer started
+# on the phone and adjusted on the watch. Every route goes to the Lambda, which
+# posts messages back through the connections management API
+resource "aws_apigatewayv2_api" "realtime" {
+  name                       = "workout-tracker-realtime-${local.environment}"
+  protocol_type              = "WEBSOCKET"
+  route_selection_expression = "$request.body.action"
+
+  tags = {
+    Name        = "workout-tracker-realtime"
+    Environment = local.environment
+  }
+}
+
+resource "aws_apigatewayv2_integration" "realtime" {
+  api_id           = aws_apigatewayv2_api.realtime.id
+  integration_type = "AWS_PROXY"
+  integration_uri  = aws_lambda_function.hello_world.invoke_arn
+}
+
+resource "aws_apigatewayv2_route" "realtime" {
+  for_each  = toset(["$connect", "$disconnect", "$default"])
+  api_id    = aws_apigatewayv2_api.realtime.id
+  route_key = each.value
+  target    = "integrations/${aws_apigatewayv2_integration.realtime.id}"
+}
+
+resource "aws_apigatewayv2_stage" "realtime" {
+  api_id      = aws_apigatewayv2_api.realtime.id
+  name        = local.environment
+  auto_deploy = true
+}
+
+resource "aws_lambda_permission" "realtime_invoke" {
+  statement_id  = "AllowExecutionFromWebSocketAPI"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.hello_world.function_name
+  principal     = "apigateway.amazonaws.com"
+  source_arn    = "${aws_apigatewayv2_api.realtime.execution_arn}/*/*"
+}
+
+resource "aws_iam_role_policy" "lambda_realtime" {
+  name = "workout-tracker-lambda-realtime-${local.environment}"
+  role = aws_iam_role.lambda_execution_role.id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = "execute-api:ManageConnections"
+        Resource = "${aws_apigatewayv2_api.realtime.execution_arn}/${aws_apigatewayv2_stage.realtime.name}/POST/@connections/*"
+      }
+    ]
+  })
+}
+
+output "websocket_url" {
+  description = "WebSocket URL devices connect to with ?token=<session token>"
+  value       = aws_apigatewayv2_stage.realtime.invoke_url
+}